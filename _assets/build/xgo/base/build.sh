@@ -159,7 +159,7 @@ for TARGET in $TARGETS; do
     if [ "$PLATFORM" == "" ] || [ "$PLATFORM" == "." ] || [ "$PLATFORM" == "android" ]; then
       PLATFORM=16 # Jelly Bean 4.0.0
     fi
-    CGO_STATUS_IM="-D ANDROID_DEPLOYMENT"
+    CGO_STATUS_IM="-D ANDROID_DEPLOYMENT -DSQLITE_ENABLE_FTS5"
     if [ "$PLATFORM" -ge 16 ]; then
       CGO_CCPIE="-fPIE"
       CGO_LDPIE="-fPIE"
@@ -496,7 +496,7 @@ for TARGET in $TARGETS; do
       if [ "$GO_VERSION" -lt 160 ]; then
         LDSTRIP="-s"
       fi
-      CGO_STATUS_IM="-D IOS_DEPLOYMENT"
+      CGO_STATUS_IM="-D IOS_DEPLOYMENT -DSQLITE_ENABLE_FTS5"
       # Cross compile to all available iOS and simulator platforms
       if [ -d "$IOS_NDK_ARM_7" ] && ([ $XGOARCH == "." ] || [ $XGOARCH == "arm-7" ] || [ $XGOARCH == "framework" ]); then
         echo "Bootstrapping ios-$PLATFORM/arm-7..."
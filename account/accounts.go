@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/accounts"
@@ -14,6 +15,7 @@ import (
 	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/status-im/status-go/extkeys"
 )
 
@@ -23,8 +25,14 @@ var (
 	ErrAccountToKeyMappingFailure     = errors.New("cannot retrieve a valid key for a given account")
 	ErrNoAccountSelected              = errors.New("no account has been selected, please login")
 	ErrInvalidMasterKeyCreated        = errors.New("can not create master extended key")
+	ErrProfileSelected                = errors.New("account is selected and can't be logged in as a profile")
+	ErrProfileLoggedIn                = errors.New("account is already logged in as a profile")
+	ErrProfileNotLoggedIn             = errors.New("account is not logged in as a profile")
 )
 
+// All general log messages in this package should be routed through this logger.
+var logger = log.New("package", "status-go/account")
+
 // GethServiceProvider provides required geth services.
 type GethServiceProvider interface {
 	AccountManager() (*accounts.Manager, error)
@@ -37,6 +45,15 @@ type Manager struct {
 
 	mu              sync.RWMutex
 	selectedAccount *SelectedExtKey // account that was processed during the last call to SelectAccount()
+	// profiles are the accounts logged in alongside the selected account.
+	profiles map[gethcommon.Address]*SelectedExtKey
+	// signer signs with the keys of the platform keystore, whose references are in keyReferencesDir.
+	signer           Signer
+	keyReferencesDir string
+
+	// kdf is the key derivation function keys are encrypted with.
+	kdfMu sync.RWMutex
+	kdf   KDF
 }
 
 // NewManager returns new node account manager.
@@ -49,7 +66,8 @@ func NewManager(geth GethServiceProvider) *Manager {
 // CreateAccount creates an internal geth account
 // BIP44-compatible keys are generated: CKD#1 is stored as account key, CKD#2 stored as sub-account root
 // Public key of CKD#1 is returned, with CKD#2 securely encoded into account key file (to be used for
-// sub-account derivations)
+// sub-account derivations). The chat identity key is derived from the same master key with an
+// EIP-1581 path and stored as a separate key file, see AccountKeys.
 func (m *Manager) CreateAccount(password string) (address, pubKey, mnemonic string, err error) {
 	// generate mnemonic phrase
 	mn := extkeys.NewMnemonic()
@@ -68,8 +86,8 @@ func (m *Manager) CreateAccount(password string) (address, pubKey, mnemonic stri
 		return "", "", "", fmt.Errorf("can not create master extended key: %v", err)
 	}
 
-	// import created key into account keystore
-	address, pubKey, err = m.importExtendedKey(extKey, password)
+	// import created keys into account keystore
+	address, pubKey, err = m.importMasterKey(extKey, password, false)
 	if err != nil {
 		return "", "", "", err
 	}
@@ -103,7 +121,7 @@ func (m *Manager) CreateChildAccount(parentAddress, password string) (address, p
 	}
 
 	// make sure that given password can decrypt key associated with a given parent address
-	account, accountKey, err := keyStore.AccountDecryptedKey(account, password)
+	account, accountKey, passphrase, err := decryptKey(keyStore, account, password)
 	if err != nil {
 		return "", "", fmt.Errorf("%s: %v", ErrAccountToKeyMappingFailure.Error(), err)
 	}
@@ -118,16 +136,20 @@ func (m *Manager) CreateChildAccount(parentAddress, password string) (address, p
 	if err != nil {
 		return "", "", err
 	}
-	if err = keyStore.IncSubAccountIndex(account, password); err != nil {
+	if err = keyStore.IncSubAccountIndex(account, passphrase); err != nil {
 		return "", "", err
 	}
 	accountKey.SubAccountIndex++
+	// the keystore encrypts the key again with its own parameters
+	m.reencryptKeys(keyStore, password, accountKey)
 
 	// import derived key into account keystore
-	address, pubKey, err = m.importExtendedKey(childKey, password)
+	child, key, err := m.importExtendedKey(keyStore, extkeys.KeyPurposeWallet, childKey, password)
 	if err != nil {
-		return
+		return "", "", err
 	}
+	address = child.Address.Hex()
+	pubKey = hexutil.Encode(crypto.FromECDSAPub(&key.PrivateKey.PublicKey))
 
 	// update in-memory selected account
 	if m.selectedAccount != nil {
@@ -140,6 +162,17 @@ func (m *Manager) CreateChildAccount(parentAddress, password string) (address, p
 // RecoverAccount re-creates master key using given details.
 // Once master key is re-generated, it is inserted into keystore (if not already there).
 func (m *Manager) RecoverAccount(password, mnemonic string) (address, pubKey string, err error) {
+	return m.recoverAccount(password, mnemonic, false)
+}
+
+// RecoverLegacyAccount recovers an account created before the chat key was derived
+// separately, which keeps its wallet key as its chat key. ScanMnemonic tells whether the
+// chat identity of the account is its wallet key.
+func (m *Manager) RecoverLegacyAccount(password, mnemonic string) (address, pubKey string, err error) {
+	return m.recoverAccount(password, mnemonic, true)
+}
+
+func (m *Manager) recoverAccount(password, mnemonic string, legacy bool) (address, pubKey string, err error) {
 	// re-create extended key (see BIP32)
 	mn := extkeys.NewMnemonic()
 	extKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, ""))
@@ -147,8 +180,8 @@ func (m *Manager) RecoverAccount(password, mnemonic string) (address, pubKey str
 		return "", "", ErrInvalidMasterKeyCreated
 	}
 
-	// import re-created key into account keystore
-	address, pubKey, err = m.importExtendedKey(extKey, password)
+	// import re-created keys into account keystore
+	address, pubKey, err = m.importMasterKey(extKey, password, legacy)
 	if err != nil {
 		return
 	}
@@ -156,11 +189,38 @@ func (m *Manager) RecoverAccount(password, mnemonic string) (address, pubKey str
 	return address, pubKey, nil
 }
 
+// ImportAccount imports a key file encrypted with passphrase into the keystore, encrypted
+// with password. If the account is already in the keystore, its key file is kept unchanged.
+func (m *Manager) ImportAccount(keyJSON []byte, passphrase, password string) (address, pubKey string, err error) {
+	keyStore, err := m.geth.AccountKeyStore()
+	if err != nil {
+		return "", "", err
+	}
+
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return "", "", err
+	}
+	address = key.Address.Hex()
+	pubKey = hexutil.Encode(crypto.FromECDSAPub(&key.PrivateKey.PublicKey))
+
+	if keyStore.HasAddress(key.Address) {
+		return address, pubKey, nil
+	}
+	if _, err = keyStore.Import(keyJSON, passphrase, password); err != nil {
+		return "", "", err
+	}
+	m.reencryptKeys(keyStore, password, key)
+
+	return address, pubKey, nil
+}
+
 // VerifyAccountPassword tries to decrypt a given account key file, with a provided password.
 // If no error is returned, then account is considered verified.
 func (m *Manager) VerifyAccountPassword(keyStoreDir, address, password string) (*keystore.Key, error) {
 	var err error
 	var foundKeyFile []byte
+	var foundKeyPath string
 
 	addressObj := gethcommon.BytesToAddress(gethcommon.FromHex(address))
 	checkAccountKey := func(path string, fileInfo os.FileInfo) error {
@@ -182,6 +242,7 @@ func (m *Manager) VerifyAccountPassword(keyStoreDir, address, password string) (
 
 		if gethcommon.HexToAddress("0x"+accountKey.Address).Hex() == addressObj.Hex() {
 			foundKeyFile = rawKeyFile
+			foundKeyPath = path
 		}
 
 		return nil
@@ -201,7 +262,7 @@ func (m *Manager) VerifyAccountPassword(keyStoreDir, address, password string) (
 		return nil, fmt.Errorf("cannot locate account for address: %s", addressObj.Hex())
 	}
 
-	key, err := keystore.DecryptKey(foundKeyFile, password)
+	key, err := decryptKeyFile(foundKeyPath, foundKeyFile, addressObj, password)
 	if err != nil {
 		return nil, err
 	}
@@ -220,33 +281,102 @@ func (m *Manager) SelectAccount(address, password string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	keyStore, err := m.geth.AccountKeyStore()
+	selectedAccount, err := m.unlockAccount(address, password)
 	if err != nil {
 		return err
 	}
+	// persist account key for easier recovery of currently selected key
+	m.selectedAccount = selectedAccount
+
+	return nil
+}
+
+// LoginProfile unlocks an account logged in alongside the selected account, as a profile
+// with its own chat identity. The selected account can't be logged in as a profile.
+func (m *Manager) LoginProfile(address, password string) (*SelectedExtKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	profile, err := m.unlockAccount(address, password)
+	if err != nil {
+		return nil, err
+	}
+	if m.selectedAccount != nil && m.selectedAccount.Address == profile.Address {
+		return nil, ErrProfileSelected
+	}
+	if _, exist := m.profiles[profile.Address]; exist {
+		return nil, ErrProfileLoggedIn
+	}
+	if m.profiles == nil {
+		m.profiles = make(map[gethcommon.Address]*SelectedExtKey)
+	}
+	m.profiles[profile.Address] = profile
+	return profile, nil
+}
+
+// LogoutProfile forgets the keys of an account logged in with LoginProfile.
+func (m *Manager) LogoutProfile(address string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
 	account, err := ParseAccountString(address)
 	if err != nil {
 		return ErrAddressToAccountMappingFailure
 	}
+	if _, exist := m.profiles[account.Address]; !exist {
+		return ErrProfileNotLoggedIn
+	}
+	delete(m.profiles, account.Address)
+	return nil
+}
+
+// Profiles returns the accounts logged in with LoginProfile.
+func (m *Manager) Profiles() []*SelectedExtKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	profiles := make([]*SelectedExtKey, 0, len(m.profiles))
+	for _, profile := range m.profiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Address.Hex() < profiles[j].Address.Hex()
+	})
+	return profiles
+}
+
+// unlockAccount decrypts the keys of an account, and finds its sub-accounts.
+func (m *Manager) unlockAccount(address, password string) (*SelectedExtKey, error) {
+	keyStore, err := m.geth.AccountKeyStore()
+	if err != nil {
+		return nil, err
+	}
 
-	account, accountKey, err := keyStore.AccountDecryptedKey(account, password)
+	account, err := ParseAccountString(address)
 	if err != nil {
-		return fmt.Errorf("%s: %v", ErrAccountToKeyMappingFailure.Error(), err)
+		return nil, ErrAddressToAccountMappingFailure
+	}
+
+	account, accountKey, _, err := decryptKey(keyStore, account, password)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", ErrAccountToKeyMappingFailure.Error(), err)
 	}
 
-	// persist account key for easier recovery of currently selected key
 	subAccounts, err := m.findSubAccounts(accountKey.ExtendedKey, accountKey.SubAccountIndex)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	chatKey, err := selectChatKey(keyStore, account, accountKey, password)
+	if err != nil {
+		return nil, err
 	}
-	m.selectedAccount = &SelectedExtKey{
+	m.reencryptKeys(keyStore, password, accountKey, chatKey)
+	return &SelectedExtKey{
 		Address:     account.Address,
 		AccountKey:  accountKey,
+		ChatKey:     chatKey,
 		SubAccounts: subAccounts,
-	}
-
-	return nil
+	}, nil
 }
 
 // SelectedAccount returns currently selected account
@@ -260,37 +390,13 @@ func (m *Manager) SelectedAccount() (*SelectedExtKey, error) {
 	return m.selectedAccount, nil
 }
 
-// Logout clears selectedAccount.
+// Logout clears selectedAccount and the profiles.
 func (m *Manager) Logout() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	m.selectedAccount = nil
-}
-
-// importExtendedKey processes incoming extended key, extracts required info and creates corresponding account key.
-// Once account key is formed, that key is put (if not already) into keystore i.e. key is *encoded* into key file.
-func (m *Manager) importExtendedKey(extKey *extkeys.ExtendedKey, password string) (address, pubKey string, err error) {
-	keyStore, err := m.geth.AccountKeyStore()
-	if err != nil {
-		return "", "", err
-	}
-
-	// imports extended key, create key file (if necessary)
-	account, err := keyStore.ImportExtendedKey(extKey, password)
-	if err != nil {
-		return "", "", err
-	}
-	address = account.Address.Hex()
-
-	// obtain public key to return
-	account, key, err := keyStore.AccountDecryptedKey(account, password)
-	if err != nil {
-		return address, "", err
-	}
-	pubKey = hexutil.Encode(crypto.FromECDSAPub(&key.PrivateKey.PublicKey))
-
-	return
+	m.profiles = nil
 }
 
 // Accounts returns list of addresses for selected account, including
@@ -351,11 +457,9 @@ func (m *Manager) refreshSelectedAccount() {
 	if err != nil {
 		return
 	}
-	m.selectedAccount = &SelectedExtKey{
-		Address:     m.selectedAccount.Address,
-		AccountKey:  m.selectedAccount.AccountKey,
-		SubAccounts: subAccounts,
-	}
+	selectedAccount := *m.selectedAccount
+	selectedAccount.SubAccounts = subAccounts
+	m.selectedAccount = &selectedAccount
 }
 
 // findSubAccounts traverses cached accounts and adds as a sub-accounts any
@@ -406,5 +510,6 @@ func (m *Manager) AddressToDecryptedAccount(address, password string) (accounts.
 		return accounts.Account{}, nil, ErrAddressToAccountMappingFailure
 	}
 
-	return keyStore.AccountDecryptedKey(account, password)
+	account, key, _, err := decryptKey(keyStore, account, password)
+	return account, key, err
 }
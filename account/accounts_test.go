@@ -258,6 +258,37 @@ func (s *ManagerTestSuite) TestSelectAccount() {
 	}
 }
 
+func (s *ManagerTestSuite) TestImportAccount() {
+	s.gethServiceProvider.EXPECT().AccountKeyStore().Return(s.keyStore, nil)
+	_, key, err := s.accManager.AddressToDecryptedAccount(s.address, s.password)
+	s.Require().NoError(err)
+	keyJSON, err := keystore.EncryptKey(key, "backup-passphrase", keystore.LightScryptN, keystore.LightScryptP)
+	s.Require().NoError(err)
+
+	keyStoreDir, err := ioutil.TempDir(os.TempDir(), "accounts-import")
+	s.Require().NoError(err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+
+	s.gethServiceProvider.EXPECT().AccountKeyStore().Return(keyStore, nil).AnyTimes()
+	_, _, err = s.accManager.ImportAccount(keyJSON, "wrong-passphrase", "new-password")
+	s.Error(err)
+
+	addr, pubKey, err := s.accManager.ImportAccount(keyJSON, "backup-passphrase", "new-password")
+	s.NoError(err)
+	s.Equal(s.address, addr)
+	s.Equal(s.pubKey, pubKey)
+	s.NoError(s.accManager.SelectAccount(addr, "new-password"))
+	selected, err := s.accManager.SelectedAccount()
+	s.NoError(err)
+	s.Equal(key.ExtendedKey.String(), selected.AccountKey.ExtendedKey.String())
+
+	_, _, err = s.accManager.ImportAccount(keyJSON, "backup-passphrase", "other-password")
+	s.NoError(err)
+	s.Len(keyStore.Accounts(), 1)
+	s.NoError(s.accManager.SelectAccount(addr, "new-password"), "Existing key files are kept")
+}
+
 func (s *ManagerTestSuite) TestCreateChildAccount() {
 	// First, test the negative case where an account is not selected
 	// and an address is not provided.
@@ -332,6 +363,38 @@ func (s *ManagerTestSuite) TestLogout() {
 	s.Nil(s.accManager.selectedAccount)
 }
 
+func (s *ManagerTestSuite) TestProfiles() {
+	s.gethServiceProvider.EXPECT().AccountKeyStore().Return(s.keyStore, nil).AnyTimes()
+	profileAddress, _, _, err := s.accManager.CreateAccount(s.password)
+	s.Require().NoError(err)
+	s.Require().NoError(s.accManager.SelectAccount(s.address, s.password))
+
+	_, err = s.accManager.LoginProfile(s.address, s.password)
+	s.Equal(ErrProfileSelected, err)
+	_, err = s.accManager.LoginProfile(profileAddress, "wrong-password")
+	s.Error(err)
+	profile, err := s.accManager.LoginProfile(profileAddress, s.password)
+	s.Require().NoError(err)
+	s.Equal(profileAddress, profile.Address.Hex())
+	s.NotNil(profile.ChatKey)
+	_, err = s.accManager.LoginProfile(profileAddress, s.password)
+	s.Equal(ErrProfileLoggedIn, err)
+
+	selected, err := s.accManager.SelectedAccount()
+	s.Require().NoError(err)
+	s.Equal(s.address, selected.Address.Hex(), "Logging in a profile doesn't change the selected account")
+	s.Equal([]*SelectedExtKey{profile}, s.accManager.Profiles())
+
+	s.Require().NoError(s.accManager.LogoutProfile(profileAddress))
+	s.Equal(ErrProfileNotLoggedIn, s.accManager.LogoutProfile(profileAddress))
+	s.Empty(s.accManager.Profiles())
+
+	_, err = s.accManager.LoginProfile(profileAddress, s.password)
+	s.Require().NoError(err)
+	s.accManager.Logout()
+	s.Empty(s.accManager.Profiles(), "Profiles are logged out with the selected account")
+}
+
 // TestAccounts tests cases for (*Manager).Accounts.
 func (s *ManagerTestSuite) TestAccounts() {
 	// Select the test account
@@ -0,0 +1,410 @@
+package account
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"golang.org/x/crypto/argon2"
+)
+
+// kdfDir is the directory of the keystore the Argon2id parameters of the keys are persisted in.
+const kdfDir = "kdf"
+
+// Names of the key derivation functions keys can be encrypted with.
+const (
+	KDFScrypt   = "scrypt"
+	KDFArgon2id = "argon2id"
+)
+
+// Versions of the key derivation functions key files are encrypted with, by which the
+// re-encryption of the key files is tracked. The parameters of a function don't change
+// its version: keys are encrypted again with new parameters when they are unlocked.
+const (
+	KDFVersionScrypt   = 1
+	KDFVersionArgon2id = 2
+)
+
+const (
+	// Argon2idTime is the default number of passes of Argon2id over the memory.
+	Argon2idTime = 3
+
+	// Argon2idMemory is the default memory used by Argon2id, in KiB (64MB).
+	Argon2idMemory = 64 * 1024
+
+	// Argon2idThreads is the default number of threads used by Argon2id.
+	Argon2idThreads = 4
+
+	argon2idSaltLength = 32
+	argon2idKeyLength  = 32
+)
+
+// KDF is the key derivation function keys are encrypted with, and its parameters.
+type KDF struct {
+	// Name is KDFScrypt or KDFArgon2id. If empty, keys are never encrypted again.
+	Name string
+
+	// ScryptN and ScryptP are the N and P parameters of scrypt.
+	ScryptN int
+	ScryptP int
+
+	// Argon2Time, Argon2Memory and Argon2Threads are the number of passes over the
+	// memory, the memory in KiB and the number of threads of Argon2id.
+	Argon2Time    uint32
+	Argon2Memory  uint32
+	Argon2Threads uint8
+}
+
+// Version returns the version of the key derivation function, or 0 if its name is empty.
+func (kdf KDF) Version() int {
+	switch kdf.Name {
+	case KDFScrypt:
+		return KDFVersionScrypt
+	case KDFArgon2id:
+		return KDFVersionArgon2id
+	}
+	return 0
+}
+
+// ScryptKDF returns the scrypt key derivation function with the given parameters.
+func ScryptKDF(scryptN, scryptP int) KDF {
+	return KDF{Name: KDFScrypt, ScryptN: scryptN, ScryptP: scryptP}
+}
+
+// Argon2idKDF returns the Argon2id key derivation function with the given parameters.
+func Argon2idKDF(time, memory uint32, threads uint8) KDF {
+	return KDF{Name: KDFArgon2id, Argon2Time: time, Argon2Memory: memory, Argon2Threads: threads}
+}
+
+// Validate returns an error if the key derivation function is unknown or its parameters
+// are invalid.
+func (kdf KDF) Validate() error {
+	switch kdf.Name {
+	case "":
+	case KDFScrypt:
+		if kdf.ScryptN <= 1 || kdf.ScryptN&(kdf.ScryptN-1) != 0 {
+			return errors.New("scrypt N must be a power of 2 greater than 1")
+		}
+		if kdf.ScryptP <= 0 {
+			return errors.New("scrypt P must be positive")
+		}
+	case KDFArgon2id:
+		if kdf.Argon2Time == 0 {
+			return errors.New("argon2id time must be positive")
+		}
+		if kdf.Argon2Threads == 0 {
+			return errors.New("argon2id threads must be positive")
+		}
+		if kdf.Argon2Memory < 8*uint32(kdf.Argon2Threads) {
+			return errors.New("argon2id memory must be at least 8KiB per thread")
+		}
+	default:
+		return fmt.Errorf("unsupported KDF: %s", kdf.Name)
+	}
+	return nil
+}
+
+// SetKDF sets the key derivation function keys are encrypted with. Keys encrypted with
+// another function, or with other parameters, are encrypted again with it when they are
+// unlocked or imported. Keys are never encrypted again if the name of the function is empty.
+func (m *Manager) SetKDF(kdf KDF) error {
+	if err := kdf.Validate(); err != nil {
+		return err
+	}
+	m.kdfMu.Lock()
+	defer m.kdfMu.Unlock()
+	m.kdf = kdf
+	return nil
+}
+
+// KDF returns the key derivation function keys are encrypted with.
+func (m *Manager) KDF() KDF {
+	m.kdfMu.RLock()
+	defer m.kdfMu.RUnlock()
+	return m.kdf
+}
+
+// argon2idParams are the parameters the passphrase of a key file is derived with from the
+// password of its account. The keystore only supports scrypt: a key encrypted with Argon2id
+// is stored encrypted with light scrypt parameters and the passphrase derived with Argon2id.
+type argon2idParams struct {
+	Salt    hexutil.Bytes `json:"salt"`
+	Time    uint32        `json:"t"`
+	Memory  uint32        `json:"m"`
+	Threads uint8         `json:"p"`
+}
+
+func newArgon2idParams(kdf KDF) (*argon2idParams, error) {
+	salt := make([]byte, argon2idSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return &argon2idParams{Salt: salt, Time: kdf.Argon2Time, Memory: kdf.Argon2Memory, Threads: kdf.Argon2Threads}, nil
+}
+
+func (p *argon2idParams) kdf() KDF {
+	return Argon2idKDF(p.Time, p.Memory, p.Threads)
+}
+
+func (p *argon2idParams) passphrase(password string) string {
+	return hex.EncodeToString(argon2.IDKey([]byte(password), p.Salt, p.Time, p.Memory, p.Threads, argon2idKeyLength))
+}
+
+// kdfParamsPath returns the path of the Argon2id parameters of a key file. The parameters
+// of a key being encrypted again are written to the pending path until its key file is.
+func kdfParamsPath(keyPath string, address gethcommon.Address, pending bool) string {
+	name := strings.ToLower(address.Hex()[2:])
+	if pending {
+		name += ".pending"
+	}
+	return filepath.Join(filepath.Dir(keyPath), kdfDir, name+".json")
+}
+
+func readArgon2idParams(path string) (*argon2idParams, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	params := new(argon2idParams)
+	if err := json.Unmarshal(data, params); err != nil {
+		return nil, err
+	}
+	if err := params.kdf().Validate(); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// keyPassphrases returns the passphrases a key file may be encrypted with: the ones derived
+// with its Argon2id parameters, if it has some, and the password.
+func keyPassphrases(keyPath string, address gethcommon.Address, password string) []string {
+	var passphrases []string
+	for _, pending := range []bool{false, true} {
+		if params, err := readArgon2idParams(kdfParamsPath(keyPath, address, pending)); err == nil {
+			passphrases = append(passphrases, params.passphrase(password))
+		}
+	}
+	return append(passphrases, password)
+}
+
+// decryptKey returns the decrypted key of an account, and the passphrase its key file is
+// encrypted with.
+func decryptKey(keyStore *keystore.KeyStore, account accounts.Account, password string) (accounts.Account, *keystore.Key, string, error) {
+	account, err := keyStore.Find(account)
+	if err != nil {
+		return account, nil, "", err
+	}
+	for _, passphrase := range keyPassphrases(account.URL.Path, account.Address, password) {
+		var key *keystore.Key
+		account, key, err = keyStore.AccountDecryptedKey(account, passphrase)
+		if err != keystore.ErrDecrypt {
+			return account, key, passphrase, err
+		}
+	}
+	return account, nil, "", err
+}
+
+// decryptKeyFile decrypts a key file read from path.
+func decryptKeyFile(path string, keyJSON []byte, address gethcommon.Address, password string) (*keystore.Key, error) {
+	var err error
+	for _, passphrase := range keyPassphrases(path, address, password) {
+		var key *keystore.Key
+		if key, err = keystore.DecryptKey(keyJSON, passphrase); err != keystore.ErrDecrypt {
+			return key, err
+		}
+	}
+	return nil, err
+}
+
+// keyFileKDF returns the key derivation function a key file is encrypted with.
+func keyFileKDF(account accounts.Account) (KDF, error) {
+	params, err := readArgon2idParams(kdfParamsPath(account.URL.Path, account.Address, false))
+	if err == nil {
+		return params.kdf(), nil
+	} else if !os.IsNotExist(err) {
+		return KDF{}, err
+	}
+
+	data, err := ioutil.ReadFile(account.URL.Path)
+	if err != nil {
+		return KDF{}, err
+	}
+	var keyJSON struct {
+		Crypto struct {
+			KDF       string `json:"kdf"`
+			KDFParams struct {
+				N int `json:"n"`
+				P int `json:"p"`
+			} `json:"kdfparams"`
+		} `json:"crypto"`
+	}
+	if err := json.Unmarshal(data, &keyJSON); err != nil {
+		return KDF{}, err
+	}
+	if keyJSON.Crypto.KDF != KDFScrypt {
+		return KDF{Name: keyJSON.Crypto.KDF}, nil
+	}
+	return ScryptKDF(keyJSON.Crypto.KDFParams.N, keyJSON.Crypto.KDFParams.P), nil
+}
+
+// reencryptKey encrypts the key file of an account again with the key derivation function
+// set with SetKDF, if it is encrypted with another one. It returns true if the file was
+// encrypted again.
+func (m *Manager) reencryptKey(account accounts.Account, key *keystore.Key, password string) (bool, error) {
+	kdf := m.KDF()
+	if kdf.Name == "" {
+		return false, nil
+	}
+	current, err := keyFileKDF(account)
+	if err != nil {
+		return false, err
+	}
+	pendingPath := kdfParamsPath(account.URL.Path, account.Address, true)
+	if _, err := os.Stat(pendingPath); current == kdf && os.IsNotExist(err) {
+		return false, nil
+	}
+
+	paramsPath := kdfParamsPath(account.URL.Path, account.Address, false)
+	if kdf.Name == KDFScrypt {
+		keyJSON, err := keystore.EncryptKey(key, password, kdf.ScryptN, kdf.ScryptP)
+		if err != nil {
+			return false, err
+		}
+		if err := writeFileAtomic(account.URL.Path, keyJSON); err != nil {
+			return false, err
+		}
+		for _, path := range []string{paramsPath, pendingPath} {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	// The parameters are pending until the key file is written, so that the key can be
+	// decrypted whichever was written last.
+	params, err := newArgon2idParams(kdf)
+	if err != nil {
+		return false, err
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(pendingPath), 0700); err != nil {
+		return false, err
+	}
+	if err := writeFileAtomic(pendingPath, data); err != nil {
+		return false, err
+	}
+	keyJSON, err := keystore.EncryptKey(key, params.passphrase(password), keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		return false, err
+	}
+	if err := writeFileAtomic(account.URL.Path, keyJSON); err != nil {
+		return false, err
+	}
+	return true, os.Rename(pendingPath, paramsPath)
+}
+
+// KeyFileKDF returns the key derivation function the key file of an account is encrypted with.
+func (m *Manager) KeyFileKDF(address string) (KDF, error) {
+	keyStore, err := m.geth.AccountKeyStore()
+	if err != nil {
+		return KDF{}, err
+	}
+	account, err := ParseAccountString(address)
+	if err != nil {
+		return KDF{}, ErrAddressToAccountMappingFailure
+	}
+	if account, err = keyStore.Find(account); err != nil {
+		return KDF{}, err
+	}
+	return keyFileKDF(account)
+}
+
+// ReencryptKeyFiles encrypts the key files of an account and of its chat identity again
+// with the key derivation function set with SetKDF, if it changed since they were
+// encrypted. Unlike when they are unlocked, failures are returned, so that the
+// re-encryption can be retried.
+func (m *Manager) ReencryptKeyFiles(address, password string) error {
+	keyStore, err := m.geth.AccountKeyStore()
+	if err != nil {
+		return err
+	}
+	account, err := ParseAccountString(address)
+	if err != nil {
+		return ErrAddressToAccountMappingFailure
+	}
+	account, accountKey, _, err := decryptKey(keyStore, account, password)
+	if err != nil {
+		return err
+	}
+	chatKey, err := selectChatKey(keyStore, account, accountKey, password)
+	if err != nil {
+		return err
+	}
+	if _, err := m.reencryptKey(account, accountKey, password); err != nil {
+		return err
+	}
+	if chatKey == nil || chatKey.Address == accountKey.Address {
+		return nil
+	}
+	chatAccount, err := keyStore.Find(accounts.Account{Address: chatKey.Address})
+	if err != nil {
+		return err
+	}
+	_, err = m.reencryptKey(chatAccount, chatKey, password)
+	return err
+}
+
+// reencryptKeys encrypts the unlocked keys again with the key derivation function set with
+// SetKDF, if it changed since they were encrypted. The keys being unlocked anyway, failures
+// are only logged, and the keys are encrypted again on the next unlock.
+func (m *Manager) reencryptKeys(keyStore *keystore.KeyStore, password string, keys ...*keystore.Key) {
+	done := make(map[gethcommon.Address]bool, len(keys))
+	for _, key := range keys {
+		if done[key.Address] {
+			continue
+		}
+		done[key.Address] = true
+		account, err := keyStore.Find(accounts.Account{Address: key.Address})
+		if err == nil {
+			var reencrypted bool
+			if reencrypted, err = m.reencryptKey(account, key, password); reencrypted {
+				logger.Info("key encrypted again", "address", key.Address.Hex(), "kdf", m.KDF().Name)
+			}
+		}
+		if err != nil {
+			logger.Warn("failed to encrypt key again", "address", key.Address.Hex(), "err", err)
+		}
+	}
+}
+
+// writeFileAtomic writes a file in a temporary file of its directory, then renames it.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
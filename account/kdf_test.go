@@ -0,0 +1,138 @@
+package account
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReencryptKeysOnSelect(t *testing.T) {
+	keyStore, cleanup := newTestKeyStore(t)
+	defer cleanup()
+	geth := newMockGethServiceProvider(t)
+	geth.EXPECT().AccountKeyStore().Return(keyStore, nil).AnyTimes()
+	m := NewManager(geth)
+
+	address, _, _, err := m.CreateAccount("password")
+	require.NoError(t, err)
+	keys, err := m.AccountKeys(address)
+	require.NoError(t, err)
+	keyKDF := func(address gethcommon.Address) KDF {
+		account, err := keyStore.Find(accounts.Account{Address: address})
+		require.NoError(t, err)
+		kdf, err := keyFileKDF(account)
+		require.NoError(t, err)
+		return kdf
+	}
+	light := ScryptKDF(keystore.LightScryptN, keystore.LightScryptP)
+	require.Equal(t, light, keyKDF(keys.Wallet.Address))
+
+	// keys are kept as they are until a key derivation function is set
+	require.NoError(t, m.SelectAccount(address, "password"))
+	require.Equal(t, light, keyKDF(keys.Wallet.Address))
+
+	argon2id := Argon2idKDF(1, 64, 1)
+	require.NoError(t, m.SetKDF(argon2id))
+	require.NoError(t, m.SelectAccount(address, "password"))
+	require.Equal(t, argon2id, keyKDF(keys.Wallet.Address))
+	require.Equal(t, argon2id, keyKDF(keys.Chat.Address))
+
+	require.NoError(t, m.SelectAccount(address, "password"), "keys encrypted with argon2id are unlocked")
+	selected, err := m.SelectedAccount()
+	require.NoError(t, err)
+	require.Equal(t, keys.Wallet.Address, selected.AccountKey.Address)
+	require.NotNil(t, selected.AccountKey.ExtendedKey)
+	require.Equal(t, keys.Chat.Address, selected.ChatKey.Address)
+	require.Error(t, m.SelectAccount(address, "wrong"))
+
+	subAddress, _, err := m.CreateChildAccount("", "password")
+	require.NoError(t, err)
+	require.Equal(t, argon2id, keyKDF(gethcommon.HexToAddress(subAddress)), "new keys are encrypted with argon2id")
+	require.Equal(t, argon2id, keyKDF(keys.Wallet.Address))
+	require.NoError(t, m.SelectAccount(address, "password"))
+
+	standard := ScryptKDF(1<<12, 2)
+	require.NoError(t, m.SetKDF(standard))
+	require.NoError(t, m.SelectAccount(address, "password"))
+	require.Equal(t, standard, keyKDF(keys.Wallet.Address))
+	require.NoError(t, m.SelectAccount(address, "password"))
+}
+
+func TestReencryptKeyFiles(t *testing.T) {
+	keyStore, cleanup := newTestKeyStore(t)
+	defer cleanup()
+	geth := newMockGethServiceProvider(t)
+	geth.EXPECT().AccountKeyStore().Return(keyStore, nil).AnyTimes()
+	m := NewManager(geth)
+
+	address, _, _, err := m.CreateAccount("password")
+	require.NoError(t, err)
+	keys, err := m.AccountKeys(address)
+	require.NoError(t, err)
+	kdf, err := m.KeyFileKDF(address)
+	require.NoError(t, err)
+	require.Equal(t, KDFVersionScrypt, kdf.Version())
+
+	argon2id := Argon2idKDF(1, 64, 1)
+	require.NoError(t, m.SetKDF(argon2id))
+	require.Error(t, m.ReencryptKeyFiles(address, "wrong"))
+	require.NoError(t, m.ReencryptKeyFiles(address, "password"))
+	kdf, err = m.KeyFileKDF(address)
+	require.NoError(t, err)
+	require.Equal(t, argon2id, kdf)
+	chatAccount, err := keyStore.Find(accounts.Account{Address: keys.Chat.Address})
+	require.NoError(t, err)
+	kdf, err = keyFileKDF(chatAccount)
+	require.NoError(t, err)
+	require.Equal(t, argon2id, kdf, "The key file of the chat identity is encrypted again")
+	require.NoError(t, m.SelectAccount(address, "password"))
+}
+
+func TestArgon2idKeyVerifyPassword(t *testing.T) {
+	keyStore, cleanup := newTestKeyStore(t)
+	defer cleanup()
+	geth := newMockGethServiceProvider(t)
+	geth.EXPECT().AccountKeyStore().Return(keyStore, nil).AnyTimes()
+	m := NewManager(geth)
+	require.NoError(t, m.SetKDF(Argon2idKDF(1, 64, 1)))
+
+	address, _, _, err := m.CreateAccount("password")
+	require.NoError(t, err)
+	account, err := keyStore.Find(accounts.Account{Address: gethcommon.HexToAddress(address)})
+	require.NoError(t, err)
+	keyStoreDir := filepath.Dir(account.URL.Path)
+
+	_, err = m.VerifyAccountPassword(keyStoreDir, address, "password")
+	require.NoError(t, err)
+	_, err = m.VerifyAccountPassword(keyStoreDir, address, "wrong")
+	require.Equal(t, keystore.ErrDecrypt, err)
+	_, _, err = keyStore.AccountDecryptedKey(account, "password")
+	require.Equal(t, keystore.ErrDecrypt, err, "the key file is encrypted with the passphrase derived with argon2id")
+}
+
+func TestReencryptKeyPendingParams(t *testing.T) {
+	keyStore, cleanup := newTestKeyStore(t)
+	defer cleanup()
+	geth := newMockGethServiceProvider(t)
+	geth.EXPECT().AccountKeyStore().Return(keyStore, nil).AnyTimes()
+	m := NewManager(geth)
+	require.NoError(t, m.SetKDF(Argon2idKDF(1, 64, 1)))
+
+	address, _, _, err := m.CreateAccount("password")
+	require.NoError(t, err)
+	account, err := keyStore.Find(accounts.Account{Address: gethcommon.HexToAddress(address)})
+	require.NoError(t, err)
+
+	// the key file was written, but not its parameters
+	paramsPath := kdfParamsPath(account.URL.Path, account.Address, false)
+	require.NoError(t, os.Rename(paramsPath, kdfParamsPath(account.URL.Path, account.Address, true)))
+	require.NoError(t, m.SelectAccount(address, "password"))
+	_, err = os.Stat(paramsPath)
+	require.NoError(t, err, "the key is encrypted again")
+	require.NoError(t, m.SelectAccount(address, "password"))
+}
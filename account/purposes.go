@@ -0,0 +1,273 @@
+package account
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/extkeys"
+)
+
+// purposesDir is the directory of the keystore the account keys are persisted in.
+const purposesDir = "purposes"
+
+// errors
+var (
+	ErrAccountKeysNotFound = errors.New("no keys for the account, they are recorded when it is logged in")
+)
+
+// PurposeKey is the public part of a key of an account.
+type PurposeKey struct {
+	Address   gethcommon.Address `json:"address"`
+	PublicKey hexutil.Bytes      `json:"publicKey"`
+}
+
+// AccountKeys are the keys derived from the master key of an account for each purpose:
+// the wallet key (BIP44, m/44'/60'/0'/0/0) and the chat identity key (EIP-1581,
+// m/43'/60'/1581'/0'/0). The account is identified by the address of its wallet key.
+//
+// Accounts created before the keys were derived separately use their wallet key as
+// their chat key, so that their chat identity is kept.
+type AccountKeys struct {
+	Wallet PurposeKey `json:"wallet"`
+	Chat   PurposeKey `json:"chat"`
+}
+
+// Legacy returns true if the account uses its wallet key as its chat key.
+func (k *AccountKeys) Legacy() bool {
+	return k.Wallet.Address == k.Chat.Address
+}
+
+// AccountKeys returns the keys of the account identified by the address of its wallet key.
+// The keys of accounts created before they were derived separately are recorded when the
+// account is logged in.
+func (m *Manager) AccountKeys(address string) (*AccountKeys, error) {
+	keyStore, err := m.geth.AccountKeyStore()
+	if err != nil {
+		return nil, err
+	}
+	account, err := ParseAccountString(address)
+	if err != nil {
+		return nil, ErrAddressToAccountMappingFailure
+	}
+	account, err = keyStore.Find(account)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", ErrAccountToKeyMappingFailure.Error(), err)
+	}
+	keys, err := readAccountKeys(account)
+	if os.IsNotExist(err) {
+		return nil, ErrAccountKeysNotFound
+	}
+	return keys, err
+}
+
+// DeleteAccount deletes the key files of an account from the keystore: its wallet key, its
+// chat key, the sub-accounts derived while it is selected, their Argon2id parameters and
+// its recorded keys. The account is logged out if it is selected or logged in as a profile.
+func (m *Manager) DeleteAccount(address string) error {
+	keyStore, err := m.geth.AccountKeyStore()
+	if err != nil {
+		return err
+	}
+	account, err := ParseAccountString(address)
+	if err != nil {
+		return ErrAddressToAccountMappingFailure
+	}
+	account, err = keyStore.Find(account)
+	if err != nil {
+		return fmt.Errorf("%s: %v", ErrAccountToKeyMappingFailure.Error(), err)
+	}
+
+	paths := []string{accountKeysPath(account)}
+	keyAccounts := []accounts.Account{account}
+	if keys, err := readAccountKeys(account); err == nil && !keys.Legacy() {
+		if chat, err := keyStore.Find(accounts.Account{Address: keys.Chat.Address}); err == nil {
+			keyAccounts = append(keyAccounts, chat)
+		}
+	}
+
+	m.mu.Lock()
+	if m.selectedAccount != nil && m.selectedAccount.Address == account.Address {
+		for _, subAccount := range m.selectedAccount.SubAccounts {
+			if subAccount, err := keyStore.Find(subAccount); err == nil {
+				keyAccounts = append(keyAccounts, subAccount)
+			}
+		}
+		m.selectedAccount = nil
+	}
+	delete(m.profiles, account.Address)
+	m.mu.Unlock()
+
+	for _, keyAccount := range keyAccounts {
+		paths = append(paths,
+			keyAccount.URL.Path,
+			kdfParamsPath(keyAccount.URL.Path, keyAccount.Address, false),
+			kdfParamsPath(keyAccount.URL.Path, keyAccount.Address, true))
+	}
+
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportChatKey imports the chat key file of an account, encrypted with passphrase, into
+// the keystore, encrypted with password. The account must be in the keystore; its keys
+// are recorded unless they already are.
+func (m *Manager) ImportChatKey(address string, keyJSON []byte, passphrase, password string) error {
+	keyStore, err := m.geth.AccountKeyStore()
+	if err != nil {
+		return err
+	}
+	account, err := ParseAccountString(address)
+	if err != nil {
+		return ErrAddressToAccountMappingFailure
+	}
+	account, walletKey, _, err := decryptKey(keyStore, account, password)
+	if err != nil {
+		return fmt.Errorf("%s: %v", ErrAccountToKeyMappingFailure.Error(), err)
+	}
+
+	chatKey, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return err
+	}
+	if !keyStore.HasAddress(chatKey.Address) {
+		if _, err := keyStore.Import(keyJSON, passphrase, password); err != nil {
+			return err
+		}
+		m.reencryptKeys(keyStore, password, chatKey)
+	}
+	return recordAccountKeys(account, &walletKey.PrivateKey.PublicKey, &chatKey.PrivateKey.PublicKey)
+}
+
+// ChatPrivateKey returns the private key of the chat identity of the account, or ErrExternalKey
+// if the key of the account is kept by the platform keystore.
+func (k *SelectedExtKey) ChatPrivateKey() (*ecdsa.PrivateKey, error) {
+	if k.External != nil {
+		return nil, ErrExternalKey
+	}
+	if k.ChatKey == nil {
+		return k.AccountKey.PrivateKey, nil
+	}
+	return k.ChatKey.PrivateKey, nil
+}
+
+// importMasterKey imports the wallet and chat keys derived from a master key. Accounts
+// created before the keys were derived separately have no chat key of their own: with
+// legacy, the wallet key is recorded as the chat key instead. Keys already recorded for
+// the account are kept.
+func (m *Manager) importMasterKey(extKey *extkeys.ExtendedKey, password string, legacy bool) (address, pubKey string, err error) {
+	keyStore, err := m.geth.AccountKeyStore()
+	if err != nil {
+		return "", "", err
+	}
+
+	wallet, walletKey, err := m.importExtendedKey(keyStore, extkeys.KeyPurposeWallet, extKey, password)
+	if err != nil {
+		return "", "", err
+	}
+	address = wallet.Address.Hex()
+	pubKey = hexutil.Encode(crypto.FromECDSAPub(&walletKey.PrivateKey.PublicKey))
+
+	if _, err := readAccountKeys(wallet); !os.IsNotExist(err) {
+		return address, pubKey, err
+	}
+	chatKey := walletKey
+	if !legacy {
+		if _, chatKey, err = m.importExtendedKey(keyStore, extkeys.KeyPurposeChat, extKey, password); err != nil {
+			return "", "", err
+		}
+	}
+	if err := recordAccountKeys(wallet, &walletKey.PrivateKey.PublicKey, &chatKey.PrivateKey.PublicKey); err != nil {
+		return "", "", err
+	}
+	return address, pubKey, nil
+}
+
+// selectChatKey returns the chat key of an account. The keys of accounts which have none
+// recorded are recorded with their wallet key as their chat key.
+func selectChatKey(keyStore *keystore.KeyStore, account accounts.Account, accountKey *keystore.Key, password string) (*keystore.Key, error) {
+	keys, err := readAccountKeys(account)
+	if os.IsNotExist(err) {
+		publicKey := &accountKey.PrivateKey.PublicKey
+		return accountKey, recordAccountKeys(account, publicKey, publicKey)
+	} else if err != nil {
+		return nil, err
+	}
+	if keys.Legacy() {
+		return accountKey, nil
+	}
+
+	_, chatKey, _, err := decryptKey(keyStore, accounts.Account{Address: keys.Chat.Address}, password)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", ErrAccountToKeyMappingFailure.Error(), err)
+	}
+	return chatKey, nil
+}
+
+// importExtendedKey imports the key derived from a master key for a purpose, unless it is
+// already in the keystore, and returns it decrypted. Its key file is encrypted again with
+// the key derivation function set with SetKDF.
+func (m *Manager) importExtendedKey(keyStore *keystore.KeyStore, purpose extkeys.KeyPurpose, extKey *extkeys.ExtendedKey, password string) (accounts.Account, *keystore.Key, error) {
+	account, err := keyStore.ImportExtendedKeyForPurpose(purpose, extKey, password)
+	if err != nil {
+		return accounts.Account{}, nil, err
+	}
+	account, key, _, err := decryptKey(keyStore, account, password)
+	if err != nil {
+		return accounts.Account{}, nil, err
+	}
+	m.reencryptKeys(keyStore, password, key)
+	return account, key, nil
+}
+
+// accountKeysPath returns the path of the keys of an account, in the directory of its key file.
+func accountKeysPath(account accounts.Account) string {
+	dir := filepath.Join(filepath.Dir(account.URL.Path), purposesDir)
+	return filepath.Join(dir, strings.ToLower(account.Address.Hex()[2:])+".json")
+}
+
+func readAccountKeys(account accounts.Account) (*AccountKeys, error) {
+	data, err := ioutil.ReadFile(accountKeysPath(account))
+	if err != nil {
+		return nil, err
+	}
+	keys := new(AccountKeys)
+	if err := json.Unmarshal(data, keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// recordAccountKeys persists the keys of an account, unless they already are.
+func recordAccountKeys(account accounts.Account, wallet, chat *ecdsa.PublicKey) error {
+	path := accountKeysPath(account)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	keys := AccountKeys{
+		Wallet: PurposeKey{Address: crypto.PubkeyToAddress(*wallet), PublicKey: crypto.FromECDSAPub(wallet)},
+		Chat:   PurposeKey{Address: crypto.PubkeyToAddress(*chat), PublicKey: crypto.FromECDSAPub(chat)},
+	}
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
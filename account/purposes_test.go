@@ -0,0 +1,162 @@
+package account
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/extkeys"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeyStore(t *testing.T) (*keystore.KeyStore, func()) {
+	dir, err := ioutil.TempDir("", "account-keys")
+	require.NoError(t, err)
+	return keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP), func() { os.RemoveAll(dir) }
+}
+
+func TestAccountKeys(t *testing.T) {
+	keyStore, cleanup := newTestKeyStore(t)
+	defer cleanup()
+	geth := newMockGethServiceProvider(t)
+	geth.EXPECT().AccountKeyStore().Return(keyStore, nil).AnyTimes()
+	m := NewManager(geth)
+
+	address, pubKey, mnemonic, err := m.CreateAccount("password")
+	require.NoError(t, err)
+	keys, err := m.AccountKeys(address)
+	require.NoError(t, err)
+	require.Equal(t, address, keys.Wallet.Address.Hex())
+	require.Equal(t, pubKey, keys.Wallet.PublicKey.String())
+	require.False(t, keys.Legacy())
+
+	master, err := extkeys.NewMaster(extkeys.NewMnemonic().MnemonicSeed(mnemonic, ""))
+	require.NoError(t, err)
+	chat, err := master.ChildForPurpose(extkeys.KeyPurposeChat, 0)
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(chat.ToECDSA().PublicKey), keys.Chat.Address, "The chat key is derived with the EIP-1581 path")
+
+	require.NoError(t, m.SelectAccount(address, "password"))
+	selected, err := m.SelectedAccount()
+	require.NoError(t, err)
+	require.Equal(t, address, selected.Address.Hex())
+	identity, err := selected.ChatPrivateKey()
+	require.NoError(t, err)
+	require.Equal(t, keys.Chat.Address, crypto.PubkeyToAddress(identity.PublicKey))
+
+	recoveredKeyStore, cleanupRecovered := newTestKeyStore(t)
+	defer cleanupRecovered()
+	recovered := newMockGethServiceProvider(t)
+	recovered.EXPECT().AccountKeyStore().Return(recoveredKeyStore, nil).AnyTimes()
+	m = NewManager(recovered)
+	_, _, err = m.RecoverAccount("password", mnemonic)
+	require.NoError(t, err)
+	recoveredKeys, err := m.AccountKeys(address)
+	require.NoError(t, err)
+	require.Equal(t, keys, recoveredKeys)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	keyStore, cleanup := newTestKeyStore(t)
+	defer cleanup()
+	geth := newMockGethServiceProvider(t)
+	geth.EXPECT().AccountKeyStore().Return(keyStore, nil).AnyTimes()
+	m := NewManager(geth)
+
+	address, _, _, err := m.CreateAccount("password")
+	require.NoError(t, err)
+	require.NoError(t, m.SelectAccount(address, "password"))
+	subAddress, _, err := m.CreateChildAccount("", "password")
+	require.NoError(t, err)
+	require.NoError(t, m.SelectAccount(address, "password"))
+	keys, err := m.AccountKeys(address)
+	require.NoError(t, err)
+
+	var paths []string
+	for _, addr := range []common.Address{keys.Wallet.Address, keys.Chat.Address, common.HexToAddress(subAddress)} {
+		account, err := keyStore.Find(accounts.Account{Address: addr})
+		require.NoError(t, err)
+		paths = append(paths, account.URL.Path)
+	}
+
+	require.NoError(t, m.DeleteAccount(address))
+	for _, path := range paths {
+		_, err := os.Stat(path)
+		require.True(t, os.IsNotExist(err), "Key files are deleted")
+	}
+	_, err = m.SelectedAccount()
+	require.Equal(t, ErrNoAccountSelected, err)
+	_, err = m.AccountKeys(address)
+	require.Error(t, err)
+}
+
+func TestLegacyAccountKeys(t *testing.T) {
+	keyStore, cleanup := newTestKeyStore(t)
+	defer cleanup()
+	geth := newMockGethServiceProvider(t)
+	geth.EXPECT().AccountKeyStore().Return(keyStore, nil).AnyTimes()
+	m := NewManager(geth)
+
+	// Accounts were created with the wallet key only
+	mnemonic, err := extkeys.NewMnemonic().MnemonicPhrase(extkeys.EntropyStrength128, extkeys.EnglishLanguage)
+	require.NoError(t, err)
+	master, err := extkeys.NewMaster(extkeys.NewMnemonic().MnemonicSeed(mnemonic, ""))
+	require.NoError(t, err)
+	legacy, err := keyStore.ImportExtendedKey(master, "password")
+	require.NoError(t, err)
+	address := legacy.Address.Hex()
+
+	_, err = m.AccountKeys(address)
+	require.Equal(t, ErrAccountKeysNotFound, err)
+
+	require.NoError(t, m.SelectAccount(address, "password"))
+	selected, err := m.SelectedAccount()
+	require.NoError(t, err)
+	require.Equal(t, selected.AccountKey, selected.ChatKey, "The chat identity of existing accounts is kept")
+	keys, err := m.AccountKeys(address)
+	require.NoError(t, err)
+	require.True(t, keys.Legacy())
+
+	_, _, err = m.RecoverAccount("password", mnemonic)
+	require.NoError(t, err)
+	recoveredKeys, err := m.AccountKeys(address)
+	require.NoError(t, err)
+	require.Equal(t, keys, recoveredKeys, "The recorded keys are kept")
+}
+
+func TestRecoverLegacyAccount(t *testing.T) {
+	keyStore, cleanup := newTestKeyStore(t)
+	defer cleanup()
+	geth := newMockGethServiceProvider(t)
+	geth.EXPECT().AccountKeyStore().Return(keyStore, nil).AnyTimes()
+	m := NewManager(geth)
+
+	mnemonic, err := extkeys.NewMnemonic().MnemonicPhrase(extkeys.EntropyStrength128, extkeys.EnglishLanguage)
+	require.NoError(t, err)
+	address, _, err := m.RecoverLegacyAccount("password", mnemonic)
+	require.NoError(t, err)
+	require.Len(t, keyStore.Accounts(), 1, "No chat key is derived")
+	keys, err := m.AccountKeys(address)
+	require.NoError(t, err)
+	require.True(t, keys.Legacy())
+
+	// Having the key file of the account doesn't make it a legacy one
+	otherKeyStore, cleanupOther := newTestKeyStore(t)
+	defer cleanupOther()
+	other := newMockGethServiceProvider(t)
+	other.EXPECT().AccountKeyStore().Return(otherKeyStore, nil).AnyTimes()
+	m = NewManager(other)
+	master, err := extkeys.NewMaster(extkeys.NewMnemonic().MnemonicSeed(mnemonic, ""))
+	require.NoError(t, err)
+	_, err = otherKeyStore.ImportExtendedKey(master, "password")
+	require.NoError(t, err)
+	_, _, err = m.RecoverAccount("password", mnemonic)
+	require.NoError(t, err)
+	keys, err = m.AccountKeys(address)
+	require.NoError(t, err)
+	require.False(t, keys.Legacy())
+}
@@ -0,0 +1,186 @@
+package account
+
+import (
+	"context"
+	"crypto/ecdsa"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/extkeys"
+)
+
+const (
+	// DefaultScanLookahead is the number of consecutive unused keys after which the
+	// scan of a derivation path stops, like the gap limit of BIP44.
+	DefaultScanLookahead = 20
+	// MaxScanLookahead is the maximum lookahead of a scan.
+	MaxScanLookahead = 100
+	// maxScanIndex is the index at which the scan of a derivation path stops.
+	maxScanIndex = 1000
+)
+
+// RecoveryCandidate is a wallet key derived from a mnemonic by ScanMnemonic.
+type RecoveryCandidate struct {
+	Path      string             `json:"path"`
+	Address   gethcommon.Address `json:"address"`
+	PublicKey hexutil.Bytes      `json:"publicKey"`
+	// OnChain is true if the address has transactions or a balance.
+	OnChain bool `json:"onChain"`
+	// ChatBundle is true if a chat bundle of the account was found. It is only checked
+	// for the default wallet key, as the other keys have no chat identity.
+	ChatBundle bool `json:"chatBundle"`
+	// LegacyChat is true if the chat bundle found is the one of the wallet key, which
+	// accounts created before the chat key was derived separately use as their chat key.
+	// Such accounts are recovered with RecoverLegacyAccount.
+	LegacyChat bool `json:"legacyChat"`
+}
+
+// ActivityChecker tells whether the keys derived from a mnemonic were used.
+type ActivityChecker interface {
+	// OnChain returns true if the address has transactions or a balance.
+	OnChain(ctx context.Context, address gethcommon.Address) (bool, error)
+	// ChatBundle returns true if a chat bundle of the identity is found.
+	ChatBundle(ctx context.Context, identity *ecdsa.PublicKey) (bool, error)
+}
+
+// scanSeries is a derivation path with an increasing index.
+type scanSeries struct {
+	first uint32
+	path  func(i uint32) []uint32
+}
+
+// scanSeriesList are the derivation paths scanned after the default wallet key:
+//   - m/44'/60'/0'/0/i, the addresses of the first account, used by most wallets,
+//   - m/44'/60'/0'/0/1/i, the sub-accounts created with CreateChildAccount,
+//   - m/44'/60'/i'/0/0, the accounts, used by some hardware wallets.
+var scanSeriesList = []scanSeries{
+	{first: 1, path: func(i uint32) []uint32 {
+		return append(bip44ParentPath(), i)
+	}},
+	{first: 0, path: func(i uint32) []uint32 {
+		return append(bip44ParentPath(), 1, i)
+	}},
+	{first: 1, path: func(i uint32) []uint32 {
+		path := bip44ParentPath()
+		path[2] = extkeys.HardenedKeyStart + i
+		return append(path, 0)
+	}},
+}
+
+func bip44ParentPath() []uint32 {
+	return append([]uint32(nil), extkeys.EthBIP44ParentPath...)
+}
+
+// ScanMnemonic derives the wallet keys of a mnemonic on the common derivation paths,
+// and returns those with on-chain activity, after the default wallet key, restored by
+// RecoverAccount, which is always returned. The scan of a path stops after lookahead
+// consecutive keys without activity; DefaultScanLookahead is used if it is not positive.
+//
+// The chat bundles of the EIP-1581 chat key and, for accounts created before it was
+// derived, of the default wallet key are looked up to tell whether the account was used
+// for chatting.
+func ScanMnemonic(ctx context.Context, mnemonic string, lookahead int, checker ActivityChecker) ([]RecoveryCandidate, error) {
+	if lookahead <= 0 {
+		lookahead = DefaultScanLookahead
+	}
+	if lookahead > MaxScanLookahead {
+		lookahead = MaxScanLookahead
+	}
+	master, err := extkeys.NewMaster(extkeys.NewMnemonic().MnemonicSeed(mnemonic, ""))
+	if err != nil {
+		return nil, ErrInvalidMasterKeyCreated
+	}
+
+	main, walletKey, err := checkCandidate(ctx, master, append(bip44ParentPath(), 0), checker)
+	if err != nil {
+		return nil, err
+	}
+	chatKey, err := master.ChildForPurpose(extkeys.KeyPurposeChat, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, identity := range []*ecdsa.PrivateKey{chatKey.ToECDSA(), walletKey} {
+		if main.ChatBundle, err = checker.ChatBundle(ctx, &identity.PublicKey); err != nil {
+			return nil, err
+		} else if main.ChatBundle {
+			main.LegacyChat = identity == walletKey
+			break
+		}
+	}
+
+	candidates := []RecoveryCandidate{main}
+	for _, series := range scanSeriesList {
+		unused := 0
+		for i := series.first; unused < lookahead && i < maxScanIndex; i++ {
+			candidate, _, err := checkCandidate(ctx, master, series.path(i), checker)
+			if err != nil {
+				return nil, err
+			}
+			if !candidate.OnChain {
+				unused++
+				continue
+			}
+			unused = 0
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates, nil
+}
+
+// checkCandidate derives the key at a path and checks its on-chain activity.
+func checkCandidate(ctx context.Context, master *extkeys.ExtendedKey, path []uint32, checker ActivityChecker) (RecoveryCandidate, *ecdsa.PrivateKey, error) {
+	extKey, err := master.Derive(path)
+	if err != nil {
+		return RecoveryCandidate{}, nil, err
+	}
+	key := extKey.ToECDSA()
+	candidate := RecoveryCandidate{
+		Path:      extkeys.FormatPath(path),
+		Address:   crypto.PubkeyToAddress(key.PublicKey),
+		PublicKey: crypto.FromECDSAPub(&key.PublicKey),
+	}
+	if candidate.OnChain, err = checker.OnChain(ctx, candidate.Address); err != nil {
+		return RecoveryCandidate{}, nil, err
+	}
+	return candidate, key, nil
+}
+
+// RecoverAccountAtPath imports the wallet key derived from a mnemonic at a path returned
+// by ScanMnemonic into the keystore, if it is not already there. The key of the default
+// path is recovered with RecoverAccount. Accounts recovered at other paths have no chat
+// key of their own: their wallet key is used as their chat key.
+func (m *Manager) RecoverAccountAtPath(password, mnemonic, path string) (address, pubKey string, err error) {
+	indices, err := extkeys.ParsePath(path)
+	if err != nil {
+		return "", "", err
+	}
+	if len(indices) == 0 {
+		return "", "", extkeys.ErrInvalidPath
+	}
+	if extkeys.FormatPath(indices) == extkeys.FormatPath(append(bip44ParentPath(), 0)) {
+		return m.RecoverAccount(password, mnemonic)
+	}
+
+	keyStore, err := m.geth.AccountKeyStore()
+	if err != nil {
+		return "", "", err
+	}
+	master, err := extkeys.NewMaster(extkeys.NewMnemonic().MnemonicSeed(mnemonic, ""))
+	if err != nil {
+		return "", "", ErrInvalidMasterKeyCreated
+	}
+	extKey, err := master.Derive(indices)
+	if err != nil {
+		return "", "", err
+	}
+	wallet, key, err := m.importExtendedKey(keyStore, extkeys.KeyPurposeWallet, extKey, password)
+	if err != nil {
+		return "", "", err
+	}
+	publicKey := &key.PrivateKey.PublicKey
+	if err := recordAccountKeys(wallet, publicKey, publicKey); err != nil {
+		return "", "", err
+	}
+	return key.Address.Hex(), hexutil.Encode(crypto.FromECDSAPub(&key.PrivateKey.PublicKey)), nil
+}
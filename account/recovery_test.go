@@ -0,0 +1,103 @@
+package account
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/extkeys"
+	"github.com/stretchr/testify/require"
+)
+
+const scanMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+type testActivityChecker struct {
+	onChain       map[gethcommon.Address]bool
+	bundles       map[gethcommon.Address]bool
+	onChainChecks int
+}
+
+func (c *testActivityChecker) OnChain(ctx context.Context, address gethcommon.Address) (bool, error) {
+	c.onChainChecks++
+	return c.onChain[address], nil
+}
+
+func (c *testActivityChecker) ChatBundle(ctx context.Context, identity *ecdsa.PublicKey) (bool, error) {
+	return c.bundles[crypto.PubkeyToAddress(*identity)], nil
+}
+
+func scanAddress(t *testing.T, path string) gethcommon.Address {
+	indices, err := extkeys.ParsePath(path)
+	require.NoError(t, err)
+	master, err := extkeys.NewMaster(extkeys.NewMnemonic().MnemonicSeed(scanMnemonic, ""))
+	require.NoError(t, err)
+	key, err := master.Derive(indices)
+	require.NoError(t, err)
+	return crypto.PubkeyToAddress(key.ToECDSA().PublicKey)
+}
+
+func TestScanMnemonic(t *testing.T) {
+	active := []string{"m/44'/60'/0'/0/2", "m/44'/60'/0'/0/1/0", "m/44'/60'/3'/0/0"}
+	checker := &testActivityChecker{
+		onChain: map[gethcommon.Address]bool{},
+		bundles: map[gethcommon.Address]bool{scanAddress(t, "m/43'/60'/1581'/0'/0"): true},
+	}
+	for _, path := range append(active, "m/44'/60'/0'/0/9") {
+		checker.onChain[scanAddress(t, path)] = true
+	}
+
+	candidates, err := ScanMnemonic(context.Background(), scanMnemonic, 3, checker)
+	require.NoError(t, err)
+	require.Len(t, candidates, 4, "Keys after more than lookahead unused keys are not found")
+	require.Equal(t, "m/44'/60'/0'/0/0", candidates[0].Path)
+	require.Equal(t, gethcommon.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"), candidates[0].Address)
+	require.False(t, candidates[0].OnChain, "The default key is returned without activity")
+	require.True(t, candidates[0].ChatBundle)
+	for i, path := range active {
+		require.Equal(t, path, candidates[i+1].Path)
+		require.Equal(t, scanAddress(t, path), candidates[i+1].Address)
+		require.True(t, candidates[i+1].OnChain)
+	}
+	// The default key, keys 1 to 5 of the addresses, 0 to 3 of the sub-accounts and 1 to 6
+	// of the accounts.
+	require.Equal(t, 16, checker.onChainChecks)
+}
+
+func TestScanMnemonicLegacyChatBundle(t *testing.T) {
+	checker := &testActivityChecker{
+		bundles: map[gethcommon.Address]bool{scanAddress(t, "m/44'/60'/0'/0/0"): true},
+	}
+	candidates, err := ScanMnemonic(context.Background(), scanMnemonic, 1, checker)
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	require.True(t, candidates[0].ChatBundle, "Accounts using their wallet key as chat key are found")
+	require.True(t, candidates[0].LegacyChat)
+}
+
+func (s *ManagerTestSuite) TestRecoverAccountAtPath() {
+	s.gethServiceProvider.EXPECT().AccountKeyStore().Return(s.keyStore, nil).AnyTimes()
+
+	addr, pubKey, err := s.accManager.RecoverAccountAtPath(s.password, s.mnemonic, "m/44'/60'/0'/0/0")
+	s.Require().NoError(err)
+	s.Equal(s.address, addr)
+	s.Equal(s.pubKey, pubKey)
+
+	_, _, err = s.accManager.RecoverAccountAtPath(s.password, s.mnemonic, "m")
+	s.Equal(extkeys.ErrInvalidPath, err)
+
+	addr, _, err = s.accManager.RecoverAccountAtPath(s.password, s.mnemonic, "m/44'/60'/2'/0/0")
+	s.Require().NoError(err)
+	master, err := extkeys.NewMaster(extkeys.NewMnemonic().MnemonicSeed(s.mnemonic, ""))
+	s.Require().NoError(err)
+	key, err := master.Derive([]uint32{extkeys.HardenedKeyStart + 44, extkeys.HardenedKeyStart + 60, extkeys.HardenedKeyStart + 2, 0, 0})
+	s.Require().NoError(err)
+	s.Equal(crypto.PubkeyToAddress(key.ToECDSA().PublicKey).Hex(), addr)
+
+	keys, err := s.accManager.AccountKeys(addr)
+	s.Require().NoError(err)
+	s.True(keys.Legacy(), "The wallet key of accounts recovered at a path is their chat key")
+	s.Require().NoError(s.accManager.SelectAccount(addr, s.password))
+	s.accManager.Logout()
+}
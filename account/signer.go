@@ -0,0 +1,350 @@
+package account
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/signal"
+)
+
+// DefaultSignTimeout is how long the client is given to sign a hash with a platform key,
+// which can require the user to authenticate.
+const DefaultSignTimeout = time.Minute
+
+// errors
+var (
+	ErrSignerNotSet           = errors.New("no signer is set for platform keys")
+	ErrExternalKey            = errors.New("the private key of the account is kept by the platform keystore")
+	ErrKeyReferenceMismatch   = errors.New("signature doesn't match the public key of the key reference")
+	ErrKeyReferenceNotFound   = errors.New("no key reference for the address")
+	ErrKeyReferencesDirNotSet = errors.New("directory of the key references is not set")
+	ErrSignRequestNotFound    = errors.New("sign request not found")
+	ErrSignTimeout            = errors.New("timed out waiting for the signature")
+	ErrWatchOnlyAccount       = errors.New("the account is watch-only, its transactions are signed by an external device")
+)
+
+// Signer signs hashes with secp256k1 keys kept by a platform keystore, such as the
+// Android Keystore or the iOS Secure Enclave, so that their private keys never enter the node.
+// It only covers the signatures of the account: the operations of the chat identity, like
+// the key agreements of X3DH and the decryption of Whisper envelopes, need the private key.
+type Signer interface {
+	// Sign returns the [R || S || V] signature of a 32 bytes hash by the key keyID,
+	// where V is 0 or 1.
+	Sign(keyID string, hash []byte) ([]byte, error)
+}
+
+// KeyReference identifies an account key kept by the platform keystore.
+// It is persisted in place of the key file. Watch-only accounts, whose keys are kept by
+// an external device such as a hardware wallet, have a reference with no key ID.
+type KeyReference struct {
+	KeyID     string             `json:"keyId"`
+	Address   gethcommon.Address `json:"address"`
+	PublicKey hexutil.Bytes      `json:"publicKey"`
+}
+
+// SetSigner sets the signer of the accounts whose keys are kept by the platform keystore.
+func (m *Manager) SetSigner(signer Signer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.signer = signer
+	if m.selectedAccount != nil && m.selectedAccount.External != nil {
+		m.selectedAccount.signer = signer
+	}
+}
+
+// SetKeyReferencesDir sets the directory the key references are persisted in.
+func (m *Manager) SetKeyReferencesDir(dir string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.keyReferencesDir = dir
+}
+
+// AddKeyReference persists the reference to a key of the platform keystore, once the
+// signer proved that the key matches the public key. It returns the address of the account.
+func (m *Manager) AddKeyReference(keyID string, publicKey []byte) (string, error) {
+	m.mu.RLock()
+	signer, dir := m.signer, m.keyReferencesDir
+	m.mu.RUnlock()
+	if signer == nil {
+		return "", ErrSignerNotSet
+	}
+	if dir == "" {
+		return "", ErrKeyReferencesDirNotSet
+	}
+
+	pub, err := crypto.UnmarshalPubkey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	ref := KeyReference{
+		KeyID:     keyID,
+		Address:   crypto.PubkeyToAddress(*pub),
+		PublicKey: crypto.FromECDSAPub(pub),
+	}
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return "", err
+	}
+	if _, err := signHash(signer, &ref, challenge); err != nil {
+		return "", err
+	}
+
+	if err := writeKeyReference(dir, &ref); err != nil {
+		return "", err
+	}
+	return ref.Address.Hex(), nil
+}
+
+// AddWatchOnlyAccount persists the reference to an account whose key is kept by an external
+// device, like a hardware wallet. It is selected with SelectExternalAccount, and its
+// transactions are exported to the device for signing. It returns the address of the account.
+func (m *Manager) AddWatchOnlyAccount(address string) (string, error) {
+	m.mu.RLock()
+	dir := m.keyReferencesDir
+	m.mu.RUnlock()
+	if dir == "" {
+		return "", ErrKeyReferencesDirNotSet
+	}
+
+	account, err := ParseAccountString(address)
+	if err != nil {
+		return "", ErrAddressToAccountMappingFailure
+	}
+	if err := writeKeyReference(dir, &KeyReference{Address: account.Address}); err != nil {
+		return "", err
+	}
+	return account.Address.Hex(), nil
+}
+
+// KeyReferences returns the persisted references to keys of the platform keystore.
+func (m *Manager) KeyReferences() ([]KeyReference, error) {
+	m.mu.RLock()
+	dir := m.keyReferencesDir
+	m.mu.RUnlock()
+	if dir == "" {
+		return nil, ErrKeyReferencesDirNotSet
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []KeyReference{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	refs := []KeyReference{}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		ref, err := readKeyReference(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, *ref)
+	}
+	return refs, nil
+}
+
+// RemoveKeyReference deletes the reference to the key of an account. The key itself
+// must be deleted from the platform keystore by the client.
+func (m *Manager) RemoveKeyReference(address string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.keyReferencesDir == "" {
+		return ErrKeyReferencesDirNotSet
+	}
+
+	account, err := ParseAccountString(address)
+	if err != nil {
+		return ErrAddressToAccountMappingFailure
+	}
+	err = os.Remove(keyReferencePath(m.keyReferencesDir, account.Address))
+	if os.IsNotExist(err) {
+		return ErrKeyReferenceNotFound
+	}
+	if m.selectedAccount != nil && m.selectedAccount.Address == account.Address {
+		m.selectedAccount = nil
+	}
+	return err
+}
+
+// SelectExternalAccount selects an account whose key is kept by the platform keystore.
+// Its signatures are requested from the signer, and its private key is never available.
+// Watch-only accounts are selected likewise, but can't sign anything.
+func (m *Manager) SelectExternalAccount(address string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.keyReferencesDir == "" {
+		return ErrKeyReferencesDirNotSet
+	}
+
+	account, err := ParseAccountString(address)
+	if err != nil {
+		return ErrAddressToAccountMappingFailure
+	}
+	ref, err := readKeyReference(keyReferencePath(m.keyReferencesDir, account.Address))
+	if os.IsNotExist(err) {
+		return ErrKeyReferenceNotFound
+	} else if err != nil {
+		return err
+	}
+	m.selectedAccount = &SelectedExtKey{
+		Address:  ref.Address,
+		External: ref,
+		signer:   m.signer,
+	}
+	return nil
+}
+
+// SignHash signs a 32 bytes hash with the key of the account. It returns a [R || S || V]
+// signature, where V is 0 or 1.
+func (k *SelectedExtKey) SignHash(hash []byte) ([]byte, error) {
+	if k.External == nil {
+		return crypto.Sign(hash, k.AccountKey.PrivateKey)
+	}
+	if k.WatchOnly() {
+		return nil, ErrWatchOnlyAccount
+	}
+	if k.signer == nil {
+		return nil, ErrSignerNotSet
+	}
+	return signHash(k.signer, k.External, hash)
+}
+
+// WatchOnly returns true if the key of the account is kept by an external device.
+func (k *SelectedExtKey) WatchOnly() bool {
+	return k.External != nil && k.External.KeyID == ""
+}
+
+// PrivateKey returns the private key of the account, or ErrExternalKey if it is
+// kept by the platform keystore.
+func (k *SelectedExtKey) PrivateKey() (*ecdsa.PrivateKey, error) {
+	if k.External != nil {
+		return nil, ErrExternalKey
+	}
+	return k.AccountKey.PrivateKey, nil
+}
+
+// signHash signs a hash with a platform key and verifies that the signature matches
+// the public key of its reference.
+func signHash(signer Signer, ref *KeyReference, hash []byte) ([]byte, error) {
+	sig, err := signer.Sign(ref.KeyID, hash)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := crypto.Ecrecover(hash, sig)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", ErrKeyReferenceMismatch, err)
+	}
+	if !bytes.Equal(pub, ref.PublicKey) {
+		return nil, ErrKeyReferenceMismatch
+	}
+	return sig, nil
+}
+
+func keyReferencePath(dir string, address gethcommon.Address) string {
+	return filepath.Join(dir, strings.ToLower(address.Hex()[2:])+".json")
+}
+
+func writeKeyReference(dir string, ref *KeyReference) error {
+	data, err := json.Marshal(ref)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(keyReferencePath(dir, ref.Address), data, 0600)
+}
+
+func readKeyReference(path string) (*KeyReference, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ref := new(KeyReference)
+	if err := json.Unmarshal(data, ref); err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// SignalSigner is a Signer requesting signatures from the client with sign-hash.request
+// signals, to which it responds with Respond.
+type SignalSigner struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	pending map[string]chan signResponse
+}
+
+type signResponse struct {
+	signature []byte
+	err       error
+}
+
+// NewSignalSigner returns a new SignalSigner waiting for each signature for timeout.
+func NewSignalSigner(timeout time.Duration) *SignalSigner {
+	return &SignalSigner{timeout: timeout, pending: make(map[string]chan signResponse)}
+}
+
+// Sign sends a sign-hash.request signal and waits for the response of the client.
+func (s *SignalSigner) Sign(keyID string, hash []byte) ([]byte, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	requestID := hexutil.Encode(id)
+	response := make(chan signResponse, 1)
+	s.mu.Lock()
+	s.pending[requestID] = response
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, requestID)
+		s.mu.Unlock()
+	}()
+
+	signal.SendSignHashRequest(requestID, keyID, hexutil.Encode(hash))
+	select {
+	case r := <-response:
+		return r.signature, r.err
+	case <-time.After(s.timeout):
+		return nil, ErrSignTimeout
+	}
+}
+
+// Respond completes a sign request with the signature of the hash, or with the error
+// that prevented the client from signing it, such as a failed authentication.
+func (s *SignalSigner) Respond(requestID string, signature []byte, errMessage string) error {
+	s.mu.Lock()
+	response, ok := s.pending[requestID]
+	s.mu.Unlock()
+	if !ok {
+		return ErrSignRequestNotFound
+	}
+	r := signResponse{signature: signature}
+	if errMessage != "" {
+		r.err = errors.New(errMessage)
+	}
+	select {
+	case response <- r:
+	default:
+	}
+	return nil
+}
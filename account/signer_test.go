@@ -0,0 +1,135 @@
+package account
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/signal"
+	"github.com/stretchr/testify/require"
+)
+
+// platformSigner stands for the platform keystore in the tests.
+type platformSigner struct {
+	keys map[string]*ecdsa.PrivateKey
+}
+
+func (s *platformSigner) Sign(keyID string, hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, s.keys[keyID])
+}
+
+func TestExternalAccount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "key-references")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	publicKey := crypto.FromECDSAPub(&key.PublicKey)
+
+	m := NewManager(nil)
+	_, err = m.AddKeyReference("identity", publicKey)
+	require.Equal(t, ErrSignerNotSet, err)
+	m.SetSigner(&platformSigner{keys: map[string]*ecdsa.PrivateKey{"identity": key, "other": other}})
+	_, err = m.AddKeyReference("identity", publicKey)
+	require.Equal(t, ErrKeyReferencesDirNotSet, err)
+	m.SetKeyReferencesDir(dir)
+
+	_, err = m.AddKeyReference("other", publicKey)
+	require.Equal(t, ErrKeyReferenceMismatch, err, "The key must match the public key")
+	address, err := m.AddKeyReference("identity", publicKey)
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey).Hex(), address)
+
+	refs, err := m.KeyReferences()
+	require.NoError(t, err)
+	require.Equal(t, []KeyReference{{KeyID: "identity", Address: crypto.PubkeyToAddress(key.PublicKey), PublicKey: publicKey}}, refs)
+
+	require.Equal(t, ErrKeyReferenceNotFound, m.SelectExternalAccount(crypto.PubkeyToAddress(other.PublicKey).Hex()))
+	require.NoError(t, m.SelectExternalAccount(address))
+	selected, err := m.SelectedAccount()
+	require.NoError(t, err)
+	require.Nil(t, selected.AccountKey)
+	_, err = selected.PrivateKey()
+	require.Equal(t, ErrExternalKey, err)
+
+	hash := crypto.Keccak256([]byte("hello"))
+	sig, err := selected.SignHash(hash)
+	require.NoError(t, err)
+	expected, err := crypto.Sign(hash, key)
+	require.NoError(t, err)
+	require.Equal(t, expected, sig)
+
+	require.NoError(t, m.RemoveKeyReference(address))
+	_, err = m.SelectedAccount()
+	require.Equal(t, ErrNoAccountSelected, err, "Removing the reference of the selected account logs it out")
+	require.Equal(t, ErrKeyReferenceNotFound, m.RemoveKeyReference(address))
+	refs, err = m.KeyReferences()
+	require.NoError(t, err)
+	require.Empty(t, refs)
+}
+
+func TestWatchOnlyAccount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "key-references")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	m := NewManager(nil)
+	m.SetKeyReferencesDir(dir)
+	_, err = m.AddWatchOnlyAccount("0xinvalid")
+	require.Equal(t, ErrAddressToAccountMappingFailure, err)
+	address, err := m.AddWatchOnlyAccount(crypto.PubkeyToAddress(key.PublicKey).Hex())
+	require.NoError(t, err)
+
+	require.NoError(t, m.SelectExternalAccount(address))
+	selected, err := m.SelectedAccount()
+	require.NoError(t, err)
+	require.True(t, selected.WatchOnly())
+	_, err = selected.SignHash(crypto.Keccak256([]byte("hello")))
+	require.Equal(t, ErrWatchOnlyAccount, err)
+}
+
+func TestSignalSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := NewSignalSigner(time.Second)
+	defer signal.ResetDefaultNodeNotificationHandler()
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		var envelope struct {
+			Type  string
+			Event signal.SignHashRequestEvent
+		}
+		require.NoError(t, json.Unmarshal([]byte(jsonEvent), &envelope))
+		require.Equal(t, signal.EventSignHashRequest, envelope.Type)
+		require.Equal(t, "identity", envelope.Event.KeyID)
+		hash, err := hexutil.Decode(envelope.Event.Hash)
+		require.NoError(t, err)
+		sig, err := crypto.Sign(hash, key)
+		require.NoError(t, err)
+		go func() {
+			require.NoError(t, signer.Respond(envelope.Event.ID, sig, ""))
+		}()
+	})
+
+	hash := crypto.Keccak256([]byte("hello"))
+	sig, err := signer.Sign("identity", hash)
+	require.NoError(t, err)
+	expected, err := crypto.Sign(hash, key)
+	require.NoError(t, err)
+	require.Equal(t, expected, sig)
+
+	require.Equal(t, ErrSignRequestNotFound, signer.Respond("0x01", sig, ""))
+	signal.SetDefaultNodeNotificationHandler(func(string) {})
+	signer.timeout = 10 * time.Millisecond
+	_, err = signer.Sign("identity", hash)
+	require.Equal(t, ErrSignTimeout, err)
+}
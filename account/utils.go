@@ -15,9 +15,16 @@ var (
 
 // SelectedExtKey is a container for the selected (logged in) external account.
 type SelectedExtKey struct {
-	Address     common.Address
-	AccountKey  *keystore.Key
+	Address    common.Address
+	AccountKey *keystore.Key
+	// ChatKey is the chat identity key of the account, which is AccountKey for accounts
+	// created before it was derived separately.
+	ChatKey     *keystore.Key
 	SubAccounts []accounts.Account
+	// External references the key of the account if it is kept by the platform keystore,
+	// in which case AccountKey is nil.
+	External *KeyReference
+	signer   Signer
 }
 
 // Hex dumps address of a given extended key as hex string.
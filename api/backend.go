@@ -2,27 +2,44 @@ package api
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
+	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/log"
 	gethnode "github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p/enode"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
 
 	"github.com/status-im/status-go/account"
 	"github.com/status-im/status-go/node"
 	"github.com/status-im/status-go/notifications/push/fcm"
 	"github.com/status-im/status-go/params"
 	"github.com/status-im/status-go/rpc"
+	"github.com/status-im/status-go/services/abiregistry"
+	"github.com/status-im/status-go/services/ens"
+	"github.com/status-im/status-go/services/permissions"
 	"github.com/status-im/status-go/services/personal"
 	"github.com/status-im/status-go/services/rpcfilters"
+	"github.com/status-im/status-go/services/scheduler"
+	"github.com/status-im/status-go/services/shhext"
 	"github.com/status-im/status-go/services/shhext/chat"
 	"github.com/status-im/status-go/services/shhext/chat/crypto"
+	"github.com/status-im/status-go/services/shhext/reencryption"
+	"github.com/status-im/status-go/services/status"
+	"github.com/status-im/status-go/services/stickers"
 	"github.com/status-im/status-go/services/typeddata"
+	"github.com/status-im/status-go/services/wallet"
+	"github.com/status-im/status-go/services/walletconnect"
 	"github.com/status-im/status-go/signal"
 	"github.com/status-im/status-go/transactions"
 )
@@ -39,6 +56,10 @@ var (
 	ErrWhisperIdentityInjectionFailure = errors.New("failed to inject identity into Whisper")
 	// ErrUnsupportedRPCMethod is for methods not supported by the RPC interface
 	ErrUnsupportedRPCMethod = errors.New("method is unsupported by RPC interface")
+	// ErrInvalidSignTypedDataParams is returned when signing typed data without an address and the data.
+	ErrInvalidSignTypedDataParams = errors.New("eth_signTypedData expects an address and the typed data")
+	// ErrInvalidTypedDataSigner is returned when signing typed data with another account than the selected one.
+	ErrInvalidTypedDataSigner = errors.New("typed data can only be signed by the selected account")
 )
 
 // StatusBackend implements Status.im service
@@ -48,11 +69,16 @@ type StatusBackend struct {
 	personalAPI     *personal.PublicAPI
 	rpcFilters      *rpcfilters.Service
 	accountManager  *account.Manager
+	signer          *account.SignalSigner
+	signRequests    *typeddata.Queue
 	transactor      *transactions.Transactor
 	newNotification fcm.NotificationConstructor
 	connectionState connectionState
 	appState        appState
-	log             log.Logger
+	conditions      scheduler.Conditions
+	// profiles are the RPC servers of the accounts logged in with LoginProfile.
+	profiles map[gethcommon.Address]*gethrpc.Server
+	log      log.Logger
 }
 
 // NewStatusBackend create a new NewStatusBackend instance
@@ -61,6 +87,9 @@ func NewStatusBackend() *StatusBackend {
 
 	statusNode := node.New()
 	accountManager := account.NewManager(statusNode)
+	signer := account.NewSignalSigner(account.DefaultSignTimeout)
+	signRequests := typeddata.NewQueue(typeddata.DefaultSignRequestTimeout)
+	accountManager.SetSigner(signer)
 	transactor := transactions.NewTransactor()
 	personalAPI := personal.NewAPI()
 	notificationManager := fcm.NewNotification(fcmServerKey)
@@ -69,10 +98,13 @@ func NewStatusBackend() *StatusBackend {
 	return &StatusBackend{
 		statusNode:      statusNode,
 		accountManager:  accountManager,
+		signer:          signer,
+		signRequests:    signRequests,
 		transactor:      transactor,
 		personalAPI:     personalAPI,
 		rpcFilters:      rpcFilters,
 		newNotification: notificationManager,
+		conditions:      scheduler.DefaultConditions(),
 		log:             log.New("package", "status-go/api.StatusBackend"),
 	}
 }
@@ -117,6 +149,72 @@ func (b *StatusBackend) rpcFiltersService() gethnode.ServiceConstructor {
 	}
 }
 
+func (b *StatusBackend) walletService(config *params.NodeConfig) gethnode.ServiceConstructor {
+	return func(ctx *gethnode.ServiceContext) (gethnode.Service, error) {
+		service := wallet.New(b.statusNode, wallet.Networks(config))
+		service.SetTransactionReplacer(b)
+		var abis *abiregistry.Service
+		if err := ctx.Service(&abis); err == nil {
+			service.SetLogDecoder(abis.Registry())
+		}
+		return service, nil
+	}
+}
+
+func (b *StatusBackend) ensService(config *params.NodeConfig) gethnode.ServiceConstructor {
+	return func(*gethnode.ServiceContext) (gethnode.Service, error) {
+		return ens.New(b.statusNode, config.NetworkID), nil
+	}
+}
+
+func (b *StatusBackend) stickersService(config *params.NodeConfig) gethnode.ServiceConstructor {
+	return func(*gethnode.ServiceContext) (gethnode.Service, error) {
+		return stickers.New(b.statusNode, config.NetworkID, config.IPFSGatewayURL)
+	}
+}
+
+func (b *StatusBackend) permissionsService() gethnode.ServiceConstructor {
+	return func(*gethnode.ServiceContext) (gethnode.Service, error) {
+		return permissions.New(), nil
+	}
+}
+
+func (b *StatusBackend) walletConnectService(config *params.NodeConfig) gethnode.ServiceConstructor {
+	return func(*gethnode.ServiceContext) (gethnode.Service, error) {
+		metadata := walletconnect.Metadata{Name: "Status", URL: "https://status.im"}
+		return walletconnect.New(config.NetworkID, metadata, walletConnectWallet{b}, walletconnect.SignalTransport{}), nil
+	}
+}
+
+// keyStoreKDF returns the key derivation function of the configuration, with the
+// default parameters for those which are not set.
+func keyStoreKDF(config params.KDFConfig) account.KDF {
+	switch config.Name {
+	case params.KDFArgon2id:
+		kdf := account.Argon2idKDF(account.Argon2idTime, account.Argon2idMemory, account.Argon2idThreads)
+		if config.Argon2Time != 0 {
+			kdf.Argon2Time = config.Argon2Time
+		}
+		if config.Argon2Memory != 0 {
+			kdf.Argon2Memory = config.Argon2Memory
+		}
+		if config.Argon2Threads != 0 {
+			kdf.Argon2Threads = config.Argon2Threads
+		}
+		return kdf
+	case params.KDFScrypt:
+		kdf := account.ScryptKDF(keystore.StandardScryptN, keystore.StandardScryptP)
+		if config.ScryptN != 0 {
+			kdf.ScryptN = config.ScryptN
+		}
+		if config.ScryptP != 0 {
+			kdf.ScryptP = config.ScryptP
+		}
+		return kdf
+	}
+	return account.KDF{}
+}
+
 func (b *StatusBackend) startNode(config *params.NodeConfig) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -131,14 +229,30 @@ func (b *StatusBackend) startNode(config *params.NodeConfig) (err error) {
 
 	services := []gethnode.ServiceConstructor{}
 	services = appendIf(config.UpstreamConfig.Enabled, services, b.rpcFiltersService())
+	services = appendIf(config.UpstreamConfig.Enabled, services, b.walletService(config))
+	services = appendIf(config.UpstreamConfig.Enabled, services, b.ensService(config))
+	services = appendIf(config.UpstreamConfig.Enabled, services, b.stickersService(config))
+	services = append(services, b.permissionsService())
+	services = append(services, b.walletConnectService(config))
 
 	if err = b.statusNode.Start(config, services...); err != nil {
 		return
 	}
 	signal.SendNodeStarted()
 
+	if config.KeyStoreDir != "" {
+		b.accountManager.SetKeyReferencesDir(filepath.Join(config.KeyStoreDir, "external"))
+	}
+	if err = b.accountManager.SetKDF(keyStoreKDF(config.KeyStoreKDF)); err != nil {
+		return
+	}
 	b.transactor.SetNetworkID(config.NetworkID)
 	b.transactor.SetRPC(b.statusNode.RPCClient(), rpc.DefaultCallTimeout)
+	networkClients := make(map[uint64]*rpc.Client, len(config.Networks))
+	for _, network := range config.Networks {
+		networkClients[network.ChainID] = b.statusNode.NetworkRPCClient(network.ChainID)
+	}
+	b.transactor.SetNetworkRPCs(networkClients)
 	b.personalAPI.SetRPC(b.statusNode.RPCPrivateClient(), rpc.DefaultCallTimeout)
 
 	if err = b.registerHandlers(); err != nil {
@@ -155,12 +269,23 @@ func (b *StatusBackend) startNode(config *params.NodeConfig) (err error) {
 
 	if st, err := b.statusNode.StatusService(); err == nil {
 		st.SetAccountManager(b.AccountManager())
+		st.SetLogin(b.SelectAccount)
+		st.AddUserDataSource("wallet", walletUserData{b})
+		if ext, err := b.statusNode.ShhExtService(); err == nil {
+			st.AddUserDataSource("chat", ext)
+			st.AddBackupSource("chat", ext)
+			st.SetLatencySource(ext)
+		}
 	}
 
 	if st, err := b.statusNode.PeerService(); err == nil {
 		st.SetDiscoverer(b.StatusNode())
+		st.SetDiscoverySummarizer(b.StatusNode())
 	}
 
+	b.applyConditions()
+	b.applyNetworkType()
+
 	signal.SendNodeReady()
 
 	return nil
@@ -178,6 +303,9 @@ func (b *StatusBackend) stopNode() error {
 		return node.ErrNoRunningNode
 	}
 	defer signal.SendNodeStopped()
+	if err := b.logoutProfiles(); err != nil {
+		b.log.Error("failed to log out profiles", "err", err)
+	}
 	return b.statusNode.Stop()
 }
 
@@ -226,6 +354,14 @@ func (b *StatusBackend) CallPrivateRPC(inputJSON string) string {
 	return client.CallRaw(inputJSON)
 }
 
+// CallDappRPC executes public RPC requests of a dapp on node's in-proc RPC server. Its
+// accounts are only exposed if the user granted it the permission, and it can only call
+// reads of the chain and of the node, besides eth_accounts and eth_signTypedData.
+func (b *StatusBackend) CallDappRPC(origin, inputJSON string) string {
+	client := b.statusNode.RPCClient()
+	return client.CallRawContext(rpc.WithOrigin(context.Background(), origin), inputJSON)
+}
+
 // SendTransaction creates a new transaction and waits until it's complete.
 func (b *StatusBackend) SendTransaction(sendArgs transactions.SendTxArgs, password string) (hash gethcommon.Hash, err error) {
 	verifiedAccount, err := b.getVerifiedAccount(password)
@@ -243,6 +379,88 @@ func (b *StatusBackend) SendTransaction(sendArgs transactions.SendTxArgs, passwo
 	return
 }
 
+// SendDappTransaction creates a new transaction requested by a dapp, like SendTransaction,
+// if the user granted it the permission.
+func (b *StatusBackend) SendDappTransaction(origin string, sendArgs transactions.SendTxArgs, password string) (gethcommon.Hash, error) {
+	if err := b.checkDappPermission(origin, permissions.Transactions); err != nil {
+		return gethcommon.Hash{}, err
+	}
+	return b.SendTransaction(sendArgs, password)
+}
+
+// SpeedUpTransaction replaces a pending transaction with the same one paying a higher gas price.
+func (b *StatusBackend) SpeedUpTransaction(hash gethcommon.Hash, gasPrice *big.Int, password string) (gethcommon.Hash, error) {
+	verifiedAccount, err := b.getVerifiedAccount(password)
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	newHash, err := b.transactor.SpeedUpTransaction(hash, gasPrice, verifiedAccount)
+	if err != nil {
+		return newHash, err
+	}
+
+	go b.rpcFilters.TriggerTransactionSentToUpstreamEvent(newHash)
+
+	return newHash, nil
+}
+
+// CancelTransaction replaces a pending transaction with an empty transfer to the sender itself.
+func (b *StatusBackend) CancelTransaction(hash gethcommon.Hash, password string) (gethcommon.Hash, error) {
+	verifiedAccount, err := b.getVerifiedAccount(password)
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	newHash, err := b.transactor.CancelTransaction(hash, verifiedAccount)
+	if err != nil {
+		return newHash, err
+	}
+
+	go b.rpcFilters.TriggerTransactionSentToUpstreamEvent(newHash)
+
+	return newHash, nil
+}
+
+// PrepareTransaction assembles a transaction of the selected account without signing it,
+// so that it is signed by an external device, like a hardware wallet, and submitted with
+// SendRawTransaction before it expires.
+func (b *StatusBackend) PrepareTransaction(sendArgs transactions.SendTxArgs) (*transactions.UnsignedTransaction, error) {
+	selectedAccount, err := b.accountManager.SelectedAccount()
+	if err != nil {
+		return nil, err
+	}
+	return b.transactor.PrepareTransaction(sendArgs, selectedAccount)
+}
+
+// SendRawTransaction submits a transaction prepared with PrepareTransaction, once signed.
+func (b *StatusBackend) SendRawTransaction(data []byte) (gethcommon.Hash, error) {
+	hash, err := b.transactor.SendRawTransaction(data)
+	if err != nil {
+		return hash, err
+	}
+
+	go b.rpcFilters.TriggerTransactionSentToUpstreamEvent(hash)
+
+	return hash, nil
+}
+
+// UnsignedTransactions returns the transactions waiting for an external signature.
+func (b *StatusBackend) UnsignedTransactions() []transactions.UnsignedTransaction {
+	return b.transactor.UnsignedTransactions()
+}
+
+// DiscardTransaction removes a transaction waiting for an external signature.
+func (b *StatusBackend) DiscardTransaction(hash gethcommon.Hash) error {
+	return b.transactor.DiscardTransaction(hash)
+}
+
+// TransactionReplacementStatus returns the transactions competing with a transaction
+// and the one that landed, if any.
+func (b *StatusBackend) TransactionReplacementStatus(hash gethcommon.Hash) (*transactions.ReplacementStatus, error) {
+	return b.transactor.ReplacementStatus(hash)
+}
+
 // SignMessage checks the pwd vs the selected account and passes on the signParams
 // to personalAPI for message signature
 func (b *StatusBackend) SignMessage(rpcParams personal.SignParams) (hexutil.Bytes, error) {
@@ -259,26 +477,69 @@ func (b *StatusBackend) Recover(rpcParams personal.RecoverParams) (gethcommon.Ad
 	return b.personalAPI.Recover(rpcParams)
 }
 
-// SignTypedData accepts data and password. Gets verified account and signs typed data.
-func (b *StatusBackend) SignTypedData(typed typeddata.TypedData, password string) (hexutil.Bytes, error) {
+// SignTypedData accepts data, its version and password. Gets verified account and signs typed data.
+func (b *StatusBackend) SignTypedData(typed typeddata.TypedData, version typeddata.Version, password string) (hexutil.Bytes, error) {
 	account, err := b.getVerifiedAccount(password)
 	if err != nil {
 		return hexutil.Bytes{}, err
 	}
 	chain := new(big.Int).SetUint64(b.StatusNode().Config().NetworkID)
-	sig, err := typeddata.Sign(typed, account.AccountKey.PrivateKey, chain)
+	sig, err := typeddata.SignWith(typed, version, account.SignHash, chain)
 	if err != nil {
 		return hexutil.Bytes{}, err
 	}
 	return hexutil.Bytes(sig), err
 }
 
+// ApproveSignRequest signs typed data requested with eth_signTypedData_v3 or
+// eth_signTypedData_v4, once the user confirmed it with the password of the account.
+// The request stays queued if the password is wrong.
+func (b *StatusBackend) ApproveSignRequest(id, password string) (hexutil.Bytes, error) {
+	return b.signRequests.Approve(id, func(request typeddata.SignRequest) (hexutil.Bytes, error) {
+		return b.SignTypedData(request.Data, request.Version, password)
+	})
+}
+
+// DiscardSignRequest discards a request to sign typed data, failing the RPC call.
+func (b *StatusBackend) DiscardSignRequest(id string) error {
+	return b.signRequests.Discard(id)
+}
+
+// PendingSignRequests returns the requests to sign typed data waiting for the confirmation
+// of the user.
+func (b *StatusBackend) PendingSignRequests() []typeddata.SignRequest {
+	return b.signRequests.Pending()
+}
+
+// SignDappTypedData signs typed data requested by a dapp, like SignTypedData, if the user
+// granted it the permission.
+func (b *StatusBackend) SignDappTypedData(origin string, typed typeddata.TypedData, version typeddata.Version, password string) (hexutil.Bytes, error) {
+	if err := b.checkDappPermission(origin, permissions.TypedData); err != nil {
+		return hexutil.Bytes{}, err
+	}
+	return b.SignTypedData(typed, version, password)
+}
+
+// checkDappPermission returns nil if a permission is granted to a dapp, or prompts the user
+// to grant it.
+func (b *StatusBackend) checkDappPermission(origin string, p permissions.Permission) error {
+	permissionsService, err := b.statusNode.PermissionsService()
+	if err != nil {
+		return err
+	}
+	return permissionsService.Check(origin, p)
+}
+
 func (b *StatusBackend) getVerifiedAccount(password string) (*account.SelectedExtKey, error) {
 	selectedAccount, err := b.accountManager.SelectedAccount()
 	if err != nil {
 		b.log.Error("failed to get a selected account", "err", err)
 		return nil, err
 	}
+	if selectedAccount.External != nil {
+		// the platform keystore authenticates the user before each signature
+		return selectedAccount, nil
+	}
 	config := b.StatusNode().Config()
 	_, err = b.accountManager.VerifyAccountPassword(config.KeyStoreDir, selectedAccount.Address.String(), password)
 	if err != nil {
@@ -307,11 +568,23 @@ func (b *StatusBackend) registerHandlers() error {
 	for _, client := range clients {
 		client.RegisterHandler(
 			params.AccountsMethodName,
-			func(context.Context, ...interface{}) (interface{}, error) {
+			func(ctx context.Context, _ ...interface{}) (interface{}, error) {
+				if origin, ok := rpc.OriginFromContext(ctx); ok {
+					// dapps don't see any account until they are granted the permission
+					switch err := b.checkDappPermission(origin, permissions.Accounts); err {
+					case nil:
+					case permissions.ErrPermissionRequired:
+						return []gethcommon.Address{}, nil
+					default:
+						return nil, err
+					}
+				}
 				return b.AccountManager().Accounts()
 			},
 		)
 
+		client.RegisterHandler(params.SignTypedDataV3MethodName, b.signTypedDataHandler(typeddata.V3))
+		client.RegisterHandler(params.SignTypedDataV4MethodName, b.signTypedDataHandler(typeddata.V4))
 		client.RegisterHandler(params.SendTransactionMethodName, unsupportedMethodHandler)
 		client.RegisterHandler(params.PersonalSignMethodName, unsupportedMethodHandler)
 		client.RegisterHandler(params.PersonalRecoverMethodName, unsupportedMethodHandler)
@@ -320,6 +593,59 @@ func (b *StatusBackend) registerHandlers() error {
 	return nil
 }
 
+// signTypedDataHandler queues the requests to sign typed data of a version, given the
+// address of the selected account and the data, until the user approves or discards them.
+func (b *StatusBackend) signTypedDataHandler(version typeddata.Version) rpc.Handler {
+	return func(ctx context.Context, rpcParams ...interface{}) (interface{}, error) {
+		if len(rpcParams) != 2 {
+			return nil, ErrInvalidSignTypedDataParams
+		}
+		request := typeddata.SignRequest{Version: version}
+		address, ok := rpcParams[0].(string)
+		if !ok || !gethcommon.IsHexAddress(address) {
+			return nil, ErrInvalidSignTypedDataParams
+		}
+		request.Address = gethcommon.HexToAddress(address)
+		if err := decodeTypedData(rpcParams[1], &request.Data); err != nil {
+			return nil, err
+		}
+		if err := request.Data.Validate(); err != nil {
+			return nil, err
+		}
+		chain := new(big.Int).SetUint64(b.StatusNode().Config().NetworkID)
+		if err := request.Data.ValidateChainID(chain); err != nil {
+			return nil, err
+		}
+		if origin, ok := rpc.OriginFromContext(ctx); ok {
+			if err := b.checkDappPermission(origin, permissions.TypedData); err != nil {
+				return nil, err
+			}
+			request.Origin = origin
+		}
+		selectedAccount, err := b.accountManager.SelectedAccount()
+		if err != nil {
+			return nil, err
+		}
+		if selectedAccount.Address != request.Address {
+			return nil, ErrInvalidTypedDataSigner
+		}
+		return b.signRequests.Add(ctx, request)
+	}
+}
+
+// decodeTypedData decodes typed data given as a JSON object, or as a string encoding it,
+// as most dapps do.
+func decodeTypedData(param interface{}, typed *typeddata.TypedData) error {
+	if encoded, ok := param.(string); ok {
+		return json.Unmarshal([]byte(encoded), typed)
+	}
+	encoded, err := json.Marshal(param)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, typed)
+}
+
 func unsupportedMethodHandler(ctx context.Context, rpcParams ...interface{}) (interface{}, error) {
 	return nil, ErrUnsupportedRPCMethod
 }
@@ -340,11 +666,40 @@ func (b *StatusBackend) ConnectionChange(typ string, expensive bool) {
 	b.log.Info("Network state change", "old", b.connectionState, "new", state)
 
 	b.connectionState = state
+	b.conditions.WiFi = state.Type == connectionWifi && !state.Expensive
+	b.applyConditions()
+	b.applyNetworkType()
 
 	// logic of handling state changes here
 	// restart node? force peers reconnect? etc
 }
 
+// ChargingStateChange handles changes of the charging state of the device,
+// which constrain the jobs of the scheduler.
+func (b *StatusBackend) ChargingStateChange(charging bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.log.Info("Charging state change", "charging", charging)
+	b.conditions.Charging = charging
+	b.applyConditions()
+}
+
+// applyConditions reports the conditions of the device to the scheduler, if the node is running.
+func (b *StatusBackend) applyConditions() {
+	if st, err := b.statusNode.SchedulerService(); err == nil {
+		st.Scheduler().SetConditions(b.conditions)
+	}
+}
+
+// applyNetworkType reports the network type of the device to the latency stats of the
+// chat, if the node is running.
+func (b *StatusBackend) applyNetworkType() {
+	if st, err := b.statusNode.ShhExtService(); err == nil {
+		st.SetNetworkType(b.connectionState.Type.String())
+	}
+}
+
 // AppStateChange handles app state changes (background/foreground).
 // state values: see https://facebook.github.io/react-native/docs/appstate.html
 func (b *StatusBackend) AppStateChange(state string) {
@@ -361,11 +716,19 @@ func (b *StatusBackend) AppStateChange(state string) {
 	// and normal mode if the app is in foreground.
 }
 
-// Logout clears whisper identities.
+// Logout clears whisper identities and releases the stores of the account.
 func (b *StatusBackend) Logout() error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if err := b.logoutProfiles(); err != nil {
+		return err
+	}
+
+	if err := b.releaseAccount(); err != nil {
+		return err
+	}
+
 	whisperService, err := b.statusNode.WhisperService()
 	switch err {
 	case node.ErrServiceUnknown: // Whisper was never registered
@@ -382,17 +745,26 @@ func (b *StatusBackend) Logout() error {
 	return nil
 }
 
+// wipeAccount deletes the keys of an account whose installation was wiped by another
+// installation of the account, and logs it out.
+func (b *StatusBackend) wipeAccount(address string) error {
+	if err := b.accountManager.DeleteAccount(address); err != nil {
+		return err
+	}
+	return b.Logout()
+}
+
 // reSelectAccount selects previously selected account, often, after node restart.
 func (b *StatusBackend) reSelectAccount() error {
 	selectedAccount, err := b.AccountManager().SelectedAccount()
-	if selectedAccount == nil || err == account.ErrNoAccountSelected {
+	if selectedAccount == nil || err == account.ErrNoAccountSelected || selectedAccount.External != nil {
 		return nil
 	}
 	whisperService, err := b.statusNode.WhisperService()
 	switch err {
 	case node.ErrServiceUnknown: // Whisper was never registered
 	case nil:
-		if err := whisperService.SelectKeyPair(selectedAccount.AccountKey.PrivateKey); err != nil {
+		if err := whisperService.SelectKeyPair(selectedAccount.ChatKey.PrivateKey); err != nil {
 			return ErrWhisperIdentityInjectionFailure
 		}
 	default:
@@ -404,11 +776,22 @@ func (b *StatusBackend) reSelectAccount() error {
 
 // SelectAccount selects current account, by verifying that address has corresponding account which can be decrypted
 // using provided password. Once verification is done, decrypted key is injected into Whisper (as a single identity,
-// all previous identities are removed).
+// all previous identities are removed, except those of the profiles).
+// If another account is selected, the node keeps running and its peers stay connected: the stores of the previous
+// account are released and the services are re-keyed with the new one. An account logged in as a profile is logged
+// out first.
 func (b *StatusBackend) SelectAccount(address, password string) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if _, ok := b.profiles[gethcommon.HexToAddress(address)]; ok {
+		if err := b.logoutProfile(gethcommon.HexToAddress(address)); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	previous, _ := b.accountManager.SelectedAccount()
 	err := b.accountManager.SelectAccount(address, password)
 	if err != nil {
 		return err
@@ -417,12 +800,21 @@ func (b *StatusBackend) SelectAccount(address, password string) error {
 	if err != nil {
 		return err
 	}
+	switched := previous != nil && previous.Address != acc.Address
+	if switched {
+		if err := b.releaseAccount(); err != nil {
+			return err
+		}
+	}
 
 	whisperService, err := b.statusNode.WhisperService()
 	switch err {
 	case node.ErrServiceUnknown: // Whisper was never registered
 	case nil:
-		if err := whisperService.SelectKeyPair(acc.AccountKey.PrivateKey); err != nil {
+		if err := whisperService.SelectKeyPair(acc.ChatKey.PrivateKey); err != nil {
+			return ErrWhisperIdentityInjectionFailure
+		}
+		if err := restoreProfileIdentities(whisperService, b.accountManager.Profiles()); err != nil {
 			return ErrWhisperIdentityInjectionFailure
 		}
 	default:
@@ -435,14 +827,339 @@ func (b *StatusBackend) SelectAccount(address, password string) error {
 			return err
 		}
 
+		// SelectKeyPair doesn't return the ID of the identity, and adding it again doesn't change it.
+		keyPairID, err := whisperService.AddKeyPair(acc.ChatKey.PrivateKey)
+		if err != nil {
+			return ErrWhisperIdentityInjectionFailure
+		}
+		st.SetKeyPairID(keyPairID)
+		st.SetWipeHandler(func() error { return b.wipeAccount(address) })
+		st.SetPasswordVerifier(func(password string) error {
+			_, err := b.accountManager.VerifyAccountPassword(b.StatusNode().Config().KeyStoreDir, address, password)
+			return err
+		})
 		if err := st.InitProtocol(address, password); err != nil {
 			return err
 		}
+		if err := b.registerKeyFiles(st, address, password); err != nil {
+			return err
+		}
+		if store := st.TransactionStore(); store != nil {
+			restored, err := b.transactor.SetStore(store)
+			if err != nil {
+				return err
+			}
+			if len(restored) > 0 {
+				signal.SendUnsignedTransactionsRestored(restored)
+			}
+		}
+		if store := st.WalletStore(); store != nil {
+			if err := b.selectWalletAccount(store, acc); err != nil {
+				return err
+			}
+		}
+		if err := b.setENSStore(st.ENSStore()); err != nil {
+			return err
+		}
+		if err := b.setStickersStore(st.StickersStore()); err != nil {
+			return err
+		}
+		if err := b.setPermissionsStore(st.PermissionsStore()); err != nil {
+			return err
+		}
+		if err := b.setWalletConnectStore(st.WalletConnectStore()); err != nil {
+			return err
+		}
 	}
 
+	if switched {
+		b.log.Info("Account switched", "from", previous.Address.Hex(), "to", acc.Address.Hex(), "duration", time.Since(start))
+	}
 	return nil
 }
 
+// registerKeyFiles schedules the re-encryption of the key files of an account when the
+// key derivation function of the configuration was upgraded, retrying the re-encryption
+// done when they are unlocked if it failed.
+func (b *StatusBackend) registerKeyFiles(st *shhext.Service, address, password string) error {
+	kdf := b.accountManager.KDF()
+	if kdf.Name == "" {
+		return nil
+	}
+	current, err := b.accountManager.KeyFileKDF(address)
+	if err != nil {
+		return err
+	}
+	store := keyFiles{manager: b.accountManager, address: address, password: password}
+	return st.RegisterEncryptedStore(store, keyFilesVersion(current), keyFilesVersion(kdf))
+}
+
+// keyFilesCipher is the cipher of the key files, AES-128-CTR.
+const keyFilesCipher = 1
+
+func keyFilesVersion(kdf account.KDF) reencryption.Version {
+	return reencryption.Version{Cipher: keyFilesCipher, KDF: kdf.Version()}
+}
+
+// keyFiles are the key files of an account, as an encrypted store of the account.
+type keyFiles struct {
+	manager  *account.Manager
+	address  string
+	password string
+}
+
+func (k keyFiles) Name() string {
+	return "keystore/" + strings.ToLower(k.address)
+}
+
+func (k keyFiles) ReencryptStage(from, to reencryption.Version, cursor []byte) ([]byte, error) {
+	return nil, k.manager.ReencryptKeyFiles(k.address, k.password)
+}
+
+// selectWalletAccount indexes the transfers of the addresses of the selected account and
+// loads its custom tokens, if the wallet service is registered.
+func (b *StatusBackend) selectWalletAccount(store wallet.Store, acc *account.SelectedExtKey) error {
+	walletService, err := b.statusNode.WalletService()
+	switch err {
+	case node.ErrServiceUnknown:
+		return nil
+	case nil:
+	default:
+		return err
+	}
+	addresses := []gethcommon.Address{acc.Address}
+	for _, subAccount := range acc.SubAccounts {
+		addresses = append(addresses, subAccount.Address)
+	}
+	walletService.SelectAccount(store, addresses)
+	return nil
+}
+
+// setENSStore sets the cache of the ENS resolutions, or clears it when store is nil, if the
+// ENS service is registered.
+func (b *StatusBackend) setENSStore(store ens.Store) error {
+	ensService, err := b.statusNode.ENSService()
+	switch err {
+	case node.ErrServiceUnknown:
+	case nil:
+		ensService.SetStore(store)
+	default:
+		return err
+	}
+	return nil
+}
+
+// setStickersStore sets the sticker packs installed by the account, or clears them when
+// store is nil, if the stickers service is registered.
+func (b *StatusBackend) setStickersStore(store stickers.Store) error {
+	stickersService, err := b.statusNode.StickersService()
+	switch err {
+	case node.ErrServiceUnknown:
+	case nil:
+		stickersService.SetStore(store)
+	default:
+		return err
+	}
+	return nil
+}
+
+// setPermissionsStore sets the permissions granted to dapps by the account, or clears them
+// when store is nil, if the permissions service is registered.
+func (b *StatusBackend) setPermissionsStore(store permissions.Store) error {
+	permissionsService, err := b.statusNode.PermissionsService()
+	switch err {
+	case node.ErrServiceUnknown:
+	case nil:
+		permissionsService.SetStore(store)
+	default:
+		return err
+	}
+	return nil
+}
+
+// setWalletConnectStore sets the WalletConnect sessions of the account, or unloads them when
+// store is nil, if the WalletConnect service is registered.
+func (b *StatusBackend) setWalletConnectStore(store walletconnect.Store) error {
+	walletConnectService, err := b.statusNode.WalletConnectService()
+	switch err {
+	case node.ErrServiceUnknown:
+	case nil:
+		return walletConnectService.SetStore(store)
+	default:
+		return err
+	}
+	return nil
+}
+
+// walletConnectWallet is the wallet of the WalletConnect sessions: their requests are made
+// like the requests of dapps in the browser, with the permissions granted to their origin.
+type walletConnectWallet struct {
+	b *StatusBackend
+}
+
+func (w walletConnectWallet) Accounts() ([]gethcommon.Address, error) {
+	return w.b.AccountManager().Accounts()
+}
+
+func (w walletConnectWallet) CallDappRPC(origin, inputJSON string) string {
+	return w.b.CallDappRPC(origin, inputJSON)
+}
+
+func (w walletConnectWallet) GrantPermissions(origin string, perms ...permissions.Permission) error {
+	permissionsService, err := w.b.statusNode.PermissionsService()
+	if err != nil {
+		return err
+	}
+	_, err = permissionsService.Grant(origin, perms...)
+	return err
+}
+
+func (w walletConnectWallet) RevokePermissions(origin string) error {
+	permissionsService, err := w.b.statusNode.PermissionsService()
+	if err != nil {
+		return err
+	}
+	return permissionsService.Revoke(origin)
+}
+
+// walletUserData is the wallet section of the exports of the user data: the transactions
+// of the account which didn't land yet, and its transfers indexed by the wallet service.
+type walletUserData struct {
+	b *StatusBackend
+}
+
+func (w walletUserData) ExportUserData() (interface{}, error) {
+	data := wallet.UserData{
+		UnsignedTransactions: w.b.transactor.UnsignedTransactions(),
+		SentTransactions:     w.b.transactor.SentTransactions(),
+		Transfers:            []wallet.Transfer{},
+	}
+	walletService, err := w.b.statusNode.WalletService()
+	switch err {
+	case node.ErrServiceUnknown:
+		return data, nil
+	case nil:
+	default:
+		return nil, err
+	}
+	if data.Transfers, err = walletService.Transfers(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// releaseAccount closes the chat database of the selected account and cancels its scheduled
+// backups, whose passphrase is only valid for that account. Its transactions waiting for an
+// external signature are dropped from the queue, but stay persisted, the indexing of its
+// transfers is stopped, its custom tokens, sticker packs, dapp permissions and WalletConnect
+// sessions are unloaded and ENS names are no longer cached.
+func (b *StatusBackend) releaseAccount() error {
+	if _, err := b.transactor.SetStore(nil); err != nil {
+		return err
+	}
+	walletService, err := b.statusNode.WalletService()
+	switch err {
+	case node.ErrServiceUnknown:
+	case nil:
+		walletService.ReleaseAccount()
+	default:
+		return err
+	}
+	if err := b.setENSStore(nil); err != nil {
+		return err
+	}
+	if err := b.setStickersStore(nil); err != nil {
+		return err
+	}
+	if err := b.setPermissionsStore(nil); err != nil {
+		return err
+	}
+	if err := b.setWalletConnectStore(nil); err != nil {
+		return err
+	}
+	st, err := b.statusNode.ShhExtService()
+	switch err {
+	case node.ErrServiceUnknown:
+	case nil:
+		if err := st.CloseProtocol(); err != nil {
+			return err
+		}
+		st.SetKeyPairID("")
+		st.SetWipeHandler(nil)
+		st.SetPasswordVerifier(nil)
+	default:
+		return err
+	}
+
+	statusService, err := b.statusNode.StatusService()
+	switch err {
+	case node.ErrServiceUnknown:
+	case nil:
+		if err := statusService.ScheduleBackups("", "", 0); err != nil && err != status.ErrSchedulerUnavailable {
+			return err
+		}
+	default:
+		return err
+	}
+	return nil
+}
+
+// AddKeyReference adds an account whose key is kept by the platform keystore, given the ID
+// of the key in the keystore and its public key. The client is requested to sign a challenge
+// with the key. It returns the address of the account.
+func (b *StatusBackend) AddKeyReference(keyID string, publicKey []byte) (string, error) {
+	return b.accountManager.AddKeyReference(keyID, publicKey)
+}
+
+// AddWatchOnlyAccount adds an account whose key is kept by an external device, like a
+// hardware wallet. It returns the address of the account.
+func (b *StatusBackend) AddWatchOnlyAccount(address string) (string, error) {
+	return b.accountManager.AddWatchOnlyAccount(address)
+}
+
+// SelectExternalAccount selects an account whose key is kept by the platform keystore, with no
+// Whisper identity: the chat protocol requires the private key for its key agreements and
+// to decrypt envelopes, and the signer only signs hashes. The chat APIs then fail with
+// shhext.ErrNoChatIdentity.
+func (b *StatusBackend) SelectExternalAccount(address string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.releaseAccount(); err != nil {
+		return err
+	}
+
+	whisperService, err := b.statusNode.WhisperService()
+	switch err {
+	case node.ErrServiceUnknown: // Whisper was never registered
+	case nil:
+		if err := whisperService.DeleteKeyPairs(); err != nil {
+			return fmt.Errorf("%s: %v", ErrWhisperClearIdentitiesFailure, err)
+		}
+		if err := restoreProfileIdentities(whisperService, b.accountManager.Profiles()); err != nil {
+			return ErrWhisperIdentityInjectionFailure
+		}
+	default:
+		return err
+	}
+	st, err := b.statusNode.ShhExtService()
+	switch err {
+	case node.ErrServiceUnknown:
+	case nil:
+		st.ClearKeyPairID()
+	default:
+		return err
+	}
+
+	return b.accountManager.SelectExternalAccount(address)
+}
+
+// SignHashResponse completes a sign-hash.request signal with the signature of the hash,
+// or with the error that prevented the client from signing it.
+func (b *StatusBackend) SignHashResponse(id string, signature []byte, errMessage string) error {
+	return b.signer.Respond(id, signature, errMessage)
+}
+
 // NotifyUsers sends push notifications to users.
 func (b *StatusBackend) NotifyUsers(dataPayloadJSON string, tokens ...string) error {
 	log.Debug("sending push notification")
@@ -464,7 +1181,7 @@ func appendIf(condition bool, services []gethnode.ServiceConstructor, service ge
 
 // CreateContactCode create or return the latest contact code
 func (b *StatusBackend) CreateContactCode() (string, error) {
-	selectedAccount, err := b.AccountManager().SelectedAccount()
+	identity, err := b.selectedIdentity()
 	if err != nil {
 		return "", err
 	}
@@ -474,7 +1191,7 @@ func (b *StatusBackend) CreateContactCode() (string, error) {
 		return "", err
 	}
 
-	bundle, err := st.GetBundle(selectedAccount.AccountKey.PrivateKey)
+	bundle, err := st.GetBundle(identity)
 	if err != nil {
 		return "", err
 	}
@@ -484,7 +1201,7 @@ func (b *StatusBackend) CreateContactCode() (string, error) {
 
 // ProcessContactCode process and adds the someone else's bundle
 func (b *StatusBackend) ProcessContactCode(contactCode string) error {
-	selectedAccount, err := b.AccountManager().SelectedAccount()
+	identity, err := b.selectedIdentity()
 	if err != nil {
 		return err
 	}
@@ -500,7 +1217,7 @@ func (b *StatusBackend) ProcessContactCode(contactCode string) error {
 		return err
 	}
 
-	if _, err := st.ProcessPublicBundle(selectedAccount.AccountKey.PrivateKey, bundle); err != nil {
+	if _, err := st.ProcessPublicBundle(identity, bundle); err != nil {
 		b.log.Error("error adding bundle", "err", err)
 		return err
 	}
@@ -530,12 +1247,22 @@ func (b *StatusBackend) SignGroupMembership(content string) (string, error) {
 		return "", err
 	}
 
-	return crypto.Sign(content, selectedAccount.AccountKey.PrivateKey)
+	return crypto.SignWith(content, selectedAccount.SignHash)
+}
+
+// selectedIdentity returns the chat key of the selected account, required by the chat protocol.
+// It isn't available if the key is kept by the platform keystore.
+func (b *StatusBackend) selectedIdentity() (*ecdsa.PrivateKey, error) {
+	selectedAccount, err := b.AccountManager().SelectedAccount()
+	if err != nil {
+		return nil, err
+	}
+	return selectedAccount.ChatPrivateKey()
 }
 
 // EnableInstallation enables an installation for multi-device sync.
 func (b *StatusBackend) EnableInstallation(installationID string) error {
-	selectedAccount, err := b.AccountManager().SelectedAccount()
+	identity, err := b.selectedIdentity()
 	if err != nil {
 		return err
 	}
@@ -545,7 +1272,7 @@ func (b *StatusBackend) EnableInstallation(installationID string) error {
 		return err
 	}
 
-	if err := st.EnableInstallation(&selectedAccount.AccountKey.PrivateKey.PublicKey, installationID); err != nil {
+	if err := st.EnableInstallation(&identity.PublicKey, installationID); err != nil {
 		b.log.Error("error enabling installation", "err", err)
 		return err
 	}
@@ -555,7 +1282,7 @@ func (b *StatusBackend) EnableInstallation(installationID string) error {
 
 // DisableInstallation disables an installation for multi-device sync.
 func (b *StatusBackend) DisableInstallation(installationID string) error {
-	selectedAccount, err := b.AccountManager().SelectedAccount()
+	identity, err := b.selectedIdentity()
 	if err != nil {
 		return err
 	}
@@ -565,7 +1292,7 @@ func (b *StatusBackend) DisableInstallation(installationID string) error {
 		return err
 	}
 
-	if err := st.DisableInstallation(&selectedAccount.AccountKey.PrivateKey.PublicKey, installationID); err != nil {
+	if err := st.DisableInstallation(&identity.PublicKey, installationID); err != nil {
 		b.log.Error("error disabling installation", "err", err)
 		return err
 	}
@@ -573,6 +1300,55 @@ func (b *StatusBackend) DisableInstallation(installationID string) error {
 	return nil
 }
 
+// CleanupInstallations deletes the state of installations disabled for longer than olderThan.
+func (b *StatusBackend) CleanupInstallations(olderThan time.Duration) (*chat.InstallationsCleanup, error) {
+	identity, err := b.selectedIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := b.statusNode.ShhExtService()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := st.CleanupInstallations(&identity.PublicKey, olderThan)
+	if err != nil {
+		b.log.Error("error cleaning up installations", "err", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// CreateBackup returns a backup of the identity key, contacts, installations and bundles
+// of the selected account, encrypted with passphrase. password must decrypt the key of the account.
+func (b *StatusBackend) CreateBackup(passphrase, password string) ([]byte, error) {
+	st, err := b.statusNode.StatusService()
+	if err != nil {
+		return nil, err
+	}
+
+	return st.CreateBackup(passphrase, password)
+}
+
+// RestoreBackup restores a backup encrypted with passphrase on this installation, imports its
+// key protected with password and selects its account. It returns the address of the account.
+func (b *StatusBackend) RestoreBackup(backup []byte, passphrase, password string) (string, error) {
+	st, err := b.statusNode.StatusService()
+	if err != nil {
+		return "", err
+	}
+
+	address, err := st.RestoreBackup(backup, passphrase, password)
+	if err != nil {
+		b.log.Error("error restoring backup", "err", err)
+		return "", err
+	}
+
+	return address, nil
+}
+
 // UpdateMailservers on ShhExtService.
 func (b *StatusBackend) UpdateMailservers(enodes []string) error {
 	st, err := b.statusNode.ShhExtService()
@@ -6,6 +6,10 @@ import (
 	"sync"
 	"testing"
 
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/account"
 	"github.com/status-im/status-go/node"
 	"github.com/status-im/status-go/params"
 	"github.com/status-im/status-go/rpc"
@@ -180,6 +184,107 @@ func TestBackendAccountsConcurrently(t *testing.T) {
 	wg.Wait()
 }
 
+func TestBackendSwitchAccount(t *testing.T) {
+	backend := NewStatusBackend()
+	config, err := utils.MakeTestNodeConfig(params.StatusChainNetworkID)
+	require.NoError(t, err)
+	require.NoError(t, backend.StartNode(config))
+	defer func() {
+		require.NoError(t, backend.StopNode())
+	}()
+
+	first, _, _, err := backend.AccountManager().CreateAccount("first-password")
+	require.NoError(t, err)
+	second, _, _, err := backend.AccountManager().CreateAccount("second-password")
+	require.NoError(t, err)
+	require.NoError(t, backend.SelectAccount(first, "first-password"))
+	self := backend.StatusNode().Server().Self()
+
+	require.Error(t, backend.SelectAccount(second, "wrong-password"))
+	selected, err := backend.AccountManager().SelectedAccount()
+	require.NoError(t, err)
+	require.Equal(t, first, selected.Address.Hex(), "The account is kept if the new one can't be selected")
+
+	require.NoError(t, backend.SelectAccount(second, "second-password"))
+	require.True(t, backend.IsNodeRunning())
+	require.Equal(t, self, backend.StatusNode().Server().Self(), "The node isn't restarted")
+
+	keys, err := backend.AccountManager().AccountKeys(second)
+	require.NoError(t, err)
+	whisperService, err := backend.StatusNode().WhisperService()
+	require.NoError(t, err)
+	identity, err := whisperService.GetPrivateKey(whisperService.SelectedKeyPairID())
+	require.NoError(t, err)
+	require.Equal(t, keys.Chat.Address, crypto.PubkeyToAddress(identity.PublicKey), "Whisper is re-keyed with the chat key")
+}
+
+func TestBackendProfiles(t *testing.T) {
+	backend := NewStatusBackend()
+	config, err := utils.MakeTestNodeConfig(params.StatusChainNetworkID)
+	require.NoError(t, err)
+	config.PFSEnabled = true
+	config.InstallationID = "test-installation"
+	require.NoError(t, backend.StartNode(config))
+	defer func() {
+		require.NoError(t, backend.StopNode())
+	}()
+
+	first, _, _, err := backend.AccountManager().CreateAccount("first-password")
+	require.NoError(t, err)
+	second, _, _, err := backend.AccountManager().CreateAccount("second-password")
+	require.NoError(t, err)
+	require.NoError(t, backend.SelectAccount(first, "first-password"))
+
+	_, err = backend.LoginProfile(second, "wrong-password")
+	require.Error(t, err)
+	namespace, err := backend.LoginProfile(second, "second-password")
+	require.NoError(t, err)
+	require.Equal(t, ProfileNamespace(gethcommon.HexToAddress(second)), namespace)
+
+	alice, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	alicePublicKey := hexutil.Encode(crypto.FromECDSAPub(&alice.PublicKey))
+	response := backend.CallRPC(`{"jsonrpc":"2.0","id":1,"method":"` + namespace + `_shhext_addContact","params":["` + alicePublicKey + `","alice"]}`)
+	require.Contains(t, response, `"name":"alice"`)
+	response = backend.CallRPC(`{"jsonrpc":"2.0","id":1,"method":"` + namespace + `_shhext_getContacts","params":[]}`)
+	require.Contains(t, response, `"name":"alice"`)
+	response = backend.CallRPC(`{"jsonrpc":"2.0","id":1,"method":"shhext_getContacts","params":[]}`)
+	require.NotContains(t, response, `"name":"alice"`, "Each profile has its own chat database")
+
+	profiles := backend.AccountManager().Profiles()
+	require.Len(t, profiles, 1)
+	whisperService, err := backend.StatusNode().WhisperService()
+	require.NoError(t, err)
+	identity, err := whisperService.AddKeyPair(profiles[0].ChatKey.PrivateKey)
+	require.NoError(t, err)
+
+	require.NoError(t, backend.SelectAccount(first, "first-password"))
+	require.True(t, whisperService.HasKeyPair(identity), "Identities of profiles are kept when the account is selected again")
+
+	require.NoError(t, backend.SelectAccount(second, "second-password"))
+	require.Empty(t, backend.AccountManager().Profiles(), "Profiles are logged out when they are selected")
+	response = backend.CallRPC(`{"jsonrpc":"2.0","id":1,"method":"` + namespace + `_shhext_getContacts","params":[]}`)
+	require.Contains(t, response, `"error"`)
+
+	_, err = backend.LoginProfile(first, "first-password")
+	require.NoError(t, err)
+	require.NoError(t, backend.Logout())
+	require.Empty(t, backend.AccountManager().Profiles())
+}
+
+func TestBackendKeyStoreKDF(t *testing.T) {
+	backend := NewStatusBackend()
+	config, err := utils.MakeTestNodeConfig(params.StatusChainNetworkID)
+	require.NoError(t, err)
+	config.KeyStoreKDF = params.KDFConfig{Name: params.KDFArgon2id, Argon2Memory: 1024}
+
+	require.NoError(t, backend.StartNode(config))
+	defer func() {
+		require.NoError(t, backend.StopNode())
+	}()
+	require.Equal(t, account.Argon2idKDF(account.Argon2idTime, 1024, account.Argon2idThreads), backend.AccountManager().KDF())
+}
+
 func TestBackendConnectionChangesConcurrently(t *testing.T) {
 	connections := [...]string{wifi, cellular, unknown}
 	backend := NewStatusBackend()
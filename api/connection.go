@@ -48,22 +48,24 @@ const (
 	connectionWifi                    // WIFI or iOS simulator
 )
 
+// String formats connectionType as reported by the mobile framework. Implements Stringer.
+func (c connectionType) String() string {
+	switch c {
+	case connectionWifi:
+		return wifi
+	case connectionCellular:
+		return cellular
+	}
+	return unknown
+}
+
 // string formats ConnectionState for logs. Implements Stringer.
 func (c connectionState) String() string {
 	if c.Offline {
 		return offline
 	}
 
-	var typ string
-	switch c.Type {
-	case connectionWifi:
-		typ = wifi
-	case connectionCellular:
-		typ = cellular
-	default:
-		typ = unknown
-	}
-
+	typ := c.Type.String()
 	if c.Expensive {
 		return fmt.Sprintf("%s (expensive)", typ)
 	}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"strings"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	whisper "github.com/status-im/whisper/whisperv6"
+
+	"github.com/status-im/status-go/account"
+	"github.com/status-im/status-go/rpc"
+	"github.com/status-im/status-go/services/shhext"
+)
+
+// ProfileNamespace returns the RPC namespace of a profile. The methods of the shh,
+// shhext and chat APIs of the profile are called by prefixing their names with the
+// namespace and an underscore, like profile0a1b..._shhext_sendDirectMessage.
+func ProfileNamespace(address gethcommon.Address) string {
+	return "profile" + strings.ToLower(strings.TrimPrefix(address.Hex(), "0x"))
+}
+
+// LoginProfile logs in an account alongside the selected account, as a profile with
+// its own Whisper identity, filters, chat database and RPC namespace, which it returns.
+// Profiles are logged out with the selected account, or when the node is stopped.
+func (b *StatusBackend) LoginProfile(address, password string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	whisperService, err := b.statusNode.WhisperService()
+	if err != nil {
+		return "", err
+	}
+	st, err := b.statusNode.ShhExtService()
+	if err != nil {
+		return "", err
+	}
+	profile, err := b.accountManager.LoginProfile(address, password)
+	if err != nil {
+		return "", err
+	}
+	service, err := st.OpenProfile(address, password, profile.ChatKey.PrivateKey)
+	if err != nil {
+		_ = b.accountManager.LogoutProfile(address)
+		return "", err
+	}
+
+	server := gethrpc.NewServer()
+	apis := append(service.APIs(), gethrpc.API{Namespace: whisper.ProtocolName, Service: whisper.NewPublicWhisperAPI(whisperService)})
+	for _, api := range apis {
+		if err = server.RegisterName(api.Namespace, api.Service); err != nil {
+			break
+		}
+	}
+	if err != nil {
+		server.Stop()
+		_ = st.CloseProfile(address)
+		_ = b.accountManager.LogoutProfile(address)
+		return "", err
+	}
+
+	namespace := ProfileNamespace(profile.Address)
+	for _, client := range b.rpcClients() {
+		client.RegisterNamespace(namespace, gethrpc.DialInProc(server))
+	}
+	if b.profiles == nil {
+		b.profiles = make(map[gethcommon.Address]*gethrpc.Server)
+	}
+	b.profiles[profile.Address] = server
+	b.log.Info("Profile logged in", "address", profile.Address.Hex(), "namespace", namespace)
+	return namespace, nil
+}
+
+// LogoutProfile logs out an account logged in with LoginProfile.
+func (b *StatusBackend) LogoutProfile(address string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.logoutProfile(gethcommon.HexToAddress(address))
+}
+
+// logoutProfile must be called with the lock held.
+func (b *StatusBackend) logoutProfile(address gethcommon.Address) error {
+	if server, ok := b.profiles[address]; ok {
+		namespace := ProfileNamespace(address)
+		for _, client := range b.rpcClients() {
+			client.UnregisterNamespace(namespace)
+		}
+		server.Stop()
+		delete(b.profiles, address)
+	}
+
+	if st, err := b.statusNode.ShhExtService(); err == nil {
+		if err := st.CloseProfile(address.Hex()); err != nil && err != shhext.ErrProfileNotFound {
+			return err
+		}
+	}
+	return b.accountManager.LogoutProfile(address.Hex())
+}
+
+// logoutProfiles logs out all the profiles. It must be called with the lock held.
+func (b *StatusBackend) logoutProfiles() error {
+	for _, profile := range b.accountManager.Profiles() {
+		if err := b.logoutProfile(profile.Address); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreProfileIdentities adds the chat identities of the profiles to Whisper again,
+// once the identities of the selected account replaced them.
+func restoreProfileIdentities(w *whisper.Whisper, profiles []*account.SelectedExtKey) error {
+	for _, profile := range profiles {
+		if _, err := w.AddKeyPair(profile.ChatKey.PrivateKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rpcClients returns the RPC clients of the running node.
+func (b *StatusBackend) rpcClients() []*rpc.Client {
+	var clients []*rpc.Client
+	for _, client := range []*rpc.Client{b.statusNode.RPCClient(), b.statusNode.RPCPrivateClient()} {
+		if client != nil {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
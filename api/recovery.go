@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/status-im/status-go/account"
+	"github.com/status-im/status-go/node"
+	"github.com/status-im/status-go/rpc"
+	"github.com/status-im/status-go/services/shhext"
+)
+
+// recoveryScanTimeout is how long a scan of the keys of a mnemonic can take.
+const recoveryScanTimeout = 5 * time.Minute
+
+// RecoverAccountWithScan derives the wallet keys of a mnemonic on the common derivation
+// paths, and returns those which were used on-chain, after the default key, see
+// account.ScanMnemonic. The accounts are not imported: the default one is recovered with
+// RecoverAccount, or RecoverLegacyAccount if its chat identity is its wallet key, the others
+// with RecoverAccountAtPath.
+func (b *StatusBackend) RecoverAccountWithScan(mnemonic string, lookahead int) ([]account.RecoveryCandidate, error) {
+	client := b.statusNode.RPCClient()
+	if client == nil {
+		return nil, node.ErrNoRunningNode
+	}
+	checker := &recoveryChecker{rpc: client, log: b.log}
+	if st, err := b.statusNode.ShhExtService(); err == nil {
+		checker.shhext = st
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), recoveryScanTimeout)
+	defer cancel()
+	return account.ScanMnemonic(ctx, mnemonic, lookahead, checker)
+}
+
+// recoveryChecker checks the activity of the keys of a mnemonic with the RPC client of
+// the node, and looks up chat bundles with the Whisper extension if it is enabled.
+type recoveryChecker struct {
+	rpc    *rpc.Client
+	shhext *shhext.Service
+	log    log.Logger
+}
+
+func (c *recoveryChecker) OnChain(ctx context.Context, address gethcommon.Address) (bool, error) {
+	var nonce hexutil.Uint64
+	if err := c.rpc.CallContext(ctx, &nonce, "eth_getTransactionCount", address, "latest"); err != nil {
+		return false, err
+	}
+	if nonce > 0 {
+		return true, nil
+	}
+	var balance hexutil.Big
+	if err := c.rpc.CallContext(ctx, &balance, "eth_getBalance", address, "latest"); err != nil {
+		return false, err
+	}
+	return balance.ToInt().Sign() > 0, nil
+}
+
+// ChatBundle returns false if the bundle can't be looked up, for instance because PFS
+// is disabled or the identity was looked up recently.
+func (c *recoveryChecker) ChatBundle(ctx context.Context, identity *ecdsa.PublicKey) (bool, error) {
+	if c.shhext == nil {
+		return false, nil
+	}
+	found, err := c.shhext.HasBundle(ctx, identity)
+	if err != nil {
+		c.log.Warn("unable to look up the chat bundle of a recovered account", "err", err)
+		return false, nil
+	}
+	return found, nil
+}
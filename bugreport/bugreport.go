@@ -0,0 +1,204 @@
+package bugreport
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto/ecies"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/status-im/status-go/params"
+)
+
+// Redacted replaces the values of the secrets in the config of the bug reports.
+const Redacted = "REDACTED"
+
+// secretSuffixes are the suffixes of the lowercased names of the config fields holding
+// secrets, like NodeKey and MailServerPassword.
+var secretSuffixes = []string{"key", "password", "secret", "token"}
+
+// Report is the content of a bug report.
+type Report struct {
+	Time      time.Time
+	Version   string
+	Config    *params.NodeConfig
+	Peers     []*p2p.PeerInfo
+	Integrity map[string]IntegrityResult
+	Logs      []string
+}
+
+// IntegrityResult is the result of the integrity check of a database.
+type IntegrityResult struct {
+	Results []string `json:"results,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// NewReport returns a new report of a node with config, at the current time.
+func NewReport(config *params.NodeConfig) *Report {
+	return &Report{
+		Time:      time.Now(),
+		Version:   params.Version,
+		Config:    config,
+		Peers:     []*p2p.PeerInfo{},
+		Integrity: map[string]IntegrityResult{},
+	}
+}
+
+// AddIntegrityCheck records the results of the integrity check of the database name.
+func (r *Report) AddIntegrityCheck(name string, results []string, err error) {
+	result := IntegrityResult{Results: results}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	r.Integrity[name] = result
+}
+
+// Archive returns the report as a tar.gz archive of report.json, with the time and the
+// version, config.json, with the secrets redacted, peers.json, integrity.json and logs.txt.
+func (r *Report) Archive() ([]byte, error) {
+	config, err := RedactConfig(r.Config)
+	if err != nil {
+		return nil, err
+	}
+	files := []struct {
+		name  string
+		value interface{}
+	}{
+		{"report.json", map[string]interface{}{"time": r.Time.UTC(), "version": r.Version}},
+		{"config.json", config},
+		{"peers.json", r.Peers},
+		{"integrity.json", r.Integrity},
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(gz)
+	for _, file := range files {
+		data, err := json.MarshalIndent(file.value, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := writeFile(archive, file.name, data, r.Time); err != nil {
+			return nil, err
+		}
+	}
+	logs := strings.Join(r.Logs, "")
+	if err := writeFile(archive, "logs.txt", []byte(logs), r.Time); err != nil {
+		return nil, err
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeFile(archive *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := archive.Write(data)
+	return err
+}
+
+// WriteFile writes the archive of the report to path, encrypted for the public key of the
+// recipient, so that it can be attached to a public issue.
+func (r *Report) WriteFile(path string, key *ecdsa.PublicKey) error {
+	archive, err := r.Archive()
+	if err != nil {
+		return err
+	}
+	data, err := Encrypt(archive, key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// Encrypt encrypts data with ECIES for the public key of the recipient.
+func Encrypt(data []byte, key *ecdsa.PublicKey) ([]byte, error) {
+	return ecies.Encrypt(rand.Reader, ecies.ImportECDSAPublic(key), data, nil, nil)
+}
+
+// Decrypt decrypts data encrypted by Encrypt with the private key of the recipient.
+func Decrypt(data []byte, key *ecdsa.PrivateKey) ([]byte, error) {
+	return ecies.ImportECDSA(key).Decrypt(data, nil, nil)
+}
+
+// RedactConfig returns the fields of config, as in its JSON encoding, with the values of
+// the secrets replaced by Redacted. URLs and URIs are reduced to their scheme and host, as
+// their user, path and query may hold passwords and API keys.
+func RedactConfig(config *params.NodeConfig) (map[string]interface{}, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	redactFields(fields)
+	return fields, nil
+}
+
+func redactFields(fields map[string]interface{}) {
+	for name, value := range fields {
+		fields[name] = redact(name, value)
+	}
+}
+
+func redact(name string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redactFields(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = redact(name, v[i])
+		}
+	case string:
+		if v == "" {
+			return v
+		}
+		name = strings.ToLower(name)
+		for _, suffix := range secretSuffixes {
+			if strings.HasSuffix(name, suffix) {
+				return Redacted
+			}
+		}
+		if strings.Contains(name, "url") || strings.HasSuffix(name, "uri") {
+			return redactURL(v)
+		}
+	}
+	return value
+}
+
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return Redacted
+	}
+	if u.User == nil && (u.Path == "" || u.Path == "/") && u.RawQuery == "" {
+		return raw
+	}
+	return u.Scheme + "://" + u.Host + "/" + Redacted
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/status-im/status-go/devtools"
+)
+
+var (
+	defaults = devtools.DefaultConfig()
+
+	dataDir        = flag.String("datadir", "", "Directory of the chat databases, the BackupDisabledDataDir of the node")
+	keyStoreDir    = flag.String("keystore", "", "Directory of the keystore of the node")
+	installationID = flag.String("installation-id", "", "Installation ID of the node")
+	password       = flag.String("password", defaults.Password, "Password of the accounts")
+	seed           = flag.Int64("seed", defaults.Seed, "Seed of the random generator")
+	accounts       = flag.Int("accounts", defaults.Accounts, "Number of accounts")
+	contacts       = flag.Int("contacts", defaults.Contacts, "Number of contacts of each account")
+	sessions       = flag.Int("sessions", defaults.Sessions, "Number of contacts of each account with a ratchet session")
+	publicChats    = flag.Int("public-chats", defaults.PublicChats, "Number of public chats of each account")
+	directChats    = flag.Int("direct-chats", defaults.DirectChats, "Number of 1:1 chats of each account")
+	messages       = flag.Int("messages", defaults.Messages, "Number of messages of each account")
+	distribution   = flag.String("distribution", string(defaults.Distribution), "Distribution of the messages across chats, uniform or zipf")
+	zipfS          = flag.Float64("zipf-s", defaults.ZipfS, "Exponent of the zipf distribution, greater than 1")
+	minWords       = flag.Int("min-words", defaults.MinWords, "Minimum number of words of messages")
+	maxWords       = flag.Int("max-words", defaults.MaxWords, "Maximum number of words of messages")
+	outgoing       = flag.Float64("outgoing", defaults.OutgoingRatio, "Ratio of messages sent by the accounts")
+	replies        = flag.Float64("replies", defaults.ReplyRatio, "Ratio of messages replying to another message")
+	span           = flag.Duration("span", defaults.Span, "Messages are sent in the span before the end")
+	end            = flag.Int64("end", 0, "Time of the last messages, in seconds since epoch, the current time if not set")
+)
+
+func main() {
+	flag.Parse()
+
+	config := devtools.Config{
+		DataDir:        *dataDir,
+		KeyStoreDir:    *keyStoreDir,
+		InstallationID: *installationID,
+		Password:       *password,
+		Seed:           *seed,
+		Accounts:       *accounts,
+		Contacts:       *contacts,
+		Sessions:       *sessions,
+		PublicChats:    *publicChats,
+		DirectChats:    *directChats,
+		Messages:       *messages,
+		Distribution:   devtools.Distribution(*distribution),
+		ZipfS:          *zipfS,
+		MinWords:       *minWords,
+		MaxWords:       *maxWords,
+		OutgoingRatio:  *outgoing,
+		ReplyRatio:     *replies,
+		Span:           *span,
+	}
+	if *end > 0 {
+		config.End = time.Unix(*end, 0)
+	}
+	if err := config.Validate(); err != nil {
+		log.Print(err)
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	seeded, err := devtools.Seed(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(seeded); err != nil {
+		log.Fatal(err)
+	}
+}
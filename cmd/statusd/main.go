@@ -18,6 +18,7 @@ import (
 	"github.com/status-im/status-go/api"
 	"github.com/status-im/status-go/logutils"
 	nodemetrics "github.com/status-im/status-go/metrics/node"
+	"github.com/status-im/status-go/metrics/prometheus"
 	"github.com/status-im/status-go/node"
 	"github.com/status-im/status-go/params"
 	"github.com/status-im/status-go/profiling"
@@ -45,6 +46,7 @@ var (
 	dataDir    = flag.String("dir", getDefaultDataDir(), "Directory used by node to store data")
 	register   = flag.Bool("register", false, "Register and make the node discoverable by other nodes")
 	mailserver = flag.Bool("mailserver", false, "Enable Mail Server with default configuration")
+	chatOnly   = flag.Bool("chat-only", false, "Run a chat-only node, without Ethereum services")
 	networkID  = flag.Int(
 		"network-id",
 		params.RopstenNetworkID,
@@ -55,7 +57,8 @@ var (
 	)
 
 	// don't change the name of this flag, https://github.com/ethereum/go-ethereum/blob/master/metrics/metrics.go#L41
-	metrics = flag.Bool("metrics", false, "Expose ethereum metrics with debug_metrics jsonrpc call")
+	metrics     = flag.Bool("metrics", false, "Expose ethereum metrics with debug_metrics jsonrpc call")
+	metricsAddr = flag.String("metrics-addr", "", "Address of the HTTP endpoint exporting the metrics in the Prometheus format, like 127.0.0.1:9305, requires -metrics")
 
 	syncAndExit = flag.Int("sync-and-exit", -1, "Timeout in minutes for blockchain sync and exit, zero means no timeout unless sync is finished")
 )
@@ -86,6 +89,9 @@ func main() {
 	if *mailserver {
 		opts = append(opts, params.WithMailserver())
 	}
+	if *chatOnly {
+		opts = append(opts, params.WithChatOnly())
+	}
 
 	config, err := params.NewNodeConfigWithDefaultsAndFiles(
 		*dataDir,
@@ -145,6 +151,9 @@ func main() {
 	if *metrics || gethmetrics.Enabled {
 		go startCollectingNodeMetrics(interruptCh, backend.StatusNode())
 		go gethmetrics.CollectProcessMetrics(3 * time.Second)
+		if *metricsAddr != "" {
+			prometheus.NewServer(*metricsAddr, gethmetrics.DefaultRegistry).Go()
+		}
 	}
 
 	// Sync blockchain and stop.
@@ -181,12 +190,9 @@ func setupLogging(config *params.NodeConfig) {
 	}
 
 	colors := !(*logWithoutColors) && terminal.IsTerminal(int(os.Stdin.Fd()))
-	if err := logutils.OverrideRootLog(
-		logEnabled(config),
-		config.LogLevel,
-		config.LogFile,
-		colors,
-	); err != nil {
+	logConfig := *config
+	logConfig.LogEnabled = logEnabled(config)
+	if err := logutils.OverrideRootLogWithConfig(&logConfig, colors); err != nil {
 		stdlog.Fatalf("Error initializing logger: %v", err)
 	}
 }
@@ -278,6 +284,8 @@ Examples:
   statusd -c ./default.json                      # run node with configuration specified in ./default.json file
   statusd -c ./default.json -c ./standalone.json # run node with configuration specified in ./default.json file, after merging ./standalone.json file
   statusd -c ./default.json -metrics             # run node with configuration specified in ./default.json file, and expose ethereum metrics with debug_metrics jsonrpc call
+  statusd -metrics -metrics-addr 127.0.0.1:9305  # run node and export the metrics in the Prometheus format at http://127.0.0.1:9305/metrics
+  statusd -chat-only                             # run lightweight node with only the chat and account services
 
 Options:
 `
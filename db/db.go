@@ -20,6 +20,35 @@ const (
 	DeduplicatorCache
 	// MailserversCache is a list of mail servers provided by users.
 	MailserversCache
+	// EncryptedStoresVersions is used for the cipher and KDF versions
+	// of encrypted stores and the progress of their re-encryption.
+	EncryptedStoresVersions
+	// MailserverRequestsCache is used for the fingerprints of recently
+	// issued requests for historic messages.
+	MailserverRequestsCache
+	// ContractABIs is used for the contract ABIs added by users and dapps.
+	ContractABIs
+	// EnvelopesRetryQueue is used for the messages whose envelopes failed
+	// to be delivered and are scheduled for a retry.
+	EnvelopesRetryQueue
+	// MailserversRanking is used for the latency and availability of mail
+	// servers measured by the mail server picker.
+	MailserversRanking
+	// PeerStats is used for the hourly statistics of the messages exchanged
+	// with each peer.
+	PeerStats
+	// ScheduledJobs is used for the results of the last runs of the jobs
+	// of the scheduler.
+	ScheduledJobs
+	// MailserversTrust is used for the trust scores of mail servers, lowered
+	// by the invalid envelopes they return.
+	MailserversTrust
+	// HealthChecks is used for the results of the last runs of the health
+	// checks of the subsystems of the node.
+	HealthChecks
+	// BandwidthStats is used for the hourly traffic of the Whisper protocol
+	// with each peer and on each topic.
+	BandwidthStats
 )
 
 // Key creates a DB key for a specified service with specified data
@@ -0,0 +1,451 @@
+package devtools
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/golang/protobuf/proto"
+
+	"github.com/status-im/status-go/account"
+	"github.com/status-im/status-go/extkeys"
+	"github.com/status-im/status-go/services/shhext"
+	"github.com/status-im/status-go/services/shhext/chat"
+	"github.com/status-im/status-go/services/shhext/contacts"
+	"github.com/status-im/status-go/services/shhext/content"
+	"github.com/status-im/status-go/services/shhext/history"
+)
+
+const (
+	// messageTypeDirect and messageTypePublic are the message types of the chat messages
+	// sent in 1:1 chats and public chats.
+	messageTypeDirect = "~:user-message"
+	messageTypePublic = "~:public-group-user-message"
+	// contactsInstallationID is the installation of the contacts with ratchet sessions.
+	contactsInstallationID = "devtools"
+)
+
+var (
+	// ErrInvalidConfig is returned when the counts or ratios of the config are out of range.
+	ErrInvalidConfig = errors.New("invalid seed config")
+	// ErrUnknownDistribution is returned for a distribution other than uniform and zipf.
+	ErrUnknownDistribution = errors.New("unknown distribution")
+)
+
+// Distribution is how messages are spread across chats.
+type Distribution string
+
+const (
+	// DistributionUniform gives the same probability to each chat.
+	DistributionUniform Distribution = "uniform"
+	// DistributionZipf makes a few chats much busier than the others, like real chats,
+	// with an exponent set by ZipfS.
+	DistributionZipf Distribution = "zipf"
+)
+
+// Config of the synthetic data. The accounts, contacts, chats and messages seeded twice
+// with the same config are identical, but for the keys of the ratchet sessions.
+type Config struct {
+	// DataDir is the directory of the chat databases, the BackupDisabledDataDir of the node.
+	DataDir string
+	// KeyStoreDir is the directory of the keystore of the node.
+	KeyStoreDir    string
+	InstallationID string
+	// Password of the accounts.
+	Password string
+	// Seed of the random generator.
+	Seed int64
+
+	Accounts int
+	// Contacts of each account.
+	Contacts int
+	// Sessions is the number of contacts of each account with a ratchet session.
+	Sessions int
+	// PublicChats and DirectChats of each account. 1:1 chats are with contacts.
+	PublicChats int
+	DirectChats int
+	// Messages of each account, spread across chats with Distribution.
+	Messages     int
+	Distribution Distribution
+	// ZipfS is the exponent of DistributionZipf, greater than 1.
+	ZipfS float64
+	// MinWords and MaxWords bound the number of words of messages, uniformly distributed.
+	MinWords int
+	MaxWords int
+	// OutgoingRatio is the ratio of messages sent by the account.
+	OutgoingRatio float64
+	// ReplyRatio is the ratio of messages replying to a previous message of the chat.
+	ReplyRatio float64
+	// Messages are sent in the Span before End. End is the current time if not set.
+	Span time.Duration
+	End  time.Time
+}
+
+// DefaultConfig returns a config seeding an account with a few hundred contacts and
+// thousands of messages, most of them in a few chats.
+func DefaultConfig() Config {
+	return Config{
+		Password:      "password",
+		Seed:          1,
+		Accounts:      1,
+		Contacts:      200,
+		Sessions:      50,
+		PublicChats:   10,
+		DirectChats:   40,
+		Messages:      5000,
+		Distribution:  DistributionZipf,
+		ZipfS:         1.2,
+		MinWords:      1,
+		MaxWords:      30,
+		OutgoingRatio: 0.3,
+		ReplyRatio:    0.1,
+		Span:          30 * 24 * time.Hour,
+	}
+}
+
+// Validate returns an error if the config can't be seeded.
+func (c Config) Validate() error {
+	switch {
+	case c.DataDir == "" || c.KeyStoreDir == "" || c.InstallationID == "":
+		return fmt.Errorf("%v: data dir, keystore dir and installation ID are required", ErrInvalidConfig)
+	case c.Accounts < 0 || c.Contacts < 0 || c.PublicChats < 0 || c.Messages < 0:
+		return fmt.Errorf("%v: negative count", ErrInvalidConfig)
+	case c.Sessions < 0 || c.Sessions > c.Contacts || c.DirectChats < 0 || c.DirectChats > c.Contacts:
+		return fmt.Errorf("%v: sessions and direct chats must be between 0 and the number of contacts", ErrInvalidConfig)
+	case c.Messages > 0 && c.PublicChats+c.DirectChats == 0:
+		return fmt.Errorf("%v: messages require chats", ErrInvalidConfig)
+	case c.MinWords < 1 || c.MaxWords < c.MinWords:
+		return fmt.Errorf("%v: words must be between 1 and max words", ErrInvalidConfig)
+	case c.OutgoingRatio < 0 || c.OutgoingRatio > 1 || c.ReplyRatio < 0 || c.ReplyRatio > 1:
+		return fmt.Errorf("%v: ratios must be between 0 and 1", ErrInvalidConfig)
+	case c.Distribution == DistributionZipf && c.ZipfS <= 1:
+		return fmt.Errorf("%v: zipf exponent must be greater than 1", ErrInvalidConfig)
+	case c.Distribution != DistributionUniform && c.Distribution != DistributionZipf:
+		return ErrUnknownDistribution
+	case c.Span <= 0:
+		return fmt.Errorf("%v: span must be positive", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// Account is an account seeded with Seed. It is logged in with the password of the config,
+// and recovered with its mnemonic.
+type Account struct {
+	Address       string `json:"address"`
+	ChatPublicKey string `json:"chatPublicKey"`
+	Mnemonic      string `json:"mnemonic"`
+	Contacts      int    `json:"contacts"`
+	Sessions      int    `json:"sessions"`
+	// Chats are the IDs of the chats in the history, with their number of messages.
+	Chats    map[string]int `json:"chats"`
+	Messages int            `json:"messages"`
+}
+
+// Seed creates the accounts of the config in the keystore, and fills their chat databases
+// with contacts, ratchet sessions and chats. The node must be stopped.
+func Seed(config Config) ([]Account, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.End.IsZero() {
+		config.End = time.Now()
+	}
+	if err := os.MkdirAll(config.DataDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	manager := account.NewManager(keyStoreProvider{keystore.NewKeyStore(config.KeyStoreDir, keystore.LightScryptN, keystore.LightScryptP)})
+
+	// The contacts with ratchet sessions have their bundles in a temporary database.
+	tmpDir, err := ioutil.TempDir("", "devtools")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir) // nolint: errcheck
+	contactsDB, err := chat.NewSQLLitePersistence(filepath.Join(tmpDir, "contacts.db"), "devtools", chat.DefaultPersistenceConfig())
+	if err != nil {
+		return nil, err
+	}
+	defer contactsDB.Close() // nolint: errcheck
+
+	words, err := extkeys.NewMnemonic().WordList(extkeys.EnglishLanguage)
+	if err != nil {
+		return nil, err
+	}
+	s := &seeder{
+		config:     config,
+		rng:        rand.New(rand.NewSource(config.Seed)),
+		words:      words,
+		manager:    manager,
+		contactsES: chat.NewEncryptionService(contactsDB, chat.DefaultEncryptionServiceConfig(contactsInstallationID)),
+	}
+	seeded := make([]Account, 0, config.Accounts)
+	for i := 0; i < config.Accounts; i++ {
+		acc, err := s.seedAccount()
+		if err != nil {
+			return nil, err
+		}
+		log.Info("seeded account", "address", acc.Address, "contacts", acc.Contacts, "chats", len(acc.Chats), "messages", acc.Messages)
+		seeded = append(seeded, *acc)
+	}
+	return seeded, nil
+}
+
+// keyStoreProvider provides the keystore to the account manager without a node.
+type keyStoreProvider struct {
+	keyStore *keystore.KeyStore
+}
+
+func (p keyStoreProvider) AccountManager() (*accounts.Manager, error) {
+	return nil, errors.New("no account manager without a node")
+}
+
+func (p keyStoreProvider) AccountKeyStore() (*keystore.KeyStore, error) {
+	return p.keyStore, nil
+}
+
+type seeder struct {
+	config     Config
+	rng        *rand.Rand
+	words      *extkeys.WordList
+	manager    *account.Manager
+	contactsES *chat.EncryptionService
+}
+
+// contact is a synthetic contact of an account.
+type contact struct {
+	key  *ecdsa.PrivateKey
+	name string
+}
+
+// seedChat is a chat of an account with the IDs of its messages.
+type seedChat struct {
+	id       string
+	direct   *contact
+	messages []string
+}
+
+func (s *seeder) seedAccount() (*Account, error) {
+	mnemonic := s.phrase(12)
+	address, _, err := s.manager.RecoverAccount(s.config.Password, mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	master, err := extkeys.NewMaster(extkeys.NewMnemonic().MnemonicSeed(mnemonic, ""))
+	if err != nil {
+		return nil, err
+	}
+	chatKey, err := master.ChildForPurpose(extkeys.KeyPurposeChat, 0)
+	if err != nil {
+		return nil, err
+	}
+	// The key of the extended key is on the curve of btcec, which ECIES doesn't support.
+	identity, err := crypto.ToECDSA(crypto.FromECDSA(chatKey.ToECDSA()))
+	if err != nil {
+		return nil, err
+	}
+
+	dbKey, err := chat.DBKey(s.config.Password, chat.DefaultDBVersion.KDF)
+	if err != nil {
+		return nil, err
+	}
+	persistence, err := chat.NewSQLLitePersistence(shhext.ChatDBPath(s.config.DataDir, s.config.InstallationID, address), dbKey, chat.DefaultPersistenceConfig())
+	if err != nil {
+		return nil, err
+	}
+	defer persistence.Close() // nolint: errcheck
+
+	acc := &Account{
+		Address:       address,
+		ChatPublicKey: hexutil.Encode(crypto.FromECDSAPub(&identity.PublicKey)),
+		Mnemonic:      mnemonic,
+		Chats:         make(map[string]int),
+	}
+	contactList, err := s.seedContacts(persistence)
+	if err != nil {
+		return nil, err
+	}
+	acc.Contacts = len(contactList)
+	if acc.Sessions, err = s.seedSessions(persistence, identity, contactList); err != nil {
+		return nil, err
+	}
+
+	chats := make([]*seedChat, 0, s.config.PublicChats+s.config.DirectChats)
+	for i := 0; i < s.config.PublicChats; i++ {
+		chats = append(chats, &seedChat{id: history.PublicChatID(chat.ChatTopic(fmt.Sprintf("devtools-%d", i)))})
+	}
+	for _, i := range s.rng.Perm(len(contactList))[:s.config.DirectChats] {
+		c := contactList[i]
+		chats = append(chats, &seedChat{id: history.DirectChatID(crypto.FromECDSAPub(&c.key.PublicKey)), direct: c})
+	}
+	if err := s.seedMessages(persistence, identity, contactList, chats); err != nil {
+		return nil, err
+	}
+	for _, c := range chats {
+		if len(c.messages) > 0 {
+			acc.Chats[c.id] = len(c.messages)
+			acc.Messages += len(c.messages)
+		}
+	}
+	return acc, nil
+}
+
+func (s *seeder) seedContacts(persistence *chat.SQLLitePersistence) ([]*contact, error) {
+	contactList := make([]*contact, 0, s.config.Contacts)
+	for i := 0; i < s.config.Contacts; i++ {
+		key, err := s.key()
+		if err != nil {
+			return nil, err
+		}
+		c := &contact{key: key, name: strings.Title(s.phrase(2))}
+		err = persistence.SaveContact(contacts.Contact{
+			PublicKey: hexutil.Encode(crypto.FromECDSAPub(&key.PublicKey)),
+			Name:      c.name,
+		})
+		if err != nil {
+			return nil, err
+		}
+		contactList = append(contactList, c)
+	}
+	return contactList, nil
+}
+
+// seedSessions creates the bundle of the account, and establishes ratchet sessions with
+// the first contacts by encrypting a message for them.
+func (s *seeder) seedSessions(persistence *chat.SQLLitePersistence, identity *ecdsa.PrivateKey, contactList []*contact) (int, error) {
+	es := chat.NewEncryptionService(persistence, chat.DefaultEncryptionServiceConfig(s.config.InstallationID))
+	if _, err := es.CreateBundle(identity); err != nil {
+		return 0, err
+	}
+	for _, c := range contactList[:s.config.Sessions] {
+		bundle, err := s.contactsES.CreateBundle(c.key)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := es.ProcessPublicBundle(identity, bundle); err != nil {
+			return 0, err
+		}
+		if _, err := es.EncryptPayload(&c.key.PublicKey, identity, []byte("hello")); err != nil {
+			return 0, err
+		}
+	}
+	return s.config.Sessions, nil
+}
+
+// seedMessages spreads the messages across the chats, sends them at random times of the
+// span, and stores them in the history ordered by time in each chat.
+func (s *seeder) seedMessages(persistence *chat.SQLLitePersistence, identity *ecdsa.PrivateKey, contactList []*contact, chats []*seedChat) error {
+	if s.config.Messages == 0 {
+		return nil
+	}
+	pick := s.picker(len(chats))
+	counts := make([]int, len(chats))
+	for i := 0; i < s.config.Messages; i++ {
+		counts[pick()]++
+	}
+
+	start := s.config.End.Add(-s.config.Span)
+	for i, c := range chats {
+		times := make([]int64, counts[i])
+		for j := range times {
+			times[j] = start.UnixNano() + s.rng.Int63n(int64(s.config.Span))
+		}
+		sort.Slice(times, func(a, b int) bool { return times[a] < times[b] })
+
+		for _, t := range times {
+			author := &identity.PublicKey
+			outgoing := s.rng.Float64() < s.config.OutgoingRatio
+			if !outgoing {
+				sender := c.direct
+				if sender == nil {
+					sender = contactList[s.rng.Intn(len(contactList))]
+				}
+				author = &sender.key.PublicKey
+			}
+			message, err := s.message(c, author, t)
+			if err != nil {
+				return err
+			}
+			message.Outgoing = outgoing
+			if err := persistence.SaveHistoryMessage(message); err != nil {
+				return err
+			}
+			c.messages = append(c.messages, message.ID)
+		}
+	}
+	return nil
+}
+
+// message returns a chat message of a chat sent at t, in nanoseconds.
+func (s *seeder) message(c *seedChat, author *ecdsa.PublicKey, t int64) (history.Message, error) {
+	words := s.config.MinWords + s.rng.Intn(s.config.MaxWords-s.config.MinWords+1)
+	payload := &chat.ChatMessagePayload{
+		Content:     s.phrase(words),
+		ContentType: "text/plain",
+		MessageType: messageTypePublic,
+		ClockValue:  float64(t / int64(time.Millisecond)),
+	}
+	if c.direct != nil {
+		payload.MessageType = messageTypeDirect
+	}
+	if len(c.messages) > 0 && s.rng.Float64() < s.config.ReplyRatio {
+		payload.ReplyTo = c.messages[s.rng.Intn(len(c.messages))]
+	}
+	data, err := proto.Marshal(payload)
+	if err != nil {
+		return history.Message{}, err
+	}
+	authorBytes := crypto.FromECDSAPub(author)
+	return history.Message{
+		ID:          content.MessageID(authorBytes, data),
+		ChatID:      c.id,
+		Author:      hexutil.Encode(authorBytes),
+		Content:     payload.Content,
+		ContentType: payload.ContentType,
+		MessageType: payload.MessageType,
+		ReplyTo:     payload.ReplyTo,
+		Clock:       uint64(payload.ClockValue),
+		Timestamp:   uint32(t / int64(time.Second)),
+	}, nil
+}
+
+// picker returns a function picking an index lower than n with the distribution of the config.
+func (s *seeder) picker(n int) func() int {
+	if s.config.Distribution == DistributionZipf && n > 1 {
+		zipf := rand.NewZipf(s.rng, s.config.ZipfS, 1, uint64(n-1))
+		return func() int { return int(zipf.Uint64()) }
+	}
+	return func() int { return s.rng.Intn(n) }
+}
+
+// key returns a private key generated with the random generator of the seeder.
+func (s *seeder) key() (*ecdsa.PrivateKey, error) {
+	for {
+		b := make([]byte, 32)
+		s.rng.Read(b) // nolint: gosec
+		key, err := crypto.ToECDSA(b)
+		if err == nil {
+			return key, nil
+		}
+	}
+}
+
+// phrase returns words of the English mnemonic word list.
+func (s *seeder) phrase(words int) string {
+	phrase := make([]string, words)
+	for i := range phrase {
+		phrase[i] = s.words[s.rng.Intn(len(s.words))]
+	}
+	return strings.Join(phrase, " ")
+}
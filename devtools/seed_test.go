@@ -0,0 +1,102 @@
+package devtools
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/status-im/status-go/services/shhext"
+	"github.com/status-im/status-go/services/shhext/chat"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig(t *testing.T) (Config, func()) {
+	dir, err := ioutil.TempDir("", "devtools-test")
+	require.NoError(t, err)
+	config := DefaultConfig()
+	config.DataDir = filepath.Join(dir, "data")
+	config.KeyStoreDir = filepath.Join(dir, "keystore")
+	config.InstallationID = "test-installation"
+	config.Contacts = 20
+	config.Sessions = 3
+	config.PublicChats = 2
+	config.DirectChats = 5
+	config.Messages = 300
+	config.End = time.Unix(1546300800, 0)
+	return config, func() { os.RemoveAll(dir) } // nolint: errcheck
+}
+
+func TestSeed(t *testing.T) {
+	config, cleanup := testConfig(t)
+	defer cleanup()
+
+	accounts, err := Seed(config)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	seeded := accounts[0]
+	require.Equal(t, 20, seeded.Contacts)
+	require.Equal(t, 3, seeded.Sessions)
+	require.Equal(t, 300, seeded.Messages)
+	require.True(t, len(seeded.Chats) <= 7)
+
+	key, err := chat.DBKey(config.Password, chat.DefaultDBVersion.KDF)
+	require.NoError(t, err)
+	persistence, err := chat.NewSQLLitePersistence(shhext.ChatDBPath(config.DataDir, config.InstallationID, seeded.Address), key, chat.DefaultPersistenceConfig())
+	require.NoError(t, err)
+	defer persistence.Close() // nolint: errcheck
+
+	contactList, err := persistence.GetContacts()
+	require.NoError(t, err)
+	require.Len(t, contactList, 20)
+	chats, err := persistence.GetHistoryChats()
+	require.NoError(t, err)
+	require.Len(t, chats, len(seeded.Chats))
+	busiest, most := "", 0
+	for id, count := range seeded.Chats {
+		if count > most {
+			busiest, most = id, count
+		}
+	}
+	messages, err := persistence.GetHistoryMessages(busiest, nil, 500)
+	require.NoError(t, err)
+	require.Len(t, messages, most)
+	for i := 1; i < len(messages); i++ {
+		require.True(t, messages[i-1].Clock >= messages[i].Clock)
+	}
+	require.True(t, messages[0].Timestamp <= uint32(config.End.Unix()))
+	require.True(t, messages[len(messages)-1].Timestamp >= uint32(config.End.Add(-config.Span).Unix()))
+}
+
+func TestSeedDeterministic(t *testing.T) {
+	config, cleanup := testConfig(t)
+	defer cleanup()
+	other, otherCleanup := testConfig(t)
+	defer otherCleanup()
+
+	accounts, err := Seed(config)
+	require.NoError(t, err)
+	otherAccounts, err := Seed(other)
+	require.NoError(t, err)
+	require.Equal(t, accounts, otherAccounts)
+
+	other.Seed = 2
+	otherAccounts, err = Seed(other)
+	require.NoError(t, err)
+	require.NotEqual(t, accounts[0].Address, otherAccounts[0].Address)
+}
+
+func TestSeedInvalidConfig(t *testing.T) {
+	config, cleanup := testConfig(t)
+	defer cleanup()
+
+	config.Sessions = config.Contacts + 1
+	_, err := Seed(config)
+	require.Error(t, err)
+
+	config.Sessions = 0
+	config.Distribution = "normal"
+	_, err = Seed(config)
+	require.Equal(t, ErrUnknownDistribution, err)
+}
@@ -0,0 +1,361 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/discv5"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// DefaultFallbackPeriod is the search period of the mechanisms which are not preferred,
+// once the preferred one found the target number of peers of a topic.
+const DefaultFallbackPeriod = 30 * time.Second
+
+// ErrNoMechanismRunning is returned when none of the mechanisms of a DualStack is running.
+var ErrNoMechanismRunning = errors.New("no discovery mechanism is running")
+
+// Mechanism is a discovery run by a DualStack, like EthereumV5 or RendezvousV1.
+type Mechanism struct {
+	Name      string
+	Discovery Discovery
+}
+
+// Health is the health of a discovery mechanism.
+type Health struct {
+	Name    string `json:"name"`
+	Running bool   `json:"running"`
+	// Preferred is true for the mechanism the topic targets are checked against.
+	Preferred bool `json:"preferred"`
+	// Failures counts the failures to start, register or discover since a node was last found.
+	Failures  int    `json:"failures"`
+	LastError string `json:"lastError,omitempty"`
+	// Found counts the nodes found, including the nodes found again.
+	Found int `json:"found"`
+	// LastFound is the unix time a node was last found, in seconds.
+	LastFound int64 `json:"lastFound,omitempty"`
+}
+
+// DualStack runs several discovery mechanisms at the same time, like discovery v5 and
+// rendezvous, and keeps running as long as one of them does. The healthiest mechanism,
+// with the fewest failures, is preferred: once it found the target number of peers of
+// a topic, the other mechanisms search the topic at a slower rate. DualStack records
+// which mechanisms found each node.
+type DualStack struct {
+	mechanisms     []Mechanism
+	targets        map[string]int
+	fallbackPeriod time.Duration
+	now            func() time.Time
+
+	mu      sync.RWMutex
+	health  []Health
+	sources map[enode.ID][]string
+}
+
+// NewDualStack returns a DualStack of mechanisms, in order of preference when they are
+// equally healthy. targets are the number of peers to find per topic, topics without
+// a target are searched at the same rate by every mechanism.
+func NewDualStack(mechanisms []Mechanism, targets map[string]int) *DualStack {
+	health := make([]Health, len(mechanisms))
+	for i, m := range mechanisms {
+		health[i].Name = m.Name
+	}
+	return &DualStack{
+		mechanisms:     mechanisms,
+		targets:        targets,
+		fallbackPeriod: DefaultFallbackPeriod,
+		now:            time.Now,
+		health:         health,
+		sources:        make(map[enode.ID][]string),
+	}
+}
+
+// Running returns true if at least one mechanism is running.
+func (d *DualStack) Running() bool {
+	return len(d.running()) > 0
+}
+
+func (d *DualStack) running() (running []int) {
+	for i, m := range d.mechanisms {
+		if m.Discovery.Running() {
+			running = append(running, i)
+		}
+	}
+	return running
+}
+
+// Start starts the mechanisms which are not running. It fails only if none of them
+// runs afterwards. Mechanisms which failed are started again the next time it is called.
+func (d *DualStack) Start() error {
+	messages := []string{}
+	for i, m := range d.mechanisms {
+		if m.Discovery.Running() {
+			continue
+		}
+		if err := m.Discovery.Start(); err != nil {
+			log.Warn("failed to start discovery mechanism", "mechanism", m.Name, "error", err)
+			d.failed(i, err)
+			messages = append(messages, fmt.Sprintf("%s: %v", m.Name, err))
+		}
+	}
+	if !d.Running() {
+		return fmt.Errorf("%v: %s", ErrNoMechanismRunning, strings.Join(messages, "; "))
+	}
+	return nil
+}
+
+// Stop every mechanism.
+func (d *DualStack) Stop() error {
+	messages := []string{}
+	for _, m := range d.mechanisms {
+		if err := m.Discovery.Stop(); err != nil {
+			messages = append(messages, fmt.Sprintf("%s: %v", m.Name, err))
+		}
+	}
+	if len(messages) != 0 {
+		return fmt.Errorf("failed to stop discoveries: %s", strings.Join(messages, "; "))
+	}
+	return nil
+}
+
+// Register registers topic with every running mechanism and waits till they return,
+// when stop is closed. It fails only if every mechanism failed.
+func (d *DualStack) Register(topic string, stop chan struct{}) error {
+	running := d.running()
+	if len(running) == 0 {
+		return ErrNoMechanismRunning
+	}
+	errs := make(chan error, len(running))
+	for _, i := range running {
+		i := i
+		go func() {
+			err := d.mechanisms[i].Discovery.Register(topic, stop)
+			if err != nil {
+				d.failed(i, err)
+				err = fmt.Errorf("%s: %v", d.mechanisms[i].Name, err)
+			}
+			errs <- err
+		}()
+	}
+	messages := []string{}
+	for range running {
+		if err := <-errs; err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+	if len(messages) == len(running) {
+		return fmt.Errorf("failed to register %s: %s", topic, strings.Join(messages, "; "))
+	}
+	return nil
+}
+
+// Discover searches topic with every running mechanism and publishes the nodes they
+// find to found. The preferred mechanism searches at the period fetched from period,
+// as do the others until it found the target number of nodes of the topic. It blocks
+// until period is closed and fails only if every mechanism failed.
+func (d *DualStack) Discover(topic string, period <-chan time.Duration, found chan<- *discv5.Node, lookup chan<- bool) error {
+	type result struct {
+		i    int
+		node *discv5.Node
+		err  error
+		done bool
+	}
+
+	running := d.running()
+	var (
+		results  = make(chan result)
+		quit     = make(chan struct{})
+		periods  = make(map[int]chan time.Duration, len(running))
+		sent     = make(map[int]time.Duration, len(running))
+		seen     = make(map[int]map[discv5.NodeID]struct{}, len(running))
+		messages = []string{}
+	)
+	defer close(quit)
+	for _, i := range running {
+		i := i
+		p := make(chan time.Duration, 1)
+		periods[i] = p
+		seen[i] = make(map[discv5.NodeID]struct{})
+		nodes := make(chan *discv5.Node, 5)
+		go func() {
+			err := d.mechanisms[i].Discovery.Discover(topic, p, nodes, lookup)
+			select {
+			case results <- result{i: i, err: err, done: true}:
+			case <-quit:
+			}
+		}()
+		go func() {
+			for {
+				select {
+				case node := <-nodes:
+					select {
+					case results <- result{i: i, node: node}:
+					case <-quit:
+						return
+					}
+				case <-quit:
+					return
+				}
+			}
+		}()
+	}
+
+	var (
+		current   time.Duration
+		closed    bool
+		remaining = len(running)
+	)
+	update := func() {
+		if closed || current == 0 {
+			return
+		}
+		preferred := d.preferred()
+		target := d.targets[topic]
+		for i, c := range periods {
+			p := current
+			if i != preferred && target > 0 && len(seen[preferred]) >= target && p < d.fallbackPeriod {
+				p = d.fallbackPeriod
+			}
+			if sent[i] != p {
+				setPeriod(c, p)
+				sent[i] = p
+			}
+		}
+	}
+	handlePeriod := func(p time.Duration, ok bool) {
+		if !ok {
+			closed = true
+			period = nil
+			for _, c := range periods {
+				close(c)
+			}
+			return
+		}
+		current = p
+		update()
+	}
+
+	for !closed || remaining > 0 {
+		select {
+		case p, ok := <-period:
+			handlePeriod(p, ok)
+		case r := <-results:
+			if r.done {
+				remaining--
+				if r.err != nil {
+					d.failed(r.i, r.err)
+					messages = append(messages, fmt.Sprintf("%s: %v", d.mechanisms[r.i].Name, r.err))
+				}
+				update()
+				continue
+			}
+			seen[r.i][r.node.ID] = struct{}{}
+			d.found(r.i, r.node)
+			update()
+		forward:
+			// the consumer stops reading found before closing period
+			for !closed {
+				select {
+				case found <- r.node:
+					break forward
+				case p, ok := <-period:
+					handlePeriod(p, ok)
+				}
+			}
+		}
+	}
+
+	if len(running) == 0 {
+		return ErrNoMechanismRunning
+	}
+	if len(messages) == len(running) {
+		return fmt.Errorf("failed to discover topic %s: %s", topic, strings.Join(messages, "; "))
+	}
+	return nil
+}
+
+// setPeriod replaces the period pending in c, if any, with p.
+func setPeriod(c chan time.Duration, p time.Duration) {
+	for {
+		select {
+		case c <- p:
+			return
+		default:
+		}
+		select {
+		case <-c:
+		default:
+		}
+	}
+}
+
+// preferred returns the index of the running mechanism with the fewest failures, the
+// first one if several have as few, or -1 if none is running.
+func (d *DualStack) preferred() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	preferred := -1
+	for i, m := range d.mechanisms {
+		if !m.Discovery.Running() {
+			continue
+		}
+		if preferred == -1 || d.health[i].Failures < d.health[preferred].Failures {
+			preferred = i
+		}
+	}
+	return preferred
+}
+
+func (d *DualStack) failed(i int, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.health[i].Failures++
+	d.health[i].LastError = err.Error()
+}
+
+func (d *DualStack) found(i int, node *discv5.Node) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	h := &d.health[i]
+	h.Found++
+	h.LastFound = d.now().Unix()
+	h.Failures = 0
+
+	pk, err := node.ID.Pubkey()
+	if err != nil {
+		return
+	}
+	id := enode.PubkeyToIDV4(pk)
+	name := d.mechanisms[i].Name
+	for _, source := range d.sources[id] {
+		if source == name {
+			return
+		}
+	}
+	d.sources[id] = append(d.sources[id], name)
+}
+
+// Health returns the health of every mechanism.
+func (d *DualStack) Health() []Health {
+	preferred := d.preferred()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	health := make([]Health, len(d.health))
+	for i := range d.health {
+		health[i] = d.health[i]
+		health[i].Running = d.mechanisms[i].Discovery.Running()
+		health[i].Preferred = i == preferred
+	}
+	return health
+}
+
+// Sources returns the names of the mechanisms which found the node id.
+func (d *DualStack) Sources(id enode.ID) []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]string{}, d.sources[id]...)
+}
@@ -0,0 +1,164 @@
+package discovery
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/discv5"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/stretchr/testify/require"
+)
+
+// static finds the same nodes every time and records the periods it is given.
+type static struct {
+	fake
+	nodes []*discv5.Node
+
+	mu      sync.Mutex
+	periods []time.Duration
+}
+
+func (s *static) Discover(topic string, period <-chan time.Duration, found chan<- *discv5.Node, lookup chan<- bool) error {
+	if s.err != nil {
+		return s.err
+	}
+	for _, n := range s.nodes {
+		found <- n
+	}
+	for p := range period {
+		s.mu.Lock()
+		s.periods = append(s.periods, p)
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+func (s *static) lastPeriod() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.periods) == 0 {
+		return 0
+	}
+	return s.periods[len(s.periods)-1]
+}
+
+func newStaticNodes(t *testing.T, count int) []*discv5.Node {
+	nodes := make([]*discv5.Node, count)
+	for i := range nodes {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		nodes[i] = discv5.NewNode(discv5.PubkeyID(&key.PublicKey), net.IPv4(10, 0, 0, byte(i)), 30303, 30303)
+	}
+	return nodes
+}
+
+func waitPeriod(t *testing.T, s *static, expected time.Duration) {
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		if s.lastPeriod() == expected {
+			return
+		}
+	}
+	require.Equal(t, expected, s.lastPeriod())
+}
+
+func TestDualStackStartFallback(t *testing.T) {
+	failing := &fake{err: errors.New("unreachable")}
+	working := &fake{}
+	stack := NewDualStack([]Mechanism{{"first", failing}, {"second", working}}, nil)
+
+	require.NoError(t, stack.Start())
+	require.True(t, stack.Running())
+	health := stack.Health()
+	require.Equal(t, Health{Name: "first", Failures: 1, LastError: "unreachable"}, health[0])
+	require.Equal(t, Health{Name: "second", Running: true, Preferred: true}, health[1])
+
+	require.Error(t, NewDualStack([]Mechanism{{"first", &fake{err: errors.New("unreachable")}}}, nil).Start())
+}
+
+func TestDualStackRegisterFallback(t *testing.T) {
+	reg := newRegistry()
+	stack := NewDualStack([]Mechanism{
+		{"first", &fake{started: true, err: errors.New("test"), registry: reg}},
+		{"second", &fake{started: true, id: 1, registry: reg}},
+	}, nil)
+	require.NoError(t, stack.Register("a", nil))
+	require.Equal(t, []int{1}, reg.Get("a"))
+	require.Equal(t, 1, stack.Health()[0].Failures)
+	require.False(t, stack.Health()[0].Preferred, "the mechanism which failed is not preferred")
+}
+
+func TestDualStackDiscover(t *testing.T) {
+	nodes := newStaticNodes(t, 3)
+	preferred := &static{fake: fake{started: true}, nodes: nodes[:2]}
+	fallback := &static{fake: fake{started: true}, nodes: nodes[1:]}
+	stack := NewDualStack([]Mechanism{{EthereumV5, preferred}, {RendezvousV1, fallback}}, map[string]int{"a": 2})
+
+	period := make(chan time.Duration, 1)
+	found := make(chan *discv5.Node, 10)
+	errors := make(chan error, 1)
+	period <- time.Second
+	go func() {
+		errors <- stack.Discover("a", period, found, nil)
+	}()
+	for range nodes[:2] {
+		<-found
+	}
+	for range nodes[1:] {
+		<-found
+	}
+
+	waitPeriod(t, preferred, time.Second)
+	waitPeriod(t, fallback, DefaultFallbackPeriod)
+	close(period)
+	require.NoError(t, <-errors)
+
+	for i, expected := range [][]string{{EthereumV5}, {EthereumV5, RendezvousV1}, {RendezvousV1}} {
+		pk, err := nodes[i].ID.Pubkey()
+		require.NoError(t, err)
+		sources := stack.Sources(enode.PubkeyToIDV4(pk))
+		sort.Strings(sources)
+		require.Equal(t, expected, sources)
+	}
+	health := stack.Health()
+	require.Equal(t, 2, health[0].Found)
+	require.Equal(t, 2, health[1].Found)
+}
+
+func TestDualStackDiscoverWithoutTarget(t *testing.T) {
+	nodes := newStaticNodes(t, 2)
+	preferred := &static{fake: fake{started: true}, nodes: nodes}
+	fallback := &static{fake: fake{started: true}}
+	stack := NewDualStack([]Mechanism{{EthereumV5, preferred}, {RendezvousV1, fallback}}, nil)
+
+	period := make(chan time.Duration, 1)
+	found := make(chan *discv5.Node, 10)
+	errors := make(chan error, 1)
+	period <- time.Second
+	go func() {
+		errors <- stack.Discover("a", period, found, nil)
+	}()
+	for range nodes {
+		<-found
+	}
+
+	waitPeriod(t, fallback, time.Second)
+	close(period)
+	require.NoError(t, <-errors)
+}
+
+func TestDualStackDiscoverFailures(t *testing.T) {
+	failing := &static{fake: fake{started: true, err: errors.New("test")}}
+	stack := NewDualStack([]Mechanism{{EthereumV5, failing}}, nil)
+
+	period := make(chan time.Duration)
+	close(period)
+	require.Error(t, stack.Discover("a", period, make(chan *discv5.Node), nil))
+	require.Equal(t, 1, stack.Health()[0].Failures)
+
+	require.Equal(t, ErrNoMechanismRunning, NewDualStack(nil, nil).Discover("a", period, nil, nil))
+}
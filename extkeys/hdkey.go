@@ -8,6 +8,8 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"strconv"
+	"strings"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
@@ -95,6 +97,7 @@ var (
 	ErrDerivingChild              = errors.New("error deriving child key")
 	ErrInvalidMasterKey           = errors.New("invalid master key supplied")
 	ErrMaxDepthExceeded           = errors.New("max depth exceeded")
+	ErrInvalidPath                = errors.New("derivation path is invalid")
 )
 
 var (
@@ -338,6 +341,42 @@ func (k *ExtendedKey) Derive(path []uint32) (*ExtendedKey, error) {
 	return extKey, nil
 }
 
+// ParsePath parses a derivation path from the master key, like m/44'/60'/0'/0/0,
+// where hardened indices end with an apostrophe.
+func ParsePath(path string) ([]uint32, error) {
+	elements := strings.Split(path, "/")
+	if elements[0] != "m" {
+		return nil, ErrInvalidPath
+	}
+	indices := make([]uint32, 0, len(elements)-1)
+	for _, element := range elements[1:] {
+		hardened := strings.HasSuffix(element, "'")
+		index, err := strconv.ParseUint(strings.TrimSuffix(element, "'"), 10, 32)
+		if err != nil || index >= HardenedKeyStart {
+			return nil, ErrInvalidPath
+		}
+		if hardened {
+			index += HardenedKeyStart
+		}
+		indices = append(indices, uint32(index))
+	}
+	return indices, nil
+}
+
+// FormatPath returns the notation of a derivation path parsed by ParsePath.
+func FormatPath(path []uint32) string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, i := range path {
+		if i >= HardenedKeyStart {
+			fmt.Fprintf(&b, "/%d'", i-HardenedKeyStart)
+		} else {
+			fmt.Fprintf(&b, "/%d", i)
+		}
+	}
+	return b.String()
+}
+
 // Neuter returns a new extended public key from a give extended private key.
 // If the input extended key is already public, it will be returned unaltered.
 func (k *ExtendedKey) Neuter() (*ExtendedKey, error) {
@@ -681,6 +681,29 @@ func TestHDWalletCompatibility(t *testing.T) {
 	}
 }
 
+func TestDerivationPaths(t *testing.T) {
+	path, err := ParsePath("m/44'/60'/0'/0/3")
+	if err != nil {
+		t.Fatalf("couldn't parse derivation path: %v", err)
+	}
+	expected := append(append([]uint32(nil), EthBIP44ParentPath...), 3)
+	if !reflect.DeepEqual(path, expected) {
+		t.Errorf("wrong derivation path parsed. expected %v, got %v", expected, path)
+	}
+	if formatted := FormatPath(path); formatted != "m/44'/60'/0'/0/3" {
+		t.Errorf("wrong derivation path formatted: %s", formatted)
+	}
+	if formatted := FormatPath(nil); formatted != "m" {
+		t.Errorf("wrong master key path formatted: %s", formatted)
+	}
+
+	for _, invalid := range []string{"", "44'/60'", "m/", "m/x", "m/-1", "m/2147483648"} {
+		if _, err := ParsePath(invalid); err != ErrInvalidPath {
+			t.Errorf("invalid path %q parsed, err: %v", invalid, err)
+		}
+	}
+}
+
 // TestPrivateKeyDataWithLeadingZeros is a regression test that checks
 // we don't re-introduce a bug we had in the past.
 // For a specific mnemonic phrase, we were deriving a wrong key/address
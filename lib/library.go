@@ -6,8 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 	"unsafe"
 
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/status-im/status-go/api"
 	"github.com/status-im/status-go/logutils"
@@ -31,7 +34,7 @@ func StartNode(configJSON *C.char) *C.char {
 		return makeJSONResponse(err)
 	}
 
-	if err := logutils.OverrideRootLog(config.LogEnabled, config.LogLevel, config.LogFile, false); err != nil {
+	if err := logutils.OverrideRootLogWithConfig(config, false); err != nil {
 		return makeJSONResponse(err)
 	}
 
@@ -171,6 +174,66 @@ func DisableInstallation(installationID *C.char) *C.char {
 	return C.CString(string(data))
 }
 
+// CleanupInstallations deletes the state of installations disabled for longer than olderThan seconds.
+//export CleanupInstallations
+func CleanupInstallations(olderThan C.int) *C.char {
+	result, err := statusBackend.CleanupInstallations(time.Duration(olderThan) * time.Second)
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	return C.CString(string(data))
+}
+
+// CreateBackup returns a backup of the identity key, contacts, installations and bundles
+// of the selected account, encrypted with passphrase, once password is checked against
+// the key of the account.
+//export CreateBackup
+func CreateBackup(passphrase, password *C.char) *C.char {
+	backup, err := statusBackend.CreateBackup(C.GoString(passphrase), C.GoString(password))
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	data, err := json.Marshal(struct {
+		Backup hexutil.Bytes `json:"backup"`
+	}{Backup: backup})
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	return C.CString(string(data))
+}
+
+// RestoreBackup restores a backup created by CreateBackup on a new installation,
+// and logs in its account with password.
+//export RestoreBackup
+func RestoreBackup(backupHex, passphrase, password *C.char) *C.char {
+	backup, err := hexutil.Decode(C.GoString(backupHex))
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	address, err := statusBackend.RestoreBackup(backup, C.GoString(passphrase), C.GoString(password))
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	data, err := json.Marshal(struct {
+		Address string `json:"address"`
+	}{Address: address})
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	return C.CString(string(data))
+}
+
 //ValidateNodeConfig validates config for status node
 //export ValidateNodeConfig
 func ValidateNodeConfig(configJSON *C.char) *C.char {
@@ -235,6 +298,13 @@ func CallPrivateRPC(inputJSON *C.char) *C.char {
 	return C.CString(outputJSON)
 }
 
+//CallDappRPC calls public APIs via RPC on behalf of a dapp, given its origin
+//export CallDappRPC
+func CallDappRPC(origin, inputJSON *C.char) *C.char {
+	outputJSON := statusBackend.CallDappRPC(C.GoString(origin), C.GoString(inputJSON))
+	return C.CString(outputJSON)
+}
+
 //CreateAccount is equivalent to creating an account from the command line,
 // just modified to handle the function arg passing
 //export CreateAccount
@@ -253,6 +323,7 @@ func CreateAccount(password *C.char) *C.char {
 		Mnemonic: mnemonic,
 		Error:    errString,
 	}
+	setChatKey(&out)
 	outBytes, _ := json.Marshal(out)
 	return C.CString(string(outBytes))
 }
@@ -294,10 +365,101 @@ func RecoverAccount(password, mnemonic *C.char) *C.char {
 		Mnemonic: C.GoString(mnemonic),
 		Error:    errString,
 	}
+	setChatKey(&out)
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+// RecoverLegacyAccount recovers an account which uses its wallet key as its chat key, as
+// accounts created before the chat key was derived separately do.
+//export RecoverLegacyAccount
+func RecoverLegacyAccount(password, mnemonic *C.char) *C.char {
+	address, pubKey, err := statusBackend.AccountManager().RecoverLegacyAccount(C.GoString(password), C.GoString(mnemonic))
+
+	errString := ""
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		errString = err.Error()
+	}
+
+	out := AccountInfo{
+		Address:  address,
+		PubKey:   pubKey,
+		Mnemonic: C.GoString(mnemonic),
+		Error:    errString,
+	}
+	setChatKey(&out)
 	outBytes, _ := json.Marshal(out)
 	return C.CString(string(outBytes))
 }
 
+// RecoverAccountWithScan returns the wallet keys derived from a mnemonic which were used,
+// scanning each derivation path until lookahead consecutive keys are unused:
+// [{"path": "m/44'/60'/0'/0/0", "address": "0x...", "publicKey": "0x04...", "onChain": true, "chatBundle": true, "legacyChat": false}].
+//export RecoverAccountWithScan
+func RecoverAccountWithScan(mnemonic *C.char, lookahead C.int) *C.char {
+	candidates, err := statusBackend.RecoverAccountWithScan(C.GoString(mnemonic), int(lookahead))
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+	data, err := json.Marshal(candidates)
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+	return C.CString(string(data))
+}
+
+// RecoverAccountAtPath imports the wallet key derived from a mnemonic at a path returned
+// by RecoverAccountWithScan.
+//export RecoverAccountAtPath
+func RecoverAccountAtPath(password, mnemonic, path *C.char) *C.char {
+	address, pubKey, err := statusBackend.AccountManager().RecoverAccountAtPath(C.GoString(password), C.GoString(mnemonic), C.GoString(path))
+
+	errString := ""
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		errString = err.Error()
+	}
+
+	out := AccountInfo{
+		Address: address,
+		PubKey:  pubKey,
+		Error:   errString,
+	}
+	setChatKey(&out)
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+// setChatKey sets the chat key of the account of info, if it was created.
+func setChatKey(info *AccountInfo) {
+	if info.Error != "" {
+		return
+	}
+	keys, err := statusBackend.AccountManager().AccountKeys(info.Address)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	info.ChatAddress = keys.Chat.Address.Hex()
+	info.ChatPubKey = keys.Chat.PublicKey.String()
+}
+
+// AccountKeys returns the addresses and public keys of the wallet and chat keys of an account:
+// {"wallet": {"address": "0x...", "publicKey": "0x04..."}, "chat": {...}}.
+//export AccountKeys
+func AccountKeys(address *C.char) *C.char {
+	keys, err := statusBackend.AccountManager().AccountKeys(C.GoString(address))
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+	return C.CString(string(data))
+}
+
 //VerifyAccountPassword verifies account password
 //export VerifyAccountPassword
 func VerifyAccountPassword(keyStoreDir, address, password *C.char) *C.char {
@@ -306,13 +468,106 @@ func VerifyAccountPassword(keyStoreDir, address, password *C.char) *C.char {
 }
 
 //Login loads a key file (for a given address), tries to decrypt it using the password, to verify ownership
-// if verified, purges all the previous identities from Whisper, and injects verified key as shh identity
+// if verified, purges all the previous identities from Whisper, and injects verified key as shh identity.
+// It switches accounts without restarting the node if another account is logged in.
 //export Login
 func Login(address, password *C.char) *C.char {
 	err := statusBackend.SelectAccount(C.GoString(address), C.GoString(password))
 	return makeJSONResponse(err)
 }
 
+// AddKeyReference adds an account whose key is kept by the platform keystore, given the ID of the
+// key in the keystore and its hex-encoded public key. It returns {"address": "0x..."}.
+// A sign-hash.request signal asks to sign a challenge with the key.
+//export AddKeyReference
+func AddKeyReference(keyID, publicKeyHex *C.char) *C.char {
+	publicKey, err := hexutil.Decode(C.GoString(publicKeyHex))
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+	address, err := statusBackend.AddKeyReference(C.GoString(keyID), publicKey)
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	data, err := json.Marshal(struct {
+		Address string `json:"address"`
+	}{Address: address})
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	return C.CString(string(data))
+}
+
+// AddWatchOnlyAccount adds an account whose key is kept by an external device, like a
+// hardware wallet. It returns {"address": "0x..."}.
+//export AddWatchOnlyAccount
+func AddWatchOnlyAccount(address *C.char) *C.char {
+	added, err := statusBackend.AddWatchOnlyAccount(C.GoString(address))
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	data, err := json.Marshal(struct {
+		Address string `json:"address"`
+	}{Address: added})
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	return C.CString(string(data))
+}
+
+// LoginWithKeyReference selects an account whose key is kept by the platform keystore.
+// Its signatures are requested with sign-hash.request signals.
+//export LoginWithKeyReference
+func LoginWithKeyReference(address *C.char) *C.char {
+	err := statusBackend.SelectExternalAccount(C.GoString(address))
+	return makeJSONResponse(err)
+}
+
+// SignHashResponse responds to a sign-hash.request signal with the hex-encoded signature
+// of the hash, or with the error that prevented the client from signing it.
+//export SignHashResponse
+func SignHashResponse(id, signatureHex, errMessage *C.char) *C.char {
+	var signature []byte
+	if C.GoString(errMessage) == "" {
+		var err error
+		if signature, err = hexutil.Decode(C.GoString(signatureHex)); err != nil {
+			return makeJSONResponse(err)
+		}
+	}
+	err := statusBackend.SignHashResponse(C.GoString(id), signature, C.GoString(errMessage))
+	return makeJSONResponse(err)
+}
+
+// LoginProfile logs in an account alongside the selected account, as a profile. It returns
+// {"namespace": "profile..."}, the prefix of the RPC methods of the profile.
+//export LoginProfile
+func LoginProfile(address, password *C.char) *C.char {
+	namespace, err := statusBackend.LoginProfile(C.GoString(address), C.GoString(password))
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	data, err := json.Marshal(struct {
+		Namespace string `json:"namespace"`
+	}{Namespace: namespace})
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	return C.CString(string(data))
+}
+
+// LogoutProfile logs out an account logged in with LoginProfile.
+//export LogoutProfile
+func LogoutProfile(address *C.char) *C.char {
+	err := statusBackend.LogoutProfile(C.GoString(address))
+	return makeJSONResponse(err)
+}
+
 //Logout is equivalent to clearing whisper identities
 //export Logout
 func Logout() *C.char {
@@ -362,6 +617,95 @@ func SendTransaction(txArgsJSON, password *C.char) *C.char {
 	return C.CString(prepareJSONResponseWithCode(hash.String(), err, code))
 }
 
+// SendDappTransaction converts RPC args and calls backend.SendDappTransaction, failing
+// if the user didn't grant the dapp the permission to request transactions.
+//export SendDappTransaction
+func SendDappTransaction(origin, txArgsJSON, password *C.char) *C.char {
+	var params transactions.SendTxArgs
+	err := json.Unmarshal([]byte(C.GoString(txArgsJSON)), &params)
+	if err != nil {
+		return C.CString(prepareJSONResponseWithCode(nil, err, codeFailedParseParams))
+	}
+	hash, err := statusBackend.SendDappTransaction(C.GoString(origin), params, C.GoString(password))
+	code := codeUnknown
+	if c, ok := errToCodeMap[err]; ok {
+		code = c
+	}
+	return C.CString(prepareJSONResponseWithCode(hash.String(), err, code))
+}
+
+// SpeedUpTransaction replaces a pending transaction with the same one paying a higher gas price.
+//export SpeedUpTransaction
+func SpeedUpTransaction(txHash, gasPrice, password *C.char) *C.char {
+	price, err := hexutil.DecodeBig(C.GoString(gasPrice))
+	if err != nil {
+		return C.CString(prepareJSONResponseWithCode(nil, err, codeFailedParseParams))
+	}
+	hash, err := statusBackend.SpeedUpTransaction(gethcommon.HexToHash(C.GoString(txHash)), price, C.GoString(password))
+	return C.CString(prepareJSONResponse(hash.String(), err))
+}
+
+// CancelTransaction replaces a pending transaction with an empty transfer to the sender itself.
+//export CancelTransaction
+func CancelTransaction(txHash, password *C.char) *C.char {
+	hash, err := statusBackend.CancelTransaction(gethcommon.HexToHash(C.GoString(txHash)), C.GoString(password))
+	return C.CString(prepareJSONResponse(hash.String(), err))
+}
+
+// PrepareTransaction assembles a transaction of the selected account without signing it.
+// It returns the transaction with the hash and the RLP encoding to sign with an external device.
+//export PrepareTransaction
+func PrepareTransaction(txArgsJSON *C.char) *C.char {
+	var params transactions.SendTxArgs
+	err := json.Unmarshal([]byte(C.GoString(txArgsJSON)), &params)
+	if err != nil {
+		return C.CString(prepareJSONResponseWithCode(nil, err, codeFailedParseParams))
+	}
+	unsigned, err := statusBackend.PrepareTransaction(params)
+	code := codeUnknown
+	if c, ok := errToCodeMap[err]; ok {
+		code = c
+	}
+	return C.CString(prepareJSONResponseWithCode(unsigned, err, code))
+}
+
+// SendRawTransaction submits a transaction prepared with PrepareTransaction, given the
+// hex-encoded RLP of the signed transaction.
+//export SendRawTransaction
+func SendRawTransaction(signedTxHex *C.char) *C.char {
+	data, err := hexutil.Decode(C.GoString(signedTxHex))
+	if err != nil {
+		return C.CString(prepareJSONResponseWithCode(nil, err, codeFailedParseParams))
+	}
+	hash, err := statusBackend.SendRawTransaction(data)
+	code := codeUnknown
+	if c, ok := errToCodeMap[err]; ok {
+		code = c
+	}
+	return C.CString(prepareJSONResponseWithCode(hash.String(), err, code))
+}
+
+// UnsignedTransactions returns the transactions waiting for an external signature.
+//export UnsignedTransactions
+func UnsignedTransactions() *C.char {
+	return C.CString(prepareJSONResponse(statusBackend.UnsignedTransactions(), nil))
+}
+
+// DiscardTransaction removes a transaction waiting for an external signature.
+//export DiscardTransaction
+func DiscardTransaction(hash *C.char) *C.char {
+	err := statusBackend.DiscardTransaction(gethcommon.HexToHash(C.GoString(hash)))
+	return makeJSONResponse(err)
+}
+
+// TransactionReplacementStatus returns the transactions competing with a transaction
+// and the one that landed, if any.
+//export TransactionReplacementStatus
+func TransactionReplacementStatus(txHash *C.char) *C.char {
+	status, err := statusBackend.TransactionReplacementStatus(gethcommon.HexToHash(C.GoString(txHash)))
+	return C.CString(prepareJSONResponse(status, err))
+}
+
 // SignTypedData unmarshall data into TypedData, validate it and signs with selected account,
 // if password matches selected account.
 //export SignTypedData
@@ -374,7 +718,63 @@ func SignTypedData(data, password *C.char) *C.char {
 	if err := typed.Validate(); err != nil {
 		return C.CString(prepareJSONResponseWithCode(nil, err, codeFailedParseParams))
 	}
-	result, err := statusBackend.SignTypedData(typed, C.GoString(password))
+	result, err := statusBackend.SignTypedData(typed, typeddata.V3, C.GoString(password))
+	return C.CString(prepareJSONResponse(result.String(), err))
+}
+
+// SignTypedDataV4 is SignTypedData with the encoding of eth_signTypedData_v4, which
+// supports arrays.
+//export SignTypedDataV4
+func SignTypedDataV4(data, password *C.char) *C.char {
+	var typed typeddata.TypedData
+	err := json.Unmarshal([]byte(C.GoString(data)), &typed)
+	if err != nil {
+		return C.CString(prepareJSONResponseWithCode(nil, err, codeFailedParseParams))
+	}
+	if err := typed.Validate(); err != nil {
+		return C.CString(prepareJSONResponseWithCode(nil, err, codeFailedParseParams))
+	}
+	result, err := statusBackend.SignTypedData(typed, typeddata.V4, C.GoString(password))
+	return C.CString(prepareJSONResponse(result.String(), err))
+}
+
+// ApproveSignRequest signs typed data requested with eth_signTypedData_v3 or
+// eth_signTypedData_v4, once confirmed by the user with the password of the account.
+//export ApproveSignRequest
+func ApproveSignRequest(id, password *C.char) *C.char {
+	result, err := statusBackend.ApproveSignRequest(C.GoString(id), C.GoString(password))
+	return C.CString(prepareJSONResponse(result.String(), err))
+}
+
+// DiscardSignRequest discards a request to sign typed data.
+//export DiscardSignRequest
+func DiscardSignRequest(id *C.char) *C.char {
+	err := statusBackend.DiscardSignRequest(C.GoString(id))
+	return makeJSONResponse(err)
+}
+
+// PendingSignRequests returns the requests to sign typed data waiting for a confirmation.
+//export PendingSignRequests
+func PendingSignRequests() *C.char {
+	return C.CString(prepareJSONResponse(statusBackend.PendingSignRequests(), nil))
+}
+
+// SignDappTypedData is SignTypedData on behalf of a dapp, given the version of the encoding,
+// failing if the user didn't grant it the permission to request signatures of typed data.
+//export SignDappTypedData
+func SignDappTypedData(origin, version, data, password *C.char) *C.char {
+	if err := typeddata.Version(C.GoString(version)).Validate(); err != nil {
+		return C.CString(prepareJSONResponseWithCode(nil, err, codeFailedParseParams))
+	}
+	var typed typeddata.TypedData
+	err := json.Unmarshal([]byte(C.GoString(data)), &typed)
+	if err != nil {
+		return C.CString(prepareJSONResponseWithCode(nil, err, codeFailedParseParams))
+	}
+	if err := typed.Validate(); err != nil {
+		return C.CString(prepareJSONResponseWithCode(nil, err, codeFailedParseParams))
+	}
+	result, err := statusBackend.SignDappTypedData(C.GoString(origin), typed, typeddata.Version(C.GoString(version)), C.GoString(password))
 	return C.CString(prepareJSONResponse(result.String(), err))
 }
 
@@ -480,6 +880,13 @@ func ConnectionChange(typ *C.char, expensive C.int) {
 	statusBackend.ConnectionChange(C.GoString(typ), expensive == 1)
 }
 
+// ChargingStateChange handles changes of the charging state of the device,
+// as reported by the client.
+//export ChargingStateChange
+func ChargingStateChange(charging C.int) {
+	statusBackend.ChargingStateChange(charging == 1)
+}
+
 // AppStateChange handles app state changes (background/foreground).
 //export AppStateChange
 func AppStateChange(state *C.char) {
@@ -491,3 +898,16 @@ func AppStateChange(state *C.char) {
 func SetSignalEventCallback(cb unsafe.Pointer) {
 	signal.SetSignalEventCallback(cb)
 }
+
+// StartSignalBridge streams signals to the UNIX socket at path, with acknowledgment-based
+// flow control, instead of the callback set with SetSignalEventCallback.
+//export StartSignalBridge
+func StartSignalBridge(path *C.char) *C.char {
+	return makeJSONResponse(signal.StartBridge(C.GoString(path), signal.DefaultBridgeConfig()))
+}
+
+// StopSignalBridge stops streaming signals to the socket, they are sent to the callback again.
+//export StopSignalBridge
+func StopSignalBridge() *C.char {
+	return makeJSONResponse(signal.StopBridge())
+}
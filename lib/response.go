@@ -5,6 +5,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/status-im/status-go/account"
+	"github.com/status-im/status-go/services/permissions"
 	"github.com/status-im/status-go/transactions"
 )
 
@@ -17,12 +18,16 @@ const (
 	codeErrNoAccountSelected
 	codeErrInvalidTxSender
 	codeErrDecrypt
+	// dapp related codes
+	codeErrPermissionRequired
 )
 
 var errToCodeMap = map[error]int{
 	account.ErrNoAccountSelected:    codeErrNoAccountSelected,
 	transactions.ErrInvalidTxSender: codeErrInvalidTxSender,
 	keystore.ErrDecrypt:             codeErrDecrypt,
+
+	permissions.ErrPermissionRequired: codeErrPermissionRequired,
 }
 
 type jsonrpcSuccessfulResponse struct {
@@ -0,0 +1,135 @@
+package logutils
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ModuleKey is the key of the module in the context of the loggers of the modules.
+const ModuleKey = "module"
+
+// Modules whose log level can be set separately.
+const (
+	ModuleShhext     = "shhext"
+	ModuleChat       = "chat"
+	ModuleMailserver = "mailserver"
+	ModuleWallet     = "wallet"
+)
+
+// modules are the known modules.
+var modules = map[string]bool{
+	ModuleShhext:     true,
+	ModuleChat:       true,
+	ModuleMailserver: true,
+	ModuleWallet:     true,
+}
+
+// NewLogger returns the logger of a module, whose records are filtered by the level
+// of the module, with additional context.
+func NewLogger(module string, ctx ...interface{}) log.Logger {
+	return log.New(append([]interface{}{ModuleKey, module}, ctx...)...)
+}
+
+// levels are the log levels of the root logger and of the modules.
+var levels = &moduleLevels{root: log.LvlInfo, modules: map[string]log.Lvl{}}
+
+// moduleLevels filters the records by the level of their module, or by the level of
+// the root logger if their module has none.
+type moduleLevels struct {
+	mu      sync.RWMutex
+	root    log.Lvl
+	modules map[string]log.Lvl
+}
+
+func (l *moduleLevels) enabled(r *log.Record) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	level, ok := l.modules[moduleOf(r.Ctx)]
+	if !ok {
+		level = l.root
+	}
+	return r.Lvl <= level
+}
+
+// moduleOf returns the module of the context of a record, if any.
+func moduleOf(ctx []interface{}) string {
+	for i := 0; i+1 < len(ctx); i += 2 {
+		if ctx[i] == ModuleKey {
+			module, _ := ctx[i+1].(string)
+			return module
+		}
+	}
+	return ""
+}
+
+// SetLogLevel sets the log level of a module, or of the root logger if the module is
+// empty. The levels of the other modules are kept.
+func SetLogLevel(module, levelStr string) error {
+	level, err := log.LvlFromString(strings.ToLower(levelStr))
+	if err != nil {
+		return err
+	}
+	if module != "" && !modules[module] {
+		return fmt.Errorf("unknown module '%s'", module)
+	}
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	if module == "" {
+		levels.root = level
+	} else {
+		levels.modules[module] = level
+	}
+	return nil
+}
+
+// setLogLevels replaces the log levels of the root logger and of the modules.
+func setLogLevels(root string, moduleLevels map[string]string) error {
+	parsed := make(map[string]log.Lvl, len(moduleLevels))
+	for module, levelStr := range moduleLevels {
+		if !modules[module] {
+			return fmt.Errorf("unknown module '%s'", module)
+		}
+		level, err := log.LvlFromString(strings.ToLower(levelStr))
+		if err != nil {
+			return err
+		}
+		parsed[module] = level
+	}
+	if root == "" {
+		root = "INFO"
+	}
+	rootLevel, err := log.LvlFromString(strings.ToLower(root))
+	if err != nil {
+		return err
+	}
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	levels.root = rootLevel
+	levels.modules = parsed
+	return nil
+}
+
+// levelNames are the names of the log levels, as in the config.
+var levelNames = map[log.Lvl]string{
+	log.LvlCrit:  "CRIT",
+	log.LvlError: "ERROR",
+	log.LvlWarn:  "WARN",
+	log.LvlInfo:  "INFO",
+	log.LvlDebug: "DEBUG",
+	log.LvlTrace: "TRACE",
+}
+
+// LogLevels returns the log levels of the modules, and of the root logger with an empty
+// module.
+func LogLevels() map[string]string {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+	result := map[string]string{"": levelNames[levels.root]}
+	for module, level := range levels.modules {
+		result[module] = levelNames[level]
+	}
+	return result
+}
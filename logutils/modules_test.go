@@ -0,0 +1,32 @@
+package logutils
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModuleLevels(t *testing.T) {
+	defer func() { require.NoError(t, setLogLevels("", nil)) }()
+
+	require.NoError(t, setLogLevels("WARN", map[string]string{ModuleShhext: "DEBUG"}))
+	require.Equal(t, map[string]string{"": "WARN", ModuleShhext: "DEBUG"}, LogLevels())
+
+	debug := func(ctx ...interface{}) *log.Record {
+		return &log.Record{Lvl: log.LvlDebug, Ctx: ctx}
+	}
+	require.True(t, levels.enabled(debug(ModuleKey, ModuleShhext, "package", "status-go/services/shhext")))
+	require.False(t, levels.enabled(debug(ModuleKey, ModuleWallet)), "Modules without a level use the level of the root logger")
+	require.False(t, levels.enabled(debug("package", "status-go/node")))
+
+	require.NoError(t, SetLogLevel(ModuleWallet, "trace"))
+	require.NoError(t, SetLogLevel("", "ERROR"))
+	require.Equal(t, map[string]string{"": "ERROR", ModuleShhext: "DEBUG", ModuleWallet: "TRACE"}, LogLevels())
+	require.True(t, levels.enabled(debug(ModuleKey, ModuleWallet)))
+
+	require.EqualError(t, SetLogLevel("unknown", "DEBUG"), "unknown module 'unknown'")
+	require.Error(t, SetLogLevel(ModuleChat, "VERBOSE"))
+	require.EqualError(t, setLogLevels("INFO", map[string]string{"unknown": "DEBUG"}), "unknown module 'unknown'")
+	require.Equal(t, "ERROR", LogLevels()[""], "Invalid levels are not applied")
+}
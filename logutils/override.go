@@ -2,51 +2,65 @@ package logutils
 
 import (
 	"os"
-	"strings"
 
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/status-im/status-go/params"
 )
 
 // OverrideRootLog overrides root logger with file handler, if defined,
 // and log level (defaults to INFO).
 func OverrideRootLog(enabled bool, levelStr string, logFile string, terminal bool) error {
-	if !enabled {
+	return OverrideRootLogWithConfig(&params.NodeConfig{
+		LogEnabled: enabled,
+		LogLevel:   levelStr,
+		LogFile:    logFile,
+	}, terminal)
+}
+
+// OverrideRootLogWithConfig overrides root logger with the log settings of the config:
+// the file handler, if defined, the format, and the log levels of the root logger
+// (defaults to INFO) and of the modules.
+func OverrideRootLogWithConfig(config *params.NodeConfig, terminal bool) error {
+	if !config.LogEnabled {
 		disableRootLog()
 		return nil
 	}
 
-	return enableRootLog(levelStr, logFile, terminal)
+	return enableRootLog(config, terminal)
 }
 
 func disableRootLog() {
 	log.Root().SetHandler(log.DiscardHandler())
 }
 
-func enableRootLog(levelStr string, logFile string, terminal bool) error {
+func enableRootLog(config *params.NodeConfig, terminal bool) error {
 	var (
 		handler log.Handler
 		err     error
 	)
 
-	if logFile != "" {
-		handler, err = log.FileHandler(logFile, log.LogfmtFormat())
+	format := log.TerminalFormat(terminal)
+	if config.LogFile != "" {
+		format = log.LogfmtFormat()
+	}
+	if config.LogFormat == params.LogFormatJSON {
+		format = log.JSONFormat()
+	}
+
+	if config.LogFile != "" {
+		handler, err = log.FileHandler(config.LogFile, format)
 		if err != nil {
 			return err
 		}
 	} else {
-		handler = log.StreamHandler(os.Stderr, log.TerminalFormat(terminal))
-	}
-
-	if levelStr == "" {
-		levelStr = "INFO"
+		handler = log.StreamHandler(os.Stderr, format)
 	}
 
-	level, err := log.LvlFromString(strings.ToLower(levelStr))
-	if err != nil {
+	if err := setLogLevels(config.LogLevel, config.LogLevels); err != nil {
 		return err
 	}
 
-	filteredHandler := log.LvlFilterHandler(level, handler)
+	filteredHandler := log.FilterHandler(levels.enabled, log.MultiHandler(handler, recent))
 	log.Root().SetHandler(filteredHandler)
 
 	return nil
@@ -0,0 +1,58 @@
+package logutils
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// DefaultRecentLogs is the number of recent log records kept in memory for the bug reports.
+const DefaultRecentLogs = 1000
+
+// recent keeps the records written by the root logger, when it is enabled.
+var recent = newRecentHandler(DefaultRecentLogs)
+
+// recentHandler keeps the last records in logfmt, in a ring buffer.
+type recentHandler struct {
+	mu      sync.Mutex
+	format  log.Format
+	records []string
+	next    int
+	full    bool
+}
+
+func newRecentHandler(size int) *recentHandler {
+	return &recentHandler{
+		format:  log.LogfmtFormat(),
+		records: make([]string, size),
+	}
+}
+
+// Log implements log.Handler.
+func (h *recentHandler) Log(r *log.Record) error {
+	record := string(h.format.Format(r))
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records[h.next] = record
+	h.next++
+	if h.next == len(h.records) {
+		h.next = 0
+		h.full = true
+	}
+	return nil
+}
+
+func (h *recentHandler) all() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.full {
+		return append([]string(nil), h.records[:h.next]...)
+	}
+	return append(append([]string(nil), h.records[h.next:]...), h.records[:h.next]...)
+}
+
+// RecentLogs returns the last DefaultRecentLogs records written by the root logger in
+// logfmt, from the oldest to the newest. Records are only kept when the logs are enabled.
+func RecentLogs() []string {
+	return recent.all()
+}
@@ -0,0 +1,23 @@
+package logutils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentHandler(t *testing.T) {
+	h := newRecentHandler(3)
+	require.Empty(t, h.all())
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, h.Log(&log.Record{Lvl: log.LvlInfo, Msg: fmt.Sprintf("record %d", i)}))
+	}
+	records := h.all()
+	require.Len(t, records, 3, "Only the last records are kept")
+	for i, record := range records {
+		require.Contains(t, record, fmt.Sprintf("record %d", i+2))
+	}
+}
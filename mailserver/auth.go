@@ -0,0 +1,257 @@
+package mailserver
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/status-im/status-go/params"
+)
+
+// UnauthorizedCode starts the errors of the requests rejected by the Authorizer of the
+// mailserver, sent in the failure responses like "UNAUTHORIZED: credential expired".
+const UnauthorizedCode = "UNAUTHORIZED"
+
+var (
+	// ErrInvalidCredentialSignature is returned when a credential is not signed by its issuer.
+	ErrInvalidCredentialSignature = errors.New("credential is not signed by the issuer")
+)
+
+// UnauthorizedError is the error of a request rejected by the Authorizer of the mailserver.
+type UnauthorizedError struct {
+	Reason string
+}
+
+// Error returns the error sent in the failure response.
+func (e *UnauthorizedError) Error() string {
+	return fmt.Sprintf("%s: %s", UnauthorizedCode, e.Reason)
+}
+
+// Code returns UnauthorizedCode.
+func (e *UnauthorizedError) Code() string {
+	return UnauthorizedCode
+}
+
+// ParseUnauthorizedError parses the error of a failure response, returning false if the
+// request was not rejected by the Authorizer of the mailserver.
+func ParseUnauthorizedError(msg string) (*UnauthorizedError, bool) {
+	if !strings.HasPrefix(msg, UnauthorizedCode+": ") {
+		return nil, false
+	}
+	return &UnauthorizedError{Reason: strings.TrimPrefix(msg, UnauthorizedCode+": ")}, true
+}
+
+// Credential is issued by a service, like the payment service of a mailserver, to let a
+// client request the history until it expires. It is sent in the requests, which are
+// signed by the client, so that it can't be used by other clients.
+type Credential struct {
+	// Client is the compressed public key of the client.
+	Client []byte
+	// Expiry is the Unix time after which the credential is invalid.
+	Expiry uint64
+	// Signature is the signature of Client and Expiry by the issuer.
+	Signature []byte
+}
+
+// NewCredential returns a credential for client signed by issuer.
+func NewCredential(issuer *ecdsa.PrivateKey, client *ecdsa.PublicKey, expiry time.Time) (*Credential, error) {
+	c := &Credential{
+		Client: crypto.CompressPubkey(client),
+		Expiry: uint64(expiry.Unix()),
+	}
+	hash, err := c.signedHash()
+	if err != nil {
+		return nil, err
+	}
+	c.Signature, err = crypto.Sign(hash, issuer)
+	return c, err
+}
+
+// Issuer returns the public key the credential is signed with.
+func (c Credential) Issuer() (*ecdsa.PublicKey, error) {
+	hash, err := c.signedHash()
+	if err != nil {
+		return nil, err
+	}
+	issuer, err := crypto.SigToPub(hash, c.Signature)
+	if err != nil {
+		return nil, ErrInvalidCredentialSignature
+	}
+	return issuer, nil
+}
+
+func (c Credential) signedHash() ([]byte, error) {
+	data, err := rlp.EncodeToBytes([]interface{}{c.Client, c.Expiry})
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(data), nil
+}
+
+// Authorizer decides which clients can request the history of the mailserver, so that
+// operators can run private or paid mailservers. A custom one is set with
+// WMailServer.SetAuthorizer.
+type Authorizer interface {
+	// Authorize returns an *UnauthorizedError if client can't request the history.
+	// credential is nil if the request has none.
+	Authorize(client *ecdsa.PublicKey, credential *Credential) error
+}
+
+// PeerAuthorizer is implemented by the Authorizers which authorize the sync requests of
+// other mailservers. These requests are not signed, their peers are authorized by node ID.
+// Sync requests are rejected by the Authorizers which don't implement it.
+type PeerAuthorizer interface {
+	// AuthorizePeer returns an *UnauthorizedError if the peer can't sync the history.
+	AuthorizePeer(id enode.ID) error
+}
+
+// errPeerUnauthorized is returned for the sync requests of peers which are not authorized.
+var errPeerUnauthorized = &UnauthorizedError{Reason: "peer is not allowed"}
+
+// authorizePeer authorizes a sync request of the peer id with authorizer.
+func authorizePeer(authorizer Authorizer, id enode.ID) error {
+	if peerAuthorizer, ok := authorizer.(PeerAuthorizer); ok {
+		return peerAuthorizer.AuthorizePeer(id)
+	}
+	return errPeerUnauthorized
+}
+
+// Allowlist authorizes the clients and the peers with an allowed key.
+type Allowlist struct {
+	keys  map[common.Address]struct{}
+	nodes map[enode.ID]struct{}
+}
+
+// NewAllowlist returns an Allowlist of keys.
+func NewAllowlist(keys []*ecdsa.PublicKey) *Allowlist {
+	a := &Allowlist{
+		keys:  make(map[common.Address]struct{}, len(keys)),
+		nodes: make(map[enode.ID]struct{}, len(keys)),
+	}
+	for _, key := range keys {
+		a.keys[crypto.PubkeyToAddress(*key)] = struct{}{}
+		a.nodes[enode.PubkeyToIDV4(key)] = struct{}{}
+	}
+	return a
+}
+
+// AuthorizePeer implements PeerAuthorizer.
+func (a *Allowlist) AuthorizePeer(id enode.ID) error {
+	if _, ok := a.nodes[id]; !ok {
+		return errPeerUnauthorized
+	}
+	return nil
+}
+
+// Authorize implements Authorizer.
+func (a *Allowlist) Authorize(client *ecdsa.PublicKey, _ *Credential) error {
+	if _, ok := a.keys[crypto.PubkeyToAddress(*client)]; !ok {
+		return &UnauthorizedError{Reason: "client key is not allowed"}
+	}
+	return nil
+}
+
+// CredentialVerifier authorizes the clients with a valid credential of a trusted issuer.
+type CredentialVerifier struct {
+	issuers map[common.Address]struct{}
+	now     func() time.Time
+}
+
+// NewCredentialVerifier returns a CredentialVerifier trusting the credentials of issuers.
+func NewCredentialVerifier(issuers []*ecdsa.PublicKey) *CredentialVerifier {
+	v := &CredentialVerifier{issuers: make(map[common.Address]struct{}, len(issuers)), now: time.Now}
+	for _, issuer := range issuers {
+		v.issuers[crypto.PubkeyToAddress(*issuer)] = struct{}{}
+	}
+	return v
+}
+
+// Authorize implements Authorizer.
+func (v *CredentialVerifier) Authorize(client *ecdsa.PublicKey, credential *Credential) error {
+	if credential == nil {
+		return &UnauthorizedError{Reason: "credential required"}
+	}
+	issuer, err := credential.Issuer()
+	if err != nil {
+		return &UnauthorizedError{Reason: err.Error()}
+	}
+	if _, ok := v.issuers[crypto.PubkeyToAddress(*issuer)]; !ok {
+		return &UnauthorizedError{Reason: "credential issuer is not trusted"}
+	}
+	key, err := crypto.DecompressPubkey(credential.Client)
+	if err != nil || crypto.PubkeyToAddress(*key) != crypto.PubkeyToAddress(*client) {
+		return &UnauthorizedError{Reason: "credential is issued to another client"}
+	}
+	if uint64(v.now().Unix()) > credential.Expiry {
+		return &UnauthorizedError{Reason: "credential expired"}
+	}
+	return nil
+}
+
+// AnyAuthorizer authorizes the clients authorized by any of its authorizers. It returns
+// the error of the last one otherwise.
+type AnyAuthorizer []Authorizer
+
+// Authorize implements Authorizer.
+func (a AnyAuthorizer) Authorize(client *ecdsa.PublicKey, credential *Credential) (err error) {
+	for _, authorizer := range a {
+		if err = authorizer.Authorize(client, credential); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// AuthorizePeer implements PeerAuthorizer.
+func (a AnyAuthorizer) AuthorizePeer(id enode.ID) (err error) {
+	err = errPeerUnauthorized
+	for _, authorizer := range a {
+		if err = authorizePeer(authorizer, id); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// newAuthorizer returns the Authorizer of config, allowing MailServerAllowedKeys or the
+// clients with a credential of MailServerCredentialIssuers, or nil if both are empty.
+func newAuthorizer(config *params.WhisperConfig) (Authorizer, error) {
+	var authorizers AnyAuthorizer
+	if len(config.MailServerAllowedKeys) > 0 {
+		keys, err := parsePublicKeys(config.MailServerAllowedKeys)
+		if err != nil {
+			return nil, err
+		}
+		authorizers = append(authorizers, NewAllowlist(keys))
+	}
+	if len(config.MailServerCredentialIssuers) > 0 {
+		issuers, err := parsePublicKeys(config.MailServerCredentialIssuers)
+		if err != nil {
+			return nil, err
+		}
+		authorizers = append(authorizers, NewCredentialVerifier(issuers))
+	}
+	if len(authorizers) == 0 {
+		return nil, nil
+	}
+	return authorizers, nil
+}
+
+// parsePublicKeys parses hex-encoded uncompressed public keys.
+func parsePublicKeys(hexKeys []string) ([]*ecdsa.PublicKey, error) {
+	keys := make([]*ecdsa.PublicKey, 0, len(hexKeys))
+	for _, hexKey := range hexKeys {
+		key, err := crypto.UnmarshalPubkey(common.FromHex(hexKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key '%s': %v", hexKey, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
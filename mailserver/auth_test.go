@@ -0,0 +1,125 @@
+package mailserver
+
+import (
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+func generateKeys(t *testing.T, count int) []*ecdsa.PrivateKey {
+	keys := make([]*ecdsa.PrivateKey, count)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		keys[i] = key
+	}
+	return keys
+}
+
+func TestAllowlist(t *testing.T) {
+	keys := generateKeys(t, 2)
+	allowlist := NewAllowlist([]*ecdsa.PublicKey{&keys[0].PublicKey})
+
+	require.NoError(t, allowlist.Authorize(&keys[0].PublicKey, nil))
+	require.Equal(t, &UnauthorizedError{Reason: "client key is not allowed"}, allowlist.Authorize(&keys[1].PublicKey, nil))
+	require.NoError(t, authorizePeer(allowlist, enode.PubkeyToIDV4(&keys[0].PublicKey)))
+	require.Equal(t, errPeerUnauthorized, authorizePeer(allowlist, enode.PubkeyToIDV4(&keys[1].PublicKey)))
+}
+
+func TestCredentialVerifier(t *testing.T) {
+	keys := generateKeys(t, 3)
+	issuer, client, other := keys[0], keys[1], keys[2]
+	now := time.Unix(1546000000, 0)
+	verifier := NewCredentialVerifier([]*ecdsa.PublicKey{&issuer.PublicKey})
+	verifier.now = func() time.Time { return now }
+
+	credential, err := NewCredential(issuer, &client.PublicKey, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, verifier.Authorize(&client.PublicKey, credential))
+
+	require.EqualError(t, verifier.Authorize(&client.PublicKey, nil), "UNAUTHORIZED: credential required")
+	require.EqualError(t, verifier.Authorize(&other.PublicKey, credential), "UNAUTHORIZED: credential is issued to another client")
+
+	untrusted, err := NewCredential(other, &client.PublicKey, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.EqualError(t, verifier.Authorize(&client.PublicKey, untrusted), "UNAUTHORIZED: credential issuer is not trusted")
+
+	tampered := *credential
+	tampered.Expiry += 3600
+	require.Error(t, verifier.Authorize(&client.PublicKey, &tampered))
+
+	now = now.Add(2 * time.Hour)
+	require.EqualError(t, verifier.Authorize(&client.PublicKey, credential), "UNAUTHORIZED: credential expired")
+}
+
+func TestAnyAuthorizer(t *testing.T) {
+	keys := generateKeys(t, 3)
+	issuer, allowed, client := keys[0], keys[1], keys[2]
+	authorizer := AnyAuthorizer{
+		NewAllowlist([]*ecdsa.PublicKey{&allowed.PublicKey}),
+		NewCredentialVerifier([]*ecdsa.PublicKey{&issuer.PublicKey}),
+	}
+
+	require.NoError(t, authorizer.Authorize(&allowed.PublicKey, nil))
+	require.EqualError(t, authorizer.Authorize(&client.PublicKey, nil), "UNAUTHORIZED: credential required")
+	credential, err := NewCredential(issuer, &client.PublicKey, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, authorizer.Authorize(&client.PublicKey, credential))
+
+	require.NoError(t, authorizePeer(authorizer, enode.PubkeyToIDV4(&allowed.PublicKey)))
+	require.Equal(t, errPeerUnauthorized, authorizePeer(authorizer, enode.PubkeyToIDV4(&client.PublicKey)))
+	require.Equal(t, errPeerUnauthorized, authorizePeer(NewCredentialVerifier(nil), enode.PubkeyToIDV4(&allowed.PublicKey)),
+		"Sync requests have no credentials")
+}
+
+func TestParseUnauthorizedError(t *testing.T) {
+	err := &UnauthorizedError{Reason: "client key is not allowed"}
+	parsed, ok := ParseUnauthorizedError(err.Error())
+	require.True(t, ok)
+	require.Equal(t, err, parsed)
+
+	_, ok = ParseUnauthorizedError("query range is invalid: lower > upper")
+	require.False(t, ok)
+}
+
+func TestMessagesRequestPayloadCredential(t *testing.T) {
+	keys := generateKeys(t, 2)
+	credential, err := NewCredential(keys[0], &keys[1].PublicKey, time.Unix(1546000000, 0))
+	require.NoError(t, err)
+	payload := MessagesRequestPayload{
+		Lower:      10,
+		Upper:      20,
+		Bloom:      []byte{0x01},
+		Cursor:     []byte{},
+		Batch:      true,
+		Features:   []uint32{FeatureResponseSummary},
+		Credential: credential,
+	}
+
+	data, err := rlp.EncodeToBytes(payload)
+	require.NoError(t, err)
+	var decoded MessagesRequestPayload
+	require.NoError(t, rlp.DecodeBytes(data, &decoded))
+	require.Equal(t, payload, decoded)
+
+	// requests without credentials are encoded like before
+	legacy := struct {
+		Lower, Upper uint32
+		Bloom        []byte
+		Limit        uint32
+		Cursor       []byte
+		Batch        bool
+		Features     []uint32 `rlp:"tail"`
+	}{10, 20, []byte{0x01}, 0, []byte{}, true, []uint32{FeatureResponseSummary}}
+	payload.Credential = nil
+	data, err = rlp.EncodeToBytes(payload)
+	require.NoError(t, err)
+	legacyData, err := rlp.EncodeToBytes(legacy)
+	require.NoError(t, err)
+	require.Equal(t, legacyData, data)
+}
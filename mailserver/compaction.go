@@ -0,0 +1,124 @@
+package mailserver
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// DefaultDedupCacheSize is the number of keys of the archived envelopes kept in memory if
+// MailServerDedupCacheSize is not set.
+const DefaultDedupCacheSize = 10000
+
+// By default go-ethereum/metrics creates dummy metrics that don't register anything.
+// Real metrics are collected only if -metrics flag is set
+var (
+	duplicatesCounter      = metrics.NewRegisteredCounter("mailserver/archive/duplicates", nil)
+	duplicatesSizeCounter  = metrics.NewRegisteredCounter("mailserver/archive/duplicatesSize", nil)
+	compactionSavedCounter = metrics.NewRegisteredCounter("mailserver/compaction/saved", nil)
+	compactionSizeGauge    = metrics.NewRegisteredGauge("mailserver/compaction/size", nil)
+	compactionRunTimer     = metrics.NewRegisteredTimer("mailserver/compaction/runTime", nil)
+)
+
+// dedup skips the writes of the envelopes which are already archived, like the envelopes
+// received from several peers or sent again by other mailservers. The keys of the envelopes
+// include their hash, so an envelope is archived if its key is in the database.
+type dedup struct {
+	db     dbImpl
+	recent *lru.Cache
+}
+
+func newDedup(db dbImpl, size int) (*dedup, error) {
+	if size == 0 {
+		size = DefaultDedupCacheSize
+	}
+	recent, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &dedup{db: db, recent: recent}, nil
+}
+
+// archived returns whether the envelope with key is already archived, looking it up in
+// the database if it was not archived recently.
+func (d *dedup) archived(key []byte) bool {
+	if d == nil {
+		return false
+	}
+	if d.recent.Contains(string(key)) {
+		return true
+	}
+	if _, err := d.db.Get(key, nil); err != nil {
+		return false
+	}
+	d.add(key)
+	return true
+}
+
+// add remembers that the envelope with key is archived.
+func (d *dedup) add(key []byte) {
+	if d == nil {
+		return
+	}
+	d.recent.Add(string(key), struct{}{})
+}
+
+// CompactionStats is the size of the envelope store before and after a compaction, in bytes.
+type CompactionStats struct {
+	Before int64
+	After  int64
+}
+
+// Saved returns the number of bytes reclaimed by the compaction.
+func (s CompactionStats) Saved() int64 {
+	if s.After > s.Before {
+		return 0
+	}
+	return s.Before - s.After
+}
+
+// Compact reclaims the space of the removed and overwritten envelopes of db, which must be
+// LevelDB or PostgreSQL.
+func Compact(db dbImpl) (CompactionStats, error) {
+	defer compactionRunTimer.UpdateSince(time.Now())
+
+	var (
+		stats CompactionStats
+		err   error
+	)
+	switch db := db.(type) {
+	case *leveldb.DB:
+		stats, err = compactLevelDB(db)
+	case *PostgresDB:
+		stats, err = db.Compact()
+	default:
+		return stats, nil
+	}
+	if err != nil {
+		return stats, err
+	}
+	compactionSavedCounter.Inc(stats.Saved())
+	compactionSizeGauge.Update(stats.After)
+	return stats, nil
+}
+
+func compactLevelDB(db *leveldb.DB) (stats CompactionStats, err error) {
+	size := func() (int64, error) {
+		sizes, err := db.SizeOf([]util.Range{{}})
+		if err != nil {
+			return 0, err
+		}
+		return sizes.Sum(), nil
+	}
+	if stats.Before, err = size(); err != nil {
+		return stats, err
+	}
+	if err = db.CompactRange(util.Range{}); err != nil {
+		return stats, err
+	}
+	stats.After, err = size()
+	return stats, err
+}
@@ -0,0 +1,56 @@
+package mailserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedup(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+	var err error
+	server.dedup, err = newDedup(server.db, 2)
+	require.NoError(t, err)
+
+	now := time.Now()
+	env := archiveEnvelope(t, now.Add(-10*time.Second), server)
+	key := NewDBKey(env.Expiry-env.TTL, env.Hash()).Bytes()
+	require.True(t, server.dedup.archived(key))
+	server.Archive(env)
+	testMessagesCount(t, 1, server)
+
+	other, err := generateEnvelope(now.Add(-5 * time.Second))
+	require.NoError(t, err)
+	require.False(t, server.dedup.archived(NewDBKey(other.Expiry-other.TTL, other.Hash()).Bytes()))
+
+	restarted, err := newDedup(server.db, 2)
+	require.NoError(t, err)
+	require.True(t, restarted.archived(key), "Envelopes archived before are looked up in the database")
+
+	var disabled *dedup
+	require.False(t, disabled.archived(key))
+}
+
+func TestCompactLevelDB(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		archiveEnvelope(t, now.Add(-time.Duration(i+1)*time.Second), server)
+	}
+	_, err := NewCleanerWithDB(server.db).Prune(0, uint32(now.Unix()))
+	require.NoError(t, err)
+
+	stats, err := Compact(server.db)
+	require.NoError(t, err)
+	require.True(t, stats.After <= stats.Before)
+	require.Equal(t, stats.Before-stats.After, stats.Saved())
+	require.Equal(t, 0, countMessages(t, server.db))
+
+	_, err = Compact(&panicDB{})
+	require.NoError(t, err, "Databases which don't support compaction are skipped")
+	require.Equal(t, int64(0), CompactionStats{Before: 10, After: 20}.Saved())
+}
@@ -37,6 +37,19 @@ func (l *limiter) isAllowed(id string) bool {
 	return true
 }
 
+// retryAfter returns the time after which the next request of id is allowed.
+func (l *limiter) retryAfter(id string) time.Duration {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if lastRequestTime, ok := l.db[id]; ok {
+		if wait := time.Until(lastRequestTime.Add(l.timeout)); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
 func (l *limiter) deleteExpired() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -17,6 +17,8 @@
 package mailserver
 
 import (
+	"context"
+	"crypto/ecdsa"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -26,10 +28,11 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/status-im/status-go/db"
+	"github.com/status-im/status-go/logutils"
 	"github.com/status-im/status-go/params"
 	whisper "github.com/status-im/whisper/whisperv6"
 	"github.com/syndtr/goleveldb/leveldb"
@@ -62,8 +65,13 @@ var (
 	processRequestErrorsCounter    = metrics.NewRegisteredCounter("mailserver/processRequestErrors", nil)
 	historicResponseErrorsCounter  = metrics.NewRegisteredCounter("mailserver/historicResponseErrors", nil)
 	syncRequestsMeter              = metrics.NewRegisteredMeter("mailserver/syncRequests", nil)
+	rateLimitedRequestsCounter     = metrics.NewRegisteredCounter("mailserver/rateLimitedRequests", nil)
+	unauthorizedRequestsCounter    = metrics.NewRegisteredCounter("mailserver/unauthorizedRequests", nil)
 )
 
+// logger is the logger of the mailserver module, whose level can be set separately.
+var logger = logutils.NewLogger(logutils.ModuleMailserver, "package", "status-go/mailserver")
+
 const (
 	// DBKeyLength is a size of the envelope key.
 	DBKeyLength = common.HashLength + timestampLength
@@ -98,6 +106,20 @@ type WMailServer struct {
 	muLimiter sync.RWMutex
 	limiter   *limiter
 	tick      *ticker
+	quotas    *quotas
+
+	// pruneTick runs the pruner of the envelopes, if the retention is enabled.
+	pruneTick *ticker
+	// compactTick runs the compaction of the envelope store, if it's enabled.
+	compactTick *ticker
+	dedup       *dedup
+	// authorizer decides which clients can request the history, all of them if it's nil.
+	authorizer Authorizer
+	// cancelReplicaSync stops the sync with the replicas, if it's enabled.
+	cancelReplicaSync context.CancelFunc
+
+	// summaryKey signs the summaries of the responses. Summaries are not sent if it's not set.
+	summaryKey *ecdsa.PrivateKey
 }
 
 // DBKey key to be stored on db.
@@ -151,18 +173,103 @@ func (s *WMailServer) Init(shh *whisper.Whisper, config *params.WhisperConfig) e
 		return err
 	}
 	s.setupLimiter(time.Duration(config.MailServerRateLimit) * time.Second)
+	s.quotas = newQuotas(config)
+	retention, err := NewRetention(config)
+	if err != nil {
+		return err
+	}
+	if s.authorizer, err = newAuthorizer(config); err != nil {
+		return err
+	}
+	replicas, err := newReplicaSync(config.MailServerReplicaSync)
+	if err != nil {
+		return err
+	}
 
 	// Open database in the last step in order not to init with error
 	// and leave the database open by accident.
-	database, err := db.Open(config.DataDir, nil)
+	database, err := openDB(config)
 	if err != nil {
 		return fmt.Errorf("open DB: %s", err)
 	}
 	s.db = database
+	if s.dedup, err = newDedup(s.db, config.MailServerDedupCacheSize); err != nil {
+		return err
+	}
+	s.setupRetention(retention, time.Duration(config.MailServerCleanupPeriod)*time.Second)
+	s.setupCompaction(time.Duration(config.MailServerCompactionPeriod) * time.Second)
+	s.setupReplicaSync(replicas)
 
 	return nil
 }
 
+// setupRetention periodically removes the envelopes older than their max age, if the
+// retention is enabled.
+func (s *WMailServer) setupRetention(retention Retention, period time.Duration) {
+	if !retention.Enabled() {
+		return
+	}
+	if period == 0 {
+		period = DefaultCleanupPeriod
+	}
+	cleaner := NewCleanerWithDB(s.db)
+	s.pruneTick = &ticker{}
+	s.pruneTick.run(period, func() {
+		removed, err := cleaner.PruneExpired(retention, time.Now())
+		if err != nil {
+			logger.Error("failed to prune expired envelopes", "err", err)
+			return
+		}
+		logger.Info("pruned expired envelopes", "removed", removed)
+	})
+}
+
+// setupCompaction periodically compacts the envelope store, if period is bigger than 0.
+func (s *WMailServer) setupCompaction(period time.Duration) {
+	if period == 0 {
+		return
+	}
+	s.compactTick = &ticker{}
+	s.compactTick.run(period, func() {
+		stats, err := Compact(s.db)
+		if err != nil {
+			logger.Error("failed to compact the envelope store", "err", err)
+			return
+		}
+		logger.Info("compacted the envelope store", "before", stats.Before, "after", stats.After, "saved", stats.Saved())
+	})
+}
+
+// openDB opens the PostgreSQL database of the envelopes if it's enabled, or LevelDB in
+// the data directory.
+func openDB(config *params.WhisperConfig) (dbImpl, error) {
+	if config.MailServerPostgres.Enabled {
+		return NewPostgresDB(config.MailServerPostgres)
+	}
+	return db.Open(config.DataDir, nil)
+}
+
+// SetSummaryKey sets the key the summaries of the responses are signed with. It must be
+// the node key, so that clients verify that summaries come from the mailserver they requested.
+func (s *WMailServer) SetSummaryKey(key *ecdsa.PrivateKey) {
+	s.summaryKey = key
+}
+
+// SetAuthorizer sets the Authorizer deciding which clients can request the history. It
+// replaces the one of MailServerAllowedKeys and MailServerCredentialIssuers.
+func (s *WMailServer) SetAuthorizer(authorizer Authorizer) {
+	s.authorizer = authorizer
+}
+
+// authorize returns an *UnauthorizedError if the client signing a request can't request
+// the history.
+func (s *WMailServer) authorize(client *ecdsa.PublicKey, credential *Credential) error {
+	if s.authorizer == nil {
+		return nil
+	}
+	return s.authorizer.Authorize(client, credential)
+}
+
 // setupLimiter in case limit is bigger than 0 it will setup an automated
 // limit db cleanup.
 func (s *WMailServer) setupLimiter(limit time.Duration) {
@@ -216,19 +323,28 @@ func (s *WMailServer) setupMailServerCleanup(period time.Duration) {
 func (s *WMailServer) Close() {
 	if s.db != nil {
 		if err := s.db.Close(); err != nil {
-			log.Error(fmt.Sprintf("s.db.Close failed: %s", err))
+			logger.Error(fmt.Sprintf("s.db.Close failed: %s", err))
 		}
 	}
 	if s.tick != nil {
 		s.tick.stop()
 	}
+	if s.pruneTick != nil {
+		s.pruneTick.stop()
+	}
+	if s.compactTick != nil {
+		s.compactTick.stop()
+	}
+	if s.cancelReplicaSync != nil {
+		s.cancelReplicaSync()
+	}
 }
 
 func recoverLevelDBPanics(calleMethodName string) {
 	// Recover from possible goleveldb panics
 	if r := recover(); r != nil {
 		if errString, ok := r.(string); ok {
-			log.Error(fmt.Sprintf("recovered from panic in %s: %s", calleMethodName, errString))
+			logger.Error(fmt.Sprintf("recovered from panic in %s: %s", calleMethodName, errString))
 		}
 	}
 }
@@ -237,17 +353,24 @@ func recoverLevelDBPanics(calleMethodName string) {
 func (s *WMailServer) Archive(env *whisper.Envelope) {
 	defer recoverLevelDBPanics("Archive")
 
-	log.Debug("Archiving envelope", "hash", env.Hash().Hex())
+	logger.Debug("Archiving envelope", "hash", env.Hash().Hex())
 
 	key := NewDBKey(env.Expiry-env.TTL, env.Hash())
+	if s.dedup.archived(key.Bytes()) {
+		duplicatesCounter.Inc(1)
+		duplicatesSizeCounter.Inc(int64(whisper.EnvelopeHeaderLength + len(env.Data)))
+		return
+	}
 	rawEnvelope, err := rlp.EncodeToBytes(env)
 	if err != nil {
-		log.Error(fmt.Sprintf("rlp.EncodeToBytes failed: %s", err))
+		logger.Error(fmt.Sprintf("rlp.EncodeToBytes failed: %s", err))
 		archivedErrorsCounter.Inc(1)
 	} else {
 		if err = s.db.Put(key.Bytes(), rawEnvelope, nil); err != nil {
-			log.Error(fmt.Sprintf("Writing to DB failed: %s", err))
+			logger.Error(fmt.Sprintf("Writing to DB failed: %s", err))
 			archivedErrorsCounter.Inc(1)
+		} else {
+			s.dedup.add(key.Bytes())
 		}
 		archivedMeter.Mark(1)
 		archivedSizeMeter.Mark(int64(whisper.EnvelopeHeaderLength + len(env.Data)))
@@ -258,68 +381,95 @@ func (s *WMailServer) Archive(env *whisper.Envelope) {
 func (s *WMailServer) DeliverMail(peer *whisper.Peer, request *whisper.Envelope) {
 	defer recoverLevelDBPanics("DeliverMail")
 
-	log.Info("Delivering mail", "peerID", peerIDString(peer))
+	logger.Info("Delivering mail", "peerID", peerIDString(peer))
 
 	requestsMeter.Mark(1)
 
 	if peer == nil {
 		requestErrorsCounter.Inc(1)
-		log.Error("Whisper peer is nil")
+		logger.Error("Whisper peer is nil")
 		return
 	}
 	if s.exceedsPeerRequests(peer.ID()) {
 		requestErrorsCounter.Inc(1)
-		log.Error("Peer exceeded request per seconds limit", "peerID", peerIDString(peer))
-		s.trySendHistoricMessageErrorResponse(peer, request, fmt.Errorf("rate limit exceeded"))
+		rateLimitedRequestsCounter.Inc(1)
+		logger.Error("Peer exceeded request per seconds limit", "peerID", peerIDString(peer))
+		s.trySendHistoricMessageErrorResponse(peer, request, &RateLimitedError{Limit: LimitPeerInterval, RetryAfter: s.limiter.retryAfter(string(peer.ID()))})
 		return
 	}
-
 	var (
 		lower, upper uint32
 		bloom        []byte
 		limit        uint32
 		cursor       []byte
 		batch        bool
-		err          error
+		summary      bool
 	)
 
 	payload, err := s.decodeRequest(peer.ID(), request)
-	if err == nil {
+	if _, ok := err.(*UnauthorizedError); ok {
+		unauthorizedRequestsCounter.Inc(1)
+		logger.Info("Peer is not authorized", "peerID", peerIDString(peer), "err", err)
+		s.trySendHistoricMessageErrorResponse(peer, request, err)
+		return
+	} else if err == nil {
 		lower, upper = payload.Lower, payload.Upper
 		bloom = payload.Bloom
 		cursor = payload.Cursor
 		limit = payload.Limit
 		batch = payload.Batch
+		summary = payload.HasFeature(FeatureResponseSummary) && s.summaryKey != nil
 	} else {
-		log.Debug("Failed to decode request", "err", err, "peerID", peerIDString(peer))
+		logger.Debug("Failed to decode request", "err", err, "peerID", peerIDString(peer))
 		lower, upper, bloom, limit, cursor, err = s.validateRequest(peer.ID(), request)
 	}
 
 	if err != nil {
 		requestValidationErrorsCounter.Inc(1)
-		log.Error("Mailserver request failed validaton", "peerID", peerIDString(peer))
+		logger.Error("Mailserver request failed validaton", "peerID", peerIDString(peer))
 		s.trySendHistoricMessageErrorResponse(peer, request, err)
 		return
 	}
 
-	log.Debug("Processing request",
+	// Only the requests of authorized peers are charged to their quotas, so that
+	// forged requests can't exhaust the quotas of other peers.
+	remaining, err := s.quotas.allow(string(peer.ID()))
+	if err != nil {
+		requestErrorsCounter.Inc(1)
+		rateLimitedRequestsCounter.Inc(1)
+		logger.Info("Peer exceeded a request quota", "peerID", peerIDString(peer), "err", err)
+		s.trySendHistoricMessageErrorResponse(peer, request, err)
+		return
+	}
+
+	logger.Debug("Processing request",
 		"lower", lower,
 		"upper", upper,
 		"bloom", bloom,
 		"limit", limit,
 		"cursor", cursor,
 		"batch", batch,
+		"summary", summary,
 	)
 
 	if batch {
 		requestsBatchedCounter.Inc(1)
 	}
 
+	// Responses are cut to the envelopes left in the quotas, the client gets a cursor to
+	// request the rest when the quotas are renewed.
+	if remaining != noLimits && (limit == noLimits || int(limit) > remaining) {
+		limit = uint32(remaining)
+	}
+
 	iter := s.createIterator(lower, upper, cursor)
 	defer iter.Release()
 
 	bundles := make(chan []*whisper.Envelope, 5)
 	errCh := make(chan error)
+	// sentHashes are the hashes of the envelopes sent, for the summary.
+	var sentHashes []common.Hash
+	sent := 0
 
 	go func() {
 		for bundle := range bundles {
@@ -327,6 +477,12 @@ func (s *WMailServer) DeliverMail(peer *whisper.Peer, request *whisper.Envelope)
 				errCh <- err
 				break
 			}
+			sent += len(bundle)
+			if summary {
+				for _, env := range bundle {
+					sentHashes = append(sentHashes, env.Hash())
+				}
+			}
 		}
 		close(errCh)
 	}()
@@ -344,9 +500,11 @@ func (s *WMailServer) DeliverMail(peer *whisper.Peer, request *whisper.Envelope)
 	close(bundles)
 
 	// Wait for the goroutine to finish the work. It may return an error.
-	if err := <-errCh; err != nil {
+	err = <-errCh
+	s.quotas.sent(string(peer.ID()), sent)
+	if err != nil {
 		processRequestErrorsCounter.Inc(1)
-		log.Error("Error while processing mail server request", "err", err, "peerID", peerIDString(peer))
+		logger.Error("Error while processing mail server request", "err", err, "peerID", peerIDString(peer))
 		s.trySendHistoricMessageErrorResponse(peer, request, err)
 		return
 	}
@@ -354,16 +512,24 @@ func (s *WMailServer) DeliverMail(peer *whisper.Peer, request *whisper.Envelope)
 	// Processing of the request could be finished earlier due to iterator error.
 	if err := iter.Error(); err != nil {
 		processRequestErrorsCounter.Inc(1)
-		log.Error("Error while processing mail server request", "err", err, "peerID", peerIDString(peer))
+		logger.Error("Error while processing mail server request", "err", err, "peerID", peerIDString(peer))
 		s.trySendHistoricMessageErrorResponse(peer, request, err)
 		return
 	}
 
-	log.Debug("Sending historic message response", "last", lastEnvelopeHash, "next", nextPageCursor)
+	var encodedSummary []byte
+	if summary {
+		encodedSummary, err = s.signSummary(NewResponseSummary(request.Hash(), lower, upper, sentHashes))
+		if err != nil {
+			logger.Error("Failed to sign response summary", "err", err, "peerID", peerIDString(peer))
+		}
+	}
+
+	logger.Debug("Sending historic message response", "last", lastEnvelopeHash, "next", nextPageCursor)
 
-	if err := s.sendHistoricMessageResponse(peer, request, lastEnvelopeHash, nextPageCursor); err != nil {
+	if err := s.sendHistoricMessageResponse(peer, request, lastEnvelopeHash, nextPageCursor, encodedSummary); err != nil {
 		historicResponseErrorsCounter.Inc(1)
-		log.Error("Error sending historic message response", "err", err, "peerID", peerIDString(peer))
+		logger.Error("Error sending historic message response", "err", err, "peerID", peerIDString(peer))
 		// we still want to try to report error even it it is a p2p error and it is unlikely
 		s.trySendHistoricMessageErrorResponse(peer, request, err)
 	}
@@ -371,7 +537,7 @@ func (s *WMailServer) DeliverMail(peer *whisper.Peer, request *whisper.Envelope)
 
 // SyncMail syncs mail servers between two Mail Servers.
 func (s *WMailServer) SyncMail(peer *whisper.Peer, request whisper.SyncMailRequest) error {
-	log.Info("Started syncing envelopes", "peer", peerIDString(peer), "req", request)
+	logger.Info("Started syncing envelopes", "peer", peerIDString(peer), "req", request)
 
 	defer recoverLevelDBPanics("SyncMail")
 
@@ -380,14 +546,28 @@ func (s *WMailServer) SyncMail(peer *whisper.Peer, request whisper.SyncMailReque
 	// Check rate limiting for a requesting peer.
 	if s.exceedsPeerRequests(peer.ID()) {
 		requestErrorsCounter.Inc(1)
-		log.Error("Peer exceeded request per seconds limit", "peerID", peerIDString(peer))
+		logger.Error("Peer exceeded request per seconds limit", "peerID", peerIDString(peer))
 		return fmt.Errorf("requests per seconds limit exceeded")
 	}
 
+	// Sync requests are not signed, the peer is authorized by its node ID instead.
+	if s.authorizer != nil {
+		var id enode.ID
+		copy(id[:], peer.ID())
+		if err := authorizePeer(s.authorizer, id); err != nil {
+			unauthorizedRequestsCounter.Inc(1)
+			return err
+		}
+	}
+
 	if err := request.Validate(); err != nil {
 		return fmt.Errorf("request is invalid: %v", err)
 	}
 
+	if bucket, ok := digestsBucket(request); ok {
+		return s.sendDigests(peer, request, bucket)
+	}
+
 	iter := s.createIterator(request.Lower, request.Upper, request.Cursor)
 	defer iter.Release()
 
@@ -435,7 +615,7 @@ func (s *WMailServer) SyncMail(peer *whisper.Peer, request whisper.SyncMailReque
 		return fmt.Errorf("levelDB iterator failed: %v", err)
 	}
 
-	log.Info("Finished syncing envelopes", "peer", peerIDString(peer))
+	logger.Info("Finished syncing envelopes", "peer", peerIDString(peer))
 
 	if err := s.w.SendSyncResponse(peer, whisper.SyncResponse{
 		Cursor: nextCursor,
@@ -456,7 +636,7 @@ func (s *WMailServer) exceedsPeerRequests(peer []byte) bool {
 	if s.limiter != nil {
 		peerID := string(peer)
 		if !s.limiter.isAllowed(peerID) {
-			log.Info("peerID exceeded the number of requests per second")
+			logger.Info("peerID exceeded the number of requests per second")
 			return true
 		}
 		s.limiter.add(peerID)
@@ -503,7 +683,7 @@ func (s *WMailServer) processRequestInBundles(
 
 		decodeErr := rlp.DecodeBytes(iter.Value(), &envelope)
 		if decodeErr != nil {
-			log.Error("failed to decode RLP", "err", decodeErr)
+			logger.Error("failed to decode RLP", "err", decodeErr)
 			continue
 		}
 
@@ -575,11 +755,24 @@ func (s *WMailServer) sendEnvelopes(peer *whisper.Peer, envelopes []*whisper.Env
 	return nil
 }
 
-func (s *WMailServer) sendHistoricMessageResponse(peer *whisper.Peer, request *whisper.Envelope, lastEnvelopeHash common.Hash, cursor []byte) error {
+// sendHistoricMessageResponse sends the completion message of a request, with the summary
+// of the response if it's set.
+func (s *WMailServer) sendHistoricMessageResponse(peer *whisper.Peer, request *whisper.Envelope, lastEnvelopeHash common.Hash, cursor []byte, summary []byte) error {
 	payload := whisper.CreateMailServerRequestCompletedPayload(request.Hash(), lastEnvelopeHash, cursor)
+	if summary != nil {
+		payload = AppendResponseSummary(payload, summary)
+	}
 	return s.w.SendHistoricMessageResponse(peer, payload)
 }
 
+// signSummary signs and encodes the summary of a response.
+func (s *WMailServer) signSummary(summary ResponseSummary) ([]byte, error) {
+	if err := summary.Sign(s.summaryKey); err != nil {
+		return nil, err
+	}
+	return EncodeResponseSummary(summary)
+}
+
 // this method doesn't return an error because it is already in the error handling chain
 func (s *WMailServer) trySendHistoricMessageErrorResponse(peer *whisper.Peer, request *whisper.Envelope, errorToReport error) {
 	payload := whisper.CreateMailServerRequestFailedPayload(request.Hash(), errorToReport)
@@ -588,7 +781,7 @@ func (s *WMailServer) trySendHistoricMessageErrorResponse(peer *whisper.Peer, re
 	// if we can't report an error, probably something is wrong with p2p connection,
 	// so we just print a log entry to document this sad fact
 	if err != nil {
-		log.Error("Error while reporting error response", "err", err, "peerID", peerIDString(peer))
+		logger.Error("Error while reporting error response", "err", err, "peerID", peerIDString(peer))
 	}
 }
 
@@ -617,12 +810,12 @@ func (s *WMailServer) decodeRequest(peerID []byte, request *whisper.Envelope) (M
 
 	decrypted := s.openEnvelope(request)
 	if decrypted == nil {
-		log.Warn("Failed to decrypt p2p request")
+		logger.Warn("Failed to decrypt p2p request")
 		return payload, errors.New("failed to decrypt p2p request")
 	}
 
 	if err := s.checkMsgSignature(decrypted, peerID); err != nil {
-		log.Warn("Check message signature failed: %s", "err", err.Error())
+		logger.Warn("Check message signature failed: %s", "err", err.Error())
 		return payload, fmt.Errorf("check message signature failed: %v", err)
 	}
 
@@ -630,15 +823,23 @@ func (s *WMailServer) decodeRequest(peerID []byte, request *whisper.Envelope) (M
 		return payload, fmt.Errorf("failed to decode data: %v", err)
 	}
 
+	if err := s.authorize(decrypted.Src, payload.Credential); err != nil {
+		return payload, err
+	}
+	// Requests of older clients don't have features.
+	if len(payload.Features) == 0 {
+		payload.Features = nil
+	}
+
 	if payload.Upper < payload.Lower {
-		log.Error("Query range is invalid: lower > upper", "lower", payload.Lower, "upper", payload.Upper)
+		logger.Error("Query range is invalid: lower > upper", "lower", payload.Lower, "upper", payload.Upper)
 		return payload, errors.New("query range is invalid: lower > upper")
 	}
 
 	lowerTime := time.Unix(int64(payload.Lower), 0)
 	upperTime := time.Unix(int64(payload.Upper), 0)
 	if upperTime.Sub(lowerTime) > maxQueryRange {
-		log.Warn("Query range too long", "peerID", peerIDBytesString(peerID), "length", upperTime.Sub(lowerTime), "max", maxQueryRange)
+		logger.Warn("Query range too long", "peerID", peerIDBytesString(peerID), "length", upperTime.Sub(lowerTime), "max", maxQueryRange)
 		return payload, fmt.Errorf("query range must be shorted than %d", maxQueryRange)
 	}
 
@@ -664,6 +865,11 @@ func (s *WMailServer) validateRequest(
 		return 0, 0, nil, 0, nil, err
 	}
 
+	// Requests in this format have no credentials.
+	if err := s.authorize(decrypted.Src, nil); err != nil {
+		return 0, 0, nil, 0, nil, err
+	}
+
 	bloom, err := s.bloomFromReceivedMessage(decrypted)
 	if err != nil {
 		return 0, 0, nil, 0, nil, err
@@ -469,6 +469,34 @@ func (s *MailserverSuite) TestDecodeRequest() {
 	s.Equal(payload, decodedPayload)
 }
 
+func (s *MailserverSuite) TestDecodeRequestUnauthorized() {
+	s.setupServer(s.server)
+	defer s.server.Close()
+
+	issuer, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	s.server.SetAuthorizer(NewCredentialVerifier([]*ecdsa.PublicKey{&issuer.PublicKey}))
+
+	id, err := s.shh.NewKeyPair()
+	s.Require().NoError(err)
+	srcKey, err := s.shh.GetPrivateKey(id)
+	s.Require().NoError(err)
+
+	payload := MessagesRequestPayload{Lower: 50, Upper: 100, Bloom: []byte{0x01}, Cursor: []byte{}, Batch: true}
+	data, err := rlp.EncodeToBytes(payload)
+	s.Require().NoError(err)
+	_, err = s.server.decodeRequest(nil, s.createEnvelope(whisper.TopicType{0x01}, data, srcKey))
+	s.Equal(&UnauthorizedError{Reason: "credential required"}, err)
+
+	payload.Credential, err = NewCredential(issuer, &srcKey.PublicKey, time.Now().Add(time.Hour))
+	s.Require().NoError(err)
+	data, err = rlp.EncodeToBytes(payload)
+	s.Require().NoError(err)
+	decodedPayload, err := s.server.decodeRequest(nil, s.createEnvelope(whisper.TopicType{0x01}, data, srcKey))
+	s.Require().NoError(err)
+	s.Equal(payload, decodedPayload)
+}
+
 func (s *MailserverSuite) messageExists(envelope *whisper.Envelope, low, upp uint32, bloom []byte, limit uint32) bool {
 	receivedHashes, _, _ := processRequestAndCollectHashes(
 		s.server, low, upp, nil, bloom, int(limit),
@@ -0,0 +1,345 @@
+package mailserver
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq" // registers the postgres driver
+	"github.com/status-im/status-go/params"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// postgresDriverName is the name of the database/sql driver of PostgreSQL, registered
+// by github.com/lib/pq.
+const postgresDriverName = "postgres"
+
+// postgresPageSize is the number of envelopes read at once by the iterators.
+const postgresPageSize = 100
+
+const postgresSchema = `CREATE TABLE IF NOT EXISTS envelopes (
+	id BYTEA NOT NULL PRIMARY KEY,
+	data BYTEA NOT NULL
+)`
+
+// PostgresDB stores the envelopes in a PostgreSQL database, so that it can be shared by
+// the replicas of a mailserver. It implements the operations of LevelDB used by the
+// mailserver, with the same iterator semantics.
+type PostgresDB struct {
+	db *sql.DB
+}
+
+// NewPostgresDB opens the PostgreSQL database of config, with its connection pool, and
+// creates the table of the envelopes if it doesn't exist.
+func NewPostgresDB(config params.PostgresConfig) (*PostgresDB, error) {
+	db, err := sql.Open(postgresDriverName, config.URI)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	if config.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(time.Duration(config.ConnMaxLifetime) * time.Second)
+
+	p, err := newPostgresDB(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func newPostgresDB(db *sql.DB) (*PostgresDB, error) {
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(postgresSchema); err != nil {
+		return nil, err
+	}
+	return &PostgresDB{db: db}, nil
+}
+
+// Close closes the connections of the pool.
+func (p *PostgresDB) Close() error {
+	return p.db.Close()
+}
+
+// Put stores an envelope. Envelopes already stored, like those archived by another
+// replica, are kept.
+func (p *PostgresDB) Put(key []byte, value []byte, _ *opt.WriteOptions) error {
+	_, err := p.db.Exec(`INSERT INTO envelopes (id, data) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING`, key, value)
+	return err
+}
+
+// Get returns an envelope, or leveldb.ErrNotFound.
+func (p *PostgresDB) Get(key []byte, _ *opt.ReadOptions) ([]byte, error) {
+	var value []byte
+	err := p.db.QueryRow(`SELECT data FROM envelopes WHERE id = $1`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, leveldb.ErrNotFound
+	}
+	return value, err
+}
+
+// Compact runs VACUUM FULL on the envelopes table, which rewrites it without the space of
+// the removed envelopes. The table is locked while it's rewritten.
+func (p *PostgresDB) Compact() (stats CompactionStats, err error) {
+	size := func() (size int64, err error) {
+		err = p.db.QueryRow(`SELECT pg_total_relation_size('envelopes')`).Scan(&size)
+		return size, err
+	}
+	if stats.Before, err = size(); err != nil {
+		return stats, err
+	}
+	if _, err = p.db.Exec(`VACUUM FULL envelopes`); err != nil {
+		return stats, err
+	}
+	stats.After, err = size()
+	return stats, err
+}
+
+// Write applies a batch in a transaction.
+func (p *PostgresDB) Write(batch *leveldb.Batch, _ *opt.WriteOptions) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	replay := &postgresBatchReplay{tx: tx}
+	if err := batch.Replay(replay); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if replay.err != nil {
+		_ = tx.Rollback()
+		return replay.err
+	}
+	return tx.Commit()
+}
+
+// postgresBatchReplay applies the operations of a batch in a transaction, until one fails.
+type postgresBatchReplay struct {
+	tx  *sql.Tx
+	err error
+}
+
+func (r *postgresBatchReplay) Put(key, value []byte) {
+	if r.err == nil {
+		_, r.err = r.tx.Exec(`INSERT INTO envelopes (id, data) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING`, key, value)
+	}
+}
+
+func (r *postgresBatchReplay) Delete(key []byte) {
+	if r.err == nil {
+		_, r.err = r.tx.Exec(`DELETE FROM envelopes WHERE id = $1`, key)
+	}
+}
+
+// NewIterator returns an iterator of the envelopes in the range. The envelopes are read
+// in pages, so that iterators don't hold connections.
+func (p *PostgresDB) NewIterator(slice *util.Range, _ *opt.ReadOptions) iterator.Iterator {
+	i := &postgresIterator{db: p.db}
+	if slice != nil {
+		i.start, i.limit = slice.Start, slice.Limit
+	}
+	return i
+}
+
+// Positions of a postgresIterator which are not on an envelope.
+const (
+	postgresBeforeFirst = iota
+	postgresAfterLast
+	postgresOnEnvelope
+)
+
+type postgresRow struct {
+	key, value []byte
+}
+
+// postgresIterator iterates the envelopes between start, inclusive, and limit, exclusive,
+// like the iterators of LevelDB.
+type postgresIterator struct {
+	util.BasicReleaser
+
+	db           *sql.DB
+	start, limit []byte
+
+	position int
+	current  postgresRow
+	// page are the next envelopes in the direction of ascending, read with current.
+	page      []postgresRow
+	ascending bool
+	err       error
+}
+
+// query reads a page of envelopes after the key, in the given direction, or from the
+// bound of the range if the key is nil. If inclusive is set, the key is included.
+func (i *postgresIterator) query(key []byte, inclusive, ascending bool) bool {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	addCondition := func(operator string, value []byte) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf("id %s $%d", operator, len(args)))
+	}
+	if i.start != nil {
+		addCondition(">=", i.start)
+	}
+	if i.limit != nil {
+		addCondition("<", i.limit)
+	}
+	order := "DESC"
+	if ascending {
+		order = "ASC"
+	}
+	if key != nil {
+		operator := "<"
+		if ascending {
+			operator = ">"
+		}
+		if inclusive {
+			operator += "="
+		}
+		addCondition(operator, key)
+	}
+	query := "SELECT id, data FROM envelopes"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY id %s LIMIT %d", order, postgresPageSize)
+
+	rows, err := i.db.Query(query, args...)
+	if err != nil {
+		return i.fail(err)
+	}
+	defer rows.Close()
+	var page []postgresRow
+	for rows.Next() {
+		var row postgresRow
+		if err := rows.Scan(&row.key, &row.value); err != nil {
+			return i.fail(err)
+		}
+		page = append(page, row)
+	}
+	if err := rows.Err(); err != nil {
+		return i.fail(err)
+	}
+
+	if len(page) == 0 {
+		i.position = postgresBeforeFirst
+		if ascending {
+			i.position = postgresAfterLast
+		}
+		i.current = postgresRow{}
+		i.page = nil
+		return false
+	}
+	i.position = postgresOnEnvelope
+	i.current = page[0]
+	i.page = page[1:]
+	i.ascending = ascending
+	return true
+}
+
+func (i *postgresIterator) fail(err error) bool {
+	i.err = err
+	i.position = postgresAfterLast
+	i.current = postgresRow{}
+	i.page = nil
+	return false
+}
+
+func (i *postgresIterator) move(ascending bool) bool {
+	if i.err != nil {
+		return false
+	}
+	switch i.position {
+	case postgresBeforeFirst:
+		if !ascending {
+			return false
+		}
+		return i.First()
+	case postgresAfterLast:
+		if ascending {
+			return false
+		}
+		return i.Last()
+	}
+	if i.ascending == ascending && len(i.page) > 0 {
+		i.current = i.page[0]
+		i.page = i.page[1:]
+		return true
+	}
+	return i.query(i.current.key, false, ascending)
+}
+
+// First moves to the first envelope of the range.
+func (i *postgresIterator) First() bool {
+	if i.err != nil {
+		return false
+	}
+	return i.query(nil, false, true)
+}
+
+// Last moves to the last envelope of the range.
+func (i *postgresIterator) Last() bool {
+	if i.err != nil {
+		return false
+	}
+	return i.query(nil, false, false)
+}
+
+// Seek moves to the first envelope whose key is greater than or equal to key.
+func (i *postgresIterator) Seek(key []byte) bool {
+	if i.err != nil {
+		return false
+	}
+	if i.start != nil && bytes.Compare(key, i.start) < 0 {
+		key = i.start
+	}
+	return i.query(append([]byte(nil), key...), true, true)
+}
+
+// Next moves to the next envelope.
+func (i *postgresIterator) Next() bool {
+	return i.move(true)
+}
+
+// Prev moves to the previous envelope.
+func (i *postgresIterator) Prev() bool {
+	return i.move(false)
+}
+
+// Valid returns whether the iterator is on an envelope.
+func (i *postgresIterator) Valid() bool {
+	return i.position == postgresOnEnvelope
+}
+
+// Key returns the key of the current envelope, or nil.
+func (i *postgresIterator) Key() []byte {
+	return i.current.key
+}
+
+// Value returns the current envelope, or nil.
+func (i *postgresIterator) Value() []byte {
+	return i.current.value
+}
+
+// Error returns the error of the queries.
+func (i *postgresIterator) Error() error {
+	return i.err
+}
+
+// Release releases the page of the iterator.
+func (i *postgresIterator) Release() {
+	i.current = postgresRow{}
+	i.page = nil
+	i.position = postgresAfterLast
+	i.BasicReleaser.Release()
+}
@@ -0,0 +1,74 @@
+// +build postgres
+
+package mailserver
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/params"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+// newIntegrationPostgresDB opens the scratch database of MAILSERVER_POSTGRES_URI like the
+// mailserver does, deletes its envelopes, and stores count envelopes in it and in LevelDB.
+func newIntegrationPostgresDB(t *testing.T, count int) (*PostgresDB, *leveldb.DB, func()) {
+	uri := os.Getenv("MAILSERVER_POSTGRES_URI")
+	if uri == "" {
+		t.Skip("MAILSERVER_POSTGRES_URI is not set")
+	}
+	database, err := openDB(&params.WhisperConfig{
+		MailServerPostgres: params.PostgresConfig{Enabled: true, URI: uri, MaxOpenConns: 4},
+	})
+	require.NoError(t, err)
+	p := database.(*PostgresDB)
+	_, err = p.db.Exec(`DELETE FROM envelopes`)
+	require.NoError(t, err)
+	level, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+
+	batch := new(leveldb.Batch)
+	for i := 0; i < count; i++ {
+		batch.Put(NewDBKey(uint32(1000+i/2), common.BytesToHash([]byte{byte(i)})).Bytes(), []byte{byte(i)})
+	}
+	require.NoError(t, p.Write(batch, nil))
+	require.NoError(t, level.Write(batch, nil))
+	return p, level, func() {
+		p.db.Exec(`DELETE FROM envelopes`) // nolint: errcheck
+		p.Close()
+		level.Close()
+	}
+}
+
+func TestPostgresIntegrationIteratorSemantics(t *testing.T) {
+	p, level, cleanup := newIntegrationPostgresDB(t, 250)
+	defer cleanup()
+
+	requireIteratorSemantics(t, p, level)
+}
+
+func TestPostgresIntegrationStore(t *testing.T) {
+	p, _, cleanup := newIntegrationPostgresDB(t, 10)
+	defer cleanup()
+
+	key := NewDBKey(2000, common.BytesToHash([]byte{42})).Bytes()
+	require.NoError(t, p.Put(key, []byte{1}, nil))
+	require.NoError(t, p.Put(key, []byte{2}, nil), "Envelopes archived by another replica are kept")
+	value, err := p.Get(key, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1}, value)
+
+	cleaner := NewCleanerWithDB(p)
+	cleaner.batchSize = 3
+	removed, err := cleaner.Prune(1000, 1003)
+	require.NoError(t, err)
+	require.Equal(t, 6, removed)
+	_, err = p.Compact()
+	require.NoError(t, err)
+	i := p.NewIterator(nil, nil)
+	defer i.Release()
+	require.Len(t, keys(i, i.Next), 5)
+}
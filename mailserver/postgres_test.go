@@ -0,0 +1,123 @@
+package mailserver
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	_ "github.com/mutecomm/go-sqlcipher" // registers the sqlite3 driver
+	"github.com/status-im/status-go/params"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// newTestPostgresDB returns a PostgresDB backed by SQLite, whose SQL is compatible with the
+// queries of the iterators, and LevelDB with the same envelopes.
+func newTestPostgresDB(t *testing.T, count int) (*PostgresDB, *leveldb.DB, func()) {
+	dir, err := ioutil.TempDir("", "mailserver-postgres")
+	require.NoError(t, err)
+	sqlDB, err := sql.Open("sqlite3", filepath.Join(dir, "envelopes.db"))
+	require.NoError(t, err)
+	p, err := newPostgresDB(sqlDB)
+	require.NoError(t, err)
+	level, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+
+	for i := 0; i < count; i++ {
+		key := NewDBKey(uint32(1000+i/2), common.BytesToHash([]byte{byte(i)})).Bytes()
+		value := []byte{byte(i)}
+		_, err := sqlDB.Exec(`INSERT INTO envelopes (id, data) VALUES ($1, $2)`, key, value)
+		require.NoError(t, err)
+		require.NoError(t, level.Put(key, value, nil))
+	}
+	return p, level, func() {
+		p.Close()
+		level.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestPostgresDriverRegistered(t *testing.T) {
+	_, err := NewPostgresDB(params.PostgresConfig{URI: "postgres://status@127.0.0.1:1/mailserver?sslmode=disable&connect_timeout=1"})
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "unknown driver", "Connects with the driver of lib/pq")
+}
+
+func keys(i iterator.Iterator, move func() bool) (result [][]byte) {
+	for move() {
+		result = append(result, append([]byte(nil), i.Key()...))
+	}
+	return result
+}
+
+func TestPostgresIteratorSemantics(t *testing.T) {
+	p, level, cleanup := newTestPostgresDB(t, 250)
+	defer cleanup()
+
+	requireIteratorSemantics(t, p, level)
+}
+
+// requireIteratorSemantics compares the iterators of a PostgresDB with those of LevelDB
+// holding the same envelopes.
+func requireIteratorSemantics(t *testing.T, p *PostgresDB, level *leveldb.DB) {
+	var zero common.Hash
+	ranges := []*util.Range{
+		nil,
+		{Start: NewDBKey(1010, zero).Bytes(), Limit: NewDBKey(1100, zero).Bytes()},
+		{Start: NewDBKey(2000, zero).Bytes(), Limit: NewDBKey(3000, zero).Bytes()},
+	}
+	for _, r := range ranges {
+		expected, actual := level.NewIterator(r, nil), p.NewIterator(r, nil)
+		require.Equal(t, keys(expected, expected.Next), keys(actual, actual.Next), "Iterates from the first envelope")
+		require.Equal(t, keys(expected, expected.Prev), keys(actual, actual.Prev), "Iterates back from the last envelope once exhausted")
+		expected.Release()
+		actual.Release()
+
+		// like createIterator, seeking to the limit of the range to iterate in a descending order
+		expected, actual = level.NewIterator(r, nil), p.NewIterator(r, nil)
+		seek := NewDBKey(1050, zero).Bytes()
+		require.Equal(t, expected.Seek(seek), actual.Seek(seek))
+		require.Equal(t, expected.Key(), actual.Key())
+		for i := 0; i < 30; i++ {
+			require.Equal(t, expected.Prev(), actual.Prev())
+			require.Equal(t, expected.Key(), actual.Key())
+			require.Equal(t, expected.Value(), actual.Value())
+		}
+		for i := 0; i < 130; i++ {
+			require.Equal(t, expected.Next(), actual.Next(), "Changes direction in the middle of a page")
+			require.Equal(t, expected.Key(), actual.Key())
+		}
+		require.Equal(t, expected.Last(), actual.Last())
+		require.Equal(t, expected.Key(), actual.Key())
+		require.NoError(t, actual.Error())
+		expected.Release()
+		actual.Release()
+	}
+}
+
+func TestPostgresGetAndCleaner(t *testing.T) {
+	p, _, cleanup := newTestPostgresDB(t, 10)
+	defer cleanup()
+
+	key := NewDBKey(1000, common.BytesToHash([]byte{1})).Bytes()
+	value, err := p.Get(key, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1}, value)
+	_, err = p.Get(NewDBKey(999, common.Hash{}).Bytes(), nil)
+	require.Equal(t, leveldb.ErrNotFound, err)
+
+	cleaner := NewCleanerWithDB(p)
+	cleaner.batchSize = 3
+	removed, err := cleaner.Prune(1000, 1003)
+	require.NoError(t, err)
+	require.Equal(t, 6, removed)
+	i := p.NewIterator(nil, nil)
+	defer i.Release()
+	require.Len(t, keys(i, i.Next), 4)
+}
@@ -0,0 +1,203 @@
+package mailserver
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/status-im/status-go/params"
+)
+
+// RateLimitedCode starts the errors of the requests rejected by the rate limits, sent in
+// the failure responses like "RATE_LIMITED limit=peer_requests retry_after=42", so that
+// clients know which limit was exceeded and after how many seconds they can retry.
+const RateLimitedCode = "RATE_LIMITED"
+
+// Limits exceeded by the requests rejected with a RateLimitedError.
+const (
+	// LimitPeerInterval is the minimum time between the requests of a peer.
+	LimitPeerInterval = "peer_interval"
+	// LimitPeerRequests is the number of requests of a peer per minute.
+	LimitPeerRequests = "peer_requests"
+	// LimitPeerEnvelopes is the number of envelopes sent to a peer per day.
+	LimitPeerEnvelopes = "peer_envelopes"
+	// LimitRequests is the number of requests of all the peers per minute.
+	LimitRequests = "requests"
+	// LimitEnvelopes is the number of envelopes sent to all the peers per day.
+	LimitEnvelopes = "envelopes"
+)
+
+const (
+	requestsWindow  = time.Minute
+	envelopesWindow = 24 * time.Hour
+)
+
+// RateLimitedError is the error of a request rejected by a rate limit.
+type RateLimitedError struct {
+	Limit      string
+	RetryAfter time.Duration
+}
+
+// Error returns the error sent in the failure response.
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("%s limit=%s retry_after=%d", RateLimitedCode, e.Limit, e.RetryAfterSeconds())
+}
+
+// RetryAfterSeconds returns RetryAfter in seconds, rounded up.
+func (e *RateLimitedError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// Code returns RateLimitedCode.
+func (e *RateLimitedError) Code() string {
+	return RateLimitedCode
+}
+
+// ParseRateLimitedError parses the error of a failure response, returning false if the
+// request was not rejected by a rate limit.
+func ParseRateLimitedError(msg string) (*RateLimitedError, bool) {
+	var (
+		limit   string
+		seconds int
+	)
+	if _, err := fmt.Sscanf(msg, RateLimitedCode+" limit=%s retry_after=%d", &limit, &seconds); err != nil {
+		return nil, false
+	}
+	return &RateLimitedError{Limit: limit, RetryAfter: time.Duration(seconds) * time.Second}, true
+}
+
+// window counts the requests or the envelopes of a fixed time window.
+type window struct {
+	start time.Time
+	count int
+}
+
+func (w *window) used(now time.Time, length time.Duration) int {
+	if now.Sub(w.start) >= length {
+		return 0
+	}
+	return w.count
+}
+
+func (w *window) add(now time.Time, length time.Duration, count int) {
+	if now.Sub(w.start) >= length {
+		w.start = now
+		w.count = 0
+	}
+	w.count += count
+}
+
+// check returns a RateLimitedError if the window has no room left under max.
+func (w *window) check(now time.Time, length time.Duration, max int, limit string) error {
+	if max > 0 && w.used(now, length) >= max {
+		return &RateLimitedError{Limit: limit, RetryAfter: w.start.Add(length).Sub(now)}
+	}
+	return nil
+}
+
+type peerQuota struct {
+	requests, envelopes window
+}
+
+// quotas limits the requests per minute and the envelopes sent per day, by peer and for
+// all the peers. Zero limits are disabled.
+type quotas struct {
+	mu sync.Mutex
+
+	peerRequests, peerEnvelopes int
+	requests, envelopes         int
+
+	peers           map[string]*peerQuota
+	requestsWindow  window
+	envelopesWindow window
+	cleaned         time.Time
+	now             func() time.Time
+}
+
+func newQuotas(config *params.WhisperConfig) *quotas {
+	return &quotas{
+		peerRequests:  config.MailServerPeerRequestsPerMinute,
+		peerEnvelopes: config.MailServerPeerEnvelopesPerDay,
+		requests:      config.MailServerRequestsPerMinute,
+		envelopes:     config.MailServerEnvelopesPerDay,
+		peers:         make(map[string]*peerQuota),
+		now:           time.Now,
+	}
+}
+
+// allow checks the limits for a request of peer and counts it if it's allowed. It returns
+// the number of envelopes which can be sent in the response, or noLimits.
+func (q *quotas) allow(peer string) (int, error) {
+	if q == nil {
+		return noLimits, nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	q.clean(now)
+	quota, ok := q.peers[peer]
+	if !ok {
+		quota = &peerQuota{}
+	}
+	if err := quota.envelopes.check(now, envelopesWindow, q.peerEnvelopes, LimitPeerEnvelopes); err != nil {
+		return 0, err
+	}
+	if err := q.envelopesWindow.check(now, envelopesWindow, q.envelopes, LimitEnvelopes); err != nil {
+		return 0, err
+	}
+	if err := quota.requests.check(now, requestsWindow, q.peerRequests, LimitPeerRequests); err != nil {
+		return 0, err
+	}
+	if err := q.requestsWindow.check(now, requestsWindow, q.requests, LimitRequests); err != nil {
+		return 0, err
+	}
+
+	quota.requests.add(now, requestsWindow, 1)
+	q.requestsWindow.add(now, requestsWindow, 1)
+	q.peers[peer] = quota
+
+	remaining := noLimits
+	if q.peerEnvelopes > 0 {
+		remaining = q.peerEnvelopes - quota.envelopes.used(now, envelopesWindow)
+	}
+	if q.envelopes > 0 {
+		global := q.envelopes - q.envelopesWindow.used(now, envelopesWindow)
+		if remaining == noLimits || global < remaining {
+			remaining = global
+		}
+	}
+	return remaining, nil
+}
+
+// sent counts the envelopes sent to peer.
+func (q *quotas) sent(peer string, count int) {
+	if q == nil || count == 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	quota, ok := q.peers[peer]
+	if !ok {
+		quota = &peerQuota{}
+		q.peers[peer] = quota
+	}
+	quota.envelopes.add(now, envelopesWindow, count)
+	q.envelopesWindow.add(now, envelopesWindow, count)
+}
+
+// clean removes the peers whose windows are over, once a minute.
+func (q *quotas) clean(now time.Time) {
+	if now.Sub(q.cleaned) < requestsWindow {
+		return
+	}
+	q.cleaned = now
+	for peer, quota := range q.peers {
+		if quota.requests.used(now, requestsWindow) == 0 && quota.envelopes.used(now, envelopesWindow) == 0 {
+			delete(q.peers, peer)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package mailserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/status-im/status-go/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotasRequests(t *testing.T) {
+	q := newQuotas(&params.WhisperConfig{MailServerPeerRequestsPerMinute: 2, MailServerRequestsPerMinute: 3})
+	now := time.Unix(1546000000, 0)
+	q.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		remaining, err := q.allow("peer1")
+		require.NoError(t, err)
+		require.Equal(t, noLimits, remaining)
+	}
+	now = now.Add(20 * time.Second)
+	_, err := q.allow("peer1")
+	require.Equal(t, &RateLimitedError{Limit: LimitPeerRequests, RetryAfter: 40 * time.Second}, err)
+
+	_, err = q.allow("peer2")
+	require.NoError(t, err)
+	_, err = q.allow("peer3")
+	require.Equal(t, &RateLimitedError{Limit: LimitRequests, RetryAfter: 40 * time.Second}, err)
+
+	now = now.Add(40 * time.Second)
+	_, err = q.allow("peer1")
+	require.NoError(t, err, "Limits are renewed every minute")
+}
+
+func TestQuotasEnvelopes(t *testing.T) {
+	q := newQuotas(&params.WhisperConfig{MailServerPeerEnvelopesPerDay: 100, MailServerEnvelopesPerDay: 150})
+	now := time.Unix(1546000000, 0)
+	q.now = func() time.Time { return now }
+
+	remaining, err := q.allow("peer1")
+	require.NoError(t, err)
+	require.Equal(t, 100, remaining)
+	q.sent("peer1", 70)
+
+	remaining, err = q.allow("peer2")
+	require.NoError(t, err)
+	require.Equal(t, 80, remaining, "The global quota is lower than the quota of the peer")
+	q.sent("peer2", 80)
+
+	now = now.Add(time.Hour)
+	_, err = q.allow("peer1")
+	require.Equal(t, &RateLimitedError{Limit: LimitEnvelopes, RetryAfter: 23 * time.Hour}, err)
+
+	now = now.Add(23 * time.Hour)
+	remaining, err = q.allow("peer1")
+	require.NoError(t, err)
+	require.Equal(t, 100, remaining)
+	q.sent("peer1", 100)
+	_, err = q.allow("peer1")
+	require.Equal(t, &RateLimitedError{Limit: LimitPeerEnvelopes, RetryAfter: 24 * time.Hour}, err)
+}
+
+func TestRateLimitedError(t *testing.T) {
+	err := &RateLimitedError{Limit: LimitPeerRequests, RetryAfter: 1500 * time.Millisecond}
+	require.Equal(t, "RATE_LIMITED limit=peer_requests retry_after=2", err.Error())
+
+	parsed, ok := ParseRateLimitedError(err.Error())
+	require.True(t, ok)
+	require.Equal(t, &RateLimitedError{Limit: LimitPeerRequests, RetryAfter: 2 * time.Second}, parsed)
+
+	_, ok = ParseRateLimitedError("query range is invalid: lower > upper")
+	require.False(t, ok)
+}
@@ -0,0 +1,364 @@
+package mailserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/status-im/status-go/params"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	// DefaultReplicaSyncPeriod is the time between the syncs of the replicas if
+	// MailServerReplicaSync.Period is not set.
+	DefaultReplicaSyncPeriod = time.Hour
+	// DefaultReplicaBucket is the length of the time buckets compared with the replicas if
+	// MailServerReplicaSync.Bucket is not set.
+	DefaultReplicaBucket = time.Hour
+	// DefaultReplicaMaxAge is how far back envelopes are synced if
+	// MailServerReplicaSync.MaxAge is not set.
+	DefaultReplicaMaxAge = 30 * 24 * time.Hour
+
+	// replicaStartDelay is the time before the first sync, to let the replicas connect.
+	replicaStartDelay = time.Minute
+	// replicaRequestTimeout is the time to wait for the response of a replica to a request.
+	replicaRequestTimeout = time.Minute
+	// replicaPageLimit is the number of envelopes requested at once from a replica.
+	replicaPageLimit = 1000
+
+	// minDigestsBucket is the shortest bucket, in seconds, whose digests are served.
+	minDigestsBucket = 60
+	// maxDigestsBuckets is the largest number of buckets whose digests are served at once.
+	maxDigestsBuckets = 10000
+	// maxDigestsRange is the longest time range, in seconds, whose digests are served at once.
+	maxDigestsRange = 90 * 24 * 60 * 60
+)
+
+// digestsCursorPrefix starts the cursors of the sync requests for the digests of the time
+// buckets, followed by the length of the buckets in seconds. The digests are sent back in
+// the cursor of the final response. Cursors of envelopes are DBKeyLength long.
+var digestsCursorPrefix = []byte("digests")
+
+// By default go-ethereum/metrics creates dummy metrics that don't register anything.
+// Real metrics are collected only if -metrics flag is set
+var (
+	replicaBucketsCounter        = metrics.NewRegisteredCounter("mailserver/replica/buckets", nil)
+	replicaMissingBucketsCounter = metrics.NewRegisteredCounter("mailserver/replica/missingBuckets", nil)
+	replicaSyncErrorsCounter     = metrics.NewRegisteredCounter("mailserver/replica/syncErrors", nil)
+	replicaSyncTimer             = metrics.NewRegisteredTimer("mailserver/replica/syncTime", nil)
+)
+
+// BucketDigest summarizes the envelopes of a time bucket which match a bloom filter.
+// Replicas sync the buckets whose digests differ.
+type BucketDigest struct {
+	// Lower is the start of the bucket.
+	Lower uint32
+	// Count is the number of envelopes.
+	Count uint32
+	// Hash is the XOR of the hashes of the envelopes, which doesn't depend on their order.
+	Hash common.Hash
+}
+
+// NewDigestsRequest returns a sync request for the digests of the buckets of envelopes
+// between lower and upper matching bloom.
+func NewDigestsRequest(lower, upper uint32, bloom []byte, bucket time.Duration) whisper.SyncMailRequest {
+	cursor := make([]byte, len(digestsCursorPrefix)+4)
+	copy(cursor, digestsCursorPrefix)
+	binary.BigEndian.PutUint32(cursor[len(digestsCursorPrefix):], uint32(bucket/time.Second))
+	return whisper.SyncMailRequest{
+		Lower:  lower,
+		Upper:  upper,
+		Bloom:  bloom,
+		Cursor: cursor,
+	}
+}
+
+// digestsBucket returns the length of the buckets of a request for digests, or false if the
+// request is for envelopes.
+func digestsBucket(request whisper.SyncMailRequest) (uint32, bool) {
+	if len(request.Cursor) != len(digestsCursorPrefix)+4 || !bytes.HasPrefix(request.Cursor, digestsCursorPrefix) {
+		return 0, false
+	}
+	bucket := binary.BigEndian.Uint32(request.Cursor[len(digestsCursorPrefix):])
+	return bucket, bucket > 0
+}
+
+// validateDigests checks that the digests of the buckets of length bucket seconds between
+// lower and upper are cheap enough to compute, as every envelope of the range is read.
+func validateDigests(lower, upper, bucket uint32) error {
+	if bucket < minDigestsBucket {
+		return fmt.Errorf("buckets must be at least %d seconds long", minDigestsBucket)
+	}
+	if upper-lower > maxDigestsRange {
+		return fmt.Errorf("range must be at most %d seconds long", maxDigestsRange)
+	}
+	if (upper-lower)/bucket+1 > maxDigestsBuckets {
+		return fmt.Errorf("range must have at most %d buckets", maxDigestsBuckets)
+	}
+	return nil
+}
+
+// Digests returns the digests of the buckets of length bucket seconds with envelopes
+// between lower and upper matching bloom, sorted by time. Empty buckets are omitted.
+func (s *WMailServer) Digests(lower, upper uint32, bloom []byte, bucket uint32) ([]BucketDigest, error) {
+	var (
+		zero    common.Hash
+		digests []BucketDigest
+	)
+	i := s.db.NewIterator(&util.Range{Start: NewDBKey(lower, zero).Bytes(), Limit: NewDBKey(upper+1, zero).Bytes()}, nil)
+	defer i.Release()
+
+	for i.Next() {
+		var envelope whisper.Envelope
+		if err := rlp.DecodeBytes(i.Value(), &envelope); err != nil {
+			logger.Error("failed to decode RLP", "err", err)
+			continue
+		}
+		if !whisper.BloomFilterMatch(bloom, envelope.Bloom()) {
+			continue
+		}
+		key := NewDBKeyFromBytes(i.Key())
+		start := key.timestamp - key.timestamp%bucket
+		if len(digests) == 0 || digests[len(digests)-1].Lower != start {
+			digests = append(digests, BucketDigest{Lower: start})
+		}
+		digest := &digests[len(digests)-1]
+		digest.Count++
+		for j := range digest.Hash {
+			digest.Hash[j] ^= key.hash[j]
+		}
+	}
+	return digests, i.Error()
+}
+
+// MissingBuckets returns the buckets of remote whose envelopes differ from local.
+func MissingBuckets(local, remote []BucketDigest) []BucketDigest {
+	byLower := make(map[uint32]BucketDigest, len(local))
+	for _, digest := range local {
+		byLower[digest.Lower] = digest
+	}
+	var missing []BucketDigest
+	for _, digest := range remote {
+		if byLower[digest.Lower] != digest {
+			missing = append(missing, digest)
+		}
+	}
+	return missing
+}
+
+// sendDigests responds to a sync request for digests. Digests are only sent to the peers
+// allowed by the authorizer: computing them reads every envelope of the range.
+func (s *WMailServer) sendDigests(peer *whisper.Peer, request whisper.SyncMailRequest, bucket uint32) error {
+	if s.authorizer == nil {
+		_ = s.w.SendSyncResponse(peer, whisper.SyncResponse{Error: "digests are not served"})
+		return errors.New("digests are only served when peers are authorized")
+	}
+	if err := validateDigests(request.Lower, request.Upper, bucket); err != nil {
+		_ = s.w.SendSyncResponse(peer, whisper.SyncResponse{Error: err.Error()})
+		return fmt.Errorf("request for digests is invalid: %v", err)
+	}
+	digests, err := s.Digests(request.Lower, request.Upper, request.Bloom, bucket)
+	if err != nil {
+		_ = s.w.SendSyncResponse(peer, whisper.SyncResponse{Error: "failed to compute digests"})
+		return fmt.Errorf("failed to compute digests: %v", err)
+	}
+	data, err := rlp.EncodeToBytes(digests)
+	if err != nil {
+		return err
+	}
+	logger.Info("Sending digests", "peer", peerIDString(peer), "buckets", len(digests))
+	return s.w.SendSyncResponse(peer, whisper.SyncResponse{Cursor: data, Final: true})
+}
+
+// ReplicaSyncStats are the results of a sync with a replica.
+type ReplicaSyncStats struct {
+	// Buckets is the number of buckets with envelopes of the replica.
+	Buckets int
+	// MissingBuckets is the number of these buckets whose envelopes were synced.
+	MissingBuckets int
+	// Requests is the number of sync requests sent for the envelopes.
+	Requests int
+}
+
+// SyncReplica syncs the envelopes between lower and upper matching bloom from the mailserver
+// peer. The digests of the buckets of length bucket of both mailservers are compared and only
+// the envelopes of the buckets which differ are requested. The peer must be trusted.
+func (s *WMailServer) SyncReplica(ctx context.Context, peer enode.ID, lower, upper uint32, bloom []byte, bucket time.Duration) (ReplicaSyncStats, error) {
+	var stats ReplicaSyncStats
+	defer replicaSyncTimer.UpdateSince(time.Now())
+
+	// Whisper blocks until every subscriber receives its events, the events of other peers
+	// and types must be read while requests are sent.
+	events := make(chan whisper.EnvelopeEvent, 10)
+	responses := make(chan whisper.SyncEventResponse, 1)
+	sub := s.w.SubscribeEnvelopeEvents(events)
+	defer sub.Unsubscribe()
+	go func() {
+		for {
+			select {
+			case event := <-events:
+				resp, ok := event.Data.(whisper.SyncEventResponse)
+				if event.Event != whisper.EventMailServerSyncFinished || event.Peer != peer || !ok {
+					continue
+				}
+				select {
+				case responses <- resp:
+				default:
+					logger.Warn("unexpected sync response", "peer", peer)
+				}
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	sync := func(request whisper.SyncMailRequest) ([]byte, error) {
+		if err := s.w.SyncMessages(peer.Bytes(), request); err != nil {
+			return nil, err
+		}
+		ctx, cancel := context.WithTimeout(ctx, replicaRequestTimeout)
+		defer cancel()
+		select {
+		case resp := <-responses:
+			if resp.Error != "" {
+				return nil, errors.New(resp.Error)
+			}
+			return resp.Cursor, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	data, err := sync(NewDigestsRequest(lower, upper, bloom, bucket))
+	if err != nil {
+		return stats, fmt.Errorf("failed to request digests: %v", err)
+	}
+	var remote []BucketDigest
+	if err := rlp.DecodeBytes(data, &remote); err != nil {
+		return stats, fmt.Errorf("failed to decode digests: %v", err)
+	}
+	local, err := s.Digests(lower, upper, bloom, uint32(bucket/time.Second))
+	if err != nil {
+		return stats, err
+	}
+	missing := MissingBuckets(local, remote)
+	stats.Buckets, stats.MissingBuckets = len(remote), len(missing)
+	replicaBucketsCounter.Inc(int64(len(remote)))
+	replicaMissingBucketsCounter.Inc(int64(len(missing)))
+
+	for _, digest := range missing {
+		request := whisper.SyncMailRequest{
+			Lower: digest.Lower,
+			Upper: digest.Lower + uint32(bucket/time.Second) - 1,
+			Bloom: bloom,
+			Limit: replicaPageLimit,
+		}
+		if request.Lower < lower {
+			request.Lower = lower
+		}
+		if request.Upper > upper {
+			request.Upper = upper
+		}
+		for {
+			stats.Requests++
+			if request.Cursor, err = sync(request); err != nil {
+				return stats, fmt.Errorf("failed to sync bucket %d: %v", digest.Lower, err)
+			}
+			if len(request.Cursor) == 0 {
+				break
+			}
+		}
+	}
+	return stats, nil
+}
+
+// replicaSync is the schedule of the syncs with the replicas.
+type replicaSync struct {
+	peers  []enode.ID
+	period time.Duration
+	bucket time.Duration
+	maxAge time.Duration
+}
+
+// newReplicaSync returns the schedule of config, or nil if it's not enabled.
+func newReplicaSync(config params.ReplicaSyncConfig) (*replicaSync, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+	r := &replicaSync{
+		period: DefaultReplicaSyncPeriod,
+		bucket: DefaultReplicaBucket,
+		maxAge: DefaultReplicaMaxAge,
+	}
+	for _, peer := range config.Peers {
+		node, err := enode.ParseV4(peer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replica '%s': %v", peer, err)
+		}
+		r.peers = append(r.peers, node.ID())
+	}
+	if config.Period > 0 {
+		r.period = time.Duration(config.Period) * time.Second
+	}
+	if config.Bucket > 0 {
+		r.bucket = time.Duration(config.Bucket) * time.Second
+	}
+	if config.MaxAge > 0 {
+		r.maxAge = time.Duration(config.MaxAge) * time.Second
+	}
+	// Replicas refuse to send the digests of larger ranges or shorter buckets.
+	if err := validateDigests(0, uint32(r.maxAge/time.Second), uint32(r.bucket/time.Second)); err != nil {
+		return nil, fmt.Errorf("invalid replica sync: %v", err)
+	}
+	return r, nil
+}
+
+// setupReplicaSync periodically syncs the envelopes of the last maxAge from the replicas,
+// if the sync is enabled. The first sync starts once the replicas had time to connect.
+func (s *WMailServer) setupReplicaSync(r *replicaSync) {
+	if r == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancelReplicaSync = cancel
+	go func() {
+		timer := time.NewTimer(replicaStartDelay)
+		defer timer.Stop()
+		for {
+			select {
+			case <-timer.C:
+				now := time.Now()
+				for _, peer := range r.peers {
+					s.syncReplica(ctx, peer, uint32(now.Add(-r.maxAge).Unix()), uint32(now.Unix()), r.bucket)
+				}
+				timer.Reset(r.period)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// syncReplica syncs all the envelopes between lower and upper from the mailserver peer.
+func (s *WMailServer) syncReplica(ctx context.Context, peer enode.ID, lower, upper uint32, bucket time.Duration) {
+	// Only the sync responses of trusted peers are archived.
+	if err := s.w.AllowP2PMessagesFromPeer(peer.Bytes()); err != nil {
+		logger.Warn("replica is not connected", "peer", peer, "err", err)
+		return
+	}
+	stats, err := s.SyncReplica(ctx, peer, lower, upper, whisper.MakeFullNodeBloom(), bucket)
+	if err != nil {
+		replicaSyncErrorsCounter.Inc(1)
+		logger.Error("failed to sync from replica", "peer", peer, "err", err)
+		return
+	}
+	logger.Info("synced from replica", "peer", peer, "buckets", stats.Buckets, "missing", stats.MissingBuckets, "requests", stats.Requests)
+}
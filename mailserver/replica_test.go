@@ -0,0 +1,91 @@
+package mailserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/status-im/status-go/params"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigestsRequest(t *testing.T) {
+	request := NewDigestsRequest(100, 200, whisper.MakeFullNodeBloom(), time.Hour)
+	require.NoError(t, request.Validate())
+	bucket, ok := digestsBucket(request)
+	require.True(t, ok)
+	require.Equal(t, uint32(3600), bucket)
+
+	request.Cursor = NewDBKey(150, [32]byte{}).Bytes()
+	_, ok = digestsBucket(request)
+	require.False(t, ok, "Requests for envelopes have a DBKey cursor")
+	request.Cursor = nil
+	_, ok = digestsBucket(request)
+	require.False(t, ok)
+}
+
+func TestValidateDigests(t *testing.T) {
+	require.NoError(t, validateDigests(0, 30*24*3600, 3600))
+	require.NoError(t, validateDigests(100, 100+(maxDigestsBuckets-1)*minDigestsBucket, minDigestsBucket))
+	require.Error(t, validateDigests(100, 200, minDigestsBucket-1), "Buckets are too short")
+	require.Error(t, validateDigests(100, 100+maxDigestsBuckets*minDigestsBucket, minDigestsBucket), "There are too many buckets")
+	require.Error(t, validateDigests(0, maxDigestsRange+1, 24*3600), "The range is too long")
+}
+
+func TestNewReplicaSyncLimits(t *testing.T) {
+	peer := "enode://c42f368a23fa98ee546fd247220759062323249ef657d26d357a777443aec04db1b29a3a22ef3e7c548e18493ddaf51a31b0aed6079bd6ebe5ae838fcfaf3a49@127.0.0.1:30303"
+	r, err := newReplicaSync(params.ReplicaSyncConfig{Enabled: true, Peers: []string{peer}})
+	require.NoError(t, err)
+	require.Equal(t, DefaultReplicaBucket, r.bucket)
+
+	_, err = newReplicaSync(params.ReplicaSyncConfig{Enabled: true, Peers: []string{peer}, Bucket: 10})
+	require.Error(t, err)
+	_, err = newReplicaSync(params.ReplicaSyncConfig{Enabled: true, Peers: []string{peer}, Bucket: 60, MaxAge: 30 * 24 * 3600})
+	require.Error(t, err, "The replicas don't send that many digests")
+}
+
+func TestDigestsAndMissingBuckets(t *testing.T) {
+	source := setupTestServer(t)
+	defer source.Close()
+	replica := setupTestServer(t)
+	defer replica.Close()
+
+	start := time.Unix(1546000000-1546000000%60, 0)
+	var envelopes []*whisper.Envelope
+	for i := 0; i < 6; i++ {
+		envelopes = append(envelopes, archiveEnvelope(t, start.Add(time.Duration(i*25)*time.Second), source))
+	}
+	// the replica has the envelopes of the first bucket and one of the second
+	for _, env := range envelopes[:4] {
+		replica.Archive(env)
+	}
+
+	bloom := whisper.MakeFullNodeBloom()
+	lower, upper := uint32(start.Unix()), uint32(start.Add(time.Hour).Unix())
+	remote, err := source.Digests(lower, upper, bloom, 60)
+	require.NoError(t, err)
+	require.Len(t, remote, 3)
+	require.Equal(t, BucketDigest{Lower: lower, Count: 3, Hash: xorHashes(envelopes[:3])}, remote[0])
+	require.Equal(t, uint32(2), remote[1].Count)
+	require.Equal(t, uint32(1), remote[2].Count)
+
+	local, err := replica.Digests(lower, upper, bloom, 60)
+	require.NoError(t, err)
+	require.Len(t, local, 2)
+	require.Equal(t, remote[1:], MissingBuckets(local, remote))
+	require.Empty(t, MissingBuckets(remote, remote))
+
+	none, err := source.Digests(lower, upper, whisper.TopicToBloom(whisper.TopicType{0xAA}), 60)
+	require.NoError(t, err)
+	require.Empty(t, none, "Envelopes which don't match the bloom are not summarized")
+}
+
+func xorHashes(envelopes []*whisper.Envelope) (result [32]byte) {
+	for _, env := range envelopes {
+		hash := env.Hash()
+		for i := range result {
+			result[i] ^= hash[i]
+		}
+	}
+	return result
+}
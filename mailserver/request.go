@@ -1,5 +1,11 @@
 package mailserver
 
+import (
+	"io"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
 // MessagesRequestPayload is a payload sent to the Mail Server.
 type MessagesRequestPayload struct {
 	// Lower is a lower bound of time range for which messages are requested.
@@ -14,4 +20,96 @@ type MessagesRequestPayload struct {
 	Cursor []byte
 	// Batch set to true indicates that the client supports batched response.
 	Batch bool
+	// Features are the optional features the client supports, such as
+	// FeatureResponseSummary. Requests of older clients don't have them.
+	Features []uint32
+	// Credential authorizes the client to request the history of mailservers which
+	// require one. It is optional.
+	Credential *Credential
+}
+
+// messagesRequestPayloadRLP is the encoding of MessagesRequestPayload. The features and
+// the credential follow the other fields, the credential being the only list, so that
+// the requests without a credential are decoded by older mailservers.
+type messagesRequestPayloadRLP struct {
+	Lower  uint32
+	Upper  uint32
+	Bloom  []byte
+	Limit  uint32
+	Cursor []byte
+	Batch  bool
+	Tail   []rlp.RawValue `rlp:"tail"`
+}
+
+// EncodeRLP implements rlp.Encoder.
+func (p MessagesRequestPayload) EncodeRLP(w io.Writer) error {
+	enc := messagesRequestPayloadRLP{
+		Lower:  p.Lower,
+		Upper:  p.Upper,
+		Bloom:  p.Bloom,
+		Limit:  p.Limit,
+		Cursor: p.Cursor,
+		Batch:  p.Batch,
+	}
+	for _, feature := range p.Features {
+		raw, err := rlp.EncodeToBytes(feature)
+		if err != nil {
+			return err
+		}
+		enc.Tail = append(enc.Tail, raw)
+	}
+	if p.Credential != nil {
+		raw, err := rlp.EncodeToBytes(p.Credential)
+		if err != nil {
+			return err
+		}
+		enc.Tail = append(enc.Tail, raw)
+	}
+	return rlp.Encode(w, enc)
+}
+
+// DecodeRLP implements rlp.Decoder.
+func (p *MessagesRequestPayload) DecodeRLP(s *rlp.Stream) error {
+	var dec messagesRequestPayloadRLP
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+	*p = MessagesRequestPayload{
+		Lower:  dec.Lower,
+		Upper:  dec.Upper,
+		Bloom:  dec.Bloom,
+		Limit:  dec.Limit,
+		Cursor: dec.Cursor,
+		Batch:  dec.Batch,
+	}
+	for _, raw := range dec.Tail {
+		kind, _, _, err := rlp.Split(raw)
+		if err != nil {
+			return err
+		}
+		if kind == rlp.List {
+			var credential Credential
+			if err := rlp.DecodeBytes(raw, &credential); err != nil {
+				return err
+			}
+			p.Credential = &credential
+			continue
+		}
+		var feature uint32
+		if err := rlp.DecodeBytes(raw, &feature); err != nil {
+			return err
+		}
+		p.Features = append(p.Features, feature)
+	}
+	return nil
+}
+
+// HasFeature returns true if the client supports a feature.
+func (p MessagesRequestPayload) HasFeature(feature uint32) bool {
+	for _, f := range p.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
 }
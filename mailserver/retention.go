@@ -0,0 +1,157 @@
+package mailserver
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/status-im/status-go/params"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// DefaultCleanupPeriod is the period of the pruner if MailServerCleanupPeriod is not set.
+const DefaultCleanupPeriod = time.Hour
+
+// By default go-ethereum/metrics creates dummy metrics that don't register anything.
+// Real metrics are collected only if -metrics flag is set
+var (
+	retentionScannedCounter = metrics.NewRegisteredCounter("mailserver/retention/scanned", nil)
+	retentionPrunedCounter  = metrics.NewRegisteredCounter("mailserver/retention/pruned", nil)
+	retentionRunTimer       = metrics.NewRegisteredTimer("mailserver/retention/runTime", nil)
+	// retentionProgressGauge is the percentage of the envelopes old enough to be pruned
+	// which were scanned by the current run, by archival time.
+	retentionProgressGauge = metrics.NewRegisteredGauge("mailserver/retention/progress", nil)
+)
+
+// Retention is the policy of the pruner: envelopes are removed once they are older than
+// the max age of their topic, or MaxAge if their topic has none. Zero ages keep envelopes
+// forever.
+type Retention struct {
+	MaxAge       time.Duration
+	TopicMaxAges map[whisper.TopicType]time.Duration
+}
+
+// NewRetention returns the retention policy of config.
+func NewRetention(config *params.WhisperConfig) (Retention, error) {
+	retention := Retention{
+		MaxAge:       time.Duration(config.MailServerMaxAge) * time.Second,
+		TopicMaxAges: make(map[whisper.TopicType]time.Duration, len(config.MailServerTopicMaxAges)),
+	}
+	for hex, seconds := range config.MailServerTopicMaxAges {
+		topic := common.FromHex(hex)
+		if len(topic) != whisper.TopicLength {
+			return retention, fmt.Errorf("invalid topic '%s'", hex)
+		}
+		retention.TopicMaxAges[whisper.BytesToTopic(topic)] = time.Duration(seconds) * time.Second
+	}
+	return retention, nil
+}
+
+// Enabled returns whether envelopes are pruned.
+func (r Retention) Enabled() bool {
+	if r.MaxAge > 0 {
+		return true
+	}
+	for _, age := range r.TopicMaxAges {
+		if age > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// maxAge returns the max age of the envelopes of topic, zero if they are kept forever.
+func (r Retention) maxAge(topic whisper.TopicType) time.Duration {
+	if age, ok := r.TopicMaxAges[topic]; ok {
+		return age
+	}
+	return r.MaxAge
+}
+
+// minAge returns the lowest max age which is not zero.
+func (r Retention) minAge() time.Duration {
+	min := r.MaxAge
+	for _, age := range r.TopicMaxAges {
+		if age > 0 && (min == 0 || age < min) {
+			min = age
+		}
+	}
+	return min
+}
+
+// PruneExpired removes the envelopes older than the max age of their topic at now, and
+// returns how many have been removed. Only the envelopes older than the lowest max age are
+// scanned. If no topic has its own max age, they are removed without being decoded.
+func (c *Cleaner) PruneExpired(retention Retention, now time.Time) (int, error) {
+	if !retention.Enabled() {
+		return 0, nil
+	}
+	defer retentionRunTimer.UpdateSince(time.Now())
+
+	upper := uint32(now.Add(-retention.minAge()).Unix())
+	if len(retention.TopicMaxAges) == 0 {
+		removed, err := c.Prune(0, upper)
+		retentionPrunedCounter.Inc(int64(removed))
+		return removed, err
+	}
+
+	var zero common.Hash
+	i := c.db.NewIterator(&util.Range{Start: NewDBKey(0, zero).Bytes(), Limit: NewDBKey(upper, zero).Bytes()}, nil)
+	defer i.Release()
+
+	var (
+		batch   leveldb.Batch
+		removed int
+		first   uint32
+	)
+	write := func() error {
+		if batch.Len() == 0 {
+			return nil
+		}
+		if err := c.db.Write(&batch, nil); err != nil {
+			return err
+		}
+		removed += batch.Len()
+		retentionPrunedCounter.Inc(int64(batch.Len()))
+		batch.Reset()
+		return nil
+	}
+	for i.Next() {
+		key := NewDBKeyFromBytes(i.Key())
+		if first == 0 {
+			first = key.timestamp
+		}
+		if upper > first {
+			retentionProgressGauge.Update(int64(key.timestamp-first) * 100 / int64(upper-first))
+		}
+		retentionScannedCounter.Inc(1)
+
+		var envelope whisper.Envelope
+		if err := rlp.DecodeBytes(i.Value(), &envelope); err != nil {
+			logger.Error("failed to decode RLP", "err", err)
+			continue
+		}
+		age := retention.maxAge(envelope.Topic)
+		if age == 0 || int64(key.timestamp) >= now.Add(-age).Unix() {
+			continue
+		}
+		batch.Delete(append([]byte(nil), i.Key()...))
+		if batch.Len() == c.batchSize {
+			if err := write(); err != nil {
+				return removed, err
+			}
+		}
+	}
+	if err := i.Error(); err != nil {
+		return removed, err
+	}
+	if err := write(); err != nil {
+		return removed, err
+	}
+	retentionProgressGauge.Update(100)
+	return removed, nil
+}
@@ -0,0 +1,69 @@
+package mailserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/status-im/status-go/params"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRetention(t *testing.T) {
+	retention, err := NewRetention(&params.WhisperConfig{
+		MailServerMaxAge:       3600,
+		MailServerTopicMaxAges: map[string]int{"0x1f7ea17f": 60, "0xaabbccdd": 0},
+	})
+	require.NoError(t, err)
+	require.True(t, retention.Enabled())
+	require.Equal(t, time.Minute, retention.maxAge(whisper.TopicType{0x1F, 0x7E, 0xA1, 0x7F}))
+	require.Equal(t, time.Duration(0), retention.maxAge(whisper.TopicType{0xAA, 0xBB, 0xCC, 0xDD}))
+	require.Equal(t, time.Hour, retention.maxAge(whisper.TopicType{}))
+	require.Equal(t, time.Minute, retention.minAge())
+
+	retention, err = NewRetention(&params.WhisperConfig{MailServerTopicMaxAges: map[string]int{"0xaabbccdd": 0}})
+	require.NoError(t, err)
+	require.False(t, retention.Enabled(), "Zero ages keep envelopes forever")
+
+	_, err = NewRetention(&params.WhisperConfig{MailServerTopicMaxAges: map[string]int{"0xaabb": 60}})
+	require.EqualError(t, err, "invalid topic '0xaabb'")
+}
+
+func TestPruneExpired(t *testing.T) {
+	now := time.Now()
+	server := setupTestServer(t)
+	defer server.Close()
+	cleaner := NewCleanerWithDB(server.db)
+	cleaner.batchSize = 1
+
+	archiveEnvelope(t, now.Add(-60*time.Second), server)
+	archiveEnvelope(t, now.Add(-30*time.Second), server)
+	archiveEnvelope(t, now.Add(-10*time.Second), server)
+	archiveEnvelope(t, now.Add(-1*time.Second), server)
+
+	removed, err := cleaner.PruneExpired(Retention{}, now)
+	require.NoError(t, err)
+	require.Equal(t, 0, removed, "Envelopes are kept forever without max ages")
+
+	// the max age of the topic of the envelopes is longer than the global one
+	topic := whisper.TopicType{0x1F, 0x7E, 0xA1, 0x7F}
+	removed, err = cleaner.PruneExpired(Retention{
+		MaxAge:       5 * time.Second,
+		TopicMaxAges: map[whisper.TopicType]time.Duration{topic: 45 * time.Second, {0xAA}: 2 * time.Second},
+	}, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, removed)
+	testMessagesCount(t, 3, server)
+
+	removed, err = cleaner.PruneExpired(Retention{
+		MaxAge:       5 * time.Second,
+		TopicMaxAges: map[whisper.TopicType]time.Duration{topic: 0},
+	}, now)
+	require.NoError(t, err)
+	require.Equal(t, 0, removed, "A zero max age of a topic overrides the global one")
+
+	removed, err = cleaner.PruneExpired(Retention{MaxAge: 5 * time.Second}, now)
+	require.NoError(t, err)
+	require.Equal(t, 2, removed)
+	testMessagesCount(t, 1, server)
+}
@@ -0,0 +1,155 @@
+package mailserver
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// FeatureResponseSummary is set in the features of a request by clients that
+	// want a signed summary of the response.
+	FeatureResponseSummary uint32 = 1
+
+	// maxSummaryHashes is the maximum number of envelope hashes listed in a summary,
+	// so that it fits in a message. Larger responses are summarized without them.
+	maxSummaryHashes = 10000
+
+	// summaryPayloadPrefix separates the summary from the payload of a completion message.
+	summaryPayloadPrefix = "SUMMARY="
+)
+
+var (
+	// ErrInvalidSummarySignature is returned when a summary is not signed by the expected mailserver.
+	ErrInvalidSummarySignature = errors.New("summary is not signed by the mailserver")
+	// ErrSummaryMismatch is returned when the hashes listed in a summary don't match its count or root.
+	ErrSummaryMismatch = errors.New("summary hashes don't match its count or root")
+)
+
+// ResponseSummary summarizes the envelopes sent in response to a request for historic
+// messages. It is signed with the node key of the mailserver and sent in the completion
+// message, so that clients detect truncated or tampered responses.
+type ResponseSummary struct {
+	RequestID common.Hash
+	// Lower and Upper are the bounds of the time range of the request.
+	Lower uint32
+	Upper uint32
+	// Count is the number of envelopes sent.
+	Count uint32
+	// Root is the merkle root of the hashes of the envelopes sent, in the order they were sent.
+	Root common.Hash
+	// Hashes are the hashes of the envelopes sent. They are omitted for large responses.
+	Hashes    []common.Hash
+	Signature []byte
+}
+
+// NewResponseSummary returns the summary of the envelopes sent in response to a request.
+func NewResponseSummary(requestID common.Hash, lower, upper uint32, hashes []common.Hash) ResponseSummary {
+	summary := ResponseSummary{
+		RequestID: requestID,
+		Lower:     lower,
+		Upper:     upper,
+		Count:     uint32(len(hashes)),
+		Root:      MerkleRoot(hashes),
+	}
+	if len(hashes) <= maxSummaryHashes {
+		summary.Hashes = hashes
+	}
+	return summary
+}
+
+// Sign signs the summary with the node key of the mailserver.
+func (s *ResponseSummary) Sign(key *ecdsa.PrivateKey) error {
+	hash, err := s.signedHash()
+	if err != nil {
+		return err
+	}
+	s.Signature, err = crypto.Sign(hash, key)
+	return err
+}
+
+// Verify checks that the summary is signed by the mailserver and that its hashes
+// match its count and root, unless the response was too large to list them.
+func (s ResponseSummary) Verify(mailServer *ecdsa.PublicKey) error {
+	hash, err := s.signedHash()
+	if err != nil {
+		return err
+	}
+	signer, err := crypto.SigToPub(hash, s.Signature)
+	if err != nil || crypto.PubkeyToAddress(*signer) != crypto.PubkeyToAddress(*mailServer) {
+		return ErrInvalidSummarySignature
+	}
+	if s.Count <= maxSummaryHashes && (int(s.Count) != len(s.Hashes) || MerkleRoot(s.Hashes) != s.Root) {
+		return ErrSummaryMismatch
+	}
+	return nil
+}
+
+// signedHash is the hash of the fields of the summary covered by the signature.
+// Hashes are covered through the root.
+func (s ResponseSummary) signedHash() ([]byte, error) {
+	data, err := rlp.EncodeToBytes([]interface{}{s.RequestID, s.Lower, s.Upper, s.Count, s.Root})
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(data), nil
+}
+
+// EncodeResponseSummary encodes a summary to be sent in a completion message.
+func EncodeResponseSummary(s ResponseSummary) ([]byte, error) {
+	return rlp.EncodeToBytes(s)
+}
+
+// DecodeResponseSummary decodes a summary received in a completion message.
+func DecodeResponseSummary(data []byte) (ResponseSummary, error) {
+	var s ResponseSummary
+	err := rlp.DecodeBytes(data, &s)
+	return s, err
+}
+
+// AppendResponseSummary appends an encoded summary to the payload of a completion
+// message. Whisper rejects such payloads, so it must be sent only to peers that asked
+// for a summary and split it with SplitResponseSummary before Whisper reads it.
+func AppendResponseSummary(payload, summary []byte) []byte {
+	payload = append(payload, summaryPayloadPrefix...)
+	return append(payload, summary...)
+}
+
+// SplitResponseSummary splits the encoded summary from the payload of a completion
+// message. The summary follows either the cursor or the last envelope hash, and it
+// is nil if the payload doesn't have one.
+func SplitResponseSummary(payload []byte) ([]byte, []byte) {
+	prefix := []byte(summaryPayloadPrefix)
+	for _, offset := range []int{common.HashLength*2 + DBKeyLength, common.HashLength * 2} {
+		if len(payload) >= offset+len(prefix) && bytes.Equal(payload[offset:offset+len(prefix)], prefix) {
+			return payload[:offset], payload[offset+len(prefix):]
+		}
+	}
+	return payload, nil
+}
+
+// MerkleRoot returns the merkle root of hashes. Each node is the keccak256 hash of
+// its two children, and the last node of a level with an odd number of nodes is
+// moved up unchanged. The root of no hashes is the zero hash.
+func MerkleRoot(hashes []common.Hash) common.Hash {
+	if len(hashes) == 0 {
+		return common.Hash{}
+	}
+	level := append([]common.Hash{}, hashes...)
+	for len(level) > 1 {
+		next := level[:0]
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, crypto.Keccak256Hash(level[i][:], level[i+1][:]))
+		}
+		level = next
+	}
+	return level[0]
+}
@@ -0,0 +1,89 @@
+package mailserver
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerkleRoot(t *testing.T) {
+	a, b, c := common.Hash{1}, common.Hash{2}, common.Hash{3}
+
+	require.Equal(t, common.Hash{}, MerkleRoot(nil))
+	require.Equal(t, a, MerkleRoot([]common.Hash{a}))
+	ab := crypto.Keccak256Hash(a[:], b[:])
+	require.Equal(t, ab, MerkleRoot([]common.Hash{a, b}))
+	require.Equal(t, crypto.Keccak256Hash(ab[:], c[:]), MerkleRoot([]common.Hash{a, b, c}))
+	require.NotEqual(t, ab, MerkleRoot([]common.Hash{b, a}), "The order of the hashes matters")
+
+	hashes := []common.Hash{a, b, c}
+	MerkleRoot(hashes)
+	require.Equal(t, []common.Hash{a, b, c}, hashes, "Hashes are not modified")
+}
+
+func TestResponseSummarySignature(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	summary := NewResponseSummary(common.Hash{0xaa}, 10, 20, []common.Hash{{1}, {2}, {3}})
+	require.Equal(t, uint32(3), summary.Count)
+	require.NoError(t, summary.Sign(key))
+
+	data, err := EncodeResponseSummary(summary)
+	require.NoError(t, err)
+	decoded, err := DecodeResponseSummary(data)
+	require.NoError(t, err)
+	require.Equal(t, summary, decoded)
+	require.NoError(t, decoded.Verify(&key.PublicKey))
+	require.Equal(t, ErrInvalidSummarySignature, decoded.Verify(&other.PublicKey))
+
+	tampered := decoded
+	tampered.Upper = 30
+	require.Equal(t, ErrInvalidSummarySignature, tampered.Verify(&key.PublicKey))
+
+	tampered = decoded
+	tampered.Hashes = tampered.Hashes[:2]
+	require.Equal(t, ErrSummaryMismatch, tampered.Verify(&key.PublicKey))
+}
+
+func TestResponseSummaryWithoutHashes(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	hashes := make([]common.Hash, maxSummaryHashes+1)
+	summary := NewResponseSummary(common.Hash{0xaa}, 10, 20, hashes)
+	require.Empty(t, summary.Hashes)
+	require.Equal(t, uint32(maxSummaryHashes+1), summary.Count)
+	require.NoError(t, summary.Sign(key))
+	require.NoError(t, summary.Verify(&key.PublicKey))
+}
+
+func TestMessagesRequestPayloadFeatures(t *testing.T) {
+	payload := MessagesRequestPayload{Lower: 10, Upper: 20, Batch: true}
+	require.False(t, payload.HasFeature(FeatureResponseSummary))
+	payload.Features = []uint32{FeatureResponseSummary}
+	require.True(t, payload.HasFeature(FeatureResponseSummary))
+}
+
+func TestSplitResponseSummary(t *testing.T) {
+	requestID, last := common.Hash{1}, common.Hash{2}
+	cursor := make([]byte, DBKeyLength)
+	summary := []byte{0xc0, 1, 2}
+
+	for _, c := range [][]byte{nil, cursor} {
+		payload := whisper.CreateMailServerRequestCompletedPayload(requestID, last, c)
+		withSummary := AppendResponseSummary(append([]byte{}, payload...), summary)
+		stripped, split := SplitResponseSummary(withSummary)
+		require.Equal(t, payload, stripped)
+		require.Equal(t, summary, split)
+
+		stripped, split = SplitResponseSummary(payload)
+		require.Equal(t, payload, stripped)
+		require.Nil(t, split)
+	}
+}
@@ -0,0 +1,123 @@
+package prometheus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// quantiles are the quantiles exported for timers and histograms.
+var quantiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+
+// Handler returns a handler exporting the metrics of registry in the Prometheus text
+// format. Counters and meters are exported as counters, gauges as gauges, and timers
+// and histograms as summaries. Timers are in seconds.
+func Handler(registry metrics.Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		buf := bufio.NewWriter(w)
+		Write(buf, registry)
+		if err := buf.Flush(); err != nil {
+			log.Debug("failed to write metrics", "err", err)
+		}
+	})
+}
+
+// Write writes the metrics of registry in the Prometheus text format, sorted by name.
+func Write(w io.Writer, registry metrics.Registry) {
+	all := map[string]interface{}{}
+	registry.Each(func(name string, metric interface{}) {
+		all[name] = metric
+	})
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		key := mangle(name)
+		switch metric := all[name].(type) {
+		case metrics.Counter:
+			writeValue(w, key, "counter", float64(metric.Count()))
+		case metrics.Meter:
+			writeValue(w, key, "counter", float64(metric.Snapshot().Count()))
+		case metrics.Gauge:
+			writeValue(w, key, "gauge", float64(metric.Value()))
+		case metrics.GaugeFloat64:
+			writeValue(w, key, "gauge", metric.Value())
+		case metrics.Timer:
+			snapshot := metric.Snapshot()
+			seconds := float64(time.Second)
+			values := snapshot.Percentiles(quantiles)
+			for i := range values {
+				values[i] /= seconds
+			}
+			writeSummary(w, key+"_seconds", values, float64(snapshot.Sum())/seconds, snapshot.Count())
+		case metrics.Histogram:
+			snapshot := metric.Snapshot()
+			writeSummary(w, key, snapshot.Percentiles(quantiles), float64(snapshot.Sum()), snapshot.Count())
+		}
+	}
+}
+
+func writeValue(w io.Writer, name, kind string, value float64) {
+	fmt.Fprintf(w, "# TYPE %s %s\n%s %s\n", name, kind, name, format(value))
+}
+
+func writeSummary(w io.Writer, name string, values []float64, sum float64, count int64) {
+	fmt.Fprintf(w, "# TYPE %s summary\n", name)
+	for i, quantile := range quantiles {
+		fmt.Fprintf(w, "%s{quantile=\"%s\"} %s\n", name, format(quantile), format(values[i]))
+	}
+	fmt.Fprintf(w, "%s_sum %s\n%s_count %d\n", name, format(sum), name, count)
+}
+
+func format(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// mangle replaces the characters of a metric name which are invalid in Prometheus, like
+// the slashes separating the names of the packages, with underscores.
+func mangle(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == ':' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// Server runs the HTTP endpoint exporting the metrics.
+type Server struct {
+	server *http.Server
+}
+
+// NewServer returns a new Server exporting the metrics of registry at /metrics on addr,
+// like "127.0.0.1:9305".
+func NewServer(addr string, registry metrics.Registry) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler(registry))
+	return &Server{
+		server: &http.Server{
+			Addr:    addr,
+			Handler: mux,
+		},
+	}
+}
+
+// Go starts the HTTP endpoint in the background.
+func (s *Server) Go() {
+	go func() {
+		log.Info("metrics server stopped", "err", s.server.ListenAndServe())
+	}()
+	log.Info("metrics server started", "addr", s.server.Addr)
+}
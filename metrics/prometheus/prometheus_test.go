@@ -0,0 +1,46 @@
+package prometheus
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	// metrics created while metrics are disabled are stubs
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	registry := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("shhext/envelopesSent", registry).Inc(3)
+	metrics.NewRegisteredGauge("p2p/PeersAbsolute", registry).Update(5)
+	metrics.NewRegisteredGaugeFloat64("shhext/pow/target", registry).Update(0.002)
+	timer := metrics.NewRegisteredTimer("chat/sqlite/query", registry)
+	timer.Update(2 * time.Millisecond)
+	timer.Update(4 * time.Millisecond)
+
+	recorder := httptest.NewRecorder()
+	Handler(registry).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := ioutil.ReadAll(recorder.Body)
+	require.NoError(t, err)
+	require.Equal(t, `# TYPE chat_sqlite_query_seconds summary
+chat_sqlite_query_seconds{quantile="0.5"} 0.003
+chat_sqlite_query_seconds{quantile="0.75"} 0.004
+chat_sqlite_query_seconds{quantile="0.95"} 0.004
+chat_sqlite_query_seconds{quantile="0.99"} 0.004
+chat_sqlite_query_seconds{quantile="0.999"} 0.004
+chat_sqlite_query_seconds_sum 0.006
+chat_sqlite_query_seconds_count 2
+# TYPE p2p_PeersAbsolute gauge
+p2p_PeersAbsolute 5
+# TYPE shhext_envelopesSent counter
+shhext_envelopesSent 3
+# TYPE shhext_pow_target gauge
+shhext_pow_target 0.002
+`, string(body))
+}
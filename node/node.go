@@ -1,6 +1,7 @@
 package node
 
 import (
+	"crypto/ecdsa"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth"
@@ -21,9 +23,20 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/nat"
 	"github.com/status-im/status-go/mailserver"
 	"github.com/status-im/status-go/params"
+	"github.com/status-im/status-go/services/abiregistry"
+	"github.com/status-im/status-go/services/bandwidth"
+	"github.com/status-im/status-go/services/health"
 	"github.com/status-im/status-go/services/peer"
 	"github.com/status-im/status-go/services/personal"
+	"github.com/status-im/status-go/services/scheduler"
 	"github.com/status-im/status-go/services/shhext"
+	"github.com/status-im/status-go/services/shhext/attachments"
+	"github.com/status-im/status-go/services/shhext/echobot"
+	"github.com/status-im/status-go/services/shhext/integrity"
+	"github.com/status-im/status-go/services/shhext/mailservers"
+	"github.com/status-im/status-go/services/shhext/push"
+	"github.com/status-im/status-go/services/shhext/ratelimit"
+	"github.com/status-im/status-go/services/shhext/trust"
 	"github.com/status-im/status-go/services/status"
 	"github.com/status-im/status-go/static"
 	"github.com/status-im/status-go/timesource"
@@ -40,6 +53,10 @@ var (
 	ErrPersonalServiceRegistrationFailure         = errors.New("failed to register the personal api service")
 	ErrStatusServiceRegistrationFailure           = errors.New("failed to register the Status service")
 	ErrPeerServiceRegistrationFailure             = errors.New("failed to register the Peer service")
+	ErrABIRegistryServiceRegistrationFailure      = errors.New("failed to register the ABI registry service")
+	ErrSchedulerServiceRegistrationFailure        = errors.New("failed to register the scheduler service")
+	ErrHealthServiceRegistrationFailure           = errors.New("failed to register the health service")
+	ErrBandwidthServiceRegistrationFailure        = errors.New("failed to register the bandwidth service")
 )
 
 // All general log messages in this package should be routed through this logger.
@@ -71,8 +88,15 @@ func MakeNode(config *params.NodeConfig, db *leveldb.DB) (*node.Node, error) {
 		return nil, fmt.Errorf(ErrNodeMakeFailureFormat, err.Error())
 	}
 
+	// start scheduler service, before the services adding jobs to it
+	if err := activateSchedulerService(stack, db); err != nil {
+		return nil, fmt.Errorf("%v: %v", ErrSchedulerServiceRegistrationFailure, err)
+	}
+
 	// start Ethereum service if we are not expected to use an upstream server
-	if !config.UpstreamConfig.Enabled {
+	if config.ChatOnly {
+		logger.Info("Chat-only mode, Ethereum services are disabled")
+	} else if !config.UpstreamConfig.Enabled {
 		if err := activateLightEthService(stack, config); err != nil {
 			return nil, fmt.Errorf("%v: %v", ErrLightEthRegistrationFailure, err)
 		}
@@ -93,6 +117,13 @@ func MakeNode(config *params.NodeConfig, db *leveldb.DB) (*node.Node, error) {
 		}
 	}
 
+	// start bandwidth service, before the Whisper service whose traffic it accounts
+	if config.BandwidthStatsEnabled && config.WhisperConfig.Enabled {
+		if err := activateBandwidthService(stack, config, db); err != nil {
+			return nil, fmt.Errorf("%v: %v", ErrBandwidthServiceRegistrationFailure, err)
+		}
+	}
+
 	// start Whisper service.
 	if err := activateShhService(stack, config, db); err != nil {
 		return nil, fmt.Errorf("%v: %v", ErrWhisperServiceRegistrationFailure, err)
@@ -104,10 +135,24 @@ func MakeNode(config *params.NodeConfig, db *leveldb.DB) (*node.Node, error) {
 	}
 
 	// start peer service
-	if err := activatePeerService(stack); err != nil {
+	if err := activatePeerService(stack, config, db); err != nil {
 		return nil, fmt.Errorf("%v: %v", ErrPeerServiceRegistrationFailure, err)
 	}
 
+	// start ABI registry service, which only decodes Ethereum calls and logs
+	if !config.ChatOnly {
+		if err := activateABIRegistryService(stack, db); err != nil {
+			return nil, fmt.Errorf("%v: %v", ErrABIRegistryServiceRegistrationFailure, err)
+		}
+	}
+
+	// start health service, after the services it checks
+	if config.HealthChecksEnabled {
+		if err := activateHealthService(stack, config, db); err != nil {
+			return nil, fmt.Errorf("%v: %v", ErrHealthServiceRegistrationFailure, err)
+		}
+	}
+
 	return stack, nil
 }
 
@@ -126,6 +171,7 @@ func newGethNodeConfig(config *params.NodeConfig) (*node.Config, error) {
 			NAT:             nat.Any(),
 			MaxPeers:        config.MaxPeers,
 			MaxPendingPeers: config.MaxPendingPeers,
+			EnableMsgEvents: config.PeerStatsEnabled,
 		},
 		HTTPModules: config.FormatAPIModules(),
 	}
@@ -241,26 +287,101 @@ func activateStatusService(stack *node.Node, config *params.NodeConfig) error {
 	}
 
 	return stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
-		var whisper *whisper.Whisper
-		if err := ctx.Service(&whisper); err != nil {
+		whisper, err := lookupWhisper(ctx.Service)
+		if err != nil {
 			return nil, err
 		}
 		svc := status.New(whisper)
+		if sched, err := lookupScheduler(ctx.Service); err == nil {
+			svc.SetScheduler(sched)
+		}
 		return svc, nil
 	})
 }
 
-func activatePeerService(stack *node.Node) error {
+func activatePeerService(stack *node.Node, config *params.NodeConfig, db *leveldb.DB) error {
 	return stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 		svc := peer.New()
+		if config.PeerStatsEnabled {
+			stats, err := peer.NewStatsRecorder(db, time.Duration(config.PeerStatsWindow)*time.Hour)
+			if err != nil {
+				return nil, err
+			}
+			svc.SetStatsRecorder(stats)
+		}
+		return svc, nil
+	})
+}
+
+func activateSchedulerService(stack *node.Node, db *leveldb.DB) error {
+	return stack.Register(func(*node.ServiceContext) (node.Service, error) {
+		return scheduler.New(db)
+	})
+}
+
+func activateABIRegistryService(stack *node.Node, db *leveldb.DB) error {
+	return stack.Register(func(*node.ServiceContext) (node.Service, error) {
+		return abiregistry.New(db)
+	})
+}
+
+func activateBandwidthService(stack *node.Node, config *params.NodeConfig, db *leveldb.DB) error {
+	return stack.Register(func(*node.ServiceContext) (node.Service, error) {
+		accountant, err := bandwidth.NewAccountant(db, time.Duration(config.BandwidthStatsWindow)*time.Hour)
+		if err != nil {
+			return nil, err
+		}
+		return bandwidth.New(accountant), nil
+	})
+}
+
+// activateHealthService registers the health service with the checks of the mail servers
+// and of the disk space. The check of the upstream RPC server is added once the RPC client
+// is set up.
+func activateHealthService(stack *node.Node, config *params.NodeConfig, db *leveldb.DB) error {
+	return stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		monitor, err := health.NewMonitor(db)
+		if err != nil {
+			return nil, err
+		}
+		var ext *shhext.Service
+		if err := ctx.Service(&ext); err == nil {
+			prober := mailservers.TCPProber{Timeout: mailservers.DefaultProbeTimeout}
+			if err := monitor.Add(health.MailserversCheck(ext, prober)); err != nil {
+				return nil, err
+			}
+		}
+		if config.DataDir != "" {
+			minFree := uint64(health.DefaultMinFreeDiskSpace)
+			if config.MinFreeDiskSpace > 0 {
+				minFree = uint64(config.MinFreeDiskSpace) << 20
+			}
+			if err := monitor.Add(health.DiskSpaceCheck(config.DataDir, minFree)); err != nil {
+				return nil, err
+			}
+		}
+		svc := health.New(monitor, time.Duration(config.HealthChecksInterval)*time.Second)
+		if sched, err := lookupScheduler(ctx.Service); err == nil {
+			svc.SetScheduler(sched)
+		}
 		return svc, nil
 	})
 }
 
-func registerMailServer(whisperService *whisper.Whisper, config *params.WhisperConfig) (err error) {
+// registerMailServer registers a mail server. Summaries of its responses are signed
+// with the node key if it's set, as clients verify them against the enode of the mail server.
+func registerMailServer(whisperService *whisper.Whisper, config *params.WhisperConfig, nodeKey string) (err error) {
 	var mailServer mailserver.WMailServer
 	whisperService.RegisterServer(&mailServer)
 
+	if nodeKey != "" {
+		key, err := crypto.HexToECDSA(nodeKey)
+		if err != nil {
+			return err
+		}
+		mailServer.SetSummaryKey(key)
+	}
+
 	return mailServer.Init(whisperService, config)
 }
 
@@ -270,6 +391,16 @@ func activateShhService(stack *node.Node, config *params.NodeConfig, db *leveldb
 		logger.Info("SHH protocol is disabled")
 		return nil
 	}
+	var validator *trust.Validator
+	if config.ValidateMailServerResponses {
+		minPoW := params.WhisperMinimumPoW
+		if config.WhisperConfig.MinimumPoW > 0 {
+			minPoW = config.WhisperConfig.MinimumPoW
+		}
+		validator = trust.NewValidator(db, minPoW, shhext.EnvelopeSignalHandler{}.MailServerUntrusted)
+	}
+	// summaries of the responses of mail servers are split before Whisper reads them
+	summaries := integrity.NewSummaries()
 	if config.WhisperConfig.EnableNTPSync {
 		if err = stack.Register(func(*node.ServiceContext) (node.Service, error) {
 			return timesource.Default(), nil
@@ -303,7 +434,7 @@ func activateShhService(stack *node.Node, config *params.NodeConfig, db *leveldb
 
 		// enable mail service
 		if config.WhisperConfig.EnableMailServer {
-			if err := registerMailServer(whisperService, &config.WhisperConfig); err != nil {
+			if err := registerMailServer(whisperService, &config.WhisperConfig, config.NodeKey); err != nil {
 				return nil, fmt.Errorf("failed to register MailServer: %v", err)
 			}
 		}
@@ -315,7 +446,24 @@ func activateShhService(stack *node.Node, config *params.NodeConfig, db *leveldb
 			}
 		}
 
-		return whisperService, nil
+		var wrappers []func(p2p.Protocol) p2p.Protocol
+		if config.WhisperConfig.PeerRateLimit > 0 {
+			limiter := ratelimit.NewLimiter(ratelimit.Config{
+				Rate:  config.WhisperConfig.PeerRateLimit,
+				Burst: config.WhisperConfig.PeerRateLimitBurst,
+			}, shhext.EnvelopeSignalHandler{}.PeerThrottled)
+			wrappers = append(wrappers, limiter.Protocol)
+		}
+		if validator != nil {
+			wrappers = append(wrappers, validator.Protocol)
+		}
+		wrappers = append(wrappers, summaries.Protocol)
+		// the traffic is accounted before the other wrappers drop envelopes
+		var bandwidthService *bandwidth.Service
+		if err := ctx.Service(&bandwidthService); err == nil {
+			wrappers = append(wrappers, bandwidthService.Accountant().Protocol)
+		}
+		return &wrappedWhisper{Whisper: whisperService, wrappers: wrappers}, nil
 	})
 	if err != nil {
 		return
@@ -323,8 +471,25 @@ func activateShhService(stack *node.Node, config *params.NodeConfig, db *leveldb
 
 	// TODO(dshulyak) add a config option to enable it by default, but disable if app is started from statusd
 	return stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
-		var whisper *whisper.Whisper
-		if err := ctx.Service(&whisper); err != nil {
+		whisper, err := lookupWhisper(ctx.Service)
+		if err != nil {
+			return nil, err
+		}
+
+		echoBot, echoBotKey, err := echoBotConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		pushServer, pushServerKey, pushServers, err := pushConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		attachmentsBackend, attachmentsLimits, err := attachmentsConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		outgoingRateLimits, err := outgoingRateLimitsConfig(config)
+		if err != nil {
 			return nil, err
 		}
 
@@ -334,13 +499,178 @@ func activateShhService(stack *node.Node, config *params.NodeConfig, db *leveldb
 			Debug:                   config.DebugAPIEnabled,
 			PFSEnabled:              config.PFSEnabled,
 			MailServerConfirmations: config.MailServerConfirmations,
+			TranslatorURL:           config.TranslatorURL,
+			RequestsDedupWindow:     time.Duration(config.MailServerRequestsDedupWindow) * time.Second,
+			EnvelopeRetries:         config.EnvelopeRetries,
+			MailServerPoolSize:      config.MailServerPoolSize,
+			MailServerProbeInterval: time.Duration(config.MailServerProbeInterval) * time.Second,
+			BundleDirectoryNodes:    parseNodes(config.BundleDirectoryNodes),
+			BundleDirectory:         config.BundleDirectory,
+			MaxBundleLookups:        config.MaxBundleLookups,
+			CompressionEnabled:      config.CompressionEnabled,
+			NarrowBloomFilter:       config.NarrowBloomFilter && !config.WhisperConfig.EnableMailServer,
+			DecoyTopics:             config.DecoyTopics,
+			AdaptivePoW:             config.AdaptivePoW,
+			AdaptivePoWMaxTarget:    config.AdaptivePoWMaxTarget,
+			AdaptivePoWMaxQueue:     config.AdaptivePoWMaxQueue,
+			PartitionedTopic:        config.PartitionedTopic,
+			EchoBot:                 echoBot,
+			EchoBotKey:              echoBotKey,
+			PushServer:              pushServer,
+			PushServerKey:           pushServerKey,
+			PushServers:             pushServers,
+			SegmentSize:             config.SegmentSize,
+			AttachmentsBackend:      attachmentsBackend,
+			Attachments:             attachmentsLimits,
+			MediaServer:             mediaServerConfig(config),
+			ContactRequests:         config.ContactRequests,
+			BackupBeforeMigrate:     config.BackupBeforeMigrate,
+			LatencyStats:            config.LatencyStatsEnabled,
+			OutgoingRateLimits:      outgoingRateLimits,
+			ResponseValidator:       validator,
+			ResponseSummaries:       summaries,
 		}
 
 		svc := shhext.New(whisper, shhext.EnvelopeSignalHandler{}, db, config)
+		if sched, err := lookupScheduler(ctx.Service); err == nil {
+			svc.SetScheduler(sched)
+		}
 		return svc, nil
 	})
 }
 
+// echoBotConfig returns the configuration and the key of the echo bot, or nil if it is disabled.
+func echoBotConfig(config *params.NodeConfig) (*echobot.Config, *ecdsa.PrivateKey, error) {
+	if !config.EchoBot {
+		return nil, nil, nil
+	}
+	var key *ecdsa.PrivateKey
+	if config.EchoBotKey != "" {
+		var err error
+		if key, err = crypto.HexToECDSA(config.EchoBotKey); err != nil {
+			return nil, nil, err
+		}
+	}
+	return &echobot.Config{
+		Delay:     time.Duration(config.EchoBotDelay) * time.Millisecond,
+		Loss:      config.EchoBotLoss,
+		Receipts:  config.EchoBotReceipts,
+		Reactions: config.EchoBotReactions,
+	}, key, nil
+}
+
+// outgoingRateLimitsConfig returns the budgets of the envelopes sent by the node, by network type.
+func outgoingRateLimitsConfig(config *params.NodeConfig) (map[string]ratelimit.Budgets, error) {
+	if len(config.OutgoingRateLimits) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]ratelimit.Budgets, len(config.OutgoingRateLimits))
+	for networkType, limit := range config.OutgoingRateLimits {
+		budgets := ratelimit.Budgets{
+			Global:  ratelimit.Budget{Rate: limit.Global.Rate, Burst: limit.Global.Burst},
+			Classes: make(map[string]ratelimit.Budget, len(limit.Classes)),
+		}
+		for class, budget := range limit.Classes {
+			budgets.Classes[class] = ratelimit.Budget{Rate: budget.Rate, Burst: budget.Burst}
+		}
+		if err := budgets.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid OutgoingRateLimits of '%s': %v", networkType, err)
+		}
+		result[networkType] = budgets
+	}
+	return result, nil
+}
+
+// pushConfig returns the configuration and the key of the push notification server, or nil
+// if it is disabled, and the default push notification servers.
+func pushConfig(config *params.NodeConfig) (*push.ServerConfig, *ecdsa.PrivateKey, []*ecdsa.PublicKey, error) {
+	var servers []*ecdsa.PublicKey
+	for _, server := range config.PushServers {
+		key, err := crypto.UnmarshalPubkey(common.FromHex(server))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		servers = append(servers, key)
+	}
+	if !config.PushServer {
+		return nil, nil, servers, nil
+	}
+	var key *ecdsa.PrivateKey
+	if config.PushServerKey != "" {
+		var err error
+		if key, err = crypto.HexToECDSA(config.PushServerKey); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return &push.ServerConfig{GorushURL: config.PushServerGorushURL}, key, servers, nil
+}
+
+// attachmentsConfig returns the storage backend and the configuration of the attachments.
+// The backend is nil if attachments are disabled.
+func attachmentsConfig(config *params.NodeConfig) (attachments.Backend, attachments.Config, error) {
+	limits := attachments.DefaultConfig()
+	if config.AttachmentsMaxSize > 0 {
+		limits.MaxSize = config.AttachmentsMaxSize
+	}
+	if config.AttachmentsCacheSize > 0 {
+		limits.CacheSize = config.AttachmentsCacheSize
+	}
+	if config.AttachmentsBackend == "" {
+		return nil, limits, nil
+	}
+	backend, err := attachments.NewBackend(config.AttachmentsBackend, config.AttachmentsURL)
+	return backend, limits, err
+}
+
+// mediaServerConfig returns the configuration of the media server, or nil if it is disabled.
+func mediaServerConfig(config *params.NodeConfig) *attachments.ServerConfig {
+	if !config.MediaServerEnabled {
+		return nil
+	}
+	return &attachments.ServerConfig{
+		Port:   config.MediaServerPort,
+		URLTTL: time.Duration(config.MediaURLTTL) * time.Second,
+	}
+}
+
+// wrappedWhisper is the Whisper service with a protocol that drops the
+// envelopes received from a peer above the rate limit, or the invalid
+// envelopes returned by mail servers, splits the summaries of their responses,
+// and accounts the traffic.
+type wrappedWhisper struct {
+	*whisper.Whisper
+	wrappers []func(p2p.Protocol) p2p.Protocol
+}
+
+// Protocols returns the wrapped Whisper protocol.
+func (w *wrappedWhisper) Protocols() []p2p.Protocol {
+	protocols := w.Whisper.Protocols()
+	for i := range protocols {
+		for _, wrap := range w.wrappers {
+			protocols[i] = wrap(protocols[i])
+		}
+	}
+	return protocols
+}
+
+// lookupWhisper returns the Whisper service, which is registered as a
+// wrappedWhisper.
+func lookupWhisper(service func(interface{}) error) (*whisper.Whisper, error) {
+	var wrapped *wrappedWhisper
+	if err := service(&wrapped); err != nil {
+		return nil, err
+	}
+	return wrapped.Whisper, nil
+}
+
+func lookupScheduler(service func(interface{}) error) (*scheduler.Scheduler, error) {
+	var svc *scheduler.Service
+	if err := service(&svc); err != nil {
+		return nil, err
+	}
+	return svc.Scheduler(), nil
+}
+
 // parseNodes creates list of enode.Node out of enode strings.
 func parseNodes(enodes []string) []*enode.Node {
 	var nodes []*enode.Node
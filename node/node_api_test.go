@@ -3,9 +3,8 @@ package node
 import (
 	"testing"
 
-	whisper "github.com/status-im/whisper/whisperv6"
-
 	"github.com/status-im/status-go/params"
+	"github.com/status-im/status-go/services/shhext"
 	"github.com/stretchr/testify/require"
 )
 
@@ -22,8 +21,8 @@ func TestWhisperLightModeEnabledSetsEmptyBloomFilter(t *testing.T) {
 		require.NoError(t, node.Stop())
 	}()
 
-	var whisper *whisper.Whisper
-	require.NoError(t, node.gethService(&whisper))
+	whisper, err := node.WhisperService()
+	require.NoError(t, err)
 
 	bloomFilter := whisper.BloomFilter()
 	expectedEmptyBloomFilter := make([]byte, 64)
@@ -44,7 +43,54 @@ func TestWhisperLightModeEnabledSetsNilBloomFilter(t *testing.T) {
 		require.NoError(t, node.Stop())
 	}()
 
-	var whisper *whisper.Whisper
-	require.NoError(t, node.gethService(&whisper))
+	whisper, err := node.WhisperService()
+	require.NoError(t, err)
 	require.Nil(t, whisper.BloomFilter())
 }
+
+func TestWhisperPeerRateLimit(t *testing.T) {
+	config := params.NodeConfig{
+		WhisperConfig: params.WhisperConfig{
+			Enabled:            true,
+			PeerRateLimit:      10,
+			PeerRateLimitBurst: 100,
+		},
+	}
+	node := New()
+	require.NoError(t, node.Start(&config))
+	defer func() {
+		require.NoError(t, node.Stop())
+	}()
+
+	var wrapped *wrappedWhisper
+	require.NoError(t, node.gethService(&wrapped))
+	whisper, err := node.WhisperService()
+	require.NoError(t, err)
+	require.Equal(t, wrapped.Whisper, whisper)
+	_, err = node.ShhExtService()
+	require.NoError(t, err)
+}
+
+func TestWhisperResponseValidation(t *testing.T) {
+	config := params.NodeConfig{
+		ValidateMailServerResponses: true,
+		WhisperConfig: params.WhisperConfig{
+			Enabled:            true,
+			PeerRateLimit:      10,
+			PeerRateLimitBurst: 100,
+		},
+	}
+	node := New()
+	require.NoError(t, node.Start(&config))
+	defer func() {
+		require.NoError(t, node.Stop())
+	}()
+
+	var wrapped *wrappedWhisper
+	require.NoError(t, node.gethService(&wrapped))
+	require.Len(t, wrapped.wrappers, 3, "Responses are validated along with the rate limit and the summaries")
+	service, err := node.ShhExtService()
+	require.NoError(t, err)
+	_, err = shhext.NewPublicAPI(service).GetMailserverTrust()
+	require.NoError(t, err)
+}
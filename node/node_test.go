@@ -5,8 +5,11 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/les"
+	gethnode "github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/status-im/status-go/params"
+	"github.com/status-im/status-go/services/abiregistry"
 	. "github.com/status-im/status-go/t/utils"
 	"github.com/stretchr/testify/require"
 	"github.com/syndtr/goleveldb/leveldb"
@@ -27,6 +30,28 @@ func TestMakeNodeDefaultConfig(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestMakeNodeChatOnly(t *testing.T) {
+	config, err := MakeTestNodeConfig(3)
+	require.NoError(t, err)
+	require.NoError(t, params.WithChatOnly()(config))
+	require.NoError(t, config.Validate())
+
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+
+	stack, err := MakeNode(config, db)
+	require.NoError(t, err)
+	require.NoError(t, stack.Start())
+	defer stack.Stop() // nolint: errcheck
+
+	var les *les.LightEthereum
+	require.Equal(t, gethnode.ErrServiceUnknown, stack.Service(&les), "LES is not started")
+	var abi *abiregistry.Service
+	require.Equal(t, gethnode.ErrServiceUnknown, stack.Service(&abi))
+	_, err = lookupWhisper(stack.Service)
+	require.NoError(t, err)
+}
+
 func TestMakeNodeWellFormedBootnodes(t *testing.T) {
 	config, err := MakeTestNodeConfig(3)
 	require.NoError(t, err)
@@ -7,12 +7,13 @@ import (
 	"net"
 	"os"
 	"path/filepath"
-	"reflect"
 	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/les"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/node"
@@ -23,19 +24,33 @@ import (
 	whisper "github.com/status-im/whisper/whisperv6"
 	"github.com/syndtr/goleveldb/leveldb"
 
+	"github.com/status-im/status-go/bugreport"
 	"github.com/status-im/status-go/db"
 	"github.com/status-im/status-go/discovery"
+	"github.com/status-im/status-go/logutils"
 	"github.com/status-im/status-go/params"
 	"github.com/status-im/status-go/peers"
 	"github.com/status-im/status-go/rpc"
+	"github.com/status-im/status-go/services/abiregistry"
+	"github.com/status-im/status-go/services/bandwidth"
+	"github.com/status-im/status-go/services/ens"
+	"github.com/status-im/status-go/services/health"
 	"github.com/status-im/status-go/services/peer"
+	"github.com/status-im/status-go/services/permissions"
+	"github.com/status-im/status-go/services/scheduler"
 	"github.com/status-im/status-go/services/shhext"
 	"github.com/status-im/status-go/services/status"
+	"github.com/status-im/status-go/services/stickers"
+	"github.com/status-im/status-go/services/wallet"
+	"github.com/status-im/status-go/services/walletconnect"
 )
 
 // tickerResolution is the delta to check blockchain sync progress.
 const tickerResolution = time.Second
 
+// bugReportsDir is the directory of the bug reports in the data directory.
+const bugReportsDir = "bugreports"
+
 // errors
 var (
 	ErrNodeRunning            = errors.New("node is already running")
@@ -50,12 +65,13 @@ var (
 type StatusNode struct {
 	mu sync.RWMutex
 
-	config           *params.NodeConfig // Status node configuration
-	gethNode         *node.Node         // reference to Geth P2P stack/node
-	rpcClient        *rpc.Client        // reference to public RPC client
-	rpcPrivateClient *rpc.Client        // reference to private RPC client (can call private APIs)
+	config           *params.NodeConfig     // Status node configuration
+	gethNode         *node.Node             // reference to Geth P2P stack/node
+	rpcClient        *rpc.Client            // reference to public RPC client
+	rpcPrivateClient *rpc.Client            // reference to private RPC client (can call private APIs)
+	networkClients   map[uint64]*rpc.Client // RPC clients of the additional networks, by chain ID
 
-	discovery discovery.Discovery
+	discovery *discovery.DualStack
 	register  *peers.Register
 	peerPool  *peers.PeerPool
 	db        *leveldb.DB // used as a cache for PeerPool
@@ -112,6 +128,10 @@ func (n *StatusNode) startWithDB(config *params.NodeConfig, db *leveldb.DB, serv
 		return err
 	}
 
+	if err := n.addUpstreamHealthCheck(); err != nil {
+		return err
+	}
+
 	if n.discoveryEnabled() {
 		return n.startDiscovery()
 	}
@@ -182,11 +202,140 @@ func (n *StatusNode) setupRPCClient() (err error) {
 	if err != nil {
 		return
 	}
-	n.rpcPrivateClient, err = rpc.NewClient(gethNodePrivateClient, n.config.UpstreamConfig)
+	n.rpcPrivateClient = rpc.NewPrivateClient(gethNodePrivateClient, n.rpcClient)
+
+	// setup RPC clients of the additional networks
+	n.networkClients = make(map[uint64]*rpc.Client, len(n.config.Networks))
+	for _, network := range n.config.Networks {
+		n.networkClients[network.ChainID], err = rpc.NewNetworkClient(network.UpstreamConfig(n.config.UpstreamConfig))
+		if err != nil {
+			return
+		}
+	}
+	n.rpcPrivateClient.RegisterHandler(params.UpstreamStatsMethodName, n.upstreamStats)
+	n.rpcPrivateClient.RegisterHandler(params.UpstreamCacheStatsMethodName, n.upstreamCacheStats)
+	n.rpcPrivateClient.RegisterHandler(params.SetLogLevelMethodName, setLogLevel)
+	n.rpcPrivateClient.RegisterHandler(params.LogLevelsMethodName, logLevels)
+	n.rpcPrivateClient.RegisterHandler(params.CreateBugReportMethodName, n.createBugReport)
 
 	return
 }
 
+// addUpstreamHealthCheck adds the check of the upstream RPC server to the health service,
+// if both are enabled.
+func (n *StatusNode) addUpstreamHealthCheck() error {
+	if !n.config.UpstreamConfig.Enabled || !n.config.HealthChecksEnabled {
+		return nil
+	}
+	var svc *health.Service
+	if err := n.gethService(&svc); err != nil {
+		return err
+	}
+	return svc.Monitor().Add(health.UpstreamCheck(n.rpcClient))
+}
+
+// setLogLevel is the handler changing the log level of a module, like
+// debug_setLogLevel("shhext", "DEBUG"), or of the root logger if only the level is given.
+func setLogLevel(_ context.Context, args ...interface{}) (interface{}, error) {
+	var module, level interface{}
+	switch len(args) {
+	case 1:
+		module, level = "", args[0]
+	case 2:
+		module, level = args[0], args[1]
+	default:
+		return nil, errors.New("expected a level, or a module and a level")
+	}
+	moduleStr, ok := module.(string)
+	if !ok {
+		return nil, errors.New("module must be a string")
+	}
+	levelStr, ok := level.(string)
+	if !ok {
+		return nil, errors.New("level must be a string")
+	}
+	return nil, logutils.SetLogLevel(moduleStr, levelStr)
+}
+
+// logLevels is the handler of the log levels of the modules, with the level of the root
+// logger under an empty module.
+func logLevels(context.Context, ...interface{}) (interface{}, error) {
+	return logutils.LogLevels(), nil
+}
+
+// createBugReport is the handler creating a bug report encrypted for the hex-encoded public
+// key of the recipient, like debug_createBugReport("0x04..."), returning the path of the file.
+func (n *StatusNode) createBugReport(_ context.Context, args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, errors.New("expected the public key of the recipient")
+	}
+	keyHex, ok := args[0].(string)
+	if !ok {
+		return nil, errors.New("public key must be a string")
+	}
+	key, err := crypto.UnmarshalPubkey(common.FromHex(keyHex))
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %v", err)
+	}
+
+	config := n.Config()
+	if config == nil {
+		return nil, ErrNoRunningNode
+	}
+	report := bugreport.NewReport(config)
+	report.Logs = logutils.RecentLogs()
+	if server := n.Server(); server != nil {
+		report.Peers = server.PeersInfo()
+	}
+	if svc, err := n.ShhExtService(); err == nil {
+		results, err := svc.IntegrityCheck()
+		report.AddIntegrityCheck("chat", results, err)
+	}
+
+	path := filepath.Join(config.DataDir, bugReportsDir, fmt.Sprintf("bugreport-%d.enc", report.Time.Unix()))
+	if err := report.WriteFile(path, key); err != nil {
+		return nil, err
+	}
+	return path, nil
+}
+
+// upstreamStats is the handler of the stats of the upstream RPC endpoints, by chain ID.
+func (n *StatusNode) upstreamStats(context.Context, ...interface{}) (interface{}, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.rpcClient == nil {
+		return nil, ErrNoRunningNode
+	}
+	stats := map[uint64][]rpc.UpstreamStats{}
+	if upstream := n.rpcClient.UpstreamStats(); upstream != nil {
+		stats[n.config.NetworkID] = upstream
+	}
+	for chainID, client := range n.networkClients {
+		stats[chainID] = client.UpstreamStats()
+	}
+	return stats, nil
+}
+
+// upstreamCacheStats is the handler of the stats of the caches of the upstream RPC calls,
+// by chain ID.
+func (n *StatusNode) upstreamCacheStats(context.Context, ...interface{}) (interface{}, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.rpcClient == nil {
+		return nil, ErrNoRunningNode
+	}
+	stats := map[uint64]*rpc.CacheStats{}
+	if cache := n.rpcClient.CacheStats(); cache != nil {
+		stats[n.config.NetworkID] = cache
+	}
+	for chainID, client := range n.networkClients {
+		if cache := client.CacheStats(); cache != nil {
+			stats[chainID] = cache
+		}
+	}
+	return stats, nil
+}
+
 func (n *StatusNode) discoveryEnabled() bool {
 	return n.config != nil && (!n.config.NoDiscovery || n.config.Rendezvous) && n.config.ClusterConfig.Enabled
 }
@@ -236,30 +385,34 @@ func (n *StatusNode) startRendezvous() (discovery.Discovery, error) {
 }
 
 func (n *StatusNode) startDiscovery() error {
-	discoveries := []discovery.Discovery{}
+	mechanisms := []discovery.Mechanism{}
 	if !n.config.NoDiscovery {
-		discoveries = append(discoveries, discovery.NewDiscV5(
-			n.gethNode.Server().PrivateKey,
-			n.config.ListenAddr,
-			parseNodesV5(n.config.ClusterConfig.BootNodes)))
+		mechanisms = append(mechanisms, discovery.Mechanism{
+			Name: discovery.EthereumV5,
+			Discovery: discovery.NewDiscV5(
+				n.gethNode.Server().PrivateKey,
+				n.config.ListenAddr,
+				parseNodesV5(n.config.ClusterConfig.BootNodes)),
+		})
 	}
 	if n.config.Rendezvous {
 		d, err := n.startRendezvous()
 		if err != nil {
 			return err
 		}
-		discoveries = append(discoveries, d)
+		mechanisms = append(mechanisms, discovery.Mechanism{Name: discovery.RendezvousV1, Discovery: d})
 	}
-	if len(discoveries) == 0 {
+	if len(mechanisms) == 0 {
 		return errors.New("wasn't able to register any discovery")
-	} else if len(discoveries) > 1 {
-		n.discovery = discovery.NewMultiplexer(discoveries)
-	} else {
-		n.discovery = discoveries[0]
 	}
+	names := make([]string, len(mechanisms))
+	for i, m := range mechanisms {
+		names[i] = m.Name
+	}
+	n.discovery = discovery.NewDualStack(mechanisms, n.discoveryTopicTargets())
 	log.Debug(
 		"using discovery",
-		"instance", reflect.TypeOf(n.discovery),
+		"mechanisms", names,
 		"registerTopics", n.config.RegisterTopics,
 		"requireTopics", n.config.RequireTopics,
 	)
@@ -277,6 +430,11 @@ func (n *StatusNode) startDiscovery() error {
 		peers.NewCache(n.db),
 		options,
 	)
+	// discovery can take several seconds to start, so dial the peers
+	// of the previous sessions before it completes
+	if err := n.peerPool.DialCachedPeers(n.gethNode.Server(), n.rpcClient); err != nil {
+		return err
+	}
 	if err := n.discovery.Start(); err != nil {
 		return err
 	}
@@ -286,6 +444,52 @@ func (n *StatusNode) startDiscovery() error {
 	return n.peerPool.Start(n.gethNode.Server(), n.rpcClient)
 }
 
+// DiscoverySummary returns the connected peers with the discovery mechanisms which
+// found them, and the health of the mechanisms.
+func (n *StatusNode) DiscoverySummary() (*peer.DiscoverySummary, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if !n.isRunning() {
+		return nil, ErrNoRunningNode
+	}
+
+	summary := &peer.DiscoverySummary{
+		Peers:      []peer.DiscoveredPeer{},
+		Mechanisms: []discovery.Health{},
+	}
+	if n.discovery != nil {
+		summary.Mechanisms = n.discovery.Health()
+	}
+	for _, info := range n.gethNode.Server().PeersInfo() {
+		p := peer.DiscoveredPeer{
+			ID:            info.ID,
+			Name:          info.Name,
+			RemoteAddress: info.Network.RemoteAddress,
+			Inbound:       info.Network.Inbound,
+			DiscoveredBy:  []string{},
+		}
+		if node, err := enode.ParseV4(info.Enode); err == nil && n.discovery != nil {
+			p.DiscoveredBy = n.discovery.Sources(node.ID())
+		}
+		summary.Peers = append(summary.Peers, p)
+	}
+	return summary, nil
+}
+
+// discoveryTopicTargets returns the number of peers to find per topic, the max limit
+// of RequireTopics unless DiscoveryTopicTargets sets it.
+func (n *StatusNode) discoveryTopicTargets() map[string]int {
+	targets := make(map[string]int, len(n.config.RequireTopics))
+	for topic, limits := range n.config.RequireTopics {
+		targets[string(topic)] = limits.Max
+	}
+	for topic, target := range n.config.DiscoveryTopicTargets {
+		targets[string(topic)] = target
+	}
+	return targets
+}
+
 // Stop will stop current StatusNode. A stopped node cannot be resumed.
 func (n *StatusNode) Stop() error {
 	n.mu.Lock()
@@ -308,12 +512,23 @@ func (n *StatusNode) stop() error {
 		n.peerPool = nil
 	}
 
+	if n.rpcClient != nil {
+		n.rpcClient.UnsubscribeAll()
+	}
+
 	if err := n.gethNode.Stop(); err != nil {
 		return err
 	}
 
+	if n.rpcClient != nil {
+		n.rpcClient.Close()
+	}
+	for _, client := range n.networkClients {
+		client.Close()
+	}
 	n.rpcClient = nil
 	n.rpcPrivateClient = nil
+	n.networkClients = nil
 	// We need to clear `gethNode` because config is passed to `Start()`
 	// and may be completely different. Similarly with `config`.
 	n.gethNode = nil
@@ -522,12 +737,129 @@ func (n *StatusNode) PeerService() (st *peer.Service, err error) {
 	return
 }
 
+// SchedulerService exposes reference to the scheduler service running on top of the node.
+func (n *StatusNode) SchedulerService() (st *scheduler.Service, err error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	err = n.gethService(&st)
+	if err == node.ErrServiceUnknown {
+		err = ErrServiceUnknown
+	}
+
+	return
+}
+
+// HealthService exposes reference to the health service running on top of the node.
+func (n *StatusNode) HealthService() (st *health.Service, err error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	err = n.gethService(&st)
+	if err == node.ErrServiceUnknown {
+		err = ErrServiceUnknown
+	}
+
+	return
+}
+
+// BandwidthService exposes reference to the bandwidth service running on top of the node.
+func (n *StatusNode) BandwidthService() (st *bandwidth.Service, err error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	err = n.gethService(&st)
+	if err == node.ErrServiceUnknown {
+		err = ErrServiceUnknown
+	}
+
+	return
+}
+
+// ABIRegistryService exposes reference to the ABI registry service running on top of the node.
+func (n *StatusNode) ABIRegistryService() (st *abiregistry.Service, err error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	err = n.gethService(&st)
+	if err == node.ErrServiceUnknown {
+		err = ErrServiceUnknown
+	}
+
+	return
+}
+
+// ENSService exposes reference to the ENS service running on top of the node.
+func (n *StatusNode) ENSService() (st *ens.Service, err error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	err = n.gethService(&st)
+	if err == node.ErrServiceUnknown {
+		err = ErrServiceUnknown
+	}
+
+	return
+}
+
+// PermissionsService exposes reference to the dapp permissions service running on top of the node.
+func (n *StatusNode) PermissionsService() (st *permissions.Service, err error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	err = n.gethService(&st)
+	if err == node.ErrServiceUnknown {
+		err = ErrServiceUnknown
+	}
+
+	return
+}
+
+// WalletConnectService exposes reference to the WalletConnect service running on top of the node.
+func (n *StatusNode) WalletConnectService() (st *walletconnect.Service, err error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	err = n.gethService(&st)
+	if err == node.ErrServiceUnknown {
+		err = ErrServiceUnknown
+	}
+
+	return
+}
+
+// StickersService exposes reference to the stickers service running on top of the node.
+func (n *StatusNode) StickersService() (st *stickers.Service, err error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	err = n.gethService(&st)
+	if err == node.ErrServiceUnknown {
+		err = ErrServiceUnknown
+	}
+
+	return
+}
+
+// WalletService exposes reference to the wallet service running on top of the node.
+func (n *StatusNode) WalletService() (st *wallet.Service, err error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	err = n.gethService(&st)
+	if err == node.ErrServiceUnknown {
+		err = ErrServiceUnknown
+	}
+
+	return
+}
+
 // WhisperService exposes reference to Whisper service running on top of the node
 func (n *StatusNode) WhisperService() (w *whisper.Whisper, err error) {
 	n.mu.RLock()
 	defer n.mu.RUnlock()
 
-	err = n.gethService(&w)
+	w, err = lookupWhisper(n.gethService)
 	if err == node.ErrServiceUnknown {
 		err = ErrServiceUnknown
 	}
@@ -590,6 +922,17 @@ func (n *StatusNode) RPCClient() *rpc.Client {
 	return n.rpcClient
 }
 
+// NetworkRPCClient exposes reference to the RPC client of a network, the RPC client
+// connected to the running node for its network, or nil if the network is unknown.
+func (n *StatusNode) NetworkRPCClient(chainID uint64) *rpc.Client {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.config != nil && chainID == n.config.NetworkID {
+		return n.rpcClient
+	}
+	return n.networkClients[chainID]
+}
+
 // RPCPrivateClient exposes reference to RPC client connected to the running node
 // that can call both public and private APIs.
 func (n *StatusNode) RPCPrivateClient() *rpc.Client {
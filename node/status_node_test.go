@@ -1,23 +1,32 @@
 package node
 
 import (
+	"fmt"
 	"io/ioutil"
 	"math"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/les"
 	gethnode "github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	whisper "github.com/status-im/whisper/whisperv6"
 
+	"github.com/status-im/status-go/bugreport"
 	"github.com/status-im/status-go/discovery"
 	"github.com/status-im/status-go/params"
+	"github.com/status-im/status-go/services/bandwidth"
+	"github.com/status-im/status-go/services/health"
 	"github.com/status-im/status-go/t/helpers"
 	"github.com/status-im/status-go/t/utils"
 	"github.com/stretchr/testify/require"
@@ -282,7 +291,19 @@ func TestStatusNodeRendezvousDiscovery(t *testing.T) {
 	require.NoError(t, n.Start(&config))
 	require.NotNil(t, n.discovery)
 	require.True(t, n.discovery.Running())
-	require.IsType(t, &discovery.Rendezvous{}, n.discovery)
+	health := n.discovery.Health()
+	require.Len(t, health, 1)
+	require.Equal(t, discovery.RendezvousV1, health[0].Name)
+
+	summary, err := n.DiscoverySummary()
+	require.NoError(t, err)
+	require.Empty(t, summary.Peers)
+	require.Len(t, summary.Mechanisms, 1)
+	require.True(t, summary.Mechanisms[0].Preferred)
+
+	require.NoError(t, n.Stop())
+	_, err = n.DiscoverySummary()
+	require.Equal(t, ErrNoRunningNode, err)
 }
 
 func TestStatusNodeDiscoverNode(t *testing.T) {
@@ -307,3 +328,76 @@ func TestStatusNodeDiscoverNode(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, net.ParseIP("127.0.0.2").To4(), node.IP())
 }
+
+func TestStatusNodeHealthChecks(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":1,"jsonrpc":"2.0","result":"0x10"}`)
+	}))
+	defer upstream.Close()
+
+	config := params.NodeConfig{
+		HealthChecksEnabled: true,
+		UpstreamConfig:      params.UpstreamRPCConfig{Enabled: true, URL: upstream.URL},
+	}
+	n := New()
+	require.NoError(t, n.Start(&config))
+	defer func() { require.NoError(t, n.Stop()) }()
+
+	_, err := n.HealthService()
+	require.NoError(t, err)
+
+	var report health.Report
+	require.NoError(t, n.RPCPrivateClient().Call(&report, "status_checkHealth"))
+	require.Equal(t, health.StatusDegraded, report.Status, "The node has no peers")
+	require.Len(t, report.Checks, 2)
+	require.Equal(t, health.Result{
+		Name:    health.UpstreamCheckName,
+		Status:  health.StatusOK,
+		Details: "block 16",
+		Time:    report.Checks[1].Time,
+		Since:   report.Checks[1].Time,
+	}, report.Checks[1])
+}
+
+func TestStatusNodeCreateBugReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bugreport")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	config := params.NodeConfig{DataDir: dir}
+	n := New()
+	require.NoError(t, n.Start(&config))
+	defer func() { require.NoError(t, n.Stop()) }()
+
+	var reportPath string
+	require.Error(t, n.RPCPrivateClient().Call(&reportPath, params.CreateBugReportMethodName, "0x1234"))
+	require.NoError(t, n.RPCPrivateClient().Call(&reportPath, params.CreateBugReportMethodName, hexutil.Encode(crypto.FromECDSAPub(&key.PublicKey))))
+	require.Equal(t, filepath.Join(dir, bugReportsDir), filepath.Dir(reportPath))
+
+	data, err := ioutil.ReadFile(reportPath)
+	require.NoError(t, err)
+	_, err = bugreport.Decrypt(data, key)
+	require.NoError(t, err)
+}
+
+func TestStatusNodeBandwidthStats(t *testing.T) {
+	config := params.NodeConfig{
+		WhisperConfig:         params.WhisperConfig{Enabled: true},
+		BandwidthStatsEnabled: true,
+	}
+	n := New()
+	require.NoError(t, n.Start(&config))
+	defer func() { require.NoError(t, n.Stop()) }()
+
+	_, err := n.BandwidthService()
+	require.NoError(t, err)
+	_, err = n.WhisperService()
+	require.NoError(t, err, "Whisper is registered with the accounted protocol")
+
+	var stats bandwidth.Stats
+	require.NoError(t, n.RPCPrivateClient().Call(&stats, "status_bandwidthStats"))
+	require.Equal(t, bandwidth.Counters{}, stats.Total, "The node has no peers")
+	require.Empty(t, stats.Peers)
+}
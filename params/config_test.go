@@ -108,6 +108,42 @@ func TestNodeConfigValidate(t *testing.T) {
 				"NoDiscovery": true
 			}`,
 		},
+		{
+			Name: "Negative outgoing rate limit",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/tmp/data",
+				"BackupDisabledDataDir": "/tmp/data",
+				"KeyStoreDir": "/tmp/data",
+				"NoDiscovery": true,
+				"OutgoingRateLimits": {"cellular": {"Classes": {"sync": {"Rate": -1}}}}
+			}`,
+			Error: "OutgoingRateLimits of 'cellular' must not be negative",
+		},
+		{
+			Name: "Negative discovery topic target",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/tmp/data",
+				"BackupDisabledDataDir": "/tmp/data",
+				"KeyStoreDir": "/tmp/data",
+				"NoDiscovery": true,
+				"DiscoveryTopicTargets": {"whisper": -1}
+			}`,
+			Error: "DiscoveryTopicTargets of 'whisper' must not be negative",
+		},
+		{
+			Name: "Negative bandwidth stats window",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/tmp/data",
+				"BackupDisabledDataDir": "/tmp/data",
+				"KeyStoreDir": "/tmp/data",
+				"NoDiscovery": true,
+				"BandwidthStatsWindow": -1
+			}`,
+			Error: "BandwidthStatsWindow can't be negative",
+		},
 		{
 			Name:   "Invalid JSON config",
 			Config: `{"NetworkId": }`,
@@ -207,6 +243,52 @@ func TestNodeConfigValidate(t *testing.T) {
 			}`,
 			Error: "NoDiscovery is false, but ClusterConfig.BootNodes is empty",
 		},
+		{
+			Name: "Validate that KeyStoreKDF.Name is a known key derivation function",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"BackupDisabledDataDir": "/some/dir",
+				"KeyStoreDir": "/some/dir",
+				"NoDiscovery": true,
+				"KeyStoreKDF": {
+					"Name": "bcrypt"
+				}
+			}`,
+			Error: "KeyStoreKDF.Name must be scrypt or argon2id",
+		},
+		{
+			Name: "Validate that KeyStoreKDF.ScryptN is a power of 2",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"BackupDisabledDataDir": "/some/dir",
+				"KeyStoreDir": "/some/dir",
+				"NoDiscovery": true,
+				"KeyStoreKDF": {
+					"Name": "scrypt",
+					"ScryptN": 1000
+				}
+			}`,
+			Error: "KeyStoreKDF.ScryptN must be a power of 2 greater than 1",
+		},
+		{
+			Name: "Validate that argon2id is accepted as KeyStoreKDF",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"BackupDisabledDataDir": "/some/dir",
+				"KeyStoreDir": "/some/dir",
+				"NoDiscovery": true,
+				"KeyStoreKDF": {
+					"Name": "argon2id",
+					"Argon2Memory": 262144
+				}
+			}`,
+			CheckFunc: func(t *testing.T, config *params.NodeConfig) {
+				require.Equal(t, uint32(262144), config.KeyStoreKDF.Argon2Memory)
+			},
+		},
 		{
 			Name: "Validate that ClusterConfig.RendezvousNodes is verified to be empty if Rendezvous is disabled",
 			Config: `{
@@ -337,6 +419,79 @@ func TestNodeConfigValidate(t *testing.T) {
 			}`,
 			Error: "WhisperConfig.MailServerAsymKey is invalid",
 		},
+		{
+			Name: "Validate that WhisperConfig.MailServerPostgres.URI is required if it's enabled",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"BackupDisabledDataDir": "/some/dir",
+				"KeyStoreDir": "/some/dir",
+				"NoDiscovery": true,
+				"WhisperConfig": {
+					"Enabled": true,
+					"EnableMailServer": true,
+					"DataDir": "/foo",
+					"MailServerPassword": "status-offline-inbox",
+					"MailServerPostgres": {"Enabled": true}
+				}
+			}`,
+			Error: "WhisperConfig.MailServerPostgres.URI must be specified when WhisperConfig.MailServerPostgres is enabled",
+		},
+		{
+			Name: "Validate that WhisperConfig.MailServerTopicMaxAges topics are checked for validity",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"BackupDisabledDataDir": "/some/dir",
+				"KeyStoreDir": "/some/dir",
+				"NoDiscovery": true,
+				"WhisperConfig": {
+					"Enabled": true,
+					"EnableMailServer": true,
+					"DataDir": "/foo",
+					"MailServerPassword": "status-offline-inbox",
+					"MailServerMaxAge": 2592000,
+					"MailServerTopicMaxAges": {"0xf8946a": 0}
+				}
+			}`,
+			Error: "WhisperConfig.MailServerTopicMaxAges has an invalid topic '0xf8946a'",
+		},
+		{
+			Name: "Validate that WhisperConfig.MailServerAllowedKeys are checked for validity",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"BackupDisabledDataDir": "/some/dir",
+				"KeyStoreDir": "/some/dir",
+				"NoDiscovery": true,
+				"WhisperConfig": {
+					"Enabled": true,
+					"EnableMailServer": true,
+					"DataDir": "/foo",
+					"MailServerPassword": "status-offline-inbox",
+					"MailServerAllowedKeys": ["0x04aabb"]
+				}
+			}`,
+			Error: "WhisperConfig.MailServerAllowedKeys has an invalid public key '0x04aabb': invalid secp256k1 public key",
+		},
+		{
+			Name: "Validate that WhisperConfig.MailServerReplicaSync.Peers are required if it's enabled",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"BackupDisabledDataDir": "/some/dir",
+				"KeyStoreDir": "/some/dir",
+				"NoDiscovery": true,
+				"WhisperConfig": {
+					"Enabled": true,
+					"EnableMailServer": true,
+					"DataDir": "/foo",
+					"MailServerPassword": "status-offline-inbox",
+					"MailServerReplicaSync": {"Enabled": true}
+				}
+			}`,
+			Error: "WhisperConfig.MailServerReplicaSync.Peers must be specified when WhisperConfig.MailServerReplicaSync is enabled",
+		},
 		{
 			Name: "Validate that PFSEnabled & InstallationID are checked for validity",
 			Config: `{
@@ -353,6 +508,142 @@ func TestNodeConfigValidate(t *testing.T) {
 			}`,
 			Error: "PFSEnabled is true, but InstallationID is empty",
 		},
+		{
+			Name: "Validate that ChatOnly is exclusive with LES",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"ChatOnly": true,
+				"BackupDisabledDataDir": "/some/dir",
+				"KeyStoreDir": "/some/dir",
+				"NoDiscovery": true,
+				"LightEthConfig": {
+					"Enabled": true
+				},
+				"WhisperConfig": {
+					"Enabled": true
+				}
+			}`,
+			Error: "ChatOnly is true, but LightEthConfig or UpstreamConfig is enabled",
+		},
+		{
+			Name: "Validate that ChatOnly requires Whisper",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"ChatOnly": true,
+				"BackupDisabledDataDir": "/some/dir",
+				"KeyStoreDir": "/some/dir",
+				"NoDiscovery": true
+			}`,
+			Error: "ChatOnly is true, but WhisperConfig is disabled",
+		},
+		{
+			Name: "Validate that Networks require the upstream RPC",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"BackupDisabledDataDir": "/some/dir",
+				"KeyStoreDir": "/some/dir",
+				"NoDiscovery": true,
+				"Networks": [{"ChainID": 10, "Name": "Optimism", "RPCURL": "https://mainnet.optimism.io"}]
+			}`,
+			Error: "Networks are set, but UpstreamConfig is disabled",
+		},
+		{
+			Name: "Validate that Networks are not set twice",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"BackupDisabledDataDir": "/some/dir",
+				"KeyStoreDir": "/some/dir",
+				"NoDiscovery": true,
+				"UpstreamConfig": {
+					"Enabled": true,
+					"URL": "http://upstream.example.com"
+				},
+				"Networks": [{"ChainID": 1, "Name": "Mainnet", "RPCURL": "https://mainnet.example.com"}]
+			}`,
+			Error: "network 1 is set twice",
+		},
+		{
+			Name: "Validate that Networks have an RPC URL",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"BackupDisabledDataDir": "/some/dir",
+				"KeyStoreDir": "/some/dir",
+				"NoDiscovery": true,
+				"UpstreamConfig": {
+					"Enabled": true,
+					"URL": "http://upstream.example.com"
+				},
+				"Networks": [{"ChainID": 10, "Name": "Optimism"}]
+			}`,
+			Error: "network 10 has no RPCURL",
+		},
+		{
+			Name: "Set Networks",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"BackupDisabledDataDir": "/some/dir",
+				"KeyStoreDir": "/some/dir",
+				"NoDiscovery": true,
+				"UpstreamConfig": {
+					"Enabled": true,
+					"URL": "http://upstream.example.com"
+				},
+				"Networks": [{"ChainID": 10, "Name": "Optimism", "RPCURL": "https://mainnet.optimism.io"}]
+			}`,
+			CheckFunc: func(t *testing.T, config *params.NodeConfig) {
+				require.Len(t, config.Networks, 1)
+				require.Equal(t, uint64(10), config.Networks[0].ChainID)
+			},
+		},
+		{
+			Name: "Validate that upstream fallback URLs are valid",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"BackupDisabledDataDir": "/some/dir",
+				"KeyStoreDir": "/some/dir",
+				"NoDiscovery": true,
+				"UpstreamConfig": {
+					"Enabled": true,
+					"URL": "http://upstream.example.com",
+					"FallbackURLs": ["upstream"]
+				}
+			}`,
+			Error: "UpstreamRPCConfig.FallbackURLs 'upstream' is invalid: parse \"upstream\": invalid URI for request",
+		},
+		{
+			Name: "Set upstream fallback URLs",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"BackupDisabledDataDir": "/some/dir",
+				"KeyStoreDir": "/some/dir",
+				"NoDiscovery": true,
+				"UpstreamConfig": {
+					"Enabled": true,
+					"URL": "http://upstream.example.com",
+					"FallbackURLs": ["http://fallback.example.com"],
+					"RateLimit": 10
+				},
+				"Networks": [{"ChainID": 10, "Name": "Optimism", "RPCURL": "https://mainnet.optimism.io", "FallbackRPCURLs": ["https://optimism.example.com"]}]
+			}`,
+			CheckFunc: func(t *testing.T, config *params.NodeConfig) {
+				require.Equal(t, []string{"http://fallback.example.com"}, config.UpstreamConfig.FallbackURLs)
+				require.Equal(t, params.UpstreamRPCConfig{
+					Enabled:      true,
+					URL:          "https://mainnet.optimism.io",
+					FallbackURLs: []string{"https://optimism.example.com"},
+					RateLimit:    10,
+					CacheSize:    params.UpstreamCacheSize,
+				}, config.Networks[0].UpstreamConfig(config.UpstreamConfig), "Networks share the config of the upstream, like the default cache")
+			},
+		},
 		{
 			Name: "Default HTTP virtual hosts is localhost and CORS is empty",
 			Config: `{
@@ -18,6 +18,30 @@ const (
 	// PersonalRecoverMethodName defines the name for `personal.recover` API.
 	PersonalRecoverMethodName = "personal_ecRecover"
 
+	// SignTypedDataV3MethodName defines the name for signing EIP-712 typed data without arrays.
+	SignTypedDataV3MethodName = "eth_signTypedData_v3"
+
+	// SignTypedDataV4MethodName defines the name for signing EIP-712 typed data with arrays.
+	SignTypedDataV4MethodName = "eth_signTypedData_v4"
+
+	// UpstreamStatsMethodName defines the name for the stats of the upstream RPC endpoints.
+	UpstreamStatsMethodName = "upstream_stats"
+
+	// UpstreamCacheStatsMethodName defines the name for the stats of the cache of the upstream RPC calls.
+	UpstreamCacheStatsMethodName = "upstream_cacheStats"
+
+	// SetLogLevelMethodName defines the name for changing the log level of a module at runtime.
+	SetLogLevelMethodName = "debug_setLogLevel"
+
+	// LogLevelsMethodName defines the name for the log levels of the modules.
+	LogLevelsMethodName = "debug_logLevels"
+
+	// CreateBugReportMethodName defines the name for creating an encrypted bug report.
+	CreateBugReportMethodName = "debug_createBugReport"
+
+	// LogFormatJSON is the format of the logs written as JSON objects, one per line.
+	LogFormatJSON = "json"
+
 	// DefaultGas default amount of gas used for transactions
 	DefaultGas = 180000
 
@@ -39,6 +63,9 @@ const (
 	// allow us avoid syncing node.
 	RinkebyEthereumNetworkURL = "https://rinkeby.infura.io/nKmXgiFgc2KqtoQ8BCGJ"
 
+	// UpstreamCacheSize is the default number of results of read-only upstream calls cached.
+	UpstreamCacheSize = 1000
+
 	// MainNetworkID is id of the main network
 	MainNetworkID = 1
 
@@ -59,6 +86,12 @@ const (
 
 	// LESDiscoveryIdentifier is a prefix for topic used for LES peers discovery.
 	LESDiscoveryIdentifier = "LES2@"
+
+	// KDFScrypt is the name of the scrypt key derivation function of the keystore.
+	KDFScrypt = "scrypt"
+
+	// KDFArgon2id is the name of the argon2id key derivation function of the keystore.
+	KDFArgon2id = "argon2id"
 )
 
 var (
@@ -1,6 +1,10 @@
 package peers
 
 import (
+	"encoding/json"
+	"sort"
+	"time"
+
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p/discv5"
 	"github.com/ethereum/go-ethereum/p2p/enode"
@@ -9,14 +13,35 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
+// cachedPeerExpiration is the time after which a peer that was not seen
+// is no longer dialed on startup.
+const cachedPeerExpiration = 7 * 24 * time.Hour
+
 // NewCache returns instance of PeersDatabase
 func NewCache(db *leveldb.DB) *Cache {
-	return &Cache{db: db}
+	return &Cache{db: db, now: time.Now}
 }
 
 // Cache maintains list of peers that were discovered.
 type Cache struct {
-	db *leveldb.DB
+	db  *leveldb.DB
+	now func() time.Time
+}
+
+// cachedPeer is a peer stored in the cache.
+type cachedPeer struct {
+	Node *discv5.Node
+	// LastSeen is the last time a connection with the peer was established.
+	// It is zero for the peers cached by older versions.
+	LastSeen time.Time
+	// Score is the number of times a connection with the peer was established.
+	Score int
+}
+
+type cachedPeerRecord struct {
+	Node     string `json:"node"`
+	LastSeen int64  `json:"lastSeen"`
+	Score    int    `json:"score"`
 }
 
 func makePeerKey(peerID enode.ID, topic discv5.Topic) []byte {
@@ -24,6 +49,7 @@ func makePeerKey(peerID enode.ID, topic discv5.Topic) []byte {
 }
 
 // AddPeer stores peer with a following key: <topic><peer ID>
+// If the peer is already stored, its score is increased.
 func (d *Cache) AddPeer(peer *discv5.Node, topic discv5.Topic) error {
 	data, err := peer.MarshalText()
 	if err != nil {
@@ -33,7 +59,20 @@ func (d *Cache) AddPeer(peer *discv5.Node, topic discv5.Topic) error {
 	if err != nil {
 		return err
 	}
-	return d.db.Put(makePeerKey(enode.PubkeyToIDV4(pk), topic), data, nil)
+	key := makePeerKey(enode.PubkeyToIDV4(pk), topic)
+	record := cachedPeerRecord{Node: string(data)}
+	if value, err := d.db.Get(key, nil); err == nil {
+		if cached, err := decodeCachedPeer(value); err == nil {
+			record.Score = cached.Score
+		}
+	}
+	record.Score++
+	record.LastSeen = d.now().Unix()
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return d.db.Put(key, value, nil)
 }
 
 // RemovePeer deletes a peer from database.
@@ -43,21 +82,77 @@ func (d *Cache) RemovePeer(nodeID enode.ID, topic discv5.Topic) error {
 
 // GetPeersRange returns peers for a given topic with a limit.
 func (d *Cache) GetPeersRange(topic discv5.Topic, limit int) (nodes []*discv5.Node) {
+	d.iteratePeers(topic, func(peer cachedPeer) bool {
+		if len(nodes) == limit {
+			return false
+		}
+		nodes = append(nodes, peer.Node)
+		return true
+	})
+	return nodes
+}
+
+// GetBestPeers returns the peers of a given topic which were seen recently,
+// the highest scores and the most recently seen first.
+func (d *Cache) GetBestPeers(topic discv5.Topic, limit int) []*discv5.Node {
+	var peers []cachedPeer
+	expired := d.now().Add(-cachedPeerExpiration)
+	d.iteratePeers(topic, func(peer cachedPeer) bool {
+		if peer.LastSeen.IsZero() || peer.LastSeen.After(expired) {
+			peers = append(peers, peer)
+		}
+		return true
+	})
+	sort.SliceStable(peers, func(i, j int) bool {
+		if peers[i].Score != peers[j].Score {
+			return peers[i].Score > peers[j].Score
+		}
+		return peers[i].LastSeen.After(peers[j].LastSeen)
+	})
+	if len(peers) > limit {
+		peers = peers[:limit]
+	}
+	nodes := make([]*discv5.Node, len(peers))
+	for i, peer := range peers {
+		nodes[i] = peer.Node
+	}
+	return nodes
+}
+
+// iteratePeers calls fn for every peer of a topic until it returns false.
+func (d *Cache) iteratePeers(topic discv5.Topic, fn func(cachedPeer) bool) {
 	key := db.Key(db.PeersCache, []byte(topic))
 	// it is important to set Limit on the range passed to iterator, so that
 	// we limit reads only to particular topic.
 	iterator := d.db.NewIterator(util.BytesPrefix(key), nil)
 	defer iterator.Release()
-	count := 0
-	for iterator.Next() && count < limit {
-		node := discv5.Node{}
+	for iterator.Next() {
 		value := iterator.Value()
-		if err := node.UnmarshalText(value); err != nil {
+		peer, err := decodeCachedPeer(value)
+		if err != nil {
 			log.Error("can't unmarshal node", "value", value, "error", err)
 			continue
 		}
-		nodes = append(nodes, &node)
-		count++
+		if !fn(peer) {
+			return
+		}
 	}
-	return nodes
+}
+
+// decodeCachedPeer decodes a cached peer. Older versions stored only the node URL.
+func decodeCachedPeer(value []byte) (peer cachedPeer, err error) {
+	var record cachedPeerRecord
+	if err := json.Unmarshal(value, &record); err != nil {
+		record = cachedPeerRecord{Node: string(value)}
+	}
+	node := discv5.Node{}
+	if err := node.UnmarshalText([]byte(record.Node)); err != nil {
+		return peer, err
+	}
+	peer.Node = &node
+	peer.Score = record.Score
+	if record.LastSeen > 0 {
+		peer.LastSeen = time.Unix(record.LastSeen, 0)
+	}
+	return peer, nil
 }
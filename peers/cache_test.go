@@ -3,6 +3,7 @@ package peers
 import (
 	"net"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/p2p/enode"
 
@@ -67,6 +68,51 @@ func TestMultipleTopics(t *testing.T) {
 	}
 }
 
+func TestBestPeers(t *testing.T) {
+	peersDB, err := newInMemoryCache()
+	require.NoError(t, err)
+	topic := discv5.Topic("test")
+	now := time.Unix(1546000000, 0)
+	peersDB.now = func() time.Time { return now }
+
+	peers, err := createDiscv5Peers(4)
+	require.NoError(t, err)
+
+	// peers[0] was seen once but too long ago
+	require.NoError(t, peersDB.AddPeer(peers[0], topic))
+	now = now.Add(cachedPeerExpiration + time.Hour)
+	// peers[1] was seen twice
+	require.NoError(t, peersDB.AddPeer(peers[1], topic))
+	require.NoError(t, peersDB.AddPeer(peers[1], topic))
+	// peers[2] was seen once, after peers[3]
+	require.NoError(t, peersDB.AddPeer(peers[3], topic))
+	now = now.Add(time.Minute)
+	require.NoError(t, peersDB.AddPeer(peers[2], topic))
+
+	require.Equal(t, []*discv5.Node{peers[1], peers[2], peers[3]}, peersDB.GetBestPeers(topic, 5))
+	require.Equal(t, []*discv5.Node{peers[1]}, peersDB.GetBestPeers(topic, 1))
+	require.Len(t, peersDB.GetPeersRange(topic, 5), 4, "expired peers are kept in the cache")
+}
+
+func TestLegacyCachedPeers(t *testing.T) {
+	peersDB, err := newInMemoryCache()
+	require.NoError(t, err)
+	topic := discv5.Topic("test")
+
+	peers, err := createDiscv5Peers(1)
+	require.NoError(t, err)
+	data, err := peers[0].MarshalText()
+	require.NoError(t, err)
+	pk, err := peers[0].ID.Pubkey()
+	require.NoError(t, err)
+	// older versions stored only the node URL
+	require.NoError(t, peersDB.db.Put(makePeerKey(enode.PubkeyToIDV4(pk), topic), data, nil))
+
+	require.Equal(t, []*discv5.Node{peers[0]}, peersDB.GetBestPeers(topic, 5))
+	require.NoError(t, peersDB.AddPeer(peers[0], topic))
+	require.Equal(t, []*discv5.Node{peers[0]}, peersDB.GetPeersRange(topic, 5))
+}
+
 // newInMemoryCache creates a cache for tests
 func newInMemoryCache() (*Cache, error) {
 	memdb, err := leveldb.Open(storage.NewMemStorage(), nil)
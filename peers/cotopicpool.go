@@ -48,6 +48,12 @@ func (t *cacheOnlyTopicPool) MaxReached() bool {
 	return len(peers) >= t.limits.Max
 }
 
+// DialCachedPeers does not dial mail servers as they are only verified
+// and cached but never kept connected by this pool.
+func (t *cacheOnlyTopicPool) DialCachedPeers(server *p2p.Server) int {
+	return 0
+}
+
 var sendEnodeDiscovered = signal.SendEnodeDiscovered
 
 // ConfirmAdded calls base TopicPool ConfirmAdded method and sends a signal
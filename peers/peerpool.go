@@ -104,6 +104,7 @@ type PeerPool struct {
 
 	mu                 sync.RWMutex
 	topics             []TopicPoolInterface
+	searching          bool
 	serverSubscription event.Subscription
 	events             chan *p2p.PeerEvent
 	quit               chan struct{}
@@ -128,7 +129,8 @@ func (p *PeerPool) setDiscoveryTimeout() {
 	}
 }
 
-// Start creates topic pool for each topic in config and subscribes to server events.
+// Start creates topic pool for each topic in config and subscribes to server events,
+// unless DialCachedPeers did it already, and starts searching for nodes.
 func (p *PeerPool) Start(server *p2p.Server, rpcClient contracts.RPCClient) error {
 	if !p.discovery.Running() {
 		return ErrDiscv5NotRunning
@@ -137,22 +139,53 @@ func (p *PeerPool) Start(server *p2p.Server, rpcClient contracts.RPCClient) erro
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	// init channels
-	p.quit = make(chan struct{})
-	p.updateTopic = make(chan *updateTopicRequest)
+	if err := p.init(server, rpcClient); err != nil {
+		return err
+	}
 	p.setDiscoveryTimeout()
 
-	// subscribe to peer events
-	p.events = make(chan *p2p.PeerEvent, 20)
-	p.serverSubscription = server.SubscribeEvents(p.events)
-	p.wg.Add(1)
-	go func() {
-		p.handleServerPeers(server, p.events)
-		p.wg.Done()
-	}()
+	for _, topicPool := range p.topics {
+		if err := topicPool.StartSearch(server); err != nil {
+			return err
+		}
+	}
+	p.searching = true
+
+	// discovery must be already started when pool is started
+	signal.SendDiscoveryStarted()
+
+	return nil
+}
 
-	// collect topics and start searching for nodes
-	p.topics = make([]TopicPoolInterface, 0, len(p.config))
+// DialCachedPeers creates topic pool for each topic in config, subscribes to server
+// events and adds the best peers cached in the previous sessions to the server.
+// It does not require discovery, which can take several seconds to start, so that
+// the node can connect to the peers it knows before discovery completes.
+func (p *PeerPool) DialCachedPeers(server *p2p.Server, rpcClient contracts.RPCClient) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.init(server, rpcClient); err != nil {
+		return err
+	}
+
+	dialed := 0
+	for _, topicPool := range p.topics {
+		dialed += topicPool.DialCachedPeers(server)
+	}
+	log.Debug("dialed cached peers", "count", dialed)
+
+	return nil
+}
+
+// init creates topic pools and subscribes to server events once.
+func (p *PeerPool) init(server *p2p.Server, rpcClient contracts.RPCClient) error {
+	if p.topics != nil {
+		return nil
+	}
+
+	// collect topics
+	topics := make([]TopicPoolInterface, 0, len(p.config))
 	for topic, limits := range p.config {
 		var topicPool TopicPoolInterface
 		t := newTopicPool(p.discovery, topic, limits, p.opts.SlowSync, p.opts.FastSync, p.cache)
@@ -165,18 +198,34 @@ func (p *PeerPool) Start(server *p2p.Server, rpcClient contracts.RPCClient) erro
 		} else {
 			topicPool = t
 		}
-		if err := topicPool.StartSearch(server); err != nil {
-			return err
-		}
-		p.topics = append(p.topics, topicPool)
+		topics = append(topics, topicPool)
 	}
+	p.topics = topics
 
-	// discovery must be already started when pool is started
-	signal.SendDiscoveryStarted()
+	// init channels
+	p.quit = make(chan struct{})
+	p.updateTopic = make(chan *updateTopicRequest)
+
+	// subscribe to peer events
+	p.events = make(chan *p2p.PeerEvent, 20)
+	p.serverSubscription = server.SubscribeEvents(p.events)
+	p.wg.Add(1)
+	go func() {
+		p.handleServerPeers(server, p.events)
+		p.wg.Done()
+	}()
 
 	return nil
 }
 
+// isSearching returns true once the pool is started. Until then, discovery
+// is neither restarted nor stopped by the pool.
+func (p *PeerPool) isSearching() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.searching
+}
+
 func (p *PeerPool) initVerifier(rpcClient contracts.RPCClient) (v Verifier, err error) {
 	if addr := p.opts.MailServerRegistryAddress; addr != "" {
 		caller := contracts.NewContractCaller(rpcClient)
@@ -226,6 +275,9 @@ func (p *PeerPool) stopDiscovery(server *p2p.Server) {
 
 // restartDiscovery and search for topics that have peer count below min
 func (p *PeerPool) restartDiscovery(server *p2p.Server) error {
+	if !p.isSearching() {
+		return nil
+	}
 	if !p.discovery.Running() {
 		if err := p.startDiscovery(); err != nil {
 			return err
@@ -314,7 +366,7 @@ func (p *PeerPool) handleAddedPeer(server *p2p.Server, nodeID enode.ID) {
 // limit or its delay stop is expired, additionally will stop discovery if all
 // peers are stopped.
 func (p *PeerPool) handleStopTopics(server *p2p.Server) {
-	if !p.opts.AllowStop {
+	if !p.opts.AllowStop || !p.isSearching() {
 		return
 	}
 	for _, t := range p.topics {
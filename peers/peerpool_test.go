@@ -411,6 +411,55 @@ func TestPeerPoolNotAllowedStopping(t *testing.T) {
 	require.True(t, discovery.Running())
 }
 
+func TestPeerPoolDialCachedPeersBeforeDiscovery(t *testing.T) {
+	maxCachedPeersMultiplier = 1
+	servers := make([]*p2p.Server, 2)
+	for i := range servers {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		servers[i] = &p2p.Server{
+			Config: p2p.Config{
+				MaxPeers:    10,
+				Name:        common.MakeName("peer-"+strconv.Itoa(i), "1.0"),
+				ListenAddr:  "0.0.0.0:0",
+				PrivateKey:  key,
+				NoDiscovery: true,
+				Protocols:   whisperv6.New(nil).Protocols(),
+			},
+		}
+		require.NoError(t, servers[i].Start())
+		defer servers[i].Stop()
+	}
+
+	// the second server was connected in a previous session
+	topic := discv5.Topic("cap=test")
+	cache, err := newInMemoryCache()
+	require.NoError(t, err)
+	self := servers[1].Self()
+	node := discv5.NewNode(discv5.PubkeyID(self.Pubkey()), net.ParseIP("127.0.0.1"), uint16(self.TCP()), uint16(self.TCP()))
+	require.NoError(t, cache.AddPeer(node, topic))
+
+	events := make(chan *p2p.PeerEvent, 20)
+	subscription := servers[0].SubscribeEvents(events)
+	defer subscription.Unsubscribe()
+
+	discovery := discovery.NewDiscV5(servers[0].PrivateKey, servers[0].ListenAddr, nil)
+	poolOpts := &Options{DefaultFastSync, DefaultSlowSync, 0, true, 100 * time.Millisecond, nil, ""}
+	pool := NewPeerPool(discovery, map[discv5.Topic]params.Limits{topic: params.NewLimits(1, 1)}, cache, poolOpts)
+	require.NoError(t, pool.DialCachedPeers(servers[0], nil))
+	defer pool.Stop()
+	require.False(t, discovery.Running())
+
+	select {
+	case ev := <-events:
+		require.Equal(t, p2p.PeerEventTypeAdd, ev.Type)
+		require.Equal(t, self.ID(), ev.Peer)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for a cached peer")
+	}
+	require.Equal(t, ErrDiscv5NotRunning, pool.Start(servers[0], nil))
+}
+
 func (s *PeerPoolSimulationSuite) TestUpdateTopicLimits() {
 	s.setupEthV5()
 	var err error
@@ -30,6 +30,7 @@ type TopicPoolInterface interface {
 	BelowMin() bool
 	SearchRunning() bool
 	StartSearch(server *p2p.Server) error
+	DialCachedPeers(server *p2p.Server) int
 	ConfirmDropped(server *p2p.Server, nodeID enode.ID) bool
 	AddPeerFromTable(server *p2p.Server) *discv5.Node
 	MaxReached() bool
@@ -392,7 +393,7 @@ func (t *TopicPool) StartSearch(server *p2p.Server) error {
 	found := make(chan *discv5.Node, 5) // 5 reasonable number for concurrently found nodes
 	lookup := make(chan bool, 10)       // sufficiently buffered channel, just prevents blocking because of lookup
 
-	for _, peer := range t.cache.GetPeersRange(t.topic, 5) {
+	for _, peer := range t.cache.GetBestPeers(t.topic, 5) {
 		log.Debug("adding a peer from cache", "peer", peer)
 		found <- peer
 	}
@@ -413,6 +414,23 @@ func (t *TopicPool) StartSearch(server *p2p.Server) error {
 	return nil
 }
 
+// DialCachedPeers adds the best cached peers to the server, up to the max limit,
+// without waiting for discovery. It returns the number of dialed peers.
+func (t *TopicPool) DialCachedPeers(server *p2p.Server) (dialed int) {
+	selfID := discv5.PubkeyID(server.Self().Pubkey())
+	for _, node := range t.cache.GetBestPeers(t.topic, t.limits.Max) {
+		if node.ID == selfID {
+			continue
+		}
+		if err := t.processFoundNode(server, node); err != nil {
+			log.Error("failed to dial a cached peer", "node", node, "error", err)
+			continue
+		}
+		dialed++
+	}
+	return dialed
+}
+
 func (t *TopicPool) handleFoundPeers(server *p2p.Server, found <-chan *discv5.Node, lookup <-chan bool) {
 	selfID := discv5.PubkeyID(server.Self().Pubkey())
 	for {
@@ -102,6 +102,25 @@ func (s *TopicPoolSuite) TestUsingCache() {
 	s.Equal([]*discv5.Node{peer2}, s.topicPool.cache.GetPeersRange(s.topicPool.topic, 10))
 }
 
+func (s *TopicPoolSuite) TestDialCachedPeers() {
+	s.topicPool.limits = params.NewLimits(1, 1)
+	s.topicPool.maxCachedPeers = 1
+
+	nodeID1, peer1 := s.createDiscV5Node(s.peer.Self().IP(), 32311)
+	_, peer2 := s.createDiscV5Node(s.peer.Self().IP(), 32311)
+	s.Require().NoError(s.topicPool.cache.AddPeer(peer1, s.topicPool.topic))
+	s.Require().NoError(s.topicPool.cache.AddPeer(peer1, s.topicPool.topic))
+	s.Require().NoError(s.topicPool.cache.AddPeer(peer2, s.topicPool.topic))
+
+	// only the peer with the highest score is dialed as max is 1
+	s.Equal(1, s.topicPool.DialCachedPeers(s.peer))
+	s.Len(s.topicPool.pendingPeers, 1)
+	s.True(s.topicPool.pendingPeers[nodeID1].added)
+
+	s.topicPool.ConfirmAdded(s.peer, nodeID1)
+	s.Contains(s.topicPool.connectedPeers, nodeID1)
+}
+
 func (s *TopicPoolSuite) TestSyncSwitches() {
 	nodeID, peer := s.createDiscV5Node(s.peer.Self().IP(), 32311)
 	s.Require().NoError(s.topicPool.processFoundNode(s.peer, peer))
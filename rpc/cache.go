@@ -0,0 +1,215 @@
+package rpc
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/status-im/status-go/params"
+)
+
+const (
+	// DefaultCacheTTL is the default time the results of the latest block are cached.
+	DefaultCacheTTL = 15 * time.Second
+
+	// DefaultCacheConfirmations is the default number of blocks after which the results
+	// of a block are cached until they are evicted.
+	DefaultCacheConfirmations = 12
+
+	// noBlock is the index of the block parameter of methods which don't depend on blocks.
+	noBlock = -1
+)
+
+// cacheableMethods are the read-only methods whose results are cached, with the index of
+// their block parameter.
+var cacheableMethods = map[string]int{
+	"eth_chainId":             noBlock,
+	"net_version":             noBlock,
+	"eth_getBalance":          1,
+	"eth_getCode":             1,
+	"eth_getTransactionCount": 1,
+	"eth_getStorageAt":        2,
+	"eth_call":                1,
+}
+
+// cacheEntry is a cached result.
+type cacheEntry struct {
+	result json.RawMessage
+	// latest is true for results of recent blocks, which are stale once a new block is
+	// seen or after the TTL.
+	latest  bool
+	head    uint64
+	expires time.Time
+}
+
+// cache caches the results of read-only upstream calls. Results of blocks deeper than the
+// confirmations, and results which don't depend on blocks, are cached until they are evicted.
+type cache struct {
+	// the counters come first, to be aligned for atomic operations on 32-bit platforms
+	head   uint64 // latest block number seen
+	hits   uint64
+	misses uint64
+
+	entries       *lru.Cache
+	ttl           time.Duration
+	confirmations uint64
+	now           func() time.Time
+}
+
+// newCache returns the cache of config, or nil if it is disabled.
+func newCache(config params.UpstreamRPCConfig) (*cache, error) {
+	if config.CacheSize <= 0 {
+		return nil, nil
+	}
+	entries, err := lru.New(config.CacheSize)
+	if err != nil {
+		return nil, err
+	}
+	c := &cache{
+		entries:       entries,
+		ttl:           time.Duration(config.CacheTTL) * time.Second,
+		confirmations: config.CacheConfirmations,
+		now:           time.Now,
+	}
+	if c.ttl == 0 {
+		c.ttl = DefaultCacheTTL
+	}
+	if c.confirmations == 0 {
+		c.confirmations = DefaultCacheConfirmations
+	}
+	return c, nil
+}
+
+// key returns the key of the result of a call, and whether it can be cached and depends on
+// the latest block.
+func (c *cache) key(method string, args []interface{}) (key string, cacheable, latest bool) {
+	index, ok := cacheableMethods[method]
+	if !ok {
+		return "", false, false
+	}
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return "", false, false
+	}
+	key = method + string(encoded)
+	if index == noBlock {
+		return key, true, false
+	}
+	if len(args) <= index {
+		// the block defaults to the latest one
+		return key, true, true
+	}
+	cacheable, latest = c.block(args[index])
+	return key, cacheable, latest
+}
+
+// block returns whether the results of a block can be cached, and whether they change
+// with the next blocks.
+func (c *cache) block(arg interface{}) (cacheable, latest bool) {
+	encoded, err := json.Marshal(arg)
+	if err != nil {
+		return false, false
+	}
+	var block string
+	if err := json.Unmarshal(encoded, &block); err != nil {
+		// blocks given by hash, like {"blockHash": "0x..."}, are immutable
+		var object map[string]interface{}
+		if json.Unmarshal(encoded, &object) == nil && object["blockHash"] != nil {
+			return true, false
+		}
+		return false, false
+	}
+	switch block {
+	case "latest":
+		return true, true
+	case "earliest":
+		return true, false
+	case "pending":
+		return false, false
+	}
+	number, err := hexutil.DecodeUint64(block)
+	if err != nil {
+		return false, false
+	}
+	head := atomic.LoadUint64(&c.head)
+	return true, head == 0 || number+c.confirmations > head
+}
+
+// get returns the cached result of a call, unless it is stale.
+func (c *cache) get(key string) (json.RawMessage, bool) {
+	value, ok := c.entries.Get(key)
+	if ok {
+		entry := value.(cacheEntry)
+		if !entry.latest || (entry.head >= atomic.LoadUint64(&c.head) && c.now().Before(entry.expires)) {
+			atomic.AddUint64(&c.hits, 1)
+			return entry.result, true
+		}
+		c.entries.Remove(key)
+	}
+	atomic.AddUint64(&c.misses, 1)
+	return nil, false
+}
+
+// add caches the result of a call. Null results, like unknown receipts, are not cached.
+func (c *cache) add(key string, latest bool, result json.RawMessage) {
+	if len(result) == 0 || string(result) == "null" {
+		return
+	}
+	entry := cacheEntry{result: result, latest: latest}
+	if latest {
+		entry.head = atomic.LoadUint64(&c.head)
+		entry.expires = c.now().Add(c.ttl)
+	}
+	c.entries.Add(key, entry)
+}
+
+// observe updates the latest block number with the result of a call, like eth_blockNumber,
+// so that the results of the previous blocks become stale.
+func (c *cache) observe(method string, result json.RawMessage) {
+	if method != "eth_blockNumber" {
+		return
+	}
+	var number hexutil.Uint64
+	if err := json.Unmarshal(result, &number); err == nil {
+		c.setHead(uint64(number))
+	}
+}
+
+// observeHead updates the latest block number with a new head, like a newHeads notification.
+func (c *cache) observeHead(header json.RawMessage) {
+	var head struct {
+		Number hexutil.Uint64 `json:"number"`
+	}
+	if err := json.Unmarshal(header, &head); err == nil {
+		c.setHead(uint64(head.Number))
+	}
+}
+
+func (c *cache) setHead(number uint64) {
+	for {
+		head := atomic.LoadUint64(&c.head)
+		if number <= head || atomic.CompareAndSwapUint64(&c.head, head, number) {
+			return
+		}
+	}
+}
+
+// CacheStats are the stats of the cache of the upstream calls since the node started.
+type CacheStats struct {
+	Size   int    `json:"size"`
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	// Head is the latest block number seen.
+	Head uint64 `json:"head"`
+}
+
+func (c *cache) stats() CacheStats {
+	return CacheStats{
+		Size:   c.entries.Len(),
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+		Head:   atomic.LoadUint64(&c.head),
+	}
+}
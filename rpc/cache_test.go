@@ -0,0 +1,119 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/status-im/status-go/params"
+)
+
+// newCountingServer answers every call with the number of calls it received.
+func newCountingServer(calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&request) // nolint: errcheck
+		n := atomic.AddInt32(calls, 1)
+		if request.Method == "eth_blockNumber" {
+			fmt.Fprintf(w, `{"id":1,"jsonrpc":"2.0","result":"0x%x"}`, 100+n)
+			return
+		}
+		fmt.Fprintf(w, `{"id":1,"jsonrpc":"2.0","result":"0x%x"}`, n)
+	}))
+}
+
+func TestUpstreamCache(t *testing.T) {
+	var calls int32
+	ts := newCountingServer(&calls)
+	defer ts.Close()
+
+	c, err := NewNetworkClient(params.UpstreamRPCConfig{URL: ts.URL, CacheSize: 10})
+	require.NoError(t, err)
+	defer c.Close()
+	now := time.Unix(1000, 0)
+	c.upstream.cache.now = func() time.Time { return now }
+
+	call := func(method string, args ...interface{}) string {
+		var result string
+		require.NoError(t, c.Call(&result, method, args...))
+		return result
+	}
+
+	require.Equal(t, "0x1", call("eth_chainId"))
+	require.Equal(t, "0x1", call("eth_chainId"))
+
+	address := "0x1dE4a7ff0C1d50BAE7b5c7c4eB5c1bD6f3c3d5e2"
+	require.Equal(t, "0x2", call("eth_getBalance", address, "latest"))
+	require.Equal(t, "0x2", call("eth_getBalance", address, "latest"))
+	require.Equal(t, "0x3", call("eth_getBalance", address, "pending"), "Pending results are not cached")
+	require.Equal(t, "0x4", call("eth_getBalance", address, "earliest"))
+	require.Equal(t, "0x4", call("eth_getBalance", address, "earliest"))
+
+	// a new block busts the results of the latest block
+	require.Equal(t, "0x69", call("eth_blockNumber"))
+	require.Equal(t, "0x6", call("eth_getBalance", address, "latest"))
+	require.Equal(t, "0x6", call("eth_getBalance", address, "latest"))
+
+	// so does the TTL
+	now = now.Add(DefaultCacheTTL)
+	require.Equal(t, "0x7", call("eth_getBalance", address, "latest"))
+
+	// results of blocks deep enough are kept
+	require.Equal(t, "0x8", call("eth_getBalance", address, "0x5a"))
+	require.Equal(t, "0x8", call("eth_getBalance", address, "0x5a"))
+	require.Equal(t, "0x6d", call("eth_blockNumber"))
+	now = now.Add(time.Hour)
+	require.Equal(t, "0x8", call("eth_getBalance", address, "0x5a"))
+
+	// methods which are not read-only are always called
+	require.Equal(t, "0xa", call("eth_sendRawTransaction", "0x01"))
+	require.Equal(t, "0xb", call("eth_sendRawTransaction", "0x01"))
+
+	stats := c.CacheStats()
+	require.Equal(t, uint64(0x6d), stats.Head)
+	require.Equal(t, uint64(6), stats.Hits)
+}
+
+func TestUpstreamCacheDisabled(t *testing.T) {
+	var calls int32
+	ts := newCountingServer(&calls)
+	defer ts.Close()
+
+	c, err := NewNetworkClient(params.UpstreamRPCConfig{URL: ts.URL})
+	require.NoError(t, err)
+	defer c.Close()
+	require.Nil(t, c.CacheStats())
+
+	var result string
+	require.NoError(t, c.Call(&result, "eth_chainId"))
+	require.NoError(t, c.Call(&result, "eth_chainId"))
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCacheNewHeads(t *testing.T) {
+	c, err := newCache(params.UpstreamRPCConfig{CacheSize: 10})
+	require.NoError(t, err)
+
+	key, cacheable, latest := c.key("eth_call", []interface{}{map[string]string{"to": "0x01"}})
+	require.True(t, cacheable)
+	require.True(t, latest, "The block defaults to the latest one")
+	c.add(key, latest, json.RawMessage(`"0x01"`))
+	_, ok := c.get(key)
+	require.True(t, ok)
+
+	c.observeHead(json.RawMessage(`{"number":"0x10","hash":"0xabc"}`))
+	_, ok = c.get(key)
+	require.False(t, ok)
+
+	_, cacheable, latest = c.key("eth_call", []interface{}{map[string]string{"to": "0x01"}, map[string]string{"blockHash": "0xabc"}})
+	require.True(t, cacheable)
+	require.False(t, latest, "Blocks given by hash are immutable")
+}
@@ -10,6 +10,7 @@ import (
 const (
 	jsonrpcVersion        = "2.0"
 	errInvalidMessageCode = -32700 // from go-ethereum/rpc/errors.go
+	errMethodNotFoundCode = -32601 // from go-ethereum/rpc/errors.go
 )
 
 // for JSON-RPC responses obtained via CallRaw(), we have no way
@@ -27,6 +28,13 @@ func (c *Client) CallRaw(body string) string {
 	return c.callRawContext(ctx, json.RawMessage(body))
 }
 
+// CallRawContext performs a JSON-RPC call with already crafted JSON-RPC body and
+// given context, like a context of the calls of a dapp. The calls of dapps are limited to
+// their reads, their accounts and the typed data they ask to sign.
+func (c *Client) CallRawContext(ctx context.Context, body string) string {
+	return c.callRawContext(ctx, json.RawMessage(body))
+}
+
 // jsonrpcMessage represents JSON-RPC message
 type jsonrpcMessage struct {
 	Version string          `json:"jsonrpc"`
@@ -115,6 +123,11 @@ func (c *Client) callSingleMethod(ctx context.Context, msg json.RawMessage) stri
 		return newErrorResponse(errInvalidMessageCode, err, id)
 	}
 
+	// the calls of dapps never reach the servers unless they're allowed
+	if _, ok := OriginFromContext(ctx); ok && !dappMethodAllowed(method) {
+		return newErrorResponse(errMethodNotFoundCode, ErrMethodNotAllowed, id)
+	}
+
 	// route and execute
 	var result json.RawMessage
 	err = c.CallContext(ctx, &result, method, params...)
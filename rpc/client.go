@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -23,6 +24,9 @@ const (
 // List of RPC client errors.
 var (
 	ErrMethodNotFound = fmt.Errorf("The method does not exist/is not available")
+	// ErrMethodNotAllowed is returned for the raw calls of dapps to other methods than
+	// their reads, their accounts and the typed data they ask to sign.
+	ErrMethodNotAllowed = fmt.Errorf("The method is not available to dapps")
 )
 
 // Handler defines handler for RPC methods.
@@ -33,16 +37,20 @@ type Handler func(context.Context, ...interface{}) (interface{}, error)
 // goes - Upstream or Local node.
 type Client struct {
 	upstreamEnabled bool
-	upstreamURL     string
 
 	local    *gethrpc.Client
-	upstream *gethrpc.Client
+	upstream *upstream
 
 	router *router
 
-	handlersMx sync.RWMutex       // mx guards handlers
-	handlers   map[string]Handler // locally registered handlers
+	handlersMx sync.RWMutex               // mx guards handlers and namespaces
+	handlers   map[string]Handler         // locally registered handlers
+	namespaces map[string]*gethrpc.Client // clients of registered namespaces
 	log        log.Logger
+
+	subscriptionsMx          sync.Mutex               // guards subscriptions
+	subscriptions            map[string]*subscription // subscriptions made with eth_subscribe, by ID
+	subscriptionPollInterval time.Duration
 }
 
 // NewClient initializes Client and tries to connect to both,
@@ -51,26 +59,75 @@ type Client struct {
 // Client is safe for concurrent use and will automatically
 // reconnect to the server if connection is lost.
 func NewClient(client *gethrpc.Client, upstream params.UpstreamRPCConfig) (*Client, error) {
-	c := Client{
-		local:    client,
-		handlers: make(map[string]Handler),
-		log:      log.New("package", "status-go/rpc.Client"),
+	if !upstream.Enabled {
+		return newClient(client, nil), nil
+	}
+	u, err := newUpstream(upstream)
+	if err != nil {
+		return nil, err
 	}
+	u.start()
+	return newClient(client, u), nil
+}
 
-	var err error
+// NewPrivateClient initializes a Client of the private APIs of the local node, which
+// shares the upstream endpoints of public, with their health checks and budget.
+func NewPrivateClient(client *gethrpc.Client, public *Client) *Client {
+	return newClient(client, public.upstream)
+}
 
-	if upstream.Enabled {
-		c.upstreamEnabled = upstream.Enabled
-		c.upstreamURL = upstream.URL
-		c.upstream, err = gethrpc.Dial(c.upstreamURL)
-		if err != nil {
-			return nil, fmt.Errorf("dial upstream server: %s", err)
-		}
+func newClient(client *gethrpc.Client, upstream *upstream) *Client {
+	c := Client{
+		upstreamEnabled: upstream != nil,
+		local:           client,
+		upstream:        upstream,
+		handlers:        make(map[string]Handler),
+		namespaces:      make(map[string]*gethrpc.Client),
+		log:             log.New("package", "status-go/rpc.Client"),
+
+		subscriptions:            make(map[string]*subscription),
+		subscriptionPollInterval: DefaultSubscriptionPollInterval,
 	}
 
 	c.router = newRouter(c.upstreamEnabled)
+	c.RegisterHandler("eth_subscribe", c.subscribeHandler)
+	c.RegisterHandler("eth_unsubscribe", c.unsubscribeHandler)
+
+	return &c
+}
 
-	return &c, nil
+// NewNetworkClient initializes a Client of another network, which routes all the calls
+// to its RPC endpoints and has no local node.
+func NewNetworkClient(upstream params.UpstreamRPCConfig) (*Client, error) {
+	upstream.Enabled = true
+	return NewClient(nil, upstream)
+}
+
+// Close stops the health checks of the upstream endpoints and closes them, also for the
+// private client sharing them. The local node is left open.
+func (c *Client) Close() {
+	if c.upstreamEnabled {
+		c.upstream.close()
+	}
+}
+
+// CacheStats returns the stats of the cache of the read-only upstream calls, or nil if
+// the upstream or the cache is disabled.
+func (c *Client) CacheStats() *CacheStats {
+	if !c.upstreamEnabled || c.upstream.cache == nil {
+		return nil
+	}
+	stats := c.upstream.cache.stats()
+	return &stats
+}
+
+// UpstreamStats returns the stats of the upstream endpoints, in the order they are
+// preferred, or nil if the upstream is disabled.
+func (c *Client) UpstreamStats() []UpstreamStats {
+	if !c.upstreamEnabled {
+		return nil
+	}
+	return c.upstream.stats()
 }
 
 // Call performs a JSON-RPC call with the given arguments and unmarshals into
@@ -103,6 +160,10 @@ func (c *Client) CallContext(ctx context.Context, result interface{}, method str
 		return c.callMethod(ctx, result, handler, args...)
 	}
 
+	if client, method, ok := c.namespace(method); ok {
+		return client.CallContext(ctx, result, method, args...)
+	}
+
 	return c.CallContextIgnoringLocalHandlers(ctx, result, method, args...)
 }
 
@@ -122,6 +183,11 @@ func (c *Client) CallContextIgnoringLocalHandlers(ctx context.Context, result in
 		return c.upstream.CallContext(ctx, result, method, args...)
 	}
 
+	// clients of other networks have no local node
+	if c.local == nil {
+		return ErrMethodNotFound
+	}
+
 	return c.local.CallContext(ctx, result, method, args...)
 }
 
@@ -136,6 +202,37 @@ func (c *Client) RegisterHandler(method string, handler Handler) {
 	c.handlers[method] = handler
 }
 
+// RegisterNamespace routes the methods prefixed with namespace and an underscore
+// to client, without the prefix. For instance, with the "profile" namespace,
+// "profile_shhext_post" is routed to "shhext_post" of client.
+func (c *Client) RegisterNamespace(namespace string, client *gethrpc.Client) {
+	c.handlersMx.Lock()
+	defer c.handlersMx.Unlock()
+
+	c.namespaces[namespace] = client
+}
+
+// UnregisterNamespace stops routing the methods of a namespace.
+func (c *Client) UnregisterNamespace(namespace string) {
+	c.handlersMx.Lock()
+	defer c.handlersMx.Unlock()
+
+	delete(c.namespaces, namespace)
+}
+
+// namespace returns the client of the namespace of a method, if it is registered,
+// and the method without the namespace.
+func (c *Client) namespace(method string) (*gethrpc.Client, string, bool) {
+	parts := strings.SplitN(method, "_", 2)
+	if len(parts) != 2 {
+		return nil, "", false
+	}
+	c.handlersMx.RLock()
+	defer c.handlersMx.RUnlock()
+	client, ok := c.namespaces[parts[0]]
+	return client, parts[1], ok
+}
+
 // callMethod calls registered RPC handler with given args and pointer to result.
 // It handles proper params and result converting
 //
@@ -76,3 +76,78 @@ func TestBlockedRoutesRawCall(t *testing.T) {
 		require.Contains(t, rawResult, fmt.Sprintf(`{"code":-32700,"message":"%s"}`, ErrMethodNotFound))
 	}
 }
+
+func TestDappRawCall(t *testing.T) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintln(w, `{"id": 1, "jsonrpc": "2.0", "result": "0x1"}`)
+	}))
+	defer ts.Close()
+
+	gethRPCClient, err := gethrpc.Dial(ts.URL)
+	require.NoError(t, err)
+
+	c, err := NewClient(gethRPCClient, params.UpstreamRPCConfig{Enabled: false, URL: ""})
+	require.NoError(t, err)
+
+	ctx := WithOrigin(context.Background(), "https://dapp.example")
+	require.Contains(t, c.CallRawContext(ctx, `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`), `"result":"0x1"`)
+	require.Equal(t, 1, calls)
+
+	for _, m := range []string{"eth_sendRawTransaction", "eth_sign", "personal_sign", "shh_post", "shhext_post", "admin_peers"} {
+		rawResult := c.CallRawContext(ctx, fmt.Sprintf(`[{"jsonrpc":"2.0","id":1,"method":"%s","params":[]}]`, m))
+		require.Contains(t, rawResult, fmt.Sprintf(`{"code":-32601,"message":"%s"}`, ErrMethodNotAllowed))
+	}
+	require.Equal(t, 1, calls, "Calls not allowed to dapps never reach the server")
+
+	require.Contains(t, c.CallRaw(`{"jsonrpc":"2.0","id":1,"method":"admin_peers"}`), `"result":"0x1"`, "Calls without origin are not limited")
+}
+
+type NamespaceTestService struct{}
+
+func (NamespaceTestService) Echo(value string) string {
+	return value
+}
+
+func TestNamespaces(t *testing.T) {
+	local := gethrpc.NewServer()
+	require.NoError(t, local.RegisterName("test", NamespaceTestService{}))
+	c, err := NewClient(gethrpc.DialInProc(local), params.UpstreamRPCConfig{})
+	require.NoError(t, err)
+
+	profile := gethrpc.NewServer()
+	require.NoError(t, profile.RegisterName("test", NamespaceTestService{}))
+	c.RegisterNamespace("profile", gethrpc.DialInProc(profile))
+
+	var result string
+	require.NoError(t, c.Call(&result, "profile_test_echo", "hello"))
+	require.Equal(t, "hello", result)
+	require.Contains(t, c.CallRaw(`{"jsonrpc":"2.0","id":1,"method":"profile_test_echo","params":["raw"]}`), `"result":"raw"`)
+	require.NoError(t, c.Call(&result, "test_echo", "local"), "Methods without namespaces are routed to the local node")
+	require.Equal(t, "local", result)
+
+	c.UnregisterNamespace("profile")
+	require.Error(t, c.Call(&result, "profile_test_echo", "hello"))
+}
+
+func TestNetworkClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{
+			"id": 1,
+			"jsonrpc": "2.0",
+			"result": "0xa"
+		}`)
+	}))
+	defer ts.Close()
+
+	c, err := NewNetworkClient(params.UpstreamRPCConfig{URL: ts.URL})
+	require.NoError(t, err)
+
+	var result string
+	require.NoError(t, c.Call(&result, "eth_blockNumber"))
+	require.Equal(t, "0xa", result)
+
+	err = c.Call(&result, "shh_version")
+	require.Equal(t, ErrMethodNotFound, err, "Clients of other networks have no local node")
+}
@@ -16,6 +16,27 @@ List of methods to be routed is currently available here: https://docs.google.co
 
 Note, upon creation of a new client, it ok to be offline - client will keep trying to reconnect in background.
 
+Subscriptions are supported with eth_subscribe and eth_unsubscribe, for newHeads and logs,
+on the upstream node if it's enabled. Notifications are sent with the subscriptions.data signal,
+instead of eth_subscription notifications:
+
+	{"type":"subscriptions.data","event":{"subscription":"0x...","result":{...}}}
+
+Nodes which don't support notifications, like upstream nodes over HTTP, are polled with filters.
+A subscription which fails is removed, and the subscriptions.error signal is sent.
+
+The upstream can have fallback endpoints, set with UpstreamConfig.FallbackURLs. Calls are made
+with the first endpoint which is healthy and within its budget of UpstreamConfig.RateLimit calls
+per second, and fail over to the next ones when an endpoint can't be reached. Endpoints are
+checked with eth_blockNumber every UpstreamConfig.HealthCheckInterval seconds, and their stats
+are returned by the private upstream_stats method, by chain ID.
+
+Results of read-only upstream calls, like eth_chainId, eth_getBalance and eth_call, are cached
+for UpstreamConfig.CacheSize calls. Results of recent blocks are cached until a new block is seen,
+with eth_blockNumber or newHeads, or for UpstreamConfig.CacheTTL seconds at most. Results of blocks
+deeper than UpstreamConfig.CacheConfirmations are cached until they are evicted. The stats of the
+cache are returned by the private upstream_cacheStats method.
+
 */
 package rpc
 
@@ -0,0 +1,17 @@
+package rpc
+
+import "context"
+
+type originKey struct{}
+
+// WithOrigin returns a context of the calls of a dapp, so that local handlers can check
+// the permissions granted to it.
+func WithOrigin(ctx context.Context, origin string) context.Context {
+	return context.WithValue(ctx, originKey{}, origin)
+}
+
+// OriginFromContext returns the origin of the dapp making a call, if it's made by a dapp.
+func OriginFromContext(ctx context.Context) (string, bool) {
+	origin, ok := ctx.Value(originKey{}).(string)
+	return origin, ok
+}
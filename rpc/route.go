@@ -55,6 +55,59 @@ func BlockedMethods() []string {
 	return append([]string(nil), blockedMethods[:]...)
 }
 
+// dappMethods are the only methods dapps can call: reads of the chain and of the node, the
+// accounts they're granted and the typed data they ask the user to sign. Transactions are
+// sent by dapps with the permission of the user, out of the RPC.
+var dappMethods = map[string]struct{}{
+	"eth_protocolVersion":                     {},
+	"eth_chainId":                             {},
+	"eth_syncing":                             {},
+	"eth_mining":                              {},
+	"eth_hashrate":                            {},
+	"eth_gasPrice":                            {},
+	"eth_feeHistory":                          {},
+	"eth_accounts":                            {},
+	"eth_blockNumber":                         {},
+	"eth_getBalance":                          {},
+	"eth_getStorageAt":                        {},
+	"eth_getTransactionCount":                 {},
+	"eth_getBlockTransactionCountByHash":      {},
+	"eth_getBlockTransactionCountByNumber":    {},
+	"eth_getUncleCountByBlockHash":            {},
+	"eth_getUncleCountByBlockNumber":          {},
+	"eth_getCode":                             {},
+	"eth_call":                                {},
+	"eth_estimateGas":                         {},
+	"eth_getBlockByHash":                      {},
+	"eth_getBlockByNumber":                    {},
+	"eth_getTransactionByHash":                {},
+	"eth_getTransactionByBlockHashAndIndex":   {},
+	"eth_getTransactionByBlockNumberAndIndex": {},
+	"eth_getTransactionReceipt":               {},
+	"eth_getUncleByBlockHashAndIndex":         {},
+	"eth_getUncleByBlockNumberAndIndex":       {},
+	"eth_getLogs":                             {},
+	"eth_newFilter":                           {},
+	"eth_newBlockFilter":                      {},
+	"eth_newPendingTransactionFilter":         {},
+	"eth_getFilterChanges":                    {},
+	"eth_getFilterLogs":                       {},
+	"eth_uninstallFilter":                     {},
+	"eth_signTypedData_v3":                    {},
+	"eth_signTypedData_v4":                    {},
+	"net_version":                             {},
+	"net_peerCount":                           {},
+	"net_listening":                           {},
+	"web3_clientVersion":                      {},
+	"web3_sha3":                               {},
+}
+
+// dappMethodAllowed returns true if dapps can call a method.
+func dappMethodAllowed(method string) bool {
+	_, ok := dappMethods[method]
+	return ok
+}
+
 // remoteMethods contains methods that should be routed to
 // the upstream node; the rest is considered to be routed to
 // the local node.
@@ -68,11 +121,13 @@ func BlockedMethods() []string {
 // The list of methods: https://github.com/ethereum/wiki/wiki/JSON-RPC
 var remoteMethods = [...]string{
 	"eth_protocolVersion",
+	"eth_chainId",
 	"eth_syncing",
 	"eth_coinbase",
 	"eth_mining",
 	"eth_hashrate",
 	"eth_gasPrice",
+	"eth_feeHistory",
 	//"eth_accounts", // due to sub-accounts handling
 	"eth_blockNumber",
 	"eth_getBalance",
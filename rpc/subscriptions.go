@@ -0,0 +1,275 @@
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/signal"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// SubscriptionNewHeads notifies the headers of new blocks.
+	SubscriptionNewHeads = "newHeads"
+	// SubscriptionLogs notifies the logs of new blocks matching a filter.
+	SubscriptionLogs = "logs"
+
+	// DefaultSubscriptionPollInterval is the interval at which the filters emulating
+	// subscriptions are polled, on nodes which don't support notifications, like
+	// upstream nodes over HTTP.
+	DefaultSubscriptionPollInterval = 4 * time.Second
+
+	// subscriptionBuffer is the number of notifications of a subscription buffered
+	// until they are sent with signals.
+	subscriptionBuffer = 100
+)
+
+var (
+	// ErrUnsupportedSubscription is returned by eth_subscribe for other kinds of subscriptions than newHeads and logs.
+	ErrUnsupportedSubscription = errors.New("only newHeads and logs subscriptions are supported")
+	// ErrSubscriptionNotFound is returned by eth_unsubscribe for unknown subscriptions.
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+)
+
+// subscription is made with eth_subscribe, its notifications are sent with the
+// subscriptions.data signal.
+type subscription struct {
+	heads bool // whether it is a newHeads subscription
+	quit  chan struct{}
+	done  chan struct{}
+}
+
+// Subscribe subscribes to the notifications of the node, like eth_subscribe, and returns
+// the ID of the subscription. Notifications are sent with the subscriptions.data signal,
+// and errors removing the subscription with the subscriptions.error signal.
+//
+// Subscriptions are made on the upstream node if it's enabled. Nodes which don't support
+// notifications are polled with filters instead.
+func (c *Client) Subscribe(ctx context.Context, args ...interface{}) (string, error) {
+	if len(args) == 0 {
+		return "", ErrUnsupportedSubscription
+	}
+	kind, _ := args[0].(string)
+	if kind != SubscriptionNewHeads && kind != SubscriptionLogs {
+		return "", ErrUnsupportedSubscription
+	}
+	client := c.local
+	if c.upstreamEnabled {
+		client = c.upstream.client()
+	}
+	if client == nil {
+		return "", ErrMethodNotFound
+	}
+
+	id, err := newSubscriptionID()
+	if err != nil {
+		return "", err
+	}
+	sub := &subscription{heads: kind == SubscriptionNewHeads, quit: make(chan struct{}), done: make(chan struct{})}
+	notifications := make(chan json.RawMessage, subscriptionBuffer)
+	native, err := client.EthSubscribe(ctx, notifications, args...)
+	switch err {
+	case nil:
+		c.addSubscription(id, sub)
+		go c.forward(id, sub, native, notifications)
+	case gethrpc.ErrNotificationsUnsupported:
+		p := &filterPoller{client: client, args: args}
+		if err := p.install(ctx); err != nil {
+			return "", err
+		}
+		c.addSubscription(id, sub)
+		go c.poll(id, sub, p)
+	default:
+		return "", err
+	}
+	return id, nil
+}
+
+// Unsubscribe stops a subscription made with Subscribe.
+func (c *Client) Unsubscribe(id string) error {
+	c.subscriptionsMx.Lock()
+	sub, ok := c.subscriptions[id]
+	delete(c.subscriptions, id)
+	c.subscriptionsMx.Unlock()
+	if !ok {
+		return ErrSubscriptionNotFound
+	}
+	close(sub.quit)
+	<-sub.done
+	return nil
+}
+
+// UnsubscribeAll stops all the subscriptions, like when the node stops.
+func (c *Client) UnsubscribeAll() {
+	c.subscriptionsMx.Lock()
+	ids := make([]string, 0, len(c.subscriptions))
+	for id := range c.subscriptions {
+		ids = append(ids, id)
+	}
+	c.subscriptionsMx.Unlock()
+	for _, id := range ids {
+		c.Unsubscribe(id) // nolint: errcheck
+	}
+}
+
+func (c *Client) subscribeHandler(ctx context.Context, args ...interface{}) (interface{}, error) {
+	return c.Subscribe(ctx, args...)
+}
+
+func (c *Client) unsubscribeHandler(ctx context.Context, args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, ErrSubscriptionNotFound
+	}
+	id, _ := args[0].(string)
+	if err := c.Unsubscribe(id); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+func (c *Client) addSubscription(id string, sub *subscription) {
+	c.subscriptionsMx.Lock()
+	defer c.subscriptionsMx.Unlock()
+	c.subscriptions[id] = sub
+}
+
+// removeFailed removes a subscription which failed, unless it was unsubscribed.
+func (c *Client) removeFailed(id string, err error) {
+	c.subscriptionsMx.Lock()
+	_, ok := c.subscriptions[id]
+	delete(c.subscriptions, id)
+	c.subscriptionsMx.Unlock()
+	if ok {
+		c.log.Warn("subscription failed", "id", id, "error", err)
+		signal.SendSubscriptionError(id, err)
+	}
+}
+
+// forward sends the notifications of a subscription of the node.
+func (c *Client) forward(id string, sub *subscription, native *gethrpc.ClientSubscription, notifications chan json.RawMessage) {
+	defer close(sub.done)
+	defer native.Unsubscribe()
+	for {
+		select {
+		case result := <-notifications:
+			c.observe(sub, result)
+			signal.SendSubscriptionData(id, result)
+		case err := <-native.Err():
+			if err != nil {
+				c.removeFailed(id, err)
+			}
+			return
+		case <-sub.quit:
+			return
+		}
+	}
+}
+
+// observe passes the new heads to the cache of the upstream, so that the results of the
+// previous blocks become stale.
+func (c *Client) observe(sub *subscription, result json.RawMessage) {
+	if sub.heads && c.upstreamEnabled && c.upstream.cache != nil {
+		c.upstream.cache.observeHead(result)
+	}
+}
+
+// poll sends the changes of the filter emulating a subscription.
+func (c *Client) poll(id string, sub *subscription, p *filterPoller) {
+	defer close(sub.done)
+	ticker := time.NewTicker(c.subscriptionPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), DefaultCallTimeout)
+			results, err := p.changes(ctx)
+			cancel()
+			if err != nil {
+				c.log.Warn("failed to poll subscription filter", "id", id, "error", err)
+				continue
+			}
+			for _, result := range results {
+				c.observe(sub, result)
+				signal.SendSubscriptionData(id, result)
+			}
+		case <-sub.quit:
+			ctx, cancel := context.WithTimeout(context.Background(), DefaultCallTimeout)
+			p.uninstall(ctx)
+			cancel()
+			return
+		}
+	}
+}
+
+// filterPoller emulates a subscription with a filter: a block filter for newHeads,
+// whose headers are requested by hash, or a log filter for logs.
+type filterPoller struct {
+	client   *gethrpc.Client
+	args     []interface{}
+	filterID string
+}
+
+func (p *filterPoller) heads() bool {
+	return p.args[0] == SubscriptionNewHeads
+}
+
+func (p *filterPoller) install(ctx context.Context) error {
+	if p.heads() {
+		return p.client.CallContext(ctx, &p.filterID, "eth_newBlockFilter")
+	}
+	return p.client.CallContext(ctx, &p.filterID, "eth_newFilter", p.args[1:]...)
+}
+
+// changes returns the notifications since the previous call. The filter is installed
+// again if it failed, as filters expire when they are not polled.
+func (p *filterPoller) changes(ctx context.Context) ([]json.RawMessage, error) {
+	if p.filterID == "" {
+		if err := p.install(ctx); err != nil {
+			return nil, err
+		}
+	}
+	var changes []json.RawMessage
+	if err := p.client.CallContext(ctx, &changes, "eth_getFilterChanges", p.filterID); err != nil {
+		p.filterID = ""
+		return nil, err
+	}
+	if !p.heads() {
+		return changes, nil
+	}
+
+	headers := make([]json.RawMessage, 0, len(changes))
+	for _, change := range changes {
+		var hash common.Hash
+		if err := json.Unmarshal(change, &hash); err != nil {
+			return headers, err
+		}
+		var header json.RawMessage
+		if err := p.client.CallContext(ctx, &header, "eth_getBlockByHash", hash, false); err != nil {
+			return headers, err
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
+
+func (p *filterPoller) uninstall(ctx context.Context) {
+	if p.filterID == "" {
+		return
+	}
+	var result bool
+	p.client.CallContext(ctx, &result, "eth_uninstallFilter", p.filterID) // nolint: errcheck
+}
+
+func newSubscriptionID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hexutil.Encode(id), nil
+}
@@ -0,0 +1,122 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/status-im/status-go/params"
+	"github.com/status-im/status-go/signal"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// HeadsService notifies the same header until unsubscribed.
+type HeadsService struct{}
+
+func (HeadsService) NewHeads(ctx context.Context) (*gethrpc.Subscription, error) {
+	notifier, _ := gethrpc.NotifierFromContext(ctx)
+	sub := notifier.CreateSubscription()
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				notifier.Notify(sub.ID, map[string]string{"number": "0x1"}) // nolint: errcheck
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+func receiveSubscriptionData(t *testing.T, s *signal.Subscription) signal.SubscriptionDataEvent {
+	select {
+	case envelope := <-s.Signals():
+		return envelope.Event.(signal.SubscriptionDataEvent)
+	case <-time.After(time.Second):
+		require.FailNow(t, "timed out waiting for a subscription notification")
+	}
+	return signal.SubscriptionDataEvent{}
+}
+
+func TestSubscribe(t *testing.T) {
+	server := gethrpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", HeadsService{}))
+	c, err := NewClient(gethrpc.DialInProc(server), params.UpstreamRPCConfig{})
+	require.NoError(t, err)
+
+	s := signal.Subscribe(signal.SubscriptionConfig{Types: []string{signal.EventSubscriptionsData}})
+	defer s.Unsubscribe()
+
+	var id string
+	require.NoError(t, c.Call(&id, "eth_subscribe", SubscriptionNewHeads))
+	data := receiveSubscriptionData(t, s)
+	require.Equal(t, id, data.SubscriptionID)
+	require.JSONEq(t, `{"number":"0x1"}`, string(data.Result.(json.RawMessage)))
+
+	var ok bool
+	require.NoError(t, c.Call(&ok, "eth_unsubscribe", id))
+	require.True(t, ok)
+	require.EqualError(t, c.Call(&ok, "eth_unsubscribe", id), ErrSubscriptionNotFound.Error())
+	require.EqualError(t, c.Call(&id, "eth_subscribe", "syncing"), ErrUnsupportedSubscription.Error())
+}
+
+func TestSubscribePollsFilters(t *testing.T) {
+	polled := make(chan struct{}, 10)
+	uninstalled := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		result := `null`
+		switch req.Method {
+		case "eth_newBlockFilter":
+			result = `"0x1"`
+		case "eth_getFilterChanges":
+			result = `[]`
+			select {
+			case polled <- struct{}{}:
+				result = `["0x0000000000000000000000000000000000000000000000000000000000000001"]`
+			default:
+			}
+		case "eth_getBlockByHash":
+			result = `{"number":"0x1"}`
+		case "eth_uninstallFilter":
+			uninstalled <- string(req.Params[0])
+			result = `true`
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+	}))
+	defer ts.Close()
+
+	gethRPCClient, err := gethrpc.Dial(ts.URL)
+	require.NoError(t, err)
+	c, err := NewClient(gethRPCClient, params.UpstreamRPCConfig{})
+	require.NoError(t, err)
+	c.subscriptionPollInterval = 10 * time.Millisecond
+
+	s := signal.Subscribe(signal.SubscriptionConfig{Types: []string{signal.EventSubscriptionsData}})
+	defer s.Unsubscribe()
+
+	id, err := c.Subscribe(context.Background(), SubscriptionNewHeads)
+	require.NoError(t, err)
+	data := receiveSubscriptionData(t, s)
+	require.Equal(t, id, data.SubscriptionID)
+	require.JSONEq(t, `{"number":"0x1"}`, string(data.Result.(json.RawMessage)),
+		"Headers of the block filter changes are not notified")
+
+	c.UnsubscribeAll()
+	require.Equal(t, `"0x1"`, <-uninstalled, "The filter is not uninstalled")
+}
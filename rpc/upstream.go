@@ -0,0 +1,288 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/status-im/status-go/params"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// DefaultHealthCheckInterval is the default interval between the health checks of the
+	// upstream endpoints.
+	DefaultHealthCheckInterval = 30 * time.Second
+
+	// healthCheckTimeout is the timeout of the health check of an endpoint.
+	healthCheckTimeout = 10 * time.Second
+)
+
+// UpstreamStats are the stats of an upstream endpoint since the node started.
+type UpstreamStats struct {
+	URL     string `json:"url"`
+	Healthy bool   `json:"healthy"`
+	// Requests is the number of calls made with the endpoint.
+	Requests uint64 `json:"requests"`
+	// Failures is the number of calls and health checks which failed to reach the endpoint.
+	Failures uint64 `json:"failures"`
+	// OverBudget is the number of calls made with the endpoint over its rate budget,
+	// because every endpoint was over budget.
+	OverBudget uint64 `json:"overBudget"`
+	LastError  string `json:"lastError,omitempty"`
+	// LastCheck is the time of the last health check, in seconds.
+	LastCheck int64 `json:"lastCheck"`
+	// Latency is the latency of the last health check, in milliseconds.
+	Latency int64 `json:"latency"`
+}
+
+// endpoint is an upstream RPC endpoint with its stats.
+type endpoint struct {
+	client *gethrpc.Client
+
+	mu          sync.Mutex
+	stats       UpstreamStats
+	window      time.Time // start of the second the budget is counted in
+	windowCalls int
+}
+
+// available returns whether the endpoint is healthy and within its budget of calls per
+// second, if it has one.
+func (e *endpoint) available(now time.Time, budget int) (healthy, withinBudget bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if budget <= 0 || now.Sub(e.window) >= time.Second {
+		return e.stats.Healthy, true
+	}
+	return e.stats.Healthy, e.windowCalls < budget
+}
+
+// begin counts a call against the budget of the endpoint.
+func (e *endpoint) begin(now time.Time, budget int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if now.Sub(e.window) >= time.Second {
+		e.window = now
+		e.windowCalls = 0
+	}
+	e.windowCalls++
+	e.stats.Requests++
+	if budget > 0 && e.windowCalls > budget {
+		e.stats.OverBudget++
+	}
+}
+
+// record marks the endpoint healthy, or unhealthy if the endpoint couldn't be reached.
+func (e *endpoint) record(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stats.Healthy = err == nil
+	if err != nil {
+		e.stats.Failures++
+		e.stats.LastError = err.Error()
+	}
+}
+
+// upstream calls the first endpoint of a network which is healthy and within its rate
+// budget, and fails over to the next ones when it can't be reached.
+type upstream struct {
+	endpoints []*endpoint
+	cache     *cache // nil if disabled
+	budget    int
+	interval  time.Duration
+	now       func() time.Time
+	log       log.Logger
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// newUpstream dials the endpoints of config, which are all considered healthy until
+// they are checked.
+func newUpstream(config params.UpstreamRPCConfig) (*upstream, error) {
+	u := &upstream{
+		budget:   config.RateLimit,
+		interval: time.Duration(config.HealthCheckInterval) * time.Second,
+		now:      time.Now,
+		log:      log.New("package", "status-go/rpc.upstream"),
+		quit:     make(chan struct{}),
+	}
+	if u.interval == 0 {
+		u.interval = DefaultHealthCheckInterval
+	}
+	var err error
+	if u.cache, err = newCache(config); err != nil {
+		return nil, err
+	}
+	for _, url := range append([]string{config.URL}, config.FallbackURLs...) {
+		client, err := gethrpc.Dial(url)
+		if err != nil {
+			u.close()
+			return nil, fmt.Errorf("dial upstream server: %s", err)
+		}
+		u.endpoints = append(u.endpoints, &endpoint{
+			client: client,
+			stats:  UpstreamStats{URL: url, Healthy: true},
+		})
+	}
+	return u, nil
+}
+
+// start checks the health of the endpoints periodically, if there are several of them.
+func (u *upstream) start() {
+	if len(u.endpoints) < 2 {
+		return
+	}
+	u.wg.Add(1)
+	go func() {
+		defer u.wg.Done()
+		ticker := time.NewTicker(u.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				u.check()
+			case <-u.quit:
+				return
+			}
+		}
+	}()
+}
+
+// close stops the health checks and closes the endpoints.
+func (u *upstream) close() {
+	u.once.Do(func() {
+		close(u.quit)
+		u.wg.Wait()
+		for _, e := range u.endpoints {
+			e.client.Close()
+		}
+	})
+}
+
+// check checks that each endpoint answers eth_blockNumber, and marks it healthy or
+// unhealthy accordingly.
+func (u *upstream) check() {
+	for _, e := range u.endpoints {
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+		start := u.now()
+		var blockNumber string
+		err := e.client.CallContext(ctx, &blockNumber, "eth_blockNumber")
+		cancel()
+		if !unreachable(err) {
+			err = nil
+		}
+		if number, decodeErr := hexutil.DecodeUint64(blockNumber); err == nil && decodeErr == nil && u.cache != nil {
+			u.cache.setHead(number)
+		}
+		if err != nil {
+			u.log.Warn("upstream endpoint is unhealthy", "url", e.stats.URL, "err", err)
+		}
+		e.record(err)
+		e.mu.Lock()
+		e.stats.LastCheck = start.Unix()
+		e.stats.Latency = int64(u.now().Sub(start) / time.Millisecond)
+		e.mu.Unlock()
+	}
+}
+
+// candidates returns the endpoints in the order they are tried: the healthy ones within
+// budget, the healthy ones over budget and the unhealthy ones, in the order of the config.
+func (u *upstream) candidates() []*endpoint {
+	var withinBudget, overBudget, unhealthy []*endpoint
+	now := u.now()
+	for _, e := range u.endpoints {
+		switch healthy, within := e.available(now, u.budget); {
+		case !healthy:
+			unhealthy = append(unhealthy, e)
+		case within:
+			withinBudget = append(withinBudget, e)
+		default:
+			overBudget = append(overBudget, e)
+		}
+	}
+	return append(append(withinBudget, overBudget...), unhealthy...)
+}
+
+// CallContext returns the cached result of read-only calls, or calls the endpoints.
+func (u *upstream) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if u.cache == nil {
+		return u.call(ctx, result, method, args...)
+	}
+	key, cacheable, latest := u.cache.key(method, args)
+	if cacheable {
+		if raw, ok := u.cache.get(key); ok {
+			return decodeResult(raw, result)
+		}
+	} else if method != "eth_blockNumber" {
+		return u.call(ctx, result, method, args...)
+	}
+	var raw json.RawMessage
+	if err := u.call(ctx, &raw, method, args...); err != nil {
+		return err
+	}
+	u.cache.observe(method, raw)
+	if cacheable {
+		u.cache.add(key, latest, raw)
+	}
+	return decodeResult(raw, result)
+}
+
+// call calls the first candidate endpoint, and the next ones if it can't be reached.
+// Errors returned by the endpoints, like a reverted call, are not retried.
+func (u *upstream) call(ctx context.Context, result interface{}, method string, args ...interface{}) (err error) {
+	for _, e := range u.candidates() {
+		e.begin(u.now(), u.budget)
+		err = e.client.CallContext(ctx, result, method, args...)
+		if !unreachable(err) {
+			e.record(nil)
+			return err
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		e.record(err)
+		u.log.Warn("upstream endpoint failed, failing over", "url", e.stats.URL, "method", method, "err", err)
+	}
+	return err
+}
+
+// client returns the client of the first candidate endpoint, used for subscriptions.
+func (u *upstream) client() *gethrpc.Client {
+	return u.candidates()[0].client
+}
+
+// stats returns the stats of the endpoints, in the order of the config.
+func (u *upstream) stats() []UpstreamStats {
+	stats := make([]UpstreamStats, 0, len(u.endpoints))
+	for _, e := range u.endpoints {
+		e.mu.Lock()
+		stats = append(stats, e.stats)
+		e.mu.Unlock()
+	}
+	return stats
+}
+
+// decodeResult decodes a result into the result of a call, ignored if it is nil.
+func decodeResult(raw json.RawMessage, result interface{}) error {
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(raw, result)
+}
+
+// unreachable returns true for errors of the transport, like HTTP errors and timeouts,
+// rather than the JSON-RPC errors returned by the endpoint or results of another type.
+func unreachable(err error) bool {
+	switch err.(type) {
+	case nil, gethrpc.Error, *json.UnmarshalTypeError:
+		return false
+	}
+	return true
+}
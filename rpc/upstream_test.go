@@ -0,0 +1,106 @@
+package rpc
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/status-im/status-go/params"
+)
+
+// testEndpoint answers every call with its block number, unless it is down.
+type testEndpoint struct {
+	*httptest.Server
+	down  int32
+	calls int32
+}
+
+func newTestEndpoint(blockNumber string) *testEndpoint {
+	e := &testEndpoint{}
+	e.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&e.calls, 1)
+		if atomic.LoadInt32(&e.down) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, `{"id":1,"jsonrpc":"2.0","result":"%s"}`, blockNumber)
+	}))
+	return e
+}
+
+func (e *testEndpoint) setDown(down bool) {
+	var value int32
+	if down {
+		value = 1
+	}
+	atomic.StoreInt32(&e.down, value)
+}
+
+func TestUpstreamFailover(t *testing.T) {
+	primary, fallback := newTestEndpoint("0x1"), newTestEndpoint("0x2")
+	defer primary.Close()
+	defer fallback.Close()
+
+	c, err := NewNetworkClient(params.UpstreamRPCConfig{URL: primary.URL, FallbackURLs: []string{fallback.URL}})
+	require.NoError(t, err)
+	defer c.Close()
+
+	var result string
+	require.NoError(t, c.Call(&result, "eth_blockNumber"))
+	require.Equal(t, "0x1", result)
+
+	primary.setDown(true)
+	require.NoError(t, c.Call(&result, "eth_blockNumber"))
+	require.Equal(t, "0x2", result, "Calls fail over to the fallback endpoint")
+	stats := c.UpstreamStats()
+	require.False(t, stats[0].Healthy)
+	require.Equal(t, uint64(1), stats[0].Failures)
+	require.Contains(t, stats[0].LastError, "503")
+
+	calls := atomic.LoadInt32(&primary.calls)
+	require.NoError(t, c.Call(&result, "eth_blockNumber"))
+	require.Equal(t, "0x2", result)
+	require.Equal(t, calls, atomic.LoadInt32(&primary.calls), "Unhealthy endpoints are skipped")
+
+	// the primary endpoint is used again once it passes a health check
+	primary.setDown(false)
+	c.upstream.check()
+	require.True(t, c.UpstreamStats()[0].Healthy)
+	require.NoError(t, c.Call(&result, "eth_blockNumber"))
+	require.Equal(t, "0x1", result)
+
+	primary.setDown(true)
+	fallback.setDown(true)
+	require.Error(t, c.Call(&result, "eth_blockNumber"))
+}
+
+func TestUpstreamRateLimit(t *testing.T) {
+	primary, fallback := newTestEndpoint("0x1"), newTestEndpoint("0x2")
+	defer primary.Close()
+	defer fallback.Close()
+
+	c, err := NewNetworkClient(params.UpstreamRPCConfig{URL: primary.URL, FallbackURLs: []string{fallback.URL}, RateLimit: 2})
+	require.NoError(t, err)
+	defer c.Close()
+	now := time.Unix(1000, 0)
+	c.upstream.now = func() time.Time { return now }
+
+	var results []string
+	for i := 0; i < 5; i++ {
+		var result string
+		require.NoError(t, c.Call(&result, "eth_blockNumber"))
+		results = append(results, result)
+	}
+	require.Equal(t, []string{"0x1", "0x1", "0x2", "0x2", "0x1"}, results, "Calls over the budget of every endpoint use the first one")
+	require.Equal(t, uint64(1), c.UpstreamStats()[0].OverBudget)
+
+	now = now.Add(time.Second)
+	var result string
+	require.NoError(t, c.Call(&result, "eth_blockNumber"))
+	require.Equal(t, "0x1", result, "The budget is renewed every second")
+}
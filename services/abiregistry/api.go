@@ -0,0 +1,48 @@
+package abiregistry
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// PublicAPI exposes the ABI registry over RPC.
+type PublicAPI struct {
+	service *Service
+}
+
+// NewPublicAPI returns a new PublicAPI.
+func NewPublicAPI(s *Service) *PublicAPI {
+	return &PublicAPI{service: s}
+}
+
+// AddABI validates and stores the ABI of a contract, replacing the previous one if any.
+func (api *PublicAPI) AddABI(context context.Context, entry Entry) (*Entry, error) {
+	return api.service.registry.Add(entry)
+}
+
+// GetABI returns the ABI added for a contract.
+func (api *PublicAPI) GetABI(context context.Context, address common.Address) (*Entry, error) {
+	return api.service.registry.Get(address)
+}
+
+// ListABIs returns the built-in ABIs and the ones added for specific contracts.
+func (api *PublicAPI) ListABIs(context context.Context) ([]Entry, error) {
+	return api.service.registry.List()
+}
+
+// RemoveABI deletes the ABI added for a contract. Built-in ABIs can't be removed.
+func (api *PublicAPI) RemoveABI(context context.Context, address common.Address) error {
+	return api.service.registry.Remove(address)
+}
+
+// DecodeCalldata decodes the data of a transaction sent to a contract.
+func (api *PublicAPI) DecodeCalldata(context context.Context, to common.Address, data hexutil.Bytes) (*DecodedCall, error) {
+	return api.service.registry.DecodeCalldata(to, data)
+}
+
+// DecodeLog decodes a log emitted by a contract.
+func (api *PublicAPI) DecodeLog(context context.Context, address common.Address, topics []common.Hash, data hexutil.Bytes) (*DecodedLog, error) {
+	return api.service.registry.DecodeLog(address, topics, data)
+}
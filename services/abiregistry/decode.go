@@ -0,0 +1,178 @@
+package abiregistry
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	// ErrUnknownMethod is returned when no known ABI describes a call.
+	ErrUnknownMethod = errors.New("unknown method")
+	// ErrUnknownEvent is returned when no known ABI describes a log.
+	ErrUnknownEvent = errors.New("unknown event")
+)
+
+// Argument is a decoded argument of a call or a log.
+type Argument struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// DecodedCall is a contract call decoded with a known ABI.
+type DecodedCall struct {
+	// Contract is the name of the ABI used to decode the call.
+	Contract  string     `json:"contract"`
+	Method    string     `json:"method"`
+	Signature string     `json:"signature"`
+	Arguments []Argument `json:"arguments"`
+}
+
+// DecodedLog is a contract log decoded with a known ABI.
+type DecodedLog struct {
+	// Contract is the name of the ABI used to decode the log.
+	Contract  string     `json:"contract"`
+	Event     string     `json:"event"`
+	Signature string     `json:"signature"`
+	Arguments []Argument `json:"arguments"`
+}
+
+// DecodeCalldata decodes the data of a transaction sent to a contract.
+func (r *Registry) DecodeCalldata(to common.Address, data []byte) (*DecodedCall, error) {
+	if len(data) < 4 {
+		return nil, ErrUnknownMethod
+	}
+	candidates, err := r.candidates(to)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range candidates {
+		method, err := candidate.abi.MethodById(data[:4])
+		if err != nil {
+			continue
+		}
+		values, err := method.Inputs.UnpackValues(data[4:])
+		if err != nil {
+			// The selector may collide with a method of another ABI.
+			continue
+		}
+		call := &DecodedCall{
+			Contract:  candidate.name,
+			Method:    method.Name,
+			Signature: method.Sig(),
+		}
+		for i, input := range method.Inputs {
+			call.Arguments = append(call.Arguments, Argument{
+				Name:  input.Name,
+				Type:  input.Type.String(),
+				Value: formatValue(values[i]),
+			})
+		}
+		return call, nil
+	}
+	return nil, ErrUnknownMethod
+}
+
+// DecodeLog decodes a log emitted by a contract.
+func (r *Registry) DecodeLog(address common.Address, topics []common.Hash, data []byte) (*DecodedLog, error) {
+	if len(topics) == 0 {
+		return nil, ErrUnknownEvent
+	}
+	candidates, err := r.candidates(address)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range candidates {
+		for _, event := range candidate.abi.Events {
+			if event.Anonymous || event.Id() != topics[0] || indexedCount(event.Inputs) != len(topics)-1 {
+				continue
+			}
+			decoded, err := decodeEvent(event, topics[1:], data)
+			if err != nil {
+				continue
+			}
+			decoded.Contract = candidate.name
+			return decoded, nil
+		}
+	}
+	return nil, ErrUnknownEvent
+}
+
+func decodeEvent(event abi.Event, topics []common.Hash, data []byte) (*DecodedLog, error) {
+	values, err := event.Inputs.UnpackValues(data)
+	if err != nil {
+		return nil, err
+	}
+
+	types := make([]string, len(event.Inputs))
+	decoded := &DecodedLog{Event: event.Name}
+	for i, input := range event.Inputs {
+		types[i] = input.Type.String()
+		argument := Argument{Name: input.Name, Type: types[i]}
+		if input.Indexed {
+			argument.Value, err = decodeTopic(input, topics[0])
+			if err != nil {
+				return nil, err
+			}
+			topics = topics[1:]
+		} else {
+			argument.Value = formatValue(values[0])
+			values = values[1:]
+		}
+		decoded.Arguments = append(decoded.Arguments, argument)
+	}
+	decoded.Signature = fmt.Sprintf("%s(%s)", event.Name, strings.Join(types, ","))
+	return decoded, nil
+}
+
+// decodeTopic decodes an indexed argument. Dynamic types are indexed by their hash,
+// which is returned as is.
+func decodeTopic(input abi.Argument, topic common.Hash) (interface{}, error) {
+	switch input.Type.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy:
+		return topic, nil
+	}
+	input.Indexed = false
+	values, err := abi.Arguments{input}.UnpackValues(topic.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return formatValue(values[0]), nil
+}
+
+func indexedCount(inputs abi.Arguments) int {
+	count := 0
+	for _, input := range inputs {
+		if input.Indexed {
+			count++
+		}
+	}
+	return count
+}
+
+// formatValue converts values that don't have a lossless JSON encoding.
+func formatValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case common.Address:
+		return v
+	case *big.Int:
+		return v.String()
+	case []byte:
+		return hexutil.Bytes(v)
+	}
+	// fixed size byte arrays, such as bytes32
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8 {
+		data := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(data), rv)
+		return hexutil.Bytes(data)
+	}
+	return value
+}
@@ -0,0 +1,80 @@
+package abiregistry
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeBuiltInCalldata(t *testing.T) {
+	registry, _ := newTestRegistry(t)
+	to := common.HexToAddress("0x0000000000000000000000000000000000000002")
+
+	data := append(crypto.Keccak256([]byte("transfer(address,uint256)"))[:4], common.LeftPadBytes(to.Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(big.NewInt(100).Bytes(), 32)...)
+
+	call, err := registry.DecodeCalldata(common.Address{1}, data)
+	require.NoError(t, err)
+	require.Equal(t, &DecodedCall{
+		Contract:  "erc20",
+		Method:    "transfer",
+		Signature: "transfer(address,uint256)",
+		Arguments: []Argument{
+			{Name: "_to", Type: "address", Value: to},
+			{Name: "_value", Type: "uint256", Value: "100"},
+		},
+	}, call)
+
+	_, err = registry.DecodeCalldata(common.Address{1}, hexutil.MustDecode("0xdeadbeef"))
+	require.Equal(t, ErrUnknownMethod, err)
+}
+
+func TestDecodeAddedCalldata(t *testing.T) {
+	registry, _ := newTestRegistry(t)
+	contract := common.Address{1}
+	data := append(crypto.Keccak256([]byte("mint(address,uint256)"))[:4], make([]byte, 64)...)
+
+	_, err := registry.DecodeCalldata(contract, data)
+	require.Equal(t, ErrUnknownMethod, err)
+
+	_, err = registry.Add(Entry{Address: contract, Name: "token", ABI: tokenABI})
+	require.NoError(t, err)
+	call, err := registry.DecodeCalldata(contract, data)
+	require.NoError(t, err)
+	require.Equal(t, "token", call.Contract)
+	require.Equal(t, "mint", call.Method)
+
+	_, err = registry.DecodeCalldata(common.Address{2}, data)
+	require.Equal(t, ErrUnknownMethod, err, "Added ABIs only apply to their contract")
+}
+
+func TestDecodeTransferLogs(t *testing.T) {
+	registry, _ := newTestRegistry(t)
+	transfer := crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	from := common.HexToAddress("0x0000000000000000000000000000000000000003")
+	to := common.HexToAddress("0x0000000000000000000000000000000000000004")
+
+	erc20, err := registry.DecodeLog(common.Address{1},
+		[]common.Hash{transfer, from.Hash(), to.Hash()},
+		common.LeftPadBytes(big.NewInt(5).Bytes(), 32))
+	require.NoError(t, err)
+	require.Equal(t, "erc20", erc20.Contract)
+	require.Equal(t, []Argument{
+		{Name: "from", Type: "address", Value: from},
+		{Name: "to", Type: "address", Value: to},
+		{Name: "value", Type: "uint256", Value: "5"},
+	}, erc20.Arguments)
+
+	erc721, err := registry.DecodeLog(common.Address{1},
+		[]common.Hash{transfer, from.Hash(), to.Hash(), common.BigToHash(big.NewInt(7))}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "erc721", erc721.Contract, "Indexed token ids tell ERC721 transfers apart")
+	require.Equal(t, Argument{Name: "tokenId", Type: "uint256", Value: "7"}, erc721.Arguments[2])
+
+	_, err = registry.DecodeLog(common.Address{1}, []common.Hash{{1}}, nil)
+	require.Equal(t, ErrUnknownEvent, err)
+}
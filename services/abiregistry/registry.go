@@ -0,0 +1,192 @@
+package abiregistry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/db"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// MaxABISize is the maximum size in bytes of an ABI that can be added to the registry.
+const MaxABISize = 256 * 1024
+
+var (
+	// ErrABINotFound is returned when no ABI is registered for an address.
+	ErrABINotFound = errors.New("ABI not found")
+	// ErrInvalidAddress is returned when an ABI is added without a contract address.
+	ErrInvalidAddress = errors.New("contract address is required")
+	// ErrInvalidName is returned when an ABI is added without a name.
+	ErrInvalidName = errors.New("name is required")
+	// ErrABITooLarge is returned when an ABI exceeds MaxABISize.
+	ErrABITooLarge = fmt.Errorf("ABI is larger than %d bytes", MaxABISize)
+	// ErrEmptyABI is returned when an ABI has neither methods nor events.
+	ErrEmptyABI = errors.New("ABI has no methods and no events")
+)
+
+// Entry is a contract ABI known to the registry.
+type Entry struct {
+	// Address of the contract. Empty for built-in entries, which apply to any contract.
+	Address common.Address `json:"address"`
+	Name    string         `json:"name"`
+	// ABI is the JSON encoded contract ABI.
+	ABI     string `json:"abi"`
+	BuiltIn bool   `json:"builtIn"`
+	AddedAt int64  `json:"addedAt"`
+}
+
+type parsedEntry struct {
+	name string
+	abi  abi.ABI
+}
+
+// Registry keeps the ABIs of common contracts and the ABIs added for specific
+// contracts by users and dapps. Added entries are persisted.
+type Registry struct {
+	db *leveldb.DB
+
+	mu       sync.Mutex
+	parsed   map[common.Address]parsedEntry
+	builtins []parsedEntry
+}
+
+// NewRegistry returns a new Registry storing added ABIs in db.
+func NewRegistry(db *leveldb.DB) (*Registry, error) {
+	r := &Registry{
+		db:     db,
+		parsed: make(map[common.Address]parsedEntry),
+	}
+	for _, entry := range builtins {
+		parsed, err := parseABI(entry.ABI)
+		if err != nil {
+			return nil, fmt.Errorf("invalid built-in ABI %s: %v", entry.Name, err)
+		}
+		r.builtins = append(r.builtins, parsedEntry{name: entry.Name, abi: parsed})
+	}
+	return r, nil
+}
+
+// Add validates and stores the ABI of a contract, replacing the previous one if any.
+func (r *Registry) Add(entry Entry) (*Entry, error) {
+	if entry.Address == (common.Address{}) {
+		return nil, ErrInvalidAddress
+	}
+	entry.Name = strings.TrimSpace(entry.Name)
+	if entry.Name == "" {
+		return nil, ErrInvalidName
+	}
+	if len(entry.ABI) > MaxABISize {
+		return nil, ErrABITooLarge
+	}
+	parsed, err := parseABI(entry.ABI)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.BuiltIn = false
+	entry.AddedAt = time.Now().Unix()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.db.Put(key(entry.Address), data, nil); err != nil {
+		return nil, err
+	}
+	r.parsed[entry.Address] = parsedEntry{name: entry.Name, abi: parsed}
+	return &entry, nil
+}
+
+// Get returns the ABI added for a contract.
+func (r *Registry) Get(address common.Address) (*Entry, error) {
+	data, err := r.db.Get(key(address), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, ErrABINotFound
+	} else if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// List returns the built-in entries followed by the added ones.
+func (r *Registry) List() ([]Entry, error) {
+	entries := append([]Entry(nil), builtins...)
+
+	iter := r.db.NewIterator(util.BytesPrefix([]byte{byte(db.ContractABIs)}), nil)
+	defer iter.Release()
+	for iter.Next() {
+		var entry Entry
+		if err := json.Unmarshal(iter.Value(), &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, iter.Error()
+}
+
+// Remove deletes the ABI added for a contract.
+func (r *Registry) Remove(address common.Address) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.db.Get(key(address), nil); err == leveldb.ErrNotFound {
+		return ErrABINotFound
+	} else if err != nil {
+		return err
+	}
+	delete(r.parsed, address)
+	return r.db.Delete(key(address), nil)
+}
+
+// candidates returns the ABIs that may describe a contract, the one added for
+// its address first and the built-in ones after.
+func (r *Registry) candidates(address common.Address) ([]parsedEntry, error) {
+	r.mu.Lock()
+	entry, ok := r.parsed[address]
+	r.mu.Unlock()
+	if !ok {
+		stored, err := r.Get(address)
+		if err == ErrABINotFound {
+			return r.builtins, nil
+		} else if err != nil {
+			return nil, err
+		}
+		parsed, err := parseABI(stored.ABI)
+		if err != nil {
+			return nil, err
+		}
+		entry = parsedEntry{name: stored.Name, abi: parsed}
+		r.mu.Lock()
+		r.parsed[address] = entry
+		r.mu.Unlock()
+	}
+	return append([]parsedEntry{entry}, r.builtins...), nil
+}
+
+func parseABI(data string) (abi.ABI, error) {
+	parsed, err := abi.JSON(strings.NewReader(data))
+	if err != nil {
+		return parsed, fmt.Errorf("invalid ABI: %v", err)
+	}
+	if len(parsed.Methods) == 0 && len(parsed.Events) == 0 {
+		return parsed, ErrEmptyABI
+	}
+	return parsed, nil
+}
+
+func key(address common.Address) []byte {
+	return db.Key(db.ContractABIs, address.Bytes())
+}
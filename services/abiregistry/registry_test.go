@@ -0,0 +1,73 @@
+package abiregistry
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+const tokenABI = `[{"type":"function","name":"mint","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[]}]`
+
+func newTestRegistry(t *testing.T) (*Registry, *leveldb.DB) {
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+	registry, err := NewRegistry(db)
+	require.NoError(t, err)
+	return registry, db
+}
+
+func TestRegistryCRUD(t *testing.T) {
+	registry, db := newTestRegistry(t)
+	address := common.Address{1}
+
+	_, err := registry.Get(address)
+	require.Equal(t, ErrABINotFound, err)
+
+	added, err := registry.Add(Entry{Address: address, Name: " token ", ABI: tokenABI, BuiltIn: true})
+	require.NoError(t, err)
+	require.Equal(t, "token", added.Name)
+	require.False(t, added.BuiltIn, "Added entries are never built-in")
+
+	entry, err := registry.Get(address)
+	require.NoError(t, err)
+	require.Equal(t, added, entry)
+
+	restarted, err := NewRegistry(db)
+	require.NoError(t, err)
+	entries, err := restarted.List()
+	require.NoError(t, err)
+	require.Len(t, entries, len(builtins)+1, "It persists added entries")
+	require.Equal(t, *added, entries[len(entries)-1])
+
+	require.NoError(t, restarted.Remove(address))
+	require.Equal(t, ErrABINotFound, restarted.Remove(address))
+	_, err = restarted.Get(address)
+	require.Equal(t, ErrABINotFound, err)
+}
+
+func TestRegistryValidation(t *testing.T) {
+	registry, _ := newTestRegistry(t)
+
+	testCases := []struct {
+		name  string
+		entry Entry
+		err   error
+	}{
+		{"no address", Entry{Name: "token", ABI: tokenABI}, ErrInvalidAddress},
+		{"no name", Entry{Address: common.Address{1}, ABI: tokenABI}, ErrInvalidName},
+		{"empty ABI", Entry{Address: common.Address{1}, Name: "token", ABI: `[]`}, ErrEmptyABI},
+		{"too large", Entry{Address: common.Address{1}, Name: "token", ABI: string(make([]byte, MaxABISize+1))}, ErrABITooLarge},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := registry.Add(tc.entry)
+			require.Equal(t, tc.err, err)
+		})
+	}
+
+	_, err := registry.Add(Entry{Address: common.Address{1}, Name: "token", ABI: `{"invalid`})
+	require.Error(t, err)
+}
@@ -0,0 +1,59 @@
+package abiregistry
+
+import (
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// Make sure that Service implements node.Service interface.
+var _ node.Service = (*Service)(nil)
+
+// Service exposes the registry of contract ABIs used to decode calls and logs.
+type Service struct {
+	registry *Registry
+}
+
+// New returns a new Service storing added ABIs in db.
+func New(db *leveldb.DB) (*Service, error) {
+	registry, err := NewRegistry(db)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{registry: registry}, nil
+}
+
+// Registry returns the registry of contract ABIs.
+func (s *Service) Registry() *Registry {
+	return s.registry
+}
+
+// Protocols returns a new protocols list. In this case, there are none.
+func (s *Service) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{}
+}
+
+// APIs returns a list of new APIs.
+func (s *Service) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "abi",
+			Version:   "1.0",
+			Service:   NewPublicAPI(s),
+			Public:    false,
+		},
+	}
+}
+
+// Start is run when a service is started.
+// It does nothing in this case but is required by `node.Service` interface.
+func (s *Service) Start(server *p2p.Server) error {
+	return nil
+}
+
+// Stop is run when a service is stopped.
+// It does nothing in this case but is required by `node.Service` interface.
+func (s *Service) Stop() error {
+	return nil
+}
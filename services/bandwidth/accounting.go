@@ -0,0 +1,436 @@
+package bandwidth
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/status-im/status-go/db"
+	"github.com/status-im/status-go/services/report"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	// DefaultWindow is the period the traffic is kept for.
+	DefaultWindow = 7 * 24 * time.Hour
+	// bucket is the period aggregated in a single record of traffic.
+	bucket = time.Hour
+	// flushInterval is the interval at which the changed records are persisted.
+	flushInterval = time.Minute
+	// maxTopics is the number of topics accounted in a bucket. The envelopes of the other
+	// topics are accounted together, so that peers can't grow the records without bound.
+	maxTopics = 1000
+)
+
+// Kinds of the records, the first byte of their keys after the prefix.
+const (
+	peerKind        byte = 'p'
+	topicKind       byte = 't'
+	otherTopicsKind byte = 'o'
+)
+
+// reporter reports the errors of the accounting of the traffic to the client.
+var reporter = report.New("bandwidth")
+
+// By default go-ethereum/metrics creates dummy metrics that don't register anything.
+// Real metrics are collected only if -metrics flag is set
+var (
+	bytesInCounter  = metrics.NewRegisteredCounter("bandwidth/in", nil)
+	bytesOutCounter = metrics.NewRegisteredCounter("bandwidth/out", nil)
+)
+
+// Counters are the bytes received and sent.
+type Counters struct {
+	BytesIn  uint64 `json:"bytesIn"`
+	BytesOut uint64 `json:"bytesOut"`
+}
+
+func (c *Counters) add(other Counters) {
+	c.BytesIn += other.BytesIn
+	c.BytesOut += other.BytesOut
+}
+
+func (c *Counters) record(inbound bool, size uint32) {
+	if inbound {
+		c.BytesIn += uint64(size)
+	} else {
+		c.BytesOut += uint64(size)
+	}
+}
+
+func (c Counters) total() uint64 {
+	return c.BytesIn + c.BytesOut
+}
+
+// PeerStats is the traffic exchanged with a peer.
+type PeerStats struct {
+	ID string `json:"id"`
+	Counters
+}
+
+// TopicStats is the traffic of the envelopes of a topic.
+type TopicStats struct {
+	Topic string `json:"topic"`
+	Counters
+}
+
+// Stats is the traffic of the Whisper protocol during the window.
+type Stats struct {
+	// Since is the unix time the oldest traffic was recorded from, in seconds.
+	Since int64 `json:"since"`
+	// Total is the traffic exchanged with all the peers.
+	Total Counters `json:"total"`
+	// Peers are sorted by traffic, the most expensive first.
+	Peers []PeerStats `json:"peers"`
+	// Topics are sorted by traffic, the most expensive first. They only count the
+	// envelopes, not the other packets of the protocol.
+	Topics []TopicStats `json:"topics"`
+	// OtherTopics is the traffic of the envelopes of the topics received once maxTopics
+	// topics were accounted in an hour.
+	OtherTopics Counters `json:"otherTopics"`
+}
+
+type statsKey struct {
+	kind byte
+	// id is the node ID of a peer or the topic.
+	id    string
+	start int64
+}
+
+// Accountant records the bytes received from and sent to each peer by the Whisper
+// protocol, and the bytes of the envelopes of each topic, in hourly buckets persisted
+// for a rolling window.
+type Accountant struct {
+	db     *leveldb.DB
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	buckets map[statsKey]*Counters
+	dirty   map[statsKey]bool
+	// topics is the number of topics of the buckets, by start.
+	topics map[int64]int
+	// conns is the traffic of the connections not merged into the buckets yet.
+	conns map[*peerTraffic]struct{}
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewAccountant returns a new Accountant, loading the traffic persisted in db during
+// window. Zero means DefaultWindow.
+func NewAccountant(level *leveldb.DB, window time.Duration) (*Accountant, error) {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	a := &Accountant{
+		db:      level,
+		window:  window,
+		now:     time.Now,
+		buckets: make(map[statsKey]*Counters),
+		dirty:   make(map[statsKey]bool),
+		topics:  make(map[int64]int),
+		conns:   make(map[*peerTraffic]struct{}),
+	}
+	iter := level.NewIterator(util.BytesPrefix([]byte{byte(db.BandwidthStats)}), nil)
+	defer iter.Release()
+	for iter.Next() {
+		key, ok := parseStatsKey(iter.Key())
+		if !ok {
+			continue
+		}
+		counters := new(Counters)
+		if err := json.Unmarshal(iter.Value(), counters); err != nil {
+			return nil, err
+		}
+		a.buckets[key] = counters
+		if key.kind == topicKind {
+			a.topics[key.start]++
+		}
+	}
+	return a, iter.Error()
+}
+
+// Start persists the traffic periodically until Stop is called.
+func (a *Accountant) Start() {
+	a.quit = make(chan struct{})
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := a.flush(); err != nil {
+					reporter.Error("save-bandwidth-stats", report.WithCode(err, report.CodeStorage))
+				}
+			case <-a.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops persisting the traffic periodically and persists it.
+func (a *Accountant) Stop() error {
+	if a.quit != nil {
+		close(a.quit)
+		a.wg.Wait()
+		a.quit = nil
+	}
+	return a.flush()
+}
+
+// Stats returns the traffic recorded during the window.
+func (a *Accountant) Stats() Stats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.mergeConns()
+	oldest := bucketStart(a.now().Add(-a.window))
+	stats := Stats{
+		Since:  bucketStart(a.now()),
+		Peers:  []PeerStats{},
+		Topics: []TopicStats{},
+	}
+	peers := make(map[string]*Counters)
+	topics := make(map[string]*Counters)
+	for key, counters := range a.buckets {
+		if key.start < oldest {
+			continue
+		}
+		if key.start < stats.Since {
+			stats.Since = key.start
+		}
+		if key.kind == otherTopicsKind {
+			stats.OtherTopics.add(*counters)
+			continue
+		}
+		merged := peers
+		if key.kind == topicKind {
+			merged = topics
+		} else {
+			stats.Total.add(*counters)
+		}
+		if _, ok := merged[key.id]; !ok {
+			merged[key.id] = new(Counters)
+		}
+		merged[key.id].add(*counters)
+	}
+	for id, counters := range peers {
+		var nodeID enode.ID
+		copy(nodeID[:], id)
+		stats.Peers = append(stats.Peers, PeerStats{ID: nodeID.String(), Counters: *counters})
+	}
+	for id, counters := range topics {
+		topic := whisper.BytesToTopic([]byte(id))
+		stats.Topics = append(stats.Topics, TopicStats{Topic: topic.String(), Counters: *counters})
+	}
+	sort.Slice(stats.Peers, func(i, j int) bool {
+		return costsMore(stats.Peers[i].Counters, stats.Peers[j].Counters, stats.Peers[i].ID, stats.Peers[j].ID)
+	})
+	sort.Slice(stats.Topics, func(i, j int) bool {
+		return costsMore(stats.Topics[i].Counters, stats.Topics[j].Counters, stats.Topics[i].Topic, stats.Topics[j].Topic)
+	})
+	return stats
+}
+
+// costsMore returns true if a costs more than b, or as much and idA sorts before idB.
+func costsMore(a, b Counters, idA, idB string) bool {
+	if a.total() != b.total() {
+		return a.total() > b.total()
+	}
+	return idA < idB
+}
+
+// peerTraffic is the traffic of a connection to a peer, accounted without the lock of the
+// Accountant and merged into its buckets when they're read or persisted.
+type peerTraffic struct {
+	peer enode.ID
+	now  func() time.Time
+
+	mu      sync.Mutex
+	current pendingTraffic
+	// previous is the traffic of the buckets before the current one.
+	previous []pendingTraffic
+}
+
+// pendingTraffic is the traffic of a connection during a bucket.
+type pendingTraffic struct {
+	start  int64
+	peer   Counters
+	topics map[whisper.TopicType]*Counters
+	other  Counters
+}
+
+// connect returns the traffic of a new connection to peer.
+func (a *Accountant) connect(peer enode.ID) *peerTraffic {
+	t := &peerTraffic{peer: peer, now: a.now}
+	a.mu.Lock()
+	a.conns[t] = struct{}{}
+	a.mu.Unlock()
+	return t
+}
+
+// disconnect merges the traffic of a connection that ended.
+func (a *Accountant) disconnect(t *peerTraffic) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.merge(t)
+	delete(a.conns, t)
+}
+
+// record records a packet of size bytes exchanged with the peer, and the envelopes it carries.
+func (t *peerTraffic) record(inbound bool, size uint32, envelopes []envelopeSize) {
+	start := bucketStart(t.now())
+	t.mu.Lock()
+	if t.current.topics == nil || t.current.start != start {
+		if t.current.topics != nil {
+			t.previous = append(t.previous, t.current)
+		}
+		t.current = pendingTraffic{start: start, topics: make(map[whisper.TopicType]*Counters)}
+	}
+	t.current.peer.record(inbound, size)
+	for _, e := range envelopes {
+		counters, ok := t.current.topics[e.topic]
+		if !ok {
+			if len(t.current.topics) >= maxTopics {
+				t.current.other.record(inbound, e.size)
+				continue
+			}
+			counters = new(Counters)
+			t.current.topics[e.topic] = counters
+		}
+		counters.record(inbound, e.size)
+	}
+	t.mu.Unlock()
+
+	if inbound {
+		bytesInCounter.Inc(int64(size))
+	} else {
+		bytesOutCounter.Inc(int64(size))
+	}
+}
+
+// take returns the traffic of the connection, and resets it.
+func (t *peerTraffic) take() []pendingTraffic {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	traffic := t.previous
+	if t.current.topics != nil {
+		traffic = append(traffic, t.current)
+	}
+	t.previous = nil
+	t.current = pendingTraffic{}
+	return traffic
+}
+
+// mergeConns merges the traffic of the connections into the buckets.
+func (a *Accountant) mergeConns() {
+	for t := range a.conns {
+		a.merge(t)
+	}
+}
+
+// merge merges the traffic of a connection into the buckets. Topics are accounted with the
+// other topics once maxTopics topics are accounted in a bucket.
+func (a *Accountant) merge(t *peerTraffic) {
+	for _, traffic := range t.take() {
+		a.add(statsKey{kind: peerKind, id: string(t.peer[:]), start: traffic.start}, traffic.peer)
+		other := traffic.other
+		for topic, counters := range traffic.topics {
+			key := statsKey{kind: topicKind, id: string(topic[:]), start: traffic.start}
+			if _, ok := a.buckets[key]; !ok {
+				if a.topics[traffic.start] >= maxTopics {
+					other.add(*counters)
+					continue
+				}
+				a.topics[traffic.start]++
+			}
+			a.add(key, *counters)
+		}
+		if other.total() > 0 {
+			a.add(statsKey{kind: otherTopicsKind, start: traffic.start}, other)
+		}
+	}
+}
+
+func (a *Accountant) add(key statsKey, traffic Counters) {
+	counters, ok := a.buckets[key]
+	if !ok {
+		counters = new(Counters)
+		a.buckets[key] = counters
+	}
+	counters.add(traffic)
+	a.dirty[key] = true
+}
+
+// flush persists the changed buckets and deletes the buckets older than the window.
+func (a *Accountant) flush() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.mergeConns()
+	oldest := bucketStart(a.now().Add(-a.window))
+	batch := new(leveldb.Batch)
+	for key, counters := range a.buckets {
+		if key.start < oldest {
+			batch.Delete(key.bytes())
+			delete(a.buckets, key)
+			delete(a.topics, key.start)
+			continue
+		}
+		if !a.dirty[key] {
+			continue
+		}
+		value, err := json.Marshal(counters)
+		if err != nil {
+			return err
+		}
+		batch.Put(key.bytes(), value)
+	}
+	if err := a.db.Write(batch, nil); err != nil {
+		return err
+	}
+	a.dirty = make(map[statsKey]bool)
+	return nil
+}
+
+func bucketStart(t time.Time) int64 {
+	return t.Truncate(bucket).Unix()
+}
+
+func (k statsKey) bytes() []byte {
+	start := make([]byte, 8)
+	binary.BigEndian.PutUint64(start, uint64(k.start))
+	return db.Key(db.BandwidthStats, []byte{k.kind}, []byte(k.id), start)
+}
+
+func parseStatsKey(key []byte) (statsKey, bool) {
+	var k statsKey
+	if len(key) < 2 {
+		return k, false
+	}
+	k.kind = key[1]
+	var idLength int
+	switch k.kind {
+	case peerKind:
+		idLength = len(enode.ID{})
+	case topicKind:
+		idLength = whisper.TopicLength
+	case otherTopicsKind:
+	default:
+		return k, false
+	}
+	if len(key) != 2+idLength+8 {
+		return k, false
+	}
+	k.id = string(key[2 : 2+idLength])
+	k.start = int64(binary.BigEndian.Uint64(key[2+idLength:]))
+	return k, true
+}
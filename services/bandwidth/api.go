@@ -0,0 +1,19 @@
+package bandwidth
+
+import "context"
+
+// API exposes the traffic of the node over RPC.
+type API struct {
+	service *Service
+}
+
+// NewAPI returns a new API.
+func NewAPI(s *Service) *API {
+	return &API{service: s}
+}
+
+// BandwidthStats returns the traffic of the Whisper protocol with each peer and on
+// each topic during the window.
+func (api *API) BandwidthStats(context context.Context) Stats {
+	return api.service.accountant.Stats()
+}
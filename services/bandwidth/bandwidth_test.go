@@ -0,0 +1,141 @@
+package bandwidth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+func TestAccountant(t *testing.T) {
+	level, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+	defer level.Close()
+
+	now := time.Unix(1546000000, 0)
+	a, err := NewAccountant(level, 2*time.Hour)
+	require.NoError(t, err)
+	a.now = func() time.Time { return now }
+
+	first, second := enode.ID{1}, enode.ID{2}
+	topic := whisper.TopicType{1, 2, 3, 4}
+	firstTraffic := a.connect(first)
+	firstTraffic.record(true, 100, []envelopeSize{{topic: topic, size: 90}})
+	firstTraffic.record(false, 10, nil)
+	a.disconnect(firstTraffic)
+	now = now.Add(time.Hour)
+	a.connect(second).record(true, 300, []envelopeSize{{topic: topic, size: 140}, {topic: topic, size: 150}})
+	require.NoError(t, a.flush())
+
+	expected := Stats{
+		Since: now.Add(-time.Hour).Truncate(time.Hour).Unix(),
+		Total: Counters{BytesIn: 400, BytesOut: 10},
+		Peers: []PeerStats{
+			{ID: second.String(), Counters: Counters{BytesIn: 300}},
+			{ID: first.String(), Counters: Counters{BytesIn: 100, BytesOut: 10}},
+		},
+		Topics: []TopicStats{{Topic: "0x01020304", Counters: Counters{BytesIn: 380}}},
+	}
+	require.Equal(t, expected, a.Stats())
+
+	loaded, err := NewAccountant(level, 2*time.Hour)
+	require.NoError(t, err)
+	loaded.now = a.now
+	require.Equal(t, expected, loaded.Stats(), "Traffic is persisted")
+
+	now = now.Add(2 * time.Hour)
+	require.NoError(t, a.flush())
+	loaded, err = NewAccountant(level, 2*time.Hour)
+	require.NoError(t, err)
+	loaded.now = a.now
+	stats := loaded.Stats()
+	require.Equal(t, Counters{BytesIn: 300}, stats.Total, "Traffic older than the window is deleted")
+	require.Len(t, stats.Peers, 1)
+	require.Equal(t, second.String(), stats.Peers[0].ID)
+}
+
+func TestAccountantTopicsLimit(t *testing.T) {
+	level, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+	defer level.Close()
+	a, err := NewAccountant(level, 0)
+	require.NoError(t, err)
+
+	first, second := a.connect(enode.ID{1}), a.connect(enode.ID{2})
+	for i := 0; i < maxTopics+10; i++ {
+		topic := whisper.TopicType{byte(i >> 8), byte(i)}
+		first.record(true, 10, []envelopeSize{{topic: topic, size: 10}})
+	}
+	second.record(true, 20, []envelopeSize{{topic: whisper.TopicType{0xff, 0xff}, size: 20}})
+	require.NoError(t, a.flush())
+
+	stats := a.Stats()
+	require.Len(t, stats.Topics, maxTopics, "Topics are capped")
+	require.Equal(t, Counters{BytesIn: 10*10 + 20}, stats.OtherTopics, "Envelopes of other topics are accounted together")
+	require.Equal(t, Counters{BytesIn: (maxTopics+10)*10 + 20}, stats.Total)
+
+	loaded, err := NewAccountant(level, 0)
+	require.NoError(t, err)
+	require.Equal(t, stats, loaded.Stats(), "Traffic of other topics is persisted")
+}
+
+func TestProtocol(t *testing.T) {
+	level, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+	defer level.Close()
+	a, err := NewAccountant(level, 0)
+	require.NoError(t, err)
+
+	relayed := &whisper.Envelope{Topic: whisper.TopicType{1}, Data: []byte{1, 2, 3}}
+	direct := &whisper.Envelope{Topic: whisper.TopicType{2}, Data: make([]byte, 100)}
+	received := make(chan []*whisper.Envelope, 1)
+	protocol := a.Protocol(p2p.Protocol{Run: func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		var envelopes []*whisper.Envelope
+		if err := msg.Decode(&envelopes); err != nil {
+			return err
+		}
+		received <- envelopes
+		if err := p2p.Send(rw, p2pMessageCode, direct); err != nil {
+			return err
+		}
+		_, err = rw.ReadMsg()
+		return err
+	}})
+
+	local, remote := p2p.MsgPipe()
+	peer := p2p.NewPeer(enode.ID{1}, "peer", nil)
+	done := make(chan error, 1)
+	go func() { done <- protocol.Run(peer, local) }()
+
+	require.NoError(t, p2p.Send(remote, messagesCode, []*whisper.Envelope{relayed}))
+	envelopes := <-received
+	require.Len(t, envelopes, 1, "Packets are read again by Whisper")
+	require.Equal(t, relayed.Topic, envelopes[0].Topic)
+	require.NoError(t, p2p.ExpectMsg(remote, p2pMessageCode, direct))
+	require.NoError(t, remote.Close())
+	require.Error(t, <-done)
+
+	packet, err := rlp.EncodeToBytes([]*whisper.Envelope{relayed})
+	require.NoError(t, err)
+	relayedRLP, err := rlp.EncodeToBytes(relayed)
+	require.NoError(t, err)
+	directRLP, err := rlp.EncodeToBytes(direct)
+	require.NoError(t, err)
+
+	stats := a.Stats()
+	require.Equal(t, Counters{BytesIn: uint64(len(packet)), BytesOut: uint64(len(directRLP))}, stats.Total)
+	require.Equal(t, []TopicStats{
+		{Topic: "0x02000000", Counters: Counters{BytesOut: uint64(len(directRLP))}},
+		{Topic: "0x01000000", Counters: Counters{BytesIn: uint64(len(relayedRLP))}},
+	}, stats.Topics)
+}
@@ -0,0 +1,130 @@
+package bandwidth
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+const (
+	// messagesCode is the code of the Whisper packets that carry envelopes relayed by peers.
+	messagesCode = 1
+	// p2pMessageCode is the code of the Whisper packets that carry envelopes sent directly
+	// by trusted peers, such as MailServers. They carry a single envelope or a list.
+	p2pMessageCode = 127
+)
+
+// envelopeSize is the size of an envelope of a packet, in bytes.
+type envelopeSize struct {
+	topic whisper.TopicType
+	size  uint32
+}
+
+// Protocol wraps the Whisper protocol so that the packets exchanged with each peer, and
+// the envelopes they carry, are accounted. It must wrap the other wrappers of the protocol,
+// so that the packets they drop are accounted too.
+func (a *Accountant) Protocol(protocol p2p.Protocol) p2p.Protocol {
+	run := protocol.Run
+	protocol.Run = func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+		traffic := a.connect(peer.ID())
+		defer a.disconnect(traffic)
+		return run(peer, &accountedReadWriter{MsgReadWriter: rw, traffic: traffic})
+	}
+	return protocol
+}
+
+type accountedReadWriter struct {
+	p2p.MsgReadWriter
+	traffic *peerTraffic
+}
+
+// ReadMsg returns the next packet, once accounted.
+func (rw *accountedReadWriter) ReadMsg() (p2p.Msg, error) {
+	msg, err := rw.MsgReadWriter.ReadMsg()
+	if err != nil {
+		return msg, err
+	}
+	envelopes, err := readEnvelopes(&msg)
+	if err != nil {
+		return msg, err
+	}
+	rw.traffic.record(true, msg.Size, envelopes)
+	return msg, nil
+}
+
+// WriteMsg sends a packet and accounts it.
+func (rw *accountedReadWriter) WriteMsg(msg p2p.Msg) error {
+	envelopes, err := readEnvelopes(&msg)
+	if err != nil {
+		return err
+	}
+	if err := rw.MsgReadWriter.WriteMsg(msg); err != nil {
+		return err
+	}
+	rw.traffic.record(false, msg.Size, envelopes)
+	return nil
+}
+
+// readEnvelopes returns the sizes of the envelopes carried by msg, leaving its payload
+// to be read again. Only the headers of the envelopes are read, Whisper decodes them.
+// Packets that can't be decoded are accounted without their envelopes, Whisper
+// disconnects the peers sending them.
+func readEnvelopes(msg *p2p.Msg) ([]envelopeSize, error) {
+	if msg.Code != messagesCode && msg.Code != p2pMessageCode {
+		return nil, nil
+	}
+	data := make([]byte, msg.Size)
+	if _, err := io.ReadFull(msg.Payload, data); err != nil {
+		return nil, err
+	}
+	msg.Payload = bytes.NewReader(data)
+
+	if msg.Code == p2pMessageCode {
+		if topic, ok := envelopeTopic(data); ok {
+			return []envelopeSize{{topic: topic, size: msg.Size}}, nil
+		}
+	}
+	content, rest, err := rlp.SplitList(data)
+	if err != nil || len(rest) != 0 {
+		return nil, nil
+	}
+	var envelopes []envelopeSize
+	for len(content) > 0 {
+		_, _, rest, err := rlp.Split(content)
+		if err != nil {
+			return nil, nil
+		}
+		raw := content[:len(content)-len(rest)]
+		topic, ok := envelopeTopic(raw)
+		if !ok {
+			return nil, nil
+		}
+		envelopes = append(envelopes, envelopeSize{topic: topic, size: uint32(len(raw))})
+		content = rest
+	}
+	return envelopes, nil
+}
+
+// envelopeTopic returns the topic of an encoded envelope, [Expiry, TTL, Topic, Data, Nonce],
+// skipping its first fields.
+func envelopeTopic(raw []byte) (topic whisper.TopicType, ok bool) {
+	fields, rest, err := rlp.SplitList(raw)
+	if err != nil || len(rest) != 0 {
+		return topic, false
+	}
+	// Expiry and TTL
+	for i := 0; i < 2; i++ {
+		if _, fields, err = rlp.SplitString(fields); err != nil {
+			return topic, false
+		}
+	}
+	value, _, err := rlp.SplitString(fields)
+	if err != nil || len(value) != whisper.TopicLength {
+		return topic, false
+	}
+	copy(topic[:], value)
+	return topic, true
+}
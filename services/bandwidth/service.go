@@ -0,0 +1,56 @@
+package bandwidth
+
+import (
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Make sure that Service implements node.Service interface.
+var _ node.Service = (*Service)(nil)
+
+// Service accounts the traffic of the Whisper protocol and exposes it. The Whisper
+// protocol must be wrapped with the Protocol of its Accountant.
+type Service struct {
+	accountant *Accountant
+}
+
+// New returns a new Service.
+func New(accountant *Accountant) *Service {
+	return &Service{accountant: accountant}
+}
+
+// Accountant returns the accountant of the traffic.
+func (s *Service) Accountant() *Accountant {
+	return s.accountant
+}
+
+// Protocols returns a new protocols list. In this case, there are none.
+func (s *Service) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{}
+}
+
+// APIs returns a list of new APIs. They extend the status namespace.
+func (s *Service) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "status",
+			Version:   "1.0",
+			Service:   NewAPI(s),
+			Public:    false,
+		},
+	}
+}
+
+// Start is run when a service is started.
+// It persists the traffic periodically.
+func (s *Service) Start(*p2p.Server) error {
+	s.accountant.Start()
+	return nil
+}
+
+// Stop is run when a service is stopped.
+// It persists the traffic.
+func (s *Service) Stop() error {
+	return s.accountant.Stop()
+}
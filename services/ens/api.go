@@ -0,0 +1,52 @@
+package ens
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// API exposes the ENS resolution over RPC.
+type API struct {
+	s *Service
+}
+
+// NewAPI returns a new API.
+func NewAPI(s *Service) *API {
+	return &API{s: s}
+}
+
+func (api *API) resolver() (*Resolver, error) {
+	if api.s.resolver == nil {
+		return nil, ErrUnsupportedNetwork
+	}
+	return api.s.resolver, nil
+}
+
+// Resolve returns the owner, the address, the chat key and the content hash of a name.
+func (api *API) Resolve(ctx context.Context, name string) (*Resolution, error) {
+	resolver, err := api.resolver()
+	if err != nil {
+		return nil, err
+	}
+	return resolver.Resolve(ctx, name)
+}
+
+// ReverseResolve returns the primary name of an address, if it resolves to the address.
+func (api *API) ReverseResolve(ctx context.Context, address common.Address) (*ReverseResolution, error) {
+	resolver, err := api.resolver()
+	if err != nil {
+		return nil, err
+	}
+	return resolver.ReverseResolve(ctx, address)
+}
+
+// VerifyOwnership returns true if a name is owned by an address, before registering it as
+// the username of the account.
+func (api *API) VerifyOwnership(ctx context.Context, name string, address common.Address) (bool, error) {
+	resolver, err := api.resolver()
+	if err != nil {
+		return false, err
+	}
+	return resolver.VerifyOwner(ctx, name, address)
+}
@@ -0,0 +1,301 @@
+package ens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/contracts/ens"
+	"github.com/ethereum/go-ethereum/contracts/ens/contract"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/status-im/status-go/params"
+)
+
+// DefaultCacheTTL is how long the resolutions are cached.
+const DefaultCacheTTL = time.Hour
+
+var (
+	// ErrInvalidName is returned when resolving a name with an empty label.
+	ErrInvalidName = errors.New("invalid ENS name")
+	// ErrNoResolver is returned when resolving a name without resolver.
+	ErrNoResolver = errors.New("no resolver is set for the name")
+	// ErrUnsupportedNetwork is returned when resolving names on a network without ENS registry.
+	ErrUnsupportedNetwork = errors.New("ENS is not deployed on the network")
+)
+
+// registries are the addresses of the ENS registry on each network.
+var registries = map[uint64]common.Address{
+	params.MainNetworkID:    ens.MainNetAddress,
+	params.RopstenNetworkID: ens.TestNetAddress,
+	params.RinkebyNetworkID: common.HexToAddress("0xe7410170f87102df0055eb195163a03b7f2bff4a"),
+}
+
+// contentHashABI is the ABI of the contenthash record of EIP-1577, which the public
+// resolver of go-ethereum predates.
+const contentHashABI = `[{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"contenthash","outputs":[{"name":"","type":"bytes"}],"type":"function"}]`
+
+// Resolution are the records of a name.
+type Resolution struct {
+	Name  string         `json:"name"`
+	Owner common.Address `json:"owner"`
+	// Address is the address the name resolves to, or nil if it's not set.
+	Address *common.Address `json:"address"`
+	// PublicKey is the uncompressed chat key of the name, omitted if it's not set.
+	PublicKey hexutil.Bytes `json:"publicKey,omitempty"`
+	// ContentHash is the EIP-1577 content hash of the name, omitted if it's not set.
+	ContentHash hexutil.Bytes `json:"contentHash,omitempty"`
+	// ResolvedAt is the time the records were resolved, in seconds.
+	ResolvedAt int64 `json:"resolvedAt"`
+}
+
+// ReverseResolution is the primary name of an address.
+type ReverseResolution struct {
+	Address common.Address `json:"address"`
+	// Name is empty if the address has no primary name, or if the name doesn't resolve
+	// to the address.
+	Name       string `json:"name"`
+	ResolvedAt int64  `json:"resolvedAt"`
+}
+
+// Store caches the resolutions in the database of the selected account.
+type Store interface {
+	// SaveENSResolution saves the resolution of a name on a network, replacing the previous one.
+	SaveENSResolution(networkID uint64, resolution Resolution) error
+	// GetENSResolution returns the resolution of a name on a network, or nil if it was never saved.
+	GetENSResolution(networkID uint64, name string) (*Resolution, error)
+	// SaveENSReverseResolution saves the primary name of an address on a network, replacing the previous one.
+	SaveENSReverseResolution(networkID uint64, resolution ReverseResolution) error
+	// GetENSReverseResolution returns the primary name of an address on a network, or nil if it was never saved.
+	GetENSReverseResolution(networkID uint64, address common.Address) (*ReverseResolution, error)
+}
+
+// Resolver resolves the names of the ENS registry of a network, and caches them in the
+// store of the selected account. Without store, names are resolved on each call.
+type Resolver struct {
+	caller    bind.ContractCaller
+	networkID uint64
+	registry  common.Address
+	ttl       time.Duration
+
+	mu    sync.RWMutex
+	store Store
+}
+
+// NewResolver returns a new Resolver of the registry at an address on a network.
+func NewResolver(caller bind.ContractCaller, networkID uint64, registry common.Address, ttl time.Duration) *Resolver {
+	return &Resolver{
+		caller:    caller,
+		networkID: networkID,
+		registry:  registry,
+		ttl:       ttl,
+	}
+}
+
+// SetStore sets the store of the resolutions of the selected account, or nil if no account
+// is selected.
+func (r *Resolver) SetStore(store Store) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store = store
+}
+
+func (r *Resolver) getStore() Store {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.store
+}
+
+func (r *Resolver) fresh(resolvedAt int64, now time.Time) bool {
+	return now.Sub(time.Unix(resolvedAt, 0)) < r.ttl
+}
+
+// Resolve returns the records of a name, from the cache if they were resolved less than
+// the TTL ago.
+func (r *Resolver) Resolve(ctx context.Context, name string) (*Resolution, error) {
+	name, err := normalize(name)
+	if err != nil {
+		return nil, err
+	}
+	store := r.getStore()
+	now := time.Now()
+	if store != nil {
+		cached, err := store.GetENSResolution(r.networkID, name)
+		if err != nil {
+			return nil, err
+		}
+		if cached != nil && r.fresh(cached.ResolvedAt, now) {
+			return cached, nil
+		}
+	}
+
+	resolution, err := r.resolve(ctx, name, now)
+	if err != nil {
+		return nil, err
+	}
+	if store != nil {
+		if err := store.SaveENSResolution(r.networkID, *resolution); err != nil {
+			log.Error("failed to cache ENS resolution", "name", name, "err", err)
+		}
+	}
+	return resolution, nil
+}
+
+// ReverseResolve returns the primary name of an address, set in the reverse registrar. It
+// is empty if the address has no primary name, or if the name doesn't resolve to the
+// address, as anyone can claim any name as primary.
+func (r *Resolver) ReverseResolve(ctx context.Context, address common.Address) (*ReverseResolution, error) {
+	store := r.getStore()
+	now := time.Now()
+	if store != nil {
+		cached, err := store.GetENSReverseResolution(r.networkID, address)
+		if err != nil {
+			return nil, err
+		}
+		if cached != nil && r.fresh(cached.ResolvedAt, now) {
+			return cached, nil
+		}
+	}
+
+	result := &ReverseResolution{Address: address, ResolvedAt: now.Unix()}
+	name, err := r.name(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		resolution, err := r.Resolve(ctx, name)
+		switch err {
+		case nil:
+			if resolution.Address != nil && *resolution.Address == address {
+				result.Name = resolution.Name
+			}
+		case ErrInvalidName, ErrNoResolver:
+		default:
+			return nil, err
+		}
+	}
+	if store != nil {
+		if err := store.SaveENSReverseResolution(r.networkID, *result); err != nil {
+			log.Error("failed to cache ENS reverse resolution", "address", address.Hex(), "err", err)
+		}
+	}
+	return result, nil
+}
+
+// VerifyOwner returns true if a name is owned by an address in the registry, without
+// cache, so that a username is only registered for the account owning it.
+func (r *Resolver) VerifyOwner(ctx context.Context, name string, owner common.Address) (bool, error) {
+	name, err := normalize(name)
+	if err != nil {
+		return false, err
+	}
+	registry, err := contract.NewENSCaller(r.registry, r.caller)
+	if err != nil {
+		return false, err
+	}
+	actual, err := registry.Owner(&bind.CallOpts{Context: ctx}, ens.EnsNode(name))
+	if err != nil {
+		return false, err
+	}
+	return actual == owner, nil
+}
+
+func (r *Resolver) resolve(ctx context.Context, name string, now time.Time) (*Resolution, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	node := ens.EnsNode(name)
+	registry, err := contract.NewENSCaller(r.registry, r.caller)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := registry.Owner(opts, node)
+	if err != nil {
+		return nil, err
+	}
+	resolverAddress, err := registry.Resolver(opts, node)
+	if err != nil {
+		return nil, err
+	}
+	if resolverAddress == (common.Address{}) {
+		return nil, ErrNoResolver
+	}
+	resolver, err := contract.NewPublicResolverCaller(resolverAddress, r.caller)
+	if err != nil {
+		return nil, err
+	}
+
+	resolution := &Resolution{Name: name, Owner: owner, ResolvedAt: now.Unix()}
+	address, err := resolver.Addr(opts, node)
+	if err != nil {
+		return nil, err
+	}
+	if address != (common.Address{}) {
+		resolution.Address = &address
+	}
+	// Resolvers may not support the other records.
+	pubkey, err := resolver.Pubkey(opts, node)
+	if err != nil {
+		log.Debug("failed to resolve ENS public key", "name", name, "err", err)
+	} else if pubkey.X != [32]byte{} || pubkey.Y != [32]byte{} {
+		resolution.PublicKey = append(append([]byte{4}, pubkey.X[:]...), pubkey.Y[:]...)
+	}
+	resolution.ContentHash, err = r.contentHash(opts, resolverAddress, node)
+	if err != nil {
+		log.Debug("failed to resolve ENS content hash", "name", name, "err", err)
+	}
+	return resolution, nil
+}
+
+func (r *Resolver) contentHash(opts *bind.CallOpts, resolver common.Address, node common.Hash) (hexutil.Bytes, error) {
+	parsed, err := abi.JSON(strings.NewReader(contentHashABI))
+	if err != nil {
+		return nil, err
+	}
+	var result []byte
+	if err := bind.NewBoundContract(resolver, parsed, r.caller, nil, nil).Call(opts, &result, "contenthash", node); err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+// name returns the name set in the reverse registrar for an address.
+func (r *Resolver) name(ctx context.Context, address common.Address) (string, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	node := ens.EnsNode(fmt.Sprintf("%x.addr.reverse", address.Bytes()))
+	registry, err := contract.NewENSCaller(r.registry, r.caller)
+	if err != nil {
+		return "", err
+	}
+	resolverAddress, err := registry.Resolver(opts, node)
+	if err != nil {
+		return "", err
+	}
+	if resolverAddress == (common.Address{}) {
+		return "", nil
+	}
+	resolver, err := contract.NewPublicResolverCaller(resolverAddress, r.caller)
+	if err != nil {
+		return "", err
+	}
+	return resolver.Name(opts, node)
+}
+
+// normalize lowercases a name, and returns ErrInvalidName if it has an empty label. The
+// other rules of UTS-46 are not applied.
+func normalize(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, label := range strings.Split(name, ".") {
+		if label == "" {
+			return "", ErrInvalidName
+		}
+	}
+	return name, nil
+}
@@ -0,0 +1,201 @@
+package ens
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/contracts/ens"
+	"github.com/ethereum/go-ethereum/contracts/ens/contract"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+const testNetworkID = 3
+
+type memoryStore struct {
+	resolutions map[string]Resolution
+	reverse     map[common.Address]ReverseResolution
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		resolutions: make(map[string]Resolution),
+		reverse:     make(map[common.Address]ReverseResolution),
+	}
+}
+
+func (s *memoryStore) SaveENSResolution(networkID uint64, resolution Resolution) error {
+	s.resolutions[resolution.Name] = resolution
+	return nil
+}
+
+func (s *memoryStore) GetENSResolution(networkID uint64, name string) (*Resolution, error) {
+	resolution, ok := s.resolutions[name]
+	if !ok {
+		return nil, nil
+	}
+	return &resolution, nil
+}
+
+func (s *memoryStore) SaveENSReverseResolution(networkID uint64, resolution ReverseResolution) error {
+	s.reverse[resolution.Address] = resolution
+	return nil
+}
+
+func (s *memoryStore) GetENSReverseResolution(networkID uint64, address common.Address) (*ReverseResolution, error) {
+	resolution, ok := s.reverse[address]
+	if !ok {
+		return nil, nil
+	}
+	return &resolution, nil
+}
+
+// testRegistry is an ENS registry deployed on a simulated backend, whose nodes are all
+// owned by the deployer.
+type testRegistry struct {
+	t               *testing.T
+	backend         *backends.SimulatedBackend
+	auth            *bind.TransactOpts
+	address         common.Address
+	registry        *contract.ENS
+	resolver        *contract.PublicResolver
+	resolverAddress common.Address
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	auth := bind.NewKeyedTransactor(key)
+	alloc := make(core.GenesisAlloc)
+	alloc[auth.From] = core.GenesisAccount{Balance: big.NewInt(math.MaxInt64)}
+	backend := backends.NewSimulatedBackend(alloc, math.MaxInt64)
+
+	r := &testRegistry{t: t, backend: backend, auth: auth}
+	r.address, _, r.registry, err = contract.DeployENS(auth, backend)
+	require.NoError(t, err)
+	backend.Commit()
+	r.resolverAddress, _, r.resolver, err = contract.DeployPublicResolver(auth, backend, r.address)
+	require.NoError(t, err)
+	backend.Commit()
+	return r
+}
+
+// register creates the node of a name, with the public resolver.
+func (r *testRegistry) register(name string) common.Hash {
+	labels := strings.Split(name, ".")
+	parent := common.Hash{}
+	for i := len(labels) - 1; i >= 0; i-- {
+		_, err := r.registry.SetSubnodeOwner(r.auth, parent, crypto.Keccak256Hash([]byte(labels[i])), r.auth.From)
+		require.NoError(r.t, err)
+		r.backend.Commit()
+		parent = crypto.Keccak256Hash(parent[:], crypto.Keccak256([]byte(labels[i])))
+	}
+	node := ens.EnsNode(name)
+	require.Equal(r.t, node, parent)
+	_, err := r.registry.SetResolver(r.auth, node, r.resolverAddress)
+	require.NoError(r.t, err)
+	r.backend.Commit()
+	return node
+}
+
+func (r *testRegistry) commit(_ interface{}, err error) {
+	require.NoError(r.t, err)
+	r.backend.Commit()
+}
+
+func TestResolve(t *testing.T) {
+	r := newTestRegistry(t)
+	resolver := NewResolver(r.backend, testNetworkID, r.address, time.Minute)
+	alice := common.HexToAddress("0x01")
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	pubkey := crypto.FromECDSAPub(&key.PublicKey)
+	var x, y [32]byte
+	copy(x[:], pubkey[1:33])
+	copy(y[:], pubkey[33:])
+
+	_, err = resolver.Resolve(context.Background(), "alice.eth")
+	require.Equal(t, ErrNoResolver, err)
+	_, err = resolver.Resolve(context.Background(), "alice..eth")
+	require.Equal(t, ErrInvalidName, err)
+
+	node := r.register("alice.eth")
+	r.commit(r.resolver.SetAddr(r.auth, node, alice))
+	r.commit(r.resolver.SetPubkey(r.auth, node, x, y))
+
+	resolution, err := resolver.Resolve(context.Background(), "Alice.ETH")
+	require.NoError(t, err)
+	require.Equal(t, "alice.eth", resolution.Name)
+	require.Equal(t, r.auth.From, resolution.Owner)
+	require.Equal(t, &alice, resolution.Address)
+	require.Equal(t, pubkey, []byte(resolution.PublicKey))
+	require.Nil(t, resolution.ContentHash, "Resolvers without contenthash have no content hash")
+
+	owner, err := resolver.VerifyOwner(context.Background(), "alice.eth", r.auth.From)
+	require.NoError(t, err)
+	require.True(t, owner)
+	owner, err = resolver.VerifyOwner(context.Background(), "alice.eth", alice)
+	require.NoError(t, err)
+	require.False(t, owner)
+}
+
+func TestResolveCache(t *testing.T) {
+	r := newTestRegistry(t)
+	resolver := NewResolver(r.backend, testNetworkID, r.address, time.Minute)
+	store := newMemoryStore()
+	resolver.SetStore(store)
+	node := r.register("alice.eth")
+	r.commit(r.resolver.SetAddr(r.auth, node, common.HexToAddress("0x01")))
+
+	resolution, err := resolver.Resolve(context.Background(), "alice.eth")
+	require.NoError(t, err)
+	require.Contains(t, store.resolutions, "alice.eth")
+
+	r.commit(r.resolver.SetAddr(r.auth, node, common.HexToAddress("0x02")))
+	cached, err := resolver.Resolve(context.Background(), "alice.eth")
+	require.NoError(t, err)
+	require.Equal(t, resolution.Address, cached.Address, "Resolutions are cached")
+
+	expired := store.resolutions["alice.eth"]
+	expired.ResolvedAt = time.Now().Add(-time.Hour).Unix()
+	store.resolutions["alice.eth"] = expired
+	resolution, err = resolver.Resolve(context.Background(), "alice.eth")
+	require.NoError(t, err)
+	require.Equal(t, common.HexToAddress("0x02"), *resolution.Address, "Expired resolutions are resolved again")
+}
+
+func TestReverseResolve(t *testing.T) {
+	r := newTestRegistry(t)
+	resolver := NewResolver(r.backend, testNetworkID, r.address, time.Minute)
+	address := r.auth.From
+
+	reverse, err := resolver.ReverseResolve(context.Background(), address)
+	require.NoError(t, err)
+	require.Equal(t, "", reverse.Name)
+
+	r.commit(r.resolver.SetName(r.auth, r.register(fmt.Sprintf("%x.addr.reverse", address.Bytes())), "alice.eth"))
+	node := r.register("alice.eth")
+	reverse, err = resolver.ReverseResolve(context.Background(), address)
+	require.NoError(t, err)
+	require.Equal(t, "", reverse.Name, "Names not resolving to the address are ignored")
+
+	r.commit(r.resolver.SetAddr(r.auth, node, address))
+	reverse, err = resolver.ReverseResolve(context.Background(), address)
+	require.NoError(t, err)
+	require.Equal(t, "alice.eth", reverse.Name)
+}
+
+func TestUnsupportedNetwork(t *testing.T) {
+	api := NewAPI(New(nil, 777))
+	_, err := api.Resolve(context.Background(), "alice.eth")
+	require.Equal(t, ErrUnsupportedNetwork, err)
+}
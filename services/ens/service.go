@@ -0,0 +1,87 @@
+package ens
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/contracts"
+	"github.com/status-im/status-go/rpc"
+)
+
+// Make sure that Service implements node.Service interface.
+var _ node.Service = (*Service)(nil)
+
+// ErrNoRPCClient is returned when resolving names while the node is not running.
+var ErrNoRPCClient = errors.New("no active RPC client: is the node running?")
+
+type rpcProvider interface {
+	RPCClient() *rpc.Client
+}
+
+// rpcCaller calls the upstream RPC of the node, which is only set once the node is started.
+type rpcCaller struct {
+	rpc rpcProvider
+}
+
+func (c rpcCaller) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	client := c.rpc.RPCClient()
+	if client == nil {
+		return ErrNoRPCClient
+	}
+	return client.CallContext(ctx, result, method, args...)
+}
+
+// Service resolves ENS names of the network of the node.
+type Service struct {
+	resolver *Resolver
+}
+
+// New returns a new Service resolving names with the RPC client of the node. Names can't be
+// resolved if ENS is not deployed on the network.
+func New(rpc rpcProvider, networkID uint64) *Service {
+	registry, ok := registries[networkID]
+	if !ok {
+		return &Service{}
+	}
+	return &Service{resolver: NewResolver(contracts.NewContractCaller(rpcCaller{rpc}), networkID, registry, DefaultCacheTTL)}
+}
+
+// SetStore sets the cache of the resolutions of the selected account, or nil if no account
+// is selected.
+func (s *Service) SetStore(store Store) {
+	if s.resolver != nil {
+		s.resolver.SetStore(store)
+	}
+}
+
+// Protocols returns a new protocols list. In this case, there are none.
+func (s *Service) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{}
+}
+
+// APIs returns a list of new APIs.
+func (s *Service) APIs() []gethrpc.API {
+	return []gethrpc.API{
+		{
+			Namespace: "ens",
+			Version:   "0.1.0",
+			Service:   NewAPI(s),
+			Public:    true,
+		},
+	}
+}
+
+// Start is run when a service is started.
+// It does nothing in this case but is required by `node.Service` interface.
+func (s *Service) Start(server *p2p.Server) error {
+	return nil
+}
+
+// Stop is run when a service is stopped.
+// It does nothing in this case but is required by `node.Service` interface.
+func (s *Service) Stop() error {
+	return nil
+}
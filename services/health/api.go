@@ -0,0 +1,24 @@
+package health
+
+import "context"
+
+// API exposes the health of the node over RPC.
+type API struct {
+	service *Service
+}
+
+// NewAPI returns a new API.
+func NewAPI(s *Service) *API {
+	return &API{service: s}
+}
+
+// Health returns the results of the last runs of the checks.
+func (api *API) Health(context context.Context) Report {
+	return api.service.monitor.Report()
+}
+
+// CheckHealth runs the checks right away and returns their results.
+func (api *API) CheckHealth(context context.Context) (Report, error) {
+	err := api.service.monitor.Run()
+	return api.service.monitor.Report(), err
+}
@@ -0,0 +1,130 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/status-im/status-go/services/shhext/mailservers"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// Names of the checks.
+const (
+	PeersCheckName       = "peers"
+	MailserversCheckName = "mailservers"
+	UpstreamCheckName    = "upstream"
+	DiskSpaceCheckName   = "disk"
+)
+
+// PeersCheck checks that the node is connected to at least min Whisper peers.
+func PeersCheck(server *p2p.Server, min int) Check {
+	return Check{
+		Name: PeersCheckName,
+		Run: func(context.Context) (string, error) {
+			count := 0
+			for _, peer := range server.Peers() {
+				for _, capability := range peer.Caps() {
+					if capability.Name == whisper.ProtocolName {
+						count++
+						break
+					}
+				}
+			}
+			details := fmt.Sprintf("%d Whisper peers", count)
+			if count < min {
+				return details, fmt.Errorf("connected to %d Whisper peers, %d are required", count, min)
+			}
+			return details, nil
+		},
+	}
+}
+
+// MailserversSource returns the mail servers of the node.
+type MailserversSource interface {
+	// ConnectedMailservers returns the mail servers the node is connected to.
+	ConnectedMailservers() []*enode.Node
+	// KnownMailservers returns the mail servers the node can connect to.
+	KnownMailservers() []*enode.Node
+}
+
+// MailserversCheck checks that the node is connected to a mail server or, if it isn't,
+// that one of the known mail servers can be reached. Nodes without mail servers are
+// not degraded.
+func MailserversCheck(source MailserversSource, prober mailservers.Prober) Check {
+	return Check{
+		Name: MailserversCheckName,
+		Run: func(ctx context.Context) (string, error) {
+			if connected := source.ConnectedMailservers(); len(connected) > 0 {
+				return fmt.Sprintf("connected to %d mail servers", len(connected)), nil
+			}
+			known := source.KnownMailservers()
+			if len(known) == 0 {
+				return "no mail servers", nil
+			}
+			var err error
+			for _, node := range known {
+				if ctx.Err() != nil {
+					break
+				}
+				rtt, probeErr := prober.Probe(node)
+				if probeErr == nil {
+					return fmt.Sprintf("not connected, %s reachable in %s", node.ID().TerminalString(), rtt), nil
+				}
+				err = probeErr
+			}
+			return "not connected", fmt.Errorf("none of the %d mail servers can be reached: %v", len(known), err)
+		},
+	}
+}
+
+// Caller calls RPC methods, like the RPC client of the node.
+type Caller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// UpstreamCheck checks that the upstream RPC server returns the latest block number.
+func UpstreamCheck(client Caller) Check {
+	return Check{
+		Name: UpstreamCheckName,
+		Run: func(ctx context.Context) (string, error) {
+			var number hexutil.Uint64
+			if err := client.CallContext(ctx, &number, "eth_blockNumber"); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("block %d", number), nil
+		},
+	}
+}
+
+// DefaultMinFreeDiskSpace is the default number of bytes of free disk space below which
+// the disk is degraded.
+const DefaultMinFreeDiskSpace = 100 << 20
+
+// ErrDiskSpaceUnsupported is returned when the free disk space can't be measured on
+// the platform.
+var ErrDiskSpaceUnsupported = errors.New("free disk space is not supported on this platform")
+
+// DiskSpaceCheck checks that the disk of dir has at least min bytes free.
+func DiskSpaceCheck(dir string, min uint64) Check {
+	return Check{
+		Name: DiskSpaceCheckName,
+		Run: func(context.Context) (string, error) {
+			free, err := freeDiskSpace(dir)
+			if err == ErrDiskSpaceUnsupported {
+				return err.Error(), nil
+			}
+			if err != nil {
+				return "", err
+			}
+			details := fmt.Sprintf("%d MB free", free>>20)
+			if free < min {
+				return details, fmt.Errorf("%d MB free, %d MB are required", free>>20, min>>20)
+			}
+			return details, nil
+		},
+	}
+}
@@ -0,0 +1,14 @@
+// +build !windows
+
+package health
+
+import "syscall"
+
+// freeDiskSpace returns the number of bytes available to the user on the disk of dir.
+func freeDiskSpace(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
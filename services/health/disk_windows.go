@@ -0,0 +1,6 @@
+package health
+
+// freeDiskSpace isn't supported on Windows.
+func freeDiskSpace(dir string) (uint64, error) {
+	return 0, ErrDiskSpaceUnsupported
+}
@@ -0,0 +1,210 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/status-im/status-go/db"
+	"github.com/status-im/status-go/signal"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// Statuses of the subsystems of the node.
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+)
+
+// DefaultCheckTimeout is the default time a check can take before the subsystem is
+// considered degraded.
+const DefaultCheckTimeout = 30 * time.Second
+
+var (
+	// ErrInvalidCheck is returned when adding a check without a name or a function.
+	ErrInvalidCheck = errors.New("check must have a name and a function")
+)
+
+// Check verifies a subsystem of the node.
+type Check struct {
+	Name string
+	// Run returns a short description of the state of the subsystem, or an error if it
+	// is degraded. It must return when ctx is done.
+	Run func(ctx context.Context) (string, error)
+}
+
+// Result is the result of the last run of a check.
+type Result struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Details string `json:"details,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// Time is the unix time of the check, in seconds.
+	Time int64 `json:"time"`
+	// Since is the unix time the subsystem is in its status since, in seconds.
+	Since int64 `json:"since"`
+	// Failures is the number of consecutive failed checks.
+	Failures int `json:"failures"`
+}
+
+// Report is the health of the node.
+type Report struct {
+	// Status is degraded if any subsystem is degraded.
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Monitor runs the checks of the subsystems of the node and sends a signal when a
+// subsystem is degraded or recovers. The results of the last runs are persisted, so
+// that transitions are detected across restarts.
+type Monitor struct {
+	db      *leveldb.DB
+	timeout time.Duration
+	now     func() time.Time
+	log     log.Logger
+
+	mu      sync.Mutex
+	checks  map[string]Check
+	results map[string]*Result
+
+	runMu sync.Mutex // serializes the runs
+}
+
+// NewMonitor returns a new Monitor, loading the results of the last runs persisted in db.
+func NewMonitor(level *leveldb.DB) (*Monitor, error) {
+	m := &Monitor{
+		db:      level,
+		timeout: DefaultCheckTimeout,
+		now:     time.Now,
+		log:     log.New("package", "status-go/services/health"),
+		checks:  make(map[string]Check),
+		results: make(map[string]*Result),
+	}
+	iter := level.NewIterator(util.BytesPrefix([]byte{byte(db.HealthChecks)}), nil)
+	defer iter.Release()
+	for iter.Next() {
+		result := new(Result)
+		if err := json.Unmarshal(iter.Value(), result); err != nil {
+			return nil, err
+		}
+		m.results[result.Name] = result
+	}
+	return m, iter.Error()
+}
+
+// Add adds a check, replacing the check with the same name.
+func (m *Monitor) Add(check Check) error {
+	if check.Name == "" || check.Run == nil {
+		return ErrInvalidCheck
+	}
+	m.mu.Lock()
+	m.checks[check.Name] = check
+	m.mu.Unlock()
+	return nil
+}
+
+// Run runs the checks concurrently and records their results. It returns an error
+// only if the results can't be persisted.
+func (m *Monitor) Run() error {
+	m.runMu.Lock()
+	defer m.runMu.Unlock()
+
+	m.mu.Lock()
+	checks := make([]Check, 0, len(m.checks))
+	for _, check := range m.checks {
+		checks = append(checks, check)
+	}
+	m.mu.Unlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = m.run(checks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	var err error
+	for _, result := range results {
+		if recordErr := m.record(result); recordErr != nil {
+			err = recordErr
+		}
+	}
+	return err
+}
+
+// run runs a check with the timeout of the monitor.
+func (m *Monitor) run(check Check) Result {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+	result := Result{Name: check.Name, Status: StatusOK, Time: m.now().Unix()}
+	details, err := check.Run(ctx)
+	result.Details = details
+	if err != nil {
+		result.Status = StatusDegraded
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// record saves the result of a check, and sends a signal if the status of the subsystem
+// changed. A subsystem whose first check fails is degraded.
+func (m *Monitor) record(result Result) error {
+	m.mu.Lock()
+	last, ok := m.results[result.Name]
+	result.Since = result.Time
+	if ok && last.Status == result.Status {
+		result.Since = last.Since
+	}
+	if result.Status == StatusDegraded {
+		result.Failures = 1
+		if ok {
+			result.Failures = last.Failures + 1
+		}
+	}
+	m.results[result.Name] = &result
+	m.mu.Unlock()
+
+	switch {
+	case result.Status == StatusDegraded && (!ok || last.Status != StatusDegraded):
+		m.log.Warn("subsystem is degraded", "name", result.Name, "err", result.Error)
+		signal.SendHealthDegraded(result)
+	case result.Status == StatusOK && ok && last.Status == StatusDegraded:
+		m.log.Info("subsystem recovered", "name", result.Name)
+		signal.SendHealthRecovered(result)
+	}
+
+	value, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return m.db.Put(db.Key(db.HealthChecks, []byte(result.Name)), value, nil)
+}
+
+// Report returns the results of the last runs of the checks, sorted by name. Results of
+// checks which are no longer added, like the checks of a previous config, are omitted.
+func (m *Monitor) Report() Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	report := Report{Status: StatusOK, Checks: make([]Result, 0, len(m.checks))}
+	for name := range m.checks {
+		result, ok := m.results[name]
+		if !ok {
+			continue
+		}
+		if result.Status == StatusDegraded {
+			report.Status = StatusDegraded
+		}
+		report.Checks = append(report.Checks, *result)
+	}
+	sort.Slice(report.Checks, func(i, j int) bool { return report.Checks[i].Name < report.Checks[j].Name })
+	return report
+}
@@ -0,0 +1,158 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/status-im/status-go/signal"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+func TestMonitor(t *testing.T) {
+	events := make(chan string, 10)
+	signal.SetDefaultNodeNotificationHandler(func(event string) {
+		if strings.Contains(event, `"health.`) {
+			events <- event
+		}
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	level, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+	defer level.Close()
+
+	m, err := NewMonitor(level)
+	require.NoError(t, err)
+	now := time.Unix(1546000000, 0)
+	m.now = func() time.Time { return now }
+	require.Equal(t, ErrInvalidCheck, m.Add(Check{Name: "invalid"}))
+
+	var upstreamErr error
+	require.NoError(t, m.Add(Check{Name: "disk", Run: func(context.Context) (string, error) { return "1024 MB free", nil }}))
+	require.NoError(t, m.Add(Check{Name: "upstream", Run: func(context.Context) (string, error) { return "", upstreamErr }}))
+
+	require.NoError(t, m.Run())
+	report := m.Report()
+	require.Equal(t, StatusOK, report.Status)
+	require.Equal(t, []Result{
+		{Name: "disk", Status: StatusOK, Details: "1024 MB free", Time: now.Unix(), Since: now.Unix()},
+		{Name: "upstream", Status: StatusOK, Time: now.Unix(), Since: now.Unix()},
+	}, report.Checks)
+	require.Len(t, events, 0)
+
+	upstreamErr = errors.New("connection refused")
+	for i := 0; i < 2; i++ {
+		now = now.Add(time.Minute)
+		require.NoError(t, m.Run())
+	}
+	report = m.Report()
+	require.Equal(t, StatusDegraded, report.Status)
+	require.Equal(t, Result{
+		Name:     "upstream",
+		Status:   StatusDegraded,
+		Error:    "connection refused",
+		Time:     now.Unix(),
+		Since:    now.Add(-time.Minute).Unix(),
+		Failures: 2,
+	}, report.Checks[1])
+	require.Equal(t, int64(1546000000), report.Checks[0].Since)
+	require.Len(t, events, 1, "The degradation is only signaled once")
+	require.Contains(t, <-events, `"type":"health.degraded"`)
+
+	// the results are persisted, so that a recovery after a restart is signaled
+	m, err = NewMonitor(level)
+	require.NoError(t, err)
+	m.now = func() time.Time { return now }
+	upstreamErr = nil
+	require.NoError(t, m.Add(Check{Name: "upstream", Run: func(context.Context) (string, error) { return "block 1", upstreamErr }}))
+	require.NoError(t, m.Run())
+	require.Contains(t, <-events, `"type":"health.recovered"`)
+	report = m.Report()
+	require.Equal(t, StatusOK, report.Status)
+	require.Len(t, report.Checks, 1, "Results of checks which are no longer added are omitted")
+}
+
+type testMailservers struct {
+	connected, known []*enode.Node
+}
+
+func (s testMailservers) ConnectedMailservers() []*enode.Node { return s.connected }
+func (s testMailservers) KnownMailservers() []*enode.Node     { return s.known }
+
+type testProber struct {
+	reachable map[enode.ID]bool
+}
+
+func (p testProber) Probe(node *enode.Node) (time.Duration, error) {
+	if p.reachable[node.ID()] {
+		return 40 * time.Millisecond, nil
+	}
+	return 0, errors.New("i/o timeout")
+}
+
+func TestMailserversCheck(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	node := enode.NewV4(&key.PublicKey, nil, 30303, 30303)
+	prober := testProber{reachable: map[enode.ID]bool{}}
+
+	details, err := MailserversCheck(testMailservers{}, prober).Run(context.Background())
+	require.NoError(t, err, "Nodes without mail servers are not degraded")
+	require.Equal(t, "no mail servers", details)
+
+	details, err = MailserversCheck(testMailservers{connected: []*enode.Node{node}, known: []*enode.Node{node}}, prober).Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "connected to 1 mail servers", details)
+
+	_, err = MailserversCheck(testMailservers{known: []*enode.Node{node}}, prober).Run(context.Background())
+	require.EqualError(t, err, "none of the 1 mail servers can be reached: i/o timeout")
+
+	prober.reachable[node.ID()] = true
+	details, err = MailserversCheck(testMailservers{known: []*enode.Node{node}}, prober).Run(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, details, "reachable in 40ms")
+}
+
+type testCaller struct {
+	err error
+}
+
+func (c testCaller) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if c.err != nil {
+		return c.err
+	}
+	*result.(*hexutil.Uint64) = 0x10
+	return nil
+}
+
+func TestUpstreamCheck(t *testing.T) {
+	details, err := UpstreamCheck(testCaller{}).Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "block 16", details)
+
+	_, err = UpstreamCheck(testCaller{err: errors.New("503 Service Unavailable")}).Run(context.Background())
+	require.EqualError(t, err, "503 Service Unavailable")
+}
+
+func TestDiskSpaceCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "health")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = DiskSpaceCheck(dir, 1).Run(context.Background())
+	require.NoError(t, err)
+	_, err = DiskSpaceCheck(dir, 1<<62).Run(context.Background())
+	require.Error(t, err)
+	_, err = DiskSpaceCheck(dir+"/missing", 1).Run(context.Background())
+	require.Error(t, err)
+}
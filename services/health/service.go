@@ -0,0 +1,106 @@
+package health
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/services/scheduler"
+)
+
+const (
+	// DefaultInterval is the default interval between two runs of the checks.
+	DefaultInterval = 5 * time.Minute
+
+	// MinPeers is the number of Whisper peers below which the peers are degraded.
+	MinPeers = 1
+
+	// startupDelay is the time after the start of the node during which the checks
+	// aren't run by the scheduler, because the node is still connecting to its peers.
+	startupDelay = time.Minute
+
+	// checksJob is the name of the job running the checks.
+	checksJob = "health/checks"
+)
+
+// Make sure that Service implements node.Service interface.
+var _ node.Service = (*Service)(nil)
+
+// Service runs the health checks of the node periodically and exposes their results.
+type Service struct {
+	monitor   *Monitor
+	interval  time.Duration
+	scheduler *scheduler.Scheduler
+	started   time.Time
+	now       func() time.Time
+}
+
+// New returns a new Service running the checks of monitor every interval. It adds the
+// check of the Whisper peers when it starts, with the p2p server.
+func New(monitor *Monitor, interval time.Duration) *Service {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Service{monitor: monitor, interval: interval, now: time.Now}
+}
+
+// SetScheduler sets the scheduler running the checks, which are added when the service starts.
+func (s *Service) SetScheduler(sched *scheduler.Scheduler) {
+	s.scheduler = sched
+}
+
+// Monitor returns the monitor to which the checks are added.
+func (s *Service) Monitor() *Monitor {
+	return s.monitor
+}
+
+// Protocols returns a new protocols list. In this case, there are none.
+func (s *Service) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{}
+}
+
+// APIs returns a list of new APIs. They extend the status namespace.
+func (s *Service) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "status",
+			Version:   "1.0",
+			Service:   NewAPI(s),
+			Public:    false,
+		},
+	}
+}
+
+// Start is run when a service is started.
+func (s *Service) Start(server *p2p.Server) error {
+	if err := s.monitor.Add(PeersCheck(server, MinPeers)); err != nil {
+		return err
+	}
+	if s.scheduler == nil {
+		return nil
+	}
+	s.started = s.now()
+	return s.scheduler.Add(scheduler.Job{
+		Name:     checksJob,
+		Interval: s.interval,
+		Jitter:   s.interval / 10,
+		Run:      s.runChecks,
+	})
+}
+
+// runChecks runs the checks, unless the node just started.
+func (s *Service) runChecks() error {
+	if s.now().Sub(s.started) < startupDelay {
+		return nil
+	}
+	return s.monitor.Run()
+}
+
+// Stop is run when a service is stopped.
+func (s *Service) Stop() error {
+	if s.scheduler != nil {
+		s.scheduler.Remove(checksJob)
+	}
+	return nil
+}
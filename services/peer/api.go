@@ -3,6 +3,8 @@ package peer
 import (
 	"context"
 	"errors"
+
+	"github.com/status-im/status-go/discovery"
 )
 
 var (
@@ -14,6 +16,12 @@ var (
 
 	// ErrDiscovererNotProvided error when discoverer is not being provided.
 	ErrDiscovererNotProvided = errors.New("discoverer not provided")
+
+	// ErrStatsDisabled error returned when the statistics of peers are not recorded.
+	ErrStatsDisabled = errors.New("peer stats are disabled")
+
+	// ErrSummarizerNotProvided error when discovery summarizer is not being provided.
+	ErrSummarizerNotProvided = errors.New("discovery summarizer not provided")
 )
 
 // PublicAPI represents a set of APIs from the `web3.peer` namespace.
@@ -46,3 +54,55 @@ func (api *PublicAPI) Discover(context context.Context, req DiscoverRequest) (er
 	}
 	return api.s.d.Discover(req.Topic, req.Max, req.Min)
 }
+
+// Stats is an implementation of `peer_stats` or `web3.peer.stats` API.
+// It returns the statistics of the peers seen during the window, by ID.
+func (api *PublicAPI) Stats(context context.Context) ([]PeerStats, error) {
+	if api.s.stats == nil {
+		return nil, ErrStatsDisabled
+	}
+	return api.s.stats.Stats(), nil
+}
+
+// DiscoveredPeer is a connected peer and the discovery mechanisms which found it.
+type DiscoveredPeer struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	RemoteAddress string `json:"remoteAddress"`
+	Inbound       bool   `json:"inbound"`
+	// DiscoveredBy are the names of the discovery mechanisms which found the peer.
+	// It is empty for the inbound peers and the peers dialed from the cache or
+	// configured statically, until discovery finds them.
+	DiscoveredBy []string `json:"discoveredBy"`
+}
+
+// DiscoverySummary is the result of `admin_discoverySummary`.
+type DiscoverySummary struct {
+	Peers      []DiscoveredPeer   `json:"peers"`
+	Mechanisms []discovery.Health `json:"mechanisms"`
+}
+
+// DiscoverySummarizer summarizes how the connected peers were discovered.
+type DiscoverySummarizer interface {
+	DiscoverySummary() (*DiscoverySummary, error)
+}
+
+// AdminAPI adds methods to the `admin` namespace of go-ethereum.
+type AdminAPI struct {
+	s *Service
+}
+
+// NewAdminAPI creates an instance of the admin API.
+func NewAdminAPI(s *Service) *AdminAPI {
+	return &AdminAPI{s: s}
+}
+
+// DiscoverySummary is an implementation of `admin_discoverySummary` API.
+// It returns the connected peers with the discovery mechanisms which found
+// them, and the health of the mechanisms.
+func (api *AdminAPI) DiscoverySummary(context context.Context) (*DiscoverySummary, error) {
+	if api.s.summarizer == nil {
+		return nil, ErrSummarizerNotProvided
+	}
+	return api.s.summarizer.DiscoverySummary()
+}
@@ -124,3 +124,24 @@ func (s *PeerSuite) TestDiscoverWihEmptyDiscoverer() {
 		Min:   1,
 	}))
 }
+
+type staticSummarizer struct {
+	summary *DiscoverySummary
+}
+
+func (s staticSummarizer) DiscoverySummary() (*DiscoverySummary, error) {
+	return s.summary, nil
+}
+
+func (s *PeerSuite) TestDiscoverySummary() {
+	var ctx context.Context
+	api := NewAdminAPI(s.s)
+	_, err := api.DiscoverySummary(ctx)
+	s.Equal(ErrSummarizerNotProvided, err)
+
+	summary := &DiscoverySummary{Peers: []DiscoveredPeer{{ID: "id", DiscoveredBy: []string{"ethv5"}}}}
+	s.s.SetDiscoverySummarizer(staticSummarizer{summary})
+	result, err := api.DiscoverySummary(ctx)
+	s.NoError(err)
+	s.Equal(summary, result)
+}
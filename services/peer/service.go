@@ -16,7 +16,9 @@ type Discoverer interface {
 
 // Service it manages all endpoints for peer operations.
 type Service struct {
-	d Discoverer
+	d          Discoverer
+	summarizer DiscoverySummarizer
+	stats      *StatsRecorder
 }
 
 // New returns a new Service.
@@ -38,6 +40,12 @@ func (s *Service) APIs() []rpc.API {
 			Service:   NewAPI(s),
 			Public:    false,
 		},
+		{
+			Namespace: "admin",
+			Version:   "1.0",
+			Service:   NewAdminAPI(s),
+			Public:    false,
+		},
 	}
 }
 
@@ -46,14 +54,30 @@ func (s *Service) SetDiscoverer(d Discoverer) {
 	s.d = d
 }
 
+// SetDiscoverySummarizer sets the summarizer of the discovery of the peers for the API calls.
+func (s *Service) SetDiscoverySummarizer(summarizer DiscoverySummarizer) {
+	s.summarizer = summarizer
+}
+
+// SetStatsRecorder sets the recorder of the statistics of the peers, started with the service.
+func (s *Service) SetStatsRecorder(r *StatsRecorder) {
+	s.stats = r
+}
+
 // Start is run when a service is started.
-// It does nothing in this case but is required by `node.Service` interface.
+// It starts recording the statistics of the peers, if enabled.
 func (s *Service) Start(server *p2p.Server) error {
+	if s.stats != nil {
+		s.stats.Start(server)
+	}
 	return nil
 }
 
 // Stop is run when a service is stopped.
-// It does nothing in this case but is required by `node.Service` interface.
+// It persists the statistics of the peers, if enabled.
 func (s *Service) Stop() error {
+	if s.stats != nil {
+		return s.stats.Stop()
+	}
 	return nil
 }
@@ -0,0 +1,361 @@
+package peer
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/status-im/status-go/db"
+	"github.com/status-im/status-go/services/report"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	// DefaultStatsWindow is the period the statistics of peers are kept for.
+	DefaultStatsWindow = 24 * time.Hour
+	// statsBucket is the period aggregated in a single record of statistics.
+	statsBucket = time.Hour
+	// statsFlushInterval is the interval at which changed statistics are persisted.
+	statsFlushInterval = time.Minute
+)
+
+// reporter reports the errors of the statistics of peers to the client.
+var reporter = report.New("peer")
+
+var (
+	connectionsCounter = metrics.NewRegisteredCounter("peerstats/connections", nil)
+	errorsCounter      = metrics.NewRegisteredCounter("peerstats/errors", nil)
+)
+
+// ProtocolStats counts the messages exchanged with a peer over a protocol.
+type ProtocolStats struct {
+	// Version is the version of the protocol negotiated in the last handshake.
+	Version          uint   `json:"version"`
+	MessagesReceived uint64 `json:"messagesReceived"`
+	MessagesSent     uint64 `json:"messagesSent"`
+	BytesReceived    uint64 `json:"bytesReceived"`
+	BytesSent        uint64 `json:"bytesSent"`
+}
+
+// PeerStats are the statistics of a peer.
+type PeerStats struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Connections int    `json:"connections"`
+	// Errors counts the disconnections caused by an error, as opposed to
+	// disconnections requested by either side or caused by a shutdown.
+	Errors int `json:"errors"`
+	// ErrorRate is the number of errors per connection.
+	ErrorRate float64 `json:"errorRate"`
+	LastError string  `json:"lastError,omitempty"`
+	// LastSeen is the unix time of the last event of the peer, in seconds.
+	LastSeen  int64                     `json:"lastSeen"`
+	Protocols map[string]*ProtocolStats `json:"protocols"`
+}
+
+func newPeerStats(id enode.ID) *PeerStats {
+	return &PeerStats{ID: id.String(), Protocols: make(map[string]*ProtocolStats)}
+}
+
+func (s *PeerStats) protocol(name string) *ProtocolStats {
+	p, ok := s.Protocols[name]
+	if !ok {
+		p = &ProtocolStats{}
+		s.Protocols[name] = p
+	}
+	return p
+}
+
+// merge adds the statistics of a later period.
+func (s *PeerStats) merge(later *PeerStats) {
+	if later.Name != "" {
+		s.Name = later.Name
+	}
+	if later.LastError != "" {
+		s.LastError = later.LastError
+	}
+	if later.LastSeen > s.LastSeen {
+		s.LastSeen = later.LastSeen
+	}
+	s.Connections += later.Connections
+	s.Errors += later.Errors
+	for name, l := range later.Protocols {
+		p := s.protocol(name)
+		if l.Version != 0 {
+			p.Version = l.Version
+		}
+		p.MessagesReceived += l.MessagesReceived
+		p.MessagesSent += l.MessagesSent
+		p.BytesReceived += l.BytesReceived
+		p.BytesSent += l.BytesSent
+	}
+}
+
+type statsKey struct {
+	id    enode.ID
+	start int64
+}
+
+// StatsRecorder records the messages exchanged with each peer, the errors and the versions
+// negotiated in handshakes, in hourly buckets persisted for a rolling window. It relies on
+// the message events of the p2p server, which must be enabled.
+type StatsRecorder struct {
+	db     *leveldb.DB
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	buckets map[statsKey]*PeerStats
+	dirty   map[statsKey]bool
+	// protocols are the capabilities of the server, used to find the negotiated versions.
+	protocols []p2p.Cap
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewStatsRecorder returns a new StatsRecorder, loading the statistics persisted in db
+// during window. Zero means DefaultStatsWindow.
+func NewStatsRecorder(level *leveldb.DB, window time.Duration) (*StatsRecorder, error) {
+	if window <= 0 {
+		window = DefaultStatsWindow
+	}
+	r := &StatsRecorder{
+		db:      level,
+		window:  window,
+		now:     time.Now,
+		buckets: make(map[statsKey]*PeerStats),
+		dirty:   make(map[statsKey]bool),
+	}
+	iter := level.NewIterator(util.BytesPrefix([]byte{byte(db.PeerStats)}), nil)
+	defer iter.Release()
+	for iter.Next() {
+		key, ok := parseStatsKey(iter.Key())
+		if !ok {
+			continue
+		}
+		stats := new(PeerStats)
+		if err := json.Unmarshal(iter.Value(), stats); err != nil {
+			return nil, err
+		}
+		r.buckets[key] = stats
+	}
+	return r, iter.Error()
+}
+
+// Start records the events of the peers of server until Stop is called.
+func (r *StatsRecorder) Start(server *p2p.Server) {
+	r.mu.Lock()
+	r.protocols = nil
+	for _, p := range server.Protocols {
+		r.protocols = append(r.protocols, p2p.Cap{Name: p.Name, Version: p.Version})
+	}
+	r.mu.Unlock()
+
+	r.quit = make(chan struct{})
+	events := make(chan *p2p.PeerEvent, 100)
+	sub := server.SubscribeEvents(events)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer sub.Unsubscribe()
+		ticker := time.NewTicker(statsFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case event := <-events:
+				var peer *p2p.Peer
+				if event.Type == p2p.PeerEventTypeAdd {
+					peer = findPeer(server, event.Peer)
+				}
+				r.handleEvent(event, peer)
+			case <-ticker.C:
+				if err := r.flush(); err != nil {
+					reporter.Error("save-peer-stats", report.WithCode(err, report.CodeStorage))
+				}
+			case err := <-sub.Err():
+				if err != nil {
+					log.Error("peer stats subscription failed", "err", err)
+				}
+				return
+			case <-r.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops recording events and persists the statistics.
+func (r *StatsRecorder) Stop() error {
+	if r.quit != nil {
+		close(r.quit)
+		r.wg.Wait()
+		r.quit = nil
+	}
+	return r.flush()
+}
+
+// Stats returns the statistics of the peers seen during the window, by ID.
+func (r *StatsRecorder) Stats() []PeerStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	oldest := r.bucketStart(r.now().Add(-r.window))
+	keys := make([]statsKey, 0, len(r.buckets))
+	for key := range r.buckets {
+		if key.start >= oldest {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].start < keys[j].start })
+
+	peers := make(map[enode.ID]*PeerStats)
+	for _, key := range keys {
+		stats, ok := peers[key.id]
+		if !ok {
+			stats = newPeerStats(key.id)
+			peers[key.id] = stats
+		}
+		stats.merge(r.buckets[key])
+	}
+	result := make([]PeerStats, 0, len(peers))
+	for _, stats := range peers {
+		if stats.Connections > 0 {
+			stats.ErrorRate = float64(stats.Errors) / float64(stats.Connections)
+		}
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// handleEvent records an event of a peer. The peer is only needed for additions,
+// to record its name and the negotiated versions.
+func (r *StatsRecorder) handleEvent(event *p2p.PeerEvent, peer *p2p.Peer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	key := statsKey{id: event.Peer, start: r.bucketStart(now)}
+	stats, ok := r.buckets[key]
+	if !ok {
+		stats = newPeerStats(event.Peer)
+		r.buckets[key] = stats
+	}
+	r.dirty[key] = true
+	stats.LastSeen = now.Unix()
+
+	switch event.Type {
+	case p2p.PeerEventTypeAdd:
+		stats.Connections++
+		connectionsCounter.Inc(1)
+		if peer == nil {
+			return
+		}
+		stats.Name = peer.Name()
+		for name, version := range negotiatedVersions(r.protocols, peer.Caps()) {
+			stats.protocol(name).Version = version
+			metrics.GetOrRegisterCounter(fmt.Sprintf("peerstats/%s/handshakes/v%d", name, version), nil).Inc(1)
+		}
+	case p2p.PeerEventTypeDrop:
+		if event.Error == p2p.DiscRequested.Error() || event.Error == p2p.DiscQuitting.Error() {
+			return
+		}
+		stats.Errors++
+		stats.LastError = event.Error
+		errorsCounter.Inc(1)
+	case p2p.PeerEventTypeMsgRecv, p2p.PeerEventTypeMsgSend:
+		p := stats.protocol(event.Protocol)
+		var size uint64
+		if event.MsgSize != nil {
+			size = uint64(*event.MsgSize)
+		}
+		direction := "received"
+		if event.Type == p2p.PeerEventTypeMsgRecv {
+			p.MessagesReceived++
+			p.BytesReceived += size
+		} else {
+			direction = "sent"
+			p.MessagesSent++
+			p.BytesSent += size
+		}
+		metrics.GetOrRegisterCounter(fmt.Sprintf("peerstats/%s/messages/%s", event.Protocol, direction), nil).Inc(1)
+		metrics.GetOrRegisterCounter(fmt.Sprintf("peerstats/%s/bytes/%s", event.Protocol, direction), nil).Inc(int64(size))
+	}
+}
+
+// flush persists the changed buckets and deletes the buckets older than the window.
+func (r *StatsRecorder) flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	oldest := r.bucketStart(r.now().Add(-r.window))
+	batch := new(leveldb.Batch)
+	for key, stats := range r.buckets {
+		if key.start < oldest {
+			batch.Delete(key.bytes())
+			delete(r.buckets, key)
+			continue
+		}
+		if !r.dirty[key] {
+			continue
+		}
+		value, err := json.Marshal(stats)
+		if err != nil {
+			return err
+		}
+		batch.Put(key.bytes(), value)
+	}
+	if err := r.db.Write(batch, nil); err != nil {
+		return err
+	}
+	r.dirty = make(map[statsKey]bool)
+	return nil
+}
+
+func (r *StatsRecorder) bucketStart(t time.Time) int64 {
+	return t.Truncate(statsBucket).Unix()
+}
+
+func (k statsKey) bytes() []byte {
+	start := make([]byte, 8)
+	binary.BigEndian.PutUint64(start, uint64(k.start))
+	return db.Key(db.PeerStats, k.id[:], start)
+}
+
+func parseStatsKey(key []byte) (statsKey, bool) {
+	var k statsKey
+	if len(key) != 1+len(k.id)+8 {
+		return k, false
+	}
+	copy(k.id[:], key[1:])
+	k.start = int64(binary.BigEndian.Uint64(key[1+len(k.id):]))
+	return k, true
+}
+
+// negotiatedVersions returns the highest version of each protocol supported by both sides.
+func negotiatedVersions(ours []p2p.Cap, theirs []p2p.Cap) map[string]uint {
+	versions := make(map[string]uint)
+	for _, our := range ours {
+		for _, their := range theirs {
+			if our.Name == their.Name && our.Version == their.Version && our.Version > versions[our.Name] {
+				versions[our.Name] = our.Version
+			}
+		}
+	}
+	return versions
+}
+
+func findPeer(server *p2p.Server, id enode.ID) *p2p.Peer {
+	for _, p := range server.Peers() {
+		if p.ID() == id {
+			return p
+		}
+	}
+	return nil
+}
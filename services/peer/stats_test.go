@@ -0,0 +1,71 @@
+package peer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+func TestStatsRecorder(t *testing.T) {
+	level, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+	defer level.Close()
+
+	now := time.Unix(1546000000, 0)
+	r, err := NewStatsRecorder(level, 2*time.Hour)
+	require.NoError(t, err)
+	r.now = func() time.Time { return now }
+	r.protocols = []p2p.Cap{{Name: "shh", Version: 6}, {Name: "les", Version: 1}, {Name: "les", Version: 2}}
+
+	id := enode.ID{0x01}
+	size := uint32(100)
+	peer := p2p.NewPeer(id, "statusd", []p2p.Cap{{Name: "shh", Version: 6}, {Name: "les", Version: 2}, {Name: "les", Version: 3}})
+	r.handleEvent(&p2p.PeerEvent{Type: p2p.PeerEventTypeAdd, Peer: id}, peer)
+	r.handleEvent(&p2p.PeerEvent{Type: p2p.PeerEventTypeMsgRecv, Peer: id, Protocol: "shh", MsgSize: &size}, nil)
+	r.handleEvent(&p2p.PeerEvent{Type: p2p.PeerEventTypeMsgSend, Peer: id, Protocol: "shh", MsgSize: &size}, nil)
+	r.handleEvent(&p2p.PeerEvent{Type: p2p.PeerEventTypeDrop, Peer: id, Error: "read tcp: i/o timeout"}, nil)
+
+	now = now.Add(time.Hour)
+	r.handleEvent(&p2p.PeerEvent{Type: p2p.PeerEventTypeAdd, Peer: id}, nil)
+	r.handleEvent(&p2p.PeerEvent{Type: p2p.PeerEventTypeMsgRecv, Peer: id, Protocol: "les", MsgSize: &size}, nil)
+	r.handleEvent(&p2p.PeerEvent{Type: p2p.PeerEventTypeDrop, Peer: id, Error: p2p.DiscRequested.Error()}, nil)
+
+	expected := PeerStats{
+		ID:          id.String(),
+		Name:        "statusd",
+		Connections: 2,
+		Errors:      1,
+		ErrorRate:   0.5,
+		LastError:   "read tcp: i/o timeout",
+		LastSeen:    now.Unix(),
+		Protocols: map[string]*ProtocolStats{
+			"shh": {Version: 6, MessagesReceived: 1, MessagesSent: 1, BytesReceived: 100, BytesSent: 100},
+			"les": {Version: 2, MessagesReceived: 1, BytesReceived: 100},
+		},
+	}
+	require.Equal(t, []PeerStats{expected}, r.Stats())
+
+	require.NoError(t, r.Stop())
+	loaded, err := NewStatsRecorder(level, 2*time.Hour)
+	require.NoError(t, err)
+	loaded.now = r.now
+	require.Equal(t, []PeerStats{expected}, loaded.Stats(), "Stats are persisted")
+
+	now = now.Add(2 * time.Hour)
+	stats := loaded.Stats()
+	require.Len(t, stats, 1)
+	require.Equal(t, 1, stats[0].Connections, "Buckets older than the window are ignored")
+	require.Equal(t, 0, stats[0].Errors)
+
+	now = now.Add(time.Hour)
+	require.NoError(t, loaded.Stop())
+	require.Empty(t, loaded.Stats())
+	loaded, err = NewStatsRecorder(level, 2*time.Hour)
+	require.NoError(t, err)
+	require.Empty(t, loaded.buckets, "Expired buckets are deleted")
+}
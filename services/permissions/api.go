@@ -0,0 +1,35 @@
+package permissions
+
+import (
+	"context"
+)
+
+// API exposes the permissions granted to dapps over RPC.
+type API struct {
+	s *Service
+}
+
+// NewAPI returns a new API.
+func NewAPI(s *Service) *API {
+	return &API{s: s}
+}
+
+// Grants returns the permissions granted to dapps, by origin.
+func (api *API) Grants(ctx context.Context) ([]Grant, error) {
+	return api.s.Grants()
+}
+
+// Grant grants permissions to a dapp, usually after the user accepted a prompt.
+func (api *API) Grant(ctx context.Context, origin string, permissions []Permission) (Grant, error) {
+	return api.s.Grant(origin, permissions...)
+}
+
+// Deny is called when the user rejects a prompt, so that the dapp can prompt again later.
+func (api *API) Deny(ctx context.Context, origin string, permissions []Permission) error {
+	return api.s.Deny(origin, permissions...)
+}
+
+// Revoke revokes permissions of a dapp, or all of them if permissions is empty.
+func (api *API) Revoke(ctx context.Context, origin string, permissions []Permission) error {
+	return api.s.Revoke(origin, permissions...)
+}
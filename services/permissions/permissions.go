@@ -0,0 +1,265 @@
+package permissions
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Permission is what a dapp is allowed to do with the web3 provider.
+type Permission string
+
+const (
+	// Accounts exposes the addresses of the account to the dapp, with eth_accounts.
+	Accounts Permission = "accounts"
+	// Transactions allows the dapp to request transactions, still confirmed by the user.
+	Transactions Permission = "transactions"
+	// TypedData allows the dapp to request signatures of typed data, still confirmed by the user.
+	TypedData Permission = "typed-data"
+
+	// keyPrefix prefixes the settings keys of the grants, followed by the origin.
+	keyPrefix = "permissions."
+)
+
+var (
+	// ErrPermissionRequired is returned when a dapp uses a permission the user didn't grant.
+	// The user is prompted, and the dapp can retry once the permission is granted.
+	ErrPermissionRequired = errors.New("permission required")
+	// ErrInvalidOrigin is returned for origins that are not absolute URLs.
+	ErrInvalidOrigin = errors.New("invalid dapp origin")
+	// ErrUnknownPermission is returned when granting permissions that don't exist.
+	ErrUnknownPermission = errors.New("unknown permission")
+	// ErrNoAccountSelected is returned when using permissions without a selected account.
+	ErrNoAccountSelected = errors.New("no account selected")
+)
+
+var known = map[Permission]struct{}{
+	Accounts:     {},
+	Transactions: {},
+	TypedData:    {},
+}
+
+// Grant holds the permissions granted to a dapp.
+type Grant struct {
+	Origin      string       `json:"origin"`
+	Permissions []Permission `json:"permissions"`
+	// Updated is the time the permissions last changed, in seconds.
+	Updated int64 `json:"updated"`
+}
+
+// Has returns true if the permission is granted.
+func (g Grant) Has(p Permission) bool {
+	for _, granted := range g.Permissions {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Store persists the grants of the selected account, with its settings.
+type Store interface {
+	SaveSetting(key string, value []byte) error
+	// GetSetting returns the value of a setting, or nil if it's not set.
+	GetSetting(key string) ([]byte, error)
+	DeleteSetting(key string) error
+	// GetSettings returns all the settings, by key.
+	GetSettings() (map[string][]byte, error)
+}
+
+// NormalizeOrigin returns the scheme and the host of the URL of a dapp, which its
+// permissions are granted to.
+func NormalizeOrigin(origin string) (string, error) {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", ErrInvalidOrigin
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host), nil
+}
+
+type pendingPrompt struct {
+	origin     string
+	permission Permission
+}
+
+// Manager grants permissions to dapps, by origin. Dapps are not allowed anything until the
+// user grants them a permission: using it sends a prompt to the client once, until the
+// permission is granted or denied.
+type Manager struct {
+	prompt func(origin string, p Permission)
+	now    func() time.Time
+
+	mu      sync.Mutex
+	store   Store
+	pending map[pendingPrompt]struct{}
+}
+
+// NewManager returns a new Manager prompting the user with prompt.
+func NewManager(prompt func(origin string, p Permission)) *Manager {
+	return &Manager{
+		prompt:  prompt,
+		now:     time.Now,
+		pending: make(map[pendingPrompt]struct{}),
+	}
+}
+
+// SetStore sets the store of the grants of the selected account, or nil if no account is
+// selected. Pending prompts are dropped.
+func (m *Manager) SetStore(store Store) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store = store
+	m.pending = make(map[pendingPrompt]struct{})
+}
+
+// Check returns nil if a permission is granted to a dapp. Otherwise, it prompts the user
+// to grant it and returns ErrPermissionRequired.
+func (m *Manager) Check(origin string, p Permission) error {
+	origin, err := NormalizeOrigin(origin)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	grant, err := m.get(origin)
+	if err != nil {
+		return err
+	}
+	if grant.Has(p) {
+		return nil
+	}
+	key := pendingPrompt{origin: origin, permission: p}
+	if _, ok := m.pending[key]; !ok {
+		m.pending[key] = struct{}{}
+		m.prompt(origin, p)
+	}
+	return ErrPermissionRequired
+}
+
+// Grant grants permissions to a dapp, in addition to those already granted.
+func (m *Manager) Grant(origin string, permissions ...Permission) (Grant, error) {
+	origin, err := NormalizeOrigin(origin)
+	if err != nil {
+		return Grant{}, err
+	}
+	for _, p := range permissions {
+		if _, ok := known[p]; !ok {
+			return Grant{}, ErrUnknownPermission
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	grant, err := m.get(origin)
+	if err != nil {
+		return Grant{}, err
+	}
+	for _, p := range permissions {
+		delete(m.pending, pendingPrompt{origin: origin, permission: p})
+		if !grant.Has(p) {
+			grant.Permissions = append(grant.Permissions, p)
+		}
+	}
+	if err := m.save(&grant); err != nil {
+		return Grant{}, err
+	}
+	return grant, nil
+}
+
+// Deny drops the prompts of permissions of a dapp, so that the user is prompted again
+// the next time the dapp uses them.
+func (m *Manager) Deny(origin string, permissions ...Permission) error {
+	origin, err := NormalizeOrigin(origin)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range permissions {
+		delete(m.pending, pendingPrompt{origin: origin, permission: p})
+	}
+	return nil
+}
+
+// Revoke revokes permissions of a dapp, or all of them if permissions is empty.
+func (m *Manager) Revoke(origin string, permissions ...Permission) error {
+	origin, err := NormalizeOrigin(origin)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	grant, err := m.get(origin)
+	if err != nil {
+		return err
+	}
+	if len(permissions) == 0 {
+		return m.store.DeleteSetting(keyPrefix + origin)
+	}
+	revoked := make(map[Permission]struct{}, len(permissions))
+	for _, p := range permissions {
+		revoked[p] = struct{}{}
+	}
+	kept := grant.Permissions[:0]
+	for _, p := range grant.Permissions {
+		if _, ok := revoked[p]; !ok {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == 0 {
+		return m.store.DeleteSetting(keyPrefix + origin)
+	}
+	grant.Permissions = kept
+	return m.save(&grant)
+}
+
+// Grants returns the permissions granted to dapps, by origin.
+func (m *Manager) Grants() ([]Grant, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.store == nil {
+		return nil, ErrNoAccountSelected
+	}
+	settings, err := m.store.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+	grants := []Grant{}
+	for key, value := range settings {
+		if !strings.HasPrefix(key, keyPrefix) {
+			continue
+		}
+		var grant Grant
+		if err := json.Unmarshal(value, &grant); err != nil {
+			return nil, err
+		}
+		grants = append(grants, grant)
+	}
+	sort.Slice(grants, func(i, j int) bool { return grants[i].Origin < grants[j].Origin })
+	return grants, nil
+}
+
+// get returns the grant of an origin, with no permissions if none were granted.
+func (m *Manager) get(origin string) (Grant, error) {
+	if m.store == nil {
+		return Grant{}, ErrNoAccountSelected
+	}
+	grant := Grant{Origin: origin}
+	value, err := m.store.GetSetting(keyPrefix + origin)
+	if err != nil || value == nil {
+		return grant, err
+	}
+	return grant, json.Unmarshal(value, &grant)
+}
+
+func (m *Manager) save(grant *Grant) error {
+	grant.Updated = m.now().Unix()
+	value, err := json.Marshal(grant)
+	if err != nil {
+		return err
+	}
+	return m.store.SaveSetting(keyPrefix+grant.Origin, value)
+}
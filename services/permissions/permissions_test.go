@@ -0,0 +1,105 @@
+package permissions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore map[string][]byte
+
+func (s memoryStore) SaveSetting(key string, value []byte) error {
+	s[key] = value
+	return nil
+}
+
+func (s memoryStore) GetSetting(key string) ([]byte, error) {
+	return s[key], nil
+}
+
+func (s memoryStore) DeleteSetting(key string) error {
+	delete(s, key)
+	return nil
+}
+
+func (s memoryStore) GetSettings() (map[string][]byte, error) {
+	return s, nil
+}
+
+type promptRecorder []string
+
+func (r *promptRecorder) prompt(origin string, p Permission) {
+	*r = append(*r, origin+" "+string(p))
+}
+
+func newTestManager(prompts *promptRecorder) *Manager {
+	m := NewManager(prompts.prompt)
+	m.now = func() time.Time { return time.Unix(1000, 0) }
+	return m
+}
+
+func TestNormalizeOrigin(t *testing.T) {
+	origin, err := NormalizeOrigin("HTTPS://App.Example.com/swap?token=0x1")
+	require.NoError(t, err)
+	require.Equal(t, "https://app.example.com", origin)
+
+	for _, invalid := range []string{"", "app.example.com", "/swap", "://"} {
+		_, err := NormalizeOrigin(invalid)
+		require.Equal(t, ErrInvalidOrigin, err, invalid)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var prompts promptRecorder
+	m := newTestManager(&prompts)
+	require.Equal(t, ErrNoAccountSelected, m.Check("https://dapp.eth", Accounts))
+
+	m.SetStore(memoryStore{})
+	require.Equal(t, ErrPermissionRequired, m.Check("https://dapp.eth", Accounts))
+	require.Equal(t, ErrPermissionRequired, m.Check("https://dapp.eth/page", Accounts))
+	require.Equal(t, promptRecorder{"https://dapp.eth accounts"}, prompts, "The user is prompted once")
+
+	require.NoError(t, m.Deny("https://dapp.eth", Accounts))
+	require.Equal(t, ErrPermissionRequired, m.Check("https://dapp.eth", Accounts))
+	require.Len(t, prompts, 2, "The user is prompted again once denied")
+
+	grant, err := m.Grant("https://dapp.eth", Accounts)
+	require.NoError(t, err)
+	require.Equal(t, Grant{Origin: "https://dapp.eth", Permissions: []Permission{Accounts}, Updated: 1000}, grant)
+	require.NoError(t, m.Check("https://dapp.eth", Accounts))
+	require.Equal(t, ErrPermissionRequired, m.Check("https://dapp.eth", Transactions), "Permissions are granted separately")
+	require.Equal(t, ErrPermissionRequired, m.Check("http://dapp.eth", Accounts), "Permissions are granted by origin")
+}
+
+func TestGrantAndRevoke(t *testing.T) {
+	var prompts promptRecorder
+	m := newTestManager(&prompts)
+	store := memoryStore{"mailserver": []byte(`"enode://1"`)}
+	m.SetStore(store)
+
+	_, err := m.Grant("https://dapp.eth", "everything")
+	require.Equal(t, ErrUnknownPermission, err)
+	_, err = m.Grant("https://dapp.eth", Accounts, TypedData)
+	require.NoError(t, err)
+	_, err = m.Grant("https://dapp.eth", Accounts, Transactions)
+	require.NoError(t, err)
+	_, err = m.Grant("https://other.eth", Accounts)
+	require.NoError(t, err)
+
+	grants, err := m.Grants()
+	require.NoError(t, err)
+	require.Equal(t, []Grant{
+		{Origin: "https://dapp.eth", Permissions: []Permission{Accounts, TypedData, Transactions}, Updated: 1000},
+		{Origin: "https://other.eth", Permissions: []Permission{Accounts}, Updated: 1000},
+	}, grants, "Other settings are not grants")
+
+	require.NoError(t, m.Revoke("https://dapp.eth", Accounts, TypedData))
+	require.NoError(t, m.Revoke("https://other.eth"))
+	grants, err = m.Grants()
+	require.NoError(t, err)
+	require.Equal(t, []Grant{{Origin: "https://dapp.eth", Permissions: []Permission{Transactions}, Updated: 1000}}, grants)
+
+	require.NoError(t, m.Revoke("https://dapp.eth", Transactions))
+	require.Equal(t, memoryStore{"mailserver": []byte(`"enode://1"`)}, store, "Grants without permissions are deleted")
+}
@@ -3,11 +3,13 @@ package personal
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/status-im/status-go/account"
 	"github.com/status-im/status-go/params"
 	"github.com/status-im/status-go/rpc"
@@ -17,6 +19,10 @@ var (
 	// ErrInvalidPersonalSignAccount is returned when the account passed to
 	// personal_sign isn't equal to the currently selected account.
 	ErrInvalidPersonalSignAccount = errors.New("invalid account as only the selected one can generate a signature")
+
+	// ErrInvalidPersonalSignData is returned when the data passed to personal_sign
+	// for an account kept by the platform keystore isn't a string.
+	ErrInvalidPersonalSignData = errors.New("invalid data to sign")
 )
 
 // SignParams required to sign messages
@@ -70,6 +76,9 @@ func (api *PublicAPI) Sign(rpcParams SignParams, verifiedAccount *account.Select
 		err = ErrInvalidPersonalSignAccount
 		return
 	}
+	if verifiedAccount.External != nil {
+		return signExternal(rpcParams.Data, verifiedAccount)
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), api.rpcTimeout)
 	defer cancel()
@@ -81,3 +90,23 @@ func (api *PublicAPI) Sign(rpcParams SignParams, verifiedAccount *account.Select
 
 	return
 }
+
+// signExternal signs data like personal_sign, with an account whose key is kept by the
+// platform keystore. Data is a hex-encoded or a plain string.
+func signExternal(data interface{}, verifiedAccount *account.SelectedExtKey) (hexutil.Bytes, error) {
+	str, ok := data.(string)
+	if !ok {
+		return nil, ErrInvalidPersonalSignData
+	}
+	message, err := hexutil.Decode(str)
+	if err != nil {
+		message = []byte(str)
+	}
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))
+	sig, err := verifiedAccount.SignHash(crypto.Keccak256([]byte(prefix), message))
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
@@ -0,0 +1,206 @@
+// Package report is the shared reporter of the errors of the services that the client
+// should know about, like failures of their background work. Errors are logged, and
+// their structured reports are sent with the node.error signal:
+//
+//	{"type":"node.error","event":{"report":{"module":"shhext","operation":"download-attachment",
+//	  "code":"network","correlationId":"9f1c2a3b4d5e6f70","message":"Could not reach the network",
+//	  "time":1547036400000}}}
+package report
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/status-im/status-go/signal"
+)
+
+// MinInterval is the minimum interval between two reports of the same operation and
+// code, so that failures repeated in a loop don't flood the clients. Reports sent in
+// between are only logged, and counted in the next report.
+const MinInterval = time.Minute
+
+// Code is the type of an error, so that clients can act on it.
+type Code string
+
+const (
+	// CodeInternal is the code of unexpected errors.
+	CodeInternal Code = "internal"
+	// CodeStorage is the code of errors reading or writing the local databases.
+	CodeStorage Code = "storage"
+	// CodeNetwork is the code of errors reaching peers, servers or the blockchain.
+	CodeNetwork Code = "network"
+	// CodeTimeout is the code of operations that took too long.
+	CodeTimeout Code = "timeout"
+	// CodeInvalid is the code of invalid data received.
+	CodeInvalid Code = "invalid"
+)
+
+// messages are the user-safe messages of the codes.
+var messages = map[Code]string{
+	CodeInternal: "Something went wrong",
+	CodeStorage:  "Could not access the local data",
+	CodeNetwork:  "Could not reach the network",
+	CodeTimeout:  "The operation took too long",
+	CodeInvalid:  "Received invalid data",
+}
+
+// Report is the structured report of an error. It never contains the error itself,
+// which may contain keys, addresses or paths: the error is logged with the correlation
+// ID of its report.
+type Report struct {
+	// Module is the service that failed, like "shhext" or "wallet".
+	Module string `json:"module"`
+	// Operation is what the module failed to do, like "post-outbox-entry".
+	Operation string `json:"operation"`
+	Code      Code   `json:"code"`
+	// CorrelationID relates the reports of the same context, and the logs of their errors.
+	CorrelationID string `json:"correlationId"`
+	// Message is safe to show to the user.
+	Message string `json:"message"`
+	// Repeated is the number of errors of the same operation and code only logged since
+	// the previous report.
+	Repeated int `json:"repeated,omitempty"`
+	// Time is the time of the error, in milliseconds.
+	Time int64 `json:"time"`
+}
+
+// Coder is implemented by errors with a code.
+type Coder interface {
+	Code() Code
+}
+
+type codedError struct {
+	error
+	code Code
+}
+
+func (e codedError) Code() Code {
+	return e.code
+}
+
+// WithCode returns an error reported with a code.
+func WithCode(err error, code Code) error {
+	return codedError{error: err, code: code}
+}
+
+// CodeOf returns the code of an error: the code of errors implementing Coder, CodeTimeout
+// and CodeNetwork for network errors, and CodeInternal for the others.
+func CodeOf(err error) Code {
+	switch e := err.(type) {
+	case Coder:
+		return e.Code()
+	case net.Error:
+		if e.Timeout() {
+			return CodeTimeout
+		}
+		return CodeNetwork
+	}
+	if err == context.DeadlineExceeded {
+		return CodeTimeout
+	}
+	return CodeInternal
+}
+
+type correlationKey struct{}
+
+// WithCorrelationID returns a context whose errors are reported with a correlation ID.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// NewCorrelationID returns a random correlation ID.
+func NewCorrelationID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id)
+}
+
+type throttleKey struct {
+	operation string
+	code      Code
+}
+
+type throttled struct {
+	reported time.Time
+	repeated int
+}
+
+// Reporter logs the errors of a module and reports them with the node.error signal.
+type Reporter struct {
+	module string
+	log    log.Logger
+	send   func(Report)
+	now    func() time.Time
+
+	mu        sync.Mutex
+	throttled map[throttleKey]*throttled
+}
+
+// New returns the reporter of a module.
+func New(module string) *Reporter {
+	return &Reporter{
+		module:    module,
+		log:       log.New("package", "status-go/services/"+module),
+		send:      func(r Report) { signal.SendNodeError(r) },
+		now:       time.Now,
+		throttled: make(map[throttleKey]*throttled),
+	}
+}
+
+// Error reports the error of an operation, with a new correlation ID. logCtx are
+// logged with the error.
+func (r *Reporter) Error(operation string, err error, logCtx ...interface{}) {
+	r.ErrorContext(context.Background(), operation, err, logCtx...)
+}
+
+// ErrorContext reports the error of an operation, with the correlation ID of the
+// context, if any, or a new one. logCtx are logged with the error.
+func (r *Reporter) ErrorContext(ctx context.Context, operation string, err error, logCtx ...interface{}) {
+	id, ok := ctx.Value(correlationKey{}).(string)
+	if !ok {
+		id = NewCorrelationID()
+	}
+	code := CodeOf(err)
+	r.log.Error(operation+" failed", append([]interface{}{"code", code, "correlationId", id, "err", err}, logCtx...)...)
+
+	now := r.now()
+	report := Report{
+		Module:        r.module,
+		Operation:     operation,
+		Code:          code,
+		CorrelationID: id,
+		Message:       messages[code],
+		Time:          now.UnixNano() / int64(time.Millisecond),
+	}
+	if !r.throttle(&report, now) {
+		r.send(report)
+	}
+}
+
+// throttle returns true if the report must only be logged, or sets the number of errors
+// only logged since the previous report.
+func (r *Reporter) throttle(report *Report, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := throttleKey{operation: report.Operation, code: report.Code}
+	t, ok := r.throttled[key]
+	if !ok {
+		r.throttled[key] = &throttled{reported: now}
+		return false
+	}
+	if now.Sub(t.reported) < MinInterval {
+		t.repeated++
+		return true
+	}
+	report.Repeated = t.repeated
+	t.reported = now
+	t.repeated = 0
+	return false
+}
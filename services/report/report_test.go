@@ -0,0 +1,72 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestReporter(now *time.Time, reports *[]Report) *Reporter {
+	r := New("test")
+	r.now = func() time.Time { return *now }
+	r.send = func(report Report) { *reports = append(*reports, report) }
+	return r
+}
+
+func TestReporter(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var reports []Report
+	r := newTestReporter(&now, &reports)
+
+	ctx := WithCorrelationID(context.Background(), "abc")
+	r.ErrorContext(ctx, "save", WithCode(errors.New("disk I/O error at /data/0x04a1.db"), CodeStorage))
+	require.Equal(t, []Report{{
+		Module:        "test",
+		Operation:     "save",
+		Code:          CodeStorage,
+		CorrelationID: "abc",
+		Message:       "Could not access the local data",
+		Time:          1000000,
+	}}, reports, "Errors are not reported to the client")
+
+	r.Error("post", errors.New("failed"))
+	require.Len(t, reports, 2)
+	require.Equal(t, CodeInternal, reports[1].Code)
+	require.Len(t, reports[1].CorrelationID, 16, "A correlation ID is generated for contexts without one")
+}
+
+func TestReporterThrottling(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var reports []Report
+	r := newTestReporter(&now, &reports)
+
+	for i := 0; i < 3; i++ {
+		r.Error("post", errors.New("failed"))
+	}
+	r.Error("post", WithCode(errors.New("failed"), CodeNetwork))
+	r.Error("save", errors.New("failed"))
+	require.Len(t, reports, 3, "Repeated errors are only reported once per interval")
+
+	now = now.Add(MinInterval)
+	r.Error("post", errors.New("failed"))
+	require.Len(t, reports, 4)
+	require.Equal(t, 2, reports[3].Repeated)
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestCodeOf(t *testing.T) {
+	require.Equal(t, CodeInternal, CodeOf(errors.New("failed")))
+	require.Equal(t, CodeInvalid, CodeOf(WithCode(errors.New("failed"), CodeInvalid)))
+	require.Equal(t, CodeTimeout, CodeOf(context.DeadlineExceeded))
+	require.Equal(t, CodeTimeout, CodeOf(timeoutError{}))
+	require.Equal(t, CodeNetwork, CodeOf(&net.OpError{Op: "dial", Err: errors.New("refused")}))
+}
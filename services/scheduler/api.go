@@ -0,0 +1,23 @@
+package scheduler
+
+import "context"
+
+// API exposes the scheduled jobs over RPC.
+type API struct {
+	service *Service
+}
+
+// NewAPI returns a new API.
+func NewAPI(s *Service) *API {
+	return &API{service: s}
+}
+
+// Jobs returns the scheduled jobs, when they are due and the results of their last runs.
+func (api *API) Jobs(context context.Context) []JobStatus {
+	return api.service.scheduler.Jobs()
+}
+
+// Conditions returns the conditions of the device last reported by the client.
+func (api *API) Conditions(context context.Context) Conditions {
+	return api.service.scheduler.Conditions()
+}
@@ -0,0 +1,293 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/status-im/status-go/db"
+	"github.com/status-im/status-go/services/report"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// checkInterval is the interval at which the jobs due are checked.
+const checkInterval = 30 * time.Second
+
+// reporter reports the errors of the jobs to the client.
+var reporter = report.New("scheduler")
+
+var (
+	// ErrInvalidJob is returned when adding a job without a name, an interval or a function.
+	ErrInvalidJob = errors.New("job must have a name, a positive interval and a function")
+)
+
+// Conditions are the conditions of the device, as reported by the client.
+type Conditions struct {
+	Charging bool `json:"charging"`
+	WiFi     bool `json:"wifi"`
+}
+
+// DefaultConditions returns the conditions assumed until the client reports them,
+// which are met, as on desktops and servers.
+func DefaultConditions() Conditions {
+	return Conditions{Charging: true, WiFi: true}
+}
+
+// Constraints are the conditions required to run a job.
+type Constraints struct {
+	RequiresCharging bool `json:"requiresCharging"`
+	RequiresWiFi     bool `json:"requiresWiFi"`
+}
+
+func (c Constraints) metBy(conditions Conditions) bool {
+	return (!c.RequiresCharging || conditions.Charging) && (!c.RequiresWiFi || conditions.WiFi)
+}
+
+// Job is a task run periodically.
+type Job struct {
+	// Name identifies the job, it is prefixed by the name of the service adding it.
+	Name     string
+	Interval time.Duration
+	// Jitter is the maximum random delay added to each interval, so that jobs added
+	// together don't always run together.
+	Jitter      time.Duration
+	Constraints Constraints
+	Run         func() error
+}
+
+// Result is the result of a run of a job.
+type Result struct {
+	// Time is the unix time the run started, in seconds.
+	Time int64 `json:"time"`
+	// Duration is the duration of the run, in milliseconds.
+	Duration int64  `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// JobStatus describes a scheduled job.
+type JobStatus struct {
+	Name string `json:"name"`
+	// Interval and Jitter are in seconds.
+	Interval    int64       `json:"interval"`
+	Jitter      int64       `json:"jitter"`
+	Constraints Constraints `json:"constraints"`
+	// NextRun is the unix time the job is due, in seconds. Runs are delayed until the
+	// constraints are met.
+	NextRun int64   `json:"nextRun"`
+	Running bool    `json:"running"`
+	LastRun *Result `json:"lastRun,omitempty"`
+}
+
+type entry struct {
+	job  Job
+	next time.Time
+}
+
+// Scheduler runs jobs periodically, when the conditions of the device meet their
+// constraints. The result of the last run of each job is persisted, so that the
+// intervals are kept across restarts.
+type Scheduler struct {
+	db     *leveldb.DB
+	now    func() time.Time
+	jitter func(max time.Duration) time.Duration
+
+	mu         sync.Mutex
+	jobs       map[string]*entry
+	running    map[string]bool
+	results    map[string]*Result
+	conditions Conditions
+
+	wake chan struct{}
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewScheduler returns a new Scheduler, loading the results of the last runs persisted in db.
+func NewScheduler(level *leveldb.DB) (*Scheduler, error) {
+	s := &Scheduler{
+		db:         level,
+		now:        time.Now,
+		jitter:     randomJitter,
+		jobs:       make(map[string]*entry),
+		running:    make(map[string]bool),
+		results:    make(map[string]*Result),
+		conditions: DefaultConditions(),
+		wake:       make(chan struct{}, 1),
+	}
+	iter := level.NewIterator(util.BytesPrefix([]byte{byte(db.ScheduledJobs)}), nil)
+	defer iter.Release()
+	for iter.Next() {
+		result := new(Result)
+		if err := json.Unmarshal(iter.Value(), result); err != nil {
+			return nil, err
+		}
+		s.results[string(iter.Key()[1:])] = result
+	}
+	return s, iter.Error()
+}
+
+// Add schedules a job, replacing the job with the same name. It is due an interval after
+// its last run, or right away if it never ran.
+func (s *Scheduler) Add(job Job) error {
+	if job.Name == "" || job.Interval <= 0 || job.Run == nil {
+		return ErrInvalidJob
+	}
+	s.mu.Lock()
+	e := &entry{job: job, next: s.now()}
+	if last, ok := s.results[job.Name]; ok {
+		e.next = time.Unix(last.Time, 0).Add(job.Interval + s.jitter(job.Jitter))
+	}
+	s.jobs[job.Name] = e
+	s.mu.Unlock()
+	s.notify()
+	return nil
+}
+
+// Remove unschedules the jobs whose name starts with prefix. A run in progress isn't interrupted.
+func (s *Scheduler) Remove(prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name := range s.jobs {
+		if strings.HasPrefix(name, prefix) {
+			delete(s.jobs, name)
+		}
+	}
+}
+
+// SetConditions updates the conditions of the device. Jobs which were delayed by their
+// constraints run if the conditions meet them.
+func (s *Scheduler) SetConditions(conditions Conditions) {
+	s.mu.Lock()
+	s.conditions = conditions
+	s.mu.Unlock()
+	s.notify()
+}
+
+// Conditions returns the conditions of the device.
+func (s *Scheduler) Conditions() Conditions {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conditions
+}
+
+// Jobs returns the status of the scheduled jobs, by name.
+func (s *Scheduler) Jobs() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]JobStatus, 0, len(s.jobs))
+	for name, e := range s.jobs {
+		status := JobStatus{
+			Name:        name,
+			Interval:    int64(e.job.Interval / time.Second),
+			Jitter:      int64(e.job.Jitter / time.Second),
+			Constraints: e.job.Constraints,
+			NextRun:     e.next.Unix(),
+			Running:     s.running[name],
+		}
+		if last, ok := s.results[name]; ok {
+			result := *last
+			status.LastRun = &result
+		}
+		jobs = append(jobs, status)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs
+}
+
+// Start runs the jobs when they are due, until Stop is called.
+func (s *Scheduler) Start() {
+	s.quit = make(chan struct{})
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			s.runDue()
+			select {
+			case <-ticker.C:
+			case <-s.wake:
+			case <-s.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops running jobs and waits for the runs in progress.
+func (s *Scheduler) Stop() {
+	if s.quit == nil {
+		return
+	}
+	close(s.quit)
+	s.wg.Wait()
+	s.quit = nil
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// runDue starts the runs of the jobs which are due and whose constraints are met.
+func (s *Scheduler) runDue() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for name, e := range s.jobs {
+		if s.running[name] || now.Before(e.next) || !e.job.Constraints.metBy(s.conditions) {
+			continue
+		}
+		s.running[name] = true
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			s.run(job)
+		}(e.job)
+	}
+}
+
+// run runs a job and records its result. The job is due again an interval after the run
+// started, if it is still scheduled.
+func (s *Scheduler) run(job Job) {
+	start := s.now()
+	err := job.Run()
+	result := &Result{
+		Time:     start.Unix(),
+		Duration: int64(s.now().Sub(start) / time.Millisecond),
+	}
+	if err != nil {
+		result.Error = err.Error()
+		reporter.Error("run-job", err, "name", job.Name)
+	}
+
+	s.mu.Lock()
+	s.results[job.Name] = result
+	delete(s.running, job.Name)
+	if e, ok := s.jobs[job.Name]; ok {
+		e.next = start.Add(e.job.Interval + s.jitter(e.job.Jitter))
+	}
+	s.mu.Unlock()
+
+	value, err := json.Marshal(result)
+	if err == nil {
+		err = s.db.Put(db.Key(db.ScheduledJobs, []byte(job.Name)), value, nil)
+	}
+	if err != nil {
+		reporter.Error("save-job-result", report.WithCode(err, report.CodeStorage), "name", job.Name)
+	}
+}
+
+func randomJitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+func newTestScheduler(t *testing.T, level *leveldb.DB, now *time.Time) *Scheduler {
+	s, err := NewScheduler(level)
+	require.NoError(t, err)
+	s.now = func() time.Time { return *now }
+	s.jitter = func(max time.Duration) time.Duration { return max }
+	return s
+}
+
+func TestScheduler(t *testing.T) {
+	level, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+	defer level.Close()
+
+	now := time.Unix(1546000000, 0)
+	s := newTestScheduler(t, level, &now)
+	require.Equal(t, ErrInvalidJob, s.Add(Job{Name: "test/invalid", Run: func() error { return nil }}))
+
+	var pruned, indexed int
+	require.NoError(t, s.Add(Job{
+		Name:     "test/prune",
+		Interval: time.Hour,
+		Jitter:   time.Minute,
+		Run: func() error {
+			pruned++
+			return errors.New("database is locked")
+		},
+	}))
+	require.NoError(t, s.Add(Job{
+		Name:        "test/index",
+		Interval:    24 * time.Hour,
+		Constraints: Constraints{RequiresCharging: true},
+		Run: func() error {
+			indexed++
+			return nil
+		},
+	}))
+	s.SetConditions(Conditions{WiFi: true})
+
+	s.runDue()
+	s.wg.Wait()
+	require.Equal(t, 1, pruned, "Jobs which never ran are due right away")
+	require.Equal(t, 0, indexed, "Jobs are delayed until their constraints are met")
+
+	now = now.Add(30 * time.Minute)
+	s.SetConditions(Conditions{Charging: true, WiFi: true})
+	s.runDue()
+	s.wg.Wait()
+	require.Equal(t, 1, pruned)
+	require.Equal(t, 1, indexed)
+
+	expected := []JobStatus{
+		{
+			Name:        "test/index",
+			Interval:    24 * 60 * 60,
+			Constraints: Constraints{RequiresCharging: true},
+			NextRun:     now.Add(24 * time.Hour).Unix(),
+			LastRun:     &Result{Time: now.Unix()},
+		},
+		{
+			Name:     "test/prune",
+			Interval: 60 * 60,
+			Jitter:   60,
+			NextRun:  now.Add(31 * time.Minute).Unix(),
+			LastRun:  &Result{Time: now.Add(-30 * time.Minute).Unix(), Error: "database is locked"},
+		},
+	}
+	require.Equal(t, expected, s.Jobs())
+
+	loaded := newTestScheduler(t, level, &now)
+	for _, job := range []string{"test/index", "test/prune"} {
+		require.NoError(t, loaded.Add(Job{Name: job, Interval: time.Hour, Run: func() error { return nil }}))
+	}
+	jobs := loaded.Jobs()
+	require.Equal(t, expected[1].LastRun, jobs[1].LastRun, "Results are persisted")
+	require.Equal(t, now.Add(30*time.Minute).Unix(), jobs[1].NextRun, "Jobs are due an interval after their last run")
+
+	loaded.Remove("test/")
+	require.Empty(t, loaded.Jobs())
+}
@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// Make sure that Service implements node.Service interface.
+var _ node.Service = (*Service)(nil)
+
+// Service runs the scheduler shared by the services of the node.
+type Service struct {
+	scheduler *Scheduler
+}
+
+// New returns a new Service persisting the results of the jobs in db.
+func New(db *leveldb.DB) (*Service, error) {
+	scheduler, err := NewScheduler(db)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{scheduler: scheduler}, nil
+}
+
+// Scheduler returns the scheduler to which the services add their jobs.
+func (s *Service) Scheduler() *Scheduler {
+	return s.scheduler
+}
+
+// Protocols returns a new protocols list. In this case, there are none.
+func (s *Service) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{}
+}
+
+// APIs returns a list of new APIs.
+func (s *Service) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "scheduler",
+			Version:   "1.0",
+			Service:   NewAPI(s),
+			Public:    false,
+		},
+	}
+}
+
+// Start is run when a service is started.
+func (s *Service) Start(server *p2p.Server) error {
+	s.scheduler.Start()
+	return nil
+}
+
+// Stop is run when a service is stopped.
+func (s *Service) Stop() error {
+	s.scheduler.Stop()
+	return nil
+}
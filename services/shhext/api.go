@@ -1,12 +1,17 @@
 package shhext
 
 import (
+	"bytes"
 	"context"
 	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"time"
 
 	"github.com/ethereum/go-ethereum/rlp"
@@ -14,19 +19,53 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/sha3"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/golang/protobuf/proto"
+	"github.com/status-im/status-go/logutils"
 	"github.com/status-im/status-go/mailserver"
+	"github.com/status-im/status-go/services/report"
+	"github.com/status-im/status-go/services/shhext/attachments"
+	"github.com/status-im/status-go/services/shhext/bloom"
 	"github.com/status-im/status-go/services/shhext/chat"
+	"github.com/status-im/status-go/services/shhext/chat/capabilities"
+	"github.com/status-im/status-go/services/shhext/consent"
+	"github.com/status-im/status-go/services/shhext/contacts"
+	"github.com/status-im/status-go/services/shhext/content"
+	"github.com/status-im/status-go/services/shhext/decoy"
+	"github.com/status-im/status-go/services/shhext/delivery"
+	"github.com/status-im/status-go/services/shhext/devicesync"
+	"github.com/status-im/status-go/services/shhext/downgrade"
+	"github.com/status-im/status-go/services/shhext/estimate"
+	"github.com/status-im/status-go/services/shhext/history"
+	"github.com/status-im/status-go/services/shhext/identity"
 	"github.com/status-im/status-go/services/shhext/mailservers"
+	"github.com/status-im/status-go/services/shhext/moderation"
+	"github.com/status-im/status-go/services/shhext/notifications"
+	"github.com/status-im/status-go/services/shhext/outbox"
+	"github.com/status-im/status-go/services/shhext/pipeline"
+	"github.com/status-im/status-go/services/shhext/presence"
+	"github.com/status-im/status-go/services/shhext/push"
+	"github.com/status-im/status-go/services/shhext/ratelimit"
+	"github.com/status-im/status-go/services/shhext/receipts"
+	"github.com/status-im/status-go/services/shhext/schema"
+	"github.com/status-im/status-go/services/shhext/segmentation"
+	"github.com/status-im/status-go/services/shhext/trust"
+	"github.com/status-im/status-go/services/shhext/wipe"
 	whisper "github.com/status-im/whisper/whisperv6"
 )
 
 const (
 	// defaultWorkTime is a work time reported in messages sent to MailServer nodes.
 	defaultWorkTime = 5
+	// aesKeyLength is the length of the symmetric keys of Whisper.
+	aesKeyLength = 32
 	// defaultRequestTimeout is the default request timeout in seconds
 	defaultRequestTimeout = 10
+	// typingTTL is the TTL in seconds of typing notifications, which are
+	// useless once the contact may have stopped typing.
+	typingTTL = 5
 )
 
 var (
@@ -40,6 +79,38 @@ var (
 	// ErrPFSNotEnabled is returned when an endpoint PFS only is called but
 	// PFS is disabled
 	ErrPFSNotEnabled = errors.New("pfs not enabled")
+	// ErrRequestDuplicated is returned when an identical request for historic
+	// messages was already sent within the deduplication window.
+	ErrRequestDuplicated = errors.New("identical request was sent recently")
+	// ErrInvalidMessagesCursor is returned when a paginated request is continued with a malformed cursor.
+	ErrInvalidMessagesCursor = errors.New("invalid messages cursor")
+	// ErrRequestExpired is returned when a mail server doesn't respond to a request in time.
+	ErrRequestExpired = errors.New("request for historic messages expired")
+	// ErrMailServerPickerDisabled is returned when the ranking of MailServers is
+	// requested but MailServers are not probed.
+	ErrMailServerPickerDisabled = errors.New("mail server picker is disabled")
+	// ErrBloomFilterNegotiationDisabled is returned when active chats are set but
+	// the bloom filter advertised to peers is not narrowed.
+	ErrBloomFilterNegotiationDisabled = errors.New("bloom filter negotiation is disabled")
+	// ErrResponseValidationDisabled is returned when the trust in MailServers is
+	// requested but their responses are not validated.
+	ErrResponseValidationDisabled = errors.New("validation of mail server responses is disabled")
+	// ErrResponseSummariesDisabled is returned when a request is verified but the
+	// summaries of the responses are not collected.
+	ErrResponseSummariesDisabled = errors.New("summaries of mail server responses are not collected")
+	// ErrEchoBotDisabled is returned when the echo bot is requested but it is not running.
+	ErrEchoBotDisabled = errors.New("echo bot is disabled")
+	// ErrPushServerDisabled is returned when the push notification server is requested but it is not running.
+	ErrPushServerDisabled = errors.New("push notification server is disabled")
+	// ErrAttachmentsDisabled is returned when attachments are used without a storage backend.
+	ErrAttachmentsDisabled = errors.New("attachments are disabled")
+	// ErrMediaServerDisabled is returned when requesting the URL of an attachment without the media server.
+	ErrMediaServerDisabled = errors.New("media server is disabled")
+	// ErrSyncDisabled is returned when the history is synced while the user disabled the sync.
+	ErrSyncDisabled = errors.New("sync is disabled")
+	// ErrNoChatIdentity is returned when the selected account has no Whisper identity, like
+	// the accounts whose key is kept by the platform keystore: the chat needs the private key.
+	ErrNoChatIdentity = errors.New("the selected account has no chat identity")
 )
 
 // -----
@@ -80,6 +151,14 @@ type MessagesRequest struct {
 	// Timeout is the time to live of the request specified in seconds.
 	// Default is 10 seconds
 	Timeout time.Duration `json:"timeout"`
+
+	// Verify asks the MailServer for a signed summary of the response,
+	// which is checked against the envelopes received.
+	Verify bool `json:"verify"`
+
+	// Credential is the hex-encoded RLP of a mailserver.Credential, required by
+	// MailServers which serve only the clients of a service (optional).
+	Credential string `json:"credential"`
 }
 
 func (r *MessagesRequest) setDefaults(now time.Time) {
@@ -102,6 +181,27 @@ func (r *MessagesRequest) setDefaults(now time.Time) {
 	}
 }
 
+// fingerprint identifies the mail server, the topics and the range of the request.
+func (r *MessagesRequest) fingerprint() []byte {
+	topics := append([]whisper.TopicType{r.Topic}, r.Topics...)
+	sort.Slice(topics, func(i, j int) bool {
+		return bytes.Compare(topics[i][:], topics[j][:]) < 0
+	})
+
+	hash := sha3.NewKeccak256()
+	hash.Write([]byte(r.MailServerPeer))
+	var buf [12]byte
+	binary.BigEndian.PutUint32(buf[0:], r.From)
+	binary.BigEndian.PutUint32(buf[4:], r.To)
+	binary.BigEndian.PutUint32(buf[8:], r.Limit)
+	hash.Write(buf[:])
+	hash.Write([]byte(r.Cursor))
+	for _, topic := range topics {
+		hash.Write(topic[:])
+	}
+	return hash.Sum(nil)
+}
+
 // SyncMessagesRequest is a SyncMessages() request payload.
 type SyncMessagesRequest struct {
 	// MailServerPeer is MailServer's enode address.
@@ -139,6 +239,67 @@ type SyncMessagesResponse struct {
 	Error string `json:"error"`
 }
 
+// DefaultMessagesPageLimit is the number of envelopes requested per page
+// when a paginated request doesn't specify a limit.
+const DefaultMessagesPageLimit = 1000
+
+// MessagesPage is the result of a paginated request for historic messages.
+type MessagesPage struct {
+	// RequestID is the hash of the request envelope.
+	RequestID hexutil.Bytes `json:"requestID"`
+
+	// LastEnvelopeHash is the hash of the last envelope sent by the mail server.
+	LastEnvelopeHash common.Hash `json:"lastEnvelopeHash"`
+
+	// Cursor continues the request from where this page ended.
+	// It is empty if there are no more pages.
+	Cursor string `json:"cursor"`
+}
+
+// messagesCursor pins the range and the topics of a paginated request,
+// so that its pages are consistent even if it relies on default values.
+type messagesCursor struct {
+	From   uint32              `json:"from"`
+	To     uint32              `json:"to"`
+	Limit  uint32              `json:"limit"`
+	Topics []whisper.TopicType `json:"topics"`
+	Cursor hexutil.Bytes       `json:"cursor"`
+}
+
+func encodeMessagesCursor(r MessagesRequest, cursor []byte) (string, error) {
+	data, err := json.Marshal(messagesCursor{
+		From:   r.From,
+		To:     r.To,
+		Limit:  r.Limit,
+		Topics: r.Topics,
+		Cursor: cursor,
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// applyMessagesCursor replaces the range, the limit and the topics of
+// a request with the ones pinned by an opaque cursor.
+func applyMessagesCursor(r *MessagesRequest, cursor string) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ErrInvalidMessagesCursor
+	}
+	var c messagesCursor
+	if err := json.Unmarshal(data, &c); err != nil || len(c.Cursor) == 0 {
+		return ErrInvalidMessagesCursor
+	}
+	r.From = c.From
+	r.To = c.To
+	r.Limit = c.Limit
+	r.Topic = whisper.TopicType{}
+	r.Topics = c.Topics
+	r.Cursor = hex.EncodeToString(c.Cursor)
+	return nil
+}
+
 // -----
 // PUBLIC API
 // -----
@@ -155,19 +316,89 @@ func NewPublicAPI(s *Service) *PublicAPI {
 	return &PublicAPI{
 		service:   s,
 		publicAPI: whisper.NewPublicWhisperAPI(s.w),
-		log:       log.New("package", "status-go/services/sshext.PublicAPI"),
+		log:       logutils.NewLogger(logutils.ModuleShhext, "package", "status-go/services/sshext.PublicAPI"),
 	}
 }
 
 // Post shamelessly copied from whisper codebase with slight modifications.
 func (api *PublicAPI) Post(ctx context.Context, req whisper.NewMessage) (hash hexutil.Bytes, err error) {
-	hash, err = api.publicAPI.Post(ctx, req)
-	if err == nil {
-		var envHash common.Hash
-		copy(envHash[:], hash[:]) // slice can't be used as key
-		api.service.tracker.Add(envHash)
+	if err := api.service.outgoing.Wait(ctx, ratelimit.ClassFromContext(ctx)); err != nil {
+		return nil, err
+	}
+	req = api.service.adaptPoW(req)
+	envelope, err := api.send(req)
+	if err != nil {
+		return nil, err
 	}
-	return hash, err
+	envHash := envelope.Hash()
+	api.service.tracker.Add(envHash)
+	if len(req.TargetPeer) == 0 {
+		api.service.retries.Add(envelope, req.PowTarget, req.PowTime)
+	}
+	return envHash.Bytes(), nil
+}
+
+// send posts a message like the Post method of the Whisper API, and returns its envelope
+// so that it can be retried without the keys of the message.
+func (api *PublicAPI) send(req whisper.NewMessage) (*whisper.Envelope, error) {
+	symKeyGiven := len(req.SymKeyID) > 0
+	pubKeyGiven := len(req.PublicKey) > 0
+	if symKeyGiven == pubKeyGiven {
+		return nil, whisper.ErrSymAsym
+	}
+
+	var err error
+	params := &whisper.MessageParams{
+		TTL:      req.TTL,
+		Payload:  req.Payload,
+		Padding:  req.Padding,
+		WorkTime: req.PowTime,
+		PoW:      req.PowTarget,
+		Topic:    req.Topic,
+	}
+	if len(req.Sig) > 0 {
+		if params.Src, err = api.service.w.GetPrivateKey(req.Sig); err != nil {
+			return nil, err
+		}
+	}
+	if symKeyGiven {
+		if params.Topic == (whisper.TopicType{}) {
+			return nil, whisper.ErrNoTopics
+		}
+		if params.KeySym, err = api.service.w.GetSymKey(req.SymKeyID); err != nil {
+			return nil, err
+		}
+		if len(params.KeySym) != aesKeyLength || bytes.Equal(params.KeySym, make([]byte, aesKeyLength)) {
+			return nil, whisper.ErrInvalidSymmetricKey
+		}
+	}
+	if pubKeyGiven {
+		if params.Dst, err = crypto.UnmarshalPubkey(req.PublicKey); err != nil {
+			return nil, whisper.ErrInvalidPublicKey
+		}
+	}
+
+	message, err := whisper.NewSentMessage(params)
+	if err != nil {
+		return nil, err
+	}
+	envelope, err := message.Wrap(params, api.service.w.GetCurrentTime())
+	if err != nil {
+		return nil, err
+	}
+
+	// messages sent to a specific peer skip the PoW check
+	if len(req.TargetPeer) > 0 {
+		n, err := enode.ParseV4(req.TargetPeer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse target peer: %s", err)
+		}
+		return envelope, api.service.w.SendP2PMessage(n.ID().Bytes(), envelope)
+	}
+	if req.PowTarget < api.service.w.MinPow() {
+		return nil, whisper.ErrTooLowPoW
+	}
+	return envelope, api.service.w.Send(envelope)
 }
 
 func (api *PublicAPI) getPeer(rawurl string) (*enode.Node, error) {
@@ -182,11 +413,33 @@ func (api *PublicAPI) RequestMessages(_ context.Context, r MessagesRequest) (hex
 	api.log.Info("RequestMessages", "request", r)
 	shh := api.service.w
 	now := api.service.w.GetCurrentTime()
+	// Fingerprint is computed before applying defaults, so that requests
+	// relying on the default time range are considered identical.
+	fingerprint := r.fingerprint()
+	seen, err := api.service.requestsCache.Seen(fingerprint, now)
+	if err != nil {
+		return nil, err
+	}
+	if seen {
+		return nil, ErrRequestDuplicated
+	}
 	r.setDefaults(now)
 
 	if r.From > r.To {
 		return nil, fmt.Errorf("Query range is invalid: from > to (%d > %d)", r.From, r.To)
 	}
+	// Whisper disconnects mail servers sending summaries it doesn't split.
+	if r.Verify && api.service.config.ResponseSummaries == nil {
+		return nil, ErrResponseSummariesDisabled
+	}
+	// Decoys are mixed into the requested topics, so that the MailServer doesn't learn
+	// which chats are in use. Their envelopes are dropped by Whisper, as no filter matches them.
+	if decoys := api.service.decoys; len(decoys) > 0 {
+		if len(r.Topics) == 0 {
+			r.Topics = []whisper.TopicType{r.Topic}
+		}
+		r.Topics = decoy.Mix(r.Topics, decoys)
+	}
 
 	mailServerNode, err := api.getPeer(r.MailServerPeer)
 	if err != nil {
@@ -224,13 +477,151 @@ func (api *PublicAPI) RequestMessages(_ context.Context, r MessagesRequest) (hex
 		return nil, err
 	}
 
-	if err := shh.RequestHistoricMessagesWithTimeout(mailServerNode.ID().Bytes(), envelope, r.Timeout*time.Second); err != nil {
+	if err := api.service.requestHistoricMessages(mailServerNode, envelope, r); err != nil {
 		return nil, err
 	}
+	if err := api.service.requestsCache.Add(fingerprint, now); err != nil {
+		api.log.Error("failed to record request fingerprint", "err", err)
+	}
 	hash := envelope.Hash()
+	if r.Verify {
+		api.service.integrity.Watch(hash, mailServerNode.Pubkey(), r.From, r.To)
+	}
 	return hash[:], nil
 }
 
+// RequestMessagesWithCursor requests a page of historic messages from a MailServer
+// and waits for the MailServer to send it. The returned cursor is opaque and must be
+// set as the cursor of the next request to continue from where the page ended.
+// The range, the limit and the topics of the first request are kept for the next pages.
+func (api *PublicAPI) RequestMessagesWithCursor(ctx context.Context, r MessagesRequest) (*MessagesPage, error) {
+	if err := api.preparePageRequest(&r); err != nil {
+		return nil, err
+	}
+
+	// Subscribe before sending the request, so that the response can't be missed.
+	events := make(chan whisper.EnvelopeEvent, 10)
+	sub := api.service.w.SubscribeEnvelopeEvents(events)
+	defer sub.Unsubscribe()
+
+	requestID, err := api.RequestMessages(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.waitForPage(ctx, events, r, []common.Hash{common.BytesToHash(requestID)})
+}
+
+// RequestMessagesFromPool sends the same request for a page of historic messages to
+// several connected MailServers and returns the first page sent successfully. Requests
+// to the other MailServers are not reported anymore, and envelopes sent by several
+// MailServers are returned once by shhext_getNewFilterMessages.
+// MailServerPeer is ignored, the cursor works as in RequestMessagesWithCursor.
+func (api *PublicAPI) RequestMessagesFromPool(ctx context.Context, r MessagesRequest) (*MessagesPage, error) {
+	if err := api.preparePageRequest(&r); err != nil {
+		return nil, err
+	}
+
+	nodes, err := mailservers.GetConnected(api.service.server, api.service.peerStore, api.service.poolSize())
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan whisper.EnvelopeEvent, 10*len(nodes))
+	sub := api.service.w.SubscribeEnvelopeEvents(events)
+	defer sub.Unsubscribe()
+
+	var (
+		requests []common.Hash
+		lastErr  error
+	)
+	for _, node := range nodes {
+		request := r
+		request.MailServerPeer = node.String()
+		requestID, err := api.RequestMessages(ctx, request)
+		if err != nil {
+			api.log.Warn("failed to request messages from the pool", "peer", node.ID(), "err", err)
+			lastErr = err
+			continue
+		}
+		requests = append(requests, common.BytesToHash(requestID))
+	}
+	if len(requests) == 0 {
+		return nil, lastErr
+	}
+
+	page, err := api.waitForPage(ctx, events, r, requests)
+	for _, hash := range requests {
+		if page == nil || !bytes.Equal(page.RequestID, hash[:]) {
+			api.service.tracker.Remove(hash)
+		}
+	}
+	return page, err
+}
+
+// preparePageRequest applies the cursor of a paginated request,
+// or the defaults if it is the first page.
+func (api *PublicAPI) preparePageRequest(r *MessagesRequest) error {
+	if r.Cursor != "" {
+		return applyMessagesCursor(r, r.Cursor)
+	}
+	r.setDefaults(api.service.w.GetCurrentTime())
+	if r.Limit == 0 {
+		r.Limit = DefaultMessagesPageLimit
+	}
+	if len(r.Topics) == 0 {
+		r.Topics = []whisper.TopicType{r.Topic}
+	}
+	r.Topic = whisper.TopicType{}
+	return nil
+}
+
+// waitForPage waits for the first of the requests to be completed successfully.
+// It fails if all of them failed or expired.
+func (api *PublicAPI) waitForPage(ctx context.Context, events <-chan whisper.EnvelopeEvent, r MessagesRequest, requests []common.Hash) (*MessagesPage, error) {
+	pending := make(map[common.Hash]struct{}, len(requests))
+	for _, hash := range requests {
+		pending[hash] = struct{}{}
+	}
+
+	var lastErr error
+	for len(pending) > 0 {
+		select {
+		case event := <-events:
+			if _, ok := pending[event.Hash]; !ok {
+				continue
+			}
+			switch event.Event {
+			case whisper.EventMailServerRequestExpired:
+				delete(pending, event.Hash)
+				lastErr = ErrRequestExpired
+			case whisper.EventMailServerRequestCompleted:
+				delete(pending, event.Hash)
+				resp, ok := event.Data.(*whisper.MailServerResponse)
+				if !ok {
+					lastErr = fmt.Errorf("did not understand the response event data")
+					continue
+				}
+				if err := responseError(resp); err != nil {
+					lastErr = err
+					continue
+				}
+				page := &MessagesPage{RequestID: event.Hash.Bytes(), LastEnvelopeHash: resp.LastEnvelopeHash}
+				if len(resp.Cursor) > 0 {
+					var err error
+					if page.Cursor, err = encodeMessagesCursor(r, resp.Cursor); err != nil {
+						return nil, err
+					}
+				}
+				return page, nil
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return nil, lastErr
+}
+
 // createSyncMailRequest creates SyncMailRequest. It uses a full bloom filter
 // if no topics are given.
 func createSyncMailRequest(r SyncMessagesRequest) (whisper.SyncMailRequest, error) {
@@ -294,7 +685,7 @@ func (api *PublicAPI) SyncMessages(ctx context.Context, r SyncMessagesRequest) (
 				continue
 			}
 
-			log.Info("received EventMailServerSyncFinished event", "data", event.Data)
+			logger.Info("received EventMailServerSyncFinished event", "data", event.Data)
 
 			if resp, ok := event.Data.(whisper.SyncEventResponse); ok {
 				return createSyncMessagesResponse(resp), nil
@@ -313,161 +704,1185 @@ func (api *PublicAPI) GetNewFilterMessages(filterID string) ([]*whisper.Message,
 		return nil, err
 	}
 
-	dedupMessages := api.service.deduplicator.Deduplicate(msgs)
+	dedupMessages := api.service.deduplicator.Deduplicate(api.service.recentEnvelopes.Deduplicate(filterID, msgs))
+	dedupMessages = api.service.dropBlocked(dedupMessages)
+	plugins := api.service.pipeline
+	dedupMessages = plugins.Run(pipeline.PreDecrypt, dedupMessages)
 
-	if api.service.pfsEnabled {
-		// Attempt to decrypt message, otherwise leave unchanged
-		for _, msg := range dedupMessages {
+	if !api.service.pfsEnabled {
+		dedupMessages = plugins.Run(pipeline.PostDecrypt, dedupMessages)
+		dedupMessages = plugins.Run(pipeline.PrePersist, dedupMessages)
+		return plugins.Run(pipeline.PreSignal, dedupMessages), nil
+	}
 
-			if err := api.processPFSMessage(msg); err != nil {
-				return nil, err
-			}
+	// Attempt to decrypt message, otherwise leave unchanged
+	processedMessages := make([]*whisper.Message, 0, len(dedupMessages))
+	for _, msg := range dedupMessages {
+		if !api.reassemble(msg) {
+			continue
 		}
+
+		err := api.processPFSMessage(msg)
+		if err == chat.ErrDuplicateMessage || err == chat.ErrSyncMessage || err == errMessagePending {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		processedMessages = append(processedMessages, msg)
+	}
+	if wiped, err := api.executePendingWipe(context.Background()); wiped {
+		return []*whisper.Message{}, err
 	}
+	dedupMessages = plugins.Run(pipeline.PostDecrypt, processedMessages)
+	dedupMessages = api.moderateMessages(dedupMessages)
+	dedupMessages = plugins.Run(pipeline.PrePersist, dedupMessages)
+	api.receiveAttachments(dedupMessages)
+	dedupMessages = api.receivePresence(dedupMessages)
+	dedupMessages = api.receivePush(dedupMessages)
+	dedupMessages = api.applyContentMessages(dedupMessages)
+	dedupMessages = plugins.Run(pipeline.PreSignal, dedupMessages)
+
+	api.translateMessages(dedupMessages)
+	api.service.notifyMessages(dedupMessages)
 
 	return dedupMessages, nil
 }
 
-// ConfirmMessagesProcessed is a method to confirm that messages was consumed by
-// the client side.
-func (api *PublicAPI) ConfirmMessagesProcessed(messages []*whisper.Message) error {
-	return api.service.deduplicator.AddMessages(messages)
+// GetPlugins returns the names of the plugins hooked into the processing of the
+// messages returned by GetNewFilterMessages, in the order they run.
+func (api *PublicAPI) GetPlugins() []string {
+	return api.service.Plugins()
 }
 
-// SendPublicMessage sends a public chat message to the underlying transport
-func (api *PublicAPI) SendPublicMessage(ctx context.Context, msg chat.SendPublicMessageRPC) (hexutil.Bytes, error) {
-	privateKey, err := api.service.w.GetPrivateKey(msg.Sig)
-	if err != nil {
-		return nil, err
+// SetChatLanguage sets the language messages received in a chat are translated to.
+// An empty language disables translations.
+func (api *PublicAPI) SetChatLanguage(chatID string, language string) error {
+	if api.service.protocol == nil {
+		return errProtocolNotInitialized
 	}
 
-	// This is transport layer agnostic
-	protocolMessage, err := api.service.protocol.BuildPublicMessage(privateKey, msg.Payload)
-	if err != nil {
-		return nil, err
-	}
+	return api.service.protocol.SetChatLanguage(chatID, language)
+}
 
-	symKeyID, err := api.service.w.AddSymKeyFromPassword(msg.Chat)
-	if err != nil {
-		return nil, err
+// GetChatLanguage returns the language messages received in a chat are translated to.
+func (api *PublicAPI) GetChatLanguage(chatID string) (string, error) {
+	if api.service.protocol == nil {
+		return "", errProtocolNotInitialized
 	}
 
-	// Enrich with transport layer info
-	whisperMessage := chat.PublicMessageToWhisper(msg, protocolMessage)
-	whisperMessage.SymKeyID = symKeyID
-
-	// And dispatch
-	return api.Post(ctx, whisperMessage)
+	return api.service.protocol.GetChatLanguage(chatID)
 }
 
-// SendDirectMessage sends a 1:1 chat message to the underlying transport
-func (api *PublicAPI) SendDirectMessage(ctx context.Context, msg chat.SendDirectMessageRPC) ([]hexutil.Bytes, error) {
-	if !api.service.pfsEnabled {
-		return nil, ErrPFSNotEnabled
-	}
-	// To be completely agnostic from whisper we should not be using whisper to store the key
-	privateKey, err := api.service.w.GetPrivateKey(msg.Sig)
-	if err != nil {
-		return nil, err
+// GetEchoBot returns the public key of the built-in echo bot, which clients add as a
+// contact to test direct messages without a second device.
+func (api *PublicAPI) GetEchoBot() (hexutil.Bytes, error) {
+	if api.service.echoBot == nil {
+		return nil, ErrEchoBotDisabled
 	}
+	return crypto.FromECDSAPub(api.service.echoBot.bot.PublicKey()), nil
+}
 
-	publicKey, err := crypto.UnmarshalPubkey(msg.PubKey)
-	if err != nil {
-		return nil, err
+// GetPushServer returns the public key of the push notification server run by the node.
+func (api *PublicAPI) GetPushServer() (hexutil.Bytes, error) {
+	if api.service.pushServer == nil {
+		return nil, ErrPushServerDisabled
 	}
+	return crypto.FromECDSAPub(api.service.pushServer.server.PublicKey()), nil
+}
 
-	// This is transport layer-agnostic
-	protocolMessages, err := api.service.protocol.BuildDirectMessage(privateKey, msg.Payload, publicKey)
+// RegisterForPushNotifications registers the token of the installation with push
+// notification servers, or the default ones if none are given. The servers answer
+// asynchronously, which GetPushRegistration reports.
+func (api *PublicAPI) RegisterForPushNotifications(servers []hexutil.Bytes, options push.Options) (*push.ClientState, error) {
+	if api.service.push == nil {
+		return nil, errProtocolNotInitialized
+	}
+	identity, err := api.service.selectedIdentity()
 	if err != nil {
 		return nil, err
 	}
-
-	var response []hexutil.Bytes
-
-	for key, message := range protocolMessages {
-		msg.PubKey = crypto.FromECDSAPub(key)
-		// Enrich with transport layer info
-		whisperMessage := chat.DirectMessageToWhisper(msg, message)
-
-		// And dispatch
-		hash, err := api.Post(ctx, whisperMessage)
+	keys := make([]*ecdsa.PublicKey, 0, len(servers))
+	for _, server := range servers {
+		key, err := crypto.UnmarshalPubkey(server)
 		if err != nil {
+			return nil, ErrInvalidPublicKey
+		}
+		keys = append(keys, key)
+	}
+	var contacts []*ecdsa.PublicKey
+	if options.AllowFromContactsOnly {
+		if contacts, err = api.service.pushContacts(); err != nil {
 			return nil, err
 		}
-		response = append(response, hash)
-
 	}
-	return response, nil
+	return api.service.push.Register(identity, contacts, keys, options)
 }
 
-// SendPairingMessage sends a 1:1 chat message to our own devices to initiate a pairing session
-func (api *PublicAPI) SendPairingMessage(ctx context.Context, msg chat.SendDirectMessageRPC) ([]hexutil.Bytes, error) {
-	if !api.service.pfsEnabled {
-		return nil, ErrPFSNotEnabled
+// UnregisterFromPushNotifications removes the registration of the installation from the
+// push notification servers.
+func (api *PublicAPI) UnregisterFromPushNotifications() error {
+	if api.service.push == nil {
+		return errProtocolNotInitialized
 	}
-	// To be completely agnostic from whisper we should not be using whisper to store the key
-	privateKey, err := api.service.w.GetPrivateKey(msg.Sig)
+	identity, err := api.service.selectedIdentity()
 	if err != nil {
-		return nil, err
+		return err
 	}
+	return api.service.push.Unregister(identity)
+}
 
-	msg.PubKey = crypto.FromECDSAPub(&privateKey.PublicKey)
+// GetPushRegistration returns the registration of the installation with push notification
+// servers, or nil if it never registered.
+func (api *PublicAPI) GetPushRegistration() (*push.ClientState, error) {
+	if api.service.push == nil {
+		return nil, errProtocolNotInitialized
+	}
+	return api.service.push.State()
+}
+
+// GetContactCapabilities returns the protocol version and features supported by all the
+// active installations of a contact, so that clients use only those in messages sent to it.
+func (api *PublicAPI) GetContactCapabilities(publicKey hexutil.Bytes) (capabilities.Set, error) {
+	if api.service.protocol == nil {
+		return capabilities.Set{}, errProtocolNotInitialized
+	}
+
+	key, err := crypto.UnmarshalPubkey(publicKey)
 	if err != nil {
-		return nil, err
+		return capabilities.Set{}, ErrInvalidPublicKey
 	}
+	return api.service.protocol.ContactCapabilities(key)
+}
 
-	protocolMessage, err := api.service.protocol.BuildPairingMessage(privateKey, msg.Payload)
+// AddContact adds a contact or renames it. The contact is returned with warnings if its
+// name can be mistaken for the name of another contact, to flag impersonation attempts.
+func (api *PublicAPI) AddContact(publicKey hexutil.Bytes, name string) (contacts.Contact, error) {
+	if api.service.contacts == nil {
+		return contacts.Contact{}, errProtocolNotInitialized
+	}
+	if _, err := crypto.UnmarshalPubkey(publicKey); err != nil {
+		return contacts.Contact{}, ErrInvalidPublicKey
+	}
+	contact, err := api.service.contacts.Add(publicKey.String(), name)
 	if err != nil {
-		return nil, err
+		return contacts.Contact{}, err
 	}
+	api.service.syncChanged(devicesync.KindContact, contact.PublicKey)
+	api.service.acceptContact(publicKey)
+	return contact, nil
+}
 
-	var response []hexutil.Bytes
+// GetContacts returns the contacts, with the warnings raised by their names when they were added.
+func (api *PublicAPI) GetContacts() ([]contacts.Contact, error) {
+	if api.service.contacts == nil {
+		return nil, errProtocolNotInitialized
+	}
+	return api.service.contacts.Contacts()
+}
 
-	// Enrich with transport layer info
-	whisperMessage := chat.DirectMessageToWhisper(msg, protocolMessage)
+// CheckContactName returns the warnings raised by the name of a public key that is not
+// a contact, such as the author of a message, if it can be mistaken for the name of a contact.
+func (api *PublicAPI) CheckContactName(publicKey hexutil.Bytes, name string) ([]contacts.Warning, error) {
+	if api.service.contacts == nil {
+		return nil, errProtocolNotInitialized
+	}
+	if _, err := crypto.UnmarshalPubkey(publicKey); err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	return api.service.contacts.Check(publicKey.String(), name)
+}
 
-	// And dispatch
-	hash, err := api.Post(ctx, whisperMessage)
+// AcceptContactRequest allows an identity to establish a session with us, and delivers
+// the messages it sent before with a messages.decrypted signal.
+func (api *PublicAPI) AcceptContactRequest(publicKey hexutil.Bytes) error {
+	if api.service.consent == nil {
+		return errProtocolNotInitialized
+	}
+	key, err := crypto.UnmarshalPubkey(publicKey)
 	if err != nil {
-		return nil, err
+		return ErrInvalidPublicKey
+	}
+	if _, err := api.service.consent.Accept(publicKey.String()); err != nil {
+		return err
 	}
-	response = append(response, hash)
+	api.service.inbox.Retry(key)
+	return nil
+}
 
-	return response, nil
+// RejectContactRequest drops the messages sent by an identity, and the messages it
+// sends until it is accepted.
+func (api *PublicAPI) RejectContactRequest(publicKey hexutil.Bytes) error {
+	if api.service.consent == nil {
+		return errProtocolNotInitialized
+	}
+	key, err := crypto.UnmarshalPubkey(publicKey)
+	if err != nil {
+		return ErrInvalidPublicKey
+	}
+	if _, err := api.service.consent.Reject(publicKey.String()); err != nil {
+		return err
+	}
+	return api.service.inbox.Drop(key)
 }
 
-// SendGroupMessage sends a group messag chat message to the underlying transport
-func (api *PublicAPI) SendGroupMessage(ctx context.Context, msg chat.SendGroupMessageRPC) ([]hexutil.Bytes, error) {
-	if !api.service.pfsEnabled {
-		return nil, ErrPFSNotEnabled
+// GetContactRequests returns the pending contact requests, from the oldest.
+func (api *PublicAPI) GetContactRequests() ([]consent.Request, error) {
+	if api.service.consent == nil {
+		return nil, errProtocolNotInitialized
 	}
 
-	// To be completely agnostic from whisper we should not be using whisper to store the key
-	privateKey, err := api.service.w.GetPrivateKey(msg.Sig)
+	return api.service.consent.Requests()
+}
+
+// BlockContact blocks an identity: its messages are dropped before being decrypted,
+// including those kept in the inbox until now.
+func (api *PublicAPI) BlockContact(publicKey hexutil.Bytes) error {
+	if api.service.blocking == nil {
+		return errProtocolNotInitialized
+	}
+	key, err := crypto.UnmarshalPubkey(publicKey)
 	if err != nil {
-		return nil, err
+		return ErrInvalidPublicKey
+	}
+	if _, err := api.service.blocking.Block(publicKey.String()); err != nil {
+		return err
 	}
+	return api.service.inbox.Drop(key)
+}
 
-	var keys []*ecdsa.PublicKey
+// UnblockContact unblocks an identity. The messages received while it was blocked are not recovered.
+func (api *PublicAPI) UnblockContact(publicKey hexutil.Bytes) error {
+	if api.service.blocking == nil {
+		return errProtocolNotInitialized
+	}
+	if _, err := crypto.UnmarshalPubkey(publicKey); err != nil {
+		return ErrInvalidPublicKey
+	}
+	_, err := api.service.blocking.Unblock(publicKey.String())
+	return err
+}
 
-	for _, k := range msg.PubKeys {
-		publicKey, err := crypto.UnmarshalPubkey(k)
-		if err != nil {
-			return nil, err
-		}
-		keys = append(keys, publicKey)
+// GetBlockedContacts returns the hex-encoded public keys of the blocked identities.
+func (api *PublicAPI) GetBlockedContacts() ([]string, error) {
+	if api.service.blocking == nil {
+		return nil, errProtocolNotInitialized
 	}
+	return api.service.blocking.BlockedContacts()
+}
 
-	// This is transport layer-agnostic
-	protocolMessages, err := api.service.protocol.BuildDirectMessage(privateKey, msg.Payload, keys...)
-	if err != nil {
-		return nil, err
+// MuteChat mutes a chat: its messages are delivered but don't trigger notifications.
+func (api *PublicAPI) MuteChat(chatID string) error {
+	if api.service.blocking == nil {
+		return errProtocolNotInitialized
 	}
+	changed, err := api.service.blocking.Mute(chatID)
+	if changed {
+		api.service.syncChanged(devicesync.KindChat, chatID)
+	}
+	return err
+}
 
-	var response []hexutil.Bytes
+// UnmuteChat unmutes a chat.
+func (api *PublicAPI) UnmuteChat(chatID string) error {
+	if api.service.blocking == nil {
+		return errProtocolNotInitialized
+	}
+	changed, err := api.service.blocking.Unmute(chatID)
+	if changed {
+		api.service.syncChanged(devicesync.KindChat, chatID)
+	}
+	return err
+}
 
-	for key, message := range protocolMessages {
-		directMessage := chat.SendDirectMessageRPC{
+// GetMutedChats returns the IDs of the muted chats.
+func (api *PublicAPI) GetMutedChats() ([]string, error) {
+	if api.service.blocking == nil {
+		return nil, errProtocolNotInitialized
+	}
+	return api.service.blocking.MutedChats()
+}
+
+// GetDowngrades returns the messages a contact who used PFS sent without PFS, which
+// happens if it reinstalled the application or if an attacker tries to downgrade the encryption.
+func (api *PublicAPI) GetDowngrades(publicKey hexutil.Bytes) ([]downgrade.Event, error) {
+	if api.service.downgrades == nil {
+		return nil, errProtocolNotInitialized
+	}
+	if _, err := crypto.UnmarshalPubkey(publicKey); err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	return api.service.downgrades.Downgrades(publicKey.String())
+}
+
+// SetSetting sets a setting of the account to a JSON value. Settings are stored in the
+// encrypted chat database, with the rest of the data of the account.
+func (api *PublicAPI) SetSetting(key string, value json.RawMessage) error {
+	if api.service.settings == nil {
+		return errProtocolNotInitialized
+	}
+	return api.service.settings.Set(key, value)
+}
+
+// GetSetting returns the value of a setting of the account, or null if it's not set.
+func (api *PublicAPI) GetSetting(key string) (json.RawMessage, error) {
+	if api.service.settings == nil {
+		return nil, errProtocolNotInitialized
+	}
+	return api.service.settings.Get(key)
+}
+
+// DeleteSetting deletes a setting of the account.
+func (api *PublicAPI) DeleteSetting(key string) error {
+	if api.service.settings == nil {
+		return errProtocolNotInitialized
+	}
+	return api.service.settings.Delete(key)
+}
+
+// GetSettings returns all the settings of the account, by key.
+func (api *PublicAPI) GetSettings() (map[string]json.RawMessage, error) {
+	if api.service.settings == nil {
+		return nil, errProtocolNotInitialized
+	}
+	return api.service.settings.All()
+}
+
+// GetMigrationStatus returns the applied and the pending migrations of the schema of the
+// chat database of the selected account, which holds its keys, messages, wallet data and
+// settings.
+func (api *PublicAPI) GetMigrationStatus() ([]schema.Status, error) {
+	if api.service.persistence == nil {
+		return nil, errProtocolNotInitialized
+	}
+	return api.service.schema.Status()
+}
+
+// Migrate applies the pending migrations of a database, or returns them if options.DryRun
+// is set. A failed migration is rolled back.
+func (api *PublicAPI) Migrate(database string, options schema.Options) (*schema.Result, error) {
+	if api.service.persistence == nil {
+		return nil, errProtocolNotInitialized
+	}
+	return api.service.schema.Migrate(database, options)
+}
+
+// RollbackMigration rolls back the last applied migration of a database. The services of
+// the account expect the latest schema, so the account must be selected again afterwards.
+func (api *PublicAPI) RollbackMigration(database string) (*schema.Result, error) {
+	if api.service.persistence == nil {
+		return nil, errProtocolNotInitialized
+	}
+	return api.service.schema.Rollback(database)
+}
+
+// GetMessageState returns the state of a message after the reactions, edits and
+// deletions applied to it. The ID of a message is the keccak256 hash of the public
+// key of its author followed by its payload.
+func (api *PublicAPI) GetMessageState(id string) (content.State, error) {
+	if api.service.content == nil {
+		return content.State{}, errProtocolNotInitialized
+	}
+	return api.service.content.State(id)
+}
+
+// UploadedAttachment is the response of UploadAttachment.
+type UploadedAttachment struct {
+	Reference attachments.Reference `json:"reference"`
+	// Payload is the payload of the message sharing the attachment.
+	Payload hexutil.Bytes `json:"payload"`
+}
+
+// UploadAttachment encrypts an attachment with a new random key and uploads it to the
+// storage backend. It returns the payload of the message sharing it, which embeds the
+// location of the encrypted attachment, its key and its hash.
+func (api *PublicAPI) UploadAttachment(ctx context.Context, data hexutil.Bytes, contentType string) (*UploadedAttachment, error) {
+	if api.service.attachments == nil {
+		return nil, ErrAttachmentsDisabled
+	}
+
+	ref, err := api.service.attachments.Upload(ctx, data, contentType)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := attachments.Encode(ref)
+	if err != nil {
+		return nil, err
+	}
+	return &UploadedAttachment{Reference: ref, Payload: payload}, nil
+}
+
+// GetAttachment returns the state of an attachment uploaded or received, or nil if it's unknown.
+func (api *PublicAPI) GetAttachment(hash hexutil.Bytes) (*attachments.Attachment, error) {
+	if api.service.attachments == nil {
+		return nil, ErrAttachmentsDisabled
+	}
+
+	return api.service.attachments.Attachment(hash)
+}
+
+// FetchAttachment returns an attachment uploaded or received, downloading, decrypting
+// and verifying it if it's not cached.
+func (api *PublicAPI) FetchAttachment(ctx context.Context, hash hexutil.Bytes) (hexutil.Bytes, error) {
+	if api.service.attachments == nil {
+		return nil, ErrAttachmentsDisabled
+	}
+
+	return api.service.attachments.Fetch(ctx, hash)
+}
+
+// GetAttachmentURL returns the URL of an attachment on the media server, which streams
+// it to the client, downloading, decrypting and verifying it if it's not cached. The URL
+// expires after the TTL of the media server, or when the account is logged out.
+func (api *PublicAPI) GetAttachmentURL(hash hexutil.Bytes) (string, error) {
+	if api.service.attachments == nil {
+		return "", ErrAttachmentsDisabled
+	}
+	if api.service.media == nil {
+		return "", ErrMediaServerDisabled
+	}
+
+	attachment, err := api.service.attachments.Attachment(hash)
+	if err != nil {
+		return "", err
+	}
+	if attachment == nil {
+		return "", attachments.ErrUnknownAttachment
+	}
+	return api.service.media.URL(hash)
+}
+
+// SetNotificationPreferences changes the notification preferences of the account and
+// syncs them with its paired devices. It returns the preferences with their new clock.
+func (api *PublicAPI) SetNotificationPreferences(ctx context.Context, sig string, preferences notifications.Preferences) (notifications.Preferences, error) {
+	if api.service.notifications == nil {
+		return notifications.Preferences{}, errProtocolNotInitialized
+	}
+
+	privateKey, err := api.service.w.GetPrivateKey(sig)
+	if err != nil {
+		return notifications.Preferences{}, err
+	}
+
+	clock := uint64(api.service.w.GetCurrentTime().UnixNano() / int64(time.Millisecond))
+	preferences, err = api.service.notifications.Set(preferences, clock)
+	if err != nil {
+		return notifications.Preferences{}, err
+	}
+	return api.syncNotificationPreferences(ctx, sig, privateKey, preferences)
+}
+
+// syncNotificationPreferences sends the preferences of the account to its paired devices.
+func (api *PublicAPI) syncNotificationPreferences(ctx context.Context, sig string, privateKey *ecdsa.PrivateKey, preferences notifications.Preferences) (notifications.Preferences, error) {
+	encoded, err := notifications.Encode(preferences)
+	if err != nil {
+		return notifications.Preferences{}, err
+	}
+	protocolMessage, err := api.service.protocol.BuildNotificationPreferencesMessage(privateKey, encoded)
+	if err != nil {
+		return notifications.Preferences{}, err
+	}
+	if protocolMessage == nil {
+		return preferences, nil
+	}
+
+	if err := api.postSyncMessage(ctx, sig, privateKey, protocolMessage); err != nil {
+		return notifications.Preferences{}, err
+	}
+	return preferences, nil
+}
+
+// SyncHistory sends the messages of the history, the contacts and the metadata of the chats
+// changed after since to the paired devices of the account, so that a newly paired device gets
+// the conversations it missed. All the records are sent if since is 0. It returns the number
+// of records sent, which is 0 if the account has no paired devices.
+func (api *PublicAPI) SyncHistory(ctx context.Context, sig string, since uint64) (int, error) {
+	if api.service.devicesync == nil {
+		return 0, errProtocolNotInitialized
+	}
+	if !api.service.syncEnabled() {
+		return 0, ErrSyncDisabled
+	}
+
+	privateKey, err := api.service.w.GetPrivateKey(sig)
+	if err != nil {
+		return 0, err
+	}
+
+	records, err := api.service.syncRecords(since)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, batch := range devicesync.Split(records, devicesync.DefaultBatchSize) {
+		encoded, err := devicesync.Encode(batch)
+		if err != nil {
+			return sent, err
+		}
+		protocolMessage, err := api.service.protocol.BuildHistorySyncMessage(privateKey, encoded)
+		if err != nil {
+			return sent, err
+		}
+		if protocolMessage == nil {
+			return 0, nil
+		}
+		if err := api.postSyncMessage(ctx, sig, privateKey, protocolMessage); err != nil {
+			return sent, err
+		}
+		sent += len(batch)
+	}
+	return sent, nil
+}
+
+// postSyncMessage posts a message built for the paired devices of the account.
+func (api *PublicAPI) postSyncMessage(ctx context.Context, sig string, privateKey *ecdsa.PrivateKey, protocolMessage []byte) error {
+	_, err := api.postSegmented(ratelimit.WithClass(ctx, ratelimit.ClassSync), api.syncMessageToWhisper(sig, privateKey, protocolMessage))
+	return err
+}
+
+// syncMessageToWhisper returns the whisper message of a message built for the paired devices
+// of the account.
+func (api *PublicAPI) syncMessageToWhisper(sig string, privateKey *ecdsa.PrivateKey, protocolMessage []byte) whisper.NewMessage {
+	whisperMessage := chat.DirectMessageToWhisper(chat.SendDirectMessageRPC{
+		Sig:    sig,
+		PubKey: crypto.FromECDSAPub(&privateKey.PublicKey),
+	}, protocolMessage)
+	whisperMessage.Topic = api.service.protocol.DirectMessageTopic(&privateKey.PublicKey)
+	return whisperMessage
+}
+
+// WipeInstallation sends a command wiping a paired installation of the account, such as a
+// lost device. The installation deletes the keys and the data of the account, then confirms
+// it with a final acknowledgment, signaled with wipe.confirmed, before disabling itself. It's
+// disabled right away, so that messages are no longer encrypted for it. password must be the
+// password of the account.
+func (api *PublicAPI) WipeInstallation(ctx context.Context, sig string, installationID string, password string) (*wipe.Command, error) {
+	if api.service.wipes == nil {
+		return nil, errProtocolNotInitialized
+	}
+	if err := api.service.checkPassword(password); err != nil {
+		return nil, err
+	}
+
+	privateKey, err := api.service.w.GetPrivateKey(sig)
+	if err != nil {
+		return nil, err
+	}
+	installations, err := api.service.protocol.Installations(&privateKey.PublicKey, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if !containsString(installations, installationID) {
+		return nil, ErrInstallationNotPaired
+	}
+
+	command, err := api.service.wipes.Issue(privateKey, api.service.installationID, installationID)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := wipe.Encode(wipe.Message{Command: command})
+	if err != nil {
+		return nil, err
+	}
+	protocolMessage, err := api.service.protocol.BuildWipeMessage(privateKey, payload)
+	if err != nil {
+		return nil, err
+	}
+	if protocolMessage == nil {
+		return nil, ErrInstallationNotPaired
+	}
+	if err := api.postSyncMessage(ctx, sig, privateKey, protocolMessage); err != nil {
+		return nil, err
+	}
+	if err := api.service.protocol.DisableInstallation(&privateKey.PublicKey, installationID); err != nil {
+		return nil, err
+	}
+	return command, nil
+}
+
+// GetWipes returns the wipes issued by the installation, from the most recent, with whether
+// the wiped installations confirmed them.
+func (api *PublicAPI) GetWipes() ([]wipe.Wipe, error) {
+	if api.service.wipes == nil {
+		return nil, errProtocolNotInitialized
+	}
+
+	return api.service.wipes.Wipes()
+}
+
+// executePendingWipe wipes the installation if another installation of the account sent a
+// command wiping it. The data of the account is deleted first, then the acknowledgment is
+// posted, as it needs the identity of the account, and the keys are deleted last. It returns
+// true if the installation was wiped.
+func (api *PublicAPI) executePendingWipe(ctx context.Context) (bool, error) {
+	command := api.service.takePendingWipe()
+	if command == nil {
+		return false, nil
+	}
+
+	sig := api.service.SelectedKeyPairID()
+	privateKey, err := api.service.w.GetPrivateKey(sig)
+	if err != nil {
+		return false, err
+	}
+	ack, err := wipe.Acknowledge(privateKey, *command, api.service.w.GetCurrentTime())
+	if err != nil {
+		return false, err
+	}
+	payload, err := wipe.Encode(wipe.Message{Ack: ack})
+	if err != nil {
+		return false, err
+	}
+	protocolMessage, err := api.service.protocol.BuildWipeMessage(privateKey, payload)
+	if err != nil {
+		return false, err
+	}
+	var whisperMessage *whisper.NewMessage
+	if protocolMessage != nil {
+		m := api.syncMessageToWhisper(sig, privateKey, protocolMessage)
+		whisperMessage = &m
+	}
+
+	if err := api.service.wipeData(); err != nil {
+		return true, err
+	}
+	if whisperMessage != nil {
+		if _, err := api.postSegmented(ratelimit.WithClass(ctx, ratelimit.ClassSync), *whisperMessage); err != nil {
+			reporter.ErrorContext(ctx, "acknowledge-wipe", err)
+		}
+	}
+	return true, api.service.wipeKeys(*command)
+}
+
+// GetNotificationPreferences returns the notification preferences of the account.
+func (api *PublicAPI) GetNotificationPreferences() (notifications.Preferences, error) {
+	if api.service.notifications == nil {
+		return notifications.Preferences{}, errProtocolNotInitialized
+	}
+
+	return api.service.notifications.Preferences()
+}
+
+// GetChatNotificationPreferences returns the notification preferences applied to a chat,
+// taking its overrides into account.
+func (api *PublicAPI) GetChatNotificationPreferences(chatID string) (notifications.Chat, error) {
+	if api.service.notifications == nil {
+		return notifications.Chat{}, errProtocolNotInitialized
+	}
+
+	return api.service.notifications.ForChat(chatID)
+}
+
+// GetIdentity returns the visual identity of a public key, the color of its avatar and
+// of the segments of its ring, derived with the algorithm of the given version, or the latest
+// one if version is 0. Identities are derived by the node so that all clients render them identically.
+func (api *PublicAPI) GetIdentity(publicKey hexutil.Bytes, version int) (identity.Identity, error) {
+	key, err := crypto.UnmarshalPubkey(publicKey)
+	if err != nil {
+		return identity.Identity{}, ErrInvalidPublicKey
+	}
+	return api.service.identities.Derive(key, version)
+}
+
+// SetChatModerator designates the moderator of a public chat. Messages of authors not
+// allowed by the lists it publishes are flagged or hidden, depending on the mode.
+// An empty moderator disables moderation.
+func (api *PublicAPI) SetChatModerator(chatID string, moderator hexutil.Bytes, mode moderation.Mode) error {
+	if api.service.moderator == nil {
+		return errProtocolNotInitialized
+	}
+
+	var key string
+	if len(moderator) > 0 {
+		if _, err := crypto.UnmarshalPubkey(moderator); err != nil {
+			return ErrInvalidPublicKey
+		}
+		key = hexutil.Encode(moderator)
+	}
+
+	return api.service.moderator.SetModerator(chatID, chat.ChatTopic(chatID), key, mode)
+}
+
+// GetChatModeration returns the moderation state of a public chat, or nil if it's not moderated.
+func (api *PublicAPI) GetChatModeration(chatID string) (*moderation.Channel, error) {
+	if api.service.moderator == nil {
+		return nil, errProtocolNotInitialized
+	}
+
+	return api.service.moderator.Channel(chat.ChatTopic(chatID))
+}
+
+// PublishModerationList signs an allowlist and a denylist with the key of the moderator
+// and publishes them in a public chat.
+func (api *PublicAPI) PublishModerationList(ctx context.Context, msg chat.SendModerationListRPC) (hexutil.Bytes, error) {
+	if api.service.protocol == nil {
+		return nil, errProtocolNotInitialized
+	}
+
+	privateKey, err := api.service.w.GetPrivateKey(msg.Sig)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &moderation.List{
+		ChatID: msg.Chat,
+		Clock:  uint64(api.service.w.GetCurrentTime().UnixNano() / int64(time.Millisecond)),
+	}
+	for _, key := range msg.Allow {
+		list.Allow = append(list.Allow, hexutil.Encode(key))
+	}
+	for _, key := range msg.Deny {
+		list.Deny = append(list.Deny, hexutil.Encode(key))
+	}
+	if err := list.Sign(privateKey); err != nil {
+		return nil, err
+	}
+
+	payload, err := moderation.Encode(list)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.SendPublicMessage(ctx, chat.SendPublicMessageRPC{
+		Sig:     msg.Sig,
+		Chat:    msg.Chat,
+		Payload: payload,
+	})
+}
+
+// MarkGroupMessageRead records that a member of a group chat read a message.
+// The member is identified by its hex-encoded public key.
+func (api *PublicAPI) MarkGroupMessageRead(messageID string, member string) error {
+	aggregator := api.service.receiptsAggregator()
+	if aggregator == nil {
+		return errProtocolNotInitialized
+	}
+
+	return aggregator.MessageRead(messageID, member)
+}
+
+// GetReceiptSummary returns the aggregated delivered and read state of a group message.
+func (api *PublicAPI) GetReceiptSummary(messageID string) (*receipts.Summary, error) {
+	aggregator := api.service.receiptsAggregator()
+	if aggregator == nil {
+		return nil, errProtocolNotInitialized
+	}
+
+	return aggregator.Summary(messageID)
+}
+
+// SendReadReceipt sends a read receipt of messages to their author, unless read receipts
+// are disabled for the contact, in which case no hashes are returned.
+func (api *PublicAPI) SendReadReceipt(ctx context.Context, msg chat.SendReadReceiptRPC) ([]hexutil.Bytes, error) {
+	if api.service.presence == nil {
+		return nil, errProtocolNotInitialized
+	}
+	if len(msg.MessageIDs) == 0 {
+		return nil, nil
+	}
+	allowed, err := api.service.presence.AllowReadReceipt(hexutil.Encode(msg.PubKey))
+	if err != nil || !allowed {
+		return nil, err
+	}
+	payload, err := proto.Marshal(&chat.ChatMessagePayload{
+		Kind:       chat.ChatMessagePayload_READ,
+		ReadIds:    msg.MessageIDs,
+		ClockValue: float64(api.service.w.GetCurrentTime().UnixNano() / int64(time.Millisecond)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return api.sendPresence(ctx, chat.SendDirectMessageRPC{Sig: msg.Sig, Chat: msg.Chat, PubKey: msg.PubKey}, payload, false)
+}
+
+// SendTyping notifies a contact that we started or stopped typing. Notifications are
+// neither persisted nor retried, and those that we started typing are rate limited.
+// No hashes are returned if the notification was not sent.
+func (api *PublicAPI) SendTyping(ctx context.Context, msg chat.SendTypingRPC) ([]hexutil.Bytes, error) {
+	if api.service.presence == nil {
+		return nil, errProtocolNotInitialized
+	}
+	allowed, err := api.service.presence.AllowTyping(hexutil.Encode(msg.PubKey), msg.Typing, api.service.w.GetCurrentTime())
+	if err != nil || !allowed {
+		return nil, err
+	}
+	payload, err := proto.Marshal(&chat.ChatMessagePayload{
+		Kind:       chat.ChatMessagePayload_TYPING,
+		Typing:     msg.Typing,
+		ClockValue: float64(api.service.w.GetCurrentTime().UnixNano() / int64(time.Millisecond)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return api.sendPresence(ctx, chat.SendDirectMessageRPC{Sig: msg.Sig, Chat: msg.Chat, PubKey: msg.PubKey}, payload, true)
+}
+
+// SetPresenceSettings changes the privacy settings of read receipts and typing notifications.
+func (api *PublicAPI) SetPresenceSettings(settings presence.Settings) error {
+	if api.service.presence == nil {
+		return errProtocolNotInitialized
+	}
+
+	return api.service.presence.SetSettings(settings)
+}
+
+// GetPresenceSettings returns the privacy settings of read receipts and typing notifications.
+func (api *PublicAPI) GetPresenceSettings() (presence.Settings, error) {
+	if api.service.presence == nil {
+		return presence.Settings{}, errProtocolNotInitialized
+	}
+
+	return api.service.presence.Settings()
+}
+
+// GetReadReceipts returns the read receipts received for a message.
+func (api *PublicAPI) GetReadReceipts(messageID string) ([]presence.Receipt, error) {
+	if api.service.presence == nil {
+		return nil, errProtocolNotInitialized
+	}
+
+	return api.service.presence.ReadReceipts(messageID)
+}
+
+// GetMessageStatus returns the delivery states of envelopes posted by this node,
+// in the same order as hashes. States are persisted and survive restarts.
+func (api *PublicAPI) GetMessageStatus(hashes []common.Hash) ([]delivery.Status, error) {
+	return api.service.tracker.delivery.Statuses(hashes)
+}
+
+// GetMailserverRanking returns the selected MailServers ranked by latency and
+// availability, best first.
+func (api *PublicAPI) GetMailserverRanking() ([]mailservers.Rank, error) {
+	if api.service.picker == nil {
+		return nil, ErrMailServerPickerDisabled
+	}
+	return api.service.picker.Ranking(), nil
+}
+
+// GetMailserverTrust returns the trust scores of the MailServers, lowered by the
+// envelopes they returned that didn't match the requests sent to them, most trusted first.
+func (api *PublicAPI) GetMailserverTrust() ([]trust.Score, error) {
+	if api.service.config.ResponseValidator == nil {
+		return nil, ErrResponseValidationDisabled
+	}
+	return api.service.config.ResponseValidator.Scores()
+}
+
+// SetActiveChats advertises to peers a bloom filter that matches only the topics of
+// the given chats and the topics used by the protocol, so that envelopes of other
+// chats are not sent to this node. It replaces the chats set previously.
+func (api *PublicAPI) SetActiveChats(chatIDs []string) error {
+	if api.service.bloomFilter == nil {
+		return ErrBloomFilterNegotiationDisabled
+	}
+	topics := make([]whisper.TopicType, len(chatIDs))
+	for i, chatID := range chatIDs {
+		topics[i] = chat.ChatTopic(chatID)
+	}
+	if api.service.config.PartitionedTopic {
+		if privateKey, err := api.service.selectedIdentity(); err == nil {
+			topics = append(topics, chat.PartitionedTopic(&privateKey.PublicKey))
+		}
+	}
+	return api.service.bloomFilter.SetTopics(topics)
+}
+
+// GetPartitionedTopic returns the topic on which the owner of the public key receives
+// direct messages once its contacts negotiated it. Clients install a filter on it in
+// addition to the discovery topic.
+func (api *PublicAPI) GetPartitionedTopic(publicKey hexutil.Bytes) (whisper.TopicType, error) {
+	key, err := crypto.UnmarshalPubkey(publicKey)
+	if err != nil {
+		return whisper.TopicType{}, ErrInvalidPublicKey
+	}
+	return chat.PartitionedTopic(key), nil
+}
+
+// GetBloomFilter returns the bloom filter currently advertised to peers.
+func (api *PublicAPI) GetBloomFilter() bloom.Status {
+	if api.service.bloomFilter == nil {
+		return bloom.StatusOf(api.service.w)
+	}
+	return api.service.bloomFilter.Status()
+}
+
+// SetContactMailServer records the MailServer used by a contact. Messages sent to
+// the contact while connected to the same trusted MailServer are reported as sent
+// only once the MailServer confirms that they were archived. An empty enode
+// removes it.
+func (api *PublicAPI) SetContactMailServer(publicKey hexutil.Bytes, enode string) error {
+	if _, err := crypto.UnmarshalPubkey(publicKey); err != nil {
+		return ErrInvalidPublicKey
+	}
+	return api.service.archival.SetContactMailServer(publicKey, enode)
+}
+
+// watchArchival confirms the archival of an envelope sent to a contact that uses
+// one of our trusted MailServers.
+func (api *PublicAPI) watchArchival(hash hexutil.Bytes, publicKey []byte, topic whisper.TopicType) {
+	node, err := api.service.archival.ContactMailServer(publicKey)
+	if err != nil {
+		api.log.Error("failed to get contact mail server", "err", err)
+		return
+	}
+	if node == nil || !api.service.peerStore.Exist(node.ID()) {
+		return
+	}
+	now := api.service.w.GetCurrentTime()
+	api.service.archival.Watch(common.BytesToHash(hash), node, topic, uint32(now.Unix()))
+}
+
+// LookupBundle queries the network, or the configured directory nodes, for the
+// bundle of a public key that never sent us a message. The installations of the
+// bundle are added, so that direct messages can be encrypted for them.
+func (api *PublicAPI) LookupBundle(ctx context.Context, publicKey hexutil.Bytes) ([]chat.IdentityAndIDPair, error) {
+	if !api.service.pfsEnabled || api.service.bundleLookups == nil {
+		return nil, ErrPFSNotEnabled
+	}
+	identity, err := crypto.UnmarshalPubkey(publicKey)
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	privateKey, err := api.service.selectedIdentity()
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := api.service.bundleLookups.resolver.Lookup(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	return api.service.ProcessPublicBundle(privateKey, bundle)
+}
+
+// PublishBundle sends the bundle of the selected account to the directory nodes,
+// which return it in the responses to lookups.
+func (api *PublicAPI) PublishBundle() error {
+	if !api.service.pfsEnabled || api.service.bundleLookups == nil {
+		return ErrPFSNotEnabled
+	}
+	privateKey, err := api.service.selectedIdentity()
+	if err != nil {
+		return err
+	}
+	bundle, err := api.service.GetBundle(privateKey)
+	if err != nil {
+		return err
+	}
+	return api.service.bundleLookups.resolver.Publish(bundle)
+}
+
+// ConfirmMessagesProcessed is a method to confirm that messages was consumed by
+// the client side.
+func (api *PublicAPI) ConfirmMessagesProcessed(messages []*whisper.Message) error {
+	return api.service.deduplicator.AddMessages(messages)
+}
+
+// GetOutgoingTraffic returns the envelopes sent and refused by the outgoing rate limits,
+// by class of traffic.
+func (api *PublicAPI) GetOutgoingTraffic() []ratelimit.ClassStats {
+	return api.service.outgoing.Stats()
+}
+
+// EstimateMessage returns the expected number and size of the envelopes posted to send a
+// payload with the key pair sig, and the time spent computing their proof of work, without
+// sending it. The payload is sent to a public chat if there are no recipients, and as a direct
+// or group message otherwise, in which case its fan-out to each recipient is returned.
+func (api *PublicAPI) EstimateMessage(sig string, payload hexutil.Bytes, recipients []hexutil.Bytes) (estimate.Estimate, error) {
+	if api.service.protocol == nil {
+		return estimate.Estimate{}, errProtocolNotInitialized
+	}
+	privateKey, err := api.service.w.GetPrivateKey(sig)
+	if err != nil {
+		return estimate.Estimate{}, err
+	}
+
+	var e estimate.Estimate
+	config := api.service.estimateConfig()
+	if len(recipients) == 0 {
+		protocolMessage, err := api.service.protocol.BuildPublicMessage(privateKey, payload)
+		if err != nil {
+			return estimate.Estimate{}, err
+		}
+		return e, config.AddPublic(&e, len(protocolMessage))
+	}
+
+	if !api.service.pfsEnabled {
+		return estimate.Estimate{}, ErrPFSNotEnabled
+	}
+	for _, recipient := range recipients {
+		publicKey, err := crypto.UnmarshalPubkey(recipient)
+		if err != nil {
+			return estimate.Estimate{}, ErrInvalidPublicKey
+		}
+		size, installations, err := api.service.protocol.EstimateDirectMessage(privateKey, payload, publicKey)
+		if err != nil {
+			return estimate.Estimate{}, err
+		}
+		if err := config.AddRecipient(&e, recipient.String(), installations, size); err != nil {
+			return estimate.Estimate{}, err
+		}
+	}
+	return e, nil
+}
+
+// SendPublicMessage sends a public chat message to the underlying transport. If the
+// payload is split into several envelopes, the hash of the first one is returned.
+func (api *PublicAPI) SendPublicMessage(ctx context.Context, msg chat.SendPublicMessageRPC) (hexutil.Bytes, error) {
+	privateKey, err := api.service.w.GetPrivateKey(msg.Sig)
+	if err != nil {
+		return nil, err
+	}
+
+	// This is transport layer agnostic
+	protocolMessage, err := api.service.protocol.BuildPublicMessage(privateKey, msg.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	symKeyID, err := api.service.w.AddSymKeyFromPassword(msg.Chat)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enrich with transport layer info
+	whisperMessage := chat.PublicMessageToWhisper(msg, protocolMessage)
+	whisperMessage.SymKeyID = symKeyID
+
+	// And dispatch
+	chatID := history.PublicChatID(whisperMessage.Topic)
+	hashes, err := api.sendThroughOutbox(ctx, &privateKey.PublicKey, msg.Chat, chatID, msg.Payload, []whisper.NewMessage{whisperMessage})
+	if err != nil {
+		return nil, err
+	}
+	api.applySentContent(&privateKey.PublicKey, chatID, msg.Payload)
+	return hashes[0][0], nil
+}
+
+// SendDirectMessage sends a 1:1 chat message to the underlying transport
+func (api *PublicAPI) SendDirectMessage(ctx context.Context, msg chat.SendDirectMessageRPC) ([]hexutil.Bytes, error) {
+	if !api.service.pfsEnabled {
+		return nil, ErrPFSNotEnabled
+	}
+	// To be completely agnostic from whisper we should not be using whisper to store the key
+	privateKey, err := api.service.w.GetPrivateKey(msg.Sig)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := crypto.UnmarshalPubkey(msg.PubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// This is transport layer-agnostic
+	protocolMessages, err := api.service.protocol.BuildDirectMessage(privateKey, msg.Payload, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	contact := msg.PubKey
+	whisperMessages := make([]whisper.NewMessage, 0, len(protocolMessages))
+	for key, message := range protocolMessages {
+		msg.PubKey = crypto.FromECDSAPub(key)
+		// Enrich with transport layer info
+		whisperMessage := chat.DirectMessageToWhisper(msg, message)
+		if msg.Chat == "" {
+			whisperMessage.Topic = api.service.protocol.DirectMessageTopic(key)
+		}
+		whisperMessages = append(whisperMessages, whisperMessage)
+	}
+
+	// And dispatch
+	chatID := history.DirectChatID(contact)
+	hashes, err := api.sendThroughOutbox(ctx, &privateKey.PublicKey, "", chatID, msg.Payload, whisperMessages)
+	if err != nil {
+		return nil, err
+	}
+
+	var response []hexutil.Bytes
+	for i, whisperMessage := range whisperMessages {
+		for _, hash := range hashes[i] {
+			api.watchArchival(hash, whisperMessage.PublicKey, whisperMessage.Topic)
+		}
+		response = append(response, hashes[i]...)
+	}
+	api.service.acceptContact(contact)
+	api.applySentContent(&privateKey.PublicKey, chatID, msg.Payload)
+	api.service.notifyPush(privateKey, publicKey)
+	return response, nil
+}
+
+// SendPairingMessage sends a 1:1 chat message to our own devices to initiate a pairing session
+func (api *PublicAPI) SendPairingMessage(ctx context.Context, msg chat.SendDirectMessageRPC) ([]hexutil.Bytes, error) {
+	if !api.service.pfsEnabled {
+		return nil, ErrPFSNotEnabled
+	}
+	// To be completely agnostic from whisper we should not be using whisper to store the key
+	privateKey, err := api.service.w.GetPrivateKey(msg.Sig)
+	if err != nil {
+		return nil, err
+	}
+
+	msg.PubKey = crypto.FromECDSAPub(&privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	protocolMessage, err := api.service.protocol.BuildPairingMessage(privateKey, msg.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var response []hexutil.Bytes
+
+	// Enrich with transport layer info
+	whisperMessage := chat.DirectMessageToWhisper(msg, protocolMessage)
+	if msg.Chat == "" {
+		whisperMessage.Topic = api.service.protocol.DirectMessageTopic(&privateKey.PublicKey)
+	}
+
+	// And dispatch
+	hashes, err := api.postSegmented(ctx, whisperMessage)
+	if err != nil {
+		return nil, err
+	}
+	response = append(response, hashes...)
+
+	return response, nil
+}
+
+// SendGroupMessage sends a group messag chat message to the underlying transport
+func (api *PublicAPI) SendGroupMessage(ctx context.Context, msg chat.SendGroupMessageRPC) ([]hexutil.Bytes, error) {
+	if !api.service.pfsEnabled {
+		return nil, ErrPFSNotEnabled
+	}
+
+	// To be completely agnostic from whisper we should not be using whisper to store the key
+	privateKey, err := api.service.w.GetPrivateKey(msg.Sig)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*ecdsa.PublicKey
+
+	for _, k := range msg.PubKeys {
+		publicKey, err := crypto.UnmarshalPubkey(k)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, publicKey)
+	}
+
+	// This is transport layer-agnostic
+	protocolMessages, err := api.service.protocol.BuildDirectMessage(privateKey, msg.Payload, keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	var response []hexutil.Bytes
+	envelopes := make(map[common.Hash]string)
+
+	for key, message := range protocolMessages {
+		directMessage := chat.SendDirectMessageRPC{
 			PubKey:  crypto.FromECDSAPub(key),
 			Payload: msg.Payload,
 			Sig:     msg.Sig,
@@ -475,42 +1890,335 @@ func (api *PublicAPI) SendGroupMessage(ctx context.Context, msg chat.SendGroupMe
 
 		// Enrich with transport layer info
 		whisperMessage := chat.DirectMessageToWhisper(directMessage, message)
+		whisperMessage.Topic = api.service.protocol.DirectMessageTopic(key)
 
 		// And dispatch
-		hash, err := api.Post(ctx, whisperMessage)
+		hashes, err := api.postSegmented(ctx, whisperMessage)
 		if err != nil {
 			return nil, err
 		}
-		response = append(response, hash)
+		for _, hash := range hashes {
+			api.watchArchival(hash, directMessage.PubKey, whisperMessage.Topic)
+			envelopes[common.BytesToHash(hash)] = hexutil.Encode(crypto.FromECDSAPub(key))
+		}
+		response = append(response, hashes...)
+	}
 
+	if aggregator := api.service.receiptsAggregator(); msg.ID != "" && aggregator != nil {
+		if err := aggregator.Track(msg.ID, envelopes); err != nil {
+			return nil, err
+		}
 	}
+	for _, member := range msg.PubKeys {
+		api.service.acceptContact(member)
+	}
+	// Group chats are not identified by the protocol, so group messages are not added to the history.
+	api.applySentContent(&privateKey.PublicKey, "", msg.Payload)
 	return response, nil
 }
 
-func (api *PublicAPI) processPFSMessage(msg *whisper.Message) error {
-	var privateKey *ecdsa.PrivateKey
-	var publicKey *ecdsa.PublicKey
+// reassemble replaces the payload of the last segment of a payload with the whole
+// payload. It returns false if the message is a segment of an incomplete payload. The
+// segments are only reassembled with those signed by the same key, so the message is
+// attributed to the sender of all of them.
+func (api *PublicAPI) reassemble(msg *whisper.Message) bool {
+	if !segmentation.IsSegment(msg.Payload) {
+		return true
+	}
+	whole, err := api.service.segments.Handle(msg.Sig, msg.Payload)
+	if err != nil {
+		api.log.Error("Failed to reassemble segments", "hash", msg.Hash, "err", err)
+		return false
+	}
+	if whole == nil {
+		return false
+	}
+	msg.Payload = whole
+	return true
+}
+
+// sendPresence sends a read receipt or a typing notification to the devices of a contact.
+// Ephemeral messages have a short TTL and are neither tracked nor retried.
+func (api *PublicAPI) sendPresence(ctx context.Context, msg chat.SendDirectMessageRPC, payload []byte, ephemeral bool) ([]hexutil.Bytes, error) {
+	ctx = ratelimit.WithClass(ctx, ratelimit.ClassReceipts)
+	privateKey, err := api.service.w.GetPrivateKey(msg.Sig)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := crypto.UnmarshalPubkey(msg.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	protocolMessages, err := api.service.protocol.BuildDirectMessage(privateKey, payload, publicKey)
+	if err != nil {
+		return nil, err
+	}
 
-	// Msg.Dst is empty is a public message, nothing to do
-	if msg.Dst != nil {
-		// There's probably a better way to do this
-		keyBytes, err := hexutil.Bytes(msg.Dst).MarshalText()
+	var response []hexutil.Bytes
+	for key, message := range protocolMessages {
+		msg.PubKey = crypto.FromECDSAPub(key)
+		whisperMessage := chat.DirectMessageToWhisper(msg, message)
+		if msg.Chat == "" {
+			whisperMessage.Topic = api.service.protocol.DirectMessageTopic(key)
+		}
+		if !ephemeral {
+			hashes, err := api.postSegmented(ctx, whisperMessage)
+			if err != nil {
+				return nil, err
+			}
+			response = append(response, hashes...)
+			continue
+		}
+		whisperMessage.TTL = typingTTL
+		if err := api.service.outgoing.Wait(ctx, ratelimit.ClassReceipts); err != nil {
+			return nil, err
+		}
+		hash, err := api.publicAPI.Post(ctx, api.service.adaptPoW(whisperMessage))
 		if err != nil {
-			return err
+			return nil, err
 		}
+		response = append(response, hash)
+	}
+	return response, nil
+}
 
-		privateKey, err = api.service.w.GetPrivateKey(string(keyBytes))
+// postSegmented posts a message, split into several envelopes if its payload is too
+// large for one. It returns the hashes of the envelopes.
+func (api *PublicAPI) postSegmented(ctx context.Context, msg whisper.NewMessage) ([]hexutil.Bytes, error) {
+	segments, err := segmentation.Split(msg.Payload, api.service.segmentSize())
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]hexutil.Bytes, 0, len(segments))
+	for _, segment := range segments {
+		msg.Payload = segment
+		hash, err := api.Post(ctx, msg)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
 
-		// This needs to be pushed down in the protocol message
-		publicKey, err = crypto.UnmarshalPubkey(msg.Sig)
-		if err != nil {
-			return err
+// receiveAttachments downloads the attachments shared in received messages.
+func (api *PublicAPI) receiveAttachments(messages []*whisper.Message) {
+	if api.service.attachments == nil {
+		return
+	}
+	for _, msg := range messages {
+		ref, ok := attachments.Decode(msg.Payload)
+		if !ok {
+			continue
+		}
+		if err := api.service.attachments.Receive(*ref); err != nil {
+			api.log.Error("Failed to receive attachment", "hash", msg.Hash, "err", err)
+		}
+	}
+}
+
+// receivePresence notifies the read receipts and typing notifications received, which
+// are dropped as clients get them through signals instead. Read receipts are also
+// recorded for the group messages whose receipts are aggregated.
+func (api *PublicAPI) receivePresence(messages []*whisper.Message) []*whisper.Message {
+	if api.service.presence == nil {
+		return messages
+	}
+
+	handler := EnvelopeSignalHandler{}
+	now := api.service.w.GetCurrentTime()
+	result := make([]*whisper.Message, 0, len(messages))
+	for _, msg := range messages {
+		var p chat.ChatMessagePayload
+		if len(msg.Sig) == 0 || proto.Unmarshal(msg.Payload, &p) != nil ||
+			(p.Kind != chat.ChatMessagePayload_READ && p.Kind != chat.ChatMessagePayload_TYPING) {
+			result = append(result, msg)
+			continue
+		}
+		author := hexutil.Encode(msg.Sig)
+		switch p.Kind {
+		case chat.ChatMessagePayload_READ:
+			if len(p.ReadIds) == 0 {
+				continue
+			}
+			if err := api.service.presence.ReceiveReadReceipt(author, p.ReadIds, uint64(p.ClockValue)); err != nil {
+				api.log.Error("Failed to save read receipt", "hash", msg.Hash, "err", err)
+				continue
+			}
+			// the aggregator ignores the receipts of authors the messages weren't sent to
+			if aggregator := api.service.receiptsAggregator(); aggregator != nil {
+				for _, id := range p.ReadIds {
+					if err := aggregator.MessageRead(id, author); err != nil {
+						api.log.Warn("Failed to update group receipts", "id", id, "err", err)
+					}
+				}
+			}
+			handler.MessagesRead(author, p.ReadIds)
+		case chat.ChatMessagePayload_TYPING:
+			if now.Sub(time.Unix(int64(msg.Timestamp), 0)) > typingTTL*time.Second {
+				continue
+			}
+			if api.service.presence.ReceiveTyping(author, p.Typing, now) {
+				handler.ContactTyping(author, p.Typing)
+			}
+		}
+	}
+	return result
+}
+
+// applyContentMessages records the authors of the chat messages received and applies
+// the reactions, edits and deletions, which are dropped as clients get the state of
+// the messages they target instead. Regular messages are added to the history.
+func (api *PublicAPI) applyContentMessages(messages []*whisper.Message) []*whisper.Message {
+	if api.service.content == nil {
+		return messages
+	}
+
+	result := make([]*whisper.Message, 0, len(messages))
+	for _, msg := range messages {
+		message, payload, ok := decodeContentMessage(msg.Sig, msg.Payload)
+		if !ok {
+			result = append(result, msg)
+			continue
+		}
+		if _, err := api.service.content.Apply(message); err != nil {
+			api.log.Warn("Ignoring content message", "hash", msg.Hash, "err", err)
+		}
+		if message.Kind == content.KindRegular {
+			chatID := history.PublicChatID(msg.Topic)
+			if msg.Dst != nil {
+				chatID = history.DirectChatID(msg.Sig)
+			}
+			api.addToHistory(message, payload, chatID, msg.Timestamp, false)
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// applySentContent applies a chat message sent by us, so that our reactions, edits and
+// deletions change the state of the messages they target like those of our contacts.
+// Regular messages are added to the history of chatID, unless it's empty.
+func (api *PublicAPI) applySentContent(author *ecdsa.PublicKey, chatID string, payload []byte) {
+	if api.service.content == nil {
+		return
+	}
+	message, p, ok := decodeContentMessage(crypto.FromECDSAPub(author), payload)
+	if !ok {
+		return
+	}
+	if _, err := api.service.content.Apply(message); err != nil {
+		api.log.Warn("Ignoring sent content message", "err", err)
+	}
+	if message.Kind == content.KindRegular && chatID != "" {
+		timestamp := uint32(api.service.w.GetCurrentTime().Unix())
+		api.addToHistory(message, p, chatID, timestamp, true)
+	}
+}
+
+// sendThroughOutbox posts the Whisper messages of a message sent by us with the outbox,
+// which adds the message to the history of chatID, if it's a regular chat message, in
+// the same transaction as the entry of the outbox. chatName is the name of the public
+// chat the messages are posted to, if any. It returns the hashes of the envelopes of
+// each Whisper message.
+func (api *PublicAPI) sendThroughOutbox(ctx context.Context, author *ecdsa.PublicKey, chatName, chatID string, payload []byte, messages []whisper.NewMessage) ([][]hexutil.Bytes, error) {
+	post := func(msg whisper.NewMessage) ([]hexutil.Bytes, error) {
+		return api.postSegmented(ctx, msg)
+	}
+	if api.service.outbox == nil {
+		hashes := make([][]hexutil.Bytes, 0, len(messages))
+		for _, msg := range messages {
+			h, err := post(msg)
+			if err != nil {
+				return nil, err
+			}
+			hashes = append(hashes, h)
+		}
+		return hashes, nil
+	}
+
+	var sent *history.Message
+	if api.service.content != nil && api.service.history != nil && chatID != "" {
+		message, p, ok := decodeContentMessage(crypto.FromECDSAPub(author), payload)
+		if ok && message.Kind == content.KindRegular {
+			m := historyMessage(message, p, chatID, uint32(api.service.w.GetCurrentTime().Unix()), true)
+			sent = &m
 		}
 	}
+	return api.service.outbox.Send(outbox.NewEntry(chatName, messages), sent, post)
+}
+
+// historyMessage returns the message stored in the history of a chat for a regular chat message.
+func historyMessage(message content.Message, payload *chat.ChatMessagePayload, chatID string, timestamp uint32, outgoing bool) history.Message {
+	return history.Message{
+		ID:          message.ID,
+		ChatID:      chatID,
+		Author:      message.Author,
+		Content:     payload.Content,
+		ContentType: payload.ContentType,
+		MessageType: payload.MessageType,
+		ReplyTo:     payload.ReplyTo,
+		Clock:       message.Clock,
+		Timestamp:   timestamp,
+		Outgoing:    outgoing,
+	}
+}
+
+// addToHistory stores a regular chat message in the history of a chat, with the edits
+// and deletions received before it. Messages already stored are left unchanged.
+func (api *PublicAPI) addToHistory(message content.Message, payload *chat.ChatMessagePayload, chatID string, timestamp uint32, outgoing bool) {
+	if api.service.history == nil {
+		return
+	}
+	err := api.service.history.Add(historyMessage(message, payload, chatID, timestamp, outgoing))
+	if err != nil {
+		reporter.Error("add-to-history", report.WithCode(err, report.CodeStorage), "id", message.ID)
+		return
+	}
+	state, err := api.service.content.State(message.ID)
+	if err == nil {
+		err = api.service.history.Apply(state)
+	}
+	if err != nil {
+		reporter.Error("update-history", report.WithCode(err, report.CodeStorage), "id", message.ID)
+	}
+}
+
+// decodeContentMessage decodes the payload of a chat message signed by author.
+// It returns false if the payload is not a chat message.
+func decodeContentMessage(author []byte, payload []byte) (content.Message, *chat.ChatMessagePayload, bool) {
+	if len(author) == 0 {
+		return content.Message{}, nil, false
+	}
+	var p chat.ChatMessagePayload
+	if err := proto.Unmarshal(payload, &p); err != nil {
+		return content.Message{}, nil, false
+	}
+	if p.Kind == chat.ChatMessagePayload_MESSAGE && p.ContentType == "" {
+		return content.Message{}, nil, false
+	}
+	if p.Kind != chat.ChatMessagePayload_MESSAGE && p.TargetId == "" {
+		return content.Message{}, nil, false
+	}
+	return content.Message{
+		ID:        content.MessageID(author, payload),
+		Author:    hexutil.Encode(author),
+		Kind:      content.Kind(p.Kind),
+		TargetID:  p.TargetId,
+		Content:   p.Content,
+		Retracted: p.Retracted,
+		Clock:     uint64(p.ClockValue),
+	}, &p, true
+}
+
+func (api *PublicAPI) processPFSMessage(msg *whisper.Message) error {
+	privateKey, publicKey, err := messageKeys(api.service.w, msg)
+	if err != nil {
+		return err
+	}
 
+	encrypted := chat.IsEncrypted(msg.Payload)
+	payload := msg.Payload
 	response, err := api.service.protocol.HandleMessage(privateKey, publicKey, msg.Payload)
 
 	// Notify that someone tried to contact us using an invalid bundle
@@ -520,18 +2228,109 @@ func (api *PublicAPI) processPFSMessage(msg *whisper.Message) error {
 		handler := EnvelopeSignalHandler{}
 		handler.DecryptMessageFailed(keyString)
 		return nil
+	} else if err == chat.ErrDuplicateMessage {
+		api.log.Debug("Dropping duplicate message", "hash", msg.Hash)
+		return err
+	} else if err == chat.ErrSyncMessage {
+		api.log.Debug("Handled sync message", "hash", msg.Hash)
+		return err
+	} else if err == chat.ErrContactRequest {
+		api.log.Debug("Keeping contact request", "hash", msg.Hash)
+		api.service.quarantine(publicKey, msg)
+		return errMessagePending
+	} else if err == chat.ErrSessionNotFound && publicKey != nil {
+		// The bundle or the pairing required to decrypt the message may arrive later
+		if err := api.service.inbox.Add(publicKey, msg); err != nil {
+			reporter.Error("keep-pending-message", report.WithCode(err, report.CodeStorage), "hash", msg.Hash)
+			return nil
+		}
+		api.log.Debug("Keeping message in the inbox", "hash", msg.Hash)
+		return errMessagePending
 	} else if err != nil {
 		// Ignore errors for now as those might be non-pfs messages
 		api.log.Error("Failed handling message with error", "err", err)
+		if !encrypted {
+			api.service.checkDowngrade(privateKey, publicKey, msg, false)
+		}
 		return nil
 	}
 
+	api.service.checkDowngrade(privateKey, publicKey, msg, encrypted)
+	api.service.observeLatency(msg, payload, time.Now())
+
 	// Add unencrypted payload
 	msg.Payload = response
 
+	api.service.retryPending(msg)
+
 	return nil
 }
 
+// moderateMessages applies the lists published in moderated public chats and
+// drops or flags the messages of authors not allowed by them.
+func (api *PublicAPI) moderateMessages(messages []*whisper.Message) []*whisper.Message {
+	moderator := api.service.moderator
+	if moderator == nil {
+		return messages
+	}
+
+	handler := EnvelopeSignalHandler{}
+	result := make([]*whisper.Message, 0, len(messages))
+	for _, msg := range messages {
+		// Only public chats are moderated
+		if msg.Dst != nil {
+			result = append(result, msg)
+			continue
+		}
+
+		if list, ok := moderation.Decode(msg.Payload); ok {
+			if err := moderator.Apply(msg.Topic, list); err != nil {
+				api.log.Warn("Ignoring moderation list", "hash", msg.Hash, "err", err)
+			}
+			continue
+		}
+
+		author := hexutil.Encode(msg.Sig)
+		verdict, err := moderator.Check(msg.Topic, author)
+		if err != nil {
+			api.log.Error("Failed to moderate message", "hash", msg.Hash, "err", err)
+		}
+
+		switch verdict {
+		case moderation.Hidden:
+			continue
+		case moderation.Flagged:
+			channel, err := moderator.Channel(msg.Topic)
+			if err == nil && channel != nil {
+				handler.MessageFlagged(common.BytesToHash(msg.Hash), channel.ChatID, author)
+			}
+		}
+		result = append(result, msg)
+	}
+
+	return result
+}
+
+// translateMessages queues the messages received in a chat that has a language set to be
+// translated in the background. A signal annotates each message once it's translated.
+func (api *PublicAPI) translateMessages(messages []*whisper.Message) {
+	if !api.service.translations.Enabled() || api.service.protocol == nil {
+		return
+	}
+
+	for _, msg := range messages {
+		language, err := api.service.protocol.GetTopicLanguage(msg.Topic)
+		if err != nil {
+			api.log.Error("Failed to get chat language", "topic", msg.Topic, "err", err)
+			continue
+		}
+		if language == "" {
+			continue
+		}
+		api.service.translations.Add(common.BytesToHash(msg.Hash), msg.Payload, language)
+	}
+}
+
 // -----
 // HELPER
 // -----
@@ -588,6 +2387,15 @@ func makeMessagesRequestPayload(r MessagesRequest) ([]byte, error) {
 		// This can be removed in the future.
 		Batch: true,
 	}
+	if r.Verify {
+		payload.Features = []uint32{mailserver.FeatureResponseSummary}
+	}
+	if r.Credential != "" {
+		payload.Credential = &mailserver.Credential{}
+		if err := rlp.DecodeBytes(common.FromHex(r.Credential), payload.Credential); err != nil {
+			return nil, fmt.Errorf("invalid credential: %v", err)
+		}
+	}
 
 	return rlp.EncodeToBytes(payload)
 }
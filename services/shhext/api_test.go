@@ -60,6 +60,43 @@ func TestMessagesRequest_setDefaults(t *testing.T) {
 	}
 }
 
+func TestMessagesRequest_fingerprint(t *testing.T) {
+	a := MessagesRequest{From: 1, To: 2, Topics: []whisper.TopicType{{1}, {2}}}
+	b := MessagesRequest{From: 1, To: 2, Topics: []whisper.TopicType{{2}, {1}}, Timeout: 100}
+	require.Equal(t, a.fingerprint(), b.fingerprint(), "It ignores topics order and timeout")
+
+	b.To = 3
+	require.NotEqual(t, a.fingerprint(), b.fingerprint())
+
+	c := a
+	c.Cursor = "01"
+	require.NotEqual(t, a.fingerprint(), c.fingerprint())
+}
+
+func TestMessagesCursor(t *testing.T) {
+	first := MessagesRequest{From: 10, To: 20, Limit: 5, Topics: []whisper.TopicType{{1}}}
+	raw := make([]byte, mailserver.DBKeyLength)
+	raw[0] = 1
+
+	cursor, err := encodeMessagesCursor(first, raw)
+	require.NoError(t, err)
+
+	next := MessagesRequest{From: 1, To: 2, Topic: whisper.TopicType{2}}
+	require.NoError(t, applyMessagesCursor(&next, cursor))
+	require.Equal(t, MessagesRequest{
+		From:   10,
+		To:     20,
+		Limit:  5,
+		Topics: []whisper.TopicType{{1}},
+		Cursor: hex.EncodeToString(raw),
+	}, next, "It pins the range, the limit and the topics of the first request")
+
+	_, err = makeMessagesRequestPayload(next)
+	require.NoError(t, err)
+
+	require.Equal(t, ErrInvalidMessagesCursor, applyMessagesCursor(&next, "not a cursor"))
+}
+
 func TestMakeMessagesRequestPayload(t *testing.T) {
 	testCases := []struct {
 		Name string
@@ -83,6 +120,11 @@ func TestMakeMessagesRequestPayload(t *testing.T) {
 			},
 			Err: "",
 		},
+		{
+			Name: "invalid credential",
+			Req:  MessagesRequest{Credential: "0x01"},
+			Err:  "invalid credential: rlp: expected input list for mailserver.Credential",
+		},
 	}
 
 	for _, tc := range testCases {
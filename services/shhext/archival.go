@@ -0,0 +1,47 @@
+package shhext
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// archivalRequester requests archived envelopes from a MailServer with a request
+// encrypted with its public key, so that no password is required.
+type archivalRequester struct {
+	service *Service
+}
+
+func (r archivalRequester) Request(node *enode.Node, topic whisper.TopicType, from, to uint32) (common.Hash, error) {
+	request := MessagesRequest{From: from, To: to, Topics: []whisper.TopicType{topic}, Timeout: defaultRequestTimeout}
+	payload, err := makeMessagesRequestPayload(request)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	shh := r.service.w
+	envelope, err := makeEnvelop(payload, nil, node.Pubkey(), r.service.nodeID, shh.MinPow(), shh.GetCurrentTime())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := r.service.requestHistoricMessages(node, envelope, request); err != nil {
+		return common.Hash{}, err
+	}
+	return envelope.Hash(), nil
+}
+
+// archivalPeers reports the MailServers that are connected.
+type archivalPeers struct {
+	service *Service
+}
+
+func (p archivalPeers) Connected(id enode.ID) bool {
+	if p.service.server == nil {
+		return false
+	}
+	for _, peer := range p.service.server.Peers() {
+		if peer.ID() == id {
+			return true
+		}
+	}
+	return false
+}
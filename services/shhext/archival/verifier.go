@@ -0,0 +1,336 @@
+package archival
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/status-im/status-go/services/shhext/delivery"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+const (
+	// DefaultGracePeriod is how long envelopes returned by a mail server are waited
+	// for after the request completed, as they are processed asynchronously.
+	DefaultGracePeriod = 2 * time.Second
+	// requestWindow is the number of seconds before and after the time an envelope
+	// was posted that are requested from the mail server.
+	requestWindow = 10
+	// eventsBuffer must be large enough to not block the whisper envelopes feed.
+	eventsBuffer  = 100
+	checkInterval = 500 * time.Millisecond
+)
+
+// ErrStoreNotSet is returned if contact mail servers are set before the store is available.
+var ErrStoreNotSet = errors.New("contact mail servers store is not set")
+
+// Store persists the mail servers used by contacts.
+type Store interface {
+	SaveContactMailServer(publicKey []byte, enode string) error
+	GetContactMailServer(publicKey []byte) (string, error)
+}
+
+// Requester requests the envelopes of a topic from a mail server.
+type Requester interface {
+	// Request returns the hash of the request.
+	Request(node *enode.Node, topic whisper.TopicType, from, to uint32) (common.Hash, error)
+}
+
+// Peers reports if mail servers are connected.
+type Peers interface {
+	Connected(enode.ID) bool
+}
+
+// Handler is notified of the delivery of envelopes sent through a shared mail server.
+type Handler interface {
+	EnvelopeSent(common.Hash)
+	EnvelopeExpired(common.Hash)
+}
+
+// EnvelopeEventsSubscriber subscribes to whisper envelope events.
+type EnvelopeEventsSubscriber interface {
+	SubscribeEnvelopeEvents(chan<- whisper.EnvelopeEvent) event.Subscription
+}
+
+type check struct {
+	node  *enode.Node
+	topic whisper.TopicType
+	// posted is the time the envelope was posted, in seconds.
+	posted uint32
+
+	started   bool
+	request   common.Hash
+	completed time.Time
+}
+
+// Verifier confirms the delivery of envelopes whose recipient uses the same
+// trusted mail server as this node. Such envelopes are reported as sent only
+// once the mail server returns them in response to a request, which proves
+// that they were archived and can be retrieved by the recipient, instead of
+// as soon as the envelope is acknowledged by a peer.
+type Verifier struct {
+	whisper   EnvelopeEventsSubscriber
+	requester Requester
+	peers     Peers
+	handler   Handler
+	recorder  *delivery.Recorder
+	grace     time.Duration
+
+	mu       sync.Mutex
+	store    Store
+	checks   map[common.Hash]*check
+	requests map[common.Hash]common.Hash
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// NewVerifier returns a new Verifier. The recorder is optional.
+func NewVerifier(w EnvelopeEventsSubscriber, requester Requester, peers Peers, handler Handler, recorder *delivery.Recorder) *Verifier {
+	return &Verifier{
+		whisper:   w,
+		requester: requester,
+		peers:     peers,
+		handler:   handler,
+		recorder:  recorder,
+		grace:     DefaultGracePeriod,
+		checks:    make(map[common.Hash]*check),
+		requests:  make(map[common.Hash]common.Hash),
+	}
+}
+
+// SetStore sets the store of the mail servers used by contacts.
+func (v *Verifier) SetStore(store Store) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.store = store
+}
+
+// SetContactMailServer records the mail server used by a contact. An empty
+// enode removes it.
+func (v *Verifier) SetContactMailServer(publicKey []byte, node string) error {
+	v.mu.Lock()
+	store := v.store
+	v.mu.Unlock()
+	if store == nil {
+		return ErrStoreNotSet
+	}
+	if node != "" {
+		if _, err := enode.ParseV4(node); err != nil {
+			return err
+		}
+	}
+	return store.SaveContactMailServer(publicKey, node)
+}
+
+// ContactMailServer returns the mail server used by a contact, or nil.
+func (v *Verifier) ContactMailServer(publicKey []byte) (*enode.Node, error) {
+	v.mu.Lock()
+	store := v.store
+	v.mu.Unlock()
+	if store == nil {
+		return nil, nil
+	}
+	node, err := store.GetContactMailServer(publicKey)
+	if err != nil || node == "" {
+		return nil, err
+	}
+	return enode.ParseV4(node)
+}
+
+// Watch delivers an envelope through a mail server shared with its recipient.
+// posted is the time the envelope was posted, in seconds.
+func (v *Verifier) Watch(hash common.Hash, node *enode.Node, topic whisper.TopicType, posted uint32) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.checks[hash] = &check{node: node, topic: topic, posted: posted}
+}
+
+// Owns returns true if an envelope is delivered through a shared mail server.
+func (v *Verifier) Owns(hash common.Hash) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	_, exist := v.checks[hash]
+	return exist
+}
+
+// Acked must be called when an envelope is acknowledged by a peer. The archival
+// of the envelope is checked once it is acknowledged by the shared mail server.
+func (v *Verifier) Acked(hash common.Hash, peer enode.ID) {
+	v.mu.Lock()
+	c, exist := v.checks[hash]
+	if !exist || c.started || c.node.ID() != peer {
+		v.mu.Unlock()
+		return
+	}
+	c.started = true
+	v.mu.Unlock()
+
+	v.wg.Add(1)
+	go func() {
+		defer v.wg.Done()
+		v.request(hash, c)
+	}()
+}
+
+// Expired must be called when an envelope expires. It returns false if the
+// archival of the envelope is not being checked, in which case the envelope
+// must be reported as usual.
+func (v *Verifier) Expired(hash common.Hash) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, exist := v.checks[hash]
+	if !exist {
+		return false
+	}
+	if c.started {
+		return true
+	}
+	delete(v.checks, hash)
+	return false
+}
+
+// Start watches the envelopes returned by mail servers.
+func (v *Verifier) Start() {
+	v.quit = make(chan struct{})
+	v.wg.Add(1)
+	go func() {
+		defer v.wg.Done()
+		events := make(chan whisper.EnvelopeEvent, eventsBuffer)
+		sub := v.whisper.SubscribeEnvelopeEvents(events)
+		defer sub.Unsubscribe()
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-v.quit:
+				return
+			case err := <-sub.Err():
+				log.Error("retry after error subscribing to whisper events", "error", err)
+				return
+			case ev := <-events:
+				v.handleEvent(ev)
+			case now := <-ticker.C:
+				v.expireChecks(now)
+			}
+		}
+	}()
+}
+
+// Stop stops watching the envelopes returned by mail servers.
+func (v *Verifier) Stop() {
+	if v.quit == nil {
+		return
+	}
+	close(v.quit)
+	v.wg.Wait()
+	v.quit = nil
+}
+
+func (v *Verifier) request(hash common.Hash, c *check) {
+	if !v.peers.Connected(c.node.ID()) {
+		log.Debug("shared mail server is not connected", "hash", hash, "peer", c.node.ID())
+		v.finish(hash, false, true)
+		return
+	}
+	request, err := v.requester.Request(c.node, c.topic, c.posted-requestWindow, c.posted+requestWindow)
+	if err != nil {
+		log.Error("failed to request archived envelope", "hash", hash, "peer", c.node.ID(), "err", err)
+		v.finish(hash, false, true)
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, exist := v.checks[hash]; exist {
+		c.request = request
+		v.requests[request] = hash
+	}
+}
+
+func (v *Verifier) handleEvent(ev whisper.EnvelopeEvent) {
+	switch ev.Event {
+	case whisper.EventEnvelopeAvailable:
+		v.mu.Lock()
+		c, exist := v.checks[ev.Hash]
+		// The envelope was already available when it was posted, so it is
+		// available again only if it is returned by a mail server.
+		archived := exist && c.started
+		v.mu.Unlock()
+		if archived {
+			v.finish(ev.Hash, true, false)
+		}
+	case whisper.EventMailServerRequestCompleted:
+		v.mu.Lock()
+		hash, exist := v.requests[ev.Hash]
+		failed := false
+		if exist {
+			if resp, ok := ev.Data.(*whisper.MailServerResponse); ok && resp.Error != nil {
+				failed = true
+			} else {
+				v.checks[hash].completed = time.Now()
+			}
+		}
+		v.mu.Unlock()
+		if failed {
+			v.finish(hash, false, false)
+		}
+	case whisper.EventMailServerRequestExpired:
+		v.mu.Lock()
+		hash, exist := v.requests[ev.Hash]
+		v.mu.Unlock()
+		if exist {
+			v.finish(hash, false, false)
+		}
+	}
+}
+
+// expireChecks fails the checks whose envelope wasn't returned before the end
+// of the grace period.
+func (v *Verifier) expireChecks(now time.Time) {
+	var failed []common.Hash
+	v.mu.Lock()
+	for hash, c := range v.checks {
+		if !c.completed.IsZero() && now.Sub(c.completed) >= v.grace {
+			failed = append(failed, hash)
+		}
+	}
+	v.mu.Unlock()
+	for _, hash := range failed {
+		v.finish(hash, false, false)
+	}
+}
+
+// finish reports the outcome of a check. If the archival couldn't be checked
+// and fallback is true, the envelope is reported as sent, as it was acknowledged
+// by the mail server.
+func (v *Verifier) finish(hash common.Hash, archived bool, fallback bool) {
+	v.mu.Lock()
+	c, exist := v.checks[hash]
+	if !exist {
+		v.mu.Unlock()
+		return
+	}
+	delete(v.checks, hash)
+	delete(v.requests, c.request)
+	v.mu.Unlock()
+
+	if archived && v.recorder != nil {
+		if err := v.recorder.Update(hash, delivery.Archived); err != nil {
+			log.Error("failed to update delivery state", "hash", hash, "err", err)
+		}
+	}
+	if v.handler == nil {
+		return
+	}
+	if archived || fallback {
+		v.handler.EnvelopeSent(hash)
+	} else {
+		log.Debug("envelope was not archived by the shared mail server", "hash", hash)
+		v.handler.EnvelopeExpired(hash)
+	}
+}
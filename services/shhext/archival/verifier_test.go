@@ -0,0 +1,208 @@
+package archival
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+)
+
+type envelopeEvents struct {
+	feed event.Feed
+}
+
+func (e *envelopeEvents) SubscribeEnvelopeEvents(events chan<- whisper.EnvelopeEvent) event.Subscription {
+	return e.feed.Subscribe(events)
+}
+
+type requester struct {
+	mu       sync.Mutex
+	err      error
+	requests []common.Hash
+}
+
+func (r *requester) Request(node *enode.Node, topic whisper.TopicType, from, to uint32) (common.Hash, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return common.Hash{}, r.err
+	}
+	hash := common.Hash{byte(len(r.requests) + 1)}
+	r.requests = append(r.requests, hash)
+	return hash, nil
+}
+
+func (r *requester) last() common.Hash {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.requests) == 0 {
+		return common.Hash{}
+	}
+	return r.requests[len(r.requests)-1]
+}
+
+type peers bool
+
+func (p peers) Connected(enode.ID) bool {
+	return bool(p)
+}
+
+type handler struct {
+	sent    chan common.Hash
+	expired chan common.Hash
+}
+
+func newHandler() *handler {
+	return &handler{sent: make(chan common.Hash, 1), expired: make(chan common.Hash, 1)}
+}
+
+func (h *handler) EnvelopeSent(hash common.Hash) {
+	h.sent <- hash
+}
+
+func (h *handler) EnvelopeExpired(hash common.Hash) {
+	h.expired <- hash
+}
+
+type memStore map[string]string
+
+func (s memStore) SaveContactMailServer(publicKey []byte, enode string) error {
+	if enode == "" {
+		delete(s, string(publicKey))
+	} else {
+		s[string(publicKey)] = enode
+	}
+	return nil
+}
+
+func (s memStore) GetContactMailServer(publicKey []byte) (string, error) {
+	return s[string(publicKey)], nil
+}
+
+func newNode(t *testing.T) *enode.Node {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return enode.NewV4(&key.PublicKey, net.IPv4(127, 0, 0, 1), 30303, 30303)
+}
+
+func setupVerifier(t *testing.T, connected bool) (*Verifier, *envelopeEvents, *requester, *handler) {
+	events := &envelopeEvents{}
+	r := &requester{}
+	h := newHandler()
+	v := NewVerifier(events, r, peers(connected), h, nil)
+	return v, events, r, h
+}
+
+func waitRequest(t *testing.T, r *requester) common.Hash {
+	for i := 0; i < 100; i++ {
+		if hash := r.last(); hash != (common.Hash{}) {
+			return hash
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.FailNow(t, "archived envelope wasn't requested")
+	return common.Hash{}
+}
+
+func waitHash(t *testing.T, c <-chan common.Hash) common.Hash {
+	select {
+	case hash := <-c:
+		return hash
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for handler")
+	}
+	return common.Hash{}
+}
+
+func TestVerifierArchived(t *testing.T) {
+	v, events, r, h := setupVerifier(t, true)
+	v.Start()
+	defer v.Stop()
+	node := newNode(t)
+	hash := common.Hash{0xaa}
+
+	v.Watch(hash, node, whisper.TopicType{1}, 100)
+	require.True(t, v.Owns(hash))
+	v.Acked(hash, newNode(t).ID())
+	require.Empty(t, r.requests, "Acks of other peers are ignored")
+
+	v.Acked(hash, node.ID())
+	request := waitRequest(t, r)
+	events.feed.Send(whisper.EnvelopeEvent{Event: whisper.EventMailServerRequestCompleted, Hash: request})
+	events.feed.Send(whisper.EnvelopeEvent{Event: whisper.EventEnvelopeAvailable, Hash: hash})
+	require.Equal(t, hash, waitHash(t, h.sent))
+	require.False(t, v.Owns(hash))
+}
+
+func TestVerifierNotArchived(t *testing.T) {
+	v, events, r, h := setupVerifier(t, true)
+	v.grace = 0
+	v.Start()
+	defer v.Stop()
+	node := newNode(t)
+	hash := common.Hash{0xaa}
+
+	v.Watch(hash, node, whisper.TopicType{1}, 100)
+	v.Acked(hash, node.ID())
+	request := waitRequest(t, r)
+	require.True(t, v.Expired(hash), "Expiration is reported once the archival is checked")
+	events.feed.Send(whisper.EnvelopeEvent{Event: whisper.EventMailServerRequestCompleted, Hash: request})
+	require.Equal(t, hash, waitHash(t, h.expired))
+}
+
+func TestVerifierFallback(t *testing.T) {
+	v, _, r, h := setupVerifier(t, false)
+	v.Start()
+	defer v.Stop()
+	node := newNode(t)
+	hash := common.Hash{0xaa}
+
+	v.Watch(hash, node, whisper.TopicType{1}, 100)
+	v.Acked(hash, node.ID())
+	require.Equal(t, hash, waitHash(t, h.sent), "Acknowledged envelopes are sent if the mail server is not connected")
+	require.Empty(t, r.requests)
+
+	v.peers = peers(true)
+	r.err = errors.New("failed")
+	v.Watch(hash, node, whisper.TopicType{1}, 100)
+	v.Acked(hash, node.ID())
+	require.Equal(t, hash, waitHash(t, h.sent), "Acknowledged envelopes are sent if the request fails")
+}
+
+func TestVerifierExpiredBeforeAck(t *testing.T) {
+	v, _, _, _ := setupVerifier(t, true)
+	v.Start()
+	defer v.Stop()
+	hash := common.Hash{0xaa}
+
+	require.False(t, v.Expired(hash))
+	v.Watch(hash, newNode(t), whisper.TopicType{1}, 100)
+	require.False(t, v.Expired(hash), "Envelopes that were never acknowledged expire as usual")
+	require.False(t, v.Owns(hash))
+}
+
+func TestContactMailServer(t *testing.T) {
+	v := NewVerifier(&envelopeEvents{}, &requester{}, peers(true), nil, nil)
+	require.Equal(t, ErrStoreNotSet, v.SetContactMailServer([]byte{1}, ""))
+
+	v.SetStore(memStore{})
+	node := newNode(t)
+	require.Error(t, v.SetContactMailServer([]byte{1}, "invalid"))
+	require.NoError(t, v.SetContactMailServer([]byte{1}, node.String()))
+	stored, err := v.ContactMailServer([]byte{1})
+	require.NoError(t, err)
+	require.Equal(t, node.ID(), stored.ID())
+
+	require.NoError(t, v.SetContactMailServer([]byte{1}, ""))
+	stored, err = v.ContactMailServer([]byte{1})
+	require.NoError(t, err)
+	require.Nil(t, stored)
+}
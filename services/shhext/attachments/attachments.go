@@ -0,0 +1,116 @@
+package attachments
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// payloadPrefix distinguishes the messages sharing an attachment from regular messages.
+var payloadPrefix = []byte("status-attachment:")
+
+// keySize is the size of the AES-256 key each attachment is encrypted with.
+const keySize = 32
+
+var (
+	// ErrInvalidKey is returned when the key of a reference has an invalid size.
+	ErrInvalidKey = errors.New("invalid attachment key")
+	// ErrHashMismatch is returned when a decrypted attachment doesn't match the hash of its reference.
+	ErrHashMismatch = errors.New("attachment doesn't match its hash")
+)
+
+// Reference is embedded in a chat message to share an attachment: the location of the
+// encrypted blob in the storage backend, the key to decrypt it and the hash to verify it.
+type Reference struct {
+	// ID is the location of the encrypted blob in the storage backend.
+	ID string `json:"id"`
+	// Key is the random key the blob is encrypted with.
+	Key hexutil.Bytes `json:"key"`
+	// Hash is the keccak256 hash of the attachment, before encryption.
+	Hash        hexutil.Bytes `json:"hash"`
+	Size        int           `json:"size"`
+	ContentType string        `json:"contentType"`
+}
+
+// Encrypt encrypts an attachment with a new random key. It returns the encrypted blob
+// and the reference to share once the blob is uploaded.
+func Encrypt(data []byte, contentType string) ([]byte, Reference, error) {
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, Reference{}, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, Reference{}, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, Reference{}, err
+	}
+
+	ref := Reference{
+		Key:         key,
+		Hash:        crypto.Keccak256(data),
+		Size:        len(data),
+		ContentType: contentType,
+	}
+	return gcm.Seal(nonce, nonce, data, nil), ref, nil
+}
+
+// Decrypt decrypts the blob of an attachment and verifies it matches its reference.
+func Decrypt(blob []byte, ref Reference) ([]byte, error) {
+	gcm, err := newGCM(ref.Key)
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) < gcm.NonceSize() {
+		return nil, ErrHashMismatch
+	}
+	data, err := gcm.Open(nil, blob[:gcm.NonceSize()], blob[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != ref.Size || !bytes.Equal(crypto.Keccak256(data), ref.Hash) {
+		return nil, ErrHashMismatch
+	}
+	return data, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != keySize {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encode returns the payload of a message sharing an attachment.
+func Encode(ref Reference) ([]byte, error) {
+	data, err := json.Marshal(ref)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, payloadPrefix...), data...), nil
+}
+
+// Decode returns the reference of the attachment shared by a message, if any.
+func Decode(payload []byte) (*Reference, bool) {
+	if !bytes.HasPrefix(payload, payloadPrefix) {
+		return nil, false
+	}
+	var ref Reference
+	if err := json.Unmarshal(payload[len(payloadPrefix):], &ref); err != nil || ref.ID == "" || len(ref.Hash) == 0 {
+		return nil, false
+	}
+	return &ref, true
+}
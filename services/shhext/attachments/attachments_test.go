@@ -0,0 +1,53 @@
+package attachments
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	data := []byte("picture")
+	blob, ref, err := Encrypt(data, "image/jpeg")
+	require.NoError(t, err)
+	require.NotContains(t, string(blob), string(data))
+	require.Len(t, blob, len(data)+blobOverhead)
+	require.Equal(t, len(data), ref.Size)
+	require.Equal(t, "image/jpeg", ref.ContentType)
+
+	decrypted, err := Decrypt(blob, ref)
+	require.NoError(t, err)
+	require.Equal(t, data, decrypted)
+
+	tampered := append([]byte{}, blob...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = Decrypt(tampered, ref)
+	require.Error(t, err, "It rejects tampered blobs")
+
+	_, other, err := Encrypt([]byte("other"), "image/jpeg")
+	require.NoError(t, err)
+	ref.Hash = other.Hash
+	_, err = Decrypt(blob, ref)
+	require.Equal(t, ErrHashMismatch, err, "It verifies the hash of the reference")
+
+	ref.Key = ref.Key[1:]
+	_, err = Decrypt(blob, ref)
+	require.Equal(t, ErrInvalidKey, err)
+}
+
+func TestEncodeDecode(t *testing.T) {
+	_, ref, err := Encrypt([]byte("picture"), "image/jpeg")
+	require.NoError(t, err)
+	ref.ID = "id"
+	payload, err := Encode(ref)
+	require.NoError(t, err)
+
+	decoded, ok := Decode(payload)
+	require.True(t, ok)
+	require.Equal(t, ref, *decoded)
+
+	_, ok = Decode([]byte("hello"))
+	require.False(t, ok)
+	_, ok = Decode([]byte("status-attachment:{}"))
+	require.False(t, ok, "It requires the ID and the hash")
+}
@@ -0,0 +1,161 @@
+package attachments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// BackendHTTP stores the blobs on an HTTP server.
+	BackendHTTP = "http"
+	// BackendIPFS stores the blobs on IPFS, through the HTTP API of a node.
+	BackendIPFS = "ipfs"
+
+	// defaultBackendTimeout is the time we wait for an upload or a download before giving up.
+	defaultBackendTimeout = time.Minute
+)
+
+var (
+	// ErrUnknownBackend is returned when creating a backend of an unknown kind.
+	ErrUnknownBackend = errors.New("unknown attachments backend")
+	// ErrInvalidURL is returned when creating a backend without the absolute URL of its server.
+	ErrInvalidURL = errors.New("invalid attachments backend URL")
+	// ErrTooLarge is returned when a blob exceeds the maximum size of attachments.
+	ErrTooLarge = errors.New("attachment is too large")
+)
+
+// Backend stores the encrypted blobs of attachments.
+type Backend interface {
+	// Upload stores a blob and returns its ID.
+	Upload(ctx context.Context, blob []byte) (string, error)
+	// Download returns a blob, reading at most maxSize bytes.
+	Download(ctx context.Context, id string, maxSize int64) ([]byte, error)
+}
+
+// NewBackend returns a backend of the given kind using the server at rawURL.
+func NewBackend(kind string, rawURL string) (Backend, error) {
+	if u, err := url.Parse(rawURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, ErrInvalidURL
+	}
+	client := &http.Client{Timeout: defaultBackendTimeout}
+	switch kind {
+	case BackendHTTP:
+		return &HTTPBackend{url: strings.TrimSuffix(rawURL, "/"), client: client}, nil
+	case BackendIPFS:
+		return &IPFSBackend{url: strings.TrimSuffix(rawURL, "/"), client: client}, nil
+	default:
+		return nil, ErrUnknownBackend
+	}
+}
+
+// HTTPBackend stores the blobs on an HTTP server. Blobs are posted to the URL of the
+// server, which responds with their ID, and are fetched from the URL followed by their ID.
+type HTTPBackend struct {
+	url    string
+	client *http.Client
+}
+
+// Upload posts the blob to the server.
+func (b *HTTPBackend) Upload(ctx context.Context, blob []byte) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, b.url, bytes.NewReader(blob))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	body, err := do(ctx, b.client, req, 1024)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// Download fetches the blob from the server.
+func (b *HTTPBackend) Download(ctx context.Context, id string, maxSize int64) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, b.url+"/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	return do(ctx, b.client, req, maxSize)
+}
+
+// IPFSBackend stores the blobs on IPFS, through the HTTP API of a node. Blobs are
+// identified by their IPFS hash.
+type IPFSBackend struct {
+	url    string
+	client *http.Client
+}
+
+// Upload adds the blob to IPFS.
+func (b *IPFSBackend) Upload(ctx context.Context, blob []byte) (string, error) {
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	part, err := writer.CreateFormFile("file", "attachment")
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(blob); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, b.url+"/api/v0/add?pin=true", &form)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	body, err := do(ctx, b.client, req, 4096)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Hash string
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", err
+	}
+	return response.Hash, nil
+}
+
+// Download fetches the blob from IPFS.
+func (b *IPFSBackend) Download(ctx context.Context, id string, maxSize int64) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, b.url+"/api/v0/cat?arg="+url.QueryEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	return do(ctx, b.client, req, maxSize)
+}
+
+// do sends a request and returns the body of the response, reading at most maxSize bytes.
+func do(ctx context.Context, client *http.Client, req *http.Request, maxSize int64) ([]byte, error) {
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("attachments server returned status %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxSize {
+		return nil, ErrTooLarge
+	}
+	return body, nil
+}
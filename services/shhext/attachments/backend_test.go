@@ -0,0 +1,106 @@
+package attachments
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type blobServer struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func (s *blobServer) save(blob []byte) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := fmt.Sprintf("blob%d", len(s.blobs))
+	s.blobs[id] = blob
+	return id
+}
+
+func (s *blobServer) load(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	blob, ok := s.blobs[id]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Write(blob) // nolint: errcheck
+}
+
+func TestNewBackend(t *testing.T) {
+	_, err := NewBackend(BackendHTTP, "")
+	require.Equal(t, ErrInvalidURL, err)
+	_, err = NewBackend("ftp", "http://localhost")
+	require.Equal(t, ErrUnknownBackend, err)
+	backend, err := NewBackend(BackendIPFS, "http://localhost:5001/")
+	require.NoError(t, err)
+	require.IsType(t, &IPFSBackend{}, backend)
+}
+
+func TestHTTPBackend(t *testing.T) {
+	blobs := &blobServer{blobs: make(map[string][]byte)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			blob, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			fmt.Fprintln(w, blobs.save(blob))
+			return
+		}
+		blobs.load(w, strings.TrimPrefix(r.URL.Path, "/blobs/"))
+	}))
+	defer server.Close()
+
+	backend, err := NewBackend(BackendHTTP, server.URL+"/blobs")
+	require.NoError(t, err)
+	id, err := backend.Upload(context.Background(), []byte("blob"))
+	require.NoError(t, err)
+	require.Equal(t, "blob0", id)
+
+	blob, err := backend.Download(context.Background(), id, 4)
+	require.NoError(t, err)
+	require.Equal(t, []byte("blob"), blob)
+
+	_, err = backend.Download(context.Background(), id, 3)
+	require.Equal(t, ErrTooLarge, err)
+	_, err = backend.Download(context.Background(), "unknown", 4)
+	require.Error(t, err)
+}
+
+func TestIPFSBackend(t *testing.T) {
+	blobs := &blobServer{blobs: make(map[string][]byte)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v0/add":
+			file, _, err := r.FormFile("file")
+			require.NoError(t, err)
+			blob, err := ioutil.ReadAll(file)
+			require.NoError(t, err)
+			fmt.Fprintf(w, `{"Name":"attachment","Hash":"%s","Size":"%d"}`, blobs.save(blob), len(blob))
+		case "/api/v0/cat":
+			blobs.load(w, r.URL.Query().Get("arg"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	backend, err := NewBackend(BackendIPFS, server.URL)
+	require.NoError(t, err)
+	id, err := backend.Upload(context.Background(), []byte("blob"))
+	require.NoError(t, err)
+	require.Equal(t, "blob0", id)
+
+	blob, err := backend.Download(context.Background(), id, 4)
+	require.NoError(t, err)
+	require.Equal(t, []byte("blob"), blob)
+}
@@ -0,0 +1,357 @@
+package attachments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/status-im/status-go/services/report"
+)
+
+// blobOverhead is the size the encryption adds to an attachment: the nonce and the tag of AES-GCM.
+const blobOverhead = 12 + 16
+
+// ErrUnknownAttachment is returned when fetching an attachment that was never uploaded or received.
+var ErrUnknownAttachment = errors.New("unknown attachment")
+
+// reporter reports the attachments that could not be downloaded to the client.
+var reporter = report.New("shhext")
+
+// State is the state of an attachment on this node.
+type State int
+
+const (
+	// Remote attachments are only available from the storage backend: they were not
+	// downloaded yet, or their data was evicted from the cache.
+	Remote State = iota
+	// Downloading attachments are being downloaded from the storage backend.
+	Downloading
+	// Cached attachments were uploaded or downloaded and verified, and their data is kept locally.
+	Cached
+	// Failed attachments couldn't be downloaded, decrypted or verified.
+	Failed
+)
+
+var stateNames = map[State]string{
+	Remote:      "remote",
+	Downloading: "downloading",
+	Cached:      "cached",
+	Failed:      "failed",
+}
+
+func (s State) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return stateNames[Remote]
+}
+
+// MarshalJSON encodes the state as its name.
+func (s State) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// Attachment is an attachment uploaded or received by this node.
+type Attachment struct {
+	Reference
+	State State `json:"state"`
+	// Data is the decrypted attachment, only set if it's cached.
+	Data []byte `json:"-"`
+	// AccessedAt is the time the attachment was last uploaded, downloaded or fetched, in milliseconds.
+	AccessedAt int64 `json:"accessedAt"`
+}
+
+// Store persists the attachments and the cache of their data.
+type Store interface {
+	// SaveAttachment persists an attachment, replacing it if it exists.
+	SaveAttachment(Attachment) error
+	// GetAttachment returns an attachment with its data, if any.
+	GetAttachment(hash []byte) (*Attachment, error)
+	// GetAttachmentInfo returns an attachment without its data.
+	GetAttachmentInfo(hash []byte) (*Attachment, error)
+	// ReadAttachment reads the data of a cached attachment from offset into p, and returns
+	// io.EOF past the end of the data.
+	ReadAttachment(hash []byte, offset int64, p []byte) (int, error)
+	// TouchAttachment updates the time an attachment was last accessed.
+	TouchAttachment(hash []byte, accessedAt int64) error
+	// GetCachedAttachments returns the cached attachments without their data, least recently accessed first.
+	GetCachedAttachments() ([]Attachment, error)
+	// EvictAttachment deletes the data of an attachment, which becomes remote.
+	EvictAttachment(hash []byte) error
+}
+
+// StateHandler is notified every time the download of a received attachment completes or fails.
+type StateHandler func(Attachment)
+
+// Config of the attachments.
+type Config struct {
+	// MaxSize is the maximum size of the attachments uploaded and downloaded, in bytes.
+	MaxSize int64
+	// CacheSize is the maximum size of the data of the attachments kept locally, in bytes.
+	// The least recently accessed attachments are evicted first.
+	CacheSize int64
+}
+
+// DefaultConfig returns the default configuration of the attachments.
+func DefaultConfig() Config {
+	return Config{
+		MaxSize:   20 * 1024 * 1024,
+		CacheSize: 200 * 1024 * 1024,
+	}
+}
+
+// Manager uploads the attachments sent and downloads the attachments received,
+// keeping their state and a cache of their data.
+type Manager struct {
+	backend Backend
+	store   Store
+	handler StateHandler
+	config  Config
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu sync.Mutex
+}
+
+// NewManager returns a new Manager. The handler can be nil.
+func NewManager(backend Backend, store Store, handler StateHandler, config Config) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		backend: backend,
+		store:   store,
+		handler: handler,
+		config:  config,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Upload encrypts an attachment with a new random key and uploads it to the storage
+// backend. It returns the reference to embed in a message with Encode.
+func (m *Manager) Upload(ctx context.Context, data []byte, contentType string) (Reference, error) {
+	if int64(len(data)) > m.config.MaxSize {
+		return Reference{}, ErrTooLarge
+	}
+	blob, ref, err := Encrypt(data, contentType)
+	if err != nil {
+		return Reference{}, err
+	}
+	if ref.ID, err = m.backend.Upload(ctx, blob); err != nil {
+		return Reference{}, err
+	}
+	if err := m.cache(Attachment{Reference: ref, State: Cached, Data: data, AccessedAt: now()}); err != nil {
+		return Reference{}, err
+	}
+	return ref, nil
+}
+
+// Receive records an attachment shared in a message and downloads it in the background,
+// unless it's already known.
+func (m *Manager) Receive(ref Reference) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attachment, err := m.store.GetAttachment(ref.Hash)
+	if err != nil {
+		return err
+	}
+	if attachment != nil && attachment.State != Failed {
+		return nil
+	}
+	if err := m.store.SaveAttachment(Attachment{Reference: ref, State: Downloading, AccessedAt: now()}); err != nil {
+		return err
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		attachment, err := m.download(m.ctx, ref)
+		if err != nil {
+			if m.ctx.Err() != nil {
+				return
+			}
+			reporter.Error("download-attachment", err, "hash", ref.Hash)
+			attachment = Attachment{Reference: ref, State: Failed, AccessedAt: now()}
+			m.mu.Lock()
+			err = m.store.SaveAttachment(attachment)
+			m.mu.Unlock()
+			if err != nil {
+				reporter.Error("save-attachment", report.WithCode(err, report.CodeStorage), "hash", ref.Hash)
+				return
+			}
+		}
+		if m.handler != nil {
+			attachment.Data = nil
+			m.handler(attachment)
+		}
+	}()
+	return nil
+}
+
+// Attachment returns the state of an attachment, without its data, or nil if it's unknown.
+func (m *Manager) Attachment(hash []byte) (*Attachment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.store.GetAttachmentInfo(hash)
+}
+
+// Fetch returns the data of an attachment, downloading it if it's not cached.
+func (m *Manager) Fetch(ctx context.Context, hash []byte) ([]byte, error) {
+	m.mu.Lock()
+	attachment, err := m.store.GetAttachment(hash)
+	if err == nil && attachment != nil && attachment.State == Cached {
+		err = m.store.TouchAttachment(hash, now())
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if attachment == nil {
+		return nil, ErrUnknownAttachment
+	}
+	if attachment.State == Cached {
+		return attachment.Data, nil
+	}
+
+	downloaded, err := m.download(ctx, attachment.Reference)
+	if err != nil {
+		return nil, err
+	}
+	return downloaded.Data, nil
+}
+
+// Open returns a reader of the data of an attachment, downloading it if it's not cached.
+// The data of cached attachments is read from the store by chunks, rather than at once.
+func (m *Manager) Open(ctx context.Context, hash []byte) (io.ReadSeeker, error) {
+	m.mu.Lock()
+	attachment, err := m.store.GetAttachmentInfo(hash)
+	if err == nil && attachment != nil && attachment.State == Cached {
+		err = m.store.TouchAttachment(hash, now())
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if attachment == nil {
+		return nil, ErrUnknownAttachment
+	}
+	if attachment.State == Cached {
+		return &dataReader{manager: m, hash: hash, size: int64(attachment.Size)}, nil
+	}
+
+	downloaded, err := m.download(ctx, attachment.Reference)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(downloaded.Data), nil
+}
+
+// Stop cancels the downloads in progress.
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// download downloads, decrypts and verifies an attachment, and caches it.
+func (m *Manager) download(ctx context.Context, ref Reference) (Attachment, error) {
+	if int64(ref.Size) > m.config.MaxSize {
+		return Attachment{}, ErrTooLarge
+	}
+	blob, err := m.backend.Download(ctx, ref.ID, int64(ref.Size)+blobOverhead)
+	if err != nil {
+		return Attachment{}, err
+	}
+	data, err := Decrypt(blob, ref)
+	if err != nil {
+		return Attachment{}, err
+	}
+	attachment := Attachment{Reference: ref, State: Cached, Data: data, AccessedAt: now()}
+	return attachment, m.cache(attachment)
+}
+
+// cache saves the data of an attachment and evicts the least recently accessed
+// attachments if the cache is full.
+func (m *Manager) cache(attachment Attachment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.store.SaveAttachment(attachment); err != nil {
+		return err
+	}
+
+	cached, err := m.store.GetCachedAttachments()
+	if err != nil {
+		return err
+	}
+	var size int64
+	for _, a := range cached {
+		size += int64(a.Size)
+	}
+	for _, a := range cached {
+		if size <= m.config.CacheSize {
+			break
+		}
+		if err := m.store.EvictAttachment(a.Hash); err != nil {
+			return err
+		}
+		size -= int64(a.Size)
+		log.Debug("evicted attachment", "hash", hexutil.Encode(a.Hash))
+	}
+	return nil
+}
+
+// dataReader reads the data of a cached attachment from the store.
+type dataReader struct {
+	manager *Manager
+	hash    []byte
+	size    int64
+	offset  int64
+}
+
+func (r *dataReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.size-r.offset {
+		p = p[:r.size-r.offset]
+	}
+	r.manager.mu.Lock()
+	n, err := r.manager.store.ReadAttachment(r.hash, r.offset, p)
+	r.manager.mu.Unlock()
+	r.offset += int64(n)
+	if err == io.EOF {
+		// The data was evicted while it was read.
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (r *dataReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+func now() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
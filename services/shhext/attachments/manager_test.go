@@ -0,0 +1,214 @@
+package attachments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memoryBackend struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func (b *memoryBackend) Upload(ctx context.Context, blob []byte) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := fmt.Sprintf("blob%d", len(b.blobs))
+	b.blobs[id] = blob
+	return id, nil
+}
+
+func (b *memoryBackend) Download(ctx context.Context, id string, maxSize int64) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	blob, ok := b.blobs[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return blob, nil
+}
+
+type memoryStore map[string]Attachment
+
+func (s memoryStore) SaveAttachment(a Attachment) error {
+	s[string(a.Hash)] = a
+	return nil
+}
+
+func (s memoryStore) GetAttachment(hash []byte) (*Attachment, error) {
+	a, ok := s[string(hash)]
+	if !ok {
+		return nil, nil
+	}
+	return &a, nil
+}
+
+func (s memoryStore) GetAttachmentInfo(hash []byte) (*Attachment, error) {
+	a, ok := s[string(hash)]
+	if !ok {
+		return nil, nil
+	}
+	a.Data = nil
+	return &a, nil
+}
+
+func (s memoryStore) ReadAttachment(hash []byte, offset int64, p []byte) (int, error) {
+	a := s[string(hash)]
+	if offset >= int64(len(a.Data)) {
+		return 0, io.EOF
+	}
+	return copy(p, a.Data[offset:]), nil
+}
+
+func (s memoryStore) TouchAttachment(hash []byte, accessedAt int64) error {
+	a := s[string(hash)]
+	a.AccessedAt = accessedAt
+	s[string(hash)] = a
+	return nil
+}
+
+func (s memoryStore) GetCachedAttachments() ([]Attachment, error) {
+	var cached []Attachment
+	for _, a := range s {
+		if a.State == Cached {
+			a.Data = nil
+			cached = append(cached, a)
+		}
+	}
+	sort.Slice(cached, func(i, j int) bool { return cached[i].AccessedAt < cached[j].AccessedAt })
+	return cached, nil
+}
+
+func (s memoryStore) EvictAttachment(hash []byte) error {
+	a := s[string(hash)]
+	a.State = Remote
+	a.Data = nil
+	s[string(hash)] = a
+	return nil
+}
+
+func TestUploadAndFetch(t *testing.T) {
+	backend := &memoryBackend{blobs: make(map[string][]byte)}
+	sender := NewManager(backend, memoryStore{}, nil, DefaultConfig())
+	defer sender.Stop()
+	ref, err := sender.Upload(context.Background(), []byte("picture"), "image/jpeg")
+	require.NoError(t, err)
+	require.Equal(t, "blob0", ref.ID)
+
+	data, err := sender.Fetch(context.Background(), ref.Hash)
+	require.NoError(t, err)
+	require.Equal(t, []byte("picture"), data, "It caches uploaded attachments")
+
+	received := make(chan Attachment, 1)
+	recipient := NewManager(backend, memoryStore{}, func(a Attachment) { received <- a }, DefaultConfig())
+	defer recipient.Stop()
+	_, err = recipient.Fetch(context.Background(), ref.Hash)
+	require.Equal(t, ErrUnknownAttachment, err)
+
+	require.NoError(t, recipient.Receive(ref))
+	select {
+	case a := <-received:
+		require.Equal(t, Cached, a.State)
+		require.Nil(t, a.Data)
+	case <-time.After(time.Second):
+		require.FailNow(t, "timed out waiting for the download")
+	}
+	data, err = recipient.Fetch(context.Background(), ref.Hash)
+	require.NoError(t, err)
+	require.Equal(t, []byte("picture"), data)
+
+	_, err = sender.Upload(context.Background(), make([]byte, DefaultConfig().MaxSize+1), "image/jpeg")
+	require.Equal(t, ErrTooLarge, err)
+}
+
+func TestOpen(t *testing.T) {
+	backend := &memoryBackend{blobs: make(map[string][]byte)}
+	sender := NewManager(backend, memoryStore{}, nil, DefaultConfig())
+	defer sender.Stop()
+	ref, err := sender.Upload(context.Background(), []byte("picture"), "image/jpeg")
+	require.NoError(t, err)
+
+	content, err := sender.Open(context.Background(), ref.Hash)
+	require.NoError(t, err)
+	require.IsType(t, &dataReader{}, content, "Cached attachments are read from the store")
+	chunk := make([]byte, 3)
+	n, err := content.Read(chunk)
+	require.NoError(t, err)
+	require.Equal(t, "pic", string(chunk[:n]))
+	_, err = content.Seek(-3, io.SeekEnd)
+	require.NoError(t, err)
+	rest, err := ioutil.ReadAll(content)
+	require.NoError(t, err)
+	require.Equal(t, "ure", string(rest))
+
+	recipient := NewManager(backend, memoryStore{}, nil, DefaultConfig())
+	defer recipient.Stop()
+	_, err = recipient.Open(context.Background(), ref.Hash)
+	require.Equal(t, ErrUnknownAttachment, err)
+	require.NoError(t, recipient.store.SaveAttachment(Attachment{Reference: ref, State: Remote}))
+	content, err = recipient.Open(context.Background(), ref.Hash)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(content)
+	require.NoError(t, err)
+	require.Equal(t, []byte("picture"), data, "Remote attachments are downloaded")
+}
+
+func TestReceiveInvalid(t *testing.T) {
+	backend := &memoryBackend{blobs: make(map[string][]byte)}
+	sender := NewManager(backend, memoryStore{}, nil, DefaultConfig())
+	defer sender.Stop()
+	ref, err := sender.Upload(context.Background(), []byte("picture"), "image/jpeg")
+	require.NoError(t, err)
+
+	received := make(chan Attachment, 1)
+	recipient := NewManager(backend, memoryStore{}, func(a Attachment) { received <- a }, DefaultConfig())
+	defer recipient.Stop()
+	ref.Size++
+	require.NoError(t, recipient.Receive(ref))
+	select {
+	case a := <-received:
+		require.Equal(t, Failed, a.State)
+	case <-time.After(time.Second):
+		require.FailNow(t, "timed out waiting for the download")
+	}
+	attachment, err := recipient.Attachment(ref.Hash)
+	require.NoError(t, err)
+	require.Equal(t, Failed, attachment.State)
+}
+
+func TestEviction(t *testing.T) {
+	backend := &memoryBackend{blobs: make(map[string][]byte)}
+	store := memoryStore{}
+	m := NewManager(backend, store, nil, Config{MaxSize: 10, CacheSize: 10})
+	defer m.Stop()
+
+	first, err := m.Upload(context.Background(), []byte("first"), "text/plain")
+	require.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+	second, err := m.Upload(context.Background(), []byte("second"), "text/plain")
+	require.NoError(t, err)
+
+	attachment, err := m.Attachment(first.Hash)
+	require.NoError(t, err)
+	require.Equal(t, Remote, attachment.State, "It evicts the least recently accessed attachment")
+	attachment, err = m.Attachment(second.Hash)
+	require.NoError(t, err)
+	require.Equal(t, Cached, attachment.State)
+
+	time.Sleep(2 * time.Millisecond)
+	data, err := m.Fetch(context.Background(), first.Hash)
+	require.NoError(t, err)
+	require.Equal(t, []byte("first"), data, "It downloads evicted attachments again")
+	attachment, err = m.Attachment(second.Hash)
+	require.NoError(t, err)
+	require.Equal(t, Remote, attachment.State)
+}
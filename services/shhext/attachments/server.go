@@ -0,0 +1,268 @@
+package attachments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// DefaultURLTTL is how long the URLs of the media server are valid by default.
+	DefaultURLTTL = time.Hour
+	// serverPath is the path of the attachments served by the media server, followed by their hash.
+	serverPath = "/attachments/"
+	// signingKeySize is the size of the key the URLs of the media server are signed with.
+	signingKeySize = 32
+)
+
+var (
+	// ErrServerNotStarted is returned when signing a URL before the media server is started.
+	ErrServerNotStarted = errors.New("media server is not started")
+	// errInvalidURL is returned when the signature of a URL is invalid or expired.
+	errInvalidURL = errors.New("invalid or expired URL")
+)
+
+// Fetcher returns the attachments streamed by the media server. It is implemented by Manager.
+type Fetcher interface {
+	// Attachment returns the state of an attachment, without its data, or nil if it's unknown.
+	Attachment(hash []byte) (*Attachment, error)
+	// Open returns a reader of the data of an attachment, downloading it if it's not cached.
+	Open(ctx context.Context, hash []byte) (io.ReadSeeker, error)
+}
+
+// ServerConfig of the media server.
+type ServerConfig struct {
+	// Port the media server listens on, on the loopback interface. Zero picks a free port.
+	Port int
+	// URLTTL is how long the URLs are valid. Zero means DefaultURLTTL.
+	URLTTL time.Duration
+}
+
+// Server is a loopback HTTP server streaming the decrypted attachments to the image views
+// of the client, which load them from signed and expiring URLs instead of passing them
+// encoded over the bridge. The URLs are signed with a random key, generated when the
+// server starts or rotated with RotateKey.
+type Server struct {
+	fetcher Fetcher
+	config  ServerConfig
+
+	mu       sync.RWMutex
+	key      []byte
+	listener net.Listener
+	server   *http.Server
+}
+
+// NewServer returns a new media server serving the attachments of the fetcher.
+func NewServer(fetcher Fetcher, config ServerConfig) *Server {
+	if config.URLTTL <= 0 {
+		config.URLTTL = DefaultURLTTL
+	}
+	return &Server{
+		fetcher: fetcher,
+		config:  config,
+	}
+}
+
+// Start listens on the loopback interface and serves the attachments in the background.
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, err := newSigningKey()
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", s.config.Port))
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(serverPath, s.serveAttachment)
+	s.key = key
+	s.listener = listener
+	s.server = &http.Server{Handler: mux}
+
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != http.ErrServerClosed {
+			log.Error("media server stopped", "err", err)
+		}
+	}(s.server)
+	log.Info("media server started", "address", listener.Addr())
+	return nil
+}
+
+// Stop closes the server and the connections in progress.
+func (s *Server) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.server == nil {
+		return nil
+	}
+	err := s.server.Close()
+	s.server = nil
+	s.listener = nil
+	s.key = nil
+	return err
+}
+
+// RotateKey replaces the key the URLs are signed with, which invalidates all the
+// URLs returned before, like when the account is logged out.
+func (s *Server) RotateKey() error {
+	key, err := newSigningKey()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.server == nil {
+		return ErrServerNotStarted
+	}
+	s.key = key
+	return nil
+}
+
+// URL returns the URL of an attachment, valid for the TTL of the server.
+func (s *Server) URL(hash []byte) (string, error) {
+	return s.signedURL(hash, time.Now().Add(s.config.URLTTL))
+}
+
+func (s *Server) signedURL(hash []byte, expires time.Time) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.listener == nil {
+		return "", ErrServerNotStarted
+	}
+	expiry := strconv.FormatInt(expires.Unix(), 10)
+	return fmt.Sprintf("http://%s%s%x?expires=%s&signature=%x",
+		s.listener.Addr(), serverPath, hash, expiry, sign(s.key, hash, expiry)), nil
+}
+
+// verify checks the signature and the expiry of the URL of an attachment.
+func (s *Server) verify(hash []byte, expiry, signature string) error {
+	expires, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return errInvalidURL
+	}
+	mac, err := hex.DecodeString(signature)
+	if err != nil {
+		return errInvalidURL
+	}
+	s.mu.RLock()
+	key := s.key
+	s.mu.RUnlock()
+	if key == nil || !hmac.Equal(mac, sign(key, hash, expiry)) {
+		return errInvalidURL
+	}
+	return nil
+}
+
+func (s *Server) serveAttachment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	hash, err := hex.DecodeString(strings.TrimPrefix(r.URL.Path, serverPath))
+	if err != nil || len(hash) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	query := r.URL.Query()
+	if err := s.verify(hash, query.Get("expires"), query.Get("signature")); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	attachment, err := s.fetcher.Attachment(hash)
+	if err != nil {
+		log.Error("failed to get attachment", "hash", hex.EncodeToString(hash), "err", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if attachment == nil {
+		http.NotFound(w, r)
+		return
+	}
+	content, err := s.fetcher.Open(r.Context(), hash)
+	if err != nil {
+		log.Error("failed to fetch attachment", "hash", hex.EncodeToString(hash), "err", err)
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+
+	// The content type is set by the sender: only media are rendered, anything else is
+	// downloaded, and nothing served runs scripts.
+	if contentType, ok := servedContentType(attachment.ContentType); ok {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", "inline")
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", "attachment")
+	}
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("ETag", fmt.Sprintf(`"%x"`, hash))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// ServeContent streams the data and answers range and conditional requests.
+	http.ServeContent(w, r, "", time.Time{}, content)
+}
+
+// servedContentTypes are the content types of the attachments rendered by the clients:
+// raster images, audio and video.
+var servedContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+	"image/bmp":  true,
+	"audio/aac":  true,
+	"audio/mp4":  true,
+	"audio/mpeg": true,
+	"audio/ogg":  true,
+	"audio/wav":  true,
+	"audio/webm": true,
+	"video/mp4":  true,
+	"video/ogg":  true,
+	"video/webm": true,
+}
+
+// servedContentType returns the media type of a content type, without its parameters,
+// and whether attachments of this type are rendered.
+func servedContentType(contentType string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !servedContentTypes[mediaType] {
+		return "", false
+	}
+	return mediaType, true
+}
+
+func newSigningKey() ([]byte, error) {
+	key := make([]byte, signingKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// sign returns the signature of the URL of an attachment expiring at expiry.
+func sign(key, hash []byte, expiry string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(hash)           // nolint: errcheck
+	mac.Write([]byte(expiry)) // nolint: errcheck
+	return mac.Sum(nil)
+}
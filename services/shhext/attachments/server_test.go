@@ -0,0 +1,101 @@
+package attachments
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, url string, header http.Header) (*http.Response, []byte) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if header != nil {
+		req.Header = header
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, body
+}
+
+func TestServer(t *testing.T) {
+	manager := NewManager(&memoryBackend{blobs: make(map[string][]byte)}, memoryStore{}, nil, DefaultConfig())
+	defer manager.Stop()
+	ref, err := manager.Upload(context.Background(), []byte("picture"), "image/jpeg")
+	require.NoError(t, err)
+
+	server := NewServer(manager, ServerConfig{})
+	_, err = server.URL(ref.Hash)
+	require.Equal(t, ErrServerNotStarted, err)
+	require.NoError(t, server.Start())
+	defer server.Stop() // nolint: errcheck
+
+	url, err := server.URL(ref.Hash)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(url, "http://127.0.0.1:"), "It only listens on the loopback interface")
+	resp, body := get(t, url, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "image/jpeg", resp.Header.Get("Content-Type"))
+	require.Equal(t, "inline", resp.Header.Get("Content-Disposition"))
+	require.Equal(t, "sandbox", resp.Header.Get("Content-Security-Policy"))
+	require.Equal(t, "private, no-store", resp.Header.Get("Cache-Control"))
+	require.Equal(t, []byte("picture"), body)
+
+	resp, body = get(t, url, http.Header{"Range": []string{"bytes=2-4"}})
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	require.Equal(t, []byte("ctu"), body)
+
+	resp, _ = get(t, strings.Replace(url, "signature=", "signature=00", 1), nil)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode, "Tampered URLs are rejected")
+
+	unknown, err := server.URL(crypto.Keccak256([]byte("unknown")))
+	require.NoError(t, err)
+	resp, _ = get(t, unknown, nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	expired, err := server.signedURL(ref.Hash, time.Now().Add(-time.Second))
+	require.NoError(t, err)
+	resp, _ = get(t, expired, nil)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode, "Expired URLs are rejected")
+
+	require.NoError(t, server.RotateKey())
+	resp, _ = get(t, url, nil)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode, "URLs signed with a previous key are rejected")
+}
+
+func TestServerContentTypes(t *testing.T) {
+	manager := NewManager(&memoryBackend{blobs: make(map[string][]byte)}, memoryStore{}, nil, DefaultConfig())
+	defer manager.Stop()
+	server := NewServer(manager, ServerConfig{})
+	require.NoError(t, server.Start())
+	defer server.Stop() // nolint: errcheck
+
+	for _, tc := range []struct {
+		contentType string
+		served      string
+		disposition string
+	}{
+		{"audio/ogg; codecs=opus", "audio/ogg", "inline"},
+		{"image/svg+xml", "application/octet-stream", "attachment"},
+		{"text/html", "application/octet-stream", "attachment"},
+		{"", "application/octet-stream", "attachment"},
+	} {
+		ref, err := manager.Upload(context.Background(), []byte(tc.contentType+" data"), tc.contentType)
+		require.NoError(t, err)
+		url, err := server.URL(ref.Hash)
+		require.NoError(t, err)
+		resp, _ := get(t, url, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, tc.served, resp.Header.Get("Content-Type"), tc.contentType)
+		require.Equal(t, tc.disposition, resp.Header.Get("Content-Disposition"), tc.contentType)
+		require.Equal(t, "sandbox", resp.Header.Get("Content-Security-Policy"))
+	}
+}
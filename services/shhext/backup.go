@@ -0,0 +1,121 @@
+package shhext
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/services/shhext/chat"
+	"github.com/status-im/status-go/services/shhext/contacts"
+)
+
+// BackupData is the chat data of the account, as stored in backups by status_createBackup.
+type BackupData struct {
+	Contacts []contacts.Contact `json:"contacts"`
+	// Installations are the IDs of the enabled installations paired with the account.
+	Installations []string `json:"installations"`
+	// Bundles are the bundles of the account and of its contacts, encoded as contact codes.
+	Bundles []string `json:"bundles"`
+}
+
+// Backup returns the contacts, the paired installations and the bundles of the account.
+// The protocol must be initialized.
+func (s *Service) Backup(identity *ecdsa.PrivateKey) (interface{}, error) {
+	if s.protocol == nil {
+		return nil, errProtocolNotInitialized
+	}
+
+	data := BackupData{Contacts: []contacts.Contact{}, Installations: []string{}, Bundles: []string{}}
+	installations, err := s.protocol.Installations(&identity.PublicKey, identity)
+	if err != nil {
+		return nil, err
+	}
+	data.Installations = append(data.Installations, installations...)
+	bundle, err := s.protocol.GetBundle(identity)
+	if err != nil {
+		return nil, err
+	}
+	if err := data.addBundle(bundle); err != nil {
+		return nil, err
+	}
+
+	contactList, err := s.contacts.Contacts()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range contactList {
+		data.Contacts = append(data.Contacts, c)
+		publicKey, err := contactPublicKey(c.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		bundle, err := s.protocol.GetPublicBundle(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		if err := data.addBundle(bundle); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// RestoreBackup restores the bundles, the paired installations and the contacts of a backup
+// created by Backup. The installation that created the backup is restored disabled, it must be
+// enabled again if it's still used. The protocol must be initialized.
+func (s *Service) RestoreBackup(identity *ecdsa.PrivateKey, raw json.RawMessage) error {
+	if s.protocol == nil {
+		return errProtocolNotInitialized
+	}
+
+	var data BackupData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return err
+	}
+	for _, encoded := range data.Bundles {
+		bundle, err := chat.FromBase64(encoded)
+		if err != nil {
+			return err
+		}
+		if err := s.protocol.RestoreBundle(identity, bundle); err != nil {
+			return err
+		}
+	}
+	for _, installationID := range data.Installations {
+		if err := s.EnableInstallation(&identity.PublicKey, installationID); err != nil {
+			return err
+		}
+	}
+	for _, c := range data.Contacts {
+		publicKey, err := hexutil.Decode(c.PublicKey)
+		if err != nil {
+			return err
+		}
+		if _, err := s.contacts.Add(c.PublicKey, c.Name); err != nil {
+			return err
+		}
+		s.acceptContact(publicKey)
+	}
+	return nil
+}
+
+func (d *BackupData) addBundle(bundle *chat.Bundle) error {
+	if bundle == nil {
+		return nil
+	}
+	encoded, err := bundle.ToBase64()
+	if err != nil {
+		return err
+	}
+	d.Bundles = append(d.Bundles, encoded)
+	return nil
+}
+
+func contactPublicKey(publicKey string) (*ecdsa.PublicKey, error) {
+	bytes, err := hexutil.Decode(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.UnmarshalPubkey(bytes)
+}
@@ -0,0 +1,98 @@
+package shhext
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/services/shhext/content"
+	"github.com/status-im/status-go/services/shhext/history"
+	"github.com/status-im/status-go/services/shhext/notifications"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// blocked returns true if the messages signed by a public key must be dropped.
+func (s *Service) blocked(publicKey []byte) bool {
+	if s.blocking == nil || len(publicKey) == 0 {
+		return false
+	}
+	blocked, err := s.blocking.Blocked(hexutil.Encode(publicKey))
+	if err != nil {
+		logger.Error("failed to check blocked contact", "err", err)
+		return false
+	}
+	return blocked
+}
+
+// dropBlocked drops the messages of blocked identities, before they are
+// reassembled or decrypted.
+func (s *Service) dropBlocked(messages []*whisper.Message) []*whisper.Message {
+	if s.blocking == nil {
+		return messages
+	}
+	result := make([]*whisper.Message, 0, len(messages))
+	for _, msg := range messages {
+		if s.blocked(msg.Sig) {
+			logger.Debug("dropping message of a blocked contact", "hash", hexutil.Encode(msg.Hash))
+			continue
+		}
+		result = append(result, msg)
+	}
+	return result
+}
+
+// chatMuted returns true if a chat is muted locally, or if it can't be checked.
+func (s *Service) chatMuted(chatID string) bool {
+	if s.blocking == nil {
+		return false
+	}
+	muted, err := s.blocking.Muted(chatID)
+	if err != nil {
+		logger.Error("failed to check muted chat", "chat", chatID, "err", err)
+		return true
+	}
+	return muted
+}
+
+// notifyMessages sends a notification signal for each chat message received in a chat
+// that is neither muted locally nor in the notification preferences of the account,
+// and that the notification rules of the account deem worth a notification. It also
+// sends a signal grouping the notifications of each chat, including those deferred by
+// quiet hours that are over, so that clients don't have to group them.
+func (s *Service) notifyMessages(messages []*whisper.Message) {
+	if s.blocking == nil || s.notifications == nil || s.notifyGroups == nil {
+		return
+	}
+	all, err := s.notifications.Preferences()
+	if err != nil {
+		logger.Error("failed to get notification preferences", "err", err)
+		return
+	}
+	handler := EnvelopeSignalHandler{}
+	now := time.Now()
+	received := make([]notifications.Received, 0, len(messages))
+	for _, msg := range messages {
+		message, payload, ok := decodeContentMessage(msg.Sig, msg.Payload)
+		if !ok || message.Kind != content.KindRegular {
+			continue
+		}
+		chatID := history.PublicChatID(msg.Topic)
+		if msg.Dst != nil {
+			chatID = history.DirectChatID(msg.Sig)
+		}
+		r := notifications.Received{
+			MessageID: message.ID,
+			ChatID:    chatID,
+			Message:   notifications.Message{Author: message.Author, Direct: msg.Dst != nil, Content: payload.Content},
+		}
+		received = append(received, r)
+		if s.chatMuted(chatID) || !all.Rules.Notify(r.Message, now) {
+			continue
+		}
+		if notification, ok := all.ForChat(chatID).Notification(message.ID, message.Author, payload.Content); ok {
+			handler.MessageNotification(notification)
+		}
+	}
+	for _, group := range s.notifyGroups.Build(all, received, now) {
+		handler.NotificationGroup(group)
+	}
+}
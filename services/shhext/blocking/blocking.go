@@ -0,0 +1,180 @@
+package blocking
+
+import (
+	"sort"
+	"sync"
+)
+
+// Store persists the blocked identities and the muted chats.
+type Store interface {
+	// BlockContact saves a blocked identity, by hex-encoded public key.
+	BlockContact(publicKey string) error
+	UnblockContact(publicKey string) error
+	GetBlockedContacts() ([]string, error)
+	// MuteChat saves a muted chat, by chat ID.
+	MuteChat(chatID string) error
+	UnmuteChat(chatID string) error
+	GetMutedChats() ([]string, error)
+}
+
+// Manager keeps the blocked identities and the muted chats of the account. They are
+// cached in memory, as they are checked for every message received.
+type Manager struct {
+	store Store
+
+	mu      sync.Mutex
+	blocked map[string]struct{}
+	muted   map[string]struct{}
+}
+
+// NewManager returns a new Manager.
+func NewManager(store Store) *Manager {
+	return &Manager{store: store}
+}
+
+// Block blocks an identity: its messages are dropped before being decrypted.
+// It returns false if the identity was already blocked.
+func (m *Manager) Block(publicKey string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.load(); err != nil {
+		return false, err
+	}
+	if _, ok := m.blocked[publicKey]; ok {
+		return false, nil
+	}
+	if err := m.store.BlockContact(publicKey); err != nil {
+		return false, err
+	}
+	m.blocked[publicKey] = struct{}{}
+	return true, nil
+}
+
+// Unblock unblocks an identity. It returns false if the identity was not blocked.
+func (m *Manager) Unblock(publicKey string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.load(); err != nil {
+		return false, err
+	}
+	if _, ok := m.blocked[publicKey]; !ok {
+		return false, nil
+	}
+	if err := m.store.UnblockContact(publicKey); err != nil {
+		return false, err
+	}
+	delete(m.blocked, publicKey)
+	return true, nil
+}
+
+// Blocked returns true if an identity is blocked.
+func (m *Manager) Blocked(publicKey string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.load(); err != nil {
+		return false, err
+	}
+	_, ok := m.blocked[publicKey]
+	return ok, nil
+}
+
+// BlockedContacts returns the blocked identities, sorted.
+func (m *Manager) BlockedContacts() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return sorted(m.blocked), nil
+}
+
+// Mute mutes a chat: its messages are delivered but don't trigger notifications.
+// It returns false if the chat was already muted.
+func (m *Manager) Mute(chatID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.load(); err != nil {
+		return false, err
+	}
+	if _, ok := m.muted[chatID]; ok {
+		return false, nil
+	}
+	if err := m.store.MuteChat(chatID); err != nil {
+		return false, err
+	}
+	m.muted[chatID] = struct{}{}
+	return true, nil
+}
+
+// Unmute unmutes a chat. It returns false if the chat was not muted.
+func (m *Manager) Unmute(chatID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.load(); err != nil {
+		return false, err
+	}
+	if _, ok := m.muted[chatID]; !ok {
+		return false, nil
+	}
+	if err := m.store.UnmuteChat(chatID); err != nil {
+		return false, err
+	}
+	delete(m.muted, chatID)
+	return true, nil
+}
+
+// Muted returns true if a chat is muted.
+func (m *Manager) Muted(chatID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.load(); err != nil {
+		return false, err
+	}
+	_, ok := m.muted[chatID]
+	return ok, nil
+}
+
+// MutedChats returns the muted chats, sorted.
+func (m *Manager) MutedChats() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return sorted(m.muted), nil
+}
+
+// load must be called with the lock held.
+func (m *Manager) load() error {
+	if m.blocked != nil {
+		return nil
+	}
+	blocked, err := m.store.GetBlockedContacts()
+	if err != nil {
+		return err
+	}
+	muted, err := m.store.GetMutedChats()
+	if err != nil {
+		return err
+	}
+	m.blocked = toSet(blocked)
+	m.muted = toSet(muted)
+	return nil
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+func sorted(set map[string]struct{}) []string {
+	values := make([]string, 0, len(set))
+	for v := range set {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return values
+}
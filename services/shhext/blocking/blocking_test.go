@@ -0,0 +1,118 @@
+package blocking
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	blocked map[string]bool
+	muted   map[string]bool
+	loads   int
+	fail    bool
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{blocked: map[string]bool{}, muted: map[string]bool{}}
+}
+
+func (s *memoryStore) BlockContact(publicKey string) error {
+	if s.fail {
+		return errors.New("failed")
+	}
+	s.blocked[publicKey] = true
+	return nil
+}
+
+func (s *memoryStore) UnblockContact(publicKey string) error {
+	delete(s.blocked, publicKey)
+	return nil
+}
+
+func (s *memoryStore) GetBlockedContacts() ([]string, error) {
+	s.loads++
+	return keys(s.blocked), nil
+}
+
+func (s *memoryStore) MuteChat(chatID string) error {
+	s.muted[chatID] = true
+	return nil
+}
+
+func (s *memoryStore) UnmuteChat(chatID string) error {
+	delete(s.muted, chatID)
+	return nil
+}
+
+func (s *memoryStore) GetMutedChats() ([]string, error) {
+	return keys(s.muted), nil
+}
+
+func keys(m map[string]bool) []string {
+	var result []string
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
+
+func TestBlock(t *testing.T) {
+	store := newMemoryStore()
+	store.blocked["0x01"] = true
+	m := NewManager(store)
+
+	blocked, err := m.Blocked("0x01")
+	require.NoError(t, err)
+	require.True(t, blocked, "Blocked identities are loaded from the store")
+
+	changed, err := m.Block("0x02")
+	require.NoError(t, err)
+	require.True(t, changed)
+	changed, err = m.Block("0x02")
+	require.NoError(t, err)
+	require.False(t, changed)
+	contacts, err := m.BlockedContacts()
+	require.NoError(t, err)
+	require.Equal(t, []string{"0x01", "0x02"}, contacts)
+
+	changed, err = m.Unblock("0x01")
+	require.NoError(t, err)
+	require.True(t, changed)
+	blocked, err = m.Blocked("0x01")
+	require.NoError(t, err)
+	require.False(t, blocked)
+	require.Equal(t, map[string]bool{"0x02": true}, store.blocked)
+	require.Equal(t, 1, store.loads, "The store is loaded once")
+
+	store.fail = true
+	_, err = m.Block("0x03")
+	require.Error(t, err)
+	blocked, err = m.Blocked("0x03")
+	require.NoError(t, err)
+	require.False(t, blocked, "Identities are not blocked if they can't be saved")
+}
+
+func TestMute(t *testing.T) {
+	store := newMemoryStore()
+	m := NewManager(store)
+
+	changed, err := m.Mute("chat")
+	require.NoError(t, err)
+	require.True(t, changed)
+	muted, err := m.Muted("chat")
+	require.NoError(t, err)
+	require.True(t, muted)
+	chats, err := m.MutedChats()
+	require.NoError(t, err)
+	require.Equal(t, []string{"chat"}, chats)
+
+	changed, err = m.Unmute("chat")
+	require.NoError(t, err)
+	require.True(t, changed)
+	changed, err = m.Unmute("chat")
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Empty(t, store.muted)
+}
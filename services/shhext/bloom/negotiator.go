@@ -0,0 +1,175 @@
+package bloom
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// Whisper advertises a bloom filter to peers, which send only the envelopes
+// that match it.
+type Whisper interface {
+	BloomFilter() []byte
+	SetBloomFilter([]byte) error
+}
+
+// Status is the bloom filter advertised to peers.
+type Status struct {
+	Bloom hexutil.Bytes `json:"bloom"`
+	// Full is true if all envelopes are received.
+	Full bool `json:"full"`
+	// Topics are the negotiated topics, or nil if the bloom filter was not narrowed.
+	// Topics of the filters installed since then are also matched by the bloom filter.
+	Topics []whisper.TopicType `json:"topics"`
+	// Decoys are the topics matched by the bloom filter so that peers can't tell which
+	// topics are in use.
+	Decoys []whisper.TopicType `json:"decoys,omitempty"`
+}
+
+// Negotiator narrows the bloom filter advertised to peers to the topics that
+// are in use, instead of a filter matching all the traffic, so that peers
+// don't send envelopes that are dropped anyway.
+type Negotiator struct {
+	w    Whisper
+	base []whisper.TopicType
+
+	mu     sync.Mutex
+	topics []whisper.TopicType
+	decoys []whisper.TopicType
+}
+
+// NewNegotiator returns a new Negotiator. Base topics are always advertised.
+func NewNegotiator(w Whisper, base ...whisper.TopicType) *Negotiator {
+	return &Negotiator{w: w, base: base}
+}
+
+// SetTopics advertises a bloom filter that matches only the base topics and
+// the given topics. Peers are notified only if the bloom filter changed.
+func (n *Negotiator) SetTopics(topics []whisper.TopicType) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.setTopics(append(append([]whisper.TopicType{}, n.base...), topics...))
+}
+
+// AddTopics adds topics to the negotiated topics, until they are set again.
+// It returns the topics that were not negotiated, or nil if the bloom filter
+// was not narrowed, as it matches all the topics.
+func (n *Negotiator) AddTopics(topics []whisper.TopicType) ([]whisper.TopicType, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.topics == nil {
+		return nil, nil
+	}
+
+	negotiated := make(map[whisper.TopicType]struct{}, len(n.topics))
+	for _, topic := range n.topics {
+		negotiated[topic] = struct{}{}
+	}
+	var added []whisper.TopicType
+	for _, topic := range topics {
+		if _, exist := negotiated[topic]; !exist {
+			negotiated[topic] = struct{}{}
+			added = append(added, topic)
+		}
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	return added, n.setTopics(append(append([]whisper.TopicType{}, n.topics...), added...))
+}
+
+// Restore advertises again the bloom filter of the negotiated topics if whisper
+// advertises a different one, for instance because it was replaced by another
+// component. It returns true if the bloom filter was restored.
+func (n *Negotiator) Restore() (bool, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.topics == nil {
+		return false, nil
+	}
+	bloom := n.bloom(n.topics)
+	if bytes.Equal(bloom, n.w.BloomFilter()) {
+		return false, nil
+	}
+	return true, n.w.SetBloomFilter(bloom)
+}
+
+// SetDecoys sets the decoy topics, matched by the bloom filter in addition to the
+// negotiated topics. They replace the decoys set previously.
+func (n *Negotiator) SetDecoys(decoys []whisper.TopicType) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.decoys = decoys
+	if n.topics == nil {
+		return nil
+	}
+	bloom := n.bloom(n.topics)
+	if bytes.Equal(bloom, n.w.BloomFilter()) {
+		return nil
+	}
+	return n.w.SetBloomFilter(bloom)
+}
+
+// bloom returns the bloom filter of the topics and the decoys. It must be called with
+// the lock held.
+func (n *Negotiator) bloom(topics []whisper.TopicType) []byte {
+	return TopicsToBloom(append(append([]whisper.TopicType{}, topics...), n.decoys...))
+}
+
+// setTopics must be called with the lock held.
+func (n *Negotiator) setTopics(topics []whisper.TopicType) error {
+	set := make(map[whisper.TopicType]struct{})
+	for _, topic := range topics {
+		set[topic] = struct{}{}
+	}
+	negotiated := make([]whisper.TopicType, 0, len(set))
+	for topic := range set {
+		negotiated = append(negotiated, topic)
+	}
+	sort.Slice(negotiated, func(i, j int) bool {
+		return bytes.Compare(negotiated[i][:], negotiated[j][:]) < 0
+	})
+
+	bloom := n.bloom(negotiated)
+	if !bytes.Equal(bloom, n.w.BloomFilter()) {
+		if err := n.w.SetBloomFilter(bloom); err != nil {
+			return err
+		}
+	}
+	n.topics = negotiated
+	return nil
+}
+
+// Status returns the bloom filter advertised to peers.
+func (n *Negotiator) Status() Status {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	status := StatusOf(n.w)
+	status.Topics = n.topics
+	status.Decoys = n.decoys
+	return status
+}
+
+// StatusOf returns the bloom filter advertised by whisper, which was not negotiated.
+func StatusOf(w Whisper) Status {
+	bloom := w.BloomFilter()
+	if bloom == nil {
+		// Whisper doesn't filter envelopes without a bloom filter.
+		return Status{Bloom: whisper.MakeFullNodeBloom(), Full: true}
+	}
+	return Status{Bloom: bloom, Full: bytes.Equal(bloom, whisper.MakeFullNodeBloom())}
+}
+
+// TopicsToBloom returns the bloom filter that matches the given topics.
+func TopicsToBloom(topics []whisper.TopicType) []byte {
+	bloom := make([]byte, whisper.BloomFilterSize)
+	for _, topic := range topics {
+		for i, b := range whisper.TopicToBloom(topic) {
+			bloom[i] |= b
+		}
+	}
+	return bloom
+}
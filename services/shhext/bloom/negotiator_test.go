@@ -0,0 +1,104 @@
+package bloom
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+)
+
+type whisperMock struct {
+	bloom   []byte
+	updates int
+}
+
+func (w *whisperMock) BloomFilter() []byte {
+	return w.bloom
+}
+
+func (w *whisperMock) SetBloomFilter(bloom []byte) error {
+	w.bloom = bloom
+	w.updates++
+	return nil
+}
+
+func topic(name string) whisper.TopicType {
+	return whisper.BytesToTopic(crypto.Keccak256([]byte(name)))
+}
+
+func TestNegotiator(t *testing.T) {
+	w := &whisperMock{}
+	base := topic("contact-discovery")
+	chat := topic("status")
+	other := topic("ethereum")
+	n := NewNegotiator(w, base)
+
+	status := n.Status()
+	require.True(t, status.Full, "Whisper without a bloom filter receives all envelopes")
+	require.Nil(t, status.Topics)
+
+	require.NoError(t, n.SetTopics([]whisper.TopicType{chat, chat}))
+	status = n.Status()
+	require.False(t, status.Full)
+	require.Len(t, status.Topics, 2, "Duplicated topics are removed")
+	require.True(t, whisper.BloomFilterMatch(w.bloom, whisper.TopicToBloom(base)))
+	require.True(t, whisper.BloomFilterMatch(w.bloom, whisper.TopicToBloom(chat)))
+	require.False(t, whisper.BloomFilterMatch(w.bloom, whisper.TopicToBloom(other)))
+
+	require.NoError(t, n.SetTopics([]whisper.TopicType{chat}))
+	require.Equal(t, 1, w.updates, "Peers are not notified if the bloom filter didn't change")
+
+	require.NoError(t, n.SetTopics(nil))
+	require.Equal(t, []whisper.TopicType{base}, n.Status().Topics)
+	require.False(t, whisper.BloomFilterMatch(w.bloom, whisper.TopicToBloom(chat)), "Topics of inactive chats are removed")
+}
+
+func TestNegotiatorRepair(t *testing.T) {
+	w := &whisperMock{}
+	base := topic("contact-discovery")
+	chat := topic("status")
+	n := NewNegotiator(w, base)
+
+	added, err := n.AddTopics([]whisper.TopicType{chat})
+	require.NoError(t, err)
+	require.Nil(t, added, "All topics are matched if the bloom filter was not narrowed")
+	restored, err := n.Restore()
+	require.NoError(t, err)
+	require.False(t, restored)
+
+	require.NoError(t, n.SetTopics(nil))
+	added, err = n.AddTopics([]whisper.TopicType{base, chat})
+	require.NoError(t, err)
+	require.Equal(t, []whisper.TopicType{chat}, added)
+	require.True(t, whisper.BloomFilterMatch(w.bloom, whisper.TopicToBloom(chat)))
+
+	w.bloom = make([]byte, whisper.BloomFilterSize)
+	restored, err = n.Restore()
+	require.NoError(t, err)
+	require.True(t, restored)
+	require.True(t, whisper.BloomFilterMatch(w.bloom, whisper.TopicToBloom(base)))
+	require.True(t, whisper.BloomFilterMatch(w.bloom, whisper.TopicToBloom(chat)))
+}
+
+func TestNegotiatorDecoys(t *testing.T) {
+	w := &whisperMock{}
+	chat := topic("status")
+	decoy := topic("decoy")
+	n := NewNegotiator(w)
+
+	require.NoError(t, n.SetDecoys([]whisper.TopicType{decoy}))
+	require.Nil(t, w.bloom, "Decoys are not advertised until the bloom filter is narrowed")
+
+	require.NoError(t, n.SetTopics([]whisper.TopicType{chat}))
+	require.True(t, whisper.BloomFilterMatch(w.bloom, whisper.TopicToBloom(decoy)))
+	status := n.Status()
+	require.Equal(t, []whisper.TopicType{chat}, status.Topics)
+	require.Equal(t, []whisper.TopicType{decoy}, status.Decoys)
+
+	require.NoError(t, n.SetTopics(nil))
+	require.True(t, whisper.BloomFilterMatch(w.bloom, whisper.TopicToBloom(decoy)), "Decoys are kept when the topics change")
+
+	require.NoError(t, n.SetDecoys(nil))
+	require.False(t, whisper.BloomFilterMatch(w.bloom, whisper.TopicToBloom(decoy)))
+}
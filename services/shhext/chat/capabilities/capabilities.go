@@ -0,0 +1,60 @@
+package capabilities
+
+import "sort"
+
+// Version is the version of the chat protocol implemented by this client.
+// Version 0 is used by clients that don't advertise their capabilities.
+const Version uint32 = 1
+
+// Features of the chat protocol that clients advertise, so that they are
+// used only with contacts whose clients support them.
+const (
+	FeatureGroups    = "groups"
+	FeatureImages    = "images"
+	FeatureReactions = "reactions"
+)
+
+// features are the features supported by this client.
+var features = []string{FeatureGroups, FeatureImages, FeatureReactions}
+
+// Set is the protocol version and features supported by a client.
+type Set struct {
+	Version  uint32   `json:"version"`
+	Features []string `json:"features"`
+}
+
+// Supported returns the capabilities of this client.
+func Supported() Set {
+	return Set{Version: Version, Features: append([]string{}, features...)}
+}
+
+// Has returns true if the feature is in the set.
+func (s Set) Has(feature string) bool {
+	for _, f := range s.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// Negotiate returns the capabilities supported by this client and by all the given
+// peers: the lowest version and the features supported by all of them, in order.
+// Peers are downgraded to, never upgraded from, this client's capabilities.
+func Negotiate(peers ...Set) Set {
+	negotiated := Supported()
+	for _, peer := range peers {
+		if peer.Version < negotiated.Version {
+			negotiated.Version = peer.Version
+		}
+		var common []string
+		for _, feature := range negotiated.Features {
+			if peer.Has(feature) {
+				common = append(common, feature)
+			}
+		}
+		negotiated.Features = common
+	}
+	sort.Strings(negotiated.Features)
+	return negotiated
+}
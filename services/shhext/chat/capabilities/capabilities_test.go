@@ -0,0 +1,23 @@
+package capabilities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	require.Equal(t, Set{Version: Version, Features: []string{FeatureGroups, FeatureImages, FeatureReactions}}, Negotiate())
+
+	legacy := Set{}
+	require.Equal(t, Set{Version: 0}, Negotiate(Supported(), legacy), "Legacy peers don't support any feature")
+
+	newer := Set{Version: Version + 1, Features: []string{"unknown", FeatureReactions, FeatureGroups}}
+	require.Equal(t, Set{Version: Version, Features: []string{FeatureGroups, FeatureReactions}}, Negotiate(newer),
+		"Unknown features of newer peers are ignored")
+}
+
+func TestHas(t *testing.T) {
+	require.True(t, Supported().Has(FeatureImages))
+	require.False(t, Set{}.Has(FeatureImages))
+}
@@ -20,6 +20,49 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+type ChatMessagePayload_Kind int32
+
+const (
+	// Regular message
+	ChatMessagePayload_MESSAGE ChatMessagePayload_Kind = 0
+	// Reaction to the target message, the content is the emoji
+	ChatMessagePayload_REACTION ChatMessagePayload_Kind = 1
+	// Edit of the target message, the content replaces its content
+	ChatMessagePayload_EDIT ChatMessagePayload_Kind = 2
+	// Deletion of the target message
+	ChatMessagePayload_DELETION ChatMessagePayload_Kind = 3
+	// Read receipt of the messages read_ids
+	ChatMessagePayload_READ ChatMessagePayload_Kind = 4
+	// Ephemeral typing notification
+	ChatMessagePayload_TYPING ChatMessagePayload_Kind = 5
+)
+
+var ChatMessagePayload_Kind_name = map[int32]string{
+	0: "MESSAGE",
+	1: "REACTION",
+	2: "EDIT",
+	3: "DELETION",
+	4: "READ",
+	5: "TYPING",
+}
+
+var ChatMessagePayload_Kind_value = map[string]int32{
+	"MESSAGE":  0,
+	"REACTION": 1,
+	"EDIT":     2,
+	"DELETION": 3,
+	"READ":     4,
+	"TYPING":   5,
+}
+
+func (x ChatMessagePayload_Kind) String() string {
+	return proto.EnumName(ChatMessagePayload_Kind_name, int32(x))
+}
+
+func (ChatMessagePayload_Kind) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_8c585a45e2093e54, []int{0, 0}
+}
+
 // What is sent through the wire
 type ChatMessagePayload struct {
 	// Message content
@@ -29,7 +72,19 @@ type ChatMessagePayload struct {
 	// Message type
 	MessageType string `protobuf:"bytes,3,opt,name=message_type,json=messageType,proto3" json:"message_type,omitempty"`
 	// Sender's clock value for message ordering
-	ClockValue           float64  `protobuf:"fixed64,4,opt,name=clock_value,json=clockValue,proto3" json:"clock_value,omitempty"`
+	ClockValue float64 `protobuf:"fixed64,4,opt,name=clock_value,json=clockValue,proto3" json:"clock_value,omitempty"`
+	// Message kind
+	Kind ChatMessagePayload_Kind `protobuf:"varint,5,opt,name=kind,proto3,enum=chat.ChatMessagePayload_Kind" json:"kind,omitempty"`
+	// ID of the message replied to, for regular messages
+	ReplyTo string `protobuf:"bytes,6,opt,name=reply_to,json=replyTo,proto3" json:"reply_to,omitempty"`
+	// ID of the message reacted to, edited or deleted
+	TargetId string `protobuf:"bytes,7,opt,name=target_id,json=targetId,proto3" json:"target_id,omitempty"`
+	// Whether the reaction is retracted
+	Retracted bool `protobuf:"varint,8,opt,name=retracted,proto3" json:"retracted,omitempty"`
+	// IDs of the messages read, for read receipts
+	ReadIds []string `protobuf:"bytes,9,rep,name=read_ids,json=readIds,proto3" json:"read_ids,omitempty"`
+	// Whether the author started or stopped typing, for typing notifications
+	Typing               bool     `protobuf:"varint,10,opt,name=typing,proto3" json:"typing,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -88,6 +143,48 @@ func (m *ChatMessagePayload) GetClockValue() float64 {
 	return 0
 }
 
+func (m *ChatMessagePayload) GetKind() ChatMessagePayload_Kind {
+	if m != nil {
+		return m.Kind
+	}
+	return ChatMessagePayload_MESSAGE
+}
+
+func (m *ChatMessagePayload) GetReplyTo() string {
+	if m != nil {
+		return m.ReplyTo
+	}
+	return ""
+}
+
+func (m *ChatMessagePayload) GetTargetId() string {
+	if m != nil {
+		return m.TargetId
+	}
+	return ""
+}
+
+func (m *ChatMessagePayload) GetRetracted() bool {
+	if m != nil {
+		return m.Retracted
+	}
+	return false
+}
+
+func (m *ChatMessagePayload) GetReadIds() []string {
+	if m != nil {
+		return m.ReadIds
+	}
+	return nil
+}
+
+func (m *ChatMessagePayload) GetTyping() bool {
+	if m != nil {
+		return m.Typing
+	}
+	return false
+}
+
 // ContactUpdatePayload is sent when a user updates its profile
 type ContactUpdatePayload struct {
 	// Contact display name
@@ -308,6 +405,7 @@ func (m *ChatProtocolMessage) GetPayload() []byte {
 }
 
 func init() {
+	proto.RegisterEnum("chat.ChatMessagePayload_Kind", ChatMessagePayload_Kind_name, ChatMessagePayload_Kind_value)
 	proto.RegisterType((*ChatMessagePayload)(nil), "chat.ChatMessagePayload")
 	proto.RegisterType((*ContactUpdatePayload)(nil), "chat.ContactUpdatePayload")
 	proto.RegisterType((*OneToOneRPC)(nil), "chat.OneToOneRPC")
@@ -318,25 +416,35 @@ func init() {
 func init() { proto.RegisterFile("chat.proto", fileDescriptor_8c585a45e2093e54) }
 
 var fileDescriptor_8c585a45e2093e54 = []byte{
-	// 314 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x92, 0xcf, 0x4a, 0xc3, 0x40,
-	0x10, 0xc6, 0x59, 0x5b, 0xd4, 0x4e, 0x2a, 0x94, 0xd5, 0x43, 0xd0, 0x83, 0x35, 0x5e, 0x7a, 0xaa,
-	0xa0, 0xbe, 0x41, 0x4f, 0x22, 0xd2, 0x12, 0xaa, 0xd7, 0xb0, 0x6e, 0xa6, 0x7f, 0xe8, 0x66, 0x77,
-	0xc9, 0xae, 0x42, 0x5f, 0xc0, 0x37, 0xf0, 0x7d, 0x65, 0x36, 0x1b, 0x35, 0xe0, 0xc1, 0xdb, 0x37,
-	0x5f, 0x86, 0xf9, 0x7e, 0x93, 0x59, 0x00, 0xb9, 0x11, 0x7e, 0x6a, 0x6b, 0xe3, 0x0d, 0xef, 0x93,
-	0xce, 0x3e, 0x19, 0xf0, 0xd9, 0x46, 0xf8, 0x27, 0x74, 0x4e, 0xac, 0x71, 0x21, 0xf6, 0xca, 0x88,
-	0x92, 0xa7, 0x70, 0x24, 0x8d, 0xf6, 0xa8, 0x7d, 0xca, 0xc6, 0x6c, 0x32, 0xc8, 0xdb, 0x92, 0x5f,
-	0xc1, 0x30, 0xca, 0xc2, 0xef, 0x2d, 0xa6, 0x07, 0xe1, 0x73, 0x12, 0xbd, 0xe5, 0xde, 0x22, 0xb5,
-	0x54, 0xcd, 0xb8, 0xa6, 0xa5, 0xd7, 0xb4, 0x44, 0x2f, 0xb4, 0x5c, 0x42, 0x22, 0x95, 0x91, 0xbb,
-	0xe2, 0x5d, 0xa8, 0x37, 0x4c, 0xfb, 0x63, 0x36, 0x61, 0x39, 0x04, 0xeb, 0x85, 0x9c, 0xec, 0x83,
-	0xc1, 0xd9, 0xcc, 0x68, 0x2f, 0xa4, 0x7f, 0xb6, 0xa5, 0xf0, 0xdf, 0x64, 0x1c, 0xfa, 0x5a, 0x54,
-	0x18, 0xb1, 0x82, 0xe6, 0xd7, 0x70, 0x62, 0x6b, 0xb3, 0xda, 0x2a, 0x2c, 0xb6, 0x95, 0x58, 0xb7,
-	0x50, 0xc3, 0x68, 0x3e, 0x90, 0x47, 0x2b, 0x89, 0xb2, 0xac, 0xd1, 0xb9, 0x08, 0xd4, 0x96, 0xfc,
-	0x02, 0x06, 0x2b, 0x59, 0x15, 0xde, 0xec, 0x50, 0x07, 0x94, 0x41, 0x7e, 0xbc, 0x92, 0xd5, 0x92,
-	0xea, 0xec, 0x11, 0x92, 0xb9, 0xc6, 0xa5, 0x99, 0x6b, 0xcc, 0x17, 0x33, 0x3e, 0x82, 0x9e, 0xab,
-	0x65, 0x4c, 0x27, 0x49, 0x4e, 0xe9, 0x7c, 0x8c, 0x24, 0x49, 0x49, 0xb6, 0xa1, 0x0d, 0x49, 0xc3,
-	0xbc, 0x2d, 0x33, 0x05, 0xa3, 0xce, 0x52, 0xff, 0x9d, 0x78, 0xdf, 0x9d, 0x98, 0xdc, 0x9e, 0x4f,
-	0xc3, 0x25, 0xff, 0xfa, 0x43, 0x3f, 0x69, 0x37, 0x70, 0x4a, 0xa7, 0x5d, 0xd0, 0xb9, 0xa5, 0x51,
-	0xf1, 0xc4, 0xbf, 0xf1, 0x58, 0x07, 0xef, 0xf5, 0x30, 0xbc, 0x8c, 0xbb, 0xaf, 0x00, 0x00, 0x00,
-	0xff, 0xff, 0xad, 0x31, 0x5d, 0x6c, 0x27, 0x02, 0x00, 0x00,
+	// 478 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x93, 0xdf, 0x8e, 0xd3, 0x3a,
+	0x10, 0xc6, 0x4f, 0x36, 0xd9, 0x36, 0x99, 0xf4, 0xa0, 0xc8, 0x20, 0x64, 0xfe, 0x89, 0x10, 0x6e,
+	0x7a, 0x55, 0xc4, 0xc2, 0x0b, 0x54, 0x6d, 0xb4, 0x8a, 0x96, 0xdd, 0x56, 0xd9, 0x80, 0xc4, 0x55,
+	0x64, 0x6c, 0xb7, 0x1b, 0x35, 0xb1, 0xa3, 0xc4, 0x20, 0xe5, 0x05, 0x78, 0x10, 0x9e, 0x14, 0xd9,
+	0x71, 0x59, 0x2a, 0xb8, 0xe0, 0x6e, 0xe6, 0xe7, 0xc9, 0xcc, 0x17, 0x7f, 0x63, 0x00, 0x7a, 0x47,
+	0xd4, 0xa2, 0xed, 0xa4, 0x92, 0xc8, 0xd3, 0x71, 0xf2, 0xc3, 0x05, 0xb4, 0xba, 0x23, 0xea, 0x9a,
+	0xf7, 0x3d, 0xd9, 0xf3, 0x2d, 0x19, 0x6a, 0x49, 0x18, 0xc2, 0x30, 0xa5, 0x52, 0x28, 0x2e, 0x14,
+	0x76, 0x62, 0x67, 0x1e, 0xe4, 0xc7, 0x14, 0xbd, 0x82, 0x99, 0x0d, 0x4b, 0x35, 0xb4, 0x1c, 0x9f,
+	0x99, 0xe3, 0xd0, 0xb2, 0x62, 0x68, 0xb9, 0x2e, 0x69, 0xc6, 0x76, 0x63, 0x89, 0x3b, 0x96, 0x58,
+	0x66, 0x4a, 0x5e, 0x42, 0x48, 0x6b, 0x49, 0x0f, 0xe5, 0x37, 0x52, 0x7f, 0xe5, 0xd8, 0x8b, 0x9d,
+	0xb9, 0x93, 0x83, 0x41, 0x9f, 0x34, 0x41, 0x6f, 0xc1, 0x3b, 0x54, 0x82, 0xe1, 0xf3, 0xd8, 0x99,
+	0x3f, 0xb8, 0x78, 0xb1, 0x30, 0xc2, 0xff, 0x14, 0xba, 0xb8, 0xaa, 0x04, 0xcb, 0x4d, 0x29, 0x7a,
+	0x02, 0x7e, 0xc7, 0xdb, 0x7a, 0x28, 0x95, 0xc4, 0x93, 0x51, 0xb4, 0xc9, 0x0b, 0x89, 0x9e, 0x41,
+	0xa0, 0x48, 0xb7, 0xe7, 0xaa, 0xac, 0x18, 0x9e, 0x9a, 0x33, 0x7f, 0x04, 0x19, 0x43, 0xcf, 0x21,
+	0xe8, 0xb8, 0xea, 0x08, 0x55, 0x9c, 0x61, 0x3f, 0x76, 0xe6, 0x7e, 0x7e, 0x0f, 0xc6, 0xae, 0x84,
+	0x95, 0x15, 0xeb, 0x71, 0x10, 0xbb, 0x63, 0x57, 0xc2, 0x32, 0xd6, 0xa3, 0xc7, 0x30, 0x51, 0x43,
+	0x5b, 0x89, 0x3d, 0x06, 0xf3, 0x95, 0xcd, 0x92, 0x0d, 0x78, 0x5a, 0x16, 0x0a, 0x61, 0x7a, 0x9d,
+	0xde, 0xde, 0x2e, 0x2f, 0xd3, 0xe8, 0x3f, 0x34, 0x03, 0x3f, 0x4f, 0x97, 0xab, 0x22, 0xdb, 0xdc,
+	0x44, 0x0e, 0xf2, 0xc1, 0x4b, 0xd7, 0x59, 0x11, 0x9d, 0x69, 0xbe, 0x4e, 0x3f, 0xa4, 0x86, 0xbb,
+	0x9a, 0xe7, 0xe9, 0x72, 0x1d, 0x79, 0x08, 0x60, 0x52, 0x7c, 0xde, 0x66, 0x37, 0x97, 0xd1, 0x79,
+	0xf2, 0xdd, 0x81, 0x47, 0x2b, 0x29, 0x14, 0xa1, 0xea, 0x63, 0xcb, 0x88, 0xfa, 0x65, 0x13, 0x02,
+	0x4f, 0x90, 0x86, 0x5b, 0x8f, 0x4c, 0x8c, 0x5e, 0xc3, 0xff, 0x6d, 0x27, 0x77, 0x55, 0xcd, 0xcb,
+	0xaa, 0x21, 0xfb, 0xa3, 0x43, 0x33, 0x0b, 0x33, 0xcd, 0xb4, 0xbf, 0x84, 0xb1, 0x8e, 0xf7, 0xbd,
+	0x75, 0xe7, 0x98, 0xea, 0xab, 0xda, 0xd1, 0xa6, 0x54, 0xf2, 0xc0, 0x85, 0xf1, 0x25, 0xc8, 0xfd,
+	0x1d, 0x6d, 0x0a, 0x9d, 0x27, 0x57, 0x10, 0x6e, 0x04, 0x2f, 0xe4, 0x46, 0xf0, 0x7c, 0xbb, 0x42,
+	0x11, 0xb8, 0x7d, 0x47, 0xed, 0x74, 0x1d, 0x6a, 0xc2, 0x7a, 0x65, 0x47, 0xea, 0x50, 0x4f, 0x6a,
+	0x47, 0xb5, 0x66, 0xd2, 0x2c, 0x3f, 0xa6, 0x49, 0x0d, 0xd1, 0xc9, 0x4f, 0xfd, 0x6b, 0xc7, 0xf7,
+	0xa7, 0x1d, 0xc3, 0x8b, 0xa7, 0x76, 0x3b, 0xfe, 0x72, 0x43, 0xf7, 0xd3, 0xde, 0xc0, 0x43, 0xbd,
+	0x3e, 0x5b, 0xbd, 0xfb, 0x54, 0xd6, 0x76, 0x8d, 0x7e, 0x97, 0xe7, 0x9c, 0xc8, 0xfb, 0x32, 0x31,
+	0xcf, 0xe4, 0xdd, 0xcf, 0x01, 0x00, 0xcd, 0x0b, 0xdc, 0x72, 0x34, 0x03, 0x00, 0x00,
 }
@@ -0,0 +1,124 @@
+package compression
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sort"
+)
+
+// MaxPayloadSize is the maximum size of a decompressed payload, which is the
+// maximum size of a whisper message.
+const MaxPayloadSize = 1024 * 1024
+
+var (
+	// ErrUnknownDictionary is returned when a payload was compressed with a dictionary that isn't supported.
+	ErrUnknownDictionary = errors.New("unknown compression dictionary")
+	// ErrPayloadTooLarge is returned when a decompressed payload exceeds MaxPayloadSize.
+	ErrPayloadTooLarge = errors.New("decompressed payload is too large")
+)
+
+// Dictionary is a preset dictionary shared by all clients. Small payloads
+// can't be compressed on their own, but they have a lot in common with each
+// other, so they are compressed with a dictionary of the patterns they share.
+type Dictionary struct {
+	// Version identifies the dictionary in messages. Zero means no compression.
+	Version uint32
+	Data    []byte
+}
+
+// dictionaries are the released dictionaries, by version.
+var dictionaries = map[uint32]*Dictionary{
+	1: {Version: 1, Data: []byte(dictionaryV1)},
+}
+
+// Get returns the dictionary of a version, or nil if it is not supported.
+func Get(version uint32) *Dictionary {
+	return dictionaries[version]
+}
+
+// Versions returns the versions of the supported dictionaries, in increasing order.
+func Versions() []uint32 {
+	versions := make([]uint32, 0, len(dictionaries))
+	for version := range dictionaries {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
+
+// Negotiate returns the latest dictionary supported by this client and by all
+// the given peers, or nil if there isn't one.
+func Negotiate(peers ...[]uint32) *Dictionary {
+	if len(peers) == 0 {
+		return nil
+	}
+	var best *Dictionary
+	for _, version := range peers[0] {
+		d := Get(version)
+		if d == nil || (best != nil && best.Version >= version) {
+			continue
+		}
+		if supportedByAll(version, peers[1:]) {
+			best = d
+		}
+	}
+	return best
+}
+
+func supportedByAll(version uint32, peers [][]uint32) bool {
+	for _, versions := range peers {
+		found := false
+		for _, v := range versions {
+			if v == version {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Compress compresses a payload with a dictionary.
+func (d *Dictionary) Compress(payload []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriterDict(&buf, flate.BestCompression, d.Data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress decompresses a payload compressed with the same dictionary.
+func (d *Dictionary) Decompress(data []byte) ([]byte, error) {
+	r := flate.NewReaderDict(bytes.NewReader(data), d.Data)
+	defer r.Close()
+	payload, err := ioutil.ReadAll(io.LimitReader(r, MaxPayloadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > MaxPayloadSize {
+		return nil, ErrPayloadTooLarge
+	}
+	return payload, nil
+}
+
+// Decompress decompresses a payload compressed with the dictionary of a version.
+func Decompress(version uint32, data []byte) ([]byte, error) {
+	d := Get(version)
+	if d == nil {
+		return nil, ErrUnknownDictionary
+	}
+	return d.Decompress(data)
+}
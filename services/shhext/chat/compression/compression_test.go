@@ -0,0 +1,71 @@
+package compression
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var payloads = []string{
+	`["~#c4",["hey there","text/plain","~:public-group-user-message",154461712345601,1544617123456,["^ ","~:chat-id","status","~:text","hey there"]]]`,
+	`["~#c4",["ok","text/plain","~:user-message",154461798765400,1544617987654,["^ ","~:chat-id","0x04e5d1f5a0f0b1c6d2e3f4a5b6c7d8e9f0a1b2c3d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f6a7b8c9d0e1f2a3b4c5d6","~:text","ok"]]]`,
+	`["~#c7",[["~#set",["0x7a3f0c2b9d1e4f56"]],154461800000001]]`,
+}
+
+func flateSize(t *testing.T, payload []byte) int {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	require.NoError(t, err)
+	_, err = w.Write(payload)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Len()
+}
+
+func TestDictionaryCompression(t *testing.T) {
+	d := Get(1)
+	require.NotNil(t, d)
+	for _, payload := range payloads {
+		compressed, err := d.Compress([]byte(payload))
+		require.NoError(t, err)
+		require.True(t, len(compressed) < len(payload), "Payload %s is compressed", payload)
+		require.True(t, len(compressed) < flateSize(t, []byte(payload)), "Dictionary beats plain compression for %s", payload)
+
+		decompressed, err := Decompress(1, compressed)
+		require.NoError(t, err)
+		require.Equal(t, payload, string(decompressed))
+	}
+}
+
+func TestDecompressErrors(t *testing.T) {
+	_, err := Decompress(0, []byte{1})
+	require.Equal(t, ErrUnknownDictionary, err)
+
+	d := Get(1)
+	compressed, err := d.Compress(make([]byte, MaxPayloadSize+1))
+	require.NoError(t, err)
+	_, err = d.Decompress(compressed)
+	require.Equal(t, ErrPayloadTooLarge, err)
+}
+
+func TestNegotiate(t *testing.T) {
+	require.Nil(t, Negotiate())
+	require.Nil(t, Negotiate([]uint32{42}))
+	require.Nil(t, Negotiate(Versions(), nil), "Peers that didn't advertise dictionaries don't support compression")
+	require.Equal(t, uint32(1), Negotiate(Versions(), []uint32{1, 42}).Version)
+}
+
+func TestTrain(t *testing.T) {
+	var samples [][]byte
+	for i := 0; i < 50; i++ {
+		samples = append(samples, []byte(fmt.Sprintf(`{"type":"message","text":"%d","content-type":"text/plain"}`, i*7919)))
+	}
+	dictionary := Train(samples, 64)
+	require.True(t, len(dictionary) <= 64)
+	require.Contains(t, string(dictionary), `","content-type":"text/plain"}`)
+	require.Equal(t, dictionary, Train(samples, 64), "Training is deterministic")
+	require.Empty(t, Train(samples[:1], 64), "Patterns of a single sample are ignored")
+}
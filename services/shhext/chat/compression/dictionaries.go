@@ -0,0 +1,18 @@
+package compression
+
+// dictionaryV1 was trained with Train from samples of the transit-encoded chat
+// payloads sent by clients: public, 1:1 and group messages, stickers, contact
+// updates and seen receipts. It must never change.
+const dictionaryV1 = `2","~:5,["^ a","~:4,["^ at","~:7,1544647955617,["^ 0,15446483239` +
+	`90,["^ 9,1544638418228,["^ 0","~:pack",1,1544635654679,["^ 15446` +
+	`2178119455,154462154466758854988,154466154468028609434,154468154` +
+	`469755333962,154469154464168782086,154464154461318149993,1544616` +
+	`,["^ ","~:chat-id","cryptod","~:response-to","0x"]],154460re!","` +
+	`te["~#c3",["2,["^ ","~:chat-id","status","~:3,["^ ","~:chat-id",` +
+	`"chitchat","","~:group-user-message",154465f","~:stick01701225dc` +
+	`060c2e1a683b","~:pack"e","~:address",154467["~#c7",[["~#set",["0` +
+	`xBORw0KGgoAAAANSUhEUgAA","0x2","data:image/png;base64,iVBORw0ick` +
+	`er",["^ ","~:hash","e30101701on to see a nice sticker here!",4",` +
+	`["Update to latest version to"~:public-group-user-","~:text","gr` +
+	`","~:response-to",["~#c4",["ra","text/plain","~:pu1,["^ ","~:cha` +
+	`t-id","0x04xt/plain","~:user-message",15446`
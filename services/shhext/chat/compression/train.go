@@ -0,0 +1,101 @@
+package compression
+
+const (
+	// kmerSize is the length of the substrings counted to find common patterns.
+	kmerSize = 6
+	// segmentSize is the maximum length of the segments added to a dictionary.
+	segmentSize = 32
+	// minShare is the minimum share of the samples a pattern must occur in.
+	minShare = 0.05
+)
+
+// Train builds a dictionary of at most size bytes from the segments that occur
+// in the most samples. Patterns found in less than 5% of the samples, such as
+// the content of messages, are ignored as they are unlikely to help compressing
+// other payloads.
+//
+// Segments are chosen greedily by the number of samples their substrings occur
+// in, and the substrings of a chosen segment don't count anymore for the next
+// ones, so that a dictionary doesn't repeat itself. The most common segments
+// are placed at the end of the dictionary, where references are the cheapest.
+//
+// Train is deterministic, but the dictionaries used by the protocol must not be
+// trained again: once released, a dictionary version never changes.
+func Train(samples [][]byte, size int) []byte {
+	counts := make(map[string]int)
+	for _, sample := range samples {
+		seen := make(map[string]struct{})
+		for i := 0; i+kmerSize <= len(sample); i++ {
+			kmer := string(sample[i : i+kmerSize])
+			if _, exist := seen[kmer]; exist {
+				continue
+			}
+			seen[kmer] = struct{}{}
+			counts[kmer]++
+		}
+	}
+
+	min := int(minShare * float64(len(samples)))
+	if min < 2 {
+		min = 2
+	}
+	for kmer, count := range counts {
+		if count < min {
+			delete(counts, kmer)
+		}
+	}
+
+	var segments [][]byte
+	total := 0
+	for total < size {
+		segment := bestSegment(samples, counts)
+		if segment == nil {
+			break
+		}
+		for i := 0; i+kmerSize <= len(segment); i++ {
+			delete(counts, string(segment[i:i+kmerSize]))
+		}
+		segments = append(segments, segment)
+		total += len(segment)
+	}
+
+	dictionary := make([]byte, 0, total)
+	for i := len(segments) - 1; i >= 0; i-- {
+		dictionary = append(dictionary, segments[i]...)
+	}
+	if len(dictionary) > size {
+		dictionary = dictionary[len(dictionary)-size:]
+	}
+	return dictionary
+}
+
+// bestSegment returns the segment of the samples with the highest score, or nil
+// if no pattern is left.
+func bestSegment(samples [][]byte, counts map[string]int) []byte {
+	var best []byte
+	bestScore := 0
+	for _, sample := range samples {
+		for start := 0; start+kmerSize <= len(sample); start++ {
+			end := start + segmentSize
+			if end > len(sample) {
+				end = len(sample)
+			}
+			score, first, last := 0, -1, -1
+			for i := start; i+kmerSize <= end; i++ {
+				if count := counts[string(sample[i:i+kmerSize])]; count > 0 {
+					score += count
+					if first < 0 {
+						first = i
+					}
+					last = i
+				}
+			}
+			if score > bestScore {
+				// Bytes that are not part of a common pattern are trimmed.
+				best = sample[first : last+kmerSize]
+				bestScore = score
+			}
+		}
+	}
+	return best
+}
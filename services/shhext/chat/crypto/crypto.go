@@ -25,6 +25,16 @@ func Sign(content string, identity *ecdsa.PrivateKey) (string, error) {
 	return hex.EncodeToString(signature), nil
 }
 
+// SignWith signs a string like Sign, with a key that is only available through signHash.
+func SignWith(content string, signHash func(hash []byte) ([]byte, error)) (string, error) {
+	signature, err := signHash(crypto.Keccak256([]byte(content)))
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(signature), nil
+}
+
 // VerifySignatures verifys tuples of signatures content/hash/public key
 func VerifySignatures(signaturePairs [][3]string) error {
 	for _, signaturePair := range signaturePairs {
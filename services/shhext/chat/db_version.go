@@ -0,0 +1,162 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/crypto/sha3"
+	"github.com/status-im/status-go/services/shhext/reencryption"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// CipherSQLCipherDefault is the default sqlcipher configuration with 4096 bytes pages.
+	CipherSQLCipherDefault = 1
+
+	// KDFSha3 derives the database key from the hex encoded sha3 hash of the password.
+	KDFSha3 = 1
+	// KDFScrypt derives the database key from the hex encoded scrypt hash of the password.
+	// sqlcipher salts the key with the random salt of each database.
+	KDFScrypt = 2
+)
+
+// Parameters of KDFScrypt.
+const (
+	scryptN      = 1 << 14
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// scryptSalt separates the keys of the chat databases from other hashes of the password.
+var scryptSalt = []byte("status-go/chat-db")
+
+var (
+	// DefaultDBVersion is the version chat databases are encrypted with. Databases
+	// encrypted with a previous version are re-encrypted with it.
+	DefaultDBVersion = reencryption.Version{Cipher: CipherSQLCipherDefault, KDF: KDFScrypt}
+	// LegacyDBVersion is the version of the databases created before their versions were
+	// tracked.
+	LegacyDBVersion = reencryption.Version{Cipher: CipherSQLCipherDefault, KDF: KDFSha3}
+)
+
+// ErrUnsupportedCipherUpgrade is returned when re-encrypting a database to a different cipher.
+var ErrUnsupportedCipherUpgrade = errors.New("cipher upgrade is not supported")
+
+// DBKey derives the key of a chat database from a password, given a KDF version.
+func DBKey(password string, kdf int) (string, error) {
+	switch kdf {
+	case KDFSha3:
+		digest := sha3.Sum256([]byte(password))
+		return fmt.Sprintf("%x", digest), nil
+	case KDFScrypt:
+		key, err := scrypt.Key([]byte(password), scryptSalt, scryptN, scryptR, scryptP, scryptKeyLen)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%x", key), nil
+	default:
+		return "", fmt.Errorf("unknown KDF version %d", kdf)
+	}
+}
+
+// SQLCipherStore is a chat database that can be re-encrypted in place.
+type SQLCipherStore struct {
+	name        string
+	persistence *SQLLitePersistence
+	password    string
+}
+
+// NewSQLCipherStore returns a new SQLCipherStore for an opened database.
+func NewSQLCipherStore(name string, persistence *SQLLitePersistence, password string) *SQLCipherStore {
+	return &SQLCipherStore{
+		name:        name,
+		persistence: persistence,
+		password:    password,
+	}
+}
+
+// Name returns the name of the store.
+func (s *SQLCipherStore) Name() string {
+	return s.name
+}
+
+// ReencryptStage rekeys the whole database in a single stage.
+// Rekeying a database with the key it is already encrypted with is a no-op.
+func (s *SQLCipherStore) ReencryptStage(from, to reencryption.Version, cursor []byte) ([]byte, error) {
+	if from.Cipher != to.Cipher {
+		return nil, ErrUnsupportedCipherUpgrade
+	}
+
+	key, err := DBKey(s.password, to.KDF)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, s.persistence.Rekey(key)
+}
+
+// SQLCipherFileStore is a chat database file which is not opened, like a backup taken
+// before migrating a database, that can be re-encrypted in place.
+type SQLCipherFileStore struct {
+	name     string
+	path     string
+	password string
+}
+
+// NewSQLCipherFileStore returns a new SQLCipherFileStore for the database file at path.
+func NewSQLCipherFileStore(name, path, password string) *SQLCipherFileStore {
+	return &SQLCipherFileStore{
+		name:     name,
+		path:     path,
+		password: password,
+	}
+}
+
+// Name returns the name of the store.
+func (s *SQLCipherFileStore) Name() string {
+	return s.name
+}
+
+// ReencryptStage opens the database with the key of from and rekeys it in a single stage.
+// A database removed meanwhile, like a backup replaced by a newer one, is skipped, and so
+// is a database already rekeyed whose re-encryption wasn't recorded.
+func (s *SQLCipherFileStore) ReencryptStage(from, to reencryption.Version, cursor []byte) ([]byte, error) {
+	if from.Cipher != to.Cipher {
+		return nil, ErrUnsupportedCipherUpgrade
+	}
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	oldKey, err := DBKey(s.password, from.KDF)
+	if err != nil {
+		return nil, err
+	}
+	newKey, err := DBKey(s.password, to.KDF)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDBKey(s.path, oldKey); err != nil {
+		return nil, checkDBKey(s.path, newKey)
+	}
+	db, err := openDB(s.path, oldKey, PersistenceConfig{})
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	_, err = db.Exec(fmt.Sprintf("PRAGMA rekey = '%s'", newKey))
+	return nil, err
+}
+
+// checkDBKey returns an error if the database file at path can't be decrypted with key.
+func checkDBKey(path, key string) error {
+	db, err := openDB(path, key, PersistenceConfig{})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.Exec("SELECT count(*) FROM sqlite_master")
+	return err
+}
@@ -0,0 +1,74 @@
+package chat
+
+import (
+	"os"
+	"testing"
+
+	"github.com/status-im/status-go/services/shhext/reencryption"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBKey(t *testing.T) {
+	key, err := DBKey("password", KDFSha3)
+	require.NoError(t, err)
+	require.Len(t, key, 64)
+
+	scryptKey, err := DBKey("password", KDFScrypt)
+	require.NoError(t, err)
+	require.Len(t, scryptKey, 64)
+	require.NotEqual(t, key, scryptKey)
+
+	_, err = DBKey("password", 0)
+	require.Error(t, err)
+}
+
+func TestSQLCipherStoreReencryptStage(t *testing.T) {
+	path := "/tmp/status-reencryption.db"
+	os.Remove(path)
+	defer os.Remove(path)
+
+	key, err := DBKey("password", DefaultDBVersion.KDF)
+	require.NoError(t, err)
+	persistence, err := NewSQLLitePersistence(path, "old-key", DefaultPersistenceConfig())
+	require.NoError(t, err)
+
+	store := NewSQLCipherStore("chat", persistence, "password")
+	from := reencryption.Version{Cipher: CipherSQLCipherDefault}
+	cursor, err := store.ReencryptStage(from, DefaultDBVersion, nil)
+	require.NoError(t, err)
+	require.Nil(t, cursor)
+
+	_, err = NewSQLLitePersistence(path, key, DefaultPersistenceConfig())
+	require.NoError(t, err, "It opens the database with the new key")
+
+	_, err = store.ReencryptStage(reencryption.Version{}, DefaultDBVersion, nil)
+	require.Equal(t, ErrUnsupportedCipherUpgrade, err)
+}
+
+func TestSQLCipherFileStoreReencryptStage(t *testing.T) {
+	path := "/tmp/status-reencryption-file.db"
+	os.Remove(path)
+	defer os.Remove(path)
+
+	legacyKey, err := DBKey("password", LegacyDBVersion.KDF)
+	require.NoError(t, err)
+	key, err := DBKey("password", DefaultDBVersion.KDF)
+	require.NoError(t, err)
+	persistence, err := NewSQLLitePersistence(path, legacyKey, DefaultPersistenceConfig())
+	require.NoError(t, err)
+	require.NoError(t, persistence.Close())
+
+	store := NewSQLCipherFileStore("chat.1.bak", path, "password")
+	cursor, err := store.ReencryptStage(LegacyDBVersion, DefaultDBVersion, nil)
+	require.NoError(t, err)
+	require.Nil(t, cursor)
+	require.NoError(t, checkDBKey(path, key), "It opens the database with the new key")
+	require.Error(t, checkDBKey(path, legacyKey))
+
+	_, err = store.ReencryptStage(LegacyDBVersion, DefaultDBVersion, nil)
+	require.NoError(t, err, "A database already re-encrypted is skipped")
+
+	require.NoError(t, os.Remove(path))
+	_, err = store.ReencryptStage(LegacyDBVersion, DefaultDBVersion, nil)
+	require.NoError(t, err, "A removed database is skipped")
+}
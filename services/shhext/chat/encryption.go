@@ -11,15 +11,23 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	dr "github.com/status-im/doubleratchet"
 
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/status-im/status-go/logutils"
+	"github.com/status-im/status-go/services/shhext/chat/capabilities"
+	"github.com/status-im/status-go/services/shhext/chat/compression"
 	"github.com/status-im/status-go/services/shhext/chat/crypto"
+	whisper "github.com/status-im/whisper/whisperv6"
 )
 
 var ErrSessionNotFound = errors.New("session not found")
 var ErrDeviceNotFound = errors.New("device not found")
 
+// ErrDuplicateMessage is returned when a message was already processed.
+var ErrDuplicateMessage = errors.New("duplicate message")
+
 // If we have no bundles, we use a constant so that the message can reach any device.
 const noInstallationID = "none"
 
@@ -43,6 +51,8 @@ type EncryptionServiceConfig struct {
 	MaxMessageKeysPerSession int
 	// How long before we refresh the interval in milliseconds
 	BundleRefreshInterval int64
+	// How long processed messages are remembered for deduplication in milliseconds
+	ProcessedMessagesTTL int64
 }
 
 type IdentityAndIDPair [2]string
@@ -55,13 +65,14 @@ func DefaultEncryptionServiceConfig(installationID string) EncryptionServiceConf
 		MaxKeep:                  3000,
 		MaxMessageKeysPerSession: 2000,
 		BundleRefreshInterval:    6 * 60 * 60 * 1000,
+		ProcessedMessagesTTL:     30 * 24 * 60 * 60 * 1000,
 		InstallationID:           installationID,
 	}
 }
 
 // NewEncryptionService creates a new EncryptionService instance.
 func NewEncryptionService(p PersistenceService, config EncryptionServiceConfig) *EncryptionService {
-	logger := log.New("package", "status-go/services/sshext.chat")
+	logger := logutils.NewLogger(logutils.ModuleChat, "package", "status-go/services/sshext.chat")
 	logger.Info("Initialized encryption service", "installationID", config.InstallationID)
 	return &EncryptionService{
 		log:         logger,
@@ -94,6 +105,139 @@ func (s *EncryptionService) getDRSession(id []byte) (dr.Session, error) {
 
 }
 
+// IsDuplicate returns true if a message from an installation of a sender was already processed.
+func (s *EncryptionService) IsDuplicate(theirIdentityKey *ecdsa.PublicKey, theirInstallationID string, messageHash []byte) (bool, error) {
+	return s.persistence.IsDuplicate(ecrypto.CompressPubkey(theirIdentityKey), messageHash, theirInstallationID)
+}
+
+// MarkProcessed records that a message from an installation of a sender was processed.
+func (s *EncryptionService) MarkProcessed(theirIdentityKey *ecdsa.PublicKey, theirInstallationID string, messageHash []byte) error {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	return s.persistence.MarkProcessed(ecrypto.CompressPubkey(theirIdentityKey), messageHash, theirInstallationID, now)
+}
+
+// PruneProcessed deletes the processed messages older than ProcessedMessagesTTL.
+// It returns the number of messages deleted.
+func (s *EncryptionService) PruneProcessed() (int, error) {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	return s.persistence.PruneProcessed(now - s.config.ProcessedMessagesTTL)
+}
+
+// SetCompressionDictionaries records the compression dictionaries advertised by an installation of a sender.
+func (s *EncryptionService) SetCompressionDictionaries(theirIdentityKey *ecdsa.PublicKey, theirInstallationID string, versions []uint32) error {
+	return s.persistence.SetCompressionDictionaries(ecrypto.CompressPubkey(theirIdentityKey), theirInstallationID, versions)
+}
+
+// CompressionDictionary returns the latest compression dictionary supported by this
+// installation and by all the active installations of a recipient, or nil.
+func (s *EncryptionService) CompressionDictionary(theirIdentityKey *ecdsa.PublicKey) (*compression.Dictionary, error) {
+	theirIdentityKeyC := ecrypto.CompressPubkey(theirIdentityKey)
+
+	activeInstallationIDs, err := s.persistence.GetActiveInstallations(s.config.MaxInstallations, theirIdentityKeyC)
+	if err != nil {
+		return nil, err
+	}
+
+	var installationIDs []string
+	for _, installationID := range activeInstallationIDs {
+		if installationID != s.config.InstallationID {
+			installationIDs = append(installationIDs, installationID)
+		}
+	}
+	if len(installationIDs) == 0 {
+		return nil, nil
+	}
+
+	advertised, err := s.persistence.GetCompressionDictionaries(theirIdentityKeyC, installationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	supported := [][]uint32{compression.Versions()}
+	for _, installationID := range installationIDs {
+		supported = append(supported, advertised[installationID])
+	}
+	return compression.Negotiate(supported...), nil
+}
+
+// SetContactTopic records the topic an installation of a sender listens on for direct messages.
+func (s *EncryptionService) SetContactTopic(theirIdentityKey *ecdsa.PublicKey, theirInstallationID string, topic whisper.TopicType) error {
+	return s.persistence.SetContactTopic(ecrypto.CompressPubkey(theirIdentityKey), theirInstallationID, topic[:])
+}
+
+// ContactTopic returns the topic direct messages to a recipient are sent on. It is the
+// partitioned topic of the recipient if all its active installations listen on it, so that
+// installations that didn't migrate yet keep receiving messages on the discovery topic.
+func (s *EncryptionService) ContactTopic(theirIdentityKey *ecdsa.PublicKey) (whisper.TopicType, error) {
+	theirIdentityKeyC := ecrypto.CompressPubkey(theirIdentityKey)
+
+	activeInstallationIDs, err := s.persistence.GetActiveInstallations(s.config.MaxInstallations, theirIdentityKeyC)
+	if err != nil {
+		return DiscoveryTopic(), err
+	}
+
+	var installationIDs []string
+	for _, installationID := range activeInstallationIDs {
+		if installationID != s.config.InstallationID {
+			installationIDs = append(installationIDs, installationID)
+		}
+	}
+	if len(installationIDs) == 0 {
+		return DiscoveryTopic(), nil
+	}
+
+	topics, err := s.persistence.GetContactTopics(theirIdentityKeyC, installationIDs)
+	if err != nil {
+		return DiscoveryTopic(), err
+	}
+
+	partitioned := PartitionedTopic(theirIdentityKey)
+	for _, installationID := range installationIDs {
+		if whisper.BytesToTopic(topics[installationID]) != partitioned {
+			return DiscoveryTopic(), nil
+		}
+	}
+	return partitioned, nil
+}
+
+// SetContactCapabilities records the capabilities advertised by an installation of a sender.
+func (s *EncryptionService) SetContactCapabilities(theirIdentityKey *ecdsa.PublicKey, theirInstallationID string, set capabilities.Set) error {
+	return s.persistence.SetContactCapabilities(ecrypto.CompressPubkey(theirIdentityKey), theirInstallationID, set)
+}
+
+// ContactCapabilities returns the capabilities supported by this installation and by all
+// the active installations of a recipient. Installations that never advertised capabilities
+// are legacy clients, which support version 0 and no feature.
+func (s *EncryptionService) ContactCapabilities(theirIdentityKey *ecdsa.PublicKey) (capabilities.Set, error) {
+	theirIdentityKeyC := ecrypto.CompressPubkey(theirIdentityKey)
+
+	activeInstallationIDs, err := s.persistence.GetActiveInstallations(s.config.MaxInstallations, theirIdentityKeyC)
+	if err != nil {
+		return capabilities.Set{}, err
+	}
+
+	var installationIDs []string
+	for _, installationID := range activeInstallationIDs {
+		if installationID != s.config.InstallationID {
+			installationIDs = append(installationIDs, installationID)
+		}
+	}
+	if len(installationIDs) == 0 {
+		return capabilities.Set{}, nil
+	}
+
+	advertised, err := s.persistence.GetContactCapabilities(theirIdentityKeyC, installationIDs)
+	if err != nil {
+		return capabilities.Set{}, err
+	}
+
+	var peers []capabilities.Set
+	for _, installationID := range installationIDs {
+		peers = append(peers, advertised[installationID])
+	}
+	return capabilities.Negotiate(peers...), nil
+}
+
 // CreateBundle retrieves or creates an X3DH bundle given a private key
 func (s *EncryptionService) CreateBundle(privateKey *ecdsa.PrivateKey) (*Bundle, error) {
 	ourIdentityKeyC := ecrypto.CompressPubkey(&privateKey.PublicKey)
@@ -194,6 +338,29 @@ func (s *EncryptionService) DisableInstallation(myIdentityKey *ecdsa.PublicKey,
 	return s.persistence.DisableInstallation(myIdentityKeyC, installationID)
 }
 
+// DisableOwnInstallation disables the installation of this device if it is recorded as one
+// of our paired installations, so that messages are not encrypted for it. It returns true
+// if the installation was enabled.
+func (s *EncryptionService) DisableOwnInstallation(myIdentityKey *ecdsa.PublicKey) (bool, error) {
+	myIdentityKeyC := ecrypto.CompressPubkey(myIdentityKey)
+	installationIDs, err := s.persistence.GetActiveInstallations(s.config.MaxInstallations, myIdentityKeyC)
+	if err != nil {
+		return false, err
+	}
+	for _, installationID := range installationIDs {
+		if installationID == s.config.InstallationID {
+			return true, s.persistence.DisableInstallation(myIdentityKeyC, installationID)
+		}
+	}
+	return false, nil
+}
+
+// CleanupInstallations deletes the state of our installations disabled for longer than olderThan.
+func (s *EncryptionService) CleanupInstallations(myIdentityKey *ecdsa.PublicKey, olderThan time.Duration) (*InstallationsCleanup, error) {
+	myIdentityKeyC := ecrypto.CompressPubkey(myIdentityKey)
+	return s.persistence.CleanupInstallations(myIdentityKeyC, time.Now().Add(-olderThan).Unix())
+}
+
 // ProcessPublicBundle persists a bundle and returns a list of tuples identity/installationID
 func (s *EncryptionService) ProcessPublicBundle(myIdentityKey *ecdsa.PrivateKey, b *Bundle) ([]IdentityAndIDPair, error) {
 	// Make sure the bundle belongs to who signed it
@@ -201,6 +368,41 @@ func (s *EncryptionService) ProcessPublicBundle(myIdentityKey *ecdsa.PrivateKey,
 	if err != nil {
 		return nil, err
 	}
+	return s.addPublicBundle(myIdentityKey, identity, b)
+}
+
+// RestoreBundle persists a bundle restored from a backup. Bundles are not signed once
+// persisted, so the backup is trusted instead. The signed pre key of our installation is ignored.
+func (s *EncryptionService) RestoreBundle(myIdentityKey *ecdsa.PrivateKey, b *Bundle) error {
+	identityKey, err := ecrypto.DecompressPubkey(b.GetIdentity())
+	if err != nil {
+		return err
+	}
+	restored := &Bundle{
+		Identity:      b.GetIdentity(),
+		Timestamp:     b.GetTimestamp(),
+		SignedPreKeys: make(map[string]*SignedPreKey),
+	}
+	for installationID, signedPreKey := range b.GetSignedPreKeys() {
+		if installationID != s.config.InstallationID {
+			restored.SignedPreKeys[installationID] = signedPreKey
+		}
+	}
+	identity := fmt.Sprintf("0x%x", ecrypto.FromECDSAPub(identityKey))
+	_, err = s.addPublicBundle(myIdentityKey, identity, restored)
+	return err
+}
+
+// GetPublicBundle returns the bundle of the active installations of an identity, if any.
+func (s *EncryptionService) GetPublicBundle(theirIdentityKey *ecdsa.PublicKey) (*Bundle, error) {
+	installationIDs, err := s.persistence.GetActiveInstallations(s.config.MaxInstallations, ecrypto.CompressPubkey(theirIdentityKey))
+	if err != nil {
+		return nil, err
+	}
+	return s.persistence.GetPublicBundle(theirIdentityKey, installationIDs)
+}
+
+func (s *EncryptionService) addPublicBundle(myIdentityKey *ecdsa.PrivateKey, identity string, b *Bundle) ([]IdentityAndIDPair, error) {
 	signedPreKeys := b.GetSignedPreKeys()
 	var response []IdentityAndIDPair
 	var installationIDs []string
@@ -217,11 +419,11 @@ func (s *EncryptionService) ProcessPublicBundle(myIdentityKey *ecdsa.PrivateKey,
 		}
 	}
 
-	if err = s.persistence.AddInstallations(b.GetIdentity(), b.GetTimestamp(), installationIDs, fromOurIdentity); err != nil {
+	if err := s.persistence.AddInstallations(b.GetIdentity(), b.GetTimestamp(), installationIDs, fromOurIdentity); err != nil {
 		return nil, err
 	}
 
-	if err = s.persistence.AddPublicBundle(b); err != nil {
+	if err := s.persistence.AddPublicBundle(b); err != nil {
 		return nil, err
 	}
 
@@ -450,6 +652,36 @@ func (s *EncryptionService) EncryptPayloadWithDH(theirIdentityKey *ecdsa.PublicK
 	return response, nil
 }
 
+// Installations returns the IDs of the installations of a recipient EncryptPayload encrypts
+// a payload for, without encrypting it. Recipients without a bundle get a single payload
+// encrypted with DH.
+func (s *EncryptionService) Installations(theirIdentityKey *ecdsa.PublicKey, myIdentityKey *ecdsa.PrivateKey) ([]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	theirIdentityKeyC := ecrypto.CompressPubkey(theirIdentityKey)
+	installationIDs, err := s.persistence.GetActiveInstallations(s.config.MaxInstallations, theirIdentityKeyC)
+	if err != nil {
+		return nil, err
+	}
+	theirBundle, err := s.persistence.GetPublicBundle(theirIdentityKey, installationIDs)
+	if err != nil {
+		return nil, err
+	}
+	if theirBundle == nil && !bytes.Equal(theirIdentityKeyC, ecrypto.CompressPubkey(&myIdentityKey.PublicKey)) {
+		return []string{noInstallationID}, nil
+	}
+
+	var result []string
+	for installationID := range theirBundle.GetSignedPreKeys() {
+		if s.config.InstallationID != installationID {
+			result = append(result, installationID)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
 // EncryptPayload returns a new DirectMessageProtocol with a given payload encrypted, given a recipient's public key and the sender private identity key
 // TODO: refactor this
 // nolint: gocyclo
@@ -414,7 +414,25 @@ type ProtocolMessage struct {
 	// One to one message, encrypted, indexed by installation_id
 	DirectMessage map[string]*DirectMessageProtocol `protobuf:"bytes,101,rep,name=direct_message,json=directMessage,proto3" json:"direct_message,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	// Public chats, not encrypted
-	PublicMessage        []byte   `protobuf:"bytes,102,opt,name=public_message,json=publicMessage,proto3" json:"public_message,omitempty"`
+	PublicMessage []byte `protobuf:"bytes,102,opt,name=public_message,json=publicMessage,proto3" json:"public_message,omitempty"`
+	// Versions of the compression dictionaries supported by the sender
+	CompressionDictionaries []uint32 `protobuf:"varint,103,rep,packed,name=compression_dictionaries,json=compressionDictionaries,proto3" json:"compression_dictionaries,omitempty"`
+	// Version of the dictionary the direct message was compressed with, 0 if not compressed
+	CompressionDictionary uint32 `protobuf:"varint,104,opt,name=compression_dictionary,json=compressionDictionary,proto3" json:"compression_dictionary,omitempty"`
+	// True if the sender listens on the partitioned topic of its key
+	PartitionedTopic bool `protobuf:"varint,105,opt,name=partitioned_topic,json=partitionedTopic,proto3" json:"partitioned_topic,omitempty"`
+	// Version of the chat protocol implemented by the sender
+	Version uint32 `protobuf:"varint,106,opt,name=version,proto3" json:"version,omitempty"`
+	// Features of the chat protocol supported by the sender
+	Features []string `protobuf:"bytes,107,rep,name=features,proto3" json:"features,omitempty"`
+	// True if the direct message carries the notification preferences of the sender, synced between its devices
+	NotificationPreferences bool `protobuf:"varint,108,opt,name=notification_preferences,json=notificationPreferences,proto3" json:"notification_preferences,omitempty"`
+	// True if the direct message carries records of the history of the sender, synced between its devices
+	HistorySync bool `protobuf:"varint,109,opt,name=history_sync,json=historySync,proto3" json:"history_sync,omitempty"`
+	// Time the message was sent, in milliseconds, so that recipients measure the delivery latency
+	SentAt uint64 `protobuf:"varint,110,opt,name=sent_at,json=sentAt,proto3" json:"sent_at,omitempty"`
+	// True if the direct message carries a command wiping an installation of the sender, or its acknowledgment, synced between its devices
+	Wipe                 bool     `protobuf:"varint,111,opt,name=wipe,proto3" json:"wipe,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -473,6 +491,69 @@ func (m *ProtocolMessage) GetPublicMessage() []byte {
 	return nil
 }
 
+func (m *ProtocolMessage) GetCompressionDictionaries() []uint32 {
+	if m != nil {
+		return m.CompressionDictionaries
+	}
+	return nil
+}
+
+func (m *ProtocolMessage) GetCompressionDictionary() uint32 {
+	if m != nil {
+		return m.CompressionDictionary
+	}
+	return 0
+}
+
+func (m *ProtocolMessage) GetPartitionedTopic() bool {
+	if m != nil {
+		return m.PartitionedTopic
+	}
+	return false
+}
+
+func (m *ProtocolMessage) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *ProtocolMessage) GetFeatures() []string {
+	if m != nil {
+		return m.Features
+	}
+	return nil
+}
+
+func (m *ProtocolMessage) GetNotificationPreferences() bool {
+	if m != nil {
+		return m.NotificationPreferences
+	}
+	return false
+}
+
+func (m *ProtocolMessage) GetHistorySync() bool {
+	if m != nil {
+		return m.HistorySync
+	}
+	return false
+}
+
+func (m *ProtocolMessage) GetSentAt() uint64 {
+	if m != nil {
+		return m.SentAt
+	}
+	return 0
+}
+
+func (m *ProtocolMessage) GetWipe() bool {
+	if m != nil {
+		return m.Wipe
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterType((*SignedPreKey)(nil), "chat.SignedPreKey")
 	proto.RegisterType((*Bundle)(nil), "chat.Bundle")
@@ -489,39 +570,49 @@ func init() {
 func init() { proto.RegisterFile("encryption.proto", fileDescriptor_8293a649ce9418c6) }
 
 var fileDescriptor_8293a649ce9418c6 = []byte{
-	// 535 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x54, 0x5d, 0x8b, 0xd3, 0x40,
-	0x14, 0x25, 0x69, 0xb7, 0xdb, 0xde, 0xa6, 0x69, 0x19, 0x51, 0x42, 0x5d, 0xb0, 0x84, 0x15, 0x03,
-	0x42, 0x61, 0x5b, 0x1f, 0xc4, 0x47, 0xad, 0x58, 0x57, 0xd4, 0x65, 0xf4, 0xc1, 0x17, 0x09, 0xd3,
-	0xe6, 0xba, 0x3b, 0x98, 0x4e, 0xc2, 0x64, 0x5a, 0xe8, 0x2f, 0xf3, 0xcd, 0xbf, 0xa6, 0x64, 0x26,
-	0x69, 0xa7, 0xdd, 0x5d, 0xd8, 0xb7, 0xde, 0x8f, 0x39, 0xe7, 0xdc, 0x73, 0x7b, 0x03, 0x03, 0x14,
-	0x4b, 0xb9, 0xcd, 0x15, 0xcf, 0xc4, 0x38, 0x97, 0x99, 0xca, 0x48, 0x73, 0x79, 0xc3, 0x54, 0xf8,
-	0x05, 0xbc, 0x6f, 0xfc, 0x5a, 0x60, 0x72, 0x25, 0xf1, 0x13, 0x6e, 0xc9, 0x39, 0xf8, 0x85, 0x8e,
-	0xe3, 0x5c, 0x62, 0xfc, 0x1b, 0xb7, 0x81, 0x33, 0x72, 0x22, 0x8f, 0x7a, 0x85, 0xdd, 0x15, 0xc0,
-	0xe9, 0x06, 0x65, 0xc1, 0x33, 0x11, 0xb8, 0x23, 0x27, 0xea, 0xd1, 0x3a, 0x0c, 0xff, 0x39, 0xd0,
-	0x7a, 0xbb, 0x16, 0x49, 0x8a, 0x64, 0x08, 0x6d, 0x9e, 0xa0, 0x50, 0x5c, 0xd5, 0x20, 0xbb, 0x98,
-	0x7c, 0x80, 0xfe, 0x21, 0x4d, 0x11, 0xb8, 0xa3, 0x46, 0xd4, 0x9d, 0x3c, 0x1b, 0x97, 0xb2, 0xc6,
-	0x06, 0x62, 0x6c, 0x4b, 0x2b, 0xde, 0x0b, 0x25, 0xb7, 0xb4, 0x67, 0x0b, 0x29, 0xc8, 0x19, 0x74,
-	0xca, 0x04, 0x53, 0x6b, 0x89, 0x41, 0x53, 0xb3, 0xec, 0x13, 0x65, 0x55, 0xf1, 0x15, 0x16, 0x8a,
-	0xad, 0xf2, 0xe0, 0x64, 0xe4, 0x44, 0x0d, 0xba, 0x4f, 0x0c, 0xbf, 0x03, 0xb9, 0x4d, 0x40, 0x06,
-	0xd0, 0xa8, 0xc7, 0xee, 0xd0, 0xf2, 0x27, 0x89, 0xe0, 0x64, 0xc3, 0xd2, 0x35, 0xea, 0x59, 0xbb,
-	0x13, 0x62, 0x24, 0xda, 0x4f, 0xa9, 0x69, 0x78, 0xe3, 0xbe, 0x76, 0x42, 0x09, 0x7d, 0xa3, 0xfe,
-	0x5d, 0x26, 0x14, 0xe3, 0x02, 0x25, 0x39, 0x87, 0xd6, 0x42, 0xa7, 0x34, 0x6a, 0x77, 0xe2, 0xd9,
-	0x43, 0xd2, 0xaa, 0x46, 0xa6, 0xf0, 0x24, 0x97, 0x7c, 0xc3, 0x14, 0xc6, 0x47, 0x2b, 0x70, 0xf5,
-	0x5c, 0x8f, 0xaa, 0xaa, 0x4d, 0x7c, 0xd9, 0x6c, 0x37, 0x06, 0xcd, 0xf0, 0x12, 0xda, 0x33, 0x3a,
-	0x47, 0x96, 0xa0, 0xb4, 0xf5, 0x7b, 0x46, 0xbf, 0x07, 0x4e, 0xbd, 0x27, 0x47, 0x10, 0x1f, 0xdc,
-	0x5c, 0x04, 0x0d, 0x1d, 0xba, 0xb9, 0x8e, 0x79, 0x52, 0x59, 0xe7, 0xf2, 0x24, 0x3c, 0x83, 0xf6,
-	0x6c, 0x7e, 0x1f, 0x56, 0xf8, 0x0a, 0xe0, 0xc7, 0xf4, 0xfe, 0xfa, 0x31, 0x5a, 0xa5, 0xef, 0xaf,
-	0x03, 0x8f, 0x67, 0x5c, 0xe2, 0x52, 0x7d, 0xc6, 0xa2, 0x60, 0xd7, 0x78, 0x55, 0xfe, 0x05, 0x97,
-	0x59, 0x4a, 0x2e, 0xa0, 0x5b, 0xe2, 0xc5, 0x37, 0x1a, 0xb0, 0xf2, 0x67, 0x60, 0xfc, 0xd9, 0x13,
-	0x51, 0x9b, 0xf4, 0x25, 0x74, 0x66, 0xb4, 0x7e, 0x60, 0x56, 0xe2, 0x9b, 0x07, 0xb5, 0x07, 0x74,
-	0xef, 0x46, 0xd9, 0xbc, 0x43, 0xc7, 0x83, 0xe6, 0xf9, 0xae, 0xb9, 0x46, 0x0e, 0xe0, 0x34, 0x67,
-	0xdb, 0x34, 0x63, 0x89, 0xf6, 0xc7, 0xa3, 0x75, 0x18, 0xfe, 0x71, 0xa1, 0x5f, 0x6b, 0xae, 0x46,
-	0x78, 0xe0, 0x56, 0x5f, 0x40, 0x9f, 0x8b, 0x42, 0xb1, 0x34, 0x65, 0xe5, 0xf1, 0xc5, 0x3c, 0xd1,
-	0x9a, 0x3b, 0xd4, 0xb7, 0xd3, 0x1f, 0x13, 0xf2, 0x15, 0xfc, 0x44, 0x5b, 0x14, 0xaf, 0x0c, 0x41,
-	0x80, 0xfa, 0x22, 0x22, 0x03, 0x7b, 0xc4, 0x3e, 0x3e, 0xb0, 0xb3, 0x3a, 0x8d, 0xc4, 0xce, 0x91,
-	0xe7, 0xe0, 0xe7, 0xeb, 0x45, 0xca, 0x97, 0x3b, 0xc0, 0x5f, 0x7a, 0xa8, 0x9e, 0xc9, 0x56, 0x6d,
-	0xc3, 0x9f, 0x40, 0x6e, 0x63, 0xdd, 0x71, 0x05, 0x17, 0x87, 0x57, 0xf0, 0xb4, 0x72, 0xf1, 0xae,
-	0xad, 0x5a, 0xe7, 0xb0, 0x68, 0xe9, 0xaf, 0xcd, 0xf4, 0x7f, 0x00, 0x00, 0x00, 0xff, 0xff, 0xed,
-	0x53, 0xaf, 0x00, 0x81, 0x04, 0x00, 0x00,
+	// 699 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x54, 0x5f, 0x6f, 0xdb, 0x36,
+	0x10, 0x87, 0x6c, 0xc7, 0xb1, 0xcf, 0xf2, 0x9f, 0x71, 0x48, 0x42, 0x64, 0x01, 0xa6, 0x19, 0x19,
+	0x26, 0x20, 0x80, 0x81, 0x24, 0x1b, 0xb0, 0xed, 0x6d, 0xab, 0x8b, 0xa6, 0x29, 0xda, 0x06, 0x4c,
+	0x1e, 0xfa, 0x52, 0x08, 0x8a, 0x74, 0x8e, 0xd9, 0xc8, 0x94, 0x40, 0xd2, 0x29, 0xf4, 0x49, 0xfa,
+	0x6d, 0xfa, 0xd5, 0x5a, 0x88, 0x92, 0x6c, 0x3a, 0x71, 0x80, 0xbe, 0xf1, 0xfe, 0xfd, 0xee, 0x77,
+	0x77, 0xbc, 0x83, 0x11, 0x8a, 0x48, 0xe6, 0x99, 0xe6, 0xa9, 0x98, 0x64, 0x32, 0xd5, 0x29, 0x69,
+	0x45, 0xf3, 0x50, 0x8f, 0xdf, 0x81, 0x7b, 0xcd, 0xef, 0x04, 0xc6, 0x57, 0x12, 0xdf, 0x60, 0x4e,
+	0x8e, 0x61, 0xa0, 0x8c, 0x1c, 0x64, 0x12, 0x83, 0x7b, 0xcc, 0xa9, 0xe3, 0x39, 0xbe, 0xcb, 0x5c,
+	0x65, 0x7b, 0x51, 0xd8, 0x7d, 0x40, 0xa9, 0x78, 0x2a, 0x68, 0xc3, 0x73, 0xfc, 0x3e, 0xab, 0xc5,
+	0xf1, 0x37, 0x07, 0xda, 0xff, 0x2f, 0x45, 0x9c, 0x20, 0x39, 0x84, 0x0e, 0x8f, 0x51, 0x68, 0xae,
+	0x6b, 0x90, 0x95, 0x4c, 0x5e, 0xc1, 0x70, 0x33, 0x8d, 0xa2, 0x0d, 0xaf, 0xe9, 0xf7, 0xce, 0x7e,
+	0x9d, 0x14, 0xb4, 0x26, 0x25, 0xc4, 0xc4, 0xa6, 0xa6, 0x5e, 0x0a, 0x2d, 0x73, 0xd6, 0xb7, 0x89,
+	0x28, 0x72, 0x04, 0xdd, 0x42, 0x11, 0xea, 0xa5, 0x44, 0xda, 0x32, 0x59, 0xd6, 0x8a, 0xc2, 0xaa,
+	0xf9, 0x02, 0x95, 0x0e, 0x17, 0x19, 0xdd, 0xf1, 0x1c, 0xbf, 0xc9, 0xd6, 0x8a, 0xc3, 0x1b, 0x20,
+	0x4f, 0x13, 0x90, 0x11, 0x34, 0xeb, 0xb2, 0xbb, 0xac, 0x78, 0x12, 0x1f, 0x76, 0x1e, 0xc2, 0x64,
+	0x89, 0xa6, 0xd6, 0xde, 0x19, 0x29, 0x29, 0xda, 0xa1, 0xac, 0x74, 0xf8, 0xb7, 0xf1, 0xb7, 0x33,
+	0x96, 0x30, 0x2c, 0xd9, 0xbf, 0x48, 0x85, 0x0e, 0xb9, 0x40, 0x49, 0x8e, 0xa1, 0x7d, 0x6b, 0x54,
+	0x06, 0xb5, 0x77, 0xe6, 0xda, 0x45, 0xb2, 0xca, 0x46, 0xce, 0x61, 0x3f, 0x93, 0xfc, 0x21, 0xd4,
+	0x18, 0x3c, 0x1a, 0x41, 0xc3, 0xd4, 0xf5, 0x73, 0x65, 0xb5, 0x13, 0x5f, 0xb6, 0x3a, 0xcd, 0x51,
+	0x6b, 0x7c, 0x09, 0x9d, 0x29, 0xbb, 0xc0, 0x30, 0x46, 0x69, 0xf3, 0x77, 0x4b, 0xfe, 0x2e, 0x38,
+	0xf5, 0x9c, 0x1c, 0x41, 0x06, 0xd0, 0xc8, 0x04, 0x6d, 0x1a, 0xb1, 0x91, 0x19, 0x99, 0xc7, 0x55,
+	0xeb, 0x1a, 0x3c, 0x1e, 0x1f, 0x41, 0x67, 0x7a, 0xf1, 0x1c, 0xd6, 0xf8, 0x4f, 0x80, 0x0f, 0xe7,
+	0xcf, 0xdb, 0x1f, 0xa3, 0x55, 0xfc, 0xbe, 0x3a, 0xb0, 0x37, 0xe5, 0x12, 0x23, 0xfd, 0x16, 0x95,
+	0x0a, 0xef, 0xf0, 0xaa, 0xf8, 0x82, 0x51, 0x9a, 0x90, 0x53, 0xe8, 0x15, 0x78, 0xc1, 0xdc, 0x00,
+	0x56, 0xfd, 0x19, 0x95, 0xfd, 0x59, 0x27, 0x62, 0x76, 0xd2, 0x13, 0xe8, 0x4e, 0x59, 0x1d, 0x50,
+	0x8e, 0x64, 0x50, 0x06, 0xd4, 0x3d, 0x60, 0xeb, 0x6e, 0x14, 0xce, 0x2b, 0x74, 0xdc, 0x70, 0xbe,
+	0x58, 0x39, 0xd7, 0xc8, 0x14, 0x76, 0xb3, 0x30, 0x4f, 0xd2, 0x30, 0x36, 0xfd, 0x71, 0x59, 0x2d,
+	0x8e, 0xbf, 0xec, 0xc0, 0xb0, 0xe6, 0x5c, 0x95, 0xf0, 0x83, 0x53, 0xfd, 0x03, 0x86, 0x5c, 0x28,
+	0x1d, 0x26, 0x49, 0x58, 0x2c, 0x5f, 0xc0, 0x63, 0xc3, 0xb9, 0xcb, 0x06, 0xb6, 0xfa, 0x75, 0x4c,
+	0xde, 0xc3, 0x20, 0x36, 0x2d, 0x0a, 0x16, 0x65, 0x02, 0x8a, 0x66, 0x23, 0xfc, 0x12, 0xf6, 0x51,
+	0xf6, 0xc9, 0x46, 0x3b, 0xab, 0xd5, 0x88, 0x6d, 0x1d, 0xf9, 0x1d, 0x06, 0xd9, 0xf2, 0x36, 0xe1,
+	0xd1, 0x0a, 0x70, 0x66, 0x8a, 0xea, 0x97, 0xda, 0xda, 0xed, 0x1f, 0xa0, 0x51, 0xba, 0xc8, 0x24,
+	0xaa, 0x62, 0x81, 0x83, 0x98, 0x47, 0x05, 0xa1, 0x50, 0x72, 0x54, 0xf4, 0xce, 0x6b, 0xfa, 0x7d,
+	0x76, 0x60, 0xd9, 0xa7, 0x96, 0x99, 0xfc, 0x05, 0xfb, 0x5b, 0x43, 0x73, 0x3a, 0x37, 0xdf, 0x6b,
+	0x6f, 0x5b, 0x60, 0x4e, 0x4e, 0xe0, 0xa7, 0x2c, 0x94, 0x9a, 0x17, 0x32, 0xc6, 0x81, 0x4e, 0x33,
+	0x1e, 0x51, 0xee, 0x39, 0x7e, 0x87, 0x8d, 0x2c, 0xc3, 0x4d, 0xa1, 0xb7, 0x4f, 0xcd, 0xa7, 0x8d,
+	0x53, 0x53, 0xdc, 0x97, 0x19, 0x9a, 0x3d, 0x57, 0xf4, 0xde, 0x6b, 0xfa, 0x5d, 0xb6, 0x92, 0x8b,
+	0xa2, 0x44, 0xaa, 0xf9, 0x8c, 0x47, 0x65, 0xd7, 0x33, 0x89, 0x33, 0x94, 0x28, 0x22, 0x54, 0x34,
+	0x31, 0x99, 0x0e, 0x6c, 0xfb, 0xd5, 0xda, 0x4c, 0x7e, 0x03, 0x77, 0xce, 0x95, 0x4e, 0x65, 0x1e,
+	0xa8, 0x5c, 0x44, 0x74, 0x61, 0xdc, 0x7b, 0x95, 0xee, 0x3a, 0x17, 0x11, 0x39, 0x80, 0x5d, 0x85,
+	0x42, 0x07, 0xa1, 0xa6, 0xc2, 0x73, 0xfc, 0x16, 0x6b, 0x17, 0xe2, 0x7f, 0x9a, 0x10, 0x68, 0x7d,
+	0xe6, 0x19, 0xd2, 0xd4, 0xc4, 0x98, 0xf7, 0xe1, 0x47, 0x20, 0x4f, 0x67, 0xb5, 0xe5, 0xca, 0x9c,
+	0x6e, 0x5e, 0x99, 0x5f, 0xaa, 0x5f, 0xba, 0x6d, 0x6b, 0xac, 0x73, 0x73, 0xdb, 0x36, 0xd7, 0xfc,
+	0xfc, 0xfb, 0x00, 0x81, 0xdb, 0x1f, 0x4e, 0xe1, 0x05, 0x00, 0x00,
 }
@@ -46,7 +46,7 @@ func setupUser(user string, s *EncryptionServiceMultiDeviceSuite, n int) error {
 
 		os.Remove(dbPath)
 
-		persistence, err := NewSQLLitePersistence(dbPath, "key")
+		persistence, err := NewSQLLitePersistence(dbPath, "key", DefaultPersistenceConfig())
 		if err != nil {
 			return err
 		}
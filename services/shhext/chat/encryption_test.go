@@ -55,12 +55,12 @@ func (s *EncryptionServiceTestSuite) initDatabases(baseConfig *EncryptionService
 		bobDBKey   = "bob"
 	)
 
-	alicePersistence, err := NewSQLLitePersistence(aliceDBPath, aliceDBKey)
+	alicePersistence, err := NewSQLLitePersistence(aliceDBPath, aliceDBKey, DefaultPersistenceConfig())
 	if err != nil {
 		panic(err)
 	}
 
-	bobPersistence, err := NewSQLLitePersistence(bobDBPath, bobDBKey)
+	bobPersistence, err := NewSQLLitePersistence(bobDBPath, bobDBKey, DefaultPersistenceConfig())
 	if err != nil {
 		panic(err)
 	}
@@ -866,3 +866,23 @@ func (s *EncryptionServiceTestSuite) TestRefreshedBundle() {
 	s.Equal(bobBundle2.GetSignedPreKeys()[bobInstallationID].GetSignedPreKey(), x3dhHeader2.GetId())
 
 }
+
+func (s *EncryptionServiceTestSuite) TestDisableOwnInstallation() {
+	aliceKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	identity := crypto.CompressPubkey(&aliceKey.PublicKey)
+
+	disabled, err := s.alice.DisableOwnInstallation(&aliceKey.PublicKey)
+	s.Require().NoError(err)
+	s.False(disabled)
+
+	// The installation of this device recorded as a paired installation
+	s.Require().NoError(s.alice.persistence.AddInstallations(identity, 1, []string{aliceInstallationID, "3"}, true))
+	disabled, err = s.alice.DisableOwnInstallation(&aliceKey.PublicKey)
+	s.Require().NoError(err)
+	s.True(disabled)
+
+	installations, err := s.alice.persistence.GetActiveInstallations(5, identity)
+	s.Require().NoError(err)
+	s.Equal([]string{"3"}, installations)
+}
@@ -0,0 +1,77 @@
+package chat
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/services/ens"
+)
+
+// ENSStore stores the cached ENS resolutions of the account, in the chat database of the account.
+type ENSStore struct {
+	accountDB
+}
+
+// ENSStore returns the store of the cached ENS resolutions of the account.
+func (s *SQLLitePersistence) ENSStore() *ENSStore {
+	return &ENSStore{s.accountDB()}
+}
+
+// SaveENSResolution saves the resolution of a name on a network, replacing the previous one
+func (s *ENSStore) SaveENSResolution(networkID uint64, resolution ens.Resolution) error {
+	data, err := json.Marshal(resolution)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO ens_resolutions(network_id, name, data) VALUES (?, ?, ?)`,
+		networkID, resolution.Name, data)
+	return err
+}
+
+// GetENSResolution returns the resolution of a name on a network, or nil if it was never saved
+func (s *ENSStore) GetENSResolution(networkID uint64, name string) (*ens.Resolution, error) {
+	var data []byte
+	err := s.db.QueryRow(`SELECT data FROM ens_resolutions WHERE network_id = ? AND name = ?`,
+		networkID, name).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var resolution ens.Resolution
+	if err := json.Unmarshal(data, &resolution); err != nil {
+		return nil, err
+	}
+	return &resolution, nil
+}
+
+// SaveENSReverseResolution saves the primary name of an address on a network, replacing the previous one
+func (s *ENSStore) SaveENSReverseResolution(networkID uint64, resolution ens.ReverseResolution) error {
+	data, err := json.Marshal(resolution)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO ens_reverse_resolutions(network_id, address, data) VALUES (?, ?, ?)`,
+		networkID, resolution.Address.Bytes(), data)
+	return err
+}
+
+// GetENSReverseResolution returns the primary name of an address on a network, or nil if it was never saved
+func (s *ENSStore) GetENSReverseResolution(networkID uint64, address common.Address) (*ens.ReverseResolution, error) {
+	var data []byte
+	err := s.db.QueryRow(`SELECT data FROM ens_reverse_resolutions WHERE network_id = ? AND address = ?`,
+		networkID, address.Bytes()).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var resolution ens.ReverseResolution
+	if err := json.Unmarshal(data, &resolution); err != nil {
+		return nil, err
+	}
+	return &resolution, nil
+}
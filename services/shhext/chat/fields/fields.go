@@ -0,0 +1,139 @@
+// Package fields encrypts the sensitive fields of stored rows and derives the
+// search tokens of their text, so that rows can be searched by keyword without
+// storing the plaintext or the words it contains.
+package fields
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/status-im/status-go/services/shhext/chat/crypto"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// tokenSize is the number of bytes of the keyed hash kept in a token.
+	// Collisions only add false positives to search results.
+	tokenSize = 16
+	// minWordLength is the length of the shortest word that is indexed.
+	minWordLength = 2
+	// maxPrefixLength is the length of the longest prefix of a word that is indexed.
+	// Words sharing their first maxPrefixLength letters have the same longest prefix.
+	maxPrefixLength = 16
+)
+
+// ErrInvalidKey is returned if the key is empty.
+var ErrInvalidKey = errors.New("fields key can't be empty")
+
+// Cipher encrypts fields and derives search tokens with keys derived from
+// a single secret, such as the password of the database.
+type Cipher struct {
+	encryptionKey []byte
+	tokenKey      []byte
+}
+
+// NewCipher returns a Cipher for the given secret.
+func NewCipher(secret []byte) (*Cipher, error) {
+	if len(secret) == 0 {
+		return nil, ErrInvalidKey
+	}
+	return &Cipher{
+		encryptionKey: deriveKey(secret, "fields-encryption"),
+		tokenKey:      deriveKey(secret, "fields-tokens"),
+	}, nil
+}
+
+// Encrypt encrypts a field. Encrypting the same plaintext twice returns different ciphertexts.
+func (c *Cipher) Encrypt(plaintext []byte) ([]byte, error) {
+	return crypto.EncryptSymmetric(c.encryptionKey, plaintext)
+}
+
+// Decrypt decrypts a field encrypted with Encrypt.
+func (c *Cipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	return crypto.DecryptSymmetric(c.encryptionKey, ciphertext)
+}
+
+// Tokens returns the search tokens of a text: the keyed hashes of its normalized
+// words, without duplicates and in the order they first appear.
+func (c *Cipher) Tokens(text string) []string {
+	seen := make(map[string]struct{})
+	var tokens []string
+	for _, word := range Words(text) {
+		token := c.Token(word)
+		if _, exist := seen[token]; exist {
+			continue
+		}
+		seen[token] = struct{}{}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// PrefixTokens returns the search tokens of the prefixes of the words of a text, from
+// minWordLength to maxPrefixLength letters, so that words can be searched by their
+// beginning with the tokens returned by SearchTokens.
+func (c *Cipher) PrefixTokens(text string) []string {
+	var prefixes []string
+	for _, word := range Words(text) {
+		runes := []rune(word)
+		for i := minWordLength; i <= len(runes) && i <= maxPrefixLength; i++ {
+			prefixes = append(prefixes, string(runes[:i]))
+		}
+	}
+	return c.Tokens(strings.Join(prefixes, " "))
+}
+
+// SearchTokens returns the tokens matching the texts whose words start with the words
+// of query, once indexed with PrefixTokens.
+func (c *Cipher) SearchTokens(query string) []string {
+	var words []string
+	for _, word := range Words(query) {
+		if runes := []rune(word); len(runes) > maxPrefixLength {
+			word = string(runes[:maxPrefixLength])
+		}
+		words = append(words, word)
+	}
+	return c.Tokens(strings.Join(words, " "))
+}
+
+// Token returns the search token of a single normalized word.
+func (c *Cipher) Token(word string) string {
+	mac := hmac.New(sha256.New, c.tokenKey)
+	mac.Write([]byte(word)) // nolint: errcheck
+	return hex.EncodeToString(mac.Sum(nil)[:tokenSize])
+}
+
+// Words splits a text in normalized words: letters and digits, lower cased and
+// without diacritics, so that searching "cafe" matches "Café".
+func Words(text string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(normalize(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) >= minWordLength {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+func normalize(text string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(text) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+func deriveKey(secret []byte, label string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(label)) // nolint: errcheck
+	return mac.Sum(nil)
+}
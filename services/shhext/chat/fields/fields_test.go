@@ -0,0 +1,66 @@
+package fields
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryption(t *testing.T) {
+	_, err := NewCipher(nil)
+	require.Equal(t, ErrInvalidKey, err)
+
+	c, err := NewCipher([]byte("secret"))
+	require.NoError(t, err)
+
+	first, err := c.Encrypt([]byte("hello"))
+	require.NoError(t, err)
+	second, err := c.Encrypt([]byte("hello"))
+	require.NoError(t, err)
+	require.NotEqual(t, first, second)
+
+	plaintext, err := c.Decrypt(first)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), plaintext)
+
+	other, err := NewCipher([]byte("other"))
+	require.NoError(t, err)
+	_, err = other.Decrypt(first)
+	require.Error(t, err, "Fields can't be decrypted with another secret")
+}
+
+func TestWords(t *testing.T) {
+	require.Equal(t, []string{"meet", "at", "the", "cafe", "at", "10"}, Words("Meet at the Café, at 10!"))
+	require.Empty(t, Words("a ? !"))
+}
+
+func TestTokens(t *testing.T) {
+	c, err := NewCipher([]byte("secret"))
+	require.NoError(t, err)
+	other, err := NewCipher([]byte("other"))
+	require.NoError(t, err)
+
+	tokens := c.Tokens("Meet at the Café, at 10!")
+	require.Len(t, tokens, 5, "Duplicated words have a single token")
+	require.Equal(t, c.Token("cafe"), tokens[3])
+	require.NotContains(t, tokens, "cafe")
+	require.NotEqual(t, c.Token("cafe"), other.Token("cafe"), "Tokens are keyed")
+}
+
+func TestPrefixTokens(t *testing.T) {
+	c, err := NewCipher([]byte("secret"))
+	require.NoError(t, err)
+
+	tokens := c.PrefixTokens("Hello wo")
+	require.Equal(t, c.Tokens("he hel hell hello wo"), tokens)
+	for _, query := range []string{"hel", "HELLO wo", "wo he"} {
+		for _, token := range c.SearchTokens(query) {
+			require.Contains(t, tokens, token, "%s matches as a prefix", query)
+		}
+	}
+	require.NotContains(t, tokens, c.SearchTokens("world")[0])
+
+	long := "abcdefghijklmnopqrstuvwxyz"
+	require.Len(t, c.PrefixTokens(long), maxPrefixLength-minWordLength+1, "Prefixes are bounded")
+	require.Contains(t, c.PrefixTokens(long), c.SearchTokens(long)[0], "Long words match their longest prefix")
+}
@@ -0,0 +1,95 @@
+package chat
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	sqlcipher "github.com/mutecomm/go-sqlcipher"
+)
+
+// By default go-ethereum/metrics creates dummy metrics that don't register anything.
+// Real metrics are collected only if -metrics flag is set
+var (
+	messagesReceivedCounter   = metrics.NewRegisteredCounter("chat/messagesReceived", nil)
+	decryptionFailuresCounter = metrics.NewRegisteredCounter("chat/decryptionFailures", nil)
+	sqliteQueryTimer          = metrics.NewRegisteredTimer("chat/sqlite/query", nil)
+	sqliteExecTimer           = metrics.NewRegisteredTimer("chat/sqlite/exec", nil)
+)
+
+// timedDriverName is the name of the SQLite driver recording the latency of the
+// statements, used when metrics are enabled.
+const timedDriverName = "sqlite3-timed"
+
+func init() {
+	sql.Register(timedDriverName, timedDriver{&sqlcipher.SQLiteDriver{}})
+}
+
+// driverName returns the name of the SQLite driver of the databases.
+func driverName() string {
+	if metrics.Enabled {
+		return timedDriverName
+	}
+	return "sqlite3"
+}
+
+// newDriver returns the SQLite driver of the connections of the read pools.
+func newDriver() driver.Driver {
+	if metrics.Enabled {
+		return timedDriver{&sqlcipher.SQLiteDriver{}}
+	}
+	return &sqlcipher.SQLiteDriver{}
+}
+
+// timedDriver opens connections recording the latency of the statements.
+type timedDriver struct {
+	driver.Driver
+}
+
+func (d timedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return timedConn{conn.(*sqlcipher.SQLiteConn)}, nil
+}
+
+// timedConn records the latency of the statements executed by a connection.
+// Queries are timed until their rows are returned, not until they are read.
+type timedConn struct {
+	*sqlcipher.SQLiteConn
+}
+
+func (c timedConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.SQLiteConn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return timedStmt{stmt}, nil
+}
+
+func (c timedConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	defer sqliteExecTimer.UpdateSince(time.Now())
+	return c.SQLiteConn.Exec(query, args)
+}
+
+func (c timedConn) Query(query string, args []driver.Value) (driver.Rows, error) {
+	defer sqliteQueryTimer.UpdateSince(time.Now())
+	return c.SQLiteConn.Query(query, args)
+}
+
+// timedStmt records the latency of a prepared statement.
+type timedStmt struct {
+	driver.Stmt
+}
+
+func (s timedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	defer sqliteExecTimer.UpdateSince(time.Now())
+	return s.Stmt.Exec(args)
+}
+
+func (s timedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	defer sqliteQueryTimer.UpdateSince(time.Now())
+	return s.Stmt.Query(args)
+}
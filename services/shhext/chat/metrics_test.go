@@ -0,0 +1,32 @@
+package chat
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimedDriver(t *testing.T) {
+	db, err := sql.Open(timedDriverName, ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE messages (id INTEGER PRIMARY KEY, body TEXT)")
+	require.NoError(t, err)
+	stmt, err := db.Prepare("INSERT INTO messages (body) VALUES (?)")
+	require.NoError(t, err)
+	_, err = stmt.Exec("hello")
+	require.NoError(t, err)
+	require.NoError(t, stmt.Close())
+
+	var body string
+	require.NoError(t, db.QueryRow("SELECT body FROM messages WHERE id = ?", 1).Scan(&body))
+	require.Equal(t, "hello", body)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	_, err = tx.Exec("DELETE FROM messages")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+}
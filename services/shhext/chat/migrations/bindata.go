@@ -8,6 +8,86 @@
 // 1540715431_add_version.up.sql
 // 1541164797_add_installations.down.sql
 // 1541164797_add_installations.up.sql
+// 1542208893_add_chat_settings.down.sql
+// 1542208893_add_chat_settings.up.sql
+// 1542637421_add_account_namespace.down.sql
+// 1542637421_add_account_namespace.up.sql
+// 1543165882_add_group_receipts.down.sql
+// 1543165882_add_group_receipts.up.sql
+// 1543501293_add_installations_disabled_at.down.sql
+// 1543501293_add_installations_disabled_at.up.sql
+// 1543919112_add_processed_messages.down.sql
+// 1543919112_add_processed_messages.up.sql
+// 1544190315_add_moderated_channels.down.sql
+// 1544190315_add_moderated_channels.up.sql
+// 1544531725_add_envelope_states.down.sql
+// 1544531725_add_envelope_states.up.sql
+// 1544617200_add_contact_mailservers.down.sql
+// 1544617200_add_contact_mailservers.up.sql
+// 1544703600_add_compression_dictionaries.down.sql
+// 1544703600_add_compression_dictionaries.up.sql
+// 1544790000_add_pending_messages.down.sql
+// 1544790000_add_pending_messages.up.sql
+// 1544876400_add_contact_topics.down.sql
+// 1544876400_add_contact_topics.up.sql
+// 1544962800_add_contact_capabilities.down.sql
+// 1544962800_add_contact_capabilities.up.sql
+// 1545049200_add_segments.down.sql
+// 1545049200_add_segments.up.sql
+// 1545135600_add_notification_preferences.down.sql
+// 1545135600_add_notification_preferences.up.sql
+// 1545222000_add_attachments.down.sql
+// 1545222000_add_attachments.up.sql
+// 1545308400_add_message_changes.down.sql
+// 1545308400_add_message_changes.up.sql
+// 1545394800_add_history_messages.down.sql
+// 1545394800_add_history_messages.up.sql
+// 1545481200_add_contacts.down.sql
+// 1545481200_add_contacts.up.sql
+// 1545567600_add_presence.down.sql
+// 1545567600_add_presence.up.sql
+// 1545654000_add_contact_requests.down.sql
+// 1545654000_add_contact_requests.up.sql
+// 1545740400_add_blocked_contacts.down.sql
+// 1545740400_add_blocked_contacts.up.sql
+// 1545826800_add_downgrades.down.sql
+// 1545826800_add_downgrades.up.sql
+// 1545913200_add_settings.down.sql
+// 1545913200_add_settings.up.sql
+// 1545999600_add_sync_clocks.down.sql
+// 1545999600_add_sync_clocks.up.sql
+// 1546086000_add_unsigned_transactions.down.sql
+// 1546086000_add_unsigned_transactions.up.sql
+// 1546172400_add_outbox_entries.down.sql
+// 1546172400_add_outbox_entries.up.sql
+// 1546258800_add_wallet_transfers.down.sql
+// 1546258800_add_wallet_transfers.up.sql
+// 1546345200_add_wallet_custom_tokens.down.sql
+// 1546345200_add_wallet_custom_tokens.up.sql
+// 1546431600_add_decoy_topics.down.sql
+// 1546431600_add_decoy_topics.up.sql
+// 1546518000_add_wallet_transfers_chain_id.down.sql
+// 1546518000_add_wallet_transfers_chain_id.up.sql
+// 1546604400_add_wallet_balance_snapshots.down.sql
+// 1546604400_add_wallet_balance_snapshots.up.sql
+// 1546690800_add_ens_cache.down.sql
+// 1546690800_add_ens_cache.up.sql
+// 1546777200_add_sticker_packs.down.sql
+// 1546777200_add_sticker_packs.up.sql
+// 1546863600_add_push_notifications.down.sql
+// 1546863600_add_push_notifications.up.sql
+// 1546950000_add_wipes.down.sql
+// 1546950000_add_wipes.up.sql
+// 1547036400_namespace_bundles_installations.down.sql
+// 1547036400_namespace_bundles_installations.up.sql
+// 1547122800_add_sent_transactions.down.sql
+// 1547122800_add_sent_transactions.up.sql
+// 1547209200_encrypt_history_messages.down.sql
+// 1547209200_encrypt_history_messages.up.sql
+// 1547295600_drop_account_namespace.down.sql
+// 1547295600_drop_account_namespace.up.sql
+// 1547382000_add_segments_sender.down.sql
+// 1547382000_add_segments_sender.up.sql
 // static.go
 // DO NOT EDIT!
 
@@ -236,6 +316,1606 @@ func _1541164797_add_installationsUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __1542208893_add_chat_settingsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x1a\x00\xe5\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x63\x68\x61\x74\x5f\x73\x65\x74\x74\x69\x6e\x67\x73\x3b\x0a\x03\x00\xb8\xcf\xab\x4a\x1a\x00\x00\x00")
+
+func _1542208893_add_chat_settingsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1542208893_add_chat_settingsDownSql,
+		"1542208893_add_chat_settings.down.sql",
+	)
+}
+
+func _1542208893_add_chat_settingsDownSql() (*asset, error) {
+	bytes, err := _1542208893_add_chat_settingsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1542208893_add_chat_settings.down.sql", size: 26, mode: os.FileMode(420), modTime: time.Unix(1542208893, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1542208893_add_chat_settingsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x7b\x00\x84\xff\x43\x52\x45\x41\x54\x45\x20\x54\x41\x42\x4c\x45\x20\x63\x68\x61\x74\x5f\x73\x65\x74\x74\x69\x6e\x67\x73\x20\x28\x0a\x20\x20\x74\x6f\x70\x69\x63\x20\x42\x4c\x4f\x42\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x20\x50\x52\x49\x4d\x41\x52\x59\x20\x4b\x45\x59\x20\x4f\x4e\x20\x43\x4f\x4e\x46\x4c\x49\x43\x54\x20\x52\x45\x50\x4c\x41\x43\x45\x2c\x0a\x20\x20\x6c\x61\x6e\x67\x75\x61\x67\x65\x20\x54\x45\x58\x54\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x20\x44\x45\x46\x41\x55\x4c\x54\x20\x27\x27\x0a\x29\x3b\x0a\x03\x00\x8f\xad\x70\x7c\x7b\x00\x00\x00")
+
+func _1542208893_add_chat_settingsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1542208893_add_chat_settingsUpSql,
+		"1542208893_add_chat_settings.up.sql",
+	)
+}
+
+func _1542208893_add_chat_settingsUpSql() (*asset, error) {
+	bytes, err := _1542208893_add_chat_settingsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1542208893_add_chat_settings.up.sql", size: 123, mode: os.FileMode(420), modTime: time.Unix(1542208893, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1542637421_add_account_namespaceDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x92\x3f\xaf\x9b\x30\x14\xc5\x77\x7f\x8a\xbb\x01\x12\x53\x33\xa2\x0e\x84\x5c\x5a\x54\xc7\x4e\x1d\xa3\x36\x13\x72\xc1\x25\x56\x89\x89\xc0\xa9\x94\x6f\x5f\x41\x2b\x1a\x93\xf7\xf4\x86\xb7\xfa\xfe\x39\xc7\xbf\x7b\x32\x81\xa9\x44\x90\xe9\x96\x22\x0c\xca\xd5\x67\xed\x2a\x63\x7f\xf6\xd5\xef\x0f\x10\x12\x80\x1f\x37\xdb\x74\xba\x32\x0d\x6c\x29\xdf\x02\xe3\x12\x58\x49\x69\x4c\x00\xf4\xf5\xac\x2f\x7a\x50\x5d\xf5\x4b\xdf\xe7\xf2\xf4\x6a\x1a\x6d\x9d\x71\xf7\xe7\xfe\xf1\x7e\xb9\x68\x37\x98\x7a\xe9\xf7\xca\xc6\x8e\x4e\x75\x9d\x72\xa6\xb7\x93\x9e\xc4\xef\xd2\x6b\x28\x59\xf1\xb5\xc4\x70\x71\x14\x2f\x5a\xf1\x7a\x38\x02\xce\x20\xe3\x2c\xa7\x45\x26\x41\xe0\x81\xa6\x19\x4e\xee\x72\x2e\xb0\xf8\xc4\xe0\x0b\x9e\xe0\xff\xa6\x08\x04\xe6\x28\x90\x65\x78\xfc\xf7\xe3\x31\x1c\x4d\x6b\x75\x53\x5d\x07\x3d\xf9\x8d\x48\x94\x10\x52\xb0\x23\x0a\x09\x05\x93\x7c\x4d\xeb\xd1\x97\x47\xe6\xd1\xa6\x87\xe0\xd9\x35\x39\x22\xc5\x4c\xc2\xfb\x57\x41\x2e\xf8\x7e\x65\x71\x03\xdf\x3e\xa3\x40\x50\x75\xdd\xdf\xac\x83\x8f\x10\x04\x09\x21\x3b\xc1\x0f\x2f\x06\x60\x93\x10\xe2\xe5\xa3\x3e\x2b\x57\x8d\xda\x39\x63\xdb\x71\x4e\x87\xeb\xaf\xa6\xf6\x4f\x09\x07\x51\xec\x53\x71\x9a\x11\xbf\x72\x85\x4e\xd9\xf6\xa6\x5a\xed\xdf\x18\x76\x98\xa7\x25\x95\x10\x04\x4f\xb0\x3d\xe9\x70\x96\x8d\x97\x35\x0b\xb7\xd5\xfb\x5f\x08\xde\xe8\x14\xeb\xb7\x28\xac\x07\x12\xf2\x67\x00\x1e\x3a\xdf\xf8\x26\x03\x00\x00")
+
+func _1542637421_add_account_namespaceDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1542637421_add_account_namespaceDownSql,
+		"1542637421_add_account_namespace.down.sql",
+	)
+}
+
+func _1542637421_add_account_namespaceDownSql() (*asset, error) {
+	bytes, err := _1542637421_add_account_namespaceDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1542637421_add_account_namespace.down.sql", size: 806, mode: os.FileMode(420), modTime: time.Unix(1542637421, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1542637421_add_account_namespaceUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x52\xc1\x6e\xab\x30\x10\xbc\xfb\x2b\xf6\x16\x90\x38\xe5\x1d\x73\x22\x64\x79\x42\xcf\xcf\x4e\x1d\x23\xb5\x27\xe4\x82\x4b\xac\x12\x13\x81\x13\x29\x7f\x5f\x91\x46\xa4\x86\x1c\x5a\xf5\xea\xd9\x9d\x19\xcf\x6c\x22\x30\x96\x08\x32\x5e\x53\x84\x4e\xb9\x72\xaf\x5d\x61\xec\x5b\x5b\x9c\xff\x40\x40\x00\x5e\x4f\xb6\x6a\x74\x61\x2a\x58\x53\xbe\x06\xc6\x25\xb0\x9c\xd2\x88\x00\xe8\xe3\x5e\x1f\x74\xa7\x9a\xe2\x5d\x5f\xae\xf0\xf0\x6a\x2a\x6d\x9d\x71\x97\xf9\x7c\x7f\x39\x1c\xb4\xeb\x4c\x39\xce\x7b\xb0\xb1\xbd\x53\x4d\xa3\x9c\x69\xed\xa0\x27\xf1\x59\x7a\x03\xaa\x2c\xdb\x93\x75\x3e\x00\x1b\x4c\xe3\x9c\x4a\x58\x2c\x06\xf5\x9c\x65\x4f\x39\x06\xb7\xd1\xe8\x6e\x3f\x1a\x8d\x45\x53\xa5\x10\x38\x83\x84\xb3\x94\x66\x89\x04\x81\x5b\x1a\x27\x38\x90\xa5\x5c\x60\xf6\x97\xc1\x3f\x7c\x81\x60\x64\x0a\x41\x60\x8a\x02\x59\x82\xbb\x1b\x7f\x1f\xf4\xa6\xb6\xba\x2a\x8e\x9d\x1e\x3e\x17\x92\x70\x45\x48\xc6\x76\x28\x24\x64\x4c\xf2\x69\xb4\x77\xb6\xc8\x8f\xf1\xab\x4d\x2f\xaf\xb9\x6b\xb2\x43\x8a\x89\x84\xdf\x53\x41\x2a\xf8\xff\x89\xc5\xe5\x8a\x90\x8d\xe0\xdb\x87\xa7\x31\x80\xde\xe5\x94\x7b\xe5\x8a\x5e\x3b\x67\x6c\xdd\x17\xe7\x25\x04\xdf\xec\xcb\xb5\x47\x53\xce\x6f\xa1\x51\xb6\x3e\xa9\x5a\xff\xa8\xeb\x2b\xd7\xc3\x32\x67\x75\x4c\xfd\x06\xd7\xdd\x68\xd4\x1d\xc3\x9d\xbc\x7f\x26\xe5\x6d\xfb\x39\x4d\xa0\x8f\x01\x00\x2b\x5c\x9e\xa1\x5f\x03\x00\x00")
+
+func _1542637421_add_account_namespaceUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1542637421_add_account_namespaceUpSql,
+		"1542637421_add_account_namespace.up.sql",
+	)
+}
+
+func _1542637421_add_account_namespaceUpSql() (*asset, error) {
+	bytes, err := _1542637421_add_account_namespaceUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1542637421_add_account_namespace.up.sql", size: 863, mode: os.FileMode(420), modTime: time.Unix(1542637421, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1543165882_add_group_receiptsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x1b\x00\xe4\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x67\x72\x6f\x75\x70\x5f\x72\x65\x63\x65\x69\x70\x74\x73\x3b\x0a\x03\x00\x1c\xeb\x84\xd5\x1b\x00\x00\x00")
+
+func _1543165882_add_group_receiptsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1543165882_add_group_receiptsDownSql,
+		"1543165882_add_group_receipts.down.sql",
+	)
+}
+
+func _1543165882_add_group_receiptsDownSql() (*asset, error) {
+	bytes, err := _1543165882_add_group_receiptsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1543165882_add_group_receipts.down.sql", size: 27, mode: os.FileMode(420), modTime: time.Unix(1543165882, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1543165882_add_group_receiptsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x8e\x41\x6b\x84\x30\x14\x06\xef\xf9\x15\xdf\x4d\x05\x0f\xbd\xf7\x94\xa6\xcf\x22\x84\xd8\xca\x0b\xf4\x26\x41\x1f\x22\xd8\x2a\x31\xee\xef\x5f\x84\x65\x59\xd9\xc3\x9e\x67\x18\xc6\xb4\xa4\x99\xc0\xfa\xc3\x12\xc6\xb8\xec\x6b\x17\xa5\x97\x69\x4d\x1b\x72\x05\x84\xbe\x5f\xf6\xff\x04\xa6\x5f\x86\x6b\x18\xce\x5b\x8b\x4f\xaa\xb4\xb7\x8c\x2c\x2b\x15\xf0\x27\xdb\x16\x46\xe9\xa6\xe1\xac\x1d\x2c\x2d\x29\xcc\xa8\x1d\xd3\x17\xb5\x27\x32\xc8\x3c\x5d\x24\xca\xf0\x44\xef\xf9\xb7\xa3\x10\x25\xbc\x52\xbc\xab\x7f\x3c\xe5\xb7\xd7\xf2\x61\xa8\x40\xe3\x60\x1a\x57\xd9\xda\x30\x5a\xfa\xb6\xda\x90\x2a\xde\xd5\x75\x00\xf7\xcb\xf6\x01\xf8\x00\x00\x00")
+
+func _1543165882_add_group_receiptsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1543165882_add_group_receiptsUpSql,
+		"1543165882_add_group_receipts.up.sql",
+	)
+}
+
+func _1543165882_add_group_receiptsUpSql() (*asset, error) {
+	bytes, err := _1543165882_add_group_receiptsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1543165882_add_group_receipts.up.sql", size: 248, mode: os.FileMode(420), modTime: time.Unix(1543165882, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1543501293_add_installations_disabled_atDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x91\xb1\x6a\xf3\x40\x10\x84\xfb\x7d\x8a\x29\x2d\x50\xf3\xd7\xaa\x4e\xd2\xca\x1c\xac\xf7\xfc\x9f\x56\x90\xce\x28\x48\xc5\x81\x2c\x07\x74\x4d\xde\x3e\x38\x60\x82\x4c\x5c\xa4\xde\x6f\x76\x66\x76\x9b\xc8\xce\x18\xe6\x6a\x61\xa4\x75\xcb\xe3\xb2\x8c\x39\xdd\xd6\xed\x72\x5b\x26\x1c\x08\x48\xd3\xbc\xe6\x94\x3f\x51\x4b\xa8\xa1\xc1\xa0\x83\x48\x49\xd8\xf1\x97\x34\xc1\xf8\xcd\x76\x40\x4e\xd7\x79\xcb\xe3\xf5\x03\x83\xf6\xfe\xa8\xdc\xa2\xf6\x47\x78\xdd\x63\xf3\x3a\xbe\x2f\xf3\x84\x3a\x04\x61\xa7\x68\xb9\x73\x83\x18\xfe\xdd\x87\x83\xfa\xff\x03\x1f\x1e\x29\xca\x67\xd7\x02\x41\xd1\x04\xed\xc4\x37\x86\xc8\x67\x71\x0d\x53\x51\x11\x79\xed\x39\xda\xdd\x2d\xec\x44\xdf\xd5\x5e\x2f\x2c\x7f\x62\x97\x8f\x68\x05\xf5\x2c\xdc\x18\xfe\xa4\x42\x17\xc3\x69\x07\x6e\x15\x51\x1b\xc3\xf9\xb7\x83\x57\x44\x4e\x8c\xe3\xcb\x67\x44\x56\x77\x62\x3c\xb7\xa9\xe8\x6b\x00\x42\xef\xa7\x31\xc5\x01\x00\x00")
+
+func _1543501293_add_installations_disabled_atDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1543501293_add_installations_disabled_atDownSql,
+		"1543501293_add_installations_disabled_at.down.sql",
+	)
+}
+
+func _1543501293_add_installations_disabled_atDownSql() (*asset, error) {
+	bytes, err := _1543501293_add_installations_disabled_atDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1543501293_add_installations_disabled_at.down.sql", size: 453, mode: os.FileMode(420), modTime: time.Unix(1543501293, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1543501293_add_installations_disabled_atUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x5c\xcc\xb1\x6a\x84\x30\x18\x07\xf0\xdd\xa7\xf8\x2f\x25\x0a\x1d\xdc\xc5\x21\x9a\xaf\x56\x48\x63\x31\x5f\xe8\x58\x22\xa6\x10\x48\x23\x34\x81\xbe\xfe\x71\xe3\xdd\x0b\xfc\xa4\x66\xda\xc1\x72\xd2\x84\x98\x4b\xf5\x29\xf9\x1a\xaf\x5c\x20\x95\xc2\xbc\x69\xf7\x61\x70\xc6\xe2\x8f\x14\xce\x6f\x5f\xe1\x8c\x5d\x17\x43\x0a\xd3\xba\x60\x35\x0c\xb3\x31\x8c\xd3\x1a\x8a\xde\xa4\xd3\x8c\x7e\x68\xdc\xa7\x92\xfc\x0c\x5a\xe2\x07\x69\xc4\x2c\x2d\xb7\xa5\xfe\xfd\xd4\xf8\x1b\x5a\xf1\x52\xc4\x2b\x44\xbe\xfe\x45\x07\x69\xef\x38\x2d\xb4\x77\xf8\x7a\xa7\x9d\x10\xb2\x3f\x52\x38\x31\xa2\x1f\x9a\xdb\x00\xa0\x44\xc4\xed\xb7\x00\x00\x00")
+
+func _1543501293_add_installations_disabled_atUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1543501293_add_installations_disabled_atUpSql,
+		"1543501293_add_installations_disabled_at.up.sql",
+	)
+}
+
+func _1543501293_add_installations_disabled_atUpSql() (*asset, error) {
+	bytes, err := _1543501293_add_installations_disabled_atUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1543501293_add_installations_disabled_at.up.sql", size: 183, mode: os.FileMode(420), modTime: time.Unix(1543501293, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1543919112_add_processed_messagesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x48\x00\xb7\xff\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x70\x72\x6f\x63\x65\x73\x73\x65\x64\x5f\x6d\x65\x73\x73\x61\x67\x65\x73\x5f\x74\x69\x6d\x65\x73\x74\x61\x6d\x70\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x70\x72\x6f\x63\x65\x73\x73\x65\x64\x5f\x6d\x65\x73\x73\x61\x67\x65\x73\x3b\x0a\x03\x00\x5f\x4a\x6b\xd9\x48\x00\x00\x00")
+
+func _1543919112_add_processed_messagesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1543919112_add_processed_messagesDownSql,
+		"1543919112_add_processed_messages.down.sql",
+	)
+}
+
+func _1543919112_add_processed_messagesDownSql() (*asset, error) {
+	bytes, err := _1543919112_add_processed_messagesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1543919112_add_processed_messages.down.sql", size: 72, mode: os.FileMode(420), modTime: time.Unix(1543919112, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1543919112_add_processed_messagesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x90\xcd\x6a\xc3\x30\x10\x84\xef\x7a\x8a\xb9\xc5\x06\xbf\x41\x4e\xfe\xd9\x04\x81\x58\xb5\xa9\x04\xb9\x19\x61\x8b\xc6\x10\xdb\x21\xab\xbe\x7f\x09\x88\x14\x37\xb9\xee\x0c\xfb\x7d\x4c\x7b\xa2\xda\x11\x5c\xdd\x18\xc2\xed\xbe\x0e\x51\x24\x8e\xfd\x1c\x45\xc2\x77\x14\x14\x0a\x08\xc3\xb0\xfe\x2c\x09\x8e\xce\x0e\x6c\x1d\xd8\x1b\x83\x8e\x0e\xb5\x37\x0e\xbb\x5d\xa5\x00\x89\xcb\x18\xef\x68\x8c\x6d\x9e\x95\xc7\x3d\x3f\xea\x2f\x41\x2e\xaf\xe9\xb4\x48\x0a\xd7\x6b\x48\xd3\xba\xf4\xd3\xb8\x25\x3c\x0a\x69\x9a\xa3\xa4\x30\xdf\xe0\xf9\x4b\x1f\x99\x3a\x34\xfa\x08\xcd\xdb\x9a\x67\xfd\xe9\xa9\xc8\xa2\x55\xb6\xa9\x36\xf4\xea\x3f\xad\x84\x65\xb4\x96\x0f\x46\xb7\x0e\x27\xfa\x30\x75\x4b\xaa\xdc\x2b\x95\x47\xd1\xdc\xd1\xf9\xcd\x28\xfd\x9f\x95\xe5\x37\x79\xf1\xcc\xcb\xbd\xfa\x1d\x00\x24\xbc\x17\x41\x61\x01\x00\x00")
+
+func _1543919112_add_processed_messagesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1543919112_add_processed_messagesUpSql,
+		"1543919112_add_processed_messages.up.sql",
+	)
+}
+
+func _1543919112_add_processed_messagesUpSql() (*asset, error) {
+	bytes, err := _1543919112_add_processed_messagesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1543919112_add_processed_messages.up.sql", size: 353, mode: os.FileMode(420), modTime: time.Unix(1543919112, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1544190315_add_moderated_channelsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x1f\x00\xe0\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x6d\x6f\x64\x65\x72\x61\x74\x65\x64\x5f\x63\x68\x61\x6e\x6e\x65\x6c\x73\x3b\x0a\x03\x00\xbd\xec\x12\xc0\x1f\x00\x00\x00")
+
+func _1544190315_add_moderated_channelsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1544190315_add_moderated_channelsDownSql,
+		"1544190315_add_moderated_channels.down.sql",
+	)
+}
+
+func _1544190315_add_moderated_channelsDownSql() (*asset, error) {
+	bytes, err := _1544190315_add_moderated_channelsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1544190315_add_moderated_channels.down.sql", size: 31, mode: os.FileMode(420), modTime: time.Unix(1544190315, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1544190315_add_moderated_channelsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x8e\x41\x6b\x84\x30\x10\x85\xef\xf9\x15\xef\xa6\x82\x87\xde\x7b\x8a\xe9\x58\x84\x90\xb4\x32\x81\xde\x24\x44\x41\xc1\x9a\xa2\xe9\xff\x5f\xb2\x2b\x0b\xcb\xee\x71\xe6\xbd\xc7\xf7\xa9\x9e\x24\x13\x58\x36\x9a\xf0\x1b\xc7\x69\xf7\x69\x1a\x87\x30\xfb\x6d\x9b\xd6\x03\xa5\x00\x7c\x08\xf1\x7f\x4b\x60\xfa\x61\x18\xcb\x30\x4e\x6b\x7c\x50\x2b\x9d\x66\x14\x45\x2d\x80\x14\xff\x96\x80\x46\xdb\xe6\xde\xc8\xef\x30\xfb\x34\x2c\xe3\xe3\x34\x07\x27\x2a\xee\xaf\x23\x74\x86\xe9\x93\xfa\x67\xdc\x5b\xae\xac\xcb\x91\xae\xb0\x7c\x38\xd3\x7d\x3b\x2a\x4f\xcb\xfa\xa6\x52\xc1\x1a\x28\x6b\x5a\xdd\x29\x46\x4f\x5f\x5a\x2a\x12\xd5\xbb\xb8\x0c\x00\xb8\x02\x8b\xc2\xf1\x00\x00\x00")
+
+func _1544190315_add_moderated_channelsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1544190315_add_moderated_channelsUpSql,
+		"1544190315_add_moderated_channels.up.sql",
+	)
+}
+
+func _1544190315_add_moderated_channelsUpSql() (*asset, error) {
+	bytes, err := _1544190315_add_moderated_channelsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1544190315_add_moderated_channels.up.sql", size: 241, mode: os.FileMode(420), modTime: time.Unix(1544190315, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1544531725_add_envelope_statesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x1c\x00\xe3\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x65\x6e\x76\x65\x6c\x6f\x70\x65\x5f\x73\x74\x61\x74\x65\x73\x3b\x0a\x03\x00\xdd\x73\x94\xf4\x1c\x00\x00\x00")
+
+func _1544531725_add_envelope_statesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1544531725_add_envelope_statesDownSql,
+		"1544531725_add_envelope_states.down.sql",
+	)
+}
+
+func _1544531725_add_envelope_statesDownSql() (*asset, error) {
+	bytes, err := _1544531725_add_envelope_statesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1544531725_add_envelope_states.down.sql", size: 28, mode: os.FileMode(420), modTime: time.Unix(1544531725, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1544531725_add_envelope_statesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\xcd\xc1\x0a\xc2\x30\x10\x04\xd0\x7b\xbe\x62\x6e\x6d\xa1\x7f\xe0\x29\xd1\x55\x0a\x21\xc5\xb2\x01\x6f\x65\x69\x03\x39\x48\x5b\xc8\xd6\xef\x17\x45\x04\xc1\xeb\xbc\x61\xe6\x38\x90\x65\x02\x5b\xe7\x09\x69\x79\xa4\xfb\xba\xa5\xb1\xa8\x68\x2a\xa8\x0d\x20\xd3\xb4\xee\x8b\x82\xe9\xc6\x08\x3d\x23\x44\xef\x71\xa2\xb3\x8d\x9e\x51\x55\xad\x01\xb2\x94\x0c\xe7\x7b\xf7\x2d\xbc\xd2\xf7\x08\xba\xc0\x74\xa1\xe1\x47\xf6\x6d\x16\x4d\xf3\x28\xfa\x97\x63\xe8\xae\x91\xea\xcf\x73\x8b\x2c\x25\x37\xa6\x39\x98\xe7\x00\xc7\xc4\xd0\x8a\xad\x00\x00\x00")
+
+func _1544531725_add_envelope_statesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1544531725_add_envelope_statesUpSql,
+		"1544531725_add_envelope_states.up.sql",
+	)
+}
+
+func _1544531725_add_envelope_statesUpSql() (*asset, error) {
+	bytes, err := _1544531725_add_envelope_statesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1544531725_add_envelope_states.up.sql", size: 173, mode: os.FileMode(420), modTime: time.Unix(1544531725, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1544617200_add_contact_mailserversDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x20\x00\xdf\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x63\x6f\x6e\x74\x61\x63\x74\x5f\x6d\x61\x69\x6c\x73\x65\x72\x76\x65\x72\x73\x3b\x0a\x03\x00\x3a\x66\xb7\x34\x20\x00\x00\x00")
+
+func _1544617200_add_contact_mailserversDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1544617200_add_contact_mailserversDownSql,
+		"1544617200_add_contact_mailservers.down.sql",
+	)
+}
+
+func _1544617200_add_contact_mailserversDownSql() (*asset, error) {
+	bytes, err := _1544617200_add_contact_mailserversDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1544617200_add_contact_mailservers.down.sql", size: 32, mode: os.FileMode(420), modTime: time.Unix(1544617200, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1544617200_add_contact_mailserversUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x0e\x72\x75\x0c\x71\x55\x08\x71\x74\xf2\x71\x55\x48\xce\xcf\x2b\x49\x4c\x2e\x89\xcf\x4d\xcc\xcc\x29\x4e\x2d\x2a\x4b\x2d\x2a\x56\xd0\xe0\x52\x50\x48\x4c\x4e\xce\x2f\xcd\x2b\x51\x08\x71\x8d\x08\x51\xf0\xf3\x0f\x51\xf0\x0b\xf5\xf1\x51\x70\x71\x75\x73\x0c\xf5\x09\x51\x50\x57\xd7\xe1\x52\x50\x28\x28\x4d\xca\xc9\x4c\x8e\xcf\x4e\xad\x54\x70\xf2\xf1\x77\x82\x2b\x03\xc9\xa5\xe6\xe5\xa7\xa4\xa2\xea\x06\x09\x87\xfa\x79\x06\x86\xba\x6a\x40\x4d\xd7\x41\x32\x42\x93\x4b\xd3\x9a\x0b\x30\x00\x46\xaf\xf4\xef\x9b\x00\x00\x00")
+
+func _1544617200_add_contact_mailserversUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1544617200_add_contact_mailserversUpSql,
+		"1544617200_add_contact_mailservers.up.sql",
+	)
+}
+
+func _1544617200_add_contact_mailserversUpSql() (*asset, error) {
+	bytes, err := _1544617200_add_contact_mailserversUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1544617200_add_contact_mailservers.up.sql", size: 155, mode: os.FileMode(420), modTime: time.Unix(1544617200, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1544703600_add_compression_dictionariesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x25\x00\xda\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x63\x6f\x6d\x70\x72\x65\x73\x73\x69\x6f\x6e\x5f\x64\x69\x63\x74\x69\x6f\x6e\x61\x72\x69\x65\x73\x3b\x0a\x03\x00\x76\x7f\x54\xbf\x25\x00\x00\x00")
+
+func _1544703600_add_compression_dictionariesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1544703600_add_compression_dictionariesDownSql,
+		"1544703600_add_compression_dictionaries.down.sql",
+	)
+}
+
+func _1544703600_add_compression_dictionariesDownSql() (*asset, error) {
+	bytes, err := _1544703600_add_compression_dictionariesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1544703600_add_compression_dictionaries.down.sql", size: 37, mode: os.FileMode(420), modTime: time.Unix(1544703600, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1544703600_add_compression_dictionariesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\x8d\x41\xca\xc2\x30\x14\x06\xf7\x39\xc5\xb7\x6b\x0b\xbd\xc1\xbf\x6a\x7f\x9f\x20\x84\x16\xe5\x05\xdc\x95\x90\x66\xf1\xa0\x26\xd2\x44\xc1\xdb\x4b\x44\x0a\xea\x7a\x86\x99\xff\x13\x75\x4c\xe0\xae\xd7\x04\x17\x2f\xd7\xd5\xa7\x24\x31\x4c\xb3\xb8\x2c\x31\xd8\x55\x7c\x42\xad\x00\xeb\x5c\xbc\x85\x0c\xa6\x33\x63\x18\x19\x83\xd1\x1a\x3b\xda\x77\x46\x33\xaa\xaa\x55\x80\xcc\x3e\x64\xc9\x0f\xf4\x7a\xec\x37\xe9\x45\x42\xca\x76\x59\x6c\x69\x4e\x32\x7f\x56\x8a\x70\xf7\x6b\xf9\xa6\x5f\x62\x86\xc3\xd1\x50\xfd\xfe\xb7\xdb\xa4\xfd\x8e\x36\xaa\xf9\x53\xcf\x01\x00\xe1\xf2\xe1\x97\xd1\x00\x00\x00")
+
+func _1544703600_add_compression_dictionariesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1544703600_add_compression_dictionariesUpSql,
+		"1544703600_add_compression_dictionaries.up.sql",
+	)
+}
+
+func _1544703600_add_compression_dictionariesUpSql() (*asset, error) {
+	bytes, err := _1544703600_add_compression_dictionariesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1544703600_add_compression_dictionaries.up.sql", size: 209, mode: os.FileMode(420), modTime: time.Unix(1544703600, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1544790000_add_pending_messagesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x41\x00\xbe\xff\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x70\x65\x6e\x64\x69\x6e\x67\x5f\x6d\x65\x73\x73\x61\x67\x65\x73\x5f\x73\x65\x6e\x64\x65\x72\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x70\x65\x6e\x64\x69\x6e\x67\x5f\x6d\x65\x73\x73\x61\x67\x65\x73\x3b\x0a\x03\x00\xd9\x04\xd2\x0f\x41\x00\x00\x00")
+
+func _1544790000_add_pending_messagesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1544790000_add_pending_messagesDownSql,
+		"1544790000_add_pending_messages.down.sql",
+	)
+}
+
+func _1544790000_add_pending_messagesDownSql() (*asset, error) {
+	bytes, err := _1544790000_add_pending_messagesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1544790000_add_pending_messages.down.sql", size: 65, mode: os.FileMode(420), modTime: time.Unix(1544790000, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1544790000_add_pending_messagesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x8f\x31\xab\x83\x30\x14\x85\xf7\xfc\x8a\xb3\xa9\xe0\xf0\x76\x27\x7d\xe6\x81\x10\x22\xaf\x24\xe0\x26\xc1\x5c\xd4\xc1\x54\x4c\xda\xdf\x5f\x2c\xa1\xa5\xa4\xeb\x3d\x1f\xe7\x7e\xe7\xf7\xc2\x6b\xc5\xa1\xea\x46\x70\xec\xe4\xec\xea\xe6\x71\x23\xef\xcd\x4c\x1e\x39\x03\xcc\x34\x5d\x6f\x2e\x40\xf1\x41\x41\xf6\x0a\x52\x0b\x81\x96\xff\xd5\x5a\x28\x64\x59\xc9\x80\xc5\xf8\x05\x8d\xe8\x9b\x17\x70\x5e\x3d\x39\x4b\x47\x7a\x8f\xf5\x69\x60\x42\xa0\x6d\x0f\x1e\x9d\xfc\xf2\xea\xe7\x44\x0e\x9a\x68\xbd\x93\x1d\x4d\xf8\xa0\xce\x4c\xcb\xee\x5f\xf3\x3c\x0a\x97\x4f\xab\x82\x15\x15\x63\x71\x65\x27\x5b\x3e\x24\x2b\xc7\xe8\xd9\xcb\x24\x7a\x77\x79\x72\x96\x8e\xa2\x62\x8f\x01\x00\xaa\xfe\x2e\xb0\x31\x01\x00\x00")
+
+func _1544790000_add_pending_messagesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1544790000_add_pending_messagesUpSql,
+		"1544790000_add_pending_messages.up.sql",
+	)
+}
+
+func _1544790000_add_pending_messagesUpSql() (*asset, error) {
+	bytes, err := _1544790000_add_pending_messagesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1544790000_add_pending_messages.up.sql", size: 305, mode: os.FileMode(420), modTime: time.Unix(1544790000, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1544876400_add_contact_topicsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x1b\x00\xe4\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x63\x6f\x6e\x74\x61\x63\x74\x5f\x74\x6f\x70\x69\x63\x73\x3b\x0a\x03\x00\x92\x9e\x65\xac\x1b\x00\x00\x00")
+
+func _1544876400_add_contact_topicsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1544876400_add_contact_topicsDownSql,
+		"1544876400_add_contact_topics.down.sql",
+	)
+}
+
+func _1544876400_add_contact_topicsDownSql() (*asset, error) {
+	bytes, err := _1544876400_add_contact_topicsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1544876400_add_contact_topics.down.sql", size: 27, mode: os.FileMode(420), modTime: time.Unix(1544876400, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1544876400_add_contact_topicsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\x8d\xb1\x0a\xc2\x30\x14\x45\xf7\x7c\xc5\xdd\xda\x42\xff\xc0\x29\xd1\x27\x08\x21\x45\x79\x01\xb7\x12\x52\x87\x40\x79\x11\xfa\x1c\xfc\x7b\xb1\x48\x41\x9d\xef\xb9\xe7\xec\x2f\x64\x99\xc0\xd6\x79\x42\xae\xa2\x29\xeb\xa8\xf5\x5e\xf2\x82\xd6\x00\x29\xe7\xfa\x10\x05\xd3\x95\x11\x06\x46\x88\xde\xe3\x40\x47\x1b\x3d\xa3\x69\x7a\x03\x94\xe9\x26\x5a\xf4\x09\xe7\x07\xb7\x41\xeb\x22\x8b\xa6\x79\x4e\x5a\xaa\x8c\x65\xfa\xb6\xbc\x81\xb5\xf4\xff\x8b\xe1\x74\x8e\xd4\x7e\xe2\xfd\x56\xe8\x7f\x8d\x9d\xe9\x76\xe6\x35\x00\x87\x6a\x82\x6b\xc4\x00\x00\x00")
+
+func _1544876400_add_contact_topicsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1544876400_add_contact_topicsUpSql,
+		"1544876400_add_contact_topics.up.sql",
+	)
+}
+
+func _1544876400_add_contact_topicsUpSql() (*asset, error) {
+	bytes, err := _1544876400_add_contact_topicsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1544876400_add_contact_topics.up.sql", size: 196, mode: os.FileMode(420), modTime: time.Unix(1544876400, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1544962800_add_contact_capabilitiesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x21\x00\xde\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x63\x6f\x6e\x74\x61\x63\x74\x5f\x63\x61\x70\x61\x62\x69\x6c\x69\x74\x69\x65\x73\x3b\x0a\x03\x00\x75\xd5\xb2\xa1\x21\x00\x00\x00")
+
+func _1544962800_add_contact_capabilitiesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1544962800_add_contact_capabilitiesDownSql,
+		"1544962800_add_contact_capabilities.down.sql",
+	)
+}
+
+func _1544962800_add_contact_capabilitiesDownSql() (*asset, error) {
+	bytes, err := _1544962800_add_contact_capabilitiesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1544962800_add_contact_capabilities.down.sql", size: 33, mode: os.FileMode(420), modTime: time.Unix(1544962800, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1544962800_add_contact_capabilitiesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\x8e\xc1\x0a\xc2\x30\x10\x44\xef\xf9\x8a\xb9\xb5\x85\xfe\x81\xa7\x56\x23\x14\x42\x8a\x92\x80\xb7\x12\xd3\x08\x0b\x25\x91\x66\x2b\xf8\xf7\xa2\x48\xa1\x7a\xdd\x7d\x33\x6f\xf6\x67\xd9\x18\x09\xd3\xb4\x4a\xc2\xa7\xc8\xce\xf3\xe0\xdd\xdd\x5d\x69\x22\xa6\x90\x51\x0a\xc0\x79\x9f\x96\xc8\x30\xf2\x62\xa0\x7b\x03\x6d\x95\xc2\x41\x1e\x1b\xab\x0c\x8a\xa2\x16\x00\x8d\x21\x32\xf1\x13\xad\xea\xdb\x15\xfa\x7c\x62\x66\x37\x4d\x8e\x29\xc5\x81\xc6\x6d\xcb\x1b\x78\x84\x39\x53\x8a\xe8\xf4\xf6\x7e\x0b\x8e\x97\x39\xe4\xff\x84\xd5\xdd\xc9\xca\xf2\xbb\xab\x5e\xe5\xf5\xaf\xac\x12\xd5\x4e\xbc\x06\x00\x04\xbc\xe8\xe6\xe5\x00\x00\x00")
+
+func _1544962800_add_contact_capabilitiesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1544962800_add_contact_capabilitiesUpSql,
+		"1544962800_add_contact_capabilities.up.sql",
+	)
+}
+
+func _1544962800_add_contact_capabilitiesUpSql() (*asset, error) {
+	bytes, err := _1544962800_add_contact_capabilitiesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1544962800_add_contact_capabilities.up.sql", size: 229, mode: os.FileMode(420), modTime: time.Unix(1544962800, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545049200_add_segmentsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x15\x00\xea\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x73\x65\x67\x6d\x65\x6e\x74\x73\x3b\x0a\x03\x00\x81\x2b\x51\xe1\x15\x00\x00\x00")
+
+func _1545049200_add_segmentsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545049200_add_segmentsDownSql,
+		"1545049200_add_segments.down.sql",
+	)
+}
+
+func _1545049200_add_segmentsDownSql() (*asset, error) {
+	bytes, err := _1545049200_add_segmentsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545049200_add_segments.down.sql", size: 21, mode: os.FileMode(420), modTime: time.Unix(1545049200, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545049200_add_segmentsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\x8e\x41\xaa\xc2\x30\x14\x45\xe7\x59\xc5\x9d\xb5\x85\xec\xe0\x8f\x92\xef\x13\x0a\x21\x45\x79\x01\x67\x12\x92\x60\x33\xb0\x82\x89\xd2\xe5\x4b\x55\x14\xec\xf4\x70\xcf\xe5\xfc\xef\x49\x31\x81\x95\x36\x84\x92\x4e\xe7\x34\xd5\x82\x56\x00\x3e\x84\xcb\x6d\xaa\x60\x3a\x30\xec\xc0\xb0\xce\x18\x6c\x68\xab\x9c\x61\x34\x8d\x14\xc0\xe8\xcb\x08\x6d\x06\xfd\x19\x2c\x34\xc7\x19\xbd\xfd\x4a\x0b\x7b\x7d\xfd\xd2\xe8\xab\x5f\xfb\xd7\x14\x52\xbe\xa7\x78\xf4\x6b\xc3\xd9\x7e\xe7\xa8\x7d\xc7\xc9\x67\x81\x44\x8e\x73\x27\xba\x3f\xf1\x18\x00\x2d\x1b\x42\xad\xce\x00\x00\x00")
+
+func _1545049200_add_segmentsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545049200_add_segmentsUpSql,
+		"1545049200_add_segments.up.sql",
+	)
+}
+
+func _1545049200_add_segmentsUpSql() (*asset, error) {
+	bytes, err := _1545049200_add_segmentsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545049200_add_segments.up.sql", size: 206, mode: os.FileMode(420), modTime: time.Unix(1545049200, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545135600_add_notification_preferencesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x25\x00\xda\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x6e\x6f\x74\x69\x66\x69\x63\x61\x74\x69\x6f\x6e\x5f\x70\x72\x65\x66\x65\x72\x65\x6e\x63\x65\x73\x3b\x0a\x03\x00\x4e\x84\x09\xdf\x25\x00\x00\x00")
+
+func _1545135600_add_notification_preferencesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545135600_add_notification_preferencesDownSql,
+		"1545135600_add_notification_preferences.down.sql",
+	)
+}
+
+func _1545135600_add_notification_preferencesDownSql() (*asset, error) {
+	bytes, err := _1545135600_add_notification_preferencesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545135600_add_notification_preferences.down.sql", size: 37, mode: os.FileMode(420), modTime: time.Unix(1545135600, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545135600_add_notification_preferencesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x4c\xcc\xc1\xca\xc2\x30\x10\x04\xe0\x7b\x9e\x62\x6e\x6d\xe1\x7f\x83\xff\x94\xc6\x2d\x14\x96\x44\xcb\x06\xbc\x49\x59\x52\x28\x4a\x22\x35\xbe\xbf\x14\x44\xbd\xce\xcc\x37\x6e\x22\x2b\x04\xb1\x3d\x13\x72\xa9\xeb\xb2\xea\x5c\xd7\x92\x2f\xf7\x2d\x2d\x69\x4b\x59\xd3\x03\xad\x01\x66\xd5\xf2\xcc\x15\x42\x67\x81\x0f\x02\x1f\x99\x71\xa0\xc1\x46\x16\x34\xcd\x9f\x01\x7e\x4d\xcf\xa1\xff\xec\xf6\x52\x6f\x45\xaf\x18\xfd\x57\xef\x69\xf4\xe3\x29\x52\xfb\x7e\xef\x10\x3c\x5c\xf0\x03\x8f\x4e\x30\xd1\x91\xad\x23\xd3\xfd\x9b\xd7\x00\x95\x42\xbe\x0f\xa8\x00\x00\x00")
+
+func _1545135600_add_notification_preferencesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545135600_add_notification_preferencesUpSql,
+		"1545135600_add_notification_preferences.up.sql",
+	)
+}
+
+func _1545135600_add_notification_preferencesUpSql() (*asset, error) {
+	bytes, err := _1545135600_add_notification_preferencesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545135600_add_notification_preferences.up.sql", size: 168, mode: os.FileMode(420), modTime: time.Unix(1545135600, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545222000_add_attachmentsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x18\x00\xe7\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x61\x74\x74\x61\x63\x68\x6d\x65\x6e\x74\x73\x3b\x0a\x03\x00\xa4\xcb\x1b\x78\x18\x00\x00\x00")
+
+func _1545222000_add_attachmentsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545222000_add_attachmentsDownSql,
+		"1545222000_add_attachments.down.sql",
+	)
+}
+
+func _1545222000_add_attachmentsDownSql() (*asset, error) {
+	bytes, err := _1545222000_add_attachmentsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545222000_add_attachments.down.sql", size: 24, mode: os.FileMode(420), modTime: time.Unix(1545222000, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545222000_add_attachmentsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\x8f\xc1\x4a\xc4\x30\x10\x86\xef\x79\x8a\xff\xb6\xbb\xb0\x6f\xe0\x29\x8d\x53\x28\x84\x44\x4b\x02\xde\xca\x90\x06\x5a\xc4\x54\xc8\x78\xa8\x4f\x2f\x2d\x22\xb8\x39\xce\xc7\xf7\x0f\x7c\x66\x24\x1d\x08\x41\x77\x96\xc0\x22\x9c\x96\x8f\x5c\xa4\xe2\xaa\x00\x4e\x69\xfb\x2a\x82\x40\x6f\x01\xce\x07\xb8\x68\x2d\x9e\xa9\xd7\xd1\x06\x5c\x2e\x77\x05\x2c\x5c\x17\x74\xd6\x77\x7f\xc2\x41\xd7\xf9\xff\xe8\x60\xef\x79\x6f\xc5\xb4\x15\xc9\x45\x26\xd9\x3f\x73\x3b\xa9\xeb\x77\xc6\xe0\x1e\xa0\xb0\xb4\x74\x66\xe1\xf3\xfd\x71\x70\x4a\xb9\xd6\x3c\x4f\x2c\x8d\x18\xdd\xf0\x1a\xe9\xfa\xdb\x76\x3f\x03\x6e\xf0\x0e\xc6\xbb\xde\x0e\x26\x60\xa4\x17\xab\x0d\xa9\xdb\x93\xfa\x19\x00\xe0\x20\x1a\x0a\x1f\x01\x00\x00")
+
+func _1545222000_add_attachmentsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545222000_add_attachmentsUpSql,
+		"1545222000_add_attachments.up.sql",
+	)
+}
+
+func _1545222000_add_attachmentsUpSql() (*asset, error) {
+	bytes, err := _1545222000_add_attachmentsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545222000_add_attachments.up.sql", size: 287, mode: os.FileMode(420), modTime: time.Unix(1545222000, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545308400_add_message_changesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x56\x00\xa9\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x6d\x65\x73\x73\x61\x67\x65\x5f\x72\x65\x61\x63\x74\x69\x6f\x6e\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x6d\x65\x73\x73\x61\x67\x65\x5f\x63\x68\x61\x6e\x67\x65\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x6d\x65\x73\x73\x61\x67\x65\x5f\x61\x75\x74\x68\x6f\x72\x73\x3b\x0a\x03\x00\x3d\xd9\xf2\xd0\x56\x00\x00\x00")
+
+func _1545308400_add_message_changesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545308400_add_message_changesDownSql,
+		"1545308400_add_message_changes.down.sql",
+	)
+}
+
+func _1545308400_add_message_changesDownSql() (*asset, error) {
+	bytes, err := _1545308400_add_message_changesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545308400_add_message_changes.down.sql", size: 86, mode: os.FileMode(420), modTime: time.Unix(1545308400, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545308400_add_message_changesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x90\xc1\x6a\xc3\x30\x10\x44\xef\xfa\x8a\xb9\x25\x01\xff\x41\x4f\x8a\xbb\x29\x06\x21\xb5\x46\x86\xde\x82\x90\x17\xc7\x6d\x22\x81\xbc\xf9\xff\x12\x12\x0a\x6e\xda\xd0\x42\x73\x9d\x45\x33\xef\xa9\x6e\x49\x7b\x82\xd7\x6b\x43\x38\xf0\x34\x85\x81\xb7\xe1\x28\xbb\x5c\x26\x2c\x15\x10\x62\xcc\xc7\x24\xf0\xf4\xea\x61\x9d\x87\xed\x8c\xc1\x23\x6d\x74\x67\x3c\x16\x8b\x4a\x01\x63\x3f\x3f\x9f\xb2\x73\xc7\x75\xde\xd9\xe6\xa5\xa3\xe5\xa5\xb6\xc2\xd8\xaf\xe0\x2c\x6a\x67\x37\xa6\xa9\x3d\x9a\x27\xeb\x5a\x52\xab\x07\xa5\xbe\x65\x8b\xbb\x90\x06\xfe\x3d\x9b\x84\x32\xb0\x6c\xff\x82\x18\x73\x12\xfe\x5a\x7b\x3a\xf4\xbc\x67\xe1\x1e\x6b\xe7\x0c\x69\x3b\x7f\xb4\xcf\xf1\x1d\x8d\xbd\x6d\xfb\x49\x53\x5d\xd6\xe7\xf2\x2d\x3d\x1b\x5d\xdf\xb0\x2f\x1c\xa2\x8c\x39\xdd\xd5\x9f\x0f\xf9\x6d\xbc\x8e\x0b\x4b\x09\xf1\x9f\xfd\xab\xf3\xda\x8f\xdf\xf0\x31\x00\xf9\xdd\xcd\x3b\xa0\x02\x00\x00")
+
+func _1545308400_add_message_changesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545308400_add_message_changesUpSql,
+		"1545308400_add_message_changes.up.sql",
+	)
+}
+
+func _1545308400_add_message_changesUpSql() (*asset, error) {
+	bytes, err := _1545308400_add_message_changesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545308400_add_message_changes.up.sql", size: 672, mode: os.FileMode(420), modTime: time.Unix(1545308400, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545394800_add_history_messagesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\xca\x31\xae\x82\x50\x10\x05\xd0\x9e\x55\xdc\x05\x7c\xfe\x06\xa8\x34\x22\x21\x21\x51\x81\xc2\x8e\x20\x5c\x7c\x93\x20\x90\x37\x43\x84\xdd\x5b\x59\x68\x61\xb4\x3f\x61\x88\xd2\x11\xdd\xdc\xf7\xa1\x71\x31\xc8\xd0\x72\x81\x28\x1a\xcf\xda\xd8\xe2\xb2\xc2\x1c\x31\xd1\xab\xa8\x71\x68\xf8\x87\xbb\xe3\x80\xe2\x94\x89\x11\x3a\x4f\xd3\xe8\x4d\x21\xf6\x1f\xec\xf2\xc3\x11\x65\x9e\x26\x49\x9c\x23\xdd\x23\x3e\xa7\x45\x59\xc0\x89\xda\xe8\xd7\xea\x46\xd5\xfa\x4a\xad\xea\x39\xfa\xde\xb6\x3f\x58\x79\xda\xcd\x36\x8b\x3f\xc9\xce\xf4\x85\xbe\x83\x28\x78\x0c\x00\xa8\x52\xcf\xfd\x1b\x01\x00\x00")
+
+func _1545394800_add_history_messagesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545394800_add_history_messagesDownSql,
+		"1545394800_add_history_messages.down.sql",
+	)
+}
+
+func _1545394800_add_history_messagesDownSql() (*asset, error) {
+	bytes, err := _1545394800_add_history_messagesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545394800_add_history_messages.down.sql", size: 283, mode: os.FileMode(420), modTime: time.Unix(1545394800, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545394800_add_history_messagesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x74\xd1\x4f\x8b\x83\x30\x10\x05\xf0\x7b\x3e\xc5\xbb\xb5\x05\x0f\x7b\xef\xc9\xda\x74\x11\xc2\x84\x2d\x11\x7a\x13\xd1\xa0\x61\xab\x11\x33\x1e\xfc\xf6\x4b\xbb\xb2\x8b\xa4\xbd\xce\x2f\x7f\x86\xf7\xb2\xab\x4c\x8d\x84\x49\x4f\x4a\xa2\x73\x81\xfd\xb4\x94\xbd\x0d\xa1\x6a\x6d\xc0\x5e\x00\x55\x5d\xfb\x79\x60\x18\x79\x33\x20\x6d\x40\x85\x52\x38\xcb\x4b\x5a\x28\x83\xdd\x2e\x11\x80\x6b\xb6\xfc\x98\xd5\x5d\xc5\xe5\x2b\xa8\x66\xee\xfc\xf4\xe2\x82\x1f\xd8\x0e\xfc\x16\x4a\x5e\x46\x1b\xeb\xba\xec\x1b\x9d\xec\x78\x5f\x4a\xf6\xb1\xd4\x77\x5f\x7f\x23\xa7\xed\x94\x5d\x6f\x03\x57\xfd\x18\x89\x9f\xb9\xf5\x6e\x68\x71\xd2\x5a\xc9\x94\x36\x68\x1b\xc7\xb6\x89\xe8\x2f\xa6\x8f\xc7\xdb\x05\xe5\x5f\x85\xdc\xaf\x81\x26\x70\xcd\x01\x9a\x90\x69\xba\xa8\x3c\x33\xc8\x3f\x49\x5f\xa5\x38\x1c\x85\x58\x6b\xc9\xe9\x2c\x6f\x51\x2d\xe5\x33\xda\xdf\xfd\x35\x45\xfc\xff\xc1\x5a\x41\x82\xe7\xd9\x04\xae\x39\x1c\xc5\xcf\x00\x8c\x3e\x58\x5e\xf2\x01\x00\x00")
+
+func _1545394800_add_history_messagesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545394800_add_history_messagesUpSql,
+		"1545394800_add_history_messages.up.sql",
+	)
+}
+
+func _1545394800_add_history_messagesUpSql() (*asset, error) {
+	bytes, err := _1545394800_add_history_messagesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545394800_add_history_messages.up.sql", size: 498, mode: os.FileMode(420), modTime: time.Unix(1545394800, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545481200_add_contactsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x15\x00\xea\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x63\x6f\x6e\x74\x61\x63\x74\x73\x3b\x0a\x03\x00\x66\x64\xd9\xdd\x15\x00\x00\x00")
+
+func _1545481200_add_contactsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545481200_add_contactsDownSql,
+		"1545481200_add_contacts.down.sql",
+	)
+}
+
+func _1545481200_add_contactsDownSql() (*asset, error) {
+	bytes, err := _1545481200_add_contactsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545481200_add_contacts.down.sql", size: 21, mode: os.FileMode(420), modTime: time.Unix(1545481200, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545481200_add_contactsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\xcd\x41\x0a\xc2\x30\x14\x04\xd0\x7d\x4e\x31\xbb\xb6\xd0\x1b\xb8\x8a\xf1\x17\x0a\x9f\x44\xcb\x0f\xb8\x93\x18\x8a\x14\x35\x15\x9b\x22\xde\x5e\x04\x17\x96\x6e\xe7\x0d\x33\xa6\x23\x2d\x04\xd1\x5b\x26\xc4\x31\xe5\x10\xf3\x84\x52\x01\x21\xc6\x71\x4e\x19\x42\x47\x81\x75\x02\xeb\x99\xb1\xa3\x46\x7b\x16\x14\x45\xad\x80\xc7\x7c\xbe\x0d\xf1\x74\xed\xdf\xcb\xda\xd7\x52\xb8\xf7\xeb\xf4\x15\x9e\x69\x48\x97\x69\x2d\xde\xb6\x07\x4f\xe5\xef\xb6\xfe\xdb\xae\xe0\x2c\x8c\xb3\x0d\xb7\x46\xd0\xd1\x9e\xb5\x21\x55\x6d\xd4\x67\x00\x70\x68\x7b\xdc\xbd\x00\x00\x00")
+
+func _1545481200_add_contactsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545481200_add_contactsUpSql,
+		"1545481200_add_contacts.up.sql",
+	)
+}
+
+func _1545481200_add_contactsUpSql() (*asset, error) {
+	bytes, err := _1545481200_add_contactsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545481200_add_contacts.up.sql", size: 189, mode: os.FileMode(420), modTime: time.Unix(1545481200, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545567600_add_presenceDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x38\x00\xc7\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x72\x65\x61\x64\x5f\x72\x65\x63\x65\x69\x70\x74\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x70\x72\x65\x73\x65\x6e\x63\x65\x5f\x73\x65\x74\x74\x69\x6e\x67\x73\x3b\x0a\x03\x00\xf3\xb4\x9d\xb9\x38\x00\x00\x00")
+
+func _1545567600_add_presenceDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545567600_add_presenceDownSql,
+		"1545567600_add_presence.down.sql",
+	)
+}
+
+func _1545567600_add_presenceDownSql() (*asset, error) {
+	bytes, err := _1545567600_add_presenceDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545567600_add_presence.down.sql", size: 56, mode: os.FileMode(420), modTime: time.Unix(1545567600, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545567600_add_presenceUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x8f\xc1\x6a\xc3\x30\x10\x44\xef\xfa\x8a\xb9\x25\x06\xff\x41\x4e\xb2\xba\x29\x02\x21\xb5\x66\x05\xbd\x19\xa3\x2c\xc1\xb4\x75\x8c\xa4\xfe\x7f\x31\x18\x53\x53\x28\xbd\xee\xcc\x3e\xde\x98\x9e\x34\x13\x58\x77\x8e\xb0\x64\x29\x32\x27\x19\x8a\xd4\x3a\xcd\xf7\x82\xb3\x02\xc6\x94\x1e\x5f\x73\x05\xd3\x1b\xc3\x07\x86\x8f\xce\xe1\x89\xae\x3a\x3a\xc6\xe9\xd4\x2a\x60\x7f\xe8\x5c\xe8\xf6\xd2\x9a\x44\x6f\x5f\x23\x9d\x37\x48\x83\xe0\x61\x82\xbf\x3a\x6b\x18\x3d\xbd\x38\x6d\x48\x35\x17\xa5\x0e\x22\x59\xc6\xdb\x90\x25\xc9\xb4\xd4\xff\x4b\x7c\x4a\x29\xe3\x5d\x86\xe9\x76\xac\xad\xd9\x4a\x94\xfc\xfb\x9e\x3e\x1e\xe9\x1d\xd6\xf3\x1f\xd2\xed\x0f\x72\xbb\x91\x8e\x43\xec\xb3\x0f\x3d\xa9\xe6\xa2\xbe\x07\x00\x14\x12\x76\xce\x50\x01\x00\x00")
+
+func _1545567600_add_presenceUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545567600_add_presenceUpSql,
+		"1545567600_add_presence.up.sql",
+	)
+}
+
+func _1545567600_add_presenceUpSql() (*asset, error) {
+	bytes, err := _1545567600_add_presenceUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545567600_add_presence.up.sql", size: 336, mode: os.FileMode(420), modTime: time.Unix(1545567600, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545654000_add_contact_requestsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x1d\x00\xe2\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x63\x6f\x6e\x74\x61\x63\x74\x5f\x72\x65\x71\x75\x65\x73\x74\x73\x3b\x0a\x03\x00\x33\x1a\x94\x73\x1d\x00\x00\x00")
+
+func _1545654000_add_contact_requestsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545654000_add_contact_requestsDownSql,
+		"1545654000_add_contact_requests.down.sql",
+	)
+}
+
+func _1545654000_add_contact_requestsDownSql() (*asset, error) {
+	bytes, err := _1545654000_add_contact_requestsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545654000_add_contact_requests.down.sql", size: 29, mode: os.FileMode(420), modTime: time.Unix(1545654000, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545654000_add_contact_requestsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\xcd\x41\x8b\x83\x30\x14\x04\xe0\x7b\x7e\xc5\xdc\x54\xf0\x1f\xec\x29\x9b\x7d\x82\x10\xe2\xae\xbc\xc0\xde\x24\x4d\xdf\x41\x5a\xb4\xd5\x67\xa1\xff\xbe\x14\x7a\xa8\xf4\x3a\xdf\x30\xe3\x7a\xb2\x4c\x60\xfb\xed\x09\x79\x9e\x34\x65\x1d\x16\xb9\x6e\xb2\xea\x8a\xd2\x00\x29\xe7\x79\x9b\x14\x4c\xff\x8c\xd0\x31\x42\xf4\x1e\x3f\xd4\xd8\xe8\x19\x45\x51\x1b\xe0\xb2\x1d\xce\x63\x1e\x4e\x72\xdf\xd7\x9e\xb6\x6a\x52\xf9\x8c\x17\xc9\x32\xde\xe4\x38\x24\x45\x1b\xf6\x16\x43\xfb\x17\xa9\x7c\x3d\xd7\x6f\xf3\x15\xba\x00\xd7\x85\xc6\xb7\x8e\xd1\xd3\xaf\xb7\x8e\x4c\xf5\x65\x1e\x03\x00\xe4\x5c\xc0\xee\xc8\x00\x00\x00")
+
+func _1545654000_add_contact_requestsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545654000_add_contact_requestsUpSql,
+		"1545654000_add_contact_requests.up.sql",
+	)
+}
+
+func _1545654000_add_contact_requestsUpSql() (*asset, error) {
+	bytes, err := _1545654000_add_contact_requestsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545654000_add_contact_requests.up.sql", size: 200, mode: os.FileMode(420), modTime: time.Unix(1545654000, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545740400_add_blocked_contactsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x35\x00\xca\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x6d\x75\x74\x65\x64\x5f\x63\x68\x61\x74\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x62\x6c\x6f\x63\x6b\x65\x64\x5f\x63\x6f\x6e\x74\x61\x63\x74\x73\x3b\x0a\x03\x00\xc4\x28\x07\x73\x35\x00\x00\x00")
+
+func _1545740400_add_blocked_contactsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545740400_add_blocked_contactsDownSql,
+		"1545740400_add_blocked_contacts.down.sql",
+	)
+}
+
+func _1545740400_add_blocked_contactsDownSql() (*asset, error) {
+	bytes, err := _1545740400_add_blocked_contactsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545740400_add_blocked_contacts.down.sql", size: 53, mode: os.FileMode(420), modTime: time.Unix(1545740400, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545740400_add_blocked_contactsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\xce\xb1\x8a\x83\x40\x14\x85\xe1\x7e\x9e\xe2\x74\x2a\xf8\x06\x5b\xcd\xce\x5e\x41\x18\xc6\x5d\xb9\x03\xdb\xc9\x78\x15\x14\x8d\x06\x32\x16\x79\xfb\x20\x58\xc4\x22\x84\xd4\xe7\xe7\xf0\x99\x9a\x34\x13\x58\x7f\x5b\x42\x3b\xaf\x32\xf5\x5d\x23\xeb\x12\x83\xc4\x1b\x52\x05\x04\x91\x75\x5b\x22\x98\xfe\x19\xae\x62\x38\x6f\x2d\x7e\xa8\xd0\xde\x32\x92\x24\x57\xc0\x75\x6b\xe7\x51\x9a\xa9\xbf\x9f\xb3\x7d\xf3\xae\xfc\xf3\x94\x1e\x37\xf9\x53\x9b\xa1\x72\x30\x95\x2b\x6c\x69\x18\x35\xfd\x5a\x6d\x48\x65\x5f\x4a\x9d\x54\x97\x2d\xee\xa6\x21\x7c\x00\x92\x21\xc4\x66\xec\xde\x6b\x8e\xf0\x25\xe5\x31\x00\x4c\xae\xc5\x83\x20\x01\x00\x00")
+
+func _1545740400_add_blocked_contactsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545740400_add_blocked_contactsUpSql,
+		"1545740400_add_blocked_contacts.up.sql",
+	)
+}
+
+func _1545740400_add_blocked_contactsUpSql() (*asset, error) {
+	bytes, err := _1545740400_add_blocked_contactsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545740400_add_blocked_contacts.up.sql", size: 288, mode: os.FileMode(420), modTime: time.Unix(1545740400, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545826800_add_downgradesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x5c\x00\xa3\xff\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x69\x64\x78\x5f\x64\x6f\x77\x6e\x67\x72\x61\x64\x65\x73\x5f\x70\x75\x62\x6c\x69\x63\x5f\x6b\x65\x79\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x64\x6f\x77\x6e\x67\x72\x61\x64\x65\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x63\x6f\x6e\x74\x61\x63\x74\x5f\x65\x6e\x63\x72\x79\x70\x74\x69\x6f\x6e\x3b\x0a\x03\x00\x1f\x66\x67\xd3\x5c\x00\x00\x00")
+
+func _1545826800_add_downgradesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545826800_add_downgradesDownSql,
+		"1545826800_add_downgrades.down.sql",
+	)
+}
+
+func _1545826800_add_downgradesDownSql() (*asset, error) {
+	bytes, err := _1545826800_add_downgradesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545826800_add_downgrades.down.sql", size: 88, mode: os.FileMode(420), modTime: time.Unix(1545826800, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545826800_add_downgradesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x8f\xc1\x6a\xc3\x30\x10\x44\xef\xfa\x8a\xb9\x25\x06\xff\x41\x4e\x8a\xb3\x01\x83\x58\xb5\x41\x86\xdc\x84\x2a\xa9\x8d\x69\x91\x42\xac\xd0\xfa\xef\x0b\xa5\x60\x99\xf6\x98\xeb\xbc\x65\xe6\x6d\x77\x22\x69\x08\x46\xee\x15\xc1\xe7\x54\x9c\x2f\x36\x26\x7f\x9b\xaf\x65\xcc\x09\x5b\x01\x38\xef\xf3\x3d\x15\x18\x3a\x1b\xb0\x36\xe0\x41\x29\x1c\xe8\x28\x07\x65\xb0\xd9\xb4\x02\xb8\xde\x5f\x3e\x46\x6f\xdf\xe3\xbc\x3e\xfb\x61\xaf\x13\xf6\x5a\x2b\x92\xbc\xca\x07\xee\x9f\x07\xda\xfe\xd6\xb7\x55\x47\x03\xcd\xe8\x34\x1f\x55\xdf\x19\x9c\xe8\x49\xc9\x8e\x44\xb3\x13\x62\xe5\x1b\xf2\x67\x7a\xbb\xb9\x10\xa7\x87\x78\x5e\xdc\x74\xf9\x9b\x86\x58\xa2\x2f\x31\x58\x57\xd0\xf3\xc2\x6a\x9d\x9e\x0f\x74\xc6\x18\xbe\xec\xa2\x64\xab\x29\xcd\x58\xc0\x7f\x0f\xb7\xf5\x4c\xb3\x13\xdf\x03\x00\x96\x05\x18\x5b\x97\x01\x00\x00")
+
+func _1545826800_add_downgradesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545826800_add_downgradesUpSql,
+		"1545826800_add_downgrades.up.sql",
+	)
+}
+
+func _1545826800_add_downgradesUpSql() (*asset, error) {
+	bytes, err := _1545826800_add_downgradesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545826800_add_downgrades.up.sql", size: 403, mode: os.FileMode(420), modTime: time.Unix(1545826800, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545913200_add_settingsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x15\x00\xea\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x73\x65\x74\x74\x69\x6e\x67\x73\x3b\x0a\x03\x00\x5e\xfc\x4d\xd4\x15\x00\x00\x00")
+
+func _1545913200_add_settingsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545913200_add_settingsDownSql,
+		"1545913200_add_settings.down.sql",
+	)
+}
+
+func _1545913200_add_settingsDownSql() (*asset, error) {
+	bytes, err := _1545913200_add_settingsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545913200_add_settings.down.sql", size: 21, mode: os.FileMode(420), modTime: time.Unix(1545913200, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545913200_add_settingsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x54\xcc\x41\x0a\xc2\x30\x10\x85\xe1\x7d\x4e\xf1\x76\x6d\xa1\x37\x70\x95\xc4\x29\x14\x86\x89\x96\x19\x70\x5b\x4a\x10\x51\xea\xa2\xa9\xe0\xed\xa5\xe0\x26\xdb\xef\xf1\xfe\x38\x91\x57\x82\xfa\xc0\x84\x2d\x97\xf2\x58\xef\x1b\x5a\x07\xcc\xcb\xf2\xde\xd7\x02\xa5\x9b\x42\x92\x42\x8c\x19\x67\x1a\xbc\xb1\xa2\x69\x7a\x07\x3c\xf3\xb7\xde\x0f\xfc\xcc\xaf\x3d\x23\x70\x0a\x15\x9b\x8c\x57\xa3\xf6\x9f\xed\x8f\x6f\x87\x24\x88\x49\x06\x1e\xa3\x62\xa2\x0b\xfb\x48\xae\x3b\xb9\xdf\x00\x45\x7d\x6a\xd3\x96\x00\x00\x00")
+
+func _1545913200_add_settingsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545913200_add_settingsUpSql,
+		"1545913200_add_settings.up.sql",
+	)
+}
+
+func _1545913200_add_settingsUpSql() (*asset, error) {
+	bytes, err := _1545913200_add_settingsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545913200_add_settings.up.sql", size: 150, mode: os.FileMode(420), modTime: time.Unix(1545913200, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545999600_add_sync_clocksDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x18\x00\xe7\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x73\x79\x6e\x63\x5f\x63\x6c\x6f\x63\x6b\x73\x3b\x0a\x03\x00\x93\x11\x2a\x18\x18\x00\x00\x00")
+
+func _1545999600_add_sync_clocksDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545999600_add_sync_clocksDownSql,
+		"1545999600_add_sync_clocks.down.sql",
+	)
+}
+
+func _1545999600_add_sync_clocksDownSql() (*asset, error) {
+	bytes, err := _1545999600_add_sync_clocksDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545999600_add_sync_clocks.down.sql", size: 24, mode: os.FileMode(420), modTime: time.Unix(1545999600, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1545999600_add_sync_clocksUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x54\x8d\xb1\x0a\xc2\x40\x10\x44\xfb\xfb\x8a\xe9\x92\x40\xfe\xc0\x6a\x3d\x37\x1a\x58\x36\x7a\xec\x89\x9d\xc8\xa5\x09\x91\x4b\x11\x2d\xfc\x7b\x49\x10\x21\xed\xcc\x9b\x37\x3e\x30\x19\xc3\x68\x2f\x8c\xf9\x93\xd3\x3d\x3d\xa7\x34\xce\x28\x1d\xf0\x48\x69\x7a\xe7\x17\x8c\x6f\x06\xed\x0c\x1a\x45\x70\xe0\x86\xa2\x18\x8a\xa2\x76\xc0\x38\xe4\x1e\x57\x0a\xfe\x44\xe1\xcf\x2c\xc5\xd0\x6f\x77\x4b\xb6\xba\xd1\xaa\xf1\x91\xb7\x74\xd4\xf6\x12\xb9\xfc\x3d\xd6\xab\xb6\xc6\xd0\x57\xe8\x14\xbe\xd3\x46\x5a\x6f\x08\x7c\x16\xf2\xec\xaa\x9d\xfb\x0e\x00\x48\x36\x98\x92\xb9\x00\x00\x00")
+
+func _1545999600_add_sync_clocksUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1545999600_add_sync_clocksUpSql,
+		"1545999600_add_sync_clocks.up.sql",
+	)
+}
+
+func _1545999600_add_sync_clocksUpSql() (*asset, error) {
+	bytes, err := _1545999600_add_sync_clocksUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1545999600_add_sync_clocks.up.sql", size: 182, mode: os.FileMode(420), modTime: time.Unix(1545999600, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546086000_add_unsigned_transactionsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x22\x00\xdd\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x75\x6e\x73\x69\x67\x6e\x65\x64\x5f\x74\x72\x61\x6e\x73\x61\x63\x74\x69\x6f\x6e\x73\x3b\x0a\x03\x00\x83\xb3\x79\x08\x22\x00\x00\x00")
+
+func _1546086000_add_unsigned_transactionsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546086000_add_unsigned_transactionsDownSql,
+		"1546086000_add_unsigned_transactions.down.sql",
+	)
+}
+
+func _1546086000_add_unsigned_transactionsDownSql() (*asset, error) {
+	bytes, err := _1546086000_add_unsigned_transactionsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546086000_add_unsigned_transactions.down.sql", size: 34, mode: os.FileMode(420), modTime: time.Unix(1546086000, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546086000_add_unsigned_transactionsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\x8d\x4d\xaa\x83\x30\x14\x85\xe7\x59\xc5\x99\xa9\xe0\x0e\xde\x28\xe6\x5d\x8b\x70\x49\x5a\xb9\x81\xce\x4a\x50\xa9\x4e\x62\x31\x11\xba\xfc\xd2\x52\x0a\xa5\xd3\xf3\xf3\x7d\xa6\x27\x2d\x04\xd1\x0d\x13\xf6\x98\x96\x6b\x9c\xc6\x4b\xde\x42\x4c\x61\xc8\xcb\x1a\x13\x4a\x05\x84\x61\x58\xf7\x98\x21\x74\x16\x58\x27\xb0\x9e\x19\xff\xd4\x6a\xcf\x82\xa2\xa8\x15\x30\x87\x34\xa3\x61\xd7\x7c\x06\xcf\x74\xba\xdf\x96\x6d\x4a\xe8\xac\xd0\x81\xfa\xaf\x6e\x0c\x39\xfc\x3e\xbc\xed\x4e\x9e\xca\xb7\xb2\x7e\x71\x2b\x38\x0b\xe3\x6c\xcb\x9d\x11\xf4\x74\x64\x6d\x48\x55\x7f\xea\x31\x00\xa3\xf6\x10\x21\xc0\x00\x00\x00")
+
+func _1546086000_add_unsigned_transactionsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546086000_add_unsigned_transactionsUpSql,
+		"1546086000_add_unsigned_transactions.up.sql",
+	)
+}
+
+func _1546086000_add_unsigned_transactionsUpSql() (*asset, error) {
+	bytes, err := _1546086000_add_unsigned_transactionsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546086000_add_unsigned_transactions.up.sql", size: 192, mode: os.FileMode(420), modTime: time.Unix(1546086000, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546172400_add_outbox_entriesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x1b\x00\xe4\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x6f\x75\x74\x62\x6f\x78\x5f\x65\x6e\x74\x72\x69\x65\x73\x3b\x0a\x03\x00\x09\x09\x08\x00\x1b\x00\x00\x00")
+
+func _1546172400_add_outbox_entriesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546172400_add_outbox_entriesDownSql,
+		"1546172400_add_outbox_entries.down.sql",
+	)
+}
+
+func _1546172400_add_outbox_entriesDownSql() (*asset, error) {
+	bytes, err := _1546172400_add_outbox_entriesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546172400_add_outbox_entries.down.sql", size: 27, mode: os.FileMode(420), modTime: time.Unix(1546172400, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546172400_add_outbox_entriesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\xce\xb1\x0a\x83\x30\x14\x46\xe1\x3d\x4f\xf1\x6f\x2a\xf8\x06\x9d\x62\x7a\x2d\x42\x48\x5a\xb9\x81\x6e\x92\x9a\x40\xb3\x18\xd0\x08\xed\xdb\x97\x42\x17\xa1\xeb\xf9\x96\xa3\x46\x92\x4c\x60\xd9\x69\x42\xde\xcb\x23\xbf\xa6\xb8\x94\x35\xc5\x0d\xb5\x00\xfc\x3c\xe7\x7d\x29\x60\xba\x33\x8c\x65\x18\xa7\x35\xce\xd4\x4b\xa7\x19\x55\xd5\x0a\x20\x85\x23\x7f\xdb\x33\x6d\x25\xaf\xef\xe9\x9f\xcd\x6b\xf4\x25\x86\xc9\x17\x0c\x86\xe9\x42\xe3\x81\x83\x2f\x1e\x9d\xb6\xdd\xa1\x3a\x33\xdc\x1c\xd5\xbf\x9f\x16\x29\x34\xb0\x06\xca\x9a\x5e\x0f\x8a\x31\xd2\x55\x4b\x45\xa2\x39\x89\xcf\x00\xcb\x8a\x2c\x51\xd4\x00\x00\x00")
+
+func _1546172400_add_outbox_entriesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546172400_add_outbox_entriesUpSql,
+		"1546172400_add_outbox_entries.up.sql",
+	)
+}
+
+func _1546172400_add_outbox_entriesUpSql() (*asset, error) {
+	bytes, err := _1546172400_add_outbox_entriesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546172400_add_outbox_entries.up.sql", size: 212, mode: os.FileMode(420), modTime: time.Unix(1546172400, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546258800_add_wallet_transfersDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x68\x00\x97\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x77\x61\x6c\x6c\x65\x74\x5f\x74\x72\x61\x6e\x73\x66\x65\x72\x73\x5f\x62\x6c\x6f\x63\x6b\x73\x3b\x0a\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x77\x61\x6c\x6c\x65\x74\x5f\x74\x72\x61\x6e\x73\x66\x65\x72\x73\x5f\x62\x6c\x6f\x63\x6b\x5f\x69\x64\x78\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x77\x61\x6c\x6c\x65\x74\x5f\x74\x72\x61\x6e\x73\x66\x65\x72\x73\x3b\x0a\x03\x00\x26\x2a\x34\xf2\x68\x00\x00\x00")
+
+func _1546258800_add_wallet_transfersDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546258800_add_wallet_transfersDownSql,
+		"1546258800_add_wallet_transfers.down.sql",
+	)
+}
+
+func _1546258800_add_wallet_transfersDownSql() (*asset, error) {
+	bytes, err := _1546258800_add_wallet_transfersDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546258800_add_wallet_transfers.down.sql", size: 104, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546258800_add_wallet_transfersUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x90\x4d\x6a\xc3\x30\x10\x85\xf7\x3e\xc5\xdb\x25\x01\xdf\xa0\x2b\xdb\x99\x14\x81\x90\x5a\x33\x82\xec\x8c\x62\xa9\x60\xea\x3a\x20\x29\xb4\xc7\x2f\x4a\xd2\x9f\x34\xf6\xae\x5b\xe9\xcd\x7c\x6f\xbe\xa6\xa5\x8a\x09\x5c\xd5\x92\xf0\x6e\xc7\xd1\xa7\x2e\x05\x3b\xc5\x17\x1f\x22\xd6\x05\x60\xfb\xfe\x78\x9a\x12\x98\xf6\x0c\xa5\x19\xca\x48\x89\x2d\xed\x2a\x23\x19\xab\x55\x99\x33\xce\x05\x1f\x23\x6a\xa9\xeb\xef\x4c\xfe\x18\xdc\xed\x5c\x7e\x3b\x8c\xc7\xfe\xb5\x9b\x4e\x6f\x07\x1f\x20\x14\xd3\x23\xb5\x37\x01\x67\x93\xbd\x5f\x65\x94\x78\x36\xb4\xbe\xd6\x29\xbf\x98\x25\x06\xb7\x81\x56\x68\xb4\xda\x49\xd1\x30\x5a\x7a\x92\x55\x43\xc5\xe6\xa1\x28\xae\xe7\x09\xb5\xa5\xfd\xdd\x79\xdd\xa5\xc9\xe0\x3e\xf2\xfc\xdf\xdf\x19\xd2\xef\xe6\x67\xee\x0f\x61\x5e\xe0\x85\xf0\x0f\x1e\xcf\x7b\x66\x65\x2d\x68\x59\x54\xf2\x39\x00\xed\x16\x52\x11\xf1\x01\x00\x00")
+
+func _1546258800_add_wallet_transfersUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546258800_add_wallet_transfersUpSql,
+		"1546258800_add_wallet_transfers.up.sql",
+	)
+}
+
+func _1546258800_add_wallet_transfersUpSql() (*asset, error) {
+	bytes, err := _1546258800_add_wallet_transfersUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546258800_add_wallet_transfers.up.sql", size: 497, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546345200_add_wallet_custom_tokensDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x21\x00\xde\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x77\x61\x6c\x6c\x65\x74\x5f\x63\x75\x73\x74\x6f\x6d\x5f\x74\x6f\x6b\x65\x6e\x73\x3b\x0a\x03\x00\x4f\x69\x5f\x34\x21\x00\x00\x00")
+
+func _1546345200_add_wallet_custom_tokensDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546345200_add_wallet_custom_tokensDownSql,
+		"1546345200_add_wallet_custom_tokens.down.sql",
+	)
+}
+
+func _1546345200_add_wallet_custom_tokensDownSql() (*asset, error) {
+	bytes, err := _1546345200_add_wallet_custom_tokensDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546345200_add_wallet_custom_tokens.down.sql", size: 33, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546345200_add_wallet_custom_tokensUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\x8d\xc1\x6a\x84\x30\x14\x45\xf7\xf9\x8a\xbb\x53\xc1\x3f\xe8\x2a\xa6\xcf\x22\x84\xa4\x95\x17\xe8\x4e\x82\xc9\xa2\x68\x0d\x98\x88\xbf\x3f\x0c\xcc\x0c\x33\xcc\xf6\x9e\xcb\x39\x6a\x24\xc9\x04\x96\x9d\x26\x9c\x7e\x5d\x63\x99\xe6\x23\x97\xf4\x3f\x95\xb4\xc4\x2d\xa3\x16\x80\x9f\xe7\x74\x6c\x05\x4c\xbf\x0c\x63\x19\xc6\x69\x8d\x4f\xea\xa5\xd3\x8c\xaa\x6a\x05\xb0\xc5\x72\xa6\x7d\x99\xfe\x02\x06\xc3\xf4\x45\xe3\xe3\x79\xc5\x3e\x84\x3d\xe6\x8c\x4e\xdb\xee\x05\x04\x5f\xfc\xfb\xea\xcc\xf0\xe3\xa8\xbe\x85\xdb\x27\x7b\x7b\x57\x35\xb0\x06\xca\x9a\x5e\x0f\x8a\x31\xd2\xb7\x96\x8a\x44\xf3\x21\x2e\x03\x00\xca\x2c\x4c\x23\xd4\x00\x00\x00")
+
+func _1546345200_add_wallet_custom_tokensUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546345200_add_wallet_custom_tokensUpSql,
+		"1546345200_add_wallet_custom_tokens.up.sql",
+	)
+}
+
+func _1546345200_add_wallet_custom_tokensUpSql() (*asset, error) {
+	bytes, err := _1546345200_add_wallet_custom_tokensUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546345200_add_wallet_custom_tokens.up.sql", size: 212, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546431600_add_decoy_topicsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x19\x00\xe6\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x64\x65\x63\x6f\x79\x5f\x74\x6f\x70\x69\x63\x73\x3b\x0a\x03\x00\x17\x31\xe9\xd0\x19\x00\x00\x00")
+
+func _1546431600_add_decoy_topicsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546431600_add_decoy_topicsDownSql,
+		"1546431600_add_decoy_topics.down.sql",
+	)
+}
+
+func _1546431600_add_decoy_topicsDownSql() (*asset, error) {
+	bytes, err := _1546431600_add_decoy_topicsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546431600_add_decoy_topics.down.sql", size: 25, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546431600_add_decoy_topicsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x54\xcc\xb1\xca\xc2\x30\x14\xc5\xf1\x3d\x4f\x71\xb6\xb6\xd0\x37\xf8\xa6\x34\xdf\xad\x04\x2e\x37\x1a\x6e\xc0\x4d\x24\x3a\x64\x69\x0a\x8d\x83\x6f\x2f\x8a\x14\x5c\xcf\xf9\xf3\x73\x91\xac\x12\xd4\x4e\x4c\xb8\xdd\x73\x7d\x5e\x5a\x5d\x4b\xde\xd0\x1b\xe0\x9a\x73\x7d\x2c\x0d\x4a\x67\x85\x04\x85\x24\x66\xfc\xd3\x6c\x13\x2b\xba\x6e\x34\xc0\x5a\xb7\xd2\x4a\x5d\xe0\x45\xe9\x40\x71\xef\xde\xe7\xc7\xc2\xc4\x61\xfa\x99\x93\xf8\x53\xa2\xfe\xcb\x8f\xbb\x31\x20\x08\x5c\x90\x99\xbd\x53\x44\x3a\xb2\x75\x64\x86\x3f\xf3\x1a\x00\x20\xe3\x1c\xb0\xa7\x00\x00\x00")
+
+func _1546431600_add_decoy_topicsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546431600_add_decoy_topicsUpSql,
+		"1546431600_add_decoy_topics.up.sql",
+	)
+}
+
+func _1546431600_add_decoy_topicsUpSql() (*asset, error) {
+	bytes, err := _1546431600_add_decoy_topicsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546431600_add_decoy_topics.up.sql", size: 167, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546518000_add_wallet_transfers_chain_idDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x91\x4d\x6a\xc3\x30\x14\x84\xf7\x3a\xc5\xec\x92\x80\x6f\xe0\x95\x7f\x5e\x8a\x41\x48\xa9\x79\x86\xec\x8c\x62\xb9\x60\xea\x3a\x60\x29\xb4\xc7\x2f\x72\xd3\x9f\xb4\x51\xb3\xc8\xd6\x33\x6f\x66\xfc\xa9\xac\xf5\x0e\x9c\xe5\x92\xf0\x6a\xc6\xb1\xf7\xad\x9f\xcd\xe4\x9e\xfa\xd9\xb5\x87\xf1\xd8\x3d\xbb\x54\x2c\x9e\x4a\x95\xb4\x8f\x78\xda\xc1\xbe\xa5\xe2\x9f\xa8\x54\x88\xa2\xa6\x8c\x29\xa2\x63\x2d\x00\xd3\x75\xc7\xd3\xe4\xc1\xb4\x67\x28\xcd\x50\x8d\x94\x28\x69\x9b\x35\x92\xb1\x5a\x25\xc1\x63\xed\xdc\x3b\x87\x5c\xea\xfc\xcb\x13\x84\xc1\x5e\xde\x85\x6f\x1f\xdb\xa6\xd3\xcb\xa1\x9f\x51\x29\xa6\x07\xaa\x2f\x0c\xd6\x78\xf3\x37\xaa\x51\xd5\x63\x43\xeb\xf3\x9c\xe4\xb3\x33\xc1\x60\x37\xd0\x0a\x85\x56\x5b\x59\x15\x8c\x9a\x76\x32\x2b\x48\x6c\xbe\x7f\xef\x16\xa5\x70\xff\x5b\xbd\xd2\xf4\x73\xf9\xd2\x7b\x0b\xe0\xf9\xad\xee\xe7\xb8\xe4\x5c\x85\x15\xc1\x12\x45\xf2\x3e\x00\x6f\x04\xb5\x86\x5a\x02\x00\x00")
+
+func _1546518000_add_wallet_transfers_chain_idDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546518000_add_wallet_transfers_chain_idDownSql,
+		"1546518000_add_wallet_transfers_chain_id.down.sql",
+	)
+}
+
+func _1546518000_add_wallet_transfers_chain_idDownSql() (*asset, error) {
+	bytes, err := _1546518000_add_wallet_transfers_chain_idDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546518000_add_wallet_transfers_chain_id.down.sql", size: 602, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546518000_add_wallet_transfers_chain_idUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x51\xcf\x6e\x82\x30\x1c\xbe\xf3\x14\xdf\x4d\x4d\x60\x2f\xe0\x09\xa1\x2e\x24\x4d\x71\xa4\x24\xde\x48\xa5\x75\x36\xb2\x36\x29\x35\xe8\xdb\x2f\x88\x3a\xdd\xfc\x73\xda\xf1\xc7\xf7\x97\xaf\x51\x04\xee\x84\x69\xd7\xca\xb5\xe8\x94\x53\xd0\x46\xaa\xbd\x92\x58\x5b\x07\xbf\x51\x30\xca\x77\xd6\x6d\x61\xd7\xc3\x69\xa5\x82\x35\xcd\x21\xec\xcf\x03\xc4\x95\x44\x7c\x0a\x6d\xd0\x69\xbf\x09\xa2\xa8\x87\xb5\x43\xbd\xe9\xbf\x65\xe9\x5b\x90\x16\xf9\x02\x3c\x9e\x51\x82\x4e\x34\x8d\xf2\x95\x3f\x27\x57\xab\xc6\xd6\xdb\x76\x3a\x70\x32\x96\x92\xe5\x03\x4e\xa5\xe5\x7e\xfa\xcc\x6a\x1a\x04\x49\x41\x62\x4e\x1e\xe0\x18\x07\x80\xa8\x6b\xbb\x33\x1e\x9c\x2c\x39\x58\xce\xc1\x4a\x4a\x91\x92\x79\x5c\x52\x8e\xd1\x28\x0c\x30\x14\xaf\xb4\x44\xc6\x38\x79\x27\xc5\x85\xd7\x83\x42\x4a\xa7\xda\x16\x33\x9a\xcf\x6e\x00\x2d\x6f\x4d\x7b\xf2\x50\xdc\xec\xbe\x56\xca\xdd\x75\x93\xc2\x8b\xbf\x56\x25\xcb\x3e\x4a\x32\x3e\x75\x0d\x2f\x85\xc2\x73\x7a\x08\x2d\x27\xc8\x19\x92\x9c\xcd\x69\x96\x70\x14\x64\x41\xe3\x84\x04\x93\x9f\x15\x5e\x8d\xd9\xeb\x7f\xa3\x4f\x33\xaf\xff\xe6\xd8\xe0\xd5\xe2\xa7\xc7\xfd\xe7\xe1\x8f\x21\x77\x25\x2f\x77\x7c\xb8\xe1\xf7\x00\x9f\x65\x57\xae\x1d\x03\x00\x00")
+
+func _1546518000_add_wallet_transfers_chain_idUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546518000_add_wallet_transfers_chain_idUpSql,
+		"1546518000_add_wallet_transfers_chain_id.up.sql",
+	)
+}
+
+func _1546518000_add_wallet_transfers_chain_idUpSql() (*asset, error) {
+	bytes, err := _1546518000_add_wallet_transfers_chain_idUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546518000_add_wallet_transfers_chain_id.up.sql", size: 690, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546604400_add_wallet_balance_snapshotsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x58\x00\xa7\xff\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x77\x61\x6c\x6c\x65\x74\x5f\x62\x61\x6c\x61\x6e\x63\x65\x5f\x73\x6e\x61\x70\x73\x68\x6f\x74\x73\x5f\x74\x69\x6d\x65\x73\x74\x61\x6d\x70\x5f\x69\x64\x78\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x77\x61\x6c\x6c\x65\x74\x5f\x62\x61\x6c\x61\x6e\x63\x65\x5f\x73\x6e\x61\x70\x73\x68\x6f\x74\x73\x3b\x0a\x03\x00\x86\x15\xc1\xee\x58\x00\x00\x00")
+
+func _1546604400_add_wallet_balance_snapshotsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546604400_add_wallet_balance_snapshotsDownSql,
+		"1546604400_add_wallet_balance_snapshots.down.sql",
+	)
+}
+
+func _1546604400_add_wallet_balance_snapshotsDownSql() (*asset, error) {
+	bytes, err := _1546604400_add_wallet_balance_snapshotsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546604400_add_wallet_balance_snapshots.down.sql", size: 88, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546604400_add_wallet_balance_snapshotsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x74\x90\xc1\x6a\xc3\x30\x10\x44\xef\xfa\x8a\xb9\x25\x01\xfd\x41\x4e\xb6\xb3\x29\x06\x21\xb7\x66\x0d\xb9\x89\xad\x25\x88\xa9\x23\x87\x4a\xa5\xfd\xfc\x12\x9a\x3a\x94\xda\x57\xed\xe8\xcd\x63\xaa\x96\x0a\x26\x70\x51\x1a\xc2\xa7\x8c\x63\xc8\xee\x55\x46\x89\x7d\x70\x29\xca\x35\x9d\xa7\x9c\xb0\x55\x80\xf4\xfd\xf4\x11\x33\x98\x4e\x0c\xdb\x30\x6c\x67\x0c\x0e\x74\x2c\x3a\xc3\xd8\x6c\xb4\x02\xfa\xb3\x0c\xd1\x0d\x1e\xb5\x65\x7a\xa2\x76\xce\xdd\x8e\xe2\xfd\x7b\x48\x09\xa5\x69\xca\x3f\x87\x3c\xbd\x85\xb8\xf0\x3c\x5c\x42\xca\x72\xb9\x2e\xd2\xbc\x64\xf9\xff\xa7\xb3\xf5\x4b\x47\xdb\xbb\xab\x9e\x85\xf4\x6f\xbb\xfe\x69\xd3\x0f\xfa\x0e\x8d\x45\xd5\xd8\xa3\xa9\x2b\x46\x4b\xcf\xa6\xa8\x48\xed\xf6\x4a\xdd\xb7\xa9\xed\x81\x4e\xab\xdb\xb8\x19\xe4\x06\xff\x75\x63\xad\x25\x97\xac\x1e\x16\x7b\xf5\x3d\x00\x1c\x84\xa9\x7a\x8b\x01\x00\x00")
+
+func _1546604400_add_wallet_balance_snapshotsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546604400_add_wallet_balance_snapshotsUpSql,
+		"1546604400_add_wallet_balance_snapshots.up.sql",
+	)
+}
+
+func _1546604400_add_wallet_balance_snapshotsUpSql() (*asset, error) {
+	bytes, err := _1546604400_add_wallet_balance_snapshotsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546604400_add_wallet_balance_snapshots.up.sql", size: 395, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546690800_add_ens_cacheDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x40\x00\xbf\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x65\x6e\x73\x5f\x72\x65\x76\x65\x72\x73\x65\x5f\x72\x65\x73\x6f\x6c\x75\x74\x69\x6f\x6e\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x65\x6e\x73\x5f\x72\x65\x73\x6f\x6c\x75\x74\x69\x6f\x6e\x73\x3b\x0a\x03\x00\xa6\xd1\xa4\x6d\x40\x00\x00\x00")
+
+func _1546690800_add_ens_cacheDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546690800_add_ens_cacheDownSql,
+		"1546690800_add_ens_cache.down.sql",
+	)
+}
+
+func _1546690800_add_ens_cacheDownSql() (*asset, error) {
+	bytes, err := _1546690800_add_ens_cacheDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546690800_add_ens_cache.down.sql", size: 64, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546690800_add_ens_cacheUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x8f\xb1\x4a\x04\x31\x10\x86\xfb\x3c\xc5\xdf\xdd\x1d\xec\x1b\x58\x65\xe3\x9c\x2c\x84\x44\x97\x09\xd8\x1d\xe1\x32\xc5\xa1\x97\x40\x92\xd5\xd7\x17\x65\x11\x17\xb5\xb0\xb0\xfd\x67\xe6\x9b\xff\x33\x33\x69\x26\xb0\x1e\x2d\x41\x72\x3b\x55\x69\xe5\x79\xe9\x97\x92\x1b\xf6\x0a\x88\xe7\x73\x59\x72\x07\xd3\x23\xc3\x79\x86\x0b\xd6\xe2\x96\x8e\x3a\x58\xc6\x6e\x37\x28\x20\x4b\x7f\x2d\xf5\xe9\x74\x49\x98\x1c\xd3\x1d\xcd\x9f\x9b\x1f\xe3\x78\x95\xed\xfd\x7b\x9a\x62\x8f\x18\xad\x1f\x37\x69\x70\xd3\x43\xa0\xfd\xfa\x75\xf8\x82\x1e\x90\xe3\x55\x0e\xf0\x0e\xc6\xbb\xa3\x9d\x0c\x63\xa6\x7b\xab\x0d\xa9\xc3\x8d\x52\x3f\x98\xbc\x48\x6d\xf2\x1f\x46\x31\xa5\x2a\xad\x7d\xaf\xff\x77\xa9\x15\xf5\xab\xd7\xdb\x00\x43\x14\x3c\x67\xa1\x01\x00\x00")
+
+func _1546690800_add_ens_cacheUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546690800_add_ens_cacheUpSql,
+		"1546690800_add_ens_cache.up.sql",
+	)
+}
+
+func _1546690800_add_ens_cacheUpSql() (*asset, error) {
+	bytes, err := _1546690800_add_ens_cacheUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546690800_add_ens_cache.up.sql", size: 417, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546777200_add_sticker_packsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x57\x00\xa8\xff\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x73\x74\x69\x63\x6b\x65\x72\x5f\x63\x6f\x6e\x74\x65\x6e\x74\x5f\x68\x61\x73\x68\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x73\x74\x69\x63\x6b\x65\x72\x5f\x63\x6f\x6e\x74\x65\x6e\x74\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x73\x74\x69\x63\x6b\x65\x72\x5f\x70\x61\x63\x6b\x73\x3b\x0a\x03\x00\x35\x88\x4c\x54\x57\x00\x00\x00")
+
+func _1546777200_add_sticker_packsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546777200_add_sticker_packsDownSql,
+		"1546777200_add_sticker_packs.down.sql",
+	)
+}
+
+func _1546777200_add_sticker_packsDownSql() (*asset, error) {
+	bytes, err := _1546777200_add_sticker_packsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546777200_add_sticker_packs.down.sql", size: 87, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546777200_add_sticker_packsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x90\x41\x6b\x83\x40\x14\x84\xef\xfb\x2b\xe6\x96\x08\xfe\x83\x9c\xd4\xbc\x14\x61\x59\x5b\x79\x42\x6e\xf2\x58\x05\xc5\xb0\x96\xec\x2b\xfd\xfb\xc5\x12\x4a\x9b\x6a\x7b\xc9\xf5\xcd\xcc\xce\x7e\x53\xd4\x94\x31\x81\xb3\xdc\x12\xa2\x8e\x7e\xea\xaf\xed\xab\xf8\x29\x62\x6f\x00\xf1\x7e\x7e\x0b\x0a\xa6\x33\xc3\x55\x0c\xd7\x58\x8b\x23\x9d\xb2\xc6\x32\x76\xbb\xd4\x00\xa1\xd7\xf7\xf9\x3a\xb5\x63\x87\xd2\x31\x3d\x51\xfd\xe5\x5c\xe4\x8d\x73\x27\x2a\xc8\x6d\x95\xff\x34\x87\xa8\x72\xb9\xf4\x5d\x2b\xba\x1a\x6b\x5c\xf9\xd2\xd0\xfe\xf6\xaf\xf4\x5b\x79\x8a\xb1\x4b\x50\x39\x14\x95\x3b\xd9\xb2\x60\xd4\xf4\x6c\xb3\x82\x4c\x72\x30\x66\x95\xd3\xcf\x41\xfb\xa0\x8f\x22\x5d\x66\xdb\xd2\x06\x89\xc3\x6f\xdc\xf5\x11\xfe\x62\xbc\x75\xa4\x9f\x0f\xfe\x8b\x5b\xba\x23\x9d\xef\x71\xdb\x25\xba\x24\xef\xee\xeb\x85\x83\xc4\x21\x39\x98\x8f\x01\x00\x35\xa6\x13\xc1\x28\x02\x00\x00")
+
+func _1546777200_add_sticker_packsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546777200_add_sticker_packsUpSql,
+		"1546777200_add_sticker_packs.up.sql",
+	)
+}
+
+func _1546777200_add_sticker_packsUpSql() (*asset, error) {
+	bytes, err := _1546777200_add_sticker_packsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546777200_add_sticker_packs.up.sql", size: 552, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546863600_add_push_notificationsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x3d\x00\xc2\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x70\x75\x73\x68\x5f\x63\x6c\x69\x65\x6e\x74\x5f\x73\x74\x61\x74\x65\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x70\x75\x73\x68\x5f\x72\x65\x67\x69\x73\x74\x72\x61\x74\x69\x6f\x6e\x73\x3b\x0a\x03\x00\x91\xe2\x71\x67\x3d\x00\x00\x00")
+
+func _1546863600_add_push_notificationsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546863600_add_push_notificationsDownSql,
+		"1546863600_add_push_notifications.down.sql",
+	)
+}
+
+func _1546863600_add_push_notificationsDownSql() (*asset, error) {
+	bytes, err := _1546863600_add_push_notificationsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546863600_add_push_notifications.down.sql", size: 61, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546863600_add_push_notificationsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x8f\xc1\x6a\x03\x21\x14\x45\xf7\x7e\xc5\xdd\x25\x81\xf9\x83\xae\x1c\xfb\x02\x01\xd1\x36\x28\x74\x27\xd6\x48\x47\x2a\x26\xc4\x37\x8b\xfe\x7d\x49\x29\x85\x66\x08\xcc\xfe\xbc\xf3\xce\x55\x47\x92\x8e\xe0\xe4\xa8\x09\x97\xb9\x4f\xe1\x9a\x3f\x4a\xe7\x6b\xe4\x72\x6e\x1d\x5b\x01\xc4\x94\xce\x73\x63\x38\x7a\x73\x30\xd6\xc1\x78\xad\xf1\x4c\x7b\xe9\xb5\xc3\x66\x33\x08\xe0\x32\xbf\xd7\x92\xc2\x67\xfe\x0a\x53\xec\x13\x46\x6d\xc7\x3f\xf6\x06\x94\xd6\x39\xd6\xfa\xa3\x0d\xe5\xf4\x5f\x76\x03\x4e\x91\xe3\xf2\xcc\x9b\xc3\xab\xa7\xed\x6f\xc2\x70\xff\x67\xb8\xf7\xee\x60\x0d\x94\x35\x7b\x7d\x50\x0e\x47\x7a\xd1\x52\x91\xd8\x3d\x09\xb1\x5c\x9a\x6a\xc9\x8d\x43\xe7\xc8\x79\xf5\xd0\x35\x99\x0f\x23\xbe\x07\x00\x1b\xe9\x6d\x4a\x6e\x01\x00\x00")
+
+func _1546863600_add_push_notificationsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546863600_add_push_notificationsUpSql,
+		"1546863600_add_push_notifications.up.sql",
+	)
+}
+
+func _1546863600_add_push_notificationsUpSql() (*asset, error) {
+	bytes, err := _1546863600_add_push_notificationsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546863600_add_push_notifications.up.sql", size: 366, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546950000_add_wipesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x12\x00\xed\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x77\x69\x70\x65\x73\x3b\x0a\x03\x00\xcf\x4b\x96\xd7\x12\x00\x00\x00")
+
+func _1546950000_add_wipesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546950000_add_wipesDownSql,
+		"1546950000_add_wipes.down.sql",
+	)
+}
+
+func _1546950000_add_wipesDownSql() (*asset, error) {
+	bytes, err := _1546950000_add_wipesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546950000_add_wipes.down.sql", size: 18, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1546950000_add_wipesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x54\xcd\xb1\x0a\xc2\x30\x10\x87\xf1\x3d\x4f\xf1\xdf\xda\x42\xdf\xc0\x29\x8d\x57\x29\x1c\x17\x2d\x17\x70\x93\xd0\x74\xc8\xa2\x42\x52\x7c\x7d\x41\x5c\xb2\x7e\xbf\xe1\x73\x2b\x59\x25\xa8\x9d\x98\xf0\xc9\xef\xbd\xa0\x37\x40\xdc\xb6\xd7\xf1\xac\x50\xba\x2b\xc4\x2b\x24\x30\xe3\x4c\xb3\x0d\xac\xe8\xba\xd1\x00\x39\xb5\xfc\x6b\xa5\x1c\x7b\x7a\xc4\x8a\x45\x94\x2e\xb4\x36\x9a\x62\x8d\x98\xd8\x4f\x4d\x0d\xb2\xdc\x02\xf5\xff\xe5\x88\x9c\x06\x78\x81\xf3\x32\xf3\xe2\x14\x2b\x5d\xd9\x3a\x32\xc3\xc9\x7c\x07\x00\xe3\xc1\xa3\xbf\xae\x00\x00\x00")
+
+func _1546950000_add_wipesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1546950000_add_wipesUpSql,
+		"1546950000_add_wipes.up.sql",
+	)
+}
+
+func _1546950000_add_wipesUpSql() (*asset, error) {
+	bytes, err := _1546950000_add_wipesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1546950000_add_wipes.up.sql", size: 174, mode: os.FileMode(420), modTime: time.Unix(1792171376, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1547036400_namespace_bundles_installationsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x94\x4f\x6f\x9b\x40\x10\xc5\xef\xfb\x29\xe6\x66\x23\x71\x68\xd3\xde\xac\x1e\x30\x1e\xbb\xab\x92\x5d\x77\x8d\xd5\xe6\x84\x36\x66\x9b\xac\x8a\x31\x82\x0d\xaa\xbf\x7d\xc5\x5f\xb3\xe0\x54\x49\x9a\x2b\x33\xf3\x78\x33\xbf\x07\x2b\xc1\xb7\x10\x7a\xcb\x00\x21\x51\x0f\xf2\x70\x8e\xe4\xe1\x70\x7a\x4a\xcd\x82\x10\x5f\xa0\x17\x62\x5b\xd5\x69\x61\x64\x92\x48\xa3\x4f\x69\x01\x73\x02\xa0\x63\x95\x1a\x6d\xce\xb0\x0c\xf8\x12\x18\x0f\x81\xed\x83\xc0\x25\x60\xf5\x46\x3a\x86\x10\x7f\x86\x56\x83\xd1\x47\x55\x18\x79\xcc\x60\xcf\x76\x74\xc3\x70\x05\x4b\xba\x01\xca\xec\x36\x95\xca\xfb\x44\xc5\xb0\xe4\x3c\x40\x8f\xc1\x0a\xd7\xde\x3e\x08\xe1\x63\x55\x8c\x75\x51\x57\x23\x69\x9e\x57\xe9\x47\x3e\x54\x23\x7b\x46\xbf\xef\x71\xde\x19\x77\xc7\x46\x1d\xe0\x0c\x7c\xce\xd6\x01\xf5\x43\x10\xb8\x0d\x3c\x1f\x89\xb3\x20\x84\xb2\x1d\x8a\xb0\x32\xc8\xad\xa1\xe2\x79\x31\xf7\xb2\xa5\xdb\x6d\xe2\x0e\x5d\x3b\x64\x87\x01\xfa\x21\xbc\x5d\x02\xd6\x82\xdf\x5a\x53\x45\x54\xde\xc0\x8f\xaf\x28\x10\x5a\x90\xf0\x05\x66\xb3\x05\x21\x03\xd2\xe3\x81\x31\xeb\xfb\xa7\x34\x4e\xd4\x7f\x53\xce\x72\x5d\x4a\xa3\xa2\xdf\xaa\xc9\x48\x35\x54\xe8\x87\x54\xc5\x51\x96\x5f\x1e\xf7\x33\xb0\x15\xf4\xd6\x13\x77\xf0\x0d\xef\x2c\x12\x74\xc3\xb8\xc0\xd7\x04\xe7\x4f\xa6\xf3\x2b\xc1\xa9\x53\x50\xaa\xbc\xd0\xa7\xb4\x1a\xc2\x0d\x8a\x2b\x59\x99\x20\x6f\x0f\xf2\x2f\xd8\x83\x65\xdd\xd1\x96\x36\xc6\xc6\x9a\xdb\xd9\x78\x49\x0a\xde\xaa\xdd\xc4\xa3\x35\x7f\x85\x73\x2e\xcd\xe1\x51\x99\x48\xa7\xbf\x4e\x51\xf9\xa9\xe6\xdd\x74\x57\x3c\x27\xc0\x55\xf6\xa8\x8e\x2a\x97\x89\x45\xb4\xf3\x3d\xed\x2f\xce\xc7\xa3\x32\xb9\x3e\x4c\x51\xbf\x28\x3f\x5d\x80\xad\x42\x4f\x69\x36\x1b\x7c\xd2\x6d\xab\xdb\x2e\x5b\x5f\xed\x75\x9f\x79\x25\xb6\xe6\x02\xe9\x86\xd5\xf9\x9b\xf7\x4a\x0e\x08\x5c\xa3\x40\xe6\xe3\xae\x3b\xe6\xdc\xa6\xe0\x4c\x12\x33\x3a\xed\x45\xcd\xb5\xcf\x38\xb4\x69\xdd\xeb\x4a\x0c\xda\x25\xfb\xc4\xbc\xa3\x66\x93\x14\xdb\xf4\x67\xd2\xfc\x47\xfa\xd7\x00\x65\x30\x6f\xdf\x6d\xef\x6f\x05\xcd\xb1\x7f\x36\x23\xd1\xc5\xb0\xd6\x4e\x44\xe5\xcd\x82\xfc\x1d\x00\xe6\xfa\x1b\xaa\x8a\x06\x00\x00")
+
+func _1547036400_namespace_bundles_installationsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1547036400_namespace_bundles_installationsDownSql,
+		"1547036400_namespace_bundles_installations.down.sql",
+	)
+}
+
+func _1547036400_namespace_bundles_installationsDownSql() (*asset, error) {
+	bytes, err := _1547036400_namespace_bundles_installationsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1547036400_namespace_bundles_installations.down.sql", size: 1674, mode: os.FileMode(420), modTime: time.Unix(1547036400, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1547036400_namespace_bundles_installationsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x95\x5d\x6f\x9b\x30\x14\x86\xef\xfd\x2b\xce\x5d\x8a\x84\xa6\x6e\xed\x5d\xae\x08\x39\x89\xd0\xa8\x9d\x19\x90\xd6\x2b\xe4\x06\xaf\xb5\x46\x48\x04\x6e\xb4\xfc\xfb\xc9\x49\x08\x36\xd0\x35\xda\xd7\x2d\x3e\x9f\xef\x79\xf4\x12\x72\x0c\x52\x84\x34\x98\xc5\x08\x4f\xaf\x55\x51\xca\x26\xdf\x7f\x82\x1b\x02\x20\xd6\xeb\xed\x6b\xa5\x21\xc5\xaf\x29\x50\x96\x02\xcd\xe2\x18\xe6\xb8\x08\xb2\x38\x85\xc9\xc4\x27\x00\xaa\x90\x95\x56\xfa\x00\xb3\x98\xcd\x2e\x41\xc7\x97\xaa\xd1\xa2\x2c\x85\x56\xdb\x2a\x57\x85\x5b\xc5\x04\xec\x6a\xb5\x17\x5a\xe6\xdf\xe5\x29\xdb\x7c\x6b\xd4\x73\x25\x8b\x7c\x57\x77\x9f\x9d\x1c\xad\x36\xb2\xd1\x62\xb3\x83\x8c\x26\xd1\x92\xe2\x1c\x66\xd1\x12\x22\xea\x96\x96\x3f\x76\xaa\x96\x05\xcc\x18\x8b\x31\xa0\x97\x99\x6f\xcd\xe3\x5e\xd6\x8d\xda\x56\x26\x09\x97\xc8\x87\x9b\x1d\xa3\x56\x3c\x7a\x08\xf8\x23\x7c\xc6\xc7\x9b\xb3\x10\x7e\x6f\x3c\x0f\x18\x85\x90\xd1\x45\x1c\x85\x29\x44\x4b\xca\x38\x12\x6f\x4a\x48\x44\x13\xe4\xa9\x69\xc0\x2c\x4d\xbb\x32\xad\x68\x7e\x5f\x24\xdf\x16\xa5\xdf\xce\xef\xb6\xf7\xdb\x0d\xfd\x76\x1b\x8f\x24\x18\x63\x98\xb6\x47\x6b\x3e\xfc\xcb\x6e\x64\xc1\xd9\x43\xbb\x9a\x0f\x37\xe7\xde\x93\x09\x04\xc9\x05\x9b\x8c\x46\x8c\x82\x3b\x16\x1c\x13\x6b\xa1\xd7\x2f\x52\xe7\xaa\xfa\xb6\xcd\xf7\x77\x9e\x95\xd5\x4c\x09\x71\x98\x74\x63\xef\xaf\x06\xf3\x34\x9c\x01\x6f\x00\x91\xdc\xbd\xc8\x8d\xac\x45\xe9\xa0\xd7\xca\x34\x8c\x6f\x0e\x9b\x8d\xd4\xb5\x5a\x8f\x33\xf9\x2e\xe8\x19\x8d\xbe\x64\xd8\x9d\xff\x32\xda\x2f\x6e\xe3\xb2\xc5\x71\x15\x07\x21\x9a\x6e\x0b\xc6\x31\x5a\x52\xc3\x25\x8c\x94\xf4\x80\xe3\x02\x39\xd2\x10\x93\x51\xf6\xdc\x2b\x1f\xdb\x64\xab\xb9\xf1\x80\x30\x48\xc2\x60\x3e\x24\xb8\x77\x81\x91\xa6\xbe\x2b\xa9\xbd\x96\xa3\xdd\x70\xcb\x1e\xb5\x7f\xa3\xe6\x28\x62\x53\x42\xe6\x9c\xad\x46\x99\xba\x9b\xda\x6f\x67\xcd\xfa\x18\xda\x4d\xfe\x97\x41\x5e\x6b\x76\x95\x78\x2a\x47\xcc\xee\xa3\xa9\x51\xa8\xe6\xf8\x9a\x0b\xfd\x76\x95\x4b\xca\xed\x18\xae\x9d\xf0\xd7\x30\x3a\xc0\xc7\x4e\xba\xda\x06\x6d\xef\x39\xad\xe7\xdb\xab\xfc\x9e\xdb\xbd\x53\xf4\x64\x6a\x76\xd6\x1f\x5b\xdb\x7d\xdf\xda\x2c\xd0\x9c\x46\x7d\xdc\x4a\xf9\x2c\xd6\x87\xbc\x2d\xfd\x36\x6c\xd6\x6f\x8a\x78\x53\xf2\x73\x00\xda\x58\xf5\x4f\xcf\x07\x00\x00")
+
+func _1547036400_namespace_bundles_installationsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1547036400_namespace_bundles_installationsUpSql,
+		"1547036400_namespace_bundles_installations.up.sql",
+	)
+}
+
+func _1547036400_namespace_bundles_installationsUpSql() (*asset, error) {
+	bytes, err := _1547036400_namespace_bundles_installationsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1547036400_namespace_bundles_installations.up.sql", size: 1999, mode: os.FileMode(420), modTime: time.Unix(1547036400, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1547122800_add_sent_transactionsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x1e\x00\xe1\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x73\x65\x6e\x74\x5f\x74\x72\x61\x6e\x73\x61\x63\x74\x69\x6f\x6e\x73\x3b\x0a\x03\x00\x37\x81\xde\x05\x1e\x00\x00\x00")
+
+func _1547122800_add_sent_transactionsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1547122800_add_sent_transactionsDownSql,
+		"1547122800_add_sent_transactions.down.sql",
+	)
+}
+
+func _1547122800_add_sent_transactionsDownSql() (*asset, error) {
+	bytes, err := _1547122800_add_sent_transactionsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1547122800_add_sent_transactions.down.sql", size: 30, mode: os.FileMode(420), modTime: time.Unix(1547122800, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1547122800_add_sent_transactionsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\x8f\xc1\x6a\xc3\x30\x10\x44\xef\xfa\x8a\xb9\x25\x86\xfc\x41\x4e\xb6\xb3\x29\x06\xb1\x6a\xcd\x1a\x72\x33\x8b\x6d\x62\x41\x58\x83\xa5\xfe\x7f\x69\x09\x29\x41\xd7\x79\xbb\xcc\x9b\xb6\xa7\x5a\x08\x52\x37\x9e\x90\x16\xcb\x63\xde\xd5\x92\x4e\x39\x6e\x96\x70\x74\x80\x4e\xd3\xf6\x6d\x19\x42\x37\x01\x07\x01\x0f\xde\xe3\x42\xd7\x7a\xf0\x82\xc3\xe1\xe4\x80\x55\xd3\x8a\xc6\x87\xe6\x75\xf0\x9b\x6e\x7b\xbc\x47\xd3\x47\x49\xa6\x55\xa3\x8d\x71\x46\xc7\x42\x1f\xd4\xbf\xc1\xb4\xd8\xbc\xec\xe5\xd3\xac\x59\xcb\x74\xe0\xee\x6b\xa0\xe3\xd3\xf2\xf4\xa7\x52\x21\x30\xda\xc0\x57\xdf\xb5\x82\x9e\x3e\x7d\xdd\x92\xab\xce\xce\x3d\xf7\x76\x7c\xa1\x5b\xb9\x77\x7c\x19\x07\x2e\xe9\x7f\xc7\xb6\xc7\x7b\x34\x7d\x54\x67\xf7\x33\x00\x55\x3d\x40\x0c\x41\x01\x00\x00")
+
+func _1547122800_add_sent_transactionsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1547122800_add_sent_transactionsUpSql,
+		"1547122800_add_sent_transactions.up.sql",
+	)
+}
+
+func _1547122800_add_sent_transactionsUpSql() (*asset, error) {
+	bytes, err := _1547122800_add_sent_transactionsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1547122800_add_sent_transactions.up.sql", size: 321, mode: os.FileMode(420), modTime: time.Unix(1547122800, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1547209200_encrypt_history_messagesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x93\x51\x6f\x9b\x30\x10\x80\xdf\xfd\x2b\xee\x2d\x8d\xe4\x4c\xed\x33\xda\x03\x4d\x9c\x0c\x89\x9a\xcd\x71\xb4\xbc\x21\x66\xae\xc1\x5a\x82\x23\x7c\x74\xca\xbf\x9f\xa0\x40\x4b\x48\xab\xed\x0d\xf9\xbb\x3b\xdd\x7d\x77\x2c\x16\xa0\x0b\x04\x2c\x4d\x75\x39\x13\xe6\x60\x5c\x49\x58\x12\x98\xac\x9c\x11\xfc\x42\xc8\xb1\x47\x7f\x2c\x15\xae\x26\xa0\x02\xc1\xa3\xa9\x90\x78\xfb\x7d\x42\xef\xb3\x03\x7a\xb6\x58\xbc\x2b\xe4\x6d\x69\xb0\xe5\xf5\xf9\x50\x65\x39\x42\x56\x21\x1c\x9d\xa7\x2f\x6c\xa5\x92\xef\xa0\x55\xb4\xd9\x08\x05\xd1\x1a\xc4\x3e\xda\xea\x2d\x14\xd6\x93\xab\x2e\x69\x5f\x30\xcd\xea\xe0\xdf\x63\xf3\xff\x88\xb5\x7d\x6c\xf8\x18\x8b\xcf\x22\x9f\xc9\x8f\x42\x9f\x2d\x1e\x73\x9f\xbe\x8e\x1f\x30\xb6\x54\x22\xd4\xa2\x83\x93\xec\x97\x07\xb8\x63\x00\x99\x31\xae\x2e\x09\xb4\xd8\x6b\x90\x89\x06\xb9\x8b\x63\x58\x89\x75\xb8\x8b\x35\xcc\x66\x9c\x01\xd8\x7c\x8c\x9b\x37\x53\x64\x94\xde\x02\x59\x4d\x85\xab\x6e\x24\x74\xcb\xfb\x08\xa4\x74\x39\xe3\x34\xad\xeb\xf7\x03\x5a\xe1\xf9\x78\x49\xc9\x4d\x89\x39\x3a\xf3\x1b\x22\x39\x7e\x25\x7b\x42\x4f\xd9\xe9\x3c\x21\xae\xa6\x83\xb3\xe5\x01\x1e\x93\x24\x16\xa1\x1c\x41\xcc\x6d\x73\x64\xd7\x68\xd0\x74\xdf\xd4\xde\xc9\xe8\xc7\x4e\xdc\x75\x42\x39\xd8\x7c\x0e\x89\x84\x65\x22\xd7\x71\xb4\xd4\x10\x6d\x64\xa2\x04\x9b\x07\x2c\x92\x5b\xa1\x74\xd3\x42\x32\xdd\xea\xcb\x03\x03\xd8\x8a\x58\x2c\x75\xbf\x9c\xa6\x16\xef\x8d\xf3\xce\x30\xef\xc5\x0d\x1f\xad\x23\x3e\x32\xc6\x07\x43\xfc\xd5\x08\x7f\x53\xc0\x87\x99\x79\x37\x20\x03\x58\xab\xe4\x69\xd2\x13\xfc\xfc\x26\x94\x78\xf7\xff\x7c\x85\xfb\xd1\xe5\x5d\x27\x04\x2c\x8c\xb5\x50\x9f\x9c\x9e\x12\x32\x7c\x12\x70\x43\x40\xd0\xdf\x6d\x24\x57\x62\x3f\x4d\x6e\x35\xb4\xb3\x34\x76\xaf\xf1\x9b\xfe\x41\x57\x37\xb7\xcd\xe7\x01\xfb\x3b\x00\x49\x6c\x8d\x0c\x53\x04\x00\x00")
+
+func _1547209200_encrypt_history_messagesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1547209200_encrypt_history_messagesDownSql,
+		"1547209200_encrypt_history_messages.down.sql",
+	)
+}
+
+func _1547209200_encrypt_history_messagesDownSql() (*asset, error) {
+	bytes, err := _1547209200_encrypt_history_messagesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1547209200_encrypt_history_messages.down.sql", size: 1107, mode: os.FileMode(420), modTime: time.Unix(1547209200, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1547209200_encrypt_history_messagesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x90\x4d\x6b\xf2\x40\x14\x46\xf7\xf3\x2b\x9e\x9d\x0a\xfa\xf2\x76\x1d\xba\xc8\xc7\x68\x83\x63\x62\xe3\x08\xba\x92\x34\xb9\x69\x86\xa6\x93\x90\xb9\xa2\xfe\xfb\xa2\xb5\x85\x22\x14\xbb\x3f\xf7\x5c\x9e\xe3\x2b\x2d\x33\x68\x3f\x50\x12\xb5\x71\xdc\xf6\xa7\xdd\x3b\x39\x97\xbf\x92\x83\x1f\x45\x08\x53\xb5\x5e\x24\xe0\xf6\x8d\xac\x83\x96\x1b\x8d\x24\xd5\x48\xd6\x4a\x21\x92\x53\x7f\xad\x34\x06\x03\x4f\xdc\x2b\x22\x5b\xf4\xa7\x8e\xa9\x44\x90\xa6\x4a\xfa\xc9\xad\xee\xbf\x27\x44\x98\x49\x5f\xcb\xab\xaf\x32\xd4\x94\x6e\xe7\xa8\xe8\x89\x31\x14\x80\x29\x11\x27\x5a\xce\x64\x86\x65\x16\x2f\xfc\x6c\x8b\xb9\xdc\x22\x7c\x92\xe1\x1c\x43\x53\xe2\x11\x0f\xa3\xb1\x00\xae\x37\x81\x4a\x83\xef\x47\x62\xe4\x09\x31\x99\x40\xd7\x84\x6a\xdf\x34\x13\xa6\x23\xc3\xd8\x92\x8e\x68\x2b\x70\x4d\x28\x5a\xcb\x64\x19\xc6\xa1\xa7\xae\xc9\x0b\x2a\x71\x30\x5c\x23\xb7\x3f\xc1\xcf\x2e\xe3\xb3\xae\xe8\x29\x3f\xef\x7a\x39\x5d\x14\x1d\xf5\xce\x38\x26\x5b\x10\x0e\x35\x59\xac\x9e\x95\x61\x82\xdb\x77\x5d\xdb\xb3\x83\xe1\x7f\x22\xca\xd2\x25\x74\x16\xcf\xce\x4b\xe2\x29\xe4\x26\x5e\xe9\xd5\x4d\xc0\x5d\xbe\xf7\xee\x67\xcb\x3f\xb0\xe6\x8b\xbd\x84\xfe\x85\xac\xd8\x79\xe2\x63\x00\xc4\x97\x18\x9a\x2e\x02\x00\x00")
+
+func _1547209200_encrypt_history_messagesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1547209200_encrypt_history_messagesUpSql,
+		"1547209200_encrypt_history_messages.up.sql",
+	)
+}
+
+func _1547209200_encrypt_history_messagesUpSql() (*asset, error) {
+	bytes, err := _1547209200_encrypt_history_messagesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1547209200_encrypt_history_messages.up.sql", size: 558, mode: os.FileMode(420), modTime: time.Unix(1547209200, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1547295600_drop_account_namespaceDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xc4\x5c\x4b\x73\xdc\xb8\x11\xbe\xeb\x57\xf0\xb6\x74\x15\x9d\xda\x64\xb3\x27\x9f\x64\x99\x76\x54\xd1\x8e\x9c\xb1\x9c\xda\x3d\xb1\x30\x24\x34\x83\x88\x43\x32\x24\x46\x8f\xfd\xf5\x29\x90\x78\x75\xa3\x39\x03\x8e\xb4\x9b\x9b\x85\x7e\xa0\xf1\x7d\xdd\x60\x13\xc4\xf8\xfd\xfb\xe4\x6e\xc7\x93\xbe\x7d\x1a\x92\x6d\x9b\x6c\x58\xf9\x90\xc8\x36\x91\x3b\x9e\x54\x4c\xb2\x64\x90\x6d\xcf\xab\x64\xc3\xef\xdb\x9e\xdb\xe1\x0d\x1b\x78\xf2\xc4\x86\xa4\x61\x7b\x3e\x74\xac\x54\x2a\x2f\x09\x2b\xcb\xf6\xd0\xc8\xec\xe2\xfd\xfb\xe4\x69\x27\xca\xdd\x68\xa0\x47\x93\xba\xdd\x6e\x45\xb3\x4d\x44\x93\x94\x35\x13\xfb\xe1\x2f\x17\x57\xeb\xfc\xf2\x2e\x4f\xee\x2e\x3f\xde\xe4\x49\xcd\xb7\xac\x7c\x29\x8c\x7a\x7a\x91\x18\x87\xc9\x5d\xfe\xeb\x5d\xb2\xba\xbd\x4b\x56\xdf\x6f\x6e\x92\xaf\xeb\xeb\x5f\x2e\xd7\xbf\x25\xff\xcc\x7f\xbb\x78\xf7\xe1\x02\x7a\xd9\x1c\x9a\xaa\xe6\x43\xf1\xf8\xb7\x23\x1e\x3e\xe5\x9f\x2f\xbf\xdf\xdc\x25\x3f\xfc\x90\x5d\x24\x89\xa8\x78\x23\x85\x7c\x49\x3e\xde\xdc\x7e\xb4\x4a\xa3\xa4\x19\x24\xab\x6b\x26\x45\xdb\x14\xa2\x82\x5e\x94\x42\xd7\x8b\x47\x26\x79\xf1\xc0\x27\x6b\x35\x36\x88\x6d\xc3\xab\xa2\xeb\xdd\x30\xb0\x91\x62\xcf\x07\xc9\xf6\x5d\xf2\x7d\xf5\xed\xfa\xcb\x2a\xff\x94\x7c\xbc\xfe\x92\x5c\xaf\xa0\x6b\xfe\xdc\x09\x05\xfd\xc7\xdb\xdb\x9b\xfc\x72\x65\x63\xfe\x51\x09\x1f\x79\x3f\x88\xb6\x51\x46\xf9\x97\x7c\x6d\x0d\xa1\x96\x07\x54\xaa\x81\xc8\x50\x78\xef\x92\xdb\x55\x72\x75\xbb\xfa\x7c\x73\x7d\x75\x97\x5c\x7f\x59\xdd\xae\x73\x05\xea\xf5\xea\x5b\xbe\xbe\x53\xfe\x6f\x3d\x48\x53\x03\x55\x86\xa1\xc9\x7c\x28\xf0\x24\x99\x5b\x73\x66\xd6\x95\x99\x35\xbc\xbb\xf8\x96\xdf\xe4\x57\x77\xc9\x1f\xe0\x3b\xf9\xbc\xbe\xfd\xc5\xc5\xff\x13\x4e\x96\x9e\xc9\x72\xc7\x65\x21\x9a\xfb\xb6\x78\xfc\x7b\x74\xc6\x4c\x1e\x55\x46\x04\xec\xf2\x6e\xc7\xf7\xbc\x67\x35\xc8\x09\xb3\xb6\x50\x7f\x78\xd9\xef\xb9\xec\x45\x49\x27\xcb\xc9\x0c\xfc\xbe\xba\xfe\xd7\xf7\xdc\xd1\x6b\x43\xcb\xe6\x01\x85\xa4\xaf\xf3\xaf\x37\x97\x57\xb9\x9a\xed\xf3\xed\x3a\xbf\xfe\xb2\x52\x95\x95\x10\x2e\xdf\x25\xeb\xfc\x73\xbe\xce\x57\x57\xf9\x37\x3d\xaa\x0a\xcd\x69\x42\x6a\xc6\x69\xbe\x7f\xfd\xa4\xf0\xbe\xba\xfc\x76\x75\xf9\x29\x48\x2d\x44\x40\xea\x45\x0f\x80\xf4\x17\x03\x10\x0b\xd7\x66\xd2\xe9\xf5\xae\xa6\xec\x81\x21\xfe\xfc\xe1\xe2\xe2\xd3\xfa\xf6\x2b\x99\x40\x3f\x7f\xf0\x65\xf3\x69\xc7\xa4\x64\xe5\x6e\xcf\x1b\x39\x14\x8f\x3f\x46\x67\xdd\x8e\x0d\x3b\x22\x43\x88\xa4\x20\x53\xa9\x6c\x1b\xc9\x1b\x59\xc8\x97\x8e\x87\x26\x83\xf8\x9d\x07\x7b\xd0\x20\x99\x0c\x47\xc7\x07\x83\xc9\x6c\x56\x96\x7c\x18\x78\x55\x30\x19\x28\xe2\xdc\x54\x0b\x20\x73\x0f\xa7\x05\x04\x28\x55\x76\x8a\xb6\x2c\x19\x69\xf2\x17\xa2\x72\xee\x77\x9e\x4d\x91\x66\xe3\xc3\x29\xf3\x63\xb2\xe9\xf0\x0a\x1f\x53\x1e\x78\x31\x01\x9a\xc1\xf8\xe5\xcd\x5d\xbe\xa6\x59\x5e\xe7\xab\xcb\x5f\xf2\x04\xae\x0e\x67\xc6\xa6\x6e\xcb\x07\x5e\x15\x6a\x89\xac\x5c\x96\x1e\xdd\x61\x53\xeb\x6d\xe4\xe4\x2e\xe1\x74\xa3\xf8\x20\xc2\x4a\x3d\x17\x06\x63\x37\xa4\x37\x5e\x64\x06\xcb\x23\x10\xfa\xe0\x11\x33\x7a\x08\x86\xb6\x10\xc6\x72\xc7\x64\x31\x70\x29\x45\xb3\x5d\xd4\x0a\xc8\xb6\x13\x65\x58\x3a\x35\x6b\xb6\x07\xb6\xe5\x47\x6d\x31\xc6\xa3\xaf\x28\x78\x71\xb8\xe9\x68\x9a\xd9\x69\x6d\x12\xa3\xf1\x09\x65\x64\xfd\x13\x40\x39\x14\x22\xa4\xda\x7d\xd7\xf3\x41\x3d\x2c\x8b\x4a\x94\x6a\x13\x65\xbd\xe0\x8b\x12\xcf\xec\xa7\x21\x6e\x78\x43\x05\x5e\xbc\x3e\x66\x38\x9d\xb1\x6e\xd3\x46\x4e\xdf\x05\x68\xce\x2f\xe9\x58\xff\x62\x42\xb1\x60\x47\xe8\x6a\x02\x66\x26\x84\x44\xcc\x2a\xf9\x69\x3f\xa7\x05\xd3\x7f\xde\x17\x26\x77\x2c\x8f\xa2\x64\x1d\xdb\x88\x5a\xc8\x3f\x9d\xd8\xe0\x91\x70\xcf\x99\x3c\xf4\xfc\x8d\x09\x27\x97\x19\x41\x76\x66\xe3\x59\xc0\xba\x33\x32\xf4\x87\xd3\x23\xea\x29\x05\x48\x7b\xa8\x81\x29\xa7\x7c\xd0\x74\xf3\xa6\xec\x5f\x3a\x95\x61\x4b\xc8\xf6\x36\xef\x80\x9b\xee\x7e\xb0\xef\x21\xc7\x38\x5b\xf8\x58\x21\x03\xf6\x1e\x2c\x59\xd2\xdd\x3b\x66\xd0\x38\x04\xdf\xb9\x20\xa1\xf7\xc5\x14\xf0\x4e\x4e\xc3\xee\xdb\xd3\xa0\xef\x99\xa8\x07\xde\xab\x1c\x39\x13\xf5\xa0\xc8\x78\xd3\x56\xfc\x74\xa1\x38\x17\xb3\x95\x01\x83\x03\x08\x8f\x93\x90\x18\x8f\x12\x88\xb2\xe7\x87\x84\x19\xc8\x29\x9c\x3d\x05\x1a\x68\xe0\x81\x46\xba\xe7\xff\x3d\xf0\xe1\xed\x7a\xa3\xb1\x75\x0c\x87\x7b\x5e\x72\xf1\x18\xd7\xd8\x9e\x99\xf7\xde\x4a\x00\x27\xba\x11\xf5\x22\x20\x09\x0a\xd5\x20\x5b\xc6\x3d\x49\x95\x13\x52\x3c\x19\x29\x4d\x92\xb3\xa5\x19\x1a\xdb\x94\x45\xfc\xbc\xe2\x49\x33\xd3\xb2\x61\x8a\x96\x3f\x4e\xec\x2a\x8e\x3d\x48\x46\x25\xcb\xce\x29\x45\xc8\xcf\x68\x4b\xb3\x63\x44\x14\x37\x0e\xdd\x90\x19\x63\x47\xf2\xf2\x66\x15\xa3\x4e\xfd\xc2\xd1\x27\xd6\x37\xaa\xdf\x0e\x25\x6f\x53\x2e\x41\x99\xa8\x30\x32\x3b\x2d\x59\x22\x50\x05\xc0\x4f\x02\x4f\x43\x4e\x83\x1d\xc0\x5c\xf1\xb2\x7d\x39\x23\xf9\xbb\x76\x10\x92\x3a\xcd\x5b\x90\xde\xc6\x47\x14\xa0\x28\xd0\xd4\x18\xe3\x74\x46\xe3\x13\x7c\xbe\x31\x80\x10\x0a\x7c\x18\x7d\x09\x84\x12\xda\x20\x38\xdb\xa7\x66\xdb\xb3\x6a\x59\xcf\xea\xd8\x87\x6a\xf6\x00\x25\x18\xad\xb8\xe4\xa5\x0c\x37\xfa\x00\x35\x3f\x1e\x90\x88\xca\x6f\xe6\xfb\x21\x73\x31\xd0\xd2\x78\x5a\xb7\x10\x4d\x6f\x18\x60\x69\xc7\x11\x92\x9e\xbe\x86\xf1\x7a\xf5\x29\xff\x35\x11\xd5\x73\xe1\x9c\x15\x2e\x22\x95\x29\x4e\x40\x55\x27\x5c\x13\xe6\x87\x37\x43\xd1\xf3\xa1\xad\x0f\x2a\x47\x16\x91\xd4\x70\xf9\xd4\xf6\x0f\x6a\x3f\xa7\x72\x9e\xde\x5f\xec\xb1\xd3\xd1\x42\x70\xae\xb3\xf1\xeb\x44\x54\x41\x84\x4b\x49\xb1\x9f\xe9\x5c\xc8\x12\x4b\x8b\x27\x46\x91\x37\x40\x6b\x20\xf3\xb9\x45\x42\x48\x70\x60\x49\xb1\xa1\xda\x4f\xfe\x07\xb1\xc2\xaa\x4a\xbd\x74\x86\x14\x2c\x27\x46\xbb\x5a\xc0\x0d\xb5\xb0\x94\x70\x79\x84\x26\xa0\xe1\x33\x15\xf8\x26\x18\x23\x74\x42\xe6\x02\x25\x8a\x41\xc2\x13\x66\xf2\x91\xd7\x6d\xc7\x8b\xb1\xbf\x7b\x83\x13\x62\x7b\x8a\x1b\x50\x7a\xe8\x2a\xe6\x76\xbe\x40\x8c\x29\x54\x3b\x58\xd0\x2e\x85\xe1\xea\xf3\x5a\xdd\x9e\xba\x39\x2c\x2b\x33\x72\xc3\x09\xf0\x87\xb8\x40\x32\xc8\x01\x10\x62\xec\x91\x25\xc4\x7c\xdb\xb7\x87\xae\x18\x1b\xee\x6e\xd9\x9b\xc5\x9e\x0f\x03\xdb\xf2\x99\xd6\x54\xb2\x9a\x44\xb6\xe2\xb5\x78\xe4\xea\xf3\x1e\x96\x5a\xf7\xe3\x77\xbe\x9e\xb3\x53\x2a\x98\x24\x17\x50\x54\x79\x05\x2b\x4f\x9d\x03\xf5\xdc\x97\xac\xce\x5c\xb8\xd9\x18\x91\x25\xf2\xb4\xea\xc4\x29\x9c\x04\x50\x8a\x45\x3e\xa3\x50\x06\x09\xc5\x76\x90\x4f\xbf\xfd\xfe\xb3\xbe\x05\xc7\x7e\xd7\x6d\xd8\xa6\x26\xbe\xeb\xfe\x55\xf9\xa8\xc4\x30\x4a\x55\x35\xcc\x7a\x39\xce\xff\xec\x3b\x48\x54\x3a\xf8\x36\xa7\xbe\xf8\xfa\x1f\x5e\xa7\x45\x65\xfe\x02\x6c\x96\x9c\xef\x62\xca\x1e\xdf\x2a\x38\xe0\x0e\x85\x30\x13\x4c\x8a\xb2\x83\xdc\xb5\xcb\xce\x66\x28\x92\x27\x37\xe1\x78\xc8\x42\xcc\x97\xf5\x30\xb8\x54\x01\x3b\xfd\xe9\xe1\x67\x86\x26\x3c\x90\x15\x80\x23\x90\xf9\xf5\x84\x84\xb0\xa0\x02\x4b\x1a\xc7\x72\xc7\x9a\xed\xb2\x96\x5c\xb2\x7e\xcb\x25\x59\x33\x73\x70\xea\xcf\x73\xa1\xa0\xe2\x35\x97\x5e\xf5\x00\x23\xf5\x55\x28\xa8\x37\xcc\x8c\x8d\xc6\xe2\x1c\x53\x17\xe1\xf2\xd3\xc0\x91\xfd\xaa\x98\x99\x30\xb3\x29\x26\xcb\x64\xbc\x09\x64\x5a\xcf\x4a\x32\x6d\x65\x14\xd3\x5a\x48\x33\x6d\x2d\x69\xa6\x7b\xce\x4a\x5d\x55\x7f\x28\xd7\x7c\xdf\xfe\x47\x84\xc3\x3d\x97\x3d\x2b\xdf\x98\xeb\x6c\x9a\x2d\x6a\x2b\xa4\x70\xa0\x48\x1f\x3d\x66\x2e\xde\x08\xd2\x67\x4c\x20\xe9\x76\x5e\x92\x76\x4f\x4a\x11\x6f\xc5\x34\xf5\x9e\x35\x22\xbf\xad\x78\x3f\xf6\x63\x2a\x3b\x1a\x5e\x2f\x63\x9f\x3e\xac\x18\x3f\x42\x52\x29\xa1\x67\xa3\xb2\x42\x89\x4e\x74\x3e\xb5\x18\xa4\xbd\x87\x70\xfe\xd7\x57\x72\xc9\xe6\x13\xac\x0e\x3d\x73\xa1\x4e\xff\xcc\xc6\xd9\x1d\xc7\x31\xda\x9a\xde\x60\x3a\xc8\x2f\x21\x06\x04\x07\x72\xc4\x30\x61\x8f\x28\x3e\x68\xe9\xb2\x46\x57\xaf\x2d\x64\x0a\x23\xaf\x15\xe3\xb0\x07\xb1\xa4\xc6\xd4\xc0\xaa\xff\xd6\xc0\x39\x5d\x88\x98\x3f\x0e\xa0\x72\x02\x84\x91\x6f\x01\xc1\x69\x5a\x29\xee\x45\x39\x35\x39\x5d\xcf\xef\x79\xcf\x9b\x72\xe1\x11\x94\x33\x23\x6a\x21\x62\xe3\x8a\x82\xee\x48\xa4\xa9\xf7\x27\xde\x8d\x42\xd1\x84\xee\x9c\x3b\x00\xf5\xbc\x92\x8f\xfb\x9c\x16\x24\x61\xde\x17\x64\xa4\x3d\xc8\x4d\xfb\x5c\xf0\x46\xf6\x62\x19\x0f\x54\xb2\xee\x84\xba\xd1\xfa\x42\x26\x72\xd9\x73\xf3\x26\x8a\x37\x9e\xf8\x63\x8e\xc8\xc4\x0f\x56\x35\x36\x7e\x2e\xba\xcc\x8b\x06\x9d\x69\x9c\x52\x9c\xf8\x84\x13\x00\x16\xb1\xc8\xe7\x0e\xca\x20\x63\xd8\x0e\xf2\xd4\xf1\xa6\x12\xcd\xb6\xd0\x8f\x98\xb7\x38\xb7\xe0\x4d\xc5\xfb\x70\x5c\xcf\x10\x0a\x98\x94\x7c\xdf\xc9\x01\x94\x17\x7e\xa1\x76\x1f\xcb\xa2\xae\xab\x61\xe2\x88\x65\x9a\xf3\x8e\x31\x5c\xfb\x02\x9e\xd9\x70\xe8\x2f\x79\x4b\x8c\x26\x4a\xf1\xd4\x80\xd4\x50\xe8\xd3\x8a\xa5\x90\xd8\xd0\x56\x33\x3b\x9d\x20\x07\xc6\x9a\x98\xdb\x55\x20\x72\xe0\x4d\x3a\xc1\xa1\xb1\xba\x03\xa3\x36\x1f\xef\x4e\x52\x7c\x9a\x18\x9b\x53\x55\x18\x55\x81\x54\x24\xa9\xf9\xb7\x65\xc9\x0c\x68\x06\xb0\x0d\xa4\x20\x94\x02\x0e\xb0\x18\x91\x10\x5a\x63\xe8\x5a\x7d\x25\xf1\x9c\x12\x3b\x51\x4c\x05\x5d\x82\xfe\xdb\x35\xb9\x67\x46\x1e\x7a\xe0\xd2\x42\x69\x5f\xe8\xdb\x99\x70\xb6\x48\x1a\x09\x54\xd2\x28\xff\xde\xf1\x83\xc7\xf7\x32\x43\x93\x17\x38\x08\x94\x18\xa1\x18\x66\x06\x96\xe3\xd4\x08\xed\x61\x85\x86\x0e\x5c\x84\xb7\x2b\x42\x9e\x5a\x79\x58\xa2\x87\x61\x57\x94\xb5\x50\x57\x6c\xc7\x33\xda\x25\x69\x16\xf3\x90\x8c\xe3\x95\x88\x22\x05\xcf\x41\xf7\xac\x0b\x74\x21\xfa\xa1\x14\x80\x8f\xc5\x08\xfb\xd0\x9a\x80\xab\xe7\x5b\x31\xc8\xde\x9c\x42\xc5\xe3\xe5\x3e\xb6\x9d\x5b\x81\x71\x6d\x09\x9a\xe7\xcc\x5a\xa3\x96\x9a\x9e\x72\x8d\xda\x97\x38\x75\x8f\x58\x30\x61\xc8\x2c\x12\x07\xd4\x02\x39\xc1\x2d\xb2\x87\xe4\xaa\x23\x6c\x70\x00\x1d\xcb\xab\xae\x33\x92\x31\xe5\x94\x13\xaf\xbc\x71\x67\x1a\xce\xb3\xea\x2b\x94\xa7\x98\x93\x46\xbc\x90\x34\x74\x83\xdf\x14\x66\x35\xf4\xef\x22\x7c\x8f\x80\x16\x30\x17\x64\x04\x87\xe1\x91\x81\xac\x20\x0f\x03\xdf\xda\x8b\xf4\xb1\x14\xcc\xd4\x53\xf5\x1c\x40\x3c\xf9\xba\x5e\xc5\x14\x96\xdf\x95\x9d\xa2\x4a\xe7\x77\xf5\x1c\x7c\x34\xf3\xd6\xa3\xbb\x47\x51\x3d\xab\xf3\xc3\xf1\xd8\x4a\xcd\x7c\xac\x69\x3c\xaa\x3b\xb1\x63\x26\x00\xc4\xb8\x41\x9f\x13\x33\x0a\xe9\x70\xba\x98\x09\xf5\xab\x89\x9e\x35\x83\x77\xc0\xf4\x3a\x4a\xda\x5e\x6c\x45\xc3\xea\x50\x52\xee\x98\x68\xe6\xbe\x47\xcf\x75\x34\x71\x1b\x62\xf4\x6f\x51\xa8\x05\x6b\xce\x4c\xe0\x99\x0d\xd4\x75\x36\x60\xcf\x8b\x53\x37\xcc\xa1\xf9\x10\x85\x81\x14\x72\x89\xc4\x98\xd4\xc0\x5a\x93\x3b\x75\xfa\xa1\xb9\x89\x59\x6d\x31\x81\xd4\xa1\x69\xd4\x82\x66\xc2\xef\x75\x63\xb3\x84\xbc\xbb\xf3\xc8\xea\x03\x3f\x4d\x6b\xec\x9d\x32\x2f\xae\x74\xbc\x9d\x33\xfa\xb7\x84\xb9\x21\x43\x8a\xe9\xc9\x01\x17\x66\x10\x52\xe0\x56\xec\x23\x6f\x74\x11\x3e\x52\x94\x0f\xbc\x2f\xf4\x67\x80\x25\x30\xb9\xbb\x0d\x64\x79\x74\xac\x9c\x95\xd1\x75\x18\x57\x38\xfe\x95\x0a\x3d\xc7\x92\x6a\x0a\xd6\x9b\xce\x3a\x44\x45\x74\x42\x4f\x13\x05\xfd\x43\xbe\xb0\x0c\xd0\x06\x85\x88\x3d\x6c\x09\xab\x06\x99\xaa\xd8\x55\x0e\xa2\x71\x1a\x42\xa5\x1c\x56\x8d\xb6\x54\xcb\x5c\x54\x3a\x27\x72\x62\x66\x98\x26\x5e\xf7\x65\xf6\x51\x17\x98\x1d\xcb\x8c\xc8\x8e\x12\x2f\x14\x24\x83\xe9\x05\x6d\x8b\x08\x9f\x85\xa7\x55\x61\x4a\x8c\x73\x90\x09\xa1\x25\x54\x3a\x58\x06\xc2\x64\xd0\x56\x88\xb9\x97\xa6\x2c\xc6\x2e\x69\xd9\x96\x27\x9a\x2a\xf9\xf7\xe5\xfa\xea\x1f\x97\x10\x63\x51\x1d\xe9\x13\x4f\x32\xf2\x20\x9a\x05\x5c\x80\xd0\x53\x63\x8b\xdb\x42\x34\xae\x31\x76\xb6\x10\x61\x7f\x1c\xe0\xeb\x04\x08\x5d\xdf\x02\x62\x7b\x68\xf4\xef\x8b\xc1\x13\xea\xd5\xed\xc7\xf4\xa3\xf1\x61\x41\x65\x9c\xdd\x4b\xcc\xad\x40\xf7\x13\x3a\x12\xb2\x79\x00\xb2\x09\x74\xd2\x1b\x80\x7f\x46\xc3\x27\x82\x54\x81\x94\xcc\x78\x81\xe4\x3c\xa9\xa2\x93\xc5\x86\xd5\x6c\x3c\xc4\x6a\x58\x37\xec\xda\x65\x1d\xbb\x69\x8b\x48\x2a\xf4\xf5\xbd\x90\x0d\xd9\x3e\xf0\x86\x18\xb6\x07\x20\xe7\x13\xeb\xfa\x34\x7b\x79\x70\x9c\xcd\x3f\x39\x8a\xe1\xfd\x08\x38\x69\xc4\x1c\x28\x21\xa2\x2d\xa6\x34\x99\x9b\x1c\x64\xca\xbc\x92\x9f\x2c\x73\x5a\x30\x5f\xe6\x7d\xe9\x8c\x99\x9a\xcd\x59\x67\x76\x15\x85\xa8\x9e\x15\xbc\x73\x9a\x14\x51\xd6\x38\x78\xa8\x6a\x2f\xe5\x61\x90\xed\xbe\x18\x31\x5e\x94\x9d\xee\x79\xb3\x2c\x3f\xe3\x52\xcd\x7f\x9a\x69\x57\x4b\x52\x0b\xaf\x2a\x25\xfc\xa1\x34\x9a\xd5\x00\x69\x03\x1c\x53\x29\x83\x14\x88\x74\x01\x1a\x64\xaa\x20\x1f\x24\x6f\xe3\xa6\x7f\xbf\xf0\xe7\x75\xe7\xed\x28\xd4\x43\x77\xfc\xc9\x79\xd1\x1c\xf6\x1b\xde\xbf\xed\x8e\x12\xf9\x78\x26\x60\xa0\xf6\x0e\xc5\xa6\x1f\xeb\xe9\xbd\x83\xb6\x00\x49\x60\x27\xa5\x12\xc0\x13\x12\xe4\x5b\x29\x49\xbc\x67\x4b\xed\x0d\xce\x78\x0a\x10\xee\x07\x56\x7a\x14\x5e\xb8\x34\x51\xcd\xed\x0b\x68\xae\x3f\x21\xcd\x36\xd1\x7d\x5c\xb0\xac\xf3\x32\xc6\x2e\x8c\x4a\x9c\x0d\xe8\xf2\xe6\x14\xe8\xb4\xd0\x9e\x8f\x66\x87\xd5\x39\x9a\x24\x0e\xfb\xf9\x5c\xb1\x9e\x10\x8f\xa2\x7b\xfd\xfd\x00\x31\x0c\x87\xf9\x37\x9e\xb8\x1a\x8f\x2d\x68\x1d\xef\xf8\xe5\xdf\xce\x8b\xea\x95\x90\x69\x0a\x94\x35\x04\x7c\x1a\x01\xf0\x1a\x48\x3c\x30\xd5\x10\x2e\x01\x73\x9d\x40\x1f\xf9\xbe\x1a\x45\x73\x6f\x27\xfa\x0e\xa0\x7e\x45\x9e\x15\xcc\xfc\xa7\x35\xe6\x88\x9a\x96\xf6\xbc\xab\xd5\x8f\xcb\x8e\xbc\x41\x81\x51\xdb\x3a\x04\x92\xf6\x20\xb7\xad\x68\xb6\xe4\x55\x44\x5e\x09\xea\x96\xa2\x85\xe9\x47\xdb\xa4\x0e\x47\x81\xd4\xbf\x31\x3f\xe9\xea\xbc\xab\xc6\x04\xc5\x69\xdf\x3e\x99\x97\x67\xcd\x98\xbb\xd5\xa8\x81\xc7\xff\x53\x8e\x8f\x78\x66\x11\xd6\xef\x82\xa0\x01\x35\x98\x65\x1a\x20\xdd\xa4\x0e\x99\x5b\xa8\xcd\xf2\xff\x77\x20\x53\x49\x61\x88\x40\x75\x85\x42\xbf\xd0\xb0\x14\xd6\x5c\x68\xab\xab\x6f\x6a\x84\x03\xe3\x91\x8c\x11\x51\xb5\xcd\x63\xb1\x63\xde\x62\xa5\x17\x2d\xaa\x77\x1f\x2e\xfe\x37\x00\x1a\x15\xf5\xc7\xd2\x4f\x00\x00")
+
+func _1547295600_drop_account_namespaceDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1547295600_drop_account_namespaceDownSql,
+		"1547295600_drop_account_namespace.down.sql",
+	)
+}
+
+func _1547295600_drop_account_namespaceDownSql() (*asset, error) {
+	bytes, err := _1547295600_drop_account_namespaceDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1547295600_drop_account_namespace.down.sql", size: 20434, mode: os.FileMode(420), modTime: time.Unix(1547295600, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1547295600_drop_account_namespaceUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xc4\x5c\x4d\x73\xe3\xb8\x11\xbd\xfb\x57\xe0\x36\x72\x85\x4e\xc5\xbb\xc9\x25\xce\xa6\x4a\x63\x73\x66\x5d\xeb\x91\x27\xb2\x27\x99\x3d\xb1\x20\x12\x96\x10\x53\x24\x43\x42\xfe\xd8\x5f\x9f\x02\x08\xe2\xa3\xd1\x90\x28\x9b\x5b\x7b\x9b\x61\xa3\x1f\x1a\xef\x75\x03\x20\x08\xeb\xec\x8c\xa4\x34\xdf\x10\x9a\xe7\xf5\xae\x12\x64\x43\x3b\xc2\x45\x47\xea\xe7\x8a\xe4\x1b\x2a\x48\x41\x05\x5d\xd1\x8e\xfd\x9d\x88\x0d\x23\x6d\xfd\xdc\x91\xfa\x41\xfd\x7b\x70\x11\xb2\x59\x5e\x52\xbe\x65\x85\x34\x9c\x9c\x9d\x29\xaf\xa1\xdd\x80\x40\x68\xcb\xc8\x23\x6b\x44\x42\x9e\xb9\xd8\xd4\x3b\xe9\xca\x78\x3b\xf4\x9d\x10\x5a\x49\x80\xba\x63\xd2\xb5\x16\x1b\x66\x6c\x9d\x04\x95\xfe\x05\x2b\x99\x60\xc5\x9f\x4f\x2e\x97\xe9\xfc\x3e\x25\xf7\xe9\x97\xaf\xe4\x7e\xfe\xf1\x26\x35\xfd\x64\xda\x87\xcc\xef\xc8\x5d\x7a\x93\x5e\xde\x93\xeb\x4f\x8b\x6f\x37\x37\xb3\x2f\xf3\xef\x33\x6d\x3c\x4d\xc8\x87\x0f\xa7\xb2\xc9\xd0\xfa\xd3\xf2\xf6\x0b\x29\xd9\x9a\xe6\xaf\x03\xc2\xc5\xc9\xc9\x55\x7a\x93\xde\xa7\xbd\xf1\x91\xbd\x76\xe4\x3f\x3f\xa7\xcb\x94\x74\xac\xeb\x78\x5d\x65\xbc\x20\xd7\x77\x64\x71\x7b\x4f\x64\x07\x64\xbe\xb8\x22\x33\xdd\xa7\x87\x0b\x63\x3b\x25\xff\xf8\x27\xf9\xf0\xe1\x84\x28\x97\x6e\xb7\xea\x44\x3b\xb3\xa0\x09\x39\x4f\x48\xc9\xaa\xb5\xd8\xcc\xc6\xe1\x9d\x2a\xc4\xcb\xf9\xdd\xfd\x48\x07\x39\xf4\x8f\x37\xb7\x1f\x4f\x2f\x4e\xbe\x7d\xbd\x92\x54\xaa\xe1\xdd\xa5\xf7\xee\xe0\x7e\xc2\x62\x3b\x2e\x30\xf2\x27\x72\x7e\x7a\x42\xa6\x25\xee\xc2\x13\x46\x83\x0e\xe2\xbc\x5d\x81\x3f\x88\x79\x13\xbf\x64\xdf\x65\xfd\xbd\x6c\x1f\x47\xa9\xa9\x29\x55\x4e\xab\x5d\x55\x94\xac\xcb\x9e\x7e\x24\xb3\x13\x42\x78\xc1\x2a\xc1\xc5\xab\x0a\xdd\x08\x97\x48\x4b\xd5\x09\x5a\x96\x54\x68\x5d\xef\xd3\xef\xf7\x5e\x83\xa6\xe5\x4f\x54\xb0\xec\x91\xf5\xde\xd2\xa9\xe3\xeb\x8a\x15\x59\xd3\xda\xc7\x9e\x8f\xe0\x5b\xd6\x09\xba\x6d\xc8\xb7\xc5\xdd\xf5\xe7\x45\x7a\x45\x3e\x5e\x7f\x26\xd7\x0b\x1f\x9a\xbd\x34\xbc\x65\x05\xf9\x78\x7b\x7b\x93\xce\x17\xe4\x2a\xfd\x34\xff\x76\x73\x4f\xfe\x22\x8d\x4f\xac\x95\xb9\x26\x9d\xd2\xcf\xe9\xd2\x38\xfa\xad\xbe\x2e\xaf\xbf\xcc\x97\xbf\x92\x5f\xd2\x5f\x67\x7e\x54\xa7\xe4\x76\x41\x2e\x6f\x17\x9f\x6e\xae\xe5\x1c\xf2\x79\x71\xbb\x4c\x4f\x4e\x2f\x4e\xae\x17\x77\xe9\xf2\x5e\xc2\xde\x3a\x2c\xcd\x06\x86\x12\xc8\x48\xe2\x32\x90\x80\xa1\x27\x76\xa8\xc9\x30\x9c\x64\x08\xfd\xf4\x44\x0b\xf8\x3b\x60\xf7\x19\x64\xe2\xff\x41\xa7\xcc\x90\x2a\x3f\x8d\x4c\x1e\x98\x36\x2d\x15\xf9\x86\x89\x8c\x57\x0f\x75\xf6\xf4\x37\x95\x3b\x7d\x27\xb2\xe6\x03\x9d\x59\xb3\x61\x5b\xd6\xd2\xd2\xcb\x8e\x61\xb8\x61\xfb\xee\x75\xbb\x65\xa2\xe5\x39\x9e\x36\x80\x9d\x30\x17\xbf\x2d\xae\xff\xf5\x2d\x9d\x99\x88\x92\x38\xb5\xbe\xfc\xcb\xf4\xeb\xcd\xfc\x32\x95\x9d\x7c\xba\x5d\xa6\xd7\x9f\x17\x32\x63\x88\x45\x3a\x25\xcb\xf4\x53\xba\x4c\x17\x97\xe9\x9d\x9b\x16\xbe\x24\xa7\x30\x81\x00\x5f\x6e\x64\x1e\x37\x6e\xa0\x1e\x09\x61\xdc\x43\xd2\xbc\x1f\xaa\xd7\xdc\x0f\xf1\xaf\x6f\x4c\x14\x35\xcf\x9a\x90\xc8\xf5\xc2\xf8\xf9\x0c\x81\xbc\xfc\xf1\x54\xae\xc2\xcb\xdb\x61\x95\x07\xc1\x5c\xb8\x36\xe3\xf4\x03\xcc\x4a\x2a\x04\xcd\x37\x5b\x56\x89\x2e\xab\xd8\xb3\xca\xca\x0d\xed\x36\x48\x06\x21\x13\x18\x9a\x6a\x79\x5d\x09\x56\x89\x4c\xbc\x36\x2c\x74\xe9\xf8\x6f\x2c\x98\xad\x3a\x41\x45\xf8\x54\x32\x65\x32\x9f\xe6\x39\xeb\x3a\x56\x64\x54\x04\x0d\x75\xee\xca\xb8\xd1\xdc\x84\xa9\x05\x06\xad\x1c\xa5\xf6\x09\x51\x5a\xbb\x03\x90\x33\xc7\x6f\x2c\xe9\x23\x4c\xd4\x12\x91\xb8\xb1\x98\x9c\x7a\x07\x46\x2f\xac\x13\xd4\x9b\x67\x1c\x47\x72\x07\xee\xe2\x64\x7e\x73\x9f\x2e\x23\x8a\x2f\xd3\xc5\xfc\x4b\x4a\x7c\x5a\x60\x9a\xac\xca\x3a\x7f\x64\x45\x26\xa9\xa1\xb9\x93\x2b\xcd\x6e\x55\xea\x39\x27\x36\xa5\xd8\x26\xa3\xc4\xc1\xba\x72\x31\x06\xc2\xed\x23\x5d\x18\xc0\x6f\x0a\x12\x61\x2c\x3e\x93\x58\xa4\x0e\x9d\xa1\xb3\xcf\x69\xbe\xa1\x22\xeb\x98\x10\xbc\x5a\x9b\xdd\x84\xa8\x1b\x9e\x87\x35\x55\xd2\x6a\xbd\xa3\x6b\x50\x4f\x66\xbd\xfe\xf0\xc1\xe1\x5b\x41\x8c\xa2\x1a\x46\xd0\xbb\x26\xa6\x37\xc3\x35\x78\xde\x53\x07\xbc\x7f\x98\x82\x70\x88\x19\x70\x56\x6f\x9b\x56\xef\x94\x0b\x9e\xcb\x89\x9d\xb6\x9c\xd9\x7c\x1c\x26\xf0\x90\x42\x38\x83\x07\xe9\xaa\x97\xff\x2e\x9a\xc8\x76\x71\x00\x58\xc1\xda\xb5\x2f\xcc\x7d\xdb\xa1\x21\x04\x43\xfc\x88\xb6\x5a\x8c\x48\x8f\x93\x88\x12\xc1\xf6\xab\x61\xdf\x98\x9d\xaa\x88\x35\x0b\x95\x56\x55\x93\xe5\xb4\xa1\x2b\x5e\x72\x31\xad\xca\xc1\x1a\xf2\xc0\xa8\xd8\xb5\x6c\x1a\xf5\xf1\xd0\x47\x28\x9f\x98\x38\x8e\x48\x01\xeb\x34\xe4\x42\xd8\xff\x34\x79\x10\xe2\xc2\x1c\x08\x5b\x04\xfa\x63\x20\xb8\xf6\xac\xca\xdb\xd7\x46\xd1\x3c\x28\xef\xcc\xfa\x81\x50\xcd\x43\x67\xde\x77\x10\x01\x8f\x5c\x87\xf0\x20\x9c\x95\x28\x21\xcd\x83\x95\x09\x3c\xf7\x95\xb0\x18\x53\xea\x60\x51\x71\x15\xac\x3d\xa2\x81\x0b\x80\x2b\xb0\xa5\xbc\xec\x58\x2b\x13\xcd\x14\x9f\x1d\x69\x58\x7e\xac\xaa\x0b\x16\x2d\x21\xeb\x19\xad\x19\xd0\xa1\x47\xb7\x02\x47\x09\x57\x16\x9f\x72\x07\x68\x4a\xce\x1d\x58\x9c\x74\xa7\x41\x84\x75\x0f\x02\xa7\xbd\x65\xff\xdb\xb1\x6e\xe4\x36\x4b\x6d\x4d\xc3\xc7\x2d\xcb\x19\x7f\xda\xbb\x61\x7e\x63\x45\xb8\xd1\x79\x02\xe9\x0d\xae\xd3\x33\xaa\x56\xd8\xcc\x97\x6e\xc0\x9f\x52\xb7\x01\x13\x17\x6d\xb0\x46\x14\xb3\xce\xb8\x5c\x6a\x7b\x64\xc5\x7a\xc7\xea\x14\xd9\xfe\x69\xbd\x8e\x5f\x82\x6c\x64\xfb\x16\x1f\xd5\xca\x48\x75\xa8\xa1\x2f\x96\xf2\x9d\x54\xaa\x1e\x11\x17\xca\x61\x3a\x94\x69\x70\x44\x45\x1a\x57\x4b\x15\xdd\x22\xd3\xd7\x33\x6d\x2b\xb9\x49\x0f\x2d\xef\x2a\xa4\xb0\x80\x64\xf7\x89\xe9\x0e\x2d\x1e\xbf\x89\xa7\xc5\x94\x2a\xe0\xfc\x47\x98\x0f\x38\x2f\x58\x5e\xbf\xba\x62\x29\xde\xeb\x8e\x0b\xec\xd8\xf1\x70\xe2\x0f\xae\xa3\xd8\x85\x9d\x1b\x6f\x98\xe8\xe0\xb9\x66\xc7\xf1\x9e\x82\x4f\x37\x1a\x9f\x53\x18\xa7\xc3\xab\xef\x04\xb8\xad\x9f\xab\x75\x4b\x0b\x67\x3b\x6c\xf3\x23\xcc\x50\x79\x2e\x11\x3e\x2d\x98\x60\xb9\x08\x17\x87\x80\x4b\xaf\x33\x2f\x57\x25\x70\xe2\x02\xa1\xe9\x1a\xb4\xd2\x9c\x19\xdc\x49\x38\x36\x68\x80\x61\xf3\x1c\xf2\xeb\x38\x68\x72\xaf\x17\x57\xe9\x77\xc2\x8b\x97\xcc\x1a\x33\x3b\x12\x99\x78\xd6\xe0\x11\xe1\x52\x00\xc5\x62\x55\x97\xb5\xac\xab\xcb\x9d\x4c\x34\xab\x58\xc5\xc4\x73\xdd\x3e\xca\x35\x00\xab\x06\x7c\x1a\x32\xe7\x61\x58\x89\x58\xc4\x7e\x1a\x19\x55\x2a\x48\x78\x01\x50\x7f\x60\x65\xc4\xc5\xcd\xbd\x4a\x00\x6e\x0a\x69\x01\xa4\xaf\x2f\x30\x02\x91\x03\x57\x4c\x1a\xb9\xb3\x65\x6f\x91\x88\x16\x85\x7c\x8d\x0d\xf5\x18\xad\x92\x46\x38\x42\x28\x34\x58\x0c\x73\x8f\x66\x5e\x0b\x57\xb6\x00\x7c\x3a\xf9\x02\x68\x4c\xc6\xa0\x11\x2a\x27\x02\x05\x65\x7d\x62\x65\xdd\xb0\x4c\xed\x32\x0f\x1d\x6a\x9b\x83\xe7\x40\xdf\x5d\x53\x50\x3b\x41\x06\x66\xad\xa7\x44\x0d\x36\x5f\x48\x08\xfa\x88\x59\xef\x7c\x2d\xb6\x91\x28\x62\x1f\x04\xf2\x00\xa7\x11\xc6\x83\x84\x82\x78\xc6\x40\x08\xe0\xea\x0b\xb0\x6e\xeb\x5d\x93\xa9\x77\x80\x46\x6f\x18\x24\xff\x5b\xd6\x75\x74\xcd\x22\xbb\x5e\x41\x4b\x94\xe6\x82\x95\xfc\x89\xc9\x0f\x99\xfb\xbf\x55\xb6\x8c\x1e\x6a\xa2\x15\xb3\x71\x8c\x2a\xbc\x70\x34\x0e\x82\xdc\x38\x08\x5a\x26\x36\xcc\x44\x45\x62\x54\x3d\xdc\xb4\xd7\xca\xef\x65\x0a\x7d\x7d\x44\x5f\x5e\xdf\x06\xd4\x85\x8e\xbe\xb8\xee\x1b\xc1\x14\x5f\xc0\xc7\x7e\xcd\xae\xe8\xaa\x44\xbe\x66\x9f\x4b\x8c\x82\x77\xca\x2a\xcb\x25\x8a\x82\xe6\x42\xf4\x4d\x67\x54\x6a\xb8\x3e\x87\xbe\x73\xbb\x9f\x9b\xfb\xb1\x24\x6e\xdc\x26\x61\xde\x0e\xd1\x27\x85\xeb\x35\xd5\x99\x3c\xc4\x84\x39\x31\x24\x39\xdd\x89\x4d\xed\x9c\x13\x61\x7a\xf7\x6d\xc2\xe7\x46\x90\x31\x57\x0b\x90\x0e\x67\x92\xe4\xfe\xff\x0e\x97\xc3\xa3\x7e\x94\xc0\x6d\x0a\x6a\x00\xa4\x5f\x66\xc0\x08\xea\x2c\x70\xc5\x49\xcd\x37\xb4\x5a\xeb\x29\x58\x4e\xa3\x82\xb6\x6b\x26\xd0\x5a\x8a\x71\xab\x3f\x45\x86\x06\x7d\x65\x0c\x3d\x33\xcd\xe5\xc7\xab\xa0\x0e\xb5\x4c\x26\x08\xc3\xf9\x98\x7a\x41\x86\x14\x22\x99\x2f\xa7\xc9\x10\x5e\xd2\xc7\x62\x64\x1d\xef\xe2\xcb\xae\xbb\x9d\x52\x76\x0d\x89\xcb\xae\x8d\x11\xd9\x8d\x2b\x2e\x7b\xcb\x68\x6e\x37\x41\x6f\x14\x9e\x6d\xeb\xff\xf2\xf0\x71\xcb\x44\x4b\xf3\x69\x84\x4f\xfa\x4e\x46\xcd\x97\xe8\xd8\xa2\x90\x89\x8d\x73\x44\x06\x44\x5c\xfc\x0c\x30\x1d\x4f\x99\x03\x06\x14\xcf\x02\x63\x8e\xe4\x81\xe3\x0e\x32\xa1\x2e\x58\xab\x76\x81\x32\x55\x2a\x56\x3a\xa9\x80\x9f\x95\xa8\x6f\xa7\x58\x7e\x68\x28\x2c\x45\xa4\xe9\xc0\xbe\xa9\xe4\x9d\x30\xb7\x30\x8e\xfe\xc4\x8c\x0f\x63\xf8\xd0\xac\x43\x4e\x6c\x88\xfd\x3f\x13\xd5\xab\x15\x7c\x4c\x6b\xad\x75\xd0\xdf\x24\x62\x07\xa8\x40\xed\xc0\x0e\xe5\x46\x00\x80\xde\x3b\x6d\x75\xf6\xcc\x51\x45\xb5\x0c\xda\x3e\x4e\x08\x1f\xdf\xf8\x0e\x1c\xeb\xff\x6b\x16\x6d\xe3\x49\xe8\xb3\x70\x80\x37\x6b\x80\x84\xb9\x2e\x3e\x53\x55\x2d\xf8\x03\xcf\xfb\xcd\x55\xd3\xb2\x07\xd6\xb2\x2a\x77\xd6\x48\xe7\x8d\xdd\xb9\xc3\x88\x91\x44\x2e\x7f\x4e\x2f\x7f\x21\x33\x75\xb5\xf4\xfc\x54\x56\x84\x03\x88\x94\x58\x30\x39\x42\x9a\xf7\x05\xa7\x36\x29\xce\x33\x38\xaf\x9d\x63\xd6\x9e\xdf\x18\xec\x14\xea\xc4\xb0\x7d\xa9\x62\xad\x80\x6e\x71\x30\x5f\xc4\x7a\x27\x56\xf5\x4b\xc6\x2a\xd1\x72\x5f\xba\x20\xd9\x37\xbc\x13\x75\xfb\x8a\x16\x42\xde\xb2\xe1\x4d\x19\xce\x62\x07\xcf\x62\x46\x16\x4e\x18\xa9\xd2\xd1\x46\x95\x38\x51\x80\x83\x97\x43\x0d\x7b\x79\xfc\x1e\xa6\x90\xd4\x47\xf4\x85\xf4\x6d\x40\x3e\xe8\xe8\x8b\xd6\xb0\xaa\xe0\xd5\x3a\xd3\x8b\xd7\xc1\xc3\x15\x56\x15\xac\x0d\x9f\x6b\xf7\xd0\x40\x85\x60\xdb\x46\x74\x7b\xde\xdc\xdc\x8f\x86\xd7\x0b\x74\x56\x44\xcf\x64\xb0\xd0\x87\x43\x19\x15\x66\x32\x84\x95\x98\x30\xf0\x2f\x99\xc7\x38\xf5\x5a\xc1\xbe\xa7\x50\x18\x62\xfa\x1a\x43\x2b\x50\x39\x74\xd6\x32\xf7\x87\xe0\x81\x77\x3f\x58\x39\x81\x42\xd3\xac\x37\x05\xe7\xde\xf2\xbe\x90\x9c\xc5\xec\x5d\xae\x49\x26\xe7\x01\xcd\x4f\x9d\x40\x6b\xac\x73\x55\xb4\xc3\x03\x23\xe6\xb9\x7d\xa6\xb5\x82\xbe\x93\x88\x05\x41\x81\x5a\xd0\x0c\xe5\x0a\xdd\x21\xdb\xb5\xbe\x4c\x1a\x54\xa6\x96\x2e\x28\x35\xdd\x30\xc3\x2b\xd7\x7d\xe5\x47\xe7\xdd\x91\xe7\x36\xba\x22\x41\xb1\x64\xfa\xae\xac\xdf\xc9\xa8\xc9\x18\x1f\xe9\xb8\x0e\x9c\x23\x14\xa3\xff\xb1\x8e\x43\x92\xc0\x28\xa6\xc9\x12\x88\x0a\xd3\x04\xda\x83\x3c\x09\x01\xfc\xc2\x0e\x11\xec\xc8\x6e\x17\x88\x7d\x66\xec\x61\x89\xef\xba\x4d\x96\x97\x5c\xde\x94\x56\xe7\xd6\xd3\x94\x78\xb8\x64\x07\x39\x80\x75\xac\xca\xdb\x5b\x7b\xcf\xdd\x25\x36\x08\x76\x12\xc1\x20\x28\xd0\x0b\x9a\xa1\x5c\xa1\x3b\xc2\x70\xcb\xd6\xbc\x13\xad\x3e\x7d\x1b\x28\xb6\xdf\x1f\xdf\x5a\xc1\x7b\xb7\x46\x00\xfe\x8d\xb5\x8a\x86\x7f\x10\x1b\xc8\x38\xae\xb9\xa3\xb2\xd7\xe3\x64\x32\x7b\xa8\x88\xce\x9e\x1d\x13\x1a\x00\xf8\x4a\xcb\x2f\x01\xc7\x7d\x32\x91\x1e\x0c\x79\xa1\xdf\x7b\x7c\x63\x01\x13\x0d\x30\xe6\xb8\x35\x08\x0e\xc1\x81\xaf\x32\xd1\x16\xfa\x0f\x67\x5c\xc8\x29\x34\xf2\x62\xf4\xe5\x09\xc2\x77\x94\x01\x6e\xbe\x28\x1d\x5b\xdb\xbf\x92\x88\xef\x72\x79\xf1\x12\xf0\xad\xee\xd5\x04\x4f\xf1\x92\x73\xb7\x8b\x11\xdd\x74\xe2\x17\x2f\xc1\xb6\xd6\x8d\x51\x6f\x67\x79\xf1\x22\x0f\x51\x77\x95\x7e\xc7\xd8\xb7\x8b\xdd\xdb\x76\xf8\x13\xd2\xf5\x64\x7f\x93\x32\x60\xf9\x02\x0d\x4f\x81\x36\xb6\x31\x94\x45\xfe\x59\x4d\x4b\xab\xce\x3d\x59\x8b\xeb\x53\xb7\x7c\xcd\x2b\x5a\x86\x96\x7c\x43\x79\x15\xfb\xbe\x1f\xdb\x38\xed\x9d\x37\x47\xff\x05\x12\x3a\x08\x2d\xe0\x10\x70\x62\x02\xb4\xaf\x1b\xde\xd4\x38\xae\xf9\x20\x23\xe8\x70\x1a\x3d\x01\x28\x14\x16\x98\x03\x85\x03\x77\xad\x74\xff\x1e\x12\xfa\x0f\x63\x95\xb3\x56\x60\x9d\x0d\xd6\x60\xab\xe2\xed\xab\x67\xfa\xef\xd6\x82\xa9\xf3\x89\x96\x3b\x16\x95\x76\xec\xdd\x3e\xb7\xaf\x99\xba\x1f\xa4\x70\x8d\x68\xf6\xd1\x20\xcc\x74\xef\x19\x03\x16\x94\xc1\x19\xbd\xcb\x3e\xfe\x32\xd1\x09\x9e\x3f\xb2\x36\xd3\x1f\x55\x0c\x67\xf6\x12\x09\x5a\x2f\x0d\xcd\xa3\x36\xbc\x30\xf7\x56\x92\x7b\x65\x45\x43\x27\x64\x7c\x79\x85\x63\x88\x23\x82\xaa\x3a\xd0\x4e\xab\xe6\x77\x30\x89\x78\x3e\x24\xd0\xd0\x37\x42\x29\xa1\xab\x5f\x46\xc0\x57\x8e\x59\x92\x08\x9e\x7b\x04\xc9\x36\x61\x19\x69\x07\xc9\x8a\xad\xa5\x03\x79\x11\x79\x8c\x8b\xaf\xf7\x76\x66\x31\x0c\xdc\x90\xec\x18\xb9\x1b\x0d\x82\x07\x20\xc3\x02\xe8\x86\x80\xe6\x04\xde\xd4\x4f\x0b\xd5\xc9\x94\x49\xa1\x00\xf1\x94\xb0\x6a\x84\x09\xa1\xdd\x80\x8c\xaf\x55\x9e\xa9\xcd\x98\x33\x21\xf2\xaa\x20\xff\x9e\x2f\x2f\x7f\x9e\xfb\x84\xf3\x62\xcf\x1e\x33\x26\xcf\x23\xaf\x8e\x10\xc6\x0f\xc7\x38\xc3\x2d\x25\x78\xae\x09\xb7\xce\x93\xd0\x6d\xe1\x00\xd9\xd6\x00\xa9\x76\x5d\x7c\xa2\x77\x95\xfe\x03\x6e\x6f\x0d\xdb\xbf\x5b\xe9\x7f\xe8\xa0\x3b\xa2\x66\x8e\xdd\x7a\x44\xa3\xd2\xdb\x0f\x1d\x01\xba\xd7\xf0\x6c\x3d\xa1\x28\xdc\x14\x5a\xa0\xc0\xbe\x2a\x68\x13\xa0\x4f\x04\xc6\x57\xea\x59\x96\xb1\xc8\x56\xb4\xa4\xea\x9c\xad\xa2\x4d\xb7\xa9\x9d\xad\xff\xb0\xb5\x42\x75\xd1\x57\x2a\x43\x69\x44\xfd\xc8\x2a\xe4\xf1\x70\xa6\x72\xbc\xca\x76\x8b\x67\xee\x71\xaa\x4e\xdc\xb3\xad\x31\x49\xb0\x6f\xc0\x63\x3a\x01\xd9\x31\xda\xa3\x17\x3e\xd6\xfb\x14\x69\x13\xc3\xf6\x33\x27\xd6\x0a\x24\x4f\x1c\x4c\xa7\x4f\xbf\x51\x8d\xa2\x99\xd1\x67\xf2\x2d\xf1\x76\x11\x6d\xe9\x70\x6e\x7c\x82\xf5\x57\x3b\xe7\xbb\x4e\xd4\xdb\x4c\x49\x62\x33\xd4\x2e\x51\xc7\xe5\xe8\xde\x74\x73\xd7\x3d\x8d\x70\x4c\x7a\x05\x91\x62\x80\x20\x95\xa2\x2d\xbc\xd4\xf1\x90\x27\x4c\x1b\x0f\x17\x4d\x19\xaf\x05\x9e\x2e\x00\x04\x15\x51\x2d\x09\x0f\xac\x7d\xef\x14\x83\xad\xcf\xea\x87\x07\xb2\x6a\xb7\x5d\xb1\x76\x92\x29\x66\xe4\x4a\x8e\x0d\x0d\x87\x4b\xbc\x18\x0f\x4f\x26\xb8\x87\x97\x11\xa6\xd7\x09\xb3\xc1\x60\xa2\x99\x60\xac\x78\x16\x38\xce\xd8\x64\x61\xbd\xfb\x81\xf9\x13\x84\xb1\x62\xfc\xf9\x4c\xf0\x22\x36\x51\x80\x2e\xde\x9b\x6a\xab\x43\xdb\xbe\x20\xd4\xb7\x65\x8d\x0d\x36\x3a\xf8\x7d\xb9\xb2\xb2\xbb\xc3\x08\xf4\xef\x91\x21\x1a\xfa\x40\xa2\x38\x3a\xc4\xf3\xc5\x40\x01\x51\x79\xe3\x7c\xd7\xc3\xea\x9e\x77\xdd\x2e\xfe\xea\xb4\xb7\xe8\xc7\x56\xf8\x10\x83\xfa\xdc\x62\xfa\x03\x05\x8c\xd8\xb4\x1e\xd2\x7d\x12\xf6\x25\x10\xe0\xda\xd0\xe3\x30\x2b\x9f\xf9\xbf\x92\x14\xfc\x8e\xe1\xd9\x19\xb9\x6a\xeb\xa6\xe1\xd5\xda\x5c\xd5\xd0\xa7\xd7\x1d\x29\xda\xba\xe9\xe4\x8f\x31\x12\xd1\xf2\xf5\x5a\xce\x2e\xf5\x83\xfa\x19\xc8\x87\x5d\x59\x9e\x09\xf6\x22\x08\xaf\x0a\xf6\x92\x90\xe7\x0d\xcf\x37\x84\xab\xdf\x63\x6c\xd9\x6a\xc7\x4b\x41\x56\xaf\xca\xb7\x91\x3f\x39\xd1\x09\xf9\x3d\xdc\xfe\x3c\xa3\x2a\x54\xd8\xe1\x5e\x79\x87\x1b\x5a\xa3\xef\x7f\xea\x17\xfb\xa8\x21\xf2\x0b\x4d\x3a\x98\x88\xb5\x65\x4d\x29\xff\x34\x71\xcf\x6b\xa1\xf7\xd4\x6c\x67\x02\x4b\xbd\x13\xeb\x5a\xd2\x8e\x5d\x43\x65\x05\xc7\x6e\xa8\xfa\x97\x41\xf4\xf2\xef\x45\x62\x5a\xf4\xbf\x89\xa3\x7f\xe2\xe0\x20\xd4\x51\x17\xd0\x31\xd9\x66\x6d\xfd\x3c\x1c\x0f\x68\xa5\xec\x8d\x56\x4d\x38\xfc\x59\x28\x97\x69\x79\xf4\xde\x33\xab\x5f\x70\xbd\xbd\xf3\xc0\x55\xa2\x89\xd1\xfb\xeb\x2e\xb1\x03\x34\xe5\xf7\x47\x07\xd2\x17\x30\xe4\x68\x8a\xb2\x87\x98\xfe\x0c\x00\xad\x60\x32\x08\x9d\x75\x29\xf6\x7b\xf8\xc0\x5b\x89\xa8\x94\x90\x19\x01\xcd\xc3\xfd\x49\xc3\x11\x2f\xfc\x58\xe1\x48\x2e\x4e\xfe\x3f\x00\xa9\x2d\x04\x8e\x52\x56\x00\x00")
+
+func _1547295600_drop_account_namespaceUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1547295600_drop_account_namespaceUpSql,
+		"1547295600_drop_account_namespace.up.sql",
+	)
+}
+
+func _1547295600_drop_account_namespaceUpSql() (*asset, error) {
+	bytes, err := _1547295600_drop_account_namespaceUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1547295600_drop_account_namespace.up.sql", size: 22098, mode: os.FileMode(420), modTime: time.Unix(1547295600, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1547382000_add_segments_senderDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\x28\x4e\x4d\xcf\x4d\xcd\x2b\x29\xb6\xe6\x72\x0e\x72\x75\x0c\x71\x45\x13\x56\xd0\xe0\x52\x50\xc8\x48\x2c\xce\x50\x70\xf2\xf1\x77\x52\xf0\xf3\x0f\x51\xf0\x0b\xf5\xf1\xd1\xe1\x52\x50\xc8\x4c\xa9\x50\xf0\xf4\x0b\x41\x11\x4b\xce\x2f\xcd\x2b\xc1\x10\x4d\x49\x2c\x49\xc4\xd4\x5f\x94\x9a\x9c\x9a\x59\x96\x9a\x12\x9f\x88\xa9\x23\xd4\xcf\x33\x30\xd4\x55\x03\x64\xb1\x8e\x42\x66\x4a\x85\x26\x97\xa6\x35\x17\x60\x00\x11\x72\x6e\x49\xb6\x00\x00\x00")
+
+func _1547382000_add_segments_senderDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1547382000_add_segments_senderDownSql,
+		"1547382000_add_segments_sender.down.sql",
+	)
+}
+
+func _1547382000_add_segments_senderDownSql() (*asset, error) {
+	bytes, err := _1547382000_add_segments_senderDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1547382000_add_segments_sender.down.sql", size: 182, mode: os.FileMode(420), modTime: time.Unix(1547382000, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __1547382000_add_segments_senderUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\x8f\xc1\x6a\xc3\x30\x10\x44\xef\xfa\x8a\x39\x26\x60\xf7\x03\x9a\x53\xec\xba\xc5\x20\xa4\xd4\xc8\xe7\xb2\xc9\xae\x63\x83\x2d\x05\xc9\x0d\xe9\xdf\x97\xa6\x4d\x21\xf1\xf5\x31\x33\xfb\x36\xcf\xb1\xa3\xaf\x31\x10\x27\x50\x14\x44\xa1\x94\x64\xda\x8f\xc2\xe8\x62\x98\x30\xf7\x82\x24\xc7\x49\xfc\x9c\x10\x3a\x10\xd2\xe0\x8f\xe3\x0f\xf4\x2c\xf1\xf9\x3e\x10\xe5\x20\xc3\x59\x58\xe5\x39\xf6\xd2\x85\x28\xe8\xe9\x2c\xf0\xe1\x2f\x0f\xf2\x7c\x3d\xc4\x31\x9c\x4e\xc2\x4f\xea\xa5\xb1\x3b\xb8\x6d\xa1\xab\xff\x99\x8d\x2a\x9b\x6a\xeb\xaa\x07\x8c\x95\xc2\x6d\xa6\xd0\xb6\x80\xb1\x0e\xa6\xd5\x3a\x53\x40\x4f\xa9\x5f\xd2\x81\x2f\xa8\x8d\xbb\x63\x87\xf0\xe9\xe7\x05\x65\x9a\x69\xd9\xbf\xfd\xf3\x41\xcb\x46\x6b\xea\xf7\xb6\x5a\xfd\x0a\x65\x57\x81\x0c\x03\x5f\xd6\xb0\x06\xa5\x35\xaf\xba\x2e\x1d\xea\x37\x63\x9b\x4a\xad\x37\xea\x7b\x00\xc9\x50\x9a\x13\x6b\x01\x00\x00")
+
+func _1547382000_add_segments_senderUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1547382000_add_segments_senderUpSql,
+		"1547382000_add_segments_sender.up.sql",
+	)
+}
+
+func _1547382000_add_segments_senderUpSql() (*asset, error) {
+	bytes, err := _1547382000_add_segments_senderUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1547382000_add_segments_sender.up.sql", size: 363, mode: os.FileMode(420), modTime: time.Unix(1547382000, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _staticGo = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x54\xcc\x41\x8a\x02\x31\x10\x46\xe1\x7d\x4e\xf1\x2f\x67\x60\x3a\xb5\x9f\x13\x0c\x83\x82\xa0\x17\xa8\x4e\x17\x95\xa2\xe9\xa4\x49\x95\xe2\xf1\xdd\x28\xe2\xf2\xc1\xe3\x23\xc2\x89\xcb\xca\x2a\xf0\xe0\xb0\x02\xd9\x66\x59\xfc\x55\x5f\xff\xe7\x1f\xfc\x5d\x8e\x87\x6f\x0c\xf1\x7e\x1d\x45\x1c\xc3\xb4\x06\xac\x45\x47\x54\xc1\x6c\x8d\x87\x89\xa7\xfd\x43\x4a\x89\x48\xfb\xaf\x4a\x93\xc1\x21\xd0\x3e\xcd\xd6\x16\x0e\xc6\xb4\xaf\x8a\xcd\x74\x70\x58\x6f\x8e\xa9\x23\x67\xca\x99\x5c\xc6\xcd\x8a\x38\x79\xad\x72\x0f\x2a\x95\x83\xde\x23\x3d\x81\xac\x1d\x39\x3d\x02\x00\x00\xff\xff\x7c\xfc\xfc\x0b\xbc\x00\x00\x00")
 
 func staticGoBytes() ([]byte, error) {
@@ -316,6 +1996,86 @@ var _bindata = map[string]func() (*asset, error){
 	"1540715431_add_version.up.sql": _1540715431_add_versionUpSql,
 	"1541164797_add_installations.down.sql": _1541164797_add_installationsDownSql,
 	"1541164797_add_installations.up.sql": _1541164797_add_installationsUpSql,
+	"1542208893_add_chat_settings.down.sql": _1542208893_add_chat_settingsDownSql,
+	"1542208893_add_chat_settings.up.sql": _1542208893_add_chat_settingsUpSql,
+	"1542637421_add_account_namespace.down.sql": _1542637421_add_account_namespaceDownSql,
+	"1542637421_add_account_namespace.up.sql": _1542637421_add_account_namespaceUpSql,
+	"1543165882_add_group_receipts.down.sql": _1543165882_add_group_receiptsDownSql,
+	"1543165882_add_group_receipts.up.sql": _1543165882_add_group_receiptsUpSql,
+	"1543501293_add_installations_disabled_at.down.sql": _1543501293_add_installations_disabled_atDownSql,
+	"1543501293_add_installations_disabled_at.up.sql": _1543501293_add_installations_disabled_atUpSql,
+	"1543919112_add_processed_messages.down.sql": _1543919112_add_processed_messagesDownSql,
+	"1543919112_add_processed_messages.up.sql": _1543919112_add_processed_messagesUpSql,
+	"1544190315_add_moderated_channels.down.sql": _1544190315_add_moderated_channelsDownSql,
+	"1544190315_add_moderated_channels.up.sql": _1544190315_add_moderated_channelsUpSql,
+	"1544531725_add_envelope_states.down.sql": _1544531725_add_envelope_statesDownSql,
+	"1544531725_add_envelope_states.up.sql": _1544531725_add_envelope_statesUpSql,
+	"1544617200_add_contact_mailservers.down.sql": _1544617200_add_contact_mailserversDownSql,
+	"1544617200_add_contact_mailservers.up.sql": _1544617200_add_contact_mailserversUpSql,
+	"1544703600_add_compression_dictionaries.down.sql": _1544703600_add_compression_dictionariesDownSql,
+	"1544703600_add_compression_dictionaries.up.sql": _1544703600_add_compression_dictionariesUpSql,
+	"1544790000_add_pending_messages.down.sql": _1544790000_add_pending_messagesDownSql,
+	"1544790000_add_pending_messages.up.sql": _1544790000_add_pending_messagesUpSql,
+	"1544876400_add_contact_topics.down.sql": _1544876400_add_contact_topicsDownSql,
+	"1544876400_add_contact_topics.up.sql": _1544876400_add_contact_topicsUpSql,
+	"1544962800_add_contact_capabilities.down.sql": _1544962800_add_contact_capabilitiesDownSql,
+	"1544962800_add_contact_capabilities.up.sql": _1544962800_add_contact_capabilitiesUpSql,
+	"1545049200_add_segments.down.sql": _1545049200_add_segmentsDownSql,
+	"1545049200_add_segments.up.sql": _1545049200_add_segmentsUpSql,
+	"1545135600_add_notification_preferences.down.sql": _1545135600_add_notification_preferencesDownSql,
+	"1545135600_add_notification_preferences.up.sql": _1545135600_add_notification_preferencesUpSql,
+	"1545222000_add_attachments.down.sql": _1545222000_add_attachmentsDownSql,
+	"1545222000_add_attachments.up.sql": _1545222000_add_attachmentsUpSql,
+	"1545308400_add_message_changes.down.sql": _1545308400_add_message_changesDownSql,
+	"1545308400_add_message_changes.up.sql": _1545308400_add_message_changesUpSql,
+	"1545394800_add_history_messages.down.sql": _1545394800_add_history_messagesDownSql,
+	"1545394800_add_history_messages.up.sql": _1545394800_add_history_messagesUpSql,
+	"1545481200_add_contacts.down.sql": _1545481200_add_contactsDownSql,
+	"1545481200_add_contacts.up.sql": _1545481200_add_contactsUpSql,
+	"1545567600_add_presence.down.sql": _1545567600_add_presenceDownSql,
+	"1545567600_add_presence.up.sql": _1545567600_add_presenceUpSql,
+	"1545654000_add_contact_requests.down.sql": _1545654000_add_contact_requestsDownSql,
+	"1545654000_add_contact_requests.up.sql": _1545654000_add_contact_requestsUpSql,
+	"1545740400_add_blocked_contacts.down.sql": _1545740400_add_blocked_contactsDownSql,
+	"1545740400_add_blocked_contacts.up.sql": _1545740400_add_blocked_contactsUpSql,
+	"1545826800_add_downgrades.down.sql": _1545826800_add_downgradesDownSql,
+	"1545826800_add_downgrades.up.sql": _1545826800_add_downgradesUpSql,
+	"1545913200_add_settings.down.sql": _1545913200_add_settingsDownSql,
+	"1545913200_add_settings.up.sql": _1545913200_add_settingsUpSql,
+	"1545999600_add_sync_clocks.down.sql": _1545999600_add_sync_clocksDownSql,
+	"1545999600_add_sync_clocks.up.sql": _1545999600_add_sync_clocksUpSql,
+	"1546086000_add_unsigned_transactions.down.sql": _1546086000_add_unsigned_transactionsDownSql,
+	"1546086000_add_unsigned_transactions.up.sql": _1546086000_add_unsigned_transactionsUpSql,
+	"1546172400_add_outbox_entries.down.sql": _1546172400_add_outbox_entriesDownSql,
+	"1546172400_add_outbox_entries.up.sql": _1546172400_add_outbox_entriesUpSql,
+	"1546258800_add_wallet_transfers.down.sql": _1546258800_add_wallet_transfersDownSql,
+	"1546258800_add_wallet_transfers.up.sql": _1546258800_add_wallet_transfersUpSql,
+	"1546345200_add_wallet_custom_tokens.down.sql": _1546345200_add_wallet_custom_tokensDownSql,
+	"1546345200_add_wallet_custom_tokens.up.sql": _1546345200_add_wallet_custom_tokensUpSql,
+	"1546431600_add_decoy_topics.down.sql": _1546431600_add_decoy_topicsDownSql,
+	"1546431600_add_decoy_topics.up.sql": _1546431600_add_decoy_topicsUpSql,
+	"1546518000_add_wallet_transfers_chain_id.down.sql": _1546518000_add_wallet_transfers_chain_idDownSql,
+	"1546518000_add_wallet_transfers_chain_id.up.sql": _1546518000_add_wallet_transfers_chain_idUpSql,
+	"1546604400_add_wallet_balance_snapshots.down.sql": _1546604400_add_wallet_balance_snapshotsDownSql,
+	"1546604400_add_wallet_balance_snapshots.up.sql": _1546604400_add_wallet_balance_snapshotsUpSql,
+	"1546690800_add_ens_cache.down.sql": _1546690800_add_ens_cacheDownSql,
+	"1546690800_add_ens_cache.up.sql": _1546690800_add_ens_cacheUpSql,
+	"1546777200_add_sticker_packs.down.sql": _1546777200_add_sticker_packsDownSql,
+	"1546777200_add_sticker_packs.up.sql": _1546777200_add_sticker_packsUpSql,
+	"1546863600_add_push_notifications.down.sql": _1546863600_add_push_notificationsDownSql,
+	"1546863600_add_push_notifications.up.sql": _1546863600_add_push_notificationsUpSql,
+	"1546950000_add_wipes.down.sql": _1546950000_add_wipesDownSql,
+	"1546950000_add_wipes.up.sql": _1546950000_add_wipesUpSql,
+	"1547036400_namespace_bundles_installations.down.sql": _1547036400_namespace_bundles_installationsDownSql,
+	"1547036400_namespace_bundles_installations.up.sql": _1547036400_namespace_bundles_installationsUpSql,
+	"1547122800_add_sent_transactions.down.sql": _1547122800_add_sent_transactionsDownSql,
+	"1547122800_add_sent_transactions.up.sql": _1547122800_add_sent_transactionsUpSql,
+	"1547209200_encrypt_history_messages.down.sql": _1547209200_encrypt_history_messagesDownSql,
+	"1547209200_encrypt_history_messages.up.sql": _1547209200_encrypt_history_messagesUpSql,
+	"1547295600_drop_account_namespace.down.sql": _1547295600_drop_account_namespaceDownSql,
+	"1547295600_drop_account_namespace.up.sql": _1547295600_drop_account_namespaceUpSql,
+	"1547382000_add_segments_sender.down.sql": _1547382000_add_segments_senderDownSql,
+	"1547382000_add_segments_sender.up.sql": _1547382000_add_segments_senderUpSql,
 	"static.go": staticGo,
 }
 
@@ -367,6 +2127,86 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"1540715431_add_version.up.sql": &bintree{_1540715431_add_versionUpSql, map[string]*bintree{}},
 	"1541164797_add_installations.down.sql": &bintree{_1541164797_add_installationsDownSql, map[string]*bintree{}},
 	"1541164797_add_installations.up.sql": &bintree{_1541164797_add_installationsUpSql, map[string]*bintree{}},
+	"1542208893_add_chat_settings.down.sql": &bintree{_1542208893_add_chat_settingsDownSql, map[string]*bintree{}},
+	"1542208893_add_chat_settings.up.sql": &bintree{_1542208893_add_chat_settingsUpSql, map[string]*bintree{}},
+	"1542637421_add_account_namespace.down.sql": &bintree{_1542637421_add_account_namespaceDownSql, map[string]*bintree{}},
+	"1542637421_add_account_namespace.up.sql": &bintree{_1542637421_add_account_namespaceUpSql, map[string]*bintree{}},
+	"1543165882_add_group_receipts.down.sql": &bintree{_1543165882_add_group_receiptsDownSql, map[string]*bintree{}},
+	"1543165882_add_group_receipts.up.sql": &bintree{_1543165882_add_group_receiptsUpSql, map[string]*bintree{}},
+	"1543501293_add_installations_disabled_at.down.sql": &bintree{_1543501293_add_installations_disabled_atDownSql, map[string]*bintree{}},
+	"1543501293_add_installations_disabled_at.up.sql": &bintree{_1543501293_add_installations_disabled_atUpSql, map[string]*bintree{}},
+	"1543919112_add_processed_messages.down.sql": &bintree{_1543919112_add_processed_messagesDownSql, map[string]*bintree{}},
+	"1543919112_add_processed_messages.up.sql": &bintree{_1543919112_add_processed_messagesUpSql, map[string]*bintree{}},
+	"1544190315_add_moderated_channels.down.sql": &bintree{_1544190315_add_moderated_channelsDownSql, map[string]*bintree{}},
+	"1544190315_add_moderated_channels.up.sql": &bintree{_1544190315_add_moderated_channelsUpSql, map[string]*bintree{}},
+	"1544531725_add_envelope_states.down.sql": &bintree{_1544531725_add_envelope_statesDownSql, map[string]*bintree{}},
+	"1544531725_add_envelope_states.up.sql": &bintree{_1544531725_add_envelope_statesUpSql, map[string]*bintree{}},
+	"1544617200_add_contact_mailservers.down.sql": &bintree{_1544617200_add_contact_mailserversDownSql, map[string]*bintree{}},
+	"1544617200_add_contact_mailservers.up.sql": &bintree{_1544617200_add_contact_mailserversUpSql, map[string]*bintree{}},
+	"1544703600_add_compression_dictionaries.down.sql": &bintree{_1544703600_add_compression_dictionariesDownSql, map[string]*bintree{}},
+	"1544703600_add_compression_dictionaries.up.sql": &bintree{_1544703600_add_compression_dictionariesUpSql, map[string]*bintree{}},
+	"1544790000_add_pending_messages.down.sql": &bintree{_1544790000_add_pending_messagesDownSql, map[string]*bintree{}},
+	"1544790000_add_pending_messages.up.sql": &bintree{_1544790000_add_pending_messagesUpSql, map[string]*bintree{}},
+	"1544876400_add_contact_topics.down.sql": &bintree{_1544876400_add_contact_topicsDownSql, map[string]*bintree{}},
+	"1544876400_add_contact_topics.up.sql": &bintree{_1544876400_add_contact_topicsUpSql, map[string]*bintree{}},
+	"1544962800_add_contact_capabilities.down.sql": &bintree{_1544962800_add_contact_capabilitiesDownSql, map[string]*bintree{}},
+	"1544962800_add_contact_capabilities.up.sql": &bintree{_1544962800_add_contact_capabilitiesUpSql, map[string]*bintree{}},
+	"1545049200_add_segments.down.sql": &bintree{_1545049200_add_segmentsDownSql, map[string]*bintree{}},
+	"1545049200_add_segments.up.sql": &bintree{_1545049200_add_segmentsUpSql, map[string]*bintree{}},
+	"1545135600_add_notification_preferences.down.sql": &bintree{_1545135600_add_notification_preferencesDownSql, map[string]*bintree{}},
+	"1545135600_add_notification_preferences.up.sql": &bintree{_1545135600_add_notification_preferencesUpSql, map[string]*bintree{}},
+	"1545222000_add_attachments.down.sql": &bintree{_1545222000_add_attachmentsDownSql, map[string]*bintree{}},
+	"1545222000_add_attachments.up.sql": &bintree{_1545222000_add_attachmentsUpSql, map[string]*bintree{}},
+	"1545308400_add_message_changes.down.sql": &bintree{_1545308400_add_message_changesDownSql, map[string]*bintree{}},
+	"1545308400_add_message_changes.up.sql": &bintree{_1545308400_add_message_changesUpSql, map[string]*bintree{}},
+	"1545394800_add_history_messages.down.sql": &bintree{_1545394800_add_history_messagesDownSql, map[string]*bintree{}},
+	"1545394800_add_history_messages.up.sql": &bintree{_1545394800_add_history_messagesUpSql, map[string]*bintree{}},
+	"1545481200_add_contacts.down.sql": &bintree{_1545481200_add_contactsDownSql, map[string]*bintree{}},
+	"1545481200_add_contacts.up.sql": &bintree{_1545481200_add_contactsUpSql, map[string]*bintree{}},
+	"1545567600_add_presence.down.sql": &bintree{_1545567600_add_presenceDownSql, map[string]*bintree{}},
+	"1545567600_add_presence.up.sql": &bintree{_1545567600_add_presenceUpSql, map[string]*bintree{}},
+	"1545654000_add_contact_requests.down.sql": &bintree{_1545654000_add_contact_requestsDownSql, map[string]*bintree{}},
+	"1545654000_add_contact_requests.up.sql": &bintree{_1545654000_add_contact_requestsUpSql, map[string]*bintree{}},
+	"1545740400_add_blocked_contacts.down.sql": &bintree{_1545740400_add_blocked_contactsDownSql, map[string]*bintree{}},
+	"1545740400_add_blocked_contacts.up.sql": &bintree{_1545740400_add_blocked_contactsUpSql, map[string]*bintree{}},
+	"1545826800_add_downgrades.down.sql": &bintree{_1545826800_add_downgradesDownSql, map[string]*bintree{}},
+	"1545826800_add_downgrades.up.sql": &bintree{_1545826800_add_downgradesUpSql, map[string]*bintree{}},
+	"1545913200_add_settings.down.sql": &bintree{_1545913200_add_settingsDownSql, map[string]*bintree{}},
+	"1545913200_add_settings.up.sql": &bintree{_1545913200_add_settingsUpSql, map[string]*bintree{}},
+	"1545999600_add_sync_clocks.down.sql": &bintree{_1545999600_add_sync_clocksDownSql, map[string]*bintree{}},
+	"1545999600_add_sync_clocks.up.sql": &bintree{_1545999600_add_sync_clocksUpSql, map[string]*bintree{}},
+	"1546086000_add_unsigned_transactions.down.sql": &bintree{_1546086000_add_unsigned_transactionsDownSql, map[string]*bintree{}},
+	"1546086000_add_unsigned_transactions.up.sql": &bintree{_1546086000_add_unsigned_transactionsUpSql, map[string]*bintree{}},
+	"1546172400_add_outbox_entries.down.sql": &bintree{_1546172400_add_outbox_entriesDownSql, map[string]*bintree{}},
+	"1546172400_add_outbox_entries.up.sql": &bintree{_1546172400_add_outbox_entriesUpSql, map[string]*bintree{}},
+	"1546258800_add_wallet_transfers.down.sql": &bintree{_1546258800_add_wallet_transfersDownSql, map[string]*bintree{}},
+	"1546258800_add_wallet_transfers.up.sql": &bintree{_1546258800_add_wallet_transfersUpSql, map[string]*bintree{}},
+	"1546345200_add_wallet_custom_tokens.down.sql": &bintree{_1546345200_add_wallet_custom_tokensDownSql, map[string]*bintree{}},
+	"1546345200_add_wallet_custom_tokens.up.sql": &bintree{_1546345200_add_wallet_custom_tokensUpSql, map[string]*bintree{}},
+	"1546431600_add_decoy_topics.down.sql": &bintree{_1546431600_add_decoy_topicsDownSql, map[string]*bintree{}},
+	"1546431600_add_decoy_topics.up.sql": &bintree{_1546431600_add_decoy_topicsUpSql, map[string]*bintree{}},
+	"1546518000_add_wallet_transfers_chain_id.down.sql": &bintree{_1546518000_add_wallet_transfers_chain_idDownSql, map[string]*bintree{}},
+	"1546518000_add_wallet_transfers_chain_id.up.sql": &bintree{_1546518000_add_wallet_transfers_chain_idUpSql, map[string]*bintree{}},
+	"1546604400_add_wallet_balance_snapshots.down.sql": &bintree{_1546604400_add_wallet_balance_snapshotsDownSql, map[string]*bintree{}},
+	"1546604400_add_wallet_balance_snapshots.up.sql": &bintree{_1546604400_add_wallet_balance_snapshotsUpSql, map[string]*bintree{}},
+	"1546690800_add_ens_cache.down.sql": &bintree{_1546690800_add_ens_cacheDownSql, map[string]*bintree{}},
+	"1546690800_add_ens_cache.up.sql": &bintree{_1546690800_add_ens_cacheUpSql, map[string]*bintree{}},
+	"1546777200_add_sticker_packs.down.sql": &bintree{_1546777200_add_sticker_packsDownSql, map[string]*bintree{}},
+	"1546777200_add_sticker_packs.up.sql": &bintree{_1546777200_add_sticker_packsUpSql, map[string]*bintree{}},
+	"1546863600_add_push_notifications.down.sql": &bintree{_1546863600_add_push_notificationsDownSql, map[string]*bintree{}},
+	"1546863600_add_push_notifications.up.sql": &bintree{_1546863600_add_push_notificationsUpSql, map[string]*bintree{}},
+	"1546950000_add_wipes.down.sql": &bintree{_1546950000_add_wipesDownSql, map[string]*bintree{}},
+	"1546950000_add_wipes.up.sql": &bintree{_1546950000_add_wipesUpSql, map[string]*bintree{}},
+	"1547036400_namespace_bundles_installations.down.sql": &bintree{_1547036400_namespace_bundles_installationsDownSql, map[string]*bintree{}},
+	"1547036400_namespace_bundles_installations.up.sql": &bintree{_1547036400_namespace_bundles_installationsUpSql, map[string]*bintree{}},
+	"1547122800_add_sent_transactions.down.sql": &bintree{_1547122800_add_sent_transactionsDownSql, map[string]*bintree{}},
+	"1547122800_add_sent_transactions.up.sql": &bintree{_1547122800_add_sent_transactionsUpSql, map[string]*bintree{}},
+	"1547209200_encrypt_history_messages.down.sql": &bintree{_1547209200_encrypt_history_messagesDownSql, map[string]*bintree{}},
+	"1547209200_encrypt_history_messages.up.sql": &bintree{_1547209200_encrypt_history_messagesUpSql, map[string]*bintree{}},
+	"1547295600_drop_account_namespace.down.sql": &bintree{_1547295600_drop_account_namespaceDownSql, map[string]*bintree{}},
+	"1547295600_drop_account_namespace.up.sql": &bintree{_1547295600_drop_account_namespaceUpSql, map[string]*bintree{}},
+	"1547382000_add_segments_sender.down.sql": &bintree{_1547382000_add_segments_senderDownSql, map[string]*bintree{}},
+	"1547382000_add_segments_sender.up.sql": &bintree{_1547382000_add_segments_senderUpSql, map[string]*bintree{}},
 	"static.go": &bintree{staticGo, map[string]*bintree{}},
 }}
 
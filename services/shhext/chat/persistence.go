@@ -3,7 +3,24 @@ package chat
 import (
 	"crypto/ecdsa"
 
+	"github.com/ethereum/go-ethereum/common"
 	dr "github.com/status-im/doubleratchet"
+	"github.com/status-im/status-go/services/shhext/attachments"
+	"github.com/status-im/status-go/services/shhext/chat/capabilities"
+	"github.com/status-im/status-go/services/shhext/consent"
+	"github.com/status-im/status-go/services/shhext/contacts"
+	"github.com/status-im/status-go/services/shhext/content"
+	"github.com/status-im/status-go/services/shhext/delivery"
+	"github.com/status-im/status-go/services/shhext/downgrade"
+	"github.com/status-im/status-go/services/shhext/history"
+	"github.com/status-im/status-go/services/shhext/inbox"
+	"github.com/status-im/status-go/services/shhext/moderation"
+	"github.com/status-im/status-go/services/shhext/notifications"
+	"github.com/status-im/status-go/services/shhext/outbox"
+	"github.com/status-im/status-go/services/shhext/presence"
+	"github.com/status-im/status-go/services/shhext/receipts"
+	"github.com/status-im/status-go/services/shhext/segmentation"
+	whisper "github.com/status-im/whisper/whisperv6"
 )
 
 // RatchetInfo holds the current ratchet state
@@ -18,6 +35,15 @@ type RatchetInfo struct {
 	InstallationID string
 }
 
+// InstallationsCleanup holds the number of rows deleted when cleaning up disabled installations.
+type InstallationsCleanup struct {
+	Installations int `json:"installations"`
+	Bundles       int `json:"bundles"`
+	RatchetInfos  int `json:"ratchetInfos"`
+	Sessions      int `json:"sessions"`
+	SkippedKeys   int `json:"skippedKeys"`
+}
+
 // PersistenceService defines the interface for a storage service
 type PersistenceService interface {
 	// GetKeysStorage returns the associated double ratchet KeysStorage object.
@@ -57,4 +83,209 @@ type PersistenceService interface {
 	EnableInstallation(identity []byte, installationID string) error
 	// DisableInstallation disable the installation.
 	DisableInstallation(identity []byte, installationID string) error
+	// SaveModeratedChannel persists the moderation state of a public channel.
+	SaveModeratedChannel(moderation.Channel) error
+	// GetModeratedChannel returns the moderation state of a public channel, if any.
+	GetModeratedChannel(topic []byte) (*moderation.Channel, error)
+
+	// IsDuplicate returns true if a message was already processed.
+	IsDuplicate(sender []byte, messageHash []byte, installationID string) (bool, error)
+	// MarkProcessed records that a message was processed at a timestamp in milliseconds.
+	MarkProcessed(sender []byte, messageHash []byte, installationID string, timestamp int64) error
+	// PruneProcessed deletes the messages processed before a timestamp in milliseconds.
+	PruneProcessed(before int64) (int, error)
+
+	// CleanupInstallations deletes the state of installations disabled before a unix timestamp.
+	CleanupInstallations(identity []byte, disabledBefore int64) (*InstallationsCleanup, error)
+
+	// SetChatLanguage sets the language messages on a given topic are translated to.
+	SetChatLanguage(topic []byte, language string) error
+	// GetChatLanguage returns the language set for a given topic, if any.
+	GetChatLanguage(topic []byte) (string, error)
+
+	// SaveReceiptSummary persists the aggregated receipts of a group message.
+	SaveReceiptSummary(receipts.Summary) error
+	// GetReceiptSummary returns the aggregated receipts of a group message, if any.
+	GetReceiptSummary(messageID string) (*receipts.Summary, error)
+
+	// SaveEnvelopeState persists the delivery state of an envelope, unless it already reached a later one.
+	SaveEnvelopeState(delivery.Status) error
+	// GetEnvelopeStates returns the persisted delivery states of the given envelopes.
+	GetEnvelopeStates(hashes []common.Hash) ([]delivery.Status, error)
+
+	// SaveContactMailServer persists the enode of the mailserver used by a contact, or removes it if empty.
+	SaveContactMailServer(publicKey []byte, enode string) error
+	// GetContactMailServer returns the enode of the mailserver used by a contact, or an empty string.
+	GetContactMailServer(publicKey []byte) (string, error)
+
+	// SetCompressionDictionaries persists the versions of the compression dictionaries supported by an installation.
+	SetCompressionDictionaries(identity []byte, installationID string, versions []uint32) error
+	// GetCompressionDictionaries returns the versions of the compression dictionaries supported
+	// by the given installations, indexed by installation ID. Installations that never advertised
+	// any are missing.
+	GetCompressionDictionaries(identity []byte, installationIDs []string) (map[string][]uint32, error)
+
+	// SetContactTopic persists the topic an installation listens on for direct messages.
+	SetContactTopic(identity []byte, installationID string, topic []byte) error
+	// GetContactTopics returns the topics the given installations listen on for direct messages,
+	// indexed by installation ID. Installations whose topic is unknown are missing.
+	GetContactTopics(identity []byte, installationIDs []string) (map[string][]byte, error)
+
+	// SetContactCapabilities persists the protocol version and features supported by an installation.
+	SetContactCapabilities(identity []byte, installationID string, set capabilities.Set) error
+	// GetContactCapabilities returns the capabilities of the given installations, indexed by
+	// installation ID. Installations that never advertised any are missing.
+	GetContactCapabilities(identity []byte, installationIDs []string) (map[string]capabilities.Set, error)
+
+	// SavePendingMessage persists a message that couldn't be decrypted yet, replacing it if it exists.
+	SavePendingMessage(inbox.PendingMessage) error
+	// GetPendingMessages returns the pending messages of a sender, oldest first.
+	GetPendingMessages(sender []byte) ([]inbox.PendingMessage, error)
+	// DeletePendingMessage deletes a pending message.
+	DeletePendingMessage(hash []byte) error
+	// CountPendingMessages returns the number of pending messages.
+	CountPendingMessages() (int, error)
+	// PrunePendingMessages deletes the messages received before the given time, in milliseconds.
+	PrunePendingMessages(before int64) (int, error)
+
+	// SaveSegment persists a segment of a payload, unless its sender already sent the
+	// segment with the same index.
+	SaveSegment(segmentation.Segment) error
+	// GetSegments returns the segments of a payload sent by a sender.
+	GetSegments(sender, hash []byte) ([]segmentation.Segment, error)
+	// DeleteSegments deletes the segments of a payload sent by a sender.
+	DeleteSegments(sender, hash []byte) error
+	// GetPendingSegments returns the number of incomplete payloads of a sender and the
+	// size of their segments.
+	GetPendingSegments(sender []byte) (payloads int, size int, err error)
+	// PruneSegments deletes the segments received before the given time, in milliseconds.
+	PruneSegments(before int64) (int, error)
+	// SaveAttachment persists an attachment, replacing it if it exists.
+	SaveAttachment(attachments.Attachment) error
+	// GetAttachment returns an attachment with its data, if any.
+	GetAttachment(hash []byte) (*attachments.Attachment, error)
+	// GetAttachmentInfo returns an attachment without its data.
+	GetAttachmentInfo(hash []byte) (*attachments.Attachment, error)
+	// ReadAttachment reads the data of a cached attachment from offset into p.
+	ReadAttachment(hash []byte, offset int64, p []byte) (int, error)
+	// TouchAttachment updates the time an attachment was last accessed.
+	TouchAttachment(hash []byte, accessedAt int64) error
+	// GetCachedAttachments returns the cached attachments without their data, least recently accessed first.
+	GetCachedAttachments() ([]attachments.Attachment, error)
+	// EvictAttachment deletes the data of an attachment.
+	EvictAttachment(hash []byte) error
+	// SaveNotificationPreferences persists the notification preferences of the account.
+	SaveNotificationPreferences(notifications.Preferences) error
+	// GetNotificationPreferences returns the notification preferences of the account, if any.
+	GetNotificationPreferences() (*notifications.Preferences, error)
+	// SaveMessageAuthor records the author of a message, keeping the first one recorded.
+	SaveMessageAuthor(id string, author string) error
+	// GetMessageAuthor returns the author of a message, or an empty string if it's unknown.
+	GetMessageAuthor(id string) (string, error)
+	// SaveMessageChange persists an edit or a deletion, replacing the previous one of its author.
+	SaveMessageChange(content.Change) error
+	// GetMessageChange returns the latest edit or deletion of a message by an author, if any.
+	GetMessageChange(targetID string, author string) (*content.Change, error)
+	// SaveMessageReaction persists a reaction, replacing the previous one of its author with the same emoji.
+	SaveMessageReaction(content.Reaction) error
+	// GetMessageReaction returns the latest reaction of an author to a message with an emoji, if any.
+	GetMessageReaction(targetID string, author string, emoji string) (*content.Reaction, error)
+	// GetMessageReactions returns the latest reactions to a message, including the retracted ones.
+	GetMessageReactions(targetID string) ([]content.Reaction, error)
+
+	// SaveHistoryMessage saves a message of the history, unless it's already saved.
+	SaveHistoryMessage(history.Message) error
+	// UpdateHistoryMessage replaces the content of a message of the history and marks it as edited.
+	UpdateHistoryMessage(id string, content string) error
+	// DeleteHistoryMessage removes a message from the history.
+	DeleteHistoryMessage(id string) error
+	// GetHistoryMessages returns at most limit messages of a chat preceding the cursor, from the most recent.
+	GetHistoryMessages(chatID string, cursor *history.Cursor, limit int) ([]history.Message, error)
+	// SearchHistoryMessages returns at most limit messages with words starting with each word of query,
+	// from the most relevant.
+	SearchHistoryMessages(query string, chatID string, limit int) ([]history.Message, error)
+	// GetHistoryChats returns the IDs of the chats with stored messages.
+	GetHistoryChats() ([]string, error)
+	// OptimizeHistoryIndex merges the segments of the full-text index of the history.
+	OptimizeHistoryIndex() error
+
+	// SaveContact persists a contact with the warnings raised by its name, replacing it if it exists.
+	SaveContact(contacts.Contact) error
+	// GetContact returns a contact, if any.
+	GetContact(publicKey string) (*contacts.Contact, error)
+	// GetContacts returns all the contacts.
+	GetContacts() ([]contacts.Contact, error)
+
+	// SavePresenceSettings persists the read receipts and typing notifications settings of the account.
+	SavePresenceSettings(presence.Settings) error
+	// GetPresenceSettings returns the read receipts and typing notifications settings of the account, if any.
+	GetPresenceSettings() (*presence.Settings, error)
+	// SaveReadReceipt persists a read receipt, unless the reader already read the message.
+	SaveReadReceipt(presence.Receipt) error
+	// GetReadReceipts returns the read receipts of a message.
+	GetReadReceipts(messageID string) ([]presence.Receipt, error)
+
+	// SaveContactRequest persists the consent state of an identity, replacing the existing one.
+	SaveContactRequest(consent.Request) error
+	// GetContactRequest returns the consent state of an identity, or nil if there is none.
+	GetContactRequest(publicKey string) (*consent.Request, error)
+	// GetContactRequests returns the identities in a consent state, from the oldest request.
+	GetContactRequests(state string) ([]consent.Request, error)
+	// CountContactRequests returns the number of identities in a consent state.
+	CountContactRequests(state string) (int, error)
+
+	// BlockContact persists a blocked identity.
+	BlockContact(publicKey string) error
+	// UnblockContact deletes a blocked identity.
+	UnblockContact(publicKey string) error
+	// GetBlockedContacts returns the blocked identities.
+	GetBlockedContacts() ([]string, error)
+	// MuteChat persists a muted chat.
+	MuteChat(chatID string) error
+	// UnmuteChat deletes a muted chat.
+	UnmuteChat(chatID string) error
+	// GetMutedChats returns the muted chats.
+	GetMutedChats() ([]string, error)
+
+	// SaveContactEncryption persists whether the last direct message of a contact was encrypted with PFS.
+	SaveContactEncryption(publicKey string, pfs bool) error
+	// GetContactEncryption returns whether the last direct message of a contact was encrypted with PFS.
+	GetContactEncryption(publicKey string) (bool, error)
+	// SaveDowngrade persists a downgrade of the encryption of a contact.
+	SaveDowngrade(downgrade.Event) error
+	// GetDowngrades returns the downgrades of the encryption of a contact, from the oldest.
+	GetDowngrades(publicKey string) ([]downgrade.Event, error)
+
+	// SaveSetting persists a setting of the account, replacing its current value.
+	SaveSetting(key string, value []byte) error
+	// GetSetting returns the value of a setting, or nil if it's not set.
+	GetSetting(key string) ([]byte, error)
+	// DeleteSetting deletes a setting.
+	DeleteSetting(key string) error
+	// GetSettings returns all the settings of the account, by key.
+	GetSettings() (map[string][]byte, error)
+
+	// SaveSyncClock persists the clock of a record synced between the devices of the account.
+	SaveSyncClock(kind string, id string, clock uint64) error
+	// GetSyncClock returns the sync clock of a record, or 0 if it has none.
+	GetSyncClock(kind string, id string) (uint64, error)
+	// GetSyncClocks returns the sync clocks of the records of a kind, by ID.
+	GetSyncClocks(kind string) (map[string]uint64, error)
+
+	// SaveOutboxEntry persists an entry of the outbox and adds its message to the history, in a single transaction.
+	SaveOutboxEntry(entry outbox.Entry, message *history.Message) error
+	// DeleteOutboxEntry deletes an entry of the outbox whose messages were posted.
+	DeleteOutboxEntry(id string) error
+	// DiscardOutboxEntry deletes an entry of the outbox and removes its message from the history.
+	DiscardOutboxEntry(id string) error
+	// GetOutboxEntries returns the entries of the outbox, oldest first.
+	GetOutboxEntries() ([]outbox.Entry, error)
+
+	// SaveDecoyTopics replaces the decoy topics of the account, in a single transaction.
+	SaveDecoyTopics(topics []whisper.TopicType) error
+	// GetDecoyTopics returns the decoy topics of the account, in the order they were generated.
+	GetDecoyTopics() ([]whisper.TopicType, error)
+
+	// GetChatTopics returns the topics of the chats with persisted settings or moderation.
+	GetChatTopics() ([][]byte, error)
 }
@@ -3,9 +3,28 @@ package chat
 import (
 	"crypto/ecdsa"
 	"errors"
+	"math"
+	"sync"
+	"time"
 
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/golang/protobuf/proto"
+	"github.com/status-im/status-go/logutils"
+	"github.com/status-im/status-go/services/shhext/chat/capabilities"
+	"github.com/status-im/status-go/services/shhext/chat/compression"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+var (
+	// ErrSyncMessage is returned when a message synced by another device of the account
+	// was handled by the protocol, and must not be delivered.
+	ErrSyncMessage = errors.New("sync message")
+	// ErrInvalidSyncMessage is returned when a sync message is not sent by the account.
+	ErrInvalidSyncMessage = errors.New("sync message not sent by the account")
+	// ErrContactRequest is returned when a direct message is sent by an identity that
+	// is not allowed to contact us yet. Its bundle is not added and no session is established.
+	ErrContactRequest = errors.New("contact request")
 )
 
 type ProtocolService struct {
@@ -13,17 +32,167 @@ type ProtocolService struct {
 	encryption          *EncryptionService
 	addedBundlesHandler func([]IdentityAndIDPair)
 	Enabled             bool
+	// compression is true if the dictionaries we support are advertised, so
+	// that direct messages sent to us can be compressed.
+	compression bool
+	// partitionedTopic is true if we listen on the partitioned topic of our key,
+	// so that direct messages sent to us can use it.
+	partitionedTopic bool
+	// notificationPreferencesHandler applies the notification preferences synced by
+	// the other devices of the account.
+	notificationPreferencesHandler func([]byte) error
+	// historySyncHandler applies the records of the history synced by the other
+	// devices of the account.
+	historySyncHandler func([]byte) error
+	// wipeHandler applies the wipe commands, and their acknowledgments, sent by the
+	// other devices of the account.
+	wipeHandler func([]byte) error
+	// contactGate returns true if an identity is allowed to add its bundle and
+	// establish a session with us. All identities are allowed if it's nil.
+	contactGate func(*ecdsa.PublicKey) bool
+	// sendTimestamps is true if the messages we send carry the time they were sent.
+	sendTimestamps bool
+
+	mutex sync.RWMutex
 }
 
 // NewProtocolService creates a new ProtocolService instance
 func NewProtocolService(encryption *EncryptionService, addedBundlesHandler func([]IdentityAndIDPair)) *ProtocolService {
 	return &ProtocolService{
-		log:                 log.New("package", "status-go/services/sshext.chat"),
+		log:                 logutils.NewLogger(logutils.ModuleChat, "package", "status-go/services/sshext.chat"),
 		encryption:          encryption,
 		addedBundlesHandler: addedBundlesHandler,
 	}
 }
 
+// EnableCompression advertises the compression dictionaries supported by this installation
+// and compresses direct messages sent to recipients that advertised them too.
+func (p *ProtocolService) EnableCompression() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.compression = true
+}
+
+func (p *ProtocolService) compressionEnabled() bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.compression
+}
+
+// compress compresses the payload of a direct message with the latest dictionary supported by
+// all the installations of the recipient, if it makes it smaller. It returns the version of the
+// dictionary, or zero if the payload is not compressed.
+func (p *ProtocolService) compress(theirPublicKey *ecdsa.PublicKey, payload []byte) ([]byte, uint32) {
+	if !p.compressionEnabled() {
+		return payload, 0
+	}
+	dictionary, err := p.encryption.CompressionDictionary(theirPublicKey)
+	if err != nil {
+		p.log.Error("failed to negotiate compression dictionary", "err", err)
+		return payload, 0
+	}
+	if dictionary == nil {
+		return payload, 0
+	}
+	compressed, err := dictionary.Compress(payload)
+	if err != nil {
+		p.log.Error("failed to compress payload", "err", err)
+		return payload, 0
+	}
+	if len(compressed) >= len(payload) {
+		return payload, 0
+	}
+	return compressed, dictionary.Version
+}
+
+// EnablePartitionedTopic advertises that this installation listens on the partitioned topic
+// of its key, and sends direct messages on the partitioned topics of recipients that advertised it too.
+func (p *ProtocolService) EnablePartitionedTopic() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.partitionedTopic = true
+}
+
+func (p *ProtocolService) partitionedTopicEnabled() bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.partitionedTopic
+}
+
+// DirectMessageTopic returns the topic direct messages to a recipient should be sent on,
+// falling back to the discovery topic until all the installations of the recipient listen
+// on its partitioned topic.
+func (p *ProtocolService) DirectMessageTopic(theirPublicKey *ecdsa.PublicKey) whisper.TopicType {
+	if !p.partitionedTopicEnabled() {
+		return DiscoveryTopic()
+	}
+	topic, err := p.encryption.ContactTopic(theirPublicKey)
+	if err != nil {
+		p.log.Error("failed to negotiate contact topic", "err", err)
+	}
+	return topic
+}
+
+// ContactCapabilities returns the protocol version and features that can be used in direct
+// messages sent to a contact, as supported by all its active installations.
+func (p *ProtocolService) ContactCapabilities(theirPublicKey *ecdsa.PublicKey) (capabilities.Set, error) {
+	return p.encryption.ContactCapabilities(theirPublicKey)
+}
+
+// SetNotificationPreferencesHandler sets the handler of the notification preferences
+// synced by the other devices of the account.
+func (p *ProtocolService) SetNotificationPreferencesHandler(handler func([]byte) error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.notificationPreferencesHandler = handler
+}
+
+// SetHistorySyncHandler sets the handler of the records of the history synced by the
+// other devices of the account.
+func (p *ProtocolService) SetHistorySyncHandler(handler func([]byte) error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.historySyncHandler = handler
+}
+
+// SetWipeHandler sets the handler of the wipe commands, and their acknowledgments, sent
+// by the other devices of the account.
+func (p *ProtocolService) SetWipeHandler(handler func([]byte) error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.wipeHandler = handler
+}
+
+// EnableSendTimestamps adds the time the messages are sent to the messages, so that
+// recipients measure their delivery latency.
+func (p *ProtocolService) EnableSendTimestamps() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.sendTimestamps = true
+}
+
+// SetContactGate sets the function deciding whether an identity is allowed to add its
+// bundle and establish a session with us. The identity of the account is always allowed.
+func (p *ProtocolService) SetContactGate(gate func(*ecdsa.PublicKey) bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.contactGate = gate
+}
+
+// contactAllowed returns true if an identity is allowed to add its bundle and establish a session with us.
+func (p *ProtocolService) contactAllowed(myIdentityKey *ecdsa.PrivateKey, theirPublicKey *ecdsa.PublicKey) bool {
+	p.mutex.RLock()
+	gate := p.contactGate
+	p.mutex.RUnlock()
+	if gate == nil {
+		return true
+	}
+	if myIdentityKey != nil && crypto.PubkeyToAddress(myIdentityKey.PublicKey) == crypto.PubkeyToAddress(*theirPublicKey) {
+		return true
+	}
+	return gate(theirPublicKey)
+}
+
 func (p *ProtocolService) addBundleAndMarshal(myIdentityKey *ecdsa.PrivateKey, msg *ProtocolMessage) ([]byte, error) {
 	// Get a bundle
 	bundle, err := p.encryption.CreateBundle(myIdentityKey)
@@ -33,6 +202,18 @@ func (p *ProtocolService) addBundleAndMarshal(myIdentityKey *ecdsa.PrivateKey, m
 	}
 
 	msg.Bundle = bundle
+	if p.compressionEnabled() {
+		msg.CompressionDictionaries = compression.Versions()
+	}
+	msg.PartitionedTopic = p.partitionedTopicEnabled()
+	supported := capabilities.Supported()
+	msg.Version = supported.Version
+	msg.Features = supported.Features
+	p.mutex.RLock()
+	if p.sendTimestamps {
+		msg.SentAt = uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	}
+	p.mutex.RUnlock()
 
 	// marshal for sending to wire
 	marshaledMessage, err := proto.Marshal(msg)
@@ -59,8 +240,10 @@ func (p *ProtocolService) BuildPublicMessage(myIdentityKey *ecdsa.PrivateKey, pa
 func (p *ProtocolService) BuildDirectMessage(myIdentityKey *ecdsa.PrivateKey, payload []byte, theirPublicKeys ...*ecdsa.PublicKey) (map[*ecdsa.PublicKey][]byte, error) {
 	response := make(map[*ecdsa.PublicKey][]byte)
 	for _, publicKey := range theirPublicKeys {
+		plaintext, dictionary := p.compress(publicKey, payload)
+
 		// Encrypt payload
-		encryptionResponse, err := p.encryption.EncryptPayload(publicKey, myIdentityKey, payload)
+		encryptionResponse, err := p.encryption.EncryptPayload(publicKey, myIdentityKey, plaintext)
 		if err != nil {
 			p.log.Error("encryption-service", "error encrypting payload", err)
 			return nil, err
@@ -68,8 +251,9 @@ func (p *ProtocolService) BuildDirectMessage(myIdentityKey *ecdsa.PrivateKey, pa
 
 		// Build message
 		protocolMessage := &ProtocolMessage{
-			InstallationId: p.encryption.config.InstallationID,
-			DirectMessage:  encryptionResponse,
+			InstallationId:        p.encryption.config.InstallationID,
+			DirectMessage:         encryptionResponse,
+			CompressionDictionary: dictionary,
 		}
 
 		payload, err := p.addBundleAndMarshal(myIdentityKey, protocolMessage)
@@ -84,6 +268,50 @@ func (p *ProtocolService) BuildDirectMessage(myIdentityKey *ecdsa.PrivateKey, pa
 	return response, nil
 }
 
+// EstimateDirectMessage returns the estimated size of the message BuildDirectMessage builds
+// for a recipient, and the number of installations of the recipient the payload is encrypted
+// for, without encrypting it.
+func (p *ProtocolService) EstimateDirectMessage(myIdentityKey *ecdsa.PrivateKey, payload []byte, theirPublicKey *ecdsa.PublicKey) (int, int, error) {
+	plaintext, dictionary := p.compress(theirPublicKey, payload)
+	installationIDs, err := p.encryption.Installations(theirPublicKey, myIdentityKey)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	directMessage := make(map[string]*DirectMessageProtocol, len(installationIDs))
+	for _, installationID := range installationIDs {
+		directMessage[installationID] = estimatedDirectMessage(installationID, len(plaintext))
+	}
+	protocolMessage := &ProtocolMessage{
+		InstallationId:        p.encryption.config.InstallationID,
+		DirectMessage:         directMessage,
+		CompressionDictionary: dictionary,
+	}
+	marshaled, err := p.addBundleAndMarshal(myIdentityKey, protocolMessage)
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(marshaled), len(installationIDs), nil
+}
+
+// estimatedDirectMessage returns a message with the largest headers and the size of a payload of
+// length bytes encrypted for an installation. The double ratchet prepends an IV and appends an HMAC,
+// and payloads encrypted with DH for recipients without a bundle have a nonce and a tag.
+func estimatedDirectMessage(installationID string, length int) *DirectMessageProtocol {
+	key := make([]byte, 33)
+	if installationID == noInstallationID {
+		return &DirectMessageProtocol{
+			DHHeader: &DHHeader{Key: key},
+			Payload:  make([]byte, length+16+12),
+		}
+	}
+	return &DirectMessageProtocol{
+		X3DHHeader: &X3DHHeader{Key: key, Id: key},
+		DRHeader:   &DRHeader{Key: key[:32], N: math.MaxUint32, Pn: math.MaxUint32, Id: key},
+		Payload:    make([]byte, 16+length+32),
+	}
+}
+
 // BuildPairingMessage sends a message to our own devices using DH so that it can be decrypted by any other device.
 func (p *ProtocolService) BuildPairingMessage(myIdentityKey *ecdsa.PrivateKey, payload []byte) ([]byte, error) {
 	// Encrypt payload
@@ -102,11 +330,71 @@ func (p *ProtocolService) BuildPairingMessage(myIdentityKey *ecdsa.PrivateKey, p
 	return p.addBundleAndMarshal(myIdentityKey, protocolMessage)
 }
 
+// BuildNotificationPreferencesMessage builds a message syncing the notification preferences
+// with the paired devices of the account. It returns nil if there are none.
+func (p *ProtocolService) BuildNotificationPreferencesMessage(myIdentityKey *ecdsa.PrivateKey, preferences []byte) ([]byte, error) {
+	return p.buildSyncMessage(myIdentityKey, preferences, func(m *ProtocolMessage) {
+		m.NotificationPreferences = true
+	})
+}
+
+// BuildHistorySyncMessage builds a message syncing records of the history with the
+// paired devices of the account. It returns nil if there are none.
+func (p *ProtocolService) BuildHistorySyncMessage(myIdentityKey *ecdsa.PrivateKey, records []byte) ([]byte, error) {
+	return p.buildSyncMessage(myIdentityKey, records, func(m *ProtocolMessage) {
+		m.HistorySync = true
+	})
+}
+
+// BuildWipeMessage builds a message carrying a wipe command, or its acknowledgment, to
+// the paired devices of the account. It returns nil if there are none.
+func (p *ProtocolService) BuildWipeMessage(myIdentityKey *ecdsa.PrivateKey, payload []byte) ([]byte, error) {
+	return p.buildSyncMessage(myIdentityKey, payload, func(m *ProtocolMessage) {
+		m.Wipe = true
+	})
+}
+
+// buildSyncMessage builds a message encrypted for the paired devices of the account,
+// flagged by mark. It returns nil if there are none.
+func (p *ProtocolService) buildSyncMessage(myIdentityKey *ecdsa.PrivateKey, payload []byte, mark func(*ProtocolMessage)) ([]byte, error) {
+	encryptionResponse, err := p.encryption.EncryptPayload(&myIdentityKey.PublicKey, myIdentityKey, payload)
+	if err != nil {
+		p.log.Error("encryption-service", "error encrypting payload", err)
+		return nil, err
+	}
+	if len(encryptionResponse) == 0 {
+		return nil, nil
+	}
+
+	protocolMessage := &ProtocolMessage{
+		InstallationId: p.encryption.config.InstallationID,
+		DirectMessage:  encryptionResponse,
+	}
+	mark(protocolMessage)
+
+	return p.addBundleAndMarshal(myIdentityKey, protocolMessage)
+}
+
 // ProcessPublicBundle processes a received X3DH bundle.
 func (p *ProtocolService) ProcessPublicBundle(myIdentityKey *ecdsa.PrivateKey, bundle *Bundle) ([]IdentityAndIDPair, error) {
 	return p.encryption.ProcessPublicBundle(myIdentityKey, bundle)
 }
 
+// RestoreBundle persists a bundle restored from a backup, without checking its signature.
+func (p *ProtocolService) RestoreBundle(myIdentityKey *ecdsa.PrivateKey, bundle *Bundle) error {
+	return p.encryption.RestoreBundle(myIdentityKey, bundle)
+}
+
+// GetPublicBundle returns the bundle of the active installations of a public key, if any.
+func (p *ProtocolService) GetPublicBundle(theirIdentityKey *ecdsa.PublicKey) (*Bundle, error) {
+	return p.encryption.GetPublicBundle(theirIdentityKey)
+}
+
+// Installations returns the IDs of the active installations of a public key, other than ours.
+func (p *ProtocolService) Installations(theirIdentityKey *ecdsa.PublicKey, myIdentityKey *ecdsa.PrivateKey) ([]string, error) {
+	return p.encryption.Installations(theirIdentityKey, myIdentityKey)
+}
+
 // GetBundle retrieves or creates a X3DH bundle, given a private identity key.
 func (p *ProtocolService) GetBundle(myIdentityKey *ecdsa.PrivateKey) (*Bundle, error) {
 	return p.encryption.CreateBundle(myIdentityKey)
@@ -122,6 +410,51 @@ func (p *ProtocolService) DisableInstallation(myIdentityKey *ecdsa.PublicKey, in
 	return p.encryption.DisableInstallation(myIdentityKey, installationID)
 }
 
+// DisableOwnInstallation disables the installation of this device if it is recorded as one of
+// our paired installations. It returns true if the installation was enabled.
+func (p *ProtocolService) DisableOwnInstallation(myIdentityKey *ecdsa.PublicKey) (bool, error) {
+	return p.encryption.DisableOwnInstallation(myIdentityKey)
+}
+
+// CleanupInstallations deletes the state of installations disabled for longer than olderThan.
+func (p *ProtocolService) CleanupInstallations(myIdentityKey *ecdsa.PublicKey, olderThan time.Duration) (*InstallationsCleanup, error) {
+	return p.encryption.CleanupInstallations(myIdentityKey, olderThan)
+}
+
+// PruneProcessed deletes the processed messages older than ProcessedMessagesTTL.
+func (p *ProtocolService) PruneProcessed() (int, error) {
+	return p.encryption.PruneProcessed()
+}
+
+// SetChatLanguage sets the language messages received in a chat are translated to.
+func (p *ProtocolService) SetChatLanguage(chatID string, language string) error {
+	topic := toTopic(chatID)
+	return p.encryption.persistence.SetChatLanguage(topic[:], language)
+}
+
+// GetChatLanguage returns the language messages received in a chat are translated to.
+func (p *ProtocolService) GetChatLanguage(chatID string) (string, error) {
+	return p.GetTopicLanguage(toTopic(chatID))
+}
+
+// GetTopicLanguage returns the language messages received on a topic are translated to.
+func (p *ProtocolService) GetTopicLanguage(topic whisper.TopicType) (string, error) {
+	return p.encryption.persistence.GetChatLanguage(topic[:])
+}
+
+// GetChatTopics returns the topics of the chats with persisted settings or moderation.
+func (p *ProtocolService) GetChatTopics() ([]whisper.TopicType, error) {
+	topics, err := p.encryption.persistence.GetChatTopics()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]whisper.TopicType, 0, len(topics))
+	for _, topic := range topics {
+		result = append(result, whisper.BytesToTopic(topic))
+	}
+	return result, nil
+}
+
 // HandleMessage unmarshals a message and processes it, decrypting it if it is a 1:1 message.
 func (p *ProtocolService) HandleMessage(myIdentityKey *ecdsa.PrivateKey, theirPublicKey *ecdsa.PublicKey, payload []byte) ([]byte, error) {
 	if p.encryption == nil {
@@ -134,9 +467,14 @@ func (p *ProtocolService) HandleMessage(myIdentityKey *ecdsa.PrivateKey, theirPu
 	if err := proto.Unmarshal(payload, protocolMessage); err != nil {
 		return nil, err
 	}
+	messagesReceivedCounter.Inc(1)
+
+	// Identities not allowed to contact us can't add their bundle or establish a session,
+	// but their public messages are delivered.
+	allowed := theirPublicKey == nil || p.contactAllowed(myIdentityKey, theirPublicKey)
 
 	// Process bundle
-	if bundle := protocolMessage.GetBundle(); bundle != nil {
+	if bundle := protocolMessage.GetBundle(); bundle != nil && allowed && p.bundleAllowed(myIdentityKey, bundle) {
 		// Should we stop processing if the bundle cannot be verified?
 		addedBundles, err := p.encryption.ProcessPublicBundle(myIdentityKey, bundle)
 		if err != nil {
@@ -146,6 +484,31 @@ func (p *ProtocolService) HandleMessage(myIdentityKey *ecdsa.PrivateKey, theirPu
 		p.addedBundlesHandler(addedBundles)
 	}
 
+	// Record the compression dictionaries supported by the sender, which are
+	// not advertised anymore if compression was disabled.
+	if installationID := protocolMessage.GetInstallationId(); installationID != "" && theirPublicKey != nil && allowed {
+		if err := p.encryption.SetCompressionDictionaries(theirPublicKey, installationID, protocolMessage.GetCompressionDictionaries()); err != nil {
+			p.log.Error("failed to record compression dictionaries", "err", err)
+		}
+
+		// Installations that don't advertise the partitioned topic, or stopped doing
+		// so, only listen on the discovery topic.
+		topic := DiscoveryTopic()
+		if protocolMessage.GetPartitionedTopic() {
+			topic = PartitionedTopic(theirPublicKey)
+		}
+		if err := p.encryption.SetContactTopic(theirPublicKey, installationID, topic); err != nil {
+			p.log.Error("failed to record contact topic", "err", err)
+		}
+
+		// Capabilities are recorded even if they are unknown or the version is newer,
+		// and legacy clients that don't advertise any record version 0.
+		set := capabilities.Set{Version: protocolMessage.GetVersion(), Features: protocolMessage.GetFeatures()}
+		if err := p.encryption.SetContactCapabilities(theirPublicKey, installationID, set); err != nil {
+			p.log.Error("failed to record contact capabilities", "err", err)
+		}
+	}
+
 	// Check if it's a public message
 	if publicMessage := protocolMessage.GetPublicMessage(); publicMessage != nil {
 		// Nothing to do, as already in cleartext
@@ -153,11 +516,57 @@ func (p *ProtocolService) HandleMessage(myIdentityKey *ecdsa.PrivateKey, theirPu
 	}
 
 	// Decrypt message
-	if directMessage := protocolMessage.GetDirectMessage(); directMessage != nil {
-		message, err := p.encryption.DecryptPayload(myIdentityKey, theirPublicKey, protocolMessage.GetInstallationId(), directMessage)
+	if directMessage := protocolMessage.GetDirectMessage(); directMessage != nil && theirPublicKey != nil {
+		if !allowed {
+			return nil, ErrContactRequest
+		}
+
+		installationID := protocolMessage.GetInstallationId()
+		// Replayed or redelivered envelopes carry the same payload.
+		messageHash := crypto.Keccak256(payload)
+		duplicate, err := p.encryption.IsDuplicate(theirPublicKey, installationID, messageHash)
 		if err != nil {
 			return nil, err
 		}
+		if duplicate {
+			return nil, ErrDuplicateMessage
+		}
+
+		message, err := p.encryption.DecryptPayload(myIdentityKey, theirPublicKey, installationID, directMessage)
+		if err != nil {
+			decryptionFailuresCounter.Inc(1)
+			return nil, err
+		}
+
+		if version := protocolMessage.GetCompressionDictionary(); version != 0 {
+			message, err = compression.Decompress(version, message)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if err := p.encryption.MarkProcessed(theirPublicKey, installationID, messageHash); err != nil {
+			p.log.Error("failed to mark message as processed", "err", err)
+		}
+
+		if protocolMessage.GetNotificationPreferences() {
+			p.mutex.RLock()
+			handler := p.notificationPreferencesHandler
+			p.mutex.RUnlock()
+			return nil, p.handleSyncMessage(myIdentityKey, theirPublicKey, handler, message, "notification preferences")
+		}
+		if protocolMessage.GetHistorySync() {
+			p.mutex.RLock()
+			handler := p.historySyncHandler
+			p.mutex.RUnlock()
+			return nil, p.handleSyncMessage(myIdentityKey, theirPublicKey, handler, message, "history")
+		}
+		if protocolMessage.GetWipe() {
+			p.mutex.RLock()
+			handler := p.wipeHandler
+			p.mutex.RUnlock()
+			return nil, p.handleSyncMessage(myIdentityKey, theirPublicKey, handler, message, "wipe")
+		}
 
 		return message, nil
 	}
@@ -165,3 +574,52 @@ func (p *ProtocolService) HandleMessage(myIdentityKey *ecdsa.PrivateKey, theirPu
 	// Return error
 	return nil, errors.New("no payload")
 }
+
+// IsEncrypted returns true if a payload is a protocol message encrypted with PFS.
+func IsEncrypted(payload []byte) bool {
+	protocolMessage := &ProtocolMessage{}
+	if err := proto.Unmarshal(payload, protocolMessage); err != nil {
+		return false
+	}
+	return len(protocolMessage.GetDirectMessage()) > 0
+}
+
+// SentTime returns the time a message was sent, if its sender set it.
+func SentTime(payload []byte) (time.Time, bool) {
+	protocolMessage := &ProtocolMessage{}
+	if err := proto.Unmarshal(payload, protocolMessage); err != nil || protocolMessage.GetSentAt() == 0 {
+		return time.Time{}, false
+	}
+	sentAt := int64(protocolMessage.GetSentAt())
+	return time.Unix(sentAt/1000, sentAt%1000*int64(time.Millisecond)), true
+}
+
+// bundleAllowed returns true if the identity of a bundle is allowed to contact us,
+// as bundles attached to public messages are not necessarily those of their sender.
+func (p *ProtocolService) bundleAllowed(myIdentityKey *ecdsa.PrivateKey, bundle *Bundle) bool {
+	p.mutex.RLock()
+	gated := p.contactGate != nil
+	p.mutex.RUnlock()
+	if !gated {
+		return true
+	}
+	identity, err := crypto.DecompressPubkey(bundle.GetIdentity())
+	if err != nil {
+		return false
+	}
+	return p.contactAllowed(myIdentityKey, identity)
+}
+
+// handleSyncMessage applies the payload of a message synced by another device of the account with handler.
+func (p *ProtocolService) handleSyncMessage(myIdentityKey *ecdsa.PrivateKey, theirPublicKey *ecdsa.PublicKey, handler func([]byte) error, payload []byte, name string) error {
+	if crypto.PubkeyToAddress(myIdentityKey.PublicKey) != crypto.PubkeyToAddress(*theirPublicKey) {
+		return ErrInvalidSyncMessage
+	}
+
+	if handler != nil {
+		if err := handler(payload); err != nil {
+			p.log.Error("failed to apply synced "+name, "err", err)
+		}
+	}
+	return ErrSyncMessage
+}
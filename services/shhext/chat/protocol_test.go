@@ -1,11 +1,14 @@
 package chat
 
 import (
+	"crypto/ecdsa"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/golang/protobuf/proto"
+	"github.com/status-im/status-go/services/shhext/chat/capabilities"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -28,12 +31,12 @@ func (s *ProtocolServiceTestSuite) SetupTest() {
 	os.Remove(aliceDBPath)
 	os.Remove(bobDBPath)
 
-	alicePersistence, err := NewSQLLitePersistence(aliceDBPath, aliceDBKey)
+	alicePersistence, err := NewSQLLitePersistence(aliceDBPath, aliceDBKey, DefaultPersistenceConfig())
 	if err != nil {
 		panic(err)
 	}
 
-	bobPersistence, err := NewSQLLitePersistence(bobDBPath, bobDBKey)
+	bobPersistence, err := NewSQLLitePersistence(bobDBPath, bobDBKey, DefaultPersistenceConfig())
 	if err != nil {
 		panic(err)
 	}
@@ -115,3 +118,303 @@ func (s *ProtocolServiceTestSuite) TestBuildAndReadDirectMessage() {
 	s.NoError(err)
 	s.Equalf(proto.Equal(&payload, &recoveredPayload), true, "It successfully unmarshal the decrypted message")
 }
+
+func (s *ProtocolServiceTestSuite) TestEstimateDirectMessage() {
+	bobKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	aliceKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	payload := make([]byte, 1000)
+
+	// Without the bundle of the recipient, the payload is encrypted with DH
+	size, installations, err := s.bob.EstimateDirectMessage(bobKey, payload, &aliceKey.PublicKey)
+	s.Require().NoError(err)
+	s.Equal(1, installations)
+	messages, err := s.bob.BuildDirectMessage(bobKey, payload, &aliceKey.PublicKey)
+	s.Require().NoError(err)
+	actual := len(messages[&aliceKey.PublicKey])
+	s.True(size >= actual, "The estimate is not lower than the size of the message")
+	s.True(size-actual < 64, "The estimate is close to the size of the message")
+
+	bundle, err := s.alice.GetBundle(aliceKey)
+	s.Require().NoError(err)
+	_, err = s.bob.ProcessPublicBundle(bobKey, bundle)
+	s.Require().NoError(err)
+
+	size, installations, err = s.bob.EstimateDirectMessage(bobKey, payload, &aliceKey.PublicKey)
+	s.Require().NoError(err)
+	s.Equal(1, installations)
+	messages, err = s.bob.BuildDirectMessage(bobKey, payload, &aliceKey.PublicKey)
+	s.Require().NoError(err)
+	actual = len(messages[&aliceKey.PublicKey])
+	s.True(size >= actual, "The estimate is not lower than the size of the message")
+	s.True(size-actual < 64, "The estimate is close to the size of the message")
+
+	// Estimating doesn't advance the ratchet
+	message, err := s.alice.HandleMessage(aliceKey, &bobKey.PublicKey, messages[&aliceKey.PublicKey])
+	s.Require().NoError(err)
+	s.Equal(payload, message)
+}
+
+func (s *ProtocolServiceTestSuite) TestIsEncrypted() {
+	bobKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	aliceKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+
+	direct, err := s.alice.BuildDirectMessage(aliceKey, []byte("hello"), &bobKey.PublicKey)
+	s.Require().NoError(err)
+	s.True(IsEncrypted(direct[&bobKey.PublicKey]))
+
+	public, err := s.alice.BuildPublicMessage(aliceKey, []byte("hello"))
+	s.Require().NoError(err)
+	s.False(IsEncrypted(public))
+	s.False(IsEncrypted([]byte("hello")))
+}
+
+func (s *ProtocolServiceTestSuite) TestSentTime() {
+	aliceKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+
+	public, err := s.alice.BuildPublicMessage(aliceKey, []byte("hello"))
+	s.Require().NoError(err)
+	_, ok := SentTime(public)
+	s.False(ok, "The time is only sent when enabled")
+
+	s.alice.EnableSendTimestamps()
+	before := time.Now().Add(-time.Millisecond)
+	public, err = s.alice.BuildPublicMessage(aliceKey, []byte("hello"))
+	s.Require().NoError(err)
+	sentAt, ok := SentTime(public)
+	s.True(ok)
+	s.False(sentAt.Before(before))
+	s.False(sentAt.After(time.Now()))
+	_, ok = SentTime([]byte("hello"))
+	s.False(ok)
+}
+
+func (s *ProtocolServiceTestSuite) TestHandleDuplicateMessage() {
+	bobKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	aliceKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+
+	marshaledMsg, err := s.alice.BuildDirectMessage(aliceKey, []byte("hello"), &bobKey.PublicKey)
+	s.Require().NoError(err)
+
+	message, err := s.bob.HandleMessage(bobKey, &aliceKey.PublicKey, marshaledMsg[&bobKey.PublicKey])
+	s.Require().NoError(err)
+	s.Equal([]byte("hello"), message)
+
+	// The same envelope is redelivered, e.g. by a mailserver
+	_, err = s.bob.HandleMessage(bobKey, &aliceKey.PublicKey, marshaledMsg[&bobKey.PublicKey])
+	s.Equal(ErrDuplicateMessage, err)
+}
+
+func (s *ProtocolServiceTestSuite) TestCompressedDirectMessage() {
+	bobKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	aliceKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+
+	payload := []byte(`["~#c4",["hey there","text/plain","~:user-message",154461712345601,1544617123456]]`)
+	s.alice.EnableCompression()
+
+	// Bob doesn't advertise any dictionary
+	bobMsg, err := s.bob.BuildDirectMessage(bobKey, []byte("hello"), &aliceKey.PublicKey)
+	s.Require().NoError(err)
+	_, err = s.alice.HandleMessage(aliceKey, &bobKey.PublicKey, bobMsg[&aliceKey.PublicKey])
+	s.Require().NoError(err)
+
+	aliceMsg, err := s.alice.BuildDirectMessage(aliceKey, payload, &bobKey.PublicKey)
+	s.Require().NoError(err)
+	protocolMessage := &ProtocolMessage{}
+	s.Require().NoError(proto.Unmarshal(aliceMsg[&bobKey.PublicKey], protocolMessage))
+	s.Equal(uint32(0), protocolMessage.GetCompressionDictionary(), "It doesn't compress messages for peers without dictionaries")
+	s.NotEmpty(protocolMessage.GetCompressionDictionaries(), "It advertises its dictionaries")
+
+	s.bob.EnableCompression()
+	bobMsg, err = s.bob.BuildDirectMessage(bobKey, []byte("hello again"), &aliceKey.PublicKey)
+	s.Require().NoError(err)
+	_, err = s.alice.HandleMessage(aliceKey, &bobKey.PublicKey, bobMsg[&aliceKey.PublicKey])
+	s.Require().NoError(err)
+
+	aliceMsg, err = s.alice.BuildDirectMessage(aliceKey, payload, &bobKey.PublicKey)
+	s.Require().NoError(err)
+	protocolMessage = &ProtocolMessage{}
+	s.Require().NoError(proto.Unmarshal(aliceMsg[&bobKey.PublicKey], protocolMessage))
+	s.Equal(uint32(1), protocolMessage.GetCompressionDictionary(), "It compresses messages once dictionaries are negotiated")
+
+	message, err := s.bob.HandleMessage(bobKey, &aliceKey.PublicKey, aliceMsg[&bobKey.PublicKey])
+	s.Require().NoError(err)
+	s.Equal(payload, message)
+}
+
+func (s *ProtocolServiceTestSuite) TestPartitionedTopic() {
+	bobKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	aliceKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+
+	s.alice.EnablePartitionedTopic()
+	s.Equal(DiscoveryTopic(), s.alice.DirectMessageTopic(&bobKey.PublicKey), "It uses the discovery topic for unknown peers")
+
+	// Bob doesn't listen on the partitioned topic
+	bobMsg, err := s.bob.BuildDirectMessage(bobKey, []byte("hello"), &aliceKey.PublicKey)
+	s.Require().NoError(err)
+	_, err = s.alice.HandleMessage(aliceKey, &bobKey.PublicKey, bobMsg[&aliceKey.PublicKey])
+	s.Require().NoError(err)
+	s.Equal(DiscoveryTopic(), s.alice.DirectMessageTopic(&bobKey.PublicKey))
+
+	s.bob.EnablePartitionedTopic()
+	bobMsg, err = s.bob.BuildDirectMessage(bobKey, []byte("hello again"), &aliceKey.PublicKey)
+	s.Require().NoError(err)
+	_, err = s.alice.HandleMessage(aliceKey, &bobKey.PublicKey, bobMsg[&aliceKey.PublicKey])
+	s.Require().NoError(err)
+	s.Equal(PartitionedTopic(&bobKey.PublicKey), s.alice.DirectMessageTopic(&bobKey.PublicKey), "It uses the partitioned topic once advertised")
+}
+
+func (s *ProtocolServiceTestSuite) TestContactCapabilities() {
+	bobKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	aliceKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+
+	set, err := s.alice.ContactCapabilities(&bobKey.PublicKey)
+	s.Require().NoError(err)
+	s.Equal(capabilities.Set{}, set, "Unknown peers are legacy clients")
+
+	bobMsg, err := s.bob.BuildDirectMessage(bobKey, []byte("hello"), &aliceKey.PublicKey)
+	s.Require().NoError(err)
+	_, err = s.alice.HandleMessage(aliceKey, &bobKey.PublicKey, bobMsg[&aliceKey.PublicKey])
+	s.Require().NoError(err)
+
+	set, err = s.alice.ContactCapabilities(&bobKey.PublicKey)
+	s.Require().NoError(err)
+	s.Equal(capabilities.Supported(), set)
+}
+
+func (s *ProtocolServiceTestSuite) TestNotificationPreferencesMessage() {
+	key, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+
+	var synced [][]byte
+	s.bob.SetNotificationPreferencesHandler(func(preferences []byte) error {
+		synced = append(synced, preferences)
+		return nil
+	})
+
+	msg, err := s.alice.BuildNotificationPreferencesMessage(key, []byte("preferences"))
+	s.Require().NoError(err)
+	s.Nil(msg, "It doesn't build a message without paired devices")
+
+	// Pair the devices
+	pairingMsg, err := s.bob.BuildPairingMessage(key, []byte("pairing"))
+	s.Require().NoError(err)
+	_, err = s.alice.HandleMessage(key, &key.PublicKey, pairingMsg)
+	s.Require().NoError(err)
+	s.Require().NoError(s.alice.EnableInstallation(&key.PublicKey, "2"))
+
+	msg, err = s.alice.BuildNotificationPreferencesMessage(key, []byte("preferences"))
+	s.Require().NoError(err)
+	s.Require().NotNil(msg)
+	payload, err := s.bob.HandleMessage(key, &key.PublicKey, msg)
+	s.Equal(ErrSyncMessage, err)
+	s.Nil(payload, "Sync messages are not delivered")
+	s.Equal([][]byte{[]byte("preferences")}, synced)
+}
+
+func (s *ProtocolServiceTestSuite) TestHistorySyncMessage() {
+	key, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+
+	var synced [][]byte
+	s.bob.SetHistorySyncHandler(func(records []byte) error {
+		synced = append(synced, records)
+		return nil
+	})
+
+	msg, err := s.alice.BuildHistorySyncMessage(key, []byte("records"))
+	s.Require().NoError(err)
+	s.Nil(msg, "It doesn't build a message without paired devices")
+
+	// Pair the devices
+	pairingMsg, err := s.bob.BuildPairingMessage(key, []byte("pairing"))
+	s.Require().NoError(err)
+	_, err = s.alice.HandleMessage(key, &key.PublicKey, pairingMsg)
+	s.Require().NoError(err)
+	s.Require().NoError(s.alice.EnableInstallation(&key.PublicKey, "2"))
+
+	msg, err = s.alice.BuildHistorySyncMessage(key, []byte("records"))
+	s.Require().NoError(err)
+	s.Require().NotNil(msg)
+	payload, err := s.bob.HandleMessage(key, &key.PublicKey, msg)
+	s.Equal(ErrSyncMessage, err)
+	s.Nil(payload, "Sync messages are not delivered")
+	s.Equal([][]byte{[]byte("records")}, synced)
+}
+
+func (s *ProtocolServiceTestSuite) TestWipeMessage() {
+	key, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+
+	var synced [][]byte
+	s.bob.SetWipeHandler(func(payload []byte) error {
+		synced = append(synced, payload)
+		return nil
+	})
+
+	msg, err := s.alice.BuildWipeMessage(key, []byte("wipe"))
+	s.Require().NoError(err)
+	s.Nil(msg, "It doesn't build a message without paired devices")
+
+	// Pair the devices
+	pairingMsg, err := s.bob.BuildPairingMessage(key, []byte("pairing"))
+	s.Require().NoError(err)
+	_, err = s.alice.HandleMessage(key, &key.PublicKey, pairingMsg)
+	s.Require().NoError(err)
+	s.Require().NoError(s.alice.EnableInstallation(&key.PublicKey, "2"))
+
+	msg, err = s.alice.BuildWipeMessage(key, []byte("wipe"))
+	s.Require().NoError(err)
+	s.Require().NotNil(msg)
+	payload, err := s.bob.HandleMessage(key, &key.PublicKey, msg)
+	s.Equal(ErrSyncMessage, err)
+	s.Nil(payload, "Sync messages are not delivered")
+	s.Equal([][]byte{[]byte("wipe")}, synced)
+}
+
+func (s *ProtocolServiceTestSuite) TestContactGate() {
+	bobKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	aliceKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+
+	var added []IdentityAndIDPair
+	s.bob.addedBundlesHandler = func(pairs []IdentityAndIDPair) {
+		added = append(added, pairs...)
+	}
+	allowed := false
+	s.bob.SetContactGate(func(publicKey *ecdsa.PublicKey) bool {
+		return allowed
+	})
+
+	marshaledMsg, err := s.alice.BuildDirectMessage(aliceKey, []byte("hello"), &bobKey.PublicKey)
+	s.Require().NoError(err)
+	_, err = s.bob.HandleMessage(bobKey, &aliceKey.PublicKey, marshaledMsg[&bobKey.PublicKey])
+	s.Equal(ErrContactRequest, err)
+	s.Empty(added, "The bundle of an identity that is not allowed is not added")
+
+	public, err := s.alice.BuildPublicMessage(aliceKey, []byte("hi"))
+	s.Require().NoError(err)
+	message, err := s.bob.HandleMessage(bobKey, nil, public)
+	s.Require().NoError(err)
+	s.Equal([]byte("hi"), message, "Public messages are delivered")
+	s.Empty(added)
+
+	allowed = true
+	message, err = s.bob.HandleMessage(bobKey, &aliceKey.PublicKey, marshaledMsg[&bobKey.PublicKey])
+	s.Require().NoError(err)
+	s.Equal([]byte("hello"), message, "Messages are decrypted once the identity is allowed")
+	s.Len(added, 1)
+}
@@ -0,0 +1,87 @@
+package chat
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/status-im/status-go/services/shhext/push"
+)
+
+// PushStore stores the push notification registrations received by the server of the account and the registration of the account, in the chat database of the account.
+type PushStore struct {
+	accountDB
+}
+
+// PushStore returns the store of the push notification registrations received by the server of the account and the registration of the account.
+func (s *SQLLitePersistence) PushStore() *PushStore {
+	return &PushStore{s.accountDB()}
+}
+
+// SavePushRegistration saves the registration of an installation of a public key, replacing the previous one
+func (s *PushStore) SavePushRegistration(publicKeyHash []byte, registration push.Registration) error {
+	data, err := json.Marshal(registration)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO push_registrations(public_key_hash, installation_id, data) VALUES (?, ?, ?)`,
+		publicKeyHash, registration.InstallationID, data)
+	return err
+}
+
+// GetPushRegistrations returns the registrations of the installations of a public key
+func (s *PushStore) GetPushRegistrations(publicKeyHash []byte) ([]push.Registration, error) {
+	rows, err := s.db.Query(`SELECT data FROM push_registrations WHERE public_key_hash = ? ORDER BY installation_id`,
+		publicKeyHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []push.Registration
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var registration push.Registration
+		if err := json.Unmarshal(data, &registration); err != nil {
+			return nil, err
+		}
+		result = append(result, registration)
+	}
+	return result, rows.Err()
+}
+
+// DeletePushRegistration deletes the registration of an installation of a public key
+func (s *PushStore) DeletePushRegistration(publicKeyHash []byte, installationID string) error {
+	_, err := s.db.Exec(`DELETE FROM push_registrations WHERE public_key_hash = ? AND installation_id = ?`,
+		publicKeyHash, installationID)
+	return err
+}
+
+// SavePushClientState saves the push notification registration of the account, replacing the previous one
+func (s *PushStore) SavePushClientState(state push.ClientState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO push_client_state(id, data) VALUES (1, ?)`, data)
+	return err
+}
+
+// GetPushClientState returns the push notification registration of the account, or nil if it never registered
+func (s *PushStore) GetPushClientState() (*push.ClientState, error) {
+	var data []byte
+	err := s.db.QueryRow(`SELECT data FROM push_client_state WHERE id = 1`).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state push.ClientState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
@@ -27,4 +27,30 @@ type SendGroupMessageRPC struct {
 	Sig     string
 	Payload hexutil.Bytes
 	PubKeys []hexutil.Bytes
+	// ID optionally identifies the message, receipts of its members are aggregated if set.
+	ID string
+}
+
+// SendModerationListRPC represents the RPC payload for the PublishModerationList RPC method
+type SendModerationListRPC struct {
+	Sig   string
+	Chat  string
+	Allow []hexutil.Bytes
+	Deny  []hexutil.Bytes
+}
+
+// SendReadReceiptRPC represents the RPC payload for the SendReadReceipt RPC method
+type SendReadReceiptRPC struct {
+	Sig        string
+	Chat       string
+	PubKey     hexutil.Bytes
+	MessageIDs []string
+}
+
+// SendTypingRPC represents the RPC payload for the SendTyping RPC method
+type SendTypingRPC struct {
+	Sig    string
+	Chat   string
+	PubKey hexutil.Bytes
+	Typing bool
 }
@@ -0,0 +1,204 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/status-im/migrate"
+	"github.com/status-im/migrate/database/sqlcipher"
+	"github.com/status-im/migrate/source/go_bindata"
+	"github.com/status-im/status-go/services/shhext/chat/migrations"
+	"github.com/status-im/status-go/services/shhext/schema"
+)
+
+// ErrDatabaseTooNew is returned when opening a database migrated by a newer version of the app.
+// Opening it could corrupt the ratchet state, as the schema is unknown.
+var ErrDatabaseTooNew = errors.New("database schema is newer than the supported one")
+
+// Migrations returns the migrations of the schema of the chat database, from the oldest.
+func Migrations() ([]schema.Migration, error) {
+	var result []schema.Migration
+	for _, name := range migrations.AssetNames() {
+		if !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimSuffix(name, ".up.sql"), "_", 2)
+		version, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration name %s: %v", name, err)
+		}
+		migration := schema.Migration{Version: uint(version)}
+		if len(parts) == 2 {
+			migration.Name = parts[1]
+		}
+		result = append(result, migration)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+// LatestSchemaVersion returns the most recent schema version of the chat database.
+func LatestSchemaVersion() (uint, error) {
+	var latest uint
+	for _, name := range migrations.AssetNames() {
+		if !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+		version, err := strconv.ParseUint(strings.SplitN(name, "_", 2)[0], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid migration name %s: %v", name, err)
+		}
+		if uint(version) > latest {
+			latest = uint(version)
+		}
+	}
+	return latest, nil
+}
+
+func (s *SQLLitePersistence) newMigrate() (*migrate.Migrate, error) {
+	resources := bindata.Resource(
+		migrations.AssetNames(),
+		func(name string) ([]byte, error) {
+			return migrations.Asset(name)
+		},
+	)
+
+	source, err := bindata.WithInstance(resources)
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := sqlcipher.WithInstance(s.db, &sqlcipher.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.NewWithInstance(
+		"go-bindata",
+		source,
+		"sqlcipher",
+		driver)
+}
+
+// SchemaVersion returns the current schema version of the database.
+func (s *SQLLitePersistence) SchemaVersion() (uint, error) {
+	m, err := s.newMigrate()
+	if err != nil {
+		return 0, err
+	}
+
+	version, dirty, err := m.Version()
+	if err == migrate.ErrNilVersion {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, migrate.ErrDirty{Version: int(version)}
+	}
+	return version, nil
+}
+
+// MigrateTo migrates the schema of the database to the specified version,
+// running the down-migrations if the version is older than the current one.
+func (s *SQLLitePersistence) MigrateTo(version uint) error {
+	m, err := s.newMigrate()
+	if err != nil {
+		return err
+	}
+
+	if err = m.Migrate(version); err != migrate.ErrNoChange {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SQLLitePersistence) setup(deferMigrations bool) error {
+	current, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	latest, err := LatestSchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	if current > latest {
+		return ErrDatabaseTooNew
+	}
+	if deferMigrations {
+		return nil
+	}
+
+	if err := s.MigrateTo(latest); err != nil {
+		return err
+	}
+	return s.EncryptHistoryMessages()
+}
+
+// Schema is the schema of a chat database, migrated by a schema.Coordinator.
+type Schema struct {
+	name        string
+	persistence *SQLLitePersistence
+}
+
+// NewSchema returns the schema of an opened database.
+func NewSchema(name string, persistence *SQLLitePersistence) *Schema {
+	return &Schema{
+		name:        name,
+		persistence: persistence,
+	}
+}
+
+// Name returns the name of the database.
+func (s *Schema) Name() string {
+	return s.name
+}
+
+// Migrations returns the migrations of the schema of the chat database.
+func (s *Schema) Migrations() ([]schema.Migration, error) {
+	return Migrations()
+}
+
+// Version returns the version of the last applied migration.
+func (s *Schema) Version() (uint, bool, bool, error) {
+	m, err := s.persistence.newMigrate()
+	if err != nil {
+		return 0, false, false, err
+	}
+	version, dirty, err := m.Version()
+	if err == migrate.ErrNilVersion {
+		return 0, false, false, nil
+	} else if err != nil {
+		return 0, false, false, err
+	}
+	return version, true, dirty, nil
+}
+
+// Steps applies or rolls back n migrations.
+func (s *Schema) Steps(n int) error {
+	m, err := s.persistence.newMigrate()
+	if err != nil {
+		return err
+	}
+	return m.Steps(n)
+}
+
+// Force records the version of the last applied migration without running it.
+func (s *Schema) Force(version int) error {
+	m, err := s.persistence.newMigrate()
+	if err != nil {
+		return err
+	}
+	return m.Force(version)
+}
+
+// Backup writes an encrypted copy of the database to path.
+func (s *Schema) Backup(path string) error {
+	return s.persistence.Backup(path)
+}
@@ -0,0 +1,130 @@
+package chat
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/status-im/status-go/services/shhext/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaMigrations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "status-schema")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := DefaultPersistenceConfig()
+	config.DeferMigrations = true
+	persistence, err := NewSQLLitePersistence(filepath.Join(dir, "chat.db"), "key", config)
+	require.NoError(t, err)
+	defer persistence.Close()
+
+	migrations, err := Migrations()
+	require.NoError(t, err)
+	latest, err := LatestSchemaVersion()
+	require.NoError(t, err)
+	require.Equal(t, latest, migrations[len(migrations)-1].Version)
+	require.Equal(t, "initial_schema", migrations[0].Name)
+
+	coordinator := schema.NewCoordinator(filepath.Join(dir, "backups"))
+	coordinator.Register(NewSchema("chat", persistence))
+	status, err := coordinator.Status()
+	require.NoError(t, err)
+	require.Len(t, status, 1)
+	require.Empty(t, status[0].Applied, "Migrations are deferred")
+	require.Equal(t, migrations, status[0].Pending)
+
+	result, err := coordinator.Migrate("chat", schema.Options{})
+	require.NoError(t, err)
+	require.Equal(t, latest, result.To)
+	version, err := persistence.SchemaVersion()
+	require.NoError(t, err)
+	require.Equal(t, latest, version)
+
+	result, err = coordinator.Rollback("chat")
+	require.NoError(t, err)
+	require.Equal(t, latest, result.RolledBack.Version)
+	result, err = coordinator.Migrate("chat", schema.Options{Backup: true})
+	require.NoError(t, err)
+	require.Len(t, result.Applied, 1)
+
+	backup, err := NewSQLLitePersistence(result.Backup, "key", config)
+	require.NoError(t, err, "The backup is encrypted with the same key")
+	version, err = backup.SchemaVersion()
+	require.NoError(t, err)
+	require.Equal(t, migrations[len(migrations)-2].Version, version, "The backup is taken before migrating")
+	require.NoError(t, backup.Close())
+}
+
+func TestEncryptHistoryMessages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "status-history")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := DefaultPersistenceConfig()
+	config.DeferMigrations = true
+	persistence, err := NewSQLLitePersistence(filepath.Join(dir, "chat.db"), "key", config)
+	require.NoError(t, err)
+	defer persistence.Close()
+
+	require.NoError(t, persistence.MigrateTo(1547122800))
+	_, err = persistence.db.Exec(`INSERT INTO history_messages(id, chat_id, author, content, content_type, message_type,
+				      reply_to, clock, timestamp, outgoing)
+				      VALUES ('1', 'chat', 'alice', 'hello world', 'text/plain', 'public-group-user-message', '', 1, 1, 0)`)
+	require.NoError(t, err)
+
+	latest, err := LatestSchemaVersion()
+	require.NoError(t, err)
+	require.NoError(t, persistence.MigrateTo(latest))
+	require.NoError(t, persistence.EncryptHistoryMessages())
+
+	var content []byte
+	var tokens string
+	require.NoError(t, persistence.db.QueryRow(`SELECT content, tokens FROM history_messages`).Scan(&content, &tokens))
+	require.NotContains(t, string(content), "hello", "The content is encrypted")
+	require.NotContains(t, tokens, "hello", "The content is indexed by its keyed tokens")
+
+	found, err := persistence.SearchHistoryMessages("wor", "", 10)
+	require.NoError(t, err)
+	require.Len(t, found, 1, "Messages saved before the migration are searchable")
+	require.Equal(t, "hello world", found[0].Content)
+}
+
+func TestDropAccountNamespace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "status-accounts")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := DefaultPersistenceConfig()
+	config.DeferMigrations = true
+	persistence, err := NewSQLLitePersistence(filepath.Join(dir, "chat.db"), "key", config)
+	require.NoError(t, err)
+	defer persistence.Close()
+
+	require.NoError(t, persistence.MigrateTo(1547209200))
+	_, err = persistence.db.Exec(`INSERT INTO legacy_account(account) VALUES ('aa')`)
+	require.NoError(t, err)
+	_, err = persistence.db.Exec(`INSERT INTO contacts(account, public_key, name, warnings)
+				      VALUES ('aa', '0x01', 'alice', 'null'), ('bb', '0x02', 'bob', 'null')`)
+	require.NoError(t, err)
+	_, err = persistence.db.Exec(`INSERT INTO sessions(id, send_chain_n, recv_chain_n, step, pn)
+				      VALUES (CAST('aabundle-1' AS BLOB), 0, 0, 0, 0), (CAST('bbbundle-2' AS BLOB), 0, 0, 0, 0)`)
+	require.NoError(t, err)
+
+	latest, err := LatestSchemaVersion()
+	require.NoError(t, err)
+	require.NoError(t, persistence.MigrateTo(latest))
+
+	found, err := persistence.GetContacts()
+	require.NoError(t, err)
+	require.Len(t, found, 1, "The rows of other accounts are deleted")
+	require.Equal(t, "alice", found[0].Name)
+	state, err := persistence.GetSessionStorage().Load([]byte("bundle-1"))
+	require.NoError(t, err)
+	require.NotNil(t, state, "The sessions of the account lose their prefix")
+	state, err = persistence.GetSessionStorage().Load([]byte("bundle-2"))
+	require.NoError(t, err)
+	require.Nil(t, state)
+}
@@ -2,30 +2,165 @@ package chat
 
 import (
 	"crypto/ecdsa"
+	"crypto/rand"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
 
 	_ "github.com/mutecomm/go-sqlcipher" // We require go sqlcipher that overrides default implementation
 	dr "github.com/status-im/doubleratchet"
-	"github.com/status-im/migrate"
-	"github.com/status-im/migrate/database/sqlcipher"
-	"github.com/status-im/migrate/source/go_bindata"
+	"github.com/status-im/status-go/services/shhext/attachments"
+	"github.com/status-im/status-go/services/shhext/chat/capabilities"
 	ecrypto "github.com/status-im/status-go/services/shhext/chat/crypto"
-	"github.com/status-im/status-go/services/shhext/chat/migrations"
+	"github.com/status-im/status-go/services/shhext/chat/fields"
+	"github.com/status-im/status-go/services/shhext/consent"
+	"github.com/status-im/status-go/services/shhext/contacts"
+	"github.com/status-im/status-go/services/shhext/content"
+	"github.com/status-im/status-go/services/shhext/delivery"
+	"github.com/status-im/status-go/services/shhext/downgrade"
+	"github.com/status-im/status-go/services/shhext/history"
+	"github.com/status-im/status-go/services/shhext/inbox"
+	"github.com/status-im/status-go/services/shhext/moderation"
+	"github.com/status-im/status-go/services/shhext/notifications"
+	"github.com/status-im/status-go/services/shhext/outbox"
+	"github.com/status-im/status-go/services/shhext/presence"
+	"github.com/status-im/status-go/services/shhext/receipts"
+	"github.com/status-im/status-go/services/shhext/segmentation"
+	whisper "github.com/status-im/whisper/whisperv6"
 )
 
 // A safe max number of rows
 const maxNumberOfRows = 100000000
 
+// maxQueryHashes is the number of hashes looked up by a single query, below
+// the limit of 999 parameters of SQLite.
+const maxQueryHashes = 500
+
+// encryptBatchSize is the number of messages of the history encrypted by a single transaction.
+const encryptBatchSize = 500
+
+// fieldsSecretLength is the length of the secret of the encrypted fields.
+const fieldsSecretLength = 32
+
+// execer executes statements in a database or a transaction.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
 // SQLLitePersistence represents a persistence service tied to an SQLite database
 type SQLLitePersistence struct {
-	db             *sql.DB
+	db *sql.DB
+	// readers are used by the queries of the clients, so that they don't
+	// wait for the writes of the protocol.
+	readers        *readPool
 	keysStorage    dr.KeysStorage
 	sessionStorage dr.SessionStorage
+	// fields encrypts the content of the messages of the history.
+	fields *fieldsCipher
+	// search is true if SQLite is built with FTS5, which indexes the history.
+	search bool
+}
+
+// fieldsCipher loads the cipher of the encrypted fields once the schema is migrated.
+type fieldsCipher struct {
+	mu     sync.Mutex
+	cipher *fields.Cipher
+}
+
+// PersistenceConfig configures the SQLite database of a SQLLitePersistence.
+// Empty values leave the SQLite defaults untouched.
+type PersistenceConfig struct {
+	// JournalMode is the journal mode of the database, one of
+	// "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL" or "OFF".
+	JournalMode string
+	// BusyTimeout is how long a query waits for a locked database
+	// before failing with SQLITE_BUSY.
+	BusyTimeout time.Duration
+	// CacheSize is the suggested number of pages kept in memory,
+	// or the size of the cache in KiB if negative.
+	CacheSize int
+	// Synchronous is the synchronous level, one of "OFF", "NORMAL", "FULL" or "EXTRA".
+	Synchronous string
+	// ReadConnections is the maximum number of read-only connections opened in addition
+	// to the single connection used for writes. They are only opened in WAL journal mode,
+	// which lets them read while the writer writes.
+	ReadConnections int
+	// DeferMigrations opens the database without applying the pending migrations of
+	// its schema, which are applied with a schema.Coordinator.
+	DeferMigrations bool
+}
+
+// DefaultPersistenceConfig returns the default configuration of the chat database.
+// The WAL journal lets readers proceed during message bursts.
+func DefaultPersistenceConfig() PersistenceConfig {
+	return PersistenceConfig{
+		JournalMode:     "WAL",
+		BusyTimeout:     5 * time.Second,
+		Synchronous:     "NORMAL",
+		ReadConnections: 4,
+	}
+}
+
+var (
+	journalModes      = map[string]bool{"DELETE": true, "TRUNCATE": true, "PERSIST": true, "MEMORY": true, "WAL": true, "OFF": true}
+	synchronousLevels = map[string]bool{"OFF": true, "NORMAL": true, "FULL": true, "EXTRA": true}
+)
+
+// Validate returns an error if the config contains invalid values.
+func (c PersistenceConfig) Validate() error {
+	if c.JournalMode != "" && !journalModes[strings.ToUpper(c.JournalMode)] {
+		return fmt.Errorf("invalid journal mode %s", c.JournalMode)
+	}
+	if c.Synchronous != "" && !synchronousLevels[strings.ToUpper(c.Synchronous)] {
+		return fmt.Errorf("invalid synchronous level %s", c.Synchronous)
+	}
+	if c.BusyTimeout < 0 {
+		return fmt.Errorf("invalid busy timeout %s", c.BusyTimeout)
+	}
+	if c.ReadConnections < 0 {
+		return fmt.Errorf("invalid number of read connections %d", c.ReadConnections)
+	}
+	return nil
+}
+
+// readPragmas returns the statements applying the config to a read-only connection.
+// The journal mode is persisted in the database by the writer.
+func (c PersistenceConfig) readPragmas() []string {
+	var pragmas []string
+	if c.BusyTimeout != 0 {
+		pragmas = append(pragmas, fmt.Sprintf("PRAGMA busy_timeout = %d", c.BusyTimeout/time.Millisecond))
+	}
+	if c.CacheSize != 0 {
+		pragmas = append(pragmas, fmt.Sprintf("PRAGMA cache_size = %d", c.CacheSize))
+	}
+	return append(pragmas, "PRAGMA query_only = 1")
+}
+
+// pragmas returns the statements applying the config to an opened database.
+func (c PersistenceConfig) pragmas() []string {
+	var pragmas []string
+	if c.JournalMode != "" {
+		pragmas = append(pragmas, fmt.Sprintf("PRAGMA journal_mode = %s", strings.ToUpper(c.JournalMode)))
+	}
+	if c.BusyTimeout != 0 {
+		pragmas = append(pragmas, fmt.Sprintf("PRAGMA busy_timeout = %d", c.BusyTimeout/time.Millisecond))
+	}
+	if c.CacheSize != 0 {
+		pragmas = append(pragmas, fmt.Sprintf("PRAGMA cache_size = %d", c.CacheSize))
+	}
+	if c.Synchronous != "" {
+		pragmas = append(pragmas, fmt.Sprintf("PRAGMA synchronous = %s", strings.ToUpper(c.Synchronous)))
+	}
+	return pragmas
 }
 
 // SQLLiteKeysStorage represents a keys persistence service tied to an SQLite database
@@ -38,11 +173,15 @@ type SQLLiteSessionStorage struct {
 	db *sql.DB
 }
 
-// NewSQLLitePersistence creates a new SQLLitePersistence instance, given a path and a key
-func NewSQLLitePersistence(path string, key string) (*SQLLitePersistence, error) {
-	s := &SQLLitePersistence{}
+// NewSQLLitePersistence creates a new SQLLitePersistence instance, given a path, a key and a config
+func NewSQLLitePersistence(path string, key string, config PersistenceConfig) (*SQLLitePersistence, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 
-	if err := s.Open(path, key); err != nil {
+	s := &SQLLitePersistence{fields: &fieldsCipher{}}
+
+	if err := s.Open(path, key, config); err != nil {
 		return nil, err
 	}
 
@@ -53,6 +192,22 @@ func NewSQLLitePersistence(path string, key string) (*SQLLitePersistence, error)
 	return s, nil
 }
 
+// accountDB is the database of the persistence of an account, shared by the stores of
+// the services using it.
+type accountDB struct {
+	db *sql.DB
+}
+
+func (s *SQLLitePersistence) accountDB() accountDB {
+	return accountDB{db: s.db}
+}
+
+// sessionID returns the ID of a double ratchet session.
+func sessionID(bundleID []byte, installationID string) []byte {
+	id := append([]byte{}, bundleID...)
+	return append(id, []byte(installationID)...)
+}
+
 func MigrateDBFile(oldPath string, newPath string, oldKey string, newKey string) error {
 	_, err := os.Stat(oldPath)
 
@@ -70,7 +225,7 @@ func MigrateDBFile(oldPath string, newPath string, oldKey string, newKey string)
 		return err
 	}
 
-	db, err := openDB(newPath, oldKey)
+	db, err := openDB(newPath, oldKey, PersistenceConfig{})
 	if err != nil {
 		return err
 	}
@@ -85,8 +240,8 @@ func MigrateDBFile(oldPath string, newPath string, oldKey string, newKey string)
 
 }
 
-func openDB(path string, key string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", path)
+func openDB(path string, key string, config PersistenceConfig) (*sql.DB, error) {
+	db, err := sql.Open(driverName(), path)
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +262,13 @@ func openDB(path string, key string) (*sql.DB, error) {
 	if _, err = db.Exec("PRAGMA cypher_page_size=4096"); err != nil {
 		return nil, err
 	}
+
+	for _, pragma := range config.pragmas() {
+		if _, err = db.Exec(pragma); err != nil {
+			return nil, err
+		}
+	}
+
 	return db, nil
 }
 
@@ -135,15 +297,96 @@ func (s *SQLLitePersistence) GetSessionStorage() dr.SessionStorage {
 }
 
 // Open opens a file at the specified path
-func (s *SQLLitePersistence) Open(path string, key string) error {
-	db, err := openDB(path, key)
+func (s *SQLLitePersistence) Open(path string, key string, config PersistenceConfig) error {
+	db, err := openDB(path, key, config)
 	if err != nil {
 		return err
 	}
 
 	s.db = db
 
-	return s.setup()
+	// The search of the history requires FTS5, which is enabled with
+	// CGO_CFLAGS=-DSQLITE_ENABLE_FTS5, as set by the Makefile.
+	if err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&s.search); err != nil {
+		return err
+	}
+	if !s.search {
+		log.Warn("sqlite is built without FTS5, the search of the history is disabled")
+	}
+
+	if err := s.setup(config.DeferMigrations); err != nil {
+		return err
+	}
+
+	if config.ReadConnections > 0 && strings.ToUpper(config.JournalMode) == "WAL" && path != ":memory:" {
+		s.readers = &readPool{path: path, config: config}
+		return s.readers.open(key)
+	}
+	return nil
+}
+
+// Close closes the database and its read-only connections.
+func (s *SQLLitePersistence) Close() error {
+	if s.readers != nil {
+		if err := s.readers.close(); err != nil {
+			return err
+		}
+	}
+	return s.db.Close()
+}
+
+// Rekey re-encrypts the database with a new key. The read-only connections
+// are reopened with the new key.
+func (s *SQLLitePersistence) Rekey(key string) error {
+	if _, err := s.db.Exec(fmt.Sprintf("PRAGMA rekey = '%s'", key)); err != nil {
+		return err
+	}
+	if s.readers != nil {
+		return s.readers.open(key)
+	}
+	return nil
+}
+
+// Backup writes an encrypted copy of the database to path, with the same key. The path
+// must not exist.
+func (s *SQLLitePersistence) Backup(path string) error {
+	if _, err := s.db.Exec(`ATTACH DATABASE ? AS backup`, path); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`SELECT sqlcipher_export('backup')`)
+	if _, detachErr := s.db.Exec(`DETACH DATABASE backup`); err == nil {
+		err = detachErr
+	}
+	return err
+}
+
+// IntegrityCheck checks the integrity of the database, returning the problems found,
+// or "ok" if there are none.
+func (s *SQLLitePersistence) IntegrityCheck() ([]string, error) {
+	rows, err := s.db.Query(`PRAGMA integrity_check`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// reader returns the database used for the reads of the clients, which is
+// the writer connection if there are no read-only connections.
+func (s *SQLLitePersistence) reader() *sql.DB {
+	if s.readers == nil {
+		return s.db
+	}
+	return s.readers.get()
 }
 
 // AddPrivateBundle adds the specified BundleContainer to the database
@@ -156,7 +399,7 @@ func (s *SQLLitePersistence) AddPrivateBundle(bc *BundleContainer) error {
 	for installationID, signedPreKey := range bc.GetBundle().GetSignedPreKeys() {
 		var version uint32
 		stmt, err := tx.Prepare(`SELECT version
-					 FROM bundles
+					 FROM bundles_v3
 					 WHERE installation_id = ? AND identity = ?
 					 ORDER BY version DESC
 					 LIMIT 1`)
@@ -171,7 +414,7 @@ func (s *SQLLitePersistence) AddPrivateBundle(bc *BundleContainer) error {
 			return err
 		}
 
-		stmt, err = tx.Prepare(`INSERT INTO bundles(identity, private_key, signed_pre_key, installation_id, version, timestamp)
+		stmt, err = tx.Prepare(`INSERT INTO bundles_v3(identity, private_key, signed_pre_key, installation_id, version, timestamp)
 					VALUES(?, ?, ?, ?, ?, ?)`)
 		if err != nil {
 			return err
@@ -211,8 +454,8 @@ func (s *SQLLitePersistence) AddPublicBundle(b *Bundle) error {
 	for installationID, signedPreKeyContainer := range b.GetSignedPreKeys() {
 		signedPreKey := signedPreKeyContainer.GetSignedPreKey()
 		version := signedPreKeyContainer.GetVersion()
-		insertStmt, err := tx.Prepare(`INSERT INTO bundles(identity, signed_pre_key, installation_id, version, timestamp)
-					       VALUES( ?, ?, ?, ?, ?)`)
+		insertStmt, err := tx.Prepare(`INSERT INTO bundles_v3(identity, signed_pre_key, installation_id, version, timestamp)
+					       VALUES(?, ?, ?, ?, ?)`)
 		if err != nil {
 			return err
 		}
@@ -230,7 +473,7 @@ func (s *SQLLitePersistence) AddPublicBundle(b *Bundle) error {
 			return err
 		}
 		// Mark old bundles as expired
-		updateStmt, err := tx.Prepare(`UPDATE bundles
+		updateStmt, err := tx.Prepare(`UPDATE bundles_v3
 					       SET expired = 1
 					       WHERE identity = ? AND installation_id = ? AND version < ?`)
 		if err != nil {
@@ -258,7 +501,7 @@ func (s *SQLLitePersistence) GetAnyPrivateBundle(myIdentityKey []byte, installat
 
 	/* #nosec */
 	statement := `SELECT identity, private_key, signed_pre_key, installation_id, timestamp, version
-	              FROM bundles
+	              FROM bundles_v3
 		      WHERE expired = 0 AND identity = ? AND installation_id IN (?` + strings.Repeat(",?", len(installationIDs)-1) + ")"
 	stmt, err := s.db.Prepare(statement)
 	if err != nil {
@@ -330,7 +573,7 @@ func (s *SQLLitePersistence) GetAnyPrivateBundle(myIdentityKey []byte, installat
 // GetPrivateKeyBundle retrieves a private key for a bundle from the database
 func (s *SQLLitePersistence) GetPrivateKeyBundle(bundleID []byte) ([]byte, error) {
 	stmt, err := s.db.Prepare(`SELECT private_key
-				   FROM bundles
+				   FROM bundles_v3
 				   WHERE expired = 0 AND signed_pre_key = ? LIMIT 1`)
 	if err != nil {
 		return nil, err
@@ -352,7 +595,7 @@ func (s *SQLLitePersistence) GetPrivateKeyBundle(bundleID []byte) ([]byte, error
 
 // MarkBundleExpired expires any private bundle for a given identity
 func (s *SQLLitePersistence) MarkBundleExpired(identity []byte) error {
-	stmt, err := s.db.Prepare(`UPDATE bundles
+	stmt, err := s.db.Prepare(`UPDATE bundles_v3
 				   SET expired = 1
 				   WHERE identity = ? AND private_key IS NOT NULL`)
 	if err != nil {
@@ -376,7 +619,7 @@ func (s *SQLLitePersistence) GetPublicBundle(publicKey *ecdsa.PublicKey, install
 
 	/* #nosec */
 	statement := `SELECT signed_pre_key,installation_id, version
-		      FROM bundles
+		      FROM bundles_v3
 		      WHERE expired = 0 AND identity = ? AND installation_id IN (?` + strings.Repeat(",?", len(installationIDs)-1) + `)
 		      ORDER BY version DESC`
 	stmt, err := s.db.Prepare(statement)
@@ -436,7 +679,7 @@ func (s *SQLLitePersistence) GetPublicBundle(publicKey *ecdsa.PublicKey, install
 
 // AddRatchetInfo persists the specified ratchet info into the database
 func (s *SQLLitePersistence) AddRatchetInfo(key []byte, identity []byte, bundleID []byte, ephemeralKey []byte, installationID string) error {
-	stmt, err := s.db.Prepare(`INSERT INTO ratchet_info_v2(symmetric_key, identity, bundle_id, ephemeral_key, installation_id)
+	stmt, err := s.db.Prepare(`INSERT INTO ratchet_info_v5(symmetric_key, identity, bundle_id, ephemeral_key, installation_id)
 				   VALUES(?, ?, ?, ?, ?)`)
 	if err != nil {
 		return err
@@ -456,9 +699,9 @@ func (s *SQLLitePersistence) AddRatchetInfo(key []byte, identity []byte, bundleI
 
 // GetRatchetInfo retrieves the existing RatchetInfo for a specified bundle ID and interlocutor public key from the database
 func (s *SQLLitePersistence) GetRatchetInfo(bundleID []byte, theirIdentity []byte, installationID string) (*RatchetInfo, error) {
-	stmt, err := s.db.Prepare(`SELECT ratchet_info_v2.identity, ratchet_info_v2.symmetric_key, bundles.private_key, bundles.signed_pre_key, ratchet_info_v2.ephemeral_key, ratchet_info_v2.installation_id
-				   FROM ratchet_info_v2 JOIN bundles ON bundle_id = signed_pre_key
-				   WHERE ratchet_info_v2.identity = ? AND ratchet_info_v2.installation_id = ? AND bundle_id = ?
+	stmt, err := s.db.Prepare(`SELECT ratchet_info_v5.identity, ratchet_info_v5.symmetric_key, bundles_v3.private_key, bundles_v3.signed_pre_key, ratchet_info_v5.ephemeral_key, ratchet_info_v5.installation_id
+				   FROM ratchet_info_v5 JOIN bundles_v3 ON bundle_id = signed_pre_key
+				   WHERE ratchet_info_v5.identity = ? AND ratchet_info_v5.installation_id = ? AND bundle_id = ?
 				   LIMIT 1`)
 	if err != nil {
 		return nil, err
@@ -481,7 +724,7 @@ func (s *SQLLitePersistence) GetRatchetInfo(bundleID []byte, theirIdentity []byt
 	case sql.ErrNoRows:
 		return nil, nil
 	case nil:
-		ratchetInfo.ID = append(bundleID, []byte(ratchetInfo.InstallationID)...)
+		ratchetInfo.ID = sessionID(bundleID, ratchetInfo.InstallationID)
 		return ratchetInfo, nil
 	default:
 		return nil, err
@@ -490,9 +733,9 @@ func (s *SQLLitePersistence) GetRatchetInfo(bundleID []byte, theirIdentity []byt
 
 // GetAnyRatchetInfo retrieves any existing RatchetInfo for a specified interlocutor public key from the database
 func (s *SQLLitePersistence) GetAnyRatchetInfo(identity []byte, installationID string) (*RatchetInfo, error) {
-	stmt, err := s.db.Prepare(`SELECT symmetric_key, bundles.private_key, signed_pre_key, bundle_id, ephemeral_key
-				   FROM ratchet_info_v2 JOIN bundles ON bundle_id = signed_pre_key
-				   WHERE expired = 0 AND ratchet_info_v2.identity = ? AND ratchet_info_v2.installation_id = ?
+	stmt, err := s.db.Prepare(`SELECT symmetric_key, bundles_v3.private_key, signed_pre_key, bundle_id, ephemeral_key
+				   FROM ratchet_info_v5 JOIN bundles_v3 ON bundle_id = signed_pre_key
+				   WHERE expired = 0 AND ratchet_info_v5.identity = ? AND ratchet_info_v5.installation_id = ?
 				   LIMIT 1`)
 	if err != nil {
 		return nil, err
@@ -515,7 +758,7 @@ func (s *SQLLitePersistence) GetAnyRatchetInfo(identity []byte, installationID s
 	case sql.ErrNoRows:
 		return nil, nil
 	case nil:
-		ratchetInfo.ID = append(ratchetInfo.BundleID, []byte(installationID)...)
+		ratchetInfo.ID = sessionID(ratchetInfo.BundleID, installationID)
 		return ratchetInfo, nil
 	default:
 		return nil, err
@@ -525,7 +768,7 @@ func (s *SQLLitePersistence) GetAnyRatchetInfo(identity []byte, installationID s
 // RatchetInfoConfirmed clears the ephemeral key in the RatchetInfo
 // associated with the specified bundle ID and interlocutor identity public key
 func (s *SQLLitePersistence) RatchetInfoConfirmed(bundleID []byte, theirIdentity []byte, installationID string) error {
-	stmt, err := s.db.Prepare(`UPDATE ratchet_info_v2
+	stmt, err := s.db.Prepare(`UPDATE ratchet_info_v5
 	                           SET ephemeral_key = NULL
 				   WHERE identity = ? AND bundle_id = ? AND installation_id = ?`)
 	if err != nil {
@@ -796,7 +1039,7 @@ func (s *SQLLiteSessionStorage) Load(id []byte) (*dr.State, error) {
 // GetActiveInstallations returns the active installations for a given identity
 func (s *SQLLitePersistence) GetActiveInstallations(maxInstallations int, identity []byte) ([]string, error) {
 	stmt, err := s.db.Prepare(`SELECT installation_id
-				   FROM installations
+				   FROM installations_v3
 				   WHERE enabled = 1 AND identity = ?
 				   ORDER BY timestamp DESC
 				   LIMIT ?`)
@@ -834,7 +1077,7 @@ func (s *SQLLitePersistence) AddInstallations(identity []byte, timestamp int64,
 
 	for _, installationID := range installationIDs {
 		stmt, err := tx.Prepare(`SELECT enabled
-					 FROM installations
+					 FROM installations_v3
 					 WHERE identity = ? AND installation_id = ?
 					 LIMIT 1`)
 		if err != nil {
@@ -851,7 +1094,7 @@ func (s *SQLLitePersistence) AddInstallations(identity []byte, timestamp int64,
 
 		// We update timestamp if present without changing enabled
 		if err != sql.ErrNoRows {
-			stmt, err = tx.Prepare(`UPDATE installations
+			stmt, err = tx.Prepare(`UPDATE installations_v3
 					        SET timestamp = ?,  enabled = ?
 						WHERE identity = ? AND installation_id = ?`)
 			if err != nil {
@@ -870,7 +1113,7 @@ func (s *SQLLitePersistence) AddInstallations(identity []byte, timestamp int64,
 			defer stmt.Close()
 
 		} else {
-			stmt, err = tx.Prepare(`INSERT INTO installations(identity, installation_id, timestamp, enabled)
+			stmt, err = tx.Prepare(`INSERT INTO installations_v3(identity, installation_id, timestamp, enabled)
 						VALUES (?, ?, ?, ?)`)
 			if err != nil {
 				return err
@@ -901,8 +1144,8 @@ func (s *SQLLitePersistence) AddInstallations(identity []byte, timestamp int64,
 
 // EnableInstallation enables the installation
 func (s *SQLLitePersistence) EnableInstallation(identity []byte, installationID string) error {
-	stmt, err := s.db.Prepare(`UPDATE installations
-				   SET enabled = 1
+	stmt, err := s.db.Prepare(`UPDATE installations_v3
+				   SET enabled = 1, disabled_at = 0
 				   WHERE identity = ? AND installation_id = ?`)
 	if err != nil {
 		return err
@@ -916,53 +1159,1614 @@ func (s *SQLLitePersistence) EnableInstallation(identity []byte, installationID
 // DisableInstallation disable the installation
 func (s *SQLLitePersistence) DisableInstallation(identity []byte, installationID string) error {
 
-	stmt, err := s.db.Prepare(`UPDATE installations
-				   SET enabled = 0
-				   WHERE identity = ? AND installation_id = ?`)
+	stmt, err := s.db.Prepare(`UPDATE installations_v3
+				   SET enabled = 0, disabled_at = ?
+				   WHERE identity = ? AND installation_id = ? AND enabled = 1`)
 	if err != nil {
 		return err
 	}
 
-	_, err = stmt.Exec(identity, installationID)
+	_, err = stmt.Exec(time.Now().Unix(), identity, installationID)
 	return err
 }
 
-func toKey(a []byte) dr.Key {
-	var k [32]byte
-	copy(k[:], a)
-	return k
+// CleanupInstallations deletes the bundles, ratchet info, sessions and skipped keys
+// of installations disabled before a unix timestamp. The installations themselves
+// are kept, so that they are not enabled again when their bundles are received.
+// Bundles with a private key are never deleted.
+func (s *SQLLitePersistence) CleanupInstallations(identity []byte, disabledBefore int64) (*InstallationsCleanup, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	rows, err := tx.Query(`SELECT installation_id
+			       FROM installations_v3
+			       WHERE identity = ? AND enabled = 0 AND disabled_at > 0 AND disabled_at < ?`,
+		identity, disabledBefore)
+	if err != nil {
+		return nil, err
+	}
+	var installationIDs []string
+	for rows.Next() {
+		var installationID string
+		if err = rows.Scan(&installationID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		installationIDs = append(installationIDs, installationID)
+	}
+	rows.Close()
+
+	result := &InstallationsCleanup{}
+	for _, installationID := range installationIDs {
+		var deleted int
+		deleted, err = s.cleanupInstallation(tx, identity, installationID, result)
+		if err != nil {
+			return nil, err
+		}
+		if deleted > 0 {
+			result.Installations++
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
-func (s *SQLLitePersistence) setup() error {
-	resources := bindata.Resource(
-		migrations.AssetNames(),
-		func(name string) ([]byte, error) {
-			return migrations.Asset(name)
-		},
-	)
+func (s *SQLLitePersistence) cleanupInstallation(tx *sql.Tx, identity []byte, installationID string, result *InstallationsCleanup) (int, error) {
+	rows, err := tx.Query(`SELECT bundle_id
+			       FROM ratchet_info_v5
+			       WHERE identity = ? AND installation_id = ?`,
+		identity, installationID)
+	if err != nil {
+		return 0, err
+	}
+	var sessionIDs [][]byte
+	for rows.Next() {
+		var bundleID []byte
+		if err := rows.Scan(&bundleID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		sessionIDs = append(sessionIDs, sessionID(bundleID, installationID))
+	}
+	rows.Close()
+
+	var total int
+	for _, sessionID := range sessionIDs {
+		keys, err := execCount(tx, `DELETE FROM keys WHERE session_id = ?`, sessionID)
+		if err != nil {
+			return 0, err
+		}
+		sessions, err := execCount(tx, `DELETE FROM sessions WHERE id = ?`, sessionID)
+		if err != nil {
+			return 0, err
+		}
+		result.SkippedKeys += keys
+		result.Sessions += sessions
+		total += keys + sessions
+	}
+
+	ratchetInfos, err := execCount(tx, `DELETE FROM ratchet_info_v5 WHERE identity = ? AND installation_id = ?`, identity, installationID)
+	if err != nil {
+		return 0, err
+	}
+	bundles, err := execCount(tx, `DELETE FROM bundles_v3 WHERE identity = ? AND installation_id = ? AND private_key IS NULL`, identity, installationID)
+	if err != nil {
+		return 0, err
+	}
+	result.RatchetInfos += ratchetInfos
+	result.Bundles += bundles
+
+	return total + ratchetInfos + bundles, nil
+}
+
+func execCount(tx *sql.Tx, query string, args ...interface{}) (int, error) {
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	count, err := res.RowsAffected()
+	return int(count), err
+}
+
+// SetChatLanguage sets the language messages on a given topic are translated to.
+// An empty language disables translations for the topic.
+func (s *SQLLitePersistence) SetChatLanguage(topic []byte, language string) error {
+	stmt, err := s.db.Prepare(`INSERT INTO chat_settings_v3(topic, language)
+				   VALUES (?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(topic, language)
+	return err
+}
+
+// GetChatLanguage returns the language set for a given topic, if any
+func (s *SQLLitePersistence) GetChatLanguage(topic []byte) (string, error) {
+	stmt, err := s.db.Prepare(`SELECT language
+				   FROM chat_settings_v3
+				   WHERE topic = ?`)
+	if err != nil {
+		return "", err
+	}
+	defer stmt.Close()
+
+	var language string
+	err = stmt.QueryRow(topic).Scan(&language)
+	switch err {
+	case sql.ErrNoRows:
+		return "", nil
+	case nil:
+		return language, nil
+	default:
+		return "", err
+	}
+}
+
+// SaveModeratedChannel persists the moderation state of a public channel
+func (s *SQLLitePersistence) SaveModeratedChannel(channel moderation.Channel) error {
+	var list []byte
+	if channel.List != nil {
+		var err error
+		if list, err = json.Marshal(channel.List); err != nil {
+			return err
+		}
+	}
+
+	stmt, err := s.db.Prepare(`INSERT INTO moderated_channels(topic, chat_id, moderator, mode, list)
+				   VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(channel.Topic[:], channel.ChatID, channel.Moderator, channel.Mode, list)
+	return err
+}
+
+// GetModeratedChannel returns the moderation state of a public channel, if any
+func (s *SQLLitePersistence) GetModeratedChannel(topic []byte) (*moderation.Channel, error) {
+	stmt, err := s.db.Prepare(`SELECT chat_id, moderator, mode, list
+				   FROM moderated_channels
+				   WHERE topic = ?`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	channel := &moderation.Channel{Topic: whisper.BytesToTopic(topic)}
+	var list []byte
+	err = stmt.QueryRow(topic).Scan(&channel.ChatID, &channel.Moderator, &channel.Mode, &list)
+	switch err {
+	case sql.ErrNoRows:
+		return nil, nil
+	case nil:
+	default:
+		return nil, err
+	}
+
+	if len(list) > 0 {
+		channel.List = &moderation.List{}
+		if err := json.Unmarshal(list, channel.List); err != nil {
+			return nil, err
+		}
+	}
+	return channel, nil
+}
+
+// IsDuplicate returns true if a message was already processed
+func (s *SQLLitePersistence) IsDuplicate(sender []byte, messageHash []byte, installationID string) (bool, error) {
+	stmt, err := s.db.Prepare(`SELECT COUNT(*)
+				   FROM processed_messages
+				   WHERE sender = ? AND message_hash = ? AND installation_id = ?`)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var count int
+	if err := stmt.QueryRow(sender, messageHash, installationID).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
 
-	source, err := bindata.WithInstance(resources)
+// MarkProcessed records that a message was processed at a timestamp in milliseconds
+func (s *SQLLitePersistence) MarkProcessed(sender []byte, messageHash []byte, installationID string, timestamp int64) error {
+	stmt, err := s.db.Prepare(`INSERT INTO processed_messages(sender, message_hash, installation_id, timestamp)
+				   VALUES (?, ?, ?, ?)`)
 	if err != nil {
 		return err
 	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(sender, messageHash, installationID, timestamp)
+	return err
+}
+
+// PruneProcessed deletes the messages processed before a timestamp in milliseconds
+func (s *SQLLitePersistence) PruneProcessed(before int64) (int, error) {
+	res, err := s.db.Exec(`DELETE FROM processed_messages WHERE timestamp < ?`, before)
+	if err != nil {
+		return 0, err
+	}
+	count, err := res.RowsAffected()
+	return int(count), err
+}
 
-	driver, err := sqlcipher.WithInstance(s.db, &sqlcipher.Config{})
+// SaveReceiptSummary persists the aggregated receipts of a group message
+func (s *SQLLitePersistence) SaveReceiptSummary(summary receipts.Summary) error {
+	stmt, err := s.db.Prepare(`INSERT INTO group_receipts(message_id, total, delivered, read)
+				   VALUES (?, ?, ?, ?)`)
 	if err != nil {
 		return err
 	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(summary.MessageID, summary.Total, summary.Delivered, summary.Read)
+	return err
+}
+
+// GetReceiptSummary returns the aggregated receipts of a group message, if any
+func (s *SQLLitePersistence) GetReceiptSummary(messageID string) (*receipts.Summary, error) {
+	stmt, err := s.reader().Prepare(`SELECT total, delivered, read
+				   FROM group_receipts
+				   WHERE message_id = ?`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	summary := &receipts.Summary{MessageID: messageID}
+	err = stmt.QueryRow(messageID).Scan(&summary.Total, &summary.Delivered, &summary.Read)
+	switch err {
+	case sql.ErrNoRows:
+		return nil, nil
+	case nil:
+		return summary, nil
+	default:
+		return nil, err
+	}
+}
 
-	m, err := migrate.NewWithInstance(
-		"go-bindata",
-		source,
-		"sqlcipher",
-		driver)
+// SaveEnvelopeState persists the delivery state of an envelope, unless it already reached a later one
+func (s *SQLLitePersistence) SaveEnvelopeState(status delivery.Status) error {
+	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
 
-	if err = m.Up(); err != migrate.ErrNoChange {
+	if _, err = tx.Exec(`INSERT OR IGNORE INTO envelope_states(hash, state, updated_at)
+			      VALUES (?, ?, ?)`, status.Hash[:], status.State, status.UpdatedAt); err != nil {
+		_ = tx.Rollback()
 		return err
 	}
 
-	return nil
+	if _, err = tx.Exec(`UPDATE envelope_states
+			      SET state = ?, updated_at = ?
+			      WHERE hash = ? AND state < ?`,
+		status.State, status.UpdatedAt, status.Hash[:], status.State); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetEnvelopeStates returns the persisted delivery states of the given envelopes
+func (s *SQLLitePersistence) GetEnvelopeStates(hashes []common.Hash) ([]delivery.Status, error) {
+	var result []delivery.Status
+	for start := 0; start < len(hashes); start += maxQueryHashes {
+		end := start + maxQueryHashes
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+		statuses, err := s.getEnvelopeStates(hashes[start:end])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, statuses...)
+	}
+	return result, nil
+}
+
+func (s *SQLLitePersistence) getEnvelopeStates(hashes []common.Hash) ([]delivery.Status, error) {
+	args := make([]interface{}, 0, len(hashes))
+	for _, hash := range hashes {
+		args = append(args, hash[:])
+	}
+	rows, err := s.reader().Query(`SELECT hash, state, updated_at
+				 FROM envelope_states
+				 WHERE hash IN (?`+strings.Repeat(",?", len(hashes)-1)+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []delivery.Status
+	for rows.Next() {
+		var (
+			hash   []byte
+			status delivery.Status
+		)
+		if err := rows.Scan(&hash, &status.State, &status.UpdatedAt); err != nil {
+			return nil, err
+		}
+		status.Hash = common.BytesToHash(hash)
+		result = append(result, status)
+	}
+	return result, rows.Err()
+}
+
+// SaveContactMailServer persists the enode of the mailserver used by a contact, or removes it if empty
+func (s *SQLLitePersistence) SaveContactMailServer(publicKey []byte, enode string) error {
+	if enode == "" {
+		_, err := s.db.Exec(`DELETE FROM contact_mailservers WHERE public_key = ?`, publicKey)
+		return err
+	}
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO contact_mailservers(public_key, enode)
+			     VALUES (?, ?)`, publicKey, enode)
+	return err
+}
+
+// GetContactMailServer returns the enode of the mailserver used by a contact, or an empty string
+func (s *SQLLitePersistence) GetContactMailServer(publicKey []byte) (string, error) {
+	var enode string
+	err := s.db.QueryRow(`SELECT enode
+			      FROM contact_mailservers
+			      WHERE public_key = ?`, publicKey).Scan(&enode)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return enode, err
+}
+
+// SetCompressionDictionaries persists the versions of the compression dictionaries supported by an installation
+func (s *SQLLitePersistence) SetCompressionDictionaries(identity []byte, installationID string, versions []uint32) error {
+	encoded, err := json.Marshal(versions)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT OR REPLACE INTO compression_dictionaries(identity, installation_id, versions)
+			     VALUES (?, ?, ?)`, identity, installationID, string(encoded))
+	return err
+}
+
+// GetCompressionDictionaries returns the versions of the compression dictionaries supported by the given installations
+func (s *SQLLitePersistence) GetCompressionDictionaries(identity []byte, installationIDs []string) (map[string][]uint32, error) {
+	response := make(map[string][]uint32)
+	if len(installationIDs) == 0 {
+		return response, nil
+	}
+
+	/* #nosec */
+	statement := `SELECT installation_id, versions
+		      FROM compression_dictionaries
+		      WHERE identity = ? AND installation_id IN (?` + strings.Repeat(",?", len(installationIDs)-1) + `)`
+	args := make([]interface{}, len(installationIDs)+1)
+	args[0] = identity
+	for i, installationID := range installationIDs {
+		args[i+1] = installationID
+	}
+
+	rows, err := s.db.Query(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var installationID, encoded string
+		if err := rows.Scan(&installationID, &encoded); err != nil {
+			return nil, err
+		}
+		var versions []uint32
+		if err := json.Unmarshal([]byte(encoded), &versions); err != nil {
+			return nil, err
+		}
+		response[installationID] = versions
+	}
+	return response, rows.Err()
+}
+
+// SetContactTopic persists the topic an installation listens on for direct messages
+func (s *SQLLitePersistence) SetContactTopic(identity []byte, installationID string, topic []byte) error {
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO contact_topics(identity, installation_id, topic)
+			     VALUES (?, ?, ?)`, identity, installationID, topic)
+	return err
+}
+
+// GetContactTopics returns the topics the given installations listen on for direct messages
+func (s *SQLLitePersistence) GetContactTopics(identity []byte, installationIDs []string) (map[string][]byte, error) {
+	response := make(map[string][]byte)
+	if len(installationIDs) == 0 {
+		return response, nil
+	}
+
+	/* #nosec */
+	statement := `SELECT installation_id, topic
+		      FROM contact_topics
+		      WHERE identity = ? AND installation_id IN (?` + strings.Repeat(",?", len(installationIDs)-1) + `)`
+	args := make([]interface{}, len(installationIDs)+1)
+	args[0] = identity
+	for i, installationID := range installationIDs {
+		args[i+1] = installationID
+	}
+
+	rows, err := s.db.Query(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var installationID string
+		var topic []byte
+		if err := rows.Scan(&installationID, &topic); err != nil {
+			return nil, err
+		}
+		response[installationID] = topic
+	}
+	return response, rows.Err()
+}
+
+// SetContactCapabilities persists the protocol version and features supported by an installation
+func (s *SQLLitePersistence) SetContactCapabilities(identity []byte, installationID string, set capabilities.Set) error {
+	encoded, err := json.Marshal(set.Features)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT OR REPLACE INTO contact_capabilities(identity, installation_id, version, features)
+			     VALUES (?, ?, ?, ?)`, identity, installationID, set.Version, string(encoded))
+	return err
+}
+
+// GetContactCapabilities returns the capabilities of the given installations
+func (s *SQLLitePersistence) GetContactCapabilities(identity []byte, installationIDs []string) (map[string]capabilities.Set, error) {
+	response := make(map[string]capabilities.Set)
+	if len(installationIDs) == 0 {
+		return response, nil
+	}
+
+	/* #nosec */
+	statement := `SELECT installation_id, version, features
+		      FROM contact_capabilities
+		      WHERE identity = ? AND installation_id IN (?` + strings.Repeat(",?", len(installationIDs)-1) + `)`
+	args := make([]interface{}, len(installationIDs)+1)
+	args[0] = identity
+	for i, installationID := range installationIDs {
+		args[i+1] = installationID
+	}
+
+	rows, err := s.db.Query(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			installationID, encoded string
+			set                     capabilities.Set
+		)
+		if err := rows.Scan(&installationID, &set.Version, &encoded); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(encoded), &set.Features); err != nil {
+			return nil, err
+		}
+		response[installationID] = set
+	}
+	return response, rows.Err()
+}
+
+// SavePendingMessage persists a message that couldn't be decrypted yet, replacing it if it exists
+func (s *SQLLitePersistence) SavePendingMessage(message inbox.PendingMessage) error {
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO pending_messages(hash, sender, message, attempts, received_at)
+			     VALUES (?, ?, ?, ?, ?)`, message.Hash, message.Sender, message.Message, message.Attempts, message.ReceivedAt)
+	return err
+}
+
+// GetPendingMessages returns the pending messages of a sender, oldest first
+func (s *SQLLitePersistence) GetPendingMessages(sender []byte) ([]inbox.PendingMessage, error) {
+	rows, err := s.db.Query(`SELECT hash, sender, message, attempts, received_at
+				 FROM pending_messages
+				 WHERE sender = ?
+				 ORDER BY received_at`, sender)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []inbox.PendingMessage
+	for rows.Next() {
+		var message inbox.PendingMessage
+		if err := rows.Scan(&message.Hash, &message.Sender, &message.Message, &message.Attempts, &message.ReceivedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
+
+// DeletePendingMessage deletes a pending message
+func (s *SQLLitePersistence) DeletePendingMessage(hash []byte) error {
+	_, err := s.db.Exec(`DELETE FROM pending_messages WHERE hash = ?`, hash)
+	return err
+}
+
+// CountPendingMessages returns the number of pending messages
+func (s *SQLLitePersistence) CountPendingMessages() (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM pending_messages`).Scan(&count)
+	return count, err
+}
+
+// PrunePendingMessages deletes the messages received before the given time, in milliseconds
+func (s *SQLLitePersistence) PrunePendingMessages(before int64) (int, error) {
+	result, err := s.db.Exec(`DELETE FROM pending_messages WHERE received_at < ?`, before)
+	if err != nil {
+		return 0, err
+	}
+	pruned, err := result.RowsAffected()
+	return int(pruned), err
+}
+
+// SaveSegment persists a segment of a payload, unless its sender already sent the segment
+// with the same index
+func (s *SQLLitePersistence) SaveSegment(segment segmentation.Segment) error {
+	_, err := s.db.Exec(`INSERT OR IGNORE INTO segments(sender, hash, idx, count, data, received_at)
+			     VALUES (?, ?, ?, ?, ?, ?)`,
+		segment.Sender, segment.Hash, segment.Index, segment.Count, segment.Data, segment.ReceivedAt)
+	return err
+}
+
+// GetSegments returns the segments of a payload sent by a sender
+func (s *SQLLitePersistence) GetSegments(sender, hash []byte) ([]segmentation.Segment, error) {
+	rows, err := s.db.Query(`SELECT sender, hash, idx, count, data, received_at
+				 FROM segments
+				 WHERE sender = ? AND hash = ?
+				 ORDER BY idx`, sender, hash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var segments []segmentation.Segment
+	for rows.Next() {
+		var segment segmentation.Segment
+		if err := rows.Scan(&segment.Sender, &segment.Hash, &segment.Index, &segment.Count, &segment.Data, &segment.ReceivedAt); err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+	return segments, rows.Err()
+}
+
+// DeleteSegments deletes the segments of a payload sent by a sender
+func (s *SQLLitePersistence) DeleteSegments(sender, hash []byte) error {
+	_, err := s.db.Exec(`DELETE FROM segments WHERE sender = ? AND hash = ?`, sender, hash)
+	return err
+}
+
+// GetPendingSegments returns the number of incomplete payloads of a sender and the size of
+// their segments
+func (s *SQLLitePersistence) GetPendingSegments(sender []byte) (int, int, error) {
+	var payloads, size int
+	err := s.db.QueryRow(`SELECT COUNT(DISTINCT hash), IFNULL(SUM(length(data)), 0)
+			      FROM segments
+			      WHERE sender = ?`, sender).Scan(&payloads, &size)
+	return payloads, size, err
+}
+
+// PruneSegments deletes the segments received before the given time
+func (s *SQLLitePersistence) PruneSegments(before int64) (int, error) {
+	result, err := s.db.Exec(`DELETE FROM segments WHERE received_at < ?`, before)
+	if err != nil {
+		return 0, err
+	}
+	pruned, err := result.RowsAffected()
+	return int(pruned), err
+}
+
+// SaveAttachment persists an attachment, replacing it if it exists
+func (s *SQLLitePersistence) SaveAttachment(attachment attachments.Attachment) error {
+	_, err := s.db.Exec(`INSERT INTO attachments(hash, id, key, content_type, size, state, data, accessed_at)
+			     VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		[]byte(attachment.Hash), attachment.ID, []byte(attachment.Key), attachment.ContentType,
+		attachment.Size, attachment.State, attachment.Data, attachment.AccessedAt)
+	return err
+}
+
+// GetAttachment returns an attachment with its data, if any
+func (s *SQLLitePersistence) GetAttachment(hash []byte) (*attachments.Attachment, error) {
+	attachment := &attachments.Attachment{}
+	var key []byte
+	err := s.reader().QueryRow(`SELECT id, key, content_type, size, state, data, accessed_at
+			      FROM attachments
+			      WHERE hash = ?`, hash).Scan(
+		&attachment.ID, &key, &attachment.ContentType, &attachment.Size, &attachment.State, &attachment.Data, &attachment.AccessedAt)
+	switch err {
+	case sql.ErrNoRows:
+		return nil, nil
+	case nil:
+	default:
+		return nil, err
+	}
+	attachment.Hash = hash
+	attachment.Key = key
+	return attachment, nil
+}
+
+// GetAttachmentInfo returns an attachment without its data
+func (s *SQLLitePersistence) GetAttachmentInfo(hash []byte) (*attachments.Attachment, error) {
+	attachment := &attachments.Attachment{}
+	var key []byte
+	err := s.reader().QueryRow(`SELECT id, key, content_type, size, state, accessed_at
+			      FROM attachments
+			      WHERE hash = ?`, hash).Scan(
+		&attachment.ID, &key, &attachment.ContentType, &attachment.Size, &attachment.State, &attachment.AccessedAt)
+	switch err {
+	case sql.ErrNoRows:
+		return nil, nil
+	case nil:
+	default:
+		return nil, err
+	}
+	attachment.Hash = hash
+	attachment.Key = key
+	return attachment, nil
+}
+
+// ReadAttachment reads the data of a cached attachment from offset into p, and returns
+// io.EOF past the end of the data
+func (s *SQLLitePersistence) ReadAttachment(hash []byte, offset int64, p []byte) (int, error) {
+	var chunk []byte
+	err := s.reader().QueryRow(`SELECT substr(data, ?, ?) FROM attachments WHERE hash = ? AND data IS NOT NULL`,
+		offset+1, len(p), hash).Scan(&chunk)
+	switch err {
+	case sql.ErrNoRows:
+		return 0, io.EOF
+	case nil:
+	default:
+		return 0, err
+	}
+	if len(chunk) == 0 && len(p) > 0 {
+		return 0, io.EOF
+	}
+	return copy(p, chunk), nil
+}
+
+// TouchAttachment updates the time an attachment was last accessed
+func (s *SQLLitePersistence) TouchAttachment(hash []byte, accessedAt int64) error {
+	_, err := s.db.Exec(`UPDATE attachments SET accessed_at = ? WHERE hash = ?`, accessedAt, hash)
+	return err
+}
+
+// GetCachedAttachments returns the cached attachments without their data, least recently accessed first
+func (s *SQLLitePersistence) GetCachedAttachments() ([]attachments.Attachment, error) {
+	rows, err := s.reader().Query(`SELECT hash, id, key, content_type, size, state, accessed_at
+				 FROM attachments
+				 WHERE state = ?
+				 ORDER BY accessed_at`, attachments.Cached)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []attachments.Attachment
+	for rows.Next() {
+		var (
+			attachment attachments.Attachment
+			hash, key  []byte
+		)
+		if err := rows.Scan(&hash, &attachment.ID, &key, &attachment.ContentType, &attachment.Size, &attachment.State, &attachment.AccessedAt); err != nil {
+			return nil, err
+		}
+		attachment.Hash = hash
+		attachment.Key = key
+		result = append(result, attachment)
+	}
+	return result, rows.Err()
+}
+
+// EvictAttachment deletes the data of an attachment, which becomes remote
+func (s *SQLLitePersistence) EvictAttachment(hash []byte) error {
+	_, err := s.db.Exec(`UPDATE attachments SET data = NULL, state = ? WHERE hash = ?`,
+		attachments.Remote, hash)
+	return err
+}
+
+// SaveNotificationPreferences persists the notification preferences of the account
+func (s *SQLLitePersistence) SaveNotificationPreferences(preferences notifications.Preferences) error {
+	encoded, err := notifications.Encode(preferences)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`INSERT INTO notification_preferences(id, preferences, clock) VALUES (1, ?, ?)`,
+		encoded, preferences.Clock)
+	return err
+}
+
+// GetNotificationPreferences returns the notification preferences of the account, if any
+func (s *SQLLitePersistence) GetNotificationPreferences() (*notifications.Preferences, error) {
+	var encoded []byte
+	err := s.db.QueryRow(`SELECT preferences FROM notification_preferences WHERE id = 1`).Scan(&encoded)
+	switch err {
+	case sql.ErrNoRows:
+		return nil, nil
+	case nil:
+	default:
+		return nil, err
+	}
+
+	preferences, err := notifications.Decode(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return &preferences, nil
+}
+
+// SaveMessageAuthor records the author of a message, keeping the first one recorded
+func (s *SQLLitePersistence) SaveMessageAuthor(id string, author string) error {
+	_, err := s.db.Exec(`INSERT INTO message_authors(id, author) VALUES (?, ?)`, id, author)
+	return err
+}
+
+// GetMessageAuthor returns the author of a message, or an empty string if it's unknown
+func (s *SQLLitePersistence) GetMessageAuthor(id string) (string, error) {
+	var author string
+	err := s.db.QueryRow(`SELECT author FROM message_authors WHERE id = ?`, id).Scan(&author)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return author, err
+}
+
+// SaveMessageChange persists an edit or a deletion, replacing the previous one of its author
+func (s *SQLLitePersistence) SaveMessageChange(change content.Change) error {
+	_, err := s.db.Exec(`INSERT INTO message_changes(target_id, author, content, deleted, clock)
+			     VALUES (?, ?, ?, ?, ?)`,
+		change.TargetID, change.Author, change.Content, change.Deleted, change.Clock)
+	return err
+}
+
+// GetMessageChange returns the latest edit or deletion of a message by an author, if any
+func (s *SQLLitePersistence) GetMessageChange(targetID string, author string) (*content.Change, error) {
+	change := &content.Change{TargetID: targetID, Author: author}
+	err := s.db.QueryRow(`SELECT content, deleted, clock
+			      FROM message_changes
+			      WHERE target_id = ? AND author = ?`, targetID, author).Scan(
+		&change.Content, &change.Deleted, &change.Clock)
+	switch err {
+	case sql.ErrNoRows:
+		return nil, nil
+	case nil:
+		return change, nil
+	default:
+		return nil, err
+	}
+}
+
+// SaveMessageReaction persists a reaction, replacing the previous one of its author with the same emoji
+func (s *SQLLitePersistence) SaveMessageReaction(reaction content.Reaction) error {
+	_, err := s.db.Exec(`INSERT INTO message_reactions(target_id, author, emoji, retracted, clock)
+			     VALUES (?, ?, ?, ?, ?)`,
+		reaction.TargetID, reaction.Author, reaction.Emoji, reaction.Retracted, reaction.Clock)
+	return err
+}
+
+// GetMessageReaction returns the latest reaction of an author to a message with an emoji, if any
+func (s *SQLLitePersistence) GetMessageReaction(targetID string, author string, emoji string) (*content.Reaction, error) {
+	reaction := &content.Reaction{TargetID: targetID, Author: author, Emoji: emoji}
+	err := s.db.QueryRow(`SELECT retracted, clock
+			      FROM message_reactions
+			      WHERE target_id = ? AND author = ? AND emoji = ?`, targetID, author, emoji).Scan(
+		&reaction.Retracted, &reaction.Clock)
+	switch err {
+	case sql.ErrNoRows:
+		return nil, nil
+	case nil:
+		return reaction, nil
+	default:
+		return nil, err
+	}
+}
+
+// GetMessageReactions returns the latest reactions to a message, including the retracted ones
+func (s *SQLLitePersistence) GetMessageReactions(targetID string) ([]content.Reaction, error) {
+	rows, err := s.reader().Query(`SELECT author, emoji, retracted, clock
+				 FROM message_reactions
+				 WHERE target_id = ?
+				 ORDER BY clock`, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []content.Reaction
+	for rows.Next() {
+		reaction := content.Reaction{TargetID: targetID}
+		if err := rows.Scan(&reaction.Author, &reaction.Emoji, &reaction.Retracted, &reaction.Clock); err != nil {
+			return nil, err
+		}
+		result = append(result, reaction)
+	}
+	return result, rows.Err()
+}
+
+// SaveHistoryMessage saves a message of the history, unless it's already saved
+func (s *SQLLitePersistence) SaveHistoryMessage(m history.Message) error {
+	cipher, err := s.fieldsCipher()
+	if err != nil {
+		return err
+	}
+	return s.insertHistoryMessage(s.db, cipher, m)
+}
+
+// insertHistoryMessage inserts a message of the history with its content encrypted
+// and indexed by its search tokens.
+func (s *SQLLitePersistence) insertHistoryMessage(db execer, cipher *fields.Cipher, m history.Message) error {
+	encrypted, err := cipher.Encrypt([]byte(m.Content))
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`INSERT INTO history_messages(id, chat_id, author, content, content_type,
+			  message_type, reply_to, clock, timestamp, outgoing, edited, tokens, encrypted)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)`,
+		m.ID, m.ChatID, m.Author, encrypted, m.ContentType,
+		m.MessageType, m.ReplyTo, m.Clock, m.Timestamp, m.Outgoing, m.Edited,
+		strings.Join(cipher.PrefixTokens(m.Content), " "))
+	return err
+}
+
+// UpdateHistoryMessage replaces the content of a message of the history and marks it as edited
+func (s *SQLLitePersistence) UpdateHistoryMessage(id string, content string) error {
+	cipher, err := s.fieldsCipher()
+	if err != nil {
+		return err
+	}
+	encrypted, err := cipher.Encrypt([]byte(content))
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`UPDATE history_messages SET content = ?, tokens = ?, encrypted = 1, edited = 1
+			    WHERE id = ?`,
+		encrypted, strings.Join(cipher.PrefixTokens(content), " "), id)
+	return err
+}
+
+// DeleteHistoryMessage removes a message from the history
+func (s *SQLLitePersistence) DeleteHistoryMessage(id string) error {
+	_, err := s.db.Exec(`DELETE FROM history_messages WHERE id = ?`, id)
+	return err
+}
+
+// GetHistoryMessages returns at most limit messages of a chat preceding the cursor, from the most recent
+func (s *SQLLitePersistence) GetHistoryMessages(chatID string, cursor *history.Cursor, limit int) ([]history.Message, error) {
+	if cursor == nil {
+		return s.queryHistoryMessages(`SELECT `+historyMessageColumns+`
+					       FROM history_messages m
+					       WHERE chat_id = ?
+					       ORDER BY clock DESC, id DESC
+					       LIMIT ?`, chatID, limit)
+	}
+	return s.queryHistoryMessages(`SELECT `+historyMessageColumns+`
+				       FROM history_messages m
+				       WHERE chat_id = ? AND (clock < ? OR (clock = ? AND id < ?))
+				       ORDER BY clock DESC, id DESC
+				       LIMIT ?`, chatID, cursor.Clock, cursor.Clock, cursor.ID, limit)
+}
+
+// SearchHistoryMessages returns at most limit messages with words starting with each word of query,
+// from the most relevant. An empty chatID searches all chats.
+func (s *SQLLitePersistence) SearchHistoryMessages(query string, chatID string, limit int) ([]history.Message, error) {
+	if !s.search {
+		return nil, history.ErrSearchUnavailable
+	}
+	cipher, err := s.fieldsCipher()
+	if err != nil {
+		return nil, err
+	}
+	tokens := cipher.SearchTokens(query)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	return s.queryHistoryMessages(`SELECT `+historyMessageColumns+`
+				       FROM history_messages_fts f
+				       JOIN history_messages m ON m.rowid = f.rowid
+				       WHERE history_messages_fts MATCH ? AND (? = '' OR m.chat_id = ?)
+				       ORDER BY f.rank
+				       LIMIT ?`, strings.Join(tokens, " "), chatID, chatID, limit)
+}
+
+// GetHistoryChats returns the IDs of the chats with stored messages
+func (s *SQLLitePersistence) GetHistoryChats() ([]string, error) {
+	return s.queryStrings(`SELECT DISTINCT chat_id FROM history_messages ORDER BY chat_id`)
+}
+
+// OptimizeHistoryIndex merges the segments of the full-text index of the history, if any
+func (s *SQLLitePersistence) OptimizeHistoryIndex() error {
+	if !s.search {
+		return nil
+	}
+	_, err := s.db.Exec(`INSERT INTO history_messages_fts(history_messages_fts) VALUES ('optimize')`)
+	return err
+}
+
+// EncryptHistoryMessages encrypts the content of the messages of the history saved before it
+// was encrypted, and indexes their search tokens. It must be called once the schema is migrated.
+func (s *SQLLitePersistence) EncryptHistoryMessages() error {
+	if err := s.setupHistoryIndex(); err != nil {
+		return err
+	}
+	cipher, err := s.fieldsCipher()
+	if err != nil {
+		return err
+	}
+	for {
+		rows, err := s.db.Query(`SELECT rowid, content FROM history_messages WHERE encrypted = 0 LIMIT ?`, encryptBatchSize)
+		if err != nil {
+			return err
+		}
+		plaintexts := make(map[int64]string)
+		for rows.Next() {
+			var rowid int64
+			var content string
+			if err := rows.Scan(&rowid, &content); err != nil {
+				rows.Close()
+				return err
+			}
+			plaintexts[rowid] = content
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(plaintexts) == 0 {
+			return nil
+		}
+
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		for rowid, content := range plaintexts {
+			encrypted, err := cipher.Encrypt([]byte(content))
+			if err == nil {
+				_, err = tx.Exec(`UPDATE history_messages SET content = ?, tokens = ?, encrypted = 1 WHERE rowid = ?`,
+					encrypted, strings.Join(cipher.PrefixTokens(content), " "), rowid)
+			}
+			if err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+}
+
+// historyIndexTriggers keep the full-text index of the history in sync with its messages.
+var historyIndexTriggers = []string{
+	`CREATE TRIGGER history_messages_ai AFTER INSERT ON history_messages BEGIN
+	   INSERT INTO history_messages_fts(rowid, tokens) VALUES (new.rowid, new.tokens);
+	 END`,
+	`CREATE TRIGGER history_messages_ad AFTER DELETE ON history_messages BEGIN
+	   INSERT INTO history_messages_fts(history_messages_fts, rowid, tokens) VALUES ('delete', old.rowid, old.tokens);
+	 END`,
+	`CREATE TRIGGER history_messages_au AFTER UPDATE OF tokens ON history_messages BEGIN
+	   INSERT INTO history_messages_fts(history_messages_fts, rowid, tokens) VALUES ('delete', old.rowid, old.tokens);
+	   INSERT INTO history_messages_fts(rowid, tokens) VALUES (new.rowid, new.tokens);
+	 END`,
+}
+
+// setupHistoryIndex creates the full-text index of the search tokens of the history, and
+// rebuilds it when its triggers are missing, like after migrations rebuilding the table of
+// the messages. Without FTS5, the triggers are dropped so that the messages can be written,
+// and the index is rebuilt once the database is opened with FTS5.
+func (s *SQLLitePersistence) setupHistoryIndex() error {
+	var triggers int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master
+			      WHERE type = 'trigger' AND tbl_name = 'history_messages'`).Scan(&triggers)
+	if err != nil {
+		return err
+	}
+	if s.search && triggers == len(historyIndexTriggers) {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	statements := []string{
+		`DROP TRIGGER IF EXISTS history_messages_ai`,
+		`DROP TRIGGER IF EXISTS history_messages_ad`,
+		`DROP TRIGGER IF EXISTS history_messages_au`,
+	}
+	if s.search {
+		statements = append(statements,
+			`CREATE VIRTUAL TABLE IF NOT EXISTS history_messages_fts
+			 USING fts5(tokens, content='history_messages', content_rowid='rowid')`)
+		statements = append(statements, historyIndexTriggers...)
+		statements = append(statements, `INSERT INTO history_messages_fts(history_messages_fts) VALUES ('rebuild')`)
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// fieldsCipher returns the cipher of the encrypted fields, generating its secret the first time.
+// The secret is kept in the database, so that it follows the database when it is re-encrypted.
+func (s *SQLLitePersistence) fieldsCipher() (*fields.Cipher, error) {
+	s.fields.mu.Lock()
+	defer s.fields.mu.Unlock()
+	if s.fields.cipher != nil {
+		return s.fields.cipher, nil
+	}
+
+	secret := make([]byte, fieldsSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	if _, err := s.db.Exec(`INSERT OR IGNORE INTO fields_secret(id, secret) VALUES (1, ?)`, secret); err != nil {
+		return nil, err
+	}
+	if err := s.db.QueryRow(`SELECT secret FROM fields_secret WHERE id = 1`).Scan(&secret); err != nil {
+		return nil, err
+	}
+	cipher, err := fields.NewCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+	s.fields.cipher = cipher
+	return cipher, nil
+}
+
+const historyMessageColumns = `m.id, m.chat_id, m.author, m.content, m.content_type, m.message_type,
+			       m.reply_to, m.clock, m.timestamp, m.outgoing, m.edited`
+
+func (s *SQLLitePersistence) queryHistoryMessages(query string, args ...interface{}) ([]history.Message, error) {
+	cipher, err := s.fieldsCipher()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.reader().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []history.Message
+	for rows.Next() {
+		var m history.Message
+		var encrypted []byte
+		if err := rows.Scan(&m.ID, &m.ChatID, &m.Author, &encrypted, &m.ContentType, &m.MessageType,
+			&m.ReplyTo, &m.Clock, &m.Timestamp, &m.Outgoing, &m.Edited); err != nil {
+			return nil, err
+		}
+		content, err := cipher.Decrypt(encrypted)
+		if err != nil {
+			return nil, err
+		}
+		m.Content = string(content)
+		result = append(result, m)
+	}
+	return result, rows.Err()
+}
+
+// SaveContact persists a contact with the warnings raised by its name, replacing it if it exists
+func (s *SQLLitePersistence) SaveContact(contact contacts.Contact) error {
+	warnings, err := json.Marshal(contact.Warnings)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO contacts(public_key, name, warnings)
+			    VALUES (?, ?, ?)`, contact.PublicKey, contact.Name, string(warnings))
+	return err
+}
+
+// GetContact returns a contact, if any
+func (s *SQLLitePersistence) GetContact(publicKey string) (*contacts.Contact, error) {
+	result, err := s.queryContacts(`SELECT public_key, name, warnings
+					FROM contacts
+					WHERE public_key = ?`, publicKey)
+	if err != nil || len(result) == 0 {
+		return nil, err
+	}
+	return &result[0], nil
+}
+
+// GetContacts returns all the contacts
+func (s *SQLLitePersistence) GetContacts() ([]contacts.Contact, error) {
+	return s.queryContacts(`SELECT public_key, name, warnings
+				FROM contacts
+				ORDER BY name`)
+}
+
+func (s *SQLLitePersistence) queryContacts(query string, args ...interface{}) ([]contacts.Contact, error) {
+	rows, err := s.reader().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []contacts.Contact
+	for rows.Next() {
+		var (
+			contact  contacts.Contact
+			warnings string
+		)
+		if err := rows.Scan(&contact.PublicKey, &contact.Name, &warnings); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(warnings), &contact.Warnings); err != nil {
+			return nil, err
+		}
+		result = append(result, contact)
+	}
+	return result, rows.Err()
+}
+
+// SavePresenceSettings persists the read receipts and typing notifications settings of the account
+func (s *SQLLitePersistence) SavePresenceSettings(settings presence.Settings) error {
+	encoded, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO presence_settings(id, settings) VALUES (1, ?)`, encoded)
+	return err
+}
+
+// GetPresenceSettings returns the read receipts and typing notifications settings of the account, if any
+func (s *SQLLitePersistence) GetPresenceSettings() (*presence.Settings, error) {
+	var encoded []byte
+	err := s.db.QueryRow(`SELECT settings FROM presence_settings WHERE id = 1`).Scan(&encoded)
+	switch err {
+	case sql.ErrNoRows:
+		return nil, nil
+	case nil:
+	default:
+		return nil, err
+	}
+
+	var settings presence.Settings
+	if err := json.Unmarshal(encoded, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SaveReadReceipt persists a read receipt, unless the reader already read the message
+func (s *SQLLitePersistence) SaveReadReceipt(receipt presence.Receipt) error {
+	_, err := s.db.Exec(`INSERT INTO read_receipts(message_id, reader, clock) VALUES (?, ?, ?)`,
+		receipt.MessageID, receipt.Reader, receipt.Clock)
+	return err
+}
+
+// GetReadReceipts returns the read receipts of a message
+func (s *SQLLitePersistence) GetReadReceipts(messageID string) ([]presence.Receipt, error) {
+	rows, err := s.reader().Query(`SELECT reader, clock
+				 FROM read_receipts
+				 WHERE message_id = ?
+				 ORDER BY clock, reader`, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []presence.Receipt
+	for rows.Next() {
+		receipt := presence.Receipt{MessageID: messageID}
+		if err := rows.Scan(&receipt.Reader, &receipt.Clock); err != nil {
+			return nil, err
+		}
+		result = append(result, receipt)
+	}
+	return result, rows.Err()
+}
+
+// SaveContactRequest persists the consent state of an identity, replacing the existing one
+func (s *SQLLitePersistence) SaveContactRequest(r consent.Request) error {
+	_, err := s.db.Exec(`INSERT INTO contact_requests(public_key, state, received_at) VALUES (?, ?, ?)`,
+		r.PublicKey, r.State, r.ReceivedAt)
+	return err
+}
+
+// GetContactRequest returns the consent state of an identity, if any
+func (s *SQLLitePersistence) GetContactRequest(publicKey string) (*consent.Request, error) {
+	r := consent.Request{PublicKey: publicKey}
+	err := s.db.QueryRow(`SELECT state, received_at FROM contact_requests WHERE public_key = ?`,
+		publicKey).Scan(&r.State, &r.ReceivedAt)
+	switch err {
+	case sql.ErrNoRows:
+		return nil, nil
+	case nil:
+		return &r, nil
+	default:
+		return nil, err
+	}
+}
+
+// GetContactRequests returns the identities in a consent state, from the oldest request
+func (s *SQLLitePersistence) GetContactRequests(state string) ([]consent.Request, error) {
+	rows, err := s.reader().Query(`SELECT public_key, received_at
+				 FROM contact_requests
+				 WHERE state = ?
+				 ORDER BY received_at, public_key`, state)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []consent.Request
+	for rows.Next() {
+		r := consent.Request{State: state}
+		if err := rows.Scan(&r.PublicKey, &r.ReceivedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+	return result, rows.Err()
+}
+
+// CountContactRequests returns the number of identities in a consent state
+func (s *SQLLitePersistence) CountContactRequests(state string) (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM contact_requests WHERE state = ?`, state).Scan(&count)
+	return count, err
+}
+
+// BlockContact persists a blocked identity
+func (s *SQLLitePersistence) BlockContact(publicKey string) error {
+	_, err := s.db.Exec(`INSERT INTO blocked_contacts(public_key) VALUES (?)`, publicKey)
+	return err
+}
+
+// UnblockContact deletes a blocked identity
+func (s *SQLLitePersistence) UnblockContact(publicKey string) error {
+	_, err := s.db.Exec(`DELETE FROM blocked_contacts WHERE public_key = ?`, publicKey)
+	return err
+}
+
+// GetBlockedContacts returns the blocked identities
+func (s *SQLLitePersistence) GetBlockedContacts() ([]string, error) {
+	return s.queryStrings(`SELECT public_key FROM blocked_contacts ORDER BY public_key`)
+}
+
+// MuteChat persists a muted chat
+func (s *SQLLitePersistence) MuteChat(chatID string) error {
+	_, err := s.db.Exec(`INSERT INTO muted_chats(chat_id) VALUES (?)`, chatID)
+	return err
+}
+
+// UnmuteChat deletes a muted chat
+func (s *SQLLitePersistence) UnmuteChat(chatID string) error {
+	_, err := s.db.Exec(`DELETE FROM muted_chats WHERE chat_id = ?`, chatID)
+	return err
+}
+
+// GetMutedChats returns the muted chats
+func (s *SQLLitePersistence) GetMutedChats() ([]string, error) {
+	return s.queryStrings(`SELECT chat_id FROM muted_chats ORDER BY chat_id`)
+}
+
+// SaveContactEncryption persists whether the last direct message of a contact was encrypted with PFS
+func (s *SQLLitePersistence) SaveContactEncryption(publicKey string, pfs bool) error {
+	_, err := s.db.Exec(`INSERT INTO contact_encryption(public_key, pfs) VALUES (?, ?)`, publicKey, pfs)
+	return err
+}
+
+// GetContactEncryption returns whether the last direct message of a contact was encrypted with PFS
+func (s *SQLLitePersistence) GetContactEncryption(publicKey string) (bool, error) {
+	var pfs bool
+	err := s.db.QueryRow(`SELECT pfs FROM contact_encryption WHERE public_key = ?`, publicKey).Scan(&pfs)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return pfs, err
+}
+
+// SaveDowngrade persists a downgrade of the encryption of a contact
+func (s *SQLLitePersistence) SaveDowngrade(e downgrade.Event) error {
+	_, err := s.db.Exec(`INSERT INTO downgrades(public_key, hash, detected_at) VALUES (?, ?, ?)`,
+		e.PublicKey, e.Hash, e.DetectedAt)
+	return err
+}
+
+// GetDowngrades returns the downgrades of the encryption of a contact, from the oldest
+func (s *SQLLitePersistence) GetDowngrades(publicKey string) ([]downgrade.Event, error) {
+	rows, err := s.reader().Query(`SELECT hash, detected_at
+				       FROM downgrades
+				       WHERE public_key = ?
+				       ORDER BY detected_at, rowid`, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []downgrade.Event
+	for rows.Next() {
+		e := downgrade.Event{PublicKey: publicKey}
+		if err := rows.Scan(&e.Hash, &e.DetectedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
+
+// SaveSetting persists a setting of the account, replacing its current value
+func (s *SQLLitePersistence) SaveSetting(key string, value []byte) error {
+	_, err := s.db.Exec(`INSERT INTO settings(key, value) VALUES (?, ?)`, key, value)
+	return err
+}
+
+// GetSetting returns the value of a setting, or nil if it's not set
+func (s *SQLLitePersistence) GetSetting(key string) ([]byte, error) {
+	var value []byte
+	err := s.db.QueryRow(`SELECT value FROM settings WHERE key = ?`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return value, err
+}
+
+// DeleteSetting deletes a setting
+func (s *SQLLitePersistence) DeleteSetting(key string) error {
+	_, err := s.db.Exec(`DELETE FROM settings WHERE key = ?`, key)
+	return err
+}
+
+// GetSettings returns all the settings of the account, by key
+func (s *SQLLitePersistence) GetSettings() (map[string][]byte, error) {
+	rows, err := s.reader().Query(`SELECT key, value FROM settings`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string][]byte)
+	for rows.Next() {
+		var (
+			key   string
+			value []byte
+		)
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		result[key] = value
+	}
+	return result, rows.Err()
+}
+
+// SaveSyncClock saves the sync clock of a record of the account
+func (s *SQLLitePersistence) SaveSyncClock(kind string, id string, clock uint64) error {
+	_, err := s.db.Exec(`INSERT INTO sync_clocks(kind, id, clock) VALUES (?, ?, ?)`, kind, id, int64(clock))
+	return err
+}
+
+// GetSyncClock returns the sync clock of a record of the account, or 0 if it has none
+func (s *SQLLitePersistence) GetSyncClock(kind string, id string) (uint64, error) {
+	var clock int64
+	err := s.db.QueryRow(`SELECT clock FROM sync_clocks WHERE kind = ? AND id = ?`, kind, id).Scan(&clock)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return uint64(clock), err
+}
+
+// GetSyncClocks returns the sync clocks of the records of a kind of the account, by ID
+func (s *SQLLitePersistence) GetSyncClocks(kind string) (map[string]uint64, error) {
+	rows, err := s.db.Query(`SELECT id, clock FROM sync_clocks WHERE kind = ?`, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]uint64)
+	for rows.Next() {
+		var (
+			id    string
+			clock int64
+		)
+		if err := rows.Scan(&id, &clock); err != nil {
+			return nil, err
+		}
+		result[id] = uint64(clock)
+	}
+	return result, rows.Err()
+}
+
+// SaveOutboxEntry persists an entry of the outbox and adds its message to the history, in a single transaction
+func (s *SQLLitePersistence) SaveOutboxEntry(entry outbox.Entry, message *history.Message) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	// the cipher is loaded before the transaction, which holds the only connection
+	cipher, err := s.fieldsCipher()
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if message != nil {
+		if err = s.insertHistoryMessage(tx, cipher, *message); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err = tx.Exec(`INSERT INTO outbox_entries(id, history_id, created_at, data) VALUES (?, ?, ?, ?)`,
+		entry.ID, entry.HistoryID, entry.CreatedAt, data); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteOutboxEntry deletes an entry of the outbox whose messages were posted
+func (s *SQLLitePersistence) DeleteOutboxEntry(id string) error {
+	_, err := s.db.Exec(`DELETE FROM outbox_entries WHERE id = ?`, id)
+	return err
+}
+
+// DiscardOutboxEntry deletes an entry of the outbox and removes its message from the history, in a single transaction
+func (s *SQLLitePersistence) DiscardOutboxEntry(id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`DELETE FROM history_messages
+			      WHERE id IN (SELECT history_id FROM outbox_entries WHERE id = ?)`,
+		id); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if _, err = tx.Exec(`DELETE FROM outbox_entries WHERE id = ?`, id); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetOutboxEntries returns the entries of the outbox, oldest first
+func (s *SQLLitePersistence) GetOutboxEntries() ([]outbox.Entry, error) {
+	rows, err := s.db.Query(`SELECT data FROM outbox_entries ORDER BY created_at, rowid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []outbox.Entry
+	for rows.Next() {
+		var (
+			data  []byte
+			entry outbox.Entry
+		)
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, err
+		}
+		result = append(result, entry)
+	}
+	return result, rows.Err()
+}
+
+// SaveDecoyTopics replaces the decoy topics of the account, in a single transaction
+func (s *SQLLitePersistence) SaveDecoyTopics(topics []whisper.TopicType) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`DELETE FROM decoy_topics`); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	for i, topic := range topics {
+		if _, err = tx.Exec(`INSERT INTO decoy_topics(position, topic) VALUES (?, ?)`,
+			i, topic[:]); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetDecoyTopics returns the decoy topics of the account, in the order they were generated
+func (s *SQLLitePersistence) GetDecoyTopics() ([]whisper.TopicType, error) {
+	rows, err := s.db.Query(`SELECT topic FROM decoy_topics ORDER BY position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []whisper.TopicType
+	for rows.Next() {
+		var topic []byte
+		if err := rows.Scan(&topic); err != nil {
+			return nil, err
+		}
+		result = append(result, whisper.BytesToTopic(topic))
+	}
+	return result, rows.Err()
+}
+
+// queryStrings returns the values of the single column selected by a query.
+func (s *SQLLitePersistence) queryStrings(query string, args ...interface{}) ([]string, error) {
+	rows, err := s.reader().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return result, rows.Err()
+}
+
+// GetChatTopics returns the topics of the chats with persisted settings or moderation
+func (s *SQLLitePersistence) GetChatTopics() ([][]byte, error) {
+	rows, err := s.db.Query(`SELECT topic FROM chat_settings_v3
+				 UNION
+				 SELECT topic FROM moderated_channels
+				 ORDER BY topic`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var topics [][]byte
+	for rows.Next() {
+		var topic []byte
+		if err := rows.Scan(&topic); err != nil {
+			return nil, err
+		}
+		topics = append(topics, topic)
+	}
+	return topics, rows.Err()
+}
+
+func toKey(a []byte) dr.Key {
+	var k [32]byte
+	copy(k[:], a)
+	return k
 }
@@ -32,7 +32,7 @@ func (s *SQLLitePersistenceKeysStorageTestSuite) SetupTest() {
 
 	os.Remove(dbPath)
 
-	p, err := NewSQLLitePersistence(dbPath, key)
+	p, err := NewSQLLitePersistence(dbPath, key, DefaultPersistenceConfig())
 	if err != nil {
 		panic(err)
 	}
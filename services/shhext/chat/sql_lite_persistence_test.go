@@ -2,10 +2,38 @@ package chat
 
 import (
 	"database/sql"
+	"io"
+	"math/big"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/services/ens"
+	"github.com/status-im/status-go/services/shhext/attachments"
+	"github.com/status-im/status-go/services/shhext/chat/capabilities"
+	"github.com/status-im/status-go/services/shhext/consent"
+	"github.com/status-im/status-go/services/shhext/contacts"
+	"github.com/status-im/status-go/services/shhext/content"
+	"github.com/status-im/status-go/services/shhext/delivery"
+	"github.com/status-im/status-go/services/shhext/downgrade"
+	"github.com/status-im/status-go/services/shhext/history"
+	"github.com/status-im/status-go/services/shhext/inbox"
+	"github.com/status-im/status-go/services/shhext/moderation"
+	"github.com/status-im/status-go/services/shhext/notifications"
+	"github.com/status-im/status-go/services/shhext/outbox"
+	"github.com/status-im/status-go/services/shhext/presence"
+	"github.com/status-im/status-go/services/shhext/push"
+	"github.com/status-im/status-go/services/shhext/receipts"
+	"github.com/status-im/status-go/services/shhext/segmentation"
+	"github.com/status-im/status-go/services/shhext/wipe"
+	"github.com/status-im/status-go/services/stickers"
+	"github.com/status-im/status-go/services/wallet"
+	"github.com/status-im/status-go/transactions"
+	whisper "github.com/status-im/whisper/whisperv6"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -28,7 +56,7 @@ type SQLLitePersistenceTestSuite struct {
 func (s *SQLLitePersistenceTestSuite) SetupTest() {
 	os.Remove(dbPath)
 
-	p, err := NewSQLLitePersistence(dbPath, key)
+	p, err := NewSQLLitePersistence(dbPath, key, DefaultPersistenceConfig())
 	s.Require().NoError(err)
 	s.service = p
 }
@@ -36,10 +64,10 @@ func (s *SQLLitePersistenceTestSuite) SetupTest() {
 func (s *SQLLitePersistenceTestSuite) TestMultipleInit() {
 	os.Remove(dbPath)
 
-	_, err := NewSQLLitePersistence(dbPath, key)
+	_, err := NewSQLLitePersistence(dbPath, key, DefaultPersistenceConfig())
 	s.Require().NoError(err)
 
-	_, err = NewSQLLitePersistence(dbPath, key)
+	_, err = NewSQLLitePersistence(dbPath, key, DefaultPersistenceConfig())
 	s.Require().NoError(err)
 }
 
@@ -477,3 +505,1101 @@ func (s *SQLLitePersistenceTestSuite) TestEnableInstallation() {
 }
 
 // TODO: Add test for MarkBundleExpired
+
+func (s *SQLLitePersistenceTestSuite) TestChatLanguage() {
+	topic := []byte("topic")
+
+	language, err := s.service.GetChatLanguage(topic)
+	s.Require().NoError(err)
+	s.Equal("", language)
+
+	s.Require().NoError(s.service.SetChatLanguage(topic, "it"))
+	language, err = s.service.GetChatLanguage(topic)
+	s.Require().NoError(err)
+	s.Equal("it", language)
+
+	s.Require().NoError(s.service.SetChatLanguage(topic, "de"))
+	language, err = s.service.GetChatLanguage(topic)
+	s.Require().NoError(err)
+	s.Equal("de", language)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestPersistenceConfig() {
+	p := s.service.(*SQLLitePersistence)
+
+	var journalMode string
+	s.Require().NoError(p.db.QueryRow("PRAGMA journal_mode").Scan(&journalMode))
+	s.Equal("wal", journalMode)
+
+	var busyTimeout int
+	s.Require().NoError(p.db.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout))
+	s.Equal(5000, busyTimeout)
+
+	s.Error(PersistenceConfig{JournalMode: "WAL; DROP TABLE keys"}.Validate())
+	s.Error(PersistenceConfig{Synchronous: "SOMETIMES"}.Validate())
+	s.NoError(PersistenceConfig{JournalMode: "wal", Synchronous: "full"}.Validate())
+}
+
+func (s *SQLLitePersistenceTestSuite) TestReadConnections() {
+	p := s.service.(*SQLLitePersistence)
+	s.Require().NotNil(p.readers)
+	s.Require().NoError(p.SaveContact(contacts.Contact{PublicKey: "0x01", Name: "alice"}))
+
+	var queryOnly int
+	s.Require().NoError(p.reader().QueryRow("PRAGMA query_only").Scan(&queryOnly))
+	s.Equal(1, queryOnly)
+
+	// The writer connection is busy with a transaction
+	tx, err := p.db.Begin()
+	s.Require().NoError(err)
+	_, err = tx.Exec(`INSERT INTO contacts(public_key, name, warnings) VALUES ('0x02', 'bob', 'null')`)
+	s.Require().NoError(err)
+
+	read := make(chan []contacts.Contact, 1)
+	go func() {
+		result, err := p.GetContacts()
+		s.NoError(err)
+		read <- result
+	}()
+	select {
+	case result := <-read:
+		s.Len(result, 1, "Uncommitted writes are not read")
+	case <-time.After(time.Second):
+		s.Fail("reads wait for the writer")
+	}
+
+	s.Require().NoError(tx.Commit())
+	result, err := p.GetContacts()
+	s.Require().NoError(err)
+	s.Len(result, 2)
+
+	s.Require().NoError(p.Rekey("new-key"))
+	result, err = p.GetContacts()
+	s.Require().NoError(err)
+	s.Len(result, 2, "Read connections are reopened with the new key")
+
+	_, err = NewSQLLitePersistence(dbPath, key, PersistenceConfig{ReadConnections: -1})
+	s.Error(err)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestIntegrityCheck() {
+	results, err := s.service.(*SQLLitePersistence).IntegrityCheck()
+	s.Require().NoError(err)
+	s.Equal([]string{"ok"}, results)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestSchemaVersion() {
+	p := s.service.(*SQLLitePersistence)
+	latest, err := LatestSchemaVersion()
+	s.Require().NoError(err)
+
+	version, err := p.SchemaVersion()
+	s.Require().NoError(err)
+	s.Equal(latest, version)
+
+	// Downgrades to the schema without chat settings
+	s.Require().NoError(p.MigrateTo(1541164797))
+	version, err = p.SchemaVersion()
+	s.Require().NoError(err)
+	s.Equal(uint(1541164797), version)
+	_, err = p.GetChatLanguage([]byte("topic"))
+	s.Error(err)
+
+	// Upgrades when opened again
+	p, err = NewSQLLitePersistence(dbPath, key, DefaultPersistenceConfig())
+	s.Require().NoError(err)
+	version, err = p.SchemaVersion()
+	s.Require().NoError(err)
+	s.Equal(latest, version)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestDatabaseTooNew() {
+	p := s.service.(*SQLLitePersistence)
+	latest, err := LatestSchemaVersion()
+	s.Require().NoError(err)
+
+	_, err = p.db.Exec("UPDATE schema_migrations SET version = ?", latest+1)
+	s.Require().NoError(err)
+
+	_, err = NewSQLLitePersistence(dbPath, key, DefaultPersistenceConfig())
+	s.Equal(ErrDatabaseTooNew, err)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestReceiptSummary() {
+	summary, err := s.service.GetReceiptSummary("message-id")
+	s.Require().NoError(err)
+	s.Nil(summary)
+
+	expected := receipts.Summary{MessageID: "message-id", Total: 3, Delivered: 2, Read: 1}
+	s.Require().NoError(s.service.SaveReceiptSummary(expected))
+
+	summary, err = s.service.GetReceiptSummary("message-id")
+	s.Require().NoError(err)
+	s.Require().NotNil(summary)
+	s.Equal(expected, *summary)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestCleanupInstallations() {
+	key, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+
+	disabled, err := NewBundleContainer(key, "alice-1")
+	s.Require().NoError(err)
+	enabled, err := NewBundleContainer(key, "alice-2")
+	s.Require().NoError(err)
+	identity := disabled.GetBundle().GetIdentity()
+
+	s.Require().NoError(s.service.AddInstallations(identity, 1, []string{"alice-1", "alice-2"}, true))
+	db := s.service.(*SQLLitePersistence).db
+	for installationID, bundle := range map[string]*BundleContainer{"alice-1": disabled, "alice-2": enabled} {
+		s.Require().NoError(s.service.AddPublicBundle(bundle.GetBundle()))
+		bundleID := bundle.GetBundle().GetSignedPreKeys()[installationID].GetSignedPreKey()
+		s.Require().NoError(s.service.AddRatchetInfo([]byte("key"), identity, bundleID, nil, installationID))
+
+		id := sessionID(bundleID, installationID)
+		_, err = db.Exec(`INSERT INTO sessions(id) VALUES (?)`, id)
+		s.Require().NoError(err)
+		s.Require().NoError(s.service.GetKeysStorage().Put(id, toKey([]byte("pk")), 0, toKey([]byte(installationID)), 1))
+	}
+
+	s.Require().NoError(s.service.DisableInstallation(identity, "alice-1"))
+
+	result, err := s.service.CleanupInstallations(identity, time.Now().Add(-time.Hour).Unix())
+	s.Require().NoError(err)
+	s.Equal(InstallationsCleanup{}, *result, "It keeps recently disabled installations")
+
+	result, err = s.service.CleanupInstallations(identity, time.Now().Add(time.Hour).Unix())
+	s.Require().NoError(err)
+	s.Equal(InstallationsCleanup{Installations: 1, Bundles: 1, RatchetInfos: 1, Sessions: 1, SkippedKeys: 1}, *result)
+
+	ratchetInfo, err := s.service.GetAnyRatchetInfo(identity, "alice-1")
+	s.Require().NoError(err)
+	s.Nil(ratchetInfo)
+
+	ratchetInfo, err = s.service.GetAnyRatchetInfo(identity, "alice-2")
+	s.Require().NoError(err)
+	s.NotNil(ratchetInfo, "It keeps enabled installations")
+
+	var count int
+	s.Require().NoError(db.QueryRow(`SELECT COUNT(*) FROM keys`).Scan(&count))
+	s.Equal(1, count)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestProcessedMessages() {
+	sender := []byte("sender")
+	hash := []byte("hash")
+
+	duplicate, err := s.service.IsDuplicate(sender, hash, "1")
+	s.Require().NoError(err)
+	s.False(duplicate)
+
+	s.Require().NoError(s.service.MarkProcessed(sender, hash, "1", 10))
+
+	duplicate, err = s.service.IsDuplicate(sender, hash, "1")
+	s.Require().NoError(err)
+	s.True(duplicate)
+
+	duplicate, err = s.service.IsDuplicate(sender, hash, "2")
+	s.Require().NoError(err)
+	s.False(duplicate, "It is keyed by installation")
+
+	s.Require().NoError(s.service.MarkProcessed(sender, []byte("new-hash"), "1", 20))
+	pruned, err := s.service.PruneProcessed(15)
+	s.Require().NoError(err)
+	s.Equal(1, pruned)
+
+	duplicate, err = s.service.IsDuplicate(sender, hash, "1")
+	s.Require().NoError(err)
+	s.False(duplicate, "It prunes old messages")
+}
+
+func (s *SQLLitePersistenceTestSuite) TestModeratedChannel() {
+	topic := []byte{1, 2, 3, 4}
+
+	channel, err := s.service.GetModeratedChannel(topic)
+	s.Require().NoError(err)
+	s.Nil(channel)
+
+	expected := moderation.Channel{
+		ChatID:    "status",
+		Topic:     whisper.BytesToTopic(topic),
+		Moderator: "0x04",
+		Mode:      moderation.ModeHide,
+		List:      &moderation.List{Deny: []string{"0x05"}, Clock: 1},
+	}
+	s.Require().NoError(s.service.SaveModeratedChannel(expected))
+
+	channel, err = s.service.GetModeratedChannel(topic)
+	s.Require().NoError(err)
+	s.Require().NotNil(channel)
+	s.Equal(expected, *channel)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestEnvelopeStates() {
+	first := common.Hash{1}
+	second := common.Hash{2}
+
+	states, err := s.service.GetEnvelopeStates([]common.Hash{first, second})
+	s.Require().NoError(err)
+	s.Empty(states)
+
+	s.Require().NoError(s.service.SaveEnvelopeState(delivery.Status{Hash: first, State: delivery.Posted, UpdatedAt: 1}))
+	s.Require().NoError(s.service.SaveEnvelopeState(delivery.Status{Hash: first, State: delivery.PeerAcked, UpdatedAt: 2}))
+	s.Require().NoError(s.service.SaveEnvelopeState(delivery.Status{Hash: first, State: delivery.MailServerAcked, UpdatedAt: 3}))
+	s.Require().NoError(s.service.SaveEnvelopeState(delivery.Status{Hash: second, State: delivery.Posted, UpdatedAt: 4}))
+
+	states, err = s.service.GetEnvelopeStates([]common.Hash{first, second, {3}})
+	s.Require().NoError(err)
+	byHash := map[common.Hash]delivery.Status{}
+	for _, state := range states {
+		byHash[state.Hash] = state
+	}
+	s.Equal(map[common.Hash]delivery.Status{
+		first:  {Hash: first, State: delivery.PeerAcked, UpdatedAt: 2},
+		second: {Hash: second, State: delivery.Posted, UpdatedAt: 4},
+	}, byHash, "States never move backwards")
+
+	hashes := make([]common.Hash, 2*maxQueryHashes)
+	for i := range hashes {
+		hashes[i] = common.BigToHash(big.NewInt(int64(i + 10)))
+	}
+	hashes[len(hashes)-1] = second
+	states, err = s.service.GetEnvelopeStates(hashes)
+	s.Require().NoError(err)
+	s.Require().Len(states, 1, "Hashes are looked up in chunks")
+	s.Equal(second, states[0].Hash)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestContactMailServers() {
+	publicKey := []byte("public-key")
+
+	enode, err := s.service.GetContactMailServer(publicKey)
+	s.Require().NoError(err)
+	s.Empty(enode)
+
+	s.Require().NoError(s.service.SaveContactMailServer(publicKey, "enode://first"))
+	s.Require().NoError(s.service.SaveContactMailServer(publicKey, "enode://second"))
+	enode, err = s.service.GetContactMailServer(publicKey)
+	s.Require().NoError(err)
+	s.Equal("enode://second", enode)
+
+	s.Require().NoError(s.service.SaveContactMailServer(publicKey, ""))
+	enode, err = s.service.GetContactMailServer(publicKey)
+	s.Require().NoError(err)
+	s.Empty(enode)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestCompressionDictionaries() {
+	identity := []byte("identity")
+
+	dictionaries, err := s.service.GetCompressionDictionaries(identity, []string{"1", "2"})
+	s.Require().NoError(err)
+	s.Empty(dictionaries)
+
+	s.Require().NoError(s.service.SetCompressionDictionaries(identity, "1", []uint32{1}))
+	s.Require().NoError(s.service.SetCompressionDictionaries(identity, "1", []uint32{1, 2}))
+	s.Require().NoError(s.service.SetCompressionDictionaries(identity, "3", []uint32{1}))
+	dictionaries, err = s.service.GetCompressionDictionaries(identity, []string{"1", "2"})
+	s.Require().NoError(err)
+	s.Equal(map[string][]uint32{"1": {1, 2}}, dictionaries)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestContactTopics() {
+	identity := []byte("identity")
+
+	topics, err := s.service.GetContactTopics(identity, []string{"1", "2"})
+	s.Require().NoError(err)
+	s.Empty(topics)
+
+	s.Require().NoError(s.service.SetContactTopic(identity, "1", []byte("discovery")))
+	s.Require().NoError(s.service.SetContactTopic(identity, "1", []byte("partitioned")))
+	s.Require().NoError(s.service.SetContactTopic(identity, "3", []byte("discovery")))
+	topics, err = s.service.GetContactTopics(identity, []string{"1", "2"})
+	s.Require().NoError(err)
+	s.Equal(map[string][]byte{"1": []byte("partitioned")}, topics)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestContactCapabilities() {
+	identity := []byte("identity")
+
+	sets, err := s.service.GetContactCapabilities(identity, []string{"1", "2"})
+	s.Require().NoError(err)
+	s.Empty(sets)
+
+	s.Require().NoError(s.service.SetContactCapabilities(identity, "1", capabilities.Supported()))
+	s.Require().NoError(s.service.SetContactCapabilities(identity, "2", capabilities.Set{}))
+	sets, err = s.service.GetContactCapabilities(identity, []string{"1", "2"})
+	s.Require().NoError(err)
+	s.Equal(map[string]capabilities.Set{"1": capabilities.Supported(), "2": {}}, sets)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestSegments() {
+	hash := []byte("hash")
+	alice, bob := []byte("alice"), []byte("bob")
+	first := segmentation.Segment{Sender: alice, Hash: hash, Index: 0, Count: 2, Data: []byte("first"), ReceivedAt: 1}
+	second := segmentation.Segment{Sender: alice, Hash: hash, Index: 1, Count: 2, Data: []byte("second"), ReceivedAt: 2}
+	forged := segmentation.Segment{Sender: bob, Hash: hash, Index: 1, Count: 2, Data: []byte("forged"), ReceivedAt: 3}
+	other := segmentation.Segment{Sender: alice, Hash: []byte("other"), Index: 0, Count: 2, Data: []byte("other"), ReceivedAt: 3}
+	s.Require().NoError(s.service.SaveSegment(second))
+	s.Require().NoError(s.service.SaveSegment(first))
+	s.Require().NoError(s.service.SaveSegment(segmentation.Segment{Sender: alice, Hash: hash, Index: 0, Count: 2, Data: []byte("replaced"), ReceivedAt: 4}))
+	s.Require().NoError(s.service.SaveSegment(forged))
+	s.Require().NoError(s.service.SaveSegment(other))
+
+	segments, err := s.service.GetSegments(alice, hash)
+	s.Require().NoError(err)
+	s.Equal([]segmentation.Segment{first, second}, segments, "Stored segments are never replaced")
+	payloads, size, err := s.service.GetPendingSegments(alice)
+	s.Require().NoError(err)
+	s.Equal(2, payloads)
+	s.Equal(len("first")+len("second")+len("other"), size)
+
+	s.Require().NoError(s.service.DeleteSegments(alice, hash))
+	segments, err = s.service.GetSegments(alice, hash)
+	s.Require().NoError(err)
+	s.Empty(segments)
+	segments, err = s.service.GetSegments(bob, hash)
+	s.Require().NoError(err)
+	s.Equal([]segmentation.Segment{forged}, segments, "Segments are kept by sender")
+
+	pruned, err := s.service.PruneSegments(4)
+	s.Require().NoError(err)
+	s.Equal(2, pruned)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestAttachments() {
+	first := attachments.Attachment{
+		Reference:  attachments.Reference{ID: "first", Key: []byte("key"), Hash: []byte("1"), Size: 5, ContentType: "image/jpeg"},
+		State:      attachments.Cached,
+		Data:       []byte("first"),
+		AccessedAt: 2,
+	}
+	second := attachments.Attachment{
+		Reference:  attachments.Reference{ID: "second", Key: []byte("key"), Hash: []byte("2"), Size: 6, ContentType: "image/png"},
+		State:      attachments.Cached,
+		Data:       []byte("second"),
+		AccessedAt: 3,
+	}
+	remote := attachments.Attachment{
+		Reference:  attachments.Reference{ID: "remote", Key: []byte("key"), Hash: []byte("3"), Size: 6, ContentType: "image/png"},
+		State:      attachments.Remote,
+		AccessedAt: 1,
+	}
+	s.Require().NoError(s.service.SaveAttachment(first))
+	s.Require().NoError(s.service.SaveAttachment(second))
+	s.Require().NoError(s.service.SaveAttachment(remote))
+
+	attachment, err := s.service.GetAttachment(first.Hash)
+	s.Require().NoError(err)
+	s.Require().NotNil(attachment)
+	s.Equal(first, *attachment)
+	attachment, err = s.service.GetAttachment([]byte("unknown"))
+	s.Require().NoError(err)
+	s.Nil(attachment)
+	attachment, err = s.service.GetAttachmentInfo(first.Hash)
+	s.Require().NoError(err)
+	s.Equal(first.ID, attachment.ID)
+	s.Nil(attachment.Data)
+
+	chunk := make([]byte, 3)
+	n, err := s.service.ReadAttachment(first.Hash, 2, chunk)
+	s.Require().NoError(err)
+	s.Equal("rst", string(chunk[:n]))
+	n, err = s.service.ReadAttachment(first.Hash, 4, chunk)
+	s.Require().NoError(err)
+	s.Equal("t", string(chunk[:n]))
+	_, err = s.service.ReadAttachment(first.Hash, 5, chunk)
+	s.Equal(io.EOF, err)
+	_, err = s.service.ReadAttachment(remote.Hash, 0, chunk)
+	s.Equal(io.EOF, err, "Remote attachments have no data")
+
+	s.Require().NoError(s.service.TouchAttachment(first.Hash, 4))
+	cached, err := s.service.GetCachedAttachments()
+	s.Require().NoError(err)
+	s.Require().Len(cached, 2)
+	s.Equal(second.ID, cached[0].ID, "It returns the least recently accessed first")
+	s.Nil(cached[0].Data)
+	s.Equal(first.ID, cached[1].ID)
+
+	s.Require().NoError(s.service.EvictAttachment(second.Hash))
+	attachment, err = s.service.GetAttachment(second.Hash)
+	s.Require().NoError(err)
+	s.Equal(attachments.Remote, attachment.State)
+	s.Nil(attachment.Data)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestMessageChanges() {
+	s.Require().NoError(s.service.SaveMessageAuthor("1", "alice"))
+	s.Require().NoError(s.service.SaveMessageAuthor("1", "mallory"))
+	author, err := s.service.GetMessageAuthor("1")
+	s.Require().NoError(err)
+	s.Equal("alice", author, "It keeps the first author")
+	author, err = s.service.GetMessageAuthor("unknown")
+	s.Require().NoError(err)
+	s.Equal("", author)
+
+	edit := content.Change{TargetID: "1", Author: "alice", Content: "edited", Clock: 1}
+	deletion := content.Change{TargetID: "1", Author: "alice", Deleted: true, Clock: 2}
+	s.Require().NoError(s.service.SaveMessageChange(edit))
+	change, err := s.service.GetMessageChange("1", "alice")
+	s.Require().NoError(err)
+	s.Equal(&edit, change)
+	s.Require().NoError(s.service.SaveMessageChange(deletion))
+	change, err = s.service.GetMessageChange("1", "alice")
+	s.Require().NoError(err)
+	s.Equal(&deletion, change)
+	change, err = s.service.GetMessageChange("1", "bob")
+	s.Require().NoError(err)
+	s.Nil(change)
+
+	like := content.Reaction{TargetID: "1", Author: "bob", Emoji: "👍", Clock: 1}
+	retracted := content.Reaction{TargetID: "1", Author: "bob", Emoji: "👍", Retracted: true, Clock: 3}
+	heart := content.Reaction{TargetID: "1", Author: "bob", Emoji: "❤️", Clock: 2}
+	s.Require().NoError(s.service.SaveMessageReaction(like))
+	s.Require().NoError(s.service.SaveMessageReaction(heart))
+	s.Require().NoError(s.service.SaveMessageReaction(retracted))
+	reaction, err := s.service.GetMessageReaction("1", "bob", "👍")
+	s.Require().NoError(err)
+	s.Equal(&retracted, reaction)
+	reaction, err = s.service.GetMessageReaction("1", "alice", "👍")
+	s.Require().NoError(err)
+	s.Nil(reaction)
+	reactions, err := s.service.GetMessageReactions("1")
+	s.Require().NoError(err)
+	s.Equal([]content.Reaction{heart, retracted}, reactions)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestNotificationPreferences() {
+	preferences, err := s.service.GetNotificationPreferences()
+	s.Require().NoError(err)
+	s.Nil(preferences)
+
+	muted := notifications.Preferences{Badges: true, Chats: map[string]notifications.ChatPreferences{"status": {Muted: true}}, Clock: 1}
+	s.Require().NoError(s.service.SaveNotificationPreferences(muted))
+	expected := notifications.Preferences{Sounds: true, Clock: 2}
+	s.Require().NoError(s.service.SaveNotificationPreferences(expected))
+
+	preferences, err = s.service.GetNotificationPreferences()
+	s.Require().NoError(err)
+	s.Require().NotNil(preferences)
+	s.Equal(expected, *preferences)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestPendingMessages() {
+	sender := []byte("sender")
+	first := inbox.PendingMessage{Hash: []byte("1"), Sender: sender, Message: []byte("first"), ReceivedAt: 1}
+	second := inbox.PendingMessage{Hash: []byte("2"), Sender: sender, Message: []byte("second"), ReceivedAt: 2}
+	other := inbox.PendingMessage{Hash: []byte("3"), Sender: []byte("other"), Message: []byte("other"), ReceivedAt: 3}
+	s.Require().NoError(s.service.SavePendingMessage(second))
+	s.Require().NoError(s.service.SavePendingMessage(first))
+	s.Require().NoError(s.service.SavePendingMessage(other))
+
+	first.Attempts = 1
+	s.Require().NoError(s.service.SavePendingMessage(first))
+	messages, err := s.service.GetPendingMessages(sender)
+	s.Require().NoError(err)
+	s.Equal([]inbox.PendingMessage{first, second}, messages)
+
+	count, err := s.service.CountPendingMessages()
+	s.Require().NoError(err)
+	s.Equal(3, count)
+
+	s.Require().NoError(s.service.DeletePendingMessage(second.Hash))
+	pruned, err := s.service.PrunePendingMessages(3)
+	s.Require().NoError(err)
+	s.Equal(1, pruned)
+	count, err = s.service.CountPendingMessages()
+	s.Require().NoError(err)
+	s.Equal(1, count)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestGetChatTopics() {
+	topics, err := s.service.GetChatTopics()
+	s.Require().NoError(err)
+	s.Empty(topics)
+
+	s.Require().NoError(s.service.SetChatLanguage([]byte{1, 1, 1, 1}, "it"))
+	s.Require().NoError(s.service.SetChatLanguage([]byte{2, 2, 2, 2}, "en"))
+	s.Require().NoError(s.service.SaveModeratedChannel(moderation.Channel{Topic: whisper.TopicType{2, 2, 2, 2}, ChatID: "status"}))
+	s.Require().NoError(s.service.SaveModeratedChannel(moderation.Channel{Topic: whisper.TopicType{3, 3, 3, 3}, ChatID: "ethereum"}))
+	topics, err = s.service.GetChatTopics()
+	s.Require().NoError(err)
+	s.Equal([][]byte{{1, 1, 1, 1}, {2, 2, 2, 2}, {3, 3, 3, 3}}, topics)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestHistoryMessages() {
+	messages := []history.Message{
+		{ID: "1", ChatID: "chat", Author: "alice", Content: "hello world", ContentType: "text/plain", Clock: 1, Timestamp: 10},
+		{ID: "2", ChatID: "chat", Author: "bob", Content: "hello alice", ContentType: "text/plain", Clock: 2, Timestamp: 11, Outgoing: true},
+		{ID: "3", ChatID: "chat", Author: "alice", Content: "how are you", ContentType: "text/plain", Clock: 2, Timestamp: 12},
+		{ID: "4", ChatID: "other", Author: "carol", Content: "hello there", ContentType: "text/plain", Clock: 1, Timestamp: 13},
+	}
+	for _, m := range messages {
+		s.Require().NoError(s.service.SaveHistoryMessage(m))
+	}
+	duplicate := messages[0]
+	duplicate.Content = "forged"
+	s.Require().NoError(s.service.SaveHistoryMessage(duplicate))
+
+	var content []byte
+	var tokens string
+	s.Require().NoError(s.service.(*SQLLitePersistence).db.QueryRow(`SELECT content, tokens FROM history_messages WHERE id = '1'`).Scan(&content, &tokens))
+	s.NotContains(string(content), "hello", "The content is stored encrypted")
+	s.NotContains(tokens, "hello", "The search tokens don't contain the words")
+
+	page, err := s.service.GetHistoryMessages("chat", nil, 2)
+	s.Require().NoError(err)
+	s.Equal([]history.Message{messages[2], messages[1]}, page)
+	page, err = s.service.GetHistoryMessages("chat", &history.Cursor{Clock: 2, ID: "2"}, 2)
+	s.Require().NoError(err)
+	s.Equal([]history.Message{messages[0]}, page, "Duplicates are ignored")
+	chats, err := s.service.GetHistoryChats()
+	s.Require().NoError(err)
+	s.Equal([]string{"chat", "other"}, chats)
+
+	found, err := s.service.SearchHistoryMessages("hel", "", 10)
+	s.Require().NoError(err)
+	s.Len(found, 3)
+	found, err = s.service.SearchHistoryMessages("hel", "other", 10)
+	s.Require().NoError(err)
+	s.Equal([]history.Message{messages[3]}, found)
+
+	s.Require().NoError(s.service.UpdateHistoryMessage("1", "goodbye world"))
+	s.Require().NoError(s.service.DeleteHistoryMessage("2"))
+	found, err = s.service.SearchHistoryMessages("hel", "chat", 10)
+	s.Require().NoError(err)
+	s.Empty(found, "Edited and deleted messages are reindexed")
+	found, err = s.service.SearchHistoryMessages("GOOD", "chat", 10)
+	s.Require().NoError(err)
+	s.Require().Len(found, 1)
+	s.Equal("goodbye world", found[0].Content)
+	s.True(found[0].Edited)
+
+	s.Require().NoError(s.service.OptimizeHistoryIndex())
+	found, err = s.service.SearchHistoryMessages("GOOD", "chat", 10)
+	s.Require().NoError(err)
+	s.Len(found, 1, "The index is intact once optimized")
+}
+
+func (s *SQLLitePersistenceTestSuite) TestHistoryWithoutSearch() {
+	p := s.service.(*SQLLitePersistence)
+	first := history.Message{ID: "1", ChatID: "chat", Author: "alice", Content: "hello world", ContentType: "text/plain", Clock: 1}
+	second := history.Message{ID: "2", ChatID: "chat", Author: "bob", Content: "hello alice", ContentType: "text/plain", Clock: 2}
+	s.Require().NoError(p.SaveHistoryMessage(first))
+
+	// like a build without FTS5
+	fts5 := p.search
+	p.search = false
+	s.Require().NoError(p.EncryptHistoryMessages())
+	s.Require().NoError(p.SaveHistoryMessage(second), "Messages are saved without the index")
+	s.Require().NoError(p.DeleteHistoryMessage("1"))
+	_, err := p.SearchHistoryMessages("hel", "", 10)
+	s.Equal(history.ErrSearchUnavailable, err)
+	s.NoError(p.OptimizeHistoryIndex())
+	page, err := p.GetHistoryMessages("chat", nil, 10)
+	s.Require().NoError(err)
+	s.Equal([]history.Message{second}, page)
+
+	if !fts5 {
+		return
+	}
+	p.search = true
+	s.Require().NoError(p.EncryptHistoryMessages())
+	found, err := p.SearchHistoryMessages("hel", "", 10)
+	s.Require().NoError(err)
+	s.Equal([]history.Message{second}, found, "The index is rebuilt with FTS5")
+}
+
+func (s *SQLLitePersistenceTestSuite) TestContacts() {
+	alice := contacts.Contact{PublicKey: "0x01", Name: "alice", Warnings: []contacts.Warning{}}
+	mallory := contacts.Contact{
+		PublicKey: "0x02",
+		Name:      "аlice",
+		Warnings:  []contacts.Warning{{Kind: contacts.Confusable, PublicKey: "0x01", Name: "alice"}},
+	}
+	s.Require().NoError(s.service.SaveContact(mallory))
+	s.Require().NoError(s.service.SaveContact(alice))
+
+	contact, err := s.service.GetContact("0x02")
+	s.Require().NoError(err)
+	s.Equal(&mallory, contact)
+	contact, err = s.service.GetContact("0x03")
+	s.Require().NoError(err)
+	s.Nil(contact)
+
+	alice.Name = "alice2"
+	s.Require().NoError(s.service.SaveContact(alice))
+	all, err := s.service.GetContacts()
+	s.Require().NoError(err)
+	s.Equal([]contacts.Contact{alice, mallory}, all, "Contacts are replaced and sorted by name")
+}
+
+func (s *SQLLitePersistenceTestSuite) TestPresence() {
+	settings, err := s.service.GetPresenceSettings()
+	s.Require().NoError(err)
+	s.Nil(settings)
+
+	disabled := false
+	expected := presence.Settings{ReadReceipts: true, Contacts: map[string]presence.ContactSettings{"0x01": {ReadReceipts: &disabled}}}
+	s.Require().NoError(s.service.SavePresenceSettings(expected))
+	settings, err = s.service.GetPresenceSettings()
+	s.Require().NoError(err)
+	s.Equal(&expected, settings)
+
+	s.Require().NoError(s.service.SaveReadReceipt(presence.Receipt{MessageID: "1", Reader: "bob", Clock: 2}))
+	s.Require().NoError(s.service.SaveReadReceipt(presence.Receipt{MessageID: "1", Reader: "alice", Clock: 1}))
+	s.Require().NoError(s.service.SaveReadReceipt(presence.Receipt{MessageID: "1", Reader: "bob", Clock: 3}))
+	receipts, err := s.service.GetReadReceipts("1")
+	s.Require().NoError(err)
+	s.Equal([]presence.Receipt{
+		{MessageID: "1", Reader: "alice", Clock: 1},
+		{MessageID: "1", Reader: "bob", Clock: 2},
+	}, receipts, "The first receipt of a reader is kept")
+}
+
+func (s *SQLLitePersistenceTestSuite) TestContactRequests() {
+	r, err := s.service.GetContactRequest("0x01")
+	s.Require().NoError(err)
+	s.Nil(r)
+
+	s.Require().NoError(s.service.SaveContactRequest(consent.Request{PublicKey: "0x01", State: consent.Pending, ReceivedAt: 2}))
+	s.Require().NoError(s.service.SaveContactRequest(consent.Request{PublicKey: "0x02", State: consent.Pending, ReceivedAt: 1}))
+	s.Require().NoError(s.service.SaveContactRequest(consent.Request{PublicKey: "0x03", State: consent.Accepted}))
+	s.Require().NoError(s.service.SaveContactRequest(consent.Request{PublicKey: "0x01", State: consent.Rejected, ReceivedAt: 2}))
+
+	r, err = s.service.GetContactRequest("0x01")
+	s.Require().NoError(err)
+	s.Equal(&consent.Request{PublicKey: "0x01", State: consent.Rejected, ReceivedAt: 2}, r)
+	requests, err := s.service.GetContactRequests(consent.Pending)
+	s.Require().NoError(err)
+	s.Equal([]consent.Request{{PublicKey: "0x02", State: consent.Pending, ReceivedAt: 1}}, requests)
+	count, err := s.service.CountContactRequests(consent.Accepted)
+	s.Require().NoError(err)
+	s.Equal(1, count)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestBlockedContactsAndMutedChats() {
+	s.Require().NoError(s.service.BlockContact("0x02"))
+	s.Require().NoError(s.service.BlockContact("0x01"))
+	s.Require().NoError(s.service.BlockContact("0x02"))
+	s.Require().NoError(s.service.UnblockContact("0x01"))
+	blocked, err := s.service.GetBlockedContacts()
+	s.Require().NoError(err)
+	s.Equal([]string{"0x02"}, blocked)
+
+	s.Require().NoError(s.service.MuteChat("chat"))
+	s.Require().NoError(s.service.MuteChat("other"))
+	s.Require().NoError(s.service.UnmuteChat("other"))
+	muted, err := s.service.GetMutedChats()
+	s.Require().NoError(err)
+	s.Equal([]string{"chat"}, muted)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestDowngrades() {
+	pfs, err := s.service.GetContactEncryption("0x01")
+	s.Require().NoError(err)
+	s.False(pfs)
+	s.Require().NoError(s.service.SaveContactEncryption("0x01", true))
+	pfs, err = s.service.GetContactEncryption("0x01")
+	s.Require().NoError(err)
+	s.True(pfs)
+
+	s.Require().NoError(s.service.SaveDowngrade(downgrade.Event{PublicKey: "0x01", Hash: "0xbb", DetectedAt: 2}))
+	s.Require().NoError(s.service.SaveDowngrade(downgrade.Event{PublicKey: "0x01", Hash: "0xaa", DetectedAt: 1}))
+	s.Require().NoError(s.service.SaveDowngrade(downgrade.Event{PublicKey: "0x02", Hash: "0xcc", DetectedAt: 1}))
+	events, err := s.service.GetDowngrades("0x01")
+	s.Require().NoError(err)
+	s.Equal([]downgrade.Event{
+		{PublicKey: "0x01", Hash: "0xaa", DetectedAt: 1},
+		{PublicKey: "0x01", Hash: "0xbb", DetectedAt: 2},
+	}, events)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestSettings() {
+	value, err := s.service.GetSetting("mailserver")
+	s.Require().NoError(err)
+	s.Nil(value)
+
+	s.Require().NoError(s.service.SaveSetting("mailserver", []byte(`"enode://1"`)))
+	s.Require().NoError(s.service.SaveSetting("mailserver", []byte(`"enode://2"`)))
+	s.Require().NoError(s.service.SaveSetting("sync.enabled", []byte(`true`)))
+	value, err = s.service.GetSetting("mailserver")
+	s.Require().NoError(err)
+	s.Equal([]byte(`"enode://2"`), value)
+
+	s.Require().NoError(s.service.DeleteSetting("sync.enabled"))
+	all, err := s.service.GetSettings()
+	s.Require().NoError(err)
+	s.Equal(map[string][]byte{"mailserver": []byte(`"enode://2"`)}, all)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestSyncClocks() {
+	clock, err := s.service.GetSyncClock("contact", "0x01")
+	s.Require().NoError(err)
+	s.Zero(clock)
+
+	s.Require().NoError(s.service.SaveSyncClock("contact", "0x01", 10))
+	s.Require().NoError(s.service.SaveSyncClock("contact", "0x01", 20))
+	s.Require().NoError(s.service.SaveSyncClock("chat", "0x01", 30))
+	clock, err = s.service.GetSyncClock("contact", "0x01")
+	s.Require().NoError(err)
+	s.Equal(uint64(20), clock)
+
+	clocks, err := s.service.GetSyncClocks("contact")
+	s.Require().NoError(err)
+	s.Equal(map[string]uint64{"0x01": 20}, clocks)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestUnsignedTransactions() {
+	store := s.service.(*SQLLitePersistence).TransactionStore()
+	first := transactions.UnsignedTransaction{Hash: common.HexToHash("0x01"), Nonce: 1, Expires: 200}
+	second := transactions.UnsignedTransaction{Hash: common.HexToHash("0x02"), Nonce: 2, Expires: 100}
+	s.Require().NoError(store.SaveUnsignedTransaction(first))
+	s.Require().NoError(store.SaveUnsignedTransaction(second))
+	first.Expires = 300
+	s.Require().NoError(store.SaveUnsignedTransaction(first))
+
+	txs, err := store.GetUnsignedTransactions()
+	s.Require().NoError(err)
+	s.Require().Len(txs, 2, "Transactions are replaced")
+	s.Equal(second.Hash, txs[0].Hash, "Transactions are sorted by expiry")
+	s.Equal(first.Hash, txs[1].Hash)
+	s.Equal(int64(300), txs[1].Expires)
+
+	s.Require().NoError(store.DeleteUnsignedTransaction(second.Hash))
+	txs, err = store.GetUnsignedTransactions()
+	s.Require().NoError(err)
+	s.Require().Len(txs, 1)
+	s.Equal(first.Hash, txs[0].Hash)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestSentTransactions() {
+	store := s.service.(*SQLLitePersistence).TransactionStore()
+	to := common.HexToAddress("0x02")
+	from := common.HexToAddress("0x01")
+	original := types.NewTransaction(1, to, big.NewInt(1), 21000, big.NewInt(10), nil)
+	spedUp := types.NewTransaction(1, to, big.NewInt(1), 21000, big.NewInt(11), nil)
+	other := types.NewTransaction(2, to, big.NewInt(1), 21000, big.NewInt(10), nil)
+	for _, tx := range []transactions.SentTransaction{
+		{Original: original.Hash(), ChainID: 1, From: from, Tx: original},
+		{Original: original.Hash(), ChainID: 1, From: from, Tx: spedUp},
+		{Original: other.Hash(), ChainID: 1, From: from, Tx: other},
+	} {
+		s.Require().NoError(store.SaveSentTransaction(tx))
+	}
+
+	txs, err := store.GetSentTransactions()
+	s.Require().NoError(err)
+	s.Require().Len(txs, 3)
+	s.Equal(original.Hash(), txs[0].Tx.Hash(), "Transactions are sorted by insertion")
+	s.Equal(spedUp.Hash(), txs[1].Tx.Hash())
+	s.Equal(original.Hash(), txs[1].Original)
+	s.Equal(from, txs[1].From)
+	s.Equal(uint64(1), txs[1].ChainID)
+
+	s.Require().NoError(store.DeleteSentTransactions(original.Hash()))
+	txs, err = store.GetSentTransactions()
+	s.Require().NoError(err)
+	s.Require().Len(txs, 1, "Competing transactions are deleted together")
+	s.Equal(other.Hash(), txs[0].Tx.Hash())
+}
+
+func (s *SQLLitePersistenceTestSuite) TestOutboxEntries() {
+	sent := outbox.NewEntry("status", []whisper.NewMessage{{Payload: []byte("sent")}})
+	sent.HistoryID = "0x01"
+	s.Require().NoError(s.service.SaveOutboxEntry(sent, &history.Message{ID: "0x01", ChatID: "chat", Content: "sent"}))
+	failed := outbox.NewEntry("", []whisper.NewMessage{{Payload: []byte("failed")}})
+	failed.HistoryID = "0x02"
+	s.Require().NoError(s.service.SaveOutboxEntry(failed, &history.Message{ID: "0x02", ChatID: "chat", Content: "failed"}))
+
+	entries, err := s.service.GetOutboxEntries()
+	s.Require().NoError(err)
+	s.Require().Len(entries, 2)
+	s.Equal(sent.ID, entries[0].ID)
+	s.Equal("status", entries[0].Chat)
+	s.Equal([]byte("sent"), []byte(entries[0].Messages[0].Payload))
+	messages, err := s.service.GetHistoryMessages("chat", nil, 10)
+	s.Require().NoError(err)
+	s.Len(messages, 2, "Messages are added to the history with their entry")
+
+	s.Require().NoError(s.service.DeleteOutboxEntry(sent.ID))
+	s.Require().NoError(s.service.DiscardOutboxEntry(failed.ID))
+	entries, err = s.service.GetOutboxEntries()
+	s.Require().NoError(err)
+	s.Empty(entries)
+	messages, err = s.service.GetHistoryMessages("chat", nil, 10)
+	s.Require().NoError(err)
+	s.Require().Len(messages, 1, "Messages of discarded entries are removed from the history")
+	s.Equal("0x01", messages[0].ID)
+
+	s.Require().NoError(s.service.SaveOutboxEntry(failed, nil))
+}
+
+func (s *SQLLitePersistenceTestSuite) TestTransfers() {
+	store := s.service.(*SQLLitePersistence).WalletStore()
+	address := common.HexToAddress("0x01")
+	_, ok, err := store.GetTransfersBlock(1, address)
+	s.Require().NoError(err)
+	s.False(ok)
+
+	contract := common.HexToAddress("0x02")
+	s.Require().NoError(store.SaveTransfers(1, address, []wallet.Transfer{
+		{ID: "0xa", Type: wallet.EthTransfer, Address: address, BlockNumber: 10},
+		{ID: "0xb-1", Type: wallet.Erc20Transfer, Address: address, Contract: &contract, BlockNumber: 12},
+		{ID: "0xc", Type: wallet.EthTransfer, Address: address, BlockNumber: 12},
+	}, 15))
+	block, ok, err := store.GetTransfersBlock(1, address)
+	s.Require().NoError(err)
+	s.True(ok)
+	s.Equal(uint64(15), block)
+
+	transfers, err := store.GetTransfers(1, address, nil, 2)
+	s.Require().NoError(err)
+	s.Require().Len(transfers, 2)
+	s.Equal("0xc", transfers[0].ID)
+	s.Equal("0xb-1", transfers[1].ID)
+	s.Equal(contract, *transfers[1].Contract)
+
+	transfers, err = store.GetTransfers(1, address, &wallet.TransfersCursor{BlockNumber: 12, ID: "0xb-1"}, 2)
+	s.Require().NoError(err)
+	s.Require().Len(transfers, 1)
+	s.Equal("0xa", transfers[0].ID)
+
+	s.Require().NoError(store.SaveTransfers(1, address, []wallet.Transfer{{ID: "0xa", BlockNumber: 10}}, 20))
+	transfers, err = store.GetTransfers(1, address, nil, 10)
+	s.Require().NoError(err)
+	s.Len(transfers, 3, "Transfers saved again are replaced")
+	block, _, err = store.GetTransfersBlock(1, address)
+	s.Require().NoError(err)
+	s.Equal(uint64(20), block)
+
+	transfers, err = store.GetTransfers(10, address, nil, 10)
+	s.Require().NoError(err)
+	s.Empty(transfers, "Transfers are namespaced by chain")
+	_, ok, err = store.GetTransfersBlock(10, address)
+	s.Require().NoError(err)
+	s.False(ok)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestBalanceSnapshots() {
+	store := s.service.(*SQLLitePersistence).WalletStore()
+	_, ok, err := store.GetLastBalanceSnapshot(1)
+	s.Require().NoError(err)
+	s.False(ok)
+
+	address := common.HexToAddress("0x01")
+	token := common.HexToAddress("0x02")
+	value := 1.5
+	snapshot := func(token common.Address, timestamp, balance int64) wallet.BalanceSnapshot {
+		return wallet.BalanceSnapshot{ChainID: 1, Address: address, Token: token, Timestamp: timestamp, Balance: (*hexutil.Big)(big.NewInt(balance))}
+	}
+	ether := snapshot(common.Address{}, 86400, 3)
+	ether.FiatValue = &value
+	ether.Currency = "USD"
+	s.Require().NoError(store.SaveBalanceSnapshots(1, []wallet.BalanceSnapshot{
+		snapshot(common.Address{}, 0, 1), snapshot(token, 0, 2), ether, snapshot(token, 86400, 4),
+	}))
+	last, ok, err := store.GetLastBalanceSnapshot(1)
+	s.Require().NoError(err)
+	s.True(ok)
+	s.Equal(int64(86400), last)
+
+	snapshots, err := store.GetBalanceSnapshots(1, address, common.Address{}, 0)
+	s.Require().NoError(err)
+	s.Require().Len(snapshots, 2)
+	s.Equal(int64(1), snapshots[0].Balance.ToInt().Int64(), "Snapshots are returned from the oldest")
+	s.Equal(ether, snapshots[1])
+
+	snapshots, err = store.GetBalanceSnapshots(1, address, token, 86400)
+	s.Require().NoError(err)
+	s.Require().Len(snapshots, 1)
+	s.Equal(int64(4), snapshots[0].Balance.ToInt().Int64())
+
+	s.Require().NoError(store.SaveBalanceSnapshots(1, []wallet.BalanceSnapshot{snapshot(token, 86400, 5)}))
+	snapshots, err = store.GetBalanceSnapshots(1, address, token, 86400)
+	s.Require().NoError(err)
+	s.Require().Len(snapshots, 1)
+	s.Equal(int64(5), snapshots[0].Balance.ToInt().Int64(), "Snapshots of the same day are replaced")
+
+	_, ok, err = store.GetLastBalanceSnapshot(10)
+	s.Require().NoError(err)
+	s.False(ok, "Snapshots are namespaced by chain")
+}
+
+func (s *SQLLitePersistenceTestSuite) TestENSResolutions() {
+	store := s.service.(*SQLLitePersistence).ENSStore()
+	resolution, err := store.GetENSResolution(1, "alice.eth")
+	s.Require().NoError(err)
+	s.Nil(resolution)
+
+	address := common.HexToAddress("0x01")
+	saved := ens.Resolution{Name: "alice.eth", Owner: address, Address: &address, PublicKey: []byte{4, 1}, ResolvedAt: 10}
+	s.Require().NoError(store.SaveENSResolution(1, saved))
+	saved.ResolvedAt = 20
+	s.Require().NoError(store.SaveENSResolution(1, saved))
+	resolution, err = store.GetENSResolution(1, "alice.eth")
+	s.Require().NoError(err)
+	s.Equal(&saved, resolution, "Resolutions are replaced")
+
+	reverse, err := store.GetENSReverseResolution(1, address)
+	s.Require().NoError(err)
+	s.Nil(reverse)
+	savedReverse := ens.ReverseResolution{Address: address, Name: "alice.eth", ResolvedAt: 10}
+	s.Require().NoError(store.SaveENSReverseResolution(1, savedReverse))
+	reverse, err = store.GetENSReverseResolution(1, address)
+	s.Require().NoError(err)
+	s.Equal(&savedReverse, reverse)
+
+	resolution, err = store.GetENSResolution(3, "alice.eth")
+	s.Require().NoError(err)
+	s.Nil(resolution, "Resolutions are namespaced by network")
+}
+
+func (s *SQLLitePersistenceTestSuite) TestStickerPacks() {
+	store := s.service.(*SQLLitePersistence).StickersStore()
+	packs, err := store.GetStickerPacks(1)
+	s.Require().NoError(err)
+	s.Empty(packs)
+
+	hash := []byte{0xe3, 1}
+	first := stickers.Pack{ID: 3, Name: "First", Price: (*hexutil.Big)(big.NewInt(10)), Stickers: []stickers.Sticker{{Hash: hash}}, InstalledAt: 10}
+	second := stickers.Pack{ID: 1, Name: "Second", Price: (*hexutil.Big)(big.NewInt(0)), InstalledAt: 20}
+	s.Require().NoError(store.SaveStickerPack(1, first, map[string][]byte{hexutil.Encode(hash): []byte("old")}))
+	s.Require().NoError(store.SaveStickerPack(1, second, nil))
+	s.Require().NoError(store.SaveStickerPack(1, first, map[string][]byte{hexutil.Encode(hash): []byte("image")}))
+	packs, err = store.GetStickerPacks(1)
+	s.Require().NoError(err)
+	s.Require().Len(packs, 2, "Packs are replaced")
+	s.Equal("First", packs[0].Name, "Packs are returned in the order they were installed")
+	s.Equal(int64(10), packs[0].Price.ToInt().Int64())
+	s.Equal(first.Stickers, packs[0].Stickers)
+	s.Equal("Second", packs[1].Name)
+	content, err := store.GetStickerContent(1, hash)
+	s.Require().NoError(err)
+	s.Equal([]byte("image"), content)
+
+	content, err = store.GetStickerContent(3, hash)
+	s.Require().NoError(err)
+	s.Nil(content, "Packs are namespaced by network")
+
+	s.Require().NoError(store.DeleteStickerPack(1, first.ID))
+	packs, err = store.GetStickerPacks(1)
+	s.Require().NoError(err)
+	s.Require().Len(packs, 1)
+	s.Equal(second.ID, packs[0].ID)
+	content, err = store.GetStickerContent(1, hash)
+	s.Require().NoError(err)
+	s.Nil(content, "Images are deleted with their pack")
+}
+
+func (s *SQLLitePersistenceTestSuite) TestPushRegistrations() {
+	store := s.service.(*SQLLitePersistence).PushStore()
+	hash := []byte{1, 2, 3}
+	registrations, err := store.GetPushRegistrations(hash)
+	s.Require().NoError(err)
+	s.Empty(registrations)
+
+	first := push.Registration{TokenType: push.TokenTypeFCM, Token: "old", InstallationID: "1", AccessToken: "secret", Version: 1}
+	second := push.Registration{TokenType: push.TokenTypeAPNs, Token: "token", InstallationID: "2", AccessToken: "secret", Version: 1}
+	s.Require().NoError(store.SavePushRegistration(hash, first))
+	s.Require().NoError(store.SavePushRegistration(hash, second))
+	first.Token = "new"
+	first.Version = 2
+	s.Require().NoError(store.SavePushRegistration(hash, first))
+	registrations, err = store.GetPushRegistrations(hash)
+	s.Require().NoError(err)
+	s.Equal([]push.Registration{first, second}, registrations, "Registrations are replaced")
+
+	registrations, err = store.GetPushRegistrations([]byte{4})
+	s.Require().NoError(err)
+	s.Empty(registrations)
+
+	s.Require().NoError(store.DeletePushRegistration(hash, first.InstallationID))
+	registrations, err = store.GetPushRegistrations(hash)
+	s.Require().NoError(err)
+	s.Equal([]push.Registration{second}, registrations)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestPushClientState() {
+	store := s.service.(*SQLLitePersistence).PushStore()
+	state, err := store.GetPushClientState()
+	s.Require().NoError(err)
+	s.Nil(state)
+
+	saved := push.ClientState{
+		Options:     push.Options{TokenType: push.TokenTypeFCM, Token: "token"},
+		AccessToken: "secret",
+		Version:     1,
+		Servers:     []push.ServerStatus{{PublicKey: []byte{4}, Registered: true, RegisteredAt: 10}},
+	}
+	s.Require().NoError(store.SavePushClientState(saved))
+	saved.Version = 2
+	s.Require().NoError(store.SavePushClientState(saved))
+	state, err = store.GetPushClientState()
+	s.Require().NoError(err)
+	s.Equal(&saved, state)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestWipes() {
+	store := s.service.(*SQLLitePersistence).WipeStore()
+	w, err := store.GetWipe("1")
+	s.Require().NoError(err)
+	s.Nil(w)
+
+	first := wipe.Wipe{Command: wipe.Command{ID: "1", InstallationID: "2", IssuedAt: 10, Signature: []byte{1}}, Status: wipe.StatusPending}
+	second := wipe.Wipe{Command: wipe.Command{ID: "2", InstallationID: "3", IssuedAt: 20, Signature: []byte{2}}, Status: wipe.StatusPending}
+	s.Require().NoError(store.SaveWipe(first))
+	s.Require().NoError(store.SaveWipe(second))
+	first.Status = wipe.StatusConfirmed
+	first.ConfirmedAt = 30
+	s.Require().NoError(store.SaveWipe(first))
+
+	w, err = store.GetWipe("1")
+	s.Require().NoError(err)
+	s.Equal(&first, w)
+	wipes, err := store.GetWipes()
+	s.Require().NoError(err)
+	s.Equal([]wipe.Wipe{second, first}, wipes)
+}
+
+func (s *SQLLitePersistenceTestSuite) TestDecoyTopics() {
+	topics, err := s.service.GetDecoyTopics()
+	s.Require().NoError(err)
+	s.Empty(topics)
+
+	decoys := []whisper.TopicType{{3}, {1}, {2}}
+	s.Require().NoError(s.service.SaveDecoyTopics(decoys))
+	topics, err = s.service.GetDecoyTopics()
+	s.Require().NoError(err)
+	s.Equal(decoys, topics, "Decoys are returned in the order they were generated")
+
+	s.Require().NoError(s.service.SaveDecoyTopics(decoys[:1]))
+	topics, err = s.service.GetDecoyTopics()
+	s.Require().NoError(err)
+	s.Equal(decoys[:1], topics, "Decoys are replaced")
+}
+
+func (s *SQLLitePersistenceTestSuite) TestCustomTokens() {
+	store := s.service.(*SQLLitePersistence).WalletStore()
+	abc := wallet.Token{Address: common.HexToAddress("0x01"), Symbol: "ABC", Decimals: 6, Standard: wallet.ERC20, Custom: true}
+	nft := wallet.Token{Address: common.HexToAddress("0x02"), Symbol: "NFT", Standard: wallet.ERC721, Custom: true}
+	s.Require().NoError(store.SaveCustomToken(1, abc))
+	s.Require().NoError(store.SaveCustomToken(1, nft))
+	s.Require().NoError(store.SaveCustomToken(3, abc))
+
+	tokens, err := store.GetCustomTokens(1)
+	s.Require().NoError(err)
+	s.Equal([]wallet.Token{abc, nft}, tokens)
+
+	abc.Decimals = 8
+	s.Require().NoError(store.SaveCustomToken(1, abc))
+	s.Require().NoError(store.DeleteCustomToken(1, nft.Address))
+	tokens, err = store.GetCustomTokens(1)
+	s.Require().NoError(err)
+	s.Equal([]wallet.Token{abc}, tokens, "Custom tokens are replaced")
+
+	tokens, err = store.GetCustomTokens(3)
+	s.Require().NoError(err)
+	s.Require().Len(tokens, 1, "Custom tokens are saved for a network")
+	s.Equal(uint(6), tokens[0].Decimals)
+}
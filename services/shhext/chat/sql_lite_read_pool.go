@@ -0,0 +1,88 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+)
+
+// connector opens connections to a SQLCipher database and executes the pragmas on
+// each of them, as the key and most pragmas only apply to the connection executing them.
+type connector struct {
+	path    string
+	pragmas []string
+}
+
+// Connect opens a new connection.
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	conn, err := c.Driver().Open(c.path)
+	if err != nil {
+		return nil, err
+	}
+	for _, pragma := range c.pragmas {
+		if _, err := conn.(driver.Execer).Exec(pragma, nil); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// Driver returns the SQLCipher driver.
+func (c connector) Driver() driver.Driver {
+	return newDriver()
+}
+
+// readPool is a pool of read-only connections to a database in WAL journal mode,
+// which read while the single writer connection writes.
+type readPool struct {
+	path   string
+	config PersistenceConfig
+
+	mu sync.RWMutex
+	db *sql.DB
+}
+
+// open opens the pool with a key, closing the connections opened with the previous key.
+func (p *readPool) open(key string) error {
+	pragmas := append([]string{fmt.Sprintf("PRAGMA key = '%s'", key)}, p.config.readPragmas()...)
+	db := sql.OpenDB(connector{path: p.path, pragmas: pragmas})
+	db.SetMaxOpenConns(p.config.ReadConnections)
+	db.SetMaxIdleConns(p.config.ReadConnections)
+	// Fail early if the key is wrong, as it's only checked when reading the database
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master").Scan(&count); err != nil {
+		db.Close()
+		return err
+	}
+
+	p.mu.Lock()
+	previous := p.db
+	p.db = db
+	p.mu.Unlock()
+
+	if previous != nil {
+		return previous.Close()
+	}
+	return nil
+}
+
+func (p *readPool) get() *sql.DB {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.db
+}
+
+// close closes the connections of the pool.
+func (p *readPool) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.db == nil {
+		return nil
+	}
+	err := p.db.Close()
+	p.db = nil
+	return err
+}
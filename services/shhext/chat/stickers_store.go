@@ -0,0 +1,112 @@
+package chat
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/services/stickers"
+)
+
+// StickersStore stores the sticker packs installed by the account, in the chat database of the account.
+type StickersStore struct {
+	accountDB
+}
+
+// StickersStore returns the store of the sticker packs installed by the account.
+func (s *SQLLitePersistence) StickersStore() *StickersStore {
+	return &StickersStore{s.accountDB()}
+}
+
+// SaveStickerPack installs a sticker pack on a network with its images, by content hash, replacing the previous installation, in a single transaction
+func (s *StickersStore) SaveStickerPack(networkID uint64, pack stickers.Pack, content map[string][]byte) error {
+	data, err := json.Marshal(pack)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`INSERT INTO sticker_packs(network_id, id, data, installed_at) VALUES (?, ?, ?, ?)`,
+		networkID, pack.ID, data, pack.InstalledAt); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM sticker_content WHERE network_id = ? AND pack_id = ?`,
+		networkID, pack.ID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	for hash, image := range content {
+		decoded, err := hexutil.Decode(hash)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if _, err = tx.Exec(`INSERT INTO sticker_content(network_id, pack_id, hash, data) VALUES (?, ?, ?, ?)`,
+			networkID, pack.ID, decoded, image); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetStickerPacks returns the sticker packs installed on a network, in the order they were installed
+func (s *StickersStore) GetStickerPacks(networkID uint64) ([]stickers.Pack, error) {
+	rows, err := s.db.Query(`SELECT data FROM sticker_packs WHERE network_id = ? ORDER BY installed_at, id`,
+		networkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []stickers.Pack
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var pack stickers.Pack
+		if err := json.Unmarshal(data, &pack); err != nil {
+			return nil, err
+		}
+		result = append(result, pack)
+	}
+	return result, rows.Err()
+}
+
+// GetStickerContent returns an image of an installed sticker pack by content hash, or nil if no installed pack has it
+func (s *StickersStore) GetStickerContent(networkID uint64, hash []byte) ([]byte, error) {
+	var data []byte
+	err := s.db.QueryRow(`SELECT data FROM sticker_content WHERE network_id = ? AND hash = ? LIMIT 1`,
+		networkID, hash).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return data, err
+}
+
+// DeleteStickerPack uninstalls a sticker pack and deletes its images, in a single transaction
+func (s *StickersStore) DeleteStickerPack(networkID uint64, id uint64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`DELETE FROM sticker_packs WHERE network_id = ? AND id = ?`,
+		networkID, id); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM sticker_content WHERE network_id = ? AND pack_id = ?`,
+		networkID, id); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
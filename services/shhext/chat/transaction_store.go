@@ -0,0 +1,107 @@
+package chat
+
+import (
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/status-im/status-go/transactions"
+)
+
+// TransactionStore stores the transactions waiting for an external signature and the transactions sent by the account, for the transactions package, in the chat database of the account.
+type TransactionStore struct {
+	accountDB
+}
+
+// TransactionStore returns the store of the transactions waiting for an external signature and the transactions sent by the account, for the transactions package.
+func (s *SQLLitePersistence) TransactionStore() *TransactionStore {
+	return &TransactionStore{s.accountDB()}
+}
+
+// SaveUnsignedTransaction persists a transaction of the account waiting for an external signature
+func (s *TransactionStore) SaveUnsignedTransaction(tx transactions.UnsignedTransaction) error {
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO unsigned_transactions(hash, expires, data) VALUES (?, ?, ?)`,
+		tx.Hash.Bytes(), tx.Expires, data)
+	return err
+}
+
+// DeleteUnsignedTransaction deletes a transaction waiting for an external signature
+func (s *TransactionStore) DeleteUnsignedTransaction(hash common.Hash) error {
+	_, err := s.db.Exec(`DELETE FROM unsigned_transactions WHERE hash = ?`, hash.Bytes())
+	return err
+}
+
+// GetUnsignedTransactions returns the transactions of the account waiting for an external signature
+func (s *TransactionStore) GetUnsignedTransactions() ([]transactions.UnsignedTransaction, error) {
+	rows, err := s.db.Query(`SELECT data FROM unsigned_transactions ORDER BY expires`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []transactions.UnsignedTransaction
+	for rows.Next() {
+		var (
+			data []byte
+			tx   transactions.UnsignedTransaction
+		)
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &tx); err != nil {
+			return nil, err
+		}
+		result = append(result, tx)
+	}
+	return result, rows.Err()
+}
+
+// SaveSentTransaction persists a transaction sent by the account, so that it can be replaced after a restart
+func (s *TransactionStore) SaveSentTransaction(tx transactions.SentTransaction) error {
+	data, err := rlp.EncodeToBytes(tx.Tx)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO sent_transactions(hash, original, chain_id, sender, data) VALUES (?, ?, ?, ?, ?)`,
+		tx.Tx.Hash().Bytes(), tx.Original.Bytes(), tx.ChainID, tx.From.Bytes(), data)
+	return err
+}
+
+// DeleteSentTransactions deletes the transactions of the account competing with original
+func (s *TransactionStore) DeleteSentTransactions(original common.Hash) error {
+	_, err := s.db.Exec(`DELETE FROM sent_transactions WHERE original = ?`, original.Bytes())
+	return err
+}
+
+// GetSentTransactions returns the transactions sent by the account, in the order they were saved
+func (s *TransactionStore) GetSentTransactions() ([]transactions.SentTransaction, error) {
+	rows, err := s.db.Query(`SELECT original, chain_id, sender, data FROM sent_transactions ORDER BY rowid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []transactions.SentTransaction
+	for rows.Next() {
+		var (
+			original, sender, data []byte
+			sent                   transactions.SentTransaction
+		)
+		if err := rows.Scan(&original, &sent.ChainID, &sender, &data); err != nil {
+			return nil, err
+		}
+		sent.Tx = new(types.Transaction)
+		if err := rlp.DecodeBytes(data, sent.Tx); err != nil {
+			return nil, err
+		}
+		sent.Original = common.BytesToHash(original)
+		sent.From = common.BytesToAddress(sender)
+		result = append(result, sent)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,120 @@
+package chat
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// DefaultTranslationsQueueSize is the number of messages waiting for their translation
+// above which the messages received are not translated.
+const DefaultTranslationsQueueSize = 100
+
+// TranslationHandler is notified of the translation of a received message.
+type TranslationHandler func(hash common.Hash, language string, translation string)
+
+type translationJob struct {
+	hash     common.Hash
+	payload  []byte
+	language string
+}
+
+// Translations translates the received messages in the background, so that a slow
+// translator doesn't delay their delivery. The handler annotates the messages once
+// they are translated.
+type Translations struct {
+	handler TranslationHandler
+	jobs    chan translationJob
+
+	mu         sync.RWMutex
+	translator Translator
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewTranslations returns Translations running translator, which can be nil to
+// disable translations, with a queue of size messages.
+func NewTranslations(translator Translator, handler TranslationHandler, size int) *Translations {
+	if size <= 0 {
+		size = DefaultTranslationsQueueSize
+	}
+	return &Translations{
+		handler:    handler,
+		jobs:       make(chan translationJob, size),
+		translator: translator,
+	}
+}
+
+// SetTranslator sets the translator of the messages. nil disables translations.
+func (t *Translations) SetTranslator(translator Translator) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.translator = translator
+}
+
+// Enabled returns true if a translator is set.
+func (t *Translations) Enabled() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.translator != nil
+}
+
+// Start translates the queued messages until Stop is called.
+func (t *Translations) Start() {
+	t.quit = make(chan struct{})
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		for {
+			select {
+			case job := <-t.jobs:
+				t.translate(job)
+			case <-t.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops translating messages. The messages left in the queue are not translated.
+func (t *Translations) Stop() {
+	if t.quit == nil {
+		return
+	}
+	close(t.quit)
+	t.wg.Wait()
+	t.quit = nil
+}
+
+// Add queues the payload of the message hash to be translated to language. It returns
+// false if translations are disabled or if the queue is full.
+func (t *Translations) Add(hash common.Hash, payload []byte, language string) bool {
+	if !t.Enabled() {
+		return false
+	}
+	job := translationJob{hash: hash, payload: append([]byte{}, payload...), language: language}
+	select {
+	case t.jobs <- job:
+		return true
+	default:
+		log.Warn("translations queue is full, message is not translated", "hash", hash)
+		return false
+	}
+}
+
+func (t *Translations) translate(job translationJob) {
+	t.mu.RLock()
+	translator := t.translator
+	t.mu.RUnlock()
+	if translator == nil {
+		return
+	}
+	translation, err := translator.Translate(job.payload, job.language)
+	if err != nil {
+		log.Warn("failed to translate message", "hash", job.hash, "err", err)
+		return
+	}
+	t.handler(job.hash, job.language, translation)
+}
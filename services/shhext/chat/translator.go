@@ -0,0 +1,78 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// defaultTranslatorTimeout is the time we wait for a translation before giving up.
+const defaultTranslatorTimeout = 5 * time.Second
+
+// Translator translates the decrypted payload of a message into a given language.
+type Translator interface {
+	Translate(payload []byte, language string) (string, error)
+}
+
+// TranslatorFunc is an adapter to allow the use of ordinary functions as a Translator,
+// for example when the translation is provided by the client through a binding.
+type TranslatorFunc func(payload []byte, language string) (string, error)
+
+// Translate calls f(payload, language).
+func (f TranslatorFunc) Translate(payload []byte, language string) (string, error) {
+	return f(payload, language)
+}
+
+// TranslationRequest is the payload sent to an HTTP translation server.
+type TranslationRequest struct {
+	Payload  hexutil.Bytes `json:"payload"`
+	Language string        `json:"language"`
+}
+
+// TranslationResponse is the payload received from an HTTP translation server.
+type TranslationResponse struct {
+	Translation string `json:"translation"`
+}
+
+// HTTPTranslator is a Translator backed by an external HTTP server.
+type HTTPTranslator struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPTranslator returns a new HTTPTranslator posting requests to url.
+func NewHTTPTranslator(url string) *HTTPTranslator {
+	return &HTTPTranslator{
+		url:    url,
+		client: &http.Client{Timeout: defaultTranslatorTimeout},
+	}
+}
+
+// Translate posts the payload to the translation server and returns its translation.
+func (t *HTTPTranslator) Translate(payload []byte, language string) (string, error) {
+	body, err := json.Marshal(TranslationRequest{Payload: payload, Language: language})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := t.client.Post(t.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("translation server returned status %d", resp.StatusCode)
+	}
+
+	var response TranslationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", err
+	}
+
+	return response.Translation, nil
+}
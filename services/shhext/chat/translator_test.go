@@ -0,0 +1,66 @@
+package chat
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPTranslator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request TranslationRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		require.Equal(t, "it", request.Language)
+		require.NoError(t, json.NewEncoder(w).Encode(TranslationResponse{
+			Translation: string(request.Payload) + "-it",
+		}))
+	}))
+	defer server.Close()
+
+	translation, err := NewHTTPTranslator(server.URL).Translate([]byte("hello"), "it")
+	require.NoError(t, err)
+	require.Equal(t, "hello-it", translation)
+}
+
+func TestHTTPTranslatorError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, err := NewHTTPTranslator(server.URL).Translate([]byte("hello"), "it")
+	require.Error(t, err)
+}
+
+func TestTranslations(t *testing.T) {
+	unblock := make(chan struct{})
+	translator := TranslatorFunc(func(payload []byte, language string) (string, error) {
+		<-unblock
+		return string(payload) + "-" + language, nil
+	})
+	translated := make(chan string, 2)
+	translations := NewTranslations(nil, func(hash common.Hash, language, translation string) {
+		translated <- translation
+	}, 1)
+	require.False(t, translations.Add(common.Hash{1}, []byte("hello"), "it"), "Translations are disabled without translator")
+
+	translations.SetTranslator(translator)
+	translations.Start()
+	defer translations.Stop()
+
+	require.True(t, translations.Add(common.Hash{1}, []byte("hello"), "it"), "Add doesn't wait for the translator")
+	// the first message is being translated once the queue is empty again
+	for start := time.Now(); len(translations.jobs) > 0 && time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+	}
+	require.True(t, translations.Add(common.Hash{2}, []byte("world"), "it"))
+	require.False(t, translations.Add(common.Hash{3}, []byte("dropped"), "it"), "Messages are not translated when the queue is full")
+
+	close(unblock)
+	require.Equal(t, "hello-it", <-translated)
+	require.Equal(t, "world-it", <-translated)
+}
@@ -0,0 +1,200 @@
+package chat
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/services/wallet"
+)
+
+// WalletStore stores the transfers, custom tokens and balance snapshots of the wallet of the account, in the chat database of the account.
+type WalletStore struct {
+	accountDB
+}
+
+// WalletStore returns the store of the transfers, custom tokens and balance snapshots of the wallet of the account.
+func (s *SQLLitePersistence) WalletStore() *WalletStore {
+	return &WalletStore{s.accountDB()}
+}
+
+// SaveTransfers saves the transfers of an address on a chain and the last block scanned for it, in a single transaction
+func (s *WalletStore) SaveTransfers(chainID uint64, address common.Address, transfers []wallet.Transfer, block uint64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, transfer := range transfers {
+		data, err := json.Marshal(transfer)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if _, err = tx.Exec(`INSERT INTO wallet_transfers(chain_id, address, id, block_number, data) VALUES (?, ?, ?, ?, ?)`,
+			chainID, address.Bytes(), transfer.ID, uint64(transfer.BlockNumber), data); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err = tx.Exec(`INSERT INTO wallet_transfers_blocks(chain_id, address, block) VALUES (?, ?, ?)`,
+		chainID, address.Bytes(), block); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetTransfersBlock returns the last block scanned for the transfers of an address on a chain, or false if it was never scanned
+func (s *WalletStore) GetTransfersBlock(chainID uint64, address common.Address) (uint64, bool, error) {
+	var block uint64
+	err := s.db.QueryRow(`SELECT block FROM wallet_transfers_blocks WHERE chain_id = ? AND address = ?`,
+		chainID, address.Bytes()).Scan(&block)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return block, true, nil
+}
+
+// GetTransfers returns at most limit transfers of an address on a chain preceding the cursor, from the most recent
+func (s *WalletStore) GetTransfers(chainID uint64, address common.Address, cursor *wallet.TransfersCursor, limit int) ([]wallet.Transfer, error) {
+	query := `SELECT data FROM wallet_transfers WHERE chain_id = ? AND address = ?`
+	args := []interface{}{chainID, address.Bytes()}
+	if cursor != nil {
+		query += ` AND (block_number < ? OR (block_number = ? AND id < ?))`
+		args = append(args, cursor.BlockNumber, cursor.BlockNumber, cursor.ID)
+	}
+	query += ` ORDER BY block_number DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []wallet.Transfer
+	for rows.Next() {
+		var (
+			data     []byte
+			transfer wallet.Transfer
+		)
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &transfer); err != nil {
+			return nil, err
+		}
+		result = append(result, transfer)
+	}
+	return result, rows.Err()
+}
+
+// SaveCustomToken saves a custom token of a network, or replaces it
+func (s *WalletStore) SaveCustomToken(networkID uint64, token wallet.Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO wallet_custom_tokens(network_id, address, data) VALUES (?, ?, ?)`,
+		networkID, token.Address.Bytes(), data)
+	return err
+}
+
+// DeleteCustomToken deletes a custom token of a network
+func (s *WalletStore) DeleteCustomToken(networkID uint64, address common.Address) error {
+	_, err := s.db.Exec(`DELETE FROM wallet_custom_tokens WHERE network_id = ? AND address = ?`,
+		networkID, address.Bytes())
+	return err
+}
+
+// GetCustomTokens returns the custom tokens of a network, in the order they were saved
+func (s *WalletStore) GetCustomTokens(networkID uint64) ([]wallet.Token, error) {
+	rows, err := s.db.Query(`SELECT data FROM wallet_custom_tokens WHERE network_id = ? ORDER BY rowid`,
+		networkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []wallet.Token
+	for rows.Next() {
+		var (
+			data  []byte
+			token wallet.Token
+		)
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &token); err != nil {
+			return nil, err
+		}
+		result = append(result, token)
+	}
+	return result, rows.Err()
+}
+
+// SaveBalanceSnapshots saves the balance snapshots of a chain, replacing those of the same day, address and token, in a single transaction
+func (s *WalletStore) SaveBalanceSnapshots(chainID uint64, snapshots []wallet.BalanceSnapshot) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, snapshot := range snapshots {
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if _, err = tx.Exec(`INSERT INTO wallet_balance_snapshots(chain_id, address, token, timestamp, data) VALUES (?, ?, ?, ?, ?)`,
+			chainID, snapshot.Address.Bytes(), snapshot.Token.Bytes(), snapshot.Timestamp, data); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetLastBalanceSnapshot returns the timestamp of the last balance snapshot of a chain, or false if none was saved
+func (s *WalletStore) GetLastBalanceSnapshot(chainID uint64) (int64, bool, error) {
+	var timestamp sql.NullInt64
+	err := s.db.QueryRow(`SELECT MAX(timestamp) FROM wallet_balance_snapshots WHERE chain_id = ?`,
+		chainID).Scan(&timestamp)
+	if err != nil {
+		return 0, false, err
+	}
+	return timestamp.Int64, timestamp.Valid, nil
+}
+
+// GetBalanceSnapshots returns the balance snapshots of a token of an address on a chain since a timestamp, from the oldest
+func (s *WalletStore) GetBalanceSnapshots(chainID uint64, address, token common.Address, since int64) ([]wallet.BalanceSnapshot, error) {
+	rows, err := s.db.Query(`SELECT data FROM wallet_balance_snapshots WHERE chain_id = ? AND address = ? AND token = ? AND timestamp >= ? ORDER BY timestamp`,
+		chainID, address.Bytes(), token.Bytes(), since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []wallet.BalanceSnapshot
+	for rows.Next() {
+		var (
+			data     []byte
+			snapshot wallet.BalanceSnapshot
+		)
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			return nil, err
+		}
+		result = append(result, snapshot)
+	}
+	return result, rows.Err()
+}
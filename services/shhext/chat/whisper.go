@@ -1,6 +1,10 @@
 package chat
 
 import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
 	"github.com/ethereum/go-ethereum/crypto"
 	whisper "github.com/status-im/whisper/whisperv6"
 )
@@ -8,10 +12,32 @@ import (
 var discoveryTopic = "contact-discovery"
 var discoveryTopicBytes = toTopic(discoveryTopic)
 
+// PartitionsNum is the number of partitioned topics direct messages are spread over.
+const PartitionsNum = 5000
+
+var partitionsNum = big.NewInt(PartitionsNum)
+
 func toTopic(s string) whisper.TopicType {
 	return whisper.BytesToTopic(crypto.Keccak256([]byte(s)))
 }
 
+// ChatTopic returns the whisper topic of a chat.
+func ChatTopic(chatID string) whisper.TopicType {
+	return toTopic(chatID)
+}
+
+// DiscoveryTopic returns the whisper topic of direct messages sent outside of a chat.
+func DiscoveryTopic() whisper.TopicType {
+	return discoveryTopicBytes
+}
+
+// PartitionedTopic returns the whisper topic on which the owner of the public key
+// receives direct messages, derived from the key modulo PartitionsNum.
+func PartitionedTopic(publicKey *ecdsa.PublicKey) whisper.TopicType {
+	partition := new(big.Int).Mod(publicKey.X, partitionsNum)
+	return toTopic(fmt.Sprintf("%s-%d", discoveryTopic, partition))
+}
+
 func defaultWhisperMessage() whisper.NewMessage {
 	msg := whisper.NewMessage{}
 
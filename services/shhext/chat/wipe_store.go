@@ -0,0 +1,69 @@
+package chat
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/status-im/status-go/services/shhext/wipe"
+)
+
+// WipeStore stores the wipes issued by the installation, in the chat database of the account.
+type WipeStore struct {
+	accountDB
+}
+
+// WipeStore returns the store of the wipes issued by the installation.
+func (s *SQLLitePersistence) WipeStore() *WipeStore {
+	return &WipeStore{s.accountDB()}
+}
+
+// SaveWipe saves a wipe issued by the installation, replacing the one of the same command
+func (s *WipeStore) SaveWipe(w wipe.Wipe) error {
+	data, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO wipes(id, issued_at, data) VALUES (?, ?, ?)`,
+		w.Command.ID, w.Command.IssuedAt, data)
+	return err
+}
+
+// GetWipe returns the wipe of a command, or nil if it was not issued
+func (s *WipeStore) GetWipe(id string) (*wipe.Wipe, error) {
+	var data []byte
+	err := s.db.QueryRow(`SELECT data FROM wipes WHERE id = ?`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var w wipe.Wipe
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// GetWipes returns the wipes issued by the installation, from the most recent
+func (s *WipeStore) GetWipes() ([]wipe.Wipe, error) {
+	rows, err := s.db.Query(`SELECT data FROM wipes ORDER BY issued_at DESC, rowid DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []wipe.Wipe
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var w wipe.Wipe
+		if err := json.Unmarshal(data, &w); err != nil {
+			return nil, err
+		}
+		result = append(result, w)
+	}
+	return result, rows.Err()
+}
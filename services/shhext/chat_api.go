@@ -0,0 +1,77 @@
+package shhext
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/services/shhext/chat"
+	"github.com/status-im/status-go/services/shhext/history"
+)
+
+var errChatRequired = errors.New("either a chat or a contact is required")
+
+// ChatAPI represents a set of APIs from the `chat` namespace.
+type ChatAPI struct {
+	s *Service
+}
+
+// NewChatAPI creates an instance of the chat API.
+func NewChatAPI(s *Service) *ChatAPI {
+	return &ChatAPI{s: s}
+}
+
+// MessagesQuery is a GetMessages() request payload.
+type MessagesQuery struct {
+	// Chat is the name of a public chat.
+	Chat string `json:"chat"`
+	// Contact is the public key of the contact of a 1:1 chat, if Chat is empty.
+	Contact hexutil.Bytes `json:"contact"`
+	// Cursor is the cursor of the previous page, empty for the first page.
+	Cursor string `json:"cursor"`
+	// Limit is the maximum number of messages of the page.
+	Limit int `json:"limit"`
+}
+
+// SearchQuery is a SearchMessages() request payload.
+type SearchQuery struct {
+	// Query are the words the messages must contain.
+	Query string `json:"query"`
+	// Chat and Contact restrict the search to a chat as in MessagesQuery.
+	// All chats are searched if both are empty.
+	Chat    string        `json:"chat"`
+	Contact hexutil.Bytes `json:"contact"`
+	// Limit is the maximum number of messages returned.
+	Limit int `json:"limit"`
+}
+
+// GetMessages returns a page of the history of a chat, from the most recent message.
+func (api *ChatAPI) GetMessages(q MessagesQuery) (history.Page, error) {
+	if api.s.history == nil {
+		return history.Page{}, errProtocolNotInitialized
+	}
+	chatID := historyChatID(q.Chat, q.Contact)
+	if chatID == "" {
+		return history.Page{}, errChatRequired
+	}
+	return api.s.history.Messages(chatID, q.Cursor, q.Limit)
+}
+
+// SearchMessages returns the messages of the history containing the words of the query,
+// from the most relevant.
+func (api *ChatAPI) SearchMessages(q SearchQuery) ([]history.Message, error) {
+	if api.s.history == nil {
+		return nil, errProtocolNotInitialized
+	}
+	return api.s.history.Search(q.Query, historyChatID(q.Chat, q.Contact), q.Limit)
+}
+
+// historyChatID returns the ID in the history of a public chat or a 1:1 chat.
+func historyChatID(chatName string, contact []byte) string {
+	if chatName != "" {
+		return history.PublicChatID(chat.ChatTopic(chatName))
+	}
+	if len(contact) > 0 {
+		return history.DirectChatID(contact)
+	}
+	return ""
+}
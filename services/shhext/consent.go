@@ -0,0 +1,60 @@
+package shhext
+
+import (
+	"crypto/ecdsa"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// contactAllowed returns true if an identity is allowed to add its bundle and establish
+// a session with us: it's not blocked, and its contact request was accepted, it's a contact
+// or we sent it a message.
+func (s *Service) contactAllowed(publicKey *ecdsa.PublicKey) bool {
+	if s.blocked(crypto.FromECDSAPub(publicKey)) {
+		return false
+	}
+	key := hexutil.Encode(crypto.FromECDSAPub(publicKey))
+	allowed, err := s.consent.Allowed(key)
+	if err != nil {
+		logger.Error("failed to check contact request", "err", err)
+		return false
+	}
+	if allowed {
+		return true
+	}
+	contact, err := s.contacts.Contact(key)
+	if err != nil {
+		logger.Error("failed to check contact", "err", err)
+		return false
+	}
+	return contact != nil
+}
+
+// quarantine keeps a message of an identity that is not allowed to contact us in the inbox
+// until its contact request is accepted, unless it was rejected.
+func (s *Service) quarantine(publicKey *ecdsa.PublicKey, msg *whisper.Message) {
+	keep, err := s.consent.Receive(hexutil.Encode(crypto.FromECDSAPub(publicKey)), s.w.GetCurrentTime())
+	if err != nil {
+		logger.Error("failed to record contact request", "hash", msg.Hash, "err", err)
+		return
+	}
+	if !keep {
+		logger.Debug("dropping message of a rejected identity", "hash", msg.Hash)
+		return
+	}
+	if err := s.inbox.Add(publicKey, msg); err != nil {
+		logger.Error("failed to keep contact request in the inbox", "hash", msg.Hash, "err", err)
+	}
+}
+
+// acceptContact allows an identity to contact us, as we added it as a contact or sent it a message.
+func (s *Service) acceptContact(publicKey []byte) {
+	if s.consent == nil {
+		return
+	}
+	if _, err := s.consent.Accept(hexutil.Encode(publicKey)); err != nil {
+		logger.Error("failed to accept contact", "err", err)
+	}
+}
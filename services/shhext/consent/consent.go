@@ -0,0 +1,139 @@
+package consent
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// Pending requests are waiting for the user to accept or reject them.
+	Pending = "pending"
+	// Accepted identities can establish a session with us.
+	Accepted = "accepted"
+	// Rejected identities can't contact us, their messages are dropped.
+	Rejected = "rejected"
+
+	// DefaultMaxPending is the maximum number of pending requests. Messages of new
+	// identities are dropped once it's reached.
+	DefaultMaxPending = 100
+)
+
+// Request is the state of the consent of the user to be contacted by an identity.
+type Request struct {
+	// PublicKey is the hex-encoded public key of the identity.
+	PublicKey string `json:"publicKey"`
+	State     string `json:"state"`
+	// ReceivedAt is the time the first message of the identity was received, in milliseconds.
+	// It is zero if the identity was accepted before sending any message.
+	ReceivedAt int64 `json:"receivedAt"`
+}
+
+// Store persists the requests.
+type Store interface {
+	// SaveContactRequest saves a request, replacing the request of the same identity.
+	SaveContactRequest(Request) error
+	// GetContactRequest returns the request of an identity, or nil if there is none.
+	GetContactRequest(publicKey string) (*Request, error)
+	// GetContactRequests returns the requests in a state, from the oldest.
+	GetContactRequests(state string) ([]Request, error)
+	// CountContactRequests returns the number of requests in a state.
+	CountContactRequests(state string) (int, error)
+}
+
+// Handler is notified of new pending requests.
+type Handler func(Request)
+
+// Manager records the identities the user accepted or rejected, and the requests
+// of the identities that contacted us without being accepted.
+type Manager struct {
+	store      Store
+	handler    Handler
+	maxPending int
+
+	mu sync.Mutex
+}
+
+// NewManager returns a new Manager keeping at most maxPending pending requests.
+func NewManager(store Store, handler Handler, maxPending int) *Manager {
+	return &Manager{store: store, handler: handler, maxPending: maxPending}
+}
+
+// Allowed returns true if an identity was accepted.
+func (m *Manager) Allowed(publicKey string) (bool, error) {
+	r, err := m.store.GetContactRequest(publicKey)
+	if err != nil {
+		return false, err
+	}
+	return r != nil && r.State == Accepted, nil
+}
+
+// Receive records that an identity that is not accepted sent a message. It returns true
+// if the message must be kept until the request is accepted, and false if it must be
+// dropped, because the identity was rejected or there are too many pending requests.
+// The handler is notified of new requests.
+func (m *Manager) Receive(publicKey string, now time.Time) (bool, error) {
+	request, keep, err := m.receive(publicKey, now)
+	if err != nil {
+		return false, err
+	}
+	if request != nil && m.handler != nil {
+		m.handler(*request)
+	}
+	return keep, nil
+}
+
+// receive returns the request created for the identity, if it's new.
+func (m *Manager) receive(publicKey string, now time.Time) (*Request, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, err := m.store.GetContactRequest(publicKey)
+	if err != nil {
+		return nil, false, err
+	}
+	if r != nil {
+		return nil, r.State != Rejected, nil
+	}
+	count, err := m.store.CountContactRequests(Pending)
+	if err != nil || count >= m.maxPending {
+		return nil, false, err
+	}
+	request := &Request{PublicKey: publicKey, State: Pending, ReceivedAt: now.UnixNano() / int64(time.Millisecond)}
+	if err := m.store.SaveContactRequest(*request); err != nil {
+		return nil, false, err
+	}
+	return request, true, nil
+}
+
+// Accept allows an identity to establish a session with us. Identities are
+// accepted when the user accepts their request, adds them as contacts or
+// sends them a message. It returns false if the identity was already accepted.
+func (m *Manager) Accept(publicKey string) (bool, error) {
+	return m.set(publicKey, Accepted)
+}
+
+// Reject prevents an identity from contacting us until it is accepted.
+// It returns false if the identity was already rejected.
+func (m *Manager) Reject(publicKey string) (bool, error) {
+	return m.set(publicKey, Rejected)
+}
+
+// Requests returns the pending requests, from the oldest.
+func (m *Manager) Requests() ([]Request, error) {
+	return m.store.GetContactRequests(Pending)
+}
+
+func (m *Manager) set(publicKey string, state string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, err := m.store.GetContactRequest(publicKey)
+	if err != nil {
+		return false, err
+	}
+	if r == nil {
+		r = &Request{PublicKey: publicKey}
+	} else if r.State == state {
+		return false, nil
+	}
+	r.State = state
+	return true, m.store.SaveContactRequest(*r)
+}
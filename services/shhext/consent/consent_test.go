@@ -0,0 +1,101 @@
+package consent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore map[string]Request
+
+func (s memoryStore) SaveContactRequest(r Request) error {
+	s[r.PublicKey] = r
+	return nil
+}
+
+func (s memoryStore) GetContactRequest(publicKey string) (*Request, error) {
+	r, ok := s[publicKey]
+	if !ok {
+		return nil, nil
+	}
+	return &r, nil
+}
+
+func (s memoryStore) GetContactRequests(state string) ([]Request, error) {
+	var result []Request
+	for _, r := range s {
+		if r.State == state {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+func (s memoryStore) CountContactRequests(state string) (int, error) {
+	requests, err := s.GetContactRequests(state)
+	return len(requests), err
+}
+
+func TestReceive(t *testing.T) {
+	var notified []Request
+	m := NewManager(memoryStore{}, func(r Request) {
+		notified = append(notified, r)
+	}, 1)
+	now := time.Unix(1, 0)
+
+	keep, err := m.Receive("0x01", now)
+	require.NoError(t, err)
+	require.True(t, keep)
+	keep, err = m.Receive("0x01", now.Add(time.Second))
+	require.NoError(t, err)
+	require.True(t, keep, "Messages of pending requests are kept")
+	require.Equal(t, []Request{{PublicKey: "0x01", State: Pending, ReceivedAt: 1000}}, notified, "Only new requests are notified")
+
+	keep, err = m.Receive("0x02", now)
+	require.NoError(t, err)
+	require.False(t, keep, "Messages of new identities are dropped when there are too many pending requests")
+	require.Len(t, notified, 1)
+
+	allowed, err := m.Allowed("0x01")
+	require.NoError(t, err)
+	require.False(t, allowed)
+	requests, err := m.Requests()
+	require.NoError(t, err)
+	require.Equal(t, notified, requests)
+}
+
+func TestAcceptReject(t *testing.T) {
+	m := NewManager(memoryStore{}, nil, DefaultMaxPending)
+	_, err := m.Receive("0x01", time.Unix(1, 0))
+	require.NoError(t, err)
+
+	changed, err := m.Accept("0x01")
+	require.NoError(t, err)
+	require.True(t, changed)
+	changed, err = m.Accept("0x01")
+	require.NoError(t, err)
+	require.False(t, changed)
+	allowed, err := m.Allowed("0x01")
+	require.NoError(t, err)
+	require.True(t, allowed)
+	requests, err := m.Requests()
+	require.NoError(t, err)
+	require.Empty(t, requests)
+
+	changed, err = m.Reject("0x01")
+	require.NoError(t, err)
+	require.True(t, changed)
+	allowed, err = m.Allowed("0x01")
+	require.NoError(t, err)
+	require.False(t, allowed)
+	keep, err := m.Receive("0x01", time.Unix(2, 0))
+	require.NoError(t, err)
+	require.False(t, keep, "Messages of rejected identities are dropped")
+
+	_, err = m.Accept("0x02")
+	require.NoError(t, err)
+	allowed, err = m.Allowed("0x02")
+	require.NoError(t, err)
+	require.True(t, allowed, "Identities can be accepted before sending messages")
+}
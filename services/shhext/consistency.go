@@ -0,0 +1,121 @@
+package shhext
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/services/shhext/outbox"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// ConsistencyReport describes the divergences between the persisted state of
+// the account and the state of Whisper that were repaired at login.
+type ConsistencyReport struct {
+	// OwnInstallationDisabled is true if the installation of this device was
+	// recorded as one of our paired installations, so messages were encrypted for it.
+	OwnInstallationDisabled bool `json:"ownInstallationDisabled"`
+	// LookupFiltersReinstalled is true if filters of the bundle lookups were removed from Whisper.
+	LookupFiltersReinstalled bool `json:"lookupFiltersReinstalled"`
+	// BloomFilterRestored is true if Whisper advertised a bloom filter other than the negotiated one.
+	BloomFilterRestored bool `json:"bloomFilterRestored"`
+	// AddedTopics are the topics of persisted chats that were missing from the negotiated topics.
+	AddedTopics []whisper.TopicType `json:"addedTopics"`
+	// ResentMessages is the number of messages added to the history but not sent before
+	// the application stopped, which were sent again.
+	ResentMessages int `json:"resentMessages"`
+}
+
+// Repaired returns true if any divergence was repaired.
+func (r *ConsistencyReport) Repaired() bool {
+	return r.OwnInstallationDisabled || r.LookupFiltersReinstalled || r.BloomFilterRestored ||
+		len(r.AddedTopics) != 0 || r.ResentMessages != 0
+}
+
+// checkConsistency compares the installations and chats persisted for the account
+// with the filters and topics installed in Whisper, and repairs the divergences.
+// Checks that fail are logged and don't prevent the others from running.
+func (s *Service) checkConsistency(identity *ecdsa.PublicKey) *ConsistencyReport {
+	report := &ConsistencyReport{}
+
+	if s.protocol != nil && identity != nil {
+		disabled, err := s.protocol.DisableOwnInstallation(identity)
+		if err != nil {
+			logger.Error("failed to check the installation of this device", "err", err)
+		}
+		report.OwnInstallationDisabled = disabled
+	}
+
+	if s.bundleLookups != nil {
+		reinstalled, err := s.bundleLookups.Repair()
+		if err != nil {
+			logger.Error("failed to reinstall bundle lookup filters", "err", err)
+		}
+		report.LookupFiltersReinstalled = reinstalled
+	}
+
+	if s.bloomFilter != nil {
+		restored, err := s.bloomFilter.Restore()
+		if err != nil {
+			logger.Error("failed to restore the bloom filter", "err", err)
+		}
+		report.BloomFilterRestored = restored
+
+		if s.protocol != nil {
+			topics, err := s.protocol.GetChatTopics()
+			if err != nil {
+				logger.Error("failed to get the topics of persisted chats", "err", err)
+			}
+			added, err := s.bloomFilter.AddTopics(topics)
+			if err != nil {
+				logger.Error("failed to add the topics of persisted chats", "err", err)
+			}
+			report.AddedTopics = added
+		}
+	}
+
+	if s.outbox != nil {
+		api := NewPublicAPI(s)
+		post := func(msg whisper.NewMessage) ([]hexutil.Bytes, error) {
+			return api.postSegmented(context.Background(), msg)
+		}
+		resent, err := s.outbox.Recover(time.Now(), outbox.DefaultMaxAge, s.prepareOutboxEntry, post)
+		if err != nil {
+			logger.Error("failed to send the messages of the outbox", "err", err)
+		}
+		report.ResentMessages = resent
+	}
+
+	return report
+}
+
+// prepareOutboxEntry adds the symmetric key of the public chat of an entry of the outbox
+// to Whisper, as the ID of the key the messages were posted with is lost on restarts.
+func (s *Service) prepareOutboxEntry(entry *outbox.Entry) error {
+	if entry.Chat == "" {
+		return nil
+	}
+	symKeyID, err := s.w.AddSymKeyFromPassword(entry.Chat)
+	if err != nil {
+		return err
+	}
+	for i := range entry.Messages {
+		entry.Messages[i].SymKeyID = symKeyID
+	}
+	return nil
+}
+
+// repairConsistency checks the consistency of the state of the selected account
+// and sends a signal if divergences were repaired.
+func (s *Service) repairConsistency() {
+	var identity *ecdsa.PublicKey
+	if privateKey, err := s.selectedIdentity(); err == nil {
+		identity = &privateKey.PublicKey
+	}
+	report := s.checkConsistency(identity)
+	if report.Repaired() {
+		logger.Warn("repaired inconsistent state", "report", report)
+		EnvelopeSignalHandler{}.ConsistencyRepaired(report)
+	}
+}
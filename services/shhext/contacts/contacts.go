@@ -0,0 +1,205 @@
+package contacts
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// Confusable warnings are raised for names that look the same as the name of another contact.
+	Confusable = "confusable"
+	// Similar warnings are raised for names that differ by few characters from the name of another contact.
+	Similar = "similar"
+
+	// minSimilarLength is the minimum length of the skeletons compared for similarity,
+	// as short names are too often close to each other.
+	minSimilarLength = 5
+	// maxSimilarDistance is the maximum edit distance between the skeletons of similar names.
+	maxSimilarDistance = 1
+)
+
+// ErrEmptyName is returned when adding a contact without a name.
+var ErrEmptyName = errors.New("contact name is empty")
+
+// homoglyphs map characters to the latin letter or digit they are confused with.
+// Characters are lowercased and stripped of their diacritics before being mapped.
+var homoglyphs = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'з': '3', 'і': 'l', 'ї': 'l', 'ј': 'j', 'к': 'k',
+	'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'ѕ': 's',
+	'һ': 'h', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w', 'ь': 'b',
+	// Greek
+	'α': 'a', 'β': 'b', 'γ': 'y', 'ε': 'e', 'η': 'n', 'ι': 'l', 'κ': 'k', 'ν': 'v', 'ο': 'o',
+	'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w',
+	// Latin
+	'ı': 'l', 'ȷ': 'j', 'ł': 'l', 'ø': 'o', 'đ': 'd', 'ß': 's',
+	// Digits and symbols
+	'0': 'o', '1': 'l', 'i': 'l', '|': 'l', '!': 'l', '5': 's', '$': 's', '@': 'a',
+}
+
+// sequences are confused with a single letter, after the homoglyphs are mapped.
+var sequences = strings.NewReplacer("rn", "m", "vv", "w", "cl", "d")
+
+// Skeleton returns the form of a name shared by the names it can be confused with:
+// it is compatibility normalized, lowercased, stripped of diacritics, invisible
+// characters, spaces and punctuation, and homoglyphs are mapped to latin letters.
+func Skeleton(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r), unicode.Is(unicode.Cf, r), unicode.IsSpace(r):
+			continue
+		case unicode.IsPunct(r) && r != '!' && r != '@':
+			continue
+		}
+		r = unicode.ToLower(r)
+		if mapped, ok := homoglyphs[r]; ok {
+			r = mapped
+		}
+		b.WriteRune(r)
+	}
+	return sequences.Replace(b.String())
+}
+
+// Warning flags a name that can be mistaken for the name of another contact.
+type Warning struct {
+	Kind string `json:"kind"`
+	// PublicKey and Name are those of the other contact.
+	PublicKey string `json:"publicKey"`
+	Name      string `json:"name"`
+}
+
+// Contact is a contact with the warnings raised when it was named.
+type Contact struct {
+	PublicKey string    `json:"publicKey"`
+	Name      string    `json:"name"`
+	Warnings  []Warning `json:"warnings"`
+}
+
+// Store persists contacts.
+type Store interface {
+	// SaveContact saves a contact with its warnings, replacing it if it exists.
+	SaveContact(Contact) error
+	// GetContact returns a contact, if any.
+	GetContact(publicKey string) (*Contact, error)
+	// GetContacts returns all the contacts.
+	GetContacts() ([]Contact, error)
+}
+
+// Manager names contacts and warns about names that can be mistaken for the names
+// of other contacts, to flag impersonation attempts.
+type Manager struct {
+	mu    sync.Mutex
+	store Store
+}
+
+// NewManager returns a new Manager.
+func NewManager(store Store) *Manager {
+	return &Manager{store: store}
+}
+
+// Add adds a contact or renames it, with the warnings raised by its name.
+func (m *Manager) Add(publicKey string, name string) (Contact, error) {
+	if strings.TrimSpace(name) == "" {
+		return Contact{}, ErrEmptyName
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	warnings, err := m.check(publicKey, name)
+	if err != nil {
+		return Contact{}, err
+	}
+	contact := Contact{PublicKey: publicKey, Name: name, Warnings: warnings}
+	return contact, m.store.SaveContact(contact)
+}
+
+// Check returns the warnings raised by the name of a public key, without adding it.
+// It is used to flag authors of messages who are not contacts.
+func (m *Manager) Check(publicKey string, name string) ([]Warning, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.check(publicKey, name)
+}
+
+// Contact returns a contact, if any.
+func (m *Manager) Contact(publicKey string) (*Contact, error) {
+	return m.store.GetContact(publicKey)
+}
+
+// Contacts returns all the contacts.
+func (m *Manager) Contacts() ([]Contact, error) {
+	return m.store.GetContacts()
+}
+
+// check must be called with the lock held.
+func (m *Manager) check(publicKey string, name string) ([]Warning, error) {
+	contacts, err := m.store.GetContacts()
+	if err != nil {
+		return nil, err
+	}
+	skeleton := Skeleton(name)
+	warnings := []Warning{}
+	for _, c := range contacts {
+		if c.PublicKey == publicKey {
+			continue
+		}
+		if kind := compare(skeleton, Skeleton(c.Name)); kind != "" {
+			warnings = append(warnings, Warning{Kind: kind, PublicKey: c.PublicKey, Name: c.Name})
+		}
+	}
+	return warnings, nil
+}
+
+// compare returns the kind of warning raised by two skeletons, or an empty string.
+func compare(a, b string) string {
+	if a == "" || b == "" {
+		return ""
+	}
+	if a == b {
+		return Confusable
+	}
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < minSimilarLength || len(rb) < minSimilarLength {
+		return ""
+	}
+	if distance(ra, rb) <= maxSimilarDistance {
+		return Similar
+	}
+	return ""
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, v := range values[1:] {
+		if v < result {
+			result = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,107 @@
+package contacts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore []Contact
+
+func (s *memoryStore) SaveContact(c Contact) error {
+	for i := range *s {
+		if (*s)[i].PublicKey == c.PublicKey {
+			(*s)[i] = c
+			return nil
+		}
+	}
+	*s = append(*s, c)
+	return nil
+}
+
+func (s *memoryStore) GetContact(publicKey string) (*Contact, error) {
+	for _, c := range *s {
+		if c.PublicKey == publicKey {
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) GetContacts() ([]Contact, error) {
+	return *s, nil
+}
+
+func TestSkeleton(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+	}{
+		{"alice", "аlice"},     // Cyrillic a
+		{"alice", "ALICE"},     // case
+		{"alice", "a l i c e"}, // spaces
+		{"alice", "al1ce"},     // digit
+		{"alice", "álïce"},     // diacritics
+		{"alice", "ali​ce"},    // zero width space
+		{"alice", "ａｌｉｃｅ"},     // fullwidth
+		{"mallory", "rnallory"},
+		{"bob_1", "bob-l"},
+		{"οscar", "0scar"}, // Greek omicron
+	} {
+		require.Equal(t, Skeleton(tc.a), Skeleton(tc.b), "%q and %q", tc.a, tc.b)
+	}
+	require.NotEqual(t, Skeleton("alice"), Skeleton("bob"))
+}
+
+func TestAdd(t *testing.T) {
+	m := NewManager(&memoryStore{})
+
+	alice, err := m.Add("0x01", "alice")
+	require.NoError(t, err)
+	require.Empty(t, alice.Warnings)
+
+	mallory, err := m.Add("0x02", "Аlice")
+	require.NoError(t, err)
+	require.Equal(t, []Warning{{Kind: Confusable, PublicKey: "0x01", Name: "alice"}}, mallory.Warnings)
+
+	eve, err := m.Add("0x03", "alicia")
+	require.NoError(t, err)
+	require.Empty(t, eve.Warnings, "Names differing by more than one character are not similar")
+
+	carol, err := m.Add("0x04", "alicea")
+	require.NoError(t, err)
+	require.Len(t, carol.Warnings, 2)
+	require.Equal(t, Similar, carol.Warnings[0].Kind)
+
+	bob, err := m.Add("0x05", "bob")
+	require.NoError(t, err)
+	require.Empty(t, bob.Warnings)
+	warnings, err := m.Check("0x06", "bod")
+	require.NoError(t, err)
+	require.Empty(t, warnings, "Short names are only compared for confusables")
+
+	alice, err = m.Add("0x01", "alice")
+	require.NoError(t, err)
+	require.Len(t, alice.Warnings, 2, "Renamed contacts are checked against the others")
+	contact, err := m.Contact("0x01")
+	require.NoError(t, err)
+	require.Equal(t, &alice, contact)
+
+	_, err = m.Add("0x07", " ")
+	require.Equal(t, ErrEmptyName, err)
+}
+
+func TestCheck(t *testing.T) {
+	m := NewManager(&memoryStore{})
+	_, err := m.Add("0x01", "alice")
+	require.NoError(t, err)
+
+	warnings, err := m.Check("0x01", "alice")
+	require.NoError(t, err)
+	require.Empty(t, warnings, "A contact doesn't impersonate itself")
+	warnings, err = m.Check("0x02", "a1ice")
+	require.NoError(t, err)
+	require.Equal(t, []Warning{{Kind: Confusable, PublicKey: "0x01", Name: "alice"}}, warnings)
+	contacts, err := m.Contacts()
+	require.NoError(t, err)
+	require.Len(t, contacts, 1, "Checked names are not added")
+}
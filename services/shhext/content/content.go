@@ -0,0 +1,254 @@
+package content
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Kind is the kind of a content message. Values match the kinds of chat.ChatMessagePayload.
+type Kind int
+
+const (
+	// KindRegular messages have a content of their own.
+	KindRegular Kind = iota
+	// KindReaction messages add or retract an emoji reaction to the target message.
+	KindReaction
+	// KindEdit messages replace the content of the target message.
+	KindEdit
+	// KindDeletion messages delete the target message.
+	KindDeletion
+)
+
+var (
+	// ErrMissingTarget is returned when applying a reaction, an edit or a deletion without target.
+	ErrMissingTarget = errors.New("message has no target")
+	// ErrEmptyReaction is returned when applying a reaction without emoji.
+	ErrEmptyReaction = errors.New("reaction has no emoji")
+	// ErrUnknownKind is returned when applying a message of an unknown kind.
+	ErrUnknownKind = errors.New("unknown message kind")
+)
+
+// MessageID returns the ID of a message: the keccak256 hash of the public key of
+// its author followed by its payload. Unlike envelope hashes, it's the same on
+// all the devices the message is sent to.
+func MessageID(author []byte, payload []byte) string {
+	return hexutil.Encode(crypto.Keccak256(author, payload))
+}
+
+// Message is a content message sent or received.
+type Message struct {
+	ID string
+	// Author is the hex-encoded public key of the author.
+	Author string
+	Kind   Kind
+	// TargetID is the ID of the message reacted to, edited or deleted.
+	TargetID string
+	// Content is the emoji of reactions and the new content of edits.
+	Content string
+	// Retracted is set by reactions that retract a previous reaction.
+	Retracted bool
+	Clock     uint64
+}
+
+// Change is the latest edit or deletion of a message by an author.
+type Change struct {
+	TargetID string
+	Author   string
+	Content  string
+	Deleted  bool
+	Clock    uint64
+}
+
+// Reaction is the latest reaction of an author to a message with an emoji.
+type Reaction struct {
+	TargetID  string
+	Author    string
+	Emoji     string
+	Retracted bool
+	Clock     uint64
+}
+
+// State is the state of a message after the edits, deletions and reactions applied to it.
+type State struct {
+	ID string `json:"id"`
+	// Content is the content of the latest edit, only set if the message is edited.
+	Content string `json:"content,omitempty"`
+	Edited  bool   `json:"edited"`
+	Deleted bool   `json:"deleted"`
+	// Reactions are the authors of the reactions to the message, by emoji.
+	Reactions map[string][]string `json:"reactions"`
+}
+
+// Store persists the authors of the messages and the changes applied to them.
+type Store interface {
+	// SaveMessageAuthor records the author of a message, keeping the first one recorded.
+	SaveMessageAuthor(id string, author string) error
+	// GetMessageAuthor returns the author of a message, or an empty string if it's unknown.
+	GetMessageAuthor(id string) (string, error)
+	// SaveMessageChange persists a change, replacing the previous one of its author.
+	SaveMessageChange(Change) error
+	// GetMessageChange returns the latest change of a message by an author, or nil.
+	GetMessageChange(targetID string, author string) (*Change, error)
+	// SaveMessageReaction persists a reaction, replacing the previous one of its author with the same emoji.
+	SaveMessageReaction(Reaction) error
+	// GetMessageReaction returns the latest reaction of an author to a message with an emoji, or nil.
+	GetMessageReaction(targetID string, author string, emoji string) (*Reaction, error)
+	// GetMessageReactions returns the latest reactions to a message, including the retracted ones.
+	GetMessageReactions(targetID string) ([]Reaction, error)
+}
+
+// StateHandler is notified every time the state of a message changes.
+type StateHandler func(State)
+
+// Manager applies the reactions, edits and deletions received to the messages they target.
+// Applying a message is idempotent, so that messages received again, for example from a
+// mailserver, are ignored.
+//
+// Edits and deletions are only applied if their author is the author of the message.
+// As they can be received before the message, they are persisted regardless and
+// checked when the state of the message is built. A deleted message can't be edited.
+type Manager struct {
+	store   Store
+	handler StateHandler
+
+	mu sync.Mutex
+}
+
+// NewManager returns a new Manager. The handler can be nil.
+func NewManager(store Store, handler StateHandler) *Manager {
+	return &Manager{store: store, handler: handler}
+}
+
+// Apply applies a message and returns whether it changed anything.
+func (m *Manager) Apply(msg Message) (bool, error) {
+	m.mu.Lock()
+	applied, err := m.apply(msg)
+	if err != nil || !applied || msg.Kind == KindRegular {
+		m.mu.Unlock()
+		return applied, err
+	}
+	state, notify, err := m.changedState(msg)
+	m.mu.Unlock()
+	if err != nil {
+		return true, err
+	}
+
+	if notify && m.handler != nil {
+		m.handler(state)
+	}
+	return true, nil
+}
+
+// State returns the state of a message.
+func (m *Manager) State(id string) (State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state(id)
+}
+
+// apply must be called with the lock held.
+func (m *Manager) apply(msg Message) (bool, error) {
+	if msg.Kind == KindRegular {
+		author, err := m.store.GetMessageAuthor(msg.ID)
+		if err != nil || author != "" {
+			return false, err
+		}
+		return true, m.store.SaveMessageAuthor(msg.ID, msg.Author)
+	}
+
+	if msg.TargetID == "" {
+		return false, ErrMissingTarget
+	}
+
+	switch msg.Kind {
+	case KindReaction:
+		if msg.Content == "" {
+			return false, ErrEmptyReaction
+		}
+		previous, err := m.store.GetMessageReaction(msg.TargetID, msg.Author, msg.Content)
+		if err != nil {
+			return false, err
+		}
+		if previous != nil && previous.Clock >= msg.Clock {
+			return false, nil
+		}
+		return true, m.store.SaveMessageReaction(Reaction{
+			TargetID:  msg.TargetID,
+			Author:    msg.Author,
+			Emoji:     msg.Content,
+			Retracted: msg.Retracted,
+			Clock:     msg.Clock,
+		})
+	case KindEdit, KindDeletion:
+		previous, err := m.store.GetMessageChange(msg.TargetID, msg.Author)
+		if err != nil {
+			return false, err
+		}
+		// Deletions win over edits, whatever their clock.
+		if previous != nil && (previous.Deleted || (msg.Kind == KindEdit && previous.Clock >= msg.Clock)) {
+			return false, nil
+		}
+		change := Change{TargetID: msg.TargetID, Author: msg.Author, Deleted: msg.Kind == KindDeletion, Clock: msg.Clock}
+		if msg.Kind == KindEdit {
+			change.Content = msg.Content
+		}
+		return true, m.store.SaveMessageChange(change)
+	default:
+		return false, ErrUnknownKind
+	}
+}
+
+// changedState returns the state of the target of an applied message, and whether it
+// changed: edits and deletions of other authors than the author of the message, or of
+// messages not received yet, don't change it. It must be called with the lock held.
+func (m *Manager) changedState(msg Message) (State, bool, error) {
+	if msg.Kind != KindReaction {
+		author, err := m.store.GetMessageAuthor(msg.TargetID)
+		if err != nil || author != msg.Author {
+			return State{}, false, err
+		}
+	}
+	state, err := m.state(msg.TargetID)
+	return state, err == nil, err
+}
+
+// state must be called with the lock held.
+func (m *Manager) state(id string) (State, error) {
+	state := State{ID: id, Reactions: make(map[string][]string)}
+
+	author, err := m.store.GetMessageAuthor(id)
+	if err != nil {
+		return State{}, err
+	}
+	if author != "" {
+		change, err := m.store.GetMessageChange(id, author)
+		if err != nil {
+			return State{}, err
+		}
+		if change != nil {
+			state.Deleted = change.Deleted
+			state.Edited = !change.Deleted
+			if state.Edited {
+				state.Content = change.Content
+			}
+		}
+	}
+
+	reactions, err := m.store.GetMessageReactions(id)
+	if err != nil {
+		return State{}, err
+	}
+	for _, r := range reactions {
+		if !r.Retracted {
+			state.Reactions[r.Emoji] = append(state.Reactions[r.Emoji], r.Author)
+		}
+	}
+	for _, authors := range state.Reactions {
+		sort.Strings(authors)
+	}
+	return state, nil
+}
@@ -0,0 +1,152 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	authors   map[string]string
+	changes   map[[2]string]Change
+	reactions []Reaction
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{authors: make(map[string]string), changes: make(map[[2]string]Change)}
+}
+
+func (s *memoryStore) SaveMessageAuthor(id string, author string) error {
+	if _, ok := s.authors[id]; !ok {
+		s.authors[id] = author
+	}
+	return nil
+}
+
+func (s *memoryStore) GetMessageAuthor(id string) (string, error) {
+	return s.authors[id], nil
+}
+
+func (s *memoryStore) SaveMessageChange(c Change) error {
+	s.changes[[2]string{c.TargetID, c.Author}] = c
+	return nil
+}
+
+func (s *memoryStore) GetMessageChange(targetID string, author string) (*Change, error) {
+	c, ok := s.changes[[2]string{targetID, author}]
+	if !ok {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+func (s *memoryStore) SaveMessageReaction(r Reaction) error {
+	for i, existing := range s.reactions {
+		if existing.TargetID == r.TargetID && existing.Author == r.Author && existing.Emoji == r.Emoji {
+			s.reactions[i] = r
+			return nil
+		}
+	}
+	s.reactions = append(s.reactions, r)
+	return nil
+}
+
+func (s *memoryStore) GetMessageReaction(targetID string, author string, emoji string) (*Reaction, error) {
+	for _, r := range s.reactions {
+		if r.TargetID == targetID && r.Author == author && r.Emoji == emoji {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) GetMessageReactions(targetID string) ([]Reaction, error) {
+	var result []Reaction
+	for _, r := range s.reactions {
+		if r.TargetID == targetID {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+func TestMessageID(t *testing.T) {
+	id := MessageID([]byte{1}, []byte("hello"))
+	require.Equal(t, id, MessageID([]byte{1}, []byte("hello")))
+	require.NotEqual(t, id, MessageID([]byte{2}, []byte("hello")))
+	require.NotEqual(t, id, MessageID([]byte{1}, []byte("hello!")))
+}
+
+func TestEdits(t *testing.T) {
+	var states []State
+	m := NewManager(newMemoryStore(), func(s State) { states = append(states, s) })
+
+	edit := Message{Author: "alice", Kind: KindEdit, TargetID: "1", Content: "edited", Clock: 2}
+	applied, err := m.Apply(edit)
+	require.NoError(t, err)
+	require.True(t, applied)
+	require.Empty(t, states, "The author of the message is not known yet")
+
+	applied, err = m.Apply(Message{ID: "1", Author: "alice", Content: "original", Clock: 1})
+	require.NoError(t, err)
+	require.True(t, applied)
+	state, err := m.State("1")
+	require.NoError(t, err)
+	require.Equal(t, State{ID: "1", Content: "edited", Edited: true, Reactions: map[string][]string{}}, state)
+
+	applied, err = m.Apply(edit)
+	require.NoError(t, err)
+	require.False(t, applied, "Replayed edits are ignored")
+	applied, err = m.Apply(Message{Author: "alice", Kind: KindEdit, TargetID: "1", Content: "older", Clock: 1})
+	require.NoError(t, err)
+	require.False(t, applied, "Older edits are ignored")
+
+	applied, err = m.Apply(Message{Author: "mallory", Kind: KindEdit, TargetID: "1", Content: "forged", Clock: 3})
+	require.NoError(t, err)
+	require.True(t, applied)
+	require.Empty(t, states, "Edits of other authors don't change the message")
+	state, err = m.State("1")
+	require.NoError(t, err)
+	require.Equal(t, "edited", state.Content)
+
+	applied, err = m.Apply(Message{Author: "alice", Kind: KindDeletion, TargetID: "1", Clock: 1})
+	require.NoError(t, err)
+	require.True(t, applied, "Deletions win over newer edits")
+	require.Len(t, states, 1)
+	require.Equal(t, State{ID: "1", Deleted: true, Reactions: map[string][]string{}}, states[0])
+
+	applied, err = m.Apply(Message{Author: "alice", Kind: KindEdit, TargetID: "1", Content: "undeleted", Clock: 4})
+	require.NoError(t, err)
+	require.False(t, applied, "Deleted messages can't be edited")
+	applied, err = m.Apply(Message{Author: "alice", Kind: KindDeletion, TargetID: "1", Clock: 1})
+	require.NoError(t, err)
+	require.False(t, applied, "Replayed deletions are ignored")
+}
+
+func TestReactions(t *testing.T) {
+	var states []State
+	m := NewManager(newMemoryStore(), func(s State) { states = append(states, s) })
+
+	for _, author := range []string{"bob", "alice"} {
+		applied, err := m.Apply(Message{Author: author, Kind: KindReaction, TargetID: "1", Content: "👍", Clock: 1})
+		require.NoError(t, err)
+		require.True(t, applied)
+	}
+	applied, err := m.Apply(Message{Author: "bob", Kind: KindReaction, TargetID: "1", Content: "👍", Clock: 1})
+	require.NoError(t, err)
+	require.False(t, applied, "Replayed reactions are ignored")
+	require.Len(t, states, 2)
+	require.Equal(t, map[string][]string{"👍": {"alice", "bob"}}, states[1].Reactions)
+
+	applied, err = m.Apply(Message{Author: "bob", Kind: KindReaction, TargetID: "1", Content: "👍", Retracted: true, Clock: 2})
+	require.NoError(t, err)
+	require.True(t, applied)
+	state, err := m.State("1")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{"👍": {"alice"}}, state.Reactions)
+
+	_, err = m.Apply(Message{Author: "bob", Kind: KindReaction, TargetID: "1", Clock: 3})
+	require.Equal(t, ErrEmptyReaction, err)
+	_, err = m.Apply(Message{Author: "bob", Kind: KindReaction, Content: "👍", Clock: 3})
+	require.Equal(t, ErrMissingTarget, err)
+}
@@ -0,0 +1,71 @@
+package decoy
+
+import (
+	"crypto/rand"
+
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// MaxTopics is the maximum number of decoy topics of an identity. Each topic sets 3 bits
+// of the bloom filter, so that more decoys would match most of the traffic.
+const MaxTopics = 32
+
+// Store persists the decoy topics of the identity.
+type Store interface {
+	// SaveDecoyTopics replaces the decoy topics of the identity.
+	SaveDecoyTopics(topics []whisper.TopicType) error
+	// GetDecoyTopics returns the decoy topics of the identity, in the order they were generated.
+	GetDecoyTopics() ([]whisper.TopicType, error)
+}
+
+// Load returns count decoy topics of the identity, at most MaxTopics. Topics are random,
+// like the topics of chats, and generated once: the decoys of an identity are persisted,
+// so that they are the same after a restart, as a decoy set changing with each session
+// would tell the real topics apart from the decoys. If more decoys are requested than
+// persisted, the missing ones are generated and persisted too.
+func Load(store Store, count int) ([]whisper.TopicType, error) {
+	if count <= 0 {
+		return nil, nil
+	}
+	if count > MaxTopics {
+		count = MaxTopics
+	}
+	topics, err := store.GetDecoyTopics()
+	if err != nil {
+		return nil, err
+	}
+	if len(topics) >= count {
+		return topics[:count], nil
+	}
+	for len(topics) < count {
+		var topic whisper.TopicType
+		if _, err := rand.Read(topic[:]); err != nil {
+			return nil, err
+		}
+		topics = append(topics, topic)
+	}
+	if err := store.SaveDecoyTopics(topics); err != nil {
+		return nil, err
+	}
+	return topics, nil
+}
+
+// Mix returns the topics followed by the decoys that are not among them.
+func Mix(topics, decoys []whisper.TopicType) []whisper.TopicType {
+	mixed := append([]whisper.TopicType{}, topics...)
+	for _, decoy := range decoys {
+		if !contains(topics, decoy) {
+			mixed = append(mixed, decoy)
+		}
+	}
+	return mixed
+}
+
+func contains(topics []whisper.TopicType, topic whisper.TopicType) bool {
+	for _, t := range topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
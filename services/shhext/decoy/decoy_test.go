@@ -0,0 +1,61 @@
+package decoy
+
+import (
+	"testing"
+
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	topics []whisper.TopicType
+	saves  int
+}
+
+func (s *memoryStore) SaveDecoyTopics(topics []whisper.TopicType) error {
+	s.topics = append([]whisper.TopicType(nil), topics...)
+	s.saves++
+	return nil
+}
+
+func (s *memoryStore) GetDecoyTopics() ([]whisper.TopicType, error) {
+	return append([]whisper.TopicType(nil), s.topics...), nil
+}
+
+func TestLoad(t *testing.T) {
+	store := &memoryStore{}
+	topics, err := Load(store, 0)
+	require.NoError(t, err)
+	require.Empty(t, topics)
+
+	topics, err = Load(store, 3)
+	require.NoError(t, err)
+	require.Len(t, topics, 3)
+	require.Equal(t, topics, store.topics)
+
+	again, err := Load(store, 3)
+	require.NoError(t, err)
+	require.Equal(t, topics, again, "Decoys are the same after a restart")
+	require.Equal(t, 1, store.saves)
+
+	fewer, err := Load(store, 2)
+	require.NoError(t, err)
+	require.Equal(t, topics[:2], fewer)
+
+	more, err := Load(store, 5)
+	require.NoError(t, err)
+	require.Len(t, more, 5)
+	require.Equal(t, topics, more[:3], "Decoys are added to the persisted ones")
+
+	capped, err := Load(store, 100)
+	require.NoError(t, err)
+	require.Len(t, capped, MaxTopics)
+}
+
+func TestMix(t *testing.T) {
+	a := whisper.TopicType{1}
+	b := whisper.TopicType{2}
+	c := whisper.TopicType{3}
+	require.Equal(t, []whisper.TopicType{a, b, c}, Mix([]whisper.TopicType{a, b}, []whisper.TopicType{b, c}))
+	require.Equal(t, []whisper.TopicType{a}, Mix([]whisper.TopicType{a}, nil))
+}
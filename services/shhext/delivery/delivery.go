@@ -0,0 +1,196 @@
+package delivery
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// State is the delivery state of an envelope sent by this node.
+// States only move forward: posted, then acknowledged by a mailserver,
+// then acknowledged by a regular peer, then archived by the mailserver
+// shared with the recipient.
+type State int
+
+const (
+	// Unknown is returned for envelopes that were never recorded.
+	Unknown State = iota
+	// Posted is set when the envelope was added to the local whisper queue.
+	Posted
+	// MailServerAcked is set when a mailserver acknowledged the envelope.
+	MailServerAcked
+	// PeerAcked is set when a regular peer acknowledged the envelope.
+	PeerAcked
+	// Archived is set when the mailserver shared with the recipient returned
+	// the envelope, so that the recipient can retrieve it even if offline.
+	// It is final.
+	Archived
+)
+
+var stateNames = map[State]string{
+	Unknown:         "unknown",
+	Posted:          "posted",
+	MailServerAcked: "mailserver-acked",
+	PeerAcked:       "peer-acked",
+	Archived:        "archived",
+}
+
+func (s State) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return stateNames[Unknown]
+}
+
+// MarshalJSON encodes the state as its name.
+func (s State) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// Status is the delivery state of an envelope with the time of its last change.
+type Status struct {
+	Hash      common.Hash `json:"hash"`
+	State     State       `json:"state"`
+	UpdatedAt int64       `json:"updatedAt"`
+}
+
+// Store persists delivery states. SaveEnvelopeState must never replace a state
+// with an earlier one.
+type Store interface {
+	SaveEnvelopeState(Status) error
+	GetEnvelopeStates(hashes []common.Hash) ([]Status, error)
+}
+
+// DefaultMaxStates is the default number of delivery states kept in memory.
+const DefaultMaxStates = 1000
+
+// Recorder records the delivery states of envelopes sent in this session and
+// persists them once a store is available, so that they survive restarts.
+// The most recent states are kept in memory, up to a maximum, except the final
+// ones once persisted. The others are read from the store.
+type Recorder struct {
+	mu        sync.Mutex
+	store     Store
+	maxStates int
+	states    map[common.Hash]*list.Element
+	order     *list.List
+}
+
+// NewRecorder returns a new Recorder without a store, keeping up to maxStates
+// states in memory.
+func NewRecorder(maxStates int) *Recorder {
+	return &Recorder{
+		maxStates: maxStates,
+		states:    make(map[common.Hash]*list.Element),
+		order:     list.New(),
+	}
+}
+
+// SetStore sets the store and persists the states recorded so far.
+func (r *Recorder) SetStore(store Store) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.store = store
+	if store == nil {
+		return nil
+	}
+	for hash, element := range r.states {
+		status := element.Value.(Status)
+		if err := store.SaveEnvelopeState(status); err != nil {
+			return err
+		}
+		if status.State == Archived {
+			r.forget(hash)
+		}
+	}
+	return nil
+}
+
+// Reset removes the store and forgets the states recorded so far, when the
+// account is logged out.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.store = nil
+	r.states = make(map[common.Hash]*list.Element)
+	r.order.Init()
+}
+
+// Update moves an envelope to a state. It is a no-op if the envelope
+// has already reached that state or a later one.
+func (r *Recorder) Update(hash common.Hash, state State) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if element, ok := r.states[hash]; ok {
+		if element.Value.(Status).State >= state {
+			return nil
+		}
+		r.forget(hash)
+	}
+	status := Status{Hash: hash, State: state, UpdatedAt: time.Now().Unix()}
+	if r.store != nil {
+		if err := r.store.SaveEnvelopeState(status); err != nil {
+			return err
+		}
+		// Archived is final, it is read from the store.
+		if state == Archived {
+			return nil
+		}
+	}
+	r.states[hash] = r.order.PushBack(status)
+	for r.order.Len() > r.maxStates {
+		r.forget(r.order.Front().Value.(Status).Hash)
+	}
+	return nil
+}
+
+// forget must be called with the lock held.
+func (r *Recorder) forget(hash common.Hash) {
+	if element, ok := r.states[hash]; ok {
+		r.order.Remove(element)
+		delete(r.states, hash)
+	}
+}
+
+// Statuses returns the delivery states of envelopes in the same order as hashes.
+// States not kept in memory are looked up in the store.
+func (r *Recorder) Statuses(hashes []common.Hash) ([]Status, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]Status, len(hashes))
+	var missing []common.Hash
+	for i, hash := range hashes {
+		element, ok := r.states[hash]
+		if !ok {
+			result[i] = Status{Hash: hash, State: Unknown}
+			missing = append(missing, hash)
+			continue
+		}
+		result[i] = element.Value.(Status)
+	}
+	if len(missing) == 0 || r.store == nil {
+		return result, nil
+	}
+
+	persisted, err := r.store.GetEnvelopeStates(missing)
+	if err != nil {
+		return nil, err
+	}
+	byHash := make(map[common.Hash]Status, len(persisted))
+	for _, status := range persisted {
+		byHash[status.Hash] = status
+	}
+	for i := range result {
+		if status, ok := byHash[result[i].Hash]; ok && result[i].State == Unknown {
+			result[i] = status
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,106 @@
+package delivery
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore map[common.Hash]Status
+
+func (s memoryStore) SaveEnvelopeState(status Status) error {
+	if current, ok := s[status.Hash]; ok && current.State >= status.State {
+		return nil
+	}
+	s[status.Hash] = status
+	return nil
+}
+
+func (s memoryStore) GetEnvelopeStates(hashes []common.Hash) ([]Status, error) {
+	var result []Status
+	for _, hash := range hashes {
+		if status, ok := s[hash]; ok {
+			result = append(result, status)
+		}
+	}
+	return result, nil
+}
+
+func TestRecorderStatesMoveForward(t *testing.T) {
+	r := NewRecorder(DefaultMaxStates)
+	hash := common.Hash{1}
+
+	require.NoError(t, r.Update(hash, Posted))
+	require.NoError(t, r.Update(hash, PeerAcked))
+	require.NoError(t, r.Update(hash, MailServerAcked))
+
+	statuses, err := r.Statuses([]common.Hash{hash, {2}})
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	require.Equal(t, PeerAcked, statuses[0].State)
+	require.Equal(t, Unknown, statuses[1].State)
+	require.Equal(t, common.Hash{2}, statuses[1].Hash)
+}
+
+func TestRecorderPersistsStates(t *testing.T) {
+	store := memoryStore{}
+	r := NewRecorder(DefaultMaxStates)
+	require.NoError(t, r.Update(common.Hash{1}, Posted))
+	require.NoError(t, r.SetStore(store))
+	require.Equal(t, Posted, store[common.Hash{1}].State, "It persists states recorded before the store was set")
+
+	require.NoError(t, r.Update(common.Hash{1}, MailServerAcked))
+	require.Equal(t, MailServerAcked, store[common.Hash{1}].State)
+
+	restarted := NewRecorder(DefaultMaxStates)
+	require.NoError(t, restarted.SetStore(store))
+	statuses, err := restarted.Statuses([]common.Hash{{1}})
+	require.NoError(t, err)
+	require.Equal(t, MailServerAcked, statuses[0].State, "It reads states from the store after a restart")
+}
+
+func TestRecorderEvictsStates(t *testing.T) {
+	store := memoryStore{}
+	r := NewRecorder(2)
+	require.NoError(t, r.SetStore(store))
+	require.NoError(t, r.Update(common.Hash{1}, Posted))
+	require.NoError(t, r.Update(common.Hash{1}, Archived))
+	require.Empty(t, r.states, "Final states are evicted once persisted")
+
+	for i := byte(2); i < 5; i++ {
+		require.NoError(t, r.Update(common.Hash{i}, MailServerAcked))
+	}
+	require.Len(t, r.states, 2, "The oldest states are evicted")
+
+	statuses, err := r.Statuses([]common.Hash{{1}, {2}, {4}})
+	require.NoError(t, err)
+	require.Equal(t, Archived, statuses[0].State, "Evicted states are read from the store")
+	require.Equal(t, MailServerAcked, statuses[1].State)
+	require.Equal(t, MailServerAcked, statuses[2].State)
+
+	require.NoError(t, r.Update(common.Hash{2}, Posted))
+	require.Equal(t, MailServerAcked, store[common.Hash{2}].State, "Evicted states still move forward only")
+}
+
+func TestRecorderReset(t *testing.T) {
+	store := memoryStore{}
+	r := NewRecorder(DefaultMaxStates)
+	require.NoError(t, r.SetStore(store))
+	require.NoError(t, r.Update(common.Hash{1}, Posted))
+	r.Reset()
+
+	other := memoryStore{}
+	require.NoError(t, r.SetStore(other))
+	require.Empty(t, other, "States recorded for the previous account are forgotten")
+	require.NoError(t, r.Update(common.Hash{2}, Posted))
+	require.Len(t, store, 1)
+	require.Len(t, other, 1)
+}
+
+func TestStateJSON(t *testing.T) {
+	data, err := json.Marshal(Status{State: MailServerAcked})
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"state":"mailserver-acked"`)
+}
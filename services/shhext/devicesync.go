@@ -0,0 +1,159 @@
+package shhext
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/services/shhext/contacts"
+	"github.com/status-im/status-go/services/shhext/devicesync"
+	"github.com/status-im/status-go/services/shhext/history"
+	"github.com/status-im/status-go/services/shhext/settings"
+)
+
+// syncEnabled returns true unless the user disabled the sync of the account with its other devices.
+func (s *Service) syncEnabled() bool {
+	if s.settings == nil {
+		return true
+	}
+	enabled, err := s.settings.Bool(settings.SyncEnabled, true)
+	if err != nil {
+		logger.Error("failed to read sync setting", "err", err)
+	}
+	return enabled
+}
+
+// syncChanged records a local change of a record synced with the other devices of the account.
+func (s *Service) syncChanged(kind string, id string) {
+	if s.devicesync == nil {
+		return
+	}
+	clock := uint64(s.w.GetCurrentTime().UnixNano() / int64(time.Millisecond))
+	if err := s.devicesync.Changed(kind, id, clock); err != nil {
+		logger.Error("failed to record change of synced record", "kind", kind, "id", id, "err", err)
+	}
+}
+
+// syncRecords returns the messages of the history, the contacts and the metadata of the
+// chats changed after since, to be synced with the other devices of the account.
+func (s *Service) syncRecords(since uint64) ([]devicesync.Record, error) {
+	chats, err := s.history.Chats()
+	if err != nil {
+		return nil, err
+	}
+	var records []devicesync.Record
+	for _, chatID := range chats {
+		messages, err := s.historyMessages(chatID)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range messages {
+			record, err := syncRecord(devicesync.KindMessage, m.ID, m.Clock, m)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, record)
+		}
+	}
+
+	contactList, err := s.contacts.Contacts()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range contactList {
+		record, err := syncRecord(devicesync.KindContact, c.PublicKey, 0, c)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	muted, err := s.blocking.MutedChats()
+	if err != nil {
+		return nil, err
+	}
+	mutedChats := make(map[string]bool, len(muted))
+	for _, chatID := range muted {
+		mutedChats[chatID] = true
+		if !containsString(chats, chatID) {
+			chats = append(chats, chatID)
+		}
+	}
+	for _, chatID := range chats {
+		record, err := syncRecord(devicesync.KindChat, chatID, 0, devicesync.Chat{ID: chatID, Muted: mutedChats[chatID]})
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return s.devicesync.Outgoing(records, since)
+}
+
+// applySyncedHistory applies the records synced by another device of the account.
+func (s *Service) applySyncedHistory(payload []byte) error {
+	if !s.syncEnabled() {
+		logger.Debug("ignoring synced history, sync is disabled")
+		return nil
+	}
+	records, err := devicesync.Decode(payload)
+	if err != nil {
+		return err
+	}
+	_, err = s.devicesync.Apply(records, s.applySyncRecord)
+	return err
+}
+
+func (s *Service) applySyncRecord(r devicesync.Record) error {
+	switch r.Kind {
+	case devicesync.KindMessage:
+		var m history.Message
+		if err := json.Unmarshal(r.Data, &m); err != nil {
+			return err
+		}
+		return s.history.Add(m)
+	case devicesync.KindContact:
+		var c contacts.Contact
+		if err := json.Unmarshal(r.Data, &c); err != nil {
+			return err
+		}
+		publicKey, err := hexutil.Decode(c.PublicKey)
+		if err != nil {
+			return err
+		}
+		if _, err := s.contacts.Add(c.PublicKey, c.Name); err != nil {
+			return err
+		}
+		s.acceptContact(publicKey)
+		return nil
+	case devicesync.KindChat:
+		var c devicesync.Chat
+		if err := json.Unmarshal(r.Data, &c); err != nil {
+			return err
+		}
+		if c.Muted {
+			_, err := s.blocking.Mute(c.ID)
+			return err
+		}
+		_, err := s.blocking.Unmute(c.ID)
+		return err
+	}
+	return devicesync.ErrInvalidRecord
+}
+
+func syncRecord(kind string, id string, clock uint64, data interface{}) (devicesync.Record, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return devicesync.Record{}, err
+	}
+	return devicesync.Record{Kind: kind, ID: id, Clock: clock, Data: encoded}, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
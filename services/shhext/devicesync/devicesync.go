@@ -0,0 +1,193 @@
+package devicesync
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+const (
+	// KindMessage is the kind of the records of messages of the history.
+	KindMessage = "message"
+	// KindContact is the kind of the records of contacts.
+	KindContact = "contact"
+	// KindChat is the kind of the records of the metadata of chats.
+	KindChat = "chat"
+
+	// DefaultBatchSize is the number of records sent in a single message.
+	DefaultBatchSize = 100
+)
+
+// ErrInvalidRecord is returned when a synced record has an unknown kind or no ID.
+var ErrInvalidRecord = errors.New("invalid sync record")
+
+// Record is a message, a contact or the metadata of a chat synced between the devices of the account.
+type Record struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+	// Clock orders the changes of the record made on different devices, the latest wins.
+	Clock uint64          `json:"clock"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Chat is the metadata of a chat synced between the devices of the account.
+type Chat struct {
+	ID    string `json:"id"`
+	Muted bool   `json:"muted"`
+}
+
+// Stats counts the records applied by kind.
+type Stats struct {
+	Messages int `json:"messages"`
+	Contacts int `json:"contacts"`
+	Chats    int `json:"chats"`
+}
+
+// Empty returns true if no record was applied.
+func (s Stats) Empty() bool {
+	return s.Messages == 0 && s.Contacts == 0 && s.Chats == 0
+}
+
+func (s *Stats) add(kind string) {
+	switch kind {
+	case KindMessage:
+		s.Messages++
+	case KindContact:
+		s.Contacts++
+	case KindChat:
+		s.Chats++
+	}
+}
+
+// Encode returns the payload used to sync records with the other devices.
+func Encode(records []Record) ([]byte, error) {
+	return json.Marshal(records)
+}
+
+// Decode returns the records synced by another device.
+func Decode(payload []byte) ([]Record, error) {
+	var records []Record
+	err := json.Unmarshal(payload, &records)
+	return records, err
+}
+
+// Split splits records into batches of at most size records.
+func Split(records []Record, size int) [][]Record {
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	var batches [][]Record
+	for len(records) > size {
+		batches = append(batches, records[:size])
+		records = records[size:]
+	}
+	if len(records) > 0 {
+		batches = append(batches, records)
+	}
+	return batches
+}
+
+// Store persists the sync clock of each record.
+type Store interface {
+	SaveSyncClock(kind string, id string, clock uint64) error
+	// GetSyncClock returns the sync clock of a record, or 0 if it was never changed or synced.
+	GetSyncClock(kind string, id string) (uint64, error)
+	// GetSyncClocks returns the sync clocks of the records of a kind, by ID.
+	GetSyncClocks(kind string) (map[string]uint64, error)
+}
+
+// Handler is notified of the records applied from each batch synced by another device.
+type Handler func(Stats)
+
+// Manager keeps the history, the contacts and the metadata of the chats in sync between
+// the devices of the account. Each record has a clock, and the changes synced by another
+// device are applied only if they are newer than the local ones.
+type Manager struct {
+	store   Store
+	handler Handler
+
+	mu sync.Mutex
+}
+
+// NewManager returns a new Manager.
+func NewManager(store Store, handler Handler) *Manager {
+	return &Manager{store: store, handler: handler}
+}
+
+// Changed records a local change of a record at clock.
+func (m *Manager) Changed(kind string, id string, clock uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, err := m.store.GetSyncClock(kind, id)
+	if err != nil || current >= clock {
+		return err
+	}
+	return m.store.SaveSyncClock(kind, id, clock)
+}
+
+// Outgoing returns the records changed after since, with their sync clocks.
+// Records carrying a clock keep it unless their sync clock is more recent.
+// All the records are returned if since is 0.
+func (m *Manager) Outgoing(records []Record, since uint64) ([]Record, error) {
+	clocks := make(map[string]map[string]uint64)
+	var outgoing []Record
+	for _, r := range records {
+		kindClocks, ok := clocks[r.Kind]
+		if !ok {
+			var err error
+			if kindClocks, err = m.store.GetSyncClocks(r.Kind); err != nil {
+				return nil, err
+			}
+			clocks[r.Kind] = kindClocks
+		}
+		if clock := kindClocks[r.ID]; clock > r.Clock {
+			r.Clock = clock
+		}
+		if since == 0 || r.Clock > since {
+			outgoing = append(outgoing, r)
+		}
+	}
+	return outgoing, nil
+}
+
+// Apply applies the records synced by another device with apply, unless the local record
+// changed at the same time or later. Records without a local change are always applied.
+func (m *Manager) Apply(records []Record, apply func(Record) error) (Stats, error) {
+	stats, err := m.apply(records, apply)
+	if !stats.Empty() && m.handler != nil {
+		m.handler(stats)
+	}
+	return stats, err
+}
+
+func (m *Manager) apply(records []Record, apply func(Record) error) (Stats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var stats Stats
+	for _, r := range records {
+		if !validKind(r.Kind) || r.ID == "" {
+			return stats, ErrInvalidRecord
+		}
+		current, err := m.store.GetSyncClock(r.Kind, r.ID)
+		if err != nil {
+			return stats, err
+		}
+		if current != 0 && r.Clock <= current {
+			continue
+		}
+		if err := apply(r); err != nil {
+			return stats, err
+		}
+		if r.Clock != 0 {
+			if err := m.store.SaveSyncClock(r.Kind, r.ID, r.Clock); err != nil {
+				return stats, err
+			}
+		}
+		stats.add(r.Kind)
+	}
+	return stats, nil
+}
+
+func validKind(kind string) bool {
+	return kind == KindMessage || kind == KindContact || kind == KindChat
+}
@@ -0,0 +1,112 @@
+package devicesync
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore map[string]map[string]uint64
+
+func (s memoryStore) SaveSyncClock(kind string, id string, clock uint64) error {
+	if s[kind] == nil {
+		s[kind] = make(map[string]uint64)
+	}
+	s[kind][id] = clock
+	return nil
+}
+
+func (s memoryStore) GetSyncClock(kind string, id string) (uint64, error) {
+	return s[kind][id], nil
+}
+
+func (s memoryStore) GetSyncClocks(kind string) (map[string]uint64, error) {
+	clocks := make(map[string]uint64)
+	for id, clock := range s[kind] {
+		clocks[id] = clock
+	}
+	return clocks, nil
+}
+
+func TestOutgoing(t *testing.T) {
+	m := NewManager(memoryStore{}, nil)
+	require.NoError(t, m.Changed(KindContact, "0x01", 20))
+	require.NoError(t, m.Changed(KindContact, "0x01", 10), "Older changes are ignored")
+
+	records := []Record{
+		{Kind: KindMessage, ID: "m1", Clock: 5},
+		{Kind: KindMessage, ID: "m2", Clock: 15},
+		{Kind: KindContact, ID: "0x01"},
+		{Kind: KindContact, ID: "0x02"},
+	}
+	outgoing, err := m.Outgoing(records, 0)
+	require.NoError(t, err)
+	require.Equal(t, []Record{
+		{Kind: KindMessage, ID: "m1", Clock: 5},
+		{Kind: KindMessage, ID: "m2", Clock: 15},
+		{Kind: KindContact, ID: "0x01", Clock: 20},
+		{Kind: KindContact, ID: "0x02"},
+	}, outgoing)
+
+	outgoing, err = m.Outgoing(records, 10)
+	require.NoError(t, err)
+	require.Equal(t, []Record{
+		{Kind: KindMessage, ID: "m2", Clock: 15},
+		{Kind: KindContact, ID: "0x01", Clock: 20},
+	}, outgoing)
+}
+
+func TestApply(t *testing.T) {
+	var notified []Stats
+	m := NewManager(memoryStore{}, func(s Stats) {
+		notified = append(notified, s)
+	})
+	require.NoError(t, m.Changed(KindContact, "0x01", 20))
+
+	var applied []string
+	apply := func(r Record) error {
+		applied = append(applied, r.ID)
+		return nil
+	}
+	stats, err := m.Apply([]Record{
+		{Kind: KindMessage, ID: "m1", Clock: 5},
+		{Kind: KindContact, ID: "0x01", Clock: 10},
+		{Kind: KindContact, ID: "0x02"},
+		{Kind: KindChat, ID: "status", Clock: 30},
+	}, apply)
+	require.NoError(t, err)
+	require.Equal(t, Stats{Messages: 1, Contacts: 1, Chats: 1}, stats)
+	require.Equal(t, []string{"m1", "0x02", "status"}, applied, "Records changed later locally are not applied")
+	require.Equal(t, []Stats{stats}, notified)
+
+	applied = nil
+	stats, err = m.Apply([]Record{
+		{Kind: KindChat, ID: "status", Clock: 30},
+		{Kind: KindContact, ID: "0x01", Clock: 25},
+	}, apply)
+	require.NoError(t, err)
+	require.Equal(t, Stats{Contacts: 1}, stats)
+	require.Equal(t, []string{"0x01"}, applied, "Records already synced are not applied again")
+
+	_, err = m.Apply([]Record{{Kind: "unknown", ID: "1"}}, apply)
+	require.Equal(t, ErrInvalidRecord, err)
+	require.Len(t, notified, 2, "Batches without applied records are not notified")
+}
+
+func TestEncodeAndSplit(t *testing.T) {
+	records := []Record{
+		{Kind: KindChat, ID: "a", Clock: 1, Data: json.RawMessage(`{"id":"a","muted":true}`)},
+		{Kind: KindChat, ID: "b", Clock: 2, Data: json.RawMessage(`{"id":"b","muted":false}`)},
+		{Kind: KindChat, ID: "c", Clock: 3, Data: json.RawMessage(`{"id":"c","muted":false}`)},
+	}
+	payload, err := Encode(records)
+	require.NoError(t, err)
+	decoded, err := Decode(payload)
+	require.NoError(t, err)
+	require.Equal(t, records, decoded)
+
+	require.Equal(t, [][]Record{records[:2], records[2:]}, Split(records, 2))
+	require.Equal(t, [][]Record{records}, Split(records, 0))
+	require.Nil(t, Split(nil, 2))
+}
@@ -0,0 +1,35 @@
+package shhext
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// checkDowngrade records whether a direct message of a contact was encrypted with PFS,
+// and sends a signal if the contact stopped using PFS. Our own messages are ignored.
+func (s *Service) checkDowngrade(myIdentityKey *ecdsa.PrivateKey, theirPublicKey *ecdsa.PublicKey, msg *whisper.Message, encrypted bool) {
+	if s.downgrades == nil || myIdentityKey == nil || theirPublicKey == nil {
+		return
+	}
+	contact := crypto.FromECDSAPub(theirPublicKey)
+	if bytes.Equal(contact, crypto.FromECDSAPub(&myIdentityKey.PublicKey)) {
+		return
+	}
+	key := hexutil.Encode(contact)
+	if encrypted {
+		if err := s.downgrades.Encrypted(key); err != nil {
+			logger.Error("failed to record encryption of contact", "err", err)
+		}
+		return
+	}
+	event, err := s.downgrades.Unencrypted(key, hexutil.Encode(msg.Hash), s.w.GetCurrentTime())
+	if err != nil {
+		logger.Error("failed to record downgrade of contact", "hash", hexutil.Encode(msg.Hash), "err", err)
+	} else if event != nil {
+		logger.Warn("contact stopped encrypting messages with PFS", "contact", key, "hash", event.Hash)
+	}
+}
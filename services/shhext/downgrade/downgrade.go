@@ -0,0 +1,114 @@
+package downgrade
+
+import (
+	"sync"
+	"time"
+)
+
+// Event records that a contact who sent messages encrypted with PFS sent a message
+// that is not, which happens if it reinstalled the application or if an attacker
+// tries to make us downgrade the encryption of the chat.
+type Event struct {
+	// PublicKey is the hex-encoded public key of the contact.
+	PublicKey string `json:"publicKey"`
+	// Hash is the hash of the envelope of the message that is not encrypted with PFS.
+	Hash string `json:"hash"`
+	// DetectedAt is the time the message was received, in milliseconds.
+	DetectedAt int64 `json:"detectedAt"`
+}
+
+// Store persists whether the last direct message of each contact was encrypted with PFS,
+// and the downgrades detected.
+type Store interface {
+	// SaveContactEncryption saves whether the last direct message of a contact was encrypted with PFS.
+	SaveContactEncryption(publicKey string, pfs bool) error
+	// GetContactEncryption returns whether the last direct message of a contact was encrypted
+	// with PFS, or false if no direct message of the contact was recorded.
+	GetContactEncryption(publicKey string) (bool, error)
+	SaveDowngrade(Event) error
+	// GetDowngrades returns the downgrades of a contact, from the oldest.
+	GetDowngrades(publicKey string) ([]Event, error)
+}
+
+// Handler is notified of the downgrades detected.
+type Handler func(Event)
+
+// Detector detects the contacts that stop encrypting their direct messages with PFS.
+// A single downgrade is reported until the contact sends a message encrypted with PFS again.
+type Detector struct {
+	store   Store
+	handler Handler
+
+	mu  sync.Mutex
+	pfs map[string]bool
+}
+
+// NewDetector returns a new Detector.
+func NewDetector(store Store, handler Handler) *Detector {
+	return &Detector{store: store, handler: handler, pfs: make(map[string]bool)}
+}
+
+// Encrypted records that a contact sent a direct message encrypted with PFS.
+func (d *Detector) Encrypted(publicKey string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pfs, err := d.encryption(publicKey)
+	if err != nil || pfs {
+		return err
+	}
+	return d.save(publicKey, true)
+}
+
+// Unencrypted records that a contact sent a direct message that is not encrypted with PFS,
+// and returns the downgrade detected if the previous message of the contact was.
+func (d *Detector) Unencrypted(publicKey string, hash string, now time.Time) (*Event, error) {
+	event, err := d.unencrypted(publicKey, hash, now)
+	if err != nil || event == nil {
+		return nil, err
+	}
+	if d.handler != nil {
+		d.handler(*event)
+	}
+	return event, nil
+}
+
+func (d *Detector) unencrypted(publicKey string, hash string, now time.Time) (*Event, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pfs, err := d.encryption(publicKey)
+	if err != nil || !pfs {
+		return nil, err
+	}
+	event := &Event{PublicKey: publicKey, Hash: hash, DetectedAt: now.UnixNano() / int64(time.Millisecond)}
+	if err := d.store.SaveDowngrade(*event); err != nil {
+		return nil, err
+	}
+	return event, d.save(publicKey, false)
+}
+
+// Downgrades returns the downgrades of a contact, from the oldest.
+func (d *Detector) Downgrades(publicKey string) ([]Event, error) {
+	return d.store.GetDowngrades(publicKey)
+}
+
+// encryption must be called with the lock held.
+func (d *Detector) encryption(publicKey string) (bool, error) {
+	if pfs, ok := d.pfs[publicKey]; ok {
+		return pfs, nil
+	}
+	pfs, err := d.store.GetContactEncryption(publicKey)
+	if err != nil {
+		return false, err
+	}
+	d.pfs[publicKey] = pfs
+	return pfs, nil
+}
+
+// save must be called with the lock held.
+func (d *Detector) save(publicKey string, pfs bool) error {
+	if err := d.store.SaveContactEncryption(publicKey, pfs); err != nil {
+		return err
+	}
+	d.pfs[publicKey] = pfs
+	return nil
+}
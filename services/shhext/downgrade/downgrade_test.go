@@ -0,0 +1,81 @@
+package downgrade
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	pfs    map[string]bool
+	events []Event
+	saves  int
+}
+
+func (s *memoryStore) SaveContactEncryption(publicKey string, pfs bool) error {
+	s.pfs[publicKey] = pfs
+	s.saves++
+	return nil
+}
+
+func (s *memoryStore) GetContactEncryption(publicKey string) (bool, error) {
+	return s.pfs[publicKey], nil
+}
+
+func (s *memoryStore) SaveDowngrade(e Event) error {
+	s.events = append(s.events, e)
+	return nil
+}
+
+func (s *memoryStore) GetDowngrades(publicKey string) ([]Event, error) {
+	var result []Event
+	for _, e := range s.events {
+		if e.PublicKey == publicKey {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+func TestDowngrade(t *testing.T) {
+	store := &memoryStore{pfs: map[string]bool{}}
+	var notified []Event
+	d := NewDetector(store, func(e Event) {
+		notified = append(notified, e)
+	})
+	now := time.Unix(1, 0)
+
+	event, err := d.Unencrypted("0x01", "0xaa", now)
+	require.NoError(t, err)
+	require.Nil(t, event, "Contacts that never used PFS are not downgraded")
+
+	require.NoError(t, d.Encrypted("0x01"))
+	require.NoError(t, d.Encrypted("0x01"))
+	require.Equal(t, 1, store.saves, "The encryption is only saved when it changes")
+
+	event, err = d.Unencrypted("0x01", "0xbb", now)
+	require.NoError(t, err)
+	require.Equal(t, &Event{PublicKey: "0x01", Hash: "0xbb", DetectedAt: 1000}, event)
+	event, err = d.Unencrypted("0x01", "0xcc", now)
+	require.NoError(t, err)
+	require.Nil(t, event, "A downgrade is reported once")
+	require.Equal(t, []Event{{PublicKey: "0x01", Hash: "0xbb", DetectedAt: 1000}}, notified)
+
+	require.NoError(t, d.Encrypted("0x01"))
+	event, err = d.Unencrypted("0x01", "0xdd", now.Add(time.Second))
+	require.NoError(t, err)
+	require.NotNil(t, event, "Downgrades are reported again once PFS is used again")
+	events, err := d.Downgrades("0x01")
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+}
+
+func TestDowngradeLoadsStore(t *testing.T) {
+	store := &memoryStore{pfs: map[string]bool{"0x01": true}}
+	d := NewDetector(store, nil)
+	event, err := d.Unencrypted("0x01", "0xaa", time.Unix(1, 0))
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	require.False(t, store.pfs["0x01"])
+}
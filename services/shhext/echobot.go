@@ -0,0 +1,171 @@
+package shhext
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/services/shhext/chat"
+	"github.com/status-im/status-go/services/shhext/echobot"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+const (
+	echoBotInterval = 200 * time.Millisecond
+	echoBotName     = "echobot"
+)
+
+// directTransport posts the direct messages of a built-in identity to whisper.
+type directTransport struct {
+	api      *whisper.PublicWhisperAPI
+	keyID    string
+	protocol *chat.ProtocolService
+}
+
+func (t directTransport) Send(recipient *ecdsa.PublicKey, message []byte) error {
+	msg := chat.DirectMessageToWhisper(chat.SendDirectMessageRPC{
+		Sig:    t.keyID,
+		PubKey: crypto.FromECDSAPub(recipient),
+	}, message)
+	msg.Topic = t.protocol.DirectMessageTopic(recipient)
+	_, err := t.api.Post(context.Background(), msg)
+	return err
+}
+
+// builtinIdentity is an identity run by the node itself, like the echo bot, with its own
+// protocol state.
+type builtinIdentity struct {
+	key         *ecdsa.PrivateKey
+	persistence *chat.SQLLitePersistence
+	protocol    *chat.ProtocolService
+	transport   directTransport
+	topics      []whisper.TopicType
+	filter      string
+}
+
+// newBuiltinIdentity adds the key of a built-in identity, or a new key if it's nil, to
+// whisper. Its protocol state is kept in the data directory, in a database named after
+// the identity and encrypted with its key, and the filter of the messages sent to it is
+// installed.
+func (s *Service) newBuiltinIdentity(name string, key *ecdsa.PrivateKey) (*builtinIdentity, error) {
+	if key == nil {
+		var err error
+		if key, err = crypto.GenerateKey(); err != nil {
+			return nil, err
+		}
+	}
+	keyID, err := s.w.AddKeyPair(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Clean(s.dataDir), os.ModePerm); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(s.dataDir, fmt.Sprintf("%s-%x.db", name, crypto.PubkeyToAddress(key.PublicKey)))
+	persistence, err := chat.NewSQLLitePersistence(path, fmt.Sprintf("%x", crypto.FromECDSA(key)), chat.DefaultPersistenceConfig())
+	if err != nil {
+		return nil, err
+	}
+	protocol := chat.NewProtocolService(chat.NewEncryptionService(persistence, chat.DefaultEncryptionServiceConfig(name)), func([]chat.IdentityAndIDPair) {})
+	if s.config.CompressionEnabled {
+		protocol.EnableCompression()
+	}
+	topics := []whisper.TopicType{chat.DiscoveryTopic()}
+	if s.config.PartitionedTopic {
+		protocol.EnablePartitionedTopic()
+		topics = append(topics, chat.PartitionedTopic(&key.PublicKey))
+	}
+	filterTopics := make([][]byte, len(topics))
+	for i := range topics {
+		filterTopics[i] = topics[i][:]
+	}
+
+	filter, err := s.w.Subscribe(&whisper.Filter{
+		KeyAsym:  key,
+		Topics:   filterTopics,
+		AllowP2P: true,
+		Messages: make(map[common.Hash]*whisper.ReceivedMessage),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &builtinIdentity{
+		key:         key,
+		persistence: persistence,
+		protocol:    protocol,
+		transport:   directTransport{api: whisper.NewPublicWhisperAPI(s.w), keyID: keyID, protocol: protocol},
+		topics:      topics,
+		filter:      filter,
+	}, nil
+}
+
+// echoBot delivers the direct messages sent to the echo bot.
+type echoBot struct {
+	w        *whisper.Whisper
+	bot      *echobot.Bot
+	identity *builtinIdentity
+	wg       sync.WaitGroup
+	quit     chan struct{}
+}
+
+// startEchoBot starts the echo bot with its own identity.
+func (s *Service) startEchoBot() error {
+	identity, err := s.newBuiltinIdentity(echoBotName, s.config.EchoBotKey)
+	if err != nil {
+		return err
+	}
+	e := &echoBot{
+		w:        s.w,
+		bot:      echobot.New(identity.key, identity.protocol, identity.transport, *s.config.EchoBot),
+		identity: identity,
+		quit:     make(chan struct{}),
+	}
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		ticker := time.NewTicker(echoBotInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-e.quit:
+				return
+			case <-ticker.C:
+				e.poll()
+			}
+		}
+	}()
+	s.echoBot = e
+	logger.Info("started echo bot", "publicKey", fmt.Sprintf("%#x", crypto.FromECDSAPub(&identity.key.PublicKey)))
+	return nil
+}
+
+func (e *echoBot) poll() {
+	f := e.w.GetFilter(e.identity.filter)
+	if f == nil {
+		return
+	}
+	for _, msg := range f.Retrieve() {
+		if msg.Src == nil {
+			continue
+		}
+		if err := e.bot.Handle(msg.Src, msg.EnvelopeHash.Bytes(), msg.Payload); err != nil {
+			logger.Debug("echo bot failed to handle message", "hash", msg.EnvelopeHash, "err", err)
+		}
+	}
+}
+
+// Stop uninstalls the filter and cancels the answers that were not sent yet.
+func (e *echoBot) Stop() {
+	close(e.quit)
+	e.wg.Wait()
+	e.bot.Stop()
+	e.w.Unsubscribe(e.identity.filter) // nolint: errcheck
+}
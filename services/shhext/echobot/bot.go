@@ -0,0 +1,173 @@
+package echobot
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/golang/protobuf/proto"
+	"github.com/status-im/status-go/services/shhext/chat"
+	"github.com/status-im/status-go/services/shhext/chat/capabilities"
+)
+
+const (
+	// MessageTypeReceipt is the message type of the receipts sent by the bot.
+	// Their content is the hex-encoded hash of the received message.
+	MessageTypeReceipt = "~:receipt"
+	// MessageTypeReaction is the message type of the reactions sent by the bot.
+	// Their content is Reaction, a colon and the hex-encoded hash of the received message.
+	MessageTypeReaction = "~:reaction"
+	// Reaction is the reaction of the bot to the messages it echoes.
+	Reaction = "+1"
+)
+
+// Protocol encrypts and decrypts the messages of the bot.
+type Protocol interface {
+	HandleMessage(myIdentityKey *ecdsa.PrivateKey, theirPublicKey *ecdsa.PublicKey, payload []byte) ([]byte, error)
+	BuildDirectMessage(myIdentityKey *ecdsa.PrivateKey, payload []byte, theirPublicKeys ...*ecdsa.PublicKey) (map[*ecdsa.PublicKey][]byte, error)
+	ContactCapabilities(theirPublicKey *ecdsa.PublicKey) (capabilities.Set, error)
+}
+
+// Transport sends the messages built by the protocol.
+type Transport interface {
+	Send(recipient *ecdsa.PublicKey, message []byte) error
+}
+
+// Config of the bot.
+type Config struct {
+	// Delay is how long the bot waits before answering a message.
+	Delay time.Duration
+	// Loss is the probability, between 0 and 1, that a message is ignored.
+	Loss float64
+	// Receipts sends a receipt for each message before echoing it.
+	Receipts bool
+	// Reactions reacts to each echoed message, if the contact supports reactions.
+	Reactions bool
+}
+
+// Bot is a contact that echoes the direct messages it receives, so that clients
+// can test the whole flow of direct messages without a second device.
+type Bot struct {
+	key       *ecdsa.PrivateKey
+	protocol  Protocol
+	transport Transport
+	config    Config
+	random    func() float64
+
+	mu      sync.Mutex
+	timers  map[*time.Timer]struct{}
+	stopped bool
+}
+
+// New returns a new Bot with the given identity.
+func New(key *ecdsa.PrivateKey, protocol Protocol, transport Transport, config Config) *Bot {
+	return &Bot{
+		key:       key,
+		protocol:  protocol,
+		transport: transport,
+		config:    config,
+		random:    rand.Float64,
+		timers:    make(map[*time.Timer]struct{}),
+	}
+}
+
+// PublicKey returns the public key clients add as a contact.
+func (b *Bot) PublicKey() *ecdsa.PublicKey {
+	return &b.key.PublicKey
+}
+
+// Handle decrypts a message sent to the bot and schedules the answer.
+func (b *Bot) Handle(sender *ecdsa.PublicKey, hash []byte, payload []byte) error {
+	message, err := b.protocol.HandleMessage(b.key, sender, payload)
+	if err != nil {
+		return err
+	}
+	if b.random() < b.config.Loss {
+		log.Debug("echo bot dropped message", "hash", hex.EncodeToString(hash))
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopped {
+		return nil
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(b.config.Delay, func() {
+		b.mu.Lock()
+		delete(b.timers, timer)
+		b.mu.Unlock()
+		b.answer(sender, hash, message)
+	})
+	b.timers[timer] = struct{}{}
+	return nil
+}
+
+// Stop cancels the answers that were not sent yet.
+func (b *Bot) Stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stopped = true
+	for timer := range b.timers {
+		timer.Stop()
+	}
+	b.timers = make(map[*time.Timer]struct{})
+}
+
+func (b *Bot) answer(sender *ecdsa.PublicKey, hash []byte, message []byte) {
+	encodedHash := hex.EncodeToString(hash)
+	if b.config.Receipts {
+		if err := b.sendPayload(sender, MessageTypeReceipt, encodedHash); err != nil {
+			log.Error("echo bot failed to send receipt", "err", err)
+		}
+	}
+
+	if err := b.send(sender, message); err != nil {
+		log.Error("echo bot failed to echo message", "err", err)
+		return
+	}
+
+	if b.config.Reactions {
+		set, err := b.protocol.ContactCapabilities(sender)
+		if err != nil {
+			log.Error("echo bot failed to get contact capabilities", "err", err)
+			return
+		}
+		if !set.Has(capabilities.FeatureReactions) {
+			return
+		}
+		if err := b.sendPayload(sender, MessageTypeReaction, fmt.Sprintf("%s:%s", Reaction, encodedHash)); err != nil {
+			log.Error("echo bot failed to send reaction", "err", err)
+		}
+	}
+}
+
+func (b *Bot) sendPayload(recipient *ecdsa.PublicKey, messageType string, content string) error {
+	payload, err := proto.Marshal(&chat.ChatMessagePayload{
+		Content:     content,
+		ContentType: "text/plain",
+		MessageType: messageType,
+		ClockValue:  float64(time.Now().UnixNano() / int64(time.Millisecond)),
+	})
+	if err != nil {
+		return err
+	}
+	return b.send(recipient, payload)
+}
+
+func (b *Bot) send(recipient *ecdsa.PublicKey, payload []byte) error {
+	messages, err := b.protocol.BuildDirectMessage(b.key, payload, recipient)
+	if err != nil {
+		return err
+	}
+	for key, message := range messages {
+		if err := b.transport.Send(key, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
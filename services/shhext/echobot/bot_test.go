@@ -0,0 +1,108 @@
+package echobot
+
+import (
+	"crypto/ecdsa"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/golang/protobuf/proto"
+	"github.com/status-im/status-go/services/shhext/chat"
+	"github.com/stretchr/testify/require"
+)
+
+type sentMessage struct {
+	recipient *ecdsa.PublicKey
+	message   []byte
+}
+
+type chanTransport chan sentMessage
+
+func (t chanTransport) Send(recipient *ecdsa.PublicKey, message []byte) error {
+	t <- sentMessage{recipient, message}
+	return nil
+}
+
+func newProtocol(t *testing.T, dir string, installationID string) *chat.ProtocolService {
+	persistence, err := chat.NewSQLLitePersistence(filepath.Join(dir, installationID+".db"), installationID, chat.DefaultPersistenceConfig())
+	require.NoError(t, err)
+	return chat.NewProtocolService(chat.NewEncryptionService(persistence, chat.DefaultEncryptionServiceConfig(installationID)), func([]chat.IdentityAndIDPair) {})
+}
+
+func receive(t *testing.T, transport chanTransport) sentMessage {
+	select {
+	case sent := <-transport:
+		return sent
+	case <-time.After(time.Second):
+		require.FailNow(t, "timed out waiting for a message")
+	}
+	return sentMessage{}
+}
+
+func TestBotEcho(t *testing.T) {
+	dir, err := ioutil.TempDir("", "echobot")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	aliceKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	botKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	alice := newProtocol(t, dir, "alice")
+	transport := make(chanTransport, 10)
+	bot := New(botKey, newProtocol(t, dir, "bot"), transport, Config{Delay: time.Millisecond, Receipts: true, Reactions: true})
+	defer bot.Stop()
+
+	messages, err := alice.BuildDirectMessage(aliceKey, []byte("hello"), bot.PublicKey())
+	require.NoError(t, err)
+	require.NoError(t, bot.Handle(&aliceKey.PublicKey, []byte{1}, messages[bot.PublicKey()]))
+
+	var payloads [][]byte
+	for i := 0; i < 3; i++ {
+		sent := receive(t, transport)
+		require.Equal(t, crypto.PubkeyToAddress(aliceKey.PublicKey), crypto.PubkeyToAddress(*sent.recipient))
+		payload, err := alice.HandleMessage(aliceKey, bot.PublicKey(), sent.message)
+		require.NoError(t, err)
+		payloads = append(payloads, payload)
+	}
+
+	receipt := &chat.ChatMessagePayload{}
+	require.NoError(t, proto.Unmarshal(payloads[0], receipt))
+	require.Equal(t, MessageTypeReceipt, receipt.MessageType)
+	require.Equal(t, "01", receipt.Content)
+
+	require.Equal(t, []byte("hello"), payloads[1])
+
+	reaction := &chat.ChatMessagePayload{}
+	require.NoError(t, proto.Unmarshal(payloads[2], reaction))
+	require.Equal(t, MessageTypeReaction, reaction.MessageType)
+	require.Equal(t, Reaction+":01", reaction.Content)
+}
+
+func TestBotLoss(t *testing.T) {
+	dir, err := ioutil.TempDir("", "echobot")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	aliceKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	botKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	alice := newProtocol(t, dir, "alice")
+	transport := make(chanTransport, 10)
+	bot := New(botKey, newProtocol(t, dir, "bot"), transport, Config{Loss: 1})
+	defer bot.Stop()
+
+	messages, err := alice.BuildDirectMessage(aliceKey, []byte("hello"), bot.PublicKey())
+	require.NoError(t, err)
+	require.NoError(t, bot.Handle(&aliceKey.PublicKey, []byte{1}, messages[bot.PublicKey()]))
+
+	select {
+	case <-transport:
+		require.FailNow(t, "lost messages are not echoed")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
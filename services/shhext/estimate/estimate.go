@@ -0,0 +1,144 @@
+package estimate
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/services/shhext/segmentation"
+)
+
+const (
+	// envelopeHeaderSize is the size of the fields of an envelope other than its data.
+	envelopeHeaderSize = 20
+	// flagsSize is the size of the flags prepended to the payload of whisper messages.
+	flagsSize = 1
+	// signatureSize is the size of the signature appended to signed whisper messages.
+	signatureSize = 65
+	// padSize is the multiple whisper messages are padded to.
+	padSize = 256
+	// asymmetricOverhead is the size added by ECIES: the ephemeral public key, the IV and the MAC.
+	asymmetricOverhead = 65 + 16 + 32
+	// symmetricOverhead is the size added by AES-GCM: the tag and the nonce.
+	symmetricOverhead = 16 + 12
+)
+
+// Config is the configuration of the envelopes posted for a message.
+type Config struct {
+	// SegmentSize is the maximum size of the payload of an envelope.
+	SegmentSize int
+	// TTL is the time-to-live of the envelopes, in seconds.
+	TTL uint32
+	// PoWTarget is the proof of work target of the envelopes.
+	PoWTarget float64
+	// PoWTime is the maximum time spent computing the proof of work of an envelope, in seconds.
+	PoWTime uint32
+	// HashRate is the number of hashes computed per second, see MeasureHashRate.
+	HashRate float64
+}
+
+// Recipient is the fan-out of a message to a recipient.
+type Recipient struct {
+	PublicKey string `json:"publicKey"`
+	// Installations is the number of installations of the recipient the payload is encrypted for.
+	Installations int `json:"installations"`
+	Envelopes     int `json:"envelopes"`
+	Bytes         int `json:"bytes"`
+}
+
+// Estimate is the expected cost of sending a message.
+type Estimate struct {
+	Envelopes int `json:"envelopes"`
+	// Bytes is the total size of the envelopes.
+	Bytes int `json:"bytes"`
+	// PoWTime is the expected time spent computing the proof of work of the envelopes, in milliseconds.
+	PoWTime int64 `json:"powTime"`
+	// Recipients is the fan-out of a direct or group message, empty for public messages.
+	Recipients []Recipient `json:"recipients,omitempty"`
+}
+
+// AddPublic adds the envelopes of a public message of size bytes to the estimate.
+func (c Config) AddPublic(e *Estimate, size int) error {
+	return c.add(e, size, symmetricOverhead)
+}
+
+// AddRecipient adds the envelopes of the message of size bytes sent to a recipient to the estimate.
+func (c Config) AddRecipient(e *Estimate, publicKey string, installations int, size int) error {
+	envelopes, bytes := e.Envelopes, e.Bytes
+	if err := c.add(e, size, asymmetricOverhead); err != nil {
+		return err
+	}
+	e.Recipients = append(e.Recipients, Recipient{
+		PublicKey:     publicKey,
+		Installations: installations,
+		Envelopes:     e.Envelopes - envelopes,
+		Bytes:         e.Bytes - bytes,
+	})
+	return nil
+}
+
+func (c Config) add(e *Estimate, size int, overhead int) error {
+	sizes, err := segmentation.Sizes(size, c.SegmentSize)
+	if err != nil {
+		return err
+	}
+	for _, segmentSize := range sizes {
+		envelope := envelopeSize(segmentSize, overhead)
+		e.Envelopes++
+		e.Bytes += envelope
+		e.PoWTime += int64(c.powTime(envelope) / time.Millisecond)
+	}
+	return nil
+}
+
+// powTime returns the expected time to compute the proof of work of an envelope,
+// which is capped by PoWTime as whisper gives up after it.
+func (c Config) powTime(envelope int) time.Duration {
+	if c.PoWTarget <= 0 || c.HashRate <= 0 {
+		return 0
+	}
+	// Whisper looks for a hash with as many trailing zero bits as required by the target
+	bits := math.Ceil(math.Log2(c.PoWTarget * float64(envelope) * float64(c.TTL)))
+	if bits < 1 {
+		bits = 1
+	}
+	seconds := math.Pow(2, bits) / c.HashRate
+	if max := float64(c.PoWTime); seconds > max {
+		seconds = max
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// envelopeSize returns the size of the envelope of a signed whisper message with
+// a payload of size bytes, encrypted with a scheme adding overhead bytes.
+func envelopeSize(size int, overhead int) int {
+	raw := flagsSize + sizeFieldSize(size) + size + signatureSize
+	raw += padSize - raw%padSize
+	return envelopeHeaderSize + raw + overhead
+}
+
+// sizeFieldSize returns the number of bytes whisper uses to encode the size of a payload.
+func sizeFieldSize(size int) int {
+	s := 1
+	for i := size; i >= 256; i /= 256 {
+		s++
+	}
+	return s
+}
+
+// MeasureHashRate returns the number of hashes computed per second for the proof of work,
+// measured for d.
+func MeasureHashRate(d time.Duration) float64 {
+	buf := make([]byte, 64)
+	start := time.Now()
+	hashes := 0
+	for time.Since(start) < d {
+		for i := 0; i < 1024; i++ {
+			binary.BigEndian.PutUint64(buf[56:], uint64(hashes))
+			crypto.Keccak256(buf)
+			hashes++
+		}
+	}
+	return float64(hashes) / time.Since(start).Seconds()
+}
@@ -0,0 +1,65 @@
+package estimate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvelopeSize(t *testing.T) {
+	src, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	dst, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	symKey := crypto.Keccak256([]byte("key"))
+
+	for _, size := range []int{0, 100, 180, 1000, 70000} {
+		params := &whisper.MessageParams{Src: src, Dst: &dst.PublicKey, TTL: 10, Payload: make([]byte, size)}
+		msg, err := whisper.NewSentMessage(params)
+		require.NoError(t, err)
+		envelope, err := msg.Wrap(params, time.Now())
+		require.NoError(t, err)
+		require.Equal(t, whisper.EnvelopeHeaderLength+len(envelope.Data), envelopeSize(size, asymmetricOverhead), "Asymmetric envelope of %d bytes", size)
+
+		params = &whisper.MessageParams{Src: src, KeySym: symKey, TTL: 10, Payload: make([]byte, size)}
+		msg, err = whisper.NewSentMessage(params)
+		require.NoError(t, err)
+		envelope, err = msg.Wrap(params, time.Now())
+		require.NoError(t, err)
+		require.Equal(t, whisper.EnvelopeHeaderLength+len(envelope.Data), envelopeSize(size, symmetricOverhead), "Symmetric envelope of %d bytes", size)
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	c := Config{SegmentSize: 1000, TTL: 10, PoWTarget: 0.002, PoWTime: 1, HashRate: 1000}
+
+	var e Estimate
+	require.NoError(t, c.AddPublic(&e, 500))
+	require.Equal(t, 1, e.Envelopes)
+	require.Equal(t, envelopeSize(500, symmetricOverhead), e.Bytes)
+	require.Empty(t, e.Recipients)
+
+	e = Estimate{}
+	require.NoError(t, c.AddRecipient(&e, "0x01", 1, 500))
+	require.NoError(t, c.AddRecipient(&e, "0x02", 3, 2500))
+	require.Equal(t, 4, e.Envelopes, "Payloads larger than a segment are split")
+	require.Equal(t, []Recipient{
+		{PublicKey: "0x01", Installations: 1, Envelopes: 1, Bytes: envelopeSize(500, asymmetricOverhead)},
+		{PublicKey: "0x02", Installations: 3, Envelopes: 3, Bytes: e.Bytes - envelopeSize(500, asymmetricOverhead)},
+	}, e.Recipients)
+	require.True(t, e.PoWTime > 0)
+
+	c.PoWTarget = 1000
+	e = Estimate{}
+	require.NoError(t, c.AddPublic(&e, 500))
+	require.Equal(t, int64(1000), e.PoWTime, "The proof of work of an envelope is capped by PoWTime")
+
+	require.Error(t, c.AddPublic(&e, 2000*1024))
+}
+
+func TestMeasureHashRate(t *testing.T) {
+	require.True(t, MeasureHashRate(10*time.Millisecond) > 0)
+}
@@ -0,0 +1,85 @@
+package shhext
+
+import (
+	"encoding/json"
+
+	"github.com/status-im/status-go/services/shhext/contacts"
+	"github.com/status-im/status-go/services/shhext/history"
+	"github.com/status-im/status-go/services/shhext/notifications"
+	"github.com/status-im/status-go/services/shhext/presence"
+)
+
+// UserData is the chat data of the account, as exported by status_exportAllUserData.
+type UserData struct {
+	Contacts []contacts.Contact `json:"contacts"`
+	Chats    []ChatData         `json:"chats"`
+	Settings SettingsData       `json:"settings"`
+}
+
+// ChatData holds the messages stored for a chat, from the most recent.
+type ChatData struct {
+	ID       string            `json:"id"`
+	Messages []history.Message `json:"messages"`
+}
+
+// SettingsData holds the settings of the account.
+type SettingsData struct {
+	Notifications notifications.Preferences  `json:"notifications"`
+	Presence      presence.Settings          `json:"presence"`
+	Values        map[string]json.RawMessage `json:"values"`
+}
+
+// ExportUserData returns the contacts, chat messages and settings of the account.
+// The protocol must be initialized.
+func (s *Service) ExportUserData() (interface{}, error) {
+	if s.history == nil {
+		return nil, errProtocolNotInitialized
+	}
+
+	data := UserData{Contacts: []contacts.Contact{}, Chats: []ChatData{}}
+	contactList, err := s.contacts.Contacts()
+	if err != nil {
+		return nil, err
+	}
+	data.Contacts = append(data.Contacts, contactList...)
+	chatIDs, err := s.history.Chats()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range chatIDs {
+		messages, err := s.historyMessages(id)
+		if err != nil {
+			return nil, err
+		}
+		data.Chats = append(data.Chats, ChatData{ID: id, Messages: append([]history.Message{}, messages...)})
+	}
+	if data.Settings.Notifications, err = s.notifications.Preferences(); err != nil {
+		return nil, err
+	}
+	if data.Settings.Presence, err = s.presence.Settings(); err != nil {
+		return nil, err
+	}
+	if data.Settings.Values, err = s.settings.All(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// historyMessages returns all the messages of the history of a chat, from the most recent.
+func (s *Service) historyMessages(chatID string) ([]history.Message, error) {
+	var (
+		messages []history.Message
+		cursor   string
+	)
+	for {
+		page, err := s.history.Messages(chatID, cursor, history.MaxLimit)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, page.Messages...)
+		if page.Cursor == "" {
+			return messages, nil
+		}
+		cursor = page.Cursor
+	}
+}
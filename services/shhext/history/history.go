@@ -0,0 +1,187 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/services/shhext/content"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+const (
+	// DefaultLimit is the number of messages returned when no limit is set.
+	DefaultLimit = 50
+	// MaxLimit is the maximum number of messages returned at once.
+	MaxLimit = 500
+)
+
+var (
+	// ErrInvalidCursor is returned when a cursor was not returned by Messages.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrEmptyQuery is returned when a search query has no terms.
+	ErrEmptyQuery = errors.New("empty search query")
+	// ErrSearchUnavailable is returned when the store can't search the history, like
+	// when SQLite is built without FTS5.
+	ErrSearchUnavailable = errors.New("search of the history is unavailable")
+)
+
+// Message is a chat message stored in the history.
+type Message struct {
+	ID string `json:"id"`
+	// ChatID identifies the chat of the message, see PublicChatID and DirectChatID.
+	ChatID      string `json:"chatId"`
+	Author      string `json:"author"`
+	Content     string `json:"content"`
+	ContentType string `json:"contentType"`
+	MessageType string `json:"messageType"`
+	ReplyTo     string `json:"replyTo,omitempty"`
+	Clock       uint64 `json:"clock"`
+	// Timestamp is the time the message was sent or received, in seconds.
+	Timestamp uint32 `json:"timestamp"`
+	Outgoing  bool   `json:"outgoing"`
+	Edited    bool   `json:"edited"`
+}
+
+// Cursor is the position of a message in the history of a chat,
+// which is ordered from the most recent clock.
+type Cursor struct {
+	Clock uint64
+	ID    string
+}
+
+// Page is a page of the history of a chat.
+type Page struct {
+	Messages []Message `json:"messages"`
+	// Cursor continues from the last message of the page. It is empty on the last page.
+	Cursor string `json:"cursor"`
+}
+
+// Store persists the history.
+type Store interface {
+	// SaveHistoryMessage saves a message, unless it's already saved.
+	SaveHistoryMessage(Message) error
+	// UpdateHistoryMessage replaces the content of a message and marks it as edited.
+	UpdateHistoryMessage(id string, content string) error
+	// DeleteHistoryMessage removes a message.
+	DeleteHistoryMessage(id string) error
+	// GetHistoryMessages returns at most limit messages of a chat preceding the cursor,
+	// from the most recent. A nil cursor starts from the most recent message.
+	GetHistoryMessages(chatID string, cursor *Cursor, limit int) ([]Message, error)
+	// SearchHistoryMessages returns at most limit messages whose content has words
+	// starting with each word of query, from the most relevant. An empty chatID
+	// searches all chats.
+	SearchHistoryMessages(query string, chatID string, limit int) ([]Message, error)
+	// GetHistoryChats returns the IDs of the chats with stored messages.
+	GetHistoryChats() ([]string, error)
+	// OptimizeHistoryIndex merges the segments of the full-text index of the messages.
+	OptimizeHistoryIndex() error
+}
+
+// PublicChatID returns the ID in the history of the public chat on topic.
+func PublicChatID(topic whisper.TopicType) string {
+	return topic.String()
+}
+
+// DirectChatID returns the ID in the history of the 1:1 chat with a contact,
+// given the serialized public key of the contact.
+func DirectChatID(contact []byte) string {
+	return hexutil.Encode(contact)
+}
+
+// Manager stores chat messages and keeps them up to date with their edits and deletions.
+type Manager struct {
+	store Store
+}
+
+// NewManager returns a new Manager.
+func NewManager(store Store) *Manager {
+	return &Manager{store: store}
+}
+
+// Add stores a message. Messages already stored are ignored.
+func (m *Manager) Add(message Message) error {
+	return m.store.SaveHistoryMessage(message)
+}
+
+// Apply updates a stored message with its state after an edit or a deletion.
+func (m *Manager) Apply(state content.State) error {
+	if state.Deleted {
+		return m.store.DeleteHistoryMessage(state.ID)
+	}
+	if state.Edited {
+		return m.store.UpdateHistoryMessage(state.ID, state.Content)
+	}
+	return nil
+}
+
+// Messages returns a page of the history of a chat, from the most recent message.
+// cursor is empty for the first page, and the cursor of the previous page otherwise.
+func (m *Manager) Messages(chatID string, cursor string, limit int) (Page, error) {
+	c, err := decodeCursor(cursor)
+	if err != nil {
+		return Page{}, err
+	}
+	limit = normalizeLimit(limit)
+	messages, err := m.store.GetHistoryMessages(chatID, c, limit)
+	if err != nil {
+		return Page{}, err
+	}
+	page := Page{Messages: messages}
+	if len(messages) == limit {
+		last := messages[len(messages)-1]
+		page.Cursor = encodeCursor(Cursor{Clock: last.Clock, ID: last.ID})
+	}
+	return page, nil
+}
+
+// Chats returns the IDs of the chats with stored messages.
+func (m *Manager) Chats() ([]string, error) {
+	return m.store.GetHistoryChats()
+}
+
+// Search returns the messages whose content contains all the words of query,
+// from the most relevant. Words match as prefixes. An empty chatID searches all chats.
+func (m *Manager) Search(query string, chatID string, limit int) ([]Message, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, ErrEmptyQuery
+	}
+	return m.store.SearchHistoryMessages(query, chatID, normalizeLimit(limit))
+}
+
+// Optimize merges the full-text index of the messages, which speeds up searches
+// after many messages were added. It is expensive and should run when the device is charging.
+func (m *Manager) Optimize() error {
+	return m.store.OptimizeHistoryIndex()
+}
+
+func normalizeLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLimit
+	}
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+	return limit
+}
+
+func encodeCursor(c Cursor) string {
+	return fmt.Sprintf("%d-%s", c.Clock, c.ID)
+}
+
+func decodeCursor(cursor string) (*Cursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	parts := strings.SplitN(cursor, "-", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, ErrInvalidCursor
+	}
+	clock, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{Clock: clock, ID: parts[1]}, nil
+}
@@ -0,0 +1,124 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/status-im/status-go/services/shhext/content"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	messages []Message
+	match    string
+}
+
+func (s *memoryStore) SaveHistoryMessage(m Message) error {
+	s.messages = append(s.messages, m)
+	return nil
+}
+
+func (s *memoryStore) UpdateHistoryMessage(id string, content string) error {
+	for i := range s.messages {
+		if s.messages[i].ID == id {
+			s.messages[i].Content = content
+			s.messages[i].Edited = true
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) DeleteHistoryMessage(id string) error {
+	for i, m := range s.messages {
+		if m.ID == id {
+			s.messages = append(s.messages[:i], s.messages[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// GetHistoryMessages expects the messages to be saved from the most recent.
+func (s *memoryStore) GetHistoryMessages(chatID string, cursor *Cursor, limit int) ([]Message, error) {
+	var result []Message
+	for _, m := range s.messages {
+		if m.ChatID != chatID || (cursor != nil && (m.Clock > cursor.Clock || (m.Clock == cursor.Clock && m.ID >= cursor.ID))) {
+			continue
+		}
+		if len(result) == limit {
+			break
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+func (s *memoryStore) SearchHistoryMessages(query string, chatID string, limit int) ([]Message, error) {
+	s.match = query
+	return nil, nil
+}
+
+func (s *memoryStore) OptimizeHistoryIndex() error {
+	return nil
+}
+
+func (s *memoryStore) GetHistoryChats() ([]string, error) {
+	var chats []string
+	seen := make(map[string]bool)
+	for _, m := range s.messages {
+		if !seen[m.ChatID] {
+			seen[m.ChatID] = true
+			chats = append(chats, m.ChatID)
+		}
+	}
+	return chats, nil
+}
+
+func TestMessagesPages(t *testing.T) {
+	store := &memoryStore{}
+	m := NewManager(store)
+	for _, msg := range []Message{
+		{ID: "3", ChatID: "chat", Clock: 3},
+		{ID: "b", ChatID: "chat", Clock: 2},
+		{ID: "a", ChatID: "chat", Clock: 2},
+		{ID: "4", ChatID: "other", Clock: 1},
+	} {
+		require.NoError(t, m.Add(msg))
+	}
+
+	page, err := m.Messages("chat", "", 2)
+	require.NoError(t, err)
+	require.Len(t, page.Messages, 2)
+	require.Equal(t, "2-b", page.Cursor)
+	page, err = m.Messages("chat", page.Cursor, 2)
+	require.NoError(t, err)
+	require.Equal(t, []Message{{ID: "a", ChatID: "chat", Clock: 2}}, page.Messages)
+	require.Empty(t, page.Cursor, "The last page has no cursor")
+
+	for _, cursor := range []string{"2", "x-a", "2-"} {
+		_, err = m.Messages("chat", cursor, 2)
+		require.Equal(t, ErrInvalidCursor, err, cursor)
+	}
+}
+
+func TestApply(t *testing.T) {
+	store := &memoryStore{}
+	m := NewManager(store)
+	require.NoError(t, m.Add(Message{ID: "1", ChatID: "chat", Content: "hello"}))
+	require.NoError(t, m.Add(Message{ID: "2", ChatID: "chat", Content: "hi"}))
+
+	require.NoError(t, m.Apply(content.State{ID: "1"}))
+	require.NoError(t, m.Apply(content.State{ID: "1", Content: "hello, world", Edited: true}))
+	require.NoError(t, m.Apply(content.State{ID: "2", Deleted: true}))
+	require.Equal(t, []Message{{ID: "1", ChatID: "chat", Content: "hello, world", Edited: true}}, store.messages)
+}
+
+func TestSearchQuery(t *testing.T) {
+	store := &memoryStore{}
+	m := NewManager(store)
+
+	_, err := m.Search(`hel wor"ld OR`, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, `hel wor"ld OR`, store.match, "The store matches the words of the query")
+	_, err = m.Search("  ", "", 0)
+	require.Equal(t, ErrEmptyQuery, err)
+}
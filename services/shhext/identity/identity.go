@@ -0,0 +1,148 @@
+package identity
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	// LatestVersion is the version of the algorithm used when none is requested.
+	LatestVersion = 1
+	// DefaultCacheSize is the number of identities kept by the cache.
+	DefaultCacheSize = 1000
+	// ringSegments is the number of segments of the ring derived by version 1.
+	ringSegments = 8
+)
+
+// ErrUnknownVersion is returned when deriving an identity with an unknown algorithm.
+var ErrUnknownVersion = errors.New("unknown identity version")
+
+// palette are the colors of the ring segments derived by version 1.
+var palette = []string{
+	"#e53935", "#d81b60", "#8e24aa", "#5e35b1", "#3949ab", "#1e88e5", "#039be5",
+	"#00acc1", "#00897b", "#43a047", "#7cb342", "#fdd835", "#fb8c00", "#6d4c41",
+}
+
+// Identity is the visual identity of a public key: the color of its avatar and
+// the colors of the segments of the ring around it, clockwise from the top.
+type Identity struct {
+	Version int      `json:"version"`
+	Color   string   `json:"color"`
+	Ring    []string `json:"ring"`
+}
+
+// algorithm derives an identity from the keccak256 hash of an uncompressed public key.
+// Algorithms must never change once released, new versions are added instead.
+type algorithm func(hash []byte) Identity
+
+var algorithms = map[int]algorithm{
+	1: deriveV1,
+}
+
+// Derive derives the identity of a public key with the algorithm of the given
+// version, or the latest one if version is 0.
+func Derive(publicKey *ecdsa.PublicKey, version int) (Identity, error) {
+	if version == 0 {
+		version = LatestVersion
+	}
+	derive, ok := algorithms[version]
+	if !ok {
+		return Identity{}, ErrUnknownVersion
+	}
+	identity := derive(crypto.Keccak256(crypto.FromECDSAPub(publicKey)))
+	identity.Version = version
+	return identity, nil
+}
+
+// deriveV1 uses the first two bytes of the hash as the hue of the color,
+// and one byte per segment to pick its color in the palette.
+func deriveV1(hash []byte) Identity {
+	hue := float64((int(hash[0])<<8|int(hash[1]))%360) / 360
+	identity := Identity{
+		Color: hslToHex(hue, 0.65, 0.5),
+		Ring:  make([]string, ringSegments),
+	}
+	for i := range identity.Ring {
+		identity.Ring[i] = palette[int(hash[2+i])%len(palette)]
+	}
+	return identity
+}
+
+// hslToHex converts a color from HSL, with all components between 0 and 1, to its hex notation.
+func hslToHex(h, s, l float64) string {
+	var q float64
+	if l < 0.5 {
+		q = l * (1 + s)
+	} else {
+		q = l + s - l*s
+	}
+	p := 2*l - q
+	r := hueToRGB(p, q, h+1.0/3)
+	g := hueToRGB(p, q, h)
+	b := hueToRGB(p, q, h-1.0/3)
+	return fmt.Sprintf("#%02x%02x%02x", toByte(r), toByte(g), toByte(b))
+}
+
+func hueToRGB(p, q, t float64) float64 {
+	if t < 0 {
+		t++
+	}
+	if t > 1 {
+		t--
+	}
+	switch {
+	case t < 1.0/6:
+		return p + (q-p)*6*t
+	case t < 1.0/2:
+		return q
+	case t < 2.0/3:
+		return p + (q-p)*(2.0/3-t)*6
+	default:
+		return p
+	}
+}
+
+func toByte(v float64) uint8 {
+	return uint8(math.Round(v * 255))
+}
+
+type cacheKey struct {
+	version int
+	key     string
+}
+
+// Cache keeps the identities derived most recently.
+type Cache struct {
+	identities *lru.Cache
+}
+
+// NewCache returns a new Cache keeping up to size identities, or DefaultCacheSize if size is not positive.
+func NewCache(size int) *Cache {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	identities, _ := lru.New(size) // nolint: errcheck, only fails with a size that is not positive
+	return &Cache{identities: identities}
+}
+
+// Derive returns the identity of a public key, deriving it if it's not cached.
+func (c *Cache) Derive(publicKey *ecdsa.PublicKey, version int) (Identity, error) {
+	if version == 0 {
+		version = LatestVersion
+	}
+	key := cacheKey{version: version, key: string(crypto.CompressPubkey(publicKey))}
+	if cached, ok := c.identities.Get(key); ok {
+		return cached.(Identity), nil
+	}
+	identity, err := Derive(publicKey, version)
+	if err != nil {
+		return Identity{}, err
+	}
+	c.identities.Add(key, identity)
+	return identity, nil
+}
@@ -0,0 +1,58 @@
+package identity
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDerive(t *testing.T) {
+	key, err := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	require.NoError(t, err)
+
+	// Released versions must never change, as clients render them.
+	expected := Identity{
+		Version: 1,
+		Color:   "#d22d64",
+		Ring:    []string{"#039be5", "#fdd835", "#1e88e5", "#8e24aa", "#d81b60", "#d81b60", "#00897b", "#00acc1"},
+	}
+	identity, err := Derive(&key.PublicKey, 1)
+	require.NoError(t, err)
+	require.Equal(t, expected, identity)
+
+	identity, err = Derive(&key.PublicKey, 0)
+	require.NoError(t, err)
+	require.Equal(t, LatestVersion, identity.Version, "It uses the latest version by default")
+
+	_, err = Derive(&key.PublicKey, LatestVersion+1)
+	require.Equal(t, ErrUnknownVersion, err)
+}
+
+func TestHSLToHex(t *testing.T) {
+	require.Equal(t, "#ff0000", hslToHex(0, 1, 0.5))
+	require.Equal(t, "#00ff00", hslToHex(1.0/3, 1, 0.5))
+	require.Equal(t, "#0000ff", hslToHex(2.0/3, 1, 0.5))
+	require.Equal(t, "#808080", hslToHex(0, 0, 0.5))
+}
+
+func TestCache(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	cache := NewCache(1)
+
+	expected, err := Derive(&key.PublicKey, LatestVersion)
+	require.NoError(t, err)
+	identity, err := cache.Derive(&key.PublicKey, 0)
+	require.NoError(t, err)
+	require.Equal(t, expected, identity)
+	require.Equal(t, 1, cache.identities.Len())
+
+	identity, err = cache.Derive(&key.PublicKey, LatestVersion)
+	require.NoError(t, err)
+	require.Equal(t, expected, identity, "It returns the cached identity")
+	require.Equal(t, 1, cache.identities.Len())
+
+	_, err = cache.Derive(&key.PublicKey, LatestVersion+1)
+	require.Equal(t, ErrUnknownVersion, err)
+}
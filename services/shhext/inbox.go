@@ -0,0 +1,78 @@
+package shhext
+
+import (
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/services/shhext/chat"
+	"github.com/status-im/status-go/services/shhext/inbox"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// errMessagePending is returned if a message was kept in the inbox as it can't be decrypted yet.
+var errMessagePending = errors.New("message is pending")
+
+// messageKeys returns our private key and the public key of the sender of a direct message,
+// or nil keys if the message is public.
+func messageKeys(w *whisper.Whisper, msg *whisper.Message) (*ecdsa.PrivateKey, *ecdsa.PublicKey, error) {
+	// Msg.Dst is empty is a public message, nothing to do
+	if msg.Dst == nil {
+		return nil, nil, nil
+	}
+
+	// There's probably a better way to do this
+	keyBytes, err := hexutil.Bytes(msg.Dst).MarshalText()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	privateKey, err := w.GetPrivateKey(string(keyBytes))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// This needs to be pushed down in the protocol message
+	publicKey, err := crypto.UnmarshalPubkey(msg.Sig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return privateKey, publicKey, nil
+}
+
+// decryptPending decrypts a message kept in the inbox.
+func (s *Service) decryptPending(msg *whisper.Message) error {
+	if s.protocol == nil {
+		return inbox.ErrNotDecryptable
+	}
+	privateKey, publicKey, err := messageKeys(s.w, msg)
+	if err != nil {
+		return err
+	}
+	response, err := s.protocol.HandleMessage(privateKey, publicKey, msg.Payload)
+	if err == chat.ErrSessionNotFound || err == chat.ErrContactRequest {
+		return inbox.ErrNotDecryptable
+	} else if err != nil {
+		return err
+	}
+	msg.Payload = response
+	return nil
+}
+
+// retryPending retries the messages kept in the inbox after a message of the sender
+// was processed, as it may have carried the bundle required to decrypt them.
+func (s *Service) retryPending(msg *whisper.Message) {
+	if len(msg.Sig) == 0 {
+		return
+	}
+	sender, err := crypto.UnmarshalPubkey(msg.Sig)
+	if err != nil {
+		return
+	}
+	// The contact requests are retried once accepted.
+	if s.config.ContactRequests && s.consent != nil && !s.contactAllowed(sender) {
+		return
+	}
+	s.inbox.Retry(sender)
+}
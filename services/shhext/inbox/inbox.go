@@ -0,0 +1,232 @@
+package inbox
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+const (
+	// DefaultMaxMessages is the maximum number of pending messages.
+	DefaultMaxMessages = 1000
+	// DefaultMaxAttempts is the number of times a pending message is retried before being dropped.
+	DefaultMaxAttempts = 50
+	// DefaultTTL is how long messages are kept pending. It matches the time
+	// mail servers keep envelopes, after which senders can't resend them anyway.
+	DefaultTTL = 30 * 24 * time.Hour
+)
+
+var (
+	// ErrNotDecryptable must be returned by a Decrypter if a message can't be decrypted yet.
+	ErrNotDecryptable = errors.New("message can't be decrypted yet")
+	// ErrStoreNotSet is returned if messages are added before the store is available.
+	ErrStoreNotSet = errors.New("inbox store is not set")
+	// ErrInboxFull is returned if there are too many pending messages.
+	ErrInboxFull = errors.New("too many pending messages")
+)
+
+// PendingMessage is a message that couldn't be decrypted yet.
+type PendingMessage struct {
+	Hash []byte
+	// Sender is the compressed public key of the sender.
+	Sender []byte
+	// Message is the JSON-encoded whisper message.
+	Message  []byte
+	Attempts int
+	// ReceivedAt is the time the message was received, in milliseconds.
+	ReceivedAt int64
+}
+
+// Store persists pending messages.
+type Store interface {
+	SavePendingMessage(PendingMessage) error
+	GetPendingMessages(sender []byte) ([]PendingMessage, error)
+	DeletePendingMessage(hash []byte) error
+	CountPendingMessages() (int, error)
+	PrunePendingMessages(before int64) (int, error)
+}
+
+// Decrypter decrypts the payload of a message in place. It returns ErrNotDecryptable
+// if the message may be decrypted later. Messages are dropped on other errors.
+type Decrypter func(*whisper.Message) error
+
+// Handler is notified of pending messages that were decrypted.
+type Handler func([]*whisper.Message)
+
+// Config is the configuration of an Inbox.
+type Config struct {
+	MaxMessages int
+	MaxAttempts int
+	TTL         time.Duration
+}
+
+// DefaultConfig returns the default configuration of an Inbox.
+func DefaultConfig() Config {
+	return Config{
+		MaxMessages: DefaultMaxMessages,
+		MaxAttempts: DefaultMaxAttempts,
+		TTL:         DefaultTTL,
+	}
+}
+
+// Inbox keeps the messages that couldn't be decrypted, for instance because
+// they were received before the message establishing the session, and retries
+// them when a message of the same sender is processed, as it may carry a new
+// bundle or establish a session.
+type Inbox struct {
+	decrypt Decrypter
+	handler Handler
+	config  Config
+
+	// mu serializes retries, so that a message is not decrypted twice.
+	mu    sync.Mutex
+	store Store
+}
+
+// New returns a new Inbox without a store.
+func New(decrypt Decrypter, handler Handler, config Config) *Inbox {
+	return &Inbox{decrypt: decrypt, handler: handler, config: config}
+}
+
+// SetStore sets the store of pending messages.
+func (i *Inbox) SetStore(store Store) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.store = store
+}
+
+// Add keeps a message that couldn't be decrypted until it can be.
+func (i *Inbox) Add(sender *ecdsa.PublicKey, msg *whisper.Message) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.store == nil {
+		return ErrStoreNotSet
+	}
+
+	count, err := i.store.CountPendingMessages()
+	if err != nil {
+		return err
+	}
+	if count >= i.config.MaxMessages {
+		return ErrInboxFull
+	}
+
+	encoded, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return i.store.SavePendingMessage(PendingMessage{
+		Hash:       msg.Hash,
+		Sender:     crypto.CompressPubkey(sender),
+		Message:    encoded,
+		ReceivedAt: toMillis(time.Now()),
+	})
+}
+
+// Retry tries to decrypt the pending messages of a sender, and notifies the
+// handler of the messages that were decrypted.
+func (i *Inbox) Retry(sender *ecdsa.PublicKey) {
+	i.mu.Lock()
+	if i.store == nil {
+		i.mu.Unlock()
+		return
+	}
+	decrypted, err := i.retry(crypto.CompressPubkey(sender))
+	i.mu.Unlock()
+	if err != nil {
+		log.Error("failed to retry pending messages", "err", err)
+	}
+	if len(decrypted) != 0 && i.handler != nil {
+		i.handler(decrypted)
+	}
+}
+
+// Drop removes the pending messages of a sender.
+func (i *Inbox) Drop(sender *ecdsa.PublicKey) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.store == nil {
+		return ErrStoreNotSet
+	}
+	pending, err := i.store.GetPendingMessages(crypto.CompressPubkey(sender))
+	if err != nil {
+		return err
+	}
+	for _, p := range pending {
+		if err := i.store.DeletePendingMessage(p.Hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Prune deletes the pending messages older than the TTL. It returns the number of messages deleted.
+func (i *Inbox) Prune() (int, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.store == nil {
+		return 0, ErrStoreNotSet
+	}
+	return i.store.PrunePendingMessages(toMillis(time.Now().Add(-i.config.TTL)))
+}
+
+// retry must be called with the lock held. Decrypting a message can establish
+// the session required by another one, so messages are retried until none
+// of them can be decrypted.
+func (i *Inbox) retry(sender []byte) ([]*whisper.Message, error) {
+	pending, err := i.store.GetPendingMessages(sender)
+	if err != nil {
+		return nil, err
+	}
+
+	var decrypted []*whisper.Message
+	for progress := true; progress && len(pending) != 0; {
+		progress = false
+		remaining := pending[:0]
+		for _, p := range pending {
+			msg := &whisper.Message{}
+			if err := json.Unmarshal(p.Message, msg); err != nil {
+				return decrypted, err
+			}
+			err := i.decrypt(msg)
+			if err == ErrNotDecryptable {
+				remaining = append(remaining, p)
+				continue
+			}
+			if err == nil {
+				progress = true
+				decrypted = append(decrypted, msg)
+			} else {
+				log.Debug("dropping pending message", "hash", msg.Hash, "err", err)
+			}
+			if err := i.store.DeletePendingMessage(p.Hash); err != nil {
+				return decrypted, err
+			}
+		}
+		pending = remaining
+	}
+
+	for _, p := range pending {
+		p.Attempts++
+		var err error
+		if p.Attempts >= i.config.MaxAttempts {
+			err = i.store.DeletePendingMessage(p.Hash)
+		} else {
+			err = i.store.SavePendingMessage(p)
+		}
+		if err != nil {
+			return decrypted, err
+		}
+	}
+	return decrypted, nil
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,135 @@
+package inbox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+)
+
+type memStore map[string]PendingMessage
+
+func (s memStore) SavePendingMessage(p PendingMessage) error {
+	s[string(p.Hash)] = p
+	return nil
+}
+
+func (s memStore) GetPendingMessages(sender []byte) ([]PendingMessage, error) {
+	var result []PendingMessage
+	for _, p := range s {
+		if string(p.Sender) == string(sender) {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (s memStore) DeletePendingMessage(hash []byte) error {
+	delete(s, string(hash))
+	return nil
+}
+
+func (s memStore) CountPendingMessages() (int, error) {
+	return len(s), nil
+}
+
+func (s memStore) PrunePendingMessages(before int64) (int, error) {
+	pruned := 0
+	for hash, p := range s {
+		if p.ReceivedAt < before {
+			delete(s, hash)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// decrypter decrypts the messages whose payload is in keys.
+type decrypter struct {
+	keys map[string]bool
+}
+
+func (d *decrypter) decrypt(msg *whisper.Message) error {
+	decryptable, exist := d.keys[string(msg.Payload)]
+	if !exist {
+		return errors.New("invalid message")
+	}
+	if !decryptable {
+		return ErrNotDecryptable
+	}
+	msg.Payload = append([]byte("decrypted "), msg.Payload...)
+	return nil
+}
+
+func TestInboxRetry(t *testing.T) {
+	sender, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	d := &decrypter{keys: map[string]bool{"first": false, "second": false}}
+	var notified []*whisper.Message
+	inbox := New(d.decrypt, func(messages []*whisper.Message) {
+		notified = append(notified, messages...)
+	}, DefaultConfig())
+	require.Equal(t, ErrStoreNotSet, inbox.Add(&sender.PublicKey, &whisper.Message{}))
+
+	store := memStore{}
+	inbox.SetStore(store)
+	require.NoError(t, inbox.Add(&sender.PublicKey, &whisper.Message{Hash: []byte{1}, Payload: []byte("first")}))
+	require.NoError(t, inbox.Add(&sender.PublicKey, &whisper.Message{Hash: []byte{2}, Payload: []byte("second")}))
+	require.NoError(t, inbox.Add(&sender.PublicKey, &whisper.Message{Hash: []byte{3}, Payload: []byte("invalid")}))
+
+	inbox.Retry(&other.PublicKey)
+	require.Len(t, store, 3, "Messages of other senders are not retried")
+
+	inbox.Retry(&sender.PublicKey)
+	require.Empty(t, notified)
+	require.Len(t, store, 2, "Messages that can't be decrypted anymore are dropped")
+	require.Equal(t, 1, store[string([]byte{1})].Attempts)
+
+	d.keys["first"] = true
+	inbox.Retry(&sender.PublicKey)
+	require.Len(t, notified, 1)
+	require.Equal(t, []byte("decrypted first"), notified[0].Payload)
+	require.Equal(t, []byte{1}, []byte(notified[0].Hash))
+	require.Len(t, store, 1)
+}
+
+func TestInboxLimits(t *testing.T) {
+	sender, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	d := &decrypter{keys: map[string]bool{"first": false}}
+	inbox := New(d.decrypt, nil, Config{MaxMessages: 1, MaxAttempts: 2, TTL: DefaultTTL})
+	store := memStore{}
+	inbox.SetStore(store)
+
+	require.NoError(t, inbox.Add(&sender.PublicKey, &whisper.Message{Hash: []byte{1}, Payload: []byte("first")}))
+	require.Equal(t, ErrInboxFull, inbox.Add(&sender.PublicKey, &whisper.Message{Hash: []byte{2}, Payload: []byte("first")}))
+
+	inbox.Retry(&sender.PublicKey)
+	require.Len(t, store, 1)
+	inbox.Retry(&sender.PublicKey)
+	require.Empty(t, store, "Messages are dropped after MaxAttempts")
+}
+
+func TestInboxDrop(t *testing.T) {
+	sender, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	d := &decrypter{keys: map[string]bool{"first": false}}
+	inbox := New(d.decrypt, nil, DefaultConfig())
+	store := memStore{}
+	inbox.SetStore(store)
+	require.NoError(t, inbox.Add(&sender.PublicKey, &whisper.Message{Hash: []byte{1}, Payload: []byte("first")}))
+	require.NoError(t, inbox.Add(&other.PublicKey, &whisper.Message{Hash: []byte{2}, Payload: []byte("first")}))
+
+	require.NoError(t, inbox.Drop(&sender.PublicKey))
+	require.Len(t, store, 1)
+	require.Contains(t, store, string([]byte{2}), "Messages of other senders are kept")
+}
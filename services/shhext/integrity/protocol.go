@@ -0,0 +1,107 @@
+package integrity
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/status-im/status-go/mailserver"
+)
+
+// requestCompleteCode is the code of the Whisper packets sent by mail servers
+// when they completed a request.
+const requestCompleteCode = 125
+
+// Summaries collects the summaries that mail servers append to the completion
+// messages of the requests that asked for one. Whisper doesn't know about them,
+// so they are split from the messages before Whisper reads them.
+type Summaries struct {
+	mu       sync.Mutex
+	expected map[common.Hash]bool
+	received map[common.Hash][]byte
+}
+
+// NewSummaries returns new Summaries.
+func NewSummaries() *Summaries {
+	return &Summaries{
+		expected: make(map[common.Hash]bool),
+		received: make(map[common.Hash][]byte),
+	}
+}
+
+// Expect collects the summary of the response to the request.
+func (s *Summaries) Expect(requestID common.Hash) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expected[requestID] = true
+}
+
+// Take returns the summary of the response to the request, if it was received, and
+// stops collecting it.
+func (s *Summaries) Take(requestID common.Hash) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	summary := s.received[requestID]
+	delete(s.expected, requestID)
+	delete(s.received, requestID)
+	return summary
+}
+
+func (s *Summaries) add(requestID common.Hash, summary []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.expected[requestID] {
+		s.received[requestID] = summary
+	}
+}
+
+// Protocol wraps the Whisper protocol so that the summaries are split from the
+// completion messages and collected for the expected requests.
+func (s *Summaries) Protocol(protocol p2p.Protocol) p2p.Protocol {
+	run := protocol.Run
+	protocol.Run = func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+		return run(peer, &summariesReadWriter{MsgReadWriter: rw, summaries: s})
+	}
+	return protocol
+}
+
+type summariesReadWriter struct {
+	p2p.MsgReadWriter
+	summaries *Summaries
+}
+
+// ReadMsg returns the next packet, without the summary of completion messages.
+func (rw *summariesReadWriter) ReadMsg() (p2p.Msg, error) {
+	msg, err := rw.MsgReadWriter.ReadMsg()
+	if err != nil || msg.Code != requestCompleteCode {
+		return msg, err
+	}
+
+	data, err := ioutil.ReadAll(msg.Payload)
+	if err != nil {
+		return msg, err
+	}
+	msg.Payload = bytes.NewReader(data)
+
+	var payload []byte
+	// Whisper disconnects peers sending invalid packets.
+	if err := rlp.DecodeBytes(data, &payload); err != nil || len(payload) < common.HashLength {
+		return msg, nil
+	}
+	payload, summary := mailserver.SplitResponseSummary(payload)
+	if summary == nil {
+		return msg, nil
+	}
+	rw.summaries.add(common.BytesToHash(payload[:common.HashLength]), summary)
+
+	data, err = rlp.EncodeToBytes(payload)
+	if err != nil {
+		return msg, err
+	}
+	msg.Size = uint32(len(data))
+	msg.Payload = bytes.NewReader(data)
+	return msg, nil
+}
@@ -0,0 +1,49 @@
+package integrity
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/status-im/status-go/mailserver"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummariesProtocol(t *testing.T) {
+	summaries := NewSummaries()
+	expected, other := common.Hash{0xaa}, common.Hash{0xbb}
+	summaries.Expect(expected)
+
+	received := make(chan []byte, 2)
+	protocol := summaries.Protocol(p2p.Protocol{Run: func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+		for {
+			msg, err := rw.ReadMsg()
+			if err != nil {
+				return err
+			}
+			var payload []byte
+			if err := msg.Decode(&payload); err != nil {
+				return err
+			}
+			received <- payload
+		}
+	}})
+
+	local, remote := p2p.MsgPipe()
+	done := make(chan error, 1)
+	go func() { done <- protocol.Run(p2p.NewPeer(enode.ID{1}, "peer", nil), local) }()
+
+	for _, requestID := range []common.Hash{expected, other} {
+		payload := whisper.CreateMailServerRequestCompletedPayload(requestID, common.Hash{1}, nil)
+		require.NoError(t, p2p.Send(remote, requestCompleteCode, mailserver.AppendResponseSummary(payload, requestID[:1])))
+		require.Equal(t, payload, <-received, "Whisper reads the payload without the summary")
+	}
+
+	require.Equal(t, []byte{0xaa}, summaries.Take(expected))
+	require.Nil(t, summaries.Take(other), "Summaries of requests that are not expected are dropped")
+	require.Nil(t, summaries.Take(expected))
+	require.NoError(t, remote.Close())
+	require.Error(t, <-done)
+}
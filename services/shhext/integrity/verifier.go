@@ -0,0 +1,263 @@
+package integrity
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/status-im/status-go/mailserver"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+const (
+	// DefaultGracePeriod is how long envelopes returned by a mail server are waited
+	// for after the request completed, as they are processed asynchronously.
+	DefaultGracePeriod = 2 * time.Second
+	// eventsBuffer must be large enough to not block the whisper envelopes feed.
+	eventsBuffer  = 100
+	checkInterval = 500 * time.Millisecond
+)
+
+// Status is the outcome of the verification of a response.
+type Status int
+
+const (
+	// Verified responses match the summary signed by the mail server.
+	Verified Status = iota
+	// Missing responses don't have a summary, so they can't be verified.
+	Missing
+	// Tampered responses have a summary that isn't signed by the mail server,
+	// or that doesn't match the request or the hashes it lists.
+	Tampered
+	// Truncated responses lack envelopes listed in their summary.
+	Truncated
+)
+
+var statusNames = map[Status]string{
+	Verified:  "verified",
+	Missing:   "missing",
+	Tampered:  "tampered",
+	Truncated: "truncated",
+}
+
+func (s Status) String() string {
+	return statusNames[s]
+}
+
+// MarshalJSON encodes the status as its name.
+func (s Status) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// Result is the outcome of the verification of a response.
+type Result struct {
+	RequestID common.Hash `json:"requestID"`
+	Status    Status      `json:"status"`
+	// Count is the number of envelopes the mail server claims it sent.
+	Count uint32 `json:"count"`
+	// MissingEnvelopes is the number of envelopes listed in the summary that were not received.
+	MissingEnvelopes int `json:"missingEnvelopes"`
+}
+
+// Handler is notified of the outcome of each verification.
+type Handler func(Result)
+
+// EnvelopeEventsSubscriber subscribes to whisper envelope events.
+type EnvelopeEventsSubscriber interface {
+	SubscribeEnvelopeEvents(chan<- whisper.EnvelopeEvent) event.Subscription
+}
+
+type request struct {
+	mailServer *ecdsa.PublicKey
+	lower      uint32
+	upper      uint32
+	sent       time.Time
+
+	completed time.Time
+	summary   []byte
+}
+
+// Verifier verifies the responses of mail servers to requests for historic messages
+// against the summary signed by the mail server and sent in the completion message:
+// the summary must be signed by the node key of the mail server, match the range of
+// the request, and list only envelopes that were received. The summaries are
+// collected by the Summaries wrapping the Whisper protocol.
+type Verifier struct {
+	whisper   EnvelopeEventsSubscriber
+	summaries *Summaries
+	handler   Handler
+	grace     time.Duration
+
+	mu       sync.Mutex
+	requests map[common.Hash]*request
+	// received are the envelopes available since the oldest pending request was sent.
+	received map[common.Hash]time.Time
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// NewVerifier returns a new Verifier.
+func NewVerifier(w EnvelopeEventsSubscriber, summaries *Summaries, handler Handler) *Verifier {
+	return &Verifier{
+		whisper:   w,
+		summaries: summaries,
+		handler:   handler,
+		grace:     DefaultGracePeriod,
+		requests:  make(map[common.Hash]*request),
+		received:  make(map[common.Hash]time.Time),
+	}
+}
+
+// Watch verifies the response to a request sent to a mail server for the given range.
+func (v *Verifier) Watch(requestID common.Hash, mailServer *ecdsa.PublicKey, lower, upper uint32) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.requests[requestID] = &request{mailServer: mailServer, lower: lower, upper: upper, sent: time.Now()}
+	v.summaries.Expect(requestID)
+}
+
+// Start watches the responses of mail servers.
+func (v *Verifier) Start() {
+	v.quit = make(chan struct{})
+	// Subscribe before returning, so that no response to a watched request is missed.
+	events := make(chan whisper.EnvelopeEvent, eventsBuffer)
+	sub := v.whisper.SubscribeEnvelopeEvents(events)
+	v.wg.Add(1)
+	go func() {
+		defer v.wg.Done()
+		defer sub.Unsubscribe()
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-v.quit:
+				return
+			case err := <-sub.Err():
+				log.Error("retry after error subscribing to whisper events", "error", err)
+				return
+			case ev := <-events:
+				v.handleEvent(ev)
+			case now := <-ticker.C:
+				v.verifyCompleted(now)
+			}
+		}
+	}()
+}
+
+// Stop stops watching the responses of mail servers.
+func (v *Verifier) Stop() {
+	if v.quit == nil {
+		return
+	}
+	close(v.quit)
+	v.wg.Wait()
+	v.quit = nil
+}
+
+func (v *Verifier) handleEvent(ev whisper.EnvelopeEvent) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	switch ev.Event {
+	case whisper.EventEnvelopeAvailable:
+		if len(v.requests) > 0 {
+			v.received[ev.Hash] = time.Now()
+		}
+	case whisper.EventMailServerRequestCompleted:
+		r, exist := v.requests[ev.Hash]
+		if !exist {
+			return
+		}
+		resp, ok := ev.Data.(*whisper.MailServerResponse)
+		if !ok || resp.Error != nil {
+			v.remove(ev.Hash)
+			return
+		}
+		r.completed = time.Now()
+		r.summary = v.summaries.Take(ev.Hash)
+	case whisper.EventMailServerRequestExpired:
+		if _, exist := v.requests[ev.Hash]; exist {
+			v.remove(ev.Hash)
+		}
+	}
+}
+
+// verifyCompleted verifies the responses whose grace period ended.
+func (v *Verifier) verifyCompleted(now time.Time) {
+	var results []Result
+	v.mu.Lock()
+	for hash, r := range v.requests {
+		if !r.completed.IsZero() && now.Sub(r.completed) >= v.grace {
+			results = append(results, v.verify(hash, r))
+			v.remove(hash)
+		}
+	}
+	v.mu.Unlock()
+
+	for _, result := range results {
+		if result.Status != Verified {
+			log.Warn("mail server response failed verification", "request", result.RequestID, "status", result.Status)
+		}
+		if v.handler != nil {
+			v.handler(result)
+		}
+	}
+}
+
+// verify must be called with the lock held.
+func (v *Verifier) verify(hash common.Hash, r *request) Result {
+	result := Result{RequestID: hash, Status: Verified}
+	if len(r.summary) == 0 {
+		result.Status = Missing
+		return result
+	}
+
+	summary, err := mailserver.DecodeResponseSummary(r.summary)
+	if err != nil {
+		result.Status = Tampered
+		return result
+	}
+	result.Count = summary.Count
+	if summary.RequestID != hash || summary.Lower != r.lower || summary.Upper != r.upper {
+		result.Status = Tampered
+		return result
+	}
+	if err := summary.Verify(r.mailServer); err != nil {
+		result.Status = Tampered
+		return result
+	}
+
+	for _, envelope := range summary.Hashes {
+		if _, ok := v.received[envelope]; !ok {
+			result.MissingEnvelopes++
+		}
+	}
+	if result.MissingEnvelopes > 0 {
+		result.Status = Truncated
+	}
+	return result
+}
+
+// remove forgets a request and the envelopes received before all the pending requests
+// were sent. It must be called with the lock held.
+func (v *Verifier) remove(hash common.Hash) {
+	delete(v.requests, hash)
+	v.summaries.Take(hash)
+
+	var oldest time.Time
+	for _, r := range v.requests {
+		if oldest.IsZero() || r.sent.Before(oldest) {
+			oldest = r.sent
+		}
+	}
+	for envelope, received := range v.received {
+		if oldest.IsZero() || received.Before(oldest) {
+			delete(v.received, envelope)
+		}
+	}
+}
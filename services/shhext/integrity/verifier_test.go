@@ -0,0 +1,164 @@
+package integrity
+
+import (
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/status-im/status-go/mailserver"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+)
+
+type envelopeEvents struct {
+	feed event.Feed
+}
+
+func (e *envelopeEvents) SubscribeEnvelopeEvents(events chan<- whisper.EnvelopeEvent) event.Subscription {
+	return e.feed.Subscribe(events)
+}
+
+func setupVerifier(t *testing.T) (*Verifier, *envelopeEvents, chan Result) {
+	events := &envelopeEvents{}
+	results := make(chan Result, 1)
+	v := NewVerifier(events, NewSummaries(), func(r Result) { results <- r })
+	v.grace = 0
+	v.Start()
+	return v, events, results
+}
+
+func signedSummary(t *testing.T, key *ecdsa.PrivateKey, requestID common.Hash, lower, upper uint32, hashes []common.Hash) []byte {
+	summary := mailserver.NewResponseSummary(requestID, lower, upper, hashes)
+	require.NoError(t, summary.Sign(key))
+	data, err := mailserver.EncodeResponseSummary(summary)
+	require.NoError(t, err)
+	return data
+}
+
+func complete(v *Verifier, events *envelopeEvents, requestID common.Hash, summary []byte) {
+	if summary != nil {
+		v.summaries.add(requestID, summary)
+	}
+	events.feed.Send(whisper.EnvelopeEvent{
+		Event: whisper.EventMailServerRequestCompleted,
+		Hash:  requestID,
+		Data:  &whisper.MailServerResponse{},
+	})
+}
+
+func receive(events *envelopeEvents, hashes ...common.Hash) {
+	for _, hash := range hashes {
+		events.feed.Send(whisper.EnvelopeEvent{Event: whisper.EventEnvelopeAvailable, Hash: hash})
+	}
+}
+
+func waitResult(t *testing.T, results chan Result) Result {
+	select {
+	case r := <-results:
+		return r
+	case <-time.After(time.Second):
+		require.FailNow(t, "timed out waiting for the result")
+	}
+	return Result{}
+}
+
+func TestVerifierStatuses(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	hashes := []common.Hash{{1}, {2}, {3}}
+
+	for _, tc := range []struct {
+		name     string
+		received []common.Hash
+		summary  func(requestID common.Hash) []byte
+		expected Result
+	}{
+		{
+			name:     "verified",
+			received: hashes,
+			summary: func(requestID common.Hash) []byte {
+				return signedSummary(t, key, requestID, 10, 20, hashes)
+			},
+			expected: Result{Status: Verified, Count: 3},
+		},
+		{
+			name:     "truncated",
+			received: hashes[:1],
+			summary: func(requestID common.Hash) []byte {
+				return signedSummary(t, key, requestID, 10, 20, hashes)
+			},
+			expected: Result{Status: Truncated, Count: 3, MissingEnvelopes: 2},
+		},
+		{
+			name:     "signed by another key",
+			received: hashes,
+			summary: func(requestID common.Hash) []byte {
+				return signedSummary(t, other, requestID, 10, 20, hashes)
+			},
+			expected: Result{Status: Tampered, Count: 3},
+		},
+		{
+			name:     "other range",
+			received: hashes,
+			summary: func(requestID common.Hash) []byte {
+				return signedSummary(t, key, requestID, 10, 15, hashes)
+			},
+			expected: Result{Status: Tampered, Count: 3},
+		},
+		{
+			name: "invalid",
+			summary: func(common.Hash) []byte {
+				return []byte{0xff}
+			},
+			expected: Result{Status: Tampered},
+		},
+		{
+			name: "missing",
+			summary: func(common.Hash) []byte {
+				return nil
+			},
+			expected: Result{Status: Missing},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			v, events, results := setupVerifier(t)
+			defer v.Stop()
+
+			requestID := common.Hash{0xaa}
+			v.Watch(requestID, &key.PublicKey, 10, 20)
+			receive(events, tc.received...)
+			complete(v, events, requestID, tc.summary(requestID))
+
+			tc.expected.RequestID = requestID
+			require.Equal(t, tc.expected, waitResult(t, results))
+		})
+	}
+}
+
+func TestVerifierIgnoresUnwatchedRequests(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	v, events, results := setupVerifier(t)
+	defer v.Stop()
+
+	complete(v, events, common.Hash{0xbb}, []byte{1})
+	v.Watch(common.Hash{0xaa}, &key.PublicKey, 10, 20)
+	events.feed.Send(whisper.EnvelopeEvent{Event: whisper.EventMailServerRequestExpired, Hash: common.Hash{0xaa}})
+
+	select {
+	case r := <-results:
+		require.FailNow(t, "unexpected result", "%v", r)
+	case <-time.After(2 * checkInterval):
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	require.Empty(t, v.requests)
+	require.Empty(t, v.received)
+	require.Empty(t, v.summaries.expected)
+	require.Empty(t, v.summaries.received)
+}
@@ -0,0 +1,122 @@
+package shhext
+
+import (
+	"time"
+
+	"github.com/status-im/status-go/services/scheduler"
+	"github.com/status-im/status-go/services/shhext/inbox"
+	"github.com/status-im/status-go/services/shhext/segmentation"
+)
+
+// jobsPrefix prefixes the names of the jobs of the service in the scheduler.
+const jobsPrefix = "shhext/"
+
+// SetScheduler sets the scheduler running the periodic jobs of the service,
+// which are added when the service starts.
+func (s *Service) SetScheduler(sched *scheduler.Scheduler) {
+	s.scheduler = sched
+}
+
+// jobs returns the periodic jobs of the service. The jobs depending on the
+// chat database do nothing until an account is logged in.
+func (s *Service) jobs() []scheduler.Job {
+	return []scheduler.Job{
+		{
+			Name:     jobsPrefix + "rotateBundle",
+			Interval: time.Hour,
+			Jitter:   10 * time.Minute,
+			Run:      s.rotateBundle,
+		},
+		{
+			Name:     jobsPrefix + "pruneProcessedMessages",
+			Interval: time.Hour,
+			Jitter:   10 * time.Minute,
+			Run: func() error {
+				if s.protocol == nil {
+					return nil
+				}
+				pruned, err := s.protocol.PruneProcessed()
+				logger.Debug("pruned processed messages", "count", pruned)
+				return err
+			},
+		},
+		{
+			Name:     jobsPrefix + "prunePendingMessages",
+			Interval: time.Hour,
+			Jitter:   10 * time.Minute,
+			Run: func() error {
+				pruned, err := s.inbox.Prune()
+				if err == inbox.ErrStoreNotSet {
+					return nil
+				}
+				logger.Debug("pruned pending messages", "count", pruned)
+				return err
+			},
+		},
+		{
+			Name:     jobsPrefix + "pruneSegments",
+			Interval: time.Hour,
+			Jitter:   10 * time.Minute,
+			Run: func() error {
+				pruned, err := s.segments.Prune()
+				if err == segmentation.ErrStoreNotSet {
+					return nil
+				}
+				logger.Debug("pruned incomplete segments", "count", pruned)
+				return err
+			},
+		},
+		{
+			Name:     jobsPrefix + "pruneRequestsCache",
+			Interval: 24 * time.Hour,
+			Jitter:   time.Hour,
+			Run: func() error {
+				return s.requestsCache.Prune(s.w.GetCurrentTime())
+			},
+		},
+		{
+			Name:        jobsPrefix + "optimizeHistoryIndex",
+			Interval:    24 * time.Hour,
+			Jitter:      time.Hour,
+			Constraints: scheduler.Constraints{RequiresCharging: true},
+			Run: func() error {
+				if s.history == nil {
+					return nil
+				}
+				return s.history.Optimize()
+			},
+		},
+	}
+}
+
+// rotateBundle replaces the signed pre-key of the bundle of the selected account
+// once it is older than the bundle refresh interval, even if no message is sent.
+func (s *Service) rotateBundle() error {
+	if s.protocol == nil {
+		return nil
+	}
+	privateKey, err := s.selectedIdentity()
+	if err != nil {
+		return nil
+	}
+	_, err = s.protocol.GetBundle(privateKey)
+	return err
+}
+
+func (s *Service) startJobs() error {
+	if s.scheduler == nil {
+		return nil
+	}
+	for _, job := range s.jobs() {
+		if err := s.scheduler.Add(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) stopJobs() {
+	if s.scheduler != nil {
+		s.scheduler.Remove(jobsPrefix)
+	}
+}
@@ -0,0 +1,45 @@
+package shhext
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/services/shhext/chat"
+	"github.com/status-im/status-go/services/shhext/latency"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// observeLatency samples the delivery latency of a message whose payload carries the
+// time it was sent, when it's processed. Our own messages are ignored.
+func (s *Service) observeLatency(msg *whisper.Message, payload []byte, receivedAt time.Time) {
+	if s.latency == nil {
+		return
+	}
+	sentAt, ok := chat.SentTime(payload)
+	if !ok {
+		return
+	}
+	if privateKey, err := s.selectedIdentity(); err == nil && bytes.Equal(msg.Sig, crypto.FromECDSAPub(&privateKey.PublicKey)) {
+		return
+	}
+	s.latency.Observe(sentAt, receivedAt)
+}
+
+// SetNetworkType sets the network type of the device, like "wifi" or "cellular", by which
+// the latencies of the received messages are aggregated and the outgoing envelopes are limited.
+func (s *Service) SetNetworkType(networkType string) {
+	if s.latency != nil {
+		s.latency.SetNetworkType(networkType)
+	}
+	s.outgoing.SetBudgets(s.outgoingBudgets(networkType))
+}
+
+// LatencyStats returns the delivery latencies of the received messages by network type,
+// or nil if they are not measured.
+func (s *Service) LatencyStats() []latency.Stats {
+	if s.latency == nil {
+		return nil
+	}
+	return s.latency.Stats()
+}
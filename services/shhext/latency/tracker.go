@@ -0,0 +1,135 @@
+package latency
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// DefaultSamples is the number of latest samples kept for each network type.
+	DefaultSamples = 1000
+	// MaxLatency is the maximum latency sampled. Messages delivered later were fetched
+	// from mail servers, or sent by a device whose clock is off.
+	MaxLatency = 5 * time.Minute
+	// UnknownNetwork is the network type until the client reports it.
+	UnknownNetwork = "unknown"
+)
+
+// Stats are the latencies of the messages received on a network type, in milliseconds.
+type Stats struct {
+	NetworkType string `json:"networkType"`
+	// Count is the number of samples of the percentiles.
+	Count int `json:"count"`
+	// Discarded is the number of messages whose latency was negative or larger than
+	// MaxLatency, which are not sampled.
+	Discarded int64 `json:"discarded"`
+	Min       int64 `json:"min"`
+	Max       int64 `json:"max"`
+	Mean      int64 `json:"mean"`
+	P50       int64 `json:"p50"`
+	P90       int64 `json:"p90"`
+	P99       int64 `json:"p99"`
+}
+
+// window holds the latest samples of a network type.
+type window struct {
+	samples   []int64
+	next      int
+	discarded int64
+}
+
+func (w *window) add(sample int64, size int) {
+	if len(w.samples) < size {
+		w.samples = append(w.samples, sample)
+		return
+	}
+	w.samples[w.next] = sample
+	w.next = (w.next + 1) % size
+}
+
+func (w *window) stats(networkType string) Stats {
+	stats := Stats{NetworkType: networkType, Count: len(w.samples), Discarded: w.discarded}
+	if len(w.samples) == 0 {
+		return stats
+	}
+	sorted := make([]int64, len(w.samples))
+	copy(sorted, w.samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var sum int64
+	for _, sample := range sorted {
+		sum += sample
+	}
+	percentile := func(p float64) int64 {
+		return sorted[int(p*float64(len(sorted)-1)+0.5)]
+	}
+	stats.Min = sorted[0]
+	stats.Max = sorted[len(sorted)-1]
+	stats.Mean = sum / int64(len(sorted))
+	stats.P50 = percentile(0.5)
+	stats.P90 = percentile(0.9)
+	stats.P99 = percentile(0.99)
+	return stats
+}
+
+// Tracker aggregates the end-to-end latencies of the received messages, from the time
+// their sender sent them, by the network type of the device when they were received.
+// Only the latest samples are kept, in memory.
+type Tracker struct {
+	size int
+
+	mu          sync.Mutex
+	networkType string
+	windows     map[string]*window
+}
+
+// NewTracker returns a new Tracker keeping size samples for each network type.
+func NewTracker(size int) *Tracker {
+	return &Tracker{
+		size:        size,
+		networkType: UnknownNetwork,
+		windows:     make(map[string]*window),
+	}
+}
+
+// SetNetworkType sets the network type of the device, like "wifi" or "cellular".
+func (t *Tracker) SetNetworkType(networkType string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.networkType = networkType
+}
+
+// Observe samples the latency of a message sent at sentAt and received at receivedAt. It
+// returns false if the latency is discarded.
+func (t *Tracker) Observe(sentAt, receivedAt time.Time) bool {
+	latency := receivedAt.Sub(sentAt)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w, ok := t.windows[t.networkType]
+	if !ok {
+		w = &window{}
+		t.windows[t.networkType] = w
+	}
+	if latency < 0 || latency > MaxLatency {
+		w.discarded++
+		return false
+	}
+	w.add(int64(latency/time.Millisecond), t.size)
+	metrics.GetOrRegisterTimer("shhext/latency/"+t.networkType, nil).Update(latency)
+	return true
+}
+
+// Stats returns the latencies of the network types messages were received on, by type.
+func (t *Tracker) Stats() []Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	result := make([]Stats, 0, len(t.windows))
+	for networkType, w := range t.windows {
+		result = append(result, w.stats(networkType))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].NetworkType < result[j].NetworkType })
+	return result
+}
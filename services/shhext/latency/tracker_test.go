@@ -0,0 +1,39 @@
+package latency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker(100)
+	now := time.Now()
+	for i := 1; i <= 100; i++ {
+		require.True(t, tracker.Observe(now.Add(-time.Duration(i)*time.Millisecond), now))
+	}
+	require.False(t, tracker.Observe(now.Add(time.Second), now), "Negative latencies are discarded")
+	require.False(t, tracker.Observe(now.Add(-time.Hour), now), "Historic messages are discarded")
+
+	tracker.SetNetworkType("cellular")
+	require.True(t, tracker.Observe(now.Add(-2*time.Second), now))
+
+	stats := tracker.Stats()
+	require.Equal(t, []Stats{
+		{NetworkType: "cellular", Count: 1, Min: 2000, Max: 2000, Mean: 2000, P50: 2000, P90: 2000, P99: 2000},
+		{NetworkType: UnknownNetwork, Count: 100, Discarded: 2, Min: 1, Max: 100, Mean: 50, P50: 51, P90: 90, P99: 99},
+	}, stats)
+}
+
+func TestTrackerWindow(t *testing.T) {
+	tracker := NewTracker(2)
+	now := time.Now()
+	for _, latency := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		tracker.Observe(now.Add(-latency), now)
+	}
+	stats := tracker.Stats()
+	require.Equal(t, 2, stats[0].Count)
+	require.Equal(t, int64(2000), stats[0].Min, "Only the latest samples are kept")
+	require.Equal(t, int64(3000), stats[0].Max)
+}
@@ -0,0 +1,249 @@
+package shhext
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/services/shhext/chat"
+	"github.com/status-im/status-go/services/shhext/lookup"
+	"github.com/status-im/status-go/services/shhext/ratelimit"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// bundleLookupInterval is how often the lookup filters are polled.
+const bundleLookupInterval = 500 * time.Millisecond
+
+// lookupTransport posts bundle lookup messages through whisper.
+type lookupTransport struct {
+	service *Service
+}
+
+func (t lookupTransport) Broadcast(payload []byte) error {
+	symKeyID, err := t.service.w.AddSymKeyFromPassword(lookup.TopicName)
+	if err != nil {
+		return err
+	}
+	msg := lookupMessage(payload)
+	msg.SymKeyID = symKeyID
+	return t.post(msg)
+}
+
+func (t lookupTransport) Send(payload []byte, recipient *ecdsa.PublicKey) error {
+	msg := lookupMessage(payload)
+	msg.PublicKey = crypto.FromECDSAPub(recipient)
+	return t.post(msg)
+}
+
+func (t lookupTransport) post(msg whisper.NewMessage) error {
+	if err := t.service.outgoing.Wait(context.Background(), ratelimit.ClassBundles); err != nil {
+		return err
+	}
+	_, err := whisper.NewPublicWhisperAPI(t.service.w).Post(context.Background(), msg)
+	return err
+}
+
+func lookupMessage(payload []byte) whisper.NewMessage {
+	return whisper.NewMessage{
+		TTL:       10,
+		Topic:     lookup.Topic,
+		Payload:   payload,
+		PowTarget: 0.002,
+		PowTime:   1,
+	}
+}
+
+// lookupProvider answers the lookups of the selected account.
+type lookupProvider struct {
+	service *Service
+}
+
+func (p lookupProvider) Bundle(identity *ecdsa.PublicKey) (*chat.Bundle, error) {
+	if p.service.protocol == nil {
+		return nil, nil
+	}
+	privateKey, err := p.service.selectedIdentity()
+	if err != nil {
+		return nil, nil
+	}
+	if crypto.PubkeyToAddress(privateKey.PublicKey) != crypto.PubkeyToAddress(*identity) {
+		return nil, nil
+	}
+	return p.service.protocol.GetBundle(privateKey)
+}
+
+// bundleLookups delivers the messages received on the lookup topic to the resolver.
+type bundleLookups struct {
+	w        *whisper.Whisper
+	resolver *lookup.Resolver
+	nodeKey  *ecdsa.PrivateKey
+
+	mu      sync.Mutex
+	filters []string
+	wg      sync.WaitGroup
+	quit    chan struct{}
+}
+
+// Start installs the filters of the requests sent to the whole network, of the
+// responses and, if nodeKey is not nil, of the requests sent to this node.
+func (l *bundleLookups) Start(nodeKey *ecdsa.PrivateKey) error {
+	l.nodeKey = nodeKey
+	if err := l.subscribe(); err != nil {
+		return err
+	}
+
+	l.quit = make(chan struct{})
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		ticker := time.NewTicker(bundleLookupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.quit:
+				return
+			case <-ticker.C:
+				l.poll()
+			}
+		}
+	}()
+	return nil
+}
+
+// Repair installs again the filters if any of them was removed from Whisper.
+// It returns true if the filters were installed again.
+func (l *bundleLookups) Repair() (bool, error) {
+	l.mu.Lock()
+	missing := false
+	for _, id := range l.filters {
+		if l.w.GetFilter(id) == nil {
+			missing = true
+		}
+	}
+	if !missing {
+		l.mu.Unlock()
+		return false, nil
+	}
+	for _, id := range l.filters {
+		if l.w.GetFilter(id) != nil {
+			l.w.Unsubscribe(id) // nolint: errcheck
+		}
+	}
+	l.filters = nil
+	l.mu.Unlock()
+	return true, l.subscribe()
+}
+
+func (l *bundleLookups) subscribe() error {
+	symKeyID, err := l.w.AddSymKeyFromPassword(lookup.TopicName)
+	if err != nil {
+		return err
+	}
+	symKey, err := l.w.GetSymKey(symKeyID)
+	if err != nil {
+		return err
+	}
+	filters := []*whisper.Filter{
+		{KeySym: symKey},
+		{KeyAsym: l.resolver.ReplyKey()},
+	}
+	if l.nodeKey != nil {
+		filters = append(filters, &whisper.Filter{KeyAsym: l.nodeKey})
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, f := range filters {
+		f.Topics = [][]byte{lookup.Topic[:]}
+		f.Messages = make(map[common.Hash]*whisper.ReceivedMessage)
+		id, err := l.w.Subscribe(f)
+		if err != nil {
+			l.unsubscribeLocked()
+			return err
+		}
+		l.filters = append(l.filters, id)
+	}
+	return nil
+}
+
+// Stop uninstalls the filters.
+func (l *bundleLookups) Stop() {
+	if l.quit == nil {
+		return
+	}
+	close(l.quit)
+	l.wg.Wait()
+	l.unsubscribe()
+}
+
+func (l *bundleLookups) poll() {
+	l.mu.Lock()
+	filters := l.filters
+	l.mu.Unlock()
+	for _, id := range filters {
+		f := l.w.GetFilter(id)
+		if f == nil {
+			continue
+		}
+		for _, msg := range f.Retrieve() {
+			if err := l.resolver.Handle(msg.Payload); err != nil {
+				logger.Debug("failed to handle bundle lookup message", "hash", msg.EnvelopeHash, "err", err)
+			}
+		}
+	}
+}
+
+func (l *bundleLookups) unsubscribe() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.unsubscribeLocked()
+}
+
+func (l *bundleLookups) unsubscribeLocked() {
+	for _, id := range l.filters {
+		if err := l.w.Unsubscribe(id); err != nil {
+			logger.Error("failed to remove bundle lookup filter", "id", id, "err", err)
+		}
+	}
+	l.filters = nil
+}
+
+// startBundleLookups answers and sends bundle lookups if PFS is enabled or
+// if the node is a bundle directory.
+func (s *Service) startBundleLookups(nodeKey *ecdsa.PrivateKey) error {
+	if !s.pfsEnabled && !s.config.BundleDirectory {
+		return nil
+	}
+	config := lookup.DefaultConfig()
+	for _, node := range s.config.BundleDirectoryNodes {
+		config.DirectoryNodes = append(config.DirectoryNodes, node.Pubkey())
+	}
+	config.Directory = s.config.BundleDirectory
+	if s.config.MaxBundleLookups > 0 {
+		config.MaxLookups = s.config.MaxBundleLookups
+	}
+	resolver, err := lookup.NewResolver(config, lookupTransport{service: s}, lookupProvider{service: s})
+	if err != nil {
+		return err
+	}
+	if !config.Directory {
+		nodeKey = nil
+	}
+	s.bundleLookups = &bundleLookups{w: s.w, resolver: resolver}
+	return s.bundleLookups.Start(nodeKey)
+}
+
+// HasBundle looks up the bundle of an identity, and returns true if one was found.
+func (s *Service) HasBundle(ctx context.Context, identity *ecdsa.PublicKey) (bool, error) {
+	if !s.pfsEnabled || s.bundleLookups == nil {
+		return false, ErrPFSNotEnabled
+	}
+	_, err := s.bundleLookups.resolver.Lookup(ctx, identity)
+	if err == lookup.ErrBundleNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,71 @@
+package lookup
+
+import (
+	"container/list"
+	"time"
+
+	"github.com/status-im/status-go/services/shhext/chat"
+)
+
+type cacheEntry struct {
+	identity string
+	bundle   *chat.Bundle
+	expires  time.Time
+}
+
+// cache keeps the most recent bundles of a limited number of identities.
+// It is not safe for concurrent use.
+type cache struct {
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+func newCache(size int, ttl time.Duration) *cache {
+	return &cache{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns the bundle of an identity if it hasn't expired.
+func (c *cache) Get(identity string, now time.Time) *chat.Bundle {
+	element, ok := c.entries[identity]
+	if !ok {
+		return nil
+	}
+	entry := element.Value.(*cacheEntry)
+	if now.After(entry.expires) {
+		c.order.Remove(element)
+		delete(c.entries, identity)
+		return nil
+	}
+	c.order.MoveToFront(element)
+	return entry.bundle
+}
+
+// Put keeps a bundle unless a more recent one is cached.
+func (c *cache) Put(identity string, bundle *chat.Bundle, now time.Time) {
+	if element, ok := c.entries[identity]; ok {
+		entry := element.Value.(*cacheEntry)
+		if entry.bundle.GetTimestamp() > bundle.GetTimestamp() {
+			return
+		}
+		entry.bundle = bundle
+		entry.expires = now.Add(c.ttl)
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[identity] = c.order.PushFront(&cacheEntry{
+		identity: identity,
+		bundle:   bundle,
+		expires:  now.Add(c.ttl),
+	})
+	if c.order.Len() > c.size {
+		oldest := c.order.Remove(c.order.Back()).(*cacheEntry)
+		delete(c.entries, oldest.identity)
+	}
+}
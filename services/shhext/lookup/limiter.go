@@ -0,0 +1,30 @@
+package lookup
+
+import "time"
+
+// limiter allows up to max events in any window of time. It is not safe for
+// concurrent use.
+type limiter struct {
+	max    int
+	window time.Duration
+	events []time.Time
+}
+
+func newLimiter(max int, window time.Duration) *limiter {
+	return &limiter{max: max, window: window}
+}
+
+// Allow records an event and returns true if the limit is not reached.
+func (l *limiter) Allow(now time.Time) bool {
+	cutoff := now.Add(-l.window)
+	i := 0
+	for i < len(l.events) && !l.events[i].After(cutoff) {
+		i++
+	}
+	l.events = l.events[i:]
+	if len(l.events) >= l.max {
+		return false
+	}
+	l.events = append(l.events, now)
+	return true
+}
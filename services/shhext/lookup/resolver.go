@@ -0,0 +1,380 @@
+package lookup
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/status-im/status-go/services/shhext/chat"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// TopicName is the name of the lookup topic. It is also the password of the
+// symmetric key of the requests sent to the whole network.
+const TopicName = "contact-discovery-lookup"
+
+// Topic is the whisper topic of bundle lookups.
+var Topic = whisper.BytesToTopic(crypto.Keccak256([]byte(TopicName)))
+
+const (
+	// DefaultTimeout is how long a lookup waits for a response.
+	DefaultTimeout = 10 * time.Second
+	// DefaultCacheTTL is how long a bundle is returned without querying the network again.
+	DefaultCacheTTL = 24 * time.Hour
+	// DefaultCacheSize is the number of cached bundles.
+	DefaultCacheSize = 1000
+	// DefaultLookupInterval is the minimum delay between two lookups of the same key.
+	DefaultLookupInterval = time.Minute
+	// DefaultMaxLookups is the number of lookups allowed per minute.
+	DefaultMaxLookups = 10
+	// DefaultMaxResponses is the number of requests answered per minute.
+	DefaultMaxResponses = 60
+
+	// MaxPayloadSize is the size above which lookup messages are dropped.
+	MaxPayloadSize = 16 * 1024
+
+	requestIDLength = 16
+)
+
+var (
+	// ErrRateLimited is returned if a key was looked up recently, or if there
+	// were too many lookups in the last minute.
+	ErrRateLimited = errors.New("too many bundle lookups")
+	// ErrBundleNotFound is returned if no valid bundle was received before the timeout.
+	ErrBundleNotFound = errors.New("bundle not found")
+	// ErrNoDirectoryNodes is returned when publishing a bundle without directory nodes.
+	ErrNoDirectoryNodes = errors.New("no directory nodes configured")
+	// ErrInvalidBundle is returned if a bundle is not signed by the identity it was requested for.
+	ErrInvalidBundle = errors.New("invalid bundle")
+)
+
+// Config of the resolver.
+type Config struct {
+	// DirectoryNodes are queried instead of the whole network if not empty.
+	DirectoryNodes []*ecdsa.PublicKey
+	// Directory is true if the node keeps the bundles published by other nodes
+	// and returns them in responses.
+	Directory bool
+
+	Timeout        time.Duration
+	CacheTTL       time.Duration
+	CacheSize      int
+	LookupInterval time.Duration
+	MaxLookups     int
+	MaxResponses   int
+}
+
+// DefaultConfig returns the default configuration.
+func DefaultConfig() Config {
+	return Config{
+		Timeout:        DefaultTimeout,
+		CacheTTL:       DefaultCacheTTL,
+		CacheSize:      DefaultCacheSize,
+		LookupInterval: DefaultLookupInterval,
+		MaxLookups:     DefaultMaxLookups,
+		MaxResponses:   DefaultMaxResponses,
+	}
+}
+
+// Transport posts lookup messages on Topic.
+type Transport interface {
+	// Broadcast posts a payload encrypted with the symmetric key of TopicName.
+	Broadcast(payload []byte) error
+	// Send posts a payload encrypted with the public key of the recipient.
+	Send(payload []byte, recipient *ecdsa.PublicKey) error
+}
+
+// BundleProvider returns the bundles of the identities owned by the node.
+type BundleProvider interface {
+	// Bundle returns nil if the identity is not owned by the node.
+	Bundle(identity *ecdsa.PublicKey) (*chat.Bundle, error)
+}
+
+const (
+	requestMessage  = "request"
+	responseMessage = "response"
+	publishMessage  = "publish"
+)
+
+type message struct {
+	Type     string        `json:"type"`
+	ID       hexutil.Bytes `json:"id,omitempty"`
+	Identity hexutil.Bytes `json:"identity,omitempty"`
+	ReplyTo  hexutil.Bytes `json:"replyTo,omitempty"`
+	Bundle   string        `json:"bundle,omitempty"`
+}
+
+type pendingLookup struct {
+	identity []byte
+	result   chan *chat.Bundle
+}
+
+// Resolver looks up the bundles of public keys over whisper, and answers
+// the lookups of the bundles it knows.
+type Resolver struct {
+	config    Config
+	transport Transport
+	provider  BundleProvider
+	replyKey  *ecdsa.PrivateKey
+
+	mu         sync.Mutex
+	cache      *cache
+	lookups    *limiter
+	responses  *limiter
+	lastLookup map[string]time.Time
+	pending    map[string]*pendingLookup
+}
+
+// NewResolver returns a new Resolver. Responses are encrypted with a key
+// generated for the lifetime of the resolver, so that they can't be linked
+// to the identity of the node.
+func NewResolver(config Config, transport Transport, provider BundleProvider) (*Resolver, error) {
+	replyKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return &Resolver{
+		config:     config,
+		transport:  transport,
+		provider:   provider,
+		replyKey:   replyKey,
+		cache:      newCache(config.CacheSize, config.CacheTTL),
+		lookups:    newLimiter(config.MaxLookups, time.Minute),
+		responses:  newLimiter(config.MaxResponses, time.Minute),
+		lastLookup: make(map[string]time.Time),
+		pending:    make(map[string]*pendingLookup),
+	}, nil
+}
+
+// ReplyKey returns the private key of the responses.
+func (r *Resolver) ReplyKey() *ecdsa.PrivateKey {
+	return r.replyKey
+}
+
+// Lookup returns the bundle of an identity, from the cache or from the first
+// valid response received before the timeout.
+func (r *Resolver) Lookup(ctx context.Context, identity *ecdsa.PublicKey) (*chat.Bundle, error) {
+	identityBytes := crypto.CompressPubkey(identity)
+	key := string(identityBytes)
+	now := time.Now()
+
+	r.mu.Lock()
+	if bundle := r.cache.Get(key, now); bundle != nil {
+		r.mu.Unlock()
+		return bundle, nil
+	}
+	if last, ok := r.lastLookup[key]; ok && now.Sub(last) < r.config.LookupInterval {
+		r.mu.Unlock()
+		return nil, ErrRateLimited
+	}
+	if !r.lookups.Allow(now) {
+		r.mu.Unlock()
+		return nil, ErrRateLimited
+	}
+	r.pruneLastLookups(now)
+	r.lastLookup[key] = now
+
+	id := make([]byte, requestIDLength)
+	if _, err := rand.Read(id); err != nil {
+		r.mu.Unlock()
+		return nil, err
+	}
+	pending := &pendingLookup{identity: identityBytes, result: make(chan *chat.Bundle, 1)}
+	r.pending[string(id)] = pending
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, string(id))
+		r.mu.Unlock()
+	}()
+
+	payload, err := json.Marshal(message{
+		Type:     requestMessage,
+		ID:       id,
+		Identity: identityBytes,
+		ReplyTo:  crypto.FromECDSAPub(&r.replyKey.PublicKey),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := r.send(payload); err != nil {
+		return nil, err
+	}
+
+	timeout := time.NewTimer(r.config.Timeout)
+	defer timeout.Stop()
+	select {
+	case bundle := <-pending.result:
+		return bundle, nil
+	case <-timeout.C:
+		return nil, ErrBundleNotFound
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Publish sends a bundle to the directory nodes.
+func (r *Resolver) Publish(bundle *chat.Bundle) error {
+	if len(r.config.DirectoryNodes) == 0 {
+		return ErrNoDirectoryNodes
+	}
+	encoded, err := bundle.ToBase64()
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(message{Type: publishMessage, Bundle: encoded})
+	if err != nil {
+		return err
+	}
+	return r.send(payload)
+}
+
+// Handle processes a lookup message received on Topic.
+func (r *Resolver) Handle(payload []byte) error {
+	if len(payload) > MaxPayloadSize {
+		return errors.New("lookup message too large")
+	}
+	var msg message
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return err
+	}
+	switch msg.Type {
+	case requestMessage:
+		return r.handleRequest(msg)
+	case responseMessage:
+		return r.handleResponse(msg)
+	case publishMessage:
+		return r.handlePublish(msg)
+	}
+	return errors.New("unknown lookup message type")
+}
+
+func (r *Resolver) handleRequest(msg message) error {
+	identity, err := crypto.DecompressPubkey(msg.Identity)
+	if err != nil {
+		return err
+	}
+	replyTo, err := crypto.UnmarshalPubkey(msg.ReplyTo)
+	if err != nil {
+		return err
+	}
+
+	var bundle *chat.Bundle
+	if r.provider != nil {
+		bundle, err = r.provider.Bundle(identity)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	if bundle == nil && r.config.Directory {
+		bundle = r.cache.Get(string(msg.Identity), time.Now())
+	}
+	if bundle == nil {
+		r.mu.Unlock()
+		return nil
+	}
+	allowed := r.responses.Allow(time.Now())
+	r.mu.Unlock()
+	if !allowed {
+		log.Debug("bundle lookup dropped by rate limit", "identity", msg.Identity)
+		return nil
+	}
+
+	encoded, err := bundle.ToBase64()
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(message{Type: responseMessage, ID: msg.ID, Bundle: encoded})
+	if err != nil {
+		return err
+	}
+	return r.transport.Send(payload, replyTo)
+}
+
+func (r *Resolver) handleResponse(msg message) error {
+	r.mu.Lock()
+	pending, ok := r.pending[string(msg.ID)]
+	r.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	bundle, err := chat.FromBase64(msg.Bundle)
+	if err != nil {
+		return err
+	}
+	if err := validateBundle(bundle, pending.identity); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.cache.Put(string(pending.identity), bundle, time.Now())
+	r.mu.Unlock()
+
+	select {
+	case pending.result <- bundle:
+	default:
+	}
+	return nil
+}
+
+func (r *Resolver) handlePublish(msg message) error {
+	if !r.config.Directory {
+		return nil
+	}
+	bundle, err := chat.FromBase64(msg.Bundle)
+	if err != nil {
+		return err
+	}
+	if err := validateBundle(bundle, bundle.GetIdentity()); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.cache.Put(string(bundle.GetIdentity()), bundle, time.Now())
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Resolver) send(payload []byte) error {
+	if len(r.config.DirectoryNodes) == 0 {
+		return r.transport.Broadcast(payload)
+	}
+	for _, node := range r.config.DirectoryNodes {
+		if err := r.transport.Send(payload, node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneLastLookups must be called with the lock held.
+func (r *Resolver) pruneLastLookups(now time.Time) {
+	for key, last := range r.lastLookup {
+		if now.Sub(last) >= r.config.LookupInterval {
+			delete(r.lastLookup, key)
+		}
+	}
+}
+
+// validateBundle checks that a bundle has installations and is signed by identity.
+func validateBundle(bundle *chat.Bundle, identity []byte) error {
+	if len(bundle.GetSignedPreKeys()) == 0 || !bytes.Equal(bundle.GetIdentity(), identity) {
+		return ErrInvalidBundle
+	}
+	if err := chat.VerifyBundle(bundle); err != nil {
+		return ErrInvalidBundle
+	}
+	return nil
+}
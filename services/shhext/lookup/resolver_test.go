@@ -0,0 +1,185 @@
+package lookup
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/services/shhext/chat"
+	"github.com/stretchr/testify/require"
+)
+
+// network delivers lookup messages between resolvers, with the node key used
+// by directory nodes.
+type network struct {
+	resolvers map[*Resolver]*ecdsa.PrivateKey
+	posted    int
+}
+
+type networkTransport struct {
+	network *network
+	from    *Resolver
+}
+
+func (t *networkTransport) Broadcast(payload []byte) error {
+	t.network.posted++
+	for resolver := range t.network.resolvers {
+		if resolver != t.from {
+			_ = resolver.Handle(payload)
+		}
+	}
+	return nil
+}
+
+func (t *networkTransport) Send(payload []byte, recipient *ecdsa.PublicKey) error {
+	t.network.posted++
+	for resolver, nodeKey := range t.network.resolvers {
+		if sameKey(&resolver.ReplyKey().PublicKey, recipient) || sameKey(&nodeKey.PublicKey, recipient) {
+			return resolver.Handle(payload)
+		}
+	}
+	return nil
+}
+
+func sameKey(a, b *ecdsa.PublicKey) bool {
+	return crypto.PubkeyToAddress(*a) == crypto.PubkeyToAddress(*b)
+}
+
+type bundleProvider struct {
+	key    *ecdsa.PrivateKey
+	bundle *chat.Bundle
+}
+
+func (p bundleProvider) Bundle(identity *ecdsa.PublicKey) (*chat.Bundle, error) {
+	if p.key == nil || !sameKey(&p.key.PublicKey, identity) {
+		return nil, nil
+	}
+	return p.bundle, nil
+}
+
+func newBundle(t *testing.T, key *ecdsa.PrivateKey) *chat.Bundle {
+	container, err := chat.NewBundleContainer(key, "installation")
+	require.NoError(t, err)
+	require.NoError(t, chat.SignBundle(key, container))
+	return container.GetBundle()
+}
+
+func (n *network) add(t *testing.T, config Config, provider BundleProvider) (*Resolver, *ecdsa.PrivateKey) {
+	transport := &networkTransport{network: n}
+	resolver, err := NewResolver(config, transport, provider)
+	require.NoError(t, err)
+	transport.from = resolver
+	nodeKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	n.resolvers[resolver] = nodeKey
+	return resolver, nodeKey
+}
+
+func testConfig() Config {
+	config := DefaultConfig()
+	config.Timeout = 50 * time.Millisecond
+	return config
+}
+
+func TestLookup(t *testing.T) {
+	n := &network{resolvers: make(map[*Resolver]*ecdsa.PrivateKey)}
+	aliceKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	bundle := newBundle(t, aliceKey)
+	n.add(t, testConfig(), bundleProvider{key: aliceKey, bundle: bundle})
+	bob, _ := n.add(t, testConfig(), bundleProvider{})
+
+	result, err := bob.Lookup(context.Background(), &aliceKey.PublicKey)
+	require.NoError(t, err)
+	require.Equal(t, bundle.GetSignature(), result.GetSignature())
+	require.Equal(t, 2, n.posted)
+
+	_, err = bob.Lookup(context.Background(), &aliceKey.PublicKey)
+	require.NoError(t, err)
+	require.Equal(t, 2, n.posted, "It returns cached bundles")
+}
+
+func TestLookupRateLimited(t *testing.T) {
+	n := &network{resolvers: make(map[*Resolver]*ecdsa.PrivateKey)}
+	config := testConfig()
+	config.MaxLookups = 2
+	bob, _ := n.add(t, config, nil)
+
+	unknown, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	_, err = bob.Lookup(context.Background(), &unknown.PublicKey)
+	require.Equal(t, ErrBundleNotFound, err)
+	_, err = bob.Lookup(context.Background(), &unknown.PublicKey)
+	require.Equal(t, ErrRateLimited, err, "It doesn't look up the same key again")
+
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	_, err = bob.Lookup(context.Background(), &other.PublicKey)
+	require.Equal(t, ErrBundleNotFound, err)
+	another, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	_, err = bob.Lookup(context.Background(), &another.PublicKey)
+	require.Equal(t, ErrRateLimited, err, "It limits the number of lookups")
+}
+
+func TestLookupRejectsInvalidBundles(t *testing.T) {
+	n := &network{resolvers: make(map[*Resolver]*ecdsa.PrivateKey)}
+	aliceKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	malloryKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	forged := newBundle(t, malloryKey)
+	forged.Identity = crypto.CompressPubkey(&aliceKey.PublicKey)
+	n.add(t, testConfig(), bundleProvider{key: aliceKey, bundle: forged})
+	bob, _ := n.add(t, testConfig(), nil)
+
+	_, err = bob.Lookup(context.Background(), &aliceKey.PublicKey)
+	require.Equal(t, ErrBundleNotFound, err)
+}
+
+func TestLookupFromDirectory(t *testing.T) {
+	n := &network{resolvers: make(map[*Resolver]*ecdsa.PrivateKey)}
+	directoryConfig := testConfig()
+	directoryConfig.Directory = true
+	_, directoryKey := n.add(t, directoryConfig, nil)
+
+	clientConfig := testConfig()
+	clientConfig.DirectoryNodes = []*ecdsa.PublicKey{&directoryKey.PublicKey}
+	aliceKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	bundle := newBundle(t, aliceKey)
+	alice, _ := n.add(t, clientConfig, nil)
+	bob, _ := n.add(t, clientConfig, nil)
+
+	require.NoError(t, alice.Publish(bundle))
+	result, err := bob.Lookup(context.Background(), &aliceKey.PublicKey)
+	require.NoError(t, err)
+	require.Equal(t, bundle.GetSignature(), result.GetSignature())
+}
+
+func TestCache(t *testing.T) {
+	c := newCache(1, time.Minute)
+	now := time.Now()
+	older := &chat.Bundle{Timestamp: 1}
+	newer := &chat.Bundle{Timestamp: 2}
+
+	c.Put("a", newer, now)
+	c.Put("a", older, now)
+	require.Equal(t, newer, c.Get("a", now), "It keeps the most recent bundle")
+	require.Nil(t, c.Get("a", now.Add(2*time.Minute)), "It expires bundles")
+
+	c.Put("a", newer, now)
+	c.Put("b", older, now)
+	require.Nil(t, c.Get("a", now), "It evicts the least recently used bundle")
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(2, time.Minute)
+	now := time.Now()
+	require.True(t, l.Allow(now))
+	require.True(t, l.Allow(now))
+	require.False(t, l.Allow(now))
+	require.True(t, l.Allow(now.Add(time.Minute)))
+}
@@ -140,3 +140,34 @@ func createPeersIterator(level *leveldb.DB) iterator.Iterator {
 func keyWithoutPrefix(key []byte) []byte {
 	return key[1:]
 }
+
+// LoadRanking loads the ranks of mail servers measured by the picker.
+func (c *Cache) LoadRanking() (map[enode.ID]*Rank, error) {
+	iter := c.db.NewIterator(util.BytesPrefix([]byte{byte(db.MailserversRanking)}), nil)
+	defer iter.Release()
+	ranks := map[enode.ID]*Rank{}
+	for iter.Next() {
+		var id enode.ID
+		copy(id[:], keyWithoutPrefix(iter.Key()))
+		rank := new(Rank)
+		if err := json.Unmarshal(iter.Value(), rank); err != nil {
+			return nil, err
+		}
+		ranks[id] = rank
+	}
+	return ranks, iter.Error()
+}
+
+// UpdateRank updates the rank of a single mail server.
+func (c *Cache) UpdateRank(id enode.ID, rank *Rank) error {
+	value, err := json.Marshal(rank)
+	if err != nil {
+		return err
+	}
+	return c.db.Put(db.Key(db.MailserversRanking, id[:]), value, nil)
+}
+
+// DeleteRank deletes the rank of a mail server.
+func (c *Cache) DeleteRank(id enode.ID) error {
+	return c.db.Delete(db.Key(db.MailserversRanking, id[:]), nil)
+}
@@ -49,6 +49,17 @@ func (ps *PeerStore) Get(nodeID enode.ID) *enode.Node {
 	return ps.nodes[nodeID]
 }
 
+// All returns all nodes added to a store.
+func (ps *PeerStore) All() []*enode.Node {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	nodes := make([]*enode.Node, 0, len(ps.nodes))
+	for _, n := range ps.nodes {
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
 // Update updates peers locally.
 func (ps *PeerStore) Update(nodes []*enode.Node) error {
 	ps.mu.Lock()
@@ -0,0 +1,293 @@
+package mailservers
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+const (
+	// DefaultProbeTimeout is how long a probe waits for a mail server to accept a connection.
+	DefaultProbeTimeout = 5 * time.Second
+
+	// rankingWeight is the weight of the last probe in the RTT and the success rate.
+	rankingWeight = 0.3
+	// switchMargin is how much better a mail server must score to replace an active one.
+	switchMargin = 0.2
+	// minRTT avoids infinite scores for mail servers running on the same host.
+	minRTT = time.Millisecond
+)
+
+// Prober measures the round trip time to a mail server.
+type Prober interface {
+	Probe(*enode.Node) (time.Duration, error)
+}
+
+// TCPProber measures the time it takes to open a TCP connection with a mail server.
+type TCPProber struct {
+	Timeout time.Duration
+}
+
+// Probe dials the TCP port of a node.
+func (p TCPProber) Probe(node *enode.Node) (time.Duration, error) {
+	address := net.JoinHostPort(node.IP().String(), strconv.Itoa(node.TCP()))
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", address, p.Timeout)
+	if err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+	_ = conn.Close()
+	return rtt, nil
+}
+
+// Rank is the latency and availability of a mail server, averaged over its probes.
+type Rank struct {
+	Enode       string        `json:"enode"`
+	RTT         time.Duration `json:"rtt"`
+	SuccessRate float64       `json:"successRate"`
+	Probes      int           `json:"probes"`
+	// Failures is the number of consecutive failed probes.
+	Failures  int       `json:"failures"`
+	LastProbe time.Time `json:"lastProbe"`
+	Score     float64   `json:"score"`
+	Active    bool      `json:"active"`
+}
+
+// Available returns true if the last probe succeeded.
+func (r *Rank) Available() bool {
+	return r.Probes > 0 && r.Failures == 0
+}
+
+func (r *Rank) update(rtt time.Duration, err error, now time.Time) {
+	outcome := 0.0
+	if err == nil {
+		outcome = 1
+		r.Failures = 0
+		if r.RTT == 0 {
+			r.RTT = rtt
+		} else {
+			r.RTT = time.Duration((1-rankingWeight)*float64(r.RTT) + rankingWeight*float64(rtt))
+		}
+	} else {
+		r.Failures++
+	}
+	if r.Probes == 0 {
+		r.SuccessRate = outcome
+	} else {
+		r.SuccessRate = (1-rankingWeight)*r.SuccessRate + rankingWeight*outcome
+	}
+	r.Probes++
+	r.LastProbe = now
+
+	rttSeconds := r.RTT
+	if rttSeconds < minRTT {
+		rttSeconds = minRTT
+	}
+	r.Score = r.SuccessRate / rttSeconds.Seconds()
+}
+
+// NewPicker returns pointer to the instance of Picker.
+func NewPicker(ps *PeerStore, cache *Cache, prober Prober, notifee NodesNotifee, size int, interval time.Duration) *Picker {
+	return &Picker{
+		ps:       ps,
+		cache:    cache,
+		prober:   prober,
+		notifee:  notifee,
+		size:     size,
+		interval: interval,
+		ranks:    map[enode.ID]*Rank{},
+		active:   map[enode.ID]struct{}{},
+	}
+}
+
+// Picker periodically probes the selected mail servers, ranks them by RTT and
+// success rate, and notifies the best ones so that they are used instead of
+// slower or unavailable mail servers. Ranks are persisted across restarts.
+type Picker struct {
+	ps      *PeerStore
+	cache   *Cache
+	prober  Prober
+	notifee NodesNotifee
+
+	size     int
+	interval time.Duration
+
+	mu     sync.Mutex
+	ranks  map[enode.ID]*Rank
+	active map[enode.ID]struct{}
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Start loads the persisted ranks and starts probing mail servers.
+func (p *Picker) Start() error {
+	ranks, err := p.cache.LoadRanking()
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.ranks = ranks
+	p.mu.Unlock()
+
+	p.quit = make(chan struct{})
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			p.ProbeAll()
+			select {
+			case <-p.quit:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop closes channel to signal a quit and waits until all goroutines are stoppped.
+func (p *Picker) Stop() {
+	if p.quit == nil {
+		return
+	}
+	select {
+	case <-p.quit:
+		return
+	default:
+	}
+	close(p.quit)
+	p.wg.Wait()
+	p.quit = nil
+}
+
+// ProbeAll probes all mail servers of a peer store, updates their ranks and
+// switches to the best ones if the active mail servers are slower or unavailable.
+func (p *Picker) ProbeAll() {
+	nodes := p.ps.All()
+	rtts := make([]time.Duration, len(nodes))
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i := range nodes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rtts[i], errs[i] = p.prober.Probe(nodes[i])
+		}(i)
+	}
+	wg.Wait()
+
+	now := time.Now()
+	p.mu.Lock()
+	current := nodesToMap(nodes)
+	for id := range p.ranks {
+		if _, exist := current[id]; !exist {
+			delete(p.ranks, id)
+			delete(p.active, id)
+			if err := p.cache.DeleteRank(id); err != nil {
+				log.Error("unable to delete mail server rank", "peer", id, "error", err)
+			}
+		}
+	}
+	for i, n := range nodes {
+		rank, exist := p.ranks[n.ID()]
+		if !exist {
+			rank = &Rank{Enode: n.String()}
+			p.ranks[n.ID()] = rank
+		}
+		rank.update(rtts[i], errs[i], now)
+		if err := p.cache.UpdateRank(n.ID(), rank); err != nil {
+			log.Error("unable to update mail server rank", "peer", n.ID(), "error", err)
+		}
+	}
+	selected := p.selectNodes(current)
+	p.mu.Unlock()
+
+	if len(selected) != 0 && p.notifee != nil {
+		log.Debug("switching mail servers", "nodes", selected)
+		p.notifee.Notify(selected)
+	}
+}
+
+// Ranking returns the ranks of all mail servers, best first.
+func (p *Picker) Ranking() []Rank {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ranking := make([]Rank, 0, len(p.ranks))
+	for _, id := range p.sortedIDs() {
+		rank := *p.ranks[id]
+		_, rank.Active = p.active[id]
+		ranking = append(ranking, rank)
+	}
+	return ranking
+}
+
+// sortedIDs must be called with the lock held.
+func (p *Picker) sortedIDs() []enode.ID {
+	ids := make([]enode.ID, 0, len(p.ranks))
+	for id := range p.ranks {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := p.ranks[ids[i]], p.ranks[ids[j]]
+		if a.Available() != b.Available() {
+			return a.Available()
+		}
+		return a.Score > b.Score
+	})
+	return ids
+}
+
+// selectNodes returns the best available mail servers if they should replace
+// the active ones, and nil otherwise. It must be called with the lock held.
+func (p *Picker) selectNodes(nodes map[enode.ID]*enode.Node) []*enode.Node {
+	var best []enode.ID
+	for _, id := range p.sortedIDs() {
+		if len(best) == p.size || !p.ranks[id].Available() {
+			break
+		}
+		best = append(best, id)
+	}
+	if len(best) == 0 || !p.shouldSwitch(best) {
+		return nil
+	}
+
+	p.active = map[enode.ID]struct{}{}
+	selected := make([]*enode.Node, len(best))
+	for i, id := range best {
+		p.active[id] = struct{}{}
+		selected[i] = nodes[id]
+	}
+	return selected
+}
+
+// shouldSwitch must be called with the lock held.
+func (p *Picker) shouldSwitch(best []enode.ID) bool {
+	if len(p.active) < len(best) {
+		return true
+	}
+	worst := -1.0
+	for id := range p.active {
+		rank, exist := p.ranks[id]
+		if !exist || !rank.Available() {
+			return true
+		}
+		if worst < 0 || rank.Score < worst {
+			worst = rank.Score
+		}
+	}
+	for _, id := range best {
+		if _, exist := p.active[id]; !exist && p.ranks[id].Score > worst*(1+switchMargin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package mailservers
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProber struct {
+	mu   sync.Mutex
+	rtts map[enode.ID]time.Duration
+}
+
+func (p *fakeProber) Probe(node *enode.Node) (time.Duration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	rtt, exist := p.rtts[node.ID()]
+	if !exist {
+		return 0, errors.New("unavailable")
+	}
+	return rtt, nil
+}
+
+type fakeNotifee struct {
+	notified [][]*enode.Node
+}
+
+func (n *fakeNotifee) Notify(nodes []*enode.Node) {
+	n.notified = append(n.notified, nodes)
+}
+
+func newTestPicker(t *testing.T, nodes []*enode.Node, size int) (*Picker, *fakeProber, *fakeNotifee) {
+	cache := newInMemCache(t)
+	store := NewPeerStore(cache)
+	require.NoError(t, store.Update(nodes))
+	prober := &fakeProber{rtts: map[enode.ID]time.Duration{}}
+	notifee := &fakeNotifee{}
+	return NewPicker(store, cache, prober, notifee, size, time.Minute), prober, notifee
+}
+
+func TestPickerSelectsFastest(t *testing.T) {
+	nodes := make([]*enode.Node, 3)
+	fillWithRandomNodes(t, nodes)
+	picker, prober, notifee := newTestPicker(t, nodes, 1)
+	prober.rtts[nodes[0].ID()] = 100 * time.Millisecond
+	prober.rtts[nodes[1].ID()] = 10 * time.Millisecond
+
+	picker.ProbeAll()
+	require.Equal(t, [][]*enode.Node{{nodes[1]}}, notifee.notified)
+
+	ranking := picker.Ranking()
+	require.Len(t, ranking, 3)
+	require.Equal(t, nodes[1].String(), ranking[0].Enode)
+	require.True(t, ranking[0].Active)
+	require.Equal(t, nodes[0].String(), ranking[1].Enode)
+	require.False(t, ranking[2].Available(), "Unavailable mail servers are ranked last")
+
+	prober.rtts[nodes[0].ID()] = 9 * time.Millisecond
+	picker.ProbeAll()
+	require.Len(t, notifee.notified, 1, "It doesn't switch for a marginally faster mail server")
+
+	delete(prober.rtts, nodes[1].ID())
+	picker.ProbeAll()
+	require.Equal(t, []*enode.Node{nodes[0]}, notifee.notified[1], "It switches when the active mail server is unavailable")
+}
+
+func TestPickerPersistsRanking(t *testing.T) {
+	nodes := make([]*enode.Node, 2)
+	fillWithRandomNodes(t, nodes)
+	picker, prober, _ := newTestPicker(t, nodes, 1)
+	prober.rtts[nodes[0].ID()] = 10 * time.Millisecond
+	picker.ProbeAll()
+
+	ranks, err := picker.cache.LoadRanking()
+	require.NoError(t, err)
+	require.Len(t, ranks, 2)
+	require.Equal(t, 10*time.Millisecond, ranks[nodes[0].ID()].RTT)
+	require.Equal(t, 1, ranks[nodes[1].ID()].Failures)
+
+	require.NoError(t, picker.ps.Update(nodes[:1]))
+	picker.ProbeAll()
+	ranks, err = picker.cache.LoadRanking()
+	require.NoError(t, err)
+	require.Len(t, ranks, 1, "It forgets mail servers that are not selected anymore")
+}
+
+func TestRankUpdate(t *testing.T) {
+	rank := &Rank{}
+	now := time.Now()
+	rank.update(100*time.Millisecond, nil, now)
+	require.Equal(t, 100*time.Millisecond, rank.RTT)
+	require.Equal(t, 1.0, rank.SuccessRate)
+
+	rank.update(0, errors.New("timeout"), now)
+	require.False(t, rank.Available())
+	require.Equal(t, 100*time.Millisecond, rank.RTT)
+	require.InDelta(t, 0.7, rank.SuccessRate, 0.001)
+
+	rank.update(200*time.Millisecond, nil, now)
+	require.True(t, rank.Available())
+	require.Equal(t, 130*time.Millisecond, rank.RTT)
+}
+
+func TestTCPProber(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	node := enode.NewV4(&key.PublicKey, net.IPv4(127, 0, 0, 1), port, port)
+	_, err = TCPProber{Timeout: time.Second}.Probe(node)
+	require.NoError(t, err)
+}
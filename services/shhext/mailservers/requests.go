@@ -0,0 +1,81 @@
+package mailservers
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/status-im/status-go/db"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// NewRequestsCache returns pointer to a RequestsCache instance.
+func NewRequestsCache(db *leveldb.DB, window time.Duration) *RequestsCache {
+	return &RequestsCache{db: db, window: window}
+}
+
+// RequestsCache persists the fingerprints of recently issued requests for historic messages,
+// so that identical requests are not issued again within a window, even across restarts.
+type RequestsCache struct {
+	db     *leveldb.DB
+	window time.Duration
+}
+
+// Seen returns true if a request with the same fingerprint was issued within the window.
+func (c *RequestsCache) Seen(fingerprint []byte, now time.Time) (bool, error) {
+	if c.window <= 0 {
+		return false, nil
+	}
+	value, err := c.db.Get(db.Key(db.MailserverRequestsCache, fingerprint), nil)
+	if err == leveldb.ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	issued := time.Unix(int64(binary.BigEndian.Uint64(value)), 0)
+	return now.Sub(issued) < c.window, nil
+}
+
+// Add records that a request with a given fingerprint was issued.
+func (c *RequestsCache) Add(fingerprint []byte, now time.Time) error {
+	if c.window <= 0 {
+		return nil
+	}
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, uint64(now.Unix()))
+	return c.db.Put(db.Key(db.MailserverRequestsCache, fingerprint), value, nil)
+}
+
+// Prune removes fingerprints of requests issued before the window.
+func (c *RequestsCache) Prune(now time.Time) error {
+	if c.window <= 0 {
+		return nil
+	}
+	batch := new(leveldb.Batch)
+	iter := c.db.NewIterator(util.BytesPrefix([]byte{byte(db.MailserverRequestsCache)}), nil)
+	for iter.Next() {
+		issued := time.Unix(int64(binary.BigEndian.Uint64(iter.Value())), 0)
+		if now.Sub(issued) >= c.window {
+			batch.Delete(iter.Key())
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return c.db.Write(batch, nil)
+}
+
+// Clear removes the fingerprints of all the requests.
+func (c *RequestsCache) Clear() error {
+	batch := new(leveldb.Batch)
+	iter := c.db.NewIterator(util.BytesPrefix([]byte{byte(db.MailserverRequestsCache)}), nil)
+	for iter.Next() {
+		batch.Delete(iter.Key())
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return c.db.Write(batch, nil)
+}
@@ -0,0 +1,68 @@
+package mailservers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+func TestRequestsCacheWindow(t *testing.T) {
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+	cache := NewRequestsCache(db, time.Minute)
+	now := time.Unix(1000, 0)
+	fingerprint := []byte("request")
+
+	seen, err := cache.Seen(fingerprint, now)
+	require.NoError(t, err)
+	require.False(t, seen)
+
+	require.NoError(t, cache.Add(fingerprint, now))
+	seen, err = cache.Seen(fingerprint, now.Add(30*time.Second))
+	require.NoError(t, err)
+	require.True(t, seen)
+
+	// Simulates a restart.
+	cache = NewRequestsCache(db, time.Minute)
+	seen, err = cache.Seen(fingerprint, now.Add(30*time.Second))
+	require.NoError(t, err)
+	require.True(t, seen, "It is persisted")
+
+	seen, err = cache.Seen(fingerprint, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.False(t, seen, "It expires after the window")
+}
+
+func TestRequestsCachePrune(t *testing.T) {
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+	cache := NewRequestsCache(db, time.Minute)
+	now := time.Unix(1000, 0)
+
+	require.NoError(t, cache.Add([]byte("old"), now))
+	require.NoError(t, cache.Add([]byte("new"), now.Add(time.Minute)))
+	require.NoError(t, cache.Prune(now.Add(90*time.Second)))
+
+	iter := db.NewIterator(nil, nil)
+	defer iter.Release()
+	var keys []string
+	for iter.Next() {
+		keys = append(keys, string(keyWithoutPrefix(iter.Key())))
+	}
+	require.Equal(t, []string{"new"}, keys)
+}
+
+func TestRequestsCacheDisabled(t *testing.T) {
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+	cache := NewRequestsCache(db, 0)
+	now := time.Unix(1000, 0)
+
+	require.NoError(t, cache.Add([]byte("request"), now))
+	seen, err := cache.Seen([]byte("request"), now)
+	require.NoError(t, err)
+	require.False(t, seen)
+}
@@ -18,6 +18,24 @@ func GetFirstConnected(provider PeersProvider, store *PeerStore) (*enode.Node, e
 	return nil, ErrNoConnected
 }
 
+// GetConnected returns up to limit connected peers that are also added to a peer store.
+// Raises ErrNoConnected if none of them is connected.
+func GetConnected(provider PeersProvider, store *PeerStore, limit int) ([]*enode.Node, error) {
+	var nodes []*enode.Node
+	for _, p := range provider.Peers() {
+		if len(nodes) == limit {
+			break
+		}
+		if store.Exist(p.ID()) {
+			nodes = append(nodes, p.Node())
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, ErrNoConnected
+	}
+	return nodes, nil
+}
+
 // NodesNotifee interface to be notified when new nodes are received.
 type NodesNotifee interface {
 	Notify([]*enode.Node)
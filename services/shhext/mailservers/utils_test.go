@@ -30,6 +30,27 @@ func TestGetFirstConnected(t *testing.T) {
 	require.Contains(t, nodesMap, node.ID())
 }
 
+func TestGetConnected(t *testing.T) {
+	nodes := make([]*enode.Node, 3)
+	fillWithRandomNodes(t, nodes)
+	peers := make([]*p2p.Peer, len(nodes))
+	for i, node := range nodes {
+		peers[i] = p2p.NewPeer(node.ID(), node.ID().String(), nil)
+	}
+	store := NewPeerStore(newInMemCache(t))
+	provider := fakePeerProvider{peers}
+	_, err := GetConnected(provider, store, 2)
+	require.EqualError(t, ErrNoConnected, err.Error())
+
+	require.NoError(t, store.Update(nodes[1:]))
+	connected, err := GetConnected(provider, store, 1)
+	require.NoError(t, err)
+	require.Len(t, connected, 1)
+	connected, err = GetConnected(provider, store, 5)
+	require.NoError(t, err)
+	require.Len(t, connected, 2, "It returns only peers added to the store")
+}
+
 type trackingNodeNotifee struct {
 	calls [][]*enode.Node
 }
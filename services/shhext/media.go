@@ -0,0 +1,29 @@
+package shhext
+
+import (
+	"context"
+	"io"
+
+	"github.com/status-im/status-go/services/shhext/attachments"
+)
+
+// mediaFetcher returns the attachments of the selected account to the media server.
+type mediaFetcher struct {
+	service *Service
+}
+
+func (f mediaFetcher) Attachment(hash []byte) (*attachments.Attachment, error) {
+	manager := f.service.attachments
+	if manager == nil {
+		return nil, nil
+	}
+	return manager.Attachment(hash)
+}
+
+func (f mediaFetcher) Open(ctx context.Context, hash []byte) (io.ReadSeeker, error) {
+	manager := f.service.attachments
+	if manager == nil {
+		return nil, ErrAttachmentsDisabled
+	}
+	return manager.Open(ctx, hash)
+}
@@ -0,0 +1,13 @@
+package shhext
+
+import "github.com/ethereum/go-ethereum/metrics"
+
+// By default go-ethereum/metrics creates dummy metrics that don't register anything.
+// Real metrics are collected only if -metrics flag is set
+var (
+	envelopesPostedCounter           = metrics.NewRegisteredCounter("shhext/envelopesPosted", nil)
+	envelopesSentCounter             = metrics.NewRegisteredCounter("shhext/envelopesSent", nil)
+	envelopesExpiredCounter          = metrics.NewRegisteredCounter("shhext/envelopesExpired", nil)
+	mailserverRequestTimer           = metrics.NewRegisteredTimer("shhext/mailserverRequests", nil)
+	mailserverRequestsExpiredCounter = metrics.NewRegisteredCounter("shhext/mailserverRequestsExpired", nil)
+)
@@ -0,0 +1,285 @@
+package moderation
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// Mode determines what happens to messages of disallowed authors.
+type Mode int
+
+const (
+	// ModeFlag delivers messages of disallowed authors, flagging them.
+	ModeFlag Mode = iota
+	// ModeHide drops messages of disallowed authors.
+	ModeHide
+)
+
+// Verdict is the outcome of checking the author of a message.
+type Verdict int
+
+const (
+	// Allowed messages are delivered as usual.
+	Allowed Verdict = iota
+	// Flagged messages are delivered and reported.
+	Flagged
+	// Hidden messages are dropped.
+	Hidden
+)
+
+var (
+	// ErrNotModerated is returned when applying a list to a channel without a moderator.
+	ErrNotModerated = errors.New("channel is not moderated")
+	// ErrInvalidSignature is returned when a list is not signed by the moderator of the channel.
+	ErrInvalidSignature = errors.New("list is not signed by the moderator")
+	// ErrStaleList is returned when a list is not newer than the one applied.
+	ErrStaleList = errors.New("list is older than the current one")
+	// ErrWrongChannel is returned when a list was signed for another channel.
+	ErrWrongChannel = errors.New("list is signed for another channel")
+)
+
+// payloadPrefix distinguishes lists from regular messages published in a channel.
+var payloadPrefix = []byte("status-moderation:")
+
+// List is an allowlist and a denylist of authors, identified by
+// their hex-encoded public keys, signed by the moderator of a channel.
+// If the allowlist is not empty, only its authors are allowed. The chat ID of
+// the channel is signed too, so that the list can't be replayed in other
+// channels of the moderator.
+type List struct {
+	ChatID    string        `json:"chatID"`
+	Allow     []string      `json:"allow,omitempty"`
+	Deny      []string      `json:"deny,omitempty"`
+	Clock     uint64        `json:"clock"`
+	Signature hexutil.Bytes `json:"signature,omitempty"`
+}
+
+func (l *List) hash() ([]byte, error) {
+	unsigned := *l
+	unsigned.Signature = nil
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(data), nil
+}
+
+// Sign signs the list with the key of the moderator.
+func (l *List) Sign(key *ecdsa.PrivateKey) error {
+	hash, err := l.hash()
+	if err != nil {
+		return err
+	}
+	l.Signature, err = crypto.Sign(hash, key)
+	return err
+}
+
+// Signer returns the hex-encoded public key the list was signed with.
+func (l *List) Signer() (string, error) {
+	hash, err := l.hash()
+	if err != nil {
+		return "", err
+	}
+	key, err := crypto.SigToPub(hash, l.Signature)
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(crypto.FromECDSAPub(key)), nil
+}
+
+// Encode returns the payload used to publish the list in a channel.
+func Encode(l *List) ([]byte, error) {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, payloadPrefix...), data...), nil
+}
+
+// Decode returns the list published in a payload, if any.
+func Decode(payload []byte) (*List, bool) {
+	if !bytes.HasPrefix(payload, payloadPrefix) {
+		return nil, false
+	}
+	var l List
+	if err := json.Unmarshal(payload[len(payloadPrefix):], &l); err != nil {
+		return nil, false
+	}
+	return &l, true
+}
+
+// Channel is the moderation state of a public channel.
+type Channel struct {
+	ChatID    string            `json:"chatID"`
+	Topic     whisper.TopicType `json:"topic"`
+	Moderator string            `json:"moderator"`
+	Mode      Mode              `json:"mode"`
+	List      *List             `json:"list"`
+}
+
+// Store persists the moderation state of channels.
+type Store interface {
+	SaveModeratedChannel(Channel) error
+	GetModeratedChannel(topic []byte) (*Channel, error)
+}
+
+// ListHandler is notified every time the list of a channel changes.
+type ListHandler func(Channel)
+
+// Moderator enforces the lists published by the moderators of public channels.
+type Moderator struct {
+	store   Store
+	handler ListHandler
+
+	mu       sync.Mutex
+	channels map[whisper.TopicType]*Channel
+}
+
+// NewModerator returns a new Moderator.
+func NewModerator(store Store, handler ListHandler) *Moderator {
+	return &Moderator{
+		store:    store,
+		handler:  handler,
+		channels: make(map[whisper.TopicType]*Channel),
+	}
+}
+
+// SetModerator designates the moderator of a channel, identified by its hex-encoded public key.
+// An empty moderator disables moderation. The list applied so far is discarded.
+func (m *Moderator) SetModerator(chatID string, topic whisper.TopicType, moderator string, mode Mode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	channel := Channel{
+		ChatID:    chatID,
+		Topic:     topic,
+		Moderator: strings.ToLower(moderator),
+		Mode:      mode,
+	}
+	if err := m.store.SaveModeratedChannel(channel); err != nil {
+		return err
+	}
+	m.channels[topic] = &channel
+	return nil
+}
+
+// Channel returns the moderation state of a channel, or nil if it's not moderated.
+func (m *Moderator) Channel(topic whisper.TopicType) (*Channel, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	channel, err := m.channel(topic)
+	if err != nil || channel == nil {
+		return nil, err
+	}
+	copied := *channel
+	return &copied, nil
+}
+
+// Apply applies a list published in a channel, if signed by its moderator.
+func (m *Moderator) Apply(topic whisper.TopicType, list *List) error {
+	signer, err := list.Signer()
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	m.mu.Lock()
+	channel, err := m.channel(topic)
+	if err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	if channel == nil {
+		m.mu.Unlock()
+		return ErrNotModerated
+	}
+	if signer != channel.Moderator {
+		m.mu.Unlock()
+		return ErrInvalidSignature
+	}
+	if list.ChatID != channel.ChatID {
+		m.mu.Unlock()
+		return ErrWrongChannel
+	}
+	if channel.List != nil && list.Clock <= channel.List.Clock {
+		m.mu.Unlock()
+		return ErrStaleList
+	}
+
+	updated := *channel
+	updated.List = list
+	if err := m.store.SaveModeratedChannel(updated); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	m.channels[topic] = &updated
+	m.mu.Unlock()
+
+	if m.handler != nil {
+		m.handler(updated)
+	}
+	return nil
+}
+
+// Check returns the verdict for a message published in a channel by an author,
+// identified by its hex-encoded public key.
+func (m *Moderator) Check(topic whisper.TopicType, author string) (Verdict, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	channel, err := m.channel(topic)
+	if err != nil || channel == nil || channel.List == nil {
+		return Allowed, err
+	}
+
+	author = strings.ToLower(author)
+	if author == channel.Moderator || allowed(channel.List, author) {
+		return Allowed, nil
+	}
+	if channel.Mode == ModeHide {
+		return Hidden, nil
+	}
+	return Flagged, nil
+}
+
+func allowed(list *List, author string) bool {
+	for _, denied := range list.Deny {
+		if strings.ToLower(denied) == author {
+			return false
+		}
+	}
+	if len(list.Allow) == 0 {
+		return true
+	}
+	for _, allowed := range list.Allow {
+		if strings.ToLower(allowed) == author {
+			return true
+		}
+	}
+	return false
+}
+
+// channel must be called with the lock held. It returns nil if the channel is not moderated.
+func (m *Moderator) channel(topic whisper.TopicType) (*Channel, error) {
+	channel, ok := m.channels[topic]
+	if !ok {
+		var err error
+		channel, err = m.store.GetModeratedChannel(topic[:])
+		if err != nil {
+			return nil, err
+		}
+		m.channels[topic] = channel
+	}
+	if channel == nil || channel.Moderator == "" {
+		return nil, nil
+	}
+	return channel, nil
+}
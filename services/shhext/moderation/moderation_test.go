@@ -0,0 +1,117 @@
+package moderation
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore map[whisper.TopicType]Channel
+
+func (s memoryStore) SaveModeratedChannel(channel Channel) error {
+	s[channel.Topic] = channel
+	return nil
+}
+
+func (s memoryStore) GetModeratedChannel(topic []byte) (*Channel, error) {
+	channel, ok := s[whisper.BytesToTopic(topic)]
+	if !ok {
+		return nil, nil
+	}
+	return &channel, nil
+}
+
+func newSignedList(t *testing.T, chatID string, clock uint64, allow, deny []string) (*List, string) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	list := &List{ChatID: chatID, Allow: allow, Deny: deny, Clock: clock}
+	require.NoError(t, list.Sign(key))
+	return list, hexutil.Encode(crypto.FromECDSAPub(&key.PublicKey))
+}
+
+func TestEncodeDecode(t *testing.T) {
+	list, _ := newSignedList(t, "chat", 1, []string{"0x01"}, nil)
+	payload, err := Encode(list)
+	require.NoError(t, err)
+
+	decoded, ok := Decode(payload)
+	require.True(t, ok)
+	require.Equal(t, list, decoded)
+
+	_, ok = Decode([]byte("hello"))
+	require.False(t, ok)
+}
+
+func TestApplyAndCheck(t *testing.T) {
+	topic := whisper.TopicType{1}
+	var changed []Channel
+	m := NewModerator(memoryStore{}, func(c Channel) { changed = append(changed, c) })
+
+	list, moderator := newSignedList(t, "chat", 1, nil, []string{"0xBAD"})
+	require.Equal(t, ErrNotModerated, m.Apply(topic, list))
+
+	require.NoError(t, m.SetModerator("chat", topic, moderator, ModeHide))
+	verdict, err := m.Check(topic, "0xbad")
+	require.NoError(t, err)
+	require.Equal(t, Allowed, verdict, "It allows everyone without a list")
+
+	require.NoError(t, m.Apply(topic, list))
+	require.Len(t, changed, 1)
+	require.Equal(t, "chat", changed[0].ChatID)
+
+	verdict, err = m.Check(topic, "0xbad")
+	require.NoError(t, err)
+	require.Equal(t, Hidden, verdict)
+
+	verdict, err = m.Check(topic, "0x02")
+	require.NoError(t, err)
+	require.Equal(t, Allowed, verdict)
+
+	require.Equal(t, ErrStaleList, m.Apply(topic, list))
+}
+
+func TestApplyRequiresModeratorSignature(t *testing.T) {
+	topic := whisper.TopicType{1}
+	m := NewModerator(memoryStore{}, nil)
+
+	_, moderator := newSignedList(t, "chat", 1, nil, nil)
+	require.NoError(t, m.SetModerator("chat", topic, moderator, ModeFlag))
+
+	forged, _ := newSignedList(t, "chat", 2, nil, []string{moderator})
+	require.Equal(t, ErrInvalidSignature, m.Apply(topic, forged))
+}
+
+func TestApplyRejectsListsOfOtherChannels(t *testing.T) {
+	m := NewModerator(memoryStore{}, nil)
+	list, moderator := newSignedList(t, "other", 1, nil, []string{"0xbad"})
+	require.NoError(t, m.SetModerator("other", whisper.TopicType{2}, moderator, ModeHide))
+	require.NoError(t, m.SetModerator("chat", whisper.TopicType{1}, moderator, ModeHide))
+	require.NoError(t, m.Apply(whisper.TopicType{2}, list))
+
+	require.Equal(t, ErrWrongChannel, m.Apply(whisper.TopicType{1}, list), "Lists can't be replayed in other channels of the moderator")
+	list.ChatID = "chat"
+	require.Equal(t, ErrInvalidSignature, m.Apply(whisper.TopicType{1}, list), "The chat ID is signed")
+}
+
+func TestAllowlistFlags(t *testing.T) {
+	topic := whisper.TopicType{1}
+	store := memoryStore{}
+	m := NewModerator(store, nil)
+
+	list, moderator := newSignedList(t, "chat", 1, []string{"0x01"}, nil)
+	require.NoError(t, m.SetModerator("chat", topic, moderator, ModeFlag))
+	require.NoError(t, m.Apply(topic, list))
+
+	// Simulates a restart.
+	m = NewModerator(store, nil)
+	verdict, err := m.Check(topic, "0x02")
+	require.NoError(t, err)
+	require.Equal(t, Flagged, verdict)
+
+	verdict, err = m.Check(topic, moderator)
+	require.NoError(t, err)
+	require.Equal(t, Allowed, verdict, "It always allows the moderator")
+}
@@ -0,0 +1,157 @@
+package notifications
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxGroupNotifications is the number of notifications of the most recent messages kept
+// in the group of a chat.
+const MaxGroupNotifications = 5
+
+// Received is a chat message received, to be notified.
+type Received struct {
+	MessageID string
+	ChatID    string
+	Message
+}
+
+// Group are the notifications of the messages received in a chat, notified together.
+type Group struct {
+	ChatID string `json:"chatId"`
+	// Count is the number of messages notified by the group, which may be more than
+	// its notifications.
+	Count  int  `json:"count"`
+	Sounds bool `json:"sounds"`
+	Badges bool `json:"badges"`
+	// Deferred is true if the group notifies messages received during quiet hours,
+	// once they are over.
+	Deferred bool `json:"deferred,omitempty"`
+	// Notifications are those of the most recent messages, the oldest first.
+	Notifications []Notification `json:"notifications"`
+}
+
+func (g *Group) add(n Notification) {
+	g.Count++
+	g.Notifications = append(g.Notifications, n)
+	if len(g.Notifications) > MaxGroupNotifications {
+		g.Notifications = g.Notifications[len(g.Notifications)-MaxGroupNotifications:]
+	}
+}
+
+// deferredChat are the messages of a chat received during quiet hours.
+type deferredChat struct {
+	count    int
+	received []Received
+}
+
+// Builder groups the notifications of the messages received per chat, and holds those
+// received during quiet hours until they are over. Deferred messages are only kept in
+// memory.
+type Builder struct {
+	muted func(chatID string) bool
+
+	mu       sync.Mutex
+	deferred map[string]*deferredChat
+}
+
+// NewBuilder returns a new Builder. muted returns true for the chats muted locally,
+// in addition to those muted in the preferences, and may be nil.
+func NewBuilder(muted func(chatID string) bool) *Builder {
+	return &Builder{
+		muted:    muted,
+		deferred: make(map[string]*deferredChat),
+	}
+}
+
+// Build returns the groups of notifications of the messages received at now, one per
+// chat, in the order of their first message. Once quiet hours are over, the messages
+// deferred come first, silently, merged with the new messages of their chat. The
+// preferences, and mutes, are applied to deferred messages when they are notified.
+func (b *Builder) Build(p Preferences, received []Received, now time.Time) []Group {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var groups []*Group
+	byChat := make(map[string]*Group)
+	group := func(chatID string, deferred bool) *Group {
+		g, ok := byChat[chatID]
+		if !ok {
+			g = &Group{ChatID: chatID, Deferred: deferred}
+			byChat[chatID] = g
+			groups = append(groups, g)
+		}
+		return g
+	}
+
+	if p.Rules.QuietHours == nil || !p.Rules.QuietHours.Active(now) {
+		for _, chatID := range b.deferredChats() {
+			deferred := b.deferred[chatID]
+			delete(b.deferred, chatID)
+			chat := p.ForChat(chatID)
+			if b.isMuted(chat) {
+				continue
+			}
+			g := group(chatID, true)
+			g.Badges = chat.Badges
+			for _, r := range deferred.received {
+				if n, ok := chat.Notification(r.MessageID, r.Author, r.Content); ok {
+					g.add(n)
+				}
+			}
+			g.Count = deferred.count
+		}
+	}
+
+	for _, r := range received {
+		chat := p.ForChat(r.ChatID)
+		if b.isMuted(chat) {
+			continue
+		}
+		switch {
+		case p.Rules.Notify(r.Message, now):
+			n, _ := chat.Notification(r.MessageID, r.Author, r.Content)
+			g := group(r.ChatID, false)
+			g.Sounds = chat.Sounds
+			g.Badges = chat.Badges
+			g.add(n)
+		case p.Rules.Deferred(r.Message, now):
+			b.deferMessage(r)
+		}
+	}
+
+	result := make([]Group, len(groups))
+	for i, g := range groups {
+		result[i] = *g
+	}
+	return result
+}
+
+// deferMessage must be called with the lock held.
+func (b *Builder) deferMessage(r Received) {
+	deferred, ok := b.deferred[r.ChatID]
+	if !ok {
+		deferred = &deferredChat{}
+		b.deferred[r.ChatID] = deferred
+	}
+	deferred.count++
+	deferred.received = append(deferred.received, r)
+	if len(deferred.received) > MaxGroupNotifications {
+		deferred.received = deferred.received[len(deferred.received)-MaxGroupNotifications:]
+	}
+}
+
+// deferredChats must be called with the lock held.
+func (b *Builder) deferredChats() []string {
+	chatIDs := make([]string, 0, len(b.deferred))
+	for chatID := range b.deferred {
+		chatIDs = append(chatIDs, chatID)
+	}
+	sort.Strings(chatIDs)
+	return chatIDs
+}
+
+func (b *Builder) isMuted(chat Chat) bool {
+	return chat.Muted || (b.muted != nil && b.muted(chat.ChatID))
+}
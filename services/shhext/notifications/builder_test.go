@@ -0,0 +1,117 @@
+package notifications
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func received(chatID string, id int, author, content string) Received {
+	return Received{
+		MessageID: fmt.Sprintf("0x%02x", id),
+		ChatID:    chatID,
+		Message:   Message{Author: author, Direct: true, Content: content},
+	}
+}
+
+func TestBuilderGroups(t *testing.T) {
+	now := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+	disabled := false
+	p := DefaultPreferences()
+	p.Chats = map[string]ChatPreferences{"quiet": {Sounds: &disabled}, "muted": {Muted: true}}
+	b := NewBuilder(func(chatID string) bool { return chatID == "local" })
+
+	var messages []Received
+	for i := 0; i < MaxGroupNotifications+2; i++ {
+		messages = append(messages, received("alice", i, "0x04a0", fmt.Sprintf("hello %d", i)))
+	}
+	messages = append(messages,
+		received("quiet", 10, "0x04b0", "hi"),
+		received("muted", 11, "0x04c0", "hi"),
+		received("local", 12, "0x04d0", "hi"),
+	)
+	groups := b.Build(p, messages, now)
+	require.Len(t, groups, 2, "Muted chats are not notified")
+
+	require.Equal(t, "alice", groups[0].ChatID)
+	require.Equal(t, MaxGroupNotifications+2, groups[0].Count)
+	require.Len(t, groups[0].Notifications, MaxGroupNotifications, "Only the most recent messages are kept")
+	require.Equal(t, "hello 2", groups[0].Notifications[0].Preview)
+	require.True(t, groups[0].Sounds)
+	require.Equal(t, Group{
+		ChatID: "quiet",
+		Count:  1,
+		Badges: true,
+		Notifications: []Notification{
+			{MessageID: "0x0a", ChatID: "quiet", Author: "0x04b0", Badges: true, Preview: "hi"},
+		},
+	}, groups[1])
+}
+
+func TestBuilderPrivacy(t *testing.T) {
+	now := time.Now()
+	b := NewBuilder(nil)
+	p := DefaultPreferences()
+	message := []Received{received("alice", 1, "0x04a0", "hello")}
+
+	p.Privacy = PrivacyHideContent
+	n := b.Build(p, message, now)[0].Notifications[0]
+	require.Equal(t, "0x04a0", n.Author)
+	require.Empty(t, n.Preview)
+
+	p.Privacy = PrivacyHideAll
+	n = b.Build(p, message, now)[0].Notifications[0]
+	require.Empty(t, n.Author)
+	require.Empty(t, n.Preview)
+}
+
+func TestBuilderQuietHours(t *testing.T) {
+	noon := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := DefaultPreferences()
+	p.Rules = Rules{
+		PublicChats: LevelNone,
+		Senders:     map[string]SenderRule{"0x04f0": {Level: LevelAll}},
+		QuietHours:  &QuietHours{Start: 11 * 60, End: 13 * 60},
+	}
+	b := NewBuilder(nil)
+
+	groups := b.Build(p, []Received{
+		received("alice", 1, "0x04a0", "hello"),
+		received("alice", 2, "0x04a0", "are you there?"),
+		{MessageID: "0x03", ChatID: "public", Message: Message{Author: "0x04a0", Content: "hi"}},
+		received("bob", 4, "0x04b0", "hi"),
+		received("frank", 5, "0x04f0", "urgent"),
+	}, noon)
+	require.Len(t, groups, 1, "Messages are deferred during quiet hours")
+	require.Equal(t, "frank", groups[0].ChatID)
+	require.Empty(t, b.Build(p, nil, noon.Add(30*time.Minute)))
+
+	p.Chats = map[string]ChatPreferences{"bob": {Muted: true}}
+	groups = b.Build(p, []Received{received("alice", 6, "0x04a0", "hello again")}, noon.Add(2*time.Hour))
+	require.Len(t, groups, 1, "Chats muted since are not notified")
+	require.Equal(t, "alice", groups[0].ChatID)
+	require.True(t, groups[0].Deferred)
+	require.True(t, groups[0].Sounds, "Groups with new messages are not silent")
+	require.Equal(t, 3, groups[0].Count)
+	require.Equal(t, "hello", groups[0].Notifications[0].Preview)
+	require.Equal(t, "hello again", groups[0].Notifications[2].Preview)
+
+	require.Empty(t, b.Build(p, nil, noon.Add(3*time.Hour)), "Deferred messages are notified once")
+}
+
+func TestBuilderDeferredSilently(t *testing.T) {
+	noon := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := DefaultPreferences()
+	p.Rules.QuietHours = &QuietHours{Start: 11 * 60, End: 13 * 60}
+	b := NewBuilder(nil)
+	require.Empty(t, b.Build(p, []Received{received("alice", 1, "0x04a0", "hello")}, noon))
+
+	p.Privacy = PrivacyHideContent
+	groups := b.Build(p, nil, noon.Add(2*time.Hour))
+	require.Len(t, groups, 1)
+	require.False(t, groups[0].Sounds, "Deferred messages are notified silently")
+	require.True(t, groups[0].Badges)
+	require.Empty(t, groups[0].Notifications[0].Preview, "The current privacy is applied")
+}
@@ -0,0 +1,285 @@
+package notifications
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrStalePreferences is returned when synced preferences are not newer than the current ones.
+	ErrStalePreferences = errors.New("preferences are older than the current ones")
+	// ErrInvalidPrivacy is returned when setting an unknown privacy mode.
+	ErrInvalidPrivacy = errors.New("invalid notification privacy")
+)
+
+// Privacy tells what notifications reveal of the messages.
+type Privacy string
+
+const (
+	// PrivacyDefault shows the authors, and the content of the messages of chats with previews.
+	PrivacyDefault Privacy = ""
+	// PrivacyHideContent never shows the content of the messages.
+	PrivacyHideContent Privacy = "hide-content"
+	// PrivacyHideAll shows neither the authors nor the content of the messages.
+	PrivacyHideAll Privacy = "hide-all"
+)
+
+func (p Privacy) valid() bool {
+	return p == PrivacyDefault || p == PrivacyHideContent || p == PrivacyHideAll
+}
+
+// ChatPreferences overrides the preferences of the account for a chat.
+// Nil values use the preference of the account.
+type ChatPreferences struct {
+	// Muted chats don't trigger notifications.
+	Muted    bool  `json:"muted,omitempty"`
+	Sounds   *bool `json:"sounds,omitempty"`
+	Badges   *bool `json:"badges,omitempty"`
+	Previews *bool `json:"previews,omitempty"`
+}
+
+// Preferences are the notification preferences of an account, shared by its devices.
+type Preferences struct {
+	// Sounds plays a sound for each notification.
+	Sounds bool `json:"sounds"`
+	// Badges counts the unread messages on the icon of the application.
+	Badges bool `json:"badges"`
+	// Previews shows the content of messages in notifications.
+	Previews bool `json:"previews"`
+	// Privacy hides the content of messages, or their authors too, in the notifications
+	// of all the chats, whatever their previews.
+	Privacy Privacy `json:"privacy,omitempty"`
+	// Chats are the overrides of the chats, by chat ID.
+	Chats map[string]ChatPreferences `json:"chats,omitempty"`
+	// Rules decide which messages of the chats that are not muted trigger notifications.
+	Rules Rules `json:"rules"`
+	// Clock orders the changes made on different devices, the latest wins.
+	Clock uint64 `json:"clock"`
+}
+
+// DefaultPreferences are the preferences of accounts that never changed them.
+func DefaultPreferences() Preferences {
+	return Preferences{Sounds: true, Badges: true, Previews: true}
+}
+
+// Chat are the preferences applied to the notifications of a chat.
+type Chat struct {
+	ChatID   string `json:"chatID"`
+	Muted    bool   `json:"muted"`
+	Sounds   bool   `json:"sounds"`
+	Badges   bool   `json:"badges"`
+	Previews bool   `json:"previews"`
+	// HideAuthors hides the authors of the messages.
+	HideAuthors bool `json:"hideAuthors,omitempty"`
+}
+
+// validate returns an error if the preferences can't be applied.
+func (p Preferences) validate() error {
+	if !p.Privacy.valid() {
+		return ErrInvalidPrivacy
+	}
+	return p.Rules.Validate()
+}
+
+// ForChat returns the preferences applied to the notifications of a chat,
+// taking its overrides and the privacy of the account into account.
+func (p Preferences) ForChat(chatID string) Chat {
+	chat := Chat{ChatID: chatID, Sounds: p.Sounds, Badges: p.Badges, Previews: p.Previews}
+	if overrides, ok := p.Chats[chatID]; ok {
+		chat.Muted = overrides.Muted
+		if overrides.Sounds != nil {
+			chat.Sounds = *overrides.Sounds
+		}
+		if overrides.Badges != nil {
+			chat.Badges = *overrides.Badges
+		}
+		if overrides.Previews != nil {
+			chat.Previews = *overrides.Previews
+		}
+	}
+	if p.Privacy != PrivacyDefault {
+		chat.Previews = false
+	}
+	chat.HideAuthors = p.Privacy == PrivacyHideAll
+	return chat
+}
+
+// Notification is a message received in a chat, with the preferences applied to it.
+type Notification struct {
+	MessageID string `json:"messageId"`
+	ChatID    string `json:"chatId"`
+	// Author is the public key of the author, empty if authors are hidden.
+	Author string `json:"author"`
+	Sounds bool   `json:"sounds"`
+	Badges bool   `json:"badges"`
+	// Preview is the content of the message, empty if previews are disabled.
+	Preview string `json:"preview,omitempty"`
+}
+
+// Notification returns the notification of a message received in the chat,
+// or false if the chat is muted.
+func (c Chat) Notification(messageID, author, content string) (Notification, bool) {
+	if c.Muted {
+		return Notification{}, false
+	}
+	n := Notification{MessageID: messageID, ChatID: c.ChatID, Sounds: c.Sounds, Badges: c.Badges}
+	if !c.HideAuthors {
+		n.Author = author
+	}
+	if c.Previews {
+		n.Preview = content
+	}
+	return n, true
+}
+
+// Encode returns the payload used to sync the preferences with the other devices.
+func Encode(p Preferences) ([]byte, error) {
+	return json.Marshal(p)
+}
+
+// Decode returns the preferences synced by another device.
+func Decode(payload []byte) (Preferences, error) {
+	var p Preferences
+	err := json.Unmarshal(payload, &p)
+	return p, err
+}
+
+// Store persists the preferences of the account.
+type Store interface {
+	SaveNotificationPreferences(Preferences) error
+	// GetNotificationPreferences returns the preferences, or nil if they were never saved.
+	GetNotificationPreferences() (*Preferences, error)
+}
+
+// PreferencesHandler is notified every time preferences synced by another device are applied.
+type PreferencesHandler func(Preferences)
+
+// Manager keeps the notification preferences of the account in sync between its devices.
+type Manager struct {
+	store   Store
+	handler PreferencesHandler
+
+	mu          sync.Mutex
+	preferences *Preferences
+}
+
+// NewManager returns a new Manager.
+func NewManager(store Store, handler PreferencesHandler) *Manager {
+	return &Manager{store: store, handler: handler}
+}
+
+// Preferences returns the preferences of the account.
+func (m *Manager) Preferences() (Preferences, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.current()
+}
+
+// ForChat returns the preferences applied to the notifications of a chat.
+func (m *Manager) ForChat(chatID string) (Chat, error) {
+	p, err := m.Preferences()
+	if err != nil {
+		return Chat{}, err
+	}
+	return p.ForChat(chatID), nil
+}
+
+// Set changes the preferences of the account at the given clock and returns them,
+// so that they can be synced with the other devices. The clock is moved forward
+// if it's not newer than the current one.
+func (m *Manager) Set(p Preferences, clock uint64) (Preferences, error) {
+	if err := p.validate(); err != nil {
+		return Preferences{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, err := m.current()
+	if err != nil {
+		return Preferences{}, err
+	}
+	return m.set(p, current, clock)
+}
+
+// SetRules changes the notification rules of the account at the given clock, keeping the
+// other preferences, and returns the preferences to sync with the other devices.
+func (m *Manager) SetRules(rules Rules, clock uint64) (Preferences, error) {
+	if err := rules.Validate(); err != nil {
+		return Preferences{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, err := m.current()
+	if err != nil {
+		return Preferences{}, err
+	}
+	p := current
+	p.Rules = rules
+	return m.set(p, current, clock)
+}
+
+// set must be called with the lock held.
+func (m *Manager) set(p, current Preferences, clock uint64) (Preferences, error) {
+	if clock <= current.Clock {
+		clock = current.Clock + 1
+	}
+	p.Clock = clock
+	if err := m.save(p); err != nil {
+		return Preferences{}, err
+	}
+	return p, nil
+}
+
+// Apply applies the preferences synced by another device, if they are newer than the current ones.
+func (m *Manager) Apply(p Preferences) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	current, err := m.current()
+	if err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	if p.Clock <= current.Clock {
+		m.mu.Unlock()
+		return ErrStalePreferences
+	}
+	if err := m.save(p); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	m.mu.Unlock()
+
+	if m.handler != nil {
+		m.handler(p)
+	}
+	return nil
+}
+
+// current must be called with the lock held.
+func (m *Manager) current() (Preferences, error) {
+	if m.preferences == nil {
+		p, err := m.store.GetNotificationPreferences()
+		if err != nil {
+			return Preferences{}, err
+		}
+		if p == nil {
+			defaults := DefaultPreferences()
+			p = &defaults
+		}
+		m.preferences = p
+	}
+	return *m.preferences, nil
+}
+
+// save must be called with the lock held.
+func (m *Manager) save(p Preferences) error {
+	if err := m.store.SaveNotificationPreferences(p); err != nil {
+		return err
+	}
+	m.preferences = &p
+	return nil
+}
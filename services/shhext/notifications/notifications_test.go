@@ -0,0 +1,102 @@
+package notifications
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	preferences *Preferences
+}
+
+func (s *memoryStore) SaveNotificationPreferences(p Preferences) error {
+	s.preferences = &p
+	return nil
+}
+
+func (s *memoryStore) GetNotificationPreferences() (*Preferences, error) {
+	return s.preferences, nil
+}
+
+func TestForChat(t *testing.T) {
+	disabled := false
+	p := Preferences{
+		Sounds:   true,
+		Badges:   true,
+		Previews: true,
+		Chats: map[string]ChatPreferences{
+			"quiet": {Sounds: &disabled},
+			"muted": {Muted: true},
+		},
+	}
+
+	require.Equal(t, Chat{ChatID: "other", Sounds: true, Badges: true, Previews: true}, p.ForChat("other"))
+	require.Equal(t, Chat{ChatID: "quiet", Sounds: false, Badges: true, Previews: true}, p.ForChat("quiet"))
+	require.Equal(t, Chat{ChatID: "muted", Muted: true, Sounds: true, Badges: true, Previews: true}, p.ForChat("muted"))
+
+	p.Privacy = PrivacyHideAll
+	require.Equal(t, Chat{ChatID: "other", Sounds: true, Badges: true, HideAuthors: true}, p.ForChat("other"), "The privacy applies to all the chats")
+}
+
+func TestNotification(t *testing.T) {
+	n, ok := Chat{ChatID: "chat", Sounds: true, Previews: true}.Notification("0x01", "0x04a1", "hello")
+	require.True(t, ok)
+	require.Equal(t, Notification{MessageID: "0x01", ChatID: "chat", Author: "0x04a1", Sounds: true, Preview: "hello"}, n)
+
+	n, ok = Chat{ChatID: "chat", Badges: true}.Notification("0x01", "0x04a1", "hello")
+	require.True(t, ok)
+	require.Empty(t, n.Preview, "The content is not previewed if previews are disabled")
+
+	n, ok = Chat{ChatID: "chat", Previews: true, HideAuthors: true}.Notification("0x01", "0x04a1", "hello")
+	require.True(t, ok)
+	require.Empty(t, n.Author)
+
+	_, ok = Chat{ChatID: "chat", Muted: true, Previews: true}.Notification("0x01", "0x04a1", "hello")
+	require.False(t, ok, "Muted chats don't trigger notifications")
+}
+
+func TestEncodeDecode(t *testing.T) {
+	enabled := true
+	p := Preferences{Badges: true, Chats: map[string]ChatPreferences{"chat": {Previews: &enabled}}, Clock: 3}
+	payload, err := Encode(p)
+	require.NoError(t, err)
+
+	decoded, err := Decode(payload)
+	require.NoError(t, err)
+	require.Equal(t, p, decoded)
+
+	_, err = Decode([]byte("hello"))
+	require.Error(t, err)
+}
+
+func TestSetAndApply(t *testing.T) {
+	var applied []Preferences
+	store := &memoryStore{}
+	m := NewManager(store, func(p Preferences) { applied = append(applied, p) })
+
+	p, err := m.Preferences()
+	require.NoError(t, err)
+	require.Equal(t, DefaultPreferences(), p, "It returns the defaults if never set")
+
+	p, err = m.Set(Preferences{Sounds: true}, 10)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), p.Clock)
+	require.Equal(t, p, *store.preferences)
+
+	p, err = m.Set(Preferences{Badges: true}, 5)
+	require.NoError(t, err)
+	require.Equal(t, uint64(11), p.Clock, "It moves the clock forward")
+
+	require.Equal(t, ErrStalePreferences, m.Apply(Preferences{Previews: true, Clock: 11}))
+	require.Equal(t, ErrInvalidPrivacy, m.Apply(Preferences{Privacy: "blurred", Clock: 20}))
+	require.Empty(t, applied)
+
+	synced := Preferences{Previews: true, Clock: 12}
+	require.NoError(t, m.Apply(synced))
+	require.Equal(t, []Preferences{synced}, applied)
+
+	chat, err := m.ForChat("chat")
+	require.NoError(t, err)
+	require.Equal(t, Chat{ChatID: "chat", Previews: true}, chat)
+}
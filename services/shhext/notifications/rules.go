@@ -0,0 +1,187 @@
+package notifications
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxKeywords is the maximum number of keywords of the rules, including those of the senders.
+	MaxKeywords   = 100
+	minutesPerDay = 24 * 60
+)
+
+// ErrInvalidRules is returned when setting rules with an unknown level, an empty keyword,
+// too many keywords or quiet hours out of range.
+var ErrInvalidRules = errors.New("invalid notification rules")
+
+// Level tells which messages of a chat or a sender trigger notifications.
+type Level string
+
+const (
+	// LevelAll notifies all the messages. It is the level of rules that don't set one.
+	LevelAll Level = "all"
+	// LevelKeywords only notifies the messages containing a keyword.
+	LevelKeywords Level = "keywords"
+	// LevelNone notifies no message.
+	LevelNone Level = "none"
+)
+
+func (l Level) valid() bool {
+	return l == "" || l == LevelAll || l == LevelKeywords || l == LevelNone
+}
+
+// SenderRule overrides the level of the chats for the messages of a sender.
+type SenderRule struct {
+	// Level replaces the level of the chat, unless empty.
+	Level Level `json:"level,omitempty"`
+	// Keywords are matched in the messages of the sender, in addition to those of the rules.
+	Keywords []string `json:"keywords,omitempty"`
+}
+
+// QuietHours is a daily period without notifications, but for the senders whose level is
+// LevelAll. Start and End are minutes after midnight, and the period wraps around midnight
+// if End is before Start.
+type QuietHours struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// UTCOffset is the offset of the time zone of the hours from UTC, in minutes.
+	UTCOffset int `json:"utcOffset"`
+}
+
+// Active returns true if t is within the quiet hours.
+func (q QuietHours) Active(t time.Time) bool {
+	t = t.UTC().Add(time.Duration(q.UTCOffset) * time.Minute)
+	minute := t.Hour()*60 + t.Minute()
+	if q.Start <= q.End {
+		return minute >= q.Start && minute < q.End
+	}
+	return minute >= q.Start || minute < q.End
+}
+
+// Rules decide which messages received in chats that are not muted trigger notifications,
+// by chat type, sender, keywords and time of the day.
+type Rules struct {
+	// Keywords, like mentions of the name of the user, are matched case-insensitively as
+	// whole words in the messages of chats and senders at LevelKeywords.
+	Keywords []string `json:"keywords,omitempty"`
+	// PublicChats and DirectChats are the levels of public and 1:1 chats.
+	PublicChats Level `json:"publicChats,omitempty"`
+	DirectChats Level `json:"directChats,omitempty"`
+	// Senders are the rules of senders, by public key.
+	Senders map[string]SenderRule `json:"senders,omitempty"`
+	// QuietHours silences notifications every day, unless nil.
+	QuietHours *QuietHours `json:"quietHours,omitempty"`
+}
+
+// Validate returns ErrInvalidRules if the rules can't be evaluated.
+func (r Rules) Validate() error {
+	if !r.PublicChats.valid() || !r.DirectChats.valid() {
+		return ErrInvalidRules
+	}
+	keywords := len(r.Keywords)
+	for _, sender := range r.Senders {
+		if !sender.Level.valid() || !validKeywords(sender.Keywords) {
+			return ErrInvalidRules
+		}
+		keywords += len(sender.Keywords)
+	}
+	if keywords > MaxKeywords || !validKeywords(r.Keywords) {
+		return ErrInvalidRules
+	}
+	if q := r.QuietHours; q != nil {
+		if q.Start < 0 || q.Start >= minutesPerDay || q.End < 0 || q.End >= minutesPerDay ||
+			q.UTCOffset <= -minutesPerDay || q.UTCOffset >= minutesPerDay {
+			return ErrInvalidRules
+		}
+	}
+	return nil
+}
+
+func validKeywords(keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.TrimSpace(keyword) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Message is a message received in a chat that is not muted, evaluated by the rules.
+type Message struct {
+	// Author is the public key of the sender.
+	Author  string
+	Direct  bool
+	Content string
+}
+
+// Notify returns true if a message received at t triggers a notification:
+//   - the level of the sender, if set, overrides the level of the chat type,
+//   - during quiet hours, only the senders at LevelAll trigger notifications,
+//   - at LevelKeywords, the message must contain a keyword of the rules or of its sender.
+func (r Rules) Notify(m Message, t time.Time) bool {
+	return !r.quiet(m, t) && r.notifiable(m)
+}
+
+// Deferred returns true if a message received at t would trigger a notification
+// but for the quiet hours, so that it can be notified once they are over.
+func (r Rules) Deferred(m Message, t time.Time) bool {
+	return r.quiet(m, t) && r.notifiable(m)
+}
+
+// quiet returns true if the quiet hours silence the notification of a message received at t.
+func (r Rules) quiet(m Message, t time.Time) bool {
+	sender, hasSender := r.Senders[m.Author]
+	return r.QuietHours != nil && r.QuietHours.Active(t) && !(hasSender && sender.Level == LevelAll)
+}
+
+// notifiable returns true if the level of a message, and its keywords, allow a notification.
+func (r Rules) notifiable(m Message) bool {
+	level := r.PublicChats
+	if m.Direct {
+		level = r.DirectChats
+	}
+	sender, hasSender := r.Senders[m.Author]
+	if hasSender && sender.Level != "" {
+		level = sender.Level
+	}
+
+	switch level {
+	case LevelNone:
+		return false
+	case LevelKeywords:
+		return containsKeyword(m.Content, r.Keywords) || containsKeyword(m.Content, sender.Keywords)
+	default:
+		return true
+	}
+}
+
+// containsKeyword returns true if the content contains one of the keywords as a whole word,
+// ignoring case.
+func containsKeyword(content string, keywords []string) bool {
+	content = strings.ToLower(content)
+	for _, keyword := range keywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		for offset := 0; ; {
+			i := strings.Index(content[offset:], keyword)
+			if i < 0 {
+				break
+			}
+			start, end := offset+i, offset+i+len(keyword)
+			before, _ := utf8.DecodeLastRuneInString(content[:start])
+			after, _ := utf8.DecodeRuneInString(content[end:])
+			if !isWordRune(before) && !isWordRune(after) {
+				return true
+			}
+			offset = start + 1
+		}
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
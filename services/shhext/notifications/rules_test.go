@@ -0,0 +1,71 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRulesNotify(t *testing.T) {
+	noon := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+	rules := Rules{
+		Keywords:    []string{"@alice", "release"},
+		PublicChats: LevelKeywords,
+		Senders: map[string]SenderRule{
+			"0x04b0": {Level: LevelAll},
+			"0x04c0": {Level: LevelNone},
+			"0x04d0": {Keywords: []string{"deploy"}},
+		},
+	}
+
+	require.True(t, rules.Notify(Message{Author: "0x04a0", Direct: true, Content: "hello"}, noon), "1:1 chats notify all messages by default")
+	require.False(t, rules.Notify(Message{Author: "0x04a0", Content: "hello"}, noon))
+	require.True(t, rules.Notify(Message{Author: "0x04a0", Content: "Hi @Alice!"}, noon), "Keywords are matched ignoring case")
+	require.True(t, rules.Notify(Message{Author: "0x04a0", Content: "the release is out"}, noon))
+	require.False(t, rules.Notify(Message{Author: "0x04a0", Content: "released"}, noon), "Keywords are matched as whole words")
+	require.True(t, rules.Notify(Message{Author: "0x04b0", Content: "hello"}, noon), "Senders override the level of the chat")
+	require.False(t, rules.Notify(Message{Author: "0x04c0", Direct: true, Content: "hello"}, noon))
+	require.True(t, rules.Notify(Message{Author: "0x04d0", Content: "deploy now"}, noon), "Senders have keywords of their own")
+	require.False(t, rules.Notify(Message{Author: "0x04a0", Content: "deploy now"}, noon))
+
+	rules.QuietHours = &QuietHours{Start: 22 * 60, End: 7 * 60, UTCOffset: 11 * 60}
+	require.True(t, rules.QuietHours.Active(noon), "It is 23:00 at UTC+11")
+	require.False(t, rules.QuietHours.Active(noon.Add(8*time.Hour)))
+	require.False(t, rules.Notify(Message{Author: "0x04a0", Direct: true, Content: "@alice"}, noon))
+	require.True(t, rules.Notify(Message{Author: "0x04b0", Direct: true, Content: "hello"}, noon), "Senders at LevelAll break through quiet hours")
+	require.True(t, rules.Deferred(Message{Author: "0x04a0", Direct: true, Content: "@alice"}, noon), "Messages silenced by quiet hours are deferred")
+	require.False(t, rules.Deferred(Message{Author: "0x04a0", Content: "hello"}, noon), "Messages that never notify are not deferred")
+	require.False(t, rules.Deferred(Message{Author: "0x04b0", Direct: true, Content: "hello"}, noon))
+	require.False(t, rules.Deferred(Message{Author: "0x04a0", Direct: true, Content: "@alice"}, noon.Add(8*time.Hour)))
+}
+
+func TestRulesValidate(t *testing.T) {
+	require.NoError(t, Rules{}.Validate())
+	require.NoError(t, Rules{DirectChats: LevelNone, QuietHours: &QuietHours{Start: 0, End: 1439, UTCOffset: -300}}.Validate())
+	require.Equal(t, ErrInvalidRules, Rules{PublicChats: "some"}.Validate())
+	require.Equal(t, ErrInvalidRules, Rules{Keywords: []string{" "}}.Validate())
+	require.Equal(t, ErrInvalidRules, Rules{Senders: map[string]SenderRule{"0x04a0": {Level: "most"}}}.Validate())
+	require.Equal(t, ErrInvalidRules, Rules{QuietHours: &QuietHours{Start: 1440}}.Validate())
+	keywords := make([]string, MaxKeywords)
+	for i := range keywords {
+		keywords[i] = "keyword"
+	}
+	require.NoError(t, Rules{Keywords: keywords}.Validate())
+	require.Equal(t, ErrInvalidRules, Rules{Keywords: keywords, Senders: map[string]SenderRule{"0x04a0": {Keywords: []string{"more"}}}}.Validate())
+}
+
+func TestSetRules(t *testing.T) {
+	m := NewManager(&memoryStore{}, nil)
+	_, err := m.Set(Preferences{Sounds: true}, 10)
+	require.NoError(t, err)
+
+	rules := Rules{Keywords: []string{"@alice"}, PublicChats: LevelKeywords}
+	p, err := m.SetRules(rules, 10)
+	require.NoError(t, err)
+	require.Equal(t, Preferences{Sounds: true, Rules: rules, Clock: 11}, p, "Other preferences are kept")
+
+	_, err = m.SetRules(Rules{PublicChats: "some"}, 20)
+	require.Equal(t, ErrInvalidRules, err)
+	require.Equal(t, ErrInvalidRules, m.Apply(Preferences{Rules: Rules{DirectChats: "some"}, Clock: 20}))
+}
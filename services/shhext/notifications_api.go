@@ -0,0 +1,49 @@
+package shhext
+
+import (
+	"context"
+	"time"
+
+	"github.com/status-im/status-go/services/shhext/notifications"
+)
+
+// NotificationsAPI represents a set of APIs from the `notifications` namespace.
+type NotificationsAPI struct {
+	s *Service
+}
+
+// NewNotificationsAPI creates an instance of the notifications API.
+func NewNotificationsAPI(s *Service) *NotificationsAPI {
+	return &NotificationsAPI{s: s}
+}
+
+// SetRules changes the notification rules of the account and syncs them with its paired
+// devices, with the other notification preferences. It returns the rules.
+func (api *NotificationsAPI) SetRules(ctx context.Context, sig string, rules notifications.Rules) (notifications.Rules, error) {
+	if api.s.notifications == nil {
+		return notifications.Rules{}, errProtocolNotInitialized
+	}
+
+	privateKey, err := api.s.w.GetPrivateKey(sig)
+	if err != nil {
+		return notifications.Rules{}, err
+	}
+
+	clock := uint64(api.s.w.GetCurrentTime().UnixNano() / int64(time.Millisecond))
+	preferences, err := api.s.notifications.SetRules(rules, clock)
+	if err != nil {
+		return notifications.Rules{}, err
+	}
+	preferences, err = NewPublicAPI(api.s).syncNotificationPreferences(ctx, sig, privateKey, preferences)
+	return preferences.Rules, err
+}
+
+// GetRules returns the notification rules of the account.
+func (api *NotificationsAPI) GetRules() (notifications.Rules, error) {
+	if api.s.notifications == nil {
+		return notifications.Rules{}, errProtocolNotInitialized
+	}
+
+	preferences, err := api.s.notifications.Preferences()
+	return preferences.Rules, err
+}
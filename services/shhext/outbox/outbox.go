@@ -0,0 +1,149 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/status-im/status-go/services/report"
+	"github.com/status-im/status-go/services/shhext/history"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// DefaultMaxAge is how long the messages of an entry left by a crash are sent at login.
+// Older entries are discarded with their message, which is removed from the history.
+const DefaultMaxAge = 24 * time.Hour
+
+// reporter reports the entries that could not be sent after a crash to the client.
+var reporter = report.New("shhext")
+
+// Entry is a chat message persisted before its envelopes are posted.
+type Entry struct {
+	// ID is the hash of the payloads of the messages.
+	ID string `json:"id"`
+	// Chat is the name of the public chat the messages are posted to, whose symmetric key
+	// is derived again when they are posted after a restart, as Whisper doesn't persist it.
+	Chat string `json:"chat,omitempty"`
+	// Messages are the Whisper messages of the entry, one per device of the recipient
+	// for direct messages.
+	Messages []whisper.NewMessage `json:"messages"`
+	// HistoryID is the ID of the message added to the history with the entry, if any.
+	HistoryID string `json:"historyId,omitempty"`
+	// CreatedAt is the time the entry was persisted, in milliseconds.
+	CreatedAt int64 `json:"createdAt"`
+}
+
+// NewEntry returns an entry posting messages, created now.
+func NewEntry(chat string, messages []whisper.NewMessage) Entry {
+	payloads := make([][]byte, 0, len(messages))
+	for _, msg := range messages {
+		payloads = append(payloads, msg.Payload)
+	}
+	return Entry{
+		ID:        crypto.Keccak256Hash(payloads...).Hex(),
+		Chat:      chat,
+		Messages:  messages,
+		CreatedAt: time.Now().UnixNano() / int64(time.Millisecond),
+	}
+}
+
+// Store persists the outbox.
+type Store interface {
+	// SaveOutboxEntry persists an entry and, in the same transaction, adds its message to
+	// the history, unless it's nil.
+	SaveOutboxEntry(entry Entry, message *history.Message) error
+	// DeleteOutboxEntry deletes an entry whose messages were posted.
+	DeleteOutboxEntry(id string) error
+	// DiscardOutboxEntry deletes an entry whose messages couldn't be posted and, in the
+	// same transaction, removes its message from the history.
+	DiscardOutboxEntry(id string) error
+	// GetOutboxEntries returns the entries, oldest first.
+	GetOutboxEntries() ([]Entry, error)
+}
+
+// PostFunc posts a Whisper message and returns the hashes of its envelopes.
+type PostFunc func(whisper.NewMessage) ([]hexutil.Bytes, error)
+
+// Outbox links the messages we send with the history, so that a message is never in the
+// history without being sent, or sent without being in the history: the message is added
+// to the history with an entry of the outbox, which is deleted once the envelopes are
+// posted. Entries left by a crash are posted again at login.
+type Outbox struct {
+	store Store
+}
+
+// New returns a new Outbox persisted in store.
+func New(store Store) *Outbox {
+	return &Outbox{store: store}
+}
+
+// Send persists the entry with its message and posts its messages. It returns the hashes
+// of the envelopes of each message. If a message can't be posted, the entry is discarded
+// and its message is removed from the history.
+func (o *Outbox) Send(entry Entry, message *history.Message, post PostFunc) ([][]hexutil.Bytes, error) {
+	if message != nil {
+		entry.HistoryID = message.ID
+	}
+	if err := o.store.SaveOutboxEntry(entry, message); err != nil {
+		return nil, err
+	}
+	hashes, err := postAll(entry, post)
+	if err != nil {
+		if discardErr := o.store.DiscardOutboxEntry(entry.ID); discardErr != nil {
+			log.Error("failed to discard outbox entry", "id", entry.ID, "err", discardErr)
+		}
+		return nil, err
+	}
+	if err := o.store.DeleteOutboxEntry(entry.ID); err != nil {
+		log.Error("failed to delete outbox entry", "id", entry.ID, "err", err)
+	}
+	return hashes, nil
+}
+
+// Recover posts the messages of the entries left by a crash, and discards those older
+// than maxAge. prepare is called before posting an entry, so that the keys of its
+// messages can be added to Whisper again. It returns the number of entries posted.
+func (o *Outbox) Recover(now time.Time, maxAge time.Duration, prepare func(*Entry) error, post PostFunc) (int, error) {
+	entries, err := o.store.GetOutboxEntries()
+	if err != nil {
+		return 0, err
+	}
+	oldest := now.Add(-maxAge).UnixNano() / int64(time.Millisecond)
+	posted := 0
+	for i := range entries {
+		entry := &entries[i]
+		if entry.CreatedAt < oldest {
+			log.Warn("discarding expired outbox entry", "id", entry.ID)
+			if err := o.store.DiscardOutboxEntry(entry.ID); err != nil {
+				return posted, err
+			}
+			continue
+		}
+		if err := prepare(entry); err != nil {
+			log.Error("failed to prepare outbox entry", "id", entry.ID, "err", err)
+			continue
+		}
+		if _, err := postAll(*entry, post); err != nil {
+			reporter.Error("post-outbox-entry", err, "id", entry.ID)
+			continue
+		}
+		if err := o.store.DeleteOutboxEntry(entry.ID); err != nil {
+			return posted, err
+		}
+		posted++
+	}
+	return posted, nil
+}
+
+func postAll(entry Entry, post PostFunc) ([][]hexutil.Bytes, error) {
+	hashes := make([][]hexutil.Bytes, 0, len(entry.Messages))
+	for _, msg := range entry.Messages {
+		h, err := post(msg)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+	}
+	return hashes, nil
+}
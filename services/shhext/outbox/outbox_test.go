@@ -0,0 +1,123 @@
+package outbox
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/services/shhext/history"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	entries []Entry
+	history map[string]history.Message
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{history: make(map[string]history.Message)}
+}
+
+func (s *memoryStore) SaveOutboxEntry(entry Entry, message *history.Message) error {
+	if message != nil {
+		s.history[message.ID] = *message
+	}
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *memoryStore) DeleteOutboxEntry(id string) error {
+	for i, entry := range s.entries {
+		if entry.ID == id {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) DiscardOutboxEntry(id string) error {
+	for _, entry := range s.entries {
+		if entry.ID == id {
+			delete(s.history, entry.HistoryID)
+		}
+	}
+	return s.DeleteOutboxEntry(id)
+}
+
+func (s *memoryStore) GetOutboxEntries() ([]Entry, error) {
+	return append([]Entry(nil), s.entries...), nil
+}
+
+type recorder struct {
+	posted []whisper.NewMessage
+	err    error
+}
+
+func (r *recorder) post(msg whisper.NewMessage) ([]hexutil.Bytes, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.posted = append(r.posted, msg)
+	return []hexutil.Bytes{msg.Payload}, nil
+}
+
+func TestSend(t *testing.T) {
+	store := newMemoryStore()
+	o := New(store)
+	messages := []whisper.NewMessage{{Payload: []byte("device 1")}, {Payload: []byte("device 2")}}
+	r := &recorder{}
+
+	hashes, err := o.Send(NewEntry("", messages), &history.Message{ID: "0x01"}, r.post)
+	require.NoError(t, err)
+	require.Equal(t, [][]hexutil.Bytes{{[]byte("device 1")}, {[]byte("device 2")}}, hashes)
+	require.Len(t, r.posted, 2)
+	require.Empty(t, store.entries, "Entries are deleted once posted")
+	require.Contains(t, store.history, "0x01")
+
+	r.err = errors.New("failed")
+	_, err = o.Send(NewEntry("", []whisper.NewMessage{{Payload: []byte("other")}}), &history.Message{ID: "0x02"}, r.post)
+	require.Equal(t, r.err, err)
+	require.Empty(t, store.entries)
+	require.NotContains(t, store.history, "0x02", "Messages that can't be posted are removed from the history")
+}
+
+func TestRecover(t *testing.T) {
+	store := newMemoryStore()
+	now := time.Now()
+	pending := NewEntry("status", []whisper.NewMessage{{Payload: []byte("pending")}})
+	expired := NewEntry("", []whisper.NewMessage{{Payload: []byte("expired")}})
+	expired.CreatedAt = now.Add(-2*time.Hour).UnixNano() / int64(time.Millisecond)
+	require.NoError(t, store.SaveOutboxEntry(pending, nil))
+	expired.HistoryID = "0x01"
+	require.NoError(t, store.SaveOutboxEntry(expired, &history.Message{ID: "0x01"}))
+
+	prepare := func(entry *Entry) error {
+		for i := range entry.Messages {
+			entry.Messages[i].SymKeyID = "key of " + entry.Chat
+		}
+		return nil
+	}
+	r := &recorder{}
+	posted, err := New(store).Recover(now, time.Hour, prepare, r.post)
+	require.NoError(t, err)
+	require.Equal(t, 1, posted)
+	require.Len(t, r.posted, 1)
+	require.Equal(t, []byte("pending"), r.posted[0].Payload)
+	require.Equal(t, "key of status", r.posted[0].SymKeyID, "Entries are prepared before being posted")
+	require.Empty(t, store.entries)
+	require.NotContains(t, store.history, "0x01", "Expired entries are discarded with their message")
+}
+
+func TestRecoverFailure(t *testing.T) {
+	store := newMemoryStore()
+	require.NoError(t, store.SaveOutboxEntry(NewEntry("", []whisper.NewMessage{{Payload: []byte("a")}}), nil))
+
+	r := &recorder{err: errors.New("failed")}
+	posted, err := New(store).Recover(time.Now(), time.Hour, func(*Entry) error { return nil }, r.post)
+	require.NoError(t, err)
+	require.Zero(t, posted)
+	require.Len(t, store.entries, 1, "Entries that can't be posted are kept for the next login")
+}
@@ -0,0 +1,17 @@
+package shhext
+
+import (
+	"github.com/status-im/status-go/services/shhext/ratelimit"
+)
+
+// DefaultNetworkBudgets is the key of the outgoing rate limits of the network types
+// without their own.
+const DefaultNetworkBudgets = "default"
+
+// outgoingBudgets returns the budgets of the envelopes sent on a network type.
+func (s *Service) outgoingBudgets(networkType string) ratelimit.Budgets {
+	if budgets, ok := s.config.OutgoingRateLimits[networkType]; ok {
+		return budgets
+	}
+	return s.config.OutgoingRateLimits[DefaultNetworkBudgets]
+}
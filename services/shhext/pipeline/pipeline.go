@@ -0,0 +1,185 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// Stage is a step of the processing of the messages received by the service.
+type Stage int
+
+// Stages run in this order.
+const (
+	// PreDecrypt runs before the segments are reassembled and the chat protocol
+	// decrypts the messages, once the duplicates and the messages of blocked
+	// identities are dropped.
+	PreDecrypt Stage = iota
+	// PostDecrypt runs once the messages are decrypted, before they are moderated.
+	PostDecrypt
+	// PrePersist runs before attachments, read receipts and chat messages are stored.
+	PrePersist
+	// PreSignal runs before the translation and notification signals are sent and
+	// the messages are returned.
+	PreSignal
+)
+
+var stageNames = map[Stage]string{
+	PreDecrypt:  "pre-decrypt",
+	PostDecrypt: "post-decrypt",
+	PrePersist:  "pre-persist",
+	PreSignal:   "pre-signal",
+}
+
+func (s Stage) String() string {
+	if name, ok := stageNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("stage(%d)", int(s))
+}
+
+// Hook processes a message at a stage. It can change the message, and returns
+// false to drop it, for instance if it handled a payload type of its own. A message
+// is kept if the hook fails.
+type Hook func(msg *whisper.Message) (keep bool, err error)
+
+// Plugin is a self-contained module hooked into the processing of messages.
+type Plugin interface {
+	// Name identifies the plugin.
+	Name() string
+	// Hooks returns the hooks of the plugin for each stage it is interested in.
+	Hooks() map[Stage]Hook
+}
+
+var (
+	// ErrPluginExists is returned when a plugin with the same name is registered.
+	ErrPluginExists = errors.New("plugin already registered")
+	// ErrPluginNotFound is returned when a plugin that is not registered is removed.
+	ErrPluginNotFound = errors.New("plugin not registered")
+	// ErrUnknownStage is returned when a plugin has hooks for an unknown stage.
+	ErrUnknownStage = errors.New("unknown stage")
+)
+
+type hook struct {
+	plugin string
+	run    Hook
+}
+
+// Pipeline runs the hooks of the registered plugins at each stage, in the order
+// the plugins were registered.
+type Pipeline struct {
+	mu      sync.RWMutex
+	plugins []string
+	hooks   map[Stage][]hook
+}
+
+// New returns a pipeline without plugins.
+func New() *Pipeline {
+	return &Pipeline{hooks: make(map[Stage][]hook)}
+}
+
+// Register adds the hooks of a plugin after those of the plugins already registered.
+func (p *Pipeline) Register(plugin Plugin) error {
+	name := plugin.Name()
+	hooks := plugin.Hooks()
+	for stage := range hooks {
+		if _, ok := stageNames[stage]; !ok {
+			return fmt.Errorf("%v: %v", ErrUnknownStage, stage)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, registered := range p.plugins {
+		if registered == name {
+			return ErrPluginExists
+		}
+	}
+	p.plugins = append(p.plugins, name)
+	for stage, run := range hooks {
+		if run != nil {
+			p.hooks[stage] = append(p.hooks[stage], hook{plugin: name, run: run})
+		}
+	}
+	return nil
+}
+
+// Unregister removes the hooks of a plugin.
+func (p *Pipeline) Unregister(name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	found := false
+	for i, registered := range p.plugins {
+		if registered == name {
+			p.plugins = append(p.plugins[:i], p.plugins[i+1:]...)
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrPluginNotFound
+	}
+	for stage, hooks := range p.hooks {
+		kept := hooks[:0:0]
+		for _, h := range hooks {
+			if h.plugin != name {
+				kept = append(kept, h)
+			}
+		}
+		p.hooks[stage] = kept
+	}
+	return nil
+}
+
+// Plugins returns the names of the registered plugins, in the order they run.
+func (p *Pipeline) Plugins() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]string(nil), p.plugins...)
+}
+
+// Run runs the hooks of a stage on each message, and returns the messages that
+// were not dropped. A message dropped by a hook is not passed to the next ones.
+func (p *Pipeline) Run(stage Stage, messages []*whisper.Message) []*whisper.Message {
+	p.mu.RLock()
+	hooks := p.hooks[stage]
+	p.mu.RUnlock()
+	if len(hooks) == 0 {
+		return messages
+	}
+
+	result := make([]*whisper.Message, 0, len(messages))
+	for _, msg := range messages {
+		keep := true
+		for _, h := range hooks {
+			if keep = run(stage, h, msg); !keep {
+				break
+			}
+		}
+		if keep {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// run runs a hook, keeping the message if the hook fails or panics, so that an
+// experimental plugin can't break the processing of messages.
+func run(stage Stage, h hook, msg *whisper.Message) (keep bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("plugin hook panicked", "plugin", h.plugin, "stage", stage, "hash", hexutil.Encode(msg.Hash), "panic", r)
+			keep = true
+		}
+	}()
+	keep, err := h.run(msg)
+	if err != nil {
+		log.Error("plugin hook failed", "plugin", h.plugin, "stage", stage, "hash", hexutil.Encode(msg.Hash), "err", err)
+		return true
+	}
+	return keep
+}
@@ -0,0 +1,101 @@
+package pipeline
+
+import (
+	"errors"
+	"testing"
+
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+)
+
+type testPlugin struct {
+	name  string
+	hooks map[Stage]Hook
+}
+
+func (p testPlugin) Name() string          { return p.name }
+func (p testPlugin) Hooks() map[Stage]Hook { return p.hooks }
+
+// appending returns a hook that appends a byte to the payload of messages.
+func appending(b byte) Hook {
+	return func(msg *whisper.Message) (bool, error) {
+		msg.Payload = append(msg.Payload, b)
+		return true, nil
+	}
+}
+
+func newMessages(payloads ...string) []*whisper.Message {
+	messages := make([]*whisper.Message, len(payloads))
+	for i, payload := range payloads {
+		messages[i] = &whisper.Message{Payload: []byte(payload), Hash: []byte{byte(i)}}
+	}
+	return messages
+}
+
+func TestRunOrder(t *testing.T) {
+	p := New()
+	require.NoError(t, p.Register(testPlugin{name: "a", hooks: map[Stage]Hook{
+		PreDecrypt: appending('a'),
+		PreSignal:  appending('A'),
+	}}))
+	require.NoError(t, p.Register(testPlugin{name: "b", hooks: map[Stage]Hook{
+		PreDecrypt: appending('b'),
+	}}))
+	require.Equal(t, []string{"a", "b"}, p.Plugins())
+
+	messages := p.Run(PreDecrypt, newMessages("x"))
+	messages = p.Run(PostDecrypt, messages)
+	messages = p.Run(PreSignal, messages)
+	require.Len(t, messages, 1)
+	require.Equal(t, "xabA", string(messages[0].Payload))
+}
+
+func TestRunDrop(t *testing.T) {
+	p := New()
+	var seen []string
+	require.NoError(t, p.Register(testPlugin{name: "consumer", hooks: map[Stage]Hook{
+		PostDecrypt: func(msg *whisper.Message) (bool, error) {
+			return string(msg.Payload) != "experimental", nil
+		},
+	}}))
+	require.NoError(t, p.Register(testPlugin{name: "observer", hooks: map[Stage]Hook{
+		PostDecrypt: func(msg *whisper.Message) (bool, error) {
+			seen = append(seen, string(msg.Payload))
+			return true, nil
+		},
+	}}))
+
+	messages := p.Run(PostDecrypt, newMessages("regular", "experimental"))
+	require.Len(t, messages, 1)
+	require.Equal(t, "regular", string(messages[0].Payload))
+	require.Equal(t, []string{"regular"}, seen, "Dropped messages are not passed to the next hooks")
+}
+
+func TestRunFailures(t *testing.T) {
+	p := New()
+	require.NoError(t, p.Register(testPlugin{name: "failing", hooks: map[Stage]Hook{
+		PrePersist: func(msg *whisper.Message) (bool, error) {
+			return false, errors.New("failed")
+		},
+	}}))
+	require.NoError(t, p.Register(testPlugin{name: "panicking", hooks: map[Stage]Hook{
+		PrePersist: func(msg *whisper.Message) (bool, error) {
+			panic("experiment went wrong")
+		},
+	}}))
+	require.Len(t, p.Run(PrePersist, newMessages("x", "y")), 2, "Messages are kept if hooks fail")
+}
+
+func TestRegistration(t *testing.T) {
+	p := New()
+	require.NoError(t, p.Register(testPlugin{name: "a", hooks: map[Stage]Hook{PreDecrypt: appending('a')}}))
+	require.Equal(t, ErrPluginExists, p.Register(testPlugin{name: "a"}))
+	require.Error(t, p.Register(testPlugin{name: "b", hooks: map[Stage]Hook{Stage(10): appending('b')}}))
+	require.Equal(t, []string{"a"}, p.Plugins())
+
+	require.NoError(t, p.Unregister("a"))
+	require.Equal(t, ErrPluginNotFound, p.Unregister("a"))
+	require.Empty(t, p.Plugins())
+	messages := p.Run(PreDecrypt, newMessages("x"))
+	require.Equal(t, "x", string(messages[0].Payload))
+}
@@ -0,0 +1,25 @@
+package shhext
+
+import (
+	"github.com/status-im/status-go/services/shhext/pipeline"
+)
+
+// RegisterPlugin hooks a plugin into the processing of the messages received by
+// GetNewFilterMessages. Its hooks run after those of the plugins already registered.
+func (s *Service) RegisterPlugin(plugin pipeline.Plugin) error {
+	if err := s.pipeline.Register(plugin); err != nil {
+		return err
+	}
+	logger.Info("registered message pipeline plugin", "plugin", plugin.Name())
+	return nil
+}
+
+// UnregisterPlugin removes the hooks of a plugin.
+func (s *Service) UnregisterPlugin(name string) error {
+	return s.pipeline.Unregister(name)
+}
+
+// Plugins returns the names of the registered plugins, in the order they run.
+func (s *Service) Plugins() []string {
+	return s.pipeline.Plugins()
+}
@@ -0,0 +1,72 @@
+package shhext
+
+import (
+	"container/list"
+	"sync"
+
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+const (
+	// DefaultMailServerPoolSize is the number of MailServers a request is sent to
+	// by RequestMessagesFromPool if the pool size is not configured.
+	DefaultMailServerPoolSize = 3
+
+	// defaultRecentEnvelopes is the number of envelopes remembered to drop
+	// the copies sent by several MailServers.
+	defaultRecentEnvelopes = 10000
+)
+
+func (s *Service) poolSize() int {
+	if s.config.MailServerPoolSize > 0 {
+		return s.config.MailServerPoolSize
+	}
+	return DefaultMailServerPoolSize
+}
+
+// recentEnvelopes remembers the envelopes recently returned by each filter,
+// so that an envelope sent by several MailServers is returned once.
+type recentEnvelopes struct {
+	size int
+
+	mu    sync.Mutex
+	seen  map[string]struct{}
+	order *list.List
+}
+
+func newRecentEnvelopes(size int) *recentEnvelopes {
+	return &recentEnvelopes{
+		size:  size,
+		seen:  make(map[string]struct{}),
+		order: list.New(),
+	}
+}
+
+// Deduplicate returns the messages whose envelopes weren't returned recently by the filter.
+func (r *recentEnvelopes) Deduplicate(filterID string, messages []*whisper.Message) []*whisper.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]*whisper.Message, 0, len(messages))
+	for _, message := range messages {
+		key := filterID + string(message.Hash)
+		if _, ok := r.seen[key]; ok {
+			continue
+		}
+		r.seen[key] = struct{}{}
+		r.order.PushBack(key)
+		if r.order.Len() > r.size {
+			delete(r.seen, r.order.Remove(r.order.Front()).(string))
+		}
+		result = append(result, message)
+	}
+	return result
+}
+
+// Reset forgets the envelopes returned by the filters.
+func (r *recentEnvelopes) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen = make(map[string]struct{})
+	r.order.Init()
+}
@@ -0,0 +1,57 @@
+package shhext
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentEnvelopesDeduplicate(t *testing.T) {
+	recent := newRecentEnvelopes(2)
+	first := &whisper.Message{Hash: []byte{1}}
+	second := &whisper.Message{Hash: []byte{2}}
+	third := &whisper.Message{Hash: []byte{3}}
+
+	require.Equal(t, []*whisper.Message{first, second}, recent.Deduplicate("filter", []*whisper.Message{first, second, first}))
+	require.Empty(t, recent.Deduplicate("filter", []*whisper.Message{second}))
+	require.Len(t, recent.Deduplicate("other", []*whisper.Message{second}), 1, "It is keyed by filter")
+
+	recent.Deduplicate("filter", []*whisper.Message{third})
+	require.Len(t, recent.Deduplicate("filter", []*whisper.Message{first}), 1, "It forgets the oldest envelopes")
+}
+
+func TestWaitForPageFirstSuccess(t *testing.T) {
+	api := &PublicAPI{}
+	failed, expired, slow, fast := common.Hash{1}, common.Hash{2}, common.Hash{3}, common.Hash{4}
+	events := make(chan whisper.EnvelopeEvent, 10)
+	events <- whisper.EnvelopeEvent{Event: whisper.EventMailServerRequestCompleted, Hash: failed,
+		Data: &whisper.MailServerResponse{Error: errors.New("failed")}}
+	events <- whisper.EnvelopeEvent{Event: whisper.EventMailServerRequestExpired, Hash: expired}
+	events <- whisper.EnvelopeEvent{Event: whisper.EventMailServerRequestCompleted, Hash: common.Hash{5},
+		Data: &whisper.MailServerResponse{}}
+	events <- whisper.EnvelopeEvent{Event: whisper.EventMailServerRequestCompleted, Hash: fast,
+		Data: &whisper.MailServerResponse{LastEnvelopeHash: common.Hash{6}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	page, err := api.waitForPage(ctx, events, MessagesRequest{}, []common.Hash{failed, expired, slow, fast})
+	require.NoError(t, err)
+	require.Equal(t, fast.Bytes(), []byte(page.RequestID))
+	require.Equal(t, common.Hash{6}, page.LastEnvelopeHash)
+	require.Empty(t, page.Cursor, "There are no more pages")
+}
+
+func TestWaitForPageAllFailed(t *testing.T) {
+	api := &PublicAPI{}
+	events := make(chan whisper.EnvelopeEvent, 10)
+	events <- whisper.EnvelopeEvent{Event: whisper.EventMailServerRequestExpired, Hash: common.Hash{1}}
+	events <- whisper.EnvelopeEvent{Event: whisper.EventMailServerRequestExpired, Hash: common.Hash{2}}
+
+	_, err := api.waitForPage(context.Background(), events, MessagesRequest{}, []common.Hash{{1}, {2}})
+	require.Equal(t, ErrRequestExpired, err)
+}
@@ -0,0 +1,73 @@
+package pow
+
+import (
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// DefaultMaxQueue is the number of queued envelopes from which the minimum target is used.
+	DefaultMaxQueue = 100
+	// lowQueueRatio is the fraction of MaxQueue up to which the requested target is used.
+	lowQueueRatio = 10
+)
+
+var (
+	queueGauge     = metrics.NewRegisteredGauge("shhext/pow/queue", nil)
+	targetGauge    = metrics.NewRegisteredGaugeFloat64("shhext/pow/target", nil)
+	loweredCounter = metrics.NewRegisteredCounter("shhext/pow/lowered", nil)
+)
+
+// Config is the configuration of an Adapter.
+type Config struct {
+	// MinTarget is the lowest target. Peers drop envelopes with a lower PoW
+	// than they require, so it must not be lower than the minimum PoW of the network.
+	MinTarget float64
+	// MaxTarget caps the requested targets. Zero means targets are not capped.
+	MaxTarget float64
+	// MaxQueue is the number of queued envelopes from which MinTarget is used.
+	MaxQueue int
+}
+
+// Adapter lowers the PoW target of outgoing envelopes while envelopes are queued
+// and restores it as the queue drains, spending less CPU per envelope so that
+// a backlog is posted sooner.
+type Adapter struct {
+	config Config
+	queue  func() int
+}
+
+// NewAdapter returns a new Adapter. queue returns the number of envelopes
+// posted but not confirmed as sent yet.
+func NewAdapter(config Config, queue func() int) *Adapter {
+	if config.MaxQueue <= 0 {
+		config.MaxQueue = DefaultMaxQueue
+	}
+	return &Adapter{config: config, queue: queue}
+}
+
+// Target returns the target to use for an envelope instead of the requested one.
+// The requested target is used while at most MaxQueue/10 envelopes are queued, and is
+// lowered linearly to MinTarget as the queue grows to MaxQueue.
+func (a *Adapter) Target(requested float64) float64 {
+	target := requested
+	if a.config.MaxTarget > 0 && target > a.config.MaxTarget {
+		target = a.config.MaxTarget
+	}
+	if target <= a.config.MinTarget {
+		return target
+	}
+
+	queue := a.queue()
+	queueGauge.Update(int64(queue))
+	low := a.config.MaxQueue / lowQueueRatio
+	if queue > low {
+		if ratio := float64(queue-low) / float64(a.config.MaxQueue-low); ratio < 1 {
+			target -= (target - a.config.MinTarget) * ratio
+		} else {
+			target = a.config.MinTarget
+		}
+		loweredCounter.Inc(1)
+	}
+	targetGauge.Update(target)
+	return target
+}
@@ -0,0 +1,26 @@
+package pow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdapterTarget(t *testing.T) {
+	queue := 0
+	a := NewAdapter(Config{MinTarget: 0.001, MaxTarget: 0.1, MaxQueue: 100}, func() int { return queue })
+
+	require.Equal(t, 0.01, a.Target(0.01))
+	require.Equal(t, 0.1, a.Target(1), "Requested targets are capped")
+	require.Equal(t, 0.0001, a.Target(0.0001), "Targets lower than the minimum are not changed")
+
+	queue = 10
+	require.Equal(t, 0.01, a.Target(0.01), "Requested target is used while few envelopes are queued")
+	queue = 55
+	require.InDelta(t, 0.0055, a.Target(0.01), 1e-9)
+	queue = 1000
+	require.Equal(t, 0.001, a.Target(0.01))
+
+	queue = 0
+	require.Equal(t, 0.01, a.Target(0.01), "Requested target is restored once the queue drained")
+}
@@ -0,0 +1,200 @@
+package presence
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTypingInterval is the minimum interval between two typing notifications
+	// sent to or received from the same contact.
+	DefaultTypingInterval = 3 * time.Second
+	// maxTypingEntries is the number of contacts above which the expired
+	// typing notifications are pruned.
+	maxTypingEntries = 1000
+)
+
+// ContactSettings override the settings of the account for a contact.
+// Nil values use the setting of the account.
+type ContactSettings struct {
+	ReadReceipts *bool `json:"readReceipts,omitempty"`
+	Typing       *bool `json:"typing,omitempty"`
+}
+
+// Settings are the privacy settings of the account for read receipts and typing notifications.
+type Settings struct {
+	// ReadReceipts sends read receipts to contacts.
+	ReadReceipts bool `json:"readReceipts"`
+	// Typing sends typing notifications to contacts.
+	Typing bool `json:"typing"`
+	// Contacts are the overrides of the contacts, by hex-encoded public key.
+	Contacts map[string]ContactSettings `json:"contacts,omitempty"`
+}
+
+// DefaultSettings are the settings of accounts that never changed them.
+func DefaultSettings() Settings {
+	return Settings{ReadReceipts: true, Typing: true}
+}
+
+// ReadReceiptsEnabled returns true if read receipts are sent to a contact.
+func (s Settings) ReadReceiptsEnabled(contact string) bool {
+	if overrides, ok := s.Contacts[contact]; ok && overrides.ReadReceipts != nil {
+		return *overrides.ReadReceipts
+	}
+	return s.ReadReceipts
+}
+
+// TypingEnabled returns true if typing notifications are sent to a contact.
+func (s Settings) TypingEnabled(contact string) bool {
+	if overrides, ok := s.Contacts[contact]; ok && overrides.Typing != nil {
+		return *overrides.Typing
+	}
+	return s.Typing
+}
+
+// Receipt records that a contact read a message.
+type Receipt struct {
+	MessageID string `json:"messageId"`
+	Reader    string `json:"reader"`
+	// Clock is the clock value of the read receipt.
+	Clock uint64 `json:"clock"`
+}
+
+// Store persists the settings and the read receipts received.
+// Typing notifications are never persisted.
+type Store interface {
+	SavePresenceSettings(Settings) error
+	// GetPresenceSettings returns the settings, or nil if they were never saved.
+	GetPresenceSettings() (*Settings, error)
+	// SaveReadReceipt saves a read receipt, unless the reader already read the message.
+	SaveReadReceipt(Receipt) error
+	// GetReadReceipts returns the read receipts of a message.
+	GetReadReceipts(messageID string) ([]Receipt, error)
+}
+
+// Manager applies the privacy settings to read receipts and typing notifications,
+// and rate limits typing notifications in both directions.
+type Manager struct {
+	store    Store
+	interval time.Duration
+
+	mu       sync.Mutex
+	settings *Settings
+	sent     map[string]time.Time
+	received map[string]time.Time
+}
+
+// NewManager returns a new Manager rate limiting typing notifications to one per interval and contact.
+func NewManager(store Store, interval time.Duration) *Manager {
+	return &Manager{
+		store:    store,
+		interval: interval,
+		sent:     make(map[string]time.Time),
+		received: make(map[string]time.Time),
+	}
+}
+
+// Settings returns the settings of the account.
+func (m *Manager) Settings() (Settings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.current()
+}
+
+// SetSettings changes the settings of the account.
+func (m *Manager) SetSettings(s Settings) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.store.SavePresenceSettings(s); err != nil {
+		return err
+	}
+	m.settings = &s
+	return nil
+}
+
+// AllowReadReceipt returns true if read receipts can be sent to a contact.
+func (m *Manager) AllowReadReceipt(contact string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.current()
+	if err != nil {
+		return false, err
+	}
+	return s.ReadReceiptsEnabled(contact), nil
+}
+
+// AllowTyping returns true if a typing notification can be sent to a contact now.
+// Notifications that the user stopped typing are always allowed if typing
+// notifications are enabled, and reset the rate limit.
+func (m *Manager) AllowTyping(contact string, typing bool, now time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.current()
+	if err != nil {
+		return false, err
+	}
+	if !s.TypingEnabled(contact) {
+		return false, nil
+	}
+	return m.allow(m.sent, contact, typing, now), nil
+}
+
+// ReceiveTyping returns true if a typing notification received from a contact must be
+// notified, or false if it's too close to the previous one.
+func (m *Manager) ReceiveTyping(contact string, typing bool, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.allow(m.received, contact, typing, now)
+}
+
+// ReceiveReadReceipt records that a contact read messages.
+func (m *Manager) ReceiveReadReceipt(reader string, messageIDs []string, clock uint64) error {
+	for _, id := range messageIDs {
+		if err := m.store.SaveReadReceipt(Receipt{MessageID: id, Reader: reader, Clock: clock}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadReceipts returns the read receipts received for a message.
+func (m *Manager) ReadReceipts(messageID string) ([]Receipt, error) {
+	return m.store.GetReadReceipts(messageID)
+}
+
+// allow must be called with the lock held.
+func (m *Manager) allow(last map[string]time.Time, contact string, typing bool, now time.Time) bool {
+	if !typing {
+		delete(last, contact)
+		return true
+	}
+	if previous, ok := last[contact]; ok && now.Sub(previous) < m.interval {
+		return false
+	}
+	if len(last) >= maxTypingEntries {
+		for c, t := range last {
+			if now.Sub(t) >= m.interval {
+				delete(last, c)
+			}
+		}
+	}
+	last[contact] = now
+	return true
+}
+
+// current must be called with the lock held.
+func (m *Manager) current() (Settings, error) {
+	if m.settings != nil {
+		return *m.settings, nil
+	}
+	s, err := m.store.GetPresenceSettings()
+	if err != nil {
+		return Settings{}, err
+	}
+	if s == nil {
+		defaults := DefaultSettings()
+		s = &defaults
+	}
+	m.settings = s
+	return *s, nil
+}
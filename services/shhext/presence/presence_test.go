@@ -0,0 +1,124 @@
+package presence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	settings *Settings
+	receipts []Receipt
+}
+
+func (s *memoryStore) SavePresenceSettings(settings Settings) error {
+	s.settings = &settings
+	return nil
+}
+
+func (s *memoryStore) GetPresenceSettings() (*Settings, error) {
+	return s.settings, nil
+}
+
+func (s *memoryStore) SaveReadReceipt(r Receipt) error {
+	for _, existing := range s.receipts {
+		if existing.MessageID == r.MessageID && existing.Reader == r.Reader {
+			return nil
+		}
+	}
+	s.receipts = append(s.receipts, r)
+	return nil
+}
+
+func (s *memoryStore) GetReadReceipts(messageID string) ([]Receipt, error) {
+	var result []Receipt
+	for _, r := range s.receipts {
+		if r.MessageID == messageID {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+func TestSettings(t *testing.T) {
+	store := &memoryStore{}
+	m := NewManager(store, time.Second)
+
+	settings, err := m.Settings()
+	require.NoError(t, err)
+	require.Equal(t, DefaultSettings(), settings)
+
+	disabled, enabled := false, true
+	require.NoError(t, m.SetSettings(Settings{
+		ReadReceipts: true,
+		Contacts: map[string]ContactSettings{
+			"0x01": {ReadReceipts: &disabled},
+			"0x02": {Typing: &enabled},
+		},
+	}))
+	require.NotNil(t, store.settings)
+
+	for _, tc := range []struct {
+		contact      string
+		readReceipts bool
+		typing       bool
+	}{
+		{"0x01", false, false},
+		{"0x02", true, true},
+		{"0x03", true, false},
+	} {
+		allowed, err := m.AllowReadReceipt(tc.contact)
+		require.NoError(t, err)
+		require.Equal(t, tc.readReceipts, allowed, tc.contact)
+		allowed, err = m.AllowTyping(tc.contact, true, time.Now())
+		require.NoError(t, err)
+		require.Equal(t, tc.typing, allowed, tc.contact)
+	}
+
+	settings, err = NewManager(store, time.Second).Settings()
+	require.NoError(t, err)
+	require.Equal(t, *store.settings, settings, "Settings are loaded from the store")
+}
+
+func TestTypingRateLimit(t *testing.T) {
+	m := NewManager(&memoryStore{}, time.Second)
+	now := time.Now()
+
+	for _, tc := range []struct {
+		offset  time.Duration
+		typing  bool
+		allowed bool
+	}{
+		{0, true, true},
+		{500 * time.Millisecond, true, false},
+		{time.Second, true, true},
+		{1100 * time.Millisecond, false, true},
+		{1200 * time.Millisecond, false, true},
+		{1300 * time.Millisecond, true, true},
+	} {
+		allowed, err := m.AllowTyping("0x01", tc.typing, now.Add(tc.offset))
+		require.NoError(t, err)
+		require.Equal(t, tc.allowed, allowed, "sent at %s", tc.offset)
+		require.Equal(t, tc.allowed, m.ReceiveTyping("0x01", tc.typing, now.Add(tc.offset)), "received at %s", tc.offset)
+	}
+
+	allowed, err := m.AllowTyping("0x02", true, now.Add(500*time.Millisecond))
+	require.NoError(t, err)
+	require.True(t, allowed, "Contacts are rate limited separately")
+}
+
+func TestReadReceipts(t *testing.T) {
+	m := NewManager(&memoryStore{}, time.Second)
+
+	require.NoError(t, m.ReceiveReadReceipt("0x01", []string{"a", "b"}, 1))
+	require.NoError(t, m.ReceiveReadReceipt("0x01", []string{"a"}, 2))
+	require.NoError(t, m.ReceiveReadReceipt("0x02", []string{"a"}, 3))
+
+	receipts, err := m.ReadReceipts("a")
+	require.NoError(t, err)
+	require.Equal(t, []Receipt{
+		{MessageID: "a", Reader: "0x01", Clock: 1},
+		{MessageID: "a", Reader: "0x02", Clock: 3},
+	}, receipts)
+}
@@ -0,0 +1,123 @@
+package shhext
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"path/filepath"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// ErrProfileExists is returned when an account is logged in twice.
+	ErrProfileExists = errors.New("account is already logged in")
+	// ErrProfileNotFound is returned when an account is not logged in as a profile.
+	ErrProfileNotFound = errors.New("account is not logged in as a profile")
+	// ErrProfilesNarrowBloomFilter is returned when a profile is opened while the
+	// bloom filter advertised to peers only matches the active chats of the selected account.
+	ErrProfilesNarrowBloomFilter = errors.New("profiles can't be used with a narrow bloom filter")
+)
+
+// OpenProfile logs in an account alongside the selected one, with its chat identity.
+// The profile has its own Whisper identity, chat database, protocol and stores, and
+// shares the peers, MailServers, retries and plugins of the service. The identity of
+// the selected account must be set with SetKeyPairID.
+func (s *Service) OpenProfile(address, password string, identity *ecdsa.PrivateKey) (*Service, error) {
+	if !s.pfsEnabled {
+		return nil, ErrPFSNotEnabled
+	}
+	if s.config.NarrowBloomFilter {
+		return nil, ErrProfilesNarrowBloomFilter
+	}
+
+	key := common.HexToAddress(address).Hex()
+	s.profilesMu.Lock()
+	defer s.profilesMu.Unlock()
+	if _, exist := s.profiles[key]; exist || filepath.Base(s.chatDBPath(address)) == s.chatDBName {
+		return nil, ErrProfileExists
+	}
+
+	keyPairID, err := s.w.AddKeyPair(identity)
+	if err != nil {
+		return nil, err
+	}
+	profile := New(s.w, s.handler, s.db, s.config)
+	profile.keyPairID = keyPairID
+	profile.tracker.skipRequests = true
+	profile.retries = s.retries
+	profile.translations = s.translations
+	profile.pipeline = s.pipeline
+	profile.nodeID = s.nodeID
+	profile.server = s.server
+	profile.picker = s.picker
+	profile.connManager = s.connManager
+	if err := profile.InitProtocol(address, password); err != nil {
+		s.w.DeleteKeyPair(keyPairID)
+		return nil, err
+	}
+	profile.tracker.Start()
+	profile.archival.Start()
+	profile.integrity.Start()
+	profile.reencryption.Start()
+
+	s.profiles[key] = profile
+	logger.Info("profile opened", "address", key)
+	return profile, nil
+}
+
+// CloseProfile logs out an account logged in with OpenProfile.
+func (s *Service) CloseProfile(address string) error {
+	key := common.HexToAddress(address).Hex()
+	s.profilesMu.Lock()
+	profile, exist := s.profiles[key]
+	delete(s.profiles, key)
+	s.profilesMu.Unlock()
+	if !exist {
+		return ErrProfileNotFound
+	}
+	logger.Info("closing profile", "address", key)
+	s.w.DeleteKeyPair(profile.SelectedKeyPairID())
+	return profile.stopProfile()
+}
+
+// Profile returns the service of an account logged in with OpenProfile.
+func (s *Service) Profile(address string) (*Service, error) {
+	s.profilesMu.Lock()
+	defer s.profilesMu.Unlock()
+	profile, exist := s.profiles[common.HexToAddress(address).Hex()]
+	if !exist {
+		return nil, ErrProfileNotFound
+	}
+	return profile, nil
+}
+
+// Profiles returns the addresses of the accounts logged in with OpenProfile.
+func (s *Service) Profiles() []string {
+	s.profilesMu.Lock()
+	defer s.profilesMu.Unlock()
+	addresses := make([]string, 0, len(s.profiles))
+	for address := range s.profiles {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// closeProfiles logs out all the profiles.
+func (s *Service) closeProfiles() {
+	for _, address := range s.Profiles() {
+		if err := s.CloseProfile(address); err != nil {
+			logger.Error("failed to close profile", "address", address, "err", err)
+		}
+	}
+}
+
+// stopProfile stops the components started by OpenProfile and closes the chat database.
+func (s *Service) stopProfile() error {
+	s.reencryption.Stop()
+	s.archival.Stop()
+	s.integrity.Stop()
+	s.tracker.Stop()
+	return s.CloseProtocol()
+}
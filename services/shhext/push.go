@@ -0,0 +1,183 @@
+package shhext
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/services/shhext/chat"
+	"github.com/status-im/status-go/services/shhext/history"
+	"github.com/status-im/status-go/services/shhext/push"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+const (
+	pushServerInterval = 200 * time.Millisecond
+	pushServerName     = "push-server"
+)
+
+// pushServer delivers the messages sent to the push notification server.
+type pushServer struct {
+	w        *whisper.Whisper
+	server   *push.Server
+	identity *builtinIdentity
+	wg       sync.WaitGroup
+	quit     chan struct{}
+}
+
+// startPushServer starts the push notification server with its own identity. The
+// registrations are kept with its protocol state.
+func (s *Service) startPushServer() error {
+	dispatcher, err := push.NewGorushDispatcher(s.config.PushServer.GorushURL)
+	if err != nil {
+		return err
+	}
+	identity, err := s.newBuiltinIdentity(pushServerName, s.config.PushServerKey)
+	if err != nil {
+		return err
+	}
+	p := &pushServer{
+		w:        s.w,
+		server:   push.NewServer(identity.key, identity.protocol, identity.transport, identity.persistence.PushStore(), dispatcher),
+		identity: identity,
+		quit:     make(chan struct{}),
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(pushServerInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.quit:
+				return
+			case <-ticker.C:
+				p.poll()
+			}
+		}
+	}()
+	s.pushServer = p
+	logger.Info("started push notification server", "publicKey", fmt.Sprintf("%#x", crypto.FromECDSAPub(&identity.key.PublicKey)))
+	return nil
+}
+
+func (p *pushServer) poll() {
+	f := p.w.GetFilter(p.identity.filter)
+	if f == nil {
+		return
+	}
+	for _, msg := range f.Retrieve() {
+		if msg.Src == nil {
+			continue
+		}
+		if err := p.server.Handle(msg.Src, msg.Payload); err != nil {
+			logger.Debug("push notification server failed to handle message", "hash", msg.EnvelopeHash, "err", err)
+		}
+	}
+}
+
+// Stop uninstalls the filter.
+func (p *pushServer) Stop() {
+	close(p.quit)
+	p.wg.Wait()
+	p.w.Unsubscribe(p.identity.filter) // nolint: errcheck
+}
+
+// pushClientTransport posts the messages of the push notification client to whisper,
+// signed by the selected account. Servers are accepted as contacts, like the recipients
+// of direct messages, so that their responses are not quarantined.
+type pushClientTransport struct {
+	s *Service
+}
+
+func (t pushClientTransport) Send(recipient *ecdsa.PublicKey, message []byte) error {
+	if t.s.protocol == nil {
+		return errProtocolNotInitialized
+	}
+	msg := chat.DirectMessageToWhisper(chat.SendDirectMessageRPC{
+		Sig:    t.s.SelectedKeyPairID(),
+		PubKey: crypto.FromECDSAPub(recipient),
+	}, message)
+	msg.Topic = t.s.protocol.DirectMessageTopic(recipient)
+	if _, err := whisper.NewPublicWhisperAPI(t.s.w).Post(context.Background(), t.s.adaptPoW(msg)); err != nil {
+		return err
+	}
+	t.s.acceptContact(crypto.FromECDSAPub(recipient))
+	return nil
+}
+
+// pushContacts returns the public keys of the contacts of the account, which are allowed
+// to notify it when it only allows its contacts.
+func (s *Service) pushContacts() ([]*ecdsa.PublicKey, error) {
+	if s.contacts == nil {
+		return nil, errProtocolNotInitialized
+	}
+	all, err := s.contacts.Contacts()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]*ecdsa.PublicKey, 0, len(all))
+	for _, contact := range all {
+		data, err := hexutil.Decode(contact.PublicKey)
+		if err != nil {
+			continue
+		}
+		if key, err := crypto.UnmarshalPubkey(data); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// notifyPush asks the push notification servers of the recipient of a direct message to
+// notify it, if it's offline. The chat is identified as the recipient sees it.
+func (s *Service) notifyPush(identity *ecdsa.PrivateKey, recipient *ecdsa.PublicKey) {
+	if s.push == nil {
+		return
+	}
+	chatID := history.DirectChatID(crypto.FromECDSAPub(&identity.PublicKey))
+	if err := s.push.Notify(identity, recipient, chatID); err != nil {
+		reporter.Error("request-push-notification", err)
+	}
+}
+
+// receivePush handles the responses of the push notification servers, which are dropped,
+// and records the contacts that are online.
+func (api *PublicAPI) receivePush(messages []*whisper.Message) []*whisper.Message {
+	if api.service.push == nil {
+		return messages
+	}
+
+	result := make([]*whisper.Message, 0, len(messages))
+	for _, msg := range messages {
+		if len(msg.Sig) == 0 {
+			result = append(result, msg)
+			continue
+		}
+		sender, err := crypto.UnmarshalPubkey(msg.Sig)
+		if err != nil {
+			result = append(result, msg)
+			continue
+		}
+		messageType, content, ok := push.Decode(msg.Payload)
+		if !ok {
+			api.service.push.Seen(sender, time.Unix(int64(msg.Timestamp), 0))
+			result = append(result, msg)
+			continue
+		}
+		identity, err := api.service.selectedIdentity()
+		if err != nil {
+			api.log.Error("Failed to handle push notification response", "hash", msg.Hash, "err", err)
+			continue
+		}
+		if err := api.service.push.HandleResponse(identity, sender, messageType, content); err != nil {
+			api.log.Error("Failed to handle push notification response", "hash", msg.Hash, "err", err)
+		}
+	}
+	return result
+}
@@ -0,0 +1,491 @@
+package push
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// OnlineWindow is how long a contact is considered online after we received a
+	// message from it. Online contacts are not notified.
+	OnlineWindow = 5 * time.Minute
+	// InfoTTL is how long the installations returned by servers are cached.
+	InfoTTL = time.Hour
+	// QueryTimeout is how long we wait for the response to a query before dropping
+	// the notifications waiting for it.
+	QueryTimeout = time.Minute
+
+	accessTokenLength = 16
+)
+
+var (
+	// ErrNoServers is returned when registering without notification servers.
+	ErrNoServers = errors.New("no push notification servers")
+	// ErrInvalidToken is returned when registering without token.
+	ErrInvalidToken = errors.New("invalid push notification token")
+)
+
+// Options chosen by a client when registering.
+type Options struct {
+	TokenType string `json:"tokenType"`
+	Token     string `json:"token"`
+	// AllowFromContactsOnly only lets the contacts of the account notify it.
+	AllowFromContactsOnly bool `json:"allowFromContactsOnly"`
+	// BlockedChats are the IDs of the chats that are never notified.
+	BlockedChats []string `json:"blockedChats,omitempty"`
+}
+
+// ServerStatus is the registration of a client with a server.
+type ServerStatus struct {
+	PublicKey  hexutil.Bytes `json:"publicKey"`
+	Registered bool          `json:"registered"`
+	// RegisteredAt is the time the server answered, in seconds.
+	RegisteredAt int64 `json:"registeredAt,omitempty"`
+	// Error is the error returned by the server.
+	Error string `json:"error,omitempty"`
+}
+
+// ClientState is the registration of the installation of an account.
+type ClientState struct {
+	Options     Options        `json:"options"`
+	AccessToken string         `json:"accessToken"`
+	Version     uint64         `json:"version"`
+	Servers     []ServerStatus `json:"servers"`
+}
+
+// ClientStore persists the state of a client.
+type ClientStore interface {
+	SavePushClientState(state ClientState) error
+	// GetPushClientState returns nil if the installation never registered.
+	GetPushClientState() (*ClientState, error)
+}
+
+// serverInfo is an installation returned by a server, with its access token.
+type serverInfo struct {
+	server         *ecdsa.PublicKey
+	publicKeyHash  []byte
+	installationID string
+	accessToken    string
+}
+
+type cachedInfos struct {
+	infos   []serverInfo
+	expires time.Time
+}
+
+// pendingQuery are the notifications of the chats of a recipient waiting for the
+// response of a server to a query.
+type pendingQuery struct {
+	server     *ecdsa.PublicKey
+	recipient  *ecdsa.PublicKey
+	chatHashes [][]byte
+	sentAt     time.Time
+}
+
+// Client registers the installation of an account with notification servers, and asks
+// the servers of the recipients of messages to notify them while they are offline.
+type Client struct {
+	store          ClientStore
+	protocol       Protocol
+	transport      Transport
+	installationID string
+	servers        []*ecdsa.PublicKey
+	now            func() time.Time
+
+	mu      sync.Mutex
+	seen    map[string]time.Time
+	infos   map[string]*cachedInfos
+	pending map[string]*pendingQuery
+}
+
+// NewClient returns a new Client of an installation. Servers are those queried for the
+// installations of the recipients, and registered with by default.
+func NewClient(store ClientStore, protocol Protocol, transport Transport, installationID string, servers []*ecdsa.PublicKey) *Client {
+	return &Client{
+		store:          store,
+		protocol:       protocol,
+		transport:      transport,
+		installationID: installationID,
+		servers:        servers,
+		now:            time.Now,
+		seen:           make(map[string]time.Time),
+		infos:          make(map[string]*cachedInfos),
+		pending:        make(map[string]*pendingQuery),
+	}
+}
+
+// State returns the registration of the installation, or nil if it never registered.
+func (c *Client) State() (*ClientState, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.store.GetPushClientState()
+}
+
+// Register registers the installation with servers, or the default servers if none are
+// given. The access token is encrypted for each contact if the options only allow them.
+// Servers answer asynchronously, and the state tracks their responses.
+func (c *Client) Register(identity *ecdsa.PrivateKey, contacts []*ecdsa.PublicKey, servers []*ecdsa.PublicKey, options Options) (*ClientState, error) {
+	if options.Token == "" {
+		return nil, ErrInvalidToken
+	}
+	if options.TokenType != TokenTypeFCM && options.TokenType != TokenTypeAPNs {
+		return nil, ErrUnsupportedTokenType
+	}
+	if len(servers) == 0 {
+		servers = c.servers
+	}
+	if len(servers) == 0 {
+		return nil, ErrNoServers
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, err := c.store.GetPushClientState()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		state = &ClientState{}
+	}
+	if state.AccessToken == "" {
+		if state.AccessToken, err = randomHex(accessTokenLength); err != nil {
+			return nil, err
+		}
+	}
+	state.Options = options
+	state.Version = c.nextVersion(state.Version)
+
+	registration := Registration{
+		TokenType:             options.TokenType,
+		Token:                 options.Token,
+		InstallationID:        c.installationID,
+		AccessToken:           state.AccessToken,
+		AllowFromContactsOnly: options.AllowFromContactsOnly,
+		Version:               state.Version,
+	}
+	if options.AllowFromContactsOnly {
+		for _, contact := range contacts {
+			allowed, err := EncryptAccessToken(identity, contact, state.AccessToken)
+			if err != nil {
+				return nil, err
+			}
+			registration.AllowedKeys = append(registration.AllowedKeys, allowed)
+		}
+	}
+	for _, chatID := range options.BlockedChats {
+		registration.BlockedChats = append(registration.BlockedChats, HashChatID(chatID))
+	}
+
+	// Servers we registered with before are told to forget us.
+	unregistered := make(map[string]*ecdsa.PublicKey)
+	for _, status := range state.Servers {
+		if server, err := crypto.UnmarshalPubkey(status.PublicKey); err == nil {
+			unregistered[keyString(server)] = server
+		}
+	}
+
+	state.Servers = make([]ServerStatus, 0, len(servers))
+	for _, server := range servers {
+		delete(unregistered, keyString(server))
+		status := ServerStatus{PublicKey: crypto.FromECDSAPub(server)}
+		if err := send(c.protocol, c.transport, identity, server, MessageTypeRegistration, registration); err != nil {
+			status.Error = err.Error()
+		}
+		state.Servers = append(state.Servers, status)
+	}
+	c.unregister(identity, unregistered, state.Version)
+
+	if err := c.store.SavePushClientState(*state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Unregister removes the registration of the installation from the servers it
+// registered with.
+func (c *Client) Unregister(identity *ecdsa.PrivateKey) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, err := c.store.GetPushClientState()
+	if err != nil || state == nil {
+		return err
+	}
+	servers := make(map[string]*ecdsa.PublicKey)
+	for _, status := range state.Servers {
+		if server, err := crypto.UnmarshalPubkey(status.PublicKey); err == nil {
+			servers[keyString(server)] = server
+		}
+	}
+	state.Version = c.nextVersion(state.Version)
+	c.unregister(identity, servers, state.Version)
+
+	state.Options = Options{}
+	state.Servers = nil
+	return c.store.SavePushClientState(*state)
+}
+
+func (c *Client) unregister(identity *ecdsa.PrivateKey, servers map[string]*ecdsa.PublicKey, version uint64) {
+	registration := Registration{
+		InstallationID: c.installationID,
+		Version:        version,
+		Unregister:     true,
+	}
+	for _, server := range servers {
+		if err := send(c.protocol, c.transport, identity, server, MessageTypeRegistration, registration); err != nil {
+			log.Error("failed to unregister from push notification server", "err", err)
+		}
+	}
+}
+
+// nextVersion returns the version of a new registration, which is the current time in
+// milliseconds so that it survives the loss of the state, unless the clock went back.
+func (c *Client) nextVersion(current uint64) uint64 {
+	version := uint64(c.now().UnixNano() / int64(time.Millisecond))
+	if version <= current {
+		version = current + 1
+	}
+	return version
+}
+
+// Seen records that we received a message from a contact, which is online for a while.
+func (c *Client) Seen(publicKey *ecdsa.PublicKey, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := keyString(publicKey)
+	if at.After(c.seen[key]) {
+		c.seen[key] = at
+	}
+}
+
+// Notify asks the servers of a recipient that is offline to notify its installations of a
+// message in a chat. The installations are queried first unless they are cached, and
+// notified when the servers answer.
+func (c *Client) Notify(identity *ecdsa.PrivateKey, recipient *ecdsa.PublicKey, chatID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	key := keyString(recipient)
+	if seen, ok := c.seen[key]; ok && now.Sub(seen) < OnlineWindow {
+		return nil
+	}
+	chatHash := HashChatID(chatID)
+
+	if cached, ok := c.infos[key]; ok && now.Before(cached.expires) {
+		return c.request(identity, cached.infos, [][]byte{chatHash})
+	}
+
+	var queried bool
+	for id, pending := range c.pending {
+		if now.Sub(pending.sentAt) > QueryTimeout {
+			delete(c.pending, id)
+			continue
+		}
+		if keyString(pending.recipient) == key {
+			pending.chatHashes = appendHash(pending.chatHashes, chatHash)
+			queried = true
+		}
+	}
+	if queried {
+		return nil
+	}
+
+	var lastErr error
+	for _, server := range c.servers {
+		id, err := randomHex(16)
+		if err != nil {
+			return err
+		}
+		query := Query{ID: id, PublicKeyHashes: []hexutil.Bytes{HashPublicKey(recipient)}}
+		if err := send(c.protocol, c.transport, identity, server, MessageTypeQuery, query); err != nil {
+			lastErr = err
+			continue
+		}
+		c.pending[id] = &pendingQuery{
+			server:     server,
+			recipient:  recipient,
+			chatHashes: [][]byte{chatHash},
+			sentAt:     now,
+		}
+	}
+	return lastErr
+}
+
+// request asks the servers of installations to notify them of the messages in chats.
+func (c *Client) request(identity *ecdsa.PrivateKey, infos []serverInfo, chatHashes [][]byte) error {
+	requests := make(map[string]*Request)
+	servers := make(map[string]*ecdsa.PublicKey)
+	for _, info := range infos {
+		key := keyString(info.server)
+		request, ok := requests[key]
+		if !ok {
+			id, err := randomHex(16)
+			if err != nil {
+				return err
+			}
+			request = &Request{ID: id}
+			requests[key] = request
+			servers[key] = info.server
+		}
+		for _, chatHash := range chatHashes {
+			request.Notifications = append(request.Notifications, Notification{
+				PublicKeyHash:  info.publicKeyHash,
+				InstallationID: info.installationID,
+				AccessToken:    info.accessToken,
+				ChatHash:       chatHash,
+			})
+		}
+	}
+
+	var lastErr error
+	for key, request := range requests {
+		for len(request.Notifications) > 0 {
+			batch := *request
+			if len(batch.Notifications) > MaxBatch {
+				batch.Notifications = batch.Notifications[:MaxBatch]
+			}
+			request.Notifications = request.Notifications[len(batch.Notifications):]
+			if err := send(c.protocol, c.transport, identity, servers[key], MessageTypeRequest, batch); err != nil {
+				lastErr = err
+			}
+		}
+	}
+	return lastErr
+}
+
+// HandleResponse handles a decrypted response of a server.
+func (c *Client) HandleResponse(identity *ecdsa.PrivateKey, sender *ecdsa.PublicKey, messageType string, content []byte) error {
+	switch messageType {
+	case MessageTypeRegistrationResponse:
+		var response RegistrationResponse
+		if err := json.Unmarshal(content, &response); err != nil {
+			return err
+		}
+		return c.registered(sender, response)
+	case MessageTypeQueryResponse:
+		var response QueryResponse
+		if err := json.Unmarshal(content, &response); err != nil {
+			return err
+		}
+		return c.answered(identity, sender, response)
+	case MessageTypeRequestResponse:
+		var response RequestResponse
+		if err := json.Unmarshal(content, &response); err != nil {
+			return err
+		}
+		if response.Error != "" {
+			log.Debug("push notification request failed", "id", response.ID, "err", response.Error)
+		}
+		for _, err := range response.Errors {
+			if err != "" {
+				log.Debug("push notification failed", "id", response.ID, "err", err)
+			}
+		}
+		return nil
+	}
+	return ErrUnknownMessage
+}
+
+// registered records the response of a server to the latest registration.
+func (c *Client) registered(server *ecdsa.PublicKey, response RegistrationResponse) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, err := c.store.GetPushClientState()
+	if err != nil || state == nil || state.Version != response.Version {
+		return err
+	}
+	publicKey := crypto.FromECDSAPub(server)
+	for i := range state.Servers {
+		if !bytes.Equal(state.Servers[i].PublicKey, publicKey) {
+			continue
+		}
+		state.Servers[i].Registered = response.Error == ""
+		state.Servers[i].RegisteredAt = c.now().Unix()
+		state.Servers[i].Error = response.Error
+		return c.store.SavePushClientState(*state)
+	}
+	return nil
+}
+
+// answered caches the installations returned by a server, and asks it to notify them of
+// the chats waiting for the response.
+func (c *Client) answered(identity *ecdsa.PrivateKey, server *ecdsa.PublicKey, response QueryResponse) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending, ok := c.pending[response.ID]
+	if !ok || keyString(pending.server) != keyString(server) {
+		return nil
+	}
+	delete(c.pending, response.ID)
+	if response.Error != "" {
+		log.Debug("push notification query failed", "id", response.ID, "err", response.Error)
+		return nil
+	}
+
+	publicKeyHash := HashPublicKey(pending.recipient)
+	var infos []serverInfo
+	for _, info := range response.Infos {
+		if !bytes.Equal(info.PublicKeyHash, publicKeyHash) {
+			continue
+		}
+		accessToken := info.AccessToken
+		if accessToken == "" {
+			var ok bool
+			if accessToken, ok = DecryptAccessToken(identity, pending.recipient, info.AllowedKeys); !ok {
+				continue
+			}
+		}
+		infos = append(infos, serverInfo{
+			server:         server,
+			publicKeyHash:  publicKeyHash,
+			installationID: info.InstallationID,
+			accessToken:    accessToken,
+		})
+	}
+
+	now := c.now()
+	key := keyString(pending.recipient)
+	cached, ok := c.infos[key]
+	if !ok || now.After(cached.expires) {
+		cached = &cachedInfos{}
+		c.infos[key] = cached
+	}
+	cached.infos = append(cached.infos, infos...)
+	cached.expires = now.Add(InfoTTL)
+
+	return c.request(identity, infos, pending.chatHashes)
+}
+
+func appendHash(hashes [][]byte, hash []byte) [][]byte {
+	for _, h := range hashes {
+		if bytes.Equal(h, hash) {
+			return hashes
+		}
+	}
+	return append(hashes, hash)
+}
+
+func keyString(publicKey *ecdsa.PublicKey) string {
+	return hexutil.Encode(crypto.FromECDSAPub(publicKey))
+}
+
+func randomHex(length int) (string, error) {
+	data := make([]byte, length)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return hexutil.Encode(data)[2:], nil
+}
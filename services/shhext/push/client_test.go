@@ -0,0 +1,149 @@
+package push
+
+import (
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryClientStore struct {
+	state *ClientState
+}
+
+func (s *memoryClientStore) SavePushClientState(state ClientState) error {
+	s.state = &state
+	return nil
+}
+
+func (s *memoryClientStore) GetPushClientState() (*ClientState, error) {
+	if s.state == nil {
+		return nil, nil
+	}
+	state := *s.state
+	return &state, nil
+}
+
+type clientNode struct {
+	key    *ecdsa.PrivateKey
+	client *Client
+	store  *memoryClientStore
+}
+
+type clientFixture struct {
+	network    *network
+	server     *Server
+	dispatcher *fakeDispatcher
+	alice      *clientNode
+	bob        *clientNode
+	now        time.Time
+}
+
+// newClientFixture returns alice and bob, both using the same server.
+func newClientFixture(t *testing.T) *clientFixture {
+	serverKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	f := &clientFixture{
+		network:    newNetwork(),
+		dispatcher: &fakeDispatcher{},
+		now:        time.Unix(1000, 0),
+	}
+	f.server = NewServer(serverKey, plainProtocol{}, f.network.transport(serverKey), newMemoryServerStore(), f.dispatcher)
+	f.network.handlers[keyString(f.server.PublicKey())] = f.server.Handle
+	f.alice = f.newNode(t, "alice")
+	f.bob = f.newNode(t, "bob")
+	return f
+}
+
+func (f *clientFixture) newNode(t *testing.T, installationID string) *clientNode {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	node := &clientNode{key: key, store: &memoryClientStore{}}
+	node.client = NewClient(node.store, plainProtocol{}, f.network.transport(key), installationID, []*ecdsa.PublicKey{f.server.PublicKey()})
+	node.client.now = func() time.Time { return f.now }
+	f.network.handlers[keyString(&key.PublicKey)] = func(sender *ecdsa.PublicKey, payload []byte) error {
+		messageType, content, ok := Decode(payload)
+		require.True(t, ok)
+		return node.client.HandleResponse(key, sender, messageType, content)
+	}
+	return node
+}
+
+func TestClientRegister(t *testing.T) {
+	f := newClientFixture(t)
+
+	_, err := f.alice.client.Register(f.alice.key, nil, nil, Options{TokenType: TokenTypeFCM})
+	require.Equal(t, ErrInvalidToken, err)
+	state, err := f.alice.client.Register(f.alice.key, nil, nil, Options{TokenType: TokenTypeFCM, Token: "alice"})
+	require.NoError(t, err)
+	require.NotEmpty(t, state.AccessToken)
+	require.Len(t, state.Servers, 1)
+	require.False(t, state.Servers[0].Registered)
+
+	f.network.deliver(t)
+	state, err = f.alice.client.State()
+	require.NoError(t, err)
+	require.True(t, state.Servers[0].Registered)
+	require.Equal(t, f.now.Unix(), state.Servers[0].RegisteredAt)
+
+	f.now = f.now.Add(time.Second)
+	again, err := f.alice.client.Register(f.alice.key, nil, nil, Options{TokenType: TokenTypeAPNs, Token: "alice"})
+	require.NoError(t, err)
+	require.Equal(t, state.AccessToken, again.AccessToken, "Access token is kept")
+	require.True(t, again.Version > state.Version)
+
+	require.NoError(t, f.alice.client.Unregister(f.alice.key))
+	f.network.deliver(t)
+	state, err = f.alice.client.State()
+	require.NoError(t, err)
+	require.Empty(t, state.Servers)
+	registrations, err := f.server.store.GetPushRegistrations(HashPublicKey(&f.alice.key.PublicKey))
+	require.NoError(t, err)
+	require.Empty(t, registrations)
+}
+
+func TestClientNotify(t *testing.T) {
+	f := newClientFixture(t)
+	_, err := f.alice.client.Register(f.alice.key, nil, nil, Options{TokenType: TokenTypeFCM, Token: "alice", BlockedChats: []string{"blocked"}})
+	require.NoError(t, err)
+	f.network.deliver(t)
+
+	require.NoError(t, f.bob.client.Notify(f.bob.key, &f.alice.key.PublicKey, "chat"))
+	require.NoError(t, f.bob.client.Notify(f.bob.key, &f.alice.key.PublicKey, "chat"))
+	require.Len(t, f.network.queue, 1, "Installations are queried once")
+	f.network.deliver(t)
+	require.Equal(t, []dispatched{{token: "alice", chatHash: HashChatID("chat")}}, f.dispatcher.dispatched)
+
+	require.NoError(t, f.bob.client.Notify(f.bob.key, &f.alice.key.PublicKey, "blocked"))
+	require.Len(t, f.network.queue, 1)
+	decodedType, _, _ := Decode(f.network.queue[0].payload)
+	require.Equal(t, MessageTypeRequest, decodedType, "Cached installations are not queried")
+	f.network.deliver(t)
+	require.Len(t, f.dispatcher.dispatched, 1, "Blocked chats are not notified")
+
+	f.bob.client.Seen(&f.alice.key.PublicKey, f.now)
+	require.NoError(t, f.bob.client.Notify(f.bob.key, &f.alice.key.PublicKey, "chat"))
+	require.Empty(t, f.network.queue, "Online contacts are not notified")
+	f.now = f.now.Add(OnlineWindow)
+	require.NoError(t, f.bob.client.Notify(f.bob.key, &f.alice.key.PublicKey, "chat"))
+	f.network.deliver(t)
+	require.Len(t, f.dispatcher.dispatched, 2)
+}
+
+func TestClientNotifyContactsOnly(t *testing.T) {
+	f := newClientFixture(t)
+	carol := f.newNode(t, "carol")
+	_, err := f.alice.client.Register(f.alice.key, []*ecdsa.PublicKey{&f.bob.key.PublicKey}, nil, Options{TokenType: TokenTypeFCM, Token: "alice", AllowFromContactsOnly: true})
+	require.NoError(t, err)
+	f.network.deliver(t)
+
+	require.NoError(t, carol.client.Notify(carol.key, &f.alice.key.PublicKey, "chat"))
+	f.network.deliver(t)
+	require.Empty(t, f.dispatcher.dispatched, "Strangers can't notify")
+
+	require.NoError(t, f.bob.client.Notify(f.bob.key, &f.alice.key.PublicKey, "chat"))
+	f.network.deliver(t)
+	require.Equal(t, []dispatched{{token: "alice", chatHash: HashChatID("chat")}}, f.dispatcher.dispatched)
+}
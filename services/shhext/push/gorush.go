@@ -0,0 +1,88 @@
+package push
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	// gorushMessage is the text of the notifications, which never reveal the messages.
+	gorushMessage = "You have a new message"
+
+	// defaultGorushTimeout is the time we wait for gorush before giving up.
+	defaultGorushTimeout = 10 * time.Second
+)
+
+// Platforms of the notifications in the API of gorush.
+const (
+	gorushPlatformIOS     = 1
+	gorushPlatformAndroid = 2
+)
+
+// ErrInvalidGorushURL is returned when creating a dispatcher without an absolute URL.
+var ErrInvalidGorushURL = errors.New("invalid gorush URL")
+
+type gorushNotification struct {
+	Tokens   []string          `json:"tokens"`
+	Platform int               `json:"platform"`
+	Message  string            `json:"message"`
+	Data     map[string]string `json:"data,omitempty"`
+}
+
+type gorushRequest struct {
+	Notifications []gorushNotification `json:"notifications"`
+}
+
+// GorushDispatcher sends the notifications through the API of a gorush server.
+type GorushDispatcher struct {
+	url    string
+	client *http.Client
+}
+
+// NewGorushDispatcher returns a new GorushDispatcher of the gorush server at rawURL.
+func NewGorushDispatcher(rawURL string) (*GorushDispatcher, error) {
+	if u, err := url.Parse(rawURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, ErrInvalidGorushURL
+	}
+	return &GorushDispatcher{
+		url:    strings.TrimSuffix(rawURL, "/") + "/api/push",
+		client: &http.Client{Timeout: defaultGorushTimeout},
+	}, nil
+}
+
+// Dispatch sends a notification of a chat to an installation.
+func (d *GorushDispatcher) Dispatch(registration Registration, chatHash []byte) error {
+	platform := gorushPlatformAndroid
+	if registration.TokenType == TokenTypeAPNs {
+		platform = gorushPlatformIOS
+	}
+	body, err := json.Marshal(gorushRequest{
+		Notifications: []gorushNotification{{
+			Tokens:   []string{registration.Token},
+			Platform: platform,
+			Message:  gorushMessage,
+			Data:     map[string]string{"chatHash": hexutil.Encode(chatHash)},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := d.client.Post(d.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("gorush returned status %d", resp.StatusCode)
+	}
+	return nil
+}
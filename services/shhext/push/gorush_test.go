@@ -0,0 +1,30 @@
+package push
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGorushDispatcher(t *testing.T) {
+	var received gorushRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/push", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	_, err := NewGorushDispatcher("gorush")
+	require.Equal(t, ErrInvalidGorushURL, err)
+	d, err := NewGorushDispatcher(server.URL + "/")
+	require.NoError(t, err)
+
+	require.NoError(t, d.Dispatch(Registration{TokenType: TokenTypeAPNs, Token: "token"}, []byte{1}))
+	require.Len(t, received.Notifications, 1)
+	require.Equal(t, []string{"token"}, received.Notifications[0].Tokens)
+	require.Equal(t, gorushPlatformIOS, received.Notifications[0].Platform)
+	require.Equal(t, "0x01", received.Notifications[0].Data["chatHash"])
+}
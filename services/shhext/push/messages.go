@@ -0,0 +1,235 @@
+package push
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/ecies"
+	ecrypto "github.com/status-im/status-go/services/shhext/chat/crypto"
+)
+
+// Types of the push notification messages.
+const (
+	// MessageTypeRegistration registers the token of an installation with a server.
+	MessageTypeRegistration = "registration"
+	// MessageTypeRegistrationResponse answers a registration.
+	MessageTypeRegistrationResponse = "registration-response"
+	// MessageTypeQuery asks a server for the installations registered by public keys.
+	MessageTypeQuery = "query"
+	// MessageTypeQueryResponse answers a query.
+	MessageTypeQueryResponse = "query-response"
+	// MessageTypeRequest asks a server to notify installations.
+	MessageTypeRequest = "request"
+	// MessageTypeRequestResponse answers a request.
+	MessageTypeRequestResponse = "request-response"
+)
+
+// payloadPrefix distinguishes the push notification messages from regular messages.
+var payloadPrefix = []byte("status-push:")
+
+const (
+	// TokenTypeFCM is the type of the tokens of Firebase Cloud Messaging, used on Android.
+	TokenTypeFCM = "fcm"
+	// TokenTypeAPNs is the type of the tokens of the Apple Push Notification service.
+	TokenTypeAPNs = "apns"
+)
+
+// MaxBatch is the maximum number of public keys of a query, or of notifications of a request.
+const MaxBatch = 100
+
+var (
+	// ErrInvalidRegistration is returned for registrations without token or installation.
+	ErrInvalidRegistration = errors.New("invalid push notification registration")
+	// ErrStaleRegistration is returned for registrations older than the registered one.
+	ErrStaleRegistration = errors.New("push notification registration is stale")
+	// ErrUnsupportedTokenType is returned for registrations of a token type the server can't notify.
+	ErrUnsupportedTokenType = errors.New("unsupported push notification token type")
+	// ErrBatchTooLarge is returned for queries and requests over MaxBatch.
+	ErrBatchTooLarge = errors.New("too many push notifications in the batch")
+	// ErrNotRegistered is returned when notifying an installation that is not registered.
+	ErrNotRegistered = errors.New("installation is not registered")
+	// ErrInvalidAccessToken is returned when notifying an installation with a wrong access token.
+	ErrInvalidAccessToken = errors.New("invalid access token")
+	// ErrChatBlocked is returned when notifying an installation of a chat it blocked.
+	ErrChatBlocked = errors.New("chat is blocked")
+)
+
+// Registration registers the token of an installation with a server. Senders must present
+// the access token to notify the installation: the server returns it in the responses to
+// queries, or only returns it encrypted for each contact if AllowFromContactsOnly is set.
+type Registration struct {
+	TokenType      string `json:"tokenType"`
+	Token          string `json:"token"`
+	InstallationID string `json:"installationId"`
+	AccessToken    string `json:"accessToken"`
+	// AllowFromContactsOnly hides the access token from the senders that are not contacts.
+	AllowFromContactsOnly bool `json:"allowFromContactsOnly"`
+	// AllowedKeys are the access token encrypted for each contact, with EncryptAccessToken.
+	AllowedKeys []hexutil.Bytes `json:"allowedKeys,omitempty"`
+	// BlockedChats are the hashes of the IDs of the chats that are never notified.
+	BlockedChats []hexutil.Bytes `json:"blockedChats,omitempty"`
+	// Version orders the registrations of an installation, the latest wins.
+	Version uint64 `json:"version"`
+	// Unregister removes the registration of the installation.
+	Unregister bool `json:"unregister,omitempty"`
+}
+
+// RegistrationResponse answers a registration.
+type RegistrationResponse struct {
+	Version uint64 `json:"version"`
+	// Error is empty if the installation was registered.
+	Error string `json:"error,omitempty"`
+}
+
+// Query asks a server for the installations registered by public keys, by hash.
+type Query struct {
+	ID              string          `json:"id"`
+	PublicKeyHashes []hexutil.Bytes `json:"publicKeyHashes"`
+}
+
+// Info is an installation registered with a server, as returned to queries.
+type Info struct {
+	PublicKeyHash  hexutil.Bytes `json:"publicKeyHash"`
+	InstallationID string        `json:"installationId"`
+	// AccessToken is empty if the installation only allows its contacts.
+	AccessToken string          `json:"accessToken,omitempty"`
+	AllowedKeys []hexutil.Bytes `json:"allowedKeys,omitempty"`
+	Version     uint64          `json:"version"`
+}
+
+// QueryResponse answers a query.
+type QueryResponse struct {
+	ID    string `json:"id"`
+	Infos []Info `json:"infos"`
+	// Error is empty if the query was answered.
+	Error string `json:"error,omitempty"`
+}
+
+// Notification asks a server to notify an installation of a new message in a chat.
+type Notification struct {
+	PublicKeyHash  hexutil.Bytes `json:"publicKeyHash"`
+	InstallationID string        `json:"installationId"`
+	AccessToken    string        `json:"accessToken"`
+	ChatHash       hexutil.Bytes `json:"chatHash"`
+}
+
+// Request asks a server to notify installations.
+type Request struct {
+	ID            string         `json:"id"`
+	Notifications []Notification `json:"notifications"`
+}
+
+// RequestResponse answers a request.
+type RequestResponse struct {
+	ID string `json:"id"`
+	// Errors are the errors of the notifications of the request, in the same order, empty
+	// for those that were sent.
+	Errors []string `json:"errors"`
+	// Error is empty if the request was handled.
+	Error string `json:"error,omitempty"`
+}
+
+// Protocol encrypts and decrypts the push notification messages.
+type Protocol interface {
+	HandleMessage(myIdentityKey *ecdsa.PrivateKey, theirPublicKey *ecdsa.PublicKey, payload []byte) ([]byte, error)
+	BuildDirectMessage(myIdentityKey *ecdsa.PrivateKey, payload []byte, theirPublicKeys ...*ecdsa.PublicKey) (map[*ecdsa.PublicKey][]byte, error)
+}
+
+// Transport sends the messages built by the protocol.
+type Transport interface {
+	Send(recipient *ecdsa.PublicKey, message []byte) error
+}
+
+// envelope is the encoding of the push notification messages.
+type envelope struct {
+	Type    string          `json:"type"`
+	Content json.RawMessage `json:"content"`
+}
+
+// Encode encodes a push notification message of a type in a payload.
+func Encode(messageType string, message interface{}) ([]byte, error) {
+	content, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(envelope{Type: messageType, Content: content})
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, payloadPrefix...), data...), nil
+}
+
+// Decode returns the type and the content of a push notification message, or false if the
+// payload is not a push notification message.
+func Decode(payload []byte) (string, []byte, bool) {
+	if !bytes.HasPrefix(payload, payloadPrefix) {
+		return "", nil, false
+	}
+	var e envelope
+	if err := json.Unmarshal(payload[len(payloadPrefix):], &e); err != nil || e.Type == "" {
+		return "", nil, false
+	}
+	return e.Type, e.Content, true
+}
+
+// send encrypts a push notification message and sends it to recipient.
+func send(protocol Protocol, transport Transport, key *ecdsa.PrivateKey, recipient *ecdsa.PublicKey, messageType string, message interface{}) error {
+	payload, err := Encode(messageType, message)
+	if err != nil {
+		return err
+	}
+	messages, err := protocol.BuildDirectMessage(key, payload, recipient)
+	if err != nil {
+		return err
+	}
+	for key, message := range messages {
+		if err := transport.Send(key, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HashPublicKey returns the hash of a public key by which its installations are registered.
+func HashPublicKey(publicKey *ecdsa.PublicKey) []byte {
+	return crypto.Keccak256(crypto.FromECDSAPub(publicKey))
+}
+
+// HashChatID returns the hash of the ID of a chat, sent to servers instead of the ID.
+func HashChatID(chatID string) []byte {
+	return crypto.Keccak256([]byte(chatID))
+}
+
+// sharedKey returns the key shared by an identity and a contact, with which the access
+// token is encrypted for the contact.
+func sharedKey(identity *ecdsa.PrivateKey, contact *ecdsa.PublicKey) ([]byte, error) {
+	return ecies.ImportECDSA(identity).GenerateShared(ecies.ImportECDSAPublic(contact), 16, 16)
+}
+
+// EncryptAccessToken encrypts an access token for a contact.
+func EncryptAccessToken(identity *ecdsa.PrivateKey, contact *ecdsa.PublicKey, accessToken string) ([]byte, error) {
+	key, err := sharedKey(identity, contact)
+	if err != nil {
+		return nil, err
+	}
+	return ecrypto.EncryptSymmetric(key, []byte(accessToken))
+}
+
+// DecryptAccessToken returns the access token of the allowed keys of an installation of
+// owner encrypted for identity, or false if none is.
+func DecryptAccessToken(identity *ecdsa.PrivateKey, owner *ecdsa.PublicKey, allowedKeys []hexutil.Bytes) (string, bool) {
+	key, err := sharedKey(identity, owner)
+	if err != nil {
+		return "", false
+	}
+	for _, allowed := range allowedKeys {
+		if accessToken, err := ecrypto.DecryptSymmetric(key, allowed); err == nil {
+			return string(accessToken), true
+		}
+	}
+	return "", false
+}
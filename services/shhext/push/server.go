@@ -0,0 +1,221 @@
+package push
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ErrUnknownMessage is returned when a server receives a message that is not a push
+// notification registration, query or request.
+var ErrUnknownMessage = errors.New("unknown push notification message")
+
+// ServerConfig of a push notification server.
+type ServerConfig struct {
+	// GorushURL is the URL of the gorush server which sends the notifications to
+	// Firebase Cloud Messaging and the Apple Push Notification service.
+	GorushURL string
+}
+
+// ServerStore persists the registrations of a server.
+type ServerStore interface {
+	// SavePushRegistration saves the registration of an installation of a public key,
+	// replacing the previous one.
+	SavePushRegistration(publicKeyHash []byte, registration Registration) error
+	// GetPushRegistrations returns the registrations of the installations of a public key.
+	GetPushRegistrations(publicKeyHash []byte) ([]Registration, error)
+	// DeletePushRegistration deletes the registration of an installation of a public key.
+	DeletePushRegistration(publicKeyHash []byte, installationID string) error
+}
+
+// Dispatcher sends a notification to a registered installation.
+type Dispatcher interface {
+	Dispatch(registration Registration, chatHash []byte) error
+}
+
+// Server registers the tokens of the installations of its clients, and notifies them
+// when asked by senders that present their access token. Servers never learn the chats
+// notified, only their hashes, nor the senders of the messages.
+type Server struct {
+	key        *ecdsa.PrivateKey
+	protocol   Protocol
+	transport  Transport
+	store      ServerStore
+	dispatcher Dispatcher
+}
+
+// NewServer returns a new Server with the given identity.
+func NewServer(key *ecdsa.PrivateKey, protocol Protocol, transport Transport, store ServerStore, dispatcher Dispatcher) *Server {
+	return &Server{
+		key:        key,
+		protocol:   protocol,
+		transport:  transport,
+		store:      store,
+		dispatcher: dispatcher,
+	}
+}
+
+// PublicKey returns the public key clients register with.
+func (s *Server) PublicKey() *ecdsa.PublicKey {
+	return &s.key.PublicKey
+}
+
+// Handle decrypts a message sent to the server and answers it.
+func (s *Server) Handle(sender *ecdsa.PublicKey, payload []byte) error {
+	message, err := s.protocol.HandleMessage(s.key, sender, payload)
+	if err != nil {
+		return err
+	}
+	messageType, content, ok := Decode(message)
+	if !ok {
+		return ErrUnknownMessage
+	}
+
+	switch messageType {
+	case MessageTypeRegistration:
+		var registration Registration
+		if err := json.Unmarshal(content, &registration); err != nil {
+			return err
+		}
+		response := RegistrationResponse{Version: registration.Version}
+		if err := s.register(sender, registration); err != nil {
+			response.Error = err.Error()
+		}
+		return send(s.protocol, s.transport, s.key, sender, MessageTypeRegistrationResponse, response)
+	case MessageTypeQuery:
+		var query Query
+		if err := json.Unmarshal(content, &query); err != nil {
+			return err
+		}
+		response := QueryResponse{ID: query.ID, Infos: []Info{}}
+		if infos, err := s.query(query); err != nil {
+			response.Error = err.Error()
+		} else {
+			response.Infos = infos
+		}
+		return send(s.protocol, s.transport, s.key, sender, MessageTypeQueryResponse, response)
+	case MessageTypeRequest:
+		var request Request
+		if err := json.Unmarshal(content, &request); err != nil {
+			return err
+		}
+		response := RequestResponse{ID: request.ID, Errors: []string{}}
+		if errs, err := s.notify(request); err != nil {
+			response.Error = err.Error()
+		} else {
+			response.Errors = errs
+		}
+		return send(s.protocol, s.transport, s.key, sender, MessageTypeRequestResponse, response)
+	}
+	return ErrUnknownMessage
+}
+
+// register saves or deletes the registration of an installation of sender, unless it's
+// older than the registered one.
+func (s *Server) register(sender *ecdsa.PublicKey, registration Registration) error {
+	if registration.InstallationID == "" {
+		return ErrInvalidRegistration
+	}
+	publicKeyHash := HashPublicKey(sender)
+	registered, err := s.registration(publicKeyHash, registration.InstallationID)
+	if err != nil {
+		return err
+	}
+	if registered != nil && registered.Version > registration.Version {
+		return ErrStaleRegistration
+	}
+
+	if registration.Unregister {
+		return s.store.DeletePushRegistration(publicKeyHash, registration.InstallationID)
+	}
+	if registration.Token == "" || registration.AccessToken == "" {
+		return ErrInvalidRegistration
+	}
+	if registration.TokenType != TokenTypeFCM && registration.TokenType != TokenTypeAPNs {
+		return ErrUnsupportedTokenType
+	}
+	return s.store.SavePushRegistration(publicKeyHash, registration)
+}
+
+// query returns the installations registered by the public keys of a query. The access
+// tokens of the installations that only allow their contacts are not returned.
+func (s *Server) query(query Query) ([]Info, error) {
+	if len(query.PublicKeyHashes) > MaxBatch {
+		return nil, ErrBatchTooLarge
+	}
+	infos := []Info{}
+	for _, publicKeyHash := range query.PublicKeyHashes {
+		registrations, err := s.store.GetPushRegistrations(publicKeyHash)
+		if err != nil {
+			return nil, err
+		}
+		for _, registration := range registrations {
+			info := Info{
+				PublicKeyHash:  publicKeyHash,
+				InstallationID: registration.InstallationID,
+				Version:        registration.Version,
+			}
+			if registration.AllowFromContactsOnly {
+				info.AllowedKeys = registration.AllowedKeys
+			} else {
+				info.AccessToken = registration.AccessToken
+			}
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+// notify dispatches the notifications of a request. It returns their errors, empty for
+// those that were sent.
+func (s *Server) notify(request Request) ([]string, error) {
+	if len(request.Notifications) > MaxBatch {
+		return nil, ErrBatchTooLarge
+	}
+	errs := make([]string, len(request.Notifications))
+	for i, notification := range request.Notifications {
+		if err := s.dispatch(notification); err != nil {
+			log.Debug("push notification server failed to notify", "installationID", notification.InstallationID, "err", err)
+			errs[i] = err.Error()
+		}
+	}
+	return errs, nil
+}
+
+func (s *Server) dispatch(notification Notification) error {
+	registration, err := s.registration(notification.PublicKeyHash, notification.InstallationID)
+	if err != nil {
+		return err
+	}
+	if registration == nil {
+		return ErrNotRegistered
+	}
+	if subtle.ConstantTimeCompare([]byte(registration.AccessToken), []byte(notification.AccessToken)) != 1 {
+		return ErrInvalidAccessToken
+	}
+	for _, chatHash := range registration.BlockedChats {
+		if bytes.Equal(chatHash, notification.ChatHash) {
+			return ErrChatBlocked
+		}
+	}
+	return s.dispatcher.Dispatch(*registration, notification.ChatHash)
+}
+
+// registration returns the registration of an installation of a public key, or nil if it
+// is not registered.
+func (s *Server) registration(publicKeyHash []byte, installationID string) (*Registration, error) {
+	registrations, err := s.store.GetPushRegistrations(publicKeyHash)
+	if err != nil {
+		return nil, err
+	}
+	for i := range registrations {
+		if registrations[i].InstallationID == installationID {
+			return &registrations[i], nil
+		}
+	}
+	return nil, nil
+}
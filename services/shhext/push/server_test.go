@@ -0,0 +1,234 @@
+package push
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// plainProtocol leaves the messages unencrypted.
+type plainProtocol struct{}
+
+func (plainProtocol) HandleMessage(myIdentityKey *ecdsa.PrivateKey, theirPublicKey *ecdsa.PublicKey, payload []byte) ([]byte, error) {
+	return payload, nil
+}
+
+func (plainProtocol) BuildDirectMessage(myIdentityKey *ecdsa.PrivateKey, payload []byte, theirPublicKeys ...*ecdsa.PublicKey) (map[*ecdsa.PublicKey][]byte, error) {
+	messages := make(map[*ecdsa.PublicKey][]byte)
+	for _, key := range theirPublicKeys {
+		messages[key] = payload
+	}
+	return messages, nil
+}
+
+type delivery struct {
+	sender    *ecdsa.PublicKey
+	recipient *ecdsa.PublicKey
+	payload   []byte
+}
+
+// network queues the messages sent by the nodes until they are delivered.
+type network struct {
+	queue    []delivery
+	handlers map[string]func(sender *ecdsa.PublicKey, payload []byte) error
+}
+
+func newNetwork() *network {
+	return &network{handlers: make(map[string]func(*ecdsa.PublicKey, []byte) error)}
+}
+
+func (n *network) transport(key *ecdsa.PrivateKey) Transport {
+	return nodeTransport{network: n, sender: &key.PublicKey}
+}
+
+// deliver delivers the queued messages, and those sent while handling them.
+func (n *network) deliver(t *testing.T) {
+	for len(n.queue) > 0 {
+		d := n.queue[0]
+		n.queue = n.queue[1:]
+		handler, ok := n.handlers[keyString(d.recipient)]
+		if ok {
+			require.NoError(t, handler(d.sender, d.payload))
+		}
+	}
+}
+
+type nodeTransport struct {
+	network *network
+	sender  *ecdsa.PublicKey
+}
+
+func (t nodeTransport) Send(recipient *ecdsa.PublicKey, message []byte) error {
+	t.network.queue = append(t.network.queue, delivery{sender: t.sender, recipient: recipient, payload: message})
+	return nil
+}
+
+type memoryServerStore struct {
+	registrations map[string]map[string]Registration
+}
+
+func newMemoryServerStore() *memoryServerStore {
+	return &memoryServerStore{registrations: make(map[string]map[string]Registration)}
+}
+
+func (s *memoryServerStore) SavePushRegistration(publicKeyHash []byte, registration Registration) error {
+	key := string(publicKeyHash)
+	if s.registrations[key] == nil {
+		s.registrations[key] = make(map[string]Registration)
+	}
+	s.registrations[key][registration.InstallationID] = registration
+	return nil
+}
+
+func (s *memoryServerStore) GetPushRegistrations(publicKeyHash []byte) ([]Registration, error) {
+	var result []Registration
+	for _, registration := range s.registrations[string(publicKeyHash)] {
+		result = append(result, registration)
+	}
+	return result, nil
+}
+
+func (s *memoryServerStore) DeletePushRegistration(publicKeyHash []byte, installationID string) error {
+	delete(s.registrations[string(publicKeyHash)], installationID)
+	return nil
+}
+
+type dispatched struct {
+	token    string
+	chatHash []byte
+}
+
+type fakeDispatcher struct {
+	dispatched []dispatched
+}
+
+func (d *fakeDispatcher) Dispatch(registration Registration, chatHash []byte) error {
+	d.dispatched = append(d.dispatched, dispatched{token: registration.Token, chatHash: chatHash})
+	return nil
+}
+
+type serverFixture struct {
+	server     *Server
+	store      *memoryServerStore
+	dispatcher *fakeDispatcher
+	client     *ecdsa.PrivateKey
+	responses  []delivery
+	network    *network
+}
+
+// newServerFixture returns a server and a client key whose responses are recorded.
+func newServerFixture(t *testing.T) *serverFixture {
+	serverKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	clientKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	f := &serverFixture{
+		store:      newMemoryServerStore(),
+		dispatcher: &fakeDispatcher{},
+		client:     clientKey,
+		network:    newNetwork(),
+	}
+	f.server = NewServer(serverKey, plainProtocol{}, f.network.transport(serverKey), f.store, f.dispatcher)
+	f.network.handlers[keyString(f.server.PublicKey())] = f.server.Handle
+	f.network.handlers[keyString(&clientKey.PublicKey)] = func(sender *ecdsa.PublicKey, payload []byte) error {
+		f.responses = append(f.responses, delivery{sender: sender, payload: payload})
+		return nil
+	}
+	return f
+}
+
+// roundTrip sends a message from the client and decodes the response of the server.
+func (f *serverFixture) roundTrip(t *testing.T, messageType string, message interface{}, responseType string, response interface{}) {
+	require.NoError(t, send(plainProtocol{}, f.network.transport(f.client), f.client, f.server.PublicKey(), messageType, message))
+	f.network.deliver(t)
+	require.Len(t, f.responses, 1)
+	decodedType, content, ok := Decode(f.responses[0].payload)
+	f.responses = nil
+	require.True(t, ok)
+	require.Equal(t, responseType, decodedType)
+	require.NoError(t, json.Unmarshal(content, response))
+}
+
+func (f *serverFixture) register(t *testing.T, registration Registration) RegistrationResponse {
+	var response RegistrationResponse
+	f.roundTrip(t, MessageTypeRegistration, registration, MessageTypeRegistrationResponse, &response)
+	return response
+}
+
+func TestServerRegistration(t *testing.T) {
+	f := newServerFixture(t)
+	registration := Registration{TokenType: TokenTypeFCM, Token: "token", InstallationID: "1", AccessToken: "secret", Version: 2}
+
+	require.Equal(t, ErrInvalidRegistration.Error(), f.register(t, Registration{TokenType: TokenTypeFCM, InstallationID: "1", AccessToken: "secret"}).Error)
+	require.Equal(t, ErrUnsupportedTokenType.Error(), f.register(t, Registration{TokenType: "sms", Token: "token", InstallationID: "1", AccessToken: "secret"}).Error)
+
+	response := f.register(t, registration)
+	require.Empty(t, response.Error)
+	require.Equal(t, uint64(2), response.Version)
+	registrations, err := f.store.GetPushRegistrations(HashPublicKey(&f.client.PublicKey))
+	require.NoError(t, err)
+	require.Equal(t, []Registration{registration}, registrations)
+
+	stale := registration
+	stale.Version = 1
+	require.Equal(t, ErrStaleRegistration.Error(), f.register(t, stale).Error)
+
+	require.Empty(t, f.register(t, Registration{InstallationID: "1", Version: 3, Unregister: true}).Error)
+	registrations, err = f.store.GetPushRegistrations(HashPublicKey(&f.client.PublicKey))
+	require.NoError(t, err)
+	require.Empty(t, registrations)
+}
+
+func TestServerQueryAndRequest(t *testing.T) {
+	f := newServerFixture(t)
+	hash := HashPublicKey(&f.client.PublicKey)
+	chatHash := HashChatID("chat")
+	require.Empty(t, f.register(t, Registration{TokenType: TokenTypeFCM, Token: "public", InstallationID: "1", AccessToken: "one", Version: 1}).Error)
+	require.Empty(t, f.register(t, Registration{TokenType: TokenTypeAPNs, Token: "private", InstallationID: "2", AccessToken: "two", AllowFromContactsOnly: true, AllowedKeys: []hexutil.Bytes{{1}}, BlockedChats: []hexutil.Bytes{chatHash}, Version: 1}).Error)
+
+	var query QueryResponse
+	f.roundTrip(t, MessageTypeQuery, Query{ID: "q", PublicKeyHashes: []hexutil.Bytes{hash}}, MessageTypeQueryResponse, &query)
+	require.Equal(t, "q", query.ID)
+	require.Len(t, query.Infos, 2)
+	for _, info := range query.Infos {
+		if info.InstallationID == "1" {
+			require.Equal(t, "one", info.AccessToken)
+		} else {
+			require.Empty(t, info.AccessToken, "Access tokens of contacts only installations are hidden")
+			require.Len(t, info.AllowedKeys, 1)
+		}
+	}
+
+	var request RequestResponse
+	f.roundTrip(t, MessageTypeRequest, Request{ID: "r", Notifications: []Notification{
+		{PublicKeyHash: hash, InstallationID: "1", AccessToken: "one", ChatHash: chatHash},
+		{PublicKeyHash: hash, InstallationID: "1", AccessToken: "two", ChatHash: chatHash},
+		{PublicKeyHash: hash, InstallationID: "2", AccessToken: "two", ChatHash: chatHash},
+		{PublicKeyHash: hash, InstallationID: "3", AccessToken: "one", ChatHash: chatHash},
+	}}, MessageTypeRequestResponse, &request)
+	require.Equal(t, "r", request.ID)
+	require.Equal(t, []string{"", ErrInvalidAccessToken.Error(), ErrChatBlocked.Error(), ErrNotRegistered.Error()}, request.Errors)
+	require.Equal(t, []dispatched{{token: "public", chatHash: chatHash}}, f.dispatcher.dispatched)
+}
+
+func TestEncryptAccessToken(t *testing.T) {
+	owner, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	contact, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	stranger, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	allowed, err := EncryptAccessToken(owner, &contact.PublicKey, "secret")
+	require.NoError(t, err)
+	accessToken, ok := DecryptAccessToken(contact, &owner.PublicKey, []hexutil.Bytes{{1, 2, 3}, allowed})
+	require.True(t, ok)
+	require.Equal(t, "secret", accessToken)
+	_, ok = DecryptAccessToken(stranger, &owner.PublicKey, []hexutil.Bytes{allowed})
+	require.False(t, ok)
+}
@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+const (
+	// maxTopicsPerPeer is the number of topics tracked separately for a peer.
+	// Envelopes on other topics share a single bucket, so that a peer can't
+	// escape the limit or exhaust memory by sending on random topics.
+	maxTopicsPerPeer = 64
+)
+
+// overflowTopic is the topic of the bucket shared by untracked topics.
+var overflowTopic = whisper.TopicType{}
+
+// Config is the configuration of a Limiter.
+type Config struct {
+	// Rate is the number of envelopes per second accepted from a peer on a topic.
+	Rate float64
+	// Burst is the number of envelopes accepted at once from a peer on a topic.
+	Burst int
+}
+
+// Handler is notified when a peer starts being throttled on a topic.
+type Handler func(peer enode.ID, topic whisper.TopicType)
+
+type bucket struct {
+	tokens    float64
+	updated   time.Time
+	throttled bool
+}
+
+// Limiter drops the envelopes received from a peer on a topic above a rate,
+// using a token bucket for each peer and topic.
+type Limiter struct {
+	config  Config
+	handler Handler
+	now     func() time.Time
+
+	mu    sync.Mutex
+	peers map[enode.ID]map[whisper.TopicType]*bucket
+}
+
+// NewLimiter returns a new Limiter. The handler can be nil.
+func NewLimiter(config Config, handler Handler) *Limiter {
+	if config.Burst < 1 {
+		config.Burst = 1
+	}
+	return &Limiter{
+		config:  config,
+		handler: handler,
+		now:     time.Now,
+		peers:   make(map[enode.ID]map[whisper.TopicType]*bucket),
+	}
+}
+
+// Allow consumes a token of the bucket of the peer and topic, and returns false
+// if there was none left.
+func (l *Limiter) Allow(peer enode.ID, topic whisper.TopicType) bool {
+	l.mu.Lock()
+	b := l.bucket(peer, topic)
+	now := l.now()
+	b.tokens += now.Sub(b.updated).Seconds() * l.config.Rate
+	if b.tokens > float64(l.config.Burst) {
+		b.tokens = float64(l.config.Burst)
+	}
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		b.throttled = false
+		l.mu.Unlock()
+		return true
+	}
+	notify := !b.throttled
+	b.throttled = true
+	l.mu.Unlock()
+
+	if notify && l.handler != nil {
+		l.handler(peer, topic)
+	}
+	return false
+}
+
+// RemovePeer forgets the buckets of a peer, once it is disconnected.
+func (l *Limiter) RemovePeer(peer enode.ID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.peers, peer)
+}
+
+// bucket must be called with the lock held.
+func (l *Limiter) bucket(peer enode.ID, topic whisper.TopicType) *bucket {
+	topics, exist := l.peers[peer]
+	if !exist {
+		topics = make(map[whisper.TopicType]*bucket)
+		l.peers[peer] = topics
+	}
+	if b, exist := topics[topic]; exist {
+		return b
+	}
+	if len(topics) >= maxTopicsPerPeer {
+		topic = overflowTopic
+		if b, exist := topics[topic]; exist {
+			return b
+		}
+	}
+	b := &bucket{tokens: float64(l.config.Burst), updated: l.now()}
+	topics[topic] = b
+	return b
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	var throttled []whisper.TopicType
+	l := NewLimiter(Config{Rate: 1, Burst: 2}, func(peer enode.ID, topic whisper.TopicType) {
+		throttled = append(throttled, topic)
+	})
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	peer := enode.ID{1}
+	topic := whisper.TopicType{1}
+	require.True(t, l.Allow(peer, topic))
+	require.True(t, l.Allow(peer, topic))
+	require.False(t, l.Allow(peer, topic))
+	require.False(t, l.Allow(peer, topic))
+	require.Equal(t, []whisper.TopicType{topic}, throttled, "Handler is notified once per throttling")
+
+	require.True(t, l.Allow(enode.ID{2}, topic), "Peers have separate buckets")
+	require.True(t, l.Allow(peer, whisper.TopicType{2}), "Topics have separate buckets")
+
+	now = now.Add(time.Second)
+	require.True(t, l.Allow(peer, topic))
+	require.False(t, l.Allow(peer, topic))
+	require.Len(t, throttled, 2)
+}
+
+func TestLimiterTopicsPerPeer(t *testing.T) {
+	l := NewLimiter(Config{Rate: 0, Burst: 1}, nil)
+	peer := enode.ID{1}
+	for i := 0; i < maxTopicsPerPeer; i++ {
+		require.True(t, l.Allow(peer, whisper.TopicType{byte(i + 1)}))
+	}
+	require.True(t, l.Allow(peer, whisper.TopicType{0, 1}))
+	require.False(t, l.Allow(peer, whisper.TopicType{0, 2}), "Untracked topics share a bucket")
+	require.Len(t, l.peers[peer], maxTopicsPerPeer+1)
+
+	l.RemovePeer(peer)
+	require.Empty(t, l.peers)
+}
+
+func TestProtocol(t *testing.T) {
+	l := NewLimiter(Config{Rate: 0, Burst: 1}, nil)
+	peer := p2p.NewPeer(enode.ID{1}, "peer", nil)
+	received := make(chan []*whisper.Envelope, 3)
+	protocol := l.Protocol(p2p.Protocol{Run: func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+		for {
+			msg, err := rw.ReadMsg()
+			if err != nil {
+				return err
+			}
+			var envelopes []*whisper.Envelope
+			if err := msg.Decode(&envelopes); err != nil {
+				return err
+			}
+			received <- envelopes
+		}
+	}})
+
+	local, remote := p2p.MsgPipe()
+	done := make(chan error, 1)
+	go func() { done <- protocol.Run(peer, local) }()
+
+	first := &whisper.Envelope{Topic: whisper.TopicType{1}, Data: []byte{1}}
+	second := &whisper.Envelope{Topic: whisper.TopicType{2}, Data: []byte{2}}
+	require.NoError(t, p2p.Send(remote, messagesCode, []*whisper.Envelope{first}))
+	require.NoError(t, p2p.Send(remote, messagesCode, []*whisper.Envelope{first}))
+	require.NoError(t, p2p.Send(remote, messagesCode, []*whisper.Envelope{first, second}))
+
+	envelopes := <-received
+	require.Len(t, envelopes, 1)
+	require.Equal(t, first.Topic, envelopes[0].Topic)
+	envelopes = <-received
+	require.Len(t, envelopes, 1, "Packets without allowed envelopes are skipped")
+	require.Equal(t, second.Topic, envelopes[0].Topic)
+
+	require.NoError(t, remote.Close())
+	require.Error(t, <-done)
+	require.Empty(t, l.peers, "Buckets are removed once the peer is disconnected")
+}
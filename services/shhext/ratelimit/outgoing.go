@@ -0,0 +1,243 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Classes of outgoing traffic, with their own budgets.
+const (
+	// ClassChat is the class of the chat messages sent by the user.
+	ClassChat = "chat"
+	// ClassReceipts is the class of the read receipts and typing notifications.
+	ClassReceipts = "receipts"
+	// ClassSync is the class of the messages synced with the other devices of the account.
+	ClassSync = "sync"
+	// ClassBundles is the class of the bundle lookups, publications and answers.
+	ClassBundles = "bundles"
+	// ClassCover is the class of the cover traffic.
+	ClassCover = "cover"
+)
+
+// Classes are the classes of outgoing traffic.
+var Classes = []string{ClassChat, ClassReceipts, ClassSync, ClassBundles, ClassCover}
+
+const (
+	// DefaultMaxWait is how long chat messages wait for the budget before being refused.
+	// The envelopes of the other classes are refused right away, so that background
+	// features don't queue up traffic.
+	DefaultMaxWait = 5 * time.Second
+)
+
+var (
+	// ErrRateLimited is returned when an envelope is refused because the budget of its
+	// class, or the global budget, is exhausted.
+	ErrRateLimited = errors.New("outgoing rate limit exceeded")
+	// ErrUnknownClass is returned when budgets are set for an unknown class of traffic.
+	ErrUnknownClass = errors.New("unknown class of outgoing traffic")
+)
+
+// Budget is the number of envelopes that can be sent.
+type Budget struct {
+	// Rate is the number of envelopes per second. Zero means unlimited.
+	Rate float64 `json:"rate"`
+	// Burst is the number of envelopes that can be sent at once.
+	Burst int `json:"burst"`
+}
+
+// Budgets limit the outgoing envelopes on a network type, with a global budget shared
+// by all the classes of traffic, and the budgets of the classes within it. Classes
+// without a budget are only limited by the global budget.
+type Budgets struct {
+	Global  Budget            `json:"global"`
+	Classes map[string]Budget `json:"classes,omitempty"`
+}
+
+// Validate returns an error if a budget is set for an unknown class.
+func (b Budgets) Validate() error {
+	for class := range b.Classes {
+		if !validClass(class) {
+			return ErrUnknownClass
+		}
+	}
+	return nil
+}
+
+// ClassStats are the envelopes of a class of traffic sent and refused.
+type ClassStats struct {
+	Class   string `json:"class"`
+	Sent    uint64 `json:"sent"`
+	Refused uint64 `json:"refused"`
+}
+
+type tokenBucket struct {
+	budget  Budget
+	tokens  float64
+	updated time.Time
+}
+
+// newTokenBucket returns a full bucket, or nil if the budget is unlimited.
+func newTokenBucket(budget Budget, now time.Time) *tokenBucket {
+	if budget.Rate <= 0 {
+		return nil
+	}
+	if budget.Burst < 1 {
+		budget.Burst = 1
+	}
+	return &tokenBucket{budget: budget, tokens: float64(budget.Burst), updated: now}
+}
+
+// delay refills the bucket and returns how long to wait for a token.
+func (b *tokenBucket) delay(now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.tokens += now.Sub(b.updated).Seconds() * b.budget.Rate
+	if b.tokens > float64(b.budget.Burst) {
+		b.tokens = float64(b.budget.Burst)
+	}
+	b.updated = now
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.budget.Rate * float64(time.Second))
+}
+
+// take takes a token, which may be one that is not refilled yet.
+func (b *tokenBucket) take() {
+	if b != nil {
+		b.tokens--
+	}
+}
+
+// Outgoing limits the envelopes sent by the node with token buckets: an envelope takes
+// a token from the bucket of its class and from the global bucket. It doesn't limit
+// until budgets are set.
+type Outgoing struct {
+	now   func() time.Time
+	sleep func(context.Context, time.Duration) error
+
+	mu      sync.Mutex
+	global  *tokenBucket
+	classes map[string]*tokenBucket
+	stats   map[string]*ClassStats
+}
+
+// NewOutgoing returns a new Outgoing limiter.
+func NewOutgoing() *Outgoing {
+	return &Outgoing{
+		now:     time.Now,
+		sleep:   sleep,
+		classes: make(map[string]*tokenBucket),
+		stats:   make(map[string]*ClassStats),
+	}
+}
+
+// SetBudgets replaces the budgets, like when the network type changes. The buckets
+// start full.
+func (o *Outgoing) SetBudgets(budgets Budgets) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	now := o.now()
+	o.global = newTokenBucket(budgets.Global, now)
+	o.classes = make(map[string]*tokenBucket, len(budgets.Classes))
+	for class, budget := range budgets.Classes {
+		if b := newTokenBucket(budget, now); b != nil {
+			o.classes[class] = b
+		}
+	}
+}
+
+// Wait waits until an envelope of a class can be sent, and takes its tokens. It returns
+// ErrRateLimited without waiting if the wait would be longer than allowed for the class.
+func (o *Outgoing) Wait(ctx context.Context, class string) error {
+	o.mu.Lock()
+	now := o.now()
+	delay := o.global.delay(now)
+	if classDelay := o.classes[class].delay(now); classDelay > delay {
+		delay = classDelay
+	}
+	stats := o.statsLocked(class)
+	if delay > maxWait(class) {
+		stats.Refused++
+		o.mu.Unlock()
+		return ErrRateLimited
+	}
+	o.global.take()
+	o.classes[class].take()
+	stats.Sent++
+	o.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	return o.sleep(ctx, delay)
+}
+
+// Stats returns the envelopes sent and refused by class, sorted by class.
+func (o *Outgoing) Stats() []ClassStats {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	result := make([]ClassStats, 0, len(o.stats))
+	for _, stats := range o.stats {
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Class < result[j].Class })
+	return result
+}
+
+// statsLocked must be called with the lock held.
+func (o *Outgoing) statsLocked(class string) *ClassStats {
+	stats, ok := o.stats[class]
+	if !ok {
+		stats = &ClassStats{Class: class}
+		o.stats[class] = stats
+	}
+	return stats
+}
+
+func maxWait(class string) time.Duration {
+	if class == ClassChat {
+		return DefaultMaxWait
+	}
+	return 0
+}
+
+func validClass(class string) bool {
+	for _, c := range Classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type classKey struct{}
+
+// WithClass returns a context whose envelopes are sent as a class of traffic.
+func WithClass(ctx context.Context, class string) context.Context {
+	return context.WithValue(ctx, classKey{}, class)
+}
+
+// ClassFromContext returns the class of traffic of the envelopes sent with a context,
+// ClassChat by default.
+func ClassFromContext(ctx context.Context) string {
+	if class, ok := ctx.Value(classKey{}).(string); ok {
+		return class
+	}
+	return ClassChat
+}
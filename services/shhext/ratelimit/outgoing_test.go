@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOutgoing(now *time.Time, slept *time.Duration) *Outgoing {
+	o := NewOutgoing()
+	o.now = func() time.Time { return *now }
+	o.sleep = func(_ context.Context, d time.Duration) error {
+		*slept += d
+		return nil
+	}
+	return o
+}
+
+func TestOutgoing(t *testing.T) {
+	now := time.Now()
+	var slept time.Duration
+	o := newTestOutgoing(&now, &slept)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, o.Wait(ctx, ClassSync), "Envelopes are not limited without budgets")
+	}
+
+	o.SetBudgets(Budgets{
+		Global:  Budget{Rate: 2, Burst: 3},
+		Classes: map[string]Budget{ClassReceipts: {Rate: 1, Burst: 1}},
+	})
+	require.NoError(t, o.Wait(ctx, ClassReceipts))
+	require.Equal(t, ErrRateLimited, o.Wait(ctx, ClassReceipts), "Background classes are refused")
+	require.NoError(t, o.Wait(ctx, ClassSync))
+	require.NoError(t, o.Wait(ctx, ClassSync))
+	require.Equal(t, ErrRateLimited, o.Wait(ctx, ClassSync), "The global budget is shared")
+	require.Zero(t, slept)
+
+	require.NoError(t, o.Wait(ctx, ClassChat), "Chat messages wait for the budget")
+	require.Equal(t, 500*time.Millisecond, slept)
+	require.NoError(t, o.Wait(ctx, ClassChat))
+	require.Equal(t, 1500*time.Millisecond, slept, "Tokens are reserved while waiting")
+
+	now = now.Add(time.Minute)
+	require.NoError(t, o.Wait(ctx, ClassReceipts))
+	require.Equal(t, []ClassStats{
+		{Class: ClassChat, Sent: 2},
+		{Class: ClassReceipts, Sent: 2, Refused: 1},
+		{Class: ClassSync, Sent: 12, Refused: 1},
+	}, o.Stats())
+}
+
+func TestOutgoingMaxWait(t *testing.T) {
+	now := time.Now()
+	var slept time.Duration
+	o := newTestOutgoing(&now, &slept)
+	o.SetBudgets(Budgets{Classes: map[string]Budget{ClassChat: {Rate: 0.1, Burst: 1}}})
+	ctx := context.Background()
+	require.NoError(t, o.Wait(ctx, ClassChat))
+	require.Equal(t, ErrRateLimited, o.Wait(ctx, ClassChat), "Chat messages don't wait longer than DefaultMaxWait")
+	require.NoError(t, o.Wait(ctx, ClassSync), "Classes without a budget only use the global one")
+}
+
+func TestBudgetsValidate(t *testing.T) {
+	require.NoError(t, Budgets{Classes: map[string]Budget{ClassCover: {Rate: 1}}}.Validate())
+	require.Equal(t, ErrUnknownClass, Budgets{Classes: map[string]Budget{"video": {Rate: 1}}}.Validate())
+}
+
+func TestClassFromContext(t *testing.T) {
+	require.Equal(t, ClassChat, ClassFromContext(context.Background()))
+	require.Equal(t, ClassSync, ClassFromContext(WithClass(context.Background(), ClassSync)))
+}
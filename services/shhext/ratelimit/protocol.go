@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// messagesCode is the code of the Whisper packets that carry envelopes relayed by peers.
+// Envelopes sent directly by trusted peers, such as MailServers, are not limited.
+const messagesCode = 1
+
+// Protocol wraps the Whisper protocol so that the envelopes received above the
+// limit are dropped before Whisper validates them and matches them against filters.
+func (l *Limiter) Protocol(protocol p2p.Protocol) p2p.Protocol {
+	run := protocol.Run
+	protocol.Run = func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+		defer l.RemovePeer(peer.ID())
+		return run(peer, &limitedReadWriter{MsgReadWriter: rw, peer: peer.ID(), limiter: l})
+	}
+	return protocol
+}
+
+type limitedReadWriter struct {
+	p2p.MsgReadWriter
+	peer    enode.ID
+	limiter *Limiter
+}
+
+// ReadMsg returns the next packet, without the envelopes above the limit.
+// Packets left without envelopes are skipped.
+func (rw *limitedReadWriter) ReadMsg() (p2p.Msg, error) {
+	for {
+		msg, err := rw.MsgReadWriter.ReadMsg()
+		if err != nil || msg.Code != messagesCode {
+			return msg, err
+		}
+
+		data, err := ioutil.ReadAll(msg.Payload)
+		if err != nil {
+			return msg, err
+		}
+		msg.Payload = bytes.NewReader(data)
+
+		var envelopes []*whisper.Envelope
+		if err := rlp.DecodeBytes(data, &envelopes); err != nil {
+			// Whisper disconnects peers sending invalid packets.
+			return msg, nil
+		}
+		allowed := make([]*whisper.Envelope, 0, len(envelopes))
+		for _, envelope := range envelopes {
+			if rw.limiter.Allow(rw.peer, envelope.Topic) {
+				allowed = append(allowed, envelope)
+			}
+		}
+		if len(allowed) == len(envelopes) {
+			// The packet is not changed, so that the confirmation sent by Whisper
+			// matches the hash of the batch.
+			return msg, nil
+		}
+		log.Debug("dropping envelopes above the rate limit", "peer", rw.peer, "count", len(envelopes)-len(allowed))
+		if len(allowed) == 0 {
+			continue
+		}
+
+		data, err = rlp.EncodeToBytes(allowed)
+		if err != nil {
+			return msg, err
+		}
+		msg.Size = uint32(len(data))
+		msg.Payload = bytes.NewReader(data)
+		return msg, nil
+	}
+}
@@ -0,0 +1,198 @@
+package receipts
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultMaxTrackedMessages is the default number of messages whose per-member state is kept in memory.
+const DefaultMaxTrackedMessages = 1000
+
+// Summary is the aggregated delivery state of a group message.
+type Summary struct {
+	MessageID string `json:"messageID"`
+	Total     int    `json:"total"`
+	Delivered int    `json:"delivered"`
+	Read      int    `json:"read"`
+}
+
+// Store persists the summaries of group messages.
+type Store interface {
+	SaveReceiptSummary(Summary) error
+	GetReceiptSummary(messageID string) (*Summary, error)
+}
+
+// SummaryHandler is notified every time the summary of a message changes.
+type SummaryHandler func(Summary)
+
+type message struct {
+	summary   Summary
+	envelopes map[common.Hash]string
+	// members are the members the message was sent to, whose receipts are counted.
+	members   map[string]struct{}
+	delivered map[string]struct{}
+	read      map[string]struct{}
+	element   *list.Element
+}
+
+// Aggregator aggregates the per-member delivered and read state of group messages.
+// The per-member state is kept in memory for the most recent messages only,
+// updates concerning older messages or other members are ignored. Summaries are
+// persisted in the order they change.
+type Aggregator struct {
+	store       Store
+	handler     SummaryHandler
+	maxMessages int
+
+	mu        sync.Mutex
+	messages  map[string]*message
+	envelopes map[common.Hash]string
+	order     *list.List
+}
+
+// NewAggregator returns a new Aggregator keeping up to maxMessages messages in memory.
+func NewAggregator(store Store, handler SummaryHandler, maxMessages int) *Aggregator {
+	return &Aggregator{
+		store:       store,
+		handler:     handler,
+		maxMessages: maxMessages,
+		messages:    make(map[string]*message),
+		envelopes:   make(map[common.Hash]string),
+		order:       list.New(),
+	}
+}
+
+// Track starts tracking a group message sent to members, given the member of each of
+// its envelopes. A member can be sent several envelopes, like the segments of the message.
+func (a *Aggregator) Track(messageID string, envelopes map[common.Hash]string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.messages[messageID]; ok {
+		return nil
+	}
+
+	members := make(map[string]struct{}, len(envelopes))
+	for _, member := range envelopes {
+		members[member] = struct{}{}
+	}
+	msg := &message{
+		summary:   Summary{MessageID: messageID, Total: len(members)},
+		envelopes: envelopes,
+		members:   members,
+		delivered: make(map[string]struct{}),
+		read:      make(map[string]struct{}),
+	}
+	msg.element = a.order.PushBack(messageID)
+	a.messages[messageID] = msg
+	for hash := range envelopes {
+		a.envelopes[hash] = messageID
+	}
+
+	for a.order.Len() > a.maxMessages {
+		a.evict(a.order.Front())
+	}
+
+	return a.store.SaveReceiptSummary(msg.summary)
+}
+
+// Owns returns true if the envelope belongs to a tracked group message.
+func (a *Aggregator) Owns(hash common.Hash) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, ok := a.envelopes[hash]
+	return ok
+}
+
+// EnvelopeDelivered marks the member an envelope was sent to as delivered. It must be
+// called once a mail server confirmed it stored the envelope, and not when a regular
+// peer relayed it, so that offline members can retrieve it.
+func (a *Aggregator) EnvelopeDelivered(hash common.Hash) error {
+	a.mu.Lock()
+	messageID, ok := a.envelopes[hash]
+	if !ok {
+		a.mu.Unlock()
+		return nil
+	}
+	msg := a.messages[messageID]
+	member := msg.envelopes[hash]
+	a.mu.Unlock()
+
+	return a.update(messageID, member, false)
+}
+
+// MessageRead marks a group message as read by a member. Receipts of anyone the
+// message wasn't sent to are ignored.
+func (a *Aggregator) MessageRead(messageID string, member string) error {
+	return a.update(messageID, member, true)
+}
+
+// Summary returns the summary of a message, from memory or from the store.
+func (a *Aggregator) Summary(messageID string) (*Summary, error) {
+	a.mu.Lock()
+	msg, ok := a.messages[messageID]
+	var summary Summary
+	if ok {
+		summary = msg.summary
+	}
+	a.mu.Unlock()
+	if ok {
+		return &summary, nil
+	}
+	return a.store.GetReceiptSummary(messageID)
+}
+
+// update saves the summary with the lock held, so that an older summary never
+// overwrites a newer one.
+func (a *Aggregator) update(messageID string, member string, read bool) error {
+	a.mu.Lock()
+	msg, ok := a.messages[messageID]
+	if !ok {
+		a.mu.Unlock()
+		return nil
+	}
+	if _, ok := msg.members[member]; !ok {
+		a.mu.Unlock()
+		return nil
+	}
+
+	state := msg.delivered
+	if read {
+		state = msg.read
+	}
+	if _, ok := state[member]; ok {
+		a.mu.Unlock()
+		return nil
+	}
+	state[member] = struct{}{}
+	// A message read by a member was necessarily delivered.
+	if _, ok := msg.delivered[member]; !ok && read {
+		msg.delivered[member] = struct{}{}
+	}
+	msg.summary.Delivered = len(msg.delivered)
+	msg.summary.Read = len(msg.read)
+	summary := msg.summary
+	a.order.MoveToBack(msg.element)
+	err := a.store.SaveReceiptSummary(summary)
+	a.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	if a.handler != nil {
+		a.handler(summary)
+	}
+	return nil
+}
+
+// evict must be called with the lock held.
+func (a *Aggregator) evict(element *list.Element) {
+	messageID := a.order.Remove(element).(string)
+	msg := a.messages[messageID]
+	for hash := range msg.envelopes {
+		delete(a.envelopes, hash)
+	}
+	delete(a.messages, messageID)
+}
@@ -0,0 +1,122 @@
+package receipts
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore map[string]Summary
+
+func (s memoryStore) SaveReceiptSummary(summary Summary) error {
+	s[summary.MessageID] = summary
+	return nil
+}
+
+func (s memoryStore) GetReceiptSummary(messageID string) (*Summary, error) {
+	summary, ok := s[messageID]
+	if !ok {
+		return nil, nil
+	}
+	return &summary, nil
+}
+
+func TestAggregateDeliveredAndRead(t *testing.T) {
+	store := memoryStore{}
+	var summaries []Summary
+	a := NewAggregator(store, func(s Summary) { summaries = append(summaries, s) }, DefaultMaxTrackedMessages)
+
+	envelopes := map[common.Hash]string{
+		{1}: "alice",
+		{2}: "bob",
+		{3}: "carol",
+	}
+	require.NoError(t, a.Track("msg", envelopes))
+	require.True(t, a.Owns(common.Hash{1}))
+	require.False(t, a.Owns(common.Hash{4}))
+
+	require.NoError(t, a.EnvelopeDelivered(common.Hash{1}))
+	require.NoError(t, a.EnvelopeDelivered(common.Hash{1}))
+	require.NoError(t, a.EnvelopeDelivered(common.Hash{2}))
+	require.NoError(t, a.MessageRead("msg", "carol"))
+
+	summary, err := a.Summary("msg")
+	require.NoError(t, err)
+	require.Equal(t, Summary{MessageID: "msg", Total: 3, Delivered: 3, Read: 1}, *summary)
+	require.Len(t, summaries, 3, "It notifies only changes")
+	require.Equal(t, *summary, store["msg"], "It persists the summary")
+}
+
+func TestAggregatorEviction(t *testing.T) {
+	store := memoryStore{}
+	a := NewAggregator(store, nil, 1)
+
+	require.NoError(t, a.Track("first", map[common.Hash]string{{1}: "alice"}))
+	require.NoError(t, a.EnvelopeDelivered(common.Hash{1}))
+	require.NoError(t, a.Track("second", map[common.Hash]string{{2}: "alice"}))
+
+	require.False(t, a.Owns(common.Hash{1}), "It evicts the oldest message")
+	require.NoError(t, a.MessageRead("first", "alice"))
+
+	summary, err := a.Summary("first")
+	require.NoError(t, err)
+	require.Equal(t, Summary{MessageID: "first", Total: 1, Delivered: 1}, *summary, "It returns the persisted summary")
+}
+
+func TestAggregatorMembers(t *testing.T) {
+	store := memoryStore{}
+	a := NewAggregator(store, nil, DefaultMaxTrackedMessages)
+
+	envelopes := map[common.Hash]string{
+		{1}: "alice",
+		{2}: "alice",
+		{3}: "bob",
+	}
+	require.NoError(t, a.Track("msg", envelopes))
+	require.NoError(t, a.MessageRead("msg", "mallory"))
+	require.NoError(t, a.MessageRead("msg", "eve"))
+	require.NoError(t, a.EnvelopeDelivered(common.Hash{1}))
+	require.NoError(t, a.EnvelopeDelivered(common.Hash{2}))
+
+	summary, err := a.Summary("msg")
+	require.NoError(t, err)
+	require.Equal(t, Summary{MessageID: "msg", Total: 2, Delivered: 1}, *summary,
+		"Members are counted once, and receipts of others are ignored")
+}
+
+// orderedStore checks that summaries are saved in the order they change.
+type orderedStore struct {
+	memoryStore
+	t *testing.T
+}
+
+func (s orderedStore) SaveReceiptSummary(summary Summary) error {
+	if previous, ok := s.memoryStore[summary.MessageID]; ok {
+		require.True(s.t, summary.Delivered >= previous.Delivered && summary.Read >= previous.Read)
+	}
+	return s.memoryStore.SaveReceiptSummary(summary)
+}
+
+func TestAggregatorConcurrentUpdates(t *testing.T) {
+	store := orderedStore{memoryStore{}, t}
+	a := NewAggregator(store, nil, DefaultMaxTrackedMessages)
+	envelopes := make(map[common.Hash]string)
+	for i := 0; i < 100; i++ {
+		envelopes[common.Hash{byte(i)}] = fmt.Sprintf("member%d", i)
+	}
+	require.NoError(t, a.Track("msg", envelopes))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			require.NoError(t, a.MessageRead("msg", fmt.Sprintf("member%d", i)))
+		}(i)
+	}
+	wg.Wait()
+	require.Equal(t, Summary{MessageID: "msg", Total: 100, Delivered: 100, Read: 100}, store.memoryStore["msg"])
+}
@@ -0,0 +1,258 @@
+package reencryption
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/status-im/status-go/db"
+	"github.com/status-im/status-go/services/report"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// reporter reports the stores that could not be re-encrypted to the client.
+var reporter = report.New("shhext")
+
+// Version identifies the cipher and the KDF an encrypted store is protected with.
+type Version struct {
+	Cipher int `json:"cipher"`
+	KDF    int `json:"kdf"`
+}
+
+// Store is an encrypted store that can be re-encrypted in stages.
+type Store interface {
+	// Name uniquely identifies the store.
+	Name() string
+	// ReencryptStage re-encrypts a stage of the store, starting at cursor.
+	// It returns the cursor of the next stage, or nil once the whole store is re-encrypted.
+	// A stage interrupted before returning must be safe to run again.
+	ReencryptStage(from, to Version, cursor []byte) ([]byte, error)
+}
+
+// record is the persisted state of an encrypted store.
+type record struct {
+	Version Version `json:"version"`
+	Target  Version `json:"target"`
+	Cursor  []byte  `json:"cursor"`
+}
+
+func (r record) done() bool {
+	return r.Version == r.Target
+}
+
+// NewManager returns a new Manager persisting its state in db.
+func NewManager(db *leveldb.DB) *Manager {
+	return &Manager{
+		db:  db,
+		log: log.New("package", "status-go/services/shhext/reencryption"),
+	}
+}
+
+// Manager tracks the versions of encrypted stores and re-encrypts them
+// in the background when their target version is upgraded.
+// The progress is persisted after every stage, so that the re-encryption
+// is resumed after a restart.
+type Manager struct {
+	db  *leveldb.DB
+	log log.Logger
+
+	mu      sync.Mutex
+	pending []Store
+	running map[string]*run
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Version returns the version a store is currently encrypted with.
+// If the store is not tracked yet, initial is recorded and returned.
+func (m *Manager) Version(name string, initial Version) (Version, error) {
+	r, err := m.load(name)
+	if err == leveldb.ErrNotFound {
+		r = record{Version: initial, Target: initial}
+		return initial, m.save(name, r)
+	} else if err != nil {
+		return Version{}, err
+	}
+	return r.Version, nil
+}
+
+// Reset records that a store was just written with version, like a copy of another
+// store, discarding the state of a previous store of the same name. It must not be
+// registered.
+func (m *Manager) Reset(name string, version Version) error {
+	return m.save(name, record{Version: version, Target: version})
+}
+
+// Register sets the target version of a store. If the store is not
+// encrypted with the target version, it is re-encrypted in the background.
+func (m *Manager) Register(store Store, target Version) error {
+	r, err := m.load(store.Name())
+	if err == leveldb.ErrNotFound {
+		r = record{Version: target}
+	} else if err != nil {
+		return err
+	}
+
+	// Restart from the beginning if the target changed during a re-encryption.
+	if r.Target != target {
+		r.Cursor = nil
+	}
+	r.Target = target
+	if err := m.save(store.Name(), r); err != nil {
+		return err
+	}
+	if r.done() {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.quit == nil {
+		m.pending = append(m.pending, store)
+		return nil
+	}
+	m.spawn(store)
+	return nil
+}
+
+// Start resumes the re-encryption of the registered stores.
+func (m *Manager) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.quit = make(chan struct{})
+	m.running = make(map[string]*run)
+	for _, store := range m.pending {
+		m.spawn(store)
+	}
+	m.pending = nil
+}
+
+// Stop interrupts the re-encryptions in progress and waits until they are stopped.
+// They are resumed from the last completed stage on the next start.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	if m.quit == nil {
+		m.mu.Unlock()
+		return
+	}
+	close(m.quit)
+	m.quit = nil
+	m.mu.Unlock()
+	m.wg.Wait()
+}
+
+// Unregister stops re-encrypting a store, which is about to be closed, and waits until
+// it is stopped. The re-encryption is resumed from the last completed stage once the
+// store is registered again.
+func (m *Manager) Unregister(name string) {
+	m.mu.Lock()
+	for i, store := range m.pending {
+		if store.Name() == name {
+			m.pending = append(m.pending[:i], m.pending[i+1:]...)
+			break
+		}
+	}
+	r, ok := m.running[name]
+	if ok {
+		close(r.stop)
+	}
+	m.mu.Unlock()
+	if ok {
+		<-r.done
+	}
+}
+
+// Rename moves the state of a store which is renamed. It must not be registered.
+func (m *Manager) Rename(name, newName string) error {
+	r, err := m.load(name)
+	if err == leveldb.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err := m.save(newName, r); err != nil {
+		return err
+	}
+	return m.db.Delete(db.Key(db.EncryptedStoresVersions, []byte(name)), nil)
+}
+
+// run is a re-encryption in progress, which is stopped by closing stop.
+type run struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// spawn must be called with the lock held.
+func (m *Manager) spawn(store Store) {
+	if _, ok := m.running[store.Name()]; ok {
+		return
+	}
+	r := &run{stop: make(chan struct{}), done: make(chan struct{})}
+	m.running[store.Name()] = r
+	quit := m.quit
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer close(r.done)
+		if err := m.reencrypt(store, quit, r.stop); err != nil {
+			reporter.Error("reencrypt-store", report.WithCode(err, report.CodeStorage), "store", store.Name())
+		}
+		m.mu.Lock()
+		delete(m.running, store.Name())
+		m.mu.Unlock()
+	}()
+}
+
+func (m *Manager) reencrypt(store Store, quit, stop chan struct{}) error {
+	m.log.Info("re-encrypting store", "store", store.Name())
+
+	for {
+		select {
+		case <-quit:
+			return nil
+		case <-stop:
+			return nil
+		default:
+		}
+
+		// Reloaded at every stage as the target might have been changed.
+		r, err := m.load(store.Name())
+		if err != nil {
+			return err
+		}
+		if r.done() {
+			m.log.Info("store re-encrypted", "store", store.Name(), "version", r.Version)
+			return nil
+		}
+
+		cursor, err := store.ReencryptStage(r.Version, r.Target, r.Cursor)
+		if err != nil {
+			return err
+		}
+		if cursor == nil {
+			r.Version = r.Target
+		}
+		r.Cursor = cursor
+		if err := m.save(store.Name(), r); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *Manager) load(name string) (r record, err error) {
+	data, err := m.db.Get(db.Key(db.EncryptedStoresVersions, []byte(name)), nil)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(data, &r)
+	return r, err
+}
+
+func (m *Manager) save(name string, r record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return m.db.Put(db.Key(db.EncryptedStoresVersions, []byte(name)), data, nil)
+}
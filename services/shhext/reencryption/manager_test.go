@@ -0,0 +1,204 @@
+package reencryption
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+var (
+	v1 = Version{Cipher: 1, KDF: 1}
+	v2 = Version{Cipher: 1, KDF: 2}
+)
+
+type stagedStore struct {
+	mu      sync.Mutex
+	stages  int
+	failAt  int
+	cursors [][]byte
+}
+
+func (s *stagedStore) Name() string {
+	return "staged"
+}
+
+func (s *stagedStore) ReencryptStage(from, to Version, cursor []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursors = append(s.cursors, cursor)
+	stage := 0
+	if cursor != nil {
+		stage = int(cursor[0])
+	}
+	if s.failAt != 0 && stage == s.failAt {
+		s.failAt = 0
+		return nil, errors.New("interrupted")
+	}
+	if stage+1 == s.stages {
+		return nil, nil
+	}
+	return []byte{byte(stage + 1)}, nil
+}
+
+func (s *stagedStore) seen() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cursors
+}
+
+func newInMemDB(t *testing.T) *leveldb.DB {
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+	return db
+}
+
+func waitForVersion(t *testing.T, m *Manager, name string, expected Version) {
+	for i := 0; i < 100; i++ {
+		r, err := m.load(name)
+		require.NoError(t, err)
+		if r.Version == expected {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("store %s was not re-encrypted to %v", name, expected)
+}
+
+func TestVersionRecordsInitial(t *testing.T) {
+	m := NewManager(newInMemDB(t))
+
+	version, err := m.Version("store", v1)
+	require.NoError(t, err)
+	require.Equal(t, v1, version)
+
+	version, err = m.Version("store", v2)
+	require.NoError(t, err)
+	require.Equal(t, v1, version, "It returns the recorded version")
+}
+
+func TestResetDiscardsState(t *testing.T) {
+	m := NewManager(newInMemDB(t))
+
+	_, err := m.Version("store", v1)
+	require.NoError(t, err)
+	require.NoError(t, m.Reset("store", v2))
+	version, err := m.Version("store", v1)
+	require.NoError(t, err)
+	require.Equal(t, v2, version, "It records the version the store was written with")
+}
+
+func TestRegisterWithSameVersion(t *testing.T) {
+	m := NewManager(newInMemDB(t))
+	store := &stagedStore{stages: 3}
+
+	_, err := m.Version(store.Name(), v1)
+	require.NoError(t, err)
+	m.Start()
+	defer m.Stop()
+
+	require.NoError(t, m.Register(store, v1))
+	m.Stop()
+	require.Empty(t, store.seen(), "It does not re-encrypt the store")
+}
+
+func TestReencryptInStages(t *testing.T) {
+	m := NewManager(newInMemDB(t))
+	store := &stagedStore{stages: 3}
+
+	_, err := m.Version(store.Name(), v1)
+	require.NoError(t, err)
+	require.NoError(t, m.Register(store, v2))
+	m.Start()
+	defer m.Stop()
+
+	waitForVersion(t, m, store.Name(), v2)
+	require.Equal(t, [][]byte{nil, {1}, {2}}, store.seen())
+
+	version, err := m.Version(store.Name(), v1)
+	require.NoError(t, err)
+	require.Equal(t, v2, version)
+}
+
+func TestReencryptResumes(t *testing.T) {
+	db := newInMemDB(t)
+	store := &stagedStore{stages: 4, failAt: 2}
+
+	m := NewManager(db)
+	_, err := m.Version(store.Name(), v1)
+	require.NoError(t, err)
+	m.Start()
+	require.NoError(t, m.Register(store, v2))
+	for i := 0; i < 100 && len(store.seen()) < 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	m.Stop()
+	require.Len(t, store.seen(), 3, "It stops at the failing stage")
+
+	// Simulates a restart.
+	m = NewManager(db)
+	require.NoError(t, m.Register(store, v2))
+	m.Start()
+	defer m.Stop()
+
+	waitForVersion(t, m, store.Name(), v2)
+	require.Equal(t, [][]byte{nil, {1}, {2}, {2}, {3}}, store.seen())
+}
+
+// endlessStore is never completely re-encrypted.
+type endlessStore struct {
+	mu     sync.Mutex
+	stages int
+}
+
+func (s *endlessStore) Name() string {
+	return "endless"
+}
+
+func (s *endlessStore) ReencryptStage(from, to Version, cursor []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stages++
+	time.Sleep(time.Millisecond)
+	return []byte{1}, nil
+}
+
+func (s *endlessStore) seen() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stages
+}
+
+func TestUnregister(t *testing.T) {
+	m := NewManager(newInMemDB(t))
+	pending := &stagedStore{stages: 3}
+	store := &endlessStore{}
+
+	_, err := m.Version(pending.Name(), v1)
+	require.NoError(t, err)
+	require.NoError(t, m.Register(pending, v2))
+	m.Unregister(pending.Name())
+	m.Start()
+	defer m.Stop()
+
+	_, err = m.Version(store.Name(), v1)
+	require.NoError(t, err)
+	require.NoError(t, m.Register(store, v2))
+	for i := 0; i < 100 && store.seen() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	m.Unregister(store.Name())
+	stages := store.seen()
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, stages, store.seen(), "It stops re-encrypting the store")
+	require.Empty(t, pending.seen(), "Pending stores are not re-encrypted")
+
+	require.NoError(t, m.Rename(store.Name(), "renamed"))
+	version, err := m.Version("renamed", v2)
+	require.NoError(t, err)
+	require.Equal(t, v1, version, "The state of renamed stores is kept")
+}
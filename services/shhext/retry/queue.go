@@ -0,0 +1,389 @@
+package retry
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/status-im/status-go/db"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	// DefaultInitialBackoff is the delay before the first retry of an envelope.
+	DefaultInitialBackoff = 5 * time.Second
+	// DefaultMaxBackoff is the maximum delay between two retries of an envelope.
+	DefaultMaxBackoff = 5 * time.Minute
+	// DefaultInterval is how often the queue looks for envelopes to retry.
+	DefaultInterval = time.Second
+)
+
+// Config of the retry queue.
+type Config struct {
+	// MaxAttempts is the number of times a failed envelope is posted again.
+	// Zero disables retries.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Interval       time.Duration
+}
+
+// DefaultConfig returns the default configuration with the given number of attempts.
+func DefaultConfig(maxAttempts int) Config {
+	return Config{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+		Interval:       DefaultInterval,
+	}
+}
+
+// Transport posts envelopes and tracks their delivery.
+type Transport interface {
+	// Post seals an envelope with the PoW of the message and posts it.
+	Post(envelope *whisper.Envelope, pow float64, workTime uint32) error
+	// Track starts tracking the delivery of an envelope.
+	Track(common.Hash)
+	// Connected returns true if envelopes can be delivered.
+	Connected() bool
+}
+
+// Handler is notified of the final outcome of retried envelopes. Envelopes are
+// always reported with the hash of the first envelope posted for the message.
+type Handler interface {
+	EnvelopeSent(common.Hash)
+	EnvelopeExpired(common.Hash)
+}
+
+// Record is a message whose envelope failed to be delivered and that is scheduled for a retry.
+// The encrypted data of the envelope is kept, rather than the message and its keys, and
+// sealed in a new envelope for each retry.
+type Record struct {
+	// ID is the hash of the first envelope posted for the message.
+	ID          common.Hash       `json:"id"`
+	Topic       whisper.TopicType `json:"topic"`
+	Data        []byte            `json:"data"`
+	TTL         uint32            `json:"ttl"`
+	PoW         float64           `json:"pow"`
+	WorkTime    uint32            `json:"workTime"`
+	Attempts    int               `json:"attempts"`
+	NextAttempt time.Time         `json:"nextAttempt"`
+
+	// inFlight is the hash of the last retried envelope, until it expires.
+	inFlight common.Hash
+}
+
+// Queue retries to post envelopes that expired before being delivered, with
+// an exponential backoff, while the transport is connected. Failed envelopes
+// are persisted, so that they are retried after a restart.
+type Queue struct {
+	db        *leveldb.DB
+	config    Config
+	transport Transport
+	handler   Handler
+
+	mu      sync.Mutex
+	posted  map[common.Hash]Record
+	retries map[common.Hash]common.Hash
+	records map[common.Hash]*Record
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// NewQueue returns a new Queue.
+func NewQueue(db *leveldb.DB, config Config, transport Transport, handler Handler) *Queue {
+	return &Queue{
+		db:        db,
+		config:    config,
+		transport: transport,
+		handler:   handler,
+		posted:    make(map[common.Hash]Record),
+		retries:   make(map[common.Hash]common.Hash),
+		records:   make(map[common.Hash]*Record),
+	}
+}
+
+// Enabled returns true if failed envelopes are retried.
+func (q *Queue) Enabled() bool {
+	return q.config.MaxAttempts > 0
+}
+
+// Start loads the persisted records and starts retrying them.
+func (q *Queue) Start() error {
+	if !q.Enabled() {
+		return nil
+	}
+	if err := q.load(); err != nil {
+		return err
+	}
+	q.quit = make(chan struct{})
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		ticker := time.NewTicker(q.config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-q.quit:
+				return
+			case now := <-ticker.C:
+				q.retryDue(now)
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops retrying envelopes.
+func (q *Queue) Stop() {
+	if q.quit == nil {
+		return
+	}
+	close(q.quit)
+	q.wg.Wait()
+	q.quit = nil
+}
+
+// Clear drops the envelopes scheduled for a retry and deletes them from the database.
+// Envelopes posted meanwhile are not retried anymore.
+func (q *Queue) Clear() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.posted = make(map[common.Hash]Record)
+	q.retries = make(map[common.Hash]common.Hash)
+	q.records = make(map[common.Hash]*Record)
+
+	batch := new(leveldb.Batch)
+	iter := q.db.NewIterator(util.BytesPrefix([]byte{byte(db.EnvelopesRetryQueue)}), nil)
+	for iter.Next() {
+		batch.Delete(iter.Key())
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return q.db.Write(batch, nil)
+}
+
+// Add keeps a posted envelope, sealed with pow during workTime seconds, until it is
+// delivered or expires.
+func (q *Queue) Add(envelope *whisper.Envelope, pow float64, workTime uint32) {
+	if !q.Enabled() {
+		return
+	}
+	hash := envelope.Hash()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.posted[hash] = Record{
+		ID:       hash,
+		Topic:    envelope.Topic,
+		Data:     envelope.Data,
+		TTL:      envelope.TTL,
+		PoW:      pow,
+		WorkTime: workTime,
+	}
+}
+
+// Sent must be called when an envelope is delivered. It returns false if the
+// envelope is not a retry, in which case it must be reported as usual.
+func (q *Queue) Sent(hash common.Hash) bool {
+	q.mu.Lock()
+	delete(q.posted, hash)
+	id, ok := q.retries[hash]
+	if !ok {
+		q.mu.Unlock()
+		return false
+	}
+	delete(q.retries, hash)
+	q.remove(id)
+	q.mu.Unlock()
+
+	log.Debug("retried envelope delivered", "id", id, "hash", hash)
+	if q.handler != nil {
+		q.handler.EnvelopeSent(id)
+	}
+	return true
+}
+
+// Expired must be called when an envelope expires before being delivered.
+// It returns false if the envelope won't be retried, in which case it must
+// be reported as usual.
+func (q *Queue) Expired(hash common.Hash) bool {
+	q.mu.Lock()
+	if record, ok := q.posted[hash]; ok {
+		delete(q.posted, hash)
+		q.schedule(&record, time.Now())
+		q.mu.Unlock()
+		return true
+	}
+
+	id, ok := q.retries[hash]
+	if !ok {
+		q.mu.Unlock()
+		return false
+	}
+	delete(q.retries, hash)
+	record, ok := q.records[id]
+	if !ok {
+		q.mu.Unlock()
+		return true
+	}
+	record.inFlight = common.Hash{}
+	if record.Attempts < q.config.MaxAttempts {
+		q.schedule(record, time.Now())
+		q.mu.Unlock()
+		return true
+	}
+	q.remove(id)
+	q.mu.Unlock()
+
+	log.Debug("envelope failed after retries", "id", id, "attempts", record.Attempts)
+	if q.handler != nil {
+		q.handler.EnvelopeExpired(id)
+	}
+	return true
+}
+
+// Records returns the messages scheduled for a retry.
+func (q *Queue) Records() []Record {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	records := make([]Record, 0, len(q.records))
+	for _, record := range q.records {
+		records = append(records, *record)
+	}
+	return records
+}
+
+func (q *Queue) retryDue(now time.Time) {
+	if !q.transport.Connected() {
+		return
+	}
+
+	q.mu.Lock()
+	var due []Record
+	for _, record := range q.records {
+		if record.inFlight == (common.Hash{}) && !record.NextAttempt.After(now) {
+			due = append(due, *record)
+		}
+	}
+	q.mu.Unlock()
+
+	for _, record := range due {
+		q.retry(record, now)
+	}
+}
+
+func (q *Queue) retry(record Record, now time.Time) {
+	// The lock is not held while posting, as the transport reports
+	// delivery events through Sent and Expired.
+	envelope := &whisper.Envelope{
+		Expiry: uint32(now.Add(time.Duration(record.TTL) * time.Second).Unix()),
+		TTL:    record.TTL,
+		Topic:  record.Topic,
+		Data:   record.Data,
+	}
+	err := q.transport.Post(envelope, record.PoW, record.WorkTime)
+	hash := envelope.Hash()
+
+	q.mu.Lock()
+	current, ok := q.records[record.ID]
+	if !ok {
+		q.mu.Unlock()
+		return
+	}
+	current.Attempts++
+	if err != nil {
+		log.Error("failed to retry envelope", "id", record.ID, "attempt", current.Attempts, "err", err)
+		if current.Attempts >= q.config.MaxAttempts {
+			q.remove(record.ID)
+			q.mu.Unlock()
+			if q.handler != nil {
+				q.handler.EnvelopeExpired(record.ID)
+			}
+			return
+		}
+		q.schedule(current, now)
+		q.mu.Unlock()
+		return
+	}
+
+	log.Debug("retried envelope", "id", record.ID, "hash", hash, "attempt", current.Attempts)
+	// The next attempt is scheduled when the envelope expires.
+	current.inFlight = hash
+	q.retries[hash] = record.ID
+	q.persist(current)
+	q.mu.Unlock()
+
+	q.transport.Track(hash)
+}
+
+// schedule must be called with the lock held.
+func (q *Queue) schedule(record *Record, now time.Time) {
+	record.NextAttempt = now.Add(q.backoff(record.Attempts))
+	q.records[record.ID] = record
+	q.persist(record)
+}
+
+// persist must be called with the lock held.
+func (q *Queue) persist(record *Record) {
+	data, err := json.Marshal(record)
+	if err == nil {
+		err = q.db.Put(key(record.ID), data, nil)
+	}
+	if err != nil {
+		log.Error("failed to persist envelope scheduled for a retry", "id", record.ID, "err", err)
+	}
+}
+
+// remove must be called with the lock held.
+func (q *Queue) remove(id common.Hash) {
+	delete(q.records, id)
+	if err := q.db.Delete(key(id), nil); err != nil {
+		log.Error("failed to delete envelope scheduled for a retry", "id", id, "err", err)
+	}
+}
+
+func (q *Queue) backoff(attempts int) time.Duration {
+	backoff := q.config.InitialBackoff
+	for i := 0; i < attempts && backoff < q.config.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > q.config.MaxBackoff {
+		backoff = q.config.MaxBackoff
+	}
+	return backoff
+}
+
+func (q *Queue) load() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	iter := q.db.NewIterator(util.BytesPrefix([]byte{byte(db.EnvelopesRetryQueue)}), nil)
+	defer iter.Release()
+	for iter.Next() {
+		var record Record
+		if err := json.Unmarshal(iter.Value(), &record); err != nil {
+			return err
+		}
+		if len(record.Data) == 0 {
+			// Records of older versions kept the message instead of its envelope.
+			log.Warn("dropping envelope scheduled for a retry without its data", "id", record.ID)
+			if err := q.db.Delete(iter.Key(), nil); err != nil {
+				return err
+			}
+			continue
+		}
+		q.records[record.ID] = &record
+	}
+	return iter.Error()
+}
+
+func key(id common.Hash) []byte {
+	return db.Key(db.EnvelopesRetryQueue, id.Bytes())
+}
@@ -0,0 +1,166 @@
+package retry
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+type transportMock struct {
+	connected bool
+	err       error
+	posted    []*whisper.Envelope
+	tracked   []common.Hash
+}
+
+func (t *transportMock) Post(envelope *whisper.Envelope, pow float64, workTime uint32) error {
+	if t.err != nil {
+		return t.err
+	}
+	// each retry has a distinct nonce
+	envelope.Nonce = uint64(len(t.posted) + 1)
+	t.posted = append(t.posted, envelope)
+	return nil
+}
+
+func (t *transportMock) Track(hash common.Hash) {
+	t.tracked = append(t.tracked, hash)
+}
+
+func (t *transportMock) Connected() bool {
+	return t.connected
+}
+
+type handlerMock struct {
+	sent    []common.Hash
+	expired []common.Hash
+}
+
+func (h *handlerMock) EnvelopeSent(hash common.Hash) {
+	h.sent = append(h.sent, hash)
+}
+
+func (h *handlerMock) EnvelopeExpired(hash common.Hash) {
+	h.expired = append(h.expired, hash)
+}
+
+func newTestQueue(t *testing.T, maxAttempts int) (*Queue, *transportMock, *handlerMock, *leveldb.DB) {
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+	transport := &transportMock{}
+	handler := &handlerMock{}
+	return NewQueue(db, DefaultConfig(maxAttempts), transport, handler), transport, handler, db
+}
+
+func newEnvelope(data string) *whisper.Envelope {
+	return &whisper.Envelope{Expiry: 1546000010, TTL: 10, Topic: whisper.TopicType{1}, Data: []byte(data)}
+}
+
+func TestRetryUntilDelivered(t *testing.T) {
+	q, transport, handler, _ := newTestQueue(t, 3)
+	envelope := newEnvelope("hello")
+	id := envelope.Hash()
+
+	q.Add(envelope, 0.002, 1)
+	require.True(t, q.Expired(id), "It hides the failure of an envelope that is retried")
+	require.Len(t, q.Records(), 1)
+
+	now := time.Now().Add(DefaultInitialBackoff)
+	q.retryDue(now)
+	require.Empty(t, transport.posted, "It waits for connectivity")
+
+	transport.connected = true
+	q.retryDue(now)
+	require.Len(t, transport.posted, 1)
+	require.Equal(t, envelope.Topic, transport.posted[0].Topic)
+	require.Equal(t, envelope.Data, transport.posted[0].Data, "The encrypted data is posted again")
+	require.Equal(t, uint32(now.Unix())+envelope.TTL, transport.posted[0].Expiry, "The retry expires after the TTL")
+	require.Len(t, transport.tracked, 1)
+	retried := transport.tracked[0]
+	require.Equal(t, transport.posted[0].Hash(), retried)
+
+	q.retryDue(now.Add(DefaultMaxBackoff))
+	require.Len(t, transport.posted, 1, "It doesn't retry while an envelope is in flight")
+
+	require.True(t, q.Sent(retried))
+	require.Equal(t, []common.Hash{id}, handler.sent, "It reports the first envelope")
+	require.Empty(t, q.Records())
+
+	require.False(t, q.Sent(common.Hash{2}), "It ignores envelopes that are not retries")
+	require.False(t, q.Expired(common.Hash{2}))
+}
+
+func TestRetryFailsAfterMaxAttempts(t *testing.T) {
+	q, transport, handler, _ := newTestQueue(t, 2)
+	transport.connected = true
+	envelope := newEnvelope("hello")
+	id := envelope.Hash()
+
+	q.Add(envelope, 0, 0)
+	q.Expired(id)
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		now = now.Add(DefaultMaxBackoff)
+		q.retryDue(now)
+		require.True(t, q.Expired(transport.tracked[len(transport.tracked)-1]))
+	}
+
+	require.Len(t, transport.posted, 2)
+	require.Equal(t, []common.Hash{id}, handler.expired)
+	require.Empty(t, q.Records())
+}
+
+func TestRetryPersistsRecords(t *testing.T) {
+	q, transport, handler, db := newTestQueue(t, 1)
+	envelope := newEnvelope("hello")
+	id := envelope.Hash()
+	q.Add(envelope, 0.002, 1)
+	q.Expired(id)
+
+	restarted := NewQueue(db, DefaultConfig(1), transport, handler)
+	require.NoError(t, restarted.load())
+	records := restarted.Records()
+	require.Len(t, records, 1)
+	require.Equal(t, id, records[0].ID)
+	require.Equal(t, []byte("hello"), records[0].Data)
+	require.Equal(t, envelope.Topic, records[0].Topic)
+	require.Equal(t, 0.002, records[0].PoW)
+
+	transport.connected = true
+	transport.err = errors.New("unknown key")
+	restarted.retryDue(time.Now().Add(DefaultMaxBackoff))
+	require.Equal(t, []common.Hash{id}, handler.expired, "It fails when the message can't be posted anymore")
+	require.NoError(t, restarted.load())
+	require.Empty(t, restarted.Records())
+}
+
+func TestBackoff(t *testing.T) {
+	q, _, _, _ := newTestQueue(t, 10)
+	require.Equal(t, DefaultInitialBackoff, q.backoff(0))
+	require.Equal(t, 2*DefaultInitialBackoff, q.backoff(1))
+	require.Equal(t, DefaultMaxBackoff, q.backoff(100))
+}
+
+func TestDisabled(t *testing.T) {
+	q, _, _, _ := newTestQueue(t, 0)
+	envelope := newEnvelope("hello")
+	q.Add(envelope, 0, 0)
+	require.False(t, q.Expired(envelope.Hash()))
+}
+
+func TestRetryDropsRecordsWithoutData(t *testing.T) {
+	q, _, _, db := newTestQueue(t, 1)
+	id := common.Hash{1}
+	require.NoError(t, db.Put(key(id), []byte(`{"id":"`+id.Hex()+`","message":{"payload":"0x01"}}`), nil))
+
+	require.NoError(t, q.load())
+	require.Empty(t, q.Records(), "Records of older versions can't be retried")
+	_, err := db.Get(key(id), nil)
+	require.Equal(t, leveldb.ErrNotFound, err)
+}
@@ -0,0 +1,286 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	// ErrUnknownDatabase is returned when migrating a database that is not registered.
+	ErrUnknownDatabase = errors.New("unknown database")
+	// ErrDirty is returned when migrating a database whose last migration failed without
+	// being rolled back. It must be restored from a backup.
+	ErrDirty = errors.New("database is dirty")
+	// ErrNothingToRollback is returned when rolling back a database without applied migrations.
+	ErrNothingToRollback = errors.New("no migration to roll back")
+)
+
+// Migration is a migration of the schema of a database.
+type Migration struct {
+	Version uint   `json:"version"`
+	Name    string `json:"name"`
+}
+
+// Database is a database whose schema is migrated by the coordinator.
+type Database interface {
+	// Name uniquely identifies the database.
+	Name() string
+	// Migrations returns the known migrations of the database, from the oldest.
+	Migrations() ([]Migration, error)
+	// Version returns the version of the last applied migration, or false if none
+	// was applied, and whether it failed.
+	Version() (version uint, applied bool, dirty bool, err error)
+	// Steps applies the next n migrations if n is positive, or rolls back the last -n
+	// applied migrations. Each migration is applied in a transaction.
+	Steps(n int) error
+	// Force records the version of the last applied migration without running it, and
+	// clears the failure. -1 records that no migration was applied.
+	Force(version int) error
+	// Backup writes a copy of the database to path.
+	Backup(path string) error
+}
+
+// Status is the state of the migrations of a database.
+type Status struct {
+	Database string      `json:"database"`
+	Version  uint        `json:"version"`
+	Dirty    bool        `json:"dirty"`
+	Applied  []Migration `json:"applied"`
+	Pending  []Migration `json:"pending"`
+}
+
+// Options are the options of a migration.
+type Options struct {
+	// DryRun returns the pending migrations without applying them.
+	DryRun bool `json:"dryRun"`
+	// Backup backs up the database before applying the pending migrations, unless
+	// no migration was applied yet.
+	Backup bool `json:"backup"`
+}
+
+// Result is the outcome of a migration or a rollback.
+type Result struct {
+	Database string `json:"database"`
+	From     uint   `json:"from"`
+	To       uint   `json:"to"`
+	// Applied are the applied migrations, or those that would be applied in a dry run.
+	Applied []Migration `json:"applied"`
+	// RolledBack is the migration that was rolled back, after failing or on request.
+	RolledBack *Migration `json:"rolledBack,omitempty"`
+	// Backup is the path of the backup taken before migrating.
+	Backup string `json:"backup,omitempty"`
+	DryRun bool   `json:"dryRun"`
+}
+
+// Coordinator reports and applies the migrations of the registered databases. A failed
+// migration is rolled back, so that the database stays at the previous version.
+type Coordinator struct {
+	backupDir string
+	log       log.Logger
+
+	mu        sync.Mutex
+	databases map[string]Database
+}
+
+// NewCoordinator returns a new Coordinator keeping the backups in backupDir.
+func NewCoordinator(backupDir string) *Coordinator {
+	return &Coordinator{
+		backupDir: backupDir,
+		log:       log.New("package", "status-go/services/shhext/schema"),
+		databases: make(map[string]Database),
+	}
+}
+
+// Register adds a database, replacing the one of the same name.
+func (c *Coordinator) Register(db Database) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.databases[db.Name()] = db
+}
+
+// Unregister removes a database, which is about to be closed.
+func (c *Coordinator) Unregister(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.databases, name)
+}
+
+func (c *Coordinator) database(name string) (Database, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	db, ok := c.databases[name]
+	if !ok {
+		return nil, ErrUnknownDatabase
+	}
+	return db, nil
+}
+
+// Status returns the state of the migrations of the registered databases, by name.
+func (c *Coordinator) Status() ([]Status, error) {
+	c.mu.Lock()
+	names := make([]string, 0, len(c.databases))
+	for name := range c.databases {
+		names = append(names, name)
+	}
+	c.mu.Unlock()
+	sort.Strings(names)
+
+	result := make([]Status, 0, len(names))
+	for _, name := range names {
+		db, err := c.database(name)
+		if err == ErrUnknownDatabase {
+			continue
+		}
+		status, err := status(db)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, status)
+	}
+	return result, nil
+}
+
+// Migrate applies the pending migrations of a database, one at a time. If a migration
+// fails, it is rolled back and the previous ones stay applied.
+func (c *Coordinator) Migrate(name string, options Options) (*Result, error) {
+	db, err := c.database(name)
+	if err != nil {
+		return nil, err
+	}
+	current, err := status(db)
+	if err != nil {
+		return nil, err
+	}
+	if current.Dirty {
+		return nil, ErrDirty
+	}
+
+	result := &Result{Database: name, From: current.Version, To: current.Version, Applied: []Migration{}, DryRun: options.DryRun}
+	if options.DryRun {
+		result.Applied = current.Pending
+		if len(current.Pending) > 0 {
+			result.To = current.Pending[len(current.Pending)-1].Version
+		}
+		return result, nil
+	}
+	if len(current.Pending) == 0 {
+		return result, nil
+	}
+	// New databases have nothing to back up.
+	if options.Backup && len(current.Applied) > 0 {
+		if result.Backup, err = c.backup(db, current.Version); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, migration := range current.Pending {
+		if err := db.Steps(1); err != nil {
+			c.log.Error("migration failed", "database", name, "version", migration.Version, "err", err)
+			previous := -1
+			if i > 0 {
+				previous = int(current.Pending[i-1].Version)
+			} else if len(current.Applied) > 0 {
+				previous = int(current.Version)
+			}
+			if forceErr := db.Force(previous); forceErr != nil {
+				return result, fmt.Errorf("migration %d failed: %v, and can't be rolled back: %v", migration.Version, err, forceErr)
+			}
+			failed := migration
+			result.RolledBack = &failed
+			return result, fmt.Errorf("migration %d failed and was rolled back: %v", migration.Version, err)
+		}
+		result.Applied = append(result.Applied, migration)
+		result.To = migration.Version
+	}
+	return result, nil
+}
+
+// Rollback rolls back the last applied migration of a database.
+func (c *Coordinator) Rollback(name string) (*Result, error) {
+	db, err := c.database(name)
+	if err != nil {
+		return nil, err
+	}
+	current, err := status(db)
+	if err != nil {
+		return nil, err
+	}
+	if current.Dirty {
+		return nil, ErrDirty
+	}
+	if len(current.Applied) == 0 {
+		return nil, ErrNothingToRollback
+	}
+	if err := db.Steps(-1); err != nil {
+		return nil, err
+	}
+
+	last := current.Applied[len(current.Applied)-1]
+	result := &Result{Database: name, From: current.Version, Applied: []Migration{}, RolledBack: &last}
+	if len(current.Applied) > 1 {
+		result.To = current.Applied[len(current.Applied)-2].Version
+	}
+	return result, nil
+}
+
+// Backups returns the paths of the backups of a database, which are encrypted like it.
+func (c *Coordinator) Backups(name string) ([]string, error) {
+	return filepath.Glob(filepath.Join(c.backupDir, name+".*.bak"))
+}
+
+// backup writes a copy of a database at a version to the backup directory, replacing the
+// previous backups of the database.
+func (c *Coordinator) backup(db Database, version uint) (string, error) {
+	if err := os.MkdirAll(c.backupDir, os.ModePerm); err != nil {
+		return "", err
+	}
+	previous, err := c.Backups(db.Name())
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(c.backupDir, fmt.Sprintf("%s.%d.bak", db.Name(), version))
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if err := db.Backup(path); err != nil {
+		_ = os.Remove(path)
+		return "", err
+	}
+	for _, old := range previous {
+		if old == path {
+			continue
+		}
+		if err := os.Remove(old); err != nil {
+			c.log.Warn("failed to remove previous backup", "path", old, "err", err)
+		}
+	}
+	return path, nil
+}
+
+// status returns the applied and the pending migrations of a database.
+func status(db Database) (Status, error) {
+	migrations, err := db.Migrations()
+	if err != nil {
+		return Status{}, err
+	}
+	version, applied, dirty, err := db.Version()
+	if err != nil {
+		return Status{}, err
+	}
+
+	result := Status{Database: db.Name(), Version: version, Dirty: dirty, Applied: []Migration{}, Pending: []Migration{}}
+	for _, migration := range migrations {
+		if applied && migration.Version <= version {
+			result.Applied = append(result.Applied, migration)
+		} else {
+			result.Pending = append(result.Pending, migration)
+		}
+	}
+	return result, nil
+}
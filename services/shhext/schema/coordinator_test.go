@@ -0,0 +1,156 @@
+package schema
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memoryDatabase applies migrations by recording their version.
+type memoryDatabase struct {
+	migrations []Migration
+	applied    int
+	dirty      bool
+	failAt     uint
+	backups    []string
+}
+
+func newMemoryDatabase(versions ...uint) *memoryDatabase {
+	db := &memoryDatabase{}
+	for _, version := range versions {
+		db.migrations = append(db.migrations, Migration{Version: version, Name: "migration"})
+	}
+	return db
+}
+
+func (db *memoryDatabase) Name() string {
+	return "memory"
+}
+
+func (db *memoryDatabase) Migrations() ([]Migration, error) {
+	return db.migrations, nil
+}
+
+func (db *memoryDatabase) Version() (uint, bool, bool, error) {
+	if db.applied == 0 {
+		return 0, false, db.dirty, nil
+	}
+	return db.migrations[db.applied-1].Version, true, db.dirty, nil
+}
+
+func (db *memoryDatabase) Steps(n int) error {
+	if n > 0 && db.migrations[db.applied].Version == db.failAt {
+		db.applied++
+		db.dirty = true
+		return errors.New("invalid statement")
+	}
+	db.applied += n
+	return nil
+}
+
+func (db *memoryDatabase) Force(version int) error {
+	db.dirty = false
+	db.applied = 0
+	for i, migration := range db.migrations {
+		if int(migration.Version) == version {
+			db.applied = i + 1
+		}
+	}
+	return nil
+}
+
+func (db *memoryDatabase) Backup(path string) error {
+	db.backups = append(db.backups, path)
+	return ioutil.WriteFile(path, nil, 0600)
+}
+
+func newTestCoordinator(t *testing.T) (*Coordinator, func()) {
+	dir, err := ioutil.TempDir("", "status-schema")
+	require.NoError(t, err)
+	return NewCoordinator(dir), func() { os.RemoveAll(dir) }
+}
+
+func TestMigrate(t *testing.T) {
+	coordinator, cleanup := newTestCoordinator(t)
+	defer cleanup()
+	db := newMemoryDatabase(1, 2, 3)
+	_, err := coordinator.Migrate("memory", Options{})
+	require.Equal(t, ErrUnknownDatabase, err)
+	coordinator.Register(db)
+
+	result, err := coordinator.Migrate("memory", Options{DryRun: true, Backup: true})
+	require.NoError(t, err)
+	require.Equal(t, db.migrations, result.Applied)
+	require.Equal(t, uint(3), result.To)
+	require.Equal(t, 0, db.applied, "Dry runs don't apply migrations")
+	require.Empty(t, db.backups)
+
+	result, err = coordinator.Migrate("memory", Options{Backup: true})
+	require.NoError(t, err)
+	require.Equal(t, 3, db.applied)
+	require.Empty(t, result.Backup, "New databases are not backed up")
+
+	status, err := coordinator.Status()
+	require.NoError(t, err)
+	require.Equal(t, []Status{{Database: "memory", Version: 3, Applied: db.migrations, Pending: []Migration{}}}, status)
+
+	result, err = coordinator.Migrate("memory", Options{})
+	require.NoError(t, err)
+	require.Empty(t, result.Applied)
+}
+
+func TestMigrateFailure(t *testing.T) {
+	coordinator, cleanup := newTestCoordinator(t)
+	defer cleanup()
+	db := newMemoryDatabase(1, 2, 3, 4)
+	coordinator.Register(db)
+	require.NoError(t, db.Steps(1))
+
+	db.failAt = 3
+	result, err := coordinator.Migrate("memory", Options{Backup: true})
+	require.Error(t, err)
+	require.Equal(t, []Migration{db.migrations[1]}, result.Applied)
+	require.Equal(t, &db.migrations[2], result.RolledBack)
+	require.Equal(t, filepath.Join(coordinator.backupDir, "memory.1.bak"), result.Backup)
+	require.False(t, db.dirty, "The failed migration is rolled back")
+	require.Equal(t, 2, db.applied)
+
+	db.failAt = 0
+	result, err = coordinator.Migrate("memory", Options{Backup: true})
+	require.NoError(t, err)
+	require.Equal(t, uint(4), result.To)
+	backups, err := filepath.Glob(filepath.Join(coordinator.backupDir, "*.bak"))
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(coordinator.backupDir, "memory.2.bak")}, backups, "Previous backups are replaced")
+
+	db.dirty = true
+	_, err = coordinator.Migrate("memory", Options{})
+	require.Equal(t, ErrDirty, err)
+}
+
+func TestRollback(t *testing.T) {
+	coordinator, cleanup := newTestCoordinator(t)
+	defer cleanup()
+	db := newMemoryDatabase(1, 2)
+	coordinator.Register(db)
+	_, err := coordinator.Rollback("memory")
+	require.Equal(t, ErrNothingToRollback, err)
+
+	_, err = coordinator.Migrate("memory", Options{})
+	require.NoError(t, err)
+	result, err := coordinator.Rollback("memory")
+	require.NoError(t, err)
+	require.Equal(t, uint(2), result.From)
+	require.Equal(t, uint(1), result.To)
+	require.Equal(t, &db.migrations[1], result.RolledBack)
+	require.Equal(t, 1, db.applied)
+
+	coordinator.Unregister("memory")
+	status, err := coordinator.Status()
+	require.NoError(t, err)
+	require.Empty(t, status)
+}
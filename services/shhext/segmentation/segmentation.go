@@ -0,0 +1,278 @@
+package segmentation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// MaxSegments is the maximum number of segments a payload is split into.
+	MaxSegments = 1024
+	// DefaultTTL is how long the segments of an incomplete payload are kept.
+	DefaultTTL = 24 * time.Hour
+	// MaxPendingPayloads is the number of incomplete payloads kept for a sender.
+	MaxPendingPayloads = 16
+	// MaxPendingSize is the size of the segments of the incomplete payloads kept for a sender.
+	MaxPendingSize = 32 * 1024 * 1024
+	// magic starts the payload of every segment. Protocol messages can't start with it,
+	// as 'S' would be the tag of a deprecated protobuf group.
+	magic = "SEG1"
+	// headerSize is the size of the header prepended to each segment: the magic,
+	// the hash of the payload, the index of the segment and the number of segments.
+	headerSize = len(magic) + common.HashLength + 4
+)
+
+var (
+	// ErrTooLarge is returned when a payload would be split into more than MaxSegments segments.
+	ErrTooLarge = errors.New("payload is too large")
+	// ErrInvalidSegment is returned when a segment has an invalid header.
+	ErrInvalidSegment = errors.New("invalid segment")
+	// ErrStoreNotSet is returned when segments are received before the store is set.
+	ErrStoreNotSet = errors.New("segments store is not set")
+	// ErrCorrupted is returned when the reassembled payload doesn't match the hash of its segments.
+	ErrCorrupted = errors.New("reassembled payload is corrupted")
+	// ErrUnsignedSegment is returned for segments without a sender, whose payload couldn't
+	// be attributed to anyone.
+	ErrUnsignedSegment = errors.New("segment is not signed")
+	// ErrConflictingSegment is returned when a sender sends a segment that doesn't match
+	// the segments of the payload already received.
+	ErrConflictingSegment = errors.New("segment conflicts with the segments received")
+	// ErrTooManyPending is returned when a sender has more incomplete payloads than
+	// MaxPendingPayloads, or bigger than MaxPendingSize.
+	ErrTooManyPending = errors.New("too many incomplete payloads")
+)
+
+// Segment is a part of a payload that was split to fit in an envelope.
+type Segment struct {
+	// Sender is the public key that signed the segment. The payload is only reassembled
+	// from the segments of the same sender.
+	Sender []byte
+	// Hash is the hash of the whole payload, shared by its segments.
+	Hash  []byte
+	Index int
+	Count int
+	Data  []byte
+	// ReceivedAt is the time the segment was received, in milliseconds.
+	ReceivedAt int64
+}
+
+// Progress is the reassembly state of a segmented payload.
+type Progress struct {
+	Hash     common.Hash `json:"hash"`
+	Received int         `json:"received"`
+	Total    int         `json:"total"`
+}
+
+// Store persists the segments received until their payload is complete, by sender.
+type Store interface {
+	// SaveSegment persists a segment, unless the segment of the payload with the same
+	// index was already saved.
+	SaveSegment(Segment) error
+	// GetSegments returns the segments of a payload sent by a sender, in any order.
+	GetSegments(sender, hash []byte) ([]Segment, error)
+	DeleteSegments(sender, hash []byte) error
+	// GetPendingSegments returns the number of incomplete payloads of a sender, and the
+	// size of their segments.
+	GetPendingSegments(sender []byte) (payloads int, size int, err error)
+	// PruneSegments deletes the segments received before the given time and returns their count.
+	PruneSegments(before int64) (int, error)
+}
+
+// ProgressHandler is notified every time a segment of a payload is received.
+type ProgressHandler func(Progress)
+
+// IsSegment returns true if the payload is a segment.
+func IsSegment(payload []byte) bool {
+	return bytes.HasPrefix(payload, []byte(magic))
+}
+
+// Split splits a payload into segments whose size, header included, is at most size.
+// Payloads that fit are returned unchanged, so that clients that don't support
+// segmentation can still read them.
+func Split(payload []byte, size int) ([][]byte, error) {
+	if len(payload) <= size {
+		return [][]byte{payload}, nil
+	}
+	count, dataSize, err := segments(len(payload), size)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := crypto.Keccak256(payload)
+	segments := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * dataSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+		segment := make([]byte, headerSize, headerSize+end-i*dataSize)
+		copy(segment, magic)
+		copy(segment[len(magic):], hash)
+		binary.BigEndian.PutUint16(segment[len(magic)+common.HashLength:], uint16(i))
+		binary.BigEndian.PutUint16(segment[len(magic)+common.HashLength+2:], uint16(count))
+		segments = append(segments, append(segment, payload[i*dataSize:end]...))
+	}
+	return segments, nil
+}
+
+// Sizes returns the sizes of the payloads Split returns for a payload of length bytes,
+// without splitting it.
+func Sizes(length int, size int) ([]int, error) {
+	if length <= size {
+		return []int{length}, nil
+	}
+	count, dataSize, err := segments(length, size)
+	if err != nil {
+		return nil, err
+	}
+	sizes := make([]int, count)
+	for i := range sizes {
+		sizes[i] = headerSize + dataSize
+	}
+	sizes[count-1] = headerSize + length - (count-1)*dataSize
+	return sizes, nil
+}
+
+// segments returns the number of segments a payload of length bytes is split into,
+// and the size of the data of each segment but the last.
+func segments(length int, size int) (int, int, error) {
+	if size <= headerSize {
+		return 0, 0, ErrTooLarge
+	}
+	dataSize := size - headerSize
+	count := (length + dataSize - 1) / dataSize
+	if count > MaxSegments {
+		return 0, 0, ErrTooLarge
+	}
+	return count, dataSize, nil
+}
+
+// parse decodes the header of a segment.
+func parse(payload []byte) (Segment, error) {
+	if !IsSegment(payload) || len(payload) < headerSize {
+		return Segment{}, ErrInvalidSegment
+	}
+	segment := Segment{
+		Hash:  payload[len(magic) : len(magic)+common.HashLength],
+		Index: int(binary.BigEndian.Uint16(payload[len(magic)+common.HashLength:])),
+		Count: int(binary.BigEndian.Uint16(payload[len(magic)+common.HashLength+2:])),
+		Data:  payload[headerSize:],
+	}
+	if segment.Count < 2 || segment.Count > MaxSegments || segment.Index >= segment.Count {
+		return Segment{}, ErrInvalidSegment
+	}
+	return segment, nil
+}
+
+// Reassembler persists the segments received and reassembles their payload once
+// all of them were received, even across restarts.
+type Reassembler struct {
+	handler ProgressHandler
+	ttl     time.Duration
+
+	mu    sync.Mutex
+	store Store
+}
+
+// NewReassembler returns a new Reassembler keeping the segments of incomplete
+// payloads for ttl. The handler can be nil.
+func NewReassembler(handler ProgressHandler, ttl time.Duration) *Reassembler {
+	return &Reassembler{handler: handler, ttl: ttl}
+}
+
+// SetStore sets the store of the segments, which is only available once the account is selected.
+func (r *Reassembler) SetStore(store Store) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store = store
+}
+
+// Prune deletes the segments of the payloads incomplete for longer than the TTL.
+// It returns the number of segments deleted.
+func (r *Reassembler) Prune() (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.store == nil {
+		return 0, ErrStoreNotSet
+	}
+	return r.store.PruneSegments(toMillis(time.Now().Add(-r.ttl)))
+}
+
+// Handle records a segment received from sender, the public key that signed it. It returns
+// the whole payload once all its segments were received from the same sender, or nil.
+func (r *Reassembler) Handle(sender []byte, payload []byte) ([]byte, error) {
+	segment, err := parse(payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(sender) == 0 {
+		return nil, ErrUnsignedSegment
+	}
+	segment.Sender = sender
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.store == nil {
+		return nil, ErrStoreNotSet
+	}
+
+	segments, err := r.store.GetSegments(sender, segment.Hash)
+	if err != nil {
+		return nil, err
+	}
+	received := make(map[int]Segment, len(segments)+1)
+	for _, s := range segments {
+		if s.Count != segment.Count || (s.Index == segment.Index && !bytes.Equal(s.Data, segment.Data)) {
+			return nil, ErrConflictingSegment
+		}
+		received[s.Index] = s
+	}
+	if _, ok := received[segment.Index]; !ok {
+		payloads, size, err := r.store.GetPendingSegments(sender)
+		if err != nil {
+			return nil, err
+		}
+		if (len(segments) == 0 && payloads >= MaxPendingPayloads) || size+len(segment.Data) > MaxPendingSize {
+			return nil, ErrTooManyPending
+		}
+		segment.ReceivedAt = toMillis(time.Now())
+		if err := r.store.SaveSegment(segment); err != nil {
+			return nil, err
+		}
+		received[segment.Index] = segment
+	}
+	if r.handler != nil {
+		r.handler(Progress{Hash: common.BytesToHash(segment.Hash), Received: len(received), Total: segment.Count})
+	}
+	if len(received) < segment.Count {
+		return nil, nil
+	}
+
+	ordered := make([]Segment, 0, len(received))
+	for _, s := range received {
+		ordered = append(ordered, s)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Index < ordered[j].Index })
+	var whole []byte
+	for _, s := range ordered {
+		whole = append(whole, s.Data...)
+	}
+	if err := r.store.DeleteSegments(sender, segment.Hash); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(crypto.Keccak256(whole), segment.Hash) {
+		return nil, ErrCorrupted
+	}
+	return whole, nil
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,206 @@
+package segmentation
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memStore keeps the segments by sender and hash.
+var (
+	alice = []byte("alice")
+	bob   = []byte("bob")
+)
+
+type memStore map[string]map[int]Segment
+
+func (s memStore) SaveSegment(segment Segment) error {
+	key := string(segment.Sender) + string(segment.Hash)
+	if s[key] == nil {
+		s[key] = make(map[int]Segment)
+	}
+	if _, ok := s[key][segment.Index]; !ok {
+		s[key][segment.Index] = segment
+	}
+	return nil
+}
+
+func (s memStore) GetSegments(sender, hash []byte) ([]Segment, error) {
+	var result []Segment
+	for _, segment := range s[string(sender)+string(hash)] {
+		result = append(result, segment)
+	}
+	return result, nil
+}
+
+func (s memStore) DeleteSegments(sender, hash []byte) error {
+	delete(s, string(sender)+string(hash))
+	return nil
+}
+
+func (s memStore) GetPendingSegments(sender []byte) (int, int, error) {
+	payloads, size := 0, 0
+	for _, segments := range s {
+		counted := false
+		for _, segment := range segments {
+			if string(segment.Sender) != string(sender) {
+				continue
+			}
+			size += len(segment.Data)
+			if !counted {
+				payloads++
+				counted = true
+			}
+		}
+	}
+	return payloads, size, nil
+}
+
+func (s memStore) PruneSegments(before int64) (int, error) {
+	return 0, nil
+}
+
+func TestSplit(t *testing.T) {
+	payload := make([]byte, 1000)
+	rand.Read(payload)
+
+	segments, err := Split(payload, 1000)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{payload}, segments, "Payloads that fit are not segmented")
+	require.False(t, IsSegment(payload))
+
+	segments, err = Split(payload, 300)
+	require.NoError(t, err)
+	require.Len(t, segments, 4)
+	for _, segment := range segments {
+		require.True(t, IsSegment(segment))
+		require.True(t, len(segment) <= 300)
+	}
+
+	_, err = Split(make([]byte, MaxSegments*10+1), headerSize+10)
+	require.Equal(t, ErrTooLarge, err)
+}
+
+func TestSizes(t *testing.T) {
+	payload := make([]byte, 1000)
+	rand.Read(payload)
+
+	for _, size := range []int{1000, 300, headerSize + 1} {
+		segments, err := Split(payload, size)
+		require.NoError(t, err)
+		sizes, err := Sizes(len(payload), size)
+		require.NoError(t, err)
+		require.Len(t, sizes, len(segments))
+		for i, segment := range segments {
+			require.Equal(t, len(segment), sizes[i])
+		}
+	}
+
+	_, err := Sizes(MaxSegments*10+1, headerSize+10)
+	require.Equal(t, ErrTooLarge, err)
+}
+
+func TestReassemble(t *testing.T) {
+	payload := make([]byte, 1000)
+	rand.Read(payload)
+	segments, err := Split(payload, 300)
+	require.NoError(t, err)
+
+	var progress []Progress
+	r := NewReassembler(func(p Progress) { progress = append(progress, p) }, DefaultTTL)
+	_, err = r.Handle(alice, segments[0])
+	require.Equal(t, ErrStoreNotSet, err)
+
+	store := memStore{}
+	r.SetStore(store)
+	_, err = r.Handle(nil, segments[0])
+	require.Equal(t, ErrUnsignedSegment, err)
+	for _, i := range []int{3, 1, 1, 0} {
+		whole, err := r.Handle(alice, segments[i])
+		require.NoError(t, err)
+		require.Nil(t, whole)
+	}
+	whole, err := r.Handle(bob, segments[2])
+	require.NoError(t, err)
+	require.Nil(t, whole, "Segments of other senders are not reassembled with the payload")
+	whole, err = r.Handle(alice, segments[2])
+	require.NoError(t, err)
+	require.Equal(t, payload, whole)
+	require.Len(t, store, 1, "Segments are deleted once reassembled")
+	require.Equal(t, Progress{Hash: progress[0].Hash, Received: 4, Total: 4}, progress[len(progress)-1])
+	require.Equal(t, 2, progress[2].Received, "Duplicated segments are counted once")
+
+	_, err = r.Handle(alice, payload)
+	require.Equal(t, ErrInvalidSegment, err)
+}
+
+func TestReassembleConflicting(t *testing.T) {
+	payload := make([]byte, 1000)
+	rand.Read(payload)
+	segments, err := Split(payload, 300)
+	require.NoError(t, err)
+
+	r := NewReassembler(nil, DefaultTTL)
+	r.SetStore(memStore{})
+	_, err = r.Handle(alice, segments[0])
+	require.NoError(t, err)
+	conflicting := append([]byte{}, segments[0]...)
+	conflicting[len(conflicting)-1]++
+	_, err = r.Handle(alice, conflicting)
+	require.Equal(t, ErrConflictingSegment, err, "Segments already received are not replaced")
+
+	other, err := Split(payload, 600)
+	require.NoError(t, err)
+	_, err = r.Handle(alice, other[1])
+	require.Equal(t, ErrConflictingSegment, err, "Segments of a payload have the same count")
+
+	for _, segment := range segments[1:] {
+		_, err = r.Handle(alice, segment)
+		require.NoError(t, err)
+	}
+}
+
+func TestReassemblePendingLimits(t *testing.T) {
+	r := NewReassembler(nil, DefaultTTL)
+	store := memStore{}
+	r.SetStore(store)
+	split := func(size int) [][]byte {
+		payload := make([]byte, size)
+		rand.Read(payload)
+		segments, err := Split(payload, size/2+headerSize)
+		require.NoError(t, err)
+		return segments
+	}
+
+	for i := 0; i < MaxPendingPayloads; i++ {
+		_, err := r.Handle(alice, split(100)[0])
+		require.NoError(t, err)
+	}
+	segments := split(100)
+	_, err := r.Handle(alice, segments[0])
+	require.Equal(t, ErrTooManyPending, err)
+	_, err = r.Handle(bob, segments[0])
+	require.NoError(t, err, "Limits are kept by sender")
+
+	large := split(MaxPendingSize)
+	_, err = r.Handle(bob, large[0])
+	require.NoError(t, err)
+	_, err = r.Handle(bob, large[1])
+	require.Equal(t, ErrTooManyPending, err)
+}
+
+func TestReassembleCorrupted(t *testing.T) {
+	payload := make([]byte, 1000)
+	rand.Read(payload)
+	segments, err := Split(payload, 600)
+	require.NoError(t, err)
+
+	r := NewReassembler(nil, DefaultTTL)
+	r.SetStore(memStore{})
+	_, err = r.Handle(alice, segments[0])
+	require.NoError(t, err)
+	segments[1][len(segments[1])-1]++
+	_, err = r.Handle(alice, segments[1])
+	require.Equal(t, ErrCorrupted, err)
+}
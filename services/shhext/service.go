@@ -6,17 +6,62 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto/sha3"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/logutils"
+	"github.com/status-im/status-go/services/ens"
+	"github.com/status-im/status-go/services/permissions"
+	"github.com/status-im/status-go/services/report"
+	"github.com/status-im/status-go/services/scheduler"
+	"github.com/status-im/status-go/services/shhext/archival"
+	"github.com/status-im/status-go/services/shhext/attachments"
+	"github.com/status-im/status-go/services/shhext/blocking"
+	"github.com/status-im/status-go/services/shhext/bloom"
 	"github.com/status-im/status-go/services/shhext/chat"
+	"github.com/status-im/status-go/services/shhext/consent"
+	"github.com/status-im/status-go/services/shhext/contacts"
+	"github.com/status-im/status-go/services/shhext/content"
+	"github.com/status-im/status-go/services/shhext/decoy"
 	"github.com/status-im/status-go/services/shhext/dedup"
+	"github.com/status-im/status-go/services/shhext/delivery"
+	"github.com/status-im/status-go/services/shhext/devicesync"
+	"github.com/status-im/status-go/services/shhext/downgrade"
+	"github.com/status-im/status-go/services/shhext/echobot"
+	"github.com/status-im/status-go/services/shhext/estimate"
+	"github.com/status-im/status-go/services/shhext/history"
+	"github.com/status-im/status-go/services/shhext/identity"
+	"github.com/status-im/status-go/services/shhext/inbox"
+	"github.com/status-im/status-go/services/shhext/integrity"
+	"github.com/status-im/status-go/services/shhext/latency"
+	"github.com/status-im/status-go/services/shhext/lookup"
 	"github.com/status-im/status-go/services/shhext/mailservers"
+	"github.com/status-im/status-go/services/shhext/moderation"
+	"github.com/status-im/status-go/services/shhext/notifications"
+	"github.com/status-im/status-go/services/shhext/outbox"
+	"github.com/status-im/status-go/services/shhext/pipeline"
+	"github.com/status-im/status-go/services/shhext/pow"
+	"github.com/status-im/status-go/services/shhext/presence"
+	"github.com/status-im/status-go/services/shhext/push"
+	"github.com/status-im/status-go/services/shhext/ratelimit"
+	"github.com/status-im/status-go/services/shhext/receipts"
+	"github.com/status-im/status-go/services/shhext/reencryption"
+	"github.com/status-im/status-go/services/shhext/retry"
+	"github.com/status-im/status-go/services/shhext/schema"
+	"github.com/status-im/status-go/services/shhext/segmentation"
+	"github.com/status-im/status-go/services/shhext/settings"
+	"github.com/status-im/status-go/services/shhext/trust"
+	"github.com/status-im/status-go/services/shhext/wipe"
+	"github.com/status-im/status-go/services/stickers"
+	"github.com/status-im/status-go/services/wallet"
+	"github.com/status-im/status-go/services/walletconnect"
+	"github.com/status-im/status-go/transactions"
 	whisper "github.com/status-im/whisper/whisperv6"
 	"github.com/syndtr/goleveldb/leveldb"
 )
@@ -30,6 +75,13 @@ const (
 
 var errProtocolNotInitialized = errors.New("procotol is not initialized")
 
+// reporter reports the errors the client should know about, like failures of the
+// background work or of the processing of the messages received.
+var reporter = report.New("shhext")
+
+// logger is the logger of the shhext module, whose level can be set separately.
+var logger = logutils.NewLogger(logutils.ModuleShhext, "package", "status-go/services/shhext")
+
 // EnvelopeEventsHandler used for two different event types.
 type EnvelopeEventsHandler interface {
 	EnvelopeSent(common.Hash)
@@ -40,22 +92,103 @@ type EnvelopeEventsHandler interface {
 
 // Service is a service that provides some additional Whisper API.
 type Service struct {
-	w              *whisper.Whisper
-	config         *ServiceConfig
-	tracker        *tracker
-	server         *p2p.Server
-	nodeID         *ecdsa.PrivateKey
-	deduplicator   *dedup.Deduplicator
-	protocol       *chat.ProtocolService
+	w            *whisper.Whisper
+	config       *ServiceConfig
+	tracker      *tracker
+	server       *p2p.Server
+	nodeID       *ecdsa.PrivateKey
+	deduplicator *dedup.Deduplicator
+	protocol     *chat.ProtocolService
+	// persistence is the chat database of the selected account, named chatDBName.
+	persistence    *chat.SQLLitePersistence
+	chatDBName     string
 	debug          bool
 	dataDir        string
 	installationID string
 	pfsEnabled     bool
+	translations   *chat.Translations
+	reencryption   *reencryption.Manager
+	// encryptedStores are the names of the stores of the selected account registered
+	// for re-encryption, other than its chat database.
+	encryptedStores []string
+	schema          *schema.Coordinator
+	// receipts aggregates the receipts of group messages, it is set with the
+	// protocol and guarded by receiptsMu as envelope events read it.
+	receiptsMu    sync.RWMutex
+	receipts      *receipts.Aggregator
+	moderator     *moderation.Moderator
+	notifications *notifications.Manager
+	notifyGroups  *notifications.Builder
+	attachments   *attachments.Manager
+	media         *attachments.Server
+	content       *content.Manager
+	history       *history.Manager
+	outbox        *outbox.Outbox
+	contacts      *contacts.Manager
+	presence      *presence.Manager
+	consent       *consent.Manager
+	blocking      *blocking.Manager
+	downgrades    *downgrade.Detector
+	settings      *settings.Manager
+	devicesync    *devicesync.Manager
+	push          *push.Client
+	wipes         *wipe.Manager
 
 	peerStore       *mailservers.PeerStore
 	cache           *mailservers.Cache
+	requestsCache   *mailservers.RequestsCache
+	retries         *retry.Queue
+	recentEnvelopes *recentEnvelopes
+	bundleLookups   *bundleLookups
+	echoBot         *echoBot
+	pushServer      *pushServer
+	archival        *archival.Verifier
+	integrity       *integrity.Verifier
+	bloomFilter     *bloom.Negotiator
+	decoys          []whisper.TopicType
+	inbox           *inbox.Inbox
+	segments        *segmentation.Reassembler
+	identities      *identity.Cache
+	pow             *pow.Adapter
 	connManager     *mailservers.ConnectionManager
 	lastUsedMonitor *mailservers.LastUsedConnectionMonitor
+	picker          *mailservers.Picker
+	scheduler       *scheduler.Scheduler
+	pipeline        *pipeline.Pipeline
+	// latency aggregates the delivery latencies of the received messages, if enabled.
+	latency *latency.Tracker
+	// outgoing limits the envelopes sent by the node, with the budgets of the network type.
+	outgoing *ratelimit.Outgoing
+
+	// pendingWipe is the command wiping the installation sent by another installation of
+	// the account, executed once the received messages are processed. wiped is true once
+	// the installation was wiped, and wipeHandler deletes the keys of the account.
+	wipeMu      sync.Mutex
+	pendingWipe *wipe.Command
+	wiped       bool
+	wipeHandler func() error
+	// consumedWipes records the commands wiping the installation it accepted, out of the chat
+	// database. verifyPassword checks the password of the account before a wipe is issued.
+	consumedWipes  *wipe.ConsumedStore
+	verifyPassword func(password string) error
+
+	// keyPairID is the Whisper identity of the account of the service, or empty to use
+	// the identity selected in Whisper, unless the account has no identity.
+	keyPairMu sync.RWMutex
+	keyPairID string
+	noKeyPair bool
+
+	// db and handler are kept to create the services of profiles.
+	db      *leveldb.DB
+	handler EnvelopeEventsHandler
+	// profiles are the services of the accounts logged in alongside the selected
+	// one, by address.
+	profilesMu sync.Mutex
+	profiles   map[string]*Service
+
+	// hashRate is the number of hashes computed per second for the proof of work, measured once.
+	hashRate     float64
+	hashRateOnce sync.Once
 }
 
 type ServiceConfig struct {
@@ -67,6 +200,122 @@ type ServiceConfig struct {
 	EnableConnectionManager bool
 	EnableLastUsedMonitor   bool
 	ConnectionTarget        int
+	TranslatorURL           string
+	// RequestsDedupWindow is the time during which identical requests for historic
+	// messages are not sent again. Zero disables the deduplication.
+	RequestsDedupWindow time.Duration
+	// EnvelopeRetries is the number of times an envelope that expired before being
+	// delivered is posted again. Zero disables retries.
+	EnvelopeRetries int
+	// MailServerPoolSize is the number of MailServers a request is sent to by
+	// RequestMessagesFromPool. Zero means DefaultMailServerPoolSize.
+	MailServerPoolSize int
+	// BundleDirectoryNodes are queried for the bundles of unknown public keys
+	// instead of the whole network, if not empty.
+	BundleDirectoryNodes []*enode.Node
+	// BundleDirectory keeps the bundles published by other nodes and returns them
+	// in the responses to lookups.
+	BundleDirectory bool
+	// MailServerProbeInterval is how often the selected MailServers are probed
+	// to rank them by latency and availability. The best ones are connected if
+	// the connection manager is enabled. Zero disables probing.
+	MailServerProbeInterval time.Duration
+	// MaxBundleLookups is the number of bundle lookups allowed per minute.
+	// Zero means lookup.DefaultMaxLookups.
+	MaxBundleLookups int
+	// CompressionEnabled compresses direct messages with a dictionary negotiated
+	// with their recipients.
+	CompressionEnabled bool
+	// NarrowBloomFilter advertises to peers a bloom filter of the topics of the
+	// active chats, set with SetActiveChats, instead of a full-traffic filter.
+	NarrowBloomFilter bool
+	// DecoyTopics is the number of decoy topics of the identity mixed into the bloom
+	// filter advertised to peers and into the requests sent to MailServers.
+	DecoyTopics int
+	// AdaptivePoW lowers the PoW of outgoing envelopes while envelopes are queued.
+	// AdaptivePoWMaxTarget caps the requested PoW, unless zero, and AdaptivePoWMaxQueue
+	// is the number of queued envelopes from which the minimum PoW of whisper is used.
+	AdaptivePoW          bool
+	AdaptivePoWMaxTarget float64
+	AdaptivePoWMaxQueue  int
+	// PartitionedTopic sends direct messages on the partitioned topic of their recipients,
+	// once all the installations of a recipient advertised that they listen on it.
+	PartitionedTopic bool
+	// EchoBot runs a built-in contact that echoes direct messages, unless nil.
+	// EchoBotKey is its identity, or nil to generate one.
+	EchoBot    *echobot.Config
+	EchoBotKey *ecdsa.PrivateKey
+	// PushServer runs a push notification server, unless nil. PushServerKey is its
+	// identity, or nil to generate one.
+	PushServer    *push.ServerConfig
+	PushServerKey *ecdsa.PrivateKey
+	// PushServers are the push notification servers the account registers with by
+	// default, and that are queried for the installations of the recipients of direct
+	// messages to notify them while they are offline.
+	PushServers []*ecdsa.PublicKey
+	// SegmentSize is the maximum size of the payload of an envelope sent by the chat
+	// protocol. Larger payloads are split into segments. Zero means the maximum message
+	// size of whisper, minus the overhead of the envelope.
+	SegmentSize int
+	// AttachmentsBackend stores the encrypted attachments, or nil to disable them.
+	AttachmentsBackend attachments.Backend
+	Attachments        attachments.Config
+	// MediaServer serves the attachments to the client on the loopback interface,
+	// unless nil. It requires AttachmentsBackend.
+	MediaServer *attachments.ServerConfig
+	// ContactRequests quarantines the direct messages of identities that are not contacts,
+	// and that we didn't send messages to, until the user accepts their contact request.
+	// Their bundles are not added and no session is established with them until then.
+	ContactRequests bool
+	// ResponseValidator validates the envelopes returned by MailServers for the
+	// requests sent by the service, if it is set. It is shared with the Whisper protocol.
+	ResponseValidator *trust.Validator
+	// ResponseSummaries collects the summaries of the responses of MailServers to the
+	// requests that are verified. It must wrap the Whisper protocol, otherwise
+	// verified requests are rejected.
+	ResponseSummaries *integrity.Summaries
+	// BackupBeforeMigrate backs up the chat database of an account to the backups
+	// directory of DataDir before applying the pending migrations of its schema.
+	BackupBeforeMigrate bool
+	// LatencyStats adds the time messages are sent to the messages, and aggregates the
+	// delivery latencies of the received messages that carry it by network type.
+	LatencyStats bool
+	// OutgoingRateLimits are the budgets of the envelopes sent by the node, by network
+	// type. The budgets of DefaultNetworkBudgets apply to network types without their
+	// own. Outgoing envelopes are not limited if it's empty.
+	OutgoingRateLimits map[string]ratelimit.Budgets
+}
+
+// segmentOverhead is the size reserved in whisper messages for the envelope
+// fields, the signature, the padding and the asymmetric encryption.
+const segmentOverhead = 1024
+
+// segmentSize returns the maximum size of the payload of an envelope.
+func (s *Service) segmentSize() int {
+	if s.config.SegmentSize > 0 {
+		return s.config.SegmentSize
+	}
+	return int(s.w.MaxMessageSize()) - segmentOverhead
+}
+
+// hashRateMeasurement is how long the hash rate used to estimate the time spent on the
+// proof of work is measured.
+const hashRateMeasurement = 20 * time.Millisecond
+
+// estimateConfig returns the configuration of the envelopes posted for chat messages,
+// used to estimate the cost of sending them.
+func (s *Service) estimateConfig() estimate.Config {
+	s.hashRateOnce.Do(func() {
+		s.hashRate = estimate.MeasureHashRate(hashRateMeasurement)
+	})
+	msg := s.adaptPoW(chat.DirectMessageToWhisper(chat.SendDirectMessageRPC{}, nil))
+	return estimate.Config{
+		SegmentSize: s.segmentSize(),
+		TTL:         msg.TTL,
+		PoWTarget:   msg.PowTarget,
+		PoWTime:     msg.PowTime,
+		HashRate:    s.hashRate,
+	}
 }
 
 // Make sure that Service implements node.Service interface.
@@ -76,26 +325,212 @@ var _ node.Service = (*Service)(nil)
 func New(w *whisper.Whisper, handler EnvelopeEventsHandler, db *leveldb.DB, config *ServiceConfig) *Service {
 	cache := mailservers.NewCache(db)
 	ps := mailservers.NewPeerStore(cache)
+	s := &Service{}
+	rawHandler := handler
+	var retriesHandler retry.Handler
+	if handler != nil {
+		handler = receiptsHandler{EnvelopeEventsHandler: handler, service: s}
+		retriesHandler = handler
+		handler = retryHandler{EnvelopeEventsHandler: handler, service: s}
+	}
 	track := &tracker{
 		w:                      w,
 		handler:                handler,
 		cache:                  map[common.Hash]EnvelopeState{},
 		batches:                map[common.Hash]map[common.Hash]struct{}{},
+		requests:               map[common.Hash]time.Time{},
 		mailPeers:              ps,
 		mailServerConfirmation: config.MailServerConfirmations,
+		delivery:               delivery.NewRecorder(delivery.DefaultMaxStates),
+		mailServerAcked:        s.groupEnvelopeDelivered,
 	}
-	return &Service{
+	var translator chat.Translator
+	if config.TranslatorURL != "" {
+		translator = chat.NewHTTPTranslator(config.TranslatorURL)
+	}
+	*s = Service{
 		w:              w,
 		config:         config,
 		tracker:        track,
-		deduplicator:   dedup.NewDeduplicator(w, db),
+		deduplicator:   dedup.NewDeduplicator(s, db),
 		debug:          config.Debug,
 		dataDir:        config.DataDir,
 		installationID: config.InstallationID,
 		pfsEnabled:     config.PFSEnabled,
+		translations:   chat.NewTranslations(translator, EnvelopeSignalHandler{}.MessageTranslated, chat.DefaultTranslationsQueueSize),
+		reencryption:   reencryption.NewManager(db),
+		schema:         schema.NewCoordinator(filepath.Join(config.DataDir, "backups")),
 		peerStore:      ps,
 		cache:          cache,
+		requestsCache:  mailservers.NewRequestsCache(db, config.RequestsDedupWindow),
+		pipeline:       pipeline.New(),
+		db:             db,
+		handler:        rawHandler,
+		profiles:       make(map[string]*Service),
+	}
+	s.recentEnvelopes = newRecentEnvelopes(defaultRecentEnvelopes)
+	s.archival = archival.NewVerifier(w, archivalRequester{service: s}, archivalPeers{service: s}, handler, track.delivery)
+	track.archival = s.archival
+	s.integrity = integrity.NewVerifier(w, config.ResponseSummaries, EnvelopeSignalHandler{}.ResponseVerified)
+	s.inbox = inbox.New(s.decryptPending, EnvelopeSignalHandler{}.MessagesDecrypted, inbox.DefaultConfig())
+	s.segments = segmentation.NewReassembler(EnvelopeSignalHandler{}.SegmentsProgress, segmentation.DefaultTTL)
+	s.identities = identity.NewCache(identity.DefaultCacheSize)
+	s.retries = retry.NewQueue(db, retry.DefaultConfig(config.EnvelopeRetries), retryTransport{service: s}, retriesHandler)
+	if config.LatencyStats {
+		s.latency = latency.NewTracker(latency.DefaultSamples)
+	}
+	s.outgoing = ratelimit.NewOutgoing()
+	s.outgoing.SetBudgets(s.outgoingBudgets(latency.UnknownNetwork))
+	if config.AdaptivePoW {
+		s.pow = pow.NewAdapter(pow.Config{
+			MinTarget: w.MinPow(),
+			MaxTarget: config.AdaptivePoWMaxTarget,
+			MaxQueue:  config.AdaptivePoWMaxQueue,
+		}, track.Queued)
+	}
+	return s
+}
+
+// receiptsHandler reports envelopes of group messages through their aggregated receipts.
+type receiptsHandler struct {
+	EnvelopeEventsHandler
+	service *Service
+}
+
+// EnvelopeSent is not reported for envelopes of group messages, which are delivered
+// once a mailserver acknowledges them, see groupEnvelopeDelivered.
+func (h receiptsHandler) EnvelopeSent(hash common.Hash) {
+	aggregator := h.service.receiptsAggregator()
+	if aggregator == nil || !aggregator.Owns(hash) {
+		h.EnvelopeEventsHandler.EnvelopeSent(hash)
+	}
+}
+
+// groupEnvelopeDelivered marks the member a group message envelope was sent to as
+// delivered, as a mailserver stored the envelope for it.
+func (s *Service) groupEnvelopeDelivered(hash common.Hash) {
+	aggregator := s.receiptsAggregator()
+	if aggregator == nil {
+		return
+	}
+	if err := aggregator.EnvelopeDelivered(hash); err != nil {
+		logger.Error("failed to aggregate group receipt", "hash", hash, "err", err)
+	}
+}
+
+// receiptsAggregator returns the receipts of group messages, or nil if the protocol
+// is not initialized.
+func (s *Service) receiptsAggregator() *receipts.Aggregator {
+	s.receiptsMu.RLock()
+	defer s.receiptsMu.RUnlock()
+	return s.receipts
+}
+
+func (s *Service) setReceiptsAggregator(aggregator *receipts.Aggregator) {
+	s.receiptsMu.Lock()
+	defer s.receiptsMu.Unlock()
+	s.receipts = aggregator
+}
+
+// retryHandler hides the failures of envelopes that are posted again, and reports
+// retried envelopes with the hash of the first envelope posted for the message.
+type retryHandler struct {
+	EnvelopeEventsHandler
+	service *Service
+}
+
+func (h retryHandler) EnvelopeSent(hash common.Hash) {
+	if !h.service.retries.Sent(hash) {
+		h.EnvelopeEventsHandler.EnvelopeSent(hash)
+	}
+}
+
+func (h retryHandler) EnvelopeExpired(hash common.Hash) {
+	if !h.service.retries.Expired(hash) {
+		h.EnvelopeEventsHandler.EnvelopeExpired(hash)
+	}
+}
+
+// retryTransport posts the envelopes of the retry queue through whisper.
+type retryTransport struct {
+	service *Service
+}
+
+func (t retryTransport) Post(envelope *whisper.Envelope, pow float64, workTime uint32) error {
+	pow = t.service.adaptPoW(whisper.NewMessage{PowTarget: pow}).PowTarget
+	if pow < t.service.w.MinPow() {
+		return whisper.ErrTooLowPoW
+	}
+	if err := envelope.Seal(&whisper.MessageParams{PoW: pow, WorkTime: workTime}); err != nil {
+		return err
+	}
+	return t.service.w.Send(envelope)
+}
+
+func (t retryTransport) Track(hash common.Hash) {
+	t.service.tracker.Add(hash)
+}
+
+func (t retryTransport) Connected() bool {
+	return t.service.server != nil && t.service.server.PeerCount() > 0
+}
+
+// adaptPoW lowers the PoW target of a message if envelopes are queued.
+func (s *Service) adaptPoW(message whisper.NewMessage) whisper.NewMessage {
+	if s.pow != nil {
+		message.PowTarget = s.pow.Target(message.PowTarget)
+	}
+	return message
+}
+
+// SetTranslator sets the translator used to annotate incoming messages.
+// It overrides the one created from TranslatorURL, if any.
+func (s *Service) SetTranslator(translator chat.Translator) {
+	s.translations.SetTranslator(translator)
+}
+
+// SetKeyPairID sets the Whisper identity of the account of the service, which must
+// be set when several identities are added to Whisper.
+func (s *Service) SetKeyPairID(id string) {
+	s.keyPairMu.Lock()
+	defer s.keyPairMu.Unlock()
+	s.keyPairID = id
+	s.noKeyPair = false
+}
+
+// ClearKeyPairID leaves the account of the service without a Whisper identity until
+// SetKeyPairID is called, instead of using the identity selected in Whisper, which
+// can be the identity of a profile.
+func (s *Service) ClearKeyPairID() {
+	s.keyPairMu.Lock()
+	defer s.keyPairMu.Unlock()
+	s.keyPairID = ""
+	s.noKeyPair = true
+}
+
+// SelectedKeyPairID returns the Whisper identity of the account of the service.
+func (s *Service) SelectedKeyPairID() string {
+	s.keyPairMu.RLock()
+	id, none := s.keyPairID, s.noKeyPair
+	s.keyPairMu.RUnlock()
+	if id != "" || none {
+		return id
+	}
+	return s.w.SelectedKeyPairID()
+}
+
+// selectedIdentity returns the private key of the Whisper identity of the account, or
+// ErrNoChatIdentity if the account has none.
+func (s *Service) selectedIdentity() (*ecdsa.PrivateKey, error) {
+	id := s.SelectedKeyPairID()
+	if id == "" {
+		return nil, ErrNoChatIdentity
+	}
+	identity, err := s.w.GetPrivateKey(id)
+	if err != nil {
+		return nil, ErrNoChatIdentity
 	}
+	return identity, nil
 }
 
 // UpdateMailservers updates information about selected mail servers.
@@ -109,19 +544,43 @@ func (s *Service) UpdateMailservers(nodes []*enode.Node) error {
 	return nil
 }
 
+// ConnectedMailservers returns the selected mail servers the node is connected to.
+func (s *Service) ConnectedMailservers() []*enode.Node {
+	if s.server == nil {
+		return nil
+	}
+	nodes, _ := mailservers.GetConnected(s.server, s.peerStore, -1)
+	return nodes
+}
+
+// KnownMailservers returns the selected mail servers.
+func (s *Service) KnownMailservers() []*enode.Node {
+	return s.peerStore.All()
+}
+
 // Protocols returns a new protocols list. In this case, there are none.
 func (s *Service) Protocols() []p2p.Protocol {
 	return []p2p.Protocol{}
 }
 
-// InitProtocol create an instance of ProtocolService given an address and password
+// InitProtocol create an instance of ProtocolService given an address and password.
+// The chat database of the account previously selected, if any, is closed first, so
+// that accounts are switched without restarting the node.
 func (s *Service) InitProtocol(address string, password string) error {
 	if !s.pfsEnabled {
 		return nil
 	}
+	if s.isWiped() {
+		return ErrInstallationWiped
+	}
+	if err := s.CloseProtocol(); err != nil {
+		return err
+	}
 
-	digest := sha3.Sum256([]byte(password))
-	hashedPassword := fmt.Sprintf("%x", digest)
+	hashedPassword, err := chat.DBKey(password, chat.KDFSha3)
+	if err != nil {
+		return err
+	}
 
 	if err := os.MkdirAll(filepath.Clean(s.dataDir), os.ModePerm); err != nil {
 		return err
@@ -129,6 +588,7 @@ func (s *Service) InitProtocol(address string, password string) error {
 	v0Path := filepath.Join(s.dataDir, fmt.Sprintf("%x.db", address))
 	v1Path := filepath.Join(s.dataDir, fmt.Sprintf("%s.db", s.installationID))
 	v2Path := filepath.Join(s.dataDir, fmt.Sprintf("%s.v2.db", s.installationID))
+	v3Path := s.chatDBPath(address)
 
 	if err := chat.MigrateDBFile(v0Path, v1Path, "ON", password); err != nil {
 		return err
@@ -141,10 +601,16 @@ func (s *Service) InitProtocol(address string, password string) error {
 		os.Remove(v2Path)
 	}
 
-	persistence, err := chat.NewSQLLitePersistence(v2Path, hashedPassword)
+	if err := s.claimChatDB(v2Path, v3Path, password); err != nil {
+		return err
+	}
+
+	persistence, err := s.openChatDB(v3Path, password)
 	if err != nil {
 		return err
 	}
+	s.persistence = persistence
+	s.chatDBName = filepath.Base(v3Path)
 
 	addedBundlesHandler := func(addedBundles []chat.IdentityAndIDPair) {
 		handler := EnvelopeSignalHandler{}
@@ -153,11 +619,279 @@ func (s *Service) InitProtocol(address string, password string) error {
 		}
 	}
 
+	if err := s.tracker.delivery.SetStore(persistence); err != nil {
+		return err
+	}
+	s.archival.SetStore(persistence)
+	s.inbox.SetStore(persistence)
+	s.segments.SetStore(persistence)
+	s.moderator = moderation.NewModerator(persistence, EnvelopeSignalHandler{}.ModerationListChanged)
+	s.notifications = notifications.NewManager(persistence, EnvelopeSignalHandler{}.NotificationPreferencesChanged)
+	s.notifyGroups = notifications.NewBuilder(s.chatMuted)
+	s.history = history.NewManager(persistence)
+	s.outbox = outbox.New(persistence)
+	s.contacts = contacts.NewManager(persistence)
+	s.presence = presence.NewManager(persistence, presence.DefaultTypingInterval)
+	s.blocking = blocking.NewManager(persistence)
+	s.downgrades = downgrade.NewDetector(persistence, EnvelopeSignalHandler{}.SecurityDowngrade)
+	s.settings = settings.NewManager(persistence, EnvelopeSignalHandler{}.SettingChanged)
+	s.devicesync = devicesync.NewManager(persistence, EnvelopeSignalHandler{}.HistorySynced)
+	s.consent = consent.NewManager(persistence, EnvelopeSignalHandler{}.ContactRequestReceived, consent.DefaultMaxPending)
+	s.content = content.NewManager(persistence, s.messageStateChanged)
+	s.setReceiptsAggregator(receipts.NewAggregator(persistence, EnvelopeSignalHandler{}.GroupReceiptsUpdated, receipts.DefaultMaxTrackedMessages))
 	s.protocol = chat.NewProtocolService(chat.NewEncryptionService(persistence, chat.DefaultEncryptionServiceConfig(s.installationID)), addedBundlesHandler)
+	if s.config.CompressionEnabled {
+		s.protocol.EnableCompression()
+	}
+	if s.config.PartitionedTopic {
+		s.protocol.EnablePartitionedTopic()
+	}
+	if s.config.LatencyStats {
+		s.protocol.EnableSendTimestamps()
+	}
+	s.protocol.SetNotificationPreferencesHandler(s.applyNotificationPreferences)
+	s.protocol.SetHistorySyncHandler(s.applySyncedHistory)
+	s.protocol.SetWipeHandler(s.applyWipe)
+	if s.config.ContactRequests {
+		s.protocol.SetContactGate(s.contactAllowed)
+	}
+	s.push = push.NewClient(persistence.PushStore(), s.protocol, pushClientTransport{s}, s.installationID, s.config.PushServers)
+	s.wipes = wipe.NewManager(persistence.WipeStore(), EnvelopeSignalHandler{}.WipeConfirmed)
+	s.consumedWipes = wipe.NewConsumedStore(filepath.Join(s.dataDir, consumedWipesFile))
+	if s.config.AttachmentsBackend != nil {
+		if s.attachments != nil {
+			s.attachments.Stop()
+		}
+		s.attachments = attachments.NewManager(s.config.AttachmentsBackend, persistence, EnvelopeSignalHandler{}.AttachmentStateChanged, s.config.Attachments)
+	}
+
+	if err := s.loadDecoys(persistence); err != nil {
+		return err
+	}
 
+	s.repairConsistency()
+
+	return nil
+}
+
+// loadDecoys loads the decoy topics of the identity, which are generated at its first login.
+func (s *Service) loadDecoys(store decoy.Store) error {
+	decoys, err := decoy.Load(store, s.config.DecoyTopics)
+	if err != nil {
+		return err
+	}
+	s.decoys = decoys
+	if s.bloomFilter != nil {
+		return s.bloomFilter.SetDecoys(decoys)
+	}
 	return nil
 }
 
+// IntegrityCheck checks the integrity of the chat database of the selected account, returning
+// the problems found, or "ok" if there are none. The protocol must be initialized.
+func (s *Service) IntegrityCheck() ([]string, error) {
+	if s.persistence == nil {
+		return nil, errProtocolNotInitialized
+	}
+	return s.persistence.IntegrityCheck()
+}
+
+// WalletStore returns the store of the wallet data of the selected account, like the transfers
+// of its addresses and its custom tokens, in its chat database, or nil if the protocol is not
+// initialized.
+func (s *Service) WalletStore() wallet.Store {
+	if s.persistence == nil {
+		return nil
+	}
+	return s.persistence.WalletStore()
+}
+
+// TransactionStore returns the store of the transactions of the selected account waiting for
+// an external signature, in its chat database, or nil if the protocol is not initialized.
+func (s *Service) TransactionStore() transactions.Store {
+	if s.persistence == nil {
+		return nil
+	}
+	return s.persistence.TransactionStore()
+}
+
+// ENSStore returns the cache of the ENS resolutions of the selected account, in its chat
+// database, or nil if the protocol is not initialized.
+func (s *Service) ENSStore() ens.Store {
+	if s.persistence == nil {
+		return nil
+	}
+	return s.persistence.ENSStore()
+}
+
+// StickersStore returns the sticker packs installed by the selected account, in its chat
+// database, or nil if the protocol is not initialized.
+func (s *Service) StickersStore() stickers.Store {
+	if s.persistence == nil {
+		return nil
+	}
+	return s.persistence.StickersStore()
+}
+
+// PermissionsStore returns the permissions granted to dapps by the selected account, in the
+// settings of its chat database, or nil if the protocol is not initialized.
+func (s *Service) PermissionsStore() permissions.Store {
+	if s.persistence == nil {
+		return nil
+	}
+	return s.persistence
+}
+
+// WalletConnectStore returns the WalletConnect pairings and sessions of the selected account,
+// in the settings of its chat database, or nil if the protocol is not initialized.
+func (s *Service) WalletConnectStore() walletconnect.Store {
+	if s.persistence == nil {
+		return nil
+	}
+	return s.persistence
+}
+
+// chatDBPath returns the path of the chat database of an account.
+func (s *Service) chatDBPath(address string) string {
+	return ChatDBPath(s.dataDir, s.installationID, address)
+}
+
+// ChatDBPath returns the path of the chat database of an account in a data directory.
+func ChatDBPath(dataDir, installationID, address string) string {
+	return filepath.Join(dataDir, fmt.Sprintf("%s.%s.db", installationID, strings.ToLower(strings.TrimPrefix(address, "0x"))))
+}
+
+// CloseProtocol closes the chat database of the selected account and releases the
+// services using it, as they are before an account is selected.
+func (s *Service) CloseProtocol() error {
+	if s.persistence == nil {
+		return nil
+	}
+	if s.attachments != nil {
+		s.attachments.Stop()
+		s.attachments = nil
+	}
+	if s.media != nil {
+		if err := s.media.RotateKey(); err != nil {
+			return err
+		}
+	}
+	s.protocol = nil
+	s.tracker.delivery.Reset()
+	s.archival.SetStore(nil)
+	s.inbox.SetStore(nil)
+	s.segments.SetStore(nil)
+	s.moderator = nil
+	s.notifications = nil
+	s.notifyGroups = nil
+	s.history = nil
+	s.outbox = nil
+	s.contacts = nil
+	s.presence = nil
+	s.blocking = nil
+	s.downgrades = nil
+	s.settings = nil
+	s.devicesync = nil
+	s.consent = nil
+	s.content = nil
+	s.setReceiptsAggregator(nil)
+	s.push = nil
+	s.wipes = nil
+	s.consumedWipes = nil
+	s.decoys = nil
+	if s.bloomFilter != nil {
+		if err := s.bloomFilter.SetDecoys(nil); err != nil {
+			return err
+		}
+	}
+
+	persistence := s.persistence
+	s.persistence = nil
+	s.unregisterEncryptedStores()
+	s.reencryption.Unregister(s.chatDBName)
+	s.schema.Unregister(s.chatDBName)
+	return persistence.Close()
+}
+
+// RegisterEncryptedStore tracks the version of an encrypted store of the selected
+// account, like its key files, which is encrypted with current if it's not tracked yet,
+// and re-encrypts it in the background when target is upgraded. The store is
+// unregistered when the protocol is closed.
+func (s *Service) RegisterEncryptedStore(store reencryption.Store, current, target reencryption.Version) error {
+	if _, err := s.reencryption.Version(store.Name(), current); err != nil {
+		return err
+	}
+	if err := s.reencryption.Register(store, target); err != nil {
+		return err
+	}
+	s.encryptedStores = append(s.encryptedStores, store.Name())
+	return nil
+}
+
+// unregisterEncryptedStores stops re-encrypting the stores of the selected account.
+func (s *Service) unregisterEncryptedStores() {
+	for _, name := range s.encryptedStores {
+		s.reencryption.Unregister(name)
+	}
+	s.encryptedStores = nil
+}
+
+// claimChatDB renames the chat database shared by the accounts of the installation, in
+// which the chat data was stored before each account had its own, to the database of the
+// account if it can be decrypted with its password.
+func (s *Service) claimChatDB(sharedPath, path, password string) error {
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return err
+	}
+	if _, err := os.Stat(sharedPath); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	name := filepath.Base(sharedPath)
+	version, err := s.reencryption.Version(name, chat.LegacyDBVersion)
+	if err != nil {
+		return err
+	}
+	key, err := chat.DBKey(password, version.KDF)
+	if err != nil {
+		return err
+	}
+	persistence, err := chat.NewSQLLitePersistence(sharedPath, key, chat.DefaultPersistenceConfig())
+	if err != nil {
+		// Used by another account
+		return nil
+	}
+	if err := persistence.Close(); err != nil {
+		return err
+	}
+
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Rename(sharedPath+suffix, path+suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(sharedPath, path); err != nil {
+		return err
+	}
+	return s.reencryption.Rename(name, filepath.Base(path))
+}
+
+// applyNotificationPreferences applies the notification preferences synced by another device of the account.
+func (s *Service) applyNotificationPreferences(payload []byte) error {
+	preferences, err := notifications.Decode(payload)
+	if err != nil {
+		return err
+	}
+	err = s.notifications.Apply(preferences)
+	if err == notifications.ErrStalePreferences {
+		logger.Debug("ignoring stale notification preferences", "clock", preferences.Clock)
+		return nil
+	}
+	return err
+}
+
 func (s *Service) ProcessPublicBundle(myIdentityKey *ecdsa.PrivateKey, bundle *chat.Bundle) ([]chat.IdentityAndIDPair, error) {
 	if s.protocol == nil {
 		return nil, errProtocolNotInitialized
@@ -180,7 +914,12 @@ func (s *Service) EnableInstallation(myIdentityKey *ecdsa.PublicKey, installatio
 		return errProtocolNotInitialized
 	}
 
-	return s.protocol.EnableInstallation(myIdentityKey, installationID)
+	if err := s.protocol.EnableInstallation(myIdentityKey, installationID); err != nil {
+		return err
+	}
+	// Messages sent by the paired device may be decrypted now
+	s.inbox.Retry(myIdentityKey)
+	return nil
 }
 
 // DisableInstallation disables an installation for multi-device sync.
@@ -192,6 +931,24 @@ func (s *Service) DisableInstallation(myIdentityKey *ecdsa.PublicKey, installati
 	return s.protocol.DisableInstallation(myIdentityKey, installationID)
 }
 
+// CleanupInstallations deletes the bundles, ratchet info and skipped keys
+// of installations disabled for longer than olderThan.
+func (s *Service) CleanupInstallations(myIdentityKey *ecdsa.PublicKey, olderThan time.Duration) (*chat.InstallationsCleanup, error) {
+	if s.protocol == nil {
+		return nil, errProtocolNotInitialized
+	}
+
+	return s.protocol.CleanupInstallations(myIdentityKey, olderThan)
+}
+
+// messageStateChanged updates the history with the new state of a message and notifies it.
+func (s *Service) messageStateChanged(state content.State) {
+	if err := s.history.Apply(state); err != nil {
+		logger.Error("failed to update message in the history", "id", state.ID, "err", err)
+	}
+	EnvelopeSignalHandler{}.MessageStateChanged(state)
+}
+
 // APIs returns a list of new APIs.
 func (s *Service) APIs() []rpc.API {
 	apis := []rpc.API{
@@ -201,6 +958,18 @@ func (s *Service) APIs() []rpc.API {
 			Service:   NewPublicAPI(s),
 			Public:    true,
 		},
+		{
+			Namespace: "chat",
+			Version:   "1.0",
+			Service:   NewChatAPI(s),
+			Public:    true,
+		},
+		{
+			Namespace: "notifications",
+			Version:   "1.0",
+			Service:   NewNotificationsAPI(s),
+			Public:    true,
+		},
 	}
 
 	if s.debug {
@@ -215,14 +984,91 @@ func (s *Service) APIs() []rpc.API {
 	return apis
 }
 
+// openChatDB opens the chat database with the key of the version it is encrypted with,
+// applies the pending migrations of its schema, and schedules its re-encryption, and the
+// re-encryption of its backups, if the default version has been upgraded. Databases which
+// are not tracked yet were created with the legacy version, unless they are created now.
+// A failed migration is rolled back and the database is closed.
+func (s *Service) openChatDB(path string, password string) (*chat.SQLLitePersistence, error) {
+	name := filepath.Base(path)
+	initial := chat.LegacyDBVersion
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		initial = chat.DefaultDBVersion
+	}
+	version, err := s.reencryption.Version(name, initial)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := chat.DBKey(password, version.KDF)
+	if err != nil {
+		return nil, err
+	}
+
+	config := chat.DefaultPersistenceConfig()
+	config.DeferMigrations = true
+	persistence, err := chat.NewSQLLitePersistence(path, key, config)
+	if err != nil && version != chat.DefaultDBVersion {
+		// The re-encryption might have completed without being recorded.
+		key, err = chat.DBKey(password, chat.DefaultDBVersion.KDF)
+		if err != nil {
+			return nil, err
+		}
+		persistence, err = chat.NewSQLLitePersistence(path, key, config)
+		version = chat.DefaultDBVersion
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.schema.Register(chat.NewSchema(name, persistence))
+	result, err := s.schema.Migrate(name, schema.Options{Backup: s.config.BackupBeforeMigrate})
+	if err == nil && result.Backup != "" {
+		err = s.reencryption.Reset(filepath.Base(result.Backup), version)
+	}
+	if err == nil {
+		err = persistence.EncryptHistoryMessages()
+	}
+	if err == nil {
+		err = s.registerChatDB(name, persistence, password, version)
+	}
+	if err != nil {
+		s.unregisterEncryptedStores()
+		s.reencryption.Unregister(name)
+		s.schema.Unregister(name)
+		if closeErr := persistence.Close(); closeErr != nil {
+			logger.Error("failed to close chat database", "err", closeErr)
+		}
+		return nil, err
+	}
+
+	return persistence, nil
+}
+
+// registerChatDB schedules the re-encryption of a chat database encrypted with version,
+// and of its backups, which are encrypted like it unless they are tracked.
+func (s *Service) registerChatDB(name string, persistence *chat.SQLLitePersistence, password string, version reencryption.Version) error {
+	backups, err := s.schema.Backups(name)
+	if err != nil {
+		return err
+	}
+	for _, path := range backups {
+		store := chat.NewSQLCipherFileStore(filepath.Base(path), path, password)
+		if err := s.RegisterEncryptedStore(store, version, chat.DefaultDBVersion); err != nil {
+			return err
+		}
+	}
+	return s.reencryption.Register(chat.NewSQLCipherStore(name, persistence, password), chat.DefaultDBVersion)
+}
+
 // Start is run when a service is started.
 // It does nothing in this case but is required by `node.Service` interface.
 func (s *Service) Start(server *p2p.Server) error {
+	connectionsTarget := s.config.ConnectionTarget
+	if connectionsTarget == 0 {
+		connectionsTarget = defaultConnectionsTarget
+	}
 	if s.config.EnableConnectionManager {
-		connectionsTarget := s.config.ConnectionTarget
-		if connectionsTarget == 0 {
-			connectionsTarget = defaultConnectionsTarget
-		}
 		s.connManager = mailservers.NewConnectionManager(server, s.w, connectionsTarget, defaultTimeoutWaitAdded)
 		s.connManager.Start()
 		if err := mailservers.EnsureUsedRecordsAddedFirst(s.peerStore, s.connManager); err != nil {
@@ -233,21 +1079,98 @@ func (s *Service) Start(server *p2p.Server) error {
 		s.lastUsedMonitor = mailservers.NewLastUsedConnectionMonitor(s.peerStore, s.cache, s.w)
 		s.lastUsedMonitor.Start()
 	}
+	if s.config.MailServerProbeInterval > 0 {
+		var notifee mailservers.NodesNotifee
+		if s.connManager != nil {
+			notifee = s.connManager
+		}
+		prober := mailservers.TCPProber{Timeout: mailservers.DefaultProbeTimeout}
+		s.picker = mailservers.NewPicker(s.peerStore, s.cache, prober, notifee, connectionsTarget, s.config.MailServerProbeInterval)
+		if err := s.picker.Start(); err != nil {
+			return err
+		}
+	}
+	if err := s.startJobs(); err != nil {
+		return err
+	}
 	s.tracker.Start()
+	s.archival.Start()
+	s.integrity.Start()
+	s.reencryption.Start()
+	s.translations.Start()
 	s.nodeID = server.PrivateKey
 	s.server = server
-	return nil
+	if err := s.startBundleLookups(server.PrivateKey); err != nil {
+		return err
+	}
+	if s.config.EchoBot != nil && s.pfsEnabled {
+		if err := s.startEchoBot(); err != nil {
+			return err
+		}
+	}
+	if s.config.PushServer != nil && s.pfsEnabled {
+		if err := s.startPushServer(); err != nil {
+			return err
+		}
+	}
+	if s.config.MediaServer != nil && s.config.AttachmentsBackend != nil {
+		s.media = attachments.NewServer(mediaFetcher{s}, *s.config.MediaServer)
+		if err := s.media.Start(); err != nil {
+			return err
+		}
+	}
+	if s.config.NarrowBloomFilter {
+		topics := []whisper.TopicType{chat.DiscoveryTopic()}
+		if s.bundleLookups != nil {
+			topics = append(topics, lookup.Topic)
+		}
+		if s.echoBot != nil {
+			topics = append(topics, s.echoBot.identity.topics...)
+		}
+		if s.pushServer != nil {
+			topics = append(topics, s.pushServer.identity.topics...)
+		}
+		s.bloomFilter = bloom.NewNegotiator(s.w, topics...)
+	}
+	return s.retries.Start()
 }
 
 // Stop is run when a service is stopped.
 // It does nothing in this case but is required by `node.Service` interface.
 func (s *Service) Stop() error {
+	s.closeProfiles()
+	s.stopJobs()
 	if s.config.EnableConnectionManager {
 		s.connManager.Stop()
 	}
 	if s.config.EnableLastUsedMonitor {
 		s.lastUsedMonitor.Stop()
 	}
+	if s.picker != nil {
+		s.picker.Stop()
+	}
+	if s.bundleLookups != nil {
+		s.bundleLookups.Stop()
+	}
+	if s.echoBot != nil {
+		s.echoBot.Stop()
+	}
+	if s.pushServer != nil {
+		s.pushServer.Stop()
+	}
+	if s.attachments != nil {
+		s.attachments.Stop()
+	}
+	if s.media != nil {
+		if err := s.media.Stop(); err != nil {
+			logger.Error("failed to stop the media server", "err", err)
+		}
+	}
+	s.retries.Stop()
+	s.archival.Stop()
+	s.integrity.Stop()
 	s.tracker.Stop()
+	s.reencryption.Stop()
+	s.translations.Stop()
 	return nil
 }
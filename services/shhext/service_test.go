@@ -1,24 +1,52 @@
 package shhext
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"math"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/golang/protobuf/proto"
+	"github.com/status-im/status-go/db"
+	"github.com/status-im/status-go/services/shhext/attachments"
+	"github.com/status-im/status-go/services/shhext/bloom"
+	"github.com/status-im/status-go/services/shhext/chat"
+	"github.com/status-im/status-go/services/shhext/consent"
+	"github.com/status-im/status-go/services/shhext/contacts"
+	"github.com/status-im/status-go/services/shhext/content"
+	"github.com/status-im/status-go/services/shhext/devicesync"
+	"github.com/status-im/status-go/services/shhext/echobot"
+	"github.com/status-im/status-go/services/shhext/history"
+	"github.com/status-im/status-go/services/shhext/notifications"
+	"github.com/status-im/status-go/services/shhext/pipeline"
+	"github.com/status-im/status-go/services/shhext/presence"
+	"github.com/status-im/status-go/services/shhext/push"
+	"github.com/status-im/status-go/services/shhext/ratelimit"
+	"github.com/status-im/status-go/services/shhext/reencryption"
+	"github.com/status-im/status-go/services/shhext/settings"
+	"github.com/status-im/status-go/services/shhext/wipe"
+	"github.com/status-im/status-go/signal"
 	"github.com/status-im/status-go/t/helpers"
 	whisper "github.com/status-im/whisper/whisperv6"
 	"github.com/stretchr/testify/suite"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 func newHandlerMock(buf int) handlerMock {
@@ -121,6 +149,186 @@ func (s *ShhExtSuite) TestInitProtocol() {
 	s.NoError(err)
 }
 
+func (s *ShhExtSuite) TestSwitchAccount() {
+	service := s.services[0]
+	s.Require().NoError(service.InitProtocol("0x01", "password"))
+	_, err := service.contacts.Add("0x04aa", "alice")
+	s.Require().NoError(err)
+
+	s.Require().NoError(service.InitProtocol("0x02", "other-password"))
+	contactList, err := service.contacts.Contacts()
+	s.Require().NoError(err)
+	s.Empty(contactList, "Each account has its own database")
+
+	s.Require().NoError(service.CloseProtocol())
+	s.Nil(service.protocol)
+	s.Nil(service.contacts)
+	s.Require().NoError(service.CloseProtocol())
+
+	s.Require().NoError(service.InitProtocol("0x01", "password"))
+	contactList, err = service.contacts.Contacts()
+	s.Require().NoError(err)
+	s.Len(contactList, 1)
+}
+
+func (s *ShhExtSuite) TestDecoys() {
+	service := s.services[0]
+	service.config.DecoyTopics = 3
+	service.bloomFilter = bloom.NewNegotiator(s.whisper[0])
+	api := NewPublicAPI(service)
+
+	s.Require().NoError(service.InitProtocol("0x01", "password"))
+	decoys := service.decoys
+	s.Require().Len(decoys, 3)
+	s.Require().NoError(api.SetActiveChats([]string{"status"}))
+	status := api.GetBloomFilter()
+	s.Equal(decoys, status.Decoys)
+	for _, topic := range decoys {
+		s.True(whisper.BloomFilterMatch(status.Bloom, whisper.TopicToBloom(topic)), "Decoys are advertised")
+	}
+
+	s.Require().NoError(service.InitProtocol("0x02", "other-password"))
+	s.Len(service.decoys, 3)
+	s.NotEqual(decoys, service.decoys, "Each identity has its own decoys")
+
+	s.Require().NoError(service.InitProtocol("0x01", "password"))
+	s.Equal(decoys, service.decoys, "Decoys are the same at each login")
+
+	s.Require().NoError(service.CloseProtocol())
+	s.Nil(service.decoys)
+	s.Nil(api.GetBloomFilter().Decoys)
+}
+
+func (s *ShhExtSuite) TestProfiles() {
+	service := s.services[0]
+	primaryKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	primaryID, err := s.whisper[0].AddKeyPair(primaryKey)
+	s.Require().NoError(err)
+	service.SetKeyPairID(primaryID)
+	s.Require().NoError(service.InitProtocol("0x01", "password"))
+	_, err = service.contacts.Add("0x04aa", "alice")
+	s.Require().NoError(err)
+
+	_, err = service.OpenProfile("0x01", "password", primaryKey)
+	s.Equal(ErrProfileExists, err, "The selected account can't be a profile")
+	profileKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	profile, err := service.OpenProfile("0x02", "other-password", profileKey)
+	s.Require().NoError(err)
+	_, err = service.OpenProfile("0x02", "other-password", profileKey)
+	s.Equal(ErrProfileExists, err)
+	s.Equal([]string{common.HexToAddress("0x02").Hex()}, service.Profiles())
+
+	s.Equal(primaryID, service.SelectedKeyPairID())
+	identity, err := s.whisper[0].GetPrivateKey(profile.SelectedKeyPairID())
+	s.Require().NoError(err)
+	s.Equal(profileKey.PublicKey, identity.PublicKey, "The profile has its own Whisper identity")
+	contactList, err := profile.contacts.Contacts()
+	s.Require().NoError(err)
+	s.Empty(contactList, "The profile has its own database")
+	s.NotNil(service.contacts, "The selected account stays logged in")
+	found, err := service.Profile("0x02")
+	s.Require().NoError(err)
+	s.Equal(profile, found)
+
+	s.Require().NoError(service.CloseProfile("0x02"))
+	s.Equal(ErrProfileNotFound, service.CloseProfile("0x02"))
+	s.Nil(profile.contacts)
+	s.False(s.whisper[0].HasKeyPair(profile.SelectedKeyPairID()), "The identity of the profile is removed")
+	s.True(s.whisper[0].HasKeyPair(primaryID))
+	s.Empty(service.Profiles())
+}
+
+func (s *ShhExtSuite) TestClearKeyPairID() {
+	service := s.services[0]
+	profileKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	profileID, err := s.whisper[0].AddKeyPair(profileKey)
+	s.Require().NoError(err)
+	s.Equal(profileID, service.SelectedKeyPairID(), "The identity selected in Whisper is used by default")
+
+	service.ClearKeyPairID()
+	s.Empty(service.SelectedKeyPairID(), "The identity of a profile is not used by an account without one")
+	_, err = service.selectedIdentity()
+	s.Equal(ErrNoChatIdentity, err)
+
+	service.SetKeyPairID(profileID)
+	identity, err := service.selectedIdentity()
+	s.Require().NoError(err)
+	s.Equal(profileKey.PublicKey, identity.PublicKey)
+}
+
+func (s *ShhExtSuite) TestClaimSharedChatDB() {
+	service := s.services[0]
+	sharedPath := filepath.Join(service.dataDir, "1.v2.db")
+	key, err := chat.DBKey("password", chat.LegacyDBVersion.KDF)
+	s.Require().NoError(err)
+	shared, err := chat.NewSQLLitePersistence(sharedPath, key, chat.DefaultPersistenceConfig())
+	s.Require().NoError(err)
+	_, err = contacts.NewManager(shared).Add("0x04aa", "alice")
+	s.Require().NoError(err)
+	s.Require().NoError(shared.Close())
+
+	s.Require().NoError(service.InitProtocol("0x01", "other-password"))
+	_, err = os.Stat(sharedPath)
+	s.NoError(err, "The shared database is kept for the account it belongs to")
+
+	s.Require().NoError(service.InitProtocol("0x02", "password"))
+	_, err = os.Stat(sharedPath)
+	s.True(os.IsNotExist(err))
+	contactList, err := service.contacts.Contacts()
+	s.Require().NoError(err)
+	s.Len(contactList, 1, "The shared database is claimed by the account it can be decrypted by")
+}
+
+func (s *ShhExtSuite) TestReencryptLegacyChatDB() {
+	service := s.services[0]
+	path := service.chatDBPath("0x03")
+	name := filepath.Base(path)
+	legacyKey, err := chat.DBKey("password", chat.LegacyDBVersion.KDF)
+	s.Require().NoError(err)
+	legacy, err := chat.NewSQLLitePersistence(path, legacyKey, chat.DefaultPersistenceConfig())
+	s.Require().NoError(err)
+	s.Require().NoError(os.MkdirAll(filepath.Join(service.dataDir, "backups"), os.ModePerm))
+	backupPath := filepath.Join(service.dataDir, "backups", name+".1.bak")
+	s.Require().NoError(legacy.Backup(backupPath))
+	s.Require().NoError(legacy.Close())
+
+	s.Require().NoError(service.InitProtocol("0x03", "password"))
+	for _, store := range []string{name, name + ".1.bak"} {
+		var version reencryption.Version
+		for i := 0; i < 500 && version != chat.DefaultDBVersion; i++ {
+			time.Sleep(10 * time.Millisecond)
+			version, err = service.reencryption.Version(store, chat.LegacyDBVersion)
+			s.Require().NoError(err)
+		}
+		s.Equal(chat.DefaultDBVersion, version, "The legacy database and its backups are re-encrypted")
+	}
+	s.Require().NoError(service.CloseProtocol())
+
+	key, err := chat.DBKey("password", chat.DefaultDBVersion.KDF)
+	s.Require().NoError(err)
+	for _, path := range []string{path, backupPath} {
+		persistence, err := chat.NewSQLLitePersistence(path, key, chat.PersistenceConfig{DeferMigrations: true})
+		s.Require().NoError(err)
+		s.Require().NoError(persistence.Close())
+	}
+}
+
+func (s *ShhExtSuite) TestCheckConsistency() {
+	s.Require().NoError(s.services[0].InitProtocol("example-address", "password"))
+	s.False(s.services[0].checkConsistency(nil).Repaired())
+
+	s.Require().NoError(s.whisper[0].Unsubscribe(s.services[0].bundleLookups.filters[0]))
+	report := s.services[0].checkConsistency(nil)
+	s.True(report.LookupFiltersReinstalled)
+	for _, id := range s.services[0].bundleLookups.filters {
+		s.NotNil(s.whisper[0].GetFilter(id))
+	}
+	s.False(s.services[0].checkConsistency(nil).Repaired())
+}
+
 func (s *ShhExtSuite) TestPostMessageWithConfirmation() {
 	mock := newHandlerMock(1)
 	s.services[0].tracker.handler = mock
@@ -174,6 +382,41 @@ func (s *ShhExtSuite) TestWaitMessageExpired() {
 	}
 }
 
+func (s *ShhExtSuite) TestOutgoingRateLimits() {
+	service := s.services[0]
+	service.config.OutgoingRateLimits = map[string]ratelimit.Budgets{
+		"cellular": {Classes: map[string]ratelimit.Budget{ratelimit.ClassReceipts: {Rate: 0.001, Burst: 1}}},
+	}
+	symID, err := s.whisper[0].GenerateSymKey()
+	s.Require().NoError(err)
+	msg := whisper.NewMessage{
+		SymKeyID:  symID,
+		PowTarget: whisper.DefaultMinimumPoW,
+		PowTime:   1,
+		TTL:       10,
+		Topic:     whisper.TopicType{0x01, 0x01, 0x01, 0x01},
+		Payload:   []byte("hello"),
+	}
+	api := NewPublicAPI(service)
+	ctx := ratelimit.WithClass(context.Background(), ratelimit.ClassReceipts)
+
+	service.SetNetworkType("cellular")
+	_, err = api.Post(ctx, msg)
+	s.Require().NoError(err)
+	_, err = api.Post(ctx, msg)
+	s.Equal(ratelimit.ErrRateLimited, err)
+	_, err = api.Post(context.Background(), msg)
+	s.NoError(err, "Other classes have their own budget")
+
+	service.SetNetworkType("wifi")
+	_, err = api.Post(ctx, msg)
+	s.NoError(err, "Network types without limits are not limited")
+	s.Equal([]ratelimit.ClassStats{
+		{Class: ratelimit.ClassChat, Sent: 1},
+		{Class: ratelimit.ClassReceipts, Sent: 2, Refused: 1},
+	}, api.GetOutgoingTraffic())
+}
+
 func (s *ShhExtSuite) TestRequestMessagesErrors() {
 	var err error
 
@@ -316,6 +559,989 @@ func (s *ShhExtSuite) TestRequestMessagesSuccess() {
 	s.Require().NoError(waitForHashInTracker(api.service.tracker, common.BytesToHash(hash), MailServerRequestSent, time.Second))
 }
 
+func (s *ShhExtSuite) TestEchoBot() {
+	dir, err := ioutil.TempDir("", "echobot")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dir)
+
+	shh := whisper.New(&whisper.Config{MinimumAcceptedPOW: 0, MaxMessageSize: whisper.DefaultMaxMessageSize})
+	aNode, err := node.New(&node.Config{
+		P2P: p2p.Config{
+			MaxPeers:    math.MaxInt32,
+			NoDiscovery: true,
+		},
+	}) // in-memory node as no data dir
+	s.Require().NoError(err)
+	s.Require().NoError(aNode.Register(func(*node.ServiceContext) (node.Service, error) { return shh, nil }))
+	s.Require().NoError(aNode.Start())
+	defer func() { s.NoError(aNode.Stop()) }()
+
+	service := New(shh, nil, nil, &ServiceConfig{
+		InstallationID: "1",
+		DataDir:        dir,
+		PFSEnabled:     true,
+		EchoBot:        &echobot.Config{},
+	})
+	s.Require().NoError(service.Start(aNode.Server()))
+	defer func() { s.NoError(service.Stop()) }()
+	api := NewPublicAPI(service)
+	botKeyBytes, err := api.GetEchoBot()
+	s.Require().NoError(err)
+	botKey, err := crypto.UnmarshalPubkey(botKeyBytes)
+	s.Require().NoError(err)
+
+	persistence, err := chat.NewSQLLitePersistence(filepath.Join(dir, "alice.db"), "alice", chat.DefaultPersistenceConfig())
+	s.Require().NoError(err)
+	alice := chat.NewProtocolService(chat.NewEncryptionService(persistence, chat.DefaultEncryptionServiceConfig("alice")), func([]chat.IdentityAndIDPair) {})
+	aliceKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	aliceKeyID, err := shh.AddKeyPair(aliceKey)
+	s.Require().NoError(err)
+	topic := chat.DiscoveryTopic()
+	filterID, err := shh.Subscribe(&whisper.Filter{
+		KeyAsym:  aliceKey,
+		Topics:   [][]byte{topic[:]},
+		Messages: make(map[common.Hash]*whisper.ReceivedMessage),
+	})
+	s.Require().NoError(err)
+
+	messages, err := alice.BuildDirectMessage(aliceKey, []byte("hello"), botKey)
+	s.Require().NoError(err)
+	for key, message := range messages {
+		_, err = api.Post(context.Background(), chat.DirectMessageToWhisper(chat.SendDirectMessageRPC{
+			Sig:    aliceKeyID,
+			PubKey: crypto.FromECDSAPub(key),
+		}, message))
+		s.Require().NoError(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if received := shh.GetFilter(filterID).Retrieve(); len(received) > 0 {
+			payload, err := alice.HandleMessage(aliceKey, received[0].Src, received[0].Payload)
+			s.Require().NoError(err)
+			s.Equal([]byte("hello"), payload)
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	s.Fail("message not echoed")
+}
+
+func (s *ShhExtSuite) TestPushServer() {
+	dir, err := ioutil.TempDir("", "push")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dir)
+
+	shh := whisper.New(&whisper.Config{MinimumAcceptedPOW: 0, MaxMessageSize: whisper.DefaultMaxMessageSize})
+	aNode, err := node.New(&node.Config{
+		P2P: p2p.Config{
+			MaxPeers:    math.MaxInt32,
+			NoDiscovery: true,
+		},
+	}) // in-memory node as no data dir
+	s.Require().NoError(err)
+	s.Require().NoError(aNode.Register(func(*node.ServiceContext) (node.Service, error) { return shh, nil }))
+	s.Require().NoError(aNode.Start())
+	defer func() { s.NoError(aNode.Stop()) }()
+
+	service := New(shh, nil, nil, &ServiceConfig{
+		InstallationID: "1",
+		DataDir:        dir,
+		PFSEnabled:     true,
+		PushServer:     &push.ServerConfig{GorushURL: "http://127.0.0.1:8088"},
+	})
+	s.Require().NoError(service.Start(aNode.Server()))
+	defer func() { s.NoError(service.Stop()) }()
+	api := NewPublicAPI(service)
+	_, err = api.GetEchoBot()
+	s.Equal(ErrEchoBotDisabled, err)
+	serverKeyBytes, err := api.GetPushServer()
+	s.Require().NoError(err)
+	serverKey, err := crypto.UnmarshalPubkey(serverKeyBytes)
+	s.Require().NoError(err)
+
+	persistence, err := chat.NewSQLLitePersistence(filepath.Join(dir, "alice.db"), "alice", chat.DefaultPersistenceConfig())
+	s.Require().NoError(err)
+	alice := chat.NewProtocolService(chat.NewEncryptionService(persistence, chat.DefaultEncryptionServiceConfig("alice")), func([]chat.IdentityAndIDPair) {})
+	aliceKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	aliceKeyID, err := shh.AddKeyPair(aliceKey)
+	s.Require().NoError(err)
+	topic := chat.DiscoveryTopic()
+	filterID, err := shh.Subscribe(&whisper.Filter{
+		KeyAsym:  aliceKey,
+		Topics:   [][]byte{topic[:]},
+		Messages: make(map[common.Hash]*whisper.ReceivedMessage),
+	})
+	s.Require().NoError(err)
+
+	payload, err := push.Encode(push.MessageTypeRegistration, push.Registration{
+		TokenType:      push.TokenTypeFCM,
+		Token:          "token",
+		InstallationID: "alice",
+		AccessToken:    "secret",
+		Version:        1,
+	})
+	s.Require().NoError(err)
+	messages, err := alice.BuildDirectMessage(aliceKey, payload, serverKey)
+	s.Require().NoError(err)
+	for key, message := range messages {
+		_, err = api.Post(context.Background(), chat.DirectMessageToWhisper(chat.SendDirectMessageRPC{
+			Sig:    aliceKeyID,
+			PubKey: crypto.FromECDSAPub(key),
+		}, message))
+		s.Require().NoError(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if received := shh.GetFilter(filterID).Retrieve(); len(received) > 0 {
+			payload, err := alice.HandleMessage(aliceKey, received[0].Src, received[0].Payload)
+			s.Require().NoError(err)
+			messageType, content, ok := push.Decode(payload)
+			s.Require().True(ok)
+			s.Equal(push.MessageTypeRegistrationResponse, messageType)
+			var response push.RegistrationResponse
+			s.Require().NoError(json.Unmarshal(content, &response))
+			s.Equal(push.RegistrationResponse{Version: 1}, response)
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	s.Fail("registration not answered")
+}
+
+func (s *ShhExtSuite) TestSegmentedMessage() {
+	s.services[0].config.SegmentSize = 512
+	s.Require().NoError(s.services[0].InitProtocol("example-address", "password"))
+	s.whisper[0].SetMinimumPowTest(0)
+	keyID, err := s.whisper[0].NewKeyPair()
+	s.Require().NoError(err)
+	symKeyID, err := s.whisper[0].AddSymKeyFromPassword("test-chat")
+	s.Require().NoError(err)
+	filterID, err := whisper.NewPublicWhisperAPI(s.whisper[0]).NewMessageFilter(whisper.Criteria{
+		SymKeyID: symKeyID,
+		Topics:   []whisper.TopicType{chat.ChatTopic("test-chat")},
+	})
+	s.Require().NoError(err)
+
+	api := NewPublicAPI(s.services[0])
+	payload := bytes.Repeat([]byte("payload "), 256)
+	hashes, err := api.postSegmented(context.Background(), whisper.NewMessage{
+		SymKeyID:  symKeyID,
+		Sig:       keyID,
+		TTL:       10,
+		Topic:     chat.ChatTopic("test-chat"),
+		Payload:   payload,
+		PowTarget: 0.002,
+		PowTime:   1,
+	})
+	s.Require().NoError(err)
+	s.Len(hashes, 5)
+
+	var received []*whisper.Message
+	deadline := time.Now().Add(5 * time.Second)
+	for len(received) == 0 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+		received, err = api.GetNewFilterMessages(filterID)
+		s.Require().NoError(err)
+	}
+	s.Require().Len(received, 1, "Segments are reassembled in a single message")
+	s.Equal(payload, []byte(received[0].Payload))
+}
+
+type experimentPlugin struct {
+	received chan string
+}
+
+func (p experimentPlugin) Name() string { return "experiment" }
+
+func (p experimentPlugin) Hooks() map[pipeline.Stage]pipeline.Hook {
+	return map[pipeline.Stage]pipeline.Hook{
+		pipeline.PostDecrypt: func(msg *whisper.Message) (bool, error) {
+			if !bytes.HasPrefix(msg.Payload, []byte("experiment:")) {
+				return true, nil
+			}
+			p.received <- string(msg.Payload)
+			return false, nil
+		},
+	}
+}
+
+func (s *ShhExtSuite) TestPipelinePlugins() {
+	s.Require().NoError(s.services[0].InitProtocol("example-address", "password"))
+	s.whisper[0].SetMinimumPowTest(0)
+	plugin := experimentPlugin{received: make(chan string, 1)}
+	s.Require().NoError(s.services[0].RegisterPlugin(plugin))
+	s.Require().Equal(pipeline.ErrPluginExists, s.services[0].RegisterPlugin(plugin))
+	api := NewPublicAPI(s.services[0])
+	s.Equal([]string{"experiment"}, api.GetPlugins())
+
+	keyID, err := s.whisper[0].NewKeyPair()
+	s.Require().NoError(err)
+	symKeyID, err := s.whisper[0].AddSymKeyFromPassword("test-chat")
+	s.Require().NoError(err)
+	filterID, err := whisper.NewPublicWhisperAPI(s.whisper[0]).NewMessageFilter(whisper.Criteria{
+		SymKeyID: symKeyID,
+		Topics:   []whisper.TopicType{chat.ChatTopic("test-chat")},
+	})
+	s.Require().NoError(err)
+	for _, payload := range []string{"experiment:hello", "hello"} {
+		_, err = api.Post(context.Background(), whisper.NewMessage{
+			SymKeyID:  symKeyID,
+			Sig:       keyID,
+			TTL:       10,
+			Topic:     chat.ChatTopic("test-chat"),
+			Payload:   []byte(payload),
+			PowTarget: 0.002,
+			PowTime:   1,
+		})
+		s.Require().NoError(err)
+	}
+
+	var received []*whisper.Message
+	deadline := time.Now().Add(5 * time.Second)
+	for len(received) == 0 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+		messages, err := api.GetNewFilterMessages(filterID)
+		s.Require().NoError(err)
+		received = append(received, messages...)
+	}
+	s.Require().Len(received, 1, "Messages handled by plugins are dropped")
+	s.Equal("hello", string(received[0].Payload))
+	s.Equal("experiment:hello", <-plugin.received)
+
+	s.Require().NoError(s.services[0].UnregisterPlugin("experiment"))
+	s.Empty(api.GetPlugins())
+}
+
+func (s *ShhExtSuite) TestEstimateMessage() {
+	s.services[0].config.SegmentSize = 512
+	api := NewPublicAPI(s.services[0])
+	_, err := api.EstimateMessage("", nil, nil)
+	s.Equal(errProtocolNotInitialized, err)
+	s.Require().NoError(s.services[0].InitProtocol("example-address", "password"))
+	s.whisper[0].SetMinimumPowTest(0)
+	keyID, err := s.whisper[0].NewKeyPair()
+	s.Require().NoError(err)
+	contactKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	contact := hexutil.Bytes(crypto.FromECDSAPub(&contactKey.PublicKey))
+
+	estimated, err := api.EstimateMessage(keyID, []byte("hello"), nil)
+	s.Require().NoError(err)
+	s.Equal(1, estimated.Envelopes)
+	s.Empty(estimated.Recipients)
+
+	payload := bytes.Repeat([]byte("payload "), 256)
+	estimated, err = api.EstimateMessage(keyID, payload, []hexutil.Bytes{contact})
+	s.Require().NoError(err)
+	s.Require().Len(estimated.Recipients, 1)
+	s.Equal(contact.String(), estimated.Recipients[0].PublicKey)
+	s.Equal(1, estimated.Recipients[0].Installations)
+
+	hashes, err := api.SendDirectMessage(context.Background(), chat.SendDirectMessageRPC{
+		Sig:     keyID,
+		PubKey:  contact,
+		Payload: payload,
+	})
+	s.Require().NoError(err)
+	s.Equal(len(hashes), estimated.Envelopes, "The envelopes sent are estimated")
+	s.Equal(estimated.Envelopes, estimated.Recipients[0].Envelopes)
+	s.Equal(estimated.Bytes, estimated.Recipients[0].Bytes)
+
+	_, err = api.EstimateMessage(keyID, payload, []hexutil.Bytes{[]byte("invalid")})
+	s.Equal(ErrInvalidPublicKey, err)
+}
+
+func (s *ShhExtSuite) TestContentMessages() {
+	s.Require().NoError(s.services[0].InitProtocol("example-address", "password"))
+	s.whisper[0].SetMinimumPowTest(0)
+	keyID, err := s.whisper[0].NewKeyPair()
+	s.Require().NoError(err)
+	key, err := s.whisper[0].GetPrivateKey(keyID)
+	s.Require().NoError(err)
+	symKeyID, err := s.whisper[0].AddSymKeyFromPassword("test-chat")
+	s.Require().NoError(err)
+	filterID, err := whisper.NewPublicWhisperAPI(s.whisper[0]).NewMessageFilter(whisper.Criteria{
+		SymKeyID: symKeyID,
+		Topics:   []whisper.TopicType{chat.ChatTopic("test-chat")},
+	})
+	s.Require().NoError(err)
+
+	original, err := proto.Marshal(&chat.ChatMessagePayload{Content: "hello", ContentType: "text/plain", ClockValue: 1})
+	s.Require().NoError(err)
+	id := content.MessageID(crypto.FromECDSAPub(&key.PublicKey), original)
+	edit, err := proto.Marshal(&chat.ChatMessagePayload{
+		Content:     "hello, world",
+		ContentType: "text/plain",
+		ClockValue:  2,
+		Kind:        chat.ChatMessagePayload_EDIT,
+		TargetId:    id,
+	})
+	s.Require().NoError(err)
+
+	api := NewPublicAPI(s.services[0])
+	for _, payload := range [][]byte{original, edit} {
+		_, err = api.Post(context.Background(), whisper.NewMessage{
+			SymKeyID:  symKeyID,
+			Sig:       keyID,
+			TTL:       10,
+			Topic:     chat.ChatTopic("test-chat"),
+			Payload:   payload,
+			PowTarget: 0.002,
+			PowTime:   1,
+		})
+		s.Require().NoError(err)
+	}
+
+	var received []*whisper.Message
+	deadline := time.Now().Add(5 * time.Second)
+	for len(received) == 0 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+		messages, err := api.GetNewFilterMessages(filterID)
+		s.Require().NoError(err)
+		received = append(received, messages...)
+	}
+	s.Require().Len(received, 1, "Edits are not returned as messages")
+	s.Equal(original, []byte(received[0].Payload))
+
+	state, err := api.GetMessageState(id)
+	s.Require().NoError(err)
+	s.True(state.Edited)
+	s.Equal("hello, world", state.Content)
+
+	chatAPI := NewChatAPI(s.services[0])
+	page, err := chatAPI.GetMessages(MessagesQuery{Chat: "test-chat"})
+	s.Require().NoError(err)
+	s.Require().Len(page.Messages, 1)
+	s.Equal(id, page.Messages[0].ID)
+	s.Equal("hello, world", page.Messages[0].Content, "The history has the edited content")
+	s.True(page.Messages[0].Edited)
+	found, err := chatAPI.SearchMessages(SearchQuery{Query: "wor"})
+	s.Require().NoError(err)
+	s.Require().Len(found, 1)
+	s.Equal(id, found[0].ID)
+}
+
+func (s *ShhExtSuite) TestPresenceMessages() {
+	s.Require().NoError(s.services[0].InitProtocol("example-address", "password"))
+	s.whisper[0].SetMinimumPowTest(0)
+	keyID, err := s.whisper[0].NewKeyPair()
+	s.Require().NoError(err)
+	key, err := s.whisper[0].GetPrivateKey(keyID)
+	s.Require().NoError(err)
+	symKeyID, err := s.whisper[0].AddSymKeyFromPassword("test-chat")
+	s.Require().NoError(err)
+	filterID, err := whisper.NewPublicWhisperAPI(s.whisper[0]).NewMessageFilter(whisper.Criteria{
+		SymKeyID: symKeyID,
+		Topics:   []whisper.TopicType{chat.ChatTopic("test-chat")},
+	})
+	s.Require().NoError(err)
+
+	regular, err := proto.Marshal(&chat.ChatMessagePayload{Content: "hello", ContentType: "text/plain", ClockValue: 1})
+	s.Require().NoError(err)
+	read, err := proto.Marshal(&chat.ChatMessagePayload{Kind: chat.ChatMessagePayload_READ, ReadIds: []string{"0x01"}, ClockValue: 2})
+	s.Require().NoError(err)
+	typing, err := proto.Marshal(&chat.ChatMessagePayload{Kind: chat.ChatMessagePayload_TYPING, Typing: true, ClockValue: 3})
+	s.Require().NoError(err)
+
+	api := NewPublicAPI(s.services[0])
+	for _, payload := range [][]byte{read, typing, regular} {
+		_, err = api.Post(context.Background(), whisper.NewMessage{
+			SymKeyID:  symKeyID,
+			Sig:       keyID,
+			TTL:       10,
+			Topic:     chat.ChatTopic("test-chat"),
+			Payload:   payload,
+			PowTarget: 0.002,
+			PowTime:   1,
+		})
+		s.Require().NoError(err)
+	}
+
+	var (
+		received []*whisper.Message
+		receipts []presence.Receipt
+	)
+	deadline := time.Now().Add(5 * time.Second)
+	for (len(received) == 0 || len(receipts) == 0) && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+		messages, err := api.GetNewFilterMessages(filterID)
+		s.Require().NoError(err)
+		received = append(received, messages...)
+		receipts, err = api.GetReadReceipts("0x01")
+		s.Require().NoError(err)
+	}
+	s.Require().Len(received, 1, "Read receipts and typing notifications are not returned as messages")
+	s.Equal(regular, []byte(received[0].Payload))
+	s.Equal([]presence.Receipt{{MessageID: "0x01", Reader: hexutil.Encode(crypto.FromECDSAPub(&key.PublicKey)), Clock: 2}}, receipts)
+
+	s.Require().NoError(api.SetPresenceSettings(presence.Settings{ReadReceipts: false, Typing: false}))
+	hashes, err := api.SendTyping(context.Background(), chat.SendTypingRPC{Sig: keyID, PubKey: crypto.FromECDSAPub(&key.PublicKey), Typing: true})
+	s.Require().NoError(err)
+	s.Empty(hashes, "Typing notifications are not sent when disabled")
+	hashes, err = api.SendReadReceipt(context.Background(), chat.SendReadReceiptRPC{Sig: keyID, PubKey: crypto.FromECDSAPub(&key.PublicKey), MessageIDs: []string{"0x01"}})
+	s.Require().NoError(err)
+	s.Empty(hashes, "Read receipts are not sent when disabled")
+}
+
+func (s *ShhExtSuite) TestExportUserData() {
+	_, err := s.services[0].ExportUserData()
+	s.Equal(errProtocolNotInitialized, err)
+
+	s.Require().NoError(s.services[0].InitProtocol("example-address", "password"))
+	key, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	_, err = NewPublicAPI(s.services[0]).AddContact(crypto.FromECDSAPub(&key.PublicKey), "alice")
+	s.Require().NoError(err)
+	s.Require().NoError(s.services[0].history.Add(history.Message{ID: "1", ChatID: "chat", Content: "hello"}))
+
+	exported, err := s.services[0].ExportUserData()
+	s.Require().NoError(err)
+	data := exported.(UserData)
+	s.Require().Len(data.Contacts, 1)
+	s.Equal("alice", data.Contacts[0].Name)
+	s.Equal([]ChatData{{ID: "chat", Messages: []history.Message{{ID: "1", ChatID: "chat", Content: "hello"}}}}, data.Chats)
+	s.Equal(presence.DefaultSettings(), data.Settings.Presence)
+}
+
+func (s *ShhExtSuite) TestSettings() {
+	changed := make(chan string, 10)
+	signal.SetDefaultNodeNotificationHandler(func(event string) {
+		if strings.Contains(event, signal.EventSettingChanged) {
+			changed <- event
+		}
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	api := NewPublicAPI(s.services[0])
+	s.Equal(errProtocolNotInitialized, api.SetSetting(settings.MailServer, json.RawMessage(`"enode://1"`)))
+	s.Require().NoError(s.services[0].InitProtocol("example-address", "password"))
+
+	s.Require().NoError(api.SetSetting(settings.MailServer, json.RawMessage(`"enode://1"`)))
+	value, err := api.GetSetting(settings.MailServer)
+	s.Require().NoError(err)
+	s.Equal(json.RawMessage(`"enode://1"`), value)
+	s.Equal(`{"type":"settings.changed","event":{"key":"mailserver","value":"enode://1"}}`, <-changed)
+
+	exported, err := s.services[0].ExportUserData()
+	s.Require().NoError(err)
+	s.Equal(map[string]json.RawMessage{settings.MailServer: json.RawMessage(`"enode://1"`)}, exported.(UserData).Settings.Values)
+
+	s.Require().NoError(api.DeleteSetting(settings.MailServer))
+	s.Equal(`{"type":"settings.changed","event":{"key":"mailserver","value":null}}`, <-changed)
+	all, err := api.GetSettings()
+	s.Require().NoError(err)
+	s.Empty(all)
+}
+
+func (s *ShhExtSuite) TestHistorySync() {
+	synced := make(chan string, 10)
+	signal.SetDefaultNodeNotificationHandler(func(event string) {
+		if strings.Contains(event, signal.EventHistorySynced) {
+			synced <- event
+		}
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	dir, err := ioutil.TempDir("", "history-sync")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dir)
+	// The second device of the account has its own installation and database
+	paired := s.services[1]
+	paired.installationID = "2"
+	paired.dataDir = dir
+	s.Require().NoError(s.services[0].InitProtocol("example-address", "password"))
+	s.Require().NoError(paired.InitProtocol("example-address", "password"))
+
+	key, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	contact := crypto.FromECDSAPub(&key.PublicKey)
+	api := NewPublicAPI(s.services[0])
+	_, err = api.AddContact(contact, "alice")
+	s.Require().NoError(err)
+	s.Require().NoError(api.MuteChat("status"))
+	s.Require().NoError(s.services[0].history.Add(history.Message{ID: "1", ChatID: "status", Content: "hello", Clock: 10}))
+
+	records, err := s.services[0].syncRecords(0)
+	s.Require().NoError(err)
+	s.Require().Len(records, 3)
+	payload, err := devicesync.Encode(records)
+	s.Require().NoError(err)
+	s.Require().NoError(paired.applySyncedHistory(payload))
+	s.Equal(`{"type":"history.synced","event":{"stats":{"messages":1,"contacts":1,"chats":1}}}`, <-synced)
+
+	page, err := paired.history.Messages("status", "", 0)
+	s.Require().NoError(err)
+	s.Require().Len(page.Messages, 1)
+	s.Equal("hello", page.Messages[0].Content)
+	muted, err := paired.blocking.Muted("status")
+	s.Require().NoError(err)
+	s.True(muted)
+	c, err := paired.contacts.Contact(hexutil.Encode(contact))
+	s.Require().NoError(err)
+	s.Require().NotNil(c)
+	s.Equal("alice", c.Name)
+
+	// Records changed on the paired device later are not overwritten
+	s.Require().NoError(NewPublicAPI(paired).UnmuteChat("status"))
+	s.Require().NoError(paired.applySyncedHistory(payload))
+	muted, err = paired.blocking.Muted("status")
+	s.Require().NoError(err)
+	s.False(muted)
+	s.Empty(synced, "Records already applied are not notified")
+
+	records, err = s.services[0].syncRecords(10)
+	s.Require().NoError(err)
+	s.Len(records, 2, "Only the records changed after the clock are synced")
+
+	sent, err := api.SyncHistory(context.Background(), "", 0)
+	s.Error(err, "The key of the account is required")
+	s.Zero(sent)
+	s.Require().NoError(s.services[0].settings.SetBool(settings.SyncEnabled, false))
+	_, err = api.SyncHistory(context.Background(), "", 0)
+	s.Equal(ErrSyncDisabled, err)
+}
+
+func (s *ShhExtSuite) TestWipe() {
+	events := make(chan string, 10)
+	signal.SetDefaultNodeNotificationHandler(func(event string) {
+		if strings.Contains(event, signal.EventWipeConfirmed) || strings.Contains(event, signal.EventInstallationWiped) {
+			events <- event
+		}
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	dir, err := ioutil.TempDir("", "wipe")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dir)
+	// The lost device of the account has its own installation and database
+	lost := s.services[1]
+	lost.installationID = "2"
+	lost.dataDir = dir
+	key, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	keyPairIDs := make([]string, len(s.services))
+	for i, service := range s.services {
+		keyPairIDs[i], err = s.whisper[i].AddKeyPair(key)
+		s.Require().NoError(err)
+		service.SetKeyPairID(keyPairIDs[i])
+		s.Require().NoError(service.InitProtocol("example-address", "password"))
+	}
+	wiped := false
+	lost.SetWipeHandler(func() error {
+		wiped = true
+		return nil
+	})
+
+	_, err = NewPublicAPI(s.services[0]).WipeInstallation(context.Background(), s.services[0].SelectedKeyPairID(), "2", "password")
+	s.Equal(ErrPasswordUnverifiable, err)
+	errWrongPassword := errors.New("wrong password")
+	s.services[0].SetPasswordVerifier(func(password string) error {
+		if password != "password" {
+			return errWrongPassword
+		}
+		return nil
+	})
+	_, err = NewPublicAPI(s.services[0]).WipeInstallation(context.Background(), s.services[0].SelectedKeyPairID(), "2", "wrong")
+	s.Equal(errWrongPassword, err)
+	_, err = NewPublicAPI(s.services[0]).WipeInstallation(context.Background(), s.services[0].SelectedKeyPairID(), "2", "password")
+	s.Equal(ErrInstallationNotPaired, err)
+	command, err := s.services[0].wipes.Issue(key, "1", "2")
+	s.Require().NoError(err)
+	payload, err := wipe.Encode(wipe.Message{Command: command})
+	s.Require().NoError(err)
+	s.Require().NoError(s.services[0].applyWipe(payload), "Commands targeting other installations are ignored")
+	s.Nil(s.services[0].takePendingWipe())
+
+	s.Require().NoError(lost.applyWipe(payload))
+	pending := lost.takePendingWipe()
+	s.Require().NotNil(pending)
+	s.Require().NoError(lost.applyWipe(payload), "Commands delivered again are ignored")
+	s.Nil(lost.takePendingWipe())
+	lost.pendingWipe = pending
+	// The lost device has messages scheduled for a retry and requests sent to the mailservers
+	s.Require().NoError(lost.db.Put(db.Key(db.EnvelopesRetryQueue, []byte{1}), []byte("{}"), nil))
+	s.Require().NoError(lost.db.Put(db.Key(db.MailserverRequestsCache, []byte{1}), []byte{0, 0, 0, 0, 0, 0, 0, 1}, nil))
+	api := NewPublicAPI(lost)
+	done, err := api.executePendingWipe(context.Background())
+	s.Require().NoError(err)
+	s.True(done)
+	s.True(wiped)
+	s.Equal(`{"type":"installation.wiped","event":{"issuedBy":"1"}}`, <-events)
+	_, err = os.Stat(lost.chatDBPath("example-address"))
+	s.True(os.IsNotExist(err), "The chat database is deleted")
+	s.False(s.whisper[1].HasKeyPair(keyPairIDs[1]), "The identity is deleted")
+	for _, prefix := range []byte{byte(db.EnvelopesRetryQueue), byte(db.MailserverRequestsCache)} {
+		iter := lost.db.NewIterator(util.BytesPrefix([]byte{prefix}), nil)
+		s.False(iter.Next(), "The caches are deleted")
+		iter.Release()
+	}
+	s.Equal(ErrInstallationWiped, lost.InitProtocol("example-address", "password"))
+
+	ack, err := wipe.Acknowledge(key, *command, time.Unix(1000, 0))
+	s.Require().NoError(err)
+	payload, err = wipe.Encode(wipe.Message{Ack: ack})
+	s.Require().NoError(err)
+	s.Require().NoError(s.services[0].applyWipe(payload))
+	s.Contains(<-events, `"status":"confirmed"`)
+	wipes, err := NewPublicAPI(s.services[0]).GetWipes()
+	s.Require().NoError(err)
+	s.Require().Len(wipes, 1)
+	s.Equal(wipe.StatusConfirmed, wipes[0].Status)
+}
+
+func (s *ShhExtSuite) TestBackup() {
+	dir, err := ioutil.TempDir("", "backup")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dir)
+	// The backup is restored on a new installation with its own database
+	restored := s.services[1]
+	restored.installationID = "2"
+	restored.dataDir = dir
+	s.Require().NoError(s.services[0].InitProtocol("example-address", "password"))
+	s.Require().NoError(restored.InitProtocol("example-address", "password"))
+
+	identity, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	contactKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	addBundle := func(key *ecdsa.PrivateKey, installationID string) {
+		container, err := chat.NewBundleContainer(key, installationID)
+		s.Require().NoError(err)
+		s.Require().NoError(chat.SignBundle(key, container))
+		_, err = s.services[0].ProcessPublicBundle(identity, container.GetBundle())
+		s.Require().NoError(err)
+	}
+	addBundle(identity, "3")
+	s.Require().NoError(s.services[0].EnableInstallation(&identity.PublicKey, "3"))
+	addBundle(identity, "4")
+	addBundle(contactKey, "c1")
+	_, err = NewPublicAPI(s.services[0]).AddContact(crypto.FromECDSAPub(&contactKey.PublicKey), "alice")
+	s.Require().NoError(err)
+
+	backup, err := s.services[0].Backup(identity)
+	s.Require().NoError(err)
+	data := backup.(BackupData)
+	s.Equal([]string{"3"}, data.Installations)
+	s.Len(data.Bundles, 2, "The bundles of the account and of the contact are stored")
+	raw, err := json.Marshal(backup)
+	s.Require().NoError(err)
+	s.Require().NoError(restored.RestoreBackup(identity, raw))
+
+	installations, err := restored.protocol.Installations(&identity.PublicKey, identity)
+	s.Require().NoError(err)
+	s.Equal([]string{"3"}, installations, "The installation that created the backup is disabled")
+	installations, err = restored.protocol.Installations(&contactKey.PublicKey, identity)
+	s.Require().NoError(err)
+	s.Equal([]string{"c1"}, installations)
+	c, err := restored.contacts.Contact(hexutil.Encode(crypto.FromECDSAPub(&contactKey.PublicKey)))
+	s.Require().NoError(err)
+	s.Require().NotNil(c)
+	s.Equal("alice", c.Name)
+	s.Require().NoError(restored.EnableInstallation(&identity.PublicKey, "1"))
+	installations, err = restored.protocol.Installations(&identity.PublicKey, identity)
+	s.Require().NoError(err)
+	s.Equal([]string{"1", "3"}, installations)
+}
+
+func (s *ShhExtSuite) TestContactRequests() {
+	dir, err := ioutil.TempDir("", "contact-requests")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dir)
+
+	decrypted := make(chan string, 10)
+	signal.SetDefaultNodeNotificationHandler(func(event string) {
+		if strings.Contains(event, signal.EventMessagesDecrypted) {
+			decrypted <- event
+		}
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	s.services[0].config.ContactRequests = true
+	s.Require().NoError(s.services[0].InitProtocol("example-address", "password"))
+	s.whisper[0].SetMinimumPowTest(0)
+	keyID, err := s.whisper[0].NewKeyPair()
+	s.Require().NoError(err)
+	key, err := s.whisper[0].GetPrivateKey(keyID)
+	s.Require().NoError(err)
+	api := NewPublicAPI(s.services[0])
+	filterID, err := api.publicAPI.NewMessageFilter(whisper.Criteria{
+		PrivateKeyID: keyID,
+		Topics:       []whisper.TopicType{chat.DiscoveryTopic()},
+	})
+	s.Require().NoError(err)
+
+	persistence, err := chat.NewSQLLitePersistence(filepath.Join(dir, "stranger.db"), "stranger", chat.DefaultPersistenceConfig())
+	s.Require().NoError(err)
+	stranger := chat.NewProtocolService(chat.NewEncryptionService(persistence, chat.DefaultEncryptionServiceConfig("stranger")), func([]chat.IdentityAndIDPair) {})
+	strangerKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	strangerKeyID, err := s.whisper[0].AddKeyPair(strangerKey)
+	s.Require().NoError(err)
+	messages, err := stranger.BuildDirectMessage(strangerKey, []byte("hello"), &key.PublicKey)
+	s.Require().NoError(err)
+	for publicKey, message := range messages {
+		_, err = api.Post(context.Background(), chat.DirectMessageToWhisper(chat.SendDirectMessageRPC{
+			Sig:    strangerKeyID,
+			PubKey: crypto.FromECDSAPub(publicKey),
+		}, message))
+		s.Require().NoError(err)
+	}
+
+	var (
+		received []*whisper.Message
+		requests []consent.Request
+	)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(requests) == 0 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+		messages, err := api.GetNewFilterMessages(filterID)
+		s.Require().NoError(err)
+		received = append(received, messages...)
+		requests, err = api.GetContactRequests()
+		s.Require().NoError(err)
+	}
+	s.Require().Len(requests, 1)
+	s.Equal(hexutil.Encode(crypto.FromECDSAPub(&strangerKey.PublicKey)), requests[0].PublicKey)
+	s.Empty(received, "Messages of unknown identities are kept until their request is accepted")
+
+	s.Require().NoError(api.AcceptContactRequest(crypto.FromECDSAPub(&strangerKey.PublicKey)))
+	select {
+	case <-decrypted:
+	case <-time.After(5 * time.Second):
+		s.Fail("message not delivered after accepting the request")
+	}
+	requests, err = api.GetContactRequests()
+	s.Require().NoError(err)
+	s.Empty(requests)
+}
+
+func (s *ShhExtSuite) TestBlockAndMute() {
+	notified := signal.Subscribe(signal.SubscriptionConfig{Types: []string{signal.EventMessageNotification}})
+	defer notified.Unsubscribe()
+	grouped := signal.Subscribe(signal.SubscriptionConfig{Types: []string{signal.EventNotificationGroup}})
+	defer grouped.Unsubscribe()
+
+	s.Require().NoError(s.services[0].InitProtocol("example-address", "password"))
+	s.whisper[0].SetMinimumPowTest(0)
+	blockedKeyID, err := s.whisper[0].NewKeyPair()
+	s.Require().NoError(err)
+	blockedKey, err := s.whisper[0].GetPrivateKey(blockedKeyID)
+	s.Require().NoError(err)
+	keyID, err := s.whisper[0].NewKeyPair()
+	s.Require().NoError(err)
+	symKeyID, err := s.whisper[0].AddSymKeyFromPassword("test-chat")
+	s.Require().NoError(err)
+	filterID, err := whisper.NewPublicWhisperAPI(s.whisper[0]).NewMessageFilter(whisper.Criteria{
+		SymKeyID: symKeyID,
+		Topics:   []whisper.TopicType{chat.ChatTopic("test-chat")},
+	})
+	s.Require().NoError(err)
+
+	api := NewPublicAPI(s.services[0])
+	s.Require().NoError(api.BlockContact(crypto.FromECDSAPub(&blockedKey.PublicKey)))
+	blocked, err := api.GetBlockedContacts()
+	s.Require().NoError(err)
+	s.Equal([]string{hexutil.Encode(crypto.FromECDSAPub(&blockedKey.PublicKey))}, blocked)
+
+	post := func(sig string, text string) []byte {
+		payload, err := proto.Marshal(&chat.ChatMessagePayload{Content: text, ContentType: "text/plain", ClockValue: 1})
+		s.Require().NoError(err)
+		_, err = api.Post(context.Background(), whisper.NewMessage{
+			SymKeyID:  symKeyID,
+			Sig:       sig,
+			TTL:       10,
+			Topic:     chat.ChatTopic("test-chat"),
+			Payload:   payload,
+			PowTarget: 0.002,
+			PowTime:   1,
+		})
+		s.Require().NoError(err)
+		return payload
+	}
+	receive := func() []*whisper.Message {
+		var received []*whisper.Message
+		deadline := time.Now().Add(5 * time.Second)
+		for len(received) == 0 && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+			messages, err := api.GetNewFilterMessages(filterID)
+			s.Require().NoError(err)
+			received = append(received, messages...)
+		}
+		return received
+	}
+
+	post(blockedKeyID, "spam")
+	allowed := post(keyID, "hello")
+	received := receive()
+	s.Require().Len(received, 1, "Messages of blocked contacts are dropped")
+	s.Equal(allowed, []byte(received[0].Payload))
+	select {
+	case envelope := <-notified.Signals():
+		notification := envelope.Event.(signal.MessageNotificationSignal).Notification.(notifications.Notification)
+		s.Equal("hello", notification.Preview)
+	case <-time.After(time.Second):
+		s.Fail("message not notified")
+	}
+	select {
+	case envelope := <-grouped.Signals():
+		group := envelope.Event.(signal.NotificationGroupSignal).Group.(notifications.Group)
+		s.Equal(1, group.Count)
+		s.Equal("hello", group.Notifications[0].Preview)
+	case <-time.After(time.Second):
+		s.Fail("notifications not grouped")
+	}
+
+	chatID := history.PublicChatID(chat.ChatTopic("test-chat"))
+	s.Require().NoError(api.MuteChat(chatID))
+	muted, err := api.GetMutedChats()
+	s.Require().NoError(err)
+	s.Equal([]string{chatID}, muted)
+	post(keyID, "muted")
+	s.Require().Len(receive(), 1, "Messages of muted chats are delivered")
+	select {
+	case <-notified.Signals():
+		s.Fail("muted chats don't trigger notifications")
+	case <-grouped.Signals():
+		s.Fail("muted chats don't trigger notifications")
+	default:
+	}
+}
+
+func (s *ShhExtSuite) TestDowngrade() {
+	dir, err := ioutil.TempDir("", "downgrade")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dir)
+
+	downgraded := make(chan string, 10)
+	signal.SetDefaultNodeNotificationHandler(func(event string) {
+		if strings.Contains(event, signal.EventSecurityDowngrade) {
+			downgraded <- event
+		}
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	s.Require().NoError(s.services[0].InitProtocol("example-address", "password"))
+	s.whisper[0].SetMinimumPowTest(0)
+	keyID, err := s.whisper[0].NewKeyPair()
+	s.Require().NoError(err)
+	key, err := s.whisper[0].GetPrivateKey(keyID)
+	s.Require().NoError(err)
+	api := NewPublicAPI(s.services[0])
+	filterID, err := api.publicAPI.NewMessageFilter(whisper.Criteria{
+		PrivateKeyID: keyID,
+		Topics:       []whisper.TopicType{chat.DiscoveryTopic()},
+	})
+	s.Require().NoError(err)
+
+	persistence, err := chat.NewSQLLitePersistence(filepath.Join(dir, "contact.db"), "contact", chat.DefaultPersistenceConfig())
+	s.Require().NoError(err)
+	contact := chat.NewProtocolService(chat.NewEncryptionService(persistence, chat.DefaultEncryptionServiceConfig("contact")), func([]chat.IdentityAndIDPair) {})
+	contactKey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	contactKeyID, err := s.whisper[0].AddKeyPair(contactKey)
+	s.Require().NoError(err)
+	rpc := chat.SendDirectMessageRPC{Sig: contactKeyID, PubKey: crypto.FromECDSAPub(&key.PublicKey)}
+	receive := func() []*whisper.Message {
+		var received []*whisper.Message
+		deadline := time.Now().Add(5 * time.Second)
+		for len(received) == 0 && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+			messages, err := api.GetNewFilterMessages(filterID)
+			s.Require().NoError(err)
+			received = append(received, messages...)
+		}
+		return received
+	}
+
+	messages, err := contact.BuildDirectMessage(contactKey, []byte("encrypted"), &key.PublicKey)
+	s.Require().NoError(err)
+	_, err = api.Post(context.Background(), chat.DirectMessageToWhisper(rpc, messages[&key.PublicKey]))
+	s.Require().NoError(err)
+	s.Require().Len(receive(), 1)
+
+	_, err = api.Post(context.Background(), chat.DirectMessageToWhisper(rpc, []byte("plain")))
+	s.Require().NoError(err)
+	received := receive()
+	s.Require().Len(received, 1, "Messages that are not encrypted with PFS are delivered")
+	s.Equal([]byte("plain"), []byte(received[0].Payload))
+	select {
+	case event := <-downgraded:
+		s.Contains(event, hexutil.Encode(received[0].Hash))
+	case <-time.After(time.Second):
+		s.Fail("downgrade not signaled")
+	}
+	events, err := api.GetDowngrades(crypto.FromECDSAPub(&contactKey.PublicKey))
+	s.Require().NoError(err)
+	s.Require().Len(events, 1)
+	s.Equal(hexutil.Encode(received[0].Hash), events[0].Hash)
+}
+
+type memoryAttachmentsBackend map[string][]byte
+
+func (b memoryAttachmentsBackend) Upload(ctx context.Context, blob []byte) (string, error) {
+	id := fmt.Sprintf("blob%d", len(b))
+	b[id] = blob
+	return id, nil
+}
+
+func (b memoryAttachmentsBackend) Download(ctx context.Context, id string, maxSize int64) ([]byte, error) {
+	return b[id], nil
+}
+
+func (s *ShhExtSuite) TestReceivedAttachment() {
+	backend := memoryAttachmentsBackend{}
+	s.services[0].config.AttachmentsBackend = backend
+	s.services[0].config.Attachments = attachments.DefaultConfig()
+	s.Require().NoError(s.services[0].InitProtocol("example-address", "password"))
+	s.whisper[0].SetMinimumPowTest(0)
+	symKeyID, err := s.whisper[0].AddSymKeyFromPassword("test-chat")
+	s.Require().NoError(err)
+	filterID, err := whisper.NewPublicWhisperAPI(s.whisper[0]).NewMessageFilter(whisper.Criteria{
+		SymKeyID: symKeyID,
+		Topics:   []whisper.TopicType{chat.ChatTopic("test-chat")},
+	})
+	s.Require().NoError(err)
+
+	// The attachment is uploaded by another node
+	blob, ref, err := attachments.Encrypt([]byte("picture"), "image/jpeg")
+	s.Require().NoError(err)
+	ref.ID, err = backend.Upload(context.Background(), blob)
+	s.Require().NoError(err)
+	payload, err := attachments.Encode(ref)
+	s.Require().NoError(err)
+
+	api := NewPublicAPI(s.services[0])
+	_, err = api.Post(context.Background(), whisper.NewMessage{
+		SymKeyID:  symKeyID,
+		TTL:       10,
+		Topic:     chat.ChatTopic("test-chat"),
+		Payload:   payload,
+		PowTarget: 0.002,
+		PowTime:   1,
+	})
+	s.Require().NoError(err)
+
+	var attachment *attachments.Attachment
+	deadline := time.Now().Add(5 * time.Second)
+	for (attachment == nil || attachment.State != attachments.Cached) && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+		_, err = api.GetNewFilterMessages(filterID)
+		s.Require().NoError(err)
+		attachment, err = api.GetAttachment(ref.Hash)
+		s.Require().NoError(err)
+	}
+	s.Require().NotNil(attachment)
+	s.Equal(attachments.Cached, attachment.State, "It downloads received attachments")
+
+	data, err := api.FetchAttachment(context.Background(), ref.Hash)
+	s.Require().NoError(err)
+	s.Equal(hexutil.Bytes("picture"), data)
+}
+
 func (s *ShhExtSuite) TestDebugPostSync() {
 	mock := newHandlerMock(1)
 	s.services[0].tracker.handler = mock
@@ -0,0 +1,207 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+const (
+	// MailServer is the enode of the mail server chosen by the user.
+	MailServer = "mailserver"
+	// NotificationsEnabled enables the notifications of the node.
+	NotificationsEnabled = "notifications.enabled"
+	// SyncEnabled enables the sync of the data of the account with its other devices.
+	SyncEnabled = "sync.enabled"
+
+	// MaxKeyLength is the maximum length of a key.
+	MaxKeyLength = 256
+)
+
+var (
+	// ErrInvalidKey is returned for empty keys and keys longer than MaxKeyLength.
+	ErrInvalidKey = errors.New("invalid settings key")
+	// ErrInvalidValue is returned when a value is not valid JSON.
+	ErrInvalidValue = errors.New("settings value must be valid JSON")
+)
+
+// Change is a setting changed. Value is nil if the setting was deleted.
+type Change struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Store persists the settings, encoded to JSON.
+type Store interface {
+	// SaveSetting saves a setting, replacing its current value.
+	SaveSetting(key string, value []byte) error
+	// GetSetting returns the value of a setting, or nil if it's not set.
+	GetSetting(key string) ([]byte, error)
+	DeleteSetting(key string) error
+	// GetSettings returns all the settings, by key.
+	GetSettings() (map[string][]byte, error)
+}
+
+// Handler is notified of every change of the settings.
+type Handler func(Change)
+
+// Manager keeps the preferences of the user in the account database, so that they
+// are encrypted and migrate with the rest of the data of the account.
+type Manager struct {
+	store   Store
+	handler Handler
+
+	mu sync.Mutex
+}
+
+// NewManager returns a new Manager.
+func NewManager(store Store, handler Handler) *Manager {
+	return &Manager{store: store, handler: handler}
+}
+
+// Set sets a setting to a value encoded to JSON. The handler is notified if the value changed.
+func (m *Manager) Set(key string, value json.RawMessage) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	if !json.Valid(value) {
+		return ErrInvalidValue
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, value); err != nil {
+		return err
+	}
+	changed, err := m.set(key, compacted.Bytes())
+	if err != nil {
+		return err
+	}
+	if changed && m.handler != nil {
+		m.handler(Change{Key: key, Value: compacted.Bytes()})
+	}
+	return nil
+}
+
+func (m *Manager) set(key string, value []byte) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, err := m.store.GetSetting(key)
+	if err != nil {
+		return false, err
+	}
+	if current != nil && bytes.Equal(current, value) {
+		return false, nil
+	}
+	return true, m.store.SaveSetting(key, value)
+}
+
+// Get returns the value of a setting encoded to JSON, or nil if it's not set.
+func (m *Manager) Get(key string) (json.RawMessage, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	value, err := m.store.GetSetting(key)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	return json.RawMessage(value), nil
+}
+
+// Delete deletes a setting. The handler is notified if it was set.
+func (m *Manager) Delete(key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	current, err := m.store.GetSetting(key)
+	if err == nil && current != nil {
+		err = m.store.DeleteSetting(key)
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if current != nil && m.handler != nil {
+		m.handler(Change{Key: key})
+	}
+	return nil
+}
+
+// All returns all the settings, by key.
+func (m *Manager) All() (map[string]json.RawMessage, error) {
+	values, err := m.store.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]json.RawMessage, len(values))
+	for key, value := range values {
+		result[key] = json.RawMessage(value)
+	}
+	return result, nil
+}
+
+// SetString sets a setting to a string.
+func (m *Manager) SetString(key string, value string) error {
+	return m.setValue(key, value)
+}
+
+// String returns the string value of a setting, or def if it's not set.
+func (m *Manager) String(key string, def string) (string, error) {
+	value := def
+	if err := m.getValue(key, &value); err != nil {
+		return def, err
+	}
+	return value, nil
+}
+
+// SetBool sets a setting to a boolean.
+func (m *Manager) SetBool(key string, value bool) error {
+	return m.setValue(key, value)
+}
+
+// Bool returns the boolean value of a setting, or def if it's not set.
+func (m *Manager) Bool(key string, def bool) (bool, error) {
+	value := def
+	if err := m.getValue(key, &value); err != nil {
+		return def, err
+	}
+	return value, nil
+}
+
+// SetInt sets a setting to an integer.
+func (m *Manager) SetInt(key string, value int64) error {
+	return m.setValue(key, value)
+}
+
+// Int returns the integer value of a setting, or def if it's not set.
+func (m *Manager) Int(key string, def int64) (int64, error) {
+	value := def
+	if err := m.getValue(key, &value); err != nil {
+		return def, err
+	}
+	return value, nil
+}
+
+func (m *Manager) setValue(key string, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return m.Set(key, encoded)
+}
+
+// getValue decodes the value of a setting, leaving value untouched if it's not set.
+func (m *Manager) getValue(key string, value interface{}) error {
+	encoded, err := m.Get(key)
+	if err != nil || encoded == nil {
+		return err
+	}
+	return json.Unmarshal(encoded, value)
+}
+
+func validateKey(key string) error {
+	if key == "" || len(key) > MaxKeyLength {
+		return ErrInvalidKey
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package settings
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore map[string][]byte
+
+func (s memoryStore) SaveSetting(key string, value []byte) error {
+	s[key] = value
+	return nil
+}
+
+func (s memoryStore) GetSetting(key string) ([]byte, error) {
+	return s[key], nil
+}
+
+func (s memoryStore) DeleteSetting(key string) error {
+	delete(s, key)
+	return nil
+}
+
+func (s memoryStore) GetSettings() (map[string][]byte, error) {
+	return s, nil
+}
+
+func TestSetAndGet(t *testing.T) {
+	var changes []Change
+	m := NewManager(memoryStore{}, func(c Change) {
+		changes = append(changes, c)
+	})
+
+	value, err := m.Get(MailServer)
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	require.NoError(t, m.Set(MailServer, json.RawMessage(`{ "enode": "enode://1" }`)))
+	require.NoError(t, m.Set(MailServer, json.RawMessage(`{"enode":"enode://1"}`)))
+	value, err = m.Get(MailServer)
+	require.NoError(t, err)
+	require.Equal(t, json.RawMessage(`{"enode":"enode://1"}`), value)
+	require.Equal(t, []Change{{Key: MailServer, Value: json.RawMessage(`{"enode":"enode://1"}`)}}, changes, "Unchanged values are not notified")
+
+	require.NoError(t, m.Delete(MailServer))
+	require.NoError(t, m.Delete(MailServer))
+	require.Len(t, changes, 2)
+	require.Equal(t, Change{Key: MailServer}, changes[1])
+
+	require.Equal(t, ErrInvalidValue, m.Set(SyncEnabled, json.RawMessage(`{`)))
+	require.Equal(t, ErrInvalidKey, m.Set("", json.RawMessage(`true`)))
+	require.Equal(t, ErrInvalidKey, m.Set(strings.Repeat("k", MaxKeyLength+1), json.RawMessage(`true`)))
+}
+
+func TestTypedAccessors(t *testing.T) {
+	m := NewManager(memoryStore{}, nil)
+
+	enabled, err := m.Bool(SyncEnabled, true)
+	require.NoError(t, err)
+	require.True(t, enabled, "The default is returned for settings that are not set")
+	require.NoError(t, m.SetBool(SyncEnabled, false))
+	enabled, err = m.Bool(SyncEnabled, true)
+	require.NoError(t, err)
+	require.False(t, enabled)
+
+	require.NoError(t, m.SetString(MailServer, "enode://1"))
+	mailServer, err := m.String(MailServer, "")
+	require.NoError(t, err)
+	require.Equal(t, "enode://1", mailServer)
+
+	require.NoError(t, m.SetInt("limit", 42))
+	limit, err := m.Int("limit", 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), limit)
+
+	_, err = m.Bool(MailServer, false)
+	require.Error(t, err, "Values of another type can't be decoded")
+
+	all, err := m.All()
+	require.NoError(t, err)
+	require.Equal(t, map[string]json.RawMessage{
+		SyncEnabled: json.RawMessage(`false`),
+		MailServer:  json.RawMessage(`"enode://1"`),
+		"limit":     json.RawMessage(`42`),
+	}, all)
+}
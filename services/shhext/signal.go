@@ -2,7 +2,22 @@ package shhext
 
 import (
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/status-im/status-go/services/shhext/attachments"
+	"github.com/status-im/status-go/services/shhext/consent"
+	"github.com/status-im/status-go/services/shhext/content"
+	"github.com/status-im/status-go/services/shhext/devicesync"
+	"github.com/status-im/status-go/services/shhext/downgrade"
+	"github.com/status-im/status-go/services/shhext/integrity"
+	"github.com/status-im/status-go/services/shhext/moderation"
+	"github.com/status-im/status-go/services/shhext/notifications"
+	"github.com/status-im/status-go/services/shhext/receipts"
+	"github.com/status-im/status-go/services/shhext/segmentation"
+	"github.com/status-im/status-go/services/shhext/settings"
+	"github.com/status-im/status-go/services/shhext/trust"
+	"github.com/status-im/status-go/services/shhext/wipe"
 	"github.com/status-im/status-go/signal"
+	whisper "github.com/status-im/whisper/whisperv6"
 )
 
 // EnvelopeSignalHandler sends signals when envelope is sent or expired.
@@ -35,3 +50,98 @@ func (h EnvelopeSignalHandler) DecryptMessageFailed(pubKey string) {
 func (h EnvelopeSignalHandler) BundleAdded(identity string, installationID string) {
 	signal.SendBundleAdded(identity, installationID)
 }
+
+func (h EnvelopeSignalHandler) MessageTranslated(hash common.Hash, language string, translation string) {
+	signal.SendMessageTranslated(hash, language, translation)
+}
+
+func (h EnvelopeSignalHandler) GroupReceiptsUpdated(summary receipts.Summary) {
+	signal.SendGroupReceiptsUpdated(summary.MessageID, summary.Total, summary.Delivered, summary.Read)
+}
+
+func (h EnvelopeSignalHandler) ModerationListChanged(channel moderation.Channel) {
+	signal.SendModerationListChanged(channel.ChatID, channel.Moderator, channel.List.Allow, channel.List.Deny, channel.List.Clock)
+}
+
+func (h EnvelopeSignalHandler) MessageFlagged(hash common.Hash, chatID string, author string) {
+	signal.SendMessageFlagged(hash, chatID, author)
+}
+
+func (h EnvelopeSignalHandler) MessagesDecrypted(messages []*whisper.Message) {
+	signal.SendMessagesDecrypted(messages)
+}
+
+func (h EnvelopeSignalHandler) PeerThrottled(peer enode.ID, topic whisper.TopicType) {
+	signal.SendPeerThrottled(peer.String(), topic.String())
+}
+
+func (h EnvelopeSignalHandler) SegmentsProgress(progress segmentation.Progress) {
+	signal.SendSegmentsProgress(progress.Hash, progress.Received, progress.Total)
+}
+
+func (h EnvelopeSignalHandler) AttachmentStateChanged(attachment attachments.Attachment) {
+	signal.SendAttachmentStateChanged(attachment.Hash.String(), attachment.State.String())
+}
+
+func (h EnvelopeSignalHandler) NotificationPreferencesChanged(preferences notifications.Preferences) {
+	signal.SendNotificationPreferencesChanged(preferences)
+}
+
+func (h EnvelopeSignalHandler) MessageStateChanged(state content.State) {
+	signal.SendMessageStateChanged(state)
+}
+
+// ResponseVerified sends a signal when the response of a mail server failed verification.
+func (h EnvelopeSignalHandler) ResponseVerified(result integrity.Result) {
+	if result.Status != integrity.Verified {
+		signal.SendResponseIntegrityFailed(result)
+	}
+}
+
+func (h EnvelopeSignalHandler) MessagesRead(reader string, messageIDs []string) {
+	signal.SendMessagesRead(reader, messageIDs)
+}
+
+func (h EnvelopeSignalHandler) ContactTyping(author string, typing bool) {
+	signal.SendContactTyping(author, typing)
+}
+
+func (h EnvelopeSignalHandler) ContactRequestReceived(request consent.Request) {
+	signal.SendContactRequestReceived(request)
+}
+
+func (h EnvelopeSignalHandler) MessageNotification(notification notifications.Notification) {
+	signal.SendMessageNotification(notification)
+}
+
+func (h EnvelopeSignalHandler) NotificationGroup(group notifications.Group) {
+	signal.SendNotificationGroup(group)
+}
+
+func (h EnvelopeSignalHandler) SecurityDowngrade(event downgrade.Event) {
+	signal.SendSecurityDowngrade(event)
+}
+
+func (h EnvelopeSignalHandler) SettingChanged(change settings.Change) {
+	signal.SendSettingChanged(change.Key, change.Value)
+}
+
+func (h EnvelopeSignalHandler) HistorySynced(stats devicesync.Stats) {
+	signal.SendHistorySynced(stats)
+}
+
+func (h EnvelopeSignalHandler) WipeConfirmed(w wipe.Wipe) {
+	signal.SendWipeConfirmed(w)
+}
+
+func (h EnvelopeSignalHandler) InstallationWiped(issuedBy string) {
+	signal.SendInstallationWiped(issuedBy)
+}
+
+func (h EnvelopeSignalHandler) MailServerUntrusted(score trust.Score) {
+	signal.SendMailServerUntrusted(score)
+}
+
+func (h EnvelopeSignalHandler) ConsistencyRepaired(report *ConsistencyReport) {
+	signal.SendConsistencyRepaired(report)
+}
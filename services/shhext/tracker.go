@@ -2,10 +2,14 @@ package shhext
 
 import (
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/status-im/status-go/mailserver"
+	"github.com/status-im/status-go/services/report"
+	"github.com/status-im/status-go/services/shhext/archival"
+	"github.com/status-im/status-go/services/shhext/delivery"
 	"github.com/status-im/status-go/services/shhext/mailservers"
 	whisper "github.com/status-im/whisper/whisperv6"
 )
@@ -30,12 +34,27 @@ type tracker struct {
 	w                      *whisper.Whisper
 	handler                EnvelopeEventsHandler
 	mailServerConfirmation bool
+	// skipRequests ignores the requests for historic messages, whose events don't
+	// identify the service that sent them, when the tracker of another service
+	// reports them.
+	skipRequests bool
 
 	mu      sync.Mutex
 	cache   map[common.Hash]EnvelopeState
 	batches map[common.Hash]map[common.Hash]struct{}
+	// requests are the times the requests for historic messages were sent.
+	requests map[common.Hash]time.Time
 
 	mailPeers *mailservers.PeerStore
+	// delivery records the persistent delivery states of added envelopes.
+	// It is optional.
+	delivery *delivery.Recorder
+	// archival reports the envelopes delivered through a mailserver shared
+	// with their recipient. It is optional.
+	archival *archival.Verifier
+	// mailServerAcked is notified of the envelopes acknowledged by a mailserver.
+	// It is optional.
+	mailServerAcked func(common.Hash)
 
 	wg   sync.WaitGroup
 	quit chan struct{}
@@ -62,6 +81,28 @@ func (t *tracker) Add(hash common.Hash) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	t.cache[hash] = EnvelopePosted
+	envelopesPostedCounter.Inc(1)
+	t.updateDelivery(hash, delivery.Posted)
+}
+
+// Remove hash from a tracker. Events about it won't be reported anymore.
+func (t *tracker) Remove(hash common.Hash) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.cache, hash)
+}
+
+// Queued returns the number of envelopes posted but not confirmed as sent yet.
+func (t *tracker) Queued() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	queued := 0
+	for _, state := range t.cache {
+		if state == EnvelopePosted {
+			queued++
+		}
+	}
+	return queued
 }
 
 func (t *tracker) GetState(hash common.Hash) EnvelopeState {
@@ -107,33 +148,64 @@ func (t *tracker) handleEvent(event whisper.EnvelopeEvent) {
 }
 
 func (t *tracker) handleEventEnvelopeSent(event whisper.EnvelopeEvent) {
-	if t.mailServerConfirmation {
-		if !t.isMailserver(event.Peer) {
-			return
-		}
-	}
-
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	state, ok := t.cache[event.Hash]
 	// if we didn't send a message using extension - skip it
-	// if message was already confirmed - skip it
-	if !ok || state == EnvelopeSent {
+	if !ok || state == MailServerRequestSent {
 		return
 	}
-	log.Debug("envelope is sent", "hash", event.Hash, "peer", event.Peer)
+	logger.Debug("envelope is sent", "hash", event.Hash, "peer", event.Peer)
 	if event.Batch != (common.Hash{}) {
 		if _, ok := t.batches[event.Batch]; !ok {
 			t.batches[event.Batch] = map[common.Hash]struct{}{}
 		}
 		t.batches[event.Batch][event.Hash] = struct{}{}
-		log.Debug("waiting for a confirmation", "batch", event.Batch)
+		logger.Debug("waiting for a confirmation", "batch", event.Batch)
 	} else {
-		t.cache[event.Hash] = EnvelopeSent
-		if t.handler != nil {
-			t.handler.EnvelopeSent(event.Hash)
+		t.confirm(event.Hash, event.Peer)
+	}
+}
+
+// confirm must be called with the lock held.
+func (t *tracker) confirm(hash common.Hash, peer enode.ID) {
+	mailserver := t.isMailserver(peer)
+	if mailserver {
+		t.updateDelivery(hash, delivery.MailServerAcked)
+		if t.mailServerAcked != nil {
+			t.mailServerAcked(hash)
 		}
+	} else {
+		t.updateDelivery(hash, delivery.PeerAcked)
+	}
+
+	if t.archival != nil && t.archival.Owns(hash) {
+		// Reported once the shared mailserver returns the envelope.
+		t.archival.Acked(hash, peer)
+		return
+	}
+	if t.mailServerConfirmation && !mailserver {
+		return
+	}
+	// if message was already confirmed - skip it
+	if t.cache[hash] == EnvelopeSent {
+		return
+	}
+	t.cache[hash] = EnvelopeSent
+	envelopesSentCounter.Inc(1)
+	if t.handler != nil {
+		t.handler.EnvelopeSent(hash)
+	}
+}
+
+// updateDelivery must be called with the lock held.
+func (t *tracker) updateDelivery(hash common.Hash, state delivery.State) {
+	if t.delivery == nil {
+		return
+	}
+	if err := t.delivery.Update(hash, state); err != nil {
+		reporter.Error("update-delivery-state", report.WithCode(err, report.CodeStorage), "hash", hash, "state", state)
 	}
 }
 
@@ -142,29 +214,19 @@ func (t *tracker) isMailserver(peer enode.ID) bool {
 }
 
 func (t *tracker) handleAcknowledgedBatch(event whisper.EnvelopeEvent) {
-	if t.mailServerConfirmation {
-		if !t.isMailserver(event.Peer) {
-			return
-		}
-	}
-
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	envelopes, ok := t.batches[event.Batch]
 	if !ok {
-		log.Debug("batch is not found", "batch", event.Batch)
+		logger.Debug("batch is not found", "batch", event.Batch)
 	}
-	log.Debug("received a confirmation", "batch", event.Batch, "peer", event.Peer)
+	logger.Debug("received a confirmation", "batch", event.Batch, "peer", event.Peer)
 	for hash := range envelopes {
-		state, ok := t.cache[hash]
-		if !ok || state == EnvelopeSent {
+		if _, ok := t.cache[hash]; !ok {
 			continue
 		}
-		t.cache[hash] = EnvelopeSent
-		if t.handler != nil {
-			t.handler.EnvelopeSent(hash)
-		}
+		t.confirm(hash, event.Peer)
 	}
 	delete(t.batches, event.Batch)
 }
@@ -175,10 +237,14 @@ func (t *tracker) handleEventEnvelopeExpired(event whisper.EnvelopeEvent) {
 
 	if state, ok := t.cache[event.Hash]; ok {
 		delete(t.cache, event.Hash)
+		if t.archival != nil && t.archival.Expired(event.Hash) {
+			return
+		}
 		if state == EnvelopeSent {
 			return
 		}
-		log.Debug("envelope expired", "hash", event.Hash, "state", state)
+		logger.Debug("envelope expired", "hash", event.Hash, "state", state)
+		envelopesExpiredCounter.Inc(1)
 		if t.handler != nil {
 			t.handler.EnvelopeExpired(event.Hash)
 		}
@@ -186,9 +252,13 @@ func (t *tracker) handleEventEnvelopeExpired(event whisper.EnvelopeEvent) {
 }
 
 func (t *tracker) handleRequestSent(event whisper.EnvelopeEvent) {
+	if t.skipRequests {
+		return
+	}
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	t.cache[event.Hash] = MailServerRequestSent
+	t.requests[event.Hash] = time.Now()
 }
 
 func (t *tracker) handleEventMailServerRequestCompleted(event whisper.EnvelopeEvent) {
@@ -199,15 +269,35 @@ func (t *tracker) handleEventMailServerRequestCompleted(event whisper.EnvelopeEv
 	if !ok || state != MailServerRequestSent {
 		return
 	}
-	log.Debug("mailserver response received", "hash", event.Hash)
+	logger.Debug("mailserver response received", "hash", event.Hash)
 	delete(t.cache, event.Hash)
+	if sent, ok := t.requests[event.Hash]; ok {
+		mailserverRequestTimer.UpdateSince(sent)
+		delete(t.requests, event.Hash)
+	}
 	if t.handler != nil {
 		if resp, ok := event.Data.(*whisper.MailServerResponse); ok {
-			t.handler.MailServerRequestCompleted(event.Hash, resp.LastEnvelopeHash, resp.Cursor, resp.Error)
+			t.handler.MailServerRequestCompleted(event.Hash, resp.LastEnvelopeHash, resp.Cursor, responseError(resp))
 		}
 	}
 }
 
+// responseError returns the error of a mailserver response, as a *mailserver.RateLimitedError
+// if the request was rejected by a rate limit of the mailserver, or as a
+// *mailserver.UnauthorizedError if the client is not authorized.
+func responseError(resp *whisper.MailServerResponse) error {
+	if resp.Error == nil {
+		return nil
+	}
+	if rateLimited, ok := mailserver.ParseRateLimitedError(resp.Error.Error()); ok {
+		return rateLimited
+	}
+	if unauthorized, ok := mailserver.ParseUnauthorizedError(resp.Error.Error()); ok {
+		return unauthorized
+	}
+	return resp.Error
+}
+
 func (t *tracker) handleEventMailServerRequestExpired(event whisper.EnvelopeEvent) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -216,8 +306,10 @@ func (t *tracker) handleEventMailServerRequestExpired(event whisper.EnvelopeEven
 	if !ok || state != MailServerRequestSent {
 		return
 	}
-	log.Debug("mailserver response expired", "hash", event.Hash)
+	logger.Debug("mailserver response expired", "hash", event.Hash)
 	delete(t.cache, event.Hash)
+	delete(t.requests, event.Hash)
+	mailserverRequestsExpiredCounter.Inc(1)
 	if t.handler != nil {
 		t.handler.MailServerRequestExpired(event.Hash)
 	}
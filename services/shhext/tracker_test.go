@@ -8,6 +8,8 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/status-im/status-go/mailserver"
+	"github.com/status-im/status-go/services/shhext/delivery"
 	"github.com/status-im/status-go/services/shhext/mailservers"
 	whisper "github.com/status-im/whisper/whisperv6"
 	"github.com/stretchr/testify/suite"
@@ -35,6 +37,7 @@ func (s *TrackerSuite) SetupTest() {
 	s.tracker = &tracker{
 		cache:     map[common.Hash]EnvelopeState{},
 		batches:   map[common.Hash]map[common.Hash]struct{}{},
+		requests:  map[common.Hash]time.Time{},
 		mailPeers: mailservers.NewPeerStore(mailservers.NewCache(db)),
 	}
 }
@@ -51,6 +54,17 @@ func (s *TrackerSuite) TestConfirmed() {
 	s.Equal(EnvelopeSent, s.tracker.cache[testHash])
 }
 
+func (s *TrackerSuite) TestQueued() {
+	s.tracker.Add(testHash)
+	s.tracker.Add(common.Hash{0x02})
+	s.Equal(2, s.tracker.Queued())
+	s.tracker.handleEvent(whisper.EnvelopeEvent{
+		Event: whisper.EventEnvelopeSent,
+		Hash:  testHash,
+	})
+	s.Equal(1, s.tracker.Queued())
+}
+
 func (s *TrackerSuite) TestConfirmedWithAcknowledge() {
 	testBatch := common.Hash{1}
 	pkey, err := crypto.GenerateKey()
@@ -75,6 +89,29 @@ func (s *TrackerSuite) TestConfirmedWithAcknowledge() {
 	s.Equal(EnvelopeSent, s.tracker.cache[testHash])
 }
 
+func (s *TrackerSuite) TestMailServerAcked() {
+	var acked []common.Hash
+	s.tracker.mailServerAcked = func(hash common.Hash) { acked = append(acked, hash) }
+	pkey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	node := enode.NewV4(&pkey.PublicKey, nil, 0, 0)
+	s.Require().NoError(s.tracker.mailPeers.Update([]*enode.Node{node}))
+
+	s.tracker.Add(testHash)
+	s.tracker.handleEvent(whisper.EnvelopeEvent{
+		Event: whisper.EventEnvelopeSent,
+		Hash:  testHash,
+		Peer:  enode.ID{1},
+	})
+	s.Empty(acked, "Envelopes relayed by regular peers are not acknowledged by a mailserver")
+	s.tracker.handleEvent(whisper.EnvelopeEvent{
+		Event: whisper.EventEnvelopeSent,
+		Hash:  testHash,
+		Peer:  node.ID(),
+	})
+	s.Equal([]common.Hash{testHash}, acked)
+}
+
 func (s *TrackerSuite) TestIgnored() {
 	s.tracker.handleEvent(whisper.EnvelopeEvent{
 		Event: whisper.EventEnvelopeSent,
@@ -129,6 +166,19 @@ func (s *TrackerSuite) TestRequestFailed() {
 	}
 }
 
+func (s *TrackerSuite) TestRateLimitedResponseError() {
+	s.Nil(responseError(&whisper.MailServerResponse{}))
+	s.EqualError(responseError(&whisper.MailServerResponse{Error: errors.New("test error")}), "test error")
+	s.Equal(
+		&mailserver.RateLimitedError{Limit: mailserver.LimitPeerRequests, RetryAfter: 42 * time.Second},
+		responseError(&whisper.MailServerResponse{Error: errors.New("RATE_LIMITED limit=peer_requests retry_after=42")}),
+	)
+	s.Equal(
+		&mailserver.UnauthorizedError{Reason: "credential expired"},
+		responseError(&whisper.MailServerResponse{Error: errors.New("UNAUTHORIZED: credential expired")}),
+	)
+}
+
 func (s *TrackerSuite) TestRequestExpiration() {
 	mock := newHandlerMock(1)
 	s.tracker.handler = mock
@@ -157,3 +207,35 @@ func (s *TrackerSuite) TestIgnoreNotFromMailserver() {
 	})
 	s.Require().Equal(EnvelopePosted, s.tracker.GetState(testHash))
 }
+
+func (s *TrackerSuite) TestDeliveryStates() {
+	s.tracker.delivery = delivery.NewRecorder(delivery.DefaultMaxStates)
+	s.tracker.mailServerConfirmation = true
+	pkey, err := crypto.GenerateKey()
+	s.Require().NoError(err)
+	node := enode.NewV4(&pkey.PublicKey, nil, 0, 0)
+	s.Require().NoError(s.tracker.mailPeers.Update([]*enode.Node{node}))
+
+	s.tracker.Add(testHash)
+	statuses, err := s.tracker.delivery.Statuses([]common.Hash{testHash})
+	s.Require().NoError(err)
+	s.Equal(delivery.Posted, statuses[0].State)
+
+	s.tracker.handleEvent(whisper.EnvelopeEvent{
+		Event: whisper.EventEnvelopeSent,
+		Hash:  testHash,
+		Peer:  node.ID(),
+	})
+	statuses, err = s.tracker.delivery.Statuses([]common.Hash{testHash})
+	s.Require().NoError(err)
+	s.Equal(delivery.MailServerAcked, statuses[0].State)
+
+	s.tracker.handleEvent(whisper.EnvelopeEvent{
+		Event: whisper.EventEnvelopeSent,
+		Hash:  testHash,
+		Peer:  enode.ID{1},
+	})
+	statuses, err = s.tracker.delivery.Statuses([]common.Hash{testHash})
+	s.Require().NoError(err)
+	s.Equal(delivery.PeerAcked, statuses[0].State, "Acknowledgements from regular peers are recorded even if they do not confirm the envelope")
+}
@@ -0,0 +1,24 @@
+package shhext
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+// requestHistoricMessages sends a request for historic messages to a MailServer, and
+// validates the envelopes it returns if the validation of responses is enabled.
+func (s *Service) requestHistoricMessages(node *enode.Node, envelope *whisper.Envelope, r MessagesRequest) error {
+	validator := s.config.ResponseValidator
+	timeout := r.Timeout * time.Second
+	// Watch before sending the request, so that no envelope of the response is missed.
+	if validator != nil {
+		validator.Watch(node.ID(), envelope.Hash(), r.From, r.To, createBloomFilter(r), timeout)
+	}
+	err := s.w.RequestHistoricMessagesWithTimeout(node.ID().Bytes(), envelope, timeout)
+	if err != nil && validator != nil {
+		validator.Cancel(node.ID(), envelope.Hash())
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package trust
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+	whisper "github.com/status-im/whisper/whisperv6"
+)
+
+const (
+	// requestCompleteCode is the code of the Whisper packets sent by mail servers
+	// when they completed a request.
+	requestCompleteCode = 125
+	// p2pMessageCode is the code of the Whisper packets that carry envelopes sent
+	// directly by trusted peers, such as the envelopes returned by mail servers.
+	p2pMessageCode = 127
+
+	// requestFailedPrefix follows the request ID in the payload of failed requests.
+	requestFailedPrefix = "ERROR="
+)
+
+// Protocol wraps the Whisper protocol so that the invalid envelopes returned by
+// mail servers are dropped before Whisper matches them against filters.
+func (v *Validator) Protocol(protocol p2p.Protocol) p2p.Protocol {
+	run := protocol.Run
+	protocol.Run = func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+		return run(peer, &validatingReadWriter{MsgReadWriter: rw, peer: peer.ID(), validator: v})
+	}
+	return protocol
+}
+
+type validatingReadWriter struct {
+	p2p.MsgReadWriter
+	peer      enode.ID
+	validator *Validator
+}
+
+// ReadMsg returns the next packet, without the invalid envelopes. Packets left
+// without envelopes are skipped.
+func (rw *validatingReadWriter) ReadMsg() (p2p.Msg, error) {
+	for {
+		msg, err := rw.MsgReadWriter.ReadMsg()
+		if err != nil || (msg.Code != p2pMessageCode && msg.Code != requestCompleteCode) {
+			return msg, err
+		}
+
+		data, err := ioutil.ReadAll(msg.Payload)
+		if err != nil {
+			return msg, err
+		}
+		msg.Payload = bytes.NewReader(data)
+
+		if msg.Code == requestCompleteCode {
+			var payload []byte
+			// Whisper disconnects peers sending invalid packets.
+			if err := rlp.DecodeBytes(data, &payload); err == nil && len(payload) >= common.HashLength {
+				failed := bytes.HasPrefix(payload[common.HashLength:], []byte(requestFailedPrefix))
+				rw.validator.Complete(rw.peer, common.BytesToHash(payload[:common.HashLength]), failed)
+			}
+			return msg, nil
+		}
+
+		envelopes, ok := decodeEnvelopes(data)
+		if !ok {
+			return msg, nil
+		}
+		valid := rw.validator.Validate(rw.peer, envelopes)
+		if len(valid) == len(envelopes) {
+			return msg, nil
+		}
+		if len(valid) == 0 {
+			continue
+		}
+
+		data, err = rlp.EncodeToBytes(valid)
+		if err != nil {
+			return msg, err
+		}
+		msg.Size = uint32(len(data))
+		msg.Payload = bytes.NewReader(data)
+		return msg, nil
+	}
+}
+
+// decodeEnvelopes decodes a list of envelopes, or a single envelope as sent by
+// older mail servers.
+func decodeEnvelopes(data []byte) ([]*whisper.Envelope, bool) {
+	var envelopes []*whisper.Envelope
+	if err := rlp.DecodeBytes(data, &envelopes); err == nil {
+		return envelopes, true
+	}
+	envelope := new(whisper.Envelope)
+	if err := rlp.DecodeBytes(data, envelope); err == nil {
+		return []*whisper.Envelope{envelope}, true
+	}
+	return nil, false
+}
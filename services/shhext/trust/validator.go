@@ -0,0 +1,368 @@
+package trust
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/status-im/status-go/db"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	// DefaultDuplicateWindow is how long the envelopes received from mail servers are
+	// remembered, so that the same envelopes sent by other mail servers, or for
+	// overlapping requests, are not processed again.
+	DefaultDuplicateWindow = 10 * time.Minute
+
+	// UntrustedScore is the score below which a mail server is untrusted.
+	UntrustedScore = 0.5
+
+	// violationPenalty is the fraction of the score lost with each violation.
+	violationPenalty = 0.1
+	// responseRecovery is the fraction of the missing score recovered with each
+	// response without violations.
+	responseRecovery = 0.1
+	// maxDelivered is the number of envelopes remembered to detect duplicates.
+	maxDelivered = 10000
+)
+
+// Violation is a reason for which an envelope returned by a mail server is dropped.
+type Violation string
+
+const (
+	// ViolationRange envelopes were sent outside of the time range of the requests.
+	ViolationRange Violation = "range"
+	// ViolationTopic envelopes don't match the topics of the requests.
+	ViolationTopic Violation = "topic"
+	// ViolationPoW envelopes don't have the minimum PoW.
+	ViolationPoW Violation = "pow"
+	// ViolationDuplicate envelopes were sent more than once in the same response.
+	ViolationDuplicate Violation = "duplicate"
+)
+
+// Score is the trust in a mail server, between 0 and 1, lowered by each violation
+// and raised by each response without violations.
+type Score struct {
+	Peer       enode.ID          `json:"peer"`
+	Score      float64           `json:"score"`
+	Responses  int               `json:"responses"`
+	Envelopes  int               `json:"envelopes"`
+	Violations map[Violation]int `json:"violations"`
+	Untrusted  bool              `json:"untrusted"`
+}
+
+func newScore(peer enode.ID) *Score {
+	return &Score{Peer: peer, Score: 1, Violations: map[Violation]int{}}
+}
+
+// Handler is notified when a mail server becomes untrusted.
+type Handler func(Score)
+
+type request struct {
+	id       common.Hash
+	lower    uint32
+	upper    uint32
+	bloom    []byte
+	expires  time.Time
+	received map[common.Hash]struct{}
+	// violations is the number of violations of the mail server since the request was sent.
+	violations int
+}
+
+// matches returns the violation of an envelope if it doesn't match the request.
+func (r *request) matches(envelope *whisper.Envelope) (Violation, bool) {
+	if !whisper.BloomFilterMatch(r.bloom, whisper.TopicToBloom(envelope.Topic)) {
+		return ViolationTopic, false
+	}
+	sent := envelope.Expiry - envelope.TTL
+	if sent < r.lower || sent > r.upper {
+		return ViolationRange, false
+	}
+	return "", true
+}
+
+type delivered struct {
+	hash common.Hash
+	time time.Time
+}
+
+// Validator checks that the envelopes returned by mail servers match the time range
+// and the topics of the requests sent to them, have the minimum PoW, and are not
+// duplicates. Invalid envelopes are dropped, and each violation lowers the trust
+// score of the mail server. Scores are persisted.
+//
+// Only the envelopes of mail servers with pending requests are validated, as the
+// requests sent without the validator are unknown.
+type Validator struct {
+	db      *leveldb.DB
+	minPoW  float64
+	handler Handler
+	window  time.Duration
+	now     func() time.Time
+
+	mu       sync.Mutex
+	requests map[enode.ID][]*request
+	scores   map[enode.ID]*Score
+	// delivered are the envelopes received from mail servers, oldest first.
+	delivered      []delivered
+	deliveredIndex map[common.Hash]time.Time
+}
+
+// NewValidator returns a new Validator. The handler can be nil.
+func NewValidator(ldb *leveldb.DB, minPoW float64, handler Handler) *Validator {
+	return &Validator{
+		db:             ldb,
+		minPoW:         minPoW,
+		handler:        handler,
+		window:         DefaultDuplicateWindow,
+		now:            time.Now,
+		requests:       make(map[enode.ID][]*request),
+		scores:         make(map[enode.ID]*Score),
+		deliveredIndex: make(map[common.Hash]time.Time),
+	}
+}
+
+// Watch validates the envelopes returned by a mail server for a request of the
+// envelopes sent between lower and upper on the topics of the bloom filter, until
+// the mail server completes the request or the timeout expires.
+func (v *Validator) Watch(peer enode.ID, requestID common.Hash, lower, upper uint32, bloom []byte, timeout time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.requests[peer] = append(v.requests[peer], &request{
+		id:       requestID,
+		lower:    lower,
+		upper:    upper,
+		bloom:    bloom,
+		expires:  v.now().Add(timeout),
+		received: make(map[common.Hash]struct{}),
+	})
+}
+
+// Validate returns the valid envelopes sent by a mail server.
+func (v *Validator) Validate(peer enode.ID, envelopes []*whisper.Envelope) []*whisper.Envelope {
+	v.mu.Lock()
+	now := v.now()
+	v.expire(peer, now)
+	requests := v.requests[peer]
+	if len(requests) == 0 {
+		v.mu.Unlock()
+		return envelopes
+	}
+
+	valid := make([]*whisper.Envelope, 0, len(envelopes))
+	var violations []Violation
+	for _, envelope := range envelopes {
+		violation, ok := v.validate(requests, envelope)
+		if ok {
+			valid = append(valid, envelope)
+			continue
+		}
+		if violation != "" {
+			violations = append(violations, violation)
+		}
+	}
+	score := v.score(peer)
+	score.Envelopes += len(valid)
+	var untrusted *Score
+	if len(violations) > 0 {
+		untrusted = v.penalize(score, requests, violations)
+	}
+	v.mu.Unlock()
+
+	if len(violations) > 0 {
+		log.Warn("dropping envelopes violating mail server requests", "peer", peer, "violations", violations)
+	}
+	if untrusted != nil && v.handler != nil {
+		v.handler(*untrusted)
+	}
+	return valid
+}
+
+// validate returns true if the envelope is valid. Duplicates of envelopes sent by
+// other mail servers, or for other requests, are dropped without a violation.
+// It must be called with the lock held.
+func (v *Validator) validate(requests []*request, envelope *whisper.Envelope) (Violation, bool) {
+	if envelope.PoW() < v.minPoW {
+		return ViolationPoW, false
+	}
+	violation := ViolationTopic
+	var matched *request
+	for _, r := range requests {
+		var ok bool
+		if violation, ok = r.matches(envelope); ok {
+			matched = r
+			break
+		}
+	}
+	if matched == nil {
+		return violation, false
+	}
+
+	hash := envelope.Hash()
+	if _, exist := matched.received[hash]; exist {
+		return ViolationDuplicate, false
+	}
+	matched.received[hash] = struct{}{}
+	now := v.now()
+	if delivered, exist := v.deliveredIndex[hash]; exist && now.Sub(delivered) < v.window {
+		return "", false
+	}
+	v.deliver(hash, now)
+	return "", true
+}
+
+// Complete forgets a request completed by a mail server, and raises its score if
+// the request succeeded and it returned no invalid envelope since it was sent.
+func (v *Validator) Complete(peer enode.ID, requestID common.Hash, failed bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	requests := v.requests[peer]
+	for i, r := range requests {
+		if r.id != requestID {
+			continue
+		}
+		v.remove(peer, i)
+		score := v.score(peer)
+		score.Responses++
+		if !failed && r.violations == 0 {
+			score.Score += responseRecovery * (1 - score.Score)
+			score.Untrusted = score.Score < UntrustedScore
+		}
+		v.persist(score)
+		return
+	}
+}
+
+// Cancel forgets a request that couldn't be sent.
+func (v *Validator) Cancel(peer enode.ID, requestID common.Hash) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for i, r := range v.requests[peer] {
+		if r.id == requestID {
+			v.remove(peer, i)
+			return
+		}
+	}
+}
+
+// Scores returns the persisted trust scores of mail servers, most trusted first.
+func (v *Validator) Scores() ([]Score, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	iter := v.db.NewIterator(util.BytesPrefix([]byte{byte(db.MailserversTrust)}), nil)
+	defer iter.Release()
+	var scores []Score
+	for iter.Next() {
+		score := Score{}
+		if err := json.Unmarshal(iter.Value(), &score); err != nil {
+			return nil, err
+		}
+		scores = append(scores, score)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		return scores[i].Score > scores[j].Score
+	})
+	return scores, nil
+}
+
+// penalize lowers the score of a mail server for its violations, and returns the
+// score if the mail server became untrusted. It must be called with the lock held.
+func (v *Validator) penalize(score *Score, requests []*request, violations []Violation) *Score {
+	for _, violation := range violations {
+		score.Violations[violation]++
+		score.Score *= 1 - violationPenalty
+	}
+	for _, r := range requests {
+		r.violations += len(violations)
+	}
+	becameUntrusted := !score.Untrusted && score.Score < UntrustedScore
+	score.Untrusted = score.Score < UntrustedScore
+	v.persist(score)
+	if becameUntrusted {
+		untrusted := *score
+		return &untrusted
+	}
+	return nil
+}
+
+// score returns the score of a mail server, loading it if needed. It must be called
+// with the lock held.
+func (v *Validator) score(peer enode.ID) *Score {
+	if score, exist := v.scores[peer]; exist {
+		return score
+	}
+	score := newScore(peer)
+	data, err := v.db.Get(db.Key(db.MailserversTrust, peer[:]), nil)
+	if err == nil {
+		err = json.Unmarshal(data, score)
+	}
+	if err != nil && err != leveldb.ErrNotFound {
+		log.Error("unable to load mail server trust score", "peer", peer, "error", err)
+	}
+	if score.Violations == nil {
+		score.Violations = map[Violation]int{}
+	}
+	v.scores[peer] = score
+	return score
+}
+
+// persist must be called with the lock held.
+func (v *Validator) persist(score *Score) {
+	data, err := json.Marshal(score)
+	if err == nil {
+		err = v.db.Put(db.Key(db.MailserversTrust, score.Peer[:]), data, nil)
+	}
+	if err != nil {
+		log.Error("unable to persist mail server trust score", "peer", score.Peer, "error", err)
+	}
+}
+
+// expire forgets the requests of a mail server whose timeout expired. It must be
+// called with the lock held.
+func (v *Validator) expire(peer enode.ID, now time.Time) {
+	requests := v.requests[peer]
+	for i := len(requests) - 1; i >= 0; i-- {
+		if now.After(requests[i].expires) {
+			v.remove(peer, i)
+			requests = v.requests[peer]
+		}
+	}
+}
+
+// remove must be called with the lock held.
+func (v *Validator) remove(peer enode.ID, i int) {
+	requests := v.requests[peer]
+	requests = append(requests[:i], requests[i+1:]...)
+	if len(requests) == 0 {
+		delete(v.requests, peer)
+		return
+	}
+	v.requests[peer] = requests
+}
+
+// deliver remembers a delivered envelope, and forgets those delivered before the
+// duplicate window. It must be called with the lock held.
+func (v *Validator) deliver(hash common.Hash, now time.Time) {
+	expired := 0
+	for _, d := range v.delivered {
+		if now.Sub(d.time) < v.window && len(v.delivered)-expired < maxDelivered {
+			break
+		}
+		if v.deliveredIndex[d.hash].Equal(d.time) {
+			delete(v.deliveredIndex, d.hash)
+		}
+		expired++
+	}
+	v.delivered = append(v.delivered[expired:], delivered{hash: hash, time: now})
+	v.deliveredIndex[hash] = now
+}
@@ -0,0 +1,201 @@
+package trust
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+	whisper "github.com/status-im/whisper/whisperv6"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+func newTestValidator(t *testing.T, minPoW float64, handler Handler) (*Validator, *leveldb.DB) {
+	ldb, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+	return NewValidator(ldb, minPoW, handler), ldb
+}
+
+// newTopic returns a topic whose bloom filter doesn't match other topics.
+func newTopic(b byte) whisper.TopicType {
+	return whisper.TopicType{b * 10, b * 10, b * 10}
+}
+
+func newEnvelope(topic byte, sent uint32, data byte) *whisper.Envelope {
+	return &whisper.Envelope{Topic: newTopic(topic), Expiry: sent + 10, TTL: 10, Data: []byte{data}}
+}
+
+func TestValidate(t *testing.T) {
+	v, _ := newTestValidator(t, 0, nil)
+	peer := enode.ID{1}
+	requestID := common.Hash{1}
+	v.Watch(peer, requestID, 100, 200, whisper.TopicToBloom(newTopic(1)), time.Minute)
+
+	valid := newEnvelope(1, 150, 1)
+	envelopes := []*whisper.Envelope{
+		valid,
+		newEnvelope(1, 250, 2),
+		newEnvelope(2, 150, 3),
+		valid,
+	}
+	require.Equal(t, []*whisper.Envelope{valid}, v.Validate(peer, envelopes))
+
+	score := v.scores[peer]
+	require.Equal(t, 1, score.Envelopes)
+	require.Equal(t, map[Violation]int{ViolationRange: 1, ViolationTopic: 1, ViolationDuplicate: 1}, score.Violations)
+	require.InDelta(t, 0.729, score.Score, 1e-9)
+
+	other := newEnvelope(1, 150, 4)
+	require.Equal(t, []*whisper.Envelope{other}, v.Validate(enode.ID{2}, []*whisper.Envelope{other}),
+		"Envelopes of mail servers without pending requests are not validated")
+}
+
+func TestValidateDuplicates(t *testing.T) {
+	v, _ := newTestValidator(t, 0, nil)
+	now := time.Now()
+	v.now = func() time.Time { return now }
+	bloom := whisper.TopicToBloom(newTopic(1))
+	v.Watch(enode.ID{1}, common.Hash{1}, 100, 200, bloom, time.Hour)
+	v.Watch(enode.ID{2}, common.Hash{2}, 100, 200, bloom, time.Hour)
+
+	envelope := newEnvelope(1, 150, 1)
+	require.Len(t, v.Validate(enode.ID{1}, []*whisper.Envelope{envelope}), 1)
+	require.Empty(t, v.Validate(enode.ID{2}, []*whisper.Envelope{envelope}), "Envelopes already received are dropped")
+	require.Empty(t, v.scores[enode.ID{2}].Violations, "Envelopes sent by several mail servers are not violations")
+
+	now = now.Add(DefaultDuplicateWindow)
+	v.Complete(enode.ID{2}, common.Hash{2}, false)
+	v.Watch(enode.ID{2}, common.Hash{3}, 100, 200, bloom, time.Hour)
+	require.Len(t, v.Validate(enode.ID{2}, []*whisper.Envelope{envelope}), 1, "Envelopes are remembered during the duplicate window")
+}
+
+func TestValidatePoW(t *testing.T) {
+	v, _ := newTestValidator(t, 1000, nil)
+	peer := enode.ID{1}
+	v.Watch(peer, common.Hash{1}, 100, 200, whisper.TopicToBloom(newTopic(1)), time.Minute)
+	require.Empty(t, v.Validate(peer, []*whisper.Envelope{newEnvelope(1, 150, 1)}))
+	require.Equal(t, map[Violation]int{ViolationPoW: 1}, v.scores[peer].Violations)
+}
+
+func TestRequestsLifecycle(t *testing.T) {
+	v, _ := newTestValidator(t, 0, nil)
+	now := time.Now()
+	v.now = func() time.Time { return now }
+	peer := enode.ID{1}
+	bloom := whisper.TopicToBloom(newTopic(1))
+	invalid := newEnvelope(2, 150, 1)
+
+	v.Watch(peer, common.Hash{1}, 100, 200, bloom, time.Minute)
+	v.Cancel(peer, common.Hash{1})
+	require.Len(t, v.Validate(peer, []*whisper.Envelope{invalid}), 1, "Cancelled requests are not validated")
+
+	v.Watch(peer, common.Hash{2}, 100, 200, bloom, time.Minute)
+	now = now.Add(2 * time.Minute)
+	require.Len(t, v.Validate(peer, []*whisper.Envelope{invalid}), 1, "Expired requests are not validated")
+
+	v.Watch(peer, common.Hash{3}, 100, 200, bloom, time.Minute)
+	v.Complete(peer, common.Hash{3}, false)
+	require.Len(t, v.Validate(peer, []*whisper.Envelope{invalid}), 1, "Completed requests are not validated")
+	require.Empty(t, v.requests)
+}
+
+func TestScores(t *testing.T) {
+	var untrusted []Score
+	v, ldb := newTestValidator(t, 0, func(score Score) {
+		untrusted = append(untrusted, score)
+	})
+	peer := enode.ID{1}
+	bloom := whisper.TopicToBloom(newTopic(1))
+
+	v.Watch(peer, common.Hash{1}, 100, 200, bloom, time.Minute)
+	invalid := make([]*whisper.Envelope, 7)
+	for i := range invalid {
+		invalid[i] = newEnvelope(2, 150, byte(i))
+	}
+	require.Empty(t, v.Validate(peer, invalid))
+	require.Len(t, untrusted, 1, "Handler is notified when the mail server becomes untrusted")
+	require.Equal(t, peer, untrusted[0].Peer)
+	require.True(t, untrusted[0].Untrusted)
+	require.Empty(t, v.Validate(peer, invalid[:1]))
+	require.Len(t, untrusted, 1, "Handler is notified once")
+
+	v.Complete(peer, common.Hash{1}, false)
+	scores, err := v.Scores()
+	require.NoError(t, err)
+	require.Len(t, scores, 1)
+	require.InDelta(t, 0.43, scores[0].Score, 0.01, "Responses with violations don't raise the score")
+	require.Equal(t, 1, scores[0].Responses)
+
+	v.Watch(peer, common.Hash{2}, 100, 200, bloom, time.Minute)
+	v.Complete(peer, common.Hash{2}, true)
+	v.Watch(peer, common.Hash{3}, 100, 200, bloom, time.Minute)
+	v.Complete(peer, common.Hash{3}, false)
+	scores, err = v.Scores()
+	require.NoError(t, err)
+	require.InDelta(t, 0.487, scores[0].Score, 0.01, "Successful responses without violations raise the score")
+	require.Equal(t, 3, scores[0].Responses)
+
+	restarted := NewValidator(ldb, 0, nil)
+	require.Equal(t, scores[0], *restarted.score(peer), "Scores are persisted")
+}
+
+func TestProtocol(t *testing.T) {
+	v, _ := newTestValidator(t, 0, nil)
+	peer := p2p.NewPeer(enode.ID{1}, "peer", nil)
+	type packet struct {
+		code      uint64
+		envelopes []*whisper.Envelope
+	}
+	received := make(chan packet, 3)
+	protocol := v.Protocol(p2p.Protocol{Run: func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+		for {
+			msg, err := rw.ReadMsg()
+			if err != nil {
+				return err
+			}
+			var envelopes []*whisper.Envelope
+			if msg.Code == p2pMessageCode {
+				if err := msg.Decode(&envelopes); err != nil {
+					return err
+				}
+			} else if err := msg.Discard(); err != nil {
+				return err
+			}
+			received <- packet{code: msg.Code, envelopes: envelopes}
+		}
+	}})
+
+	local, remote := p2p.MsgPipe()
+	done := make(chan error, 1)
+	go func() { done <- protocol.Run(peer, local) }()
+
+	requestID := common.Hash{1}
+	v.Watch(peer.ID(), requestID, 100, 200, whisper.TopicToBloom(newTopic(1)), time.Minute)
+	valid := newEnvelope(1, 150, 1)
+	invalid := newEnvelope(2, 150, 2)
+	require.NoError(t, p2p.Send(remote, p2pMessageCode, []*whisper.Envelope{invalid}))
+	require.NoError(t, p2p.Send(remote, p2pMessageCode, []*whisper.Envelope{valid, invalid}))
+	payload := append(requestID.Bytes(), make([]byte, common.HashLength)...)
+	require.NoError(t, p2p.Send(remote, requestCompleteCode, payload))
+
+	first := <-received
+	require.Equal(t, uint64(p2pMessageCode), first.code, "Packets without valid envelopes are skipped")
+	require.Len(t, first.envelopes, 1)
+	require.Equal(t, valid.Topic, first.envelopes[0].Topic)
+	require.Equal(t, uint64(requestCompleteCode), (<-received).code)
+	require.Empty(t, v.requests, "Requests are completed by the mail server")
+
+	// Older mail servers send single envelopes.
+	data, err := rlp.EncodeToBytes(invalid)
+	require.NoError(t, err)
+	envelopes, ok := decodeEnvelopes(data)
+	require.True(t, ok)
+	require.Equal(t, invalid.Topic, envelopes[0].Topic)
+
+	require.NoError(t, remote.Close())
+	require.Error(t, <-done)
+}
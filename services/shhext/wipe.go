@@ -0,0 +1,159 @@
+package shhext
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/status-im/status-go/services/shhext/wipe"
+)
+
+// consumedWipesFile is the file of the data directory recording the commands wiping the
+// installation it accepted.
+const consumedWipesFile = "wipes.consumed.json"
+
+var (
+	// ErrInstallationWiped is returned when the protocol is initialized on an installation
+	// wiped by another installation of the account.
+	ErrInstallationWiped = errors.New("installation was wiped")
+	// ErrInstallationNotPaired is returned when wiping an installation that is not paired.
+	ErrInstallationNotPaired = errors.New("installation is not paired")
+	// ErrPasswordUnverifiable is returned when wiping an installation before a password
+	// verifier is set.
+	ErrPasswordUnverifiable = errors.New("account password can't be verified")
+)
+
+// SetWipeHandler sets the function called once the installation was wiped by another
+// installation of the account, to delete the keys of the account from the keystore.
+func (s *Service) SetWipeHandler(handler func() error) {
+	s.wipeMu.Lock()
+	defer s.wipeMu.Unlock()
+	s.wipeHandler = handler
+}
+
+// SetPasswordVerifier sets the function checking the password of the account before an
+// installation is wiped. Installations can't be wiped while it's nil.
+func (s *Service) SetPasswordVerifier(verify func(password string) error) {
+	s.wipeMu.Lock()
+	defer s.wipeMu.Unlock()
+	s.verifyPassword = verify
+}
+
+// checkPassword returns nil if password is the password of the account.
+func (s *Service) checkPassword(password string) error {
+	s.wipeMu.Lock()
+	verify := s.verifyPassword
+	s.wipeMu.Unlock()
+	if verify == nil {
+		return ErrPasswordUnverifiable
+	}
+	return verify(password)
+}
+
+func (s *Service) isWiped() bool {
+	s.wipeMu.Lock()
+	defer s.wipeMu.Unlock()
+	return s.wiped
+}
+
+// applyWipe applies the wipe commands, and their acknowledgments, sent by the other
+// installations of the account. Commands are sent to all the paired installations, and
+// ignored by those they don't target or which already accepted them. Stale commands are
+// rejected. The installation is wiped once the received
+// messages are processed.
+func (s *Service) applyWipe(payload []byte) error {
+	message, err := wipe.Decode(payload)
+	if err != nil {
+		return err
+	}
+	identity, err := s.selectedIdentity()
+	if err != nil {
+		return err
+	}
+
+	if message.Ack != nil {
+		_, err := s.wipes.Confirm(&identity.PublicKey, *message.Ack)
+		if err == wipe.ErrUnknownCommand {
+			return nil
+		}
+		return err
+	}
+
+	now := s.w.GetCurrentTime()
+	err = wipe.Verify(&identity.PublicKey, s.installationID, *message.Command, now)
+	if err == wipe.ErrNotTarget {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// Commands are delivered again by the mailservers, and must never be replayed
+	err = s.consumedWipes.Consume(*message.Command, now)
+	if err == wipe.ErrCommandConsumed {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	logger.Warn("installation wipe commanded", "issuedBy", message.Command.IssuedBy)
+	s.wipeMu.Lock()
+	s.pendingWipe = message.Command
+	s.wipeMu.Unlock()
+	return nil
+}
+
+// takePendingWipe returns the command wiping the installation, if one was received.
+func (s *Service) takePendingWipe() *wipe.Command {
+	s.wipeMu.Lock()
+	defer s.wipeMu.Unlock()
+	command := s.pendingWipe
+	s.pendingWipe = nil
+	return command
+}
+
+// wipeData closes the chat database of the account and deletes it, with the envelopes
+// remembered by the filters, the messages scheduled for a retry and the requests sent to
+// the mailservers. The protocol can't be initialized again.
+func (s *Service) wipeData() error {
+	s.wipeMu.Lock()
+	s.wiped = true
+	s.wipeMu.Unlock()
+
+	name := s.chatDBName
+	if err := s.CloseProtocol(); err != nil {
+		return err
+	}
+	s.retries.Stop()
+	if err := s.retries.Clear(); err != nil {
+		return err
+	}
+	if err := s.requestsCache.Clear(); err != nil {
+		return err
+	}
+	path := filepath.Join(s.dataDir, name)
+	for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+		if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	s.recentEnvelopes.Reset()
+	return nil
+}
+
+// wipeKeys deletes the identities of the accounts from Whisper, then calls the wipe handler
+// to delete the keys of the account.
+func (s *Service) wipeKeys(command wipe.Command) error {
+	if err := s.w.DeleteKeyPairs(); err != nil {
+		return err
+	}
+	s.SetKeyPairID("")
+	EnvelopeSignalHandler{}.InstallationWiped(command.IssuedBy)
+
+	s.wipeMu.Lock()
+	handler := s.wipeHandler
+	s.wipeMu.Unlock()
+	if handler != nil {
+		return handler()
+	}
+	return nil
+}
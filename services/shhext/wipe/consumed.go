@@ -0,0 +1,76 @@
+package wipe
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ConsumedStore records the commands accepted by an installation, so that a command is never
+// executed twice. It's a file kept out of the chat database of the account, which the wipe
+// deletes: a command replayed once the account is restored on the installation is rejected.
+type ConsumedStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewConsumedStore returns a new ConsumedStore recording the commands in the file at path.
+func NewConsumedStore(path string) *ConsumedStore {
+	return &ConsumedStore{path: path}
+}
+
+// Consume records a command accepted at now, or returns ErrCommandConsumed if it was already.
+// Commands issued more than MaxCommandAge ago are forgotten, as they're stale anyway.
+func (s *ConsumedStore) Consume(command Command, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	consumed, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := consumed[command.ID]; ok {
+		return ErrCommandConsumed
+	}
+	for id, issuedAt := range consumed {
+		if now.Sub(time.Unix(issuedAt, 0)) > MaxCommandAge {
+			delete(consumed, id)
+		}
+	}
+	consumed[command.ID] = command.IssuedAt
+	return s.write(consumed)
+}
+
+// read returns the issue time of the consumed commands, by ID.
+func (s *ConsumedStore) read() (map[string]int64, error) {
+	consumed := make(map[string]int64)
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return consumed, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &consumed); err != nil {
+		return nil, err
+	}
+	return consumed, nil
+}
+
+func (s *ConsumedStore) write(consumed map[string]int64) error {
+	data, err := json.Marshal(consumed)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,241 @@
+package wipe
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// signatureLength is the length of a recoverable signature, [R || S || V].
+const signatureLength = 65
+
+const (
+	// MaxCommandAge is the age after which a command is stale, and rejected by the
+	// installation it targets.
+	MaxCommandAge = 7 * 24 * time.Hour
+
+	// maxClockSkew is how far in the future a command may be issued, as the clocks of the
+	// installations differ.
+	maxClockSkew = 10 * time.Minute
+)
+
+// Statuses of the wipes issued by an installation.
+const (
+	// StatusPending is the status of a wipe sent to an installation which didn't confirm it yet.
+	StatusPending = "pending"
+	// StatusConfirmed is the status of a wipe the installation confirmed.
+	StatusConfirmed = "confirmed"
+)
+
+var (
+	// ErrInvalidSignature is returned for commands and acknowledgments not signed by the account.
+	ErrInvalidSignature = errors.New("invalid wipe signature")
+	// ErrNotTarget is returned when an installation receives a command wiping another one.
+	ErrNotTarget = errors.New("wipe command targets another installation")
+	// ErrSelfWipe is returned when an installation is asked to wipe itself remotely.
+	ErrSelfWipe = errors.New("an installation can't wipe itself remotely")
+	// ErrUnknownCommand is returned for acknowledgments of commands that were not issued.
+	ErrUnknownCommand = errors.New("unknown wipe command")
+	// ErrInvalidMessage is returned for sync messages that are neither a command nor an acknowledgment.
+	ErrInvalidMessage = errors.New("invalid wipe message")
+	// ErrStaleCommand is returned for commands issued more than MaxCommandAge ago, or in the future.
+	ErrStaleCommand = errors.New("stale wipe command")
+	// ErrCommandConsumed is returned for commands an installation already accepted.
+	ErrCommandConsumed = errors.New("wipe command already accepted")
+)
+
+// Command asks an installation of the account to wipe itself. It's signed with the
+// identity key of the account.
+type Command struct {
+	ID string `json:"id"`
+	// InstallationID is the installation to wipe.
+	InstallationID string `json:"installationId"`
+	// IssuedBy is the installation that sent the command.
+	IssuedBy  string        `json:"issuedBy"`
+	IssuedAt  int64         `json:"issuedAt"`
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+func (c *Command) hash() []byte {
+	return crypto.Keccak256([]byte(fmt.Sprintf("status-wipe-command:%s:%s:%s:%d", c.ID, c.InstallationID, c.IssuedBy, c.IssuedAt)))
+}
+
+// Ack is the final message of a wiped installation, confirming the command. It's
+// signed with the identity key of the account.
+type Ack struct {
+	CommandID      string        `json:"commandId"`
+	InstallationID string        `json:"installationId"`
+	WipedAt        int64         `json:"wipedAt"`
+	Signature      hexutil.Bytes `json:"signature"`
+}
+
+func (a *Ack) hash() []byte {
+	return crypto.Keccak256([]byte(fmt.Sprintf("status-wipe-ack:%s:%s:%d", a.CommandID, a.InstallationID, a.WipedAt)))
+}
+
+// Message is the payload of the wipe sync messages, either a command or an acknowledgment.
+type Message struct {
+	Command *Command `json:"command,omitempty"`
+	Ack     *Ack     `json:"ack,omitempty"`
+}
+
+// Encode encodes a wipe sync message.
+func Encode(message Message) ([]byte, error) {
+	return json.Marshal(message)
+}
+
+// Decode decodes a wipe sync message.
+func Decode(payload []byte) (*Message, error) {
+	var message Message
+	if err := json.Unmarshal(payload, &message); err != nil {
+		return nil, err
+	}
+	if (message.Command == nil) == (message.Ack == nil) {
+		return nil, ErrInvalidMessage
+	}
+	return &message, nil
+}
+
+// Wipe is a command issued by an installation, with its status.
+type Wipe struct {
+	Command Command `json:"command"`
+	Status  string  `json:"status"`
+	// ConfirmedAt is the time the installation was wiped, in seconds, if it confirmed it.
+	ConfirmedAt int64 `json:"confirmedAt,omitempty"`
+}
+
+// Store persists the wipes issued by an installation.
+type Store interface {
+	// SaveWipe saves a wipe, replacing the one of the same command.
+	SaveWipe(Wipe) error
+	// GetWipe returns the wipe of a command, or nil if it was not issued.
+	GetWipe(id string) (*Wipe, error)
+	// GetWipes returns the wipes issued, from the most recent.
+	GetWipes() ([]Wipe, error)
+}
+
+// Manager issues the wipe commands of an installation and verifies those it receives.
+type Manager struct {
+	store     Store
+	confirmed func(Wipe)
+	now       func() time.Time
+}
+
+// NewManager returns a new Manager. confirmed is called with the wipes confirmed by
+// their installation.
+func NewManager(store Store, confirmed func(Wipe)) *Manager {
+	return &Manager{
+		store:     store,
+		confirmed: confirmed,
+		now:       time.Now,
+	}
+}
+
+// Issue returns a new command, issued by an installation of the account of identity,
+// wiping another one.
+func (m *Manager) Issue(identity *ecdsa.PrivateKey, issuedBy, installationID string) (*Command, error) {
+	if issuedBy == installationID {
+		return nil, ErrSelfWipe
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	command := &Command{
+		ID:             hexutil.Encode(id),
+		InstallationID: installationID,
+		IssuedBy:       issuedBy,
+		IssuedAt:       m.now().Unix(),
+	}
+	signature, err := crypto.Sign(command.hash(), identity)
+	if err != nil {
+		return nil, err
+	}
+	command.Signature = signature
+	if err := m.store.SaveWipe(Wipe{Command: *command, Status: StatusPending}); err != nil {
+		return nil, err
+	}
+	return command, nil
+}
+
+// Wipes returns the wipes issued, from the most recent.
+func (m *Manager) Wipes() ([]Wipe, error) {
+	return m.store.GetWipes()
+}
+
+// Verify returns nil if a command received by an installation at now is signed by the
+// account of identity, targets the installation and is not stale.
+func Verify(identity *ecdsa.PublicKey, installationID string, command Command, now time.Time) error {
+	if !signedBy(identity, command.hash(), command.Signature) {
+		return ErrInvalidSignature
+	}
+	if command.InstallationID != installationID {
+		return ErrNotTarget
+	}
+	if command.IssuedBy == installationID {
+		return ErrSelfWipe
+	}
+	issuedAt := time.Unix(command.IssuedAt, 0)
+	if now.Sub(issuedAt) > MaxCommandAge || issuedAt.Sub(now) > maxClockSkew {
+		return ErrStaleCommand
+	}
+	return nil
+}
+
+// Acknowledge returns the acknowledgment of a command, signed with identity.
+func Acknowledge(identity *ecdsa.PrivateKey, command Command, wipedAt time.Time) (*Ack, error) {
+	ack := &Ack{
+		CommandID:      command.ID,
+		InstallationID: command.InstallationID,
+		WipedAt:        wipedAt.Unix(),
+	}
+	signature, err := crypto.Sign(ack.hash(), identity)
+	if err != nil {
+		return nil, err
+	}
+	ack.Signature = signature
+	return ack, nil
+}
+
+// Confirm marks the wipe of an acknowledgment signed by the account of identity as confirmed.
+func (m *Manager) Confirm(identity *ecdsa.PublicKey, ack Ack) (*Wipe, error) {
+	if !signedBy(identity, ack.hash(), ack.Signature) {
+		return nil, ErrInvalidSignature
+	}
+	wipe, err := m.store.GetWipe(ack.CommandID)
+	if err != nil {
+		return nil, err
+	}
+	if wipe == nil || wipe.Command.InstallationID != ack.InstallationID {
+		return nil, ErrUnknownCommand
+	}
+	if wipe.Status == StatusConfirmed {
+		return wipe, nil
+	}
+	wipe.Status = StatusConfirmed
+	wipe.ConfirmedAt = ack.WipedAt
+	if err := m.store.SaveWipe(*wipe); err != nil {
+		return nil, err
+	}
+	if m.confirmed != nil {
+		m.confirmed(*wipe)
+	}
+	return wipe, nil
+}
+
+func signedBy(identity *ecdsa.PublicKey, hash, signature []byte) bool {
+	if len(signature) != signatureLength {
+		return false
+	}
+	signer, err := crypto.SigToPub(hash, signature)
+	if err != nil {
+		return false
+	}
+	return crypto.PubkeyToAddress(*signer) == crypto.PubkeyToAddress(*identity)
+}
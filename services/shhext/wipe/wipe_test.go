@@ -0,0 +1,118 @@
+package wipe
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	wipes []Wipe
+}
+
+func (s *memoryStore) SaveWipe(wipe Wipe) error {
+	for i := range s.wipes {
+		if s.wipes[i].Command.ID == wipe.Command.ID {
+			s.wipes[i] = wipe
+			return nil
+		}
+	}
+	s.wipes = append([]Wipe{wipe}, s.wipes...)
+	return nil
+}
+
+func (s *memoryStore) GetWipe(id string) (*Wipe, error) {
+	for _, wipe := range s.wipes {
+		if wipe.Command.ID == id {
+			return &wipe, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) GetWipes() ([]Wipe, error) {
+	return s.wipes, nil
+}
+
+func TestWipe(t *testing.T) {
+	identity, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	var confirmed []Wipe
+	m := NewManager(&memoryStore{}, func(wipe Wipe) { confirmed = append(confirmed, wipe) })
+
+	_, err = m.Issue(identity, "1", "1")
+	require.Equal(t, ErrSelfWipe, err)
+	command, err := m.Issue(identity, "1", "2")
+	require.NoError(t, err)
+	wipes, err := m.Wipes()
+	require.NoError(t, err)
+	require.Len(t, wipes, 1)
+	require.Equal(t, StatusPending, wipes[0].Status)
+
+	payload, err := Encode(Message{Command: command})
+	require.NoError(t, err)
+	message, err := Decode(payload)
+	require.NoError(t, err)
+	require.Equal(t, command, message.Command)
+
+	now := time.Now()
+	require.NoError(t, Verify(&identity.PublicKey, "2", *message.Command, now))
+	require.Equal(t, ErrNotTarget, Verify(&identity.PublicKey, "3", *message.Command, now))
+	tampered := *message.Command
+	tampered.InstallationID = "3"
+	require.Equal(t, ErrInvalidSignature, Verify(&identity.PublicKey, "3", tampered, now))
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	require.Equal(t, ErrInvalidSignature, Verify(&other.PublicKey, "2", *message.Command, now))
+	require.Equal(t, ErrStaleCommand, Verify(&identity.PublicKey, "2", *message.Command, now.Add(MaxCommandAge+time.Minute)))
+	require.Equal(t, ErrStaleCommand, Verify(&identity.PublicKey, "2", *message.Command, now.Add(-time.Hour)))
+
+	ack, err := Acknowledge(identity, *message.Command, time.Unix(1000, 0))
+	require.NoError(t, err)
+	forged, err := Acknowledge(other, *message.Command, time.Unix(1000, 0))
+	require.NoError(t, err)
+	_, err = m.Confirm(&identity.PublicKey, *forged)
+	require.Equal(t, ErrInvalidSignature, err)
+	require.Empty(t, confirmed)
+
+	wipe, err := m.Confirm(&identity.PublicKey, *ack)
+	require.NoError(t, err)
+	require.Equal(t, StatusConfirmed, wipe.Status)
+	require.Equal(t, int64(1000), wipe.ConfirmedAt)
+	_, err = m.Confirm(&identity.PublicKey, *ack)
+	require.NoError(t, err)
+	require.Len(t, confirmed, 1, "Wipes are confirmed once")
+
+	unknown, err := Acknowledge(identity, Command{ID: "unknown", InstallationID: "2"}, time.Unix(1000, 0))
+	require.NoError(t, err)
+	_, err = m.Confirm(&identity.PublicKey, *unknown)
+	require.Equal(t, ErrUnknownCommand, err)
+}
+
+func TestConsumedStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wipe")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wipes.json")
+	now := time.Unix(1546950000, 0)
+
+	command := Command{ID: "1", IssuedAt: now.Unix()}
+	require.NoError(t, NewConsumedStore(path).Consume(command, now))
+	require.Equal(t, ErrCommandConsumed, NewConsumedStore(path).Consume(command, now), "Consumed commands are persisted")
+
+	later := now.Add(MaxCommandAge + time.Hour)
+	require.NoError(t, NewConsumedStore(path).Consume(Command{ID: "2", IssuedAt: later.Unix()}, later))
+	require.NoError(t, NewConsumedStore(path).Consume(command, later), "Stale commands are forgotten")
+}
+
+func TestDecodeInvalidMessage(t *testing.T) {
+	_, err := Decode([]byte(`{}`))
+	require.Equal(t, ErrInvalidMessage, err)
+	_, err = Decode([]byte(`{"command":{},"ack":{}}`))
+	require.Equal(t, ErrInvalidMessage, err)
+}
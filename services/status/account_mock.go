@@ -9,6 +9,7 @@ import (
 	accounts "github.com/ethereum/go-ethereum/accounts"
 	keystore "github.com/ethereum/go-ethereum/accounts/keystore"
 	gomock "github.com/golang/mock/gomock"
+	account "github.com/status-im/status-go/account"
 	reflect "reflect"
 )
 
@@ -73,22 +74,6 @@ func (m *MockAccountManager) EXPECT() *MockAccountManagerMockRecorder {
 	return m.recorder
 }
 
-// AddressToDecryptedAccount mocks base method
-func (m *MockAccountManager) AddressToDecryptedAccount(arg0, arg1 string) (accounts.Account, *keystore.Key, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddressToDecryptedAccount", arg0, arg1)
-	ret0, _ := ret[0].(accounts.Account)
-	ret1, _ := ret[1].(*keystore.Key)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
-}
-
-// AddressToDecryptedAccount indicates an expected call of AddressToDecryptedAccount
-func (mr *MockAccountManagerMockRecorder) AddressToDecryptedAccount(arg0, arg1 interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddressToDecryptedAccount", reflect.TypeOf((*MockAccountManager)(nil).AddressToDecryptedAccount), arg0, arg1)
-}
-
 // SelectAccount mocks base method
 func (m *MockAccountManager) SelectAccount(address, password string) error {
 	m.ctrl.T.Helper()
@@ -119,3 +104,79 @@ func (mr *MockAccountManagerMockRecorder) CreateAccount(password interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockAccountManager)(nil).CreateAccount), password)
 }
+
+// SelectedAccount mocks base method
+func (m *MockAccountManager) SelectedAccount() (*account.SelectedExtKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectedAccount")
+	ret0, _ := ret[0].(*account.SelectedExtKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectedAccount indicates an expected call of SelectedAccount
+func (mr *MockAccountManagerMockRecorder) SelectedAccount() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectedAccount", reflect.TypeOf((*MockAccountManager)(nil).SelectedAccount))
+}
+
+// AccountKeys mocks base method
+func (m *MockAccountManager) AccountKeys(address string) (*account.AccountKeys, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccountKeys", address)
+	ret0, _ := ret[0].(*account.AccountKeys)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AccountKeys indicates an expected call of AccountKeys
+func (mr *MockAccountManagerMockRecorder) AccountKeys(address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountKeys", reflect.TypeOf((*MockAccountManager)(nil).AccountKeys), address)
+}
+
+// ImportAccount mocks base method
+func (m *MockAccountManager) ImportAccount(keyJSON []byte, passphrase, password string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportAccount", keyJSON, passphrase, password)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ImportAccount indicates an expected call of ImportAccount
+func (mr *MockAccountManagerMockRecorder) ImportAccount(keyJSON, passphrase, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportAccount", reflect.TypeOf((*MockAccountManager)(nil).ImportAccount), keyJSON, passphrase, password)
+}
+
+// ImportChatKey mocks base method
+func (m *MockAccountManager) ImportChatKey(address string, keyJSON []byte, passphrase, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportChatKey", address, keyJSON, passphrase, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ImportChatKey indicates an expected call of ImportChatKey
+func (mr *MockAccountManagerMockRecorder) ImportChatKey(address, keyJSON, passphrase, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportChatKey", reflect.TypeOf((*MockAccountManager)(nil).ImportChatKey), address, keyJSON, passphrase, password)
+}
+
+// AddressToDecryptedAccount mocks base method
+func (m *MockAccountManager) AddressToDecryptedAccount(address, password string) (accounts.Account, *keystore.Key, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddressToDecryptedAccount", address, password)
+	ret0, _ := ret[0].(accounts.Account)
+	ret1, _ := ret[1].(*keystore.Key)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AddressToDecryptedAccount indicates an expected call of AddressToDecryptedAccount
+func (mr *MockAccountManagerMockRecorder) AddressToDecryptedAccount(address, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddressToDecryptedAccount", reflect.TypeOf((*MockAccountManager)(nil).AddressToDecryptedAccount), address, password)
+}
@@ -3,6 +3,11 @@ package status
 import (
 	"context"
 	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/account"
+	"github.com/status-im/status-go/services/shhext/latency"
 )
 
 // PublicAPI represents a set of APIs from the `web3.status` namespace.
@@ -28,19 +33,7 @@ type LoginResponse struct {
 
 // Login is an implementation of `status_login` or `web3.status.login` API
 func (api *PublicAPI) Login(context context.Context, req LoginRequest) (res LoginResponse, err error) {
-	_, accountKey, err := api.s.am.AddressToDecryptedAccount(req.Addr, req.Password)
-	if err != nil {
-		return
-	}
-
-	if res.AddressKeyID, err = api.s.w.AddKeyPair(accountKey.PrivateKey); err != nil {
-		return
-	}
-
-	if err = api.s.am.SelectAccount(req.Addr, req.Password); err != nil {
-		return
-	}
-
+	res.AddressKeyID, err = api.s.selectAccount(req.Addr, req.Password)
 	return
 }
 
@@ -51,9 +44,11 @@ type SignupRequest struct {
 
 // SignupResponse : json response returned by status_signup.
 type SignupResponse struct {
-	Address  string `json:"address"`
-	Pubkey   string `json:"pubkey"`
-	Mnemonic string `json:"mnemonic"`
+	Address     string `json:"address"`
+	Pubkey      string `json:"pubkey"`
+	ChatAddress string `json:"chatAddress"`
+	ChatPubkey  string `json:"chatPubkey"`
+	Mnemonic    string `json:"mnemonic"`
 }
 
 // Signup is an implementation of `status_signup` or `web3.status.signup` API
@@ -62,6 +57,52 @@ func (api *PublicAPI) Signup(context context.Context, req SignupRequest) (res Si
 		err = errors.New("could not create the specified account : " + err.Error())
 		return
 	}
+	keys, err := api.s.am.AccountKeys(res.Address)
+	if err != nil {
+		return
+	}
+	res.ChatAddress = keys.Chat.Address.Hex()
+	res.ChatPubkey = keys.Chat.PublicKey.String()
 
 	return
 }
+
+// AccountKeys is an implementation of `status_accountKeys` or `web3.status.accountKeys` API.
+// It returns the addresses and public keys of the wallet and chat keys of an account.
+func (api *PublicAPI) AccountKeys(context context.Context, address string) (*account.AccountKeys, error) {
+	return api.s.am.AccountKeys(address)
+}
+
+// ExportAllUserData is an implementation of `status_exportAllUserData` or `web3.status.exportAllUserData` API.
+// It writes the data of the selected account to a new file at path, encrypted with password.
+func (api *PublicAPI) ExportAllUserData(context context.Context, password string, path string) error {
+	return api.s.exportUserData(password, path)
+}
+
+// CreateBackup is an implementation of `status_createBackup` or `web3.status.createBackup` API.
+// It returns a backup of the identity key, contacts, installations and bundles of the selected
+// account, encrypted with passphrase, once password is checked against the key of the account.
+func (api *PublicAPI) CreateBackup(context context.Context, passphrase string, password string) (hexutil.Bytes, error) {
+	return api.s.CreateBackup(passphrase, password)
+}
+
+// ScheduleBackups is an implementation of `status_scheduleBackups` or `web3.status.scheduleBackups` API.
+// It creates a backup encrypted with passphrase every interval hours, sent in a backup.created signal,
+// once password is checked against the key of the account. An empty passphrase or a zero interval
+// cancels the scheduled backups.
+func (api *PublicAPI) ScheduleBackups(context context.Context, passphrase string, password string, interval int) error {
+	return api.s.ScheduleBackups(passphrase, password, time.Duration(interval)*time.Hour)
+}
+
+// RestoreBackup is an implementation of `status_restoreBackup` or `web3.status.restoreBackup` API.
+// It restores a backup decrypted with passphrase, protects the imported key with password and
+// logs in the account. It returns the address of the account.
+func (api *PublicAPI) RestoreBackup(context context.Context, backup hexutil.Bytes, passphrase string, password string) (string, error) {
+	return api.s.RestoreBackup(backup, passphrase, password)
+}
+
+// GetLatencyStats is an implementation of `status_getLatencyStats` or `web3.status.getLatencyStats` API.
+// It returns the delivery latencies of the latest messages received on each network type.
+func (api *PublicAPI) GetLatencyStats(context context.Context) []latency.Stats {
+	return api.s.latencyStats()
+}
@@ -6,9 +6,11 @@ import (
 	"errors"
 	"testing"
 
-	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/golang/mock/gomock"
+	"github.com/status-im/status-go/account"
+	"github.com/status-im/status-go/services/shhext/latency"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -47,20 +49,20 @@ var logintests = []struct {
 			key := keystore.Key{
 				PrivateKey: &ecdsa.PrivateKey{},
 			}
-			s.am.EXPECT().AddressToDecryptedAccount("address...", "password").Return(accounts.Account{}, &key, nil)
-			s.w.EXPECT().AddKeyPair(key.PrivateKey).Return("addressKey", nil)
+			chatKey := keystore.Key{
+				PrivateKey: &ecdsa.PrivateKey{},
+			}
 			s.am.EXPECT().SelectAccount("address...", "password").Return(nil)
+			s.am.EXPECT().SelectedAccount().Return(&account.SelectedExtKey{AccountKey: &key, ChatKey: &chatKey}, nil)
+			s.w.EXPECT().AddKeyPair(chatKey.PrivateKey).Return("addressKey", nil)
 		},
 	},
 	{
-		name:               "error when decrypting account from address",
+		name:               "error when selecting account",
 		expectedAddressKey: "",
 		expectedError:      errors.New("foo"),
 		prepareExpectations: func(s *StatusSuite) {
-			key := keystore.Key{
-				PrivateKey: &ecdsa.PrivateKey{},
-			}
-			s.am.EXPECT().AddressToDecryptedAccount("address...", "password").Return(accounts.Account{}, &key, errors.New("foo"))
+			s.am.EXPECT().SelectAccount("address...", "password").Return(errors.New("foo"))
 		},
 	},
 	{
@@ -71,21 +73,18 @@ var logintests = []struct {
 			key := keystore.Key{
 				PrivateKey: &ecdsa.PrivateKey{},
 			}
-			s.am.EXPECT().AddressToDecryptedAccount("address...", "password").Return(accounts.Account{}, &key, nil)
+			s.am.EXPECT().SelectAccount("address...", "password").Return(nil)
+			s.am.EXPECT().SelectedAccount().Return(&account.SelectedExtKey{AccountKey: &key, ChatKey: &key}, nil)
 			s.w.EXPECT().AddKeyPair(key.PrivateKey).Return("", errors.New("foo"))
 		},
 	},
 	{
-		name:               "error when selecting account",
+		name:               "error when the key is kept by the platform keystore",
 		expectedAddressKey: "",
-		expectedError:      errors.New("foo"),
+		expectedError:      account.ErrExternalKey,
 		prepareExpectations: func(s *StatusSuite) {
-			key := keystore.Key{
-				PrivateKey: &ecdsa.PrivateKey{},
-			}
-			s.am.EXPECT().AddressToDecryptedAccount("address...", "password").Return(accounts.Account{}, &key, nil)
-			s.w.EXPECT().AddKeyPair(key.PrivateKey).Return("", nil)
-			s.am.EXPECT().SelectAccount("address...", "password").Return(errors.New("foo"))
+			s.am.EXPECT().SelectAccount("address...", "password").Return(nil)
+			s.am.EXPECT().SelectedAccount().Return(&account.SelectedExtKey{External: &account.KeyReference{}}, nil)
 		},
 	},
 }
@@ -112,13 +111,18 @@ var signuptests = []struct {
 	{
 		name: "success signup",
 		expectedResponse: SignupResponse{
-			Address:  "addr",
-			Pubkey:   "pubkey",
-			Mnemonic: "mnemonic",
+			Address:     "0x01",
+			Pubkey:      "pubkey",
+			ChatAddress: common.HexToAddress("0x02").Hex(),
+			ChatPubkey:  "0x0402",
+			Mnemonic:    "mnemonic",
 		},
 		expectedError: nil,
 		prepareExpectations: func(s *StatusSuite) {
-			s.am.EXPECT().CreateAccount("password").Return("addr", "pubkey", "mnemonic", nil)
+			s.am.EXPECT().CreateAccount("password").Return("0x01", "pubkey", "mnemonic", nil)
+			s.am.EXPECT().AccountKeys("0x01").Return(&account.AccountKeys{
+				Chat: account.PurposeKey{Address: common.HexToAddress("0x02"), PublicKey: []byte{4, 2}},
+			}, nil)
 		},
 	},
 	{
@@ -143,7 +147,26 @@ func (s *StatusSuite) TestSignup() {
 		res, err := s.api.Signup(ctx, SignupRequest{Password: "password"})
 		s.Equal(t.expectedResponse.Address, res.Address, "failed scenario : "+t.name)
 		s.Equal(t.expectedResponse.Pubkey, res.Pubkey, "failed scenario : "+t.name)
+		s.Equal(t.expectedResponse.ChatAddress, res.ChatAddress, "failed scenario : "+t.name)
+		s.Equal(t.expectedResponse.ChatPubkey, res.ChatPubkey, "failed scenario : "+t.name)
 		s.Equal(t.expectedResponse.Mnemonic, res.Mnemonic, "failed scenario : "+t.name)
 		s.Equal(t.expectedError, err, "failed scenario : "+t.name)
 	}
 }
+
+type latencySource []latency.Stats
+
+func (l latencySource) LatencyStats() []latency.Stats {
+	return l
+}
+
+func (s *StatusSuite) TestGetLatencyStats() {
+	s.Equal([]latency.Stats{}, s.api.GetLatencyStats(context.Background()), "Stats are empty without source")
+
+	s.api.s.SetLatencySource(latencySource(nil))
+	s.Equal([]latency.Stats{}, s.api.GetLatencyStats(context.Background()), "Stats are empty when latencies are not measured")
+
+	stats := []latency.Stats{{NetworkType: "wifi", Count: 1, Min: 10, Max: 10}}
+	s.api.s.SetLatencySource(latencySource(stats))
+	s.Equal(stats, s.api.GetLatencyStats(context.Background()))
+}
@@ -0,0 +1,283 @@
+package status
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/account"
+	"github.com/status-im/status-go/services/scheduler"
+	"github.com/status-im/status-go/signal"
+)
+
+// backupVersion is the version of the format of the backups of the account.
+const backupVersion = 1
+
+var (
+	// ErrUnsupportedBackup is returned when restoring a backup of an unknown version.
+	ErrUnsupportedBackup = errors.New("unsupported backup version")
+	// ErrSchedulerUnavailable is returned when scheduling backups without a scheduler.
+	ErrSchedulerUnavailable = errors.New("scheduler is not available")
+	// ErrBackupAccountChanged is returned by scheduled backups once another account is selected.
+	ErrBackupAccountChanged = errors.New("selected account changed since the backups were scheduled")
+)
+
+// BackupSource provides a section of the backups of the account.
+type BackupSource interface {
+	// Backup returns the data of the account with the identity key, encoded to JSON in the backup.
+	Backup(identity *ecdsa.PrivateKey) (interface{}, error)
+	// RestoreBackup restores a section of a backup, once the account is logged in.
+	RestoreBackup(identity *ecdsa.PrivateKey, data json.RawMessage) error
+}
+
+// LoginFunc logs in an account with its password.
+type LoginFunc func(address, password string) error
+
+// Backup is the decrypted content of a backup of the account.
+type Backup struct {
+	Version   int         `json:"version"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Account   AccountData `json:"account"`
+	// Key is the key file of the account, encrypted with the passphrase of the backup.
+	Key json.RawMessage `json:"key"`
+	// ChatKey is the key file of the chat identity of the account, encrypted with the
+	// passphrase of the backup. It is omitted if the account uses its key as chat key.
+	ChatKey json.RawMessage `json:"chatKey,omitempty"`
+	// Sections hold the data of the services, by name.
+	Sections map[string]json.RawMessage `json:"sections"`
+}
+
+// backupKeys are the key files of an account encrypted with the passphrase of its backups,
+// and the key derived from the passphrase the backups are encrypted with, so that backups
+// are created without keeping the passphrase.
+type backupKeys struct {
+	address common.Address
+	key     json.RawMessage
+	chatKey json.RawMessage
+	export  *exportKey
+}
+
+// newBackupKeys encrypts the key files of the selected account with passphrase.
+func (s *Service) newBackupKeys(selected *account.SelectedExtKey, passphrase string) (*backupKeys, error) {
+	// Accounts whose key is kept by the platform keystore can't be backed up
+	if _, err := selected.ChatPrivateKey(); err != nil {
+		return nil, err
+	}
+	keys := &backupKeys{address: selected.Address}
+	// The key file is stored in the encrypted backup, its own scrypt parameters can be light
+	var err error
+	if keys.key, err = keystore.EncryptKey(selected.AccountKey, passphrase, keystore.LightScryptN, keystore.LightScryptP); err != nil {
+		return nil, err
+	}
+	if selected.ChatKey != nil && selected.ChatKey.Address != selected.Address {
+		if keys.chatKey, err = keystore.EncryptKey(selected.ChatKey, passphrase, keystore.LightScryptN, keystore.LightScryptP); err != nil {
+			return nil, err
+		}
+	}
+	if keys.export, err = newExportKey(passphrase, s.scryptN); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// authenticate returns the selected account if password decrypts its key.
+func (s *Service) authenticate(password string) (*account.SelectedExtKey, error) {
+	selected, err := s.am.SelectedAccount()
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := s.am.AddressToDecryptedAccount(selected.Address.Hex(), password); err != nil {
+		return nil, err
+	}
+	return selected, nil
+}
+
+// CreateBackup returns a backup of the identity key and of the data of the sources of the
+// selected account, encrypted with passphrase. password must decrypt the key of the account.
+func (s *Service) CreateBackup(passphrase, password string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, ErrEmptyPassphrase
+	}
+	selected, err := s.authenticate(password)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.newBackupKeys(selected, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return s.createBackup(keys)
+}
+
+// createBackup returns a backup of the selected account encrypted with keys.
+func (s *Service) createBackup(keys *backupKeys) ([]byte, error) {
+	selected, err := s.am.SelectedAccount()
+	if err != nil {
+		return nil, err
+	}
+	if selected.Address != keys.address {
+		return nil, ErrBackupAccountChanged
+	}
+	identity, err := selected.ChatPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	backup := Backup{
+		Version:   backupVersion,
+		CreatedAt: time.Now().UTC(),
+		Account: AccountData{
+			Address:       selected.Address.Hex(),
+			PublicKey:     hexutil.Encode(crypto.FromECDSAPub(&selected.AccountKey.PrivateKey.PublicKey)),
+			ChatPublicKey: hexutil.Encode(crypto.FromECDSAPub(&identity.PublicKey)),
+			SubAccounts:   []string{},
+		},
+		Key:     keys.key,
+		ChatKey: keys.chatKey,
+	}
+	for _, sub := range selected.SubAccounts {
+		backup.Account.SubAccounts = append(backup.Account.SubAccounts, sub.Address.Hex())
+	}
+	if backup.Sections, err = s.backupSections(identity); err != nil {
+		return nil, err
+	}
+
+	plain, err := json.Marshal(backup)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := keys.export.encrypt(plain)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encrypted)
+}
+
+func (s *Service) backupSections(identity *ecdsa.PrivateKey) (map[string]json.RawMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sections := make(map[string]json.RawMessage)
+	for name, source := range s.backupSources {
+		section, err := source.Backup(identity)
+		if err != nil {
+			return nil, err
+		}
+		if sections[name], err = json.Marshal(section); err != nil {
+			return nil, err
+		}
+	}
+	return sections, nil
+}
+
+// RestoreBackup imports the keys of the account of a backup created by CreateBackup into the
+// keystore, encrypted with password, logs in the account and restores the data of the sources.
+// Sections without a source are ignored. It returns the address of the account.
+func (s *Service) RestoreBackup(content []byte, passphrase, password string) (string, error) {
+	plain, err := DecryptUserData(content, passphrase)
+	if err != nil {
+		return "", err
+	}
+	var backup Backup
+	if err := json.Unmarshal(plain, &backup); err != nil {
+		return "", err
+	}
+	if backup.Version != backupVersion {
+		return "", ErrUnsupportedBackup
+	}
+
+	address, _, err := s.am.ImportAccount(backup.Key, passphrase, password)
+	if err != nil {
+		return "", err
+	}
+	if len(backup.ChatKey) > 0 {
+		if err := s.am.ImportChatKey(address, backup.ChatKey, passphrase, password); err != nil {
+			return "", err
+		}
+	}
+	if err := s.login(address, password); err != nil {
+		return "", err
+	}
+	selected, err := s.am.SelectedAccount()
+	if err != nil {
+		return "", err
+	}
+	identity, err := selected.ChatPrivateKey()
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for name, section := range backup.Sections {
+		source, ok := s.backupSources[name]
+		if !ok {
+			continue
+		}
+		if err := source.RestoreBackup(identity, section); err != nil {
+			return "", err
+		}
+	}
+	return address, nil
+}
+
+// login logs in the account with the LoginFunc set by SetLogin, or as status_login does.
+func (s *Service) login(address, password string) error {
+	s.mu.RLock()
+	login := s.loginFunc
+	s.mu.RUnlock()
+	if login != nil {
+		return login(address, password)
+	}
+
+	_, err := s.selectAccount(address, password)
+	return err
+}
+
+// backupJob is the name of the job of the scheduled backups.
+const backupJob = "status/backup"
+
+// ScheduleBackups creates a backup of the selected account encrypted with passphrase every
+// interval, when the device is charging and connected to WiFi, and sends it to the client in
+// a backup.created signal. password must decrypt the key of the account. The passphrase is
+// not kept: the key files and the key of the backups are derived from it once, and kept in
+// memory until the backups are cancelled. An empty passphrase or a zero interval cancels
+// the scheduled backups.
+func (s *Service) ScheduleBackups(passphrase, password string, interval time.Duration) error {
+	s.mu.RLock()
+	sched := s.scheduler
+	s.mu.RUnlock()
+	if sched == nil {
+		return ErrSchedulerUnavailable
+	}
+	if passphrase == "" || interval <= 0 {
+		sched.Remove(backupJob)
+		return nil
+	}
+	selected, err := s.authenticate(password)
+	if err != nil {
+		return err
+	}
+	keys, err := s.newBackupKeys(selected, passphrase)
+	if err != nil {
+		return err
+	}
+	return sched.Add(scheduler.Job{
+		Name:        backupJob,
+		Interval:    interval,
+		Jitter:      interval / 10,
+		Constraints: scheduler.Constraints{RequiresCharging: true, RequiresWiFi: true},
+		Run: func() error {
+			backup, err := s.createBackup(keys)
+			if err != nil {
+				return err
+			}
+			signal.SendBackupCreated(hexutil.Encode(backup))
+			return nil
+		},
+	})
+}
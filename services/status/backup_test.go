@@ -0,0 +1,184 @@
+package status
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/golang/mock/gomock"
+	"github.com/status-im/status-go/account"
+	"github.com/status-im/status-go/services/scheduler"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+type backupSource struct {
+	data     interface{}
+	restored json.RawMessage
+	identity *ecdsa.PrivateKey
+}
+
+func (s *backupSource) Backup(identity *ecdsa.PrivateKey) (interface{}, error) {
+	s.identity = identity
+	return s.data, nil
+}
+
+func (s *backupSource) RestoreBackup(identity *ecdsa.PrivateKey, data json.RawMessage) error {
+	s.identity = identity
+	s.restored = data
+	return nil
+}
+
+func TestBackup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+	chatKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	selected := &account.SelectedExtKey{
+		Address:    address,
+		AccountKey: &keystore.Key{Address: address, PrivateKey: privateKey},
+		ChatKey:    &keystore.Key{Address: crypto.PubkeyToAddress(chatKey.PublicKey), PrivateKey: chatKey},
+	}
+	am := NewMockAccountManager(ctrl)
+	am.EXPECT().SelectedAccount().Return(selected, nil).AnyTimes()
+	am.EXPECT().AddressToDecryptedAccount(address.Hex(), "password").Return(accounts.Account{}, selected.AccountKey, nil).AnyTimes()
+	am.EXPECT().AddressToDecryptedAccount(address.Hex(), "wrong").Return(accounts.Account{}, nil, keystore.ErrDecrypt).AnyTimes()
+
+	service := New(NewMockWhisperService(ctrl))
+	service.SetAccountManager(am)
+	service.scryptN = keystore.LightScryptN
+	source := &backupSource{data: map[string]string{"hello": "world"}}
+	service.AddBackupSource("chat", source)
+
+	_, err = service.CreateBackup("", "password")
+	require.Equal(t, ErrEmptyPassphrase, err)
+	_, err = service.CreateBackup("secret", "wrong")
+	require.Equal(t, keystore.ErrDecrypt, err, "The password of the account is required")
+	backup, err := service.CreateBackup("secret", "password")
+	require.NoError(t, err)
+	require.Equal(t, chatKey, source.identity, "Sources are backed up with the chat key")
+
+	_, err = service.RestoreBackup(backup, "wrong", "password")
+	require.Equal(t, ErrInvalidPassphrase, err)
+
+	am.EXPECT().ImportAccount(gomock.Any(), "secret", "password").DoAndReturn(
+		func(keyJSON []byte, passphrase, password string) (string, string, error) {
+			key, err := keystore.DecryptKey(keyJSON, passphrase)
+			require.NoError(t, err)
+			require.Equal(t, privateKey.D, key.PrivateKey.D)
+			return key.Address.Hex(), "", nil
+		})
+	am.EXPECT().ImportChatKey(address.Hex(), gomock.Any(), "secret", "password").DoAndReturn(
+		func(address string, keyJSON []byte, passphrase, password string) error {
+			key, err := keystore.DecryptKey(keyJSON, passphrase)
+			require.NoError(t, err)
+			require.Equal(t, chatKey.D, key.PrivateKey.D)
+			return nil
+		})
+	var loggedIn []string
+	service.SetLogin(func(address, password string) error {
+		loggedIn = append(loggedIn, address, password)
+		return nil
+	})
+
+	restoredSource := &backupSource{}
+	service.AddBackupSource("chat", restoredSource)
+	restored, err := service.RestoreBackup(backup, "secret", "password")
+	require.NoError(t, err)
+	require.Equal(t, address.Hex(), restored)
+	require.Equal(t, []string{address.Hex(), "password"}, loggedIn)
+	require.JSONEq(t, `{"hello":"world"}`, string(restoredSource.restored))
+	require.Equal(t, chatKey, restoredSource.identity)
+}
+
+func TestScheduleBackups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+	selected := &account.SelectedExtKey{
+		Address:    address,
+		AccountKey: &keystore.Key{Address: address, PrivateKey: privateKey},
+	}
+	am := NewMockAccountManager(ctrl)
+	am.EXPECT().SelectedAccount().Return(selected, nil).AnyTimes()
+	am.EXPECT().AddressToDecryptedAccount(address.Hex(), "password").Return(accounts.Account{}, selected.AccountKey, nil).AnyTimes()
+	am.EXPECT().AddressToDecryptedAccount(address.Hex(), "wrong").Return(accounts.Account{}, nil, keystore.ErrDecrypt).AnyTimes()
+
+	service := New(NewMockWhisperService(ctrl))
+	service.SetAccountManager(am)
+	service.scryptN = keystore.LightScryptN
+	require.Equal(t, ErrSchedulerUnavailable, service.ScheduleBackups("secret", "password", time.Hour))
+
+	level, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+	defer level.Close()
+	sched, err := scheduler.NewScheduler(level)
+	require.NoError(t, err)
+	service.SetScheduler(sched)
+
+	require.Equal(t, keystore.ErrDecrypt, service.ScheduleBackups("secret", "wrong", 24*time.Hour))
+	require.Empty(t, sched.Jobs(), "The password of the account is required")
+
+	require.NoError(t, service.ScheduleBackups("secret", "password", 24*time.Hour))
+	jobs := sched.Jobs()
+	require.Len(t, jobs, 1)
+	require.Equal(t, backupJob, jobs[0].Name)
+	require.Equal(t, int64(24*60*60), jobs[0].Interval)
+	require.Equal(t, scheduler.Constraints{RequiresCharging: true, RequiresWiFi: true}, jobs[0].Constraints)
+
+	require.NoError(t, service.ScheduleBackups("", "", 24*time.Hour))
+	require.Empty(t, sched.Jobs(), "An empty passphrase cancels the backups")
+}
+
+func TestScheduledBackupKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+	selected := &account.SelectedExtKey{
+		Address:    address,
+		AccountKey: &keystore.Key{Address: address, PrivateKey: privateKey},
+	}
+	am := NewMockAccountManager(ctrl)
+	am.EXPECT().SelectedAccount().Return(selected, nil).Times(1)
+
+	service := New(NewMockWhisperService(ctrl))
+	service.SetAccountManager(am)
+	service.scryptN = keystore.LightScryptN
+	keys, err := service.newBackupKeys(selected, "secret")
+	require.NoError(t, err)
+
+	backup, err := service.createBackup(keys)
+	require.NoError(t, err, "Backups are created without the passphrase")
+	plain, err := DecryptUserData(backup, "secret")
+	require.NoError(t, err)
+	var decoded Backup
+	require.NoError(t, json.Unmarshal(plain, &decoded))
+	key, err := keystore.DecryptKey(decoded.Key, "secret")
+	require.NoError(t, err)
+	require.Equal(t, privateKey.D, key.PrivateKey.D)
+
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	otherAddress := crypto.PubkeyToAddress(other.PublicKey)
+	am.EXPECT().SelectedAccount().Return(&account.SelectedExtKey{
+		Address:    otherAddress,
+		AccountKey: &keystore.Key{Address: otherAddress, PrivateKey: other},
+	}, nil)
+	_, err = service.createBackup(keys)
+	require.Equal(t, ErrBackupAccountChanged, err)
+}
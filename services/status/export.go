@@ -0,0 +1,245 @@
+package status
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// userDataVersion is the version of the format of the exports of the user data.
+	userDataVersion = 1
+
+	exportCipher  = "aes-256-gcm"
+	exportKDF     = "scrypt"
+	exportScryptR = 8
+	exportScryptP = 1
+	exportKeyLen  = 32
+)
+
+var (
+	// ErrEmptyPassphrase is returned when exporting the user data without a passphrase.
+	ErrEmptyPassphrase = errors.New("passphrase is empty")
+	// ErrUnsupportedExport is returned when decrypting an export of an unknown version.
+	ErrUnsupportedExport = errors.New("unsupported export version")
+	// ErrInvalidPassphrase is returned when an export can't be decrypted with a passphrase.
+	ErrInvalidPassphrase = errors.New("invalid passphrase")
+)
+
+// UserDataSource provides a section of the exports of the user data.
+type UserDataSource interface {
+	// ExportUserData returns the data of the selected account, encoded to JSON in the export.
+	ExportUserData() (interface{}, error)
+}
+
+// AccountData holds the metadata of the selected account.
+type AccountData struct {
+	Address   string `json:"address"`
+	PublicKey string `json:"publicKey"`
+	// ChatPublicKey is the public key of the chat identity of the account.
+	ChatPublicKey string   `json:"chatPublicKey"`
+	SubAccounts   []string `json:"subAccounts"`
+}
+
+// UserData is the decrypted content of an export of the user data.
+type UserData struct {
+	Version   int         `json:"version"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Account   AccountData `json:"account"`
+	// Sections hold the data of the services, by name.
+	Sections map[string]interface{} `json:"sections"`
+}
+
+// EncryptedUserData is the content of an export file.
+type EncryptedUserData struct {
+	Version int          `json:"version"`
+	Crypto  ExportCrypto `json:"crypto"`
+}
+
+// ExportCrypto describes how the user data is encrypted. The key is derived from
+// the passphrase with scrypt, and the data is encrypted with AES-256-GCM.
+type ExportCrypto struct {
+	Cipher     string        `json:"cipher"`
+	CipherText hexutil.Bytes `json:"ciphertext"`
+	Nonce      hexutil.Bytes `json:"nonce"`
+	KDF        string        `json:"kdf"`
+	KDFParams  ScryptParams  `json:"kdfparams"`
+}
+
+// ScryptParams are the parameters of the derivation of the key from the passphrase.
+type ScryptParams struct {
+	N     int           `json:"n"`
+	R     int           `json:"r"`
+	P     int           `json:"p"`
+	DKLen int           `json:"dklen"`
+	Salt  hexutil.Bytes `json:"salt"`
+}
+
+// exportUserData writes the data of the selected account and of the sources to a new
+// file at path, encrypted with passphrase. Existing files are never overwritten.
+func (s *Service) exportUserData(passphrase string, path string) error {
+	if passphrase == "" {
+		return ErrEmptyPassphrase
+	}
+	selected, err := s.am.SelectedAccount()
+	if err != nil {
+		return err
+	}
+
+	data := UserData{
+		Version:   userDataVersion,
+		CreatedAt: time.Now().UTC(),
+		Account: AccountData{
+			Address:     selected.Address.Hex(),
+			SubAccounts: []string{},
+		},
+		Sections: make(map[string]interface{}),
+	}
+	if selected.AccountKey != nil && selected.AccountKey.PrivateKey != nil {
+		data.Account.PublicKey = hexutil.Encode(crypto.FromECDSAPub(&selected.AccountKey.PrivateKey.PublicKey))
+		data.Account.ChatPublicKey = data.Account.PublicKey
+	} else if selected.External != nil {
+		data.Account.PublicKey = selected.External.PublicKey.String()
+		data.Account.ChatPublicKey = data.Account.PublicKey
+	}
+	if selected.ChatKey != nil {
+		data.Account.ChatPublicKey = hexutil.Encode(crypto.FromECDSAPub(&selected.ChatKey.PrivateKey.PublicKey))
+	}
+	for _, sub := range selected.SubAccounts {
+		data.Account.SubAccounts = append(data.Account.SubAccounts, sub.Address.Hex())
+	}
+
+	s.mu.RLock()
+	for name, source := range s.sources {
+		section, err := source.ExportUserData()
+		if err != nil {
+			s.mu.RUnlock()
+			return err
+		}
+		data.Sections[name] = section
+	}
+	s.mu.RUnlock()
+
+	plain, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	encrypted, err := encryptUserData(plain, passphrase, s.scryptN)
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(encrypted, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+func encryptUserData(plain []byte, passphrase string, scryptN int) (*EncryptedUserData, error) {
+	key, err := newExportKey(passphrase, scryptN)
+	if err != nil {
+		return nil, err
+	}
+	return key.encrypt(plain)
+}
+
+// exportKey is the key derived from a passphrase to encrypt user data, with the
+// parameters it was derived with.
+type exportKey struct {
+	params ScryptParams
+	aead   cipher.AEAD
+}
+
+// newExportKey derives a key from passphrase with a random salt.
+func newExportKey(passphrase string, scryptN int) (*exportKey, error) {
+	params := ScryptParams{
+		N:     scryptN,
+		R:     exportScryptR,
+		P:     exportScryptP,
+		DKLen: exportKeyLen,
+		Salt:  make([]byte, 32),
+	}
+	if _, err := rand.Read(params.Salt); err != nil {
+		return nil, err
+	}
+	aead, err := exportAEAD(passphrase, params)
+	if err != nil {
+		return nil, err
+	}
+	return &exportKey{params: params, aead: aead}, nil
+}
+
+// encrypt encrypts plain with a random nonce.
+func (k *exportKey) encrypt(plain []byte) (*EncryptedUserData, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &EncryptedUserData{
+		Version: userDataVersion,
+		Crypto: ExportCrypto{
+			Cipher:     exportCipher,
+			CipherText: k.aead.Seal(nil, nonce, plain, nil),
+			Nonce:      nonce,
+			KDF:        exportKDF,
+			KDFParams:  k.params,
+		},
+	}, nil
+}
+
+// DecryptUserData decrypts the content of an export file with its passphrase,
+// and returns the user data encoded to JSON.
+func DecryptUserData(content []byte, passphrase string) ([]byte, error) {
+	var encrypted EncryptedUserData
+	if err := json.Unmarshal(content, &encrypted); err != nil {
+		return nil, err
+	}
+	if encrypted.Version != userDataVersion || encrypted.Crypto.Cipher != exportCipher || encrypted.Crypto.KDF != exportKDF {
+		return nil, ErrUnsupportedExport
+	}
+	aead, err := exportAEAD(passphrase, encrypted.Crypto.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted.Crypto.Nonce) != aead.NonceSize() {
+		return nil, ErrUnsupportedExport
+	}
+	plain, err := aead.Open(nil, encrypted.Crypto.Nonce, encrypted.Crypto.CipherText, nil)
+	if err != nil {
+		return nil, ErrInvalidPassphrase
+	}
+	return plain, nil
+}
+
+func exportAEAD(passphrase string, params ScryptParams) (cipher.AEAD, error) {
+	if params.DKLen != exportKeyLen {
+		return nil, ErrUnsupportedExport
+	}
+	key, err := scrypt.Key([]byte(passphrase), params.Salt, params.N, params.R, params.P, params.DKLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
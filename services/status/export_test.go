@@ -0,0 +1,85 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/golang/mock/gomock"
+	"github.com/status-im/status-go/account"
+	"github.com/stretchr/testify/require"
+)
+
+type userDataSource struct {
+	data interface{}
+	err  error
+}
+
+func (s userDataSource) ExportUserData() (interface{}, error) {
+	return s.data, s.err
+}
+
+func TestExportUserData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "status-export")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	selected := &account.SelectedExtKey{
+		Address:     crypto.PubkeyToAddress(privateKey.PublicKey),
+		AccountKey:  &keystore.Key{PrivateKey: privateKey},
+		SubAccounts: []accounts.Account{{Address: common.HexToAddress("0x01")}},
+	}
+	am := NewMockAccountManager(ctrl)
+	am.EXPECT().SelectedAccount().Return(selected, nil).AnyTimes()
+
+	service := New(NewMockWhisperService(ctrl))
+	service.SetAccountManager(am)
+	service.scryptN = keystore.LightScryptN
+	service.AddUserDataSource("chat", userDataSource{data: map[string]string{"hello": "world"}})
+
+	path := filepath.Join(dir, "export.json")
+	require.Equal(t, ErrEmptyPassphrase, service.exportUserData("", path))
+	require.NoError(t, service.exportUserData("secret", path))
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+	require.True(t, os.IsExist(service.exportUserData("secret", path)), "Existing files are not overwritten")
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	_, err = DecryptUserData(content, "wrong")
+	require.Equal(t, ErrInvalidPassphrase, err)
+	plain, err := DecryptUserData(content, "secret")
+	require.NoError(t, err)
+
+	var data struct {
+		Version  int                          `json:"version"`
+		Account  AccountData                  `json:"account"`
+		Sections map[string]map[string]string `json:"sections"`
+	}
+	require.NoError(t, json.Unmarshal(plain, &data))
+	require.Equal(t, userDataVersion, data.Version)
+	require.Equal(t, selected.Address.Hex(), data.Account.Address)
+	require.Equal(t, []string{common.HexToAddress("0x01").Hex()}, data.Account.SubAccounts)
+	require.NotEmpty(t, data.Account.PublicKey)
+	require.Equal(t, data.Account.PublicKey, data.Account.ChatPublicKey, "Accounts without a chat key use their key")
+	require.Equal(t, map[string]string{"hello": "world"}, data.Sections["chat"])
+
+	failing := errors.New("failing source")
+	service.AddUserDataSource("other", userDataSource{err: failing})
+	other := filepath.Join(dir, "other.json")
+	require.Equal(t, failing, service.exportUserData("secret", other))
+	_, err = os.Stat(other)
+	require.True(t, os.IsNotExist(err), "Nothing is written if a source fails")
+}
@@ -0,0 +1,34 @@
+package status
+
+import (
+	"github.com/status-im/status-go/services/shhext/latency"
+)
+
+// LatencySource provides the delivery latencies of the received messages.
+type LatencySource interface {
+	// LatencyStats returns the latencies by network type, or nil if they are not measured.
+	LatencyStats() []latency.Stats
+}
+
+// SetLatencySource sets the source of the delivery latencies returned by status_getLatencyStats.
+func (s *Service) SetLatencySource(source LatencySource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latencySource = source
+}
+
+// latencyStats returns the delivery latencies of the received messages by network type.
+// It's empty if there is no source or if the latencies are not measured.
+func (s *Service) latencyStats() []latency.Stats {
+	s.mu.RLock()
+	source := s.latencySource
+	s.mu.RUnlock()
+	if source == nil {
+		return []latency.Stats{}
+	}
+	stats := source.LatencyStats()
+	if stats == nil {
+		return []latency.Stats{}
+	}
+	return stats
+}
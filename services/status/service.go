@@ -2,12 +2,15 @@ package status
 
 import (
 	"crypto/ecdsa"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/account"
+	"github.com/status-im/status-go/services/scheduler"
 )
 
 // Make sure that Service implements node.Service interface.
@@ -20,20 +23,37 @@ type WhisperService interface {
 
 // AccountManager interface to manage account actions
 type AccountManager interface {
-	AddressToDecryptedAccount(string, string) (accounts.Account, *keystore.Key, error)
 	SelectAccount(address, password string) error
 	CreateAccount(password string) (address, pubKey, mnemonic string, err error)
+	SelectedAccount() (*account.SelectedExtKey, error)
+	AccountKeys(address string) (*account.AccountKeys, error)
+	ImportAccount(keyJSON []byte, passphrase, password string) (address, pubKey string, err error)
+	ImportChatKey(address string, keyJSON []byte, passphrase, password string) error
+	AddressToDecryptedAccount(address, password string) (accounts.Account, *keystore.Key, error)
 }
 
 // Service represents our own implementation of status status operations.
 type Service struct {
 	am AccountManager
 	w  WhisperService
+
+	mu            sync.RWMutex
+	sources       map[string]UserDataSource
+	backupSources map[string]BackupSource
+	latencySource LatencySource
+	loginFunc     LoginFunc
+	scheduler     *scheduler.Scheduler
+	scryptN       int
 }
 
 // New returns a new Service.
 func New(w WhisperService) *Service {
-	return &Service{w: w}
+	return &Service{
+		w:             w,
+		sources:       make(map[string]UserDataSource),
+		backupSources: make(map[string]BackupSource),
+		scryptN:       keystore.StandardScryptN,
+	}
 }
 
 // Protocols returns a new protocols list. In this case, there are none.
@@ -54,11 +74,57 @@ func (s *Service) APIs() []rpc.API {
 	}
 }
 
+// selectAccount selects an account and adds its chat key to Whisper. It returns the ID
+// of the key pair in Whisper.
+func (s *Service) selectAccount(address, password string) (string, error) {
+	if err := s.am.SelectAccount(address, password); err != nil {
+		return "", err
+	}
+	selected, err := s.am.SelectedAccount()
+	if err != nil {
+		return "", err
+	}
+	identity, err := selected.ChatPrivateKey()
+	if err != nil {
+		return "", err
+	}
+	return s.w.AddKeyPair(identity)
+}
+
 // SetAccountManager sets account manager for the API calls.
 func (s *Service) SetAccountManager(a AccountManager) {
 	s.am = a
 }
 
+// SetScheduler sets the scheduler running the scheduled backups.
+func (s *Service) SetScheduler(sched *scheduler.Scheduler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scheduler = sched
+}
+
+// AddUserDataSource adds a section to the exports of the user data, under name.
+func (s *Service) AddUserDataSource(name string, source UserDataSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sources[name] = source
+}
+
+// AddBackupSource adds a section to the backups of the account, under name.
+func (s *Service) AddBackupSource(name string, source BackupSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.backupSources[name] = source
+}
+
+// SetLogin sets how the account of a backup is logged in before its sections are restored.
+// By default, it is logged in as status_login does.
+func (s *Service) SetLogin(login LoginFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loginFunc = login
+}
+
 // Start is run when a service is started.
 // It does nothing in this case but is required by `node.Service` interface.
 func (s *Service) Start(server *p2p.Server) error {
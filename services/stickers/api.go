@@ -0,0 +1,85 @@
+package stickers
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/transactions"
+)
+
+// API exposes the sticker packs over RPC.
+type API struct {
+	s *Service
+}
+
+// NewAPI returns a new API.
+func NewAPI(s *Service) *API {
+	return &API{s: s}
+}
+
+func (api *API) manager() (*Manager, error) {
+	if api.s.manager == nil {
+		return nil, ErrUnsupportedNetwork
+	}
+	return api.s.manager, nil
+}
+
+// Packs returns the packs registered in the market, with their metadata.
+func (api *API) Packs(ctx context.Context) ([]Pack, error) {
+	manager, err := api.manager()
+	if err != nil {
+		return nil, err
+	}
+	return manager.Packs(ctx)
+}
+
+// Installed returns the packs installed by the selected account.
+func (api *API) Installed(ctx context.Context) ([]Pack, error) {
+	manager, err := api.manager()
+	if err != nil {
+		return nil, err
+	}
+	return manager.Installed()
+}
+
+// Install downloads a pack and installs it for the selected account.
+func (api *API) Install(ctx context.Context, id uint64) (*Pack, error) {
+	manager, err := api.manager()
+	if err != nil {
+		return nil, err
+	}
+	return manager.Install(ctx, id)
+}
+
+// Uninstall uninstalls a pack for the selected account.
+func (api *API) Uninstall(ctx context.Context, id uint64) error {
+	manager, err := api.manager()
+	if err != nil {
+		return err
+	}
+	return manager.Uninstall(id)
+}
+
+// Content returns an image of a pack by content hash.
+func (api *API) Content(ctx context.Context, hash hexutil.Bytes) (hexutil.Bytes, error) {
+	manager, err := api.manager()
+	if err != nil {
+		return nil, err
+	}
+	content, err := manager.Content(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// BuyTransaction returns the transaction with which an address buys a pack, to be signed
+// and sent with eth_sendTransaction.
+func (api *API) BuyTransaction(ctx context.Context, id uint64, from common.Address) (*transactions.SendTxArgs, error) {
+	manager, err := api.manager()
+	if err != nil {
+		return nil, err
+	}
+	return manager.BuyTransaction(ctx, id, from)
+}
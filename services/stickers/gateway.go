@@ -0,0 +1,104 @@
+package stickers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	multihash "github.com/multiformats/go-multihash"
+)
+
+const (
+	// DefaultGatewayURL is the IPFS gateway used when none is configured.
+	DefaultGatewayURL = "https://ipfs.infura.io/ipfs/"
+
+	// defaultGatewayTimeout is the time we wait for a download before giving up.
+	defaultGatewayTimeout = time.Minute
+)
+
+var (
+	// ErrInvalidGatewayURL is returned when creating a gateway without an absolute URL.
+	ErrInvalidGatewayURL = errors.New("invalid IPFS gateway URL")
+	// ErrUnsupportedContentHash is returned for content hashes that are not EIP-1577 IPFS hashes.
+	ErrUnsupportedContentHash = errors.New("unsupported content hash")
+	// ErrTooLarge is returned when content downloaded from the gateway exceeds its maximum size.
+	ErrTooLarge = errors.New("content is too large")
+)
+
+// ipfsPrefix is the EIP-1577 prefix of the content hashes of IPFS files: the ipfs-ns
+// namespace, CIDv1 and the dag-pb codec, followed by the multihash of the file.
+var ipfsPrefix = []byte{0xe3, 0x01, 0x01, 0x70}
+
+// CID returns the CIDv0 of the file of an EIP-1577 IPFS content hash.
+func CID(contentHash []byte) (string, error) {
+	if !bytes.HasPrefix(contentHash, ipfsPrefix) {
+		return "", ErrUnsupportedContentHash
+	}
+	hash, err := multihash.Cast(contentHash[len(ipfsPrefix):])
+	if err != nil {
+		return "", ErrUnsupportedContentHash
+	}
+	return hash.B58String(), nil
+}
+
+// Gateway downloads files from an IPFS HTTP gateway.
+type Gateway struct {
+	url    string
+	client *http.Client
+}
+
+// NewGateway returns a new Gateway of the gateway at rawURL.
+func NewGateway(rawURL string) (*Gateway, error) {
+	if u, err := url.Parse(rawURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, ErrInvalidGatewayURL
+	}
+	return &Gateway{
+		url:    strings.TrimSuffix(rawURL, "/"),
+		client: &http.Client{Timeout: defaultGatewayTimeout},
+	}, nil
+}
+
+// URL returns the URL of the file of a content hash on the gateway.
+func (g *Gateway) URL(contentHash []byte) (string, error) {
+	cid, err := CID(contentHash)
+	if err != nil {
+		return "", err
+	}
+	return g.url + "/" + cid, nil
+}
+
+// Download returns the file of a content hash, reading at most maxSize bytes.
+func (g *Gateway) Download(ctx context.Context, contentHash []byte, maxSize int64) ([]byte, error) {
+	u, err := g.URL(contentHash)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("IPFS gateway returned status %d", resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxSize {
+		return nil, ErrTooLarge
+	}
+	return body, nil
+}
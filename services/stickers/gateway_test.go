@@ -0,0 +1,46 @@
+package stickers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCID(t *testing.T) {
+	// Example of EIP-1577.
+	cid, err := CID(hexutil.MustDecode("0xe3010170122029f2d17be6139079dc48696d1f582a8530eb9805b561eda517e22a892c7e3f1f"))
+	require.NoError(t, err)
+	require.Equal(t, "QmRAQB6YaCyidP37UdDnjFY5vQuiBrcqdyoW1CuDgwxkD4", cid)
+
+	_, err = CID(hexutil.MustDecode("0xe40101fa011b20d1de9994b4d039f6548d191eb26786769f580809256b4685ef316805265ea162"))
+	require.Equal(t, ErrUnsupportedContentHash, err, "Swarm hashes are not supported")
+	_, err = CID(hexutil.MustDecode("0xe30101701220"))
+	require.Equal(t, ErrUnsupportedContentHash, err)
+}
+
+func TestGateway(t *testing.T) {
+	_, err := NewGateway("ipfs.infura.io")
+	require.Equal(t, ErrInvalidGatewayURL, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ipfs/QmRAQB6YaCyidP37UdDnjFY5vQuiBrcqdyoW1CuDgwxkD4" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("content")) // nolint: errcheck
+	}))
+	defer server.Close()
+
+	gateway, err := NewGateway(server.URL + "/ipfs/")
+	require.NoError(t, err)
+	hash := hexutil.MustDecode("0xe3010170122029f2d17be6139079dc48696d1f582a8530eb9805b561eda517e22a892c7e3f1f")
+	content, err := gateway.Download(context.Background(), hash, 10)
+	require.NoError(t, err)
+	require.Equal(t, []byte("content"), content)
+	_, err = gateway.Download(context.Background(), hash, 3)
+	require.Equal(t, ErrTooLarge, err)
+}
@@ -0,0 +1,408 @@
+package stickers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/status-im/status-go/params"
+	"github.com/status-im/status-go/transactions"
+)
+
+const (
+	// MaxMetadataSize is the maximum size of the metadata of a pack.
+	MaxMetadataSize = 256 * 1024
+	// MaxContentSize is the maximum size of a sticker, a thumbnail or a preview.
+	MaxContentSize = 1024 * 1024
+	// MaxStickers is the maximum number of stickers of a pack.
+	MaxStickers = 100
+)
+
+var (
+	// ErrUnsupportedNetwork is returned on networks without sticker market.
+	ErrUnsupportedNetwork = errors.New("the sticker market is not deployed on the network")
+	// ErrNoAccountSelected is returned when installing packs without a selected account.
+	ErrNoAccountSelected = errors.New("no account selected")
+	// ErrUnknownPack is returned for packs that are not registered in the market.
+	ErrUnknownPack = errors.New("unknown sticker pack")
+	// ErrPackNotMintable is returned when buying a pack whose sale is stopped.
+	ErrPackNotMintable = errors.New("sticker pack can't be bought")
+	// ErrInvalidMetadata is returned for packs whose metadata can't be decoded.
+	ErrInvalidMetadata = errors.New("invalid sticker pack metadata")
+)
+
+// Contracts are the addresses of the sticker market of a network and of the token it's
+// paid with.
+type Contracts struct {
+	Market common.Address
+	Token  common.Address
+}
+
+// markets are the contracts of the sticker market on each network.
+var markets = map[uint64]Contracts{
+	params.MainNetworkID: {
+		Market: common.HexToAddress("0x0577215622f43a39f4bc9640806dfea9b10d2a36"),
+		Token:  common.HexToAddress("0x744d70fdbe2ba4cf95131626614a1763df805b9e"),
+	},
+}
+
+// marketABI is the part of the ABI of the sticker market read by the manager.
+const marketABI = `[
+{"constant":true,"inputs":[],"name":"packCount","outputs":[{"name":"","type":"uint256"}],"type":"function"},
+{"constant":true,"inputs":[{"name":"_packId","type":"uint256"}],"name":"getPackData","outputs":[{"name":"category","type":"bytes4[]"},{"name":"owner","type":"address"},{"name":"mintable","type":"bool"},{"name":"timestamp","type":"uint256"},{"name":"price","type":"uint256"},{"name":"contenthash","type":"bytes"}],"type":"function"},
+{"constant":false,"inputs":[{"name":"_packId","type":"uint256"},{"name":"_destination","type":"address"},{"name":"_price","type":"uint256"}],"name":"buyToken","outputs":[{"name":"tokenId","type":"uint256"}],"type":"function"}
+]`
+
+// tokenABI is the approveAndCall method of the token, with which packs are bought in a
+// single transaction.
+const tokenABI = `[{"constant":false,"inputs":[{"name":"_spender","type":"address"},{"name":"_amount","type":"uint256"},{"name":"_extraData","type":"bytes"}],"name":"approveAndCall","outputs":[{"name":"success","type":"bool"}],"type":"function"}]`
+
+// Sticker is a sticker of a pack.
+type Sticker struct {
+	// Hash is the EIP-1577 content hash of the image of the sticker.
+	Hash hexutil.Bytes `json:"hash"`
+}
+
+// Pack is a sticker pack registered in the market, with its metadata.
+type Pack struct {
+	ID        uint64         `json:"id"`
+	Owner     common.Address `json:"owner"`
+	Mintable  bool           `json:"mintable"`
+	Timestamp int64          `json:"timestamp"`
+	// Price is the price of the pack, in the smallest unit of the token.
+	Price *hexutil.Big `json:"price"`
+	// ContentHash is the EIP-1577 content hash of the metadata of the pack.
+	ContentHash hexutil.Bytes `json:"contentHash"`
+	Name        string        `json:"name"`
+	Author      string        `json:"author"`
+	Thumbnail   hexutil.Bytes `json:"thumbnail"`
+	Preview     hexutil.Bytes `json:"preview"`
+	Stickers    []Sticker     `json:"stickers"`
+	// InstalledAt is the time the pack was installed by the account, in seconds, or zero
+	// if it's not installed.
+	InstalledAt int64 `json:"installedAt"`
+}
+
+// images returns the content hashes of the thumbnail, the preview and the stickers of a pack.
+func images(thumbnail, preview hexutil.Bytes, stickers []Sticker) []hexutil.Bytes {
+	hashes := []hexutil.Bytes{thumbnail, preview}
+	for _, sticker := range stickers {
+		hashes = append(hashes, sticker.Hash)
+	}
+	return hashes
+}
+
+// metadata is the JSON file of a pack, at its content hash.
+type metadata struct {
+	Name      string        `json:"name"`
+	Author    string        `json:"author"`
+	Thumbnail hexutil.Bytes `json:"thumbnail"`
+	Preview   hexutil.Bytes `json:"preview"`
+	Stickers  []Sticker     `json:"stickers"`
+}
+
+func (m *metadata) validate() error {
+	if m.Name == "" || len(m.Stickers) == 0 || len(m.Stickers) > MaxStickers {
+		return ErrInvalidMetadata
+	}
+	for _, hash := range images(m.Thumbnail, m.Preview, m.Stickers) {
+		if _, err := CID(hash); err != nil {
+			return ErrInvalidMetadata
+		}
+	}
+	return nil
+}
+
+// Store keeps the packs installed by the selected account.
+type Store interface {
+	// SaveStickerPack installs a pack on a network with the images of its stickers, by
+	// content hash, replacing the previous installation.
+	SaveStickerPack(networkID uint64, pack Pack, content map[string][]byte) error
+	// GetStickerPacks returns the packs installed on a network, in the order they were installed.
+	GetStickerPacks(networkID uint64) ([]Pack, error)
+	// GetStickerContent returns an image of an installed pack by content hash, or nil if no
+	// installed pack has it.
+	GetStickerContent(networkID uint64, hash []byte) ([]byte, error)
+	// DeleteStickerPack uninstalls a pack and deletes the images of its stickers.
+	DeleteStickerPack(networkID uint64, id uint64) error
+}
+
+// Manager lists the packs of the sticker market of a network, whose content is downloaded
+// through an IPFS gateway, and installs them for the selected account.
+type Manager struct {
+	caller    bind.ContractCaller
+	networkID uint64
+	contracts Contracts
+	gateway   *Gateway
+	market    abi.ABI
+	token     abi.ABI
+
+	mu    sync.RWMutex
+	store Store
+	// metadata are the metadata downloaded from the gateway, by content hash, which
+	// never change.
+	metadata map[string]*metadata
+}
+
+// NewManager returns a new Manager of the market of contracts on a network.
+func NewManager(caller bind.ContractCaller, networkID uint64, contracts Contracts, gateway *Gateway) *Manager {
+	market, err := abi.JSON(strings.NewReader(marketABI))
+	if err != nil {
+		panic(err)
+	}
+	token, err := abi.JSON(strings.NewReader(tokenABI))
+	if err != nil {
+		panic(err)
+	}
+	return &Manager{
+		caller:    caller,
+		networkID: networkID,
+		contracts: contracts,
+		gateway:   gateway,
+		market:    market,
+		token:     token,
+		metadata:  make(map[string]*metadata),
+	}
+}
+
+// SetStore sets the store of the packs installed by the selected account, or nil if no
+// account is selected.
+func (m *Manager) SetStore(store Store) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store = store
+}
+
+func (m *Manager) getStore() Store {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.store
+}
+
+// Packs returns the packs registered in the market. Packs whose metadata can't be
+// downloaded or decoded are skipped.
+func (m *Manager) Packs(ctx context.Context) ([]Pack, error) {
+	installed, err := m.installedAt()
+	if err != nil {
+		return nil, err
+	}
+	contract := bind.NewBoundContract(m.contracts.Market, m.market, m.caller, nil, nil)
+	count := new(big.Int)
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &count, "packCount"); err != nil {
+		return nil, err
+	}
+	result := []Pack{}
+	for id := uint64(0); id < count.Uint64(); id++ {
+		pack, err := m.pack(ctx, id)
+		if err == ErrUnknownPack {
+			continue
+		} else if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			log.Warn("skipping sticker pack", "id", id, "err", err)
+			continue
+		}
+		pack.InstalledAt = installed[id]
+		result = append(result, *pack)
+	}
+	return result, nil
+}
+
+// installedAt returns the time the packs were installed by the selected account, by ID.
+func (m *Manager) installedAt() (map[uint64]int64, error) {
+	result := make(map[uint64]int64)
+	store := m.getStore()
+	if store == nil {
+		return result, nil
+	}
+	packs, err := store.GetStickerPacks(m.networkID)
+	if err != nil {
+		return nil, err
+	}
+	for _, pack := range packs {
+		result[pack.ID] = pack.InstalledAt
+	}
+	return result, nil
+}
+
+// Installed returns the packs installed by the selected account.
+func (m *Manager) Installed() ([]Pack, error) {
+	store := m.getStore()
+	if store == nil {
+		return nil, ErrNoAccountSelected
+	}
+	packs, err := store.GetStickerPacks(m.networkID)
+	if err != nil {
+		return nil, err
+	}
+	if packs == nil {
+		packs = []Pack{}
+	}
+	return packs, nil
+}
+
+// Install downloads the images of a pack and installs it for the selected account, so
+// that its stickers are available offline.
+func (m *Manager) Install(ctx context.Context, id uint64) (*Pack, error) {
+	store := m.getStore()
+	if store == nil {
+		return nil, ErrNoAccountSelected
+	}
+	pack, err := m.pack(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	content := make(map[string][]byte)
+	for _, hash := range images(pack.Thumbnail, pack.Preview, pack.Stickers) {
+		key := hash.String()
+		if _, ok := content[key]; ok {
+			continue
+		}
+		data, err := m.gateway.Download(ctx, hash, MaxContentSize)
+		if err != nil {
+			return nil, err
+		}
+		content[key] = data
+	}
+	pack.InstalledAt = time.Now().Unix()
+	if err := store.SaveStickerPack(m.networkID, *pack, content); err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+// Uninstall uninstalls a pack for the selected account.
+func (m *Manager) Uninstall(id uint64) error {
+	store := m.getStore()
+	if store == nil {
+		return ErrNoAccountSelected
+	}
+	return store.DeleteStickerPack(m.networkID, id)
+}
+
+// Content returns an image of a pack by content hash, from the installed packs or else
+// from the gateway.
+func (m *Manager) Content(ctx context.Context, hash []byte) ([]byte, error) {
+	if store := m.getStore(); store != nil {
+		data, err := store.GetStickerContent(m.networkID, hash)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			return data, nil
+		}
+	}
+	return m.gateway.Download(ctx, hash, MaxContentSize)
+}
+
+// BuyTransaction returns the transaction with which from buys a pack at its current price.
+// The price is approved to the market, which mints the pack, in a single call of the token.
+func (m *Manager) BuyTransaction(ctx context.Context, id uint64, from common.Address) (*transactions.SendTxArgs, error) {
+	data, err := m.packData(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !data.Mintable {
+		return nil, ErrPackNotMintable
+	}
+	buy, err := m.market.Pack("buyToken", new(big.Int).SetUint64(id), from, data.Price)
+	if err != nil {
+		return nil, err
+	}
+	input, err := m.token.Pack("approveAndCall", m.contracts.Market, data.Price, buy)
+	if err != nil {
+		return nil, err
+	}
+	return &transactions.SendTxArgs{
+		From:  from,
+		To:    &m.contracts.Token,
+		Value: (*hexutil.Big)(new(big.Int)),
+		Input: input,
+	}, nil
+}
+
+// packRecord are the outputs of getPackData.
+type packRecord struct {
+	Category    [][4]byte
+	Owner       common.Address
+	Mintable    bool
+	Timestamp   *big.Int
+	Price       *big.Int
+	Contenthash []byte
+}
+
+// packData returns the data of a pack registered in the market.
+func (m *Manager) packData(ctx context.Context, id uint64) (*packRecord, error) {
+	contract := bind.NewBoundContract(m.contracts.Market, m.market, m.caller, nil, nil)
+	data := new(packRecord)
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, data, "getPackData", new(big.Int).SetUint64(id)); err != nil {
+		return nil, err
+	}
+	if data.Owner == (common.Address{}) {
+		return nil, ErrUnknownPack
+	}
+	return data, nil
+}
+
+// pack returns a pack registered in the market, with its metadata.
+func (m *Manager) pack(ctx context.Context, id uint64) (*Pack, error) {
+	data, err := m.packData(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := m.packMetadata(ctx, data.Contenthash)
+	if err != nil {
+		return nil, err
+	}
+	return &Pack{
+		ID:          id,
+		Owner:       data.Owner,
+		Mintable:    data.Mintable,
+		Timestamp:   data.Timestamp.Int64(),
+		Price:       (*hexutil.Big)(data.Price),
+		ContentHash: data.Contenthash,
+		Name:        meta.Name,
+		Author:      meta.Author,
+		Thumbnail:   meta.Thumbnail,
+		Preview:     meta.Preview,
+		Stickers:    meta.Stickers,
+	}, nil
+}
+
+// packMetadata returns the metadata of a pack at a content hash, from the gateway the
+// first time.
+func (m *Manager) packMetadata(ctx context.Context, contentHash []byte) (*metadata, error) {
+	key := hexutil.Encode(contentHash)
+	m.mu.RLock()
+	meta, ok := m.metadata[key]
+	m.mu.RUnlock()
+	if ok {
+		return meta, nil
+	}
+
+	body, err := m.gateway.Download(ctx, contentHash, MaxMetadataSize)
+	if err != nil {
+		return nil, err
+	}
+	meta = new(metadata)
+	if err := json.Unmarshal(body, meta); err != nil {
+		return nil, ErrInvalidMetadata
+	}
+	if err := meta.validate(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	m.metadata[key] = meta
+	m.mu.Unlock()
+	return meta, nil
+}
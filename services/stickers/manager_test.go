@@ -0,0 +1,257 @@
+package stickers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	multihash "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+)
+
+var testContracts = Contracts{
+	Market: common.HexToAddress("0x10"),
+	Token:  common.HexToAddress("0x20"),
+}
+
+// fakeMarket answers the calls of the sticker market.
+type fakeMarket struct {
+	abi   abi.ABI
+	packs []packRecord
+}
+
+func newFakeMarket(t *testing.T) *fakeMarket {
+	parsed, err := abi.JSON(strings.NewReader(marketABI))
+	require.NoError(t, err)
+	return &fakeMarket{abi: parsed}
+}
+
+func (m *fakeMarket) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (m *fakeMarket) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if *call.To != testContracts.Market {
+		return nil, errors.New("not the market")
+	}
+	method, err := m.abi.MethodById(call.Data[:4])
+	if err != nil {
+		return nil, err
+	}
+	switch method.Name {
+	case "packCount":
+		return method.Outputs.Pack(big.NewInt(int64(len(m.packs))))
+	case "getPackData":
+		id := new(big.Int).SetBytes(call.Data[4:36]).Uint64()
+		pack := packRecord{Category: [][4]byte{}, Timestamp: new(big.Int), Price: new(big.Int), Contenthash: []byte{}}
+		if id < uint64(len(m.packs)) {
+			pack = m.packs[id]
+		}
+		return method.Outputs.Pack(pack.Category, pack.Owner, pack.Mintable, pack.Timestamp, pack.Price, pack.Contenthash)
+	}
+	return nil, errors.New("unknown method")
+}
+
+// fakeGateway serves files by CID.
+type fakeGateway struct {
+	files     map[string][]byte
+	downloads int
+}
+
+func (g *fakeGateway) add(t *testing.T, data []byte) hexutil.Bytes {
+	hash, err := multihash.Sum(data, multihash.SHA2_256, -1)
+	require.NoError(t, err)
+	g.files[hash.B58String()] = data
+	return append(append([]byte{}, ipfsPrefix...), hash...)
+}
+
+func (g *fakeGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.downloads++
+	data, ok := g.files[strings.TrimPrefix(r.URL.Path, "/ipfs/")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write(data) // nolint: errcheck
+}
+
+type memoryStore struct {
+	packs   map[uint64]Pack
+	content map[uint64]map[string][]byte
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		packs:   make(map[uint64]Pack),
+		content: make(map[uint64]map[string][]byte),
+	}
+}
+
+func (s *memoryStore) SaveStickerPack(networkID uint64, pack Pack, content map[string][]byte) error {
+	s.packs[pack.ID] = pack
+	s.content[pack.ID] = content
+	return nil
+}
+
+func (s *memoryStore) GetStickerPacks(networkID uint64) ([]Pack, error) {
+	var result []Pack
+	for _, pack := range s.packs {
+		result = append(result, pack)
+	}
+	return result, nil
+}
+
+func (s *memoryStore) GetStickerContent(networkID uint64, hash []byte) ([]byte, error) {
+	for _, content := range s.content {
+		if data, ok := content[hexutil.Encode(hash)]; ok {
+			return data, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) DeleteStickerPack(networkID uint64, id uint64) error {
+	delete(s.packs, id)
+	delete(s.content, id)
+	return nil
+}
+
+type managerFixture struct {
+	manager *Manager
+	market  *fakeMarket
+	gateway *fakeGateway
+	sticker hexutil.Bytes
+	close   func()
+}
+
+// newManagerFixture returns a manager of a market with a pack whose metadata is valid,
+// and a pack whose metadata is not.
+func newManagerFixture(t *testing.T) *managerFixture {
+	gateway := &fakeGateway{files: make(map[string][]byte)}
+	server := httptest.NewServer(gateway)
+	g, err := NewGateway(server.URL + "/ipfs")
+	require.NoError(t, err)
+
+	image := gateway.add(t, []byte("image"))
+	sticker := gateway.add(t, []byte("sticker"))
+	meta, err := json.Marshal(metadata{Name: "Pack", Author: "Alice", Thumbnail: image, Preview: image, Stickers: []Sticker{{Hash: sticker}}})
+	require.NoError(t, err)
+
+	market := newFakeMarket(t)
+	market.packs = []packRecord{
+		{Category: [][4]byte{}, Owner: common.HexToAddress("0x01"), Mintable: true, Timestamp: big.NewInt(100), Price: big.NewInt(1000), Contenthash: gateway.add(t, meta)},
+		{Category: [][4]byte{}, Owner: common.HexToAddress("0x01"), Timestamp: big.NewInt(200), Price: big.NewInt(0), Contenthash: gateway.add(t, []byte("{}"))},
+	}
+	return &managerFixture{
+		manager: NewManager(market, 1, testContracts, g),
+		market:  market,
+		gateway: gateway,
+		sticker: sticker,
+		close:   server.Close,
+	}
+}
+
+func TestPacks(t *testing.T) {
+	f := newManagerFixture(t)
+	defer f.close()
+
+	packs, err := f.manager.Packs(context.Background())
+	require.NoError(t, err)
+	require.Len(t, packs, 1, "Packs with invalid metadata are skipped")
+	require.Equal(t, uint64(0), packs[0].ID)
+	require.Equal(t, "Pack", packs[0].Name)
+	require.Equal(t, "Alice", packs[0].Author)
+	require.Equal(t, int64(1000), packs[0].Price.ToInt().Int64())
+	require.Equal(t, int64(100), packs[0].Timestamp)
+	require.Equal(t, []Sticker{{Hash: f.sticker}}, packs[0].Stickers)
+	require.Zero(t, packs[0].InstalledAt)
+
+	downloads := f.gateway.downloads
+	_, err = f.manager.Packs(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, downloads+1, f.gateway.downloads, "Only invalid metadata are downloaded again")
+}
+
+func TestInstall(t *testing.T) {
+	f := newManagerFixture(t)
+	defer f.close()
+
+	_, err := f.manager.Install(context.Background(), 0)
+	require.Equal(t, ErrNoAccountSelected, err)
+	_, err = f.manager.Installed()
+	require.Equal(t, ErrNoAccountSelected, err)
+
+	store := newMemoryStore()
+	f.manager.SetStore(store)
+	_, err = f.manager.Install(context.Background(), 2)
+	require.Equal(t, ErrUnknownPack, err)
+	_, err = f.manager.Install(context.Background(), 1)
+	require.Equal(t, ErrInvalidMetadata, err)
+	pack, err := f.manager.Install(context.Background(), 0)
+	require.NoError(t, err)
+	require.NotZero(t, pack.InstalledAt)
+	require.Len(t, store.content[0], 2, "Images are downloaded once")
+
+	installed, err := f.manager.Installed()
+	require.NoError(t, err)
+	require.Equal(t, []Pack{*pack}, installed)
+	packs, err := f.manager.Packs(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, pack.InstalledAt, packs[0].InstalledAt)
+
+	downloads := f.gateway.downloads
+	content, err := f.manager.Content(context.Background(), f.sticker)
+	require.NoError(t, err)
+	require.Equal(t, []byte("sticker"), content)
+	require.Equal(t, downloads, f.gateway.downloads, "Images of installed packs are not downloaded")
+
+	require.NoError(t, f.manager.Uninstall(0))
+	installed, err = f.manager.Installed()
+	require.NoError(t, err)
+	require.Empty(t, installed)
+	content, err = f.manager.Content(context.Background(), f.sticker)
+	require.NoError(t, err)
+	require.Equal(t, []byte("sticker"), content)
+	require.Equal(t, downloads+1, f.gateway.downloads)
+}
+
+func TestBuyTransaction(t *testing.T) {
+	f := newManagerFixture(t)
+	defer f.close()
+
+	buyer := common.HexToAddress("0x02")
+	_, err := f.manager.BuyTransaction(context.Background(), 1, buyer)
+	require.Equal(t, ErrPackNotMintable, err)
+	_, err = f.manager.BuyTransaction(context.Background(), 2, buyer)
+	require.Equal(t, ErrUnknownPack, err)
+
+	args, err := f.manager.BuyTransaction(context.Background(), 0, buyer)
+	require.NoError(t, err)
+	require.Equal(t, buyer, args.From)
+	require.Equal(t, testContracts.Token, *args.To)
+	require.Zero(t, args.Value.ToInt().Sign())
+
+	method, err := f.manager.token.MethodById(args.Input[:4])
+	require.NoError(t, err)
+	require.Equal(t, "approveAndCall", method.Name)
+	var approve struct {
+		Spender   common.Address
+		Amount    *big.Int
+		ExtraData []byte
+	}
+	require.NoError(t, method.Inputs.Unpack(&approve, args.Input[4:]))
+	require.Equal(t, testContracts.Market, approve.Spender)
+	require.Equal(t, int64(1000), approve.Amount.Int64())
+	buy, err := f.manager.market.Pack("buyToken", big.NewInt(0), buyer, big.NewInt(1000))
+	require.NoError(t, err)
+	require.Equal(t, buy, approve.ExtraData)
+}
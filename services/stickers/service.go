@@ -0,0 +1,95 @@
+package stickers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/contracts"
+	"github.com/status-im/status-go/rpc"
+)
+
+// Make sure that Service implements node.Service interface.
+var _ node.Service = (*Service)(nil)
+
+// ErrNoRPCClient is returned when reading the market while the node is not running.
+var ErrNoRPCClient = errors.New("no active RPC client: is the node running?")
+
+type rpcProvider interface {
+	RPCClient() *rpc.Client
+}
+
+// rpcCaller calls the upstream RPC of the node, which is only set once the node is started.
+type rpcCaller struct {
+	rpc rpcProvider
+}
+
+func (c rpcCaller) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	client := c.rpc.RPCClient()
+	if client == nil {
+		return ErrNoRPCClient
+	}
+	return client.CallContext(ctx, result, method, args...)
+}
+
+// Service lists and installs the sticker packs of the market of the network of the node.
+type Service struct {
+	manager *Manager
+}
+
+// New returns a new Service reading the market with the RPC client of the node, and
+// downloading packs from the IPFS gateway at gatewayURL, or the default gateway if it's
+// empty. Packs can't be listed if the market is not deployed on the network.
+func New(rpc rpcProvider, networkID uint64, gatewayURL string) (*Service, error) {
+	if gatewayURL == "" {
+		gatewayURL = DefaultGatewayURL
+	}
+	gateway, err := NewGateway(gatewayURL)
+	if err != nil {
+		return nil, err
+	}
+	market, ok := markets[networkID]
+	if !ok {
+		return &Service{}, nil
+	}
+	return &Service{manager: NewManager(contracts.NewContractCaller(rpcCaller{rpc}), networkID, market, gateway)}, nil
+}
+
+// SetStore sets the store of the packs installed by the selected account, or nil if no
+// account is selected.
+func (s *Service) SetStore(store Store) {
+	if s.manager != nil {
+		s.manager.SetStore(store)
+	}
+}
+
+// Protocols returns a new protocols list. In this case, there are none.
+func (s *Service) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{}
+}
+
+// APIs returns a list of new APIs.
+func (s *Service) APIs() []gethrpc.API {
+	return []gethrpc.API{
+		{
+			Namespace: "stickers",
+			Version:   "0.1.0",
+			Service:   NewAPI(s),
+			Public:    true,
+		},
+	}
+}
+
+// Start is run when a service is started.
+// It does nothing in this case but is required by `node.Service` interface.
+func (s *Service) Start(server *p2p.Server) error {
+	return nil
+}
+
+// Stop is run when a service is stopped.
+// It does nothing in this case but is required by `node.Service` interface.
+func (s *Service) Stop() error {
+	return nil
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"math/big"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
@@ -24,6 +26,7 @@ var (
 // in the result array. all other dependencies are sorted alphabetically.
 // for example: Z{c C, a A} A{c C} and the target is Z.
 // result would be Z, A, B, C
+// arrays of composite types, like A[] or A[2], depend on their element type.
 func deps(target string, types Types) []string {
 	unique := map[string]struct{}{}
 	unique[target] = struct{}{}
@@ -33,11 +36,11 @@ func deps(target string, types Types) []string {
 		current := visited[0]
 		fields := types[current]
 		for i := range fields {
-			f := fields[i]
-			if _, defined := types[f.Type]; defined {
-				if _, exist := unique[f.Type]; !exist {
-					visited = append(visited, f.Type)
-					unique[f.Type] = struct{}{}
+			typ := baseType(fields[i].Type)
+			if _, defined := types[typ]; defined {
+				if _, exist := unique[typ]; !exist {
+					visited = append(visited, typ)
+					unique[typ] = struct{}{}
 				}
 			}
 		}
@@ -50,6 +53,14 @@ func deps(target string, types Types) []string {
 	return deps
 }
 
+// baseType returns the element type of array types, or the type itself.
+func baseType(typ string) string {
+	if i := strings.Index(typ, "["); i >= 0 {
+		return typ[:i]
+	}
+	return typ
+}
+
 func typeString(target string, types Types) string {
 	b := new(bytes.Buffer)
 	for _, dep := range deps(target, types) {
@@ -77,14 +88,14 @@ func typeHash(target string, types Types) (rst common.Hash) {
 	return crypto.Keccak256Hash([]byte(typeString(target, types)))
 }
 
-func hashStruct(target string, data map[string]json.RawMessage, types Types) (rst common.Hash, err error) {
+func hashStruct(target string, data map[string]json.RawMessage, types Types, version Version) (rst common.Hash, err error) {
 	fields := types[target]
 	typeh := typeHash(target, types)
 	args := abi.Arguments{{Type: bytes32Type}}
 	vals := []interface{}{typeh}
 	for i := range fields {
 		f := fields[i]
-		val, typ, err := toABITypeAndValue(f, data, types)
+		val, typ, err := toABITypeAndValue(f, data[f.Name], types, version)
 		if err != nil {
 			return rst, err
 		}
@@ -98,34 +109,74 @@ func hashStruct(target string, data map[string]json.RawMessage, types Types) (rs
 	return crypto.Keccak256Hash(packed), nil
 }
 
-func toABITypeAndValue(f Field, data map[string]json.RawMessage, types Types) (val interface{}, typ abi.Type, err error) {
+func toABITypeAndValue(f Field, data json.RawMessage, types Types, version Version) (val interface{}, typ abi.Type, err error) {
 	if f.Type == "string" {
 		var str string
-		if err = json.Unmarshal(data[f.Name], &str); err != nil {
+		if err = json.Unmarshal(data, &str); err != nil {
 			return
 		}
 		return crypto.Keccak256Hash([]byte(str)), bytes32Type, nil
 	} else if f.Type == "bytes" {
 		var bytes hexutil.Bytes
-		if err = json.Unmarshal(data[f.Name], &bytes); err != nil {
+		if err = json.Unmarshal(data, &bytes); err != nil {
 			return
 		}
 		return crypto.Keccak256Hash(bytes), bytes32Type, nil
 	} else if _, exist := types[f.Type]; exist {
+		// v4 encodes missing structs as zero, like recursive structs that end with null
+		if version == V4 && (data == nil || string(data) == "null") {
+			return common.Hash{}, bytes32Type, nil
+		}
 		var obj map[string]json.RawMessage
-		if err = json.Unmarshal(data[f.Name], &obj); err != nil {
+		if err = json.Unmarshal(data, &obj); err != nil {
 			return
 		}
-		val, err = hashStruct(f.Type, obj, types)
+		val, err = hashStruct(f.Type, obj, types, version)
 		if err != nil {
 			return
 		}
 		return val, bytes32Type, nil
+	} else if version == V4 && strings.HasSuffix(f.Type, "]") {
+		return toArray(f, data, types)
 	}
 	return atomicType(f, data)
 }
 
-func atomicType(f Field, data map[string]json.RawMessage) (val interface{}, typ abi.Type, err error) {
+// toArray encodes an array as the hash of the concatenated encodings of its elements.
+func toArray(f Field, data json.RawMessage, types Types) (val interface{}, typ abi.Type, err error) {
+	open := strings.LastIndex(f.Type, "[")
+	if open < 0 {
+		return val, typ, fmt.Errorf("type %s is not supported", f.Type)
+	}
+	var elements []json.RawMessage
+	if err = json.Unmarshal(data, &elements); err != nil {
+		return
+	}
+	if size := f.Type[open+1 : len(f.Type)-1]; size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n != len(elements) {
+			return val, typ, fmt.Errorf("%s expects %s elements, got %d", f.Name, size, len(elements))
+		}
+	}
+	element := Field{Name: f.Name, Type: f.Type[:open]}
+	args := make(abi.Arguments, 0, len(elements))
+	vals := make([]interface{}, 0, len(elements))
+	for i := range elements {
+		val, typ, err := toABITypeAndValue(element, elements[i], types, V4)
+		if err != nil {
+			return nil, typ, err
+		}
+		vals = append(vals, val)
+		args = append(args, abi.Argument{Type: typ})
+	}
+	packed, err := args.Pack(vals...)
+	if err != nil {
+		return
+	}
+	return crypto.Keccak256Hash(packed), bytes32Type, nil
+}
+
+func atomicType(f Field, data json.RawMessage) (val interface{}, typ abi.Type, err error) {
 	typ, err = abi.NewType(f.Type)
 	if err != nil {
 		return
@@ -133,13 +184,13 @@ func atomicType(f Field, data map[string]json.RawMessage) (val interface{}, typ
 	if typ.T == abi.SliceTy || typ.T == abi.ArrayTy || typ.T == abi.FunctionTy {
 		return val, typ, errors.New("arrays, slices and functions are not supported")
 	} else if typ.T == abi.FixedBytesTy {
-		return toFixedBytes(f, data[f.Name])
+		return toFixedBytes(f, data)
 	} else if typ.T == abi.AddressTy {
-		val, err = toAddress(f, data[f.Name])
+		val, err = toAddress(f, data)
 	} else if typ.T == abi.IntTy || typ.T == abi.UintTy {
-		return toInt(f, data[f.Name])
+		return toInt(f, data)
 	} else if typ.T == abi.BoolTy {
-		val, err = toBool(f, data[f.Name])
+		val, err = toBool(f, data)
 	} else {
 		err = fmt.Errorf("type %s is not supported", f.Type)
 	}
@@ -161,12 +212,30 @@ func toFixedBytes(f Field, data json.RawMessage) (rst [32]byte, typ abi.Type, er
 	return rst, typ, nil
 }
 
+// toInt decodes integers given as JSON numbers, or as decimal or hex strings, as dapps
+// usually do for amounts that overflow JavaScript numbers.
 func toInt(f Field, data json.RawMessage) (val *big.Int, typ abi.Type, err error) {
-	var rst big.Int
-	if err = json.Unmarshal(data, &rst); err != nil {
+	var rst *big.Int
+	if rst, err = decodeBig(data); err != nil {
 		return
 	}
-	return &rst, int256Type, nil
+	return rst, int256Type, nil
+}
+
+func decodeBig(data json.RawMessage) (*big.Int, error) {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		var rst big.Int
+		if err := json.Unmarshal(data, &rst); err != nil {
+			return nil, err
+		}
+		return &rst, nil
+	}
+	rst, ok := new(big.Int).SetString(str, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", str)
+	}
+	return rst, nil
 }
 
 func toAddress(f Field, data json.RawMessage) (rst common.Address, err error) {
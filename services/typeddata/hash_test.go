@@ -204,7 +204,7 @@ func TestEncodeData(t *testing.T) {
 	} {
 		tc := tc
 		t.Run(tc.description, func(t *testing.T) {
-			encoded, err := hashStruct(tc.target, tc.message, tc.types)
+			encoded, err := hashStruct(tc.target, tc.message, tc.types, V3)
 			require.NoError(t, err)
 			require.Equal(t, tc.result(tc), encoded)
 		})
@@ -265,7 +265,7 @@ func TestEncodeDataErrors(t *testing.T) {
 	} {
 		tc := tc
 		t.Run(tc.description, func(t *testing.T) {
-			encoded, err := hashStruct(tc.target, tc.message, tc.types)
+			encoded, err := hashStruct(tc.target, tc.message, tc.types, V3)
 			require.Error(t, err)
 			require.Equal(t, common.Hash{}, encoded)
 		})
@@ -0,0 +1,165 @@
+package typeddata
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/signal"
+)
+
+// DefaultSignRequestTimeout is how long a sign request waits for the confirmation of the user.
+const DefaultSignRequestTimeout = 10 * time.Minute
+
+const (
+	// SignRequestTimeoutCode is the code of the sign-request.failed signals of requests
+	// that were not confirmed in time.
+	SignRequestTimeoutCode = 3
+	// SignRequestDiscardedCode is the code of the sign-request.failed signals of requests
+	// discarded by the user.
+	SignRequestDiscardedCode = 4
+)
+
+var (
+	// ErrSignRequestNotFound is returned when completing a request that is not pending.
+	ErrSignRequestNotFound = errors.New("sign request not found")
+	// ErrSignRequestTimeout is returned when a request was not confirmed in time.
+	ErrSignRequestTimeout = errors.New("sign request timed out")
+	// ErrSignRequestDiscarded is returned when a request was discarded by the user.
+	ErrSignRequestDiscarded = errors.New("sign request discarded")
+)
+
+// SignRequest is a request to sign typed data, waiting for the confirmation of the user.
+type SignRequest struct {
+	ID string `json:"id"`
+	// Origin is the origin of the dapp requesting the signature, if any.
+	Origin  string         `json:"origin,omitempty"`
+	Address common.Address `json:"address"`
+	Version Version        `json:"version"`
+	Data    TypedData      `json:"data"`
+}
+
+// Method returns the RPC method of the request, like eth_signTypedData_v4.
+func (r SignRequest) Method() string {
+	return "eth_signTypedData_" + string(r.Version)
+}
+
+func (r SignRequest) event() signal.PendingRequestEvent {
+	return signal.PendingRequestEvent{ID: r.ID, Method: r.Method(), Args: r}
+}
+
+type signResult struct {
+	signature hexutil.Bytes
+	err       error
+}
+
+type pendingRequest struct {
+	request SignRequest
+	result  chan signResult
+}
+
+// Queue keeps the requests to sign typed data until the user approves or discards them,
+// like transactions: each request is sent with a sign-request.queued signal, and is
+// completed with Approve or Discard.
+type Queue struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*pendingRequest
+}
+
+// NewQueue returns a new Queue whose requests wait for timeout.
+func NewQueue(timeout time.Duration) *Queue {
+	return &Queue{timeout: timeout, pending: make(map[string]*pendingRequest)}
+}
+
+// Add queues a request and waits until it's approved, discarded or timed out. The ID of
+// the request is generated.
+func (q *Queue) Add(ctx context.Context, request SignRequest) (hexutil.Bytes, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	request.ID = hexutil.Encode(id)
+	p := &pendingRequest{request: request, result: make(chan signResult, 1)}
+	q.mu.Lock()
+	q.pending[request.ID] = p
+	q.mu.Unlock()
+
+	signal.SendSignRequestAdded(request.event())
+	select {
+	case r := <-p.result:
+		return r.signature, r.err
+	case <-time.After(q.timeout):
+		if q.remove(request.ID) {
+			signal.SendSignRequestFailed(request.event(), ErrSignRequestTimeout, SignRequestTimeoutCode)
+			return nil, ErrSignRequestTimeout
+		}
+	case <-ctx.Done():
+		if q.remove(request.ID) {
+			return nil, ctx.Err()
+		}
+	}
+	// completed while timing out
+	r := <-p.result
+	return r.signature, r.err
+}
+
+// Approve signs a pending request with sign, and completes it with the signature. The request
+// stays pending if it can't be signed, like with a wrong password, so that the user can retry.
+func (q *Queue) Approve(id string, sign func(SignRequest) (hexutil.Bytes, error)) (hexutil.Bytes, error) {
+	q.mu.Lock()
+	p, ok := q.pending[id]
+	q.mu.Unlock()
+	if !ok {
+		return nil, ErrSignRequestNotFound
+	}
+	signature, err := sign(p.request)
+	if err != nil {
+		return nil, err
+	}
+	if !q.remove(id) {
+		return nil, ErrSignRequestNotFound
+	}
+	p.result <- signResult{signature: signature}
+	return signature, nil
+}
+
+// Discard completes a pending request with ErrSignRequestDiscarded.
+func (q *Queue) Discard(id string) error {
+	q.mu.Lock()
+	p, ok := q.pending[id]
+	delete(q.pending, id)
+	q.mu.Unlock()
+	if !ok {
+		return ErrSignRequestNotFound
+	}
+	signal.SendSignRequestFailed(p.request.event(), ErrSignRequestDiscarded, SignRequestDiscardedCode)
+	p.result <- signResult{err: ErrSignRequestDiscarded}
+	return nil
+}
+
+// Pending returns the pending requests, by ID.
+func (q *Queue) Pending() []SignRequest {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	requests := make([]SignRequest, 0, len(q.pending))
+	for _, p := range q.pending {
+		requests = append(requests, p.request)
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].ID < requests[j].ID })
+	return requests
+}
+
+func (q *Queue) remove(id string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, ok := q.pending[id]
+	delete(q.pending, id)
+	return ok
+}
@@ -0,0 +1,79 @@
+package typeddata
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/signal"
+	"github.com/stretchr/testify/require"
+)
+
+type queueResult struct {
+	signature hexutil.Bytes
+	err       error
+}
+
+func addAsync(q *Queue, request SignRequest) chan queueResult {
+	result := make(chan queueResult, 1)
+	go func() {
+		signature, err := q.Add(context.Background(), request)
+		result <- queueResult{signature, err}
+	}()
+	return result
+}
+
+func queuedID(t *testing.T, s *signal.Subscription) string {
+	envelope := <-s.Signals()
+	require.Equal(t, signal.EventSignRequestAdded, envelope.Type)
+	event := envelope.Event.(signal.PendingRequestEvent)
+	require.Equal(t, "eth_signTypedData_v4", event.Method)
+	return event.ID
+}
+
+func TestQueueApprove(t *testing.T) {
+	s := signal.Subscribe(signal.SubscriptionConfig{Types: []string{signal.EventSignRequestAdded}})
+	defer s.Unsubscribe()
+	q := NewQueue(time.Minute)
+
+	result := addAsync(q, SignRequest{Version: V4, Origin: "https://dapp.eth"})
+	id := queuedID(t, s)
+	require.Len(t, q.Pending(), 1)
+
+	_, err := q.Approve(id, func(SignRequest) (hexutil.Bytes, error) { return nil, errors.New("wrong password") })
+	require.EqualError(t, err, "wrong password")
+	require.Len(t, q.Pending(), 1, "Requests stay queued when they can't be signed")
+
+	signature, err := q.Approve(id, func(r SignRequest) (hexutil.Bytes, error) {
+		require.Equal(t, "https://dapp.eth", r.Origin)
+		return hexutil.Bytes{1, 2, 3}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, hexutil.Bytes{1, 2, 3}, signature)
+	require.Equal(t, queueResult{signature: hexutil.Bytes{1, 2, 3}}, <-result)
+	require.Empty(t, q.Pending())
+
+	_, err = q.Approve(id, func(SignRequest) (hexutil.Bytes, error) { return nil, nil })
+	require.Equal(t, ErrSignRequestNotFound, err)
+}
+
+func TestQueueDiscardAndTimeout(t *testing.T) {
+	s := signal.Subscribe(signal.SubscriptionConfig{Types: []string{signal.EventSignRequestAdded, signal.EventSignRequestFailed}})
+	defer s.Unsubscribe()
+	q := NewQueue(50 * time.Millisecond)
+
+	result := addAsync(q, SignRequest{Version: V4})
+	require.NoError(t, q.Discard(queuedID(t, s)))
+	require.Equal(t, ErrSignRequestDiscarded, (<-result).err)
+	failed := (<-s.Signals()).Event.(signal.PendingRequestErrorEvent)
+	require.Equal(t, SignRequestDiscardedCode, failed.ErrorCode)
+
+	result = addAsync(q, SignRequest{Version: V4})
+	queuedID(t, s)
+	require.Equal(t, ErrSignRequestTimeout, (<-result).err)
+	failed = (<-s.Signals()).Event.(signal.PendingRequestErrorEvent)
+	require.Equal(t, SignRequestTimeoutCode, failed.ErrorCode)
+	require.Empty(t, q.Pending())
+}
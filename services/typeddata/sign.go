@@ -13,24 +13,24 @@ var (
 	messagePadding = []byte{0x19, 0x01}
 )
 
-func encodeData(typed TypedData) (rst common.Hash, err error) {
-	domainSeparator, err := hashStruct(eip712Domain, typed.Domain, typed.Types)
+func encodeData(typed TypedData, version Version) (rst common.Hash, err error) {
+	domainSeparator, err := hashStruct(eip712Domain, typed.Domain, typed.Types, version)
 	if err != nil {
 		return rst, err
 	}
-	primary, err := hashStruct(typed.PrimaryType, typed.Message, typed.Types)
+	primary, err := hashStruct(typed.PrimaryType, typed.Message, typed.Types, version)
 	if err != nil {
 		return rst, err
 	}
 	return crypto.Keccak256Hash(messagePadding, domainSeparator[:], primary[:]), nil
 }
 
-// Sign TypedData with a given private key. Verify that chainId in the typed data matches currently selected chain.
-func Sign(typed TypedData, prv *ecdsa.PrivateKey, chain *big.Int) ([]byte, error) {
+// Sign TypedData encoded with version with a given private key. Verify that chainId in the typed data matches currently selected chain.
+func Sign(typed TypedData, version Version, prv *ecdsa.PrivateKey, chain *big.Int) ([]byte, error) {
 	if err := typed.ValidateChainID(chain); err != nil {
 		return nil, err
 	}
-	hash, err := encodeData(typed)
+	hash, err := encodeData(typed, version)
 	if err != nil {
 		return nil, err
 	}
@@ -41,3 +41,23 @@ func Sign(typed TypedData, prv *ecdsa.PrivateKey, chain *big.Int) ([]byte, error
 	sig[64] += 27
 	return sig, nil
 }
+
+// SignHashFunc signs a 32 bytes hash, returning a [R || S || V] signature where V is 0 or 1.
+type SignHashFunc func(hash []byte) ([]byte, error)
+
+// SignWith signs TypedData with a key that is only available through signHash, like Sign.
+func SignWith(typed TypedData, version Version, signHash SignHashFunc, chain *big.Int) ([]byte, error) {
+	if err := typed.ValidateChainID(chain); err != nil {
+		return nil, err
+	}
+	hash, err := encodeData(typed, version)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signHash(hash[:])
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
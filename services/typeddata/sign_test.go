@@ -9,6 +9,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -53,7 +54,7 @@ func TestChainIDValidation(t *testing.T) {
 	} {
 		t.Run(tc.description, func(t *testing.T) {
 			typed := TypedData{Domain: tc.domain}
-			_, err := Sign(typed, nil, chain)
+			_, err := Sign(typed, V3, nil, chain)
 			require.Error(t, err)
 		})
 	}
@@ -111,15 +112,15 @@ func TestInteroparableWithSolidity(t *testing.T) {
 		Message:     msg,
 	}
 
-	domainHash, err := hashStruct(eip712Domain, typed.Domain, typed.Types)
+	domainHash, err := hashStruct(eip712Domain, typed.Domain, typed.Types, V3)
 	require.NoError(t, err)
 	require.Equal(t, domainSol[:], domainHash[:])
 
-	mailHash, err := hashStruct(typed.PrimaryType, typed.Message, typed.Types)
+	mailHash, err := hashStruct(typed.PrimaryType, typed.Message, typed.Types, V3)
 	require.NoError(t, err)
 	require.Equal(t, mailSol[:], mailHash[:])
 
-	signature, err := Sign(typed, key, big.NewInt(1))
+	signature, err := Sign(typed, V3, key, big.NewInt(1))
 	require.NoError(t, err)
 	require.Len(t, signature, 65)
 
@@ -135,3 +136,75 @@ func TestInteroparableWithSolidity(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
 }
+
+func TestSignV4(t *testing.T) {
+	var typed TypedData
+	require.NoError(t, json.Unmarshal([]byte(`{
+  "types": {
+    "EIP712Domain": [
+      {"name": "name", "type": "string"},
+      {"name": "version", "type": "string"},
+      {"name": "chainId", "type": "uint256"},
+      {"name": "verifyingContract", "type": "address"}
+    ],
+    "Person": [
+      {"name": "name", "type": "string"},
+      {"name": "wallets", "type": "address[]"}
+    ],
+    "Mail": [
+      {"name": "from", "type": "Person"},
+      {"name": "to", "type": "Person[]"},
+      {"name": "contents", "type": "string"}
+    ],
+    "Group": [
+      {"name": "name", "type": "string"},
+      {"name": "members", "type": "Person[]"}
+    ]
+  },
+  "primaryType": "Mail",
+  "domain": {
+    "name": "Ether Mail",
+    "version": "1",
+    "chainId": "0x1",
+    "verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+  },
+  "message": {
+    "from": {"name": "Cow", "wallets": ["0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826", "0xDeaDbeefdEAdbeefdEadbEEFdeadbeEFdEaDbeeF"]},
+    "to": [{"name": "Bob", "wallets": ["0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB", "0xB0BdaBea57B0BDABeA57b0bdABEA57b0BDabEa57", "0xB0B0b0b0b0b0B000000000000000000000000000"]}],
+    "contents": "Hello, Bob!"
+  }
+}`), &typed))
+	require.NoError(t, typed.Validate())
+
+	require.Equal(t, "Mail(Person from,Person[] to,string contents)Person(string name,address[] wallets)", typeString("Mail", typed.Types))
+	mailHash, err := hashStruct(typed.PrimaryType, typed.Message, typed.Types, V4)
+	require.NoError(t, err)
+	require.Equal(t, "0xeb4221181ff3f1a83ea7313993ca9218496e424604ba9492bb4052c03d5c3df8", mailHash.Hex())
+	domainHash, err := hashStruct(eip712Domain, typed.Domain, typed.Types, V4)
+	require.NoError(t, err)
+	require.Equal(t, "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f", domainHash.Hex())
+
+	key := crypto.ToECDSAUnsafe(crypto.Keccak256([]byte("cow")))
+	signature, err := Sign(typed, V4, key, big.NewInt(1))
+	require.NoError(t, err)
+	require.Equal(t, "0x65cbd956f2fae28a601bebc9b906cea0191744bd4c4247bcd27cd08f8eb6b71c78efdf7a31dc9abee78f492292721f362d296cf86b4538e07b51303b67f749061b", hexutil.Encode(signature))
+
+	_, err = Sign(typed, V3, key, big.NewInt(1))
+	require.Error(t, err, "Arrays are not supported by v3")
+}
+
+func TestEncodeDataV4(t *testing.T) {
+	types := Types{
+		"Node": []Field{{Name: "value", Type: "uint256"}, {Name: "next", Type: "Node"}},
+		"Pair": []Field{{Name: "values", Type: "uint8[2]"}},
+	}
+	_, err := hashStruct("Node", map[string]json.RawMessage{"value": json.RawMessage(`"1000000000000000000"`)}, types, V4)
+	require.NoError(t, err, "Missing structs are encoded as zero")
+	_, err = hashStruct("Node", map[string]json.RawMessage{"value": json.RawMessage(`1`)}, types, V3)
+	require.Error(t, err)
+
+	_, err = hashStruct("Pair", map[string]json.RawMessage{"values": json.RawMessage(`[1,2]`)}, types, V4)
+	require.NoError(t, err)
+	_, err = hashStruct("Pair", map[string]json.RawMessage{"values": json.RawMessage(`[1,2,3]`)}, types, V4)
+	require.Error(t, err, "Fixed size arrays must have their size")
+}
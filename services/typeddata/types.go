@@ -12,6 +12,25 @@ const (
 	chainIDKey   = "chainId"
 )
 
+// Version is the version of eth_signTypedData the data is encoded with.
+type Version string
+
+const (
+	// V3 is eth_signTypedData_v3, which doesn't support arrays.
+	V3 Version = "v3"
+	// V4 is eth_signTypedData_v4, which supports arrays of any type and encodes missing
+	// structs as zero.
+	V4 Version = "v4"
+)
+
+// Validate checks that the version is supported.
+func (v Version) Validate() error {
+	if v != V3 && v != V4 {
+		return fmt.Errorf("unsupported typed data version %q", v)
+	}
+	return nil
+}
+
 // Types define fields for each composite type.
 type Types map[string][]Field
 
@@ -75,12 +94,12 @@ func (t TypedData) ValidateChainID(chain *big.Int) error {
 	if _, exist := t.Domain[chainIDKey]; !exist {
 		return fmt.Errorf("domain misses chain key %s", chainIDKey)
 	}
-	var chainID int64
-	if err := json.Unmarshal(t.Domain[chainIDKey], &chainID); err != nil {
+	chainID, err := decodeBig(t.Domain[chainIDKey])
+	if err != nil {
 		return err
 	}
-	if chainID != chain.Int64() {
-		return fmt.Errorf("chainId %d doesn't match selected chain %s", chainID, chain)
+	if chainID.Cmp(chain) != 0 {
+		return fmt.Errorf("chainId %s doesn't match selected chain %s", chainID, chain)
 	}
 	return nil
 }
@@ -0,0 +1,159 @@
+package wallet
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/transactions"
+)
+
+// PublicAPI exposes the wallet helpers over RPC. Except GetNetworks and
+// GetAllTokenBalances, its methods apply to the active network.
+type PublicAPI struct {
+	service *Service
+}
+
+// NewPublicAPI returns a new PublicAPI.
+func NewPublicAPI(s *Service) *PublicAPI {
+	return &PublicAPI{service: s}
+}
+
+// GetNetworks returns the networks used by the wallet, the network of the node first.
+func (api *PublicAPI) GetNetworks(ctx context.Context) []Network {
+	return api.service.Networks()
+}
+
+// SetActiveNetwork selects the network the other methods apply to, given its chain ID.
+func (api *PublicAPI) SetActiveNetwork(ctx context.Context, chainID uint64) error {
+	return api.service.SetActiveNetwork(chainID)
+}
+
+// SuggestFees returns the fees suggested for transactions at the low, medium and high
+// urgency levels, computed from recent blocks.
+func (api *PublicAPI) SuggestFees(ctx context.Context) (*FeeSuggestions, error) {
+	return api.service.activeChain().fees.SuggestFees(ctx)
+}
+
+// GetTransfers returns a page of the ETH and ERC-20 transfers of an address of the
+// account, from the most recent. cursor is empty for the first page, and the cursor
+// returned with the previous page otherwise. limit defaults to 50.
+func (api *PublicAPI) GetTransfers(ctx context.Context, address common.Address, cursor string, limit int) (*TransfersPage, error) {
+	c := api.service.activeChain()
+	store := c.transfers.Store()
+	if store == nil {
+		return nil, ErrTransfersNotStarted
+	}
+	return transfersPage(store, c.transfers.chainID, address, cursor, limit)
+}
+
+// GetTokens returns the default tokens of the network, followed by the custom tokens of
+// the selected account.
+func (api *PublicAPI) GetTokens(ctx context.Context) ([]Token, error) {
+	return api.service.activeChain().tokens.Tokens()
+}
+
+// AddCustomToken adds a custom token to the selected account, or replaces it.
+func (api *PublicAPI) AddCustomToken(ctx context.Context, token Token) error {
+	c := api.service.activeChain()
+	if err := c.tokens.AddCustomToken(token); err != nil {
+		return err
+	}
+	c.balances.Reset()
+	return nil
+}
+
+// DeleteCustomToken deletes a custom token of the selected account.
+func (api *PublicAPI) DeleteCustomToken(ctx context.Context, address common.Address) error {
+	c := api.service.activeChain()
+	if err := c.tokens.DeleteCustomToken(address); err != nil {
+		return err
+	}
+	c.balances.Reset()
+	return nil
+}
+
+// GetTokenBalances returns the balances of ether and of the tokens of the addresses. They
+// are cached for 30 seconds, and the cached balances are returned as stale when they can't
+// be fetched again.
+func (api *PublicAPI) GetTokenBalances(ctx context.Context, addresses []common.Address) ([]TokenBalances, error) {
+	return api.service.activeChain().tokenBalances(ctx, addresses)
+}
+
+// GetAllTokenBalances returns the balances of the addresses on every network, tagged with
+// their chain ID, in the order of the networks. Networks whose balances can't be fetched
+// are skipped, unless none of them can.
+func (api *PublicAPI) GetAllTokenBalances(ctx context.Context, addresses []common.Address) ([]TokenBalances, error) {
+	var (
+		result  []TokenBalances
+		lastErr error
+	)
+	for _, network := range api.service.networks {
+		balances, err := api.service.chains[network.ChainID].tokenBalances(ctx, addresses)
+		if err != nil {
+			logger.Warn("failed to fetch balances of network", "chainID", network.ChainID, "err", err)
+			lastErr = err
+			continue
+		}
+		result = append(result, balances...)
+	}
+	if result == nil && lastErr != nil {
+		return nil, lastErr
+	}
+	if result == nil {
+		result = []TokenBalances{}
+	}
+	return result, nil
+}
+
+// SetPrices sets the prices of the tokens in a fiat currency, by symbol, used to value the
+// balance snapshots recorded afterwards. Clients set the prices they fetched for their own
+// display, so that the node doesn't request an external service.
+func (api *PublicAPI) SetPrices(ctx context.Context, currency string, prices map[string]float64) {
+	api.service.prices.Set(currency, prices)
+}
+
+// SpeedUpTransaction replaces a pending transaction of the selected account with the same
+// one paying a higher gas price, and returns the hash of the replacement.
+func (api *PublicAPI) SpeedUpTransaction(ctx context.Context, hash common.Hash, gasPrice *hexutil.Big, password string) (common.Hash, error) {
+	replacer, err := api.service.transactionReplacer()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if gasPrice == nil {
+		return common.Hash{}, transactions.ErrReplacementUnderpriced
+	}
+	return replacer.SpeedUpTransaction(hash, gasPrice.ToInt(), password)
+}
+
+// CancelTransaction replaces a pending transaction of the selected account with an empty
+// transfer to itself, and returns the hash of the replacement.
+func (api *PublicAPI) CancelTransaction(ctx context.Context, hash common.Hash, password string) (common.Hash, error) {
+	replacer, err := api.service.transactionReplacer()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return replacer.CancelTransaction(hash, password)
+}
+
+// GetReplacementStatus returns the transactions competing with a transaction sent by the
+// selected account, and the one that landed, if any.
+func (api *PublicAPI) GetReplacementStatus(ctx context.Context, hash common.Hash) (*transactions.ReplacementStatus, error) {
+	replacer, err := api.service.transactionReplacer()
+	if err != nil {
+		return nil, err
+	}
+	return replacer.TransactionReplacementStatus(hash)
+}
+
+// GetBalanceHistory returns the daily snapshots of the balance of a token of an address
+// over the last days, from the oldest. token is the zero address for ether, and days
+// defaults to 30.
+func (api *PublicAPI) GetBalanceHistory(ctx context.Context, address, token common.Address, days int) ([]BalanceSnapshot, error) {
+	store := api.service.snapshots.Store()
+	if store == nil {
+		return nil, ErrNoAccountSelected
+	}
+	return balanceHistory(store, api.service.activeChain().transfers.chainID, address, token, days, time.Now())
+}
@@ -0,0 +1,329 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/params"
+)
+
+const (
+	// DefaultBalanceCacheTTL is how long the balances of an address are cached.
+	DefaultBalanceCacheTTL = 30 * time.Second
+	// multicallBatchSize is the maximum number of balanceOf calls aggregated in a call.
+	multicallBatchSize = 100
+)
+
+var (
+	// errInvalidMulticallResult is returned when the result of aggregate can't be decoded.
+	errInvalidMulticallResult = errors.New("invalid multicall result")
+
+	balanceOfSelector = crypto.Keccak256([]byte("balanceOf(address)"))[:4]
+	aggregateSelector = crypto.Keccak256([]byte("aggregate((address,bytes)[])"))[:4]
+
+	// multicallContracts are the addresses of the Multicall contract of MakerDAO, which
+	// aggregates the results of several calls in a single call, on each network.
+	multicallContracts = map[uint64]common.Address{
+		params.MainNetworkID:    common.HexToAddress("0xeefba1e63905ef1d7acba5a8513c70429b09d1dd"),
+		params.RopstenNetworkID: common.HexToAddress("0x53c43764255c17bd724f74c4ef150724ac50a3ed"),
+		params.RinkebyNetworkID: common.HexToAddress("0x42ad527de7d4e9d9d011ac45b31d8551f8fe9821"),
+	}
+)
+
+// TokenBalances are the balances of an address at a block.
+type TokenBalances struct {
+	// ChainID is the chain of the balances.
+	ChainID uint64         `json:"chainId"`
+	Address common.Address `json:"address"`
+	// Ether is the balance of ether, in wei.
+	Ether *hexutil.Big `json:"ether"`
+	// Tokens are the balances of the tokens, by address of their contract. Tokens whose
+	// balance can't be fetched are missing.
+	Tokens map[common.Address]*hexutil.Big `json:"tokens"`
+	// Block is the block the balances were fetched at.
+	Block hexutil.Uint64 `json:"block"`
+	// UpdatedAt is the time the balances were fetched, in milliseconds.
+	UpdatedAt int64 `json:"updatedAt"`
+	// Stale is true when the balances couldn't be fetched again, and the cached ones are
+	// returned.
+	Stale bool `json:"stale"`
+}
+
+// balanceCall is a call to a contract in a multicall.
+type balanceCall struct {
+	Target common.Address
+	Data   []byte
+}
+
+// balanceProvider requests the balances from the chain.
+type balanceProvider interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	Balance(ctx context.Context, address common.Address, block uint64) (*big.Int, error)
+	Call(ctx context.Context, to common.Address, data []byte, block uint64) ([]byte, error)
+}
+
+// balanceProviderRPC requests the balances from the upstream RPC of the node.
+type balanceProviderRPC struct {
+	rpc rpcProvider
+}
+
+func (p *balanceProviderRPC) BlockNumber(ctx context.Context) (uint64, error) {
+	var result hexutil.Uint64
+	err := call(ctx, p.rpc, &result, "eth_blockNumber")
+	return uint64(result), err
+}
+
+func (p *balanceProviderRPC) Balance(ctx context.Context, address common.Address, block uint64) (*big.Int, error) {
+	var result hexutil.Big
+	if err := call(ctx, p.rpc, &result, "eth_getBalance", address, hexutil.Uint64(block)); err != nil {
+		return nil, err
+	}
+	return result.ToInt(), nil
+}
+
+func (p *balanceProviderRPC) Call(ctx context.Context, to common.Address, data []byte, block uint64) ([]byte, error) {
+	var result hexutil.Bytes
+	err := call(ctx, p.rpc, &result, "eth_call", map[string]interface{}{
+		"to":   to,
+		"data": hexutil.Bytes(data),
+	}, hexutil.Uint64(block))
+	return result, err
+}
+
+// BalanceFetcher fetches the balances of ether and of the tokens of addresses, aggregating
+// the balanceOf calls with the Multicall contract when it is deployed on the network.
+// Balances are cached, and the cached balances are returned as stale when they can't be
+// fetched again.
+type BalanceFetcher struct {
+	chainID   uint64
+	provider  balanceProvider
+	multicall *common.Address
+	ttl       time.Duration
+
+	mu    sync.Mutex
+	cache map[common.Address]*TokenBalances
+}
+
+// NewBalanceFetcher returns a new BalanceFetcher of a chain, requesting the balances from
+// the RPC client of the chain.
+func NewBalanceFetcher(rpc rpcProvider, chainID uint64) *BalanceFetcher {
+	var multicall *common.Address
+	if address, ok := multicallContracts[chainID]; ok {
+		multicall = &address
+	}
+	return newBalanceFetcher(chainID, &balanceProviderRPC{rpc}, multicall, DefaultBalanceCacheTTL)
+}
+
+func newBalanceFetcher(chainID uint64, provider balanceProvider, multicall *common.Address, ttl time.Duration) *BalanceFetcher {
+	return &BalanceFetcher{
+		chainID:   chainID,
+		provider:  provider,
+		multicall: multicall,
+		ttl:       ttl,
+		cache:     make(map[common.Address]*TokenBalances),
+	}
+}
+
+// Balances returns the balances of the tokens of the addresses, fetching those that are
+// not cached or older than the TTL.
+func (f *BalanceFetcher) Balances(ctx context.Context, addresses []common.Address, tokens []Token) ([]TokenBalances, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	var outdated []common.Address
+	for _, address := range addresses {
+		cached, ok := f.cache[address]
+		if !ok || now.Sub(time.Unix(0, cached.UpdatedAt*int64(time.Millisecond))) >= f.ttl {
+			outdated = append(outdated, address)
+		}
+	}
+	if len(outdated) > 0 {
+		fetched, err := f.fetch(ctx, outdated, tokens)
+		if err != nil {
+			logger.Warn("failed to fetch balances", "err", err)
+			for _, address := range outdated {
+				cached, ok := f.cache[address]
+				if !ok {
+					return nil, err
+				}
+				cached.Stale = true
+			}
+		}
+		for _, balances := range fetched {
+			f.cache[balances.Address] = balances
+		}
+	}
+
+	result := make([]TokenBalances, 0, len(addresses))
+	for _, address := range addresses {
+		result = append(result, *f.cache[address])
+	}
+	return result, nil
+}
+
+// Reset drops the cached balances, when the tokens or the selected account change.
+func (f *BalanceFetcher) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cache = make(map[common.Address]*TokenBalances)
+}
+
+// fetch fetches the balances of the addresses at the latest block.
+func (f *BalanceFetcher) fetch(ctx context.Context, addresses []common.Address, tokens []Token) ([]*TokenBalances, error) {
+	block, err := f.provider.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	updatedAt := time.Now().UnixNano() / int64(time.Millisecond)
+
+	result := make([]*TokenBalances, 0, len(addresses))
+	calls := make([]balanceCall, 0, len(addresses)*len(tokens))
+	for _, address := range addresses {
+		ether, err := f.provider.Balance(ctx, address, block)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &TokenBalances{
+			ChainID:   f.chainID,
+			Address:   address,
+			Ether:     (*hexutil.Big)(ether),
+			Tokens:    make(map[common.Address]*hexutil.Big, len(tokens)),
+			Block:     hexutil.Uint64(block),
+			UpdatedAt: updatedAt,
+		})
+		for _, token := range tokens {
+			calls = append(calls, balanceCall{
+				Target: token.Address,
+				Data:   append(append([]byte{}, balanceOfSelector...), common.LeftPadBytes(address.Bytes(), 32)...),
+			})
+		}
+	}
+
+	for start := 0; start < len(calls); start += multicallBatchSize {
+		end := start + multicallBatchSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+		returned := f.callAll(ctx, calls[start:end], block)
+		for i, data := range returned {
+			// balanceOf returns a single uint256. Calls to accounts without code return nothing.
+			if len(data) != 32 {
+				continue
+			}
+			n := start + i
+			balances := result[n/len(tokens)]
+			balances.Tokens[tokens[n%len(tokens)].Address] = (*hexutil.Big)(new(big.Int).SetBytes(data))
+		}
+	}
+	return result, nil
+}
+
+// callAll returns the results of the calls, with a multicall if possible. As a multicall
+// fails if one of its calls reverts, the calls are made separately when it fails. The
+// result of a call that fails is nil.
+func (f *BalanceFetcher) callAll(ctx context.Context, calls []balanceCall, block uint64) [][]byte {
+	if f.multicall != nil {
+		data, err := f.provider.Call(ctx, *f.multicall, encodeAggregate(calls), block)
+		var returned [][]byte
+		if err == nil {
+			returned, err = decodeAggregate(data, len(calls))
+		}
+		if err == nil {
+			return returned
+		}
+		logger.Warn("multicall failed, calling the tokens separately", "err", err)
+	}
+
+	returned := make([][]byte, len(calls))
+	for i, c := range calls {
+		data, err := f.provider.Call(ctx, c.Target, c.Data, block)
+		if err != nil {
+			logger.Warn("failed to fetch token balance", "token", c.Target.Hex(), "err", err)
+			continue
+		}
+		returned[i] = data
+	}
+	return returned
+}
+
+// encodeAggregate returns the ABI encoding of a call to aggregate((address,bytes)[]).
+// The ABI package of go-ethereum vendored doesn't support tuples, so it's encoded here.
+func encodeAggregate(calls []balanceCall) []byte {
+	word := func(n int) []byte {
+		return common.LeftPadBytes(big.NewInt(int64(n)).Bytes(), 32)
+	}
+	padded := func(data []byte) []byte {
+		return append(append([]byte{}, data...), make([]byte, (32-len(data)%32)%32)...)
+	}
+
+	// The array of tuples is preceded by its offset and its length, and the tuples by their offsets.
+	var tuples [][]byte
+	for _, c := range calls {
+		tuple := append(common.LeftPadBytes(c.Target.Bytes(), 32), word(64)...)
+		tuple = append(tuple, word(len(c.Data))...)
+		tuple = append(tuple, padded(c.Data)...)
+		tuples = append(tuples, tuple)
+	}
+	data := append(append([]byte{}, aggregateSelector...), word(32)...)
+	data = append(data, word(len(calls))...)
+	offset := 32 * len(calls)
+	for _, tuple := range tuples {
+		data = append(data, word(offset)...)
+		offset += len(tuple)
+	}
+	for _, tuple := range tuples {
+		data = append(data, tuple...)
+	}
+	return data
+}
+
+// decodeAggregate decodes the (uint256 blockNumber, bytes[] returnData) result of aggregate.
+func decodeAggregate(data []byte, expected int) ([][]byte, error) {
+	readInt := func(offset int) (int, error) {
+		if offset < 0 || offset+32 > len(data) {
+			return 0, errInvalidMulticallResult
+		}
+		n := new(big.Int).SetBytes(data[offset : offset+32])
+		if !n.IsInt64() || n.Int64() > int64(len(data)) {
+			return 0, errInvalidMulticallResult
+		}
+		return int(n.Int64()), nil
+	}
+
+	array, err := readInt(32)
+	if err != nil {
+		return nil, err
+	}
+	length, err := readInt(array)
+	if err != nil {
+		return nil, err
+	}
+	if length != expected {
+		return nil, errInvalidMulticallResult
+	}
+	start := array + 32
+	result := make([][]byte, length)
+	for i := range result {
+		offset, err := readInt(start + 32*i)
+		if err != nil {
+			return nil, err
+		}
+		size, err := readInt(start + offset)
+		if err != nil {
+			return nil, err
+		}
+		begin := start + offset + 32
+		if begin+size > len(data) {
+			return nil, errInvalidMulticallResult
+		}
+		result[i] = data[begin : begin+size]
+	}
+	return result, nil
+}
@@ -0,0 +1,236 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/params"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBalanceProvider struct {
+	block     uint64
+	ether     map[common.Address]int64
+	tokens    map[common.Address]map[common.Address]int64
+	reverting map[common.Address]bool
+	multicall common.Address
+	err       error
+	calls     int
+}
+
+func newFakeBalanceProvider() *fakeBalanceProvider {
+	return &fakeBalanceProvider{
+		block:     100,
+		ether:     make(map[common.Address]int64),
+		tokens:    make(map[common.Address]map[common.Address]int64),
+		reverting: make(map[common.Address]bool),
+		multicall: common.HexToAddress("0xff"),
+	}
+}
+
+func (p *fakeBalanceProvider) BlockNumber(ctx context.Context) (uint64, error) {
+	return p.block, p.err
+}
+
+func (p *fakeBalanceProvider) Balance(ctx context.Context, address common.Address, block uint64) (*big.Int, error) {
+	return big.NewInt(p.ether[address]), p.err
+}
+
+func (p *fakeBalanceProvider) Call(ctx context.Context, to common.Address, data []byte, block uint64) ([]byte, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	if to == p.multicall {
+		return p.aggregate(data)
+	}
+	return p.balanceOf(to, data)
+}
+
+func (p *fakeBalanceProvider) balanceOf(token common.Address, data []byte) ([]byte, error) {
+	if p.reverting[token] {
+		return nil, errors.New("execution reverted")
+	}
+	balances, ok := p.tokens[token]
+	if !ok {
+		// Not a contract.
+		return nil, nil
+	}
+	if !bytes.Equal(data[:4], balanceOfSelector) {
+		return nil, errors.New("unknown method")
+	}
+	owner := common.BytesToAddress(data[4:36])
+	return common.LeftPadBytes(big.NewInt(balances[owner]).Bytes(), 32), nil
+}
+
+// aggregate decodes the calls of a multicall and encodes their results like the contract.
+func (p *fakeBalanceProvider) aggregate(data []byte) ([]byte, error) {
+	if !bytes.Equal(data[:4], aggregateSelector) {
+		return nil, errors.New("unknown method")
+	}
+	args := data[4:]
+	word := func(offset int) int { return int(new(big.Int).SetBytes(args[offset : offset+32]).Int64()) }
+	array := word(0)
+	n := word(array)
+	var results [][]byte
+	for i := 0; i < n; i++ {
+		tuple := array + 32 + word(array+32+32*i)
+		target := common.BytesToAddress(args[tuple : tuple+32])
+		size := word(tuple + 64)
+		result, err := p.balanceOf(target, args[tuple+96:tuple+96+size])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	encoded := append(common.LeftPadBytes(big.NewInt(int64(p.block)).Bytes(), 32), common.LeftPadBytes([]byte{64}, 32)...)
+	encoded = append(encoded, common.LeftPadBytes(big.NewInt(int64(n)).Bytes(), 32)...)
+	offset := 32 * n
+	for _, result := range results {
+		encoded = append(encoded, common.LeftPadBytes(big.NewInt(int64(offset)).Bytes(), 32)...)
+		offset += 32 + len(result) + (32-len(result)%32)%32
+	}
+	for _, result := range results {
+		encoded = append(encoded, common.LeftPadBytes(big.NewInt(int64(len(result))).Bytes(), 32)...)
+		encoded = append(encoded, result...)
+		encoded = append(encoded, make([]byte, (32-len(result)%32)%32)...)
+	}
+	return encoded, nil
+}
+
+func TestBalances(t *testing.T) {
+	alice := common.HexToAddress("0x01")
+	bob := common.HexToAddress("0x02")
+	snt := Token{Address: common.HexToAddress("0x10"), Symbol: "SNT", Standard: ERC20}
+	kitties := Token{Address: common.HexToAddress("0x11"), Symbol: "CK", Standard: ERC721}
+	notContract := Token{Address: common.HexToAddress("0x12"), Symbol: "NONE", Standard: ERC20}
+
+	provider := newFakeBalanceProvider()
+	provider.ether[alice] = 7
+	provider.tokens[snt.Address] = map[common.Address]int64{alice: 1000, bob: 5}
+	provider.tokens[kitties.Address] = map[common.Address]int64{bob: 2}
+
+	for _, multicall := range []*common.Address{&provider.multicall, nil} {
+		provider.calls = 0
+		fetcher := newBalanceFetcher(testChainID, provider, multicall, time.Minute)
+		balances, err := fetcher.Balances(context.Background(), []common.Address{alice, bob}, []Token{snt, kitties, notContract})
+		require.NoError(t, err)
+		require.Len(t, balances, 2)
+		require.Equal(t, alice, balances[0].Address)
+		require.Equal(t, uint64(testChainID), balances[0].ChainID)
+		require.Equal(t, int64(7), balances[0].Ether.ToInt().Int64())
+		require.Equal(t, int64(1000), balances[0].Tokens[snt.Address].ToInt().Int64())
+		require.Equal(t, int64(0), balances[0].Tokens[kitties.Address].ToInt().Int64())
+		require.NotContains(t, balances[0].Tokens, notContract.Address, "Accounts without code have no balance")
+		require.Equal(t, int64(2), balances[1].Tokens[kitties.Address].ToInt().Int64())
+		require.Equal(t, uint64(100), uint64(balances[1].Block))
+		require.False(t, balances[1].Stale)
+		if multicall != nil {
+			require.Equal(t, 1, provider.calls, "The balanceOf calls are aggregated")
+		} else {
+			require.Equal(t, 6, provider.calls)
+		}
+	}
+}
+
+func TestBalancesMulticallFallback(t *testing.T) {
+	alice := common.HexToAddress("0x01")
+	snt := Token{Address: common.HexToAddress("0x10"), Symbol: "SNT", Standard: ERC20}
+	broken := Token{Address: common.HexToAddress("0x11"), Symbol: "BRK", Standard: ERC20}
+
+	provider := newFakeBalanceProvider()
+	provider.tokens[snt.Address] = map[common.Address]int64{alice: 3}
+	provider.tokens[broken.Address] = map[common.Address]int64{}
+	provider.reverting[broken.Address] = true
+
+	fetcher := newBalanceFetcher(testChainID, provider, &provider.multicall, time.Minute)
+	balances, err := fetcher.Balances(context.Background(), []common.Address{alice}, []Token{snt, broken})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), balances[0].Tokens[snt.Address].ToInt().Int64(), "Tokens are called separately when the multicall reverts")
+	require.NotContains(t, balances[0].Tokens, broken.Address)
+}
+
+func TestBalancesCache(t *testing.T) {
+	alice := common.HexToAddress("0x01")
+	provider := newFakeBalanceProvider()
+	provider.ether[alice] = 1
+	fetcher := newBalanceFetcher(testChainID, provider, nil, time.Minute)
+
+	balances, err := fetcher.Balances(context.Background(), []common.Address{alice}, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), balances[0].Ether.ToInt().Int64())
+
+	provider.ether[alice] = 2
+	balances, err = fetcher.Balances(context.Background(), []common.Address{alice}, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), balances[0].Ether.ToInt().Int64(), "Balances are cached")
+
+	fetcher.ttl = 0
+	provider.err = errors.New("no connection")
+	balances, err = fetcher.Balances(context.Background(), []common.Address{alice}, nil)
+	require.NoError(t, err)
+	require.True(t, balances[0].Stale, "Cached balances are returned as stale when they can't be fetched")
+	require.Equal(t, int64(1), balances[0].Ether.ToInt().Int64())
+
+	_, err = fetcher.Balances(context.Background(), []common.Address{common.HexToAddress("0x02")}, nil)
+	require.Equal(t, provider.err, err)
+
+	provider.err = nil
+	balances, err = fetcher.Balances(context.Background(), []common.Address{alice}, nil)
+	require.NoError(t, err)
+	require.False(t, balances[0].Stale)
+	require.Equal(t, int64(2), balances[0].Ether.ToInt().Int64())
+}
+
+func TestTokenRegistry(t *testing.T) {
+	registry := NewTokenRegistry(params.RopstenNetworkID)
+	tokens, err := registry.Tokens()
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	require.Equal(t, "STT", tokens[0].Symbol)
+
+	custom := Token{Address: common.HexToAddress("0x10"), Symbol: "ABC", Decimals: 6, Standard: ERC20}
+	require.Equal(t, ErrNoAccountSelected, registry.AddCustomToken(custom))
+
+	store := newMemoryStore()
+	registry.SetStore(store)
+	require.Equal(t, ErrInvalidToken, registry.AddCustomToken(Token{Address: custom.Address, Standard: ERC20}))
+	require.Equal(t, ErrDefaultToken, registry.AddCustomToken(Token{Address: tokens[0].Address, Symbol: "STT", Standard: ERC20}))
+	require.NoError(t, registry.AddCustomToken(custom))
+	tokens, err = registry.Tokens()
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	require.Equal(t, "ABC", tokens[1].Symbol)
+	require.True(t, tokens[1].Custom)
+	require.False(t, tokens[0].Custom)
+
+	mainnet := NewTokenRegistry(params.MainNetworkID)
+	mainnet.SetStore(store)
+	tokens, err = mainnet.Tokens()
+	require.NoError(t, err)
+	for _, token := range tokens {
+		require.False(t, token.Custom, "Custom tokens are added to a network")
+	}
+
+	require.NoError(t, registry.DeleteCustomToken(custom.Address))
+	tokens, err = registry.Tokens()
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+}
+
+func TestDecodeAggregateInvalid(t *testing.T) {
+	_, err := decodeAggregate([]byte{1, 2, 3}, 1)
+	require.Equal(t, errInvalidMulticallResult, err)
+
+	data := append(common.LeftPadBytes([]byte{1}, 32), common.LeftPadBytes([]byte{64}, 32)...)
+	data = append(data, common.LeftPadBytes([]byte{1}, 32)...)
+	data = append(data, common.LeftPadBytes([]byte{0xff}, 32)...)
+	_, err = decodeAggregate(data, 1)
+	require.Equal(t, errInvalidMulticallResult, err, "Offsets out of the result are rejected")
+}
@@ -0,0 +1,70 @@
+package wallet
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/transactions"
+)
+
+// UserData is the wallet history of the account, as exported by status_exportAllUserData.
+type UserData struct {
+	// UnsignedTransactions are the transactions waiting for an external signature.
+	UnsignedTransactions []transactions.UnsignedTransaction `json:"unsignedTransactions"`
+	// SentTransactions are the transactions sent which didn't land yet.
+	SentTransactions []transactions.SentTransaction `json:"sentTransactions"`
+	// Transfers are the transfers indexed for the addresses of the account.
+	Transfers []Transfer `json:"transfers"`
+}
+
+// Transfers returns all the transfers indexed for the selected account, by network and
+// address, from the most recent. It's empty when no account is selected.
+func (s *Service) Transfers() ([]Transfer, error) {
+	result := []Transfer{}
+	for _, network := range s.networks {
+		transfers, err := s.chains[network.ChainID].transfers.all()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, transfers...)
+	}
+	return result, nil
+}
+
+// all returns the transfers indexed for all the addresses, from the most recent.
+func (i *Indexer) all() ([]Transfer, error) {
+	i.mu.Lock()
+	store, addresses := i.store, i.addresses
+	i.mu.Unlock()
+	if store == nil {
+		return nil, nil
+	}
+
+	var result []Transfer
+	for _, address := range addresses {
+		transfers, err := allTransfers(store, i.chainID, address)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, transfers...)
+	}
+	return result, nil
+}
+
+// allTransfers returns the transfers of an address on a chain, requested by pages.
+func allTransfers(store Store, chainID uint64, address common.Address) ([]Transfer, error) {
+	var (
+		result []Transfer
+		cursor *TransfersCursor
+	)
+	for {
+		transfers, err := store.GetTransfers(chainID, address, cursor, MaxTransfersLimit)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, transfers...)
+		if len(transfers) < MaxTransfersLimit {
+			return result, nil
+		}
+		last := transfers[len(transfers)-1]
+		cursor = &TransfersCursor{BlockNumber: uint64(last.BlockNumber), ID: last.ID}
+	}
+}
@@ -0,0 +1,201 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/rpc"
+)
+
+const (
+	// DefaultFeeHistoryBlocks is the number of recent blocks the suggestions are computed from.
+	DefaultFeeHistoryBlocks = 20
+	// DefaultFeeCacheTTL is how long suggestions are cached, about the time between two blocks.
+	DefaultFeeCacheTTL = 12 * time.Second
+	// baseFeeMultiplier is applied to the base fee of the next block in the maximum fees, so
+	// that transactions stay valid for a few full blocks.
+	baseFeeMultiplier = 2
+)
+
+var (
+	// ErrNoRPCClient is returned when requesting the chain data while the node is not running.
+	ErrNoRPCClient = errors.New("no active RPC client: is the node running?")
+
+	// rewardPercentiles are the percentiles of the tips of the transactions of recent blocks
+	// used for the low, medium and high urgency levels.
+	rewardPercentiles = []float64{10, 50, 90}
+	// minPriorityFee is the lowest tip suggested, as blocks with no transactions report none.
+	minPriorityFee = big.NewInt(1000000000)
+)
+
+// Fee is the suggestion of an urgency level.
+type Fee struct {
+	// MaxPriorityFeePerGas is the tip paid to the miner.
+	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas"`
+	// MaxFeePerGas is the maximum price paid per gas, including the base fee.
+	MaxFeePerGas *hexutil.Big `json:"maxFeePerGas"`
+}
+
+// FeeSuggestions are the fees suggested at three urgency levels. On chains without EIP-1559,
+// Legacy is true and only GasPrice is set.
+type FeeSuggestions struct {
+	Legacy bool `json:"legacy"`
+	// BaseFee is the base fee of the next block.
+	BaseFee *hexutil.Big `json:"baseFee,omitempty"`
+	Low     *Fee         `json:"low,omitempty"`
+	Medium  *Fee         `json:"medium,omitempty"`
+	High    *Fee         `json:"high,omitempty"`
+	// GasPrice is the price suggested by the node, set on all chains.
+	GasPrice *hexutil.Big `json:"gasPrice"`
+	// Block is the number of the latest block the suggestions are computed from.
+	Block hexutil.Uint64 `json:"block"`
+}
+
+// feeHistory is the result of eth_feeHistory.
+type feeHistory struct {
+	OldestBlock hexutil.Uint64   `json:"oldestBlock"`
+	BaseFee     []*hexutil.Big   `json:"baseFeePerGas"`
+	Reward      [][]*hexutil.Big `json:"reward"`
+}
+
+// feeProvider returns the fees of recent blocks.
+type feeProvider interface {
+	FeeHistory(ctx context.Context, blocks int, percentiles []float64) (*feeHistory, error)
+	GasPrice(ctx context.Context) (*big.Int, error)
+}
+
+type rpcProvider interface {
+	RPCClient() *rpc.Client
+}
+
+// feeProviderRPC requests the fees from the upstream RPC of the node.
+type feeProviderRPC struct {
+	rpc rpcProvider
+}
+
+// call calls a method with the RPC client of the node.
+func call(ctx context.Context, provider rpcProvider, result interface{}, method string, args ...interface{}) error {
+	client := provider.RPCClient()
+	if client == nil {
+		return ErrNoRPCClient
+	}
+	return client.CallContext(ctx, result, method, args...)
+}
+
+func (p *feeProviderRPC) FeeHistory(ctx context.Context, blocks int, percentiles []float64) (*feeHistory, error) {
+	var result feeHistory
+	if err := call(ctx, p.rpc, &result, "eth_feeHistory", hexutil.Uint(blocks), "latest", percentiles); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (p *feeProviderRPC) GasPrice(ctx context.Context) (*big.Int, error) {
+	var result hexutil.Big
+	if err := call(ctx, p.rpc, &result, "eth_gasPrice"); err != nil {
+		return nil, err
+	}
+	return result.ToInt(), nil
+}
+
+// FeeOracle suggests the fees of transactions from the base fees and the tips of recent
+// blocks, so that clients don't hardcode gas prices. Suggestions are cached for a block.
+type FeeOracle struct {
+	provider feeProvider
+	blocks   int
+	ttl      time.Duration
+
+	mu        sync.Mutex
+	cached    *FeeSuggestions
+	updatedAt time.Time
+}
+
+// NewFeeOracle returns a new FeeOracle requesting the fees from the RPC client of the node.
+func NewFeeOracle(rpc rpcProvider) *FeeOracle {
+	return newFeeOracle(&feeProviderRPC{rpc}, DefaultFeeHistoryBlocks, DefaultFeeCacheTTL)
+}
+
+func newFeeOracle(provider feeProvider, blocks int, ttl time.Duration) *FeeOracle {
+	return &FeeOracle{
+		provider: provider,
+		blocks:   blocks,
+		ttl:      ttl,
+	}
+}
+
+// SuggestFees returns the cached suggestions, or computes them if they are older than a block.
+func (o *FeeOracle) SuggestFees(ctx context.Context) (*FeeSuggestions, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.cached != nil && time.Since(o.updatedAt) < o.ttl {
+		return o.cached, nil
+	}
+	suggestions, err := o.suggest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	o.cached = suggestions
+	o.updatedAt = time.Now()
+	return suggestions, nil
+}
+
+func (o *FeeOracle) suggest(ctx context.Context) (*FeeSuggestions, error) {
+	gasPrice, err := o.provider.GasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	suggestions := &FeeSuggestions{Legacy: true, GasPrice: (*hexutil.Big)(gasPrice)}
+
+	history, err := o.provider.FeeHistory(ctx, o.blocks, rewardPercentiles)
+	if err != nil || len(history.BaseFee) == 0 {
+		// eth_feeHistory is not supported by nodes of chains without EIP-1559.
+		return suggestions, nil
+	}
+	// baseFeePerGas has one more entry than the blocks: the base fee of the next block.
+	baseFee := history.BaseFee[len(history.BaseFee)-1].ToInt()
+	if baseFee.Sign() == 0 {
+		return suggestions, nil
+	}
+	suggestions.Legacy = false
+	suggestions.BaseFee = (*hexutil.Big)(baseFee)
+	suggestions.Block = history.OldestBlock + hexutil.Uint64(len(history.BaseFee)-2)
+
+	levels := make([]*Fee, len(rewardPercentiles))
+	for i := range rewardPercentiles {
+		tip := medianReward(history.Reward, i)
+		maxFee := new(big.Int).Mul(baseFee, big.NewInt(baseFeeMultiplier))
+		maxFee.Add(maxFee, tip)
+		levels[i] = &Fee{
+			MaxPriorityFeePerGas: (*hexutil.Big)(tip),
+			MaxFeePerGas:         (*hexutil.Big)(maxFee),
+		}
+	}
+	suggestions.Low, suggestions.Medium, suggestions.High = levels[0], levels[1], levels[2]
+	return suggestions, nil
+}
+
+// medianReward returns the median of the tips at a percentile of the blocks with
+// transactions, and at least minPriorityFee.
+func medianReward(rewards [][]*hexutil.Big, percentile int) *big.Int {
+	var tips []*big.Int
+	for _, block := range rewards {
+		if percentile < len(block) && block[percentile] != nil && block[percentile].ToInt().Sign() > 0 {
+			tips = append(tips, block[percentile].ToInt())
+		}
+	}
+	if len(tips) == 0 {
+		return new(big.Int).Set(minPriorityFee)
+	}
+	sort.Slice(tips, func(i, j int) bool { return tips[i].Cmp(tips[j]) < 0 })
+	median := tips[len(tips)/2]
+	if median.Cmp(minPriorityFee) < 0 {
+		return new(big.Int).Set(minPriorityFee)
+	}
+	return new(big.Int).Set(median)
+}
@@ -0,0 +1,86 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeFeeProvider struct {
+	history  *feeHistory
+	gasPrice int64
+	calls    int
+}
+
+func (p *fakeFeeProvider) FeeHistory(ctx context.Context, blocks int, percentiles []float64) (*feeHistory, error) {
+	p.calls++
+	if p.history == nil {
+		return nil, errors.New("the method eth_feeHistory does not exist/is not available")
+	}
+	return p.history, nil
+}
+
+func (p *fakeFeeProvider) GasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(p.gasPrice), nil
+}
+
+func gwei(n int64) *hexutil.Big {
+	return (*hexutil.Big)(new(big.Int).Mul(big.NewInt(n), big.NewInt(1000000000)))
+}
+
+func TestSuggestFees(t *testing.T) {
+	provider := &fakeFeeProvider{
+		gasPrice: 30,
+		history: &feeHistory{
+			OldestBlock: 100,
+			BaseFee:     []*hexutil.Big{gwei(18), gwei(20), gwei(19), gwei(21)},
+			Reward: [][]*hexutil.Big{
+				{gwei(1), gwei(2), gwei(5)},
+				{gwei(0), gwei(0), gwei(0)},
+				{gwei(2), gwei(3), gwei(4)},
+			},
+		},
+	}
+	oracle := newFeeOracle(provider, 3, time.Minute)
+	fees, err := oracle.SuggestFees(context.Background())
+	require.NoError(t, err)
+	require.False(t, fees.Legacy)
+	require.Equal(t, hexutil.Uint64(102), fees.Block)
+	require.Equal(t, gwei(21), fees.BaseFee, "The base fee of the next block is suggested")
+	require.Equal(t, gwei(2), fees.Low.MaxPriorityFeePerGas, "Empty blocks are ignored")
+	require.Equal(t, gwei(3), fees.Medium.MaxPriorityFeePerGas)
+	require.Equal(t, gwei(5), fees.High.MaxPriorityFeePerGas)
+	require.Equal(t, gwei(45), fees.Medium.MaxFeePerGas, "The maximum fee covers twice the base fee")
+	require.Equal(t, int64(30), fees.GasPrice.ToInt().Int64())
+
+	_, err = oracle.SuggestFees(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, provider.calls, "Suggestions are cached")
+}
+
+func TestSuggestFeesLegacy(t *testing.T) {
+	provider := &fakeFeeProvider{gasPrice: 30}
+	fees, err := newFeeOracle(provider, 3, 0).SuggestFees(context.Background())
+	require.NoError(t, err)
+	require.True(t, fees.Legacy, "Nodes without eth_feeHistory only suggest a gas price")
+	require.Nil(t, fees.Medium)
+	require.Equal(t, int64(30), fees.GasPrice.ToInt().Int64())
+
+	provider.history = &feeHistory{BaseFee: []*hexutil.Big{gwei(0), gwei(0)}, Reward: [][]*hexutil.Big{{gwei(1)}}}
+	fees, err = newFeeOracle(provider, 1, 0).SuggestFees(context.Background())
+	require.NoError(t, err)
+	require.True(t, fees.Legacy, "Chains without base fee only suggest a gas price")
+}
+
+func TestMedianRewardMinimum(t *testing.T) {
+	tip := medianReward([][]*hexutil.Big{{(*hexutil.Big)(big.NewInt(1))}}, 0)
+	require.Equal(t, minPriorityFee, tip)
+	tip = medianReward(nil, 0)
+	require.Equal(t, minPriorityFee, tip)
+	require.False(t, tip == minPriorityFee, "The minimum is copied")
+}
@@ -0,0 +1,425 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/services/abiregistry"
+)
+
+// transferEventSignature is the topic of the Transfer events of ERC-20 tokens.
+var transferEventSignature = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// IndexerConfig of the transfers indexer.
+type IndexerConfig struct {
+	// Interval is how often new blocks are scanned once the indexer caught up.
+	Interval time.Duration
+	// BatchSize is the maximum number of blocks scanned at once.
+	BatchSize uint64
+	// Confirmations is the number of blocks on top of the last block scanned, so that
+	// blocks are not scanned before they are unlikely to be reorganized.
+	Confirmations uint64
+	// InitialLookback is the number of blocks scanned before the head of the chain the
+	// first time an address is indexed.
+	InitialLookback uint64
+	// Timeout of the scan of a batch.
+	Timeout time.Duration
+}
+
+// DefaultIndexerConfig returns the default configuration of the indexer.
+func DefaultIndexerConfig() IndexerConfig {
+	return IndexerConfig{
+		Interval:        15 * time.Second,
+		BatchSize:       100,
+		Confirmations:   12,
+		InitialLookback: 10000,
+		Timeout:         time.Minute,
+	}
+}
+
+// rpcBlock is a block with its transactions, decoded without the types of go-ethereum so
+// that the transaction types it doesn't know are decoded too.
+type rpcBlock struct {
+	Number       hexutil.Uint64   `json:"number"`
+	Hash         common.Hash      `json:"hash"`
+	Timestamp    hexutil.Uint64   `json:"timestamp"`
+	Transactions []rpcTransaction `json:"transactions"`
+}
+
+type rpcTransaction struct {
+	Hash  common.Hash     `json:"hash"`
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to"`
+	Value *hexutil.Big    `json:"value"`
+}
+
+// chainReader requests the blocks and the logs of the chain.
+type chainReader interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	Block(ctx context.Context, number uint64) (*rpcBlock, error)
+	// TransactionSucceeded returns false if the transaction was reverted.
+	TransactionSucceeded(ctx context.Context, hash common.Hash) (bool, error)
+	// TransferLogs returns the Transfer events sent or received by the addresses in a range of blocks.
+	TransferLogs(ctx context.Context, from, to uint64, addresses []common.Address) ([]types.Log, error)
+}
+
+// chainReaderRPC requests the chain from the upstream RPC of the node.
+type chainReaderRPC struct {
+	rpc rpcProvider
+}
+
+func (r *chainReaderRPC) BlockNumber(ctx context.Context) (uint64, error) {
+	var result hexutil.Uint64
+	err := call(ctx, r.rpc, &result, "eth_blockNumber")
+	return uint64(result), err
+}
+
+func (r *chainReaderRPC) Block(ctx context.Context, number uint64) (*rpcBlock, error) {
+	var result *rpcBlock
+	if err := call(ctx, r.rpc, &result, "eth_getBlockByNumber", hexutil.Uint64(number), true); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("block %d not found", number)
+	}
+	return result, nil
+}
+
+func (r *chainReaderRPC) TransactionSucceeded(ctx context.Context, hash common.Hash) (bool, error) {
+	var result *struct {
+		Status hexutil.Uint64 `json:"status"`
+	}
+	if err := call(ctx, r.rpc, &result, "eth_getTransactionReceipt", hash); err != nil {
+		return false, err
+	}
+	if result == nil {
+		return false, fmt.Errorf("receipt of %s not found", hash.Hex())
+	}
+	return result.Status == hexutil.Uint64(types.ReceiptStatusSuccessful), nil
+}
+
+func (r *chainReaderRPC) TransferLogs(ctx context.Context, from, to uint64, addresses []common.Address) ([]types.Log, error) {
+	topics := make([]common.Hash, 0, len(addresses))
+	for _, address := range addresses {
+		topics = append(topics, common.BytesToHash(address.Bytes()))
+	}
+	var result []types.Log
+	// Transfers sent and received by the addresses are requested separately, as topics
+	// of different positions can't be matched with a single filter.
+	for _, filter := range [][]interface{}{
+		{transferEventSignature, topics},
+		{transferEventSignature, nil, topics},
+	} {
+		var logs []types.Log
+		err := call(ctx, r.rpc, &logs, "eth_getLogs", map[string]interface{}{
+			"fromBlock": hexutil.Uint64(from),
+			"toBlock":   hexutil.Uint64(to),
+			"topics":    filter,
+		})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, logs...)
+	}
+	return result, nil
+}
+
+// LogDecoder decodes the logs of contracts with their ABI. It is implemented by the
+// ABI registry, so that the logs of the contracts whose ABI was added are decoded with it.
+type LogDecoder interface {
+	DecodeLog(address common.Address, topics []common.Hash, data []byte) (*abiregistry.DecodedLog, error)
+}
+
+// Indexer scans the blocks of the chain for the ETH and ERC-20 transfers of the addresses
+// of the account, and persists them with the last block scanned for each address, so
+// that it resumes from it after a restart. Transfers found in new blocks are notified.
+type Indexer struct {
+	chainID uint64
+	reader  chainReader
+	config  IndexerConfig
+	handler func(address common.Address, transfers []Transfer)
+
+	mu        sync.Mutex
+	decoder   LogDecoder
+	store     Store
+	addresses []common.Address
+	quit      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewIndexer returns a new Indexer of a chain, requested from the RPC client of the chain.
+func NewIndexer(rpc rpcProvider, chainID uint64, config IndexerConfig, handler func(common.Address, []Transfer)) *Indexer {
+	return &Indexer{
+		chainID: chainID,
+		reader:  &chainReaderRPC{rpc},
+		config:  config,
+		handler: handler,
+	}
+}
+
+// SetDecoder sets the decoder of the Transfer events. Without a decoder, the events are
+// decoded as ERC-20 Transfer events.
+func (i *Indexer) SetDecoder(decoder LogDecoder) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.decoder = decoder
+}
+
+// Start indexes the transfers of the addresses in the background, persisting them in store.
+// The indexing of the previous addresses is stopped.
+func (i *Indexer) Start(store Store, addresses []common.Address) {
+	i.Stop()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.store = store
+	i.addresses = addresses
+	i.quit = make(chan struct{})
+	i.wg.Add(1)
+	go i.run(i.quit)
+}
+
+// Stop stops the indexing and waits for the scan in progress.
+func (i *Indexer) Stop() {
+	i.mu.Lock()
+	if i.quit == nil {
+		i.mu.Unlock()
+		return
+	}
+	close(i.quit)
+	i.quit = nil
+	i.store = nil
+	i.addresses = nil
+	i.mu.Unlock()
+	i.wg.Wait()
+}
+
+// Store returns the store of the addresses indexed, or nil if the indexer is stopped.
+func (i *Indexer) Store() Store {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.store
+}
+
+func (i *Indexer) run(quit chan struct{}) {
+	defer i.wg.Done()
+	i.mu.Lock()
+	store, addresses := i.store, i.addresses
+	i.mu.Unlock()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), i.config.Timeout)
+		caughtUp, err := i.scan(ctx, store, addresses)
+		cancel()
+		if err != nil {
+			reporter.Error("scan-transfers", err)
+		}
+		wait := i.config.Interval
+		if err == nil && !caughtUp {
+			wait = 0
+		}
+		select {
+		case <-quit:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// scan scans the next batch of blocks of the addresses. It returns true if the addresses
+// are scanned up to the last confirmed block.
+func (i *Indexer) scan(ctx context.Context, store Store, addresses []common.Address) (bool, error) {
+	latest, err := i.reader.BlockNumber(ctx)
+	if err != nil {
+		return false, err
+	}
+	if latest < i.config.Confirmations {
+		return true, nil
+	}
+	head := latest - i.config.Confirmations
+
+	// scanned is the last block scanned for each address.
+	scanned := make(map[common.Address]uint64, len(addresses))
+	from := head + 1
+	for _, address := range addresses {
+		block, ok, err := store.GetTransfersBlock(i.chainID, address)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			block = 0
+			if head > i.config.InitialLookback {
+				block = head - i.config.InitialLookback
+			}
+		}
+		scanned[address] = block
+		if block+1 < from {
+			from = block + 1
+		}
+	}
+	if from > head {
+		return true, nil
+	}
+	to := head
+	if to-from+1 > i.config.BatchSize {
+		to = from + i.config.BatchSize - 1
+	}
+
+	transfers, err := i.transfers(ctx, from, to, scanned)
+	if err != nil {
+		return false, err
+	}
+	for _, address := range addresses {
+		if scanned[address] >= to {
+			continue
+		}
+		if err := store.SaveTransfers(i.chainID, address, transfers[address], to); err != nil {
+			return false, err
+		}
+		if len(transfers[address]) > 0 && i.handler != nil {
+			i.handler(address, transfers[address])
+		}
+	}
+	return to == head, nil
+}
+
+// transfers returns the transfers of the addresses in a range of blocks, ignoring the
+// blocks already scanned for each address.
+func (i *Indexer) transfers(ctx context.Context, from, to uint64, scanned map[common.Address]uint64) (map[common.Address][]Transfer, error) {
+	result := make(map[common.Address][]Transfer)
+	add := func(address common.Address, t Transfer) {
+		if last, ok := scanned[address]; ok && uint64(t.BlockNumber) > last {
+			t.Address = address
+			result[address] = append(result[address], t)
+		}
+	}
+
+	timestamps := make(map[uint64]hexutil.Uint64, to-from+1)
+	for number := from; number <= to; number++ {
+		block, err := i.reader.Block(ctx, number)
+		if err != nil {
+			return nil, err
+		}
+		timestamps[number] = block.Timestamp
+		for _, tx := range block.Transactions {
+			if tx.Value == nil || tx.Value.ToInt().Sign() == 0 || tx.To == nil {
+				continue
+			}
+			_, fromTracked := scanned[tx.From]
+			_, toTracked := scanned[*tx.To]
+			if !fromTracked && !toTracked {
+				continue
+			}
+			succeeded, err := i.reader.TransactionSucceeded(ctx, tx.Hash)
+			if err != nil {
+				return nil, err
+			}
+			if !succeeded {
+				continue
+			}
+			t := Transfer{
+				ID:          tx.Hash.Hex(),
+				Type:        EthTransfer,
+				ChainID:     i.chainID,
+				From:        tx.From,
+				To:          *tx.To,
+				Value:       tx.Value,
+				BlockNumber: block.Number,
+				BlockHash:   block.Hash,
+				TxHash:      tx.Hash,
+				Timestamp:   block.Timestamp,
+			}
+			add(tx.From, t)
+			if *tx.To != tx.From {
+				add(*tx.To, t)
+			}
+		}
+	}
+
+	addresses := make([]common.Address, 0, len(scanned))
+	for address := range scanned {
+		addresses = append(addresses, address)
+	}
+	logs, err := i.reader.TransferLogs(ctx, from, to, addresses)
+	if err != nil {
+		return nil, err
+	}
+	i.mu.Lock()
+	decoder := i.decoder
+	i.mu.Unlock()
+	seen := make(map[string]bool, len(logs))
+	for _, l := range logs {
+		// ERC-721 Transfer events have the same signature, with the token ID as third topic.
+		if len(l.Topics) != 3 || l.Removed {
+			continue
+		}
+		id := fmt.Sprintf("%s-%d", l.TxHash.Hex(), l.Index)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		from, to, value, ok := decodeTransferLog(decoder, l)
+		if !ok {
+			continue
+		}
+		contract := l.Address
+		t := Transfer{
+			ID:          id,
+			Type:        Erc20Transfer,
+			ChainID:     i.chainID,
+			From:        from,
+			To:          to,
+			Contract:    &contract,
+			Value:       (*hexutil.Big)(value),
+			BlockNumber: hexutil.Uint64(l.BlockNumber),
+			BlockHash:   l.BlockHash,
+			TxHash:      l.TxHash,
+			Timestamp:   timestamps[l.BlockNumber],
+		}
+		add(t.From, t)
+		if t.To != t.From {
+			add(t.To, t)
+		}
+	}
+	return result, nil
+}
+
+// decodeTransferLog returns the sender, the recipient and the value of an ERC-20 Transfer
+// event, decoded with decoder if it is set. It returns false if the log is not a Transfer
+// event of the ERC-20 layout.
+func decodeTransferLog(decoder LogDecoder, l types.Log) (from, to common.Address, value *big.Int, ok bool) {
+	if decoder == nil {
+		if len(l.Data) != 32 {
+			return from, to, nil, false
+		}
+		from = common.BytesToAddress(l.Topics[1].Bytes())
+		to = common.BytesToAddress(l.Topics[2].Bytes())
+		return from, to, new(big.Int).SetBytes(l.Data), true
+	}
+
+	decoded, err := decoder.DecodeLog(l.Address, l.Topics, l.Data)
+	if err != nil {
+		logger.Debug("failed to decode transfer log", "contract", l.Address, "tx", l.TxHash, "err", err)
+		return from, to, nil, false
+	}
+	args := decoded.Arguments
+	if decoded.Event != "Transfer" || len(args) != 3 ||
+		args[0].Type != "address" || args[1].Type != "address" || args[2].Type != "uint256" {
+		return from, to, nil, false
+	}
+	from, fromOK := args[0].Value.(common.Address)
+	to, toOK := args[1].Value.(common.Address)
+	// the registry formats integers as decimal strings.
+	formatted, valueOK := args[2].Value.(string)
+	if !fromOK || !toOK || !valueOK {
+		return from, to, nil, false
+	}
+	value, ok = new(big.Int).SetString(formatted, 10)
+	return from, to, value, ok
+}
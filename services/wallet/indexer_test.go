@@ -0,0 +1,382 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/services/abiregistry"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+type fakeChain struct {
+	latest   uint64
+	blocks   map[uint64]*rpcBlock
+	reverted map[common.Hash]bool
+	logs     []types.Log
+}
+
+func newFakeChain(latest uint64) *fakeChain {
+	c := &fakeChain{
+		latest:   latest,
+		blocks:   make(map[uint64]*rpcBlock),
+		reverted: make(map[common.Hash]bool),
+	}
+	for n := uint64(0); n <= latest; n++ {
+		c.blocks[n] = &rpcBlock{Number: hexutil.Uint64(n), Hash: common.BigToHash(big.NewInt(int64(n))), Timestamp: hexutil.Uint64(1000 + n)}
+	}
+	return c
+}
+
+func (c *fakeChain) addTransaction(block uint64, tx rpcTransaction) {
+	c.blocks[block].Transactions = append(c.blocks[block].Transactions, tx)
+}
+
+func (c *fakeChain) addLog(block uint64, l types.Log) {
+	l.BlockNumber = block
+	l.BlockHash = c.blocks[block].Hash
+	c.logs = append(c.logs, l)
+}
+
+func (c *fakeChain) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.latest, nil
+}
+
+func (c *fakeChain) Block(ctx context.Context, number uint64) (*rpcBlock, error) {
+	return c.blocks[number], nil
+}
+
+func (c *fakeChain) TransactionSucceeded(ctx context.Context, hash common.Hash) (bool, error) {
+	return !c.reverted[hash], nil
+}
+
+// TransferLogs matches the senders and the recipients separately, like the two filters
+// requested from the RPC, so that transfers between two addresses are returned twice.
+func (c *fakeChain) TransferLogs(ctx context.Context, from, to uint64, addresses []common.Address) ([]types.Log, error) {
+	var result []types.Log
+	for position := 1; position <= 2; position++ {
+		for _, l := range c.logs {
+			if l.BlockNumber < from || l.BlockNumber > to || len(l.Topics) <= position {
+				continue
+			}
+			for _, address := range addresses {
+				if l.Topics[position] == common.BytesToHash(address.Bytes()) {
+					result = append(result, l)
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// transfersKey identifies the transfers of an address on a chain.
+type transfersKey struct {
+	chainID uint64
+	address common.Address
+}
+
+type memoryStore struct {
+	transfers map[transfersKey]map[string]Transfer
+	blocks    map[transfersKey]uint64
+	tokens    map[uint64][]Token
+	snapshots map[uint64][]BalanceSnapshot
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		transfers: make(map[transfersKey]map[string]Transfer),
+		blocks:    make(map[transfersKey]uint64),
+		tokens:    make(map[uint64][]Token),
+		snapshots: make(map[uint64][]BalanceSnapshot),
+	}
+}
+
+func (s *memoryStore) SaveTransfers(chainID uint64, address common.Address, transfers []Transfer, block uint64) error {
+	key := transfersKey{chainID, address}
+	if s.transfers[key] == nil {
+		s.transfers[key] = make(map[string]Transfer)
+	}
+	for _, t := range transfers {
+		s.transfers[key][t.ID] = t
+	}
+	s.blocks[key] = block
+	return nil
+}
+
+func (s *memoryStore) GetTransfersBlock(chainID uint64, address common.Address) (uint64, bool, error) {
+	block, ok := s.blocks[transfersKey{chainID, address}]
+	return block, ok, nil
+}
+
+func (s *memoryStore) GetTransfers(chainID uint64, address common.Address, cursor *TransfersCursor, limit int) ([]Transfer, error) {
+	var result []Transfer
+	for _, t := range s.transfers[transfersKey{chainID, address}] {
+		if cursor == nil || uint64(t.BlockNumber) < cursor.BlockNumber ||
+			(uint64(t.BlockNumber) == cursor.BlockNumber && t.ID < cursor.ID) {
+			result = append(result, t)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].BlockNumber != result[j].BlockNumber {
+			return result[i].BlockNumber > result[j].BlockNumber
+		}
+		return result[i].ID > result[j].ID
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (s *memoryStore) SaveCustomToken(networkID uint64, token Token) error {
+	if err := s.DeleteCustomToken(networkID, token.Address); err != nil {
+		return err
+	}
+	s.tokens[networkID] = append(s.tokens[networkID], token)
+	return nil
+}
+
+func (s *memoryStore) DeleteCustomToken(networkID uint64, address common.Address) error {
+	for i, token := range s.tokens[networkID] {
+		if token.Address == address {
+			s.tokens[networkID] = append(s.tokens[networkID][:i], s.tokens[networkID][i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) GetCustomTokens(networkID uint64) ([]Token, error) {
+	return append([]Token(nil), s.tokens[networkID]...), nil
+}
+
+func (s *memoryStore) SaveBalanceSnapshots(chainID uint64, snapshots []BalanceSnapshot) error {
+	s.snapshots[chainID] = append(s.snapshots[chainID], snapshots...)
+	return nil
+}
+
+func (s *memoryStore) GetLastBalanceSnapshot(chainID uint64) (int64, bool, error) {
+	snapshots := s.snapshots[chainID]
+	if len(snapshots) == 0 {
+		return 0, false, nil
+	}
+	return snapshots[len(snapshots)-1].Timestamp, true, nil
+}
+
+func (s *memoryStore) GetBalanceSnapshots(chainID uint64, address, token common.Address, since int64) ([]BalanceSnapshot, error) {
+	var result []BalanceSnapshot
+	for _, snapshot := range s.snapshots[chainID] {
+		if snapshot.Address == address && snapshot.Token == token && snapshot.Timestamp >= since {
+			result = append(result, snapshot)
+		}
+	}
+	return result, nil
+}
+
+func addressTopic(address common.Address) common.Hash {
+	return common.BytesToHash(address.Bytes())
+}
+
+const testChainID = 10
+
+func newTestIndexer(chain chainReader, handler func(common.Address, []Transfer)) *Indexer {
+	return &Indexer{
+		chainID: testChainID,
+		reader:  chain,
+		config: IndexerConfig{
+			BatchSize:       5,
+			Confirmations:   2,
+			InitialLookback: 20,
+		},
+		handler: handler,
+	}
+}
+
+func TestIndexerScan(t *testing.T) {
+	alice := common.HexToAddress("0x01")
+	bob := common.HexToAddress("0x02")
+	other := common.HexToAddress("0x03")
+	token := common.HexToAddress("0x04")
+
+	chain := newFakeChain(30)
+	chain.addTransaction(5, rpcTransaction{Hash: common.HexToHash("0x05"), From: other, To: &alice, Value: (*hexutil.Big)(big.NewInt(1))})
+	chain.addTransaction(10, rpcTransaction{Hash: common.HexToHash("0x10"), From: other, To: &alice, Value: (*hexutil.Big)(big.NewInt(2))})
+	chain.addTransaction(10, rpcTransaction{Hash: common.HexToHash("0x11"), From: alice, To: &other, Value: (*hexutil.Big)(big.NewInt(0))})
+	chain.addTransaction(11, rpcTransaction{Hash: common.HexToHash("0x12"), From: alice, To: &other, Value: (*hexutil.Big)(big.NewInt(3))})
+	chain.reverted[common.HexToHash("0x12")] = true
+	chain.addTransaction(12, rpcTransaction{Hash: common.HexToHash("0x13"), From: alice, To: &bob, Value: (*hexutil.Big)(big.NewInt(4))})
+	chain.addLog(14, types.Log{
+		Address: token,
+		Topics:  []common.Hash{transferEventSignature, addressTopic(alice), addressTopic(bob)},
+		Data:    common.LeftPadBytes(big.NewInt(5).Bytes(), 32),
+		TxHash:  common.HexToHash("0x14"),
+		Index:   1,
+	})
+	// ERC-721 transfer.
+	chain.addLog(15, types.Log{
+		Address: token,
+		Topics:  []common.Hash{transferEventSignature, addressTopic(other), addressTopic(alice), common.HexToHash("0x07")},
+		TxHash:  common.HexToHash("0x15"),
+	})
+	chain.addTransaction(29, rpcTransaction{Hash: common.HexToHash("0x29"), From: other, To: &alice, Value: (*hexutil.Big)(big.NewInt(6))})
+
+	notified := make(map[common.Address]int)
+	indexer := newTestIndexer(chain, func(address common.Address, transfers []Transfer) {
+		notified[address] += len(transfers)
+	})
+	store := newMemoryStore()
+	addresses := []common.Address{alice, bob}
+
+	caughtUp, err := indexer.scan(context.Background(), store, addresses)
+	require.NoError(t, err)
+	require.False(t, caughtUp)
+	block, ok, err := store.GetTransfersBlock(testChainID, alice)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(13), block, "The first scan starts from the lookback before the confirmed head")
+
+	for !caughtUp {
+		caughtUp, err = indexer.scan(context.Background(), store, addresses)
+		require.NoError(t, err)
+	}
+	require.Equal(t, uint64(28), store.blocks[transfersKey{testChainID, alice}])
+	require.Equal(t, uint64(28), store.blocks[transfersKey{testChainID, bob}])
+
+	transfers, err := store.GetTransfers(testChainID, alice, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, transfers, 3, "Transfers before the lookback, reverted, empty, unconfirmed and ERC-721 transfers are ignored")
+	require.Equal(t, Erc20Transfer, transfers[0].Type)
+	require.Equal(t, uint64(testChainID), transfers[0].ChainID)
+	require.Equal(t, fmt.Sprintf("%s-1", common.HexToHash("0x14").Hex()), transfers[0].ID)
+	require.Equal(t, token, *transfers[0].Contract)
+	require.Equal(t, int64(5), transfers[0].Value.ToInt().Int64())
+	require.Equal(t, hexutil.Uint64(1014), transfers[0].Timestamp)
+	require.Equal(t, EthTransfer, transfers[1].Type)
+	require.Equal(t, uint64(testChainID), transfers[1].ChainID)
+	require.Equal(t, common.HexToHash("0x13"), transfers[1].TxHash)
+	require.Equal(t, common.HexToHash("0x10"), transfers[2].TxHash)
+	require.Equal(t, alice, transfers[2].Address)
+
+	transfers, err = store.GetTransfers(testChainID, bob, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, transfers, 2, "Transfers between two addresses are indexed for both")
+	require.Equal(t, bob, transfers[0].Address)
+	require.Equal(t, map[common.Address]int{alice: 3, bob: 2}, notified)
+
+	chain.latest = 31
+	caughtUp, err = indexer.scan(context.Background(), store, addresses)
+	require.NoError(t, err)
+	require.True(t, caughtUp)
+	transfers, err = store.GetTransfers(testChainID, alice, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, transfers, 4, "New blocks are scanned once confirmed")
+	require.Equal(t, 4, notified[alice])
+}
+
+func TestIndexerResumesFromCursors(t *testing.T) {
+	alice := common.HexToAddress("0x01")
+	bob := common.HexToAddress("0x02")
+
+	chain := newFakeChain(30)
+	chain.addTransaction(20, rpcTransaction{Hash: common.HexToHash("0x20"), From: alice, To: &bob, Value: (*hexutil.Big)(big.NewInt(1))})
+	store := newMemoryStore()
+	require.NoError(t, store.SaveTransfers(testChainID, alice, nil, 18))
+	require.NoError(t, store.SaveTransfers(testChainID, bob, nil, 25))
+
+	indexer := newTestIndexer(chain, nil)
+	caughtUp, err := indexer.scan(context.Background(), store, []common.Address{alice, bob})
+	require.NoError(t, err)
+	require.False(t, caughtUp)
+	require.Equal(t, uint64(23), store.blocks[transfersKey{testChainID, alice}])
+	require.Equal(t, uint64(25), store.blocks[transfersKey{testChainID, bob}], "Addresses scanned further are not rolled back")
+	require.Len(t, store.transfers[transfersKey{testChainID, alice}], 1)
+	require.Empty(t, store.transfers[transfersKey{testChainID, bob}], "Blocks already scanned for an address are not indexed again")
+}
+
+func TestTransfersPage(t *testing.T) {
+	alice := common.HexToAddress("0x01")
+	store := newMemoryStore()
+	require.NoError(t, store.SaveTransfers(testChainID, alice, []Transfer{
+		{ID: "0xa", BlockNumber: 1},
+		{ID: "0xb", BlockNumber: 2},
+		{ID: "0xc", BlockNumber: 2},
+	}, 2))
+
+	page, err := transfersPage(store, testChainID, alice, "", 2)
+	require.NoError(t, err)
+	require.Len(t, page.Transfers, 2)
+	require.Equal(t, "0xc", page.Transfers[0].ID)
+	require.Equal(t, "0xb", page.Transfers[1].ID)
+	require.Equal(t, "2-0xb", page.Cursor)
+
+	page, err = transfersPage(store, testChainID, alice, page.Cursor, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Transfers, 1)
+	require.Equal(t, "0xa", page.Transfers[0].ID)
+	require.Empty(t, page.Cursor, "The last page has no cursor")
+
+	page, err = transfersPage(store, testChainID, common.HexToAddress("0x02"), "", 0)
+	require.NoError(t, err)
+	require.NotNil(t, page.Transfers)
+	require.Empty(t, page.Transfers)
+
+	_, err = transfersPage(store, testChainID, alice, "0xb", 2)
+	require.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestIndexerDecodesLogsWithRegistry(t *testing.T) {
+	level, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.NoError(t, err)
+	defer level.Close()
+	registry, err := abiregistry.NewRegistry(level)
+	require.NoError(t, err)
+
+	alice := common.HexToAddress("0x01")
+	bob := common.HexToAddress("0x02")
+	token := common.HexToAddress("0x04")
+	other := common.HexToAddress("0x05")
+	_, err = registry.Add(abiregistry.Entry{
+		Address: other,
+		Name:    "other",
+		ABI:     `[{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"id","type":"bytes32","indexed":false}]}]`,
+	})
+	require.NoError(t, err)
+
+	chain := newFakeChain(10)
+	chain.addLog(4, types.Log{
+		Address: token,
+		Topics:  []common.Hash{transferEventSignature, addressTopic(alice), addressTopic(bob)},
+		Data:    common.LeftPadBytes(big.NewInt(5).Bytes(), 32),
+		TxHash:  common.HexToHash("0x04"),
+	})
+	// The event of a contract whose ABI was added doesn't have the ERC-20 layout.
+	chain.addLog(5, types.Log{
+		Address: other,
+		Topics:  []common.Hash{crypto.Keccak256Hash([]byte("Transfer(address,address,bytes32)")), addressTopic(bob), addressTopic(alice)},
+		Data:    common.LeftPadBytes([]byte{7}, 32),
+		TxHash:  common.HexToHash("0x05"),
+	})
+
+	indexer := newTestIndexer(chain, nil)
+	indexer.SetDecoder(registry)
+	store := newMemoryStore()
+	for caughtUp := false; !caughtUp; {
+		caughtUp, err = indexer.scan(context.Background(), store, []common.Address{alice})
+		require.NoError(t, err)
+	}
+
+	transfers, err := store.GetTransfers(testChainID, alice, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, transfers, 1, "Events that don't have the ERC-20 layout are ignored")
+	require.Equal(t, alice, transfers[0].From)
+	require.Equal(t, bob, transfers[0].To)
+	require.Equal(t, int64(5), transfers[0].Value.ToInt().Int64())
+}
@@ -0,0 +1,52 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/status-im/status-go/params"
+	"github.com/status-im/status-go/rpc"
+)
+
+// ErrUnknownNetwork is returned when selecting a network the wallet doesn't use.
+var ErrUnknownNetwork = errors.New("unknown network")
+
+// networkNames are the names of the networks a node can run on.
+var networkNames = map[uint64]string{
+	params.MainNetworkID:        "Mainnet",
+	params.RopstenNetworkID:     "Ropsten",
+	params.RinkebyNetworkID:     "Rinkeby",
+	params.StatusChainNetworkID: "StatusChain",
+}
+
+// Network is a chain used by the wallet.
+type Network struct {
+	ChainID uint64 `json:"chainId"`
+	Name    string `json:"name"`
+	// Active is true for the network of the wallet API calls.
+	Active bool `json:"active"`
+}
+
+// Networks returns the networks used by the wallet of a node: its network, followed by the
+// additional networks of its configuration.
+func Networks(config *params.NodeConfig) []Network {
+	networks := []Network{{ChainID: config.NetworkID, Name: networkNames[config.NetworkID]}}
+	for _, network := range config.Networks {
+		networks = append(networks, Network{ChainID: network.ChainID, Name: network.Name})
+	}
+	return networks
+}
+
+// networkRPCProvider provides the RPC client of each network.
+type networkRPCProvider interface {
+	NetworkRPCClient(chainID uint64) *rpc.Client
+}
+
+// networkRPC provides the RPC client of a network.
+type networkRPC struct {
+	provider networkRPCProvider
+	chainID  uint64
+}
+
+func (n networkRPC) RPCClient() *rpc.Client {
+	return n.provider.NetworkRPCClient(n.chainID)
+}
@@ -0,0 +1,37 @@
+package wallet
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/transactions"
+)
+
+// ErrReplacementUnavailable is returned when replacing transactions without a replacer.
+var ErrReplacementUnavailable = errors.New("transactions can't be replaced by this node")
+
+// TransactionReplacer replaces the pending transactions sent by the selected account,
+// once its password is verified.
+type TransactionReplacer interface {
+	SpeedUpTransaction(hash common.Hash, gasPrice *big.Int, password string) (common.Hash, error)
+	CancelTransaction(hash common.Hash, password string) (common.Hash, error)
+	TransactionReplacementStatus(hash common.Hash) (*transactions.ReplacementStatus, error)
+}
+
+// SetTransactionReplacer sets the replacer of the pending transactions of the account.
+func (s *Service) SetTransactionReplacer(replacer TransactionReplacer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replacer = replacer
+}
+
+// transactionReplacer returns the replacer of the pending transactions.
+func (s *Service) transactionReplacer() (TransactionReplacer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.replacer == nil {
+		return nil, ErrReplacementUnavailable
+	}
+	return s.replacer, nil
+}
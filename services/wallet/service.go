@@ -0,0 +1,168 @@
+package wallet
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/logutils"
+	"github.com/status-im/status-go/services/report"
+	"github.com/status-im/status-go/signal"
+)
+
+// Make sure that Service implements node.Service interface.
+var _ node.Service = (*Service)(nil)
+
+// reporter reports the errors of the background work of the wallet to the client.
+var reporter = report.New("wallet")
+
+// logger is the logger of the wallet module, whose level can be set separately.
+var logger = logutils.NewLogger(logutils.ModuleWallet, "package", "status-go/services/wallet")
+
+// Service exposes the wallet helpers of the node, like fee suggestions, the history of the
+// transfers of the account and the balances of its tokens, on each network it uses.
+type Service struct {
+	networks  []Network
+	chains    map[uint64]*chain
+	prices    *PriceTable
+	snapshots *Snapshotter
+
+	mu       sync.RWMutex
+	active   uint64
+	replacer TransactionReplacer
+}
+
+// chain are the wallet helpers of a network.
+type chain struct {
+	fees      *FeeOracle
+	transfers *Indexer
+	tokens    *TokenRegistry
+	balances  *BalanceFetcher
+}
+
+// tokenBalances returns the balances of the default and custom tokens of the addresses.
+func (c *chain) tokenBalances(ctx context.Context, addresses []common.Address) ([]TokenBalances, error) {
+	tokens, err := c.tokens.Tokens()
+	if err != nil {
+		return nil, err
+	}
+	return c.balances.Balances(ctx, addresses, tokens)
+}
+
+// New returns a new Service of networks, requesting the chain data from the RPC client of
+// each network. The first network is active.
+func New(rpc networkRPCProvider, networks []Network) *Service {
+	s := &Service{
+		networks: networks,
+		chains:   make(map[uint64]*chain, len(networks)),
+		active:   networks[0].ChainID,
+		prices:   &PriceTable{},
+	}
+	for _, network := range networks {
+		provider := networkRPC{rpc, network.ChainID}
+		s.chains[network.ChainID] = &chain{
+			fees:      NewFeeOracle(provider),
+			transfers: NewIndexer(provider, network.ChainID, DefaultIndexerConfig(), sendNewTransfers),
+			tokens:    NewTokenRegistry(network.ChainID),
+			balances:  NewBalanceFetcher(provider, network.ChainID),
+		}
+	}
+	s.snapshots = NewSnapshotter(s.chains, s.prices, DefaultSnapshotInterval)
+	return s
+}
+
+// SetLogDecoder sets the decoder of the Transfer events indexed on each network.
+func (s *Service) SetLogDecoder(decoder LogDecoder) {
+	for _, c := range s.chains {
+		c.transfers.SetDecoder(decoder)
+	}
+}
+
+// Networks returns the networks used by the wallet.
+func (s *Service) Networks() []Network {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]Network, 0, len(s.networks))
+	for _, network := range s.networks {
+		network.Active = network.ChainID == s.active
+		result = append(result, network)
+	}
+	return result
+}
+
+// SetActiveNetwork selects the network of the wallet API calls.
+func (s *Service) SetActiveNetwork(chainID uint64) error {
+	if _, ok := s.chains[chainID]; !ok {
+		return ErrUnknownNetwork
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active = chainID
+	return nil
+}
+
+// activeChain returns the helpers of the active network.
+func (s *Service) activeChain() *chain {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.chains[s.active]
+}
+
+// SelectAccount indexes the transfers of the addresses of the selected account, loads
+// its custom tokens and records the snapshots of its balances, persisted in store, on
+// each network.
+func (s *Service) SelectAccount(store Store, addresses []common.Address) {
+	for _, c := range s.chains {
+		c.transfers.Start(store, addresses)
+		c.tokens.SetStore(store)
+		c.balances.Reset()
+	}
+	s.snapshots.Start(store, addresses)
+}
+
+// ReleaseAccount stops indexing the transfers of the account and recording its balances,
+// and unloads its custom tokens.
+func (s *Service) ReleaseAccount() {
+	s.snapshots.Stop()
+	for _, c := range s.chains {
+		c.transfers.Stop()
+		c.tokens.SetStore(nil)
+		c.balances.Reset()
+	}
+}
+
+func sendNewTransfers(address common.Address, transfers []Transfer) {
+	signal.SendNewTransfers(address.Hex(), transfers)
+}
+
+// Protocols returns a new protocols list. In this case, there are none.
+func (s *Service) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{}
+}
+
+// APIs returns a list of new APIs.
+func (s *Service) APIs() []gethrpc.API {
+	return []gethrpc.API{
+		{
+			Namespace: "wallet",
+			Version:   "1.0",
+			Service:   NewPublicAPI(s),
+			Public:    true,
+		},
+	}
+}
+
+// Start is run when a service is started.
+// It does nothing in this case but is required by `node.Service` interface.
+func (s *Service) Start(server *p2p.Server) error {
+	return nil
+}
+
+// Stop is run when a service is stopped.
+func (s *Service) Stop() error {
+	s.ReleaseAccount()
+	return nil
+}
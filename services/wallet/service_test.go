@@ -0,0 +1,149 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/params"
+	"github.com/status-im/status-go/rpc"
+	"github.com/status-im/status-go/transactions"
+	"github.com/stretchr/testify/require"
+)
+
+type noNetworks struct{}
+
+func (noNetworks) NetworkRPCClient(chainID uint64) *rpc.Client {
+	return nil
+}
+
+func TestNetworks(t *testing.T) {
+	config := &params.NodeConfig{
+		NetworkID: params.MainNetworkID,
+		Networks:  []params.NetworkConfig{{ChainID: 10, Name: "Optimism", RPCURL: "http://localhost"}},
+	}
+	networks := Networks(config)
+	require.Equal(t, []Network{{ChainID: params.MainNetworkID, Name: "Mainnet"}, {ChainID: 10, Name: "Optimism"}}, networks)
+
+	service := New(noNetworks{}, networks)
+	require.True(t, service.Networks()[0].Active, "The network of the node is active")
+	require.False(t, service.Networks()[1].Active)
+
+	require.Equal(t, ErrUnknownNetwork, service.SetActiveNetwork(3))
+	require.NoError(t, service.SetActiveNetwork(10))
+	require.False(t, service.Networks()[0].Active)
+	require.True(t, service.Networks()[1].Active)
+	require.Equal(t, service.chains[10], service.activeChain())
+
+	_, err := NewPublicAPI(service).SuggestFees(context.Background())
+	require.Equal(t, ErrNoRPCClient, err)
+}
+
+func TestAllTokenBalances(t *testing.T) {
+	alice := common.HexToAddress("0x01")
+	service := New(noNetworks{}, []Network{{ChainID: 1}, {ChainID: 10}, {ChainID: 42161}})
+	mainnet := newFakeBalanceProvider()
+	mainnet.ether[alice] = 1
+	optimism := newFakeBalanceProvider()
+	optimism.ether[alice] = 2
+	arbitrum := newFakeBalanceProvider()
+	arbitrum.err = errors.New("no connection")
+	service.chains[1].balances = newBalanceFetcher(1, mainnet, nil, time.Minute)
+	service.chains[10].balances = newBalanceFetcher(10, optimism, nil, time.Minute)
+	service.chains[42161].balances = newBalanceFetcher(42161, arbitrum, nil, time.Minute)
+	api := NewPublicAPI(service)
+
+	balances, err := api.GetAllTokenBalances(context.Background(), []common.Address{alice})
+	require.NoError(t, err)
+	require.Len(t, balances, 2, "Networks whose balances can't be fetched are skipped")
+	require.Equal(t, uint64(1), balances[0].ChainID)
+	require.Equal(t, int64(1), balances[0].Ether.ToInt().Int64())
+	require.Equal(t, uint64(10), balances[1].ChainID)
+	require.Equal(t, int64(2), balances[1].Ether.ToInt().Int64())
+
+	require.NoError(t, service.SetActiveNetwork(10))
+	balances, err = api.GetTokenBalances(context.Background(), []common.Address{alice})
+	require.NoError(t, err)
+	require.Len(t, balances, 1)
+	require.Equal(t, uint64(10), balances[0].ChainID)
+
+	mainnet.err = arbitrum.err
+	optimism.err = arbitrum.err
+	service.chains[1].balances.Reset()
+	service.chains[10].balances.Reset()
+	_, err = api.GetAllTokenBalances(context.Background(), []common.Address{alice})
+	require.Equal(t, arbitrum.err, err)
+}
+
+type fakeReplacer struct {
+	password string
+}
+
+func (r *fakeReplacer) SpeedUpTransaction(hash common.Hash, gasPrice *big.Int, password string) (common.Hash, error) {
+	r.password = password
+	return common.BigToHash(gasPrice), nil
+}
+
+func (r *fakeReplacer) CancelTransaction(hash common.Hash, password string) (common.Hash, error) {
+	r.password = password
+	return common.Hash{}, transactions.ErrTransactionLanded
+}
+
+func (r *fakeReplacer) TransactionReplacementStatus(hash common.Hash) (*transactions.ReplacementStatus, error) {
+	return &transactions.ReplacementStatus{Hashes: []common.Hash{hash}}, nil
+}
+
+func TestReplaceTransactions(t *testing.T) {
+	service := New(noNetworks{}, []Network{{ChainID: 1}})
+	api := NewPublicAPI(service)
+	hash := common.HexToHash("0x01")
+
+	_, err := api.CancelTransaction(context.Background(), hash, "password")
+	require.Equal(t, ErrReplacementUnavailable, err)
+
+	replacer := &fakeReplacer{}
+	service.SetTransactionReplacer(replacer)
+	spedUp, err := api.SpeedUpTransaction(context.Background(), hash, (*hexutil.Big)(big.NewInt(11)), "password")
+	require.NoError(t, err)
+	require.Equal(t, common.BigToHash(big.NewInt(11)), spedUp)
+	require.Equal(t, "password", replacer.password)
+	_, err = api.SpeedUpTransaction(context.Background(), hash, nil, "password")
+	require.Equal(t, transactions.ErrReplacementUnderpriced, err)
+	_, err = api.CancelTransaction(context.Background(), hash, "password")
+	require.Equal(t, transactions.ErrTransactionLanded, err)
+
+	status, err := api.GetReplacementStatus(context.Background(), hash)
+	require.NoError(t, err)
+	require.Equal(t, []common.Hash{hash}, status.Hashes)
+}
+
+func TestExportTransfers(t *testing.T) {
+	alice := common.HexToAddress("0x01")
+	bob := common.HexToAddress("0x02")
+	service := New(noNetworks{}, []Network{{ChainID: 1}, {ChainID: 10}})
+	transfers, err := service.Transfers()
+	require.NoError(t, err)
+	require.Empty(t, transfers, "No transfers are exported without a selected account")
+
+	store := newMemoryStore()
+	for i := 0; i < MaxTransfersLimit+1; i++ {
+		id := hexutil.EncodeUint64(uint64(i))
+		require.NoError(t, store.SaveTransfers(1, alice, []Transfer{{ID: id, ChainID: 1, BlockNumber: hexutil.Uint64(i)}}, uint64(i)))
+	}
+	require.NoError(t, store.SaveTransfers(10, bob, []Transfer{{ID: "0xa", ChainID: 10}}, 1))
+	for _, c := range service.chains {
+		c.transfers.store = store
+		c.transfers.addresses = []common.Address{alice, bob}
+	}
+
+	transfers, err = service.Transfers()
+	require.NoError(t, err)
+	require.Len(t, transfers, MaxTransfersLimit+2, "All the pages of transfers are exported")
+	require.Equal(t, hexutil.EncodeUint64(MaxTransfersLimit), transfers[0].ID)
+	require.Equal(t, "0x0", transfers[MaxTransfersLimit].ID)
+	require.Equal(t, "0xa", transfers[MaxTransfersLimit+1].ID)
+}
@@ -0,0 +1,247 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/services/report"
+)
+
+const (
+	// DefaultSnapshotInterval is how often the snapshotter checks whether the balances of
+	// the day were recorded.
+	DefaultSnapshotInterval = time.Hour
+	// DefaultBalanceHistoryDays is the number of days of history returned when no number is set.
+	DefaultBalanceHistoryDays = 30
+	// MaxBalanceHistoryDays is the maximum number of days of history returned at once.
+	MaxBalanceHistoryDays = 365
+
+	day = 24 * time.Hour
+)
+
+// errStaleBalances is returned when the balances of a snapshot can't be fetched again.
+var errStaleBalances = errors.New("balances are stale")
+
+// etherSymbol and etherDecimals are the symbol and the decimals of ether, whose
+// snapshots have the zero address as token.
+const (
+	etherSymbol   = "ETH"
+	etherDecimals = 18
+)
+
+// BalanceSnapshot is the balance of a token of an address recorded on a day.
+type BalanceSnapshot struct {
+	ChainID uint64         `json:"chainId"`
+	Address common.Address `json:"address"`
+	// Token is the address of the contract of the token, the zero address for ether.
+	Token common.Address `json:"token"`
+	// Timestamp is the start of the day the snapshot was recorded, UTC, in seconds.
+	Timestamp int64        `json:"timestamp"`
+	Balance   *hexutil.Big `json:"balance"`
+	// FiatValue is the value of the balance in Currency, at the prices set when the
+	// snapshot was recorded. It is nil if no price was set for the token.
+	FiatValue *float64 `json:"fiatValue"`
+	Currency  string   `json:"currency"`
+}
+
+// PriceTable holds the fiat prices of the tokens set by the client, which fetches them
+// for its own display, so that the node doesn't request an external service.
+type PriceTable struct {
+	mu       sync.RWMutex
+	currency string
+	prices   map[string]float64
+}
+
+// Set replaces the prices of the tokens, by symbol, in a currency.
+func (p *PriceTable) Set(currency string, prices map[string]float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.currency = currency
+	p.prices = prices
+}
+
+// value returns the fiat value of a balance of a token, and the currency of the price, or
+// nil if no price was set for the token.
+func (p *PriceTable) value(symbol string, decimals uint, balance *big.Int) (*float64, string) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	price, ok := p.prices[symbol]
+	if !ok {
+		return nil, ""
+	}
+	amount := new(big.Float).SetInt(balance)
+	amount.Quo(amount, new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	value, _ := amount.Mul(amount, big.NewFloat(price)).Float64()
+	return &value, p.currency
+}
+
+// Snapshotter records the balances of the addresses of the account on each network once a
+// day, so that clients can chart them. Missed days are not recorded afterwards.
+type Snapshotter struct {
+	chains   map[uint64]*chain
+	prices   *PriceTable
+	interval time.Duration
+
+	mu        sync.Mutex
+	store     Store
+	addresses []common.Address
+	quit      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewSnapshotter returns a new Snapshotter of the balances of chains, valued at prices.
+func NewSnapshotter(chains map[uint64]*chain, prices *PriceTable, interval time.Duration) *Snapshotter {
+	return &Snapshotter{
+		chains:   chains,
+		prices:   prices,
+		interval: interval,
+	}
+}
+
+// Start records the balances of the addresses in the background, persisting them in store.
+// The recording of the previous addresses is stopped.
+func (s *Snapshotter) Start(store Store, addresses []common.Address) {
+	s.Stop()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store = store
+	s.addresses = addresses
+	s.quit = make(chan struct{})
+	s.wg.Add(1)
+	go s.run(s.quit)
+}
+
+// Stop stops the recording and waits for the snapshot in progress.
+func (s *Snapshotter) Stop() {
+	s.mu.Lock()
+	if s.quit == nil {
+		s.mu.Unlock()
+		return
+	}
+	close(s.quit)
+	s.quit = nil
+	s.store = nil
+	s.addresses = nil
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// Store returns the store of the snapshots, or nil if the snapshotter is stopped.
+func (s *Snapshotter) Store() Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store
+}
+
+func (s *Snapshotter) run(quit chan struct{}) {
+	defer s.wg.Done()
+	s.mu.Lock()
+	store, addresses := s.store, s.addresses
+	s.mu.Unlock()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		s.snapshot(ctx, store, addresses, time.Now())
+		cancel()
+		select {
+		case <-quit:
+			return
+		case <-time.After(s.interval):
+		}
+	}
+}
+
+// snapshot records the balances of the addresses on the networks whose balances of the
+// day of now were not recorded yet. Networks whose balances can't be fetched are retried
+// at the next interval.
+func (s *Snapshotter) snapshot(ctx context.Context, store Store, addresses []common.Address, now time.Time) {
+	today := now.UTC().Truncate(day).Unix()
+	chainIDs := make([]uint64, 0, len(s.chains))
+	for chainID := range s.chains {
+		chainIDs = append(chainIDs, chainID)
+	}
+	sort.Slice(chainIDs, func(i, j int) bool { return chainIDs[i] < chainIDs[j] })
+
+	for _, chainID := range chainIDs {
+		last, ok, err := store.GetLastBalanceSnapshot(chainID)
+		if err != nil {
+			reporter.Error("get-balance-snapshot", report.WithCode(err, report.CodeStorage), "chainID", chainID)
+			continue
+		}
+		if ok && last >= today {
+			continue
+		}
+		snapshots, err := s.balances(ctx, s.chains[chainID], chainID, addresses, today)
+		if err != nil {
+			logger.Warn("failed to fetch balances of snapshot", "chainID", chainID, "err", err)
+			continue
+		}
+		if err := store.SaveBalanceSnapshots(chainID, snapshots); err != nil {
+			reporter.Error("save-balance-snapshots", report.WithCode(err, report.CodeStorage), "chainID", chainID)
+		}
+	}
+}
+
+// balances returns the snapshots of the balances of ether and of the tokens of the
+// addresses on a chain.
+func (s *Snapshotter) balances(ctx context.Context, c *chain, chainID uint64, addresses []common.Address, timestamp int64) ([]BalanceSnapshot, error) {
+	tokens, err := c.tokens.Tokens()
+	if err != nil {
+		return nil, err
+	}
+	balances, err := c.balances.Balances(ctx, addresses, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []BalanceSnapshot
+	add := func(address, token common.Address, symbol string, decimals uint, balance *hexutil.Big) {
+		value, currency := s.prices.value(symbol, decimals, balance.ToInt())
+		result = append(result, BalanceSnapshot{
+			ChainID:   chainID,
+			Address:   address,
+			Token:     token,
+			Timestamp: timestamp,
+			Balance:   balance,
+			FiatValue: value,
+			Currency:  currency,
+		})
+	}
+	for _, b := range balances {
+		if b.Stale {
+			return nil, errStaleBalances
+		}
+		add(b.Address, common.Address{}, etherSymbol, etherDecimals, b.Ether)
+		for _, token := range tokens {
+			if balance, ok := b.Tokens[token.Address]; ok {
+				add(b.Address, token.Address, token.Symbol, token.Decimals, balance)
+			}
+		}
+	}
+	return result, nil
+}
+
+// balanceHistory returns the snapshots of a token of an address on a chain over the last
+// days, from the oldest.
+func balanceHistory(store Store, chainID uint64, address, token common.Address, days int, now time.Time) ([]BalanceSnapshot, error) {
+	if days <= 0 {
+		days = DefaultBalanceHistoryDays
+	} else if days > MaxBalanceHistoryDays {
+		days = MaxBalanceHistoryDays
+	}
+	since := now.UTC().Truncate(day).Add(-time.Duration(days-1) * day).Unix()
+	snapshots, err := store.GetBalanceSnapshots(chainID, address, token, since)
+	if err != nil {
+		return nil, err
+	}
+	if snapshots == nil {
+		snapshots = []BalanceSnapshot{}
+	}
+	return snapshots, nil
+}
@@ -0,0 +1,91 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	alice := common.HexToAddress("0x01")
+	snt := Token{Address: common.HexToAddress("0x10"), Symbol: "SNT", Decimals: 2, Standard: ERC20}
+	provider := newFakeBalanceProvider()
+	provider.ether[alice] = 2000000000000000000
+	provider.tokens[snt.Address] = map[common.Address]int64{alice: 150}
+	failing := newFakeBalanceProvider()
+	failing.err = errors.New("no connection")
+
+	store := newMemoryStore()
+	tokens := NewTokenRegistry(1)
+	tokens.SetStore(store)
+	require.NoError(t, tokens.AddCustomToken(snt))
+	prices := &PriceTable{}
+	prices.Set("USD", map[string]float64{"ETH": 100})
+	snapshotter := NewSnapshotter(map[uint64]*chain{
+		1:  {tokens: tokens, balances: newBalanceFetcher(1, provider, nil, 0)},
+		10: {tokens: NewTokenRegistry(10), balances: newBalanceFetcher(10, failing, nil, 0)},
+	}, prices, time.Hour)
+
+	now := time.Date(2019, 1, 2, 15, 0, 0, 0, time.UTC)
+	today := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC).Unix()
+	snapshotter.snapshot(context.Background(), store, []common.Address{alice}, now)
+	require.Empty(t, store.snapshots[10], "Networks whose balances can't be fetched are skipped")
+	require.Len(t, store.snapshots[1], 2)
+	ether := store.snapshots[1][0]
+	require.Equal(t, common.Address{}, ether.Token)
+	require.Equal(t, today, ether.Timestamp)
+	require.Equal(t, uint64(1), ether.ChainID)
+	require.Equal(t, 200.0, *ether.FiatValue)
+	require.Equal(t, "USD", ether.Currency)
+	require.Equal(t, snt.Address, store.snapshots[1][1].Token)
+	require.Equal(t, int64(150), store.snapshots[1][1].Balance.ToInt().Int64())
+	require.Nil(t, store.snapshots[1][1].FiatValue, "Tokens without price have no fiat value")
+
+	provider.ether[alice] = 1
+	snapshotter.snapshot(context.Background(), store, []common.Address{alice}, now.Add(time.Hour))
+	require.Len(t, store.snapshots[1], 2, "Balances are recorded once a day")
+
+	prices.Set("EUR", map[string]float64{"SNT": 2})
+	failing.err = nil
+	snapshotter.snapshot(context.Background(), store, []common.Address{alice}, now.Add(day))
+	require.Len(t, store.snapshots[1], 4)
+	require.Len(t, store.snapshots[10], 1)
+	require.Equal(t, 3.0, *store.snapshots[1][3].FiatValue)
+	require.Equal(t, "EUR", store.snapshots[1][3].Currency)
+}
+
+func TestBalanceHistory(t *testing.T) {
+	alice := common.HexToAddress("0x01")
+	store := newMemoryStore()
+	now := time.Date(2019, 1, 31, 15, 0, 0, 0, time.UTC)
+	var snapshots []BalanceSnapshot
+	for i := 0; i < 40; i++ {
+		snapshots = append(snapshots, BalanceSnapshot{
+			ChainID:   1,
+			Address:   alice,
+			Timestamp: now.Truncate(day).Add(time.Duration(i-39) * day).Unix(),
+			Balance:   (*hexutil.Big)(big.NewInt(int64(i))),
+		})
+	}
+	require.NoError(t, store.SaveBalanceSnapshots(1, snapshots))
+
+	history, err := balanceHistory(store, 1, alice, common.Address{}, 7, now)
+	require.NoError(t, err)
+	require.Len(t, history, 7, "The snapshot of today is included")
+	require.Equal(t, int64(33), history[0].Balance.ToInt().Int64())
+
+	history, err = balanceHistory(store, 1, alice, common.Address{}, 0, now)
+	require.NoError(t, err)
+	require.Len(t, history, DefaultBalanceHistoryDays)
+
+	history, err = balanceHistory(store, 1, common.HexToAddress("0x02"), common.Address{}, 7, now)
+	require.NoError(t, err)
+	require.NotNil(t, history)
+	require.Empty(t, history)
+}
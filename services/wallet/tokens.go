@@ -0,0 +1,142 @@
+package wallet
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/params"
+)
+
+var (
+	// ErrNoAccountSelected is returned when managing custom tokens while no account is selected.
+	ErrNoAccountSelected = errors.New("no account is selected")
+	// ErrInvalidToken is returned when adding a custom token without an address or a symbol,
+	// or of an unknown standard.
+	ErrInvalidToken = errors.New("invalid token: address, symbol and standard are required")
+	// ErrDefaultToken is returned when adding or deleting a custom token of the default list.
+	ErrDefaultToken = errors.New("token is in the default list")
+)
+
+// TokenStandard is the standard implemented by the contract of a token.
+type TokenStandard string
+
+const (
+	// ERC20 is a fungible token.
+	ERC20 TokenStandard = "erc20"
+	// ERC721 is a non-fungible token, whose balance is the number of tokens owned.
+	ERC721 TokenStandard = "erc721"
+)
+
+// Token is a token whose balances are fetched.
+type Token struct {
+	Address  common.Address `json:"address"`
+	Name     string         `json:"name"`
+	Symbol   string         `json:"symbol"`
+	Decimals uint           `json:"decimals"`
+	Standard TokenStandard  `json:"standard"`
+	// Custom is true for the tokens added by the account.
+	Custom bool `json:"custom"`
+}
+
+// defaultTokens are the tokens bundled for each network.
+var defaultTokens = map[uint64][]Token{
+	params.MainNetworkID: {
+		{Address: common.HexToAddress("0x744d70fdbe2ba4cf95131626614a1763df805b9e"), Name: "Status Network Token", Symbol: "SNT", Decimals: 18, Standard: ERC20},
+		{Address: common.HexToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"), Name: "Wrapped Ether", Symbol: "WETH", Decimals: 18, Standard: ERC20},
+		{Address: common.HexToAddress("0x9f8f72aa9304c8b593d555f12ef6589cc3a579a2"), Name: "Maker", Symbol: "MKR", Decimals: 18, Standard: ERC20},
+		{Address: common.HexToAddress("0x0d8775f648430679a709e98d2b0cb6250d2887ef"), Name: "Basic Attention Token", Symbol: "BAT", Decimals: 18, Standard: ERC20},
+		{Address: common.HexToAddress("0xe41d2489571d322189246dafa5ebde1f4699f498"), Name: "0x Protocol Token", Symbol: "ZRX", Decimals: 18, Standard: ERC20},
+		{Address: common.HexToAddress("0xd26114cd6ee289accf82350c8d8487fedb8a0c07"), Name: "OmiseGO", Symbol: "OMG", Decimals: 18, Standard: ERC20},
+		{Address: common.HexToAddress("0xe94327d07fc17907b4db788e5adf2ed424addff6"), Name: "Augur", Symbol: "REP", Decimals: 18, Standard: ERC20},
+		{Address: common.HexToAddress("0x06012c8cf97bead5deae237070f9587f8e7a266d"), Name: "CryptoKitties", Symbol: "CK", Decimals: 0, Standard: ERC721},
+	},
+	params.RopstenNetworkID: {
+		{Address: common.HexToAddress("0xc55cf4b03948d7ebc8b9e8bad92643703811d162"), Name: "Status Test Token", Symbol: "STT", Decimals: 18, Standard: ERC20},
+	},
+}
+
+// TokenRegistry is the list of the tokens of a network: the default tokens and the custom
+// tokens added by the selected account, persisted in its database.
+type TokenRegistry struct {
+	networkID uint64
+
+	mu    sync.RWMutex
+	store Store
+}
+
+// NewTokenRegistry returns a new TokenRegistry of a network.
+func NewTokenRegistry(networkID uint64) *TokenRegistry {
+	return &TokenRegistry{networkID: networkID}
+}
+
+// SetStore sets the store of the custom tokens of the selected account, or nil if no
+// account is selected.
+func (r *TokenRegistry) SetStore(store Store) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store = store
+}
+
+// Tokens returns the default tokens of the network, followed by the custom tokens of the
+// selected account.
+func (r *TokenRegistry) Tokens() ([]Token, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tokens := append([]Token(nil), defaultTokens[r.networkID]...)
+	if r.store == nil {
+		return tokens, nil
+	}
+	custom, err := r.store.GetCustomTokens(r.networkID)
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range custom {
+		token.Custom = true
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// AddCustomToken adds a custom token to the selected account, or replaces it.
+func (r *TokenRegistry) AddCustomToken(token Token) error {
+	if token.Address == (common.Address{}) || token.Symbol == "" ||
+		(token.Standard != ERC20 && token.Standard != ERC721) {
+		return ErrInvalidToken
+	}
+	if r.isDefault(token.Address) {
+		return ErrDefaultToken
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.store == nil {
+		return ErrNoAccountSelected
+	}
+	token.Custom = true
+	return r.store.SaveCustomToken(r.networkID, token)
+}
+
+// DeleteCustomToken deletes a custom token of the selected account.
+func (r *TokenRegistry) DeleteCustomToken(address common.Address) error {
+	if r.isDefault(address) {
+		return ErrDefaultToken
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.store == nil {
+		return ErrNoAccountSelected
+	}
+	return r.store.DeleteCustomToken(r.networkID, address)
+}
+
+func (r *TokenRegistry) isDefault(address common.Address) bool {
+	for _, token := range defaultTokens[r.networkID] {
+		if token.Address == address {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	// DefaultTransfersLimit is the number of transfers returned when no limit is set.
+	DefaultTransfersLimit = 50
+	// MaxTransfersLimit is the maximum number of transfers returned at once.
+	MaxTransfersLimit = 500
+)
+
+var (
+	// ErrInvalidCursor is returned when a cursor was not returned by GetTransfers.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrTransfersNotStarted is returned when getting transfers while no account is selected.
+	ErrTransfersNotStarted = errors.New("transfers are not indexed: no account is selected")
+)
+
+// TransferType is the type of the asset of a transfer.
+type TransferType string
+
+const (
+	// EthTransfer is a transaction transferring ether.
+	EthTransfer TransferType = "eth"
+	// Erc20Transfer is a Transfer event of an ERC-20 token.
+	Erc20Transfer TransferType = "erc20"
+)
+
+// Transfer is a transfer sent or received by an address of the account.
+type Transfer struct {
+	// ID is the hash of the transaction, followed by the index of the log for ERC-20 transfers.
+	ID   string       `json:"id"`
+	Type TransferType `json:"type"`
+	// ChainID is the chain the transfer was made on.
+	ChainID uint64 `json:"chainId"`
+	// Address is the address of the account the transfer was indexed for.
+	Address common.Address `json:"address"`
+	From    common.Address `json:"from"`
+	To      common.Address `json:"to"`
+	// Contract is the address of the token of ERC-20 transfers.
+	Contract    *common.Address `json:"contract,omitempty"`
+	Value       *hexutil.Big    `json:"value"`
+	BlockNumber hexutil.Uint64  `json:"blockNumber"`
+	BlockHash   common.Hash     `json:"blockHash"`
+	TxHash      common.Hash     `json:"transactionHash"`
+	// Timestamp is the time of the block, in seconds.
+	Timestamp hexutil.Uint64 `json:"timestamp"`
+}
+
+// TransfersCursor is the position of a transfer in the transfers of an address, which are
+// ordered from the most recent block.
+type TransfersCursor struct {
+	BlockNumber uint64
+	ID          string
+}
+
+// TransfersPage is a page of the transfers of an address.
+type TransfersPage struct {
+	Transfers []Transfer `json:"transfers"`
+	// Cursor continues from the last transfer of the page. It is empty on the last page.
+	Cursor string `json:"cursor"`
+}
+
+// Store persists the wallet data of the selected account: the transfers of its addresses,
+// its custom tokens and the snapshots of its balances, on each chain.
+type Store interface {
+	// SaveTransfers saves the transfers of an address on a chain found up to block, and
+	// records that the blocks of the chain were scanned up to block for the address, in
+	// a single transaction.
+	SaveTransfers(chainID uint64, address common.Address, transfers []Transfer, block uint64) error
+	// GetTransfersBlock returns the last block of a chain scanned for an address, or false
+	// if it was never scanned.
+	GetTransfersBlock(chainID uint64, address common.Address) (uint64, bool, error)
+	// GetTransfers returns at most limit transfers of an address on a chain preceding the
+	// cursor, from the most recent. A nil cursor starts from the most recent transfer.
+	GetTransfers(chainID uint64, address common.Address, cursor *TransfersCursor, limit int) ([]Transfer, error)
+
+	// SaveCustomToken saves a custom token of a network, or replaces it.
+	SaveCustomToken(networkID uint64, token Token) error
+	// DeleteCustomToken deletes a custom token of a network.
+	DeleteCustomToken(networkID uint64, address common.Address) error
+	// GetCustomTokens returns the custom tokens of a network, in the order they were saved.
+	GetCustomTokens(networkID uint64) ([]Token, error)
+
+	// SaveBalanceSnapshots saves the balance snapshots of a chain, replacing those of the
+	// same day, address and token.
+	SaveBalanceSnapshots(chainID uint64, snapshots []BalanceSnapshot) error
+	// GetLastBalanceSnapshot returns the timestamp of the last balance snapshot of a chain,
+	// or false if none was saved.
+	GetLastBalanceSnapshot(chainID uint64) (int64, bool, error)
+	// GetBalanceSnapshots returns the balance snapshots of a token of an address on a
+	// chain since a timestamp, from the oldest.
+	GetBalanceSnapshots(chainID uint64, address, token common.Address, since int64) ([]BalanceSnapshot, error)
+}
+
+// transfersPage returns a page of the transfers of an address on a chain, from the most
+// recent. cursor is empty for the first page, and the cursor of the previous page otherwise.
+func transfersPage(store Store, chainID uint64, address common.Address, cursor string, limit int) (*TransfersPage, error) {
+	c, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultTransfersLimit
+	} else if limit > MaxTransfersLimit {
+		limit = MaxTransfersLimit
+	}
+	transfers, err := store.GetTransfers(chainID, address, c, limit)
+	if err != nil {
+		return nil, err
+	}
+	page := &TransfersPage{Transfers: transfers}
+	if page.Transfers == nil {
+		page.Transfers = []Transfer{}
+	}
+	if len(transfers) == limit {
+		last := transfers[len(transfers)-1]
+		page.Cursor = encodeCursor(TransfersCursor{BlockNumber: uint64(last.BlockNumber), ID: last.ID})
+	}
+	return page, nil
+}
+
+func encodeCursor(c TransfersCursor) string {
+	return fmt.Sprintf("%d-%s", c.BlockNumber, c.ID)
+}
+
+func decodeCursor(cursor string) (*TransfersCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	parts := strings.SplitN(cursor, "-", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, ErrInvalidCursor
+	}
+	block, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &TransfersCursor{BlockNumber: block, ID: parts[1]}, nil
+}
@@ -0,0 +1,61 @@
+package walletconnect
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// API exposes the WalletConnect pairings and sessions over RPC.
+type API struct {
+	s *Service
+}
+
+// NewAPI returns a new API.
+func NewAPI(s *Service) *API {
+	return &API{s: s}
+}
+
+// Pair pairs with a dapp given its wc: URI.
+func (api *API) Pair(ctx context.Context, uri string) (Pairing, error) {
+	return api.s.Pair(uri)
+}
+
+// Receive passes a decrypted message of the relay to the service.
+func (api *API) Receive(ctx context.Context, topic string, message json.RawMessage) error {
+	return api.s.Receive(topic, message)
+}
+
+// Proposals returns the session proposals waiting for the user.
+func (api *API) Proposals(ctx context.Context) []Proposal {
+	return api.s.Proposals()
+}
+
+// ApproveSession approves a session proposal.
+func (api *API) ApproveSession(ctx context.Context, id uint64) (Session, error) {
+	return api.s.ApproveSession(id)
+}
+
+// RejectSession rejects a session proposal.
+func (api *API) RejectSession(ctx context.Context, id uint64) error {
+	return api.s.RejectSession(id)
+}
+
+// Sessions returns the sessions of the selected account.
+func (api *API) Sessions(ctx context.Context) []Session {
+	return api.s.Sessions()
+}
+
+// Requests returns the requests of the sessions waiting for a response, like transactions.
+func (api *API) Requests(ctx context.Context) []Request {
+	return api.s.Requests()
+}
+
+// Respond responds to a request of a session, or rejects it if errMessage is not empty.
+func (api *API) Respond(ctx context.Context, topic string, id uint64, result json.RawMessage, errMessage string) error {
+	return api.s.Respond(topic, id, result, errMessage)
+}
+
+// Disconnect ends a session.
+func (api *API) Disconnect(ctx context.Context, topic string) error {
+	return api.s.Disconnect(topic)
+}
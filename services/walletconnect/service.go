@@ -0,0 +1,745 @@
+package walletconnect
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/services/permissions"
+)
+
+const (
+	// pairingKeyPrefix prefixes the settings keys of the pairings, followed by the topic.
+	pairingKeyPrefix = "walletconnect.pairing."
+	// sessionKeyPrefix prefixes the settings keys of the sessions, followed by the topic.
+	sessionKeyPrefix = "walletconnect.session."
+	// maxRelayedRequests is the number of requests relayed to the RPC of the node at once,
+	// as signatures wait for the user. Other requests are rejected meanwhile.
+	maxRelayedRequests = 16
+)
+
+var (
+	// ErrPairingNotFound is returned for proposals received on an unknown topic.
+	ErrPairingNotFound = errors.New("pairing not found")
+	// ErrSessionNotFound is returned for unknown or expired sessions.
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrProposalNotFound is returned when approving or rejecting an unknown proposal.
+	ErrProposalNotFound = errors.New("proposal not found")
+	// ErrRequestNotFound is returned when responding to an unknown request.
+	ErrRequestNotFound = errors.New("request not found")
+	// ErrUnsupportedNamespace is returned when approving a proposal requiring other chains than
+	// the chain of the node.
+	ErrUnsupportedNamespace = errors.New("unsupported namespace")
+	// ErrNoAccountSelected is returned when approving a proposal without a selected account.
+	ErrNoAccountSelected = errors.New("no account selected")
+)
+
+// relayedMethods are the methods of the sessions handled by the RPC of the node on behalf
+// of the dapp, like a dapp in the browser. Typed data is queued until the user confirms it.
+var relayedMethods = map[string]struct{}{
+	"eth_accounts":         {},
+	"eth_chainId":          {},
+	"eth_signTypedData_v3": {},
+	"eth_signTypedData_v4": {},
+}
+
+// errTooManyRequests rejects the requests of the sessions while maxRelayedRequests are relayed.
+var errTooManyRequests = &rpcError{Code: -32005, Message: "Too many pending requests."}
+
+// methodPermissions are the permissions granted to a dapp when the user approves a session
+// allowing the methods.
+var methodPermissions = map[string]permissions.Permission{
+	"eth_sendTransaction":  permissions.Transactions,
+	"eth_signTypedData_v3": permissions.TypedData,
+	"eth_signTypedData_v4": permissions.TypedData,
+}
+
+// Store persists the pairings and the sessions of the selected account, with its settings.
+type Store interface {
+	SaveSetting(key string, value []byte) error
+	// GetSetting returns the value of a setting, or nil if it's not set.
+	GetSetting(key string) ([]byte, error)
+	DeleteSetting(key string) error
+	// GetSettings returns all the settings, by key.
+	GetSettings() (map[string][]byte, error)
+}
+
+// Wallet is the wallet of the node that the sessions use.
+type Wallet interface {
+	// Accounts returns the addresses of the selected account.
+	Accounts() ([]common.Address, error)
+	// CallDappRPC calls the RPC of the node on behalf of a dapp, given its origin.
+	CallDappRPC(origin, inputJSON string) string
+	// GrantPermissions grants permissions to a dapp, given its origin.
+	GrantPermissions(origin string, perms ...permissions.Permission) error
+	// RevokePermissions revokes all the permissions of a dapp, given its origin.
+	RevokePermissions(origin string) error
+}
+
+// Make sure that Service implements node.Service interface.
+var _ node.Service = (*Service)(nil)
+
+// Service keeps the WalletConnect v2 pairings and sessions of the selected account, and
+// handles the requests of the sessions with the wallet of the node.
+type Service struct {
+	chainID   uint64
+	metadata  Metadata
+	wallet    Wallet
+	transport Transport
+	now       func() time.Time
+	log       log.Logger
+
+	mu        sync.Mutex
+	store     Store
+	pairings  map[string]Pairing
+	sessions  map[string]Session
+	proposals map[uint64]Proposal
+	requests  map[uint64]Request
+
+	// relays holds a token for each request relayed to the RPC of the node.
+	relays chan struct{}
+}
+
+// New returns a new Service, given the chain of the node and the metadata describing the
+// wallet to dapps. Messages are exchanged with the relay through transport.
+func New(chainID uint64, metadata Metadata, wallet Wallet, transport Transport) *Service {
+	return &Service{
+		chainID:   chainID,
+		metadata:  metadata,
+		wallet:    wallet,
+		transport: transport,
+		now:       time.Now,
+		log:       log.New("package", "status-go/services/walletconnect"),
+		pairings:  make(map[string]Pairing),
+		sessions:  make(map[string]Session),
+		proposals: make(map[uint64]Proposal),
+		requests:  make(map[uint64]Request),
+		relays:    make(chan struct{}, maxRelayedRequests),
+	}
+}
+
+// SetStore loads the pairings and the sessions of the selected account, and subscribes to
+// their topics. When store is nil, they are unloaded and unsubscribed.
+func (s *Service) SetStore(store Store) error {
+	s.mu.Lock()
+	previous := s.topics()
+	s.store = store
+	s.pairings = make(map[string]Pairing)
+	s.sessions = make(map[string]Session)
+	s.proposals = make(map[uint64]Proposal)
+	s.requests = make(map[uint64]Request)
+	if store == nil {
+		s.mu.Unlock()
+		s.unsubscribe(previous...)
+		return nil
+	}
+	settings, err := store.GetSettings()
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	now := s.now().Unix()
+	for key, value := range settings {
+		var (
+			topic  string
+			expiry int64
+		)
+		switch {
+		case strings.HasPrefix(key, pairingKeyPrefix):
+			var pairing Pairing
+			if err := json.Unmarshal(value, &pairing); err != nil {
+				s.mu.Unlock()
+				return err
+			}
+			if pairing.Expiry > now {
+				s.pairings[pairing.Topic] = pairing
+			}
+			topic, expiry = pairing.Topic, pairing.Expiry
+		case strings.HasPrefix(key, sessionKeyPrefix):
+			var session Session
+			if err := json.Unmarshal(value, &session); err != nil {
+				s.mu.Unlock()
+				return err
+			}
+			if session.Expiry > now {
+				s.sessions[session.Topic] = session
+			}
+			topic, expiry = session.Topic, session.Expiry
+		default:
+			continue
+		}
+		if expiry <= now {
+			s.log.Debug("dropping expired WalletConnect topic", "topic", topic)
+			if err := store.DeleteSetting(key); err != nil {
+				s.mu.Unlock()
+				return err
+			}
+		}
+	}
+	keys := s.topicKeys()
+	s.mu.Unlock()
+
+	s.unsubscribe(previous...)
+	for topic, symKey := range keys {
+		if err := s.transport.Subscribe(topic, symKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pair pairs with a dapp given the URI it shares, usually with a QR code. The dapp then
+// proposes a session on the topic of the pairing.
+func (s *Service) Pair(uri string) (Pairing, error) {
+	pairing, err := ParseURI(uri)
+	if err != nil {
+		return Pairing{}, err
+	}
+	if pairing.Expiry == 0 {
+		pairing.Expiry = s.now().Add(PairingTTL).Unix()
+	}
+	s.mu.Lock()
+	err = s.save(pairingKeyPrefix+pairing.Topic, pairing)
+	if err == nil {
+		s.pairings[pairing.Topic] = pairing
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return Pairing{}, err
+	}
+	return pairing, s.transport.Subscribe(pairing.Topic, pairing.SymKey)
+}
+
+// Receive handles a message received from the relay on a topic, once decrypted.
+func (s *Service) Receive(topic string, message json.RawMessage) error {
+	var msg rpcMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return err
+	}
+	switch msg.Method {
+	case "":
+		// responses to our requests, like wc_sessionSettle, need nothing
+		if msg.Error != nil {
+			s.log.Warn("WalletConnect request failed", "topic", topic, "id", msg.ID, "code", msg.Error.Code, "message", msg.Error.Message)
+		}
+		return nil
+	case "wc_sessionPropose":
+		return s.receiveProposal(topic, msg)
+	case "wc_sessionRequest":
+		return s.receiveRequest(topic, msg)
+	case "wc_sessionDelete":
+		return s.receiveDelete(topic, msg)
+	case "wc_pairingDelete":
+		s.mu.Lock()
+		_, ok := s.pairings[topic]
+		err := s.deletePairing(topic)
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		if ok {
+			s.unsubscribe(topic)
+		}
+		return s.respond(topic, msg.ID, true, nil)
+	case "wc_sessionPing", "wc_pairingPing", "wc_sessionExtend", "wc_sessionUpdate", "wc_sessionEvent":
+		return s.respond(topic, msg.ID, true, nil)
+	default:
+		return s.respond(topic, msg.ID, nil, errUnsupportedMethod)
+	}
+}
+
+func (s *Service) receiveProposal(topic string, msg rpcMessage) error {
+	var params struct {
+		Relays             []Relay              `json:"relays"`
+		Proposer           Peer                 `json:"proposer"`
+		RequiredNamespaces map[string]Namespace `json:"requiredNamespaces"`
+		OptionalNamespaces map[string]Namespace `json:"optionalNamespaces"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return err
+	}
+	proposal := Proposal{
+		ID:                 msg.ID,
+		PairingTopic:       topic,
+		Proposer:           params.Proposer,
+		Relays:             params.Relays,
+		RequiredNamespaces: params.RequiredNamespaces,
+		OptionalNamespaces: params.OptionalNamespaces,
+	}
+	s.mu.Lock()
+	_, ok := s.pairings[topic]
+	if ok {
+		s.proposals[proposal.ID] = proposal
+	}
+	s.mu.Unlock()
+	if !ok {
+		return ErrPairingNotFound
+	}
+	sendProposal(proposal)
+	return nil
+}
+
+func (s *Service) receiveRequest(topic string, msg rpcMessage) error {
+	var params struct {
+		Request struct {
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		} `json:"request"`
+		ChainID string `json:"chainId"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return err
+	}
+	request := Request{
+		ID:      msg.ID,
+		Topic:   topic,
+		ChainID: params.ChainID,
+		Method:  params.Request.Method,
+		Params:  params.Request.Params,
+	}
+	s.mu.Lock()
+	session, ok := s.sessions[topic]
+	s.mu.Unlock()
+	if !ok {
+		return ErrSessionNotFound
+	}
+	if !session.Allows(request.ChainID, request.Method) {
+		return s.respond(topic, request.ID, nil, errUnauthorizedMethod)
+	}
+	if _, ok := relayedMethods[request.Method]; ok {
+		select {
+		case s.relays <- struct{}{}:
+		default:
+			return s.respond(topic, request.ID, nil, errTooManyRequests)
+		}
+		// signatures block until the user confirms them
+		go func() {
+			defer func() { <-s.relays }()
+			s.relay(session, request)
+		}()
+		return nil
+	}
+	s.mu.Lock()
+	s.requests[request.ID] = request
+	s.mu.Unlock()
+	sendRequest(request)
+	return nil
+}
+
+// relay calls the RPC of the node with a request, on behalf of the dapp of the session,
+// and publishes the response.
+func (s *Service) relay(session Session, request Request) {
+	body, err := json.Marshal(rpcMessage{
+		ID:      request.ID,
+		JSONRPC: "2.0",
+		Method:  request.Method,
+		Params:  request.Params,
+	})
+	if err != nil {
+		s.log.Error("failed to encode WalletConnect request", "id", request.ID, "err", err)
+		return
+	}
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	raw := s.wallet.CallDappRPC(session.Origin(), string(body))
+	if err := json.Unmarshal([]byte(raw), &response); err != nil {
+		response.Error = &rpcError{Code: 5000, Message: err.Error()}
+	}
+	var result interface{}
+	if response.Error == nil {
+		result = response.Result
+	}
+	if err := s.respond(session.Topic, request.ID, result, response.Error); err != nil {
+		s.log.Error("failed to respond to WalletConnect request", "id", request.ID, "err", err)
+	}
+}
+
+func (s *Service) receiveDelete(topic string, msg rpcMessage) error {
+	s.mu.Lock()
+	session, ok := s.sessions[topic]
+	err := s.deleteSession(topic)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSessionNotFound
+	}
+	if err := s.wallet.RevokePermissions(session.Origin()); err != nil {
+		return err
+	}
+	// the response is sent before unsubscribing, as the key of the topic is dropped
+	if err := s.respond(topic, msg.ID, true, nil); err != nil {
+		return err
+	}
+	s.unsubscribe(topic)
+	sendDeleted(topic)
+	return nil
+}
+
+// ApproveSession approves a proposal with the addresses of the selected account on the chain
+// of the node. The dapp is granted the permissions of the methods it proposed before the
+// session is settled, and they are revoked if it can't be.
+func (s *Service) ApproveSession(id uint64) (Session, error) {
+	s.mu.Lock()
+	proposal, ok := s.proposals[id]
+	s.mu.Unlock()
+	if !ok {
+		return Session{}, ErrProposalNotFound
+	}
+	namespace, err := s.namespace(proposal)
+	if err != nil {
+		return Session{}, err
+	}
+	keys, err := newKeyPair()
+	if err != nil {
+		return Session{}, err
+	}
+	symKey, topic, err := keys.sessionKey(proposal.Proposer.PublicKey)
+	if err != nil {
+		return Session{}, err
+	}
+	session := Session{
+		Topic:        topic,
+		SymKey:       hex.EncodeToString(symKey),
+		PairingTopic: proposal.PairingTopic,
+		Peer:         proposal.Proposer,
+		Namespaces:   map[string]Namespace{EIP155: namespace},
+		Expiry:       s.now().Add(SessionTTL).Unix(),
+	}
+
+	if err := s.wallet.GrantPermissions(session.Origin(), grantedPermissions(namespace)...); err != nil {
+		return Session{}, err
+	}
+	s.mu.Lock()
+	if _, ok := s.proposals[id]; !ok {
+		err = ErrProposalNotFound
+	} else {
+		err = s.save(sessionKeyPrefix+session.Topic, session)
+	}
+	if err == nil {
+		delete(s.proposals, id)
+		s.sessions[session.Topic] = session
+	}
+	s.mu.Unlock()
+	if err != nil {
+		s.revoke(session)
+		return Session{}, err
+	}
+
+	err = s.respond(proposal.PairingTopic, proposal.ID, map[string]interface{}{
+		"relay":              Relay{Protocol: RelayProtocol},
+		"responderPublicKey": hex.EncodeToString(keys.public[:]),
+	}, nil)
+	if err == nil {
+		err = s.transport.Subscribe(session.Topic, session.SymKey)
+	}
+	if err == nil {
+		err = s.request(session.Topic, "wc_sessionSettle", map[string]interface{}{
+			"relay":      Relay{Protocol: RelayProtocol},
+			"namespaces": session.Namespaces,
+			"controller": Peer{PublicKey: hex.EncodeToString(keys.public[:]), Metadata: s.metadata},
+			"expiry":     session.Expiry,
+		})
+	}
+	if err != nil {
+		s.mu.Lock()
+		if deleteErr := s.deleteSession(session.Topic); deleteErr != nil {
+			s.log.Error("failed to delete WalletConnect session", "topic", session.Topic, "err", deleteErr)
+		}
+		s.mu.Unlock()
+		s.unsubscribe(session.Topic)
+		s.revoke(session)
+		return Session{}, err
+	}
+	return session, nil
+}
+
+// namespace returns the eip155 namespace of a session approving a proposal, with the methods
+// and the events it requires or proposes, and the addresses of the selected account.
+func (s *Service) namespace(proposal Proposal) (Namespace, error) {
+	chainID := fmt.Sprintf("%s:%d", EIP155, s.chainID)
+	for key, required := range proposal.RequiredNamespaces {
+		if key != EIP155 && key != chainID {
+			return Namespace{}, ErrUnsupportedNamespace
+		}
+		for _, chain := range required.Chains {
+			if chain != chainID {
+				return Namespace{}, ErrUnsupportedNamespace
+			}
+		}
+	}
+	addresses, err := s.wallet.Accounts()
+	if err != nil {
+		return Namespace{}, err
+	}
+	if len(addresses) == 0 {
+		return Namespace{}, ErrNoAccountSelected
+	}
+	namespace := Namespace{Chains: []string{chainID}, Methods: []string{}, Events: []string{}}
+	for _, address := range addresses {
+		namespace.Accounts = append(namespace.Accounts, chainID+":"+address.Hex())
+	}
+	for _, namespaces := range []map[string]Namespace{proposal.RequiredNamespaces, proposal.OptionalNamespaces} {
+		for key, proposed := range namespaces {
+			if key != EIP155 && key != chainID {
+				continue
+			}
+			namespace.Methods = union(namespace.Methods, proposed.Methods)
+			namespace.Events = union(namespace.Events, proposed.Events)
+		}
+	}
+	return namespace, nil
+}
+
+// RejectSession rejects a proposal.
+func (s *Service) RejectSession(id uint64) error {
+	s.mu.Lock()
+	proposal, ok := s.proposals[id]
+	delete(s.proposals, id)
+	s.mu.Unlock()
+	if !ok {
+		return ErrProposalNotFound
+	}
+	return s.respond(proposal.PairingTopic, proposal.ID, nil, errUserRejected)
+}
+
+// Respond responds to a request of a session, with its result, or with an error if
+// errMessage is not empty, like when the user rejects a transaction.
+func (s *Service) Respond(topic string, id uint64, result json.RawMessage, errMessage string) error {
+	s.mu.Lock()
+	request, ok := s.requests[id]
+	if ok && request.Topic == topic {
+		delete(s.requests, id)
+	}
+	s.mu.Unlock()
+	if !ok || request.Topic != topic {
+		return ErrRequestNotFound
+	}
+	if errMessage != "" {
+		return s.respond(topic, id, nil, &rpcError{Code: errUserRejected.Code, Message: errMessage})
+	}
+	return s.respond(topic, id, result, nil)
+}
+
+// Disconnect ends a session, revoking the permissions of its dapp, and notifies the dapp.
+func (s *Service) Disconnect(topic string) error {
+	s.mu.Lock()
+	session, ok := s.sessions[topic]
+	err := s.deleteSession(topic)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSessionNotFound
+	}
+	if err := s.wallet.RevokePermissions(session.Origin()); err != nil {
+		return err
+	}
+	if err := s.request(topic, "wc_sessionDelete", errUserDisconnected); err != nil {
+		return err
+	}
+	s.unsubscribe(topic)
+	return nil
+}
+
+// Sessions returns the sessions, by expiry.
+func (s *Service) Sessions() []Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessions := make([]Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Expiry < sessions[j].Expiry
+	})
+	return sessions
+}
+
+// Proposals returns the proposals waiting for the user to approve or reject them.
+func (s *Service) Proposals() []Proposal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	proposals := make([]Proposal, 0, len(s.proposals))
+	for _, proposal := range s.proposals {
+		proposals = append(proposals, proposal)
+	}
+	sort.Slice(proposals, func(i, j int) bool {
+		return proposals[i].ID < proposals[j].ID
+	})
+	return proposals
+}
+
+// Requests returns the requests waiting for the response of the client.
+func (s *Service) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	requests := make([]Request, 0, len(s.requests))
+	for _, request := range s.requests {
+		requests = append(requests, request)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].ID < requests[j].ID
+	})
+	return requests
+}
+
+// respond publishes the response to a request.
+func (s *Service) respond(topic string, id uint64, result interface{}, rpcErr *rpcError) error {
+	return s.transport.Publish(topic, rpcMessage{ID: id, JSONRPC: "2.0", Result: result, Error: rpcErr})
+}
+
+// request publishes a request, whose response is ignored.
+func (s *Service) request(topic, method string, params interface{}) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return s.transport.Publish(topic, rpcMessage{
+		ID:      newMessageID(s.now()),
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  data,
+	})
+}
+
+// revoke revokes the permissions of the dapp of a session that couldn't be settled.
+func (s *Service) revoke(session Session) {
+	if err := s.wallet.RevokePermissions(session.Origin()); err != nil {
+		s.log.Error("failed to revoke the permissions of a WalletConnect session", "topic", session.Topic, "err", err)
+	}
+}
+
+func (s *Service) unsubscribe(topics ...string) {
+	for _, topic := range topics {
+		if err := s.transport.Unsubscribe(topic); err != nil {
+			s.log.Error("failed to unsubscribe from WalletConnect topic", "topic", topic, "err", err)
+		}
+	}
+}
+
+// topics returns the topics of the pairings and the sessions. It must be called with the
+// lock held.
+func (s *Service) topics() []string {
+	topics := make([]string, 0, len(s.pairings)+len(s.sessions))
+	for topic := range s.topicKeys() {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// topicKeys returns the keys of the topics of the pairings and the sessions. It must be
+// called with the lock held.
+func (s *Service) topicKeys() map[string]string {
+	keys := make(map[string]string, len(s.pairings)+len(s.sessions))
+	for topic, pairing := range s.pairings {
+		keys[topic] = pairing.SymKey
+	}
+	for topic, session := range s.sessions {
+		keys[topic] = session.SymKey
+	}
+	return keys
+}
+
+// save persists a pairing or a session, if an account is selected. It must be called with
+// the lock held.
+func (s *Service) save(key string, value interface{}) error {
+	if s.store == nil {
+		return ErrNoAccountSelected
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return s.store.SaveSetting(key, data)
+}
+
+// deletePairing must be called with the lock held.
+func (s *Service) deletePairing(topic string) error {
+	delete(s.pairings, topic)
+	if s.store == nil {
+		return nil
+	}
+	return s.store.DeleteSetting(pairingKeyPrefix + topic)
+}
+
+// deleteSession must be called with the lock held.
+func (s *Service) deleteSession(topic string) error {
+	delete(s.sessions, topic)
+	if s.store == nil {
+		return nil
+	}
+	return s.store.DeleteSetting(sessionKeyPrefix + topic)
+}
+
+// grantedPermissions returns the permissions of the methods of a namespace. The accounts are
+// always exposed to the sessions.
+func grantedPermissions(namespace Namespace) []permissions.Permission {
+	granted := []permissions.Permission{permissions.Accounts}
+	for _, method := range namespace.Methods {
+		p, ok := methodPermissions[method]
+		if !ok {
+			continue
+		}
+		found := false
+		for _, g := range granted {
+			found = found || g == p
+		}
+		if !found {
+			granted = append(granted, p)
+		}
+	}
+	return granted
+}
+
+func union(values []string, others []string) []string {
+	for _, value := range others {
+		if !contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Protocols returns a new protocols list. In this case, there are none.
+func (s *Service) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{}
+}
+
+// APIs returns a list of new APIs. They are private, so that dapps can't approve sessions.
+func (s *Service) APIs() []gethrpc.API {
+	return []gethrpc.API{
+		{
+			Namespace: "walletconnect",
+			Version:   "0.1.0",
+			Service:   NewAPI(s),
+			Public:    false,
+		},
+	}
+}
+
+// Start is run when a service is started.
+// It does nothing in this case but is required by `node.Service` interface.
+func (s *Service) Start(server *p2p.Server) error {
+	return nil
+}
+
+// Stop is run when a service is stopped.
+// It does nothing in this case but is required by `node.Service` interface.
+func (s *Service) Stop() error {
+	return nil
+}
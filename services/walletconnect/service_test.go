@@ -0,0 +1,378 @@
+package walletconnect
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/services/permissions"
+	"github.com/status-im/status-go/signal"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore map[string][]byte
+
+func (s memoryStore) SaveSetting(key string, value []byte) error {
+	s[key] = value
+	return nil
+}
+
+func (s memoryStore) GetSetting(key string) ([]byte, error) {
+	return s[key], nil
+}
+
+func (s memoryStore) DeleteSetting(key string) error {
+	delete(s, key)
+	return nil
+}
+
+func (s memoryStore) GetSettings() (map[string][]byte, error) {
+	return s, nil
+}
+
+type published struct {
+	topic   string
+	message rpcMessage
+}
+
+// testTransport records the topics and the messages, encoded like they are for the relay.
+type testTransport struct {
+	subscribed map[string]string
+	published  chan published
+}
+
+func newTestTransport() *testTransport {
+	return &testTransport{subscribed: make(map[string]string), published: make(chan published, 10)}
+}
+
+func (t *testTransport) Subscribe(topic, symKey string) error {
+	t.subscribed[topic] = symKey
+	return nil
+}
+
+func (t *testTransport) Unsubscribe(topic string) error {
+	delete(t.subscribed, topic)
+	return nil
+}
+
+func (t *testTransport) Publish(topic string, message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	var msg rpcMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	t.published <- published{topic, msg}
+	return nil
+}
+
+func (t *testTransport) next(tt *testing.T) published {
+	select {
+	case p := <-t.published:
+		return p
+	case <-time.After(time.Second):
+		tt.Fatal("no message published")
+		return published{}
+	}
+}
+
+var errPublish = errors.New("publish failed")
+
+// failingTransport fails to publish requests, like wc_sessionSettle.
+type failingTransport struct {
+	*testTransport
+}
+
+func (t *failingTransport) Publish(topic string, message interface{}) error {
+	if msg, ok := message.(rpcMessage); ok && msg.Method != "" {
+		return errPublish
+	}
+	return t.testTransport.Publish(topic, message)
+}
+
+type testWallet struct {
+	mu      sync.Mutex
+	granted map[string][]permissions.Permission
+	calls   []string
+	// block blocks the calls until it's closed, if it's not nil.
+	block chan struct{}
+}
+
+func (w *testWallet) Accounts() ([]common.Address, error) {
+	return []common.Address{common.HexToAddress("0x1dE4")}, nil
+}
+
+func (w *testWallet) CallDappRPC(origin, inputJSON string) string {
+	w.mu.Lock()
+	w.calls = append(w.calls, origin+" "+inputJSON)
+	block := w.block
+	w.mu.Unlock()
+	if block != nil {
+		<-block
+	}
+	return `{"jsonrpc":"2.0","id":1,"result":"0x65cb"}`
+}
+
+func (w *testWallet) GrantPermissions(origin string, perms ...permissions.Permission) error {
+	w.granted[origin] = perms
+	return nil
+}
+
+func (w *testWallet) RevokePermissions(origin string) error {
+	delete(w.granted, origin)
+	return nil
+}
+
+// permissionsWallet grants permissions with the permissions of the node.
+type permissionsWallet struct {
+	testWallet
+	manager *permissions.Manager
+}
+
+func (w *permissionsWallet) GrantPermissions(origin string, perms ...permissions.Permission) error {
+	_, err := w.manager.Grant(origin, perms...)
+	return err
+}
+
+func (w *permissionsWallet) RevokePermissions(origin string) error {
+	return w.manager.Revoke(origin)
+}
+
+const testPairingURI = "wc:7f6e504bfad60b485450578e05678ed3e8e8c4751d3c6160be17160d63ec90f9@2?relay-protocol=irn&symKey=" + testSymKey
+
+func newTestService() (*Service, *testTransport, *testWallet) {
+	transport := newTestTransport()
+	wallet := &testWallet{granted: make(map[string][]permissions.Permission)}
+	s := New(1, Metadata{Name: "Status"}, wallet, transport)
+	s.now = func() time.Time { return time.Unix(1000, 0) }
+	return s, transport, wallet
+}
+
+func receive(t *testing.T, s *Service, topic string, id uint64, method string, params interface{}) {
+	data, err := json.Marshal(params)
+	require.NoError(t, err)
+	message, err := json.Marshal(rpcMessage{ID: id, JSONRPC: "2.0", Method: method, Params: data})
+	require.NoError(t, err)
+	require.NoError(t, s.Receive(topic, message))
+}
+
+// propose proposes a session from a dapp, and returns the key pair of the dapp.
+func propose(t *testing.T, s *Service, pairing Pairing, id uint64) *keyPair {
+	dapp, err := newKeyPair()
+	require.NoError(t, err)
+	receive(t, s, pairing.Topic, id, "wc_sessionPropose", map[string]interface{}{
+		"relays":   []Relay{{Protocol: RelayProtocol}},
+		"proposer": Peer{PublicKey: hex.EncodeToString(dapp.public[:]), Metadata: Metadata{URL: "https://dapp.eth"}},
+		"requiredNamespaces": map[string]Namespace{
+			EIP155: {Chains: []string{"eip155:1"}, Methods: []string{"eth_sendTransaction", "eth_signTypedData_v4"}, Events: []string{"accountsChanged"}},
+		},
+	})
+	return dapp
+}
+
+func TestApproveSession(t *testing.T) {
+	s, transport, wallet := newTestService()
+	_, err := s.Pair(testPairingURI)
+	require.Equal(t, ErrNoAccountSelected, err)
+
+	store := memoryStore{}
+	require.NoError(t, s.SetStore(store))
+	pairing, err := s.Pair(testPairingURI)
+	require.NoError(t, err)
+	require.Equal(t, int64(1300), pairing.Expiry)
+	require.Equal(t, testSymKey, transport.subscribed[pairing.Topic])
+
+	signals := signal.Subscribe(signal.SubscriptionConfig{Types: []string{signal.EventWalletConnectProposal}})
+	defer signals.Unsubscribe()
+	dapp := propose(t, s, pairing, 42)
+	require.Equal(t, signal.EventWalletConnectProposal, (<-signals.Signals()).Type)
+	require.Len(t, s.Proposals(), 1)
+
+	session, err := s.ApproveSession(42)
+	require.NoError(t, err)
+	require.Empty(t, s.Proposals())
+	require.Equal(t, []string{"eip155:1:0x0000000000000000000000000000000000001De4"}, session.Namespaces[EIP155].Accounts)
+	require.Equal(t, []permissions.Permission{permissions.Accounts, permissions.Transactions, permissions.TypedData}, wallet.granted[session.Origin()])
+	require.Equal(t, "wc://"+session.Topic, session.Origin())
+	require.NotContains(t, wallet.granted, "https://dapp.eth", "Grants are never keyed by the metadata of the dapp")
+
+	response := transport.next(t)
+	require.Equal(t, pairing.Topic, response.topic)
+	require.Equal(t, uint64(42), response.message.ID)
+	responder := response.message.Result.(map[string]interface{})["responderPublicKey"].(string)
+	symKey, topic, err := dapp.sessionKey(responder)
+	require.NoError(t, err)
+	require.Equal(t, session.Topic, topic)
+	require.Equal(t, hex.EncodeToString(symKey), transport.subscribed[topic], "The dapp derives the key of the session")
+
+	settle := transport.next(t)
+	require.Equal(t, session.Topic, settle.topic)
+	require.Equal(t, "wc_sessionSettle", settle.message.Method)
+
+	// sessions are restored for the account
+	require.NoError(t, s.SetStore(nil))
+	require.Empty(t, s.Sessions())
+	require.Empty(t, transport.subscribed)
+	require.NoError(t, s.SetStore(store))
+	require.Equal(t, []Session{session}, s.Sessions())
+	require.Contains(t, transport.subscribed, session.Topic)
+
+	// until they expire
+	s.now = func() time.Time { return time.Unix(session.Expiry, 0) }
+	require.NoError(t, s.SetStore(store))
+	require.Empty(t, s.Sessions())
+	require.Empty(t, store)
+}
+
+func TestRejectSession(t *testing.T) {
+	s, transport, _ := newTestService()
+	require.NoError(t, s.SetStore(memoryStore{}))
+	pairing, err := s.Pair(testPairingURI)
+	require.NoError(t, err)
+	propose(t, s, pairing, 42)
+
+	require.NoError(t, s.RejectSession(42))
+	response := transport.next(t)
+	require.Equal(t, errUserRejected, response.message.Error)
+	require.Equal(t, ErrProposalNotFound, s.RejectSession(42))
+}
+
+func TestSessionRequests(t *testing.T) {
+	s, transport, wallet := newTestService()
+	require.NoError(t, s.SetStore(memoryStore{}))
+	pairing, err := s.Pair(testPairingURI)
+	require.NoError(t, err)
+	propose(t, s, pairing, 42)
+	session, err := s.ApproveSession(42)
+	require.NoError(t, err)
+	transport.next(t)
+	transport.next(t)
+
+	request := func(id uint64, method string) {
+		receive(t, s, session.Topic, id, "wc_sessionRequest", map[string]interface{}{
+			"chainId": "eip155:1",
+			"request": map[string]interface{}{"method": method, "params": []string{"0x1dE4"}},
+		})
+	}
+
+	// methods the session doesn't allow are rejected
+	request(1, "personal_sign")
+	require.Equal(t, errUnauthorizedMethod, transport.next(t).message.Error)
+
+	// typed data is relayed to the RPC of the node, on behalf of the dapp
+	request(2, "eth_signTypedData_v4")
+	response := transport.next(t)
+	require.Equal(t, uint64(2), response.message.ID)
+	require.Equal(t, "0x65cb", response.message.Result)
+	require.Len(t, wallet.calls, 1)
+	require.True(t, strings.HasPrefix(wallet.calls[0], session.Origin()+" "))
+
+	// transactions wait for the client
+	signals := signal.Subscribe(signal.SubscriptionConfig{Types: []string{signal.EventWalletConnectRequest}})
+	defer signals.Unsubscribe()
+	request(3, "eth_sendTransaction")
+	require.Equal(t, signal.EventWalletConnectRequest, (<-signals.Signals()).Type)
+	require.Len(t, s.Requests(), 1)
+	require.Equal(t, ErrRequestNotFound, s.Respond(pairing.Topic, 3, nil, ""))
+	require.NoError(t, s.Respond(session.Topic, 3, json.RawMessage(`"0xabcd"`), ""))
+	require.Equal(t, "0xabcd", transport.next(t).message.Result)
+	require.Empty(t, s.Requests())
+
+	require.NoError(t, s.Disconnect(session.Topic))
+	deleted := transport.next(t)
+	require.Equal(t, "wc_sessionDelete", deleted.message.Method)
+	require.Empty(t, s.Sessions())
+	require.NotContains(t, transport.subscribed, session.Topic)
+	require.Equal(t, ErrSessionNotFound, s.Disconnect(session.Topic))
+	require.NotContains(t, wallet.granted, session.Origin(), "Permissions are revoked with the session")
+}
+
+func TestSessionPermissions(t *testing.T) {
+	transport := newTestTransport()
+	manager := permissions.NewManager(func(string, permissions.Permission) {})
+	manager.SetStore(memoryStore{})
+	s := New(1, Metadata{Name: "Status"}, &permissionsWallet{manager: manager}, transport)
+	s.now = func() time.Time { return time.Unix(1000, 0) }
+	require.NoError(t, s.SetStore(memoryStore{}))
+	pairing, err := s.Pair(testPairingURI)
+	require.NoError(t, err)
+
+	propose(t, s, pairing, 42)
+	session, err := s.ApproveSession(42)
+	require.NoError(t, err)
+	require.NoError(t, manager.Check(session.Origin(), permissions.Transactions))
+	require.NoError(t, manager.Check(session.Origin(), permissions.TypedData))
+	transport.next(t)
+	settle := transport.next(t)
+	require.Equal(t, "wc_sessionSettle", settle.message.Method)
+
+	// the dapp ends the session
+	receive(t, s, session.Topic, 43, "wc_sessionDelete", errUserDisconnected)
+	transport.next(t)
+	require.Equal(t, permissions.ErrPermissionRequired, manager.Check(session.Origin(), permissions.Transactions))
+	grants, err := manager.Grants()
+	require.NoError(t, err)
+	require.Empty(t, grants)
+
+	// the user ends the session
+	propose(t, s, pairing, 44)
+	session, err = s.ApproveSession(44)
+	require.NoError(t, err)
+	transport.next(t)
+	transport.next(t)
+	require.NoError(t, s.Disconnect(session.Topic))
+	grants, err = manager.Grants()
+	require.NoError(t, err)
+	require.Empty(t, grants)
+}
+
+func TestApproveSessionRollback(t *testing.T) {
+	s, _, wallet := newTestService()
+	transport := &failingTransport{newTestTransport()}
+	s.transport = transport
+	require.NoError(t, s.SetStore(memoryStore{}))
+	pairing, err := s.Pair(testPairingURI)
+	require.NoError(t, err)
+	propose(t, s, pairing, 42)
+
+	_, err = s.ApproveSession(42)
+	require.Equal(t, errPublish, err)
+	require.Empty(t, wallet.granted, "Permissions are revoked when the session can't be settled")
+	require.Empty(t, s.Sessions())
+	require.Equal(t, map[string]string{pairing.Topic: testSymKey}, transport.subscribed)
+}
+
+func TestRelayedRequestsLimit(t *testing.T) {
+	s, transport, wallet := newTestService()
+	require.NoError(t, s.SetStore(memoryStore{}))
+	pairing, err := s.Pair(testPairingURI)
+	require.NoError(t, err)
+	propose(t, s, pairing, 42)
+	session, err := s.ApproveSession(42)
+	require.NoError(t, err)
+	transport.next(t)
+	transport.next(t)
+
+	wallet.block = make(chan struct{})
+	for id := uint64(1); id <= maxRelayedRequests+1; id++ {
+		receive(t, s, session.Topic, id, "wc_sessionRequest", map[string]interface{}{
+			"chainId": "eip155:1",
+			"request": map[string]interface{}{"method": "eth_signTypedData_v4", "params": []string{"0x1dE4"}},
+		})
+	}
+	response := transport.next(t)
+	require.Equal(t, uint64(maxRelayedRequests+1), response.message.ID)
+	require.Equal(t, errTooManyRequests, response.message.Error)
+
+	close(wallet.block)
+	for i := 0; i < maxRelayedRequests; i++ {
+		require.Equal(t, "0x65cb", transport.next(t).message.Result)
+	}
+}
@@ -0,0 +1,50 @@
+package walletconnect
+
+import (
+	"github.com/status-im/status-go/signal"
+)
+
+// Transport exchanges the messages of the topics with the relay.
+type Transport interface {
+	// Subscribe requests the messages of a topic, encrypted with symKey.
+	Subscribe(topic, symKey string) error
+	// Unsubscribe stops the messages of a topic.
+	Unsubscribe(topic string) error
+	// Publish encrypts a JSON-RPC message with the key of the topic and publishes it.
+	Publish(topic string, message interface{}) error
+}
+
+// SignalTransport delegates the relay to the client with signals: it keeps the websocket
+// of the relay, encrypts and decrypts the envelopes of the messages with the keys of the
+// topics, and passes the messages it receives to walletconnect_receive.
+type SignalTransport struct{}
+
+// Subscribe sends the walletconnect.subscribe signal.
+func (SignalTransport) Subscribe(topic, symKey string) error {
+	signal.SendWalletConnectSubscribe(topic, symKey)
+	return nil
+}
+
+// Unsubscribe sends the walletconnect.unsubscribe signal.
+func (SignalTransport) Unsubscribe(topic string) error {
+	signal.SendWalletConnectUnsubscribe(topic)
+	return nil
+}
+
+// Publish sends the walletconnect.publish signal.
+func (SignalTransport) Publish(topic string, message interface{}) error {
+	signal.SendWalletConnectPublish(topic, message)
+	return nil
+}
+
+func sendProposal(proposal Proposal) {
+	signal.SendWalletConnectProposal(proposal)
+}
+
+func sendRequest(request Request) {
+	signal.SendWalletConnectRequest(request)
+}
+
+func sendDeleted(topic string) {
+	signal.SendWalletConnectDeleted(topic)
+}
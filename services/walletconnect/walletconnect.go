@@ -0,0 +1,227 @@
+package walletconnect
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// RelayProtocol is the protocol of the relay of the topics.
+	RelayProtocol = "irn"
+	// EIP155 is the namespace of Ethereum chains.
+	EIP155 = "eip155"
+
+	// PairingTTL is how long a pairing waits for a session proposal.
+	PairingTTL = 5 * time.Minute
+	// SessionTTL is how long a session lasts.
+	SessionTTL = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidURI is returned when pairing with a URI that is not a WalletConnect v2 URI.
+	ErrInvalidURI = errors.New("invalid WalletConnect v2 URI")
+	// ErrInvalidPublicKey is returned for proposals whose public key is not an X25519 key.
+	ErrInvalidPublicKey = errors.New("invalid public key")
+)
+
+// Metadata describes a peer of a session, like a dapp.
+type Metadata struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	URL         string   `json:"url"`
+	Icons       []string `json:"icons"`
+}
+
+// Peer is a participant of a session, identified by its X25519 public key.
+type Peer struct {
+	PublicKey string   `json:"publicKey"`
+	Metadata  Metadata `json:"metadata"`
+}
+
+// Relay is the relay of the messages of a topic.
+type Relay struct {
+	Protocol string `json:"protocol"`
+}
+
+// Namespace is what a session allows on a family of chains, like eip155.
+type Namespace struct {
+	Chains   []string `json:"chains,omitempty"`
+	Accounts []string `json:"accounts,omitempty"`
+	Methods  []string `json:"methods"`
+	Events   []string `json:"events"`
+}
+
+// Pairing is the topic a dapp proposes sessions on, shared with a URI.
+type Pairing struct {
+	Topic  string `json:"topic"`
+	SymKey string `json:"symKey"`
+	Relay  Relay  `json:"relay"`
+	// Expiry is the time the pairing is dropped, in seconds.
+	Expiry int64 `json:"expiry"`
+}
+
+// Proposal is a session proposed by a dapp, until the user approves or rejects it.
+type Proposal struct {
+	ID                 uint64               `json:"id"`
+	PairingTopic       string               `json:"pairingTopic"`
+	Proposer           Peer                 `json:"proposer"`
+	Relays             []Relay              `json:"relays"`
+	RequiredNamespaces map[string]Namespace `json:"requiredNamespaces"`
+	OptionalNamespaces map[string]Namespace `json:"optionalNamespaces,omitempty"`
+}
+
+// Session is a session approved by the user. Its messages are encrypted with SymKey.
+type Session struct {
+	Topic        string               `json:"topic"`
+	SymKey       string               `json:"symKey"`
+	PairingTopic string               `json:"pairingTopic"`
+	Peer         Peer                 `json:"peer"`
+	Namespaces   map[string]Namespace `json:"namespaces"`
+	// Expiry is the time the session ends, in seconds.
+	Expiry int64 `json:"expiry"`
+}
+
+// Origin returns the origin the dapp of the session calls the node and is granted permissions
+// with. It's named after the topic of the session, as the metadata of the peer is chosen by
+// the dapp and could name any other dapp.
+func (s Session) Origin() string {
+	return "wc://" + s.Topic
+}
+
+// Allows returns true if the session allows a method on a chain, like eip155:1.
+func (s Session) Allows(chainID, method string) bool {
+	namespace, ok := s.Namespaces[strings.SplitN(chainID, ":", 2)[0]]
+	if !ok || !contains(namespace.Chains, chainID) {
+		return false
+	}
+	return contains(namespace.Methods, method)
+}
+
+// Request is a request of a session that the client completes, like eth_sendTransaction,
+// which is signed with the password of the account.
+type Request struct {
+	ID      uint64          `json:"id"`
+	Topic   string          `json:"topic"`
+	ChainID string          `json:"chainId"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// ParseURI parses a pairing URI, like wc:7f6e...@2?relay-protocol=irn&symKey=587d...
+func ParseURI(uri string) (Pairing, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "wc" {
+		return Pairing{}, ErrInvalidURI
+	}
+	parts := strings.SplitN(u.Opaque, "@", 2)
+	if len(parts) != 2 || parts[1] != "2" || parts[0] == "" {
+		return Pairing{}, ErrInvalidURI
+	}
+	query := u.Query()
+	symKey, err := hex.DecodeString(query.Get("symKey"))
+	if err != nil || len(symKey) != 32 {
+		return Pairing{}, ErrInvalidURI
+	}
+	pairing := Pairing{
+		Topic:  parts[0],
+		SymKey: hex.EncodeToString(symKey),
+		Relay:  Relay{Protocol: query.Get("relay-protocol")},
+	}
+	if pairing.Relay.Protocol != RelayProtocol {
+		return Pairing{}, fmt.Errorf("unsupported relay protocol %q", pairing.Relay.Protocol)
+	}
+	if expiry := query.Get("expiryTimestamp"); expiry != "" {
+		if pairing.Expiry, err = strconv.ParseInt(expiry, 10, 64); err != nil {
+			return Pairing{}, ErrInvalidURI
+		}
+	}
+	return pairing, nil
+}
+
+// keyPair is an X25519 key pair agreeing on the key of a session.
+type keyPair struct {
+	private [32]byte
+	public  [32]byte
+}
+
+func newKeyPair() (*keyPair, error) {
+	k := new(keyPair)
+	if _, err := io.ReadFull(rand.Reader, k.private[:]); err != nil {
+		return nil, err
+	}
+	curve25519.ScalarBaseMult(&k.public, &k.private)
+	return k, nil
+}
+
+// sessionKey returns the key of the session with a peer, derived with HKDF-SHA256 from
+// the X25519 shared secret, and its topic, the SHA-256 of the key.
+func (k *keyPair) sessionKey(peerPublicKey string) (symKey []byte, topic string, err error) {
+	decoded, err := hex.DecodeString(peerPublicKey)
+	if err != nil || len(decoded) != 32 {
+		return nil, "", ErrInvalidPublicKey
+	}
+	var peer, shared [32]byte
+	copy(peer[:], decoded)
+	curve25519.ScalarMult(&shared, &k.private, &peer)
+	symKey = make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared[:], nil, nil), symKey); err != nil {
+		return nil, "", err
+	}
+	hash := sha256.Sum256(symKey)
+	return symKey, hex.EncodeToString(hash[:]), nil
+}
+
+// rpcMessage is a JSON-RPC request or response exchanged through the relay.
+type rpcMessage struct {
+	ID      uint64          `json:"id"`
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error codes of the WalletConnect v2 protocol.
+var (
+	errUserRejected       = &rpcError{Code: 5000, Message: "User rejected."}
+	errUserDisconnected   = &rpcError{Code: 6000, Message: "User disconnected."}
+	errUnauthorizedMethod = &rpcError{Code: 3001, Message: "Unauthorized method."}
+	errUnsupportedMethod  = &rpcError{Code: 10001, Message: "Unsupported method."}
+)
+
+// newMessageID returns a JSON-RPC ID like the WalletConnect clients: the time in
+// milliseconds followed by three random digits.
+func newMessageID(now time.Time) uint64 {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000))
+	if err != nil {
+		n = big.NewInt(0)
+	}
+	return uint64(now.UnixNano()/int64(time.Millisecond))*1000 + n.Uint64()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
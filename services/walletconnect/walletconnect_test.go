@@ -0,0 +1,62 @@
+package walletconnect
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSymKey = "587d5484ce2a2a6ee3ba1962fdd7e8588e06200c46823bd18fbd67def96ad303"
+
+func TestParseURI(t *testing.T) {
+	pairing, err := ParseURI("wc:7f6e504bfad60b485450578e05678ed3e8e8c4751d3c6160be17160d63ec90f9@2?relay-protocol=irn&symKey=" + testSymKey + "&expiryTimestamp=1705000000")
+	require.NoError(t, err)
+	require.Equal(t, Pairing{
+		Topic:  "7f6e504bfad60b485450578e05678ed3e8e8c4751d3c6160be17160d63ec90f9",
+		SymKey: testSymKey,
+		Relay:  Relay{Protocol: RelayProtocol},
+		Expiry: 1705000000,
+	}, pairing)
+
+	for _, invalid := range []string{
+		"",
+		"https://example.com",
+		"wc:00e46b69-d0cc-4b3e-b6a2-cee442f97188@1?bridge=https%3A%2F%2Fbridge.walletconnect.org&key=" + testSymKey,
+		"wc:@2?relay-protocol=irn&symKey=" + testSymKey,
+		"wc:7f6e@2?relay-protocol=irn&symKey=587d",
+	} {
+		_, err := ParseURI(invalid)
+		require.Equal(t, ErrInvalidURI, err, invalid)
+	}
+	_, err = ParseURI("wc:7f6e@2?relay-protocol=waku&symKey=" + testSymKey)
+	require.EqualError(t, err, `unsupported relay protocol "waku"`)
+}
+
+func TestSessionKey(t *testing.T) {
+	wallet, err := newKeyPair()
+	require.NoError(t, err)
+	dapp, err := newKeyPair()
+	require.NoError(t, err)
+
+	walletKey, walletTopic, err := wallet.sessionKey(hex.EncodeToString(dapp.public[:]))
+	require.NoError(t, err)
+	dappKey, dappTopic, err := dapp.sessionKey(hex.EncodeToString(wallet.public[:]))
+	require.NoError(t, err)
+	require.Len(t, walletKey, 32)
+	require.Equal(t, walletKey, dappKey)
+	require.Equal(t, walletTopic, dappTopic)
+
+	_, _, err = wallet.sessionKey("0x1234")
+	require.Equal(t, ErrInvalidPublicKey, err)
+}
+
+func TestSessionAllows(t *testing.T) {
+	session := Session{Namespaces: map[string]Namespace{
+		EIP155: {Chains: []string{"eip155:1"}, Methods: []string{"eth_sendTransaction"}},
+	}}
+	require.True(t, session.Allows("eip155:1", "eth_sendTransaction"))
+	require.False(t, session.Allows("eip155:1", "personal_sign"))
+	require.False(t, session.Allows("eip155:5", "eth_sendTransaction"))
+	require.False(t, session.Allows("cosmos:cosmoshub-4", "eth_sendTransaction"))
+}
@@ -0,0 +1,266 @@
+package signal
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+)
+
+const (
+	// EventSignalsDropped is sent through the bridge when signals were dropped because
+	// the queue was full, before the signals sent after them.
+	EventSignalsDropped = "signals.dropped"
+
+	// DefaultBridgeWindow is the default number of signals sent without being acknowledged.
+	DefaultBridgeWindow = 64
+	// DefaultBridgeQueueSize is the default number of signals kept until they are acknowledged.
+	DefaultBridgeQueueSize = 10000
+)
+
+// ErrBridgeStarted is returned when starting the bridge twice.
+var ErrBridgeStarted = errors.New("signal bridge is already started")
+
+// SignalsDroppedSignal holds the number of signals dropped by the bridge.
+type SignalsDroppedSignal struct {
+	Count int `json:"count"`
+}
+
+// BridgeConfig configures the flow control of the bridge.
+type BridgeConfig struct {
+	// Window is the number of signals sent without being acknowledged.
+	Window int
+	// QueueSize is the number of signals kept until they are acknowledged.
+	// Signals sent when the queue is full are dropped and reported with EventSignalsDropped.
+	QueueSize int
+}
+
+// DefaultBridgeConfig returns the default configuration of the bridge.
+func DefaultBridgeConfig() BridgeConfig {
+	return BridgeConfig{Window: DefaultBridgeWindow, QueueSize: DefaultBridgeQueueSize}
+}
+
+// Bridge streams signals to an embedder connected to a local socket, as an alternative
+// to the callback, which blocks the node or drops signals when the embedder is slow.
+//
+// Each signal is written as a 4-byte big-endian length followed by its JSON envelope.
+// The embedder acknowledges the signals it processed by writing their count as a 4-byte
+// big-endian integer. At most Window signals are sent without being acknowledged, the
+// others are queued. Signals that were not acknowledged are sent again if the embedder
+// reconnects. A single embedder is connected at a time, a new connection replaces the
+// previous one.
+type Bridge struct {
+	listener net.Listener
+	config   BridgeConfig
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// queue are the signals not acknowledged yet. The first sent of them were
+	// sent to the current connection.
+	queue   [][]byte
+	sent    int
+	dropped int
+	conn    net.Conn
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// ListenBridge creates a bridge listening on the UNIX socket at path, replacing any
+// stale socket. The socket is only accessible to the user running the node.
+func ListenBridge(path string, config BridgeConfig) (*Bridge, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close() // nolint: errcheck
+		return nil, err
+	}
+
+	b := &Bridge{listener: listener, config: config}
+	b.cond = sync.NewCond(&b.mu)
+	b.wg.Add(1)
+	go b.accept()
+	return b, nil
+}
+
+// Send queues a signal, encoded as JSON.
+func (b *Bridge) Send(data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	if len(b.queue) >= b.config.QueueSize {
+		b.dropped++
+		return
+	}
+	b.queue = append(b.queue, data)
+	b.cond.Broadcast()
+}
+
+// Close stops listening and disconnects the embedder. Signals that were not
+// acknowledged are discarded.
+func (b *Bridge) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	if b.conn != nil {
+		b.conn.Close() // nolint: errcheck
+	}
+	b.cond.Broadcast()
+	b.mu.Unlock()
+
+	err := b.listener.Close()
+	b.wg.Wait()
+	return err
+}
+
+func (b *Bridge) accept() {
+	defer b.wg.Done()
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			conn.Close() // nolint: errcheck
+			return
+		}
+		if b.conn != nil {
+			b.conn.Close() // nolint: errcheck
+		}
+		b.conn = conn
+		b.sent = 0
+		b.cond.Broadcast()
+		b.mu.Unlock()
+
+		b.wg.Add(2)
+		go b.write(conn)
+		go b.read(conn)
+	}
+}
+
+// write sends the queued signals to the connection while the window allows it.
+func (b *Bridge) write(conn net.Conn) {
+	defer b.wg.Done()
+	for {
+		b.mu.Lock()
+		for !b.closed && b.conn == conn && (b.sent >= len(b.queue) || b.sent >= b.config.Window) {
+			b.cond.Wait()
+		}
+		if b.closed || b.conn != conn {
+			b.mu.Unlock()
+			return
+		}
+		data := b.queue[b.sent]
+		b.sent++
+		b.mu.Unlock()
+
+		frame := make([]byte, 4+len(data))
+		binary.BigEndian.PutUint32(frame, uint32(len(data)))
+		copy(frame[4:], data)
+		if _, err := conn.Write(frame); err != nil {
+			logger.Debug("signal bridge failed to write", "error", err)
+			b.disconnect(conn)
+			return
+		}
+	}
+}
+
+// read removes the signals acknowledged by the connection from the queue.
+func (b *Bridge) read(conn net.Conn) {
+	defer b.wg.Done()
+	ack := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(conn, ack); err != nil {
+			b.disconnect(conn)
+			return
+		}
+
+		b.mu.Lock()
+		if b.conn != conn {
+			b.mu.Unlock()
+			return
+		}
+		count := int(binary.BigEndian.Uint32(ack))
+		if count > b.sent {
+			count = b.sent
+		}
+		b.queue = b.queue[count:]
+		b.sent -= count
+		if b.dropped > 0 && len(b.queue) < b.config.QueueSize {
+			if data, err := json.Marshal(NewEnvelope(EventSignalsDropped, SignalsDroppedSignal{Count: b.dropped})); err == nil {
+				b.queue = append(b.queue, data)
+				b.dropped = 0
+			}
+		}
+		b.cond.Broadcast()
+		b.mu.Unlock()
+	}
+}
+
+func (b *Bridge) disconnect(conn net.Conn) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	conn.Close() // nolint: errcheck
+	if b.conn == conn {
+		b.conn = nil
+		b.sent = 0
+		b.cond.Broadcast()
+	}
+}
+
+var (
+	bridge      *Bridge
+	bridgeMutex sync.RWMutex
+)
+
+// StartBridge starts streaming signals to the UNIX socket at path instead of the callback.
+func StartBridge(path string, config BridgeConfig) error {
+	bridgeMutex.Lock()
+	defer bridgeMutex.Unlock()
+	if bridge != nil {
+		return ErrBridgeStarted
+	}
+	b, err := ListenBridge(path, config)
+	if err != nil {
+		return err
+	}
+	bridge = b
+	return nil
+}
+
+// StopBridge stops the bridge started with StartBridge, if any. Signals are sent
+// to the callback again.
+func StopBridge() error {
+	bridgeMutex.Lock()
+	defer bridgeMutex.Unlock()
+	if bridge == nil {
+		return nil
+	}
+	err := bridge.Close()
+	bridge = nil
+	return err
+}
+
+// sendToBridge sends a signal through the bridge and returns true if it is started.
+func sendToBridge(data []byte) bool {
+	bridgeMutex.RLock()
+	defer bridgeMutex.RUnlock()
+	if bridge == nil {
+		return false
+	}
+	bridge.Send(data)
+	return true
+}
@@ -0,0 +1,127 @@
+package signal
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBridge(t *testing.T, config BridgeConfig) (*Bridge, string, func()) {
+	dir, err := ioutil.TempDir("", "bridge")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "signals.sock")
+	b, err := ListenBridge(path, config)
+	require.NoError(t, err)
+	return b, path, func() {
+		require.NoError(t, b.Close())
+		os.RemoveAll(dir)
+	}
+}
+
+func readSignal(t *testing.T, conn net.Conn) string {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	header := make([]byte, 4)
+	_, err := io.ReadFull(conn, header)
+	require.NoError(t, err)
+	data := make([]byte, binary.BigEndian.Uint32(header))
+	_, err = io.ReadFull(conn, data)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func requireNoSignal(t *testing.T, conn net.Conn) {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+	_, err := conn.Read(make([]byte, 1))
+	require.Error(t, err)
+	netErr, ok := err.(net.Error)
+	require.True(t, ok && netErr.Timeout(), "It doesn't send signals beyond the window")
+}
+
+func ack(t *testing.T, conn net.Conn, count uint32) {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, count)
+	_, err := conn.Write(data)
+	require.NoError(t, err)
+}
+
+func TestBridgeFlowControl(t *testing.T) {
+	b, path, stop := newTestBridge(t, BridgeConfig{Window: 2, QueueSize: 10})
+	defer stop()
+
+	// Signals sent before the embedder connects are queued
+	b.Send([]byte("1"))
+	b.Send([]byte("2"))
+	b.Send([]byte("3"))
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Equal(t, "1", readSignal(t, conn))
+	require.Equal(t, "2", readSignal(t, conn))
+	requireNoSignal(t, conn)
+
+	ack(t, conn, 1)
+	require.Equal(t, "3", readSignal(t, conn))
+
+	// Signals that were not acknowledged are sent again on reconnection
+	conn.Close()
+	conn, err = net.Dial("unix", path)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Equal(t, "2", readSignal(t, conn))
+	require.Equal(t, "3", readSignal(t, conn))
+}
+
+func TestBridgeDropped(t *testing.T) {
+	b, path, stop := newTestBridge(t, BridgeConfig{Window: 1, QueueSize: 1})
+	defer stop()
+
+	b.Send([]byte("1"))
+	b.Send([]byte("2"))
+	b.Send([]byte("3"))
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Equal(t, "1", readSignal(t, conn))
+	ack(t, conn, 1)
+
+	var envelope struct {
+		Type  string
+		Event SignalsDroppedSignal
+	}
+	require.NoError(t, json.Unmarshal([]byte(readSignal(t, conn)), &envelope))
+	require.Equal(t, EventSignalsDropped, envelope.Type)
+	require.Equal(t, 2, envelope.Event.Count)
+}
+
+func TestStartBridge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "signals.sock")
+
+	require.NoError(t, StartBridge(path, DefaultBridgeConfig()))
+	defer StopBridge() // nolint: errcheck
+	require.Equal(t, ErrBridgeStarted, StartBridge(path, DefaultBridgeConfig()))
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	defer conn.Close()
+	SendSegmentsProgress([32]byte{1}, 1, 2)
+
+	var envelope Envelope
+	require.NoError(t, json.Unmarshal([]byte(readSignal(t, conn)), &envelope))
+	require.Equal(t, EventSegmentsProgress, envelope.Type)
+
+	require.NoError(t, StopBridge())
+	require.NoError(t, StopBridge(), "It does nothing if the bridge is stopped")
+}
@@ -0,0 +1,158 @@
+package signal
+
+import (
+	"sync"
+)
+
+// DefaultSubscriptionBuffer is the number of signals buffered by subscriptions that
+// don't set a buffer size.
+const DefaultSubscriptionBuffer = 100
+
+// DropPolicy decides which signal is dropped when a signal is published to a
+// subscription whose buffer is full.
+type DropPolicy int
+
+const (
+	// DropNewest drops the signal published, keeping those buffered.
+	DropNewest DropPolicy = iota
+	// DropOldest drops the oldest signal buffered to make room for the one published.
+	DropOldest
+)
+
+// SubscriptionConfig configures a subscription.
+type SubscriptionConfig struct {
+	// Types are the event types of the signals received, all of them if empty.
+	Types []string
+	// BufferSize is the number of signals buffered until they are received.
+	BufferSize int
+	// Policy decides which signal is dropped when the buffer is full.
+	Policy DropPolicy
+}
+
+// Subscription receives the signals of some event types on its own buffered channel,
+// so that a slow subscriber neither blocks the node nor the other subscribers.
+type Subscription struct {
+	bus    *Bus
+	types  map[string]struct{}
+	policy DropPolicy
+	ch     chan Envelope
+
+	mu      sync.Mutex
+	dropped int
+	closed  bool
+}
+
+// Signals returns the channel of the signals, closed once unsubscribed. The events
+// of the envelopes are the signal structs, like MessageNotificationSignal, not JSON.
+func (s *Subscription) Signals() <-chan Envelope {
+	return s.ch
+}
+
+// Dropped returns the number of signals dropped because the buffer was full.
+func (s *Subscription) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Unsubscribe stops the subscription and closes its channel. Buffered signals can
+// still be received.
+func (s *Subscription) Unsubscribe() {
+	s.bus.mu.Lock()
+	delete(s.bus.subscriptions, s)
+	s.bus.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+func (s *Subscription) matches(typ string) bool {
+	if len(s.types) == 0 {
+		return true
+	}
+	_, ok := s.types[typ]
+	return ok
+}
+
+// deliver buffers a signal without blocking, applying the drop policy if the buffer is full.
+func (s *Subscription) deliver(envelope Envelope) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.ch <- envelope:
+		return
+	default:
+	}
+
+	s.dropped++
+	if s.policy != DropOldest {
+		return
+	}
+	select {
+	case <-s.ch:
+	default:
+	}
+	select {
+	case s.ch <- envelope:
+	default:
+	}
+}
+
+// Bus publishes the signals to the subscriptions of their event types.
+type Bus struct {
+	mu            sync.RWMutex
+	subscriptions map[*Subscription]struct{}
+}
+
+// NewBus returns a new Bus.
+func NewBus() *Bus {
+	return &Bus{subscriptions: make(map[*Subscription]struct{})}
+}
+
+// Subscribe returns a new subscription.
+func (b *Bus) Subscribe(config SubscriptionConfig) *Subscription {
+	size := config.BufferSize
+	if size <= 0 {
+		size = DefaultSubscriptionBuffer
+	}
+	s := &Subscription{
+		bus:    b,
+		types:  make(map[string]struct{}, len(config.Types)),
+		policy: config.Policy,
+		ch:     make(chan Envelope, size),
+	}
+	for _, typ := range config.Types {
+		s.types[typ] = struct{}{}
+	}
+
+	b.mu.Lock()
+	b.subscriptions[s] = struct{}{}
+	b.mu.Unlock()
+	return s
+}
+
+// Publish sends a signal to the subscriptions of its event type.
+func (b *Bus) Publish(envelope Envelope) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subscriptions {
+		if s.matches(envelope.Type) {
+			s.deliver(envelope)
+		}
+	}
+}
+
+var defaultBus = NewBus()
+
+// Subscribe subscribes to the signals sent by the node. Signals are also sent to the
+// callback, or the bridge, as JSON.
+func Subscribe(config SubscriptionConfig) *Subscription {
+	return defaultBus.Subscribe(config)
+}
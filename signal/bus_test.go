@@ -0,0 +1,71 @@
+package signal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func receiveAll(s *Subscription) []string {
+	var types []string
+	for {
+		select {
+		case envelope, ok := <-s.Signals():
+			if !ok {
+				return types
+			}
+			types = append(types, envelope.Type)
+		default:
+			return types
+		}
+	}
+}
+
+func TestBusSubscriptions(t *testing.T) {
+	b := NewBus()
+	all := b.Subscribe(SubscriptionConfig{})
+	typing := b.Subscribe(SubscriptionConfig{Types: []string{EventContactTyping}})
+
+	b.Publish(*NewEnvelope(EventContactTyping, ContactTypingSignal{Author: "0x04a1", Typing: true}))
+	b.Publish(*NewEnvelope(EventMessagesRead, MessagesReadSignal{Reader: "0x04a1"}))
+
+	envelope := <-typing.Signals()
+	require.Equal(t, ContactTypingSignal{Author: "0x04a1", Typing: true}, envelope.Event, "Events are not encoded")
+	require.Empty(t, receiveAll(typing), "Subscriptions only receive their types")
+	require.Equal(t, []string{EventContactTyping, EventMessagesRead}, receiveAll(all))
+
+	typing.Unsubscribe()
+	typing.Unsubscribe()
+	b.Publish(*NewEnvelope(EventContactTyping, ContactTypingSignal{}))
+	_, ok := <-typing.Signals()
+	require.False(t, ok, "The channel is closed once unsubscribed")
+	require.Equal(t, []string{EventContactTyping}, receiveAll(all))
+}
+
+func TestBusDropPolicies(t *testing.T) {
+	b := NewBus()
+	newest := b.Subscribe(SubscriptionConfig{BufferSize: 2, Policy: DropNewest})
+	oldest := b.Subscribe(SubscriptionConfig{BufferSize: 2, Policy: DropOldest})
+	for _, typ := range []string{"a", "b", "c"} {
+		b.Publish(*NewEnvelope(typ, nil))
+	}
+
+	require.Equal(t, []string{"a", "b"}, receiveAll(newest))
+	require.Equal(t, []string{"b", "c"}, receiveAll(oldest))
+	require.Equal(t, 1, newest.Dropped())
+	require.Equal(t, 1, oldest.Dropped())
+}
+
+func TestSubscribe(t *testing.T) {
+	var received []string
+	SetDefaultNodeNotificationHandler(func(event string) { received = append(received, event) })
+	defer ResetDefaultNodeNotificationHandler()
+	s := Subscribe(SubscriptionConfig{Types: []string{EventContactTyping}})
+	defer s.Unsubscribe()
+
+	SendContactTyping("0x04a1", true)
+	envelope := <-s.Signals()
+	require.Equal(t, ContactTypingSignal{Author: "0x04a1", Typing: true}, envelope.Event)
+	require.Equal(t, []string{`{"type":"contact.typing","event":{"author":"0x04a1","typing":true}}`}, received,
+		"Signals are still sent to the callback in JSON")
+}
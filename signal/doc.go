@@ -2,4 +2,13 @@
 // and externally linked codebases like status-react or status-desktop.
 // Events are send asynchronously using OS-specific linking mechanisms. See sources
 // for implementation details.
+//
+// Desktop embedders can instead start a Bridge, which streams events to a local
+// UNIX socket with acknowledgment-based flow control, so that events are queued
+// rather than dropped when the embedder is slow. See Bridge for the wire format.
+//
+// Go consumers subscribe to the event types they need with Subscribe. Each
+// subscription has its own buffered channel and drop policy, and receives the
+// signal structs rather than JSON. The callback and the bridge still receive all
+// the signals in JSON, for the mobile embedders.
 package signal
@@ -0,0 +1,23 @@
+package signal
+
+const (
+	// EventHealthDegraded is triggered when a health check of a subsystem of the node fails
+	EventHealthDegraded = "health.degraded"
+	// EventHealthRecovered is triggered when a degraded subsystem passes its health check again
+	EventHealthRecovered = "health.recovered"
+)
+
+// HealthEvent holds the result of the health check of a subsystem
+type HealthEvent struct {
+	Result interface{} `json:"result"`
+}
+
+// SendHealthDegraded sends a signal when a subsystem of the node is degraded.
+func SendHealthDegraded(result interface{}) {
+	send(EventHealthDegraded, HealthEvent{Result: result})
+}
+
+// SendHealthRecovered sends a signal when a degraded subsystem of the node recovers.
+func SendHealthRecovered(result interface{}) {
+	send(EventHealthRecovered, HealthEvent{Result: result})
+}
@@ -16,6 +16,9 @@ const (
 
 	// EventChainDataRemoved is triggered when node's chain data is removed
 	EventChainDataRemoved = "chaindata.removed"
+
+	// EventNodeError is triggered when a service fails in a way the client should know about
+	EventNodeError = "node.error"
 )
 
 // NodeCrashEvent is special kind of error, used to report node crashes
@@ -23,6 +26,11 @@ type NodeCrashEvent struct {
 	Error string `json:"error"`
 }
 
+// NodeErrorSignal holds the structured report of an error of a service
+type NodeErrorSignal struct {
+	Report interface{} `json:"report"`
+}
+
 // SendNodeCrashed emits a signal when status node has crashed, and
 // provides error description.
 func SendNodeCrashed(err error) {
@@ -53,3 +61,8 @@ func SendNodeStopped() {
 func SendChainDataRemoved() {
 	send(EventChainDataRemoved, nil)
 }
+
+// SendNodeError emits a signal with the structured report of an error of a service.
+func SendNodeError(report interface{}) {
+	send(EventNodeError, NodeErrorSignal{Report: report})
+}
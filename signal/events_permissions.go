@@ -0,0 +1,17 @@
+package signal
+
+const (
+	// EventPermissionRequest is triggered when a dapp needs a permission the user didn't grant
+	EventPermissionRequest = "permissions.request"
+)
+
+// PermissionRequestEvent holds the permission a dapp needs, so that the client prompts the user
+type PermissionRequestEvent struct {
+	Origin     string `json:"origin"`
+	Permission string `json:"permission"`
+}
+
+// SendPermissionRequest sends a signal prompting the user to grant a permission to a dapp.
+func SendPermissionRequest(origin, permission string) {
+	send(EventPermissionRequest, PermissionRequestEvent{Origin: origin, Permission: permission})
+}
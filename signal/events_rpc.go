@@ -0,0 +1,31 @@
+package signal
+
+const (
+	// EventSubscriptionsData is triggered with a notification of a subscription made with eth_subscribe
+	EventSubscriptionsData = "subscriptions.data"
+
+	// EventSubscriptionsError is triggered when a subscription made with eth_subscribe fails and is removed
+	EventSubscriptionsError = "subscriptions.error"
+)
+
+// SubscriptionDataEvent holds a notification of a subscription, like the params of an eth_subscription notification
+type SubscriptionDataEvent struct {
+	SubscriptionID string      `json:"subscription"`
+	Result         interface{} `json:"result"`
+}
+
+// SubscriptionErrorEvent holds the error which removed a subscription
+type SubscriptionErrorEvent struct {
+	SubscriptionID string `json:"subscription"`
+	Error          string `json:"error"`
+}
+
+// SendSubscriptionData sends a signal with a notification of a subscription.
+func SendSubscriptionData(id string, result interface{}) {
+	send(EventSubscriptionsData, SubscriptionDataEvent{SubscriptionID: id, Result: result})
+}
+
+// SendSubscriptionError sends a signal when a subscription fails and is removed.
+func SendSubscriptionError(id string, err error) {
+	send(EventSubscriptionsError, SubscriptionErrorEvent{SubscriptionID: id, Error: err.Error()})
+}
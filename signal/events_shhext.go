@@ -28,6 +28,75 @@ const (
 
 	// EventBundleAdded is triggered when we receive a bundle
 	EventBundleAdded = "bundles.added"
+
+	// EventMessageTranslated is triggered when a received message has been translated
+	EventMessageTranslated = "messages.translated"
+
+	// EventGroupReceiptsUpdated is triggered when the aggregated receipts of a group message change
+	EventGroupReceiptsUpdated = "messages.receipts.updated"
+
+	// EventModerationListChanged is triggered when the moderator of a public chat publishes a new list
+	EventModerationListChanged = "moderation.list.changed"
+
+	// EventMessageFlagged is triggered when a message is received from an author not allowed in a moderated chat
+	EventMessageFlagged = "messages.flagged"
+
+	// EventMessagesDecrypted is triggered when messages that previously failed to decrypt are decrypted
+	EventMessagesDecrypted = "messages.decrypted"
+
+	// EventPeerThrottled is triggered when envelopes received from a peer on a topic are dropped for exceeding the rate limit
+	EventPeerThrottled = "peer.throttled"
+
+	// EventConsistencyRepaired is triggered when divergences between the persisted state and Whisper are repaired at login
+	EventConsistencyRepaired = "consistency.repaired"
+
+	// EventSegmentsProgress is triggered when a segment of a payload split in several envelopes is received
+	EventSegmentsProgress = "segments.progress"
+
+	// EventAttachmentStateChanged is triggered when the download of a received attachment completes or fails
+	EventAttachmentStateChanged = "attachments.state.changed"
+
+	// EventNotificationPreferencesChanged is triggered when notification preferences synced by another device are applied
+	EventNotificationPreferencesChanged = "notifications.preferences.changed"
+
+	// EventMessageStateChanged is triggered when a reaction, an edit or a deletion sent or received changes a message
+	EventMessageStateChanged = "messages.state.changed"
+
+	// EventResponseIntegrityFailed is triggered when the response of a mail server doesn't match its signed summary
+	EventResponseIntegrityFailed = "mailserver.response.integrity.failed"
+
+	// EventMessagesRead is triggered when a contact sent a read receipt
+	EventMessagesRead = "messages.read"
+
+	// EventContactTyping is triggered when a contact started or stopped typing
+	EventContactTyping = "contact.typing"
+
+	// EventContactRequestReceived is triggered when an identity that is not allowed to contact us sends its first message
+	EventContactRequestReceived = "contact.request.received"
+
+	// EventMessageNotification is triggered when a message is received in a chat that is not muted
+	EventMessageNotification = "messages.notification"
+
+	// EventNotificationGroup is triggered with the notifications of the messages received in a chat at once
+	EventNotificationGroup = "messages.notification.group"
+
+	// EventSecurityDowngrade is triggered when a contact who used PFS sends a message that is not encrypted with PFS
+	EventSecurityDowngrade = "security.downgrade"
+
+	// EventSettingChanged is triggered when a setting of the account is changed or deleted
+	EventSettingChanged = "settings.changed"
+
+	// EventHistorySynced is triggered when records of the history synced by another device of the account are applied
+	EventHistorySynced = "history.synced"
+
+	// EventWipeConfirmed is triggered when an installation wiped by the account confirms it was wiped
+	EventWipeConfirmed = "wipe.confirmed"
+
+	// EventInstallationWiped is triggered when the installation was wiped by another installation of the account
+	EventInstallationWiped = "installation.wiped"
+
+	// EventMailServerUntrusted is triggered when a mail server returned too many envelopes that didn't match the requests
+	EventMailServerUntrusted = "mailserver.untrusted"
 )
 
 // EnvelopeSignal includes hash of the envelope.
@@ -41,6 +110,21 @@ type MailServerResponseSignal struct {
 	LastEnvelopeHash common.Hash `json:"lastEnvelopeHash"`
 	Cursor           string      `json:"cursor"`
 	ErrorMsg         string      `json:"errorMessage"`
+	// ErrorCode is the code of the error, like "RATE_LIMITED" if the request was rejected by
+	// a rate limit of the mailserver, or "UNAUTHORIZED" if the client is not authorized.
+	ErrorCode string `json:"errorCode,omitempty"`
+	// RetryAfter is the number of seconds after which a rate limited request can be retried.
+	RetryAfter int `json:"retryAfter,omitempty"`
+}
+
+// codedError is the error of a request rejected by the mailserver with a code.
+type codedError interface {
+	Code() string
+}
+
+// rateLimitedError is the error of a request rejected by a rate limit of the mailserver.
+type rateLimitedError interface {
+	RetryAfterSeconds() int
 }
 
 // DecryptMessageFailedSignal holds the sender of the message that could not be decrypted
@@ -54,6 +138,139 @@ type BundleAddedSignal struct {
 	InstallationID string `json:"installationID"`
 }
 
+// MessageTranslatedSignal holds the translation of a received message
+type MessageTranslatedSignal struct {
+	Hash        common.Hash `json:"hash"`
+	Language    string      `json:"language"`
+	Translation string      `json:"translation"`
+}
+
+// GroupReceiptsSignal holds the aggregated receipts of a group message
+type GroupReceiptsSignal struct {
+	MessageID string `json:"messageID"`
+	Total     int    `json:"total"`
+	Delivered int    `json:"delivered"`
+	Read      int    `json:"read"`
+}
+
+// ModerationListSignal holds the list published by the moderator of a public chat
+type ModerationListSignal struct {
+	ChatID    string   `json:"chatID"`
+	Moderator string   `json:"moderator"`
+	Allow     []string `json:"allow"`
+	Deny      []string `json:"deny"`
+	Clock     uint64   `json:"clock"`
+}
+
+// MessageFlaggedSignal holds a message received from an author not allowed in a moderated chat
+type MessageFlaggedSignal struct {
+	Hash   common.Hash `json:"hash"`
+	ChatID string      `json:"chatID"`
+	Author string      `json:"author"`
+}
+
+// MessagesDecryptedSignal holds the messages that were decrypted after being kept in the inbox
+type MessagesDecryptedSignal struct {
+	Messages interface{} `json:"messages"`
+}
+
+// PeerThrottledSignal holds the peer and the topic of the envelopes dropped for exceeding the rate limit
+type PeerThrottledSignal struct {
+	PeerID string `json:"peerID"`
+	Topic  string `json:"topic"`
+}
+
+// SegmentsProgressSignal holds the number of segments of a payload received so far
+type SegmentsProgressSignal struct {
+	Hash     common.Hash `json:"hash"`
+	Received int         `json:"received"`
+	Total    int         `json:"total"`
+}
+
+// AttachmentStateSignal holds the state of a received attachment
+type AttachmentStateSignal struct {
+	Hash  string `json:"hash"`
+	State string `json:"state"`
+}
+
+// NotificationPreferencesSignal holds the notification preferences synced by another device
+type NotificationPreferencesSignal struct {
+	Preferences interface{} `json:"preferences"`
+}
+
+// MessageStateSignal holds the state of a message after the reactions, edits and deletions applied to it
+type MessageStateSignal struct {
+	State interface{} `json:"state"`
+}
+
+// ResponseIntegritySignal holds the outcome of the verification of a mail server response
+type ResponseIntegritySignal struct {
+	Result interface{} `json:"result"`
+}
+
+// MessagesReadSignal holds the messages read by a contact
+type MessagesReadSignal struct {
+	Reader     string   `json:"reader"`
+	MessageIDs []string `json:"messageIds"`
+}
+
+// ContactTypingSignal holds whether a contact started or stopped typing
+type ContactTypingSignal struct {
+	Author string `json:"author"`
+	Typing bool   `json:"typing"`
+}
+
+// ContactRequestSignal holds the request of an identity that is not allowed to contact us
+type ContactRequestSignal struct {
+	Request interface{} `json:"request"`
+}
+
+// MessageNotificationSignal holds the notification of a message received in a chat that is not muted
+type MessageNotificationSignal struct {
+	Notification interface{} `json:"notification"`
+}
+
+// NotificationGroupSignal holds the notifications of the messages received in a chat at once
+type NotificationGroupSignal struct {
+	Group interface{} `json:"group"`
+}
+
+// SecurityDowngradeSignal holds the downgrade of the encryption of a contact
+type SecurityDowngradeSignal struct {
+	Downgrade interface{} `json:"downgrade"`
+}
+
+// SettingChangedSignal holds the new value of a setting, null if it was deleted
+type SettingChangedSignal struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// HistorySyncedSignal holds the number of records synced by another device and applied, by kind
+type HistorySyncedSignal struct {
+	Stats interface{} `json:"stats"`
+}
+
+// WipeConfirmedSignal holds a wipe confirmed by the wiped installation
+type WipeConfirmedSignal struct {
+	Wipe interface{} `json:"wipe"`
+}
+
+// InstallationWipedSignal holds the installation that issued the wipe of this one
+type InstallationWipedSignal struct {
+	IssuedBy string `json:"issuedBy"`
+}
+
+// MailServerUntrustedSignal holds the trust score of a mail server that became untrusted
+type MailServerUntrustedSignal struct {
+	Score interface{} `json:"score"`
+}
+
+// ConsistencyRepairedSignal holds the divergences repaired at login
+type ConsistencyRepairedSignal struct {
+	Report interface{} `json:"report"`
+}
+
 // SendEnvelopeSent triggered when envelope delivered at least to 1 peer.
 func SendEnvelopeSent(hash common.Hash) {
 	send(EventEnvelopeSent, EnvelopeSignal{hash})
@@ -76,6 +293,12 @@ func SendMailServerRequestCompleted(requestID common.Hash, lastEnvelopeHash comm
 		Cursor:           hex.EncodeToString(cursor),
 		ErrorMsg:         errorMsg,
 	}
+	if coded, ok := err.(codedError); ok {
+		sig.ErrorCode = coded.Code()
+	}
+	if rateLimited, ok := err.(rateLimitedError); ok {
+		sig.RetryAfter = rateLimited.RetryAfterSeconds()
+	}
 	send(EventMailServerRequestCompleted, sig)
 }
 
@@ -106,3 +329,95 @@ func SendDecryptMessageFailed(sender string) {
 func SendBundleAdded(identity string, installationID string) {
 	send(EventBundleAdded, BundleAddedSignal{Identity: identity, InstallationID: installationID})
 }
+
+func SendMessageTranslated(hash common.Hash, language string, translation string) {
+	send(EventMessageTranslated, MessageTranslatedSignal{Hash: hash, Language: language, Translation: translation})
+}
+
+func SendGroupReceiptsUpdated(messageID string, total, delivered, read int) {
+	send(EventGroupReceiptsUpdated, GroupReceiptsSignal{MessageID: messageID, Total: total, Delivered: delivered, Read: read})
+}
+
+func SendModerationListChanged(chatID, moderator string, allow, deny []string, clock uint64) {
+	send(EventModerationListChanged, ModerationListSignal{ChatID: chatID, Moderator: moderator, Allow: allow, Deny: deny, Clock: clock})
+}
+
+func SendMessageFlagged(hash common.Hash, chatID, author string) {
+	send(EventMessageFlagged, MessageFlaggedSignal{Hash: hash, ChatID: chatID, Author: author})
+}
+
+func SendMessagesDecrypted(messages interface{}) {
+	send(EventMessagesDecrypted, MessagesDecryptedSignal{Messages: messages})
+}
+
+func SendPeerThrottled(peerID, topic string) {
+	send(EventPeerThrottled, PeerThrottledSignal{PeerID: peerID, Topic: topic})
+}
+
+func SendConsistencyRepaired(report interface{}) {
+	send(EventConsistencyRepaired, ConsistencyRepairedSignal{Report: report})
+}
+
+func SendSegmentsProgress(hash common.Hash, received, total int) {
+	send(EventSegmentsProgress, SegmentsProgressSignal{Hash: hash, Received: received, Total: total})
+}
+
+func SendAttachmentStateChanged(hash, state string) {
+	send(EventAttachmentStateChanged, AttachmentStateSignal{Hash: hash, State: state})
+}
+
+func SendNotificationPreferencesChanged(preferences interface{}) {
+	send(EventNotificationPreferencesChanged, NotificationPreferencesSignal{Preferences: preferences})
+}
+
+func SendMessageStateChanged(state interface{}) {
+	send(EventMessageStateChanged, MessageStateSignal{State: state})
+}
+
+func SendResponseIntegrityFailed(result interface{}) {
+	send(EventResponseIntegrityFailed, ResponseIntegritySignal{Result: result})
+}
+
+func SendMessagesRead(reader string, messageIDs []string) {
+	send(EventMessagesRead, MessagesReadSignal{Reader: reader, MessageIDs: messageIDs})
+}
+
+func SendContactTyping(author string, typing bool) {
+	send(EventContactTyping, ContactTypingSignal{Author: author, Typing: typing})
+}
+
+func SendContactRequestReceived(request interface{}) {
+	send(EventContactRequestReceived, ContactRequestSignal{Request: request})
+}
+
+func SendMessageNotification(notification interface{}) {
+	send(EventMessageNotification, MessageNotificationSignal{Notification: notification})
+}
+
+func SendNotificationGroup(group interface{}) {
+	send(EventNotificationGroup, NotificationGroupSignal{Group: group})
+}
+
+func SendSecurityDowngrade(downgrade interface{}) {
+	send(EventSecurityDowngrade, SecurityDowngradeSignal{Downgrade: downgrade})
+}
+
+func SendSettingChanged(key string, value interface{}) {
+	send(EventSettingChanged, SettingChangedSignal{Key: key, Value: value})
+}
+
+func SendHistorySynced(stats interface{}) {
+	send(EventHistorySynced, HistorySyncedSignal{Stats: stats})
+}
+
+func SendWipeConfirmed(wipe interface{}) {
+	send(EventWipeConfirmed, WipeConfirmedSignal{Wipe: wipe})
+}
+
+func SendInstallationWiped(issuedBy string) {
+	send(EventInstallationWiped, InstallationWipedSignal{IssuedBy: issuedBy})
+}
+
+func SendMailServerUntrusted(score interface{}) {
+	send(EventMailServerUntrusted, MailServerUntrustedSignal{Score: score})
+}
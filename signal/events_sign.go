@@ -5,6 +5,10 @@ const (
 	EventSignRequestAdded = "sign-request.queued"
 	// EventSignRequestFailed is triggered when send transaction request fails
 	EventSignRequestFailed = "sign-request.failed"
+	// EventSignHashRequest is triggered when a hash must be signed with a key of the platform keystore
+	EventSignHashRequest = "sign-hash.request"
+	// EventUnsignedTransactionsRestored is triggered when transactions waiting for an external signature are restored after a restart
+	EventUnsignedTransactionsRestored = "transactions.unsigned.restored"
 )
 
 // PendingRequestEvent is a signal sent when a sign request is added
@@ -36,3 +40,26 @@ func SendSignRequestFailed(event PendingRequestEvent, err error, errCode int) {
 			ErrorCode:           errCode,
 		})
 }
+
+// SignHashRequestEvent is a signal sent when a hash must be signed with a key of the platform keystore
+type SignHashRequestEvent struct {
+	ID    string `json:"id"`
+	KeyID string `json:"keyId"`
+	Hash  string `json:"hash"`
+}
+
+// SendSignHashRequest sends a signal requesting the signature of a hash by a key of the platform keystore.
+func SendSignHashRequest(id, keyID, hash string) {
+	send(EventSignHashRequest, SignHashRequestEvent{ID: id, KeyID: keyID, Hash: hash})
+}
+
+// UnsignedTransactionsRestoredEvent is a signal sent when transactions waiting for an external signature are restored
+type UnsignedTransactionsRestoredEvent struct {
+	Transactions interface{} `json:"transactions"`
+}
+
+// SendUnsignedTransactionsRestored sends a signal with the transactions waiting for an external signature,
+// restored when the account logs in again.
+func SendUnsignedTransactionsRestored(transactions interface{}) {
+	send(EventUnsignedTransactionsRestored, UnsignedTransactionsRestoredEvent{Transactions: transactions})
+}
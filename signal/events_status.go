@@ -0,0 +1,16 @@
+package signal
+
+const (
+	// EventBackupCreated is triggered when a scheduled backup of the account is created.
+	EventBackupCreated = "backup.created"
+)
+
+// BackupCreatedEvent contains a backup of the account, encrypted with its passphrase.
+type BackupCreatedEvent struct {
+	Backup string `json:"backup"`
+}
+
+// SendBackupCreated sends a backup.created signal with a hex-encoded backup.
+func SendBackupCreated(backup string) {
+	send(EventBackupCreated, BackupCreatedEvent{Backup: backup})
+}
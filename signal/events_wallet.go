@@ -0,0 +1,17 @@
+package signal
+
+const (
+	// EventNewTransfers is triggered when new transfers of an address of the account are indexed
+	EventNewTransfers = "wallet.transfers.new"
+)
+
+// NewTransfersEvent is a signal sent when new transfers of an address are indexed
+type NewTransfersEvent struct {
+	Address   string      `json:"address"`
+	Transfers interface{} `json:"transfers"`
+}
+
+// SendNewTransfers sends a signal with the transfers of an address found in new blocks.
+func SendNewTransfers(address string, transfers interface{}) {
+	send(EventNewTransfers, NewTransfersEvent{Address: address, Transfers: transfers})
+}
@@ -0,0 +1,68 @@
+package signal
+
+const (
+	// EventWalletConnectSubscribe is triggered when the relay must deliver the messages of a topic
+	EventWalletConnectSubscribe = "walletconnect.subscribe"
+	// EventWalletConnectUnsubscribe is triggered when the messages of a topic are no longer needed
+	EventWalletConnectUnsubscribe = "walletconnect.unsubscribe"
+	// EventWalletConnectPublish is triggered when a message must be published to the relay
+	EventWalletConnectPublish = "walletconnect.publish"
+	// EventWalletConnectProposal is triggered when a dapp proposes a session
+	EventWalletConnectProposal = "walletconnect.session.proposal"
+	// EventWalletConnectRequest is triggered when a session requests what the client must confirm, like a transaction
+	EventWalletConnectRequest = "walletconnect.session.request"
+	// EventWalletConnectDeleted is triggered when a dapp ends a session
+	EventWalletConnectDeleted = "walletconnect.session.deleted"
+)
+
+// WalletConnectTopicEvent holds a topic of the relay, with the key encrypting its messages when subscribing
+type WalletConnectTopicEvent struct {
+	Topic  string `json:"topic"`
+	SymKey string `json:"symKey,omitempty"`
+}
+
+// WalletConnectPublishEvent holds a JSON-RPC message to encrypt and publish to the relay
+type WalletConnectPublishEvent struct {
+	Topic   string      `json:"topic"`
+	Message interface{} `json:"message"`
+}
+
+// WalletConnectProposalEvent holds a session proposed by a dapp
+type WalletConnectProposalEvent struct {
+	Proposal interface{} `json:"proposal"`
+}
+
+// WalletConnectRequestEvent holds a request of a session waiting for the response of the client
+type WalletConnectRequestEvent struct {
+	Request interface{} `json:"request"`
+}
+
+// SendWalletConnectSubscribe sends a signal to subscribe to the messages of a topic of the relay.
+func SendWalletConnectSubscribe(topic, symKey string) {
+	send(EventWalletConnectSubscribe, WalletConnectTopicEvent{Topic: topic, SymKey: symKey})
+}
+
+// SendWalletConnectUnsubscribe sends a signal to unsubscribe from a topic of the relay.
+func SendWalletConnectUnsubscribe(topic string) {
+	send(EventWalletConnectUnsubscribe, WalletConnectTopicEvent{Topic: topic})
+}
+
+// SendWalletConnectPublish sends a signal to publish a message to a topic of the relay.
+func SendWalletConnectPublish(topic string, message interface{}) {
+	send(EventWalletConnectPublish, WalletConnectPublishEvent{Topic: topic, Message: message})
+}
+
+// SendWalletConnectProposal sends a signal with a session proposed by a dapp.
+func SendWalletConnectProposal(proposal interface{}) {
+	send(EventWalletConnectProposal, WalletConnectProposalEvent{Proposal: proposal})
+}
+
+// SendWalletConnectRequest sends a signal with a request of a session to confirm.
+func SendWalletConnectRequest(request interface{}) {
+	send(EventWalletConnectRequest, WalletConnectRequestEvent{Request: request})
+}
+
+// SendWalletConnectDeleted sends a signal when a dapp ends a session.
+func SendWalletConnectDeleted(topic string) {
+	send(EventWalletConnectDeleted, WalletConnectTopicEvent{Topic: topic})
+}
@@ -35,15 +35,27 @@ func NewEnvelope(typ string, event interface{}) *Envelope {
 	}
 }
 
-// send sends application signal (in JSON) upwards to application (via default notification handler)
+// send publishes a signal to the subscriptions of its type, then sends it upwards to
+// application in JSON (via default notification handler, or the bridge if started)
 func send(typ string, event interface{}) {
 	signal := NewEnvelope(typ, event)
-	data, err := json.Marshal(&signal)
+	defaultBus.Publish(*signal)
+	sendJSON(signal)
+}
+
+// sendJSON is the compatibility shim of the embedders that don't subscribe, which
+// receive all the signals in JSON through a single callback, synchronously.
+func sendJSON(signal *Envelope) {
+	data, err := json.Marshal(signal)
 	if err != nil {
 		logger.Error("Marshalling signal envelope", "error", err)
 		return
 	}
 
+	if sendToBridge(data) {
+		return
+	}
+
 	str := C.CString(string(data))
 	C.StatusServiceSignalEvent(str)
 	C.free(unsafe.Pointer(str))
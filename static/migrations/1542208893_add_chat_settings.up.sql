@@ -0,0 +1,4 @@
+CREATE TABLE chat_settings (
+  topic BLOB NOT NULL PRIMARY KEY ON CONFLICT REPLACE,
+  language TEXT NOT NULL DEFAULT ''
+);
@@ -0,0 +1,24 @@
+CREATE TABLE ratchet_info_v2 (
+  bundle_id BLOB NOT NULL,
+  ephemeral_key BLOB,
+  identity BLOB NOT NULL,
+  symmetric_key BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  UNIQUE(bundle_id, identity, installation_id) ON CONFLICT REPLACE,
+  FOREIGN KEY (bundle_id) REFERENCES bundles(signed_pre_key)
+);
+
+INSERT INTO ratchet_info_v2(bundle_id, ephemeral_key, identity, symmetric_key, installation_id)
+SELECT bundle_id, ephemeral_key, identity, symmetric_key, installation_id FROM ratchet_info_v3 WHERE account = '';
+
+DROP TABLE ratchet_info_v3;
+
+CREATE TABLE chat_settings (
+  topic BLOB NOT NULL PRIMARY KEY ON CONFLICT REPLACE,
+  language TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO chat_settings(topic, language)
+SELECT topic, language FROM chat_settings_v2 WHERE account = '';
+
+DROP TABLE chat_settings_v2;
@@ -0,0 +1,27 @@
+CREATE TABLE ratchet_info_v3 (
+  bundle_id BLOB NOT NULL,
+  ephemeral_key BLOB,
+  identity BLOB NOT NULL,
+  symmetric_key BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  account TEXT NOT NULL DEFAULT '',
+  UNIQUE(account, bundle_id, identity, installation_id) ON CONFLICT REPLACE,
+  FOREIGN KEY (bundle_id) REFERENCES bundles(signed_pre_key)
+);
+
+INSERT INTO ratchet_info_v3(bundle_id, ephemeral_key, identity, symmetric_key, installation_id)
+SELECT bundle_id, ephemeral_key, identity, symmetric_key, installation_id FROM ratchet_info_v2;
+
+DROP TABLE ratchet_info_v2;
+
+CREATE TABLE chat_settings_v2 (
+  account TEXT NOT NULL DEFAULT '',
+  topic BLOB NOT NULL,
+  language TEXT NOT NULL DEFAULT '',
+  UNIQUE(account, topic) ON CONFLICT REPLACE
+);
+
+INSERT INTO chat_settings_v2(topic, language)
+SELECT topic, language FROM chat_settings;
+
+DROP TABLE chat_settings;
@@ -0,0 +1,8 @@
+CREATE TABLE group_receipts (
+  account TEXT NOT NULL DEFAULT '',
+  message_id TEXT NOT NULL,
+  total INTEGER NOT NULL,
+  delivered INTEGER NOT NULL DEFAULT 0,
+  read INTEGER NOT NULL DEFAULT 0,
+  UNIQUE(account, message_id) ON CONFLICT REPLACE
+);
@@ -0,0 +1,14 @@
+CREATE TABLE installations_old (
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  timestamp UNSIGNED BIG INT NOT NULL,
+  enabled BOOLEAN DEFAULT 1,
+  UNIQUE(identity, installation_id) ON CONFLICT REPLACE
+);
+
+INSERT INTO installations_old(identity, installation_id, timestamp, enabled)
+SELECT identity, installation_id, timestamp, enabled FROM installations;
+
+DROP TABLE installations;
+
+ALTER TABLE installations_old RENAME TO installations;
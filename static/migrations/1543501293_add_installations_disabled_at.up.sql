@@ -0,0 +1,2 @@
+ALTER TABLE installations ADD COLUMN disabled_at UNSIGNED BIG INT NOT NULL DEFAULT 0;
+UPDATE installations SET disabled_at = CAST(strftime('%s', 'now') AS INTEGER) WHERE enabled = 0;
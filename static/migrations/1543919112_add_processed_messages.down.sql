@@ -0,0 +1,2 @@
+DROP INDEX processed_messages_timestamp;
+DROP TABLE processed_messages;
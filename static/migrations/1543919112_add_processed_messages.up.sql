@@ -0,0 +1,10 @@
+CREATE TABLE processed_messages (
+  account TEXT NOT NULL DEFAULT '',
+  sender BLOB NOT NULL,
+  message_hash BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  timestamp UNSIGNED BIG INT NOT NULL,
+  UNIQUE(account, sender, message_hash, installation_id) ON CONFLICT REPLACE
+);
+
+CREATE INDEX processed_messages_timestamp ON processed_messages(timestamp);
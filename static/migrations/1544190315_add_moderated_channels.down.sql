@@ -0,0 +1 @@
+DROP TABLE moderated_channels;
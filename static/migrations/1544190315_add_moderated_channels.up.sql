@@ -0,0 +1,9 @@
+CREATE TABLE moderated_channels (
+  account TEXT NOT NULL DEFAULT '',
+  topic BLOB NOT NULL,
+  chat_id TEXT NOT NULL,
+  moderator TEXT NOT NULL,
+  mode INTEGER NOT NULL DEFAULT 0,
+  list BLOB,
+  UNIQUE(account, topic) ON CONFLICT REPLACE
+);
@@ -0,0 +1 @@
+DROP TABLE envelope_states;
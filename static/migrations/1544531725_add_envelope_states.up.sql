@@ -0,0 +1,7 @@
+CREATE TABLE envelope_states (
+  account TEXT NOT NULL DEFAULT '',
+  hash BLOB NOT NULL,
+  state INTEGER NOT NULL,
+  updated_at INTEGER NOT NULL,
+  UNIQUE(account, hash)
+);
@@ -0,0 +1,6 @@
+CREATE TABLE contact_mailservers (
+  account TEXT NOT NULL DEFAULT '',
+  public_key BLOB NOT NULL,
+  enode TEXT NOT NULL,
+  UNIQUE(account, public_key)
+);
@@ -0,0 +1 @@
+DROP TABLE compression_dictionaries;
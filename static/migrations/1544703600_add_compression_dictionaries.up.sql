@@ -0,0 +1,7 @@
+CREATE TABLE compression_dictionaries (
+  account TEXT NOT NULL DEFAULT '',
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  versions TEXT NOT NULL,
+  UNIQUE(account, identity, installation_id)
+);
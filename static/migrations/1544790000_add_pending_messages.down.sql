@@ -0,0 +1,2 @@
+DROP INDEX pending_messages_sender;
+DROP TABLE pending_messages;
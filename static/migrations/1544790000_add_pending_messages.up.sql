@@ -0,0 +1,11 @@
+CREATE TABLE pending_messages (
+  account TEXT NOT NULL DEFAULT '',
+  hash BLOB NOT NULL,
+  sender BLOB NOT NULL,
+  message BLOB NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  received_at INT NOT NULL,
+  UNIQUE(account, hash)
+);
+
+CREATE INDEX pending_messages_sender ON pending_messages(account, sender);
@@ -0,0 +1,7 @@
+CREATE TABLE contact_topics (
+  account TEXT NOT NULL DEFAULT '',
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  topic BLOB NOT NULL,
+  UNIQUE(account, identity, installation_id)
+);
@@ -0,0 +1,8 @@
+CREATE TABLE contact_capabilities (
+  account TEXT NOT NULL DEFAULT '',
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  version INT NOT NULL,
+  features TEXT NOT NULL,
+  UNIQUE(account, identity, installation_id)
+);
@@ -0,0 +1,9 @@
+CREATE TABLE segments (
+  account TEXT NOT NULL DEFAULT '',
+  hash BLOB NOT NULL,
+  idx INT NOT NULL,
+  count INT NOT NULL,
+  data BLOB NOT NULL,
+  received_at INT NOT NULL,
+  UNIQUE(account, hash, idx)
+);
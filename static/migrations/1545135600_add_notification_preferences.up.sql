@@ -0,0 +1,6 @@
+CREATE TABLE notification_preferences (
+  account TEXT NOT NULL DEFAULT '',
+  preferences BLOB NOT NULL,
+  clock INT NOT NULL,
+  UNIQUE(account) ON CONFLICT REPLACE
+);
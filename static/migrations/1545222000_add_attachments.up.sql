@@ -0,0 +1,12 @@
+CREATE TABLE attachments (
+  account TEXT NOT NULL DEFAULT '',
+  hash BLOB NOT NULL,
+  id TEXT NOT NULL,
+  key BLOB NOT NULL,
+  content_type TEXT NOT NULL,
+  size INT NOT NULL,
+  state INT NOT NULL,
+  data BLOB,
+  accessed_at INT NOT NULL,
+  UNIQUE(account, hash) ON CONFLICT REPLACE
+);
@@ -0,0 +1,3 @@
+DROP TABLE message_reactions;
+DROP TABLE message_changes;
+DROP TABLE message_authors;
@@ -0,0 +1,26 @@
+CREATE TABLE message_authors (
+  account TEXT NOT NULL DEFAULT '',
+  id TEXT NOT NULL,
+  author TEXT NOT NULL,
+  UNIQUE(account, id) ON CONFLICT IGNORE
+);
+
+CREATE TABLE message_changes (
+  account TEXT NOT NULL DEFAULT '',
+  target_id TEXT NOT NULL,
+  author TEXT NOT NULL,
+  content TEXT NOT NULL,
+  deleted BOOLEAN NOT NULL,
+  clock INT NOT NULL,
+  UNIQUE(account, target_id, author) ON CONFLICT REPLACE
+);
+
+CREATE TABLE message_reactions (
+  account TEXT NOT NULL DEFAULT '',
+  target_id TEXT NOT NULL,
+  author TEXT NOT NULL,
+  emoji TEXT NOT NULL,
+  retracted BOOLEAN NOT NULL,
+  clock INT NOT NULL,
+  UNIQUE(account, target_id, author, emoji) ON CONFLICT REPLACE
+);
@@ -0,0 +1,6 @@
+-- The full-text index is created by the persistence, when SQLite supports it.
+DROP TRIGGER IF EXISTS history_messages_au;
+DROP TRIGGER IF EXISTS history_messages_ad;
+DROP TRIGGER IF EXISTS history_messages_ai;
+DROP TABLE IF EXISTS history_messages_fts;
+DROP TABLE history_messages;
@@ -0,0 +1,17 @@
+CREATE TABLE history_messages (
+  account TEXT NOT NULL DEFAULT '',
+  id TEXT NOT NULL,
+  chat_id TEXT NOT NULL,
+  author TEXT NOT NULL,
+  content TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  message_type TEXT NOT NULL,
+  reply_to TEXT NOT NULL,
+  clock INT NOT NULL,
+  timestamp INT NOT NULL,
+  outgoing BOOLEAN NOT NULL,
+  edited BOOLEAN NOT NULL DEFAULT 0,
+  UNIQUE(account, id) ON CONFLICT IGNORE
+);
+
+CREATE INDEX history_messages_chat_clock ON history_messages(account, chat_id, clock, id);
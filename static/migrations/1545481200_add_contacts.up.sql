@@ -0,0 +1,7 @@
+CREATE TABLE contacts (
+  account TEXT NOT NULL DEFAULT '',
+  public_key TEXT NOT NULL,
+  name TEXT NOT NULL,
+  warnings TEXT NOT NULL,
+  UNIQUE(account, public_key) ON CONFLICT REPLACE
+);
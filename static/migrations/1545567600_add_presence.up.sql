@@ -0,0 +1,13 @@
+CREATE TABLE presence_settings (
+  account TEXT NOT NULL DEFAULT '',
+  settings BLOB NOT NULL,
+  UNIQUE(account) ON CONFLICT REPLACE
+);
+
+CREATE TABLE read_receipts (
+  account TEXT NOT NULL DEFAULT '',
+  message_id TEXT NOT NULL,
+  reader TEXT NOT NULL,
+  clock INT NOT NULL,
+  UNIQUE(account, message_id, reader) ON CONFLICT IGNORE
+);
@@ -0,0 +1,7 @@
+CREATE TABLE contact_requests (
+  account TEXT NOT NULL DEFAULT '',
+  public_key TEXT NOT NULL,
+  state TEXT NOT NULL,
+  received_at INT NOT NULL,
+  UNIQUE(account, public_key) ON CONFLICT REPLACE
+);
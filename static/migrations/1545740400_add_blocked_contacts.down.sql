@@ -0,0 +1,2 @@
+DROP TABLE muted_chats;
+DROP TABLE blocked_contacts;
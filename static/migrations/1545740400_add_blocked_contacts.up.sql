@@ -0,0 +1,11 @@
+CREATE TABLE blocked_contacts (
+  account TEXT NOT NULL DEFAULT '',
+  public_key TEXT NOT NULL,
+  UNIQUE(account, public_key) ON CONFLICT REPLACE
+);
+
+CREATE TABLE muted_chats (
+  account TEXT NOT NULL DEFAULT '',
+  chat_id TEXT NOT NULL,
+  UNIQUE(account, chat_id) ON CONFLICT REPLACE
+);
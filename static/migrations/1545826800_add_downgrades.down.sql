@@ -0,0 +1,3 @@
+DROP INDEX downgrades_public_key;
+DROP TABLE downgrades;
+DROP TABLE contact_encryption;
@@ -0,0 +1,15 @@
+CREATE TABLE contact_encryption (
+  account TEXT NOT NULL DEFAULT '',
+  public_key TEXT NOT NULL,
+  pfs BOOLEAN NOT NULL,
+  UNIQUE(account, public_key) ON CONFLICT REPLACE
+);
+
+CREATE TABLE downgrades (
+  account TEXT NOT NULL DEFAULT '',
+  public_key TEXT NOT NULL,
+  hash TEXT NOT NULL,
+  detected_at INT NOT NULL
+);
+
+CREATE INDEX downgrades_public_key ON downgrades(account, public_key, detected_at);
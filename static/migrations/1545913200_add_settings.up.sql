@@ -0,0 +1,6 @@
+CREATE TABLE settings (
+  account TEXT NOT NULL DEFAULT '',
+  key TEXT NOT NULL,
+  value BLOB NOT NULL,
+  UNIQUE(account, key) ON CONFLICT REPLACE
+);
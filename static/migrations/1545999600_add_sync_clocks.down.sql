@@ -0,0 +1 @@
+DROP TABLE sync_clocks;
@@ -0,0 +1,7 @@
+CREATE TABLE sync_clocks (
+  account TEXT NOT NULL DEFAULT '',
+  kind TEXT NOT NULL,
+  id TEXT NOT NULL,
+  clock INTEGER NOT NULL,
+  UNIQUE(account, kind, id) ON CONFLICT REPLACE
+);
@@ -0,0 +1 @@
+DROP TABLE unsigned_transactions;
@@ -0,0 +1,7 @@
+CREATE TABLE unsigned_transactions (
+  account TEXT NOT NULL DEFAULT '',
+  hash BLOB NOT NULL,
+  expires INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, hash) ON CONFLICT REPLACE
+);
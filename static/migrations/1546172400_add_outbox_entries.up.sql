@@ -0,0 +1,8 @@
+CREATE TABLE outbox_entries (
+  account TEXT NOT NULL DEFAULT '',
+  id TEXT NOT NULL,
+  history_id TEXT NOT NULL,
+  created_at INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, id) ON CONFLICT REPLACE
+);
@@ -0,0 +1,3 @@
+DROP TABLE wallet_transfers_blocks;
+DROP INDEX wallet_transfers_block_idx;
+DROP TABLE wallet_transfers;
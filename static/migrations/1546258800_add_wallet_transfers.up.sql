@@ -0,0 +1,17 @@
+CREATE TABLE wallet_transfers (
+  account TEXT NOT NULL DEFAULT '',
+  address BLOB NOT NULL,
+  id TEXT NOT NULL,
+  block_number INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, address, id) ON CONFLICT REPLACE
+);
+
+CREATE INDEX wallet_transfers_block_idx ON wallet_transfers(account, address, block_number, id);
+
+CREATE TABLE wallet_transfers_blocks (
+  account TEXT NOT NULL DEFAULT '',
+  address BLOB NOT NULL,
+  block INTEGER NOT NULL,
+  UNIQUE(account, address) ON CONFLICT REPLACE
+);
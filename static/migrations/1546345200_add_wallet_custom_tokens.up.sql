@@ -0,0 +1,7 @@
+CREATE TABLE wallet_custom_tokens (
+  account TEXT NOT NULL DEFAULT '',
+  network_id INTEGER NOT NULL,
+  address BLOB NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, network_id, address) ON CONFLICT REPLACE
+);
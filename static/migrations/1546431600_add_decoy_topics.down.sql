@@ -0,0 +1 @@
+DROP TABLE decoy_topics;
@@ -0,0 +1,6 @@
+CREATE TABLE decoy_topics (
+  account TEXT NOT NULL DEFAULT '',
+  position INTEGER NOT NULL,
+  topic BLOB NOT NULL,
+  UNIQUE(account, position) ON CONFLICT REPLACE
+);
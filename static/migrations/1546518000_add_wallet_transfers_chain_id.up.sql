@@ -0,0 +1,23 @@
+DROP TABLE wallet_transfers_blocks;
+DROP INDEX wallet_transfers_block_idx;
+DROP TABLE wallet_transfers;
+
+CREATE TABLE wallet_transfers (
+  account TEXT NOT NULL DEFAULT '',
+  chain_id INTEGER NOT NULL,
+  address BLOB NOT NULL,
+  id TEXT NOT NULL,
+  block_number INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, chain_id, address, id) ON CONFLICT REPLACE
+);
+
+CREATE INDEX wallet_transfers_block_idx ON wallet_transfers(account, chain_id, address, block_number, id);
+
+CREATE TABLE wallet_transfers_blocks (
+  account TEXT NOT NULL DEFAULT '',
+  chain_id INTEGER NOT NULL,
+  address BLOB NOT NULL,
+  block INTEGER NOT NULL,
+  UNIQUE(account, chain_id, address) ON CONFLICT REPLACE
+);
@@ -0,0 +1,2 @@
+DROP INDEX wallet_balance_snapshots_timestamp_idx;
+DROP TABLE wallet_balance_snapshots;
@@ -0,0 +1,11 @@
+CREATE TABLE wallet_balance_snapshots (
+  account TEXT NOT NULL DEFAULT '',
+  chain_id INTEGER NOT NULL,
+  address BLOB NOT NULL,
+  token BLOB NOT NULL,
+  timestamp INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, chain_id, address, token, timestamp) ON CONFLICT REPLACE
+);
+
+CREATE INDEX wallet_balance_snapshots_timestamp_idx ON wallet_balance_snapshots(account, chain_id, timestamp);
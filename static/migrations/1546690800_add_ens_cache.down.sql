@@ -0,0 +1,2 @@
+DROP TABLE ens_reverse_resolutions;
+DROP TABLE ens_resolutions;
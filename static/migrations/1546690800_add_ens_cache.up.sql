@@ -0,0 +1,15 @@
+CREATE TABLE ens_resolutions (
+  account TEXT NOT NULL DEFAULT '',
+  network_id INTEGER NOT NULL,
+  name TEXT NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, network_id, name) ON CONFLICT REPLACE
+);
+
+CREATE TABLE ens_reverse_resolutions (
+  account TEXT NOT NULL DEFAULT '',
+  network_id INTEGER NOT NULL,
+  address BLOB NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, network_id, address) ON CONFLICT REPLACE
+);
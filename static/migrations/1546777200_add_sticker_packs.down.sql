@@ -0,0 +1,3 @@
+DROP INDEX sticker_content_hash;
+DROP TABLE sticker_content;
+DROP TABLE sticker_packs;
@@ -0,0 +1,19 @@
+CREATE TABLE sticker_packs (
+  account TEXT NOT NULL DEFAULT '',
+  network_id INTEGER NOT NULL,
+  id INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  installed_at INTEGER NOT NULL,
+  UNIQUE(account, network_id, id) ON CONFLICT REPLACE
+);
+
+CREATE TABLE sticker_content (
+  account TEXT NOT NULL DEFAULT '',
+  network_id INTEGER NOT NULL,
+  pack_id INTEGER NOT NULL,
+  hash BLOB NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, network_id, pack_id, hash) ON CONFLICT REPLACE
+);
+
+CREATE INDEX sticker_content_hash ON sticker_content(account, network_id, hash);
@@ -0,0 +1,2 @@
+DROP TABLE push_client_state;
+DROP TABLE push_registrations;
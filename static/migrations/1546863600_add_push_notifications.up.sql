@@ -0,0 +1,13 @@
+CREATE TABLE push_registrations (
+  account TEXT NOT NULL DEFAULT '',
+  public_key_hash BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, public_key_hash, installation_id) ON CONFLICT REPLACE
+);
+
+CREATE TABLE push_client_state (
+  account TEXT NOT NULL DEFAULT '',
+  data BLOB NOT NULL,
+  UNIQUE(account) ON CONFLICT REPLACE
+);
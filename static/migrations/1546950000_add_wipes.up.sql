@@ -0,0 +1,7 @@
+CREATE TABLE wipes (
+  account TEXT NOT NULL DEFAULT '',
+  id TEXT NOT NULL,
+  issued_at INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, id) ON CONFLICT REPLACE
+);
@@ -0,0 +1,46 @@
+DROP TABLE legacy_account;
+
+CREATE TABLE installations (
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  timestamp UNSIGNED BIG INT NOT NULL,
+  enabled BOOLEAN DEFAULT 1,
+  disabled_at UNSIGNED BIG INT NOT NULL DEFAULT 0,
+  UNIQUE(identity, installation_id) ON CONFLICT REPLACE
+);
+
+INSERT INTO installations(identity, installation_id, timestamp, enabled, disabled_at)
+SELECT identity, installation_id, timestamp, enabled, disabled_at FROM installations_v2 WHERE account = '';
+
+DROP TABLE installations_v2;
+
+CREATE TABLE bundles (
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  private_key BLOB,
+  signed_pre_key BLOB NOT NULL PRIMARY KEY ON CONFLICT IGNORE,
+  timestamp UNSIGNED BIG INT NOT NULL,
+  expired BOOLEAN DEFAULT 0,
+  version INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO bundles(identity, installation_id, private_key, signed_pre_key, timestamp, expired, version)
+SELECT identity, installation_id, private_key, signed_pre_key, timestamp, expired, version FROM bundles_v2;
+
+CREATE TABLE ratchet_info_v3 (
+  bundle_id BLOB NOT NULL,
+  ephemeral_key BLOB,
+  identity BLOB NOT NULL,
+  symmetric_key BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  account TEXT NOT NULL DEFAULT '',
+  UNIQUE(account, bundle_id, identity, installation_id) ON CONFLICT REPLACE,
+  FOREIGN KEY (bundle_id) REFERENCES bundles(signed_pre_key)
+);
+
+INSERT INTO ratchet_info_v3(bundle_id, ephemeral_key, identity, symmetric_key, installation_id, account)
+SELECT bundle_id, ephemeral_key, identity, symmetric_key, installation_id, account FROM ratchet_info_v4
+WHERE bundle_id IN (SELECT signed_pre_key FROM bundles);
+
+DROP TABLE ratchet_info_v4;
+DROP TABLE bundles_v2;
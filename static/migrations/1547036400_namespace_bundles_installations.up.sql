@@ -0,0 +1,52 @@
+CREATE TABLE bundles_v2 (
+  account TEXT NOT NULL DEFAULT '',
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  private_key BLOB,
+  signed_pre_key BLOB NOT NULL,
+  timestamp UNSIGNED BIG INT NOT NULL,
+  expired BOOLEAN DEFAULT 0,
+  version INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY(account, signed_pre_key) ON CONFLICT IGNORE
+);
+
+INSERT INTO bundles_v2(account, identity, installation_id, private_key, signed_pre_key, timestamp, expired, version)
+SELECT accounts.account, identity, installation_id, private_key, signed_pre_key, timestamp, expired, version
+FROM bundles, (SELECT '' AS account UNION SELECT account FROM ratchet_info_v3) AS accounts;
+
+CREATE TABLE ratchet_info_v4 (
+  account TEXT NOT NULL DEFAULT '',
+  bundle_id BLOB NOT NULL,
+  ephemeral_key BLOB,
+  identity BLOB NOT NULL,
+  symmetric_key BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  UNIQUE(account, bundle_id, identity, installation_id) ON CONFLICT REPLACE,
+  FOREIGN KEY (account, bundle_id) REFERENCES bundles_v2(account, signed_pre_key) ON UPDATE CASCADE
+);
+
+INSERT INTO ratchet_info_v4(account, bundle_id, ephemeral_key, identity, symmetric_key, installation_id)
+SELECT account, bundle_id, ephemeral_key, identity, symmetric_key, installation_id FROM ratchet_info_v3;
+
+DROP TABLE ratchet_info_v3;
+DROP TABLE bundles;
+
+CREATE TABLE installations_v2 (
+  account TEXT NOT NULL DEFAULT '',
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  timestamp UNSIGNED BIG INT NOT NULL,
+  enabled BOOLEAN DEFAULT 1,
+  disabled_at UNSIGNED BIG INT NOT NULL DEFAULT 0,
+  UNIQUE(account, identity, installation_id) ON CONFLICT REPLACE
+);
+
+INSERT INTO installations_v2(account, identity, installation_id, timestamp, enabled, disabled_at)
+SELECT accounts.account, identity, installation_id, timestamp, enabled, disabled_at
+FROM installations, (SELECT '' AS account UNION SELECT account FROM ratchet_info_v4) AS accounts;
+
+DROP TABLE installations;
+
+CREATE TABLE legacy_account (
+  account TEXT NOT NULL PRIMARY KEY
+);
@@ -0,0 +1 @@
+DROP TABLE sent_transactions;
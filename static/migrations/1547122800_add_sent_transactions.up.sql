@@ -0,0 +1,11 @@
+CREATE TABLE sent_transactions (
+  account TEXT NOT NULL DEFAULT '',
+  hash BLOB NOT NULL,
+  original BLOB NOT NULL,
+  chain_id INTEGER NOT NULL,
+  sender BLOB NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, hash) ON CONFLICT REPLACE
+);
+
+CREATE INDEX sent_transactions_original ON sent_transactions(account, original);
@@ -0,0 +1,29 @@
+-- The encrypted content can't be decrypted without the secret, the messages
+-- encrypted since the upgrade are lost.
+DROP TRIGGER IF EXISTS history_messages_au;
+DROP TRIGGER IF EXISTS history_messages_ad;
+DROP TRIGGER IF EXISTS history_messages_ai;
+DROP TABLE IF EXISTS history_messages_fts;
+DROP TABLE fields_secret;
+
+CREATE TABLE history_messages_v1 (
+  account TEXT NOT NULL DEFAULT '',
+  id TEXT NOT NULL,
+  chat_id TEXT NOT NULL,
+  author TEXT NOT NULL,
+  content TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  message_type TEXT NOT NULL,
+  reply_to TEXT NOT NULL,
+  clock INT NOT NULL,
+  timestamp INT NOT NULL,
+  outgoing BOOLEAN NOT NULL,
+  edited BOOLEAN NOT NULL DEFAULT 0,
+  UNIQUE(account, id) ON CONFLICT IGNORE
+);
+INSERT INTO history_messages_v1
+  SELECT account, id, chat_id, author, content, content_type, message_type, reply_to, clock, timestamp, outgoing, edited
+  FROM history_messages WHERE encrypted = 0;
+DROP TABLE history_messages;
+ALTER TABLE history_messages_v1 RENAME TO history_messages;
+CREATE INDEX history_messages_chat_clock ON history_messages(account, chat_id, clock, id);
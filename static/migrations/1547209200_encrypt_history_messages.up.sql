@@ -0,0 +1,14 @@
+ALTER TABLE history_messages ADD COLUMN tokens TEXT NOT NULL DEFAULT '';
+ALTER TABLE history_messages ADD COLUMN encrypted BOOLEAN NOT NULL DEFAULT 0;
+
+CREATE TABLE fields_secret (
+  id INTEGER PRIMARY KEY CHECK (id = 1),
+  secret BLOB NOT NULL
+);
+
+-- The full-text index of the content is replaced with an index of the tokens,
+-- created by the persistence when SQLite supports it.
+DROP TRIGGER IF EXISTS history_messages_au;
+DROP TRIGGER IF EXISTS history_messages_ad;
+DROP TRIGGER IF EXISTS history_messages_ai;
+DROP TABLE IF EXISTS history_messages_fts;
@@ -0,0 +1,580 @@
+-- The rows go back to the data stored before the database was namespaced by account,
+-- which the account logging in claims.
+CREATE TABLE legacy_account (
+  account TEXT NOT NULL PRIMARY KEY
+);
+
+CREATE TABLE bundles_v2 (
+  account TEXT NOT NULL DEFAULT '',
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  private_key BLOB,
+  signed_pre_key BLOB NOT NULL,
+  timestamp UNSIGNED BIG INT NOT NULL,
+  expired BOOLEAN DEFAULT 0,
+  version INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY(account, signed_pre_key) ON CONFLICT IGNORE
+);
+INSERT INTO bundles_v2(identity, installation_id, private_key, signed_pre_key, timestamp, expired, version)
+SELECT identity, installation_id, private_key, signed_pre_key, timestamp, expired, version FROM bundles_v3;
+
+CREATE TABLE ratchet_info_v4 (
+  account TEXT NOT NULL DEFAULT '',
+  bundle_id BLOB NOT NULL,
+  ephemeral_key BLOB,
+  identity BLOB NOT NULL,
+  symmetric_key BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  UNIQUE(account, bundle_id, identity, installation_id) ON CONFLICT REPLACE,
+  FOREIGN KEY (account, bundle_id) REFERENCES bundles_v2(account, signed_pre_key) ON UPDATE CASCADE
+);
+INSERT INTO ratchet_info_v4(bundle_id, ephemeral_key, identity, symmetric_key, installation_id)
+SELECT bundle_id, ephemeral_key, identity, symmetric_key, installation_id FROM ratchet_info_v5;
+
+DROP TABLE ratchet_info_v5;
+DROP TABLE bundles_v3;
+
+CREATE TABLE attachments_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  hash BLOB NOT NULL,
+  id TEXT NOT NULL,
+  key BLOB NOT NULL,
+  content_type TEXT NOT NULL,
+  size INT NOT NULL,
+  state INT NOT NULL,
+  data BLOB,
+  accessed_at INT NOT NULL,
+  UNIQUE(account, hash) ON CONFLICT REPLACE
+);
+INSERT INTO attachments_v0(hash, id, key, content_type, size, state, data, accessed_at)
+SELECT hash, id, key, content_type, size, state, data, accessed_at FROM attachments;
+DROP TABLE attachments;
+ALTER TABLE attachments_v0 RENAME TO attachments;
+
+CREATE TABLE blocked_contacts_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  public_key TEXT NOT NULL,
+  UNIQUE(account, public_key) ON CONFLICT REPLACE
+);
+INSERT INTO blocked_contacts_v0(public_key)
+SELECT public_key FROM blocked_contacts;
+DROP TABLE blocked_contacts;
+ALTER TABLE blocked_contacts_v0 RENAME TO blocked_contacts;
+
+CREATE TABLE chat_settings_v2 (
+  account TEXT NOT NULL DEFAULT '',
+  topic BLOB NOT NULL,
+  language TEXT NOT NULL DEFAULT '',
+  UNIQUE(account, topic) ON CONFLICT REPLACE
+);
+INSERT INTO chat_settings_v2(topic, language)
+SELECT topic, language FROM chat_settings_v3;
+DROP TABLE chat_settings_v3;
+
+CREATE TABLE compression_dictionaries_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  versions TEXT NOT NULL,
+  UNIQUE(account, identity, installation_id)
+);
+INSERT INTO compression_dictionaries_v0(identity, installation_id, versions)
+SELECT identity, installation_id, versions FROM compression_dictionaries;
+DROP TABLE compression_dictionaries;
+ALTER TABLE compression_dictionaries_v0 RENAME TO compression_dictionaries;
+
+CREATE TABLE contact_capabilities_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  version INT NOT NULL,
+  features TEXT NOT NULL,
+  UNIQUE(account, identity, installation_id)
+);
+INSERT INTO contact_capabilities_v0(identity, installation_id, version, features)
+SELECT identity, installation_id, version, features FROM contact_capabilities;
+DROP TABLE contact_capabilities;
+ALTER TABLE contact_capabilities_v0 RENAME TO contact_capabilities;
+
+CREATE TABLE contact_encryption_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  public_key TEXT NOT NULL,
+  pfs BOOLEAN NOT NULL,
+  UNIQUE(account, public_key) ON CONFLICT REPLACE
+);
+INSERT INTO contact_encryption_v0(public_key, pfs)
+SELECT public_key, pfs FROM contact_encryption;
+DROP TABLE contact_encryption;
+ALTER TABLE contact_encryption_v0 RENAME TO contact_encryption;
+
+CREATE TABLE contact_mailservers_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  public_key BLOB NOT NULL,
+  enode TEXT NOT NULL,
+  UNIQUE(account, public_key)
+);
+INSERT INTO contact_mailservers_v0(public_key, enode)
+SELECT public_key, enode FROM contact_mailservers;
+DROP TABLE contact_mailservers;
+ALTER TABLE contact_mailservers_v0 RENAME TO contact_mailservers;
+
+CREATE TABLE contact_requests_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  public_key TEXT NOT NULL,
+  state TEXT NOT NULL,
+  received_at INT NOT NULL,
+  UNIQUE(account, public_key) ON CONFLICT REPLACE
+);
+INSERT INTO contact_requests_v0(public_key, state, received_at)
+SELECT public_key, state, received_at FROM contact_requests;
+DROP TABLE contact_requests;
+ALTER TABLE contact_requests_v0 RENAME TO contact_requests;
+
+CREATE TABLE contact_topics_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  topic BLOB NOT NULL,
+  UNIQUE(account, identity, installation_id)
+);
+INSERT INTO contact_topics_v0(identity, installation_id, topic)
+SELECT identity, installation_id, topic FROM contact_topics;
+DROP TABLE contact_topics;
+ALTER TABLE contact_topics_v0 RENAME TO contact_topics;
+
+CREATE TABLE contacts_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  public_key TEXT NOT NULL,
+  name TEXT NOT NULL,
+  warnings TEXT NOT NULL,
+  UNIQUE(account, public_key) ON CONFLICT REPLACE
+);
+INSERT INTO contacts_v0(public_key, name, warnings)
+SELECT public_key, name, warnings FROM contacts;
+DROP TABLE contacts;
+ALTER TABLE contacts_v0 RENAME TO contacts;
+
+CREATE TABLE decoy_topics_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  position INTEGER NOT NULL,
+  topic BLOB NOT NULL,
+  UNIQUE(account, position) ON CONFLICT REPLACE
+);
+INSERT INTO decoy_topics_v0(position, topic)
+SELECT position, topic FROM decoy_topics;
+DROP TABLE decoy_topics;
+ALTER TABLE decoy_topics_v0 RENAME TO decoy_topics;
+
+CREATE TABLE downgrades_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  public_key TEXT NOT NULL,
+  hash TEXT NOT NULL,
+  detected_at INT NOT NULL
+);
+INSERT INTO downgrades_v0(public_key, hash, detected_at)
+SELECT public_key, hash, detected_at FROM downgrades;
+DROP TABLE downgrades;
+ALTER TABLE downgrades_v0 RENAME TO downgrades;
+CREATE INDEX idx_downgrades_public_key ON downgrades(account, public_key, detected_at);
+
+CREATE TABLE ens_resolutions_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  network_id INTEGER NOT NULL,
+  name TEXT NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, network_id, name) ON CONFLICT REPLACE
+);
+INSERT INTO ens_resolutions_v0(network_id, name, data)
+SELECT network_id, name, data FROM ens_resolutions;
+DROP TABLE ens_resolutions;
+ALTER TABLE ens_resolutions_v0 RENAME TO ens_resolutions;
+
+CREATE TABLE ens_reverse_resolutions_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  network_id INTEGER NOT NULL,
+  address BLOB NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, network_id, address) ON CONFLICT REPLACE
+);
+INSERT INTO ens_reverse_resolutions_v0(network_id, address, data)
+SELECT network_id, address, data FROM ens_reverse_resolutions;
+DROP TABLE ens_reverse_resolutions;
+ALTER TABLE ens_reverse_resolutions_v0 RENAME TO ens_reverse_resolutions;
+
+CREATE TABLE envelope_states_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  hash BLOB NOT NULL,
+  state INTEGER NOT NULL,
+  updated_at INTEGER NOT NULL,
+  UNIQUE(account, hash)
+);
+INSERT INTO envelope_states_v0(hash, state, updated_at)
+SELECT hash, state, updated_at FROM envelope_states;
+DROP TABLE envelope_states;
+ALTER TABLE envelope_states_v0 RENAME TO envelope_states;
+
+CREATE TABLE group_receipts_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  message_id TEXT NOT NULL,
+  total INTEGER NOT NULL,
+  delivered INTEGER NOT NULL DEFAULT 0,
+  read INTEGER NOT NULL DEFAULT 0,
+  UNIQUE(account, message_id) ON CONFLICT REPLACE
+);
+INSERT INTO group_receipts_v0(message_id, total, delivered, read)
+SELECT message_id, total, delivered, read FROM group_receipts;
+DROP TABLE group_receipts;
+ALTER TABLE group_receipts_v0 RENAME TO group_receipts;
+
+CREATE TABLE installations_v2 (
+  account TEXT NOT NULL DEFAULT '',
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  timestamp UNSIGNED BIG INT NOT NULL,
+  enabled BOOLEAN DEFAULT 1,
+  disabled_at UNSIGNED BIG INT NOT NULL DEFAULT 0,
+  UNIQUE(account, identity, installation_id) ON CONFLICT REPLACE
+);
+INSERT INTO installations_v2(identity, installation_id, timestamp, enabled, disabled_at)
+SELECT identity, installation_id, timestamp, enabled, disabled_at FROM installations_v3;
+DROP TABLE installations_v3;
+
+CREATE TABLE message_authors_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  id TEXT NOT NULL,
+  author TEXT NOT NULL,
+  UNIQUE(account, id) ON CONFLICT IGNORE
+);
+INSERT INTO message_authors_v0(id, author)
+SELECT id, author FROM message_authors;
+DROP TABLE message_authors;
+ALTER TABLE message_authors_v0 RENAME TO message_authors;
+
+CREATE TABLE message_changes_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  target_id TEXT NOT NULL,
+  author TEXT NOT NULL,
+  content TEXT NOT NULL,
+  deleted BOOLEAN NOT NULL,
+  clock INT NOT NULL,
+  UNIQUE(account, target_id, author) ON CONFLICT REPLACE
+);
+INSERT INTO message_changes_v0(target_id, author, content, deleted, clock)
+SELECT target_id, author, content, deleted, clock FROM message_changes;
+DROP TABLE message_changes;
+ALTER TABLE message_changes_v0 RENAME TO message_changes;
+
+CREATE TABLE message_reactions_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  target_id TEXT NOT NULL,
+  author TEXT NOT NULL,
+  emoji TEXT NOT NULL,
+  retracted BOOLEAN NOT NULL,
+  clock INT NOT NULL,
+  UNIQUE(account, target_id, author, emoji) ON CONFLICT REPLACE
+);
+INSERT INTO message_reactions_v0(target_id, author, emoji, retracted, clock)
+SELECT target_id, author, emoji, retracted, clock FROM message_reactions;
+DROP TABLE message_reactions;
+ALTER TABLE message_reactions_v0 RENAME TO message_reactions;
+
+CREATE TABLE moderated_channels_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  topic BLOB NOT NULL,
+  chat_id TEXT NOT NULL,
+  moderator TEXT NOT NULL,
+  mode INTEGER NOT NULL DEFAULT 0,
+  list BLOB,
+  UNIQUE(account, topic) ON CONFLICT REPLACE
+);
+INSERT INTO moderated_channels_v0(topic, chat_id, moderator, mode, list)
+SELECT topic, chat_id, moderator, mode, list FROM moderated_channels;
+DROP TABLE moderated_channels;
+ALTER TABLE moderated_channels_v0 RENAME TO moderated_channels;
+
+CREATE TABLE muted_chats_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  chat_id TEXT NOT NULL,
+  UNIQUE(account, chat_id) ON CONFLICT REPLACE
+);
+INSERT INTO muted_chats_v0(chat_id)
+SELECT chat_id FROM muted_chats;
+DROP TABLE muted_chats;
+ALTER TABLE muted_chats_v0 RENAME TO muted_chats;
+
+CREATE TABLE notification_preferences_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  preferences BLOB NOT NULL,
+  clock INT NOT NULL,
+  UNIQUE(account) ON CONFLICT REPLACE
+);
+INSERT INTO notification_preferences_v0(preferences, clock)
+SELECT preferences, clock FROM notification_preferences;
+DROP TABLE notification_preferences;
+ALTER TABLE notification_preferences_v0 RENAME TO notification_preferences;
+
+CREATE TABLE outbox_entries_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  id TEXT NOT NULL,
+  history_id TEXT NOT NULL,
+  created_at INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, id) ON CONFLICT REPLACE
+);
+INSERT INTO outbox_entries_v0(id, history_id, created_at, data)
+SELECT id, history_id, created_at, data FROM outbox_entries;
+DROP TABLE outbox_entries;
+ALTER TABLE outbox_entries_v0 RENAME TO outbox_entries;
+
+CREATE TABLE pending_messages_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  hash BLOB NOT NULL,
+  sender BLOB NOT NULL,
+  message BLOB NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  received_at INT NOT NULL,
+  UNIQUE(account, hash)
+);
+INSERT INTO pending_messages_v0(hash, sender, message, attempts, received_at)
+SELECT hash, sender, message, attempts, received_at FROM pending_messages;
+DROP TABLE pending_messages;
+ALTER TABLE pending_messages_v0 RENAME TO pending_messages;
+CREATE INDEX pending_messages_sender ON pending_messages(account, sender);
+
+CREATE TABLE presence_settings_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  settings BLOB NOT NULL,
+  UNIQUE(account) ON CONFLICT REPLACE
+);
+INSERT INTO presence_settings_v0(settings)
+SELECT settings FROM presence_settings;
+DROP TABLE presence_settings;
+ALTER TABLE presence_settings_v0 RENAME TO presence_settings;
+
+CREATE TABLE processed_messages_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  sender BLOB NOT NULL,
+  message_hash BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  timestamp UNSIGNED BIG INT NOT NULL,
+  UNIQUE(account, sender, message_hash, installation_id) ON CONFLICT REPLACE
+);
+INSERT INTO processed_messages_v0(sender, message_hash, installation_id, timestamp)
+SELECT sender, message_hash, installation_id, timestamp FROM processed_messages;
+DROP TABLE processed_messages;
+ALTER TABLE processed_messages_v0 RENAME TO processed_messages;
+CREATE INDEX processed_messages_timestamp ON processed_messages(timestamp);
+
+CREATE TABLE push_client_state_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  data BLOB NOT NULL,
+  UNIQUE(account) ON CONFLICT REPLACE
+);
+INSERT INTO push_client_state_v0(data)
+SELECT data FROM push_client_state;
+DROP TABLE push_client_state;
+ALTER TABLE push_client_state_v0 RENAME TO push_client_state;
+
+CREATE TABLE push_registrations_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  public_key_hash BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, public_key_hash, installation_id) ON CONFLICT REPLACE
+);
+INSERT INTO push_registrations_v0(public_key_hash, installation_id, data)
+SELECT public_key_hash, installation_id, data FROM push_registrations;
+DROP TABLE push_registrations;
+ALTER TABLE push_registrations_v0 RENAME TO push_registrations;
+
+CREATE TABLE read_receipts_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  message_id TEXT NOT NULL,
+  reader TEXT NOT NULL,
+  clock INT NOT NULL,
+  UNIQUE(account, message_id, reader) ON CONFLICT IGNORE
+);
+INSERT INTO read_receipts_v0(message_id, reader, clock)
+SELECT message_id, reader, clock FROM read_receipts;
+DROP TABLE read_receipts;
+ALTER TABLE read_receipts_v0 RENAME TO read_receipts;
+
+CREATE TABLE segments_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  hash BLOB NOT NULL,
+  idx INT NOT NULL,
+  count INT NOT NULL,
+  data BLOB NOT NULL,
+  received_at INT NOT NULL,
+  UNIQUE(account, hash, idx)
+);
+INSERT INTO segments_v0(hash, idx, count, data, received_at)
+SELECT hash, idx, count, data, received_at FROM segments;
+DROP TABLE segments;
+ALTER TABLE segments_v0 RENAME TO segments;
+
+CREATE TABLE sent_transactions_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  hash BLOB NOT NULL,
+  original BLOB NOT NULL,
+  chain_id INTEGER NOT NULL,
+  sender BLOB NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, hash) ON CONFLICT REPLACE
+);
+INSERT INTO sent_transactions_v0(hash, original, chain_id, sender, data)
+SELECT hash, original, chain_id, sender, data FROM sent_transactions;
+DROP TABLE sent_transactions;
+ALTER TABLE sent_transactions_v0 RENAME TO sent_transactions;
+CREATE INDEX sent_transactions_original ON sent_transactions(account, original);
+
+CREATE TABLE settings_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  key TEXT NOT NULL,
+  value BLOB NOT NULL,
+  UNIQUE(account, key) ON CONFLICT REPLACE
+);
+INSERT INTO settings_v0(key, value)
+SELECT key, value FROM settings;
+DROP TABLE settings;
+ALTER TABLE settings_v0 RENAME TO settings;
+
+CREATE TABLE sticker_content_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  network_id INTEGER NOT NULL,
+  pack_id INTEGER NOT NULL,
+  hash BLOB NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, network_id, pack_id, hash) ON CONFLICT REPLACE
+);
+INSERT INTO sticker_content_v0(network_id, pack_id, hash, data)
+SELECT network_id, pack_id, hash, data FROM sticker_content;
+DROP TABLE sticker_content;
+ALTER TABLE sticker_content_v0 RENAME TO sticker_content;
+CREATE INDEX sticker_content_hash ON sticker_content(account, network_id, hash);
+
+CREATE TABLE sticker_packs_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  network_id INTEGER NOT NULL,
+  id INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  installed_at INTEGER NOT NULL,
+  UNIQUE(account, network_id, id) ON CONFLICT REPLACE
+);
+INSERT INTO sticker_packs_v0(network_id, id, data, installed_at)
+SELECT network_id, id, data, installed_at FROM sticker_packs;
+DROP TABLE sticker_packs;
+ALTER TABLE sticker_packs_v0 RENAME TO sticker_packs;
+
+CREATE TABLE sync_clocks_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  kind VARCHAR NOT NULL,
+  id TEXT NOT NULL,
+  clock INTEGER NOT NULL,
+  UNIQUE(account, kind, id) ON CONFLICT REPLACE
+);
+INSERT INTO sync_clocks_v0(kind, id, clock)
+SELECT kind, id, clock FROM sync_clocks;
+DROP TABLE sync_clocks;
+ALTER TABLE sync_clocks_v0 RENAME TO sync_clocks;
+
+CREATE TABLE unsigned_transactions_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  hash BLOB NOT NULL,
+  expires INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, hash) ON CONFLICT REPLACE
+);
+INSERT INTO unsigned_transactions_v0(hash, expires, data)
+SELECT hash, expires, data FROM unsigned_transactions;
+DROP TABLE unsigned_transactions;
+ALTER TABLE unsigned_transactions_v0 RENAME TO unsigned_transactions;
+
+CREATE TABLE wallet_balance_snapshots_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  chain_id INTEGER NOT NULL,
+  address BLOB NOT NULL,
+  token BLOB NOT NULL,
+  timestamp INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, chain_id, address, token, timestamp) ON CONFLICT REPLACE
+);
+INSERT INTO wallet_balance_snapshots_v0(chain_id, address, token, timestamp, data)
+SELECT chain_id, address, token, timestamp, data FROM wallet_balance_snapshots;
+DROP TABLE wallet_balance_snapshots;
+ALTER TABLE wallet_balance_snapshots_v0 RENAME TO wallet_balance_snapshots;
+CREATE INDEX wallet_balance_snapshots_timestamp_idx ON wallet_balance_snapshots(account, chain_id, timestamp);
+
+CREATE TABLE wallet_custom_tokens_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  network_id INTEGER NOT NULL,
+  address BLOB NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, network_id, address) ON CONFLICT REPLACE
+);
+INSERT INTO wallet_custom_tokens_v0(network_id, address, data)
+SELECT network_id, address, data FROM wallet_custom_tokens;
+DROP TABLE wallet_custom_tokens;
+ALTER TABLE wallet_custom_tokens_v0 RENAME TO wallet_custom_tokens;
+
+CREATE TABLE wallet_transfers_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  chain_id INTEGER NOT NULL,
+  address BLOB NOT NULL,
+  id TEXT NOT NULL,
+  block_number INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, chain_id, address, id) ON CONFLICT REPLACE
+);
+INSERT INTO wallet_transfers_v0(chain_id, address, id, block_number, data)
+SELECT chain_id, address, id, block_number, data FROM wallet_transfers;
+DROP TABLE wallet_transfers;
+ALTER TABLE wallet_transfers_v0 RENAME TO wallet_transfers;
+CREATE INDEX wallet_transfers_block_idx ON wallet_transfers(account, chain_id, address, block_number, id);
+
+CREATE TABLE wallet_transfers_blocks_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  chain_id INTEGER NOT NULL,
+  address BLOB NOT NULL,
+  block INTEGER NOT NULL,
+  UNIQUE(account, chain_id, address) ON CONFLICT REPLACE
+);
+INSERT INTO wallet_transfers_blocks_v0(chain_id, address, block)
+SELECT chain_id, address, block FROM wallet_transfers_blocks;
+DROP TABLE wallet_transfers_blocks;
+ALTER TABLE wallet_transfers_blocks_v0 RENAME TO wallet_transfers_blocks;
+
+CREATE TABLE wipes_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  id TEXT NOT NULL,
+  issued_at INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(account, id) ON CONFLICT REPLACE
+);
+INSERT INTO wipes_v0(id, issued_at, data)
+SELECT id, issued_at, data FROM wipes;
+DROP TABLE wipes;
+ALTER TABLE wipes_v0 RENAME TO wipes;
+
+CREATE TABLE history_messages_v0 (
+  account TEXT NOT NULL DEFAULT '',
+  id TEXT NOT NULL,
+  chat_id TEXT NOT NULL,
+  author TEXT NOT NULL,
+  content TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  message_type TEXT NOT NULL,
+  reply_to TEXT NOT NULL,
+  clock INT NOT NULL,
+  timestamp INT NOT NULL,
+  outgoing BOOLEAN NOT NULL,
+  edited BOOLEAN NOT NULL DEFAULT 0,
+  tokens TEXT NOT NULL DEFAULT '',
+  encrypted BOOLEAN NOT NULL DEFAULT 0,
+  UNIQUE(account, id) ON CONFLICT IGNORE
+);
+INSERT INTO history_messages_v0(rowid, id, chat_id, author, content, content_type, message_type, reply_to, clock, timestamp, outgoing, edited, tokens, encrypted)
+SELECT rowid, id, chat_id, author, content, content_type, message_type, reply_to, clock, timestamp, outgoing, edited, tokens, encrypted FROM history_messages;
+DROP TABLE history_messages;
+ALTER TABLE history_messages_v0 RENAME TO history_messages;
+CREATE INDEX history_messages_chat_clock ON history_messages(account, chat_id, clock, id);
@@ -0,0 +1,548 @@
+-- Each account has its own chat database: the rows of the account that claimed the
+-- data of the database are kept, without their account, and those of other accounts
+-- are deleted.
+CREATE TEMP TABLE database_account AS SELECT IFNULL(MAX(account), '') AS account FROM legacy_account;
+
+DELETE FROM keys WHERE session_id IS NOT NULL AND (SELECT account FROM database_account) <> ''
+  AND substr(session_id, 1, length((SELECT account FROM database_account))) <> CAST((SELECT account FROM database_account) AS BLOB);
+UPDATE keys SET session_id = substr(session_id, length((SELECT account FROM database_account)) + 1)
+  WHERE session_id IS NOT NULL AND (SELECT account FROM database_account) <> '';
+DELETE FROM sessions WHERE (SELECT account FROM database_account) <> ''
+  AND substr(id, 1, length((SELECT account FROM database_account))) <> CAST((SELECT account FROM database_account) AS BLOB);
+UPDATE sessions SET id = substr(id, length((SELECT account FROM database_account)) + 1)
+  WHERE (SELECT account FROM database_account) <> '';
+
+CREATE TABLE bundles_v3 (
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  private_key BLOB,
+  signed_pre_key BLOB NOT NULL,
+  timestamp UNSIGNED BIG INT NOT NULL,
+  expired BOOLEAN DEFAULT 0,
+  version INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY(signed_pre_key) ON CONFLICT IGNORE
+);
+INSERT INTO bundles_v3(identity, installation_id, private_key, signed_pre_key, timestamp, expired, version)
+SELECT identity, installation_id, private_key, signed_pre_key, timestamp, expired, version FROM bundles_v2 WHERE account = (SELECT account FROM database_account);
+
+CREATE TABLE ratchet_info_v5 (
+  bundle_id BLOB NOT NULL,
+  ephemeral_key BLOB,
+  identity BLOB NOT NULL,
+  symmetric_key BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  UNIQUE(bundle_id, identity, installation_id) ON CONFLICT REPLACE,
+  FOREIGN KEY (bundle_id) REFERENCES bundles_v3(signed_pre_key)
+);
+INSERT INTO ratchet_info_v5(bundle_id, ephemeral_key, identity, symmetric_key, installation_id)
+SELECT bundle_id, ephemeral_key, identity, symmetric_key, installation_id FROM ratchet_info_v4 WHERE account = (SELECT account FROM database_account) AND bundle_id IN (SELECT signed_pre_key FROM bundles_v3);
+
+DROP TABLE ratchet_info_v4;
+DROP TABLE bundles_v2;
+
+CREATE TABLE attachments_new (
+  hash BLOB NOT NULL,
+  id TEXT NOT NULL,
+  key BLOB NOT NULL,
+  content_type TEXT NOT NULL,
+  size INT NOT NULL,
+  state INT NOT NULL,
+  data BLOB,
+  accessed_at INT NOT NULL,
+  UNIQUE(hash) ON CONFLICT REPLACE
+);
+INSERT INTO attachments_new(hash, id, key, content_type, size, state, data, accessed_at)
+SELECT hash, id, key, content_type, size, state, data, accessed_at FROM attachments WHERE account = (SELECT account FROM database_account);
+DROP TABLE attachments;
+ALTER TABLE attachments_new RENAME TO attachments;
+
+CREATE TABLE blocked_contacts_new (
+  public_key TEXT NOT NULL,
+  UNIQUE(public_key) ON CONFLICT REPLACE
+);
+INSERT INTO blocked_contacts_new(public_key)
+SELECT public_key FROM blocked_contacts WHERE account = (SELECT account FROM database_account);
+DROP TABLE blocked_contacts;
+ALTER TABLE blocked_contacts_new RENAME TO blocked_contacts;
+
+CREATE TABLE chat_settings_v3 (
+  topic BLOB NOT NULL,
+  language TEXT NOT NULL DEFAULT '',
+  UNIQUE(topic) ON CONFLICT REPLACE
+);
+INSERT INTO chat_settings_v3(topic, language)
+SELECT topic, language FROM chat_settings_v2 WHERE account = (SELECT account FROM database_account);
+DROP TABLE chat_settings_v2;
+
+CREATE TABLE compression_dictionaries_new (
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  versions TEXT NOT NULL,
+  UNIQUE(identity, installation_id)
+);
+INSERT INTO compression_dictionaries_new(identity, installation_id, versions)
+SELECT identity, installation_id, versions FROM compression_dictionaries WHERE account = (SELECT account FROM database_account);
+DROP TABLE compression_dictionaries;
+ALTER TABLE compression_dictionaries_new RENAME TO compression_dictionaries;
+
+CREATE TABLE contact_capabilities_new (
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  version INT NOT NULL,
+  features TEXT NOT NULL,
+  UNIQUE(identity, installation_id)
+);
+INSERT INTO contact_capabilities_new(identity, installation_id, version, features)
+SELECT identity, installation_id, version, features FROM contact_capabilities WHERE account = (SELECT account FROM database_account);
+DROP TABLE contact_capabilities;
+ALTER TABLE contact_capabilities_new RENAME TO contact_capabilities;
+
+CREATE TABLE contact_encryption_new (
+  public_key TEXT NOT NULL,
+  pfs BOOLEAN NOT NULL,
+  UNIQUE(public_key) ON CONFLICT REPLACE
+);
+INSERT INTO contact_encryption_new(public_key, pfs)
+SELECT public_key, pfs FROM contact_encryption WHERE account = (SELECT account FROM database_account);
+DROP TABLE contact_encryption;
+ALTER TABLE contact_encryption_new RENAME TO contact_encryption;
+
+CREATE TABLE contact_mailservers_new (
+  public_key BLOB NOT NULL,
+  enode TEXT NOT NULL,
+  UNIQUE(public_key)
+);
+INSERT INTO contact_mailservers_new(public_key, enode)
+SELECT public_key, enode FROM contact_mailservers WHERE account = (SELECT account FROM database_account);
+DROP TABLE contact_mailservers;
+ALTER TABLE contact_mailservers_new RENAME TO contact_mailservers;
+
+CREATE TABLE contact_requests_new (
+  public_key TEXT NOT NULL,
+  state TEXT NOT NULL,
+  received_at INT NOT NULL,
+  UNIQUE(public_key) ON CONFLICT REPLACE
+);
+INSERT INTO contact_requests_new(public_key, state, received_at)
+SELECT public_key, state, received_at FROM contact_requests WHERE account = (SELECT account FROM database_account);
+DROP TABLE contact_requests;
+ALTER TABLE contact_requests_new RENAME TO contact_requests;
+
+CREATE TABLE contact_topics_new (
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  topic BLOB NOT NULL,
+  UNIQUE(identity, installation_id)
+);
+INSERT INTO contact_topics_new(identity, installation_id, topic)
+SELECT identity, installation_id, topic FROM contact_topics WHERE account = (SELECT account FROM database_account);
+DROP TABLE contact_topics;
+ALTER TABLE contact_topics_new RENAME TO contact_topics;
+
+CREATE TABLE contacts_new (
+  public_key TEXT NOT NULL,
+  name TEXT NOT NULL,
+  warnings TEXT NOT NULL,
+  UNIQUE(public_key) ON CONFLICT REPLACE
+);
+INSERT INTO contacts_new(public_key, name, warnings)
+SELECT public_key, name, warnings FROM contacts WHERE account = (SELECT account FROM database_account);
+DROP TABLE contacts;
+ALTER TABLE contacts_new RENAME TO contacts;
+
+CREATE TABLE decoy_topics_new (
+  position INTEGER NOT NULL,
+  topic BLOB NOT NULL,
+  UNIQUE(position) ON CONFLICT REPLACE
+);
+INSERT INTO decoy_topics_new(position, topic)
+SELECT position, topic FROM decoy_topics WHERE account = (SELECT account FROM database_account);
+DROP TABLE decoy_topics;
+ALTER TABLE decoy_topics_new RENAME TO decoy_topics;
+
+CREATE TABLE downgrades_new (
+  public_key TEXT NOT NULL,
+  hash TEXT NOT NULL,
+  detected_at INT NOT NULL
+);
+INSERT INTO downgrades_new(public_key, hash, detected_at)
+SELECT public_key, hash, detected_at FROM downgrades WHERE account = (SELECT account FROM database_account);
+DROP TABLE downgrades;
+ALTER TABLE downgrades_new RENAME TO downgrades;
+CREATE INDEX idx_downgrades_public_key ON downgrades(public_key, detected_at);
+
+CREATE TABLE ens_resolutions_new (
+  network_id INTEGER NOT NULL,
+  name TEXT NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(network_id, name) ON CONFLICT REPLACE
+);
+INSERT INTO ens_resolutions_new(network_id, name, data)
+SELECT network_id, name, data FROM ens_resolutions WHERE account = (SELECT account FROM database_account);
+DROP TABLE ens_resolutions;
+ALTER TABLE ens_resolutions_new RENAME TO ens_resolutions;
+
+CREATE TABLE ens_reverse_resolutions_new (
+  network_id INTEGER NOT NULL,
+  address BLOB NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(network_id, address) ON CONFLICT REPLACE
+);
+INSERT INTO ens_reverse_resolutions_new(network_id, address, data)
+SELECT network_id, address, data FROM ens_reverse_resolutions WHERE account = (SELECT account FROM database_account);
+DROP TABLE ens_reverse_resolutions;
+ALTER TABLE ens_reverse_resolutions_new RENAME TO ens_reverse_resolutions;
+
+CREATE TABLE envelope_states_new (
+  hash BLOB NOT NULL,
+  state INTEGER NOT NULL,
+  updated_at INTEGER NOT NULL,
+  UNIQUE(hash)
+);
+INSERT INTO envelope_states_new(hash, state, updated_at)
+SELECT hash, state, updated_at FROM envelope_states WHERE account = (SELECT account FROM database_account);
+DROP TABLE envelope_states;
+ALTER TABLE envelope_states_new RENAME TO envelope_states;
+
+CREATE TABLE group_receipts_new (
+  message_id TEXT NOT NULL,
+  total INTEGER NOT NULL,
+  delivered INTEGER NOT NULL DEFAULT 0,
+  read INTEGER NOT NULL DEFAULT 0,
+  UNIQUE(message_id) ON CONFLICT REPLACE
+);
+INSERT INTO group_receipts_new(message_id, total, delivered, read)
+SELECT message_id, total, delivered, read FROM group_receipts WHERE account = (SELECT account FROM database_account);
+DROP TABLE group_receipts;
+ALTER TABLE group_receipts_new RENAME TO group_receipts;
+
+CREATE TABLE installations_v3 (
+  identity BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  timestamp UNSIGNED BIG INT NOT NULL,
+  enabled BOOLEAN DEFAULT 1,
+  disabled_at UNSIGNED BIG INT NOT NULL DEFAULT 0,
+  UNIQUE(identity, installation_id) ON CONFLICT REPLACE
+);
+INSERT INTO installations_v3(identity, installation_id, timestamp, enabled, disabled_at)
+SELECT identity, installation_id, timestamp, enabled, disabled_at FROM installations_v2 WHERE account = (SELECT account FROM database_account);
+DROP TABLE installations_v2;
+
+CREATE TABLE message_authors_new (
+  id TEXT NOT NULL,
+  author TEXT NOT NULL,
+  UNIQUE(id) ON CONFLICT IGNORE
+);
+INSERT INTO message_authors_new(id, author)
+SELECT id, author FROM message_authors WHERE account = (SELECT account FROM database_account);
+DROP TABLE message_authors;
+ALTER TABLE message_authors_new RENAME TO message_authors;
+
+CREATE TABLE message_changes_new (
+  target_id TEXT NOT NULL,
+  author TEXT NOT NULL,
+  content TEXT NOT NULL,
+  deleted BOOLEAN NOT NULL,
+  clock INT NOT NULL,
+  UNIQUE(target_id, author) ON CONFLICT REPLACE
+);
+INSERT INTO message_changes_new(target_id, author, content, deleted, clock)
+SELECT target_id, author, content, deleted, clock FROM message_changes WHERE account = (SELECT account FROM database_account);
+DROP TABLE message_changes;
+ALTER TABLE message_changes_new RENAME TO message_changes;
+
+CREATE TABLE message_reactions_new (
+  target_id TEXT NOT NULL,
+  author TEXT NOT NULL,
+  emoji TEXT NOT NULL,
+  retracted BOOLEAN NOT NULL,
+  clock INT NOT NULL,
+  UNIQUE(target_id, author, emoji) ON CONFLICT REPLACE
+);
+INSERT INTO message_reactions_new(target_id, author, emoji, retracted, clock)
+SELECT target_id, author, emoji, retracted, clock FROM message_reactions WHERE account = (SELECT account FROM database_account);
+DROP TABLE message_reactions;
+ALTER TABLE message_reactions_new RENAME TO message_reactions;
+
+CREATE TABLE moderated_channels_new (
+  topic BLOB NOT NULL,
+  chat_id TEXT NOT NULL,
+  moderator TEXT NOT NULL,
+  mode INTEGER NOT NULL DEFAULT 0,
+  list BLOB,
+  UNIQUE(topic) ON CONFLICT REPLACE
+);
+INSERT INTO moderated_channels_new(topic, chat_id, moderator, mode, list)
+SELECT topic, chat_id, moderator, mode, list FROM moderated_channels WHERE account = (SELECT account FROM database_account);
+DROP TABLE moderated_channels;
+ALTER TABLE moderated_channels_new RENAME TO moderated_channels;
+
+CREATE TABLE muted_chats_new (
+  chat_id TEXT NOT NULL,
+  UNIQUE(chat_id) ON CONFLICT REPLACE
+);
+INSERT INTO muted_chats_new(chat_id)
+SELECT chat_id FROM muted_chats WHERE account = (SELECT account FROM database_account);
+DROP TABLE muted_chats;
+ALTER TABLE muted_chats_new RENAME TO muted_chats;
+
+CREATE TABLE notification_preferences_new (
+  id INTEGER PRIMARY KEY ON CONFLICT REPLACE CHECK (id = 1),
+  preferences BLOB NOT NULL,
+  clock INT NOT NULL
+);
+INSERT INTO notification_preferences_new(id, preferences, clock)
+SELECT 1, preferences, clock FROM notification_preferences WHERE account = (SELECT account FROM database_account);
+DROP TABLE notification_preferences;
+ALTER TABLE notification_preferences_new RENAME TO notification_preferences;
+
+CREATE TABLE outbox_entries_new (
+  id TEXT NOT NULL,
+  history_id TEXT NOT NULL,
+  created_at INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(id) ON CONFLICT REPLACE
+);
+INSERT INTO outbox_entries_new(id, history_id, created_at, data)
+SELECT id, history_id, created_at, data FROM outbox_entries WHERE account = (SELECT account FROM database_account);
+DROP TABLE outbox_entries;
+ALTER TABLE outbox_entries_new RENAME TO outbox_entries;
+
+CREATE TABLE pending_messages_new (
+  hash BLOB NOT NULL,
+  sender BLOB NOT NULL,
+  message BLOB NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  received_at INT NOT NULL,
+  UNIQUE(hash)
+);
+INSERT INTO pending_messages_new(hash, sender, message, attempts, received_at)
+SELECT hash, sender, message, attempts, received_at FROM pending_messages WHERE account = (SELECT account FROM database_account);
+DROP TABLE pending_messages;
+ALTER TABLE pending_messages_new RENAME TO pending_messages;
+CREATE INDEX pending_messages_sender ON pending_messages(sender);
+
+CREATE TABLE presence_settings_new (
+  id INTEGER PRIMARY KEY ON CONFLICT REPLACE CHECK (id = 1),
+  settings BLOB NOT NULL
+);
+INSERT INTO presence_settings_new(id, settings)
+SELECT 1, settings FROM presence_settings WHERE account = (SELECT account FROM database_account);
+DROP TABLE presence_settings;
+ALTER TABLE presence_settings_new RENAME TO presence_settings;
+
+CREATE TABLE processed_messages_new (
+  sender BLOB NOT NULL,
+  message_hash BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  timestamp UNSIGNED BIG INT NOT NULL,
+  UNIQUE(sender, message_hash, installation_id) ON CONFLICT REPLACE
+);
+INSERT INTO processed_messages_new(sender, message_hash, installation_id, timestamp)
+SELECT sender, message_hash, installation_id, timestamp FROM processed_messages WHERE account = (SELECT account FROM database_account);
+DROP TABLE processed_messages;
+ALTER TABLE processed_messages_new RENAME TO processed_messages;
+CREATE INDEX processed_messages_timestamp ON processed_messages(timestamp);
+
+CREATE TABLE push_client_state_new (
+  id INTEGER PRIMARY KEY ON CONFLICT REPLACE CHECK (id = 1),
+  data BLOB NOT NULL
+);
+INSERT INTO push_client_state_new(id, data)
+SELECT 1, data FROM push_client_state WHERE account = (SELECT account FROM database_account);
+DROP TABLE push_client_state;
+ALTER TABLE push_client_state_new RENAME TO push_client_state;
+
+CREATE TABLE push_registrations_new (
+  public_key_hash BLOB NOT NULL,
+  installation_id TEXT NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(public_key_hash, installation_id) ON CONFLICT REPLACE
+);
+INSERT INTO push_registrations_new(public_key_hash, installation_id, data)
+SELECT public_key_hash, installation_id, data FROM push_registrations WHERE account = (SELECT account FROM database_account);
+DROP TABLE push_registrations;
+ALTER TABLE push_registrations_new RENAME TO push_registrations;
+
+CREATE TABLE read_receipts_new (
+  message_id TEXT NOT NULL,
+  reader TEXT NOT NULL,
+  clock INT NOT NULL,
+  UNIQUE(message_id, reader) ON CONFLICT IGNORE
+);
+INSERT INTO read_receipts_new(message_id, reader, clock)
+SELECT message_id, reader, clock FROM read_receipts WHERE account = (SELECT account FROM database_account);
+DROP TABLE read_receipts;
+ALTER TABLE read_receipts_new RENAME TO read_receipts;
+
+CREATE TABLE segments_new (
+  hash BLOB NOT NULL,
+  idx INT NOT NULL,
+  count INT NOT NULL,
+  data BLOB NOT NULL,
+  received_at INT NOT NULL,
+  UNIQUE(hash, idx)
+);
+INSERT INTO segments_new(hash, idx, count, data, received_at)
+SELECT hash, idx, count, data, received_at FROM segments WHERE account = (SELECT account FROM database_account);
+DROP TABLE segments;
+ALTER TABLE segments_new RENAME TO segments;
+
+CREATE TABLE sent_transactions_new (
+  hash BLOB NOT NULL,
+  original BLOB NOT NULL,
+  chain_id INTEGER NOT NULL,
+  sender BLOB NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(hash) ON CONFLICT REPLACE
+);
+INSERT INTO sent_transactions_new(hash, original, chain_id, sender, data)
+SELECT hash, original, chain_id, sender, data FROM sent_transactions WHERE account = (SELECT account FROM database_account);
+DROP TABLE sent_transactions;
+ALTER TABLE sent_transactions_new RENAME TO sent_transactions;
+CREATE INDEX sent_transactions_original ON sent_transactions(original);
+
+CREATE TABLE settings_new (
+  key TEXT NOT NULL,
+  value BLOB NOT NULL,
+  UNIQUE(key) ON CONFLICT REPLACE
+);
+INSERT INTO settings_new(key, value)
+SELECT key, value FROM settings WHERE account = (SELECT account FROM database_account);
+DROP TABLE settings;
+ALTER TABLE settings_new RENAME TO settings;
+
+CREATE TABLE sticker_content_new (
+  network_id INTEGER NOT NULL,
+  pack_id INTEGER NOT NULL,
+  hash BLOB NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(network_id, pack_id, hash) ON CONFLICT REPLACE
+);
+INSERT INTO sticker_content_new(network_id, pack_id, hash, data)
+SELECT network_id, pack_id, hash, data FROM sticker_content WHERE account = (SELECT account FROM database_account);
+DROP TABLE sticker_content;
+ALTER TABLE sticker_content_new RENAME TO sticker_content;
+CREATE INDEX sticker_content_hash ON sticker_content(network_id, hash);
+
+CREATE TABLE sticker_packs_new (
+  network_id INTEGER NOT NULL,
+  id INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  installed_at INTEGER NOT NULL,
+  UNIQUE(network_id, id) ON CONFLICT REPLACE
+);
+INSERT INTO sticker_packs_new(network_id, id, data, installed_at)
+SELECT network_id, id, data, installed_at FROM sticker_packs WHERE account = (SELECT account FROM database_account);
+DROP TABLE sticker_packs;
+ALTER TABLE sticker_packs_new RENAME TO sticker_packs;
+
+CREATE TABLE sync_clocks_new (
+  kind VARCHAR NOT NULL,
+  id TEXT NOT NULL,
+  clock INTEGER NOT NULL,
+  UNIQUE(kind, id) ON CONFLICT REPLACE
+);
+INSERT INTO sync_clocks_new(kind, id, clock)
+SELECT kind, id, clock FROM sync_clocks WHERE account = (SELECT account FROM database_account);
+DROP TABLE sync_clocks;
+ALTER TABLE sync_clocks_new RENAME TO sync_clocks;
+
+CREATE TABLE unsigned_transactions_new (
+  hash BLOB NOT NULL,
+  expires INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(hash) ON CONFLICT REPLACE
+);
+INSERT INTO unsigned_transactions_new(hash, expires, data)
+SELECT hash, expires, data FROM unsigned_transactions WHERE account = (SELECT account FROM database_account);
+DROP TABLE unsigned_transactions;
+ALTER TABLE unsigned_transactions_new RENAME TO unsigned_transactions;
+
+CREATE TABLE wallet_balance_snapshots_new (
+  chain_id INTEGER NOT NULL,
+  address BLOB NOT NULL,
+  token BLOB NOT NULL,
+  timestamp INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(chain_id, address, token, timestamp) ON CONFLICT REPLACE
+);
+INSERT INTO wallet_balance_snapshots_new(chain_id, address, token, timestamp, data)
+SELECT chain_id, address, token, timestamp, data FROM wallet_balance_snapshots WHERE account = (SELECT account FROM database_account);
+DROP TABLE wallet_balance_snapshots;
+ALTER TABLE wallet_balance_snapshots_new RENAME TO wallet_balance_snapshots;
+CREATE INDEX wallet_balance_snapshots_timestamp_idx ON wallet_balance_snapshots(chain_id, timestamp);
+
+CREATE TABLE wallet_custom_tokens_new (
+  network_id INTEGER NOT NULL,
+  address BLOB NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(network_id, address) ON CONFLICT REPLACE
+);
+INSERT INTO wallet_custom_tokens_new(network_id, address, data)
+SELECT network_id, address, data FROM wallet_custom_tokens WHERE account = (SELECT account FROM database_account);
+DROP TABLE wallet_custom_tokens;
+ALTER TABLE wallet_custom_tokens_new RENAME TO wallet_custom_tokens;
+
+CREATE TABLE wallet_transfers_new (
+  chain_id INTEGER NOT NULL,
+  address BLOB NOT NULL,
+  id TEXT NOT NULL,
+  block_number INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(chain_id, address, id) ON CONFLICT REPLACE
+);
+INSERT INTO wallet_transfers_new(chain_id, address, id, block_number, data)
+SELECT chain_id, address, id, block_number, data FROM wallet_transfers WHERE account = (SELECT account FROM database_account);
+DROP TABLE wallet_transfers;
+ALTER TABLE wallet_transfers_new RENAME TO wallet_transfers;
+CREATE INDEX wallet_transfers_block_idx ON wallet_transfers(chain_id, address, block_number, id);
+
+CREATE TABLE wallet_transfers_blocks_new (
+  chain_id INTEGER NOT NULL,
+  address BLOB NOT NULL,
+  block INTEGER NOT NULL,
+  UNIQUE(chain_id, address) ON CONFLICT REPLACE
+);
+INSERT INTO wallet_transfers_blocks_new(chain_id, address, block)
+SELECT chain_id, address, block FROM wallet_transfers_blocks WHERE account = (SELECT account FROM database_account);
+DROP TABLE wallet_transfers_blocks;
+ALTER TABLE wallet_transfers_blocks_new RENAME TO wallet_transfers_blocks;
+
+CREATE TABLE wipes_new (
+  id TEXT NOT NULL,
+  issued_at INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  UNIQUE(id) ON CONFLICT REPLACE
+);
+INSERT INTO wipes_new(id, issued_at, data)
+SELECT id, issued_at, data FROM wipes WHERE account = (SELECT account FROM database_account);
+DROP TABLE wipes;
+ALTER TABLE wipes_new RENAME TO wipes;
+
+DROP TABLE legacy_account;
+
+-- Dropping history_messages drops the triggers of its full-text index, which is
+-- rebuilt by the persistence.
+CREATE TABLE history_messages_new (
+  id TEXT NOT NULL,
+  chat_id TEXT NOT NULL,
+  author TEXT NOT NULL,
+  content TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  message_type TEXT NOT NULL,
+  reply_to TEXT NOT NULL,
+  clock INT NOT NULL,
+  timestamp INT NOT NULL,
+  outgoing BOOLEAN NOT NULL,
+  edited BOOLEAN NOT NULL DEFAULT 0,
+  tokens TEXT NOT NULL DEFAULT '',
+  encrypted BOOLEAN NOT NULL DEFAULT 0,
+  UNIQUE(id) ON CONFLICT IGNORE
+);
+INSERT INTO history_messages_new(rowid, id, chat_id, author, content, content_type, message_type, reply_to, clock, timestamp, outgoing, edited, tokens, encrypted)
+SELECT rowid, id, chat_id, author, content, content_type, message_type, reply_to, clock, timestamp, outgoing, edited, tokens, encrypted FROM history_messages WHERE account = (SELECT account FROM database_account);
+DROP TABLE history_messages;
+ALTER TABLE history_messages_new RENAME TO history_messages;
+CREATE INDEX history_messages_chat_clock ON history_messages(chat_id, clock, id);
+DROP TABLE database_account;
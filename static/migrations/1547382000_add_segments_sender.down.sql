@@ -0,0 +1,9 @@
+DROP TABLE segments;
+CREATE TABLE segments (
+  hash BLOB NOT NULL,
+  idx INT NOT NULL,
+  count INT NOT NULL,
+  data BLOB NOT NULL,
+  received_at INT NOT NULL,
+  UNIQUE(hash, idx)
+);
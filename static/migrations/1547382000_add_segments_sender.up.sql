@@ -0,0 +1,12 @@
+-- Payloads are reassembled from the segments of a single sender: the segments received
+-- before have no sender and are dropped.
+DROP TABLE segments;
+CREATE TABLE segments (
+  sender BLOB NOT NULL,
+  hash BLOB NOT NULL,
+  idx INT NOT NULL,
+  count INT NOT NULL,
+  data BLOB NOT NULL,
+  received_at INT NOT NULL,
+  UNIQUE(sender, hash, idx) ON CONFLICT IGNORE
+);
@@ -0,0 +1,289 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/status-im/status-go/account"
+)
+
+// DefaultUnsignedTransactionTTL is how long a prepared transaction waits for its signature.
+const DefaultUnsignedTransactionTTL = 10 * time.Minute
+
+var (
+	// ErrUnsignedTransactionNotFound is returned when submitting a transaction that wasn't
+	// prepared, or that was discarded or submitted already.
+	ErrUnsignedTransactionNotFound = errors.New("unsigned transaction not found")
+	// ErrUnsignedTransactionExpired is returned when submitting a transaction signed after its expiry.
+	ErrUnsignedTransactionExpired = errors.New("unsigned transaction expired")
+)
+
+// UnsignedTransaction is a transaction assembled by the node and waiting for the signature
+// of an external device, like a hardware wallet.
+type UnsignedTransaction struct {
+	From     gethcommon.Address  `json:"from"`
+	To       *gethcommon.Address `json:"to"`
+	Nonce    hexutil.Uint64      `json:"nonce"`
+	Gas      hexutil.Uint64      `json:"gas"`
+	GasPrice *hexutil.Big        `json:"gasPrice"`
+	Value    *hexutil.Big        `json:"value"`
+	Input    hexutil.Bytes       `json:"input"`
+	ChainID  *hexutil.Big        `json:"chainId"`
+	// Hash is the EIP-155 hash of the transaction signed by the device, which identifies it.
+	Hash gethcommon.Hash `json:"hash"`
+	// RLP is the encoding of the transaction signed by hardware wallets:
+	// [nonce, gasPrice, gas, to, value, input, chainId, 0, 0].
+	RLP hexutil.Bytes `json:"rlp"`
+	// Expires is the time the transaction is discarded if it's not signed, in seconds.
+	Expires int64 `json:"expires"`
+}
+
+// Store persists the transactions waiting for an external signature and the transactions
+// sent, so that they are not dropped, and can be replaced, when the application restarts.
+type Store interface {
+	SaveUnsignedTransaction(UnsignedTransaction) error
+	DeleteUnsignedTransaction(hash gethcommon.Hash) error
+	GetUnsignedTransactions() ([]UnsignedTransaction, error)
+
+	SaveSentTransaction(SentTransaction) error
+	// DeleteSentTransactions deletes the transactions competing with original.
+	DeleteSentTransactions(original gethcommon.Hash) error
+	// GetSentTransactions returns the transactions sent, in the order they were saved.
+	GetSentTransactions() ([]SentTransaction, error)
+}
+
+// unsignedTransactions is the queue of the transactions waiting for an external signature.
+type unsignedTransactions struct {
+	mu     sync.Mutex
+	byHash map[gethcommon.Hash]*UnsignedTransaction
+	store  Store
+}
+
+func (u *unsignedTransactions) add(tx *UnsignedTransaction) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.store != nil {
+		if err := u.store.SaveUnsignedTransaction(*tx); err != nil {
+			return err
+		}
+	}
+	if u.byHash == nil {
+		u.byHash = make(map[gethcommon.Hash]*UnsignedTransaction)
+	}
+	u.byHash[tx.Hash] = tx
+	return nil
+}
+
+func (u *unsignedTransactions) get(hash gethcommon.Hash) *UnsignedTransaction {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.byHash[hash]
+}
+
+func (u *unsignedTransactions) remove(hash gethcommon.Hash) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	_, ok := u.byHash[hash]
+	u.delete(hash)
+	return ok
+}
+
+// delete must be called with the lock held.
+func (u *unsignedTransactions) delete(hash gethcommon.Hash) {
+	delete(u.byHash, hash)
+	if u.store != nil {
+		if err := u.store.DeleteUnsignedTransaction(hash); err != nil {
+			log.Error("failed to delete unsigned transaction", "hash", hash, "err", err)
+		}
+	}
+}
+
+// load replaces the queue with the transactions persisted in the store, or empties it if
+// the store is nil.
+func (u *unsignedTransactions) load(store Store) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.store = store
+	u.byHash = make(map[gethcommon.Hash]*UnsignedTransaction)
+	if store == nil {
+		return nil
+	}
+	txs, err := store.GetUnsignedTransactions()
+	if err != nil {
+		return err
+	}
+	for i := range txs {
+		u.byHash[txs[i].Hash] = &txs[i]
+	}
+	return nil
+}
+
+// pending removes the expired transactions and returns the others, by nonce.
+func (u *unsignedTransactions) pending(now time.Time) []*UnsignedTransaction {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	var txs []*UnsignedTransaction
+	for hash, tx := range u.byHash {
+		if now.Unix() > tx.Expires {
+			u.delete(hash)
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	sort.Slice(txs, func(i, j int) bool { return txs[i].Nonce < txs[j].Nonce })
+	return txs
+}
+
+// PrepareTransaction assembles a transaction of the selected account, like
+// eth_sendTransaction, without signing it. It is queued until it's signed by an external
+// device and submitted with SendRawTransaction, or until it expires. Its nonce follows
+// the transactions of the account waiting for a signature.
+func (t *Transactor) PrepareTransaction(args SendTxArgs, selectedAccount *account.SelectedExtKey) (*UnsignedTransaction, error) {
+	if err := t.validateAccount(args, selectedAccount); err != nil {
+		return nil, err
+	}
+	if !args.Valid() {
+		return nil, ErrInvalidSendTxArgs
+	}
+
+	backend, err := t.backend(args.chainID())
+	if err != nil {
+		return nil, err
+	}
+
+	t.addrLock.LockAddr(args.From)
+	defer t.addrLock.UnlockAddr(args.From)
+
+	var minNonce uint64
+	if val, ok := backend.localNonce.Load(args.From); ok {
+		minNonce = val.(uint64)
+	}
+	now := time.Now()
+	for _, queued := range t.unsigned.pending(now) {
+		if queued.From == args.From && queued.ChainID.ToInt().Cmp(backend.chainID) == 0 && uint64(queued.Nonce) >= minNonce {
+			minNonce = uint64(queued.Nonce) + 1
+		}
+	}
+	tx, err := t.newTransaction(backend, args, minNonce)
+	if err != nil {
+		return nil, err
+	}
+
+	chainID := backend.chainID
+	encoded, err := rlp.EncodeToBytes([]interface{}{
+		tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), chainID, uint(0), uint(0),
+	})
+	if err != nil {
+		return nil, err
+	}
+	unsigned := &UnsignedTransaction{
+		From:     args.From,
+		To:       tx.To(),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: (*hexutil.Big)(tx.GasPrice()),
+		Value:    (*hexutil.Big)(tx.Value()),
+		Input:    tx.Data(),
+		ChainID:  (*hexutil.Big)(chainID),
+		Hash:     types.NewEIP155Signer(chainID).Hash(tx),
+		RLP:      encoded,
+		Expires:  now.Add(t.unsignedTTL).Unix(),
+	}
+	if err := t.unsigned.add(unsigned); err != nil {
+		return nil, err
+	}
+	t.log.Info("Transaction waiting for an external signature", "hash", unsigned.Hash, "nonce", tx.Nonce())
+	return unsigned, nil
+}
+
+// SendRawTransaction submits a transaction prepared with PrepareTransaction, once signed
+// by an external device. It returns the hash of the signed transaction.
+func (t *Transactor) SendRawTransaction(data []byte) (gethcommon.Hash, error) {
+	var hash gethcommon.Hash
+	signedTx := new(types.Transaction)
+	if err := rlp.DecodeBytes(data, signedTx); err != nil {
+		return hash, err
+	}
+	backend, err := t.backend(signedTx.ChainId().Uint64())
+	if err != nil {
+		return hash, err
+	}
+	signer := types.NewEIP155Signer(backend.chainID)
+	unsigned := t.unsigned.get(signer.Hash(signedTx))
+	if unsigned == nil {
+		return hash, ErrUnsignedTransactionNotFound
+	}
+	if time.Now().Unix() > unsigned.Expires {
+		t.unsigned.remove(unsigned.Hash)
+		return hash, ErrUnsignedTransactionExpired
+	}
+	from, err := types.Sender(signer, signedTx)
+	if err != nil {
+		return hash, err
+	}
+	if from != unsigned.From {
+		return hash, ErrInvalidTxSender
+	}
+
+	t.addrLock.LockAddr(from)
+	defer t.addrLock.UnlockAddr(from)
+	if !t.unsigned.remove(unsigned.Hash) {
+		return hash, ErrUnsignedTransactionNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.rpcCallTimeout)
+	defer cancel()
+	if err := backend.sender.SendTransaction(ctx, signedTx); err != nil {
+		// the signed transaction can be submitted again until it expires
+		if addErr := t.unsigned.add(unsigned); addErr != nil {
+			t.log.Error("failed to queue unsigned transaction again", "hash", unsigned.Hash, "err", addErr)
+		}
+		return hash, err
+	}
+	if val, ok := backend.localNonce.Load(from); !ok || val.(uint64) <= signedTx.Nonce() {
+		backend.localNonce.Store(from, signedTx.Nonce()+1)
+	}
+	t.replacements.track(backend.chainID.Uint64(), from, signedTx)
+	return signedTx.Hash(), nil
+}
+
+// SetStore sets the store of the transactions of the selected account waiting for an
+// external signature, or nil when no account is selected. The transactions queued before
+// are dropped, and those persisted in the store that didn't expire are queued and returned,
+// so that they can be signed after the application restarted. The transactions sent by
+// the account that didn't land can be replaced again.
+func (t *Transactor) SetStore(store Store) ([]UnsignedTransaction, error) {
+	if err := t.unsigned.load(store); err != nil {
+		return nil, err
+	}
+	if err := t.replacements.load(store); err != nil {
+		return nil, err
+	}
+	return t.UnsignedTransactions(), nil
+}
+
+// UnsignedTransactions returns the transactions waiting for an external signature, by nonce.
+func (t *Transactor) UnsignedTransactions() []UnsignedTransaction {
+	result := []UnsignedTransaction{}
+	for _, tx := range t.unsigned.pending(time.Now()) {
+		result = append(result, *tx)
+	}
+	return result
+}
+
+// DiscardTransaction removes a transaction waiting for an external signature, given its hash.
+func (t *Transactor) DiscardTransaction(hash gethcommon.Hash) error {
+	if !t.unsigned.remove(hash) {
+		return ErrUnsignedTransactionNotFound
+	}
+	return nil
+}
@@ -95,3 +95,18 @@ func (mr *MockPublicTransactionPoolAPIMockRecorder) SendRawTransaction(ctx, enco
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendRawTransaction", reflect.TypeOf((*MockPublicTransactionPoolAPI)(nil).SendRawTransaction), ctx, encodedTx)
 }
+
+// GetTransactionReceipt mocks base method
+func (m *MockPublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionReceipt", ctx, hash)
+	ret0, _ := ret[0].(map[string]interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactionReceipt indicates an expected call of GetTransactionReceipt
+func (mr *MockPublicTransactionPoolAPIMockRecorder) GetTransactionReceipt(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionReceipt", reflect.TypeOf((*MockPublicTransactionPoolAPI)(nil).GetTransactionReceipt), ctx, hash)
+}
@@ -37,4 +37,5 @@ type PublicTransactionPoolAPI interface {
 	EstimateGas(ctx context.Context, args CallArgs) (hexutil.Uint64, error)
 	GetTransactionCount(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (*hexutil.Uint64, error)
 	SendRawTransaction(ctx context.Context, encodedTx hexutil.Bytes) (common.Hash, error)
+	GetTransactionReceipt(ctx context.Context, hash common.Hash) (map[string]interface{}, error)
 }
@@ -0,0 +1,332 @@
+package transactions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/status-im/status-go/account"
+)
+
+const (
+	// replacementPriceBump is the minimum gas price increase in percent
+	// required by nodes to replace a pending transaction.
+	replacementPriceBump = 10
+
+	cancelGas = 21000
+)
+
+var (
+	// ErrTransactionNotFound is returned when replacing a transaction that wasn't sent by the transactor.
+	ErrTransactionNotFound = errors.New("transaction not found")
+	// ErrReplacementUnderpriced is returned when the gas price of a replacement isn't high enough.
+	ErrReplacementUnderpriced = errors.New("replacement transaction underpriced")
+	// ErrTransactionLanded is returned when replacing a transaction whose nonce was already used.
+	ErrTransactionLanded = errors.New("a transaction with the same nonce already landed")
+)
+
+// ReceiptProvider provides the receipts of mined transactions.
+type ReceiptProvider interface {
+	// TransactionMined returns true if the transaction is included in a block.
+	TransactionMined(ctx context.Context, hash gethcommon.Hash) (bool, error)
+}
+
+// SentTransaction is a transaction sent by the transactor, persisted so that it can be
+// replaced after the application restarted. The transactions competing for a nonce
+// share the hash of the first one sent, Original.
+type SentTransaction struct {
+	Original gethcommon.Hash    `json:"original"`
+	ChainID  uint64             `json:"chainId"`
+	From     gethcommon.Address `json:"from"`
+	Tx       *types.Transaction `json:"transaction"`
+}
+
+// ReplacementStatus lists the competing transactions sharing a nonce and the one that landed, if any.
+type ReplacementStatus struct {
+	Hashes []gethcommon.Hash `json:"hashes"`
+	Landed *gethcommon.Hash  `json:"landed"`
+}
+
+// competingTransactions are the transactions of an account sharing a nonce,
+// only one of them can be mined.
+type competingTransactions struct {
+	chainID uint64
+	from    gethcommon.Address
+	txs     []*types.Transaction
+	landed  *gethcommon.Hash
+}
+
+func (c *competingTransactions) latest() *types.Transaction {
+	return c.txs[len(c.txs)-1]
+}
+
+func (c *competingTransactions) status() *ReplacementStatus {
+	status := &ReplacementStatus{Landed: c.landed}
+	for _, tx := range c.txs {
+		status.Hashes = append(status.Hashes, tx.Hash())
+	}
+	return status
+}
+
+// replacements tracks the competing transactions by their hashes.
+type replacements struct {
+	mu    sync.Mutex
+	byTxs map[gethcommon.Hash]*competingTransactions
+	store Store
+}
+
+func (r *replacements) track(chainID uint64, from gethcommon.Address, tx *types.Transaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.byTxs == nil {
+		r.byTxs = make(map[gethcommon.Hash]*competingTransactions)
+	}
+	c := &competingTransactions{chainID: chainID, from: from, txs: []*types.Transaction{tx}}
+	r.byTxs[tx.Hash()] = c
+	r.save(c, tx)
+}
+
+func (r *replacements) get(hash gethcommon.Hash) *competingTransactions {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.byTxs[hash]
+}
+
+func (r *replacements) add(c *competingTransactions, tx *types.Transaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c.txs = append(c.txs, tx)
+	r.byTxs[tx.Hash()] = c
+	r.save(c, tx)
+}
+
+// save persists a transaction sent, it must be called with the lock held. The transaction
+// was sent already, so failing to persist it only prevents replacing it after a restart.
+func (r *replacements) save(c *competingTransactions, tx *types.Transaction) {
+	if r.store == nil {
+		return
+	}
+	sent := SentTransaction{Original: c.txs[0].Hash(), ChainID: c.chainID, From: c.from, Tx: tx}
+	if err := r.store.SaveSentTransaction(sent); err != nil {
+		log.Error("failed to save sent transaction", "hash", tx.Hash(), "err", err)
+	}
+}
+
+// landed records the transaction of c that was mined. The competing transactions are
+// not persisted anymore as they can't be replaced.
+func (r *replacements) landed(c *competingTransactions, hash gethcommon.Hash) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c.landed = &hash
+	if r.store == nil {
+		return
+	}
+	if err := r.store.DeleteSentTransactions(c.txs[0].Hash()); err != nil {
+		log.Error("failed to delete sent transactions", "hash", hash, "err", err)
+	}
+}
+
+// load replaces the tracked transactions with the ones persisted in the store, or
+// forgets them if the store is nil.
+func (r *replacements) load(store Store) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store = store
+	r.byTxs = make(map[gethcommon.Hash]*competingTransactions)
+	if store == nil {
+		return nil
+	}
+	sent, err := store.GetSentTransactions()
+	if err != nil {
+		return err
+	}
+	for _, s := range sent {
+		c, ok := r.byTxs[s.Original]
+		if !ok {
+			c = &competingTransactions{chainID: s.ChainID, from: s.From}
+		}
+		c.txs = append(c.txs, s.Tx)
+		r.byTxs[s.Tx.Hash()] = c
+	}
+	return nil
+}
+
+// sent returns the transactions competing for a nonce which didn't land yet, by original
+// and in the order they were sent.
+func (r *replacements) sent() []SentTransaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var competing []*competingTransactions
+	for hash, c := range r.byTxs {
+		if c.landed == nil && c.txs[0].Hash() == hash {
+			competing = append(competing, c)
+		}
+	}
+	sort.Slice(competing, func(i, j int) bool {
+		if competing[i].chainID != competing[j].chainID {
+			return competing[i].chainID < competing[j].chainID
+		}
+		if competing[i].from != competing[j].from {
+			return bytes.Compare(competing[i].from.Bytes(), competing[j].from.Bytes()) < 0
+		}
+		return competing[i].txs[0].Nonce() < competing[j].txs[0].Nonce()
+	})
+	result := []SentTransaction{}
+	for _, c := range competing {
+		for _, tx := range c.txs {
+			result = append(result, SentTransaction{Original: c.txs[0].Hash(), ChainID: c.chainID, From: c.from, Tx: tx})
+		}
+	}
+	return result
+}
+
+// minReplacementGasPrice returns the lowest gas price accepted to replace a transaction.
+func minReplacementGasPrice(gasPrice *big.Int) *big.Int {
+	price := new(big.Int).Mul(gasPrice, big.NewInt(100+replacementPriceBump))
+	price.Add(price, big.NewInt(99))
+	return price.Div(price, big.NewInt(100))
+}
+
+// SpeedUpTransaction replaces a pending transaction with the same one paying a higher gas price.
+func (t *Transactor) SpeedUpTransaction(hash gethcommon.Hash, gasPrice *big.Int, selectedAccount *account.SelectedExtKey) (gethcommon.Hash, error) {
+	return t.replace(hash, selectedAccount, func(backend *chainBackend, old *types.Transaction) (*types.Transaction, error) {
+		if gasPrice.Cmp(minReplacementGasPrice(old.GasPrice())) < 0 {
+			return nil, ErrReplacementUnderpriced
+		}
+		if old.To() == nil {
+			return types.NewContractCreation(old.Nonce(), old.Value(), old.Gas(), gasPrice, old.Data()), nil
+		}
+		return types.NewTransaction(old.Nonce(), *old.To(), old.Value(), old.Gas(), gasPrice, old.Data()), nil
+	})
+}
+
+// CancelTransaction replaces a pending transaction with an empty transfer to the sender itself,
+// paying the higher of the minimum replacement and the suggested gas prices.
+func (t *Transactor) CancelTransaction(hash gethcommon.Hash, selectedAccount *account.SelectedExtKey) (gethcommon.Hash, error) {
+	return t.replace(hash, selectedAccount, func(backend *chainBackend, old *types.Transaction) (*types.Transaction, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), t.rpcCallTimeout)
+		defer cancel()
+		gasPrice, err := backend.gasCalculator.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if min := minReplacementGasPrice(old.GasPrice()); gasPrice.Cmp(min) < 0 {
+			gasPrice = min
+		}
+		return types.NewTransaction(old.Nonce(), selectedAccount.Address, big.NewInt(0), cancelGas, gasPrice, nil), nil
+	})
+}
+
+// SentTransactions returns the transactions sent which didn't land yet, with the
+// transactions replacing them, by chain, sender and nonce.
+func (t *Transactor) SentTransactions() []SentTransaction {
+	return t.replacements.sent()
+}
+
+// ReplacementStatus returns the transactions competing with a transaction
+// and the one that landed, if any.
+func (t *Transactor) ReplacementStatus(hash gethcommon.Hash) (*ReplacementStatus, error) {
+	competing := t.replacements.get(hash)
+	if competing == nil {
+		return nil, ErrTransactionNotFound
+	}
+	backend, err := t.backend(competing.chainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.checkLanded(backend, competing); err != nil {
+		return nil, err
+	}
+
+	t.replacements.mu.Lock()
+	defer t.replacements.mu.Unlock()
+	return competing.status(), nil
+}
+
+func (t *Transactor) checkLanded(backend *chainBackend, competing *competingTransactions) error {
+	t.replacements.mu.Lock()
+	landed := competing.landed
+	txs := append([]*types.Transaction{}, competing.txs...)
+	t.replacements.mu.Unlock()
+	if landed != nil {
+		return nil
+	}
+
+	for _, tx := range txs {
+		ctx, cancel := context.WithTimeout(context.Background(), t.rpcCallTimeout)
+		mined, err := backend.receiptProvider.TransactionMined(ctx, tx.Hash())
+		cancel()
+		if err != nil {
+			return err
+		}
+		if mined {
+			hash := tx.Hash()
+			t.replacements.landed(competing, hash)
+			t.log.Info("Competing transaction landed", "hash", hash, "nonce", tx.Nonce())
+			return nil
+		}
+	}
+	return nil
+}
+
+func (t *Transactor) replace(hash gethcommon.Hash, selectedAccount *account.SelectedExtKey, build func(backend *chainBackend, old *types.Transaction) (*types.Transaction, error)) (gethcommon.Hash, error) {
+	var newHash gethcommon.Hash
+	competing := t.replacements.get(hash)
+	if competing == nil {
+		return newHash, ErrTransactionNotFound
+	}
+	if selectedAccount == nil {
+		return newHash, account.ErrNoAccountSelected
+	}
+	if competing.from != selectedAccount.Address {
+		return newHash, ErrInvalidTxSender
+	}
+	backend, err := t.backend(competing.chainID)
+	if err != nil {
+		return newHash, err
+	}
+
+	t.addrLock.LockAddr(competing.from)
+	defer t.addrLock.UnlockAddr(competing.from)
+
+	if err := t.checkLanded(backend, competing); err != nil {
+		return newHash, err
+	}
+	t.replacements.mu.Lock()
+	landed := competing.landed
+	old := competing.latest()
+	t.replacements.mu.Unlock()
+	if landed != nil {
+		return newHash, ErrTransactionLanded
+	}
+
+	signer, err := NewAccountSigner(selectedAccount)
+	if err != nil {
+		return newHash, err
+	}
+	tx, err := build(backend, old)
+	if err != nil {
+		return newHash, err
+	}
+	signedTx, err := signer.SignTx(tx, backend.chainID)
+	if err != nil {
+		return newHash, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.rpcCallTimeout)
+	defer cancel()
+	if err := backend.sender.SendTransaction(ctx, signedTx); err != nil {
+		return newHash, err
+	}
+	t.log.Info("Replaced transaction", "old", old.Hash(), "new", signedTx.Hash(), "nonce", signedTx.Nonce(), "gasPrice", signedTx.GasPrice())
+
+	t.replacements.add(competing, signedTx)
+	return signedTx.Hash(), nil
+}
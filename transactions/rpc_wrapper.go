@@ -65,6 +65,17 @@ func (w *rpcWrapper) SendTransaction(ctx context.Context, tx *types.Transaction)
 	return w.rpcClient.CallContext(ctx, nil, "eth_sendRawTransaction", hexutil.Encode(data))
 }
 
+// TransactionMined returns true if the transaction is included in a block.
+func (w *rpcWrapper) TransactionMined(ctx context.Context, hash common.Hash) (bool, error) {
+	var receipt *struct {
+		BlockNumber *hexutil.Big `json:"blockNumber"`
+	}
+	if err := w.rpcClient.CallContext(ctx, &receipt, "eth_getTransactionReceipt", hash); err != nil {
+		return false, err
+	}
+	return receipt != nil && receipt.BlockNumber != nil, nil
+}
+
 func toCallArg(msg ethereum.CallMsg) interface{} {
 	arg := map[string]interface{}{
 		"from": msg.From,
@@ -30,13 +30,20 @@ type Transactor struct {
 	sender               ethereum.TransactionSender
 	pendingNonceProvider PendingNonceProvider
 	gasCalculator        GasCalculator
+	receiptProvider      ReceiptProvider
 	sendTxTimeout        time.Duration
 	rpcCallTimeout       time.Duration
 	networkID            uint64
 
-	addrLock   *AddrLocker
-	localNonce sync.Map
-	log        log.Logger
+	networksMu sync.RWMutex
+	networks   map[uint64]*chainBackend // backends of the additional networks, by chain ID
+
+	addrLock     *AddrLocker
+	localNonce   sync.Map
+	replacements replacements
+	unsigned     unsignedTransactions
+	unsignedTTL  time.Duration
+	log          log.Logger
 }
 
 // NewTransactor returns a new Manager.
@@ -44,6 +51,7 @@ func NewTransactor() *Transactor {
 	return &Transactor{
 		addrLock:      &AddrLocker{},
 		sendTxTimeout: sendTxTimeout,
+		unsignedTTL:   DefaultUnsignedTransactionTTL,
 		localNonce:    sync.Map{},
 		log:           log.New("package", "status-go/transactions.Manager"),
 	}
@@ -60,9 +68,61 @@ func (t *Transactor) SetRPC(rpcClient *rpc.Client, timeout time.Duration) {
 	t.sender = rpcWrapper
 	t.pendingNonceProvider = rpcWrapper
 	t.gasCalculator = rpcWrapper
+	t.receiptProvider = rpcWrapper
 	t.rpcCallTimeout = timeout
 }
 
+// SetNetworkRPCs sets the RPC clients of the additional networks the transactions can be
+// sent to, by chain ID, replacing those set before.
+func (t *Transactor) SetNetworkRPCs(clients map[uint64]*rpc.Client) {
+	networks := make(map[uint64]*chainBackend, len(clients))
+	for chainID, client := range clients {
+		rpcWrapper := newRPCWrapper(client)
+		networks[chainID] = &chainBackend{
+			chainID:              new(big.Int).SetUint64(chainID),
+			sender:               rpcWrapper,
+			pendingNonceProvider: rpcWrapper,
+			gasCalculator:        rpcWrapper,
+			receiptProvider:      rpcWrapper,
+			localNonce:           &sync.Map{},
+		}
+	}
+	t.networksMu.Lock()
+	defer t.networksMu.Unlock()
+	t.networks = networks
+}
+
+// chainBackend sends the transactions of a chain and keeps the nonces used on it.
+type chainBackend struct {
+	chainID              *big.Int
+	sender               ethereum.TransactionSender
+	pendingNonceProvider PendingNonceProvider
+	gasCalculator        GasCalculator
+	receiptProvider      ReceiptProvider
+	localNonce           *sync.Map
+}
+
+// backend returns the backend of a chain. The zero chain ID is the network of the node.
+func (t *Transactor) backend(chainID uint64) (*chainBackend, error) {
+	if chainID == 0 || chainID == t.networkID {
+		return &chainBackend{
+			chainID:              new(big.Int).SetUint64(t.networkID),
+			sender:               t.sender,
+			pendingNonceProvider: t.pendingNonceProvider,
+			gasCalculator:        t.gasCalculator,
+			receiptProvider:      t.receiptProvider,
+			localNonce:           &t.localNonce,
+		}, nil
+	}
+	t.networksMu.RLock()
+	defer t.networksMu.RUnlock()
+	backend, ok := t.networks[chainID]
+	if !ok {
+		return nil, ErrUnknownChain
+	}
+	return backend, nil
+}
+
 // SendTransaction is an implementation of eth_sendTransaction. It queues the tx to the sign queue.
 func (t *Transactor) SendTransaction(sendArgs SendTxArgs, verifiedAccount *account.SelectedExtKey) (hash gethcommon.Hash, err error) {
 	hash, err = t.validateAndPropagate(verifiedAccount, sendArgs)
@@ -90,9 +150,17 @@ func (t *Transactor) validateAndPropagate(selectedAccount *account.SelectedExtKe
 	if !args.Valid() {
 		return hash, ErrInvalidSendTxArgs
 	}
+	backend, err := t.backend(args.chainID())
+	if err != nil {
+		return hash, err
+	}
+	signer, err := NewAccountSigner(selectedAccount)
+	if err != nil {
+		return hash, err
+	}
 	t.addrLock.LockAddr(args.From)
 	var localNonce uint64
-	if val, ok := t.localNonce.Load(args.From); ok {
+	if val, ok := backend.localNonce.Load(args.From); ok {
 		localNonce = val.(uint64)
 	}
 	var nonce uint64
@@ -100,40 +168,62 @@ func (t *Transactor) validateAndPropagate(selectedAccount *account.SelectedExtKe
 		// nonce should be incremented only if tx completed without error
 		// if upstream node returned nonce higher than ours we will stick to it
 		if err == nil {
-			t.localNonce.Store(args.From, nonce+1)
+			backend.localNonce.Store(args.From, nonce+1)
 		}
 		t.addrLock.UnlockAddr(args.From)
 
 	}()
+	tx, err := t.newTransaction(backend, args, localNonce)
+	if err != nil {
+		return hash, err
+	}
+	nonce = tx.Nonce()
+	signedTx, err := signer.SignTx(tx, backend.chainID)
+	if err != nil {
+		return hash, err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), t.rpcCallTimeout)
 	defer cancel()
-	nonce, err = t.pendingNonceProvider.PendingNonceAt(ctx, args.From)
-	if err != nil {
+
+	if err := backend.sender.SendTransaction(ctx, signedTx); err != nil {
 		return hash, err
 	}
+	t.replacements.track(backend.chainID.Uint64(), args.From, signedTx)
+	return signedTx.Hash(), nil
+}
+
+// newTransaction assembles the transaction of args on the chain of backend, estimating
+// its gas and gas price if they're not set. Its nonce is the pending nonce of the sender,
+// unless minNonce is higher. It must be called with the address of the sender locked.
+func (t *Transactor) newTransaction(backend *chainBackend, args SendTxArgs, minNonce uint64) (*types.Transaction, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.rpcCallTimeout)
+	defer cancel()
+	nonce, err := backend.pendingNonceProvider.PendingNonceAt(ctx, args.From)
+	if err != nil {
+		return nil, err
+	}
 	// if upstream node returned nonce higher than ours we will use it, as it probably means
 	// that another client was used for sending transactions
-	if localNonce > nonce {
-		nonce = localNonce
+	if minNonce > nonce {
+		nonce = minNonce
 	}
 	gasPrice := (*big.Int)(args.GasPrice)
 	if args.GasPrice == nil {
 		ctx, cancel = context.WithTimeout(context.Background(), t.rpcCallTimeout)
 		defer cancel()
-		gasPrice, err = t.gasCalculator.SuggestGasPrice(ctx)
+		gasPrice, err = backend.gasCalculator.SuggestGasPrice(ctx)
 		if err != nil {
-			return hash, err
+			return nil, err
 		}
 	}
 
-	chainID := big.NewInt(int64(t.networkID))
 	value := (*big.Int)(args.Value)
 
 	var gas uint64
 	if args.Gas == nil {
 		ctx, cancel = context.WithTimeout(context.Background(), t.rpcCallTimeout)
 		defer cancel()
-		gas, err = t.gasCalculator.EstimateGas(ctx, ethereum.CallMsg{
+		gas, err = backend.gasCalculator.EstimateGas(ctx, ethereum.CallMsg{
 			From:     args.From,
 			To:       args.To,
 			GasPrice: gasPrice,
@@ -141,7 +231,7 @@ func (t *Transactor) validateAndPropagate(selectedAccount *account.SelectedExtKe
 			Data:     args.GetInput(),
 		})
 		if err != nil {
-			return hash, err
+			return nil, err
 		}
 		if gas < defaultGas {
 			t.log.Info("default gas will be used because estimated is lower", "estimated", gas, "default", defaultGas)
@@ -151,7 +241,6 @@ func (t *Transactor) validateAndPropagate(selectedAccount *account.SelectedExtKe
 		gas = uint64(*args.Gas)
 	}
 
-	var tx *types.Transaction
 	if args.To != nil {
 		t.log.Info("New transaction",
 			"From", args.From,
@@ -160,27 +249,46 @@ func (t *Transactor) validateAndPropagate(selectedAccount *account.SelectedExtKe
 			"GasPrice", gasPrice,
 			"Value", value,
 		)
-		tx = types.NewTransaction(nonce, *args.To, value, gas, gasPrice, args.GetInput())
-	} else {
-		// contract creation is rare enough to log an expected address
-		t.log.Info("New contract",
-			"From", args.From,
-			"Gas", gas,
-			"GasPrice", gasPrice,
-			"Value", value,
-			"Contract address", crypto.CreateAddress(args.From, nonce),
-		)
-		tx = types.NewContractCreation(nonce, value, gas, gasPrice, args.GetInput())
+		return types.NewTransaction(nonce, *args.To, value, gas, gasPrice, args.GetInput()), nil
 	}
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), selectedAccount.AccountKey.PrivateKey)
-	if err != nil {
-		return hash, err
+	// contract creation is rare enough to log an expected address
+	t.log.Info("New contract",
+		"From", args.From,
+		"Gas", gas,
+		"GasPrice", gasPrice,
+		"Value", value,
+		"Contract address", crypto.CreateAddress(args.From, nonce),
+	)
+	return types.NewContractCreation(nonce, value, gas, gasPrice, args.GetInput()), nil
+}
+
+// Signer signs the transactions assembled by the Transactor. The keys of watch-only
+// accounts are kept by an external device, like a hardware wallet, which has no Signer:
+// their transactions are queued with PrepareTransaction, exported to the device, and
+// submitted with SendRawTransaction once signed.
+type Signer interface {
+	// SignTx returns the transaction signed for the chain.
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// NewAccountSigner returns the Signer of an account, whose key can be kept by the platform
+// keystore. It returns ErrExternalSignatureRequired for watch-only accounts.
+func NewAccountSigner(selectedAccount *account.SelectedExtKey) (Signer, error) {
+	if selectedAccount.WatchOnly() {
+		return nil, ErrExternalSignatureRequired
 	}
-	ctx, cancel = context.WithTimeout(context.Background(), t.rpcCallTimeout)
-	defer cancel()
+	return accountSigner{selectedAccount}, nil
+}
 
-	if err := t.sender.SendTransaction(ctx, signedTx); err != nil {
-		return hash, err
+type accountSigner struct {
+	account *account.SelectedExtKey
+}
+
+func (s accountSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signer := types.NewEIP155Signer(chainID)
+	sig, err := s.account.SignHash(signer.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
 	}
-	return signedTx.Hash(), nil
+	return tx.WithSignature(signer, sig)
 }
@@ -2,6 +2,7 @@ package transactions
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"errors"
 	"math"
 	"math/big"
@@ -259,6 +260,46 @@ func (s *TransactorSuite) TestLocalNonce() {
 	s.Equal(uint64(nonce)+1, resultNonce.(uint64))
 }
 
+func (s *TransactorSuite) TestOtherNetwork() {
+	key, _ := crypto.GenerateKey()
+	selectedAccount := &account.SelectedExtKey{
+		Address:    account.FromAddress(TestConfig.Account1.Address),
+		AccountKey: &keystore.Key{PrivateKey: key},
+	}
+	server, txServiceMock := fake.NewTestServer(s.txServiceMockCtrl)
+	defer server.Stop()
+	client := gethrpc.DialInProc(server)
+	defer client.Close()
+	rpcClient, _ := rpc.NewClient(client, params.UpstreamRPCConfig{})
+	s.manager.SetNetworkRPCs(map[uint64]*rpc.Client{10: rpcClient})
+
+	chainID := hexutil.Uint64(10)
+	args := SendTxArgs{
+		From:     account.FromAddress(TestConfig.Account1.Address),
+		To:       account.ToAddress(TestConfig.Account2.Address),
+		Gas:      &testGas,
+		GasPrice: testGasPrice,
+		ChainID:  &chainID,
+	}
+	nonce := hexutil.Uint64(5)
+	config := *s.nodeConfig
+	config.NetworkID = 10
+	txServiceMock.EXPECT().GetTransactionCount(gomock.Any(), selectedAccount.Address, gethrpc.PendingBlockNumber).Return(&nonce, nil)
+	data := s.rlpEncodeTx(args, &config, selectedAccount, &nonce, testGas, testGasPrice.ToInt())
+	txServiceMock.EXPECT().SendRawTransaction(gomock.Any(), data).Return(gethcommon.Hash{}, nil)
+
+	hash, err := s.manager.SendTransaction(args, selectedAccount)
+	s.Require().NoError(err)
+	_, ok := s.manager.localNonce.Load(args.From)
+	s.False(ok, "Nonces are kept for each chain")
+	s.Equal(uint64(10), s.manager.replacements.get(hash).chainID)
+
+	unknown := hexutil.Uint64(3)
+	args.ChainID = &unknown
+	_, err = s.manager.SendTransaction(args, selectedAccount)
+	s.Equal(ErrUnknownChain, err)
+}
+
 func (s *TransactorSuite) TestContractCreation() {
 	key, _ := crypto.GenerateKey()
 	testaddr := crypto.PubkeyToAddress(key.PublicKey)
@@ -285,3 +326,219 @@ func (s *TransactorSuite) TestContractCreation() {
 	s.NoError(err)
 	s.Equal(crypto.CreateAddress(testaddr, 0), receipt.ContractAddress)
 }
+
+func (s *TransactorSuite) TestSpeedUpAndCancelTransaction() {
+	key, _ := crypto.GenerateKey()
+	selectedAccount := &account.SelectedExtKey{
+		Address:    account.FromAddress(TestConfig.Account1.Address),
+		AccountKey: &keystore.Key{PrivateKey: key},
+	}
+	args := SendTxArgs{
+		From:     account.FromAddress(TestConfig.Account1.Address),
+		To:       account.ToAddress(TestConfig.Account2.Address),
+		Gas:      &testGas,
+		GasPrice: testGasPrice,
+	}
+	store := newMemoryStore()
+	_, err := s.manager.SetStore(store)
+	s.Require().NoError(err)
+	s.setupTransactionPoolAPI(args, testNonce, testNonce, selectedAccount, nil)
+	hash, err := s.manager.SendTransaction(args, selectedAccount)
+	s.Require().NoError(err)
+
+	var landed gethcommon.Hash
+	s.txServiceMock.EXPECT().GetTransactionReceipt(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, hash gethcommon.Hash) (map[string]interface{}, error) {
+			if hash == landed {
+				return map[string]interface{}{"blockNumber": "0x1"}, nil
+			}
+			return nil, nil
+		}).AnyTimes()
+
+	_, err = s.manager.SpeedUpTransaction(hash, big.NewInt(10), selectedAccount)
+	s.Equal(ErrReplacementUnderpriced, err)
+
+	s.txServiceMock.EXPECT().SendRawTransaction(gomock.Any(), gomock.Any()).Return(gethcommon.Hash{}, nil).Times(2)
+	spedUp, err := s.manager.SpeedUpTransaction(hash, big.NewInt(11), selectedAccount)
+	s.Require().NoError(err)
+
+	s.txServiceMock.EXPECT().GasPrice(gomock.Any()).Return(testGasPrice, nil)
+	cancelled, err := s.manager.CancelTransaction(spedUp, selectedAccount)
+	s.Require().NoError(err)
+
+	status, err := s.manager.ReplacementStatus(hash)
+	s.Require().NoError(err)
+	s.Equal([]gethcommon.Hash{hash, spedUp, cancelled}, status.Hashes)
+	s.Nil(status.Landed)
+
+	_, err = s.manager.SetStore(store)
+	s.Require().NoError(err)
+	status, err = s.manager.ReplacementStatus(cancelled)
+	s.Require().NoError(err)
+	s.Equal([]gethcommon.Hash{hash, spedUp, cancelled}, status.Hashes, "Sent transactions are restored")
+	sent := s.manager.SentTransactions()
+	s.Require().Len(sent, 3)
+	for i, h := range []gethcommon.Hash{hash, spedUp, cancelled} {
+		s.Equal(hash, sent[i].Original)
+		s.Equal(h, sent[i].Tx.Hash())
+	}
+
+	landed = spedUp
+	status, err = s.manager.ReplacementStatus(cancelled)
+	s.Require().NoError(err)
+	s.Equal(&spedUp, status.Landed)
+
+	_, err = s.manager.CancelTransaction(hash, selectedAccount)
+	s.Equal(ErrTransactionLanded, err)
+	s.Empty(store.sent, "Transactions that landed are not persisted")
+	s.Empty(s.manager.SentTransactions(), "Transactions that landed are not exported")
+}
+
+func (s *TransactorSuite) TestExternalSignature() {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	watchOnly := &account.SelectedExtKey{Address: from, External: &account.KeyReference{Address: from}}
+	args := SendTxArgs{
+		From:     from,
+		To:       account.ToAddress(TestConfig.Account2.Address),
+		Gas:      &testGas,
+		GasPrice: testGasPrice,
+	}
+	_, err := s.manager.SendTransaction(args, watchOnly)
+	s.Equal(ErrExternalSignatureRequired, err)
+
+	s.txServiceMock.EXPECT().GetTransactionCount(gomock.Any(), from, gethrpc.PendingBlockNumber).Return(&testNonce, nil).Times(3)
+	first, err := s.manager.PrepareTransaction(args, watchOnly)
+	s.Require().NoError(err)
+	s.Equal(testNonce, first.Nonce)
+	s.Equal(crypto.Keccak256Hash(first.RLP), first.Hash, "Devices sign the hash of the RLP")
+	second, err := s.manager.PrepareTransaction(args, watchOnly)
+	s.Require().NoError(err)
+	s.Equal(testNonce+1, second.Nonce, "Nonces follow the transactions waiting for a signature")
+	s.Len(s.manager.UnsignedTransactions(), 2)
+
+	sign := func(unsigned *UnsignedTransaction, key *ecdsa.PrivateKey) []byte {
+		tx := types.NewTransaction(uint64(unsigned.Nonce), *unsigned.To, unsigned.Value.ToInt(), uint64(unsigned.Gas), unsigned.GasPrice.ToInt(), unsigned.Input)
+		signed, err := types.SignTx(tx, types.NewEIP155Signer(unsigned.ChainID.ToInt()), key)
+		s.Require().NoError(err)
+		data, err := rlp.EncodeToBytes(signed)
+		s.Require().NoError(err)
+		return data
+	}
+	_, err = s.manager.SendRawTransaction(sign(first, other))
+	s.Equal(ErrInvalidTxSender, err)
+	s.txServiceMock.EXPECT().SendRawTransaction(gomock.Any(), hexutil.Bytes(sign(first, key))).Return(gethcommon.Hash{}, nil)
+	hash, err := s.manager.SendRawTransaction(sign(first, key))
+	s.Require().NoError(err)
+	s.txServiceMock.EXPECT().GetTransactionReceipt(gomock.Any(), hash).Return(nil, nil)
+	_, err = s.manager.ReplacementStatus(hash)
+	s.NoError(err, "Submitted transactions can be replaced")
+	_, err = s.manager.SendRawTransaction(sign(first, key))
+	s.Equal(ErrUnsignedTransactionNotFound, err)
+
+	s.Require().NoError(s.manager.DiscardTransaction(second.Hash))
+	s.Empty(s.manager.UnsignedTransactions())
+	s.Equal(ErrUnsignedTransactionNotFound, s.manager.DiscardTransaction(second.Hash))
+
+	s.manager.unsignedTTL = -time.Second
+	expired, err := s.manager.PrepareTransaction(args, watchOnly)
+	s.Require().NoError(err)
+	s.Equal(testNonce+1, expired.Nonce, "The nonce of discarded transactions is used again")
+	_, err = s.manager.SendRawTransaction(sign(expired, key))
+	s.Equal(ErrUnsignedTransactionExpired, err)
+}
+
+type memoryStore struct {
+	unsigned map[gethcommon.Hash]UnsignedTransaction
+	sent     []SentTransaction
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{unsigned: make(map[gethcommon.Hash]UnsignedTransaction)}
+}
+
+func (m *memoryStore) SaveUnsignedTransaction(tx UnsignedTransaction) error {
+	m.unsigned[tx.Hash] = tx
+	return nil
+}
+
+func (m *memoryStore) DeleteUnsignedTransaction(hash gethcommon.Hash) error {
+	delete(m.unsigned, hash)
+	return nil
+}
+
+func (m *memoryStore) GetUnsignedTransactions() ([]UnsignedTransaction, error) {
+	var txs []UnsignedTransaction
+	for _, tx := range m.unsigned {
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+func (m *memoryStore) SaveSentTransaction(tx SentTransaction) error {
+	m.sent = append(m.sent, tx)
+	return nil
+}
+
+func (m *memoryStore) DeleteSentTransactions(original gethcommon.Hash) error {
+	var kept []SentTransaction
+	for _, tx := range m.sent {
+		if tx.Original != original {
+			kept = append(kept, tx)
+		}
+	}
+	m.sent = kept
+	return nil
+}
+
+func (m *memoryStore) GetSentTransactions() ([]SentTransaction, error) {
+	return m.sent, nil
+}
+
+func (s *TransactorSuite) TestUnsignedTransactionsStore() {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	watchOnly := &account.SelectedExtKey{Address: from, External: &account.KeyReference{Address: from}}
+	args := SendTxArgs{
+		From:     from,
+		To:       account.ToAddress(TestConfig.Account2.Address),
+		Gas:      &testGas,
+		GasPrice: testGasPrice,
+	}
+	store := newMemoryStore()
+	restored, err := s.manager.SetStore(store)
+	s.Require().NoError(err)
+	s.Empty(restored)
+
+	s.txServiceMock.EXPECT().GetTransactionCount(gomock.Any(), from, gethrpc.PendingBlockNumber).Return(&testNonce, nil).Times(2)
+	first, err := s.manager.PrepareTransaction(args, watchOnly)
+	s.Require().NoError(err)
+	second, err := s.manager.PrepareTransaction(args, watchOnly)
+	s.Require().NoError(err)
+	s.Len(store.unsigned, 2, "Prepared transactions are persisted")
+	s.Require().NoError(s.manager.DiscardTransaction(second.Hash))
+	s.Len(store.unsigned, 1, "Discarded transactions are deleted")
+
+	restored, err = s.manager.SetStore(nil)
+	s.Require().NoError(err)
+	s.Empty(restored)
+	s.Empty(s.manager.UnsignedTransactions(), "Transactions are dropped when the account is logged out")
+
+	expired := UnsignedTransaction{Hash: gethcommon.HexToHash("0x01"), Expires: time.Now().Add(-time.Second).Unix()}
+	store.unsigned[expired.Hash] = expired
+	restored, err = s.manager.SetStore(store)
+	s.Require().NoError(err)
+	s.Require().Len(restored, 1, "Expired transactions are not restored")
+	s.Equal(first.Hash, restored[0].Hash)
+	s.Len(store.unsigned, 1, "Expired transactions are deleted")
+}
+
+func TestMinReplacementGasPrice(t *testing.T) {
+	if price := minReplacementGasPrice(big.NewInt(10)); price.Cmp(big.NewInt(11)) != 0 {
+		t.Errorf("expected 11, got %v", price)
+	}
+	if price := minReplacementGasPrice(big.NewInt(15)); price.Cmp(big.NewInt(17)) != 0 {
+		t.Errorf("expected 17, got %v", price)
+	}
+}
@@ -17,6 +17,11 @@ var (
 	ErrUnexpectedArgs = errors.New("unexpected args")
 	//ErrInvalidTxSender is returned when selected account is different tham From field.
 	ErrInvalidTxSender = errors.New("transaction can only be send by its creator")
+	// ErrExternalSignatureRequired is returned when sending a transaction of a watch-only account,
+	// which must be prepared with PrepareTransaction and signed by an external device.
+	ErrExternalSignatureRequired = errors.New("transaction must be signed by an external device")
+	// ErrUnknownChain is returned when sending a transaction to a chain without RPC client.
+	ErrUnknownChain = errors.New("unknown chain")
 )
 
 // PendingNonceProvider provides information about nonces.
@@ -45,6 +50,8 @@ type SendTxArgs struct {
 	// see `vendor/github.com/ethereum/go-ethereum/internal/ethapi/api.go:1107`
 	Input hexutil.Bytes `json:"input"`
 	Data  hexutil.Bytes `json:"data"`
+	// ChainID is the chain the transaction is sent to, the network of the node if nil.
+	ChainID *hexutil.Uint64 `json:"chainId"`
 }
 
 // Valid checks whether this structure is filled in correctly.
@@ -58,6 +65,13 @@ func (args SendTxArgs) Valid() bool {
 	return bytes.Equal(args.Input, args.Data)
 }
 
+func (args SendTxArgs) chainID() uint64 {
+	if args.ChainID == nil {
+		return 0
+	}
+	return uint64(*args.ChainID)
+}
+
 // GetInput returns either Input or Data field's value dependent on what is filled.
 func (args SendTxArgs) GetInput() hexutil.Bytes {
 	if !isNilOrEmpty(args.Input) {